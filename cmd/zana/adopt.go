@@ -0,0 +1,82 @@
+package zana
+
+import (
+	"fmt"
+
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/spf13/cobra"
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt <binary> [binary...]",
+	Short: "Track executables put into the bin directory by hand",
+	Long: `Register executables that were copied into the zana bin directory by hand
+as local packages (local:<binary>) in zana-lock.json, so zana tracks them:
+they are listed by zana ls, kept by sync and removed with zana remove.
+
+With the per-provider bin layout, adopted executables are moved into the
+local provider's bin directory.
+zana doctor lists the executables that can be adopted.
+
+Examples:
+  zana adopt my-tool
+  zana remove local:my-tool`,
+	Args: cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var names []string
+		for _, b := range findUnmanagedBinariesFn() {
+			names = append(names, b.Name)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		results := []map[string]interface{}{}
+		failed := false
+		for _, name := range args {
+			sourceID, err := adoptBinaryFn(name)
+			result := map[string]interface{}{"binary": name, "success": err == nil}
+			if err != nil {
+				failed = true
+				result["error"] = err.Error()
+				if !ShouldUseJSONOutput() {
+					fmt.Printf("%s Failed to adopt %s: %v\n", IconClose(), name, err)
+				}
+			} else {
+				result["package"] = sourceID
+				if !ShouldUseJSONOutput() {
+					fmt.Printf("%s Adopted %s as %s\n", IconCheck(), name, sourceID)
+				}
+			}
+			results = append(results, result)
+		}
+		if ShouldUseJSONOutput() {
+			PrintJSON(map[string]interface{}{
+				"success":  !failed,
+				"binaries": results,
+			})
+		}
+		if failed {
+			osExit(1)
+		}
+	},
+}
+
+// indirections for testability
+var (
+	adoptBinaryFn           = providers.Adopt
+	findUnmanagedBinariesFn = providers.FindUnmanagedBinaries
+)
+
+// warnUnmanagedBinaries points out executables in the bin directory that
+// zana doesn't manage, and how to adopt them
+func warnUnmanagedBinaries(unmanaged []providers.UnmanagedBinary) {
+	if len(unmanaged) == 0 {
+		return
+	}
+	fmt.Printf("%s Executables in the bin directory not managed by zana:\n", IconAlert())
+	for _, b := range unmanaged {
+		fmt.Printf("   %s\n", b.Path)
+	}
+	fmt.Printf("   %s Run 'zana adopt <binary>' to track them as local packages.\n", IconLightbulb())
+	fmt.Println()
+}
@@ -0,0 +1,44 @@
+package zana
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdoptCommand(t *testing.T) {
+	prevAdopt, prevExit := adoptBinaryFn, osExit
+	t.Cleanup(func() { adoptBinaryFn, osExit = prevAdopt, prevExit })
+	adoptBinaryFn = func(name string) (string, error) {
+		if name == "black" {
+			return "", errors.New("black is already managed by zana")
+		}
+		return "local:" + name, nil
+	}
+	codes := []int{}
+	osExit = func(code int) { codes = append(codes, code) }
+
+	out := captureOutput(t, func() { adoptCmd.Run(adoptCmd, []string{"mytool"}) })
+	assert.Contains(t, out, "Adopted mytool as local:mytool")
+	assert.Empty(t, codes)
+
+	out = captureOutputWithMode(t, func() { adoptCmd.Run(adoptCmd, []string{"mytool", "black"}) }, config.OutputModeJSON)
+	var result struct {
+		Success  bool `json:"success"`
+		Binaries []struct {
+			Binary  string `json:"binary"`
+			Package string `json:"package"`
+			Error   string `json:"error"`
+		} `json:"binaries"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	assert.False(t, result.Success)
+	require.Len(t, result.Binaries, 2)
+	assert.Equal(t, "local:mytool", result.Binaries[0].Package)
+	assert.Equal(t, "black is already managed by zana", result.Binaries[1].Error)
+	assert.Equal(t, []int{1}, codes)
+}
@@ -0,0 +1,46 @@
+package zana
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// indirections for testability
+var (
+	findBinShadowingFn    = providers.FindBinShadowing
+	findAllBinShadowingFn = providers.FindAllBinShadowing
+)
+
+// warnBinShadowing warns about executables of a freshly installed package
+// that shadow, or are shadowed by, a namesake elsewhere on PATH
+func warnBinShadowing(sourceID string, item registry_parser.RegistryItem) {
+	if installTarget != "" || installNoSymlink || ShouldUseJSONOutput() || len(item.Bin) == 0 {
+		return
+	}
+	provider, _, _ := strings.Cut(sourceID, ":")
+	names := make([]string, 0, len(item.Bin))
+	for name := range item.Bin {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, shadow := range findBinShadowingFn(binPathForProviderFn(provider), names) {
+		fmt.Printf("  %s %s\n", IconAlert(), describeBinShadow(shadow))
+	}
+}
+
+// describeBinShadow explains which of the same-named executables runs
+func describeBinShadow(s providers.BinShadow) string {
+	others := strings.Join(s.OtherPaths, ", ")
+	switch {
+	case !s.OnPath:
+		return fmt.Sprintf("%s: %s is not on PATH, %s runs instead", s.Name, s.ZanaPath, s.Winner)
+	case s.ZanaWins():
+		return fmt.Sprintf("%s: %s shadows %s", s.Name, s.ZanaPath, others)
+	default:
+		return fmt.Sprintf("%s: %s is shadowed by %s, which comes first on PATH", s.Name, s.ZanaPath, s.Winner)
+	}
+}
@@ -0,0 +1,41 @@
+package zana
+
+import (
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeBinShadow(t *testing.T) {
+	shadow := providers.BinShadow{Name: "fd", ZanaPath: "/zana/bin/fd", OtherPaths: []string{"/usr/bin/fd", "/bin/fd"}, OnPath: true}
+
+	shadow.Winner = shadow.ZanaPath
+	assert.Equal(t, "fd: /zana/bin/fd shadows /usr/bin/fd, /bin/fd", describeBinShadow(shadow))
+
+	shadow.Winner = "/usr/bin/fd"
+	assert.Equal(t, "fd: /zana/bin/fd is shadowed by /usr/bin/fd, which comes first on PATH", describeBinShadow(shadow))
+
+	shadow.OnPath = false
+	assert.Equal(t, "fd: /zana/bin/fd is not on PATH, /usr/bin/fd runs instead", describeBinShadow(shadow))
+}
+
+func TestWarnBinShadowing(t *testing.T) {
+	prevFind, prevBinPath := findBinShadowingFn, binPathForProviderFn
+	t.Cleanup(func() { findBinShadowingFn, binPathForProviderFn = prevFind, prevBinPath })
+	binPathForProviderFn = func(provider string) string { return "/zana/bin/" + provider }
+	var gotDir string
+	var gotNames []string
+	findBinShadowingFn = func(binDir string, names []string) []providers.BinShadow {
+		gotDir, gotNames = binDir, names
+		return []providers.BinShadow{{Name: "fd", ZanaPath: "/zana/bin/github/fd", OtherPaths: []string{"/usr/bin/fd"}, Winner: "/usr/bin/fd", OnPath: true}}
+	}
+
+	item := registry_parser.RegistryItem{Bin: map[string]string{"fd": "fd", "fdfind": "fd"}}
+	out := captureOutput(t, func() { warnBinShadowing("github:sharkdp/fd", item) })
+
+	assert.Equal(t, "/zana/bin/github", gotDir)
+	assert.Equal(t, []string{"fd", "fdfind"}, gotNames)
+	assert.Contains(t, out, "fd: /zana/bin/github/fd is shadowed by /usr/bin/fd, which comes first on PATH")
+}
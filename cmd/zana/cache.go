@@ -0,0 +1,212 @@
+package zana
+
+import (
+	"fmt"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the download cache",
+	Long: `Manage the download cache.
+
+Release assets prefetched or imported into the cache are reused by later installs,
+installs don't add to it themselves. The cache can be moved between machines, e.g. to install packages on an air-gapped machine:
+
+  zana cache export assets.tar   # on a machine with internet access
+  zana cache import assets.tar   # on the offline machine, then: zana sync packages
+
+The subcommands are:
+  prefetch   - Download the assets of all packages in zana-lock.json into the cache
+  export     - Prefetch and write all cached assets into a tar archive
+  import     - Add the assets from a tar archive to the cache
+  quarantine - List downloads that failed to extract, --clear deletes them
+
+A download that fails to extract (e.g. a truncated archive) is moved into the
+quarantine directory with its URL, package, sha256 and error, its cache entry
+is dropped and it's downloaded once more before the install fails.`,
+}
+
+var cachePrefetchCmd = &cobra.Command{
+	Use:   "prefetch",
+	Short: "Download the assets of all packages in zana-lock.json into the cache",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		result := runCachePrefetch()
+		if ShouldUseJSONOutput() {
+			PrintJSON(map[string]interface{}{
+				"success":  len(result.Failed) == 0,
+				"prefetch": result,
+			})
+		} else {
+			printPrefetchResult(result)
+		}
+		if len(result.Failed) > 0 {
+			osExit(1)
+		}
+	},
+}
+
+var cacheExportCmd = &cobra.Command{
+	Use:   "export <file.tar>",
+	Short: "Write all cached assets into a tar archive",
+	Long: `Write all cached assets into a tar archive.
+
+Unless --no-prefetch is given, the assets of all packages in zana-lock.json
+are downloaded into the cache first, so the archive contains everything
+needed to install the locked versions for the current platform.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var result *providers.PrefetchResult
+		if !cacheExportNoPrefetch {
+			r := runCachePrefetch()
+			result = &r
+			if !ShouldUseJSONOutput() {
+				printPrefetchResult(r)
+			}
+		}
+		count, err := exportAssetCacheFn(args[0])
+		if err != nil {
+			printCacheError("export", err)
+			osExit(1)
+			return
+		}
+		if ShouldUseJSONOutput() {
+			out := map[string]interface{}{
+				"success": true,
+				"file":    args[0],
+				"assets":  count,
+			}
+			if result != nil {
+				out["prefetch"] = result
+			}
+			PrintJSON(out)
+		} else {
+			fmt.Printf("%s Exported %d cached assets to %s\n", IconCheck(), count, args[0])
+		}
+	},
+}
+
+var cacheImportCmd = &cobra.Command{
+	Use:   "import <file.tar>",
+	Short: "Add the assets from a tar archive to the cache",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		count, err := importAssetCacheFn(args[0])
+		if err != nil {
+			printCacheError("import", err)
+			osExit(1)
+			return
+		}
+		if ShouldUseJSONOutput() {
+			PrintJSON(map[string]interface{}{
+				"success": true,
+				"file":    args[0],
+				"assets":  count,
+			})
+		} else {
+			fmt.Printf("%s Imported %d assets from %s\n", IconCheck(), count, args[0])
+		}
+	},
+}
+
+var cacheQuarantineCmd = &cobra.Command{
+	Use:   "quarantine",
+	Short: "List downloads that failed to extract",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if cacheQuarantineClear {
+			count, err := clearQuarantineFn()
+			if err != nil {
+				printCacheError("clear the quarantine of the", err)
+				osExit(1)
+				return
+			}
+			if ShouldUseJSONOutput() {
+				PrintJSON(map[string]interface{}{
+					"success": true,
+					"deleted": count,
+				})
+			} else {
+				fmt.Printf("%s Deleted %d quarantined downloads\n", IconCheck(), count)
+			}
+			return
+		}
+		entries := quarantinedDownloadsFn()
+		if ShouldUseJSONOutput() {
+			if entries == nil {
+				entries = []providers.QuarantineEntry{}
+			}
+			PrintJSON(map[string]interface{}{
+				"success":    true,
+				"quarantine": entries,
+			})
+			return
+		}
+		if len(entries) == 0 {
+			fmt.Println("No quarantined downloads.")
+			return
+		}
+		for _, entry := range entries {
+			name := entry.URL
+			if entry.Package != "" {
+				name = fmt.Sprintf("%s (%s)", entry.URL, entry.Package)
+			}
+			fmt.Printf("%s %s, %s\n", IconAlert(), name, formatWhen(entry.QuarantinedAt))
+			fmt.Printf("    %s\n", entry.Error)
+			fmt.Printf("    %s, %s, sha256 %s\n", entry.File, formatBytes(entry.Size), orDash(entry.SHA256))
+		}
+	},
+}
+
+var cacheExportNoPrefetch bool
+var cacheQuarantineClear bool
+
+func init() {
+	cacheCmd.AddCommand(cachePrefetchCmd)
+	cacheCmd.AddCommand(cacheExportCmd)
+	cacheCmd.AddCommand(cacheImportCmd)
+	cacheCmd.AddCommand(cacheQuarantineCmd)
+	cacheExportCmd.Flags().BoolVar(&cacheExportNoPrefetch, "no-prefetch", false, "only export what is already cached")
+	cacheQuarantineCmd.Flags().BoolVar(&cacheQuarantineClear, "clear", false, "delete the quarantined downloads")
+}
+
+// runCachePrefetch downloads the assets of all locked packages into the cache
+func runCachePrefetch() providers.PrefetchResult {
+	packages := local_packages_parser.GetData(false).Packages
+	if !ShouldUseJSONOutput() && !ShouldUsePlainOutput() {
+		fmt.Printf("Prefetching assets for %d locked packages...\n", len(packages))
+	}
+	return prefetchAssetsFn(packages)
+}
+
+func printPrefetchResult(result providers.PrefetchResult) {
+	fmt.Printf("%s Prefetched assets: %d downloaded, %d already cached\n", IconCheck(), result.Downloaded, result.Cached)
+	for _, url := range result.Failed {
+		fmt.Printf("%s Failed to prefetch %s\n", IconClose(), url)
+	}
+}
+
+func printCacheError(action string, err error) {
+	if ShouldUseJSONOutput() {
+		PrintJSON(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+	} else {
+		fmt.Printf("%s Failed to %s asset cache: %v\n", IconClose(), action, err)
+	}
+}
+
+// indirections for testability
+var (
+	prefetchAssetsFn       = providers.PrefetchAssets
+	exportAssetCacheFn     = files.ExportAssetCache
+	importAssetCacheFn     = files.ImportAssetCache
+	quarantinedDownloadsFn = providers.QuarantinedDownloads
+	clearQuarantineFn      = providers.ClearQuarantine
+)
@@ -0,0 +1,169 @@
+package zana
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheCommand(t *testing.T) {
+	t.Run("cache command structure", func(t *testing.T) {
+		assert.Equal(t, "cache", cacheCmd.Use)
+		assert.NotEmpty(t, cacheCmd.Long)
+		names := []string{}
+		for _, c := range cacheCmd.Commands() {
+			names = append(names, c.Name())
+		}
+		assert.ElementsMatch(t, []string{"prefetch", "export", "import", "quarantine"}, names)
+	})
+}
+
+func stubCacheFns(t *testing.T) *[]int {
+	t.Helper()
+	prevPrefetch := prefetchAssetsFn
+	prevExport := exportAssetCacheFn
+	prevImport := importAssetCacheFn
+	prevQuarantined, prevClear := quarantinedDownloadsFn, clearQuarantineFn
+	prevExit := osExit
+	exitCodes := []int{}
+	osExit = func(code int) { exitCodes = append(exitCodes, code) }
+	t.Cleanup(func() {
+		prefetchAssetsFn = prevPrefetch
+		exportAssetCacheFn = prevExport
+		importAssetCacheFn = prevImport
+		quarantinedDownloadsFn, clearQuarantineFn = prevQuarantined, prevClear
+		osExit = prevExit
+		cacheExportNoPrefetch = false
+		cacheQuarantineClear = false
+	})
+	return &exitCodes
+}
+
+func TestCacheExportCommandRun(t *testing.T) {
+	t.Run("prefetches then exports", func(t *testing.T) {
+		exitCodes := stubCacheFns(t)
+		prefetched := false
+		prefetchAssetsFn = func(_ []local_packages_parser.LocalPackageItem) providers.PrefetchResult {
+			prefetched = true
+			return providers.PrefetchResult{Downloaded: 2, Cached: 1}
+		}
+		exportAssetCacheFn = func(path string) (int, error) {
+			assert.Equal(t, "assets.tar", path)
+			return 3, nil
+		}
+
+		out := captureOutput(t, func() {
+			cacheExportCmd.Run(cacheExportCmd, []string{"assets.tar"})
+		})
+		assert.True(t, prefetched)
+		assert.Contains(t, out, "2 downloaded, 1 already cached")
+		assert.Contains(t, out, "Exported 3 cached assets to assets.tar")
+		assert.Empty(t, *exitCodes)
+	})
+
+	t.Run("skips prefetch with --no-prefetch", func(t *testing.T) {
+		_ = stubCacheFns(t)
+		cacheExportNoPrefetch = true
+		prefetchAssetsFn = func(_ []local_packages_parser.LocalPackageItem) providers.PrefetchResult {
+			t.Fatal("prefetch should not run")
+			return providers.PrefetchResult{}
+		}
+		exportAssetCacheFn = func(path string) (int, error) { return 0, nil }
+
+		out := captureOutput(t, func() {
+			cacheExportCmd.Run(cacheExportCmd, []string{"assets.tar"})
+		})
+		assert.Contains(t, out, "Exported 0 cached assets")
+	})
+
+	t.Run("export error exits", func(t *testing.T) {
+		exitCodes := stubCacheFns(t)
+		cacheExportNoPrefetch = true
+		exportAssetCacheFn = func(path string) (int, error) { return 0, errors.New("disk full") }
+
+		out := captureOutput(t, func() {
+			cacheExportCmd.Run(cacheExportCmd, []string{"assets.tar"})
+		})
+		assert.Contains(t, out, "Failed to export asset cache: disk full")
+		assert.Equal(t, []int{1}, *exitCodes)
+	})
+}
+
+func TestCacheImportCommandRun(t *testing.T) {
+	t.Run("imports archive", func(t *testing.T) {
+		exitCodes := stubCacheFns(t)
+		importAssetCacheFn = func(path string) (int, error) { return 4, nil }
+
+		out := captureOutput(t, func() {
+			cacheImportCmd.Run(cacheImportCmd, []string{"assets.tar"})
+		})
+		assert.Contains(t, out, "Imported 4 assets from assets.tar")
+		assert.Empty(t, *exitCodes)
+	})
+
+	t.Run("import error exits", func(t *testing.T) {
+		exitCodes := stubCacheFns(t)
+		importAssetCacheFn = func(path string) (int, error) { return 0, errors.New("bad archive") }
+
+		out := captureOutput(t, func() {
+			cacheImportCmd.Run(cacheImportCmd, []string{"assets.tar"})
+		})
+		assert.Contains(t, out, "Failed to import asset cache: bad archive")
+		assert.Equal(t, []int{1}, *exitCodes)
+	})
+}
+
+func TestCachePrefetchCommandRunFailure(t *testing.T) {
+	exitCodes := stubCacheFns(t)
+	prefetchAssetsFn = func(_ []local_packages_parser.LocalPackageItem) providers.PrefetchResult {
+		return providers.PrefetchResult{Failed: []string{"https://example.com/x.zip"}}
+	}
+
+	out := captureOutput(t, func() {
+		cachePrefetchCmd.Run(cachePrefetchCmd, []string{})
+	})
+	assert.Contains(t, out, "Failed to prefetch https://example.com/x.zip")
+	assert.Equal(t, []int{1}, *exitCodes)
+}
+
+func TestCacheQuarantineCommandRun(t *testing.T) {
+	entry := providers.QuarantineEntry{
+		File: "/cache/quarantine/20240101T000000.000000000Z-tool.tar.gz", URL: "https://example.com/tool.tar.gz",
+		Package: "github:owner/tool", SHA256: "abc", Size: 2048, Error: "failed to extract tar.gz: exit status 2",
+		QuarantinedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("lists quarantined downloads", func(t *testing.T) {
+		stubCacheFns(t)
+		quarantinedDownloadsFn = func() []providers.QuarantineEntry { return []providers.QuarantineEntry{entry} }
+		out := captureOutputWithMode(t, func() { cacheQuarantineCmd.Run(cacheQuarantineCmd, nil) }, config.OutputModePlain)
+		assert.Contains(t, out, "https://example.com/tool.tar.gz (github:owner/tool)")
+		assert.Contains(t, out, "failed to extract tar.gz: exit status 2")
+		assert.Contains(t, out, entry.File)
+	})
+
+	t.Run("nothing quarantined", func(t *testing.T) {
+		stubCacheFns(t)
+		quarantinedDownloadsFn = func() []providers.QuarantineEntry { return nil }
+		out := captureOutputWithMode(t, func() { cacheQuarantineCmd.Run(cacheQuarantineCmd, nil) }, config.OutputModeJSON)
+		assert.Contains(t, out, `"quarantine": []`)
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		exitCodes := stubCacheFns(t)
+		cacheQuarantineClear = true
+		clearQuarantineFn = func() (int, error) { return 2, nil }
+		out := captureOutputWithMode(t, func() { cacheQuarantineCmd.Run(cacheQuarantineCmd, nil) }, config.OutputModePlain)
+		assert.Contains(t, out, "Deleted 2 quarantined downloads")
+
+		clearQuarantineFn = func() (int, error) { return 0, errors.New("permission denied") }
+		out = captureOutputWithMode(t, func() { cacheQuarantineCmd.Run(cacheQuarantineCmd, nil) }, config.OutputModePlain)
+		assert.Contains(t, out, "permission denied")
+		assert.Equal(t, []int{1}, *exitCodes)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
 	"github.com/spf13/cobra"
 )
@@ -45,6 +46,10 @@ var newRegistryParser = registry_parser.NewDefaultRegistryParser
 // that contain the typed text and returns the full "provider:package" format.
 // When the user types with a provider prefix (e.g., "npm:yaml"), it matches the full ID.
 func packageIDCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if completions, ok := packageVersionCompletion(toComplete); ok {
+		return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+	}
+
 	parser := newRegistryParser()
 	items := parser.GetData(false)
 
@@ -106,6 +111,29 @@ func packageIDCompletion(cmd *cobra.Command, args []string, toComplete string) (
 	return completions, cobra.ShellCompDirectiveNoFileComp
 }
 
+// completionVersionsFn is an indirection for tests.
+var completionVersionsFn = providers.CompletionVersions
+
+// packageVersionCompletion completes the version of "<provider>:<package>@<version>"
+// for providers that can be asked for their versions (npm, pypi, cargo).
+// Versions are offered newest first. ok is false when toComplete has no version part.
+func packageVersionCompletion(toComplete string) (completions []string, ok bool) {
+	provider, rest, found := strings.Cut(toComplete, ":")
+	// An @ at the start of the package is an npm scope, not a version
+	at := strings.LastIndex(rest, "@")
+	if !found || at <= 0 || !providers.SupportsVersionCompletion(strings.ToLower(provider)) {
+		return nil, false
+	}
+	sourceID, prefix := toComplete[:len(provider)+1+at], rest[at+1:]
+	completions = []string{}
+	for _, version := range completionVersionsFn(sourceID) {
+		if strings.HasPrefix(version, prefix) {
+			completions = append(completions, sourceID+"@"+version)
+		}
+	}
+	return completions, true
+}
+
 // newLocalPackagesParserFn is an indirection for tests.
 var newLocalPackagesParserFn = func() local_packages_parser.LocalPackageRoot {
 	return local_packages_parser.GetData(false)
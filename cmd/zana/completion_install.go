@@ -0,0 +1,269 @@
+package zana
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+)
+
+var completionInstallCmd = &cobra.Command{
+	Use:   "install [bash|zsh|fish|pwsh]",
+	Short: "Install the autocompletion script for your shell",
+	Long: `Writes the autocompletion script of the shell (default: the one in $SHELL)
+to where the shell loads completions from, instead of piping
+"zana completion <shell>" into a file yourself:
+
+  bash  $XDG_DATA_HOME/bash-completion/completions/zana (needs bash-completion)
+  zsh   $XDG_DATA_HOME/zsh/site-functions/_zana (needs to be on $fpath)
+  fish  $XDG_CONFIG_HOME/fish/completions/zana.fish
+  pwsh  zana-completion.ps1 next to $PROFILE, dot-sourced from a marked block in it
+
+The path is shown for confirmation first, --yes skips it.
+--uninstall removes the script (and the $PROFILE block) again.
+
+Examples:
+  zana completion install
+  zana completion install zsh
+  zana completion install fish --uninstall`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"bash", "zsh", "fish", "pwsh", "powershell"},
+	Run: func(cmd *cobra.Command, args []string) {
+		shell := defaultCompletionShell()
+		if len(args) == 1 {
+			shell = args[0]
+		}
+		if err := runCompletionInstall(cmd.Root(), shell); err != nil {
+			fmt.Printf("%s %v\n", IconClose(), err)
+			osExit(1)
+		}
+	},
+}
+
+var completionInstallYes bool
+var completionUninstall bool
+
+func init() {
+	completionInstallCmd.Flags().BoolVarP(&completionInstallYes, "yes", "y", false, "install or uninstall without asking")
+	completionInstallCmd.Flags().BoolVar(&completionUninstall, "uninstall", false, "remove the installed autocompletion script")
+}
+
+// addCompletionInstallCmd adds zana completion install to cobra's default
+// completion command, which cobra otherwise only creates when executing
+func addCompletionInstallCmd(root *cobra.Command) {
+	root.InitDefaultCompletionCmd()
+	for _, cmd := range root.Commands() {
+		if cmd.Name() == "completion" {
+			cmd.AddCommand(completionInstallCmd)
+			return
+		}
+	}
+}
+
+// indirections for testability
+var (
+	confirmCompletionChangeFn = confirmCompletionChange
+	completionUserHomeDir     = os.UserHomeDir
+)
+
+// Markers around the block zana completion install adds to the PowerShell profile
+const (
+	completionBlockStart = "# >>> zana completion >>>"
+	completionBlockEnd   = "# <<< zana completion <<<"
+)
+
+// defaultCompletionShell returns the shell of the user, from $SHELL
+func defaultCompletionShell() string {
+	if shell := filepath.Base(os.Getenv("SHELL")); shell != "." && shell != string(filepath.Separator) {
+		return shell
+	}
+	if runtime.GOOS == "windows" {
+		return "pwsh"
+	}
+	return "bash"
+}
+
+// completionScriptPath returns where shell loads the zana completions from
+func completionScriptPath(shell string) (string, error) {
+	home, err := completionUserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	xdgDir := func(env string, fallback ...string) string {
+		if dir := os.Getenv(env); dir != "" {
+			return dir
+		}
+		return filepath.Join(append([]string{home}, fallback...)...)
+	}
+	switch shell {
+	case "bash":
+		return filepath.Join(xdgDir("XDG_DATA_HOME", ".local", "share"), "bash-completion", "completions", "zana"), nil
+	case "zsh":
+		return filepath.Join(xdgDir("XDG_DATA_HOME", ".local", "share"), "zsh", "site-functions", "_zana"), nil
+	case "fish":
+		return filepath.Join(xdgDir("XDG_CONFIG_HOME", ".config"), "fish", "completions", "zana.fish"), nil
+	case "pwsh", "powershell":
+		profile, err := completionPowerShellProfile(shell)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(filepath.Dir(profile), "zana-completion.ps1"), nil
+	default:
+		return "", fmt.Errorf("unsupported shell %q, use bash, zsh, fish or pwsh", shell)
+	}
+}
+
+// completionPowerShellProfile returns the profile dot-sourcing the script
+func completionPowerShellProfile(shell string) (string, error) {
+	profile, err := powerShellProfilePathFn(shell)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the PowerShell profile: %w", err)
+	}
+	return profile, nil
+}
+
+// completionScript generates the completion script of root for shell
+func completionScript(root *cobra.Command, shell string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch shell {
+	case "bash":
+		err = root.GenBashCompletionV2(&buf, true)
+	case "zsh":
+		err = root.GenZshCompletion(&buf)
+	case "fish":
+		err = root.GenFishCompletion(&buf, true)
+	default:
+		err = root.GenPowerShellCompletionWithDesc(&buf)
+	}
+	return buf.Bytes(), err
+}
+
+func runCompletionInstall(root *cobra.Command, shell string) error {
+	path, err := completionScriptPath(shell)
+	if err != nil {
+		return err
+	}
+	if completionUninstall {
+		return uninstallCompletion(shell, path)
+	}
+
+	script, err := completionScript(root, shell)
+	if err != nil {
+		return fmt.Errorf("failed to generate the %s completions: %w", shell, err)
+	}
+	if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, script) {
+		fmt.Printf("%s The %s completions in %s are up to date\n", IconCheck(), shell, path)
+		return nil
+	}
+	if !confirmCompletion(fmt.Sprintf("Install the %s completions?", shell), path) {
+		fmt.Println("Nothing installed.")
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, script, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	fmt.Printf("%s Installed the %s completions to %s\n", IconCheck(), shell, path)
+
+	switch shell {
+	case "bash":
+		fmt.Printf("%s Open a new shell to use them, they are loaded by the bash-completion package\n", IconLightbulb())
+	case "zsh":
+		fmt.Printf("%s Open a new shell to use them. Unless %s is on your $fpath, add this before compinit in ~/.zshrc:\n", IconLightbulb(), filepath.Dir(path))
+		fmt.Printf("  fpath=(%s $fpath)\n", filepath.Dir(path))
+	case "fish":
+		fmt.Printf("%s Open a new fish session to use them\n", IconLightbulb())
+	default:
+		return changeCompletionProfile(shell, completionBlockStart+"\n. '"+strings.ReplaceAll(path, "'", "''")+"'\n"+completionBlockEnd+"\n")
+	}
+	return nil
+}
+
+func uninstallCompletion(shell, path string) error {
+	_, statErr := os.Stat(path)
+	if os.IsNotExist(statErr) && shell != "pwsh" && shell != "powershell" {
+		fmt.Printf("%s No %s completions installed in %s, nothing to remove\n", IconCheck(), shell, path)
+		return nil
+	}
+	if !confirmCompletion(fmt.Sprintf("Remove the %s completions?", shell), path) {
+		fmt.Println("Nothing removed.")
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	if statErr == nil {
+		fmt.Printf("%s Removed the %s completions from %s\n", IconCheck(), shell, path)
+	}
+	if shell == "pwsh" || shell == "powershell" {
+		return changeCompletionProfile(shell, "")
+	}
+	return nil
+}
+
+// confirmCompletion asks whether to change path, unless --yes is given
+func confirmCompletion(title, path string) bool {
+	if completionInstallYes {
+		return true
+	}
+	if !canPromptForSelection() {
+		fmt.Printf("%s Not changing %s without confirmation, pass --yes to change it\n", IconClose(), path)
+		return false
+	}
+	return confirmCompletionChangeFn(title, path)
+}
+
+// changeCompletionProfile replaces the zana completion block of the
+// PowerShell profile with block, an empty block removes it
+func changeCompletionProfile(shell, block string) error {
+	profile, err := completionPowerShellProfile(shell)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(profile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", profile, err)
+	}
+	updated, changed := updateMarkedBlock(string(content), block, completionBlockStart, completionBlockEnd)
+	if !changed {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(profile), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(profile), err)
+	}
+	if err := os.WriteFile(profile, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", profile, err)
+	}
+	if block != "" {
+		fmt.Printf("%s Added the completions to %s, restart PowerShell to use them\n", IconCheck(), profile)
+	} else {
+		fmt.Printf("%s Removed the completions from %s\n", IconCheck(), profile)
+	}
+	return nil
+}
+
+func confirmCompletionChange(title, path string) bool {
+	confirm := true
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(title).
+				Description(path).
+				Affirmative("Yes").
+				Negative("No").
+				Value(&confirm),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return false
+	}
+	return confirm
+}
@@ -0,0 +1,127 @@
+package zana
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubCompletionInstall isolates the completion directories in temp dirs and
+// answers confirmations with confirm
+func stubCompletionInstall(t *testing.T, confirm bool) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	prevHome, prevConfirm, prevPrompt, prevProfile := completionUserHomeDir, confirmCompletionChangeFn, canPromptForSelection, powerShellProfilePathFn
+	prevYes, prevUninstall := completionInstallYes, completionUninstall
+	t.Cleanup(func() {
+		completionUserHomeDir, confirmCompletionChangeFn, canPromptForSelection, powerShellProfilePathFn = prevHome, prevConfirm, prevPrompt, prevProfile
+		completionInstallYes, completionUninstall = prevYes, prevUninstall
+	})
+	completionUserHomeDir = func() (string, error) { return home, nil }
+	canPromptForSelection = func() bool { return true }
+	confirmCompletionChangeFn = func(string, string) bool { return confirm }
+	powerShellProfilePathFn = func(string) (string, error) {
+		return filepath.Join(home, ".config", "powershell", "Microsoft.PowerShell_profile.ps1"), nil
+	}
+	completionInstallYes, completionUninstall = false, false
+	return home
+}
+
+func TestCompletionScriptPath(t *testing.T) {
+	home := stubCompletionInstall(t, true)
+
+	for shell, want := range map[string]string{
+		"bash": filepath.Join(home, ".local", "share", "bash-completion", "completions", "zana"),
+		"zsh":  filepath.Join(home, ".local", "share", "zsh", "site-functions", "_zana"),
+		"fish": filepath.Join(home, ".config", "fish", "completions", "zana.fish"),
+		"pwsh": filepath.Join(home, ".config", "powershell", "zana-completion.ps1"),
+	} {
+		path, err := completionScriptPath(shell)
+		require.NoError(t, err)
+		assert.Equal(t, want, path, shell)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+	path, err := completionScriptPath("fish")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/xdg/config", "fish", "completions", "zana.fish"), path)
+
+	_, err = completionScriptPath("tcsh")
+	assert.ErrorContains(t, err, `unsupported shell "tcsh"`)
+}
+
+func TestCompletionInstall(t *testing.T) {
+	home := stubCompletionInstall(t, true)
+	path := filepath.Join(home, ".config", "fish", "completions", "zana.fish")
+
+	out := captureOutput(t, func() { require.NoError(t, runCompletionInstall(rootCmd, "fish")) })
+	assert.Contains(t, out, "Installed the fish completions to "+path)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "complete -c zana")
+
+	out = captureOutput(t, func() { require.NoError(t, runCompletionInstall(rootCmd, "fish")) })
+	assert.Contains(t, out, "are up to date")
+
+	completionUninstall = true
+	out = captureOutput(t, func() { require.NoError(t, runCompletionInstall(rootCmd, "fish")) })
+	assert.Contains(t, out, "Removed the fish completions from "+path)
+	assert.NoFileExists(t, path)
+
+	out = captureOutput(t, func() { require.NoError(t, runCompletionInstall(rootCmd, "fish")) })
+	assert.Contains(t, out, "nothing to remove")
+}
+
+func TestCompletionInstallNeedsConfirmation(t *testing.T) {
+	home := stubCompletionInstall(t, false)
+	path := filepath.Join(home, ".local", "share", "bash-completion", "completions", "zana")
+
+	out := captureOutput(t, func() { require.NoError(t, runCompletionInstall(rootCmd, "bash")) })
+	assert.Contains(t, out, "Nothing installed.")
+	assert.NoFileExists(t, path)
+
+	canPromptForSelection = func() bool { return false }
+	out = captureOutput(t, func() { require.NoError(t, runCompletionInstall(rootCmd, "bash")) })
+	assert.Contains(t, out, "pass --yes")
+	assert.NoFileExists(t, path)
+
+	completionInstallYes = true
+	captureOutput(t, func() { require.NoError(t, runCompletionInstall(rootCmd, "bash")) })
+	assert.FileExists(t, path)
+}
+
+func TestCompletionInstallPowerShellProfile(t *testing.T) {
+	home := stubCompletionInstall(t, true)
+	dir := filepath.Join(home, ".config", "powershell")
+	profile := filepath.Join(dir, "Microsoft.PowerShell_profile.ps1")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(profile, []byte("Set-Alias ll ls\n"), 0644))
+
+	captureOutput(t, func() { require.NoError(t, runCompletionInstall(rootCmd, "pwsh")) })
+	assert.FileExists(t, filepath.Join(dir, "zana-completion.ps1"))
+	content, err := os.ReadFile(profile)
+	require.NoError(t, err)
+	assert.Equal(t, "Set-Alias ll ls\n"+completionBlockStart+"\n. '"+filepath.Join(dir, "zana-completion.ps1")+"'\n"+completionBlockEnd+"\n", string(content))
+
+	completionUninstall = true
+	captureOutput(t, func() { require.NoError(t, runCompletionInstall(rootCmd, "pwsh")) })
+	assert.NoFileExists(t, filepath.Join(dir, "zana-completion.ps1"))
+	content, err = os.ReadFile(profile)
+	require.NoError(t, err)
+	assert.Equal(t, "Set-Alias ll ls\n", string(content))
+}
+
+func TestAddCompletionInstallCmd(t *testing.T) {
+	root := &cobra.Command{Use: "zana"}
+	root.AddCommand(&cobra.Command{Use: "ls", Run: func(*cobra.Command, []string) {}})
+	addCompletionInstallCmd(root)
+	cmd, _, err := root.Find([]string{"completion", "install"})
+	require.NoError(t, err)
+	assert.Equal(t, completionInstallCmd, cmd)
+}
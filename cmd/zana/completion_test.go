@@ -0,0 +1,44 @@
+package zana
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageIDCompletionVersions(t *testing.T) {
+	origVersions := completionVersionsFn
+	t.Cleanup(func() { completionVersionsFn = origVersions })
+	var asked []string
+	completionVersionsFn = func(sourceID string) []string {
+		asked = append(asked, sourceID)
+		return []string{"3.3.0", "3.2.5", "2.8.8"}
+	}
+
+	completions, directive := packageIDCompletion(installCmd, nil, "npm:prettier@3")
+	assert.Equal(t, []string{"npm:prettier@3.3.0", "npm:prettier@3.2.5"}, completions)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveKeepOrder, directive)
+
+	completions, _ = packageIDCompletion(installCmd, nil, "npm:@scope/pkg@")
+	assert.Len(t, completions, 3)
+	assert.Equal(t, []string{"npm:prettier", "npm:@scope/pkg"}, asked)
+
+	t.Run("no version part or unsupported provider", func(t *testing.T) {
+		asked = nil
+		_, ok := packageVersionCompletion("npm:@scope/pkg")
+		assert.False(t, ok)
+		_, ok = packageVersionCompletion("github:owner/repo@v1")
+		assert.False(t, ok)
+		_, ok = packageVersionCompletion("prettier@3")
+		assert.False(t, ok)
+		assert.Empty(t, asked)
+	})
+
+	t.Run("no versions when offline", func(t *testing.T) {
+		completionVersionsFn = func(string) []string { return nil }
+		completions, ok := packageVersionCompletion("pypi:black@2")
+		assert.True(t, ok)
+		assert.Empty(t, completions)
+	})
+}
@@ -0,0 +1,153 @@
+package zana
+
+import (
+	"fmt"
+
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/spf13/cobra"
+)
+
+// findDeprecatedPackagesFn is a variable to allow overriding in tests
+var findDeprecatedPackagesFn = providers.FindDeprecatedPackages
+
+// deprecationMessage explains that sourceID is deprecated and what to use instead
+func deprecationMessage(sourceID string, d *registry_parser.RegistryItemDeprecation) string {
+	msg := sourceID + " is deprecated"
+	if d.Reason != "" {
+		msg += ": " + d.Reason
+	}
+	if d.Replacement != "" {
+		msg += fmt.Sprintf(", use %s instead (zana migrate-package %s)", d.Replacement, sourceID)
+	}
+	return msg
+}
+
+// warnDeprecated warns after an install when the registry deprecates the package
+func warnDeprecated(sourceID string, item registry_parser.RegistryItem) {
+	if item.Deprecated == nil || ShouldUseJSONOutput() {
+		return
+	}
+	fmt.Printf("  %s %s\n", IconAlert(), deprecationMessage(sourceID, item.Deprecated))
+}
+
+// printDeprecatedPackages lists the deprecated installed packages for zana doctor
+func printDeprecatedPackages(deprecated []providers.DeprecatedPackage) {
+	if len(deprecated) == 0 {
+		return
+	}
+	fmt.Printf("%s Deprecated packages:\n", IconAlert())
+	for _, d := range deprecated {
+		msg := deprecationMessage(d.SourceID, &registry_parser.RegistryItemDeprecation{Reason: d.Reason, Replacement: d.Replacement})
+		fmt.Printf("   %s (installed %s)\n", msg, d.Version)
+	}
+	fmt.Println()
+}
+
+var migratePackageCmd = &cobra.Command{
+	Use:   "migrate-package <old> [new]",
+	Short: "Replace an installed package with another one",
+	Long: `Replace an installed package with another one.
+
+The new package is installed first and the old one is removed only when that
+succeeded. Without a new package, the replacement the registry suggests for
+the deprecated old package is installed. Integrations enabled for the old
+package are enabled for the new one as well.
+
+Examples:
+  zana migrate-package npm:typescript-language-server
+  zana migrate-package tsserver npm:@vtsls/language-server`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: installedPackageIDCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		result, err := migratePackage(args)
+		if err != nil {
+			if ShouldUseJSONOutput() {
+				PrintJSON(map[string]interface{}{
+					"success": false,
+					"error":   err.Error(),
+				})
+			} else {
+				fmt.Printf("%s %v\n", IconClose(), err)
+			}
+			osExit(1)
+			return
+		}
+		if ShouldUseJSONOutput() {
+			PrintJSON(result)
+			return
+		}
+		fmt.Printf("%s Migrated %s to %s@%s\n", IconCheck(), result.From, result.To, result.Version)
+	},
+}
+
+type migratePackageResult struct {
+	Success bool   `json:"success"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Version string `json:"version"`
+}
+
+// migratePackage installs the replacement of args[0], args[1] or the one the
+// registry suggests, and removes args[0] once that worked
+func migratePackage(args []string) (migratePackageResult, error) {
+	ids, err := resolveInstalledPackageIDs(args[:1])
+	if err != nil {
+		return migratePackageResult{}, err
+	}
+	from := ids[0]
+	var old local_packages_parser.LocalPackageItem
+	for _, pkg := range newLocalPackagesParserFn().Packages {
+		if pkg.SourceID == from {
+			old = pkg
+		}
+	}
+	if old.SourceID == "" {
+		return migratePackageResult{}, fmt.Errorf("%s is not installed", from)
+	}
+
+	var to string
+	if len(args) == 2 {
+		provider, pkgName, err := parseUserPackageID(args[1])
+		if err != nil {
+			return migratePackageResult{}, err
+		}
+		to = toInternalPackageID(provider, pkgName)
+	} else {
+		d := newRegistryParser().GetBySourceId(from).Deprecated
+		if d == nil || d.Replacement == "" {
+			return migratePackageResult{}, fmt.Errorf("the registry suggests no replacement for %s, give one: zana migrate-package %s <new>", from, from)
+		}
+		to = d.Replacement
+	}
+	if to == from {
+		return migratePackageResult{}, fmt.Errorf("%s can't replace itself", from)
+	}
+
+	version, err := resolveVersionFn(to, "")
+	if err != nil {
+		return migratePackageResult{}, fmt.Errorf("failed to resolve version for %s: %w", to, err)
+	}
+	var integrations []string
+	if old.Extras != nil {
+		integrations = old.Extras.Integrations
+	}
+	providers.SetRequestedIntegrations(integrations)
+	defer providers.SetRequestedIntegrations(nil)
+	if !ShouldUseJSONOutput() {
+		fmt.Printf("%s Installing %s@%s\n", IconRefresh(), to, version)
+	}
+	if !installPackageFn(to, version) {
+		return migratePackageResult{}, fmt.Errorf("failed to install %s@%s, %s is kept", to, version, from)
+	}
+	_ = local_packages_parser.MergePackageIntegrations(to, integrations)
+
+	if !ShouldUseJSONOutput() {
+		fmt.Printf("%s Removing %s\n", IconRefresh(), from)
+	}
+	if !removePackageFn(from) {
+		return migratePackageResult{}, fmt.Errorf("installed %s@%s, but failed to remove %s", to, version, from)
+	}
+	return migratePackageResult{Success: true, From: from, To: to, Version: version}, nil
+}
@@ -0,0 +1,138 @@
+package zana
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubMigratePackage(t *testing.T, install func(sourceID, version string) bool, remove func(sourceID string) bool) *[]int {
+	t.Helper()
+	prevParser, prevLocal, prevResolve := newRegistryParser, newLocalPackagesParserFn, resolveVersionFn
+	prevInstall, prevRemove, prevExit := installPackageFn, removePackageFn, osExit
+	t.Cleanup(func() {
+		newRegistryParser, newLocalPackagesParserFn, resolveVersionFn = prevParser, prevLocal, prevResolve
+		installPackageFn, removePackageFn, osExit = prevInstall, prevRemove, prevExit
+	})
+	newRegistryParser = func() *registry_parser.RegistryParser {
+		rp := registry_parser.NewRegistryParser(nil)
+		require.NoError(t, rp.LoadFromBytes([]byte(`[
+			{"name": "typescript-language-server", "source": {"id": "npm:typescript-language-server"},
+			 "deprecated": {"reason": "unmaintained", "replacement": "npm:@vtsls/language-server"}},
+			{"name": "ruff", "version": "0.6.0", "source": {"id": "pypi:ruff"}}
+		]`)))
+		return rp
+	}
+	newLocalPackagesParserFn = func() local_packages_parser.LocalPackageRoot {
+		return local_packages_parser.LocalPackageRoot{Packages: []local_packages_parser.LocalPackageItem{
+			{SourceID: "npm:typescript-language-server", Version: "4.3.3"},
+			{SourceID: "pypi:ruff", Version: "0.6.0"},
+		}}
+	}
+	resolveVersionFn = func(sourceID, version string) (string, error) { return "0.2.0", nil }
+	installPackageFn = install
+	removePackageFn = remove
+	var codes []int
+	osExit = func(code int) { codes = append(codes, code) }
+	return &codes
+}
+
+func TestDeprecationMessage(t *testing.T) {
+	assert.Equal(t, "npm:tsserver is deprecated", deprecationMessage("npm:tsserver", &registry_parser.RegistryItemDeprecation{}))
+	assert.Equal(t,
+		"npm:tsserver is deprecated: unmaintained, use npm:vtsls instead (zana migrate-package npm:tsserver)",
+		deprecationMessage("npm:tsserver", &registry_parser.RegistryItemDeprecation{Reason: "unmaintained", Replacement: "npm:vtsls"}))
+}
+
+func TestMigratePackage(t *testing.T) {
+	t.Run("installs the suggested replacement and removes the old package", func(t *testing.T) {
+		var calls []string
+		codes := stubMigratePackage(t,
+			func(sourceID, version string) bool {
+				calls = append(calls, "install "+sourceID+"@"+version)
+				return true
+			},
+			func(sourceID string) bool { calls = append(calls, "remove "+sourceID); return true })
+
+		out := captureOutputWithMode(t, func() {
+			migratePackageCmd.Run(migratePackageCmd, []string{"typescript-language-server"})
+		}, config.OutputModeJSON)
+		assert.Empty(t, *codes)
+		assert.Equal(t, []string{"install npm:@vtsls/language-server@0.2.0", "remove npm:typescript-language-server"}, calls)
+		var result migratePackageResult
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		assert.Equal(t, migratePackageResult{Success: true, From: "npm:typescript-language-server", To: "npm:@vtsls/language-server", Version: "0.2.0"}, result)
+	})
+
+	t.Run("an explicit replacement", func(t *testing.T) {
+		var installed []string
+		codes := stubMigratePackage(t,
+			func(sourceID, version string) bool { installed = append(installed, sourceID); return true },
+			func(string) bool { return true })
+
+		out := captureOutput(t, func() { migratePackageCmd.Run(migratePackageCmd, []string{"pypi:ruff", "cargo:ruff"}) })
+		assert.Empty(t, *codes)
+		assert.Equal(t, []string{"cargo:ruff"}, installed)
+		assert.Contains(t, out, "Migrated pypi:ruff to cargo:ruff@0.2.0")
+	})
+
+	t.Run("keeps the old package when the install fails", func(t *testing.T) {
+		codes := stubMigratePackage(t,
+			func(string, string) bool { return false },
+			func(string) bool { t.Fatal("nothing should be removed"); return false })
+
+		out := captureOutput(t, func() { migratePackageCmd.Run(migratePackageCmd, []string{"typescript-language-server"}) })
+		assert.Equal(t, []int{1}, *codes)
+		assert.Contains(t, out, "failed to install npm:@vtsls/language-server@0.2.0, npm:typescript-language-server is kept")
+	})
+
+	t.Run("without a replacement", func(t *testing.T) {
+		codes := stubMigratePackage(t,
+			func(string, string) bool { t.Fatal("nothing should be installed"); return false },
+			func(string) bool { return false })
+
+		out := captureOutput(t, func() { migratePackageCmd.Run(migratePackageCmd, []string{"ruff"}) })
+		assert.Contains(t, out, "the registry suggests no replacement for pypi:ruff")
+		out = captureOutput(t, func() { migratePackageCmd.Run(migratePackageCmd, []string{"ruff", "pypi:ruff"}) })
+		assert.Contains(t, out, "pypi:ruff can't replace itself")
+		assert.Equal(t, []int{1, 1}, *codes)
+	})
+}
+
+func TestListInstalledPackagesDeprecated(t *testing.T) {
+	mockLocal := &MockLocalPackagesProvider{
+		GetDataFunc: func(force bool) local_packages_parser.LocalPackageRoot {
+			return local_packages_parser.LocalPackageRoot{Packages: []local_packages_parser.LocalPackageItem{
+				{SourceID: "npm:pkg-a", Version: "1.0.0"},
+				{SourceID: "npm:pkg-b", Version: "2.0.0"},
+			}}
+		},
+	}
+	mockRegistry := &MockRegistryProvider{
+		GetDataFunc: func(force bool) []registry_parser.RegistryItem {
+			return []registry_parser.RegistryItem{
+				{Source: registry_parser.RegistryItemSource{ID: "pkg:npm/pkg-a"}, Deprecated: &registry_parser.RegistryItemDeprecation{Replacement: "npm:pkg-c"}},
+				{Source: registry_parser.RegistryItemSource{ID: "npm:pkg-b"}},
+			}
+		},
+	}
+	svc := NewListServiceWithDependencies(mockLocal, mockRegistry, &MockUpdateChecker{}, &MockFileDownloader{})
+
+	out := captureOutputWithMode(t, func() { svc.ListInstalledPackages(ListQueryOptions{}) }, config.OutputModePlain)
+	assert.Contains(t, out, "1 deprecated: npm:pkg-a")
+	assert.Contains(t, out, "zana migrate-package <pkgId>")
+
+	out = captureOutputWithMode(t, func() { svc.ListInstalledPackages(ListQueryOptions{}) }, config.OutputModeJSON)
+	var result struct {
+		Packages []listInstalledPackageJSON `json:"packages"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	require.Len(t, result.Packages, 2)
+	assert.Equal(t, &registry_parser.RegistryItemDeprecation{Replacement: "npm:pkg-c"}, result.Packages[0].Deprecated)
+	assert.Nil(t, result.Packages[1].Deprecated)
+}
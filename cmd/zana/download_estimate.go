@@ -0,0 +1,98 @@
+package zana
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+)
+
+// indirections for testability
+var (
+	estimateDownloadsFn    = providers.EstimateDownloads
+	confirmDownloadSizeFn  = files.GetConfirmDownloadSize
+	confirmLargeDownloadFn = confirmLargeDownload
+)
+
+// downloadConfirmYes is --yes of install and update, it skips the
+// confirmation of downloads above install.confirmDownloadSize
+var downloadConfirmYes bool
+
+// confirmDownloadEstimate shows the estimated download size and duration of
+// the planned installs or updates and, when the download is larger than
+// install.confirmDownloadSize, asks whether to go on
+func confirmDownloadEstimate(planned []providers.PlannedDownload, printf func(string, ...interface{})) bool {
+	threshold := confirmDownloadSizeFn()
+	if len(planned) == 0 || threshold == 0 && ShouldUseJSONOutput() {
+		return true
+	}
+	estimate := estimateDownloadsFn(planned)
+	if estimate.Bytes == 0 {
+		return true
+	}
+	if !ShouldUseJSONOutput() {
+		printf("%s\n", downloadEstimateSummary(estimate, len(planned)))
+	}
+	if threshold == 0 || estimate.Bytes <= threshold || downloadConfirmYes {
+		return true
+	}
+	if !canPromptForSelection() {
+		printf("%s Not downloading %s (more than %s) without confirmation, pass --yes to download it\n", IconClose(), formatBytes(estimate.Bytes), formatBytes(threshold))
+		return false
+	}
+	return confirmLargeDownloadFn(estimate.Bytes, len(planned))
+}
+
+// downloadEstimateSummary describes an estimate, e.g.
+// "Estimated download: 1.2 GiB for 3 package(s), 1 of unknown size, about 40s based on previous installs"
+func downloadEstimateSummary(estimate providers.DownloadEstimate, count int) string {
+	summary := fmt.Sprintf("Estimated download: %s for %d package(s)", formatBytes(estimate.Bytes), count)
+	if len(estimate.Unknown) > 0 {
+		summary += fmt.Sprintf(", %d of unknown size", len(estimate.Unknown))
+	}
+	if estimate.Duration > 0 {
+		summary += fmt.Sprintf(", about %s based on previous installs", formatDuration(estimate.Duration))
+		if len(estimate.WithoutHistory) > 0 {
+			summary += fmt.Sprintf(" of %d of them", count-len(estimate.WithoutHistory))
+		}
+	}
+	return summary
+}
+
+func confirmLargeDownload(size int64, count int) bool {
+	confirm := false
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(fmt.Sprintf("Download %s for %d package(s)?", formatBytes(size), count)).
+				Description("Set install.confirmDownloadSize in config.yaml or ZANA_CONFIRM_DOWNLOAD_SIZE to change when this is asked").
+				Affirmative("Download").
+				Negative("Cancel").
+				Value(&confirm),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return false
+	}
+	return confirm
+}
+
+// plannedInstallDownloads returns the packages install args download, only
+// full provider:package-id args, names are resolved later by prompting
+func plannedInstallDownloads(args []string) []providers.PlannedDownload {
+	planned := make([]providers.PlannedDownload, 0, len(args))
+	for _, arg := range args {
+		baseID, version := parsePackageIDAndVersion(arg)
+		if !packageid.HasProvider(baseID) {
+			continue
+		}
+		provider, pkgName, err := parseUserPackageID(baseID)
+		if err != nil {
+			continue
+		}
+		planned = append(planned, providers.PlannedDownload{SourceID: toInternalPackageID(provider, pkgName), Version: version})
+	}
+	return planned
+}
@@ -0,0 +1,115 @@
+package zana
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/mistweaverco/zana-client/internal/lib/versioncmp"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubDownloadEstimate estimates estimate for every plan, with threshold as
+// install.confirmDownloadSize, and answers confirmations with confirm
+func stubDownloadEstimate(t *testing.T, estimate providers.DownloadEstimate, threshold int64, confirm bool) *[]providers.PlannedDownload {
+	t.Helper()
+	prevEstimate, prevThreshold, prevConfirm, prevPrompt, prevYes := estimateDownloadsFn, confirmDownloadSizeFn, confirmLargeDownloadFn, canPromptForSelection, downloadConfirmYes
+	t.Cleanup(func() {
+		estimateDownloadsFn, confirmDownloadSizeFn, confirmLargeDownloadFn, canPromptForSelection, downloadConfirmYes = prevEstimate, prevThreshold, prevConfirm, prevPrompt, prevYes
+	})
+	planned := []providers.PlannedDownload{}
+	estimateDownloadsFn = func(p []providers.PlannedDownload) providers.DownloadEstimate {
+		planned = append(planned, p...)
+		return estimate
+	}
+	confirmDownloadSizeFn = func() int64 { return threshold }
+	confirmLargeDownloadFn = func(int64, int) bool { return confirm }
+	canPromptForSelection = func() bool { return true }
+	downloadConfirmYes = false
+	return &planned
+}
+
+func TestConfirmDownloadEstimate(t *testing.T) {
+	plan := []providers.PlannedDownload{{SourceID: "github:owner/tool"}, {SourceID: "npm:lib"}}
+	large := providers.DownloadEstimate{Bytes: 600 << 20, Unknown: []string{"npm:lib"}, Duration: 90 * time.Second, WithoutHistory: []string{"npm:lib"}}
+
+	stubDownloadEstimate(t, large, 500<<20, false)
+	var ok bool
+	out := captureOutput(t, func() { ok = confirmDownloadEstimate(plan, printfStdout) })
+	assert.False(t, ok)
+	assert.Contains(t, out, "Estimated download: 600.0 MiB for 2 package(s), 1 of unknown size, about 1m30s based on previous installs of 1 of them")
+
+	confirmLargeDownloadFn = func(int64, int) bool { return true }
+	captureOutput(t, func() { ok = confirmDownloadEstimate(plan, printfStdout) })
+	assert.True(t, ok)
+
+	canPromptForSelection = func() bool { return false }
+	out = captureOutput(t, func() { ok = confirmDownloadEstimate(plan, printfStdout) })
+	assert.False(t, ok)
+	assert.Contains(t, out, "Not downloading 600.0 MiB (more than 500.0 MiB) without confirmation, pass --yes")
+
+	downloadConfirmYes = true
+	captureOutput(t, func() { ok = confirmDownloadEstimate(plan, printfStdout) })
+	assert.True(t, ok)
+}
+
+func TestConfirmDownloadEstimateBelowThreshold(t *testing.T) {
+	plan := []providers.PlannedDownload{{SourceID: "github:owner/tool"}}
+
+	stubDownloadEstimate(t, providers.DownloadEstimate{Bytes: 12 << 20}, 500<<20, false)
+	var ok bool
+	out := captureOutput(t, func() { ok = confirmDownloadEstimate(plan, printfStdout) })
+	assert.True(t, ok)
+	assert.Contains(t, out, "Estimated download: 12.0 MiB for 1 package(s)\n")
+
+	stubDownloadEstimate(t, providers.DownloadEstimate{Bytes: 2 << 30}, 0, false)
+	captureOutput(t, func() { ok = confirmDownloadEstimate(plan, printfStdout) })
+	assert.True(t, ok)
+
+	stubDownloadEstimate(t, providers.DownloadEstimate{Unknown: []string{"github:owner/tool"}}, 1, false)
+	out = captureOutput(t, func() { ok = confirmDownloadEstimate(plan, printfStdout) })
+	assert.True(t, ok)
+	assert.Empty(t, out)
+}
+
+func TestPlannedInstallDownloads(t *testing.T) {
+	assert.Equal(t, []providers.PlannedDownload{
+		{SourceID: "github:owner/tool", Version: "v1.0.0"},
+		{SourceID: "npm:prettier"},
+	}, plannedInstallDownloads([]string{"github:owner/tool@v1.0.0", "ripgrep", "npm:prettier"}))
+}
+
+func TestUpdateAllConfirmsLargeDownloads(t *testing.T) {
+	planned := stubDownloadEstimate(t, providers.DownloadEstimate{Bytes: 1 << 30}, 500<<20, false)
+	updated := 0
+	providers.SetProviderFactory(&providers.MockProviderFactory{
+		MockGitHubProvider: &providers.MockPackageManager{UpdateFunc: func(context.Context, string) bool {
+			updated++
+			return true
+		}},
+	})
+	defer providers.ResetProviderFactory()
+
+	out := &MockOutputWriter{}
+	service := NewUpdateServiceWithDependencies(
+		&MockLocalPackagesProvider{
+			GetDataFunc: func(force bool) local_packages_parser.LocalPackageRoot {
+				return local_packages_parser.LocalPackageRoot{Packages: []local_packages_parser.LocalPackageItem{
+					{SourceID: "github:owner/tool", Version: "v1.0.0"},
+				}}
+			},
+		},
+		&MockRegistryProvider{GetLatestVersionFunc: func(string) string { return "v2.0.0" }},
+		&MockUpdateChecker{CheckIfUpdateIsAvailableFunc: func(currentVersion, latestVersion string) (bool, string) {
+			return currentVersion != latestVersion, ""
+		}},
+		out,
+	)
+
+	assert.True(t, service.UpdateAllPackagesUpTo(versioncmp.BumpUnknown))
+	assert.True(t, service.cancelled)
+	assert.Zero(t, updated)
+	assert.Equal(t, []providers.PlannedDownload{{SourceID: "github:owner/tool", Version: "v2.0.0"}}, *planned)
+}
@@ -3,8 +3,13 @@ package zana
 import (
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 
-	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/mistweaverco/zana-client/internal/lib/shell_out"
 	"github.com/spf13/cobra"
 )
 
@@ -13,7 +18,14 @@ var envCmd = &cobra.Command{
 	Short: "Outputs a script to set environment variables for the current shell",
 	Long: `The env command outputs a script that sets environment variables for the current shell.
                This command takes one argument, the shell.
-               If omitted, it will default to bash.`,
+               If omitted, it will default to bash.
+               With the per-provider bin layout (paths.binLayout in config.yaml),
+               all provider bin directories are added in precedence order.
+
+               --install adds a marked block running the script to the shell's profile
+               (replacing an earlier one), --uninstall removes it again:
+               ~/.bashrc for bash, ~/.zshrc for zsh and $PROFILE for PowerShell
+               (pwsh or powershell). --profile overrides the profile path.`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) > 1 {
@@ -23,13 +35,21 @@ var envCmd = &cobra.Command{
 		if len(args) == 1 {
 			shell = args[0]
 		}
-		pathString := files.GetAppBinPath()
-		if shell == "pwsh" || shell == "powershell" {
-			fmt.Println(`$env:PATH = "` + pathString + `;" + $env:PATH`)
+		isPowerShell := shell == "pwsh" || shell == "powershell"
+		if envInstall || envUninstall {
+			runEnvProfileChange(shell)
+			return
+		}
+		binPaths := binPathsFn()
+		if isPowerShell {
+			fmt.Print(powerShellEnvScript(binPaths))
 		} else {
 			fmt.Println(`#!/bin/sh
-# zana shell setup; adapted from rustup
-# affix colons on either side of $PATH to simplify matching
+# zana shell setup; adapted from rustup`)
+			// Prepend in reverse so the first path ends up with the highest precedence
+			for i := len(binPaths) - 1; i >= 0; i-- {
+				pathString := binPaths[i]
+				fmt.Println(`# affix colons on either side of $PATH to simplify matching
 case ":${PATH}:" in
     *:"` + pathString + `":*)
         ;;
@@ -38,6 +58,194 @@ case ":${PATH}:" in
         export PATH="` + pathString + `:$PATH"
         ;;
 esac`)
+			}
 		}
 	},
 }
+
+var envInstall bool
+var envUninstall bool
+var envProfile string
+
+func init() {
+	envCmd.Flags().BoolVar(&envInstall, "install", false, "add the zana block to the shell's profile (bash, zsh, pwsh, powershell)")
+	envCmd.Flags().BoolVar(&envUninstall, "uninstall", false, "remove the zana block from the shell's profile")
+	envCmd.Flags().StringVar(&envProfile, "profile", "", "profile to change instead of ~/.bashrc, ~/.zshrc or $PROFILE")
+	envCmd.MarkFlagsMutuallyExclusive("install", "uninstall")
+}
+
+// indirection for testability
+var binPathsFn = providers.BinPathsInPrecedenceOrder
+var powerShellProfilePathFn = powerShellProfilePath
+var envUserHomeDir = os.UserHomeDir
+
+// Markers around the block zana env --install adds to the shell's profile
+const (
+	envBlockStart = "# >>> zana env >>>"
+	envBlockEnd   = "# <<< zana env <<<"
+)
+
+// powerShellEnvScript prepends the bin directories to PATH, skipping those
+// already on it so running it twice changes nothing. The separator is left
+// to PowerShell, as pwsh on Linux and macOS uses ':'. It is a single line,
+// because Invoke-Expression runs piped input line by line.
+func powerShellEnvScript(binPaths []string) string {
+	quoted := make([]string, 0, len(binPaths))
+	// Prepend in reverse so the first path ends up with the highest precedence
+	for i := len(binPaths) - 1; i >= 0; i-- {
+		quoted = append(quoted, "'"+strings.ReplaceAll(binPaths[i], "'", "''")+"'")
+	}
+	return "& { $s = [IO.Path]::PathSeparator; foreach ($b in @(" + strings.Join(quoted, ", ") + ")) " +
+		"{ if ($env:PATH.Split($s) -notcontains $b) { $env:PATH = $b + $s + $env:PATH } } }\n"
+}
+
+// envProfileBlock is the marked block added to the profile of shell. A
+// zanaHome, picked by zana init, is exported before zana env runs.
+func envProfileBlock(shell, zanaHome string) string {
+	block := envBlockStart + "\n"
+	if shell == "pwsh" || shell == "powershell" {
+		if zanaHome != "" {
+			block += "$env:ZANA_HOME = '" + strings.ReplaceAll(zanaHome, "'", "''") + "'\n"
+		}
+		block += "zana env " + shell + " | Invoke-Expression\n"
+	} else {
+		if zanaHome != "" {
+			block += "export ZANA_HOME='" + strings.ReplaceAll(zanaHome, "'", `'\''`) + "'\n"
+		}
+		block += "eval \"$(zana env)\"\n"
+	}
+	return block + envBlockEnd + "\n"
+}
+
+// updateEnvProfile returns content with the zana block replaced by
+// block, or appended when there is none; an empty block removes it.
+// It reports whether content changed.
+func updateEnvProfile(content, block string) (string, bool) {
+	return updateMarkedBlock(content, block, envBlockStart, envBlockEnd)
+}
+
+// updateMarkedBlock is updateEnvProfile for the block between the
+// startMarker and endMarker lines
+func updateMarkedBlock(content, block, startMarker, endMarker string) (string, bool) {
+	start := strings.Index(content, startMarker)
+	end := strings.Index(content, endMarker)
+	if start >= 0 && end > start {
+		end += len(endMarker)
+		if end < len(content) && content[end] == '\r' {
+			end++
+		}
+		if end < len(content) && content[end] == '\n' {
+			end++
+		}
+		updated := content[:start] + block + content[end:]
+		return updated, updated != content
+	}
+	if block == "" {
+		return content, false
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + block, true
+}
+
+// envProfilePath returns the profile zana env --install changes for shell
+func envProfilePath(shell string) (string, error) {
+	if envProfile != "" {
+		return envProfile, nil
+	}
+	switch shell {
+	case "pwsh", "powershell":
+		profile, err := powerShellProfilePathFn(shell)
+		if err != nil {
+			return "", fmt.Errorf("failed to find the PowerShell profile: %w (use --profile)", err)
+		}
+		return profile, nil
+	case "bash", "zsh":
+		home, err := envUserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		if dir := os.Getenv("ZDOTDIR"); shell == "zsh" && dir != "" {
+			home = dir
+		}
+		return filepath.Join(home, "."+shell+"rc"), nil
+	default:
+		return "", fmt.Errorf("--install and --uninstall support bash, zsh, pwsh and powershell, not %s", shell)
+	}
+}
+
+// changeEnvProfile adds the zana env block for shell to its profile, or
+// removes it when install is false. It reports whether the profile changed.
+func changeEnvProfile(shell string, install bool, zanaHome string) (string, bool, error) {
+	profile, err := envProfilePath(shell)
+	if err != nil {
+		return "", false, err
+	}
+	content, err := os.ReadFile(profile)
+	if err != nil && !os.IsNotExist(err) {
+		return profile, false, fmt.Errorf("failed to read %s: %w", profile, err)
+	}
+	block := ""
+	if install {
+		block = envProfileBlock(shell, zanaHome)
+	}
+	updated, changed := updateEnvProfile(string(content), block)
+	if !changed {
+		return profile, false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(profile), 0755); err != nil {
+		return profile, false, fmt.Errorf("failed to create %s: %w", filepath.Dir(profile), err)
+	}
+	if err := os.WriteFile(profile, []byte(updated), 0644); err != nil {
+		return profile, false, fmt.Errorf("failed to write %s: %w", profile, err)
+	}
+	return profile, true, nil
+}
+
+func runEnvProfileChange(shell string) {
+	profile, changed, err := changeEnvProfile(shell, envInstall, "")
+	switch {
+	case err != nil:
+		fmt.Printf("%s %v\n", IconClose(), err)
+		osExit(1)
+	case !changed && envInstall:
+		fmt.Printf("%s %s already sets up zana\n", IconCheck(), profile)
+	case !changed:
+		fmt.Printf("%s %s does not set up zana, nothing to remove\n", IconCheck(), profile)
+	case envInstall:
+		fmt.Printf("%s Added zana to %s, %s to use it\n", IconCheck(), profile, envRestartHint(shell))
+	default:
+		fmt.Printf("%s Removed zana from %s\n", IconCheck(), profile)
+	}
+}
+
+// envRestartHint tells how to pick up a changed profile
+func envRestartHint(shell string) string {
+	if shell == "pwsh" || shell == "powershell" {
+		return "restart PowerShell"
+	}
+	return "open a new shell"
+}
+
+// powerShellProfilePath asks PowerShell for $PROFILE, falling back to
+// the default location of the current user's profile
+func powerShellProfilePath(shell string) (string, error) {
+	if code, out, err := shell_out.ShellOutCapture(shell, []string{"-NoProfile", "-NonInteractive", "-Command", "$PROFILE"}, "", nil); err == nil && code == 0 {
+		if path := strings.TrimSpace(out); path != "" {
+			return path, nil
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS != "windows" {
+		return filepath.Join(home, ".config", "powershell", "Microsoft.PowerShell_profile.ps1"), nil
+	}
+	dir := "PowerShell"
+	if shell == "powershell" {
+		dir = "WindowsPowerShell"
+	}
+	return filepath.Join(home, "Documents", dir, "Microsoft.PowerShell_profile.ps1"), nil
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -105,3 +107,128 @@ func TestEnvCommand(t *testing.T) {
 		assert.NotNil(t, envCmd.Run)
 	})
 }
+
+func TestEnvCommandPerProviderLayout(t *testing.T) {
+	prev := binPathsFn
+	binPathsFn = func() []string { return []string{"/zana/bin/cargo", "/zana/bin/npm"} }
+	defer func() { binPathsFn = prev }()
+
+	t.Run("sh prepends all paths with the first path first", func(t *testing.T) {
+		old := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		envCmd.Run(envCmd, []string{"bash"})
+
+		w.Close()
+		os.Stdout = old
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		out := buf.String()
+
+		assert.Equal(t, 1, strings.Count(out, "#!/bin/sh"))
+		npmIdx := strings.Index(out, `export PATH="/zana/bin/npm:$PATH"`)
+		cargoIdx := strings.Index(out, `export PATH="/zana/bin/cargo:$PATH"`)
+		assert.NotEqual(t, -1, npmIdx)
+		assert.NotEqual(t, -1, cargoIdx)
+		// cargo is prepended last, so it ends up first in PATH
+		assert.Less(t, npmIdx, cargoIdx)
+	})
+
+	t.Run("powershell joins all paths", func(t *testing.T) {
+		old := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		envCmd.Run(envCmd, []string{"pwsh"})
+
+		w.Close()
+		os.Stdout = old
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+
+		assert.Contains(t, buf.String(), `foreach ($b in @('/zana/bin/npm', '/zana/bin/cargo'))`)
+		assert.Contains(t, buf.String(), `$env:PATH = $b + $s + $env:PATH`)
+	})
+}
+
+func TestPowerShellEnvScript(t *testing.T) {
+	script := powerShellEnvScript([]string{`C:\Users\O'Brien\zana\bin`})
+	assert.Equal(t, 1, strings.Count(script, "\n"), "Invoke-Expression runs piped input line by line")
+	assert.Contains(t, script, `@('C:\Users\O''Brien\zana\bin')`)
+	assert.Contains(t, script, "[IO.Path]::PathSeparator")
+	assert.Contains(t, script, "-notcontains $b")
+}
+
+func TestUpdateEnvProfile(t *testing.T) {
+	block := envProfileBlock("pwsh", "")
+
+	installed, changed := updateEnvProfile("Set-Alias ll ls", block)
+	assert.True(t, changed)
+	assert.Equal(t, "Set-Alias ll ls\n"+block, installed)
+
+	again, changed := updateEnvProfile(installed, block)
+	assert.False(t, changed)
+	assert.Equal(t, installed, again)
+
+	replaced, changed := updateEnvProfile(strings.Replace(installed, "pwsh", "powershell", 1)+"Import-Module posh-git\n", block)
+	assert.True(t, changed)
+	assert.Equal(t, "Set-Alias ll ls\n"+block+"Import-Module posh-git\n", replaced)
+
+	removed, changed := updateEnvProfile(replaced, "")
+	assert.True(t, changed)
+	assert.Equal(t, "Set-Alias ll ls\nImport-Module posh-git\n", removed)
+
+	_, changed = updateEnvProfile(removed, "")
+	assert.False(t, changed)
+}
+
+func TestEnvCommandInstallsPowerShellProfile(t *testing.T) {
+	profile := filepath.Join(t.TempDir(), "PowerShell", "Microsoft.PowerShell_profile.ps1")
+	prevInstall, prevUninstall, prevProfile := envInstall, envUninstall, envProfile
+	t.Cleanup(func() { envInstall, envUninstall, envProfile = prevInstall, prevUninstall, prevProfile })
+	envProfile = profile
+
+	envInstall, envUninstall = true, false
+	out := captureOutput(t, func() { envCmd.Run(envCmd, []string{"pwsh"}) })
+	assert.Contains(t, out, "Added zana to "+profile)
+	content, err := os.ReadFile(profile)
+	assert.NoError(t, err)
+	assert.Equal(t, envProfileBlock("pwsh", ""), string(content))
+
+	out = captureOutput(t, func() { envCmd.Run(envCmd, []string{"pwsh"}) })
+	assert.Contains(t, out, "already sets up zana")
+
+	envInstall, envUninstall = false, true
+	out = captureOutput(t, func() { envCmd.Run(envCmd, []string{"pwsh"}) })
+	assert.Contains(t, out, "Removed zana from "+profile)
+	content, _ = os.ReadFile(profile)
+	assert.Empty(t, string(content))
+}
+
+func TestEnvCommandInstallsBashProfile(t *testing.T) {
+	home := t.TempDir()
+	prevInstall, prevUninstall, prevProfile, prevHome := envInstall, envUninstall, envProfile, envUserHomeDir
+	t.Cleanup(func() {
+		envInstall, envUninstall, envProfile, envUserHomeDir = prevInstall, prevUninstall, prevProfile, prevHome
+	})
+	envProfile = ""
+	envUserHomeDir = func() (string, error) { return home, nil }
+	profile := filepath.Join(home, ".bashrc")
+	assert.NoError(t, os.WriteFile(profile, []byte("alias ll='ls -l'\n"), 0644))
+
+	envInstall, envUninstall = true, false
+	out := captureOutput(t, func() { envCmd.Run(envCmd, []string{"bash"}) })
+	assert.Contains(t, out, "Added zana to "+profile+", open a new shell to use it")
+	content, _ := os.ReadFile(profile)
+	assert.Contains(t, string(content), "alias ll='ls -l'\n")
+	assert.Contains(t, string(content), `eval "$(zana env)"`)
+
+	envInstall, envUninstall = false, true
+	captureOutput(t, func() { envCmd.Run(envCmd, []string{"bash"}) })
+	content, _ = os.ReadFile(profile)
+	assert.Equal(t, "alias ll='ls -l'\n", string(content))
+
+	_, _, err := changeEnvProfile("fish", true, "")
+	assert.EqualError(t, err, "--install and --uninstall support bash, zsh, pwsh and powershell, not fish")
+}
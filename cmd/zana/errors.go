@@ -0,0 +1,70 @@
+package zana
+
+import (
+	"fmt"
+
+	"github.com/mistweaverco/zana-client/internal/lib/errcodes"
+	"github.com/spf13/cobra"
+)
+
+var errorsCmd = &cobra.Command{
+	Use:   "errors [code]",
+	Short: "List the error codes zana reports",
+	Long: `List the error codes zana reports in its JSON output, with what they
+mean and how to resolve them.
+
+The summary of add, remove, update and sync maps each failed package to one
+of them in "failure_codes", so wrappers can tell failures apart without
+parsing messages. Codes are stable: new ones may be added, existing ones
+aren't renamed or removed.
+
+Examples:
+  zana errors
+  zana errors network
+  zana errors -o json`,
+	Args: cobra.MaximumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		codes := []string{}
+		for _, entry := range errcodes.Catalog() {
+			codes = append(codes, string(entry.Code))
+		}
+		return codes, cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		entries := errcodes.Catalog()
+		if len(args) == 1 {
+			entry, ok := errcodes.Lookup(errcodes.Code(args[0]))
+			if !ok {
+				fmt.Printf("%s Unknown error code '%s', run 'zana errors' to list them\n", IconClose(), args[0])
+				osExit(1)
+				return
+			}
+			entries = []errcodes.Entry{entry}
+		}
+		if ShouldUseJSONOutput() {
+			PrintJSON(errorCatalogJSON{Errors: entries})
+			return
+		}
+		for i, entry := range entries {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Println(entry.Code)
+			fmt.Printf("  %s\n", entry.Description)
+			if ShouldUsePlainOutput() {
+				fmt.Printf("  Fix: %s\n", entry.Remediation)
+			} else {
+				fmt.Printf("  %s %s\n", IconLightbulb(), entry.Remediation)
+			}
+		}
+	},
+}
+
+// errorCatalogJSON is the --json output of zana errors
+type errorCatalogJSON struct {
+	SchemaVersion jsonSchemaVersion `json:"schema_version"`
+	Errors        []errcodes.Entry  `json:"errors"`
+}
@@ -0,0 +1,40 @@
+package zana
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/errcodes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorsCommand(t *testing.T) {
+	prevExit := osExit
+	t.Cleanup(func() { osExit = prevExit })
+	codes := []int{}
+	osExit = func(code int) { codes = append(codes, code) }
+
+	out := captureOutputWithMode(t, func() { errorsCmd.Run(errorsCmd, nil) }, config.OutputModePlain)
+	assert.Contains(t, out, "asset-not-found\n")
+	assert.Contains(t, out, "  Fix: Install the tool, or run 'zana health --fix'")
+
+	out = captureOutputWithMode(t, func() { errorsCmd.Run(errorsCmd, nil) }, config.OutputModeJSON)
+	var result struct {
+		SchemaVersion int              `json:"schema_version"`
+		Errors        []errcodes.Entry `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	assert.Equal(t, JSONSchemaVersion, result.SchemaVersion)
+	assert.Equal(t, errcodes.Catalog(), result.Errors)
+
+	out = captureOutputWithMode(t, func() { errorsCmd.Run(errorsCmd, []string{"permission"}) }, config.OutputModeJSON)
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, errcodes.Permission, result.Errors[0].Code)
+
+	out = captureOutput(t, func() { errorsCmd.Run(errorsCmd, []string{"nope"}) })
+	assert.Contains(t, out, "Unknown error code 'nope'")
+	assert.Equal(t, []int{1}, codes)
+}
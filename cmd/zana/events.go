@@ -0,0 +1,131 @@
+package zana
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/mistweaverco/zana-client/internal/lib/events"
+)
+
+// eventsPath is the file given with --events
+var eventsPath string
+
+// stopEvents stops writing the events of --events, see startEvents
+var stopEvents = func() {}
+
+// startEvents writes the events of this run to the file given with --events.
+// Commands exit directly on failure, so osExit closes the file as well as
+// closeEvents after the command ran.
+func startEvents(path string) error {
+	stop, err := writeEventsTo(path)
+	if err != nil {
+		return err
+	}
+	stopEvents = stop
+	exit := osExit
+	osExit = func(code int) {
+		closeEvents()
+		exit(code)
+	}
+	return nil
+}
+
+// closeEvents stops writing the events of --events and closes the file, once
+func closeEvents() {
+	stop := stopEvents
+	stopEvents = func() {}
+	stop()
+}
+
+// writeEventsTo appends every event as JSON line to the file at path.
+// Events are written synchronously, so none get lost when zana exits.
+// The returned function stops writing and closes the file.
+func writeEventsTo(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	var mu sync.Mutex
+	enc := json.NewEncoder(f)
+	write := func(e events.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		_ = enc.Encode(e)
+	}
+	unwatch := events.Watch(events.Handlers{OnProgress: write, OnLog: write, OnStateChange: write})
+	return func() {
+		unwatch()
+		mu.Lock()
+		defer mu.Unlock()
+		_ = f.Close()
+	}, nil
+}
+
+// progressModeJSON is the --progress mode writing progress as JSON lines to stderr
+const progressModeJSON = "json"
+
+// progressMode is the --progress flag, validated when it is parsed
+type progressMode string
+
+func (p *progressMode) String() string { return string(*p) }
+
+func (p *progressMode) Type() string { return "mode" }
+
+func (p *progressMode) Set(v string) error {
+	if v != progressModeJSON {
+		return fmt.Errorf("unsupported progress mode %q, supported: %s", v, progressModeJSON)
+	}
+	*p = progressMode(v)
+	return nil
+}
+
+// progressFlag is the mode given with --progress
+var progressFlag progressMode
+
+// progressLine is a line written by --progress json. Pct and Total are
+// omitted when the size of a download is unknown.
+type progressLine struct {
+	Phase   string `json:"phase"`
+	Package string `json:"package,omitempty"`
+	Op      string `json:"op,omitempty"`
+	Pct     *int   `json:"pct,omitempty"`
+	Bytes   int64  `json:"bytes,omitempty"`
+	Total   int64  `json:"total,omitempty"`
+}
+
+// toProgressLine converts a state change or progress event to its --progress json line
+func toProgressLine(e events.Event) progressLine {
+	line := progressLine{Phase: e.State, Package: e.Package, Op: e.OperationID}
+	pct := func(p int) *int { return &p }
+	switch e.Kind {
+	case events.KindProgress:
+		line.Phase = events.StateDownloading
+		line.Bytes = e.BytesDone
+		if e.BytesTotal > 0 {
+			line.Total = e.BytesTotal
+			line.Pct = pct(int(min(e.BytesDone*100/e.BytesTotal, 100)))
+		}
+	case events.KindStateChange:
+		if e.State == events.StateDone {
+			line.Pct = pct(100)
+		}
+	}
+	return line
+}
+
+// writeProgressTo writes state changes and download progress as JSON lines
+// to w, leaving stdout to the command's result. Log records are left out,
+// they stay in the log file. The returned function stops writing.
+func writeProgressTo(w io.Writer) func() {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	write := func(e events.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		_ = enc.Encode(toProgressLine(e))
+	}
+	return events.Watch(events.Handlers{OnProgress: write, OnStateChange: write})
+}
@@ -0,0 +1,91 @@
+package zana
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteEventsTo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	stop, err := writeEventsTo(path)
+	require.NoError(t, err)
+
+	events.Publish(events.Event{Kind: events.KindStateChange, Package: "npm:prettier", State: events.StateStarted})
+	events.Publish(events.Event{Kind: events.KindProgress, Package: "npm:prettier", BytesDone: 42, BytesTotal: -1})
+	stop()
+	events.Publish(events.Event{Kind: events.KindLog, Message: "after stop"})
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var got []events.Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e events.Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		got = append(got, e)
+	}
+	require.Len(t, got, 2)
+	assert.Equal(t, events.StateStarted, got[0].State)
+	assert.Equal(t, int64(42), got[1].BytesDone)
+
+	_, err = writeEventsTo(filepath.Join(t.TempDir(), "missing", "events.jsonl"))
+	assert.Error(t, err)
+}
+
+func TestStartEvents(t *testing.T) {
+	prevExit := osExit
+	t.Cleanup(func() { osExit = prevExit; closeEvents() })
+	var codes []int
+	osExit = func(code int) { codes = append(codes, code) }
+
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	require.NoError(t, startEvents(path))
+	events.Publish(events.Event{Kind: events.KindLog, Message: "before exit"})
+	osExit(1)
+	events.Publish(events.Event{Kind: events.KindLog, Message: "after exit"})
+	assert.Equal(t, []int{1}, codes)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "before exit")
+	assert.NotContains(t, string(content), "after exit")
+
+	// Closing again after the command ran is a no-op
+	closeEvents()
+
+	assert.Error(t, startEvents(filepath.Join(t.TempDir(), "missing", "events.jsonl")))
+}
+
+func TestWriteProgressTo(t *testing.T) {
+	var buf bytes.Buffer
+	stop := writeProgressTo(&buf)
+	events.Publish(events.Event{Kind: events.KindStateChange, OperationID: "op1", Package: "npm:prettier", State: events.StateStarted})
+	events.Publish(events.Event{Kind: events.KindProgress, Package: "npm:prettier", BytesDone: 512, BytesTotal: 2048})
+	events.Publish(events.Event{Kind: events.KindProgress, Package: "npm:prettier", BytesDone: 4096, BytesTotal: -1})
+	events.Publish(events.Event{Kind: events.KindLog, Package: "npm:prettier", Message: "not progress"})
+	events.Publish(events.Event{Kind: events.KindStateChange, Package: "npm:prettier", State: events.StateDone})
+	stop()
+	events.Publish(events.Event{Kind: events.KindStateChange, Package: "npm:prettier", State: events.StateStarted})
+
+	assert.Equal(t, `{"phase":"started","package":"npm:prettier","op":"op1"}
+{"phase":"downloading","package":"npm:prettier","pct":25,"bytes":512,"total":2048}
+{"phase":"downloading","package":"npm:prettier","bytes":4096}
+{"phase":"done","package":"npm:prettier","pct":100}
+`, buf.String())
+}
+
+func TestProgressFlag(t *testing.T) {
+	var p progressMode
+	assert.NoError(t, p.Set("json"))
+	assert.Equal(t, "json", p.String())
+	assert.ErrorContains(t, p.Set("xml"), `unsupported progress mode "xml"`)
+}
@@ -0,0 +1,254 @@
+package zana
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the installed packages as a Brewfile or Nix expression",
+	Long: `Export the packages in zana-lock.json as an equivalent declarative file of
+another package manager, for migrating to or sharing a tool set with it.
+
+Formats:
+  brewfile  - a Homebrew Brewfile (brew bundle)
+  nix       - a Nix expression evaluating to a list of nixpkgs packages
+
+The mapping is best effort: package names are assumed to match the formula or
+nixpkgs attribute names, and neither format pins the installed versions.
+Packages that can't be mapped (e.g. generic or data packages) are listed as
+comments with the reason.
+
+Examples:
+  zana export --format brewfile > Brewfile
+  zana export --format nix --file zana-packages.nix`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		content, entries, err := buildExport(exportFormat, newLocalPackagesParserFn().Packages)
+		if err == nil && exportFile != "" {
+			err = os.WriteFile(exportFile, []byte(content), 0644)
+		}
+		if err != nil {
+			if ShouldUseJSONOutput() {
+				PrintJSON(map[string]interface{}{
+					"success": false,
+					"error":   err.Error(),
+				})
+			} else {
+				fmt.Printf("%s %v\n", IconClose(), err)
+			}
+			osExit(1)
+			return
+		}
+		if ShouldUseJSONOutput() {
+			PrintJSON(map[string]interface{}{
+				"success":  true,
+				"format":   exportFormat,
+				"content":  content,
+				"packages": entries,
+			})
+			return
+		}
+		if exportFile == "" {
+			fmt.Print(content)
+			return
+		}
+		unmapped := 0
+		for _, e := range entries {
+			if e.Entry == "" {
+				unmapped++
+			}
+		}
+		fmt.Printf("%s Exported %d packages to %s\n", IconCheck(), len(entries)-unmapped, exportFile)
+		if unmapped > 0 {
+			fmt.Printf("%s %d packages could not be mapped, see the comments in %s\n", IconAlert(), unmapped, exportFile)
+		}
+	},
+}
+
+var exportFormat string
+var exportFile string
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormat, "format", "", "format to export: brewfile or nix")
+	exportCmd.Flags().StringVar(&exportFile, "file", "", "write to this file instead of stdout")
+	_ = exportCmd.RegisterFlagCompletionFunc("format", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"brewfile", "nix"}, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// exportEntry is a package of the lock file and the line it maps to in the
+// exported file. Entry is empty when the package can't be mapped, Note says why.
+type exportEntry struct {
+	SourceID string `json:"package"`
+	Version  string `json:"version"`
+	Entry    string `json:"entry,omitempty"`
+	Note     string `json:"note,omitempty"`
+}
+
+var brewNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9@+._-]*$`)
+var nixIdentifierPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_'-]*$`)
+
+// nixPackageSets maps providers to the nixpkgs package set of their packages;
+// packages of other providers are looked up at the top level
+var nixPackageSets = map[string]string{
+	"npm":      "nodePackages",
+	"pypi":     "python3Packages",
+	"gem":      "rubyPackages",
+	"luarocks": "luaPackages",
+	"opam":     "ocamlPackages",
+	"composer": "phpPackages",
+}
+
+// buildExport renders the packages in the given format and returns the
+// content with the mapping of every package
+func buildExport(format string, packages []local_packages_parser.LocalPackageItem) (string, []exportEntry, error) {
+	var mapEntry func(rp *registry_parser.RegistryParser, sourceID string) (string, string)
+	switch format {
+	case "brewfile":
+		mapEntry = brewfileEntry
+	case "nix":
+		mapEntry = nixEntry
+	case "":
+		return "", nil, fmt.Errorf("--format is required (brewfile or nix)")
+	default:
+		return "", nil, fmt.Errorf("unknown export format %q (use brewfile or nix)", format)
+	}
+
+	rp := newRegistryParser()
+	entries := make([]exportEntry, 0, len(packages))
+	for _, pkg := range packages {
+		entry, note := mapEntry(rp, pkg.SourceID)
+		entries = append(entries, exportEntry{SourceID: pkg.SourceID, Version: pkg.Version, Entry: entry, Note: note})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].SourceID < entries[j].SourceID })
+
+	if format == "nix" {
+		return renderNixExport(entries), entries, nil
+	}
+	return renderBrewfileExport(entries), entries, nil
+}
+
+// exportPackageName returns the name of the package in the registry, or the
+// last segment of its package ID (golang.org/x/tools/gopls -> gopls)
+func exportPackageName(rp *registry_parser.RegistryParser, sourceID string) string {
+	if name := strings.TrimSpace(rp.GetBySourceId(sourceID).Name); name != "" {
+		return name
+	}
+	_, pkg, _ := strings.Cut(sourceID, ":")
+	return path.Base(pkg)
+}
+
+// exportUnmappable returns why a package has no counterpart in another
+// package manager, or an empty string
+func exportUnmappable(rp *registry_parser.RegistryParser, sourceID string) string {
+	provider, _, _ := strings.Cut(sourceID, ":")
+	if provider == "generic" {
+		return "generic packages are installed by shell commands"
+	}
+	if rp.GetBySourceId(sourceID).IsDataOnly() {
+		return "data package without executables"
+	}
+	return ""
+}
+
+func brewfileEntry(rp *registry_parser.RegistryParser, sourceID string) (string, string) {
+	if reason := exportUnmappable(rp, sourceID); reason != "" {
+		return "", reason
+	}
+	provider, pkg, _ := strings.Cut(sourceID, ":")
+	if provider == "openvsx" {
+		// Brewfiles install VS Code extensions by their publisher.name ID
+		return fmt.Sprintf("vscode %q", strings.Replace(pkg, "/", ".", 1)), ""
+	}
+	name := strings.ToLower(exportPackageName(rp, sourceID))
+	if !brewNamePattern.MatchString(name) {
+		return "", fmt.Sprintf("%s is not a valid Homebrew formula name", name)
+	}
+	return fmt.Sprintf("brew %q", name), ""
+}
+
+func nixEntry(rp *registry_parser.RegistryParser, sourceID string) (string, string) {
+	if reason := exportUnmappable(rp, sourceID); reason != "" {
+		return "", reason
+	}
+	provider, pkg, _ := strings.Cut(sourceID, ":")
+	if provider == "openvsx" {
+		publisher, extension, _ := strings.Cut(strings.ToLower(pkg), "/")
+		return "vscode-extensions." + nixAttr(publisher) + "." + nixAttr(extension), ""
+	}
+	name := exportPackageName(rp, sourceID)
+	switch provider {
+	case "npm":
+		// nodePackages keeps the npm name, scope included
+		name = pkg
+	case "pypi":
+		name = strings.ReplaceAll(strings.ToLower(name), "_", "-")
+	}
+	if set, ok := nixPackageSets[provider]; ok {
+		return set + "." + nixAttr(name), ""
+	}
+	return nixAttr(name), ""
+}
+
+// nixAttr quotes attribute names that aren't valid Nix identifiers
+func nixAttr(name string) string {
+	if nixIdentifierPattern.MatchString(name) {
+		return name
+	}
+	return fmt.Sprintf("%q", name)
+}
+
+func renderBrewfileExport(entries []exportEntry) string {
+	var b strings.Builder
+	b.WriteString("# Brewfile generated by zana export from zana-lock.json\n")
+	b.WriteString("# The mapping is best effort: formula names may differ from the package\n")
+	b.WriteString("# names and Homebrew installs its current versions.\n\n")
+	for _, e := range entries {
+		if e.Entry != "" {
+			fmt.Fprintf(&b, "%s # %s@%s\n", e.Entry, e.SourceID, e.Version)
+		}
+	}
+	writeUnmappedComments(&b, entries, "")
+	return b.String()
+}
+
+func renderNixExport(entries []exportEntry) string {
+	var b strings.Builder
+	b.WriteString("# Generated by zana export from zana-lock.json\n")
+	b.WriteString("# The mapping is best effort: attribute names may differ from the package\n")
+	b.WriteString("# names and the versions are those of your nixpkgs revision.\n")
+	b.WriteString("# Use it with e.g. home.packages = import ./this-file.nix { inherit pkgs; };\n")
+	b.WriteString("{ pkgs ? import <nixpkgs> { } }:\n\nwith pkgs; [\n")
+	for _, e := range entries {
+		if e.Entry != "" {
+			fmt.Fprintf(&b, "  %s # %s@%s\n", e.Entry, e.SourceID, e.Version)
+		}
+	}
+	writeUnmappedComments(&b, entries, "  ")
+	b.WriteString("]\n")
+	return b.String()
+}
+
+func writeUnmappedComments(b *strings.Builder, entries []exportEntry, indent string) {
+	header := false
+	for _, e := range entries {
+		if e.Entry != "" {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(b, "\n%s# Not mapped:\n", indent)
+			header = true
+		}
+		fmt.Fprintf(b, "%s# %s@%s: %s\n", indent, e.SourceID, e.Version, e.Note)
+	}
+}
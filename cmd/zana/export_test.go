@@ -0,0 +1,114 @@
+package zana
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubExport(t *testing.T) *[]int {
+	t.Helper()
+	prevParser, prevLocal, prevFormat, prevFile, prevExit := newRegistryParser, newLocalPackagesParserFn, exportFormat, exportFile, osExit
+	t.Cleanup(func() {
+		newRegistryParser, newLocalPackagesParserFn, exportFormat, exportFile, osExit = prevParser, prevLocal, prevFormat, prevFile, prevExit
+	})
+
+	newRegistryParser = func() *registry_parser.RegistryParser {
+		rp := registry_parser.NewRegistryParser(nil)
+		require.NoError(t, rp.LoadFromBytes([]byte(`[
+			{"name": "prettier", "source": {"id": "npm:prettier"}},
+			{"name": "python-lsp-server", "source": {"id": "pypi:python_lsp_server"}},
+			{"name": "fonts", "kind": "data", "source": {"id": "github:owner/fonts"}}
+		]`)))
+		return rp
+	}
+	newLocalPackagesParserFn = func() local_packages_parser.LocalPackageRoot {
+		return local_packages_parser.LocalPackageRoot{Packages: []local_packages_parser.LocalPackageItem{
+			{SourceID: "npm:prettier", Version: "3.3.0"},
+			{SourceID: "npm:@angular/cli", Version: "18.0.0"},
+			{SourceID: "pypi:python_lsp_server", Version: "1.11.0"},
+			{SourceID: "golang:golang.org/x/tools/gopls", Version: "0.16.0"},
+			{SourceID: "openvsx:esbenp/prettier-vscode", Version: "11.0.0"},
+			{SourceID: "generic:my-tool", Version: "1.0.0"},
+			{SourceID: "github:owner/fonts", Version: "2.0.0"},
+		}}
+	}
+	codes := &[]int{}
+	osExit = func(code int) { *codes = append(*codes, code) }
+	return codes
+}
+
+func TestBuildExportBrewfile(t *testing.T) {
+	stubExport(t)
+	content, entries, err := buildExport("brewfile", newLocalPackagesParserFn().Packages)
+	require.NoError(t, err)
+	assert.Len(t, entries, 7)
+	assert.Contains(t, content, "brew \"prettier\" # npm:prettier@3.3.0\n")
+	assert.Contains(t, content, "brew \"cli\" # npm:@angular/cli@18.0.0\n")
+	assert.Contains(t, content, "brew \"python-lsp-server\" # pypi:python_lsp_server@1.11.0\n")
+	assert.Contains(t, content, "brew \"gopls\" # golang:golang.org/x/tools/gopls@0.16.0\n")
+	assert.Contains(t, content, "vscode \"esbenp.prettier-vscode\" # openvsx:esbenp/prettier-vscode@11.0.0\n")
+	assert.Contains(t, content, "\n# Not mapped:\n"+
+		"# generic:my-tool@1.0.0: generic packages are installed by shell commands\n"+
+		"# github:owner/fonts@2.0.0: data package without executables\n")
+}
+
+func TestBuildExportNix(t *testing.T) {
+	stubExport(t)
+	content, _, err := buildExport("nix", newLocalPackagesParserFn().Packages)
+	require.NoError(t, err)
+	assert.Contains(t, content, "{ pkgs ? import <nixpkgs> { } }:\n\nwith pkgs; [\n")
+	assert.Contains(t, content, "  gopls # golang:golang.org/x/tools/gopls@0.16.0\n")
+	assert.Contains(t, content, "  nodePackages.\"@angular/cli\" # npm:@angular/cli@18.0.0\n")
+	assert.Contains(t, content, "  nodePackages.prettier # npm:prettier@3.3.0\n")
+	assert.Contains(t, content, "  python3Packages.python-lsp-server # pypi:python_lsp_server@1.11.0\n")
+	assert.Contains(t, content, "  vscode-extensions.esbenp.prettier-vscode # openvsx:esbenp/prettier-vscode@11.0.0\n")
+	assert.Contains(t, content, "  # github:owner/fonts@2.0.0: data package without executables\n]\n")
+}
+
+func TestBuildExportFormatErrors(t *testing.T) {
+	stubExport(t)
+	_, _, err := buildExport("", nil)
+	assert.EqualError(t, err, "--format is required (brewfile or nix)")
+	_, _, err = buildExport("apt", nil)
+	assert.EqualError(t, err, `unknown export format "apt" (use brewfile or nix)`)
+}
+
+func TestExportCommand(t *testing.T) {
+	codes := stubExport(t)
+
+	exportFormat = "nix"
+	out := captureOutputWithMode(t, func() { exportCmd.Run(exportCmd, nil) }, config.OutputModeJSON)
+	var result struct {
+		Success  bool          `json:"success"`
+		Format   string        `json:"format"`
+		Content  string        `json:"content"`
+		Packages []exportEntry `json:"packages"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	assert.True(t, result.Success)
+	assert.Equal(t, "nix", result.Format)
+	assert.Contains(t, result.Content, "nodePackages.prettier")
+	assert.Contains(t, result.Packages, exportEntry{SourceID: "generic:my-tool", Version: "1.0.0", Note: "generic packages are installed by shell commands"})
+
+	exportFormat = "brewfile"
+	exportFile = filepath.Join(t.TempDir(), "Brewfile")
+	out = captureOutput(t, func() { exportCmd.Run(exportCmd, nil) })
+	assert.Contains(t, out, "Exported 5 packages to "+exportFile)
+	assert.Contains(t, out, "2 packages could not be mapped")
+	data, err := os.ReadFile(exportFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "brew \"prettier\"")
+
+	exportFormat = "apt"
+	out = captureOutput(t, func() { exportCmd.Run(exportCmd, nil) })
+	assert.Contains(t, out, "unknown export format")
+	assert.Equal(t, []int{1}, *codes)
+}
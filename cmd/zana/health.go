@@ -2,6 +2,7 @@ package zana
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/mistweaverco/zana-client/internal/lib/providers"
@@ -9,19 +10,56 @@ import (
 )
 
 var healthCmd = &cobra.Command{
-	Use:   "health",
-	Short: "Check system health and requirements",
+	Use:     "health",
+	Aliases: []string{"doctor"},
+	Short:   "Check system health and requirements",
 	Long: `Check if the system meets all requirements for running Zana.
 
-This command verifies the presence of required tools and dependencies for all providers.`,
+This command verifies the presence of required tools and dependencies for all providers.
+For missing tools, installation instructions for the current OS are shown.
+Executables in the zana bin directory that shadow, or are shadowed by,
+a same-named executable elsewhere on PATH are reported as well.
+So are executables put into the bin directory by hand, which zana doesn't
+manage; track them with zana adopt, and installed packages the registry
+deprecates; replace them with zana migrate-package.
+
+Use --fix to install missing tools with the system package manager
+(e.g. brew, apt-get, dnf, pacman or winget), when it provides them.
+
+Use --network to probe the registry URLs, the GitHub API, crates.io, PyPI
+and the npm registry, reporting whether they are reachable and their latency,
+to tell zana bugs apart from network, proxy or firewall problems.`,
 	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Check all providers
 		providerStatuses := checkAllProvidersHealthFn()
+		var fixErrors map[string]string
+		if healthFix {
+			fixErrors = fixMissingProviderTools(providerStatuses)
+			providerStatuses = checkAllProvidersHealthFn()
+		}
+
+		shadows := findAllBinShadowingFn()
+		unmanaged := findUnmanagedBinariesFn()
+		deprecated := findDeprecatedPackagesFn(newLocalPackagesParserFn().Packages)
+
+		var probes []providers.NetworkProbeResult
+		if healthNetwork {
+			probes = probeNetworkFn()
+		}
 
 		if ShouldUseJSONOutput() {
 			result := map[string]interface{}{
-				"providers": providerStatuses,
+				"providers":           providerStatuses,
+				"shadowed_binaries":   shadows,
+				"unmanaged_binaries":  unmanaged,
+				"deprecated_packages": deprecated,
+			}
+			if healthFix {
+				result["fix_errors"] = fixErrors
+			}
+			if healthNetwork {
+				result["network"] = probes
 			}
 			PrintJSON(result)
 		} else {
@@ -34,25 +72,109 @@ This command verifies the presence of required tools and dependencies for all pr
 			hasWarnings := false
 			for _, status := range providerStatuses {
 				icon := getProviderIcon(status.Provider)
-				if status.Available {
+				if status.Disabled {
+					fmt.Printf("%s %s: Disabled by policy\n", icon, strings.ToUpper(status.Provider))
+				} else if status.Available {
 					fmt.Printf("%s %s: Available\n", icon, strings.ToUpper(status.Provider))
 				} else {
 					hasWarnings = true
 					fmt.Printf("%s %s: %s Not available (missing: %s)\n", icon, strings.ToUpper(status.Provider), IconAlert(), status.RequiredTool)
 					fmt.Printf("   %s\n", status.Description)
+					if status.InstallHint != "" {
+						fmt.Printf("   %s %s\n", IconLightbulb(), status.InstallHint)
+					}
+					if err, ok := fixErrors[status.Provider]; ok {
+						fmt.Printf("   %s Fix failed: %s\n", IconClose(), err)
+					}
 				}
 				fmt.Println()
 			}
 
+			if len(shadows) > 0 {
+				fmt.Printf("%s Executables with a namesake elsewhere on PATH:\n", IconAlert())
+				for _, shadow := range shadows {
+					fmt.Printf("   %s\n", describeBinShadow(shadow))
+				}
+				fmt.Println()
+			}
+
+			warnUnmanagedBinaries(unmanaged)
+			printDeprecatedPackages(deprecated)
+
+			if healthNetwork {
+				printNetworkProbes(probes)
+			}
+
 			// Overall status
 			if !hasWarnings {
 				fmt.Printf("%s All providers are available! Your system is ready to use Zana.\n", IconCheckCircle())
 			} else {
 				fmt.Printf("%s Some providers are not available. Install the required tools to use those providers.\n", IconAlert())
+				if !healthFix {
+					fmt.Printf("%s Run 'zana doctor --fix' to install missing tools with your system package manager.\n", IconLightbulb())
+				}
 			}
 		}
 	},
 }
 
+var (
+	healthFix     bool
+	healthNetwork bool
+)
+
+func init() {
+	healthCmd.Flags().BoolVar(&healthFix, "fix", false, "install missing provider tools with the system package manager")
+	healthCmd.Flags().BoolVar(&healthNetwork, "network", false, "probe the registry and package services for reachability and latency")
+}
+
+// printNetworkProbes prints the outcome of the network probes, one service per line
+func printNetworkProbes(probes []providers.NetworkProbeResult) {
+	fmt.Printf("%s Network:\n", IconMagnify())
+	unreachable := false
+	for _, p := range probes {
+		switch {
+		case !p.Reachable:
+			unreachable = true
+			fmt.Printf("   %s %s (%s): unreachable after %d ms: %s\n", IconClose(), p.Name, p.URL, p.LatencyMs, p.Error)
+		case p.StatusCode >= http.StatusBadRequest:
+			fmt.Printf("   %s %s (%s): HTTP %d in %d ms\n", IconAlert(), p.Name, p.URL, p.StatusCode, p.LatencyMs)
+		default:
+			fmt.Printf("   %s %s (%s): %d ms\n", IconCheck(), p.Name, p.URL, p.LatencyMs)
+		}
+	}
+	if unreachable {
+		fmt.Printf("   %s Unreachable services point to a network, proxy or firewall problem rather than a zana bug.\n", IconLightbulb())
+	}
+	fmt.Println()
+}
+
+// fixMissingProviderTools installs the missing tools of all unavailable providers.
+// Tools shared by several providers (e.g. git) are only installed once.
+// It returns the errors by provider name.
+func fixMissingProviderTools(statuses []providers.ProviderHealthStatus) map[string]string {
+	errs := map[string]string{}
+	attempted := map[string]error{}
+	for _, status := range statuses {
+		if status.Available || status.Disabled {
+			continue
+		}
+		err, done := attempted[status.RequiredTool]
+		if !done {
+			if !ShouldUseJSONOutput() {
+				fmt.Printf("%s Installing %s for %s...\n", IconRefresh(), status.RequiredTool, strings.ToUpper(status.Provider))
+			}
+			err = fixProviderToolFn(status)
+			attempted[status.RequiredTool] = err
+		}
+		if err != nil {
+			errs[status.Provider] = err.Error()
+		}
+	}
+	return errs
+}
+
 // indirection for testability
 var checkAllProvidersHealthFn = providers.CheckAllProvidersHealth
+var fixProviderToolFn = providers.FixProviderTool
+var probeNetworkFn = providers.ProbeNetwork
@@ -2,10 +2,14 @@ package zana
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"strings"
 	"testing"
 
+	"github.com/mistweaverco/zana-client/internal/config"
 	"github.com/mistweaverco/zana-client/internal/lib/providers"
 	"github.com/stretchr/testify/assert"
 )
@@ -105,3 +109,155 @@ func TestHealthCommandRun(t *testing.T) {
 		assert.Contains(t, out, "GENERIC: Available")
 	})
 }
+
+func TestHealthCommandFix(t *testing.T) {
+	prevCheck, prevFix := checkAllProvidersHealthFn, fixProviderToolFn
+	t.Cleanup(func() {
+		checkAllProvidersHealthFn, fixProviderToolFn = prevCheck, prevFix
+		healthFix = false
+	})
+
+	installed := map[string]bool{}
+	checkAllProvidersHealthFn = func() []providers.ProviderHealthStatus {
+		return []providers.ProviderHealthStatus{
+			{Provider: "github", Available: installed["git"], RequiredTool: "git", FixCommand: []string{"brew", "install", "git"}},
+			{Provider: "gitlab", Available: installed["git"], RequiredTool: "git", FixCommand: []string{"brew", "install", "git"}},
+			{Provider: "opam", Available: false, RequiredTool: "opam", InstallHint: "See https://opam.ocaml.org/doc/Install.html for installation instructions"},
+		}
+	}
+	fixes := []string{}
+	fixProviderToolFn = func(status providers.ProviderHealthStatus) error {
+		fixes = append(fixes, status.RequiredTool)
+		if len(status.FixCommand) == 0 {
+			return errors.New("no automatic installation available for opam")
+		}
+		installed[status.RequiredTool] = true
+		return nil
+	}
+
+	healthFix = true
+	out := captureOutput(t, func() { healthCmd.Run(healthCmd, nil) })
+
+	assert.Equal(t, []string{"git", "opam"}, fixes, "tools shared by several providers are installed once")
+	assert.Contains(t, out, "GITHUB: Available")
+	assert.Contains(t, out, "GITLAB: Available")
+	assert.Contains(t, out, "OPAM:")
+	assert.Contains(t, out, "See https://opam.ocaml.org/doc/Install.html")
+	assert.Contains(t, out, "Fix failed: no automatic installation available for opam")
+}
+
+func TestProviderToolPreflight(t *testing.T) {
+	prev, prevNeeds := checkProviderHealthFn, installNeedsProviderToolFn
+	t.Cleanup(func() { checkProviderHealthFn, installNeedsProviderToolFn = prev, prevNeeds })
+	installNeedsProviderToolFn = func(sourceID string) bool { return sourceID != "github:sharkdp/bat" }
+	checks := 0
+	checkProviderHealthFn = func(provider string) providers.ProviderHealthStatus {
+		checks++
+		if provider == "npm" {
+			return providers.ProviderHealthStatus{Provider: "npm", RequiredTool: "npm", InstallHint: "Install it with: brew install node", FixCommand: []string{"brew", "install", "node"}}
+		}
+		return providers.ProviderHealthStatus{Provider: provider, Available: true}
+	}
+
+	preflight := newProviderToolPreflight()
+	var out strings.Builder
+	printf := func(format string, args ...interface{}) { fmt.Fprintf(&out, format, args...) }
+
+	assert.False(t, preflight.check("npm:prettier", printf))
+	assert.False(t, preflight.check("npm:eslint", printf))
+	assert.True(t, preflight.check("cargo:ripgrep", printf))
+	assert.True(t, preflight.check("github:sharkdp/bat", printf))
+	assert.Equal(t, 2, checks, "each provider is checked once, and only when the install uses its tool")
+	assert.Contains(t, out.String(), "Cannot install npm:prettier: npm is not installed or not on PATH")
+	assert.Contains(t, out.String(), "Cannot install npm:eslint")
+	assert.Equal(t, 1, strings.Count(out.String(), "brew install node"), "the hint is shown once per provider")
+	assert.Contains(t, out.String(), "zana doctor --fix")
+}
+
+func TestHealthCommandShowsDisabledProviders(t *testing.T) {
+	prev := checkAllProvidersHealthFn
+	t.Cleanup(func() { checkAllProvidersHealthFn = prev })
+	checkAllProvidersHealthFn = func() []providers.ProviderHealthStatus {
+		return []providers.ProviderHealthStatus{
+			{Provider: "npm", Available: true},
+			{Provider: "generic", Available: true, Disabled: true},
+		}
+	}
+
+	out := captureOutput(t, func() { healthCmd.Run(healthCmd, nil) })
+	assert.Contains(t, out, "GENERIC: Disabled by policy")
+	assert.Contains(t, out, "NPM: Available")
+	assert.Contains(t, out, "All providers are available")
+}
+
+func TestHealthCommandReportsShadowedBinaries(t *testing.T) {
+	prevHealth, prevShadows := checkAllProvidersHealthFn, findAllBinShadowingFn
+	t.Cleanup(func() { checkAllProvidersHealthFn, findAllBinShadowingFn = prevHealth, prevShadows })
+	checkAllProvidersHealthFn = func() []providers.ProviderHealthStatus {
+		return []providers.ProviderHealthStatus{{Provider: "npm", Available: true}}
+	}
+	findAllBinShadowingFn = func() []providers.BinShadow {
+		return []providers.BinShadow{{Name: "prettier", ZanaPath: "/zana/bin/prettier", OtherPaths: []string{"/usr/bin/prettier"}, Winner: "/zana/bin/prettier", OnPath: true}}
+	}
+
+	out := captureOutput(t, func() { healthCmd.Run(healthCmd, nil) })
+	assert.Contains(t, out, "Executables with a namesake elsewhere on PATH")
+	assert.Contains(t, out, "prettier: /zana/bin/prettier shadows /usr/bin/prettier")
+}
+
+func TestHealthCommandReportsUnmanagedBinaries(t *testing.T) {
+	prevHealth, prevShadows, prevUnmanaged := checkAllProvidersHealthFn, findAllBinShadowingFn, findUnmanagedBinariesFn
+	t.Cleanup(func() {
+		checkAllProvidersHealthFn, findAllBinShadowingFn, findUnmanagedBinariesFn = prevHealth, prevShadows, prevUnmanaged
+	})
+	checkAllProvidersHealthFn = func() []providers.ProviderHealthStatus {
+		return []providers.ProviderHealthStatus{{Provider: "npm", Available: true}}
+	}
+	findAllBinShadowingFn = func() []providers.BinShadow { return nil }
+	findUnmanagedBinariesFn = func() []providers.UnmanagedBinary {
+		return []providers.UnmanagedBinary{{Name: "mytool", Path: "/zana/bin/mytool"}}
+	}
+
+	out := captureOutput(t, func() { healthCmd.Run(healthCmd, nil) })
+	assert.Contains(t, out, "not managed by zana")
+	assert.Contains(t, out, "/zana/bin/mytool")
+	assert.Contains(t, out, "zana adopt <binary>")
+
+	out = captureOutputWithMode(t, func() { healthCmd.Run(healthCmd, nil) }, config.OutputModeJSON)
+	assert.Contains(t, out, `"unmanaged_binaries"`)
+}
+
+func TestHealthCommandNetwork(t *testing.T) {
+	prevHealth, prevShadows, prevProbe := checkAllProvidersHealthFn, findAllBinShadowingFn, probeNetworkFn
+	t.Cleanup(func() {
+		checkAllProvidersHealthFn, findAllBinShadowingFn, probeNetworkFn = prevHealth, prevShadows, prevProbe
+		healthNetwork = false
+	})
+	checkAllProvidersHealthFn = func() []providers.ProviderHealthStatus {
+		return []providers.ProviderHealthStatus{{Provider: "npm", Available: true}}
+	}
+	findAllBinShadowingFn = func() []providers.BinShadow { return nil }
+	probes := 0
+	probeNetworkFn = func() []providers.NetworkProbeResult {
+		probes++
+		return []providers.NetworkProbeResult{
+			{Name: "Registry", URL: "https://example.com/zana-registry.json.zip", Reachable: true, StatusCode: 200, LatencyMs: 42},
+			{Name: "PyPI", URL: "https://pypi.org/simple/", Reachable: true, StatusCode: 403, LatencyMs: 7},
+			{Name: "npm registry", URL: "https://registry.npmjs.org/", LatencyMs: 5000, Error: "context deadline exceeded"},
+		}
+	}
+
+	_ = captureOutput(t, func() { healthCmd.Run(healthCmd, nil) })
+	assert.Zero(t, probes, "the network is only probed with --network")
+
+	healthNetwork = true
+	out := captureOutput(t, func() { healthCmd.Run(healthCmd, nil) })
+	assert.Contains(t, out, "Registry (https://example.com/zana-registry.json.zip): 42 ms")
+	assert.Contains(t, out, "PyPI (https://pypi.org/simple/): HTTP 403 in 7 ms")
+	assert.Contains(t, out, "npm registry (https://registry.npmjs.org/): unreachable after 5000 ms: context deadline exceeded")
+	assert.Contains(t, out, "network, proxy or firewall problem")
+
+	out = captureOutputWithMode(t, func() { healthCmd.Run(healthCmd, nil) }, config.OutputModeJSON)
+	assert.Contains(t, out, `"network"`)
+	assert.Contains(t, out, `"latency_ms": 42`)
+}
@@ -83,6 +83,7 @@ const (
 	iconGitHub   = "🐙"
 	iconGitLab   = "🦊"
 	iconCodeberg = "🏔️"
+	iconGitea    = "🍵"
 	iconGem      = "💎"
 	iconComposer = "🐘"
 	iconLuaRocks = "🌙"
@@ -113,6 +114,7 @@ const (
 	textGitHub      = "[gh]"
 	textGitLab      = "[gl]"
 	textCodeberg    = "[cb]"
+	textGitea       = "[gt]"
 	textGem         = "[rb]"
 	textComposer    = "[php]"
 	textLuaRocks    = "[lua]"
@@ -318,6 +320,13 @@ func IconCodeberg() string {
 	return colorCyan + iconCodeberg + colorReset // Mountain in cyan
 }
 
+func IconGitea() string {
+	if !shouldUseColors() {
+		return textGitea
+	}
+	return colorGreen + iconGitea + colorReset
+}
+
 func IconGem() string {
 	if !shouldUseColors() {
 		return textGem
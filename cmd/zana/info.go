@@ -1,10 +1,19 @@
 package zana
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"os"
+	"sort"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/charmbracelet/glamour"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/providers"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
 	"github.com/spf13/cobra"
@@ -19,12 +28,28 @@ var infoCmd = &cobra.Command{
 For Tree-sitter-parser packages, also shows grammar languages, supported integrations,
 optional external query repositories, and registry requires (requires.all / requires.one).
 
+The registry bundles including the package are listed, and related packages
+are suggested: the other packages of those bundles, then packages for the
+same languages, tools of other categories (e.g. an LSP server for a linter) first.
+
+Several packages are first compared in a summary table (installed and
+registry version, provider, status); --summary-only prints just that table.
+
+--readme shows the package's README instead, read from the installed
+content or, when the package is not installed, fetched from upstream.
+
+--as-installed shows the registry entry an installed package was installed
+or updated from, and which of its fields changed in the registry since.
+
 Examples:
   zana info npm:eslint
   zana info pypi:black
   zana info golang:golang.org/x/tools/gopls
   zana info eslint (will prompt for provider selection if multiple matches)
-  zana info npm:eslint pypi:black`,
+  zana info npm:eslint pypi:black
+  zana info --summary-only npm:eslint pypi:black npm:prettier
+  zana info --readme npm:prettier
+  zana info --as-installed github:BurntSushi/ripgrep`,
 	Args: cobra.MinimumNArgs(1),
 	// Enable shell completion for package IDs based on the local registry.
 	ValidArgsFunction: packageIDCompletion,
@@ -41,7 +66,7 @@ Examples:
 			baseID, _ := parsePackageIDAndVersion(userPkgID)
 
 			// Check if this is a package name without provider
-			if !strings.Contains(baseID, ":") && !strings.HasPrefix(baseID, "pkg:") {
+			if !packageid.HasProvider(baseID) {
 				// Package name without provider - search registry and prompt user
 				matches := findPackagesByName(baseID)
 				if len(matches) == 0 {
@@ -54,35 +79,7 @@ Examples:
 				}
 
 				// Filter matches to exact package name or alias matches first (for better UX)
-				exactMatches := []PackageMatch{}
-				partialMatches := []PackageMatch{}
-				baseIDLower := strings.ToLower(baseID)
-				parserForExactMatch := newRegistryParser()
-
-				for _, match := range matches {
-					matchNameLower := strings.ToLower(match.PackageName)
-					// Check if package name matches exactly
-					isExactMatch := matchNameLower == baseIDLower
-
-					// Also check if any alias matches exactly
-					if !isExactMatch {
-						registryItem := parserForExactMatch.GetBySourceId(match.SourceID)
-						if registryItem.Source.ID != "" {
-							for _, alias := range registryItem.Aliases {
-								if strings.ToLower(alias) == baseIDLower {
-									isExactMatch = true
-									break
-								}
-							}
-						}
-					}
-
-					if isExactMatch {
-						exactMatches = append(exactMatches, match)
-					} else {
-						partialMatches = append(partialMatches, match)
-					}
-				}
+				exactMatches, partialMatches := splitExactMatches(baseID, matches)
 
 				// Use exact matches if available, otherwise use partial matches
 				matchesToShow := exactMatches
@@ -119,6 +116,26 @@ Examples:
 			}
 		}
 
+		if infoReadme {
+			displayPackageReadmes(packagesToShow)
+			return
+		}
+
+		if infoAsInstalled {
+			displayPackagesAsInstalled(parser, packagesToShow)
+			return
+		}
+
+		if infoSummaryOnly {
+			summary := buildInfoSummary(parser, packagesToShow)
+			if ShouldUseJSONOutput() {
+				PrintJSON(summary)
+			} else {
+				displayInfoSummary(summary)
+			}
+			return
+		}
+
 		// Display info for each package
 		if ShouldUseJSONOutput() {
 			// Collect all packages for JSON output
@@ -128,7 +145,7 @@ Examples:
 				if item.Source.ID == "" {
 					continue
 				}
-				packagesInfo = append(packagesInfo, buildPackageInfoJSON(item, sourceID))
+				packagesInfo = append(packagesInfo, buildPackageInfoJSON(item, sourceID, collectInfoDiscovery(parser, item, sourceID)))
 			}
 			if len(packagesInfo) == 1 {
 				PrintJSON(packagesInfo[0])
@@ -136,8 +153,11 @@ Examples:
 				PrintJSON(packagesInfo)
 			}
 		} else {
+			if len(packagesToShow) > 1 {
+				displayInfoSummary(buildInfoSummary(parser, packagesToShow))
+			}
 			for i, sourceID := range packagesToShow {
-				if i > 0 {
+				if i > 0 || len(packagesToShow) > 1 {
 					fmt.Println() // Add spacing between multiple packages
 				}
 
@@ -151,23 +171,310 @@ Examples:
 					continue
 				}
 
-				displayPackageInfo(item, sourceID)
+				displayPackageInfo(item, sourceID, collectInfoDiscovery(parser, item, sourceID))
 			}
 		}
 	},
 }
 
+var infoSummaryOnly bool
+var infoReadme bool
+var infoAsInstalled bool
+
+// indirections for testability
+var packageReadmeFn = providers.PackageReadme
+
+func init() {
+	infoCmd.Flags().BoolVar(&infoSummaryOnly, "summary-only", false, "only print the summary table")
+	infoCmd.Flags().BoolVar(&infoReadme, "readme", false, "show the package's README")
+	infoCmd.Flags().BoolVar(&infoAsInstalled, "as-installed", false, "show the registry entry the package was installed from")
+}
+
+// infoReadmeResult is the README of a package as printed with --readme
+type infoReadmeResult struct {
+	SchemaVersion jsonSchemaVersion `json:"schema_version"`
+	PackageID     string            `json:"package_id"`
+	Source        string            `json:"source,omitempty"`
+	Readme        string            `json:"readme,omitempty"`
+	Error         string            `json:"error,omitempty"`
+}
+
+// displayPackageReadmes prints the README of each package, rendered as
+// markdown in rich output and as-is in plain output
+func displayPackageReadmes(sourceIDs []string) {
+	results := make([]infoReadmeResult, 0, len(sourceIDs))
+	for _, sourceID := range sourceIDs {
+		result := infoReadmeResult{PackageID: sourceID}
+		if content, origin, err := packageReadmeFn(sourceID); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Source, result.Readme = origin, content
+		}
+		results = append(results, result)
+	}
+
+	if ShouldUseJSONOutput() {
+		if len(results) == 1 {
+			PrintJSON(results[0])
+		} else {
+			PrintJSON(results)
+		}
+		return
+	}
+
+	var stream *markdownStream
+	for i, result := range results {
+		if i > 0 {
+			fmt.Println()
+		}
+		if result.Error != "" {
+			if ShouldUsePlainOutput() {
+				fmt.Printf("[✗] No README for '%s': %s\n", result.PackageID, result.Error)
+			} else {
+				fmt.Printf("%s No README for '%s': %s\n", IconClose(), result.PackageID, result.Error)
+			}
+			continue
+		}
+		if ShouldUsePlainOutput() {
+			fmt.Printf("==> %s (%s)\n\n", result.PackageID, result.Source)
+			fmt.Println(strings.TrimRight(result.Readme, "\n"))
+			continue
+		}
+		fmt.Printf("%s %s (%s)\n", IconBook(), result.PackageID, result.Source)
+		if stream == nil {
+			stream = newMarkdownStream(os.Stdout)
+		}
+		stream.render(result.Readme)
+	}
+}
+
+// infoAsInstalledResult is the registry entry of an installed package as
+// printed with --as-installed
+type infoAsInstalledResult struct {
+	SchemaVersion jsonSchemaVersion `json:"schema_version"`
+	PackageID     string            `json:"package_id"`
+	Version       string            `json:"version,omitempty"`
+	RegistryEntry json.RawMessage   `json:"registry_entry,omitempty"`
+	// Changed lists the fields of the entry that differ in the registry now
+	Changed             []string `json:"changed,omitempty"`
+	RemovedFromRegistry bool     `json:"removed_from_registry,omitempty"`
+	Error               string   `json:"error,omitempty"`
+}
+
+// buildAsInstalled returns the registry entries the packages were installed from
+func buildAsInstalled(parser *registry_parser.RegistryParser, sourceIDs []string) []infoAsInstalledResult {
+	installed := map[string]local_packages_parser.LocalPackageItem{}
+	for _, pkg := range newLocalPackagesParserFn().Packages {
+		installed[pkg.SourceID] = pkg
+	}
+	results := make([]infoAsInstalledResult, 0, len(sourceIDs))
+	for _, sourceID := range sourceIDs {
+		result := infoAsInstalledResult{PackageID: sourceID}
+		pkg, ok := installed[sourceID]
+		switch {
+		case !ok:
+			result.Error = "not installed"
+		case pkg.Extras == nil || len(pkg.Extras.RegistryEntry) == 0:
+			result.Version = pkg.Version
+			result.Error = "no registry entry recorded, it is recorded on the next install or update"
+		default:
+			result.Version = pkg.Version
+			result.RegistryEntry = pkg.Extras.RegistryEntry
+			item := parser.GetBySourceId(sourceID)
+			if item.Source.ID == "" {
+				result.RemovedFromRegistry = true
+			} else if current, err := json.Marshal(item); err == nil {
+				result.Changed = changedEntryFields(pkg.Extras.RegistryEntry, current)
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// changedEntryFields returns the top-level fields that differ between two
+// registry entries, sorted by name
+func changedEntryFields(recorded, current json.RawMessage) []string {
+	var before, after map[string]json.RawMessage
+	if json.Unmarshal(recorded, &before) != nil || json.Unmarshal(current, &after) != nil {
+		return nil
+	}
+	var changed []string
+	for key, value := range after {
+		if !jsonEqual(before[key], value) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// jsonEqual compares two JSON values regardless of their formatting
+func jsonEqual(a, b json.RawMessage) bool {
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+// displayPackagesAsInstalled prints the registry entry each package was
+// installed from
+func displayPackagesAsInstalled(parser *registry_parser.RegistryParser, sourceIDs []string) {
+	results := buildAsInstalled(parser, sourceIDs)
+	if ShouldUseJSONOutput() {
+		if len(results) == 1 {
+			PrintJSON(results[0])
+		} else {
+			PrintJSON(results)
+		}
+		return
+	}
+
+	for i, result := range results {
+		if i > 0 {
+			fmt.Println()
+		}
+		if result.Error != "" {
+			if ShouldUsePlainOutput() {
+				fmt.Printf("[✗] %s: %s\n", result.PackageID, result.Error)
+			} else {
+				fmt.Printf("%s %s: %s\n", IconClose(), result.PackageID, result.Error)
+			}
+			continue
+		}
+		if ShouldUsePlainOutput() {
+			fmt.Printf("==> %s %s (registry entry as installed)\n", result.PackageID, result.Version)
+		} else {
+			fmt.Printf("%s %s %s (registry entry as installed)\n", IconBook(), result.PackageID, result.Version)
+		}
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, result.RegistryEntry, "", "  "); err != nil {
+			indented.Write(result.RegistryEntry)
+		}
+		fmt.Println(indented.String())
+		switch {
+		case result.RemovedFromRegistry:
+			fmt.Println("The package is no longer in the registry.")
+		case len(result.Changed) > 0:
+			fmt.Printf("Changed in the registry since: %s\n", strings.Join(result.Changed, ", "))
+		default:
+			fmt.Println("Unchanged in the registry since.")
+		}
+	}
+}
+
+// Status values of an info summary row
+const (
+	infoStatusUpToDate     = "up_to_date"
+	infoStatusOutdated     = "outdated"
+	infoStatusNotInstalled = "not_installed"
+	infoStatusNotFound     = "not_in_registry"
+)
+
+// infoSummaryRow compares a package's installed version with the registry
+type infoSummaryRow struct {
+	SchemaVersion    jsonSchemaVersion `json:"schema_version"`
+	PackageID        string            `json:"package_id"`
+	Provider         string            `json:"provider"`
+	InstalledVersion string            `json:"installed_version,omitempty"`
+	RegistryVersion  string            `json:"registry_version,omitempty"`
+	Status           string            `json:"status"`
+}
+
+// buildInfoSummary returns a summary row for each of sourceIDs
+func buildInfoSummary(parser *registry_parser.RegistryParser, sourceIDs []string) []infoSummaryRow {
+	installed := map[string]string{}
+	for _, pkg := range newLocalPackagesParserFn().Packages {
+		installed[pkg.SourceID] = pkg.Version
+	}
+	rows := make([]infoSummaryRow, 0, len(sourceIDs))
+	for _, sourceID := range sourceIDs {
+		provider, _, _ := strings.Cut(sourceID, ":")
+		row := infoSummaryRow{PackageID: sourceID, Provider: provider}
+		item := parser.GetBySourceId(sourceID)
+		row.RegistryVersion = item.Version
+		version, isInstalled := installed[sourceID]
+		row.InstalledVersion = version
+		switch {
+		case item.Source.ID == "":
+			row.Status = infoStatusNotFound
+		case !isInstalled:
+			row.Status = infoStatusNotInstalled
+		default:
+			row.Status = infoStatusUpToDate
+			if available, _ := providers.CheckIfUpdateIsAvailable(version, item.Version); available {
+				row.Status = infoStatusOutdated
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// infoStatusLabel is the human readable status of a summary row
+func infoStatusLabel(status string) string {
+	switch status {
+	case infoStatusUpToDate:
+		return "up to date"
+	case infoStatusOutdated:
+		return "update available"
+	case infoStatusNotInstalled:
+		return "not installed"
+	default:
+		return "not in registry"
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// displayInfoSummary renders the summary table based on output mode
+func displayInfoSummary(rows []infoSummaryRow) {
+	if ShouldUsePlainOutput() {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PACKAGE\tPROVIDER\tINSTALLED\tREGISTRY\tSTATUS")
+		for _, r := range rows {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.PackageID, r.Provider, orDash(r.InstalledVersion), orDash(r.RegistryVersion), infoStatusLabel(r.Status))
+		}
+		_ = w.Flush()
+		return
+	}
+
+	var markdown strings.Builder
+	markdown.WriteString("| Package | Provider | Installed | Registry | Status |\n")
+	markdown.WriteString("|---------|----------|-----------|----------|--------|\n")
+	for _, r := range rows {
+		markdown.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s | %s |\n", r.PackageID, r.Provider, orDash(r.InstalledVersion), orDash(r.RegistryVersion), infoStatusLabel(r.Status)))
+	}
+	rendered, err := glamour.Render(markdown.String(), "dark")
+	if err != nil {
+		fmt.Println(markdown.String())
+		return
+	}
+	fmt.Print(rendered)
+}
+
 // displayPackageInfo renders package information based on output mode
-func displayPackageInfo(item registry_parser.RegistryItem, sourceID string) {
+func displayPackageInfo(item registry_parser.RegistryItem, sourceID string, discovery infoDiscovery) {
 	if ShouldUsePlainOutput() {
-		displayPackageInfoPlain(item, sourceID)
+		displayPackageInfoPlain(item, sourceID, discovery)
 	} else {
-		displayPackageInfoRich(item, sourceID)
+		displayPackageInfoRich(item, sourceID, discovery)
 	}
 }
 
 // displayPackageInfoRich renders package information as markdown using glamour
-func displayPackageInfoRich(item registry_parser.RegistryItem, sourceID string) {
+func displayPackageInfoRich(item registry_parser.RegistryItem, sourceID string, discovery infoDiscovery) {
 	// Build markdown content
 	var markdown strings.Builder
 
@@ -175,6 +482,11 @@ func displayPackageInfoRich(item registry_parser.RegistryItem, sourceID string)
 	markdown.WriteString(fmt.Sprintf("# %s\n\n", item.Name))
 	markdown.WriteString(fmt.Sprintf("**Package ID:** `%s`\n\n", sourceID))
 
+	// Deprecation
+	if item.Deprecated != nil {
+		markdown.WriteString(fmt.Sprintf("> ⚠️ **Deprecated:** %s\n\n", deprecationMessage(sourceID, item.Deprecated)))
+	}
+
 	// Aliases
 	if len(item.Aliases) > 0 {
 		markdown.WriteString(fmt.Sprintf("**Aliases:** %s\n\n", strings.Join(item.Aliases, ", ")))
@@ -184,6 +496,9 @@ func displayPackageInfoRich(item registry_parser.RegistryItem, sourceID string)
 	if item.Version != "" {
 		markdown.WriteString(fmt.Sprintf("**Version:** `%s`\n\n", item.Version))
 	}
+	if item.PrereleaseVersion != "" {
+		markdown.WriteString(fmt.Sprintf("**Prerelease:** `%s`\n\n", item.PrereleaseVersion))
+	}
 
 	// Description
 	if item.Description != "" {
@@ -195,6 +510,11 @@ func displayPackageInfoRich(item registry_parser.RegistryItem, sourceID string)
 		markdown.WriteString(fmt.Sprintf("**Homepage:** %s\n\n", item.Homepage))
 	}
 
+	// Repository
+	if repoURL := providers.RepositoryURL(sourceID); repoURL != "" {
+		markdown.WriteString(fmt.Sprintf("**Repository:** %s\n\n", repoURL))
+	}
+
 	// Provider
 	if strings.Contains(sourceID, ":") {
 		parts := strings.SplitN(sourceID, ":", 2)
@@ -237,6 +557,9 @@ func displayPackageInfoRich(item registry_parser.RegistryItem, sourceID string)
 		} else {
 			markdown.WriteString("**Status:** ✅ Installed\n\n")
 		}
+		if installedAt, ok := lastInstalledAt(sourceID, installedVersion); ok {
+			markdown.WriteString(fmt.Sprintf("**Installed:** %s\n\n", formatWhen(installedAt)))
+		}
 	} else {
 		markdown.WriteString("**Status:** ⬜ Not installed\n\n")
 	}
@@ -244,8 +567,8 @@ func displayPackageInfoRich(item registry_parser.RegistryItem, sourceID string)
 	// Binaries
 	if len(item.Bin) > 0 {
 		markdown.WriteString("## Binaries\n\n")
-		for binName, binPath := range item.Bin {
-			markdown.WriteString(fmt.Sprintf("- **%s:** `%s`\n", binName, binPath))
+		for _, binName := range sortedBinNames(item.Bin) {
+			markdown.WriteString(fmt.Sprintf("- **%s:** `%s`\n", binName, item.Bin[binName]))
 		}
 		markdown.WriteString("\n")
 	}
@@ -257,6 +580,7 @@ func displayPackageInfoRich(item registry_parser.RegistryItem, sourceID string)
 	if extra.TreeSitter != nil {
 		appendTreeSitterMarkdown(&markdown, extra.TreeSitter)
 	}
+	appendDiscoveryMarkdown(&markdown, discovery)
 
 	// Render markdown with glamour
 	rendered, err := glamour.Render(markdown.String(), "dark")
@@ -269,11 +593,45 @@ func displayPackageInfoRich(item registry_parser.RegistryItem, sourceID string)
 	fmt.Print(rendered)
 }
 
+// sortedBinNames returns the names of the bin entries in alphabetical order
+func sortedBinNames(bin map[string]string) []string {
+	names := make([]string, 0, len(bin))
+	for name := range bin {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lastInstalledAt returns when version of sourceID was last installed or
+// updated, according to the history log
+func lastInstalledAt(sourceID, version string) (time.Time, bool) {
+	history, err := readHistoryFn()
+	if err != nil {
+		return time.Time{}, false
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		entry := history[i]
+		if entry.SourceID != sourceID || !entry.Success || entry.Action == files.HistoryActionRemove {
+			continue
+		}
+		if version != "" && entry.Version != version {
+			continue
+		}
+		return entry.Time, true
+	}
+	return time.Time{}, false
+}
+
 // displayPackageInfoPlain renders package information as plain text
-func displayPackageInfoPlain(item registry_parser.RegistryItem, sourceID string) {
+func displayPackageInfoPlain(item registry_parser.RegistryItem, sourceID string, discovery infoDiscovery) {
 	fmt.Printf("Name: %s\n", item.Name)
 	fmt.Printf("Package ID: %s\n", sourceID)
 
+	if item.Deprecated != nil {
+		fmt.Printf("Deprecated: %s\n", deprecationMessage(sourceID, item.Deprecated))
+	}
+
 	if len(item.Aliases) > 0 {
 		fmt.Printf("Aliases: %s\n", strings.Join(item.Aliases, ", "))
 	}
@@ -281,6 +639,9 @@ func displayPackageInfoPlain(item registry_parser.RegistryItem, sourceID string)
 	if item.Version != "" {
 		fmt.Printf("Version: %s\n", item.Version)
 	}
+	if item.PrereleaseVersion != "" {
+		fmt.Printf("Prerelease: %s\n", item.PrereleaseVersion)
+	}
 
 	if item.Description != "" {
 		fmt.Printf("Description: %s\n", item.Description)
@@ -290,6 +651,10 @@ func displayPackageInfoPlain(item registry_parser.RegistryItem, sourceID string)
 		fmt.Printf("Homepage: %s\n", item.Homepage)
 	}
 
+	if repoURL := providers.RepositoryURL(sourceID); repoURL != "" {
+		fmt.Printf("Repository: %s\n", repoURL)
+	}
+
 	if strings.Contains(sourceID, ":") {
 		parts := strings.SplitN(sourceID, ":", 2)
 		if len(parts) == 2 {
@@ -328,14 +693,17 @@ func displayPackageInfoPlain(item registry_parser.RegistryItem, sourceID string)
 		} else {
 			fmt.Printf("Status: Installed\n")
 		}
+		if installedAt, ok := lastInstalledAt(sourceID, installedVersion); ok {
+			fmt.Printf("Installed: %s\n", formatWhen(installedAt))
+		}
 	} else {
 		fmt.Printf("Status: Not installed\n")
 	}
 
 	if len(item.Bin) > 0 {
 		fmt.Printf("Binaries:\n")
-		for binName, binPath := range item.Bin {
-			fmt.Printf("  %s: %s\n", binName, binPath)
+		for _, binName := range sortedBinNames(item.Bin) {
+			fmt.Printf("  %s: %s\n", binName, item.Bin[binName])
 		}
 	}
 
@@ -350,14 +718,22 @@ func displayPackageInfoPlain(item registry_parser.RegistryItem, sourceID string)
 		appendTreeSitterPlain(&b, extra.TreeSitter)
 		fmt.Print(b.String())
 	}
+	var b strings.Builder
+	appendDiscoveryPlain(&b, discovery)
+	fmt.Print(b.String())
 }
 
 // buildPackageInfoJSON builds a JSON representation of package info
-func buildPackageInfoJSON(item registry_parser.RegistryItem, sourceID string) map[string]interface{} {
+func buildPackageInfoJSON(item registry_parser.RegistryItem, sourceID string, discovery infoDiscovery) map[string]interface{} {
 	result := make(map[string]interface{})
+	result["schema_version"] = JSONSchemaVersion
 	result["name"] = item.Name
 	result["package_id"] = sourceID
 
+	if item.Deprecated != nil {
+		result["deprecated"] = item.Deprecated
+	}
+
 	if len(item.Aliases) > 0 {
 		result["aliases"] = item.Aliases
 	}
@@ -365,6 +741,9 @@ func buildPackageInfoJSON(item registry_parser.RegistryItem, sourceID string) ma
 	if item.Version != "" {
 		result["version"] = item.Version
 	}
+	if item.PrereleaseVersion != "" {
+		result["prerelease_version"] = item.PrereleaseVersion
+	}
 
 	if item.Description != "" {
 		result["description"] = item.Description
@@ -374,6 +753,10 @@ func buildPackageInfoJSON(item registry_parser.RegistryItem, sourceID string) ma
 		result["homepage"] = item.Homepage
 	}
 
+	if repoURL := providers.RepositoryURL(sourceID); repoURL != "" {
+		result["repository"] = repoURL
+	}
+
 	if strings.Contains(sourceID, ":") {
 		parts := strings.SplitN(sourceID, ":", 2)
 		if len(parts) == 2 {
@@ -412,6 +795,9 @@ func buildPackageInfoJSON(item registry_parser.RegistryItem, sourceID string) ma
 		if installedVersion != "" {
 			result["installed_version"] = installedVersion
 		}
+		if installedAt, ok := lastInstalledAt(sourceID, installedVersion); ok {
+			result["installed_at"] = displayTime(installedAt)
+		}
 	}
 	result["status"] = status
 
@@ -420,6 +806,7 @@ func buildPackageInfoJSON(item registry_parser.RegistryItem, sourceID string) ma
 	}
 
 	mergeExtraDetailsJSON(result, collectPackageExtraDetails(item))
+	mergeDiscoveryJSON(result, discovery)
 
 	return result
 }
@@ -0,0 +1,176 @@
+package zana
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// infoMaxRelated is how many related packages zana info suggests at most
+const infoMaxRelated = 5
+
+type infoBundle struct {
+	Name      string `json:"name"`
+	PackageID string `json:"package_id"`
+}
+
+type infoRelatedPackage struct {
+	PackageID string `json:"package_id"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason"`
+	Installed bool   `json:"installed"`
+}
+
+// infoDiscovery is what zana info shows to explore the registry from a
+// package: the bundles including it and packages used with it
+type infoDiscovery struct {
+	Bundles []infoBundle
+	Related []infoRelatedPackage
+}
+
+func collectInfoDiscovery(parser *registry_parser.RegistryParser, item registry_parser.RegistryItem, sourceID string) infoDiscovery {
+	var d infoDiscovery
+	// The other packages of its bundles are the best suggestions
+	bundleOf := map[string]string{}
+	for _, bundle := range parser.GetBundlesIncluding(sourceID) {
+		d.Bundles = append(d.Bundles, infoBundle{Name: bundle.Name, PackageID: normalizeInfoPackageRef(bundle.Source.ID)})
+		for _, ref := range bundle.Includes {
+			if id := normalizeInfoPackageRef(ref); id != sourceID {
+				if _, ok := bundleOf[id]; !ok {
+					bundleOf[id] = bundle.Name
+				}
+			}
+		}
+	}
+	d.Related = relatedPackages(parser.GetData(false), item, sourceID, bundleOf)
+	return d
+}
+
+// relatedPackages suggests packages in the same bundles as the package,
+// then packages for the same languages. Among those, tools of other
+// categories come first: they complement the package (e.g. ruff-lsp for
+// ruff), while tools of the same categories are alternatives to it.
+func relatedPackages(registry registry_parser.RegistryRoot, item registry_parser.RegistryItem, sourceID string, bundleOf map[string]string) []infoRelatedPackage {
+	languages := lowerSet(item.Languages)
+	categories := lowerSet(item.Categories)
+
+	type candidate struct {
+		related infoRelatedPackage
+		score   int
+	}
+	var candidates []candidate
+	for _, other := range registry {
+		id := normalizeInfoPackageRef(other.Source.ID)
+		if id == "" || id == sourceID || len(other.Includes) > 0 || other.IsDataOnly() {
+			continue
+		}
+		var shared []string
+		for _, lang := range other.Languages {
+			if _, ok := languages[strings.ToLower(lang)]; ok {
+				shared = append(shared, lang)
+			}
+		}
+		bundle, inBundle := bundleOf[id]
+		if !inBundle && len(shared) == 0 {
+			continue
+		}
+
+		score := len(shared)
+		reason := strings.TrimSpace(strings.Join(shared, ", ") + " " + strings.Join(other.Categories, ", "))
+		if inBundle {
+			score += 100
+			reason = fmt.Sprintf("in the %s bundle", bundle)
+		}
+		if len(other.Categories) > 0 && !sharesAny(other.Categories, categories) {
+			score += 10
+		}
+		candidates = append(candidates, candidate{
+			related: infoRelatedPackage{PackageID: id, Name: other.Name, Reason: reason, Installed: packageIsInstalled(id)},
+			score:   score,
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].related.PackageID < candidates[j].related.PackageID
+	})
+	var related []infoRelatedPackage
+	for _, c := range candidates {
+		if len(related) == infoMaxRelated {
+			break
+		}
+		related = append(related, c.related)
+	}
+	return related
+}
+
+func lowerSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[strings.ToLower(v)] = struct{}{}
+	}
+	return set
+}
+
+func sharesAny(values []string, set map[string]struct{}) bool {
+	for _, v := range values {
+		if _, ok := set[strings.ToLower(v)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func bundleNames(bundles []infoBundle) []string {
+	names := make([]string, 0, len(bundles))
+	for _, b := range bundles {
+		names = append(names, b.Name)
+	}
+	return names
+}
+
+func appendDiscoveryPlain(b *strings.Builder, d infoDiscovery) {
+	if len(d.Bundles) > 0 {
+		b.WriteString(fmt.Sprintf("Bundles: %s\n", strings.Join(bundleNames(d.Bundles), ", ")))
+	}
+	if len(d.Related) > 0 {
+		b.WriteString("Related packages:\n")
+		for _, r := range d.Related {
+			mark := ""
+			if r.Installed {
+				mark = " [installed]"
+			}
+			b.WriteString(fmt.Sprintf("  %s (%s)%s\n", r.PackageID, r.Reason, mark))
+		}
+	}
+}
+
+func appendDiscoveryMarkdown(b *strings.Builder, d infoDiscovery) {
+	if len(d.Bundles) > 0 {
+		b.WriteString(fmt.Sprintf("**Bundles:** %s\n\n", strings.Join(bundleNames(d.Bundles), ", ")))
+	}
+	if len(d.Related) > 0 {
+		b.WriteString("## Related packages\n\n")
+		for _, r := range d.Related {
+			mark := ""
+			if r.Installed {
+				mark = " ✅"
+			}
+			b.WriteString(fmt.Sprintf("- `%s` (%s)%s\n", r.PackageID, r.Reason, mark))
+		}
+		b.WriteString("\n")
+	}
+}
+
+func mergeDiscoveryJSON(result map[string]interface{}, d infoDiscovery) {
+	if len(d.Bundles) > 0 {
+		result["bundles"] = d.Bundles
+	}
+	if len(d.Related) > 0 {
+		result["related"] = d.Related
+	}
+}
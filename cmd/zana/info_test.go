@@ -0,0 +1,174 @@
+package zana
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubInfo(t *testing.T) {
+	t.Helper()
+	prevParser, prevLocal, prevDownload, prevSummary := newRegistryParser, newLocalPackagesParserFn, downloadAndUnzipRegistryFn, infoSummaryOnly
+	t.Cleanup(func() {
+		newRegistryParser, newLocalPackagesParserFn, downloadAndUnzipRegistryFn, infoSummaryOnly = prevParser, prevLocal, prevDownload, prevSummary
+	})
+
+	newRegistryParser = func() *registry_parser.RegistryParser {
+		rp := registry_parser.NewRegistryParser(nil)
+		require.NoError(t, rp.LoadFromBytes([]byte(`[
+			{"name": "prettier", "version": "3.3.0", "source": {"id": "npm:prettier"}},
+			{"name": "black", "version": "24.2.0", "source": {"id": "pypi:black"}},
+			{"name": "eslint", "version": "9.0.0", "source": {"id": "npm:eslint"}}
+		]`)))
+		return rp
+	}
+	newLocalPackagesParserFn = func() local_packages_parser.LocalPackageRoot {
+		return local_packages_parser.LocalPackageRoot{Packages: []local_packages_parser.LocalPackageItem{
+			{SourceID: "npm:prettier", Version: "3.3.0"},
+			{SourceID: "pypi:black", Version: "24.1.0"},
+		}}
+	}
+	downloadAndUnzipRegistryFn = func() error { return nil }
+	infoSummaryOnly = false
+}
+
+func TestBuildInfoSummary(t *testing.T) {
+	stubInfo(t)
+	rows := buildInfoSummary(newRegistryParser(), []string{"npm:prettier", "pypi:black", "npm:eslint", "npm:nope"})
+	assert.Equal(t, []infoSummaryRow{
+		{PackageID: "npm:prettier", Provider: "npm", InstalledVersion: "3.3.0", RegistryVersion: "3.3.0", Status: infoStatusUpToDate},
+		{PackageID: "pypi:black", Provider: "pypi", InstalledVersion: "24.1.0", RegistryVersion: "24.2.0", Status: infoStatusOutdated},
+		{PackageID: "npm:eslint", Provider: "npm", RegistryVersion: "9.0.0", Status: infoStatusNotInstalled},
+		{PackageID: "npm:nope", Provider: "npm", Status: infoStatusNotFound},
+	}, rows)
+}
+
+func TestInfoCommandSummary(t *testing.T) {
+	t.Run("summary precedes the details of several packages", func(t *testing.T) {
+		stubInfo(t)
+		out := captureOutput(t, func() { infoCmd.Run(infoCmd, []string{"npm:prettier", "pypi:black"}) })
+		assert.Contains(t, out, "PACKAGE")
+		assert.Contains(t, out, "update available")
+		assert.Less(t, strings.Index(out, "update available"), strings.Index(out, "Name: prettier"))
+		assert.Contains(t, out, "Name: black")
+	})
+
+	t.Run("summary only", func(t *testing.T) {
+		stubInfo(t)
+		infoSummaryOnly = true
+		out := captureOutput(t, func() { infoCmd.Run(infoCmd, []string{"npm:prettier", "npm:eslint"}) })
+		assert.Contains(t, out, "not installed")
+		assert.NotContains(t, out, "Name: prettier")
+
+		out = captureOutputWithMode(t, func() { infoCmd.Run(infoCmd, []string{"npm:eslint"}) }, config.OutputModeJSON)
+		assert.JSONEq(t, `[{"schema_version": 1, "package_id": "npm:eslint", "provider": "npm", "registry_version": "9.0.0", "status": "not_installed"}]`, out)
+	})
+}
+
+func TestInfoCommandReadme(t *testing.T) {
+	stubInfo(t)
+	prevReadme, prevFn := infoReadme, packageReadmeFn
+	t.Cleanup(func() { infoReadme, packageReadmeFn = prevReadme, prevFn })
+	infoReadme = true
+	packageReadmeFn = func(sourceID string) (string, string, error) {
+		if sourceID == "npm:prettier" {
+			return "# Prettier\n", "/data/npm/node_modules/prettier/README.md", nil
+		}
+		return "", "", providers.ErrReadmeNotFound
+	}
+
+	out := captureOutput(t, func() { infoCmd.Run(infoCmd, []string{"npm:prettier", "pypi:black"}) })
+	assert.Contains(t, out, "==> npm:prettier (/data/npm/node_modules/prettier/README.md)\n\n# Prettier\n")
+	assert.Contains(t, out, "[✗] No README for 'pypi:black': no README found")
+	assert.NotContains(t, out, "Name: prettier")
+
+	out = captureOutputWithMode(t, func() { infoCmd.Run(infoCmd, []string{"npm:prettier"}) }, config.OutputModeJSON)
+	assert.JSONEq(t, `{"schema_version": 1, "package_id": "npm:prettier", "source": "/data/npm/node_modules/prettier/README.md", "readme": "# Prettier\n"}`, out)
+}
+
+func TestInfoCommandAsInstalled(t *testing.T) {
+	stubInfo(t)
+	prevAsInstalled := infoAsInstalled
+	t.Cleanup(func() { infoAsInstalled = prevAsInstalled })
+	infoAsInstalled = true
+	newLocalPackagesParserFn = func() local_packages_parser.LocalPackageRoot {
+		return local_packages_parser.LocalPackageRoot{Packages: []local_packages_parser.LocalPackageItem{
+			{SourceID: "npm:prettier", Version: "3.3.0", Extras: &local_packages_parser.PackageExtras{
+				RegistryEntry: json.RawMessage(`{"name":"prettier","version":"3.3.0","description":"","homepage":"","licenses":null,"languages":null,"categories":null,"source":{"id":"npm:prettier"},"bin":{"prettier":"old"}}`),
+			}},
+			{SourceID: "pypi:black", Version: "24.1.0"},
+		}}
+	}
+
+	out := captureOutput(t, func() { infoCmd.Run(infoCmd, []string{"npm:prettier", "pypi:black", "npm:eslint"}) })
+	assert.Contains(t, out, "==> npm:prettier 3.3.0 (registry entry as installed)\n{\n  \"name\": \"prettier\"")
+	assert.Contains(t, out, `"prettier": "old"`)
+	assert.Contains(t, out, "Changed in the registry since: bin\n")
+	assert.Contains(t, out, "[✗] pypi:black: no registry entry recorded")
+	assert.Contains(t, out, "[✗] npm:eslint: not installed")
+
+	out = captureOutputWithMode(t, func() { infoCmd.Run(infoCmd, []string{"npm:prettier"}) }, config.OutputModeJSON)
+	var result infoAsInstalledResult
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	assert.Equal(t, "3.3.0", result.Version)
+	assert.Equal(t, []string{"bin"}, result.Changed)
+}
+
+func TestInfoDiscovery(t *testing.T) {
+	stubInfo(t)
+	prevInstalled := packageIsInstalled
+	t.Cleanup(func() { packageIsInstalled = prevInstalled })
+	packageIsInstalled = func(id string) bool { return id == "pypi:ruff-lsp" }
+
+	parser := registry_parser.NewRegistryParser(nil)
+	require.NoError(t, parser.LoadFromBytes([]byte(`[
+		{"name": "ruff", "languages": ["Python"], "categories": ["Linter", "Formatter"], "source": {"id": "pypi:ruff"}},
+		{"name": "ruff-lsp", "languages": ["Python"], "categories": ["LSP"], "source": {"id": "pypi:ruff-lsp"}},
+		{"name": "black", "languages": ["Python"], "categories": ["Formatter"], "source": {"id": "pypi:black"}},
+		{"name": "mypy", "languages": ["Python"], "categories": ["Linter"], "source": {"id": "pypi:mypy"}},
+		{"name": "debugpy", "languages": ["Python"], "categories": ["DAP"], "source": {"id": "pypi:debugpy"}},
+		{"name": "prettier", "languages": ["JavaScript"], "categories": ["Formatter"], "source": {"id": "npm:prettier"}},
+		{"name": "python-dev", "source": {"id": "github:zana/python-dev"}, "includes": ["pypi:ruff", "pypi:mypy"]}
+	]`)))
+
+	d := collectInfoDiscovery(parser, parser.GetBySourceId("pypi:ruff"), "pypi:ruff")
+	assert.Equal(t, []infoBundle{{Name: "python-dev", PackageID: "github:zana/python-dev"}}, d.Bundles)
+	assert.Equal(t, []infoRelatedPackage{
+		{PackageID: "pypi:mypy", Name: "mypy", Reason: "in the python-dev bundle"},
+		{PackageID: "pypi:debugpy", Name: "debugpy", Reason: "Python DAP"},
+		{PackageID: "pypi:ruff-lsp", Name: "ruff-lsp", Reason: "Python LSP", Installed: true},
+		{PackageID: "pypi:black", Name: "black", Reason: "Python Formatter"},
+	}, d.Related)
+
+	var b strings.Builder
+	appendDiscoveryPlain(&b, d)
+	assert.Contains(t, b.String(), "Bundles: python-dev\n")
+	assert.Contains(t, b.String(), "  pypi:ruff-lsp (Python LSP) [installed]\n")
+
+	result := map[string]interface{}{}
+	mergeDiscoveryJSON(result, collectInfoDiscovery(parser, parser.GetBySourceId("npm:prettier"), "npm:prettier"))
+	assert.Empty(t, result, "nothing to show without bundles or packages for the same languages")
+}
+
+func TestDisplayPackageInfoVersionsAndBinaries(t *testing.T) {
+	stubInfo(t)
+	item := registry_parser.RegistryItem{
+		Name: "tool", Version: "1.2.0", PrereleaseVersion: "1.3.0-rc.1",
+		Source: registry_parser.RegistryItemSource{ID: "npm:tool"},
+		Bin:    map[string]string{"tool-b": "npm:tool-b", "tool-a": "npm:tool-a"},
+	}
+
+	out := captureOutputWithMode(t, func() { displayPackageInfo(item, "npm:tool", infoDiscovery{}) }, config.OutputModePlain)
+	assert.Contains(t, out, "Version: 1.2.0\nPrerelease: 1.3.0-rc.1\n")
+	assert.Contains(t, out, "Binaries:\n  tool-a: npm:tool-a\n  tool-b: npm:tool-b\n")
+
+	info := buildPackageInfoJSON(item, "npm:tool", infoDiscovery{})
+	assert.Equal(t, "1.3.0-rc.1", info["prerelease_version"])
+}
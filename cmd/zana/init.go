@@ -0,0 +1,278 @@
+package zana
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/huh"
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/migrations"
+	"github.com/mistweaverco/zana-client/internal/lib/spinnerutil"
+	"github.com/spf13/cobra"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Set up zana with a short wizard",
+	Long: `Set up zana step by step:
+
+  1. the data directory (ZANA_HOME), where packages and the lock file live
+  2. the PATH, adding "zana env" to your shell's profile (see zana env --install)
+  3. the default output style (ui.output in config.yaml)
+  4. importing the packages mason.nvim installed, in the same versions
+  5. downloading the registry
+
+On a terminal each step is asked for, the flags preset the answers.
+--yes takes them as they are, e.g. for provisioning scripts.
+The wizard is also offered the first time zana runs on a terminal.
+
+Examples:
+  zana init
+  zana init --yes --default-output plain --import-mason
+  zana init --yes --data-dir ~/tools/zana --no-path`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		answers := defaultInitAnswers()
+		if !initYes {
+			if ShouldUseJSONOutput() || !canPromptForSelection() {
+				fmt.Printf("%s Not setting up zana without a terminal to ask on, pass --yes to use the defaults\n", IconClose())
+				osExit(1)
+				return
+			}
+			if !askInitAnswersFn(&answers) {
+				fmt.Println("Nothing set up.")
+				return
+			}
+		}
+		result := runInit(answers)
+		if ShouldUseJSONOutput() {
+			PrintJSON(result)
+		}
+		if !result.Success {
+			osExit(1)
+		}
+	},
+}
+
+var (
+	initYes           bool
+	initDataDir       string
+	initDefaultOutput string
+	initNoPath        bool
+	initImportMason   bool
+)
+
+func init() {
+	initCmd.Flags().BoolVarP(&initYes, "yes", "y", false, "don't ask, use the flags and defaults")
+	initCmd.Flags().StringVar(&initDataDir, "data-dir", "", "data directory to use instead of the default (recorded as ZANA_HOME in the shell's profile)")
+	initCmd.Flags().StringVar(&initDefaultOutput, "default-output", "", "default output style to record in config.yaml: rich, plain or json")
+	initCmd.Flags().BoolVar(&initNoPath, "no-path", false, "don't add zana to the shell's profile")
+	initCmd.Flags().BoolVar(&initImportMason, "import-mason", false, "install the packages mason.nvim installed")
+}
+
+// Injectable helpers for tests
+var (
+	askInitAnswersFn        = askInitAnswers
+	changeEnvProfileFn      = changeEnvProfile
+	setFileConfigValueFn    = config.SetFileConfigValue
+	isFirstRunFn            = migrations.IsFirstRun
+	confirmFirstRunSetupFn  = confirmFirstRunSetup
+	offerFirstRunSetupFn    = offerFirstRunSetup
+	defaultAppDataPathFn    = files.GetAppDataPath
+	initRegistryDownloadFn  = func() error { return syncRegistryFn() }
+	initMasonPackagesFinder = func() []masonPackage { return findMasonPackagesFn(masonPackagesDirFn()) }
+)
+
+// initAnswers are the choices of the zana init wizard
+type initAnswers struct {
+	DataDir     string
+	SetupPath   bool
+	Shell       string
+	Output      string
+	ImportMason bool
+}
+
+// initResult is what zana init set up
+type initResult struct {
+	Success  bool     `json:"success"`
+	DataDir  string   `json:"data_dir"`
+	Profile  string   `json:"profile,omitempty"`
+	Output   string   `json:"output,omitempty"`
+	Registry bool     `json:"registry"`
+	Imported []string `json:"imported,omitempty"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// defaultInitAnswers returns the answers preset by the flags
+func defaultInitAnswers() initAnswers {
+	answers := initAnswers{
+		DataDir:     initDataDir,
+		SetupPath:   !initNoPath,
+		Shell:       defaultCompletionShell(),
+		Output:      initDefaultOutput,
+		ImportMason: initImportMason,
+	}
+	if answers.DataDir == "" {
+		answers.DataDir = defaultAppDataPathFn()
+	}
+	return answers
+}
+
+func askInitAnswers(answers *initAnswers) bool {
+	if answers.Output == "" {
+		answers.Output = string(config.OutputModeRich)
+	}
+	fields := []huh.Field{
+		huh.NewInput().
+			Title("Data directory").
+			Description("Where packages and the lock file live (ZANA_HOME)").
+			Value(&answers.DataDir),
+		huh.NewConfirm().
+			Title("Add zana to your PATH?").
+			Description(fmt.Sprintf("Runs \"zana env\" from the profile of %s", answers.Shell)).
+			Value(&answers.SetupPath),
+		huh.NewSelect[string]().
+			Title("Default output style").
+			Options(
+				huh.NewOption("rich (colors and icons)", string(config.OutputModeRich)),
+				huh.NewOption("plain (no colors or icons)", string(config.OutputModePlain)),
+				huh.NewOption("json (for scripts)", string(config.OutputModeJSON)),
+			).
+			Value(&answers.Output),
+	}
+	if pkgs := initMasonPackagesFinder(); len(pkgs) > 0 {
+		answers.ImportMason = true
+		fields = append(fields, huh.NewConfirm().
+			Title(fmt.Sprintf("Import the %d packages mason.nvim installed?", len(pkgs))).
+			Value(&answers.ImportMason))
+	}
+	if err := huh.NewForm(huh.NewGroup(fields...)).Run(); err != nil {
+		return false
+	}
+	return true
+}
+
+// runInit sets up zana as answered, step by step. Failed steps don't stop
+// the following ones.
+func runInit(answers initAnswers) initResult {
+	result := initResult{Success: true, DataDir: answers.DataDir}
+	fail := func(format string, args ...interface{}) {
+		msg := fmt.Sprintf(format, args...)
+		result.Success = false
+		result.Errors = append(result.Errors, msg)
+		if !ShouldUseJSONOutput() {
+			fmt.Printf("%s %s\n", IconClose(), msg)
+		}
+	}
+	say := func(format string, args ...interface{}) {
+		if !ShouldUseJSONOutput() {
+			fmt.Printf(format, args...)
+		}
+	}
+
+	// A data directory other than the default one is handed down as
+	// ZANA_HOME, for the rest of this run and in the shell's profile
+	zanaHome := ""
+	if answers.DataDir != defaultAppDataPathFn() {
+		if err := os.MkdirAll(answers.DataDir, 0755); err != nil {
+			fail("failed to create %s: %v", answers.DataDir, err)
+		} else {
+			zanaHome = answers.DataDir
+			_ = os.Setenv("ZANA_HOME", zanaHome)
+			say("%s Using %s as data directory\n", IconCheck(), zanaHome)
+		}
+	}
+
+	switch {
+	case answers.SetupPath:
+		profile, changed, err := changeEnvProfileFn(answers.Shell, true, zanaHome)
+		switch {
+		case err != nil:
+			fail("failed to add zana to your PATH: %v", err)
+		case changed:
+			result.Profile = profile
+			say("%s Added zana to %s, %s to use it\n", IconCheck(), profile, envRestartHint(answers.Shell))
+		default:
+			result.Profile = profile
+			say("%s %s already sets up zana\n", IconCheck(), profile)
+		}
+	case zanaHome != "":
+		say("%s Set ZANA_HOME=%s in your shell's profile to keep using it\n", IconLightbulb(), zanaHome)
+	}
+
+	if answers.Output != "" {
+		if err := setFileConfigValueFn("ui", "output", answers.Output); err != nil {
+			fail("failed to set ui.output in %s: %v", config.ConfigFilePath(), err)
+		} else {
+			result.Output = answers.Output
+			say("%s Set ui.output to %s in %s\n", IconCheck(), answers.Output, config.ConfigFilePath())
+		}
+	}
+
+	var registryErr error
+	if err := spinnerutil.Run("Downloading the registry...", func() { registryErr = initRegistryDownloadFn() }); err != nil {
+		registryErr = err
+	}
+	if registryErr != nil {
+		fail("failed to download the registry: %v", registryErr)
+	} else {
+		result.Registry = true
+		say("%s Downloaded the registry\n", IconCheck())
+	}
+
+	if answers.ImportMason && result.Registry {
+		summary := importMasonPackages(initMasonPackagesFinder())
+		summary.print(printfStdout)
+		result.Imported = summary.succeeded
+		if !summary.allSucceeded() {
+			result.Success = false
+		}
+	}
+
+	if result.Success {
+		say("%s zana is set up, add packages with 'zana add <pkgId>'\n", IconLightbulb())
+	}
+	return result
+}
+
+// offerFirstRunSetup offers the zana init wizard the first time zana runs on
+// a terminal. Without one, or when declined, it only points at zana init.
+func offerFirstRunSetup(cmd *cobra.Command) {
+	switch cmd.Name() {
+	case "init", "env":
+		return
+	}
+	if isHelpOrCompletion(cmd) {
+		return
+	}
+	if !isFirstRunFn() {
+		return
+	}
+	if ShouldUseJSONOutput() || !canPromptForSelection() || !confirmFirstRunSetupFn() {
+		fmt.Fprintf(os.Stderr, "%s Run \"zana init\" to set up zana\n", IconLightbulb())
+		return
+	}
+	answers := defaultInitAnswers()
+	if askInitAnswersFn(&answers) {
+		runInit(answers)
+	}
+}
+
+func confirmFirstRunSetup() bool {
+	confirm := true
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title("Welcome to zana").
+				Description("This looks like the first run. Set up zana now?").
+				Affirmative("Yes").
+				Negative("No").
+				Value(&confirm),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return false
+	}
+	return confirm
+}
@@ -0,0 +1,162 @@
+package zana
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/mistweaverco/zana-client/internal/lib/spinnerutil"
+)
+
+// Injectable helpers for tests
+var (
+	masonPackagesDirFn  = masonPackagesDir
+	findMasonPackagesFn = findMasonPackages
+)
+
+// masonReceipt is the part of the mason-receipt.json of a mason.nvim package
+// zana reads. The primary source of registry packages is a purl, e.g.
+// pkg:npm/%40prisma/language-server@5.0.0, which zana's legacy IDs are based on.
+type masonReceipt struct {
+	Name          string `json:"name"`
+	PrimarySource struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+	} `json:"primary_source"`
+}
+
+// masonPackage is a package installed by mason.nvim
+type masonPackage struct {
+	Name     string `json:"name"`
+	SourceID string `json:"source_id"`
+	Version  string `json:"version"`
+}
+
+// masonPackagesDir returns where mason.nvim installs packages,
+// stdpath("data")/mason/packages of Neovim
+func masonPackagesDir() string {
+	appName := os.Getenv("NVIM_APPNAME")
+	if appName == "" {
+		appName = "nvim"
+	}
+	var data string
+	switch {
+	case runtime.GOOS == "windows":
+		data = filepath.Join(os.Getenv("LOCALAPPDATA"), appName+"-data")
+	case os.Getenv("XDG_DATA_HOME") != "":
+		data = filepath.Join(os.Getenv("XDG_DATA_HOME"), appName)
+	default:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		data = filepath.Join(home, ".local", "share", appName)
+	}
+	return filepath.Join(data, "mason", "packages")
+}
+
+// parseMasonPurl converts the purl of a Mason receipt to a zana source ID and
+// version, dropping qualifiers and subpath
+func parseMasonPurl(purl string) (string, string, bool) {
+	purl, _, _ = strings.Cut(purl, "#")
+	purl, _, _ = strings.Cut(purl, "?")
+	version := ""
+	if at := strings.LastIndex(purl, "@"); at > strings.LastIndex(purl, "/") {
+		purl, version = purl[:at], purl[at+1:]
+	}
+	unescaped, err := url.PathUnescape(purl)
+	if err != nil || !packageid.IsLegacy(unescaped) {
+		return "", "", false
+	}
+	sourceID := packageid.Normalize(unescaped)
+	if packageid.IsLegacy(sourceID) {
+		return "", "", false
+	}
+	return sourceID, version, true
+}
+
+// findMasonPackages lists the packages in Mason's packages directory whose
+// receipt names a purl, sorted by name
+func findMasonPackages(dir string) []masonPackage {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var pkgs []masonPackage
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name(), "mason-receipt.json"))
+		if err != nil {
+			continue
+		}
+		var receipt masonReceipt
+		if json.Unmarshal(data, &receipt) != nil {
+			continue
+		}
+		sourceID, version, ok := parseMasonPurl(receipt.PrimarySource.ID)
+		if !ok {
+			providers.Logger.Info(fmt.Sprintf("Mason import: Skipping %s, its receipt has no package URL", entry.Name()))
+			continue
+		}
+		name := receipt.Name
+		if name == "" {
+			name = entry.Name()
+		}
+		pkgs = append(pkgs, masonPackage{Name: name, SourceID: sourceID, Version: version})
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Name < pkgs[j].Name })
+	return pkgs
+}
+
+// importMasonPackages installs the Mason packages zana knows in the versions
+// Mason installed. Packages installed already, of providers zana doesn't
+// support or missing from the registry are skipped.
+func importMasonPackages(pkgs []masonPackage) *operationSummary {
+	summary := newOperationSummary("Mason Import", "import", "imported")
+	rp := newRegistryParser()
+	installed := map[string]bool{}
+	for _, pkg := range newLocalPackagesParserFn().Packages {
+		installed[pkg.SourceID] = true
+	}
+	var unknown []string
+	for _, pkg := range pkgs {
+		provider, _ := packageid.Split(pkg.SourceID)
+		switch {
+		case installed[pkg.SourceID]:
+			summary.skip(pkg.SourceID)
+			continue
+		case !isSupportedProviderFn(provider) || rp.GetBySourceId(pkg.SourceID).Source.ID == "":
+			unknown = append(unknown, pkg.Name)
+			continue
+		}
+		version, err := resolveVersionFn(pkg.SourceID, pkg.Version)
+		if err != nil {
+			summary.fail(pkg.SourceID)
+			continue
+		}
+		var ok bool
+		title := fmt.Sprintf("Importing %s@%s...", pkg.SourceID, version)
+		if err := spinnerutil.Run(title, func() { ok = installPackageFn(pkg.SourceID, version) }); err != nil {
+			ok = false
+		}
+		if ok {
+			summary.succeed(pkg.SourceID)
+		} else {
+			summary.fail(pkg.SourceID)
+		}
+	}
+	summary.skipReason = "installed already"
+	if len(unknown) > 0 {
+		summary.details = append(summary.details, fmt.Sprintf("Not in the registry: %s", strings.Join(unknown, ", ")))
+	}
+	return summary
+}
@@ -0,0 +1,182 @@
+package zana
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type initCalls struct {
+	profiles  []string
+	outputs   []string
+	downloads int
+	codes     []int
+}
+
+func stubInit(t *testing.T, registryErr error) *initCalls {
+	t.Helper()
+	prevProfile, prevSet, prevDownload, prevMason, prevDefault, prevAsk, prevPrompt, prevExit, prevYes :=
+		changeEnvProfileFn, setFileConfigValueFn, initRegistryDownloadFn, initMasonPackagesFinder, defaultAppDataPathFn, askInitAnswersFn, canPromptForSelection, osExit, initYes
+	prevHome, hadHome := os.LookupEnv("ZANA_HOME")
+	t.Cleanup(func() {
+		changeEnvProfileFn, setFileConfigValueFn, initRegistryDownloadFn, initMasonPackagesFinder, defaultAppDataPathFn, askInitAnswersFn, canPromptForSelection, osExit, initYes =
+			prevProfile, prevSet, prevDownload, prevMason, prevDefault, prevAsk, prevPrompt, prevExit, prevYes
+		if hadHome {
+			_ = os.Setenv("ZANA_HOME", prevHome)
+		} else {
+			_ = os.Unsetenv("ZANA_HOME")
+		}
+	})
+
+	calls := &initCalls{}
+	changeEnvProfileFn = func(shell string, install bool, zanaHome string) (string, bool, error) {
+		calls.profiles = append(calls.profiles, shell+":"+zanaHome)
+		return "/home/me/.bashrc", true, nil
+	}
+	setFileConfigValueFn = func(section, key, value string) error {
+		calls.outputs = append(calls.outputs, section+"."+key+"="+value)
+		return nil
+	}
+	initRegistryDownloadFn = func() error {
+		calls.downloads++
+		return registryErr
+	}
+	initMasonPackagesFinder = func() []masonPackage { return nil }
+	defaultAppDataPathFn = func() string { return "/default/zana" }
+	osExit = func(code int) { calls.codes = append(calls.codes, code) }
+	return calls
+}
+
+func TestRunInit(t *testing.T) {
+	t.Run("sets up every step", func(t *testing.T) {
+		calls := stubInit(t, nil)
+		dataDir := filepath.Join(t.TempDir(), "zana")
+		var result initResult
+		out := captureOutput(t, func() {
+			result = runInit(initAnswers{DataDir: dataDir, SetupPath: true, Shell: "bash", Output: "plain"})
+		})
+		assert.True(t, result.Success)
+		assert.DirExists(t, dataDir)
+		assert.Equal(t, dataDir, os.Getenv("ZANA_HOME"))
+		assert.Equal(t, []string{"bash:" + dataDir}, calls.profiles)
+		assert.Equal(t, []string{"ui.output=plain"}, calls.outputs)
+		assert.Equal(t, 1, calls.downloads)
+		assert.Equal(t, "/home/me/.bashrc", result.Profile)
+		assert.True(t, result.Registry)
+		assert.Contains(t, out, "Added zana to /home/me/.bashrc, open a new shell to use it")
+		assert.Contains(t, out, "zana is set up")
+	})
+
+	t.Run("keeps the default data directory out of the profile", func(t *testing.T) {
+		calls := stubInit(t, nil)
+		result := runInit(initAnswers{DataDir: "/default/zana", SetupPath: true, Shell: "zsh"})
+		assert.True(t, result.Success)
+		assert.Equal(t, []string{"zsh:"}, calls.profiles)
+		assert.Empty(t, calls.outputs, "no output style chosen")
+	})
+
+	t.Run("reports failed steps and goes on", func(t *testing.T) {
+		calls := stubInit(t, errors.New("offline"))
+		changeEnvProfileFn = func(string, bool, string) (string, bool, error) {
+			return "", false, errors.New("--install and --uninstall support bash, zsh, pwsh and powershell, not fish")
+		}
+		var result initResult
+		out := captureOutput(t, func() {
+			result = runInit(initAnswers{DataDir: "/default/zana", SetupPath: true, Shell: "fish", Output: "json"})
+		})
+		assert.False(t, result.Success)
+		assert.Equal(t, []string{"ui.output=json"}, calls.outputs)
+		assert.Len(t, result.Errors, 2)
+		assert.Contains(t, out, "failed to add zana to your PATH")
+		assert.Contains(t, out, "failed to download the registry: offline")
+	})
+}
+
+func TestInitCommand(t *testing.T) {
+	t.Run("refuses to ask without a terminal", func(t *testing.T) {
+		calls := stubInit(t, nil)
+		canPromptForSelection = func() bool { return false }
+		initYes = false
+		out := captureOutput(t, func() { initCmd.Run(initCmd, nil) })
+		assert.Equal(t, []int{1}, calls.codes)
+		assert.Contains(t, out, "pass --yes")
+		assert.Equal(t, 0, calls.downloads)
+	})
+
+	t.Run("--yes uses the defaults", func(t *testing.T) {
+		calls := stubInit(t, nil)
+		askInitAnswersFn = func(*initAnswers) bool {
+			t.Fatal("--yes shouldn't ask")
+			return false
+		}
+		initYes = true
+		out := captureOutputWithMode(t, func() { initCmd.Run(initCmd, nil) }, config.OutputModeJSON)
+		assert.Empty(t, calls.codes)
+		assert.Len(t, calls.profiles, 1)
+		assert.JSONEq(t, `{"success": true, "data_dir": "/default/zana", "profile": "/home/me/.bashrc", "registry": true}`, out)
+	})
+}
+
+func TestOfferFirstRunSetup(t *testing.T) {
+	calls := stubInit(t, nil)
+	prevFirst, prevConfirm := isFirstRunFn, confirmFirstRunSetupFn
+	t.Cleanup(func() { isFirstRunFn, confirmFirstRunSetupFn = prevFirst, prevConfirm })
+	canPromptForSelection = func() bool { return true }
+	confirmFirstRunSetupFn = func() bool { return true }
+	asked := 0
+	askInitAnswersFn = func(*initAnswers) bool {
+		asked++
+		return true
+	}
+
+	isFirstRunFn = func() bool { return false }
+	offerFirstRunSetup(listCmd)
+	assert.Equal(t, 0, asked)
+
+	isFirstRunFn = func() bool { return true }
+	offerFirstRunSetup(&cobra.Command{Use: "env"})
+	assert.Equal(t, 0, asked, "env isn't interrupted")
+
+	captureOutput(t, func() { offerFirstRunSetup(listCmd) })
+	assert.Equal(t, 1, asked)
+	assert.Equal(t, 1, calls.downloads)
+}
+
+func TestParseMasonPurl(t *testing.T) {
+	sourceID, version, ok := parseMasonPurl("pkg:npm/%40prisma/language-server@5.0.0?x=1")
+	require.True(t, ok)
+	assert.Equal(t, "npm:@prisma/language-server", sourceID)
+	assert.Equal(t, "5.0.0", version)
+
+	sourceID, version, ok = parseMasonPurl("pkg:golang/golang.org/x/tools/gopls@v0.15.3#gopls")
+	require.True(t, ok)
+	assert.Equal(t, "golang:golang.org/x/tools/gopls", sourceID)
+	assert.Equal(t, "v0.15.3", version)
+
+	_, _, ok = parseMasonPurl("")
+	assert.False(t, ok)
+}
+
+func TestFindMasonPackages(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, receipt string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, name), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name, "mason-receipt.json"), []byte(receipt), 0644))
+	}
+	write("ruff", `{"name": "ruff", "primary_source": {"type": "pypi", "id": "pkg:pypi/ruff@0.5.7"}}`)
+	write("lua-language-server", `{"name": "lua-language-server", "primary_source": {"type": "github", "id": "pkg:github/LuaLS/lua-language-server@3.9.3"}}`)
+	write("custom", `{"name": "custom", "primary_source": {"type": "unmanaged"}}`)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "broken"), 0755))
+
+	assert.Equal(t, []masonPackage{
+		{Name: "lua-language-server", SourceID: "github:LuaLS/lua-language-server", Version: "3.9.3"},
+		{Name: "ruff", SourceID: "pypi:ruff", Version: "0.5.7"},
+	}, findMasonPackages(dir))
+	assert.Empty(t, findMasonPackages(filepath.Join(dir, "missing")))
+}
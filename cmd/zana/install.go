@@ -1,11 +1,14 @@
 package zana
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/charmbracelet/huh"
+	"github.com/mistweaverco/zana-client/internal/lib/errcodes"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/providers"
 	"github.com/spf13/cobra"
 )
@@ -21,39 +24,19 @@ import (
 //
 //	<provider>:<package-name>
 func parseUserPackageID(arg string) (string, string, error) {
-	// Legacy format: pkg:provider/name
-	if strings.HasPrefix(arg, "pkg:") {
-		parts := strings.Split(strings.TrimPrefix(arg, "pkg:"), "/")
-		if len(parts) < 2 {
-			return "", "", fmt.Errorf("invalid package ID format '%s': expected 'pkg:provider/package-name[@version]'", arg)
-		}
-		provider := parts[0]
-		if provider == "" {
-			return "", "", fmt.Errorf("invalid package ID format '%s': provider cannot be empty", arg)
-		}
-		packageName := parts[1]
-		if packageName == "" {
-			return "", "", fmt.Errorf("invalid package ID format '%s': package name cannot be empty", arg)
-		}
-		return provider, packageName, nil
-	}
-
-	// New format: provider:package-name
-	if !strings.Contains(arg, ":") {
+	if !packageid.HasProvider(arg) {
 		return "", "", fmt.Errorf("invalid package ID format '%s': expected '<provider>:<package-id>[@version]'", arg)
 	}
-
-	parts := strings.SplitN(arg, ":", 2)
-	provider := parts[0]
-	packageName := parts[1]
-
+	provider, packageName := packageid.Split(arg)
 	if provider == "" {
 		return "", "", fmt.Errorf("invalid package ID format '%s': provider cannot be empty", arg)
 	}
+	if packageName == "" && packageid.IsLegacy(arg) {
+		return "", "", fmt.Errorf("invalid package ID format '%s': package name cannot be empty", arg)
+	}
 	if packageName == "" {
 		return "", "", fmt.Errorf("invalid package ID format '%s': package id cannot be empty", arg)
 	}
-
 	return provider, packageName, nil
 }
 
@@ -73,8 +56,8 @@ func validatePackageArgs(args []string) error {
 	}
 
 	for _, arg := range args {
-		// Check if it's a package name without provider (no colon and no pkg: prefix)
-		if !strings.Contains(arg, ":") && !strings.HasPrefix(arg, "pkg:") {
+		// Check if it's a package name without provider
+		if !packageid.HasProvider(arg) {
 			// Allow it - will be handled in Run function
 			continue
 		}
@@ -88,6 +71,9 @@ func validatePackageArgs(args []string) error {
 			return fmt.Errorf("unsupported provider '%s' for package '%s'. Supported providers: %s",
 				provider, arg, strings.Join(providers.AvailableProviders, ", "))
 		}
+		if err := providers.CheckProviderAllowed(provider); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -111,6 +97,8 @@ Supported package ID formats:
   gitlab:group/subgroup/project@v1.0.0
   codeberg:user/repo
   codeberg:user/repo@v1.0.0
+  gitea:git.example.com/user/repo
+  gitea:git.example.com/user/repo@v1.0.0
 
 Examples:
   zana install npm:@prisma/language-server
@@ -119,13 +107,54 @@ Examples:
   zana install cargo:ripgrep@13.0.0 npm:prettier
   zana install github:sharkdp/bat
   zana install gitlab:group/subgroup/myproject@v1.0.0
-  zana install codeberg:user/repo`,
+  zana install codeberg:user/repo
+  zana install gitea:git.example.com/user/repo
+  zana install --target .tools --no-lock npm:prettier
+  zana install --no-symlink npm:typescript
+  zana install prettier --from github:prettier/prettier
+  zana add --isolated github:sharkdp/bat
+  zana add gopls ruff --version-from ~/work-laptop/zana-lock.json
+
+--from installs a registry package from another source when its own is
+broken. The lock file records the override, so updates follow the source
+given with --from while the package keeps its registry name.
+
+--version-from installs the versions recorded in another machine's lock file
+(a path or URL) instead of the latest ones, for packages given without
+@version. Packages not in that lock file aren't installed.
+
+--isolated installs into a temporary ZANA_HOME, prints the executables and,
+on a terminal, starts a shell with them on the PATH. The sandbox is removed
+when the shell exits (or right away without a terminal), unless --keep is
+given. The real lock file, bin directory and cache are left alone.`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		return validatePackageArgs(args)
 	},
 	// Enable shell completion for package IDs based on the local registry.
 	ValidArgsFunction: packageIDCompletion,
 	Run: func(cmd *cobra.Command, args []string) {
+		if installKeep && !installIsolated {
+			fmt.Printf("%s --keep can only be used together with --isolated\n", IconClose())
+			osExit(1)
+			return
+		}
+		if installIsolated {
+			if installTarget != "" || installNoLock || installNoSymlink || len(installIntegrations) > 0 || installVersionFrom != "" {
+				fmt.Printf("%s --isolated can't be used together with --target, --no-lock, --no-symlink, --integrate or --version-from\n", IconClose())
+				osExit(1)
+				return
+			}
+			result := runIsolatedInstall(args)
+			if ShouldUseJSONOutput() {
+				PrintJSON(result)
+			} else {
+				printIsolatedInstallResult(result)
+			}
+			if !result.Success {
+				osExit(1)
+			}
+			return
+		}
 		if err := providers.ConfigureExternalTreeSitterQueriesFromCLI(
 			cmd.Flags().Changed("external-treesitter-queries"),
 			installExternalTreeSitterQueries,
@@ -134,6 +163,41 @@ Examples:
 			osExit(1)
 			return
 		}
+		if installNoLock && installTarget == "" {
+			fmt.Printf("%s --no-lock can only be used together with --target\n", IconClose())
+			osExit(1)
+			return
+		}
+		if installNoSymlink && installTarget != "" {
+			fmt.Printf("%s --no-symlink can't be used together with --target\n", IconClose())
+			osExit(1)
+			return
+		}
+		// --from installs the registry package from another source
+		var override installOverride
+		if installFrom != "" {
+			var err error
+			if override, err = resolveInstallOverride(args, installFrom); err != nil {
+				fmt.Printf("%s %v\n", IconClose(), err)
+				osExit(1)
+				return
+			}
+			args = []string{override.Arg}
+		}
+		// --version-from installs the versions of another machine's lock file
+		var versionFrom *lockFileVersions
+		if installVersionFrom != "" {
+			var err error
+			if versionFrom, err = loadLockFileVersions(installVersionFrom); err != nil {
+				fmt.Printf("%s %v\n", IconClose(), err)
+				osExit(1)
+				return
+			}
+		}
+		if !confirmDownloadEstimate(plannedInstallDownloads(args), printfStdout) {
+			fmt.Println("Nothing installed.")
+			return
+		}
 		userIntegrations := append([]string(nil), installIntegrations...)
 		providers.SetRequestedIntegrations(userIntegrations)
 		providers.ResetTreeSitterDependencyInstallSuccessCount()
@@ -142,9 +206,11 @@ Examples:
 		defer cleanupNestedInstallOutput()
 
 		// Install all packages
+		summary := newOperationSummary("Installation", "install", "installed")
 		successCount := 0
 		failureCount := 0
 		var failures []string
+		preflight := newProviderToolPreflight()
 
 		for _, userPkgID := range args {
 			// Parse package ID and version from the user-facing ID
@@ -154,7 +220,7 @@ Examples:
 			var displayID string
 
 			// Check if this is a package name without provider
-			if !strings.Contains(baseID, ":") && !strings.HasPrefix(baseID, "pkg:") {
+			if !packageid.HasProvider(baseID) {
 				// Package name without provider - search registry and prompt user
 				// Always show confirmation for partial names (user didn't provide full provider:package-id)
 				matches := findPackagesByName(baseID)
@@ -162,39 +228,12 @@ Examples:
 					fmt.Printf("%s No packages found matching '%s'\n", IconClose(), baseID)
 					failureCount++
 					failures = append(failures, userPkgID)
+					summary.code(userPkgID, errcodes.PackageNotFound)
 					continue
 				}
 
 				// Filter matches to exact package name or alias matches first (for better UX)
-				exactMatches := []PackageMatch{}
-				partialMatches := []PackageMatch{}
-				baseIDLower := strings.ToLower(baseID)
-				parser := newRegistryParser()
-
-				for _, match := range matches {
-					matchNameLower := strings.ToLower(match.PackageName)
-					// Check if package name matches exactly
-					isExactMatch := matchNameLower == baseIDLower
-
-					// Also check if any alias matches exactly
-					if !isExactMatch {
-						registryItem := parser.GetBySourceId(match.SourceID)
-						if registryItem.Source.ID != "" {
-							for _, alias := range registryItem.Aliases {
-								if strings.ToLower(alias) == baseIDLower {
-									isExactMatch = true
-									break
-								}
-							}
-						}
-					}
-
-					if isExactMatch {
-						exactMatches = append(exactMatches, match)
-					} else {
-						partialMatches = append(partialMatches, match)
-					}
-				}
+				exactMatches, partialMatches := splitExactMatches(baseID, matches)
 
 				// Use exact matches if available, otherwise use partial matches
 				matchesToShow := exactMatches
@@ -202,8 +241,9 @@ Examples:
 					matchesToShow = partialMatches
 				}
 
-				// Always show confirmation for partial names
-				selectedSourceIDs, err := promptForProviderSelection(baseID, matchesToShow, "install")
+				// Always show confirmation for partial names on a terminal,
+				// otherwise resolve exact matches by provider priority
+				selectedSourceIDs, err := selectInstallMatches(baseID, matchesToShow, len(exactMatches) > 0)
 				if err != nil {
 					fmt.Printf("%s Error selecting provider for '%s': %v\n", IconClose(), baseID, err)
 					failureCount++
@@ -217,12 +257,29 @@ Examples:
 					// selectedSourceID is already in provider:package-id format, use it directly
 					displayID := selectedSourceID
 
+					if code := installBlocked(preflight, internalID); code != "" {
+						failureCount++
+						failures = append(failures, displayID)
+						summary.code(displayID, code)
+						continue
+					}
+
+					requestedVersion, err := versionFrom.versionFor(internalID, version)
+					if err != nil {
+						fmt.Printf("%s %v\n", IconClose(), err)
+						failureCount++
+						failures = append(failures, displayID)
+						summary.code(displayID, errcodes.PackageNotFound)
+						continue
+					}
+
 					// Resolve version before installing to show actual version in spinner
-					resolvedVersion, err := resolveVersionFn(internalID, version)
+					resolvedVersion, err := resolveVersionFn(internalID, requestedVersion)
 					if err != nil {
 						fmt.Printf("%s Failed to resolve version for %s: %v\n", IconClose(), displayID, err)
 						failureCount++
 						failures = append(failures, displayID)
+						summary.code(displayID, errcodes.Classify(err))
 						continue
 					}
 
@@ -244,21 +301,31 @@ Examples:
 					providers.SetRequestedIntegrations(effectiveIntegrations)
 
 					title := fmt.Sprintf("Installing %s@%s...", displayID, resolvedVersion)
+					var placed []string
+					var installErr error
 					success, err := runZanaInstallWithTreeSitterSpinnerPhases(title, internalID, resolvedVersion, registryItem, func() bool {
-						return installPackageFn(internalID, resolvedVersion)
+						placed, installErr = installPackage(internalID, resolvedVersion)
+						return installErr == nil
 					})
 					providers.SetRequestedIntegrations(userIntegrations)
 					if err != nil {
 						failureCount++
 						failures = append(failures, displayID)
+						summary.code(displayID, errcodes.Classify(err))
 						fmt.Printf("%s Failed to install %s@%s: %v\n", IconClose(), displayID, resolvedVersion, err)
 						continue
 					}
 
 					if success {
 						successCount++
-						_ = local_packages_parser.MergePackageIntegrations(internalID, effectiveIntegrations)
+						summary.succeed(displayID)
+						if !installNoLock {
+							_ = local_packages_parser.MergePackageIntegrations(internalID, effectiveIntegrations)
+						}
 						fmt.Printf("%s Successfully installed %s@%s\n", IconCheck(), displayID, resolvedVersion)
+						printPlacedBinaries(placed)
+						warnBinShadowing(internalID, registryItem)
+						warnDeprecated(internalID, registryItem)
 						for _, line := range providers.ConsumeIntegrationReport(internalID, resolvedVersion) {
 							fmt.Printf("  %s@%s: %s\n", internalID, resolvedVersion, line)
 						}
@@ -266,6 +333,10 @@ Examples:
 						failureCount++
 						failures = append(failures, displayID)
 						fmt.Printf("%s Failed to install %s@%s\n", IconClose(), displayID, resolvedVersion)
+						summary.code(displayID, installFailureCode(internalID, installErr))
+						if !errors.Is(installErr, errInstallFailed) {
+							fmt.Printf("  %v\n", installErr)
+						}
 					}
 				}
 				continue // Skip the single package processing below
@@ -285,12 +356,29 @@ Examples:
 				displayID = fmt.Sprintf("%s:%s", provider, pkgName)
 			}
 
+			if code := installBlocked(preflight, internalID); code != "" {
+				failureCount++
+				failures = append(failures, displayID)
+				summary.code(displayID, code)
+				continue
+			}
+
+			requestedVersion, err := versionFrom.versionFor(internalID, version)
+			if err != nil {
+				fmt.Printf("%s %v\n", IconClose(), err)
+				failureCount++
+				failures = append(failures, displayID)
+				summary.code(displayID, errcodes.PackageNotFound)
+				continue
+			}
+
 			// Resolve version before installing to show actual version in spinner
-			resolvedVersion, err := resolveVersionFn(internalID, version)
+			resolvedVersion, err := resolveVersionFn(internalID, requestedVersion)
 			if err != nil {
 				fmt.Printf("%s Failed to resolve version for %s: %v\n", IconClose(), displayID, err)
 				failureCount++
 				failures = append(failures, displayID)
+				summary.code(displayID, errcodes.Classify(err))
 				continue
 			}
 
@@ -312,21 +400,31 @@ Examples:
 			providers.SetRequestedIntegrations(effectiveIntegrations)
 
 			title := fmt.Sprintf("Installing %s@%s...", displayID, resolvedVersion)
+			var placed []string
+			var installErr error
 			success, err := runZanaInstallWithTreeSitterSpinnerPhases(title, internalID, resolvedVersion, registryItem, func() bool {
-				return installPackageFn(internalID, resolvedVersion)
+				placed, installErr = installPackage(internalID, resolvedVersion)
+				return installErr == nil
 			})
 			providers.SetRequestedIntegrations(userIntegrations)
 			if err != nil {
 				failureCount++
 				failures = append(failures, displayID)
+				summary.code(displayID, errcodes.Classify(err))
 				fmt.Printf("%s Failed to install %s@%s: %v\n", IconClose(), displayID, resolvedVersion, err)
 				continue
 			}
 
 			if success {
 				successCount++
-				_ = local_packages_parser.MergePackageIntegrations(internalID, effectiveIntegrations)
+				summary.succeed(displayID)
+				if !installNoLock {
+					_ = local_packages_parser.MergePackageIntegrations(internalID, effectiveIntegrations)
+				}
 				fmt.Printf("%s Successfully installed %s@%s\n", IconCheck(), displayID, resolvedVersion)
+				printPlacedBinaries(placed)
+				warnBinShadowing(internalID, registryItem)
+				warnDeprecated(internalID, registryItem)
 				for _, line := range providers.ConsumeIntegrationReport(internalID, resolvedVersion) {
 					fmt.Printf("  %s@%s: %s\n", internalID, resolvedVersion, line)
 				}
@@ -334,12 +432,24 @@ Examples:
 				failureCount++
 				failures = append(failures, displayID)
 				fmt.Printf("%s Failed to install %s@%s\n", IconClose(), displayID, resolvedVersion)
+				summary.code(displayID, installFailureCode(internalID, installErr))
+				if !errors.Is(installErr, errInstallFailed) {
+					fmt.Printf("  %v\n", installErr)
+				}
 			}
 		}
 
+		if override.SourceID != "" && successCount > 0 && installTarget == "" {
+			recordInstallOverride(override)
+		}
+
 		depSuccess := providers.ConsumeTreeSitterDependencyInstallSuccessCount()
 		totalSuccess := successCount + depSuccess
 
+		summary.failed = failures
+		summary.extraSucceeded = depSuccess
+		summary.note = installDependencyNote(successCount, depSuccess)
+
 		// Print summary
 		if ShouldUseJSONOutput() {
 			result := map[string]interface{}{
@@ -351,54 +461,128 @@ Examples:
 				"direct_successful":        successCount,
 				"dependency_successful":    depSuccess,
 				"failed":                   failures,
+				"summary":                  summary.toJSON(),
 			}
 			PrintJSON(result)
 		} else {
-			fmt.Printf("\nInstallation Summary:\n")
-			fmt.Printf("  Successfully installed: %d", totalSuccess)
-			if depSuccess > 0 {
-				if successCount > 0 {
-					fmt.Printf(" (%d you requested", successCount)
-					if depSuccess == 1 {
-						fmt.Printf(", 1 dependency")
-					} else {
-						fmt.Printf(", %d dependencies", depSuccess)
-					}
-					fmt.Printf(")\n")
-				} else {
-					if depSuccess == 1 {
-						fmt.Printf(" (1 dependency)\n")
-					} else {
-						fmt.Printf(" (%d dependencies)\n", depSuccess)
-					}
-				}
-			} else {
-				fmt.Printf("\n")
-			}
-			if failureCount > 0 {
-				fmt.Printf("  Failed to install: %d\n", failureCount)
-				fmt.Printf("  Failed packages: %s\n", strings.Join(failures, ", "))
-			}
+			summary.print(printfStdout)
 		}
 	},
 }
 
+// installDependencyNote tells the requested packages apart from the
+// dependencies installed along, e.g. (2 you requested, 1 dependency)
+func installDependencyNote(requested, dependencies int) string {
+	if dependencies == 0 {
+		return ""
+	}
+	deps := "1 dependency"
+	if dependencies != 1 {
+		deps = fmt.Sprintf("%d dependencies", dependencies)
+	}
+	if requested == 0 {
+		return "(" + deps + ")"
+	}
+	return fmt.Sprintf("(%d you requested, %s)", requested, deps)
+}
+
 var installIntegrations []string
 var installExternalTreeSitterQueries string
+var installForce bool
+var installTarget string
+var installNoLock bool
+var installNoSymlink bool
+var installFrom string
 
 func init() {
 	installCmd.Flags().StringSliceVar(&installIntegrations, "integrate", nil, "run integration backends after install (e.g. --integrate neovim)")
+	installCmd.Flags().BoolVar(&installForce, "force", false, "install even if the registry marks the package as unsupported on this platform")
+	installCmd.Flags().StringVar(&installTarget, "target", "", "place the package's binaries in this directory instead of the zana bin dir (e.g. .tools)")
+	installCmd.Flags().BoolVar(&installNoLock, "no-lock", false, "don't record the install in the global lock file and keep the package in the target directory")
+	installCmd.Flags().BoolVar(&installNoSymlink, "no-symlink", false, "don't link the package's executables into the bin dir (see zana link)")
+	installCmd.Flags().BoolVarP(&downloadConfirmYes, "yes", "y", false, "install without asking when the download is larger than install.confirmDownloadSize")
+	installCmd.Flags().StringVar(&installFrom, "from", "", "install the package from this source instead of the registry's (e.g. github:owner/repo)")
+	installCmd.Flags().StringVar(&installVersionFrom, "version-from", "", "install the versions recorded in this lock file (path or URL), e.g. of another machine")
+	installCmd.Flags().BoolVar(&installIsolated, "isolated", false, "install into a throwaway ZANA_HOME to try the package without touching the real one")
+	installCmd.Flags().BoolVar(&installKeep, "keep", false, "keep the sandbox of --isolated instead of removing it")
 	installCmd.Flags().StringVar(&installExternalTreeSitterQueries, "external-treesitter-queries", "ask", "when Neovim integration needs optional query-only git repos from the registry: ask (default), always, never (overridden by ZANA_EXTERNAL_TREESITTER_QUERIES when this flag is left at default)")
 }
 
 // indirections for testability
 var (
-	isSupportedProviderFn = providers.IsSupportedProvider
-	availableProvidersFn  = func() []string { return providers.AvailableProviders }
-	installPackageFn      = providers.Install
-	resolveVersionFn      = providers.ResolveVersion
+	isSupportedProviderFn  = providers.IsSupportedProvider
+	availableProvidersFn   = func() []string { return providers.AvailableProviders }
+	installPackageFn       = providers.Install
+	resolveVersionFn       = providers.ResolveVersion
+	checkPlatformSupportFn = providers.CheckPlatformSupport
+	installToTargetFn      = providers.InstallToTarget
+	installUnlinkedFn      = providers.InstallUnlinked
 )
 
+// installBlocked returns the error code of the first check before installing
+// sourceID that fails, or "" when it can be installed
+func installBlocked(preflight *providerToolPreflight, sourceID string) errcodes.Code {
+	if !preflight.check(sourceID, printfStdout) {
+		return errcodes.ToolMissing
+	}
+	if !installForce && !platformSupported(sourceID, printfStdout) {
+		return errcodes.UnsupportedPlatform
+	}
+	return ""
+}
+
+// installFailureCode returns why installing sourceID failed: the code of
+// installErr, or the one the provider recorded when it only reported failure
+func installFailureCode(sourceID string, installErr error) errcodes.Code {
+	code := providers.ConsumeFailureCode(sourceID)
+	if !errors.Is(installErr, errInstallFailed) {
+		return errcodes.Classify(installErr)
+	}
+	return code
+}
+
+// errInstallFailed is returned by installPackage when the provider reported a
+// failure without further details
+var errInstallFailed = errors.New("install failed")
+
+// installPackage installs sourceID into the zana bin dir or, with --target,
+// into the target directory. It returns the binaries placed in the target.
+func installPackage(sourceID, version string) ([]string, error) {
+	if installTarget != "" {
+		return installToTargetFn(sourceID, version, installTarget, installNoLock)
+	}
+	install := installPackageFn
+	if installNoSymlink {
+		install = installUnlinkedFn
+	}
+	if !install(sourceID, version) {
+		return nil, errInstallFailed
+	}
+	return nil, nil
+}
+
+func printPlacedBinaries(placed []string) {
+	if len(placed) > 0 {
+		fmt.Printf("  Placed in %s: %s\n", installTarget, strings.Join(placed, ", "))
+	}
+}
+
+// platformSupported reports whether the registry allows installing sourceID on the
+// current OS/architecture. Otherwise it explains why, suggests packages of other
+// providers that are available on this platform and points to --force.
+func platformSupported(sourceID string, printf func(format string, args ...interface{})) bool {
+	var platformErr *providers.UnsupportedPlatformError
+	if !errors.As(checkPlatformSupportFn(sourceID), &platformErr) {
+		return true
+	}
+	printf("%s Cannot install %s: not supported on %s (supported: %s)\n", IconClose(), sourceID, platformErr.Target, strings.Join(platformErr.Supported, ", "))
+	if len(platformErr.Alternatives) > 0 {
+		printf("   %s Available on this platform: %s\n", IconLightbulb(), strings.Join(platformErr.Alternatives, ", "))
+	}
+	printf("   %s Use --force to install anyway\n", IconLightbulb())
+	return false
+}
+
 // isValidVersionString checks if a string looks like a valid version
 func isValidVersionString(version string) bool {
 	// Common version patterns: "1.0.0", "latest", "v1.0.0", "1.0.0-beta", etc.
@@ -437,8 +621,10 @@ func isValidVersionString(version string) bool {
 	return false
 }
 
-// parsePackageIDAndVersion extracts the package ID and version from a full package ID string.
-// It handles the format pkg:provider/name[@version] where name can contain @ symbols.
+// parsePackageIDAndVersion extracts the package ID and version from a package
+// argument, <provider>:<package-id>[@version] where the package id can contain
+// @ symbols. Legacy IDs are converted to <provider>:<package-id> here, so the
+// commands and providers only see canonical ones.
 func parsePackageIDAndVersion(pkgId string) (string, string) {
 	// Split by @ and check if the last part looks like a version
 	parts := strings.Split(pkgId, "@")
@@ -448,12 +634,12 @@ func parsePackageIDAndVersion(pkgId string) (string, string) {
 		if isValidVersionString(lastPart) {
 			// Reconstruct the package name without the version
 			packageName := strings.Join(parts[:len(parts)-1], "@")
-			return packageName, lastPart
+			return packageid.Normalize(packageName), lastPart
 		}
 	}
 	// No valid version found, return the full package ID with empty version.
 	// Empty version means "use the registry default if present, otherwise provider default".
-	return pkgId, ""
+	return packageid.Normalize(pkgId), ""
 }
 
 // PackageMatch represents a package found in the registry
@@ -464,6 +650,9 @@ type PackageMatch struct {
 	Name        string
 	Description string
 	Version     string
+	// InstalledVersion and InstallCount are only filled in for the install prompt
+	InstalledVersion string
+	InstallCount     int
 }
 
 // findPackagesByName searches the registry for packages matching the given name
@@ -587,19 +776,7 @@ func promptForProviderSelection(packageName string, matches []PackageMatch, acti
 	// Multiple matches, show multi-select
 	options := make([]huh.Option[string], 0, len(matches))
 	for _, match := range matches {
-		displayName := fmt.Sprintf("%s:%s", match.Provider, match.PackageName)
-		if match.Name != "" && match.Name != match.PackageName {
-			displayName = fmt.Sprintf("%s (%s)", displayName, match.Name)
-		}
-		if match.Description != "" {
-			// Truncate description if too long
-			desc := match.Description
-			if len(desc) > 60 {
-				desc = desc[:57] + "..."
-			}
-			displayName = fmt.Sprintf("%s - %s", displayName, desc)
-		}
-		options = append(options, huh.NewOption(displayName, match.SourceID))
+		options = append(options, huh.NewOption(selectionLabel(match), match.SourceID))
 	}
 
 	var selected []string
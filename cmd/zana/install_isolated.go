@@ -0,0 +1,198 @@
+package zana
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+)
+
+// installIsolated and installKeep are --isolated and --keep of install
+var (
+	installIsolated bool
+	installKeep     bool
+)
+
+// isolatedInstallResult is what zana install --isolated reports
+type isolatedInstallResult struct {
+	Success bool     `json:"success"`
+	Error   string   `json:"error,omitempty"`
+	Sandbox string   `json:"sandbox"`
+	BinDir  string   `json:"bin_dir"`
+	Bins    []string `json:"bins"`
+	Kept    bool     `json:"kept"`
+}
+
+// isolatedRegistryFilesFn returns the downloaded registry files copied into the sandbox
+var isolatedRegistryFilesFn = func() []string {
+	return []string{files.GetRegistryCachePath(), files.GetAppRegistryFilePath()}
+}
+
+// isolatedShellFn starts an interactive shell with the sandbox environment
+var isolatedShellFn = runIsolatedShell
+
+// runIsolatedInstall installs the packages into a throwaway ZANA_HOME instead
+// of the real one, prints the executables they brought and, on a terminal,
+// starts a shell with them on the PATH. The sandbox is removed afterwards,
+// unless --keep is given.
+func runIsolatedInstall(args []string) isolatedInstallResult {
+	// Ctrl-C stops the install or ends the shell, but must not keep the
+	// sandbox from being removed
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	root, err := files.MkdirTemp("isolated")
+	if err != nil {
+		return isolatedInstallResult{Error: err.Error()}
+	}
+	sandbox := testInstallSandbox{root: root}
+	result := isolatedInstallResult{Sandbox: root, BinDir: filepath.Join(sandbox.data(), "bin"), Bins: []string{}, Kept: installKeep}
+	if !installKeep {
+		defer os.RemoveAll(root)
+	}
+	for _, dir := range []string{sandbox.home(), sandbox.data(), sandbox.cache(), sandbox.tmp()} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	}
+	// The downloaded registry is reused, so only the packages are downloaded
+	for _, path := range isolatedRegistryFilesFn() {
+		copyIntoSandbox(path, sandbox.cache())
+	}
+
+	if !ShouldUseJSONOutput() {
+		fmt.Printf("%s Installing into sandbox %s\n", IconMagnify(), root)
+	}
+	if err := testInstallRunFn(isolatedEnv(sandbox), isolatedInstallArgs(args)...); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Bins = isolatedBins(result.BinDir)
+	result.Success = true
+	if ShouldUseJSONOutput() || !canPromptForSelection() {
+		return result
+	}
+
+	printIsolatedBins(result)
+	fmt.Printf("\n%s Starting a shell with the sandbox on the PATH, exit it when you're done\n", IconLightbulb())
+	if err := isolatedShellFn(sandbox); err != nil {
+		fmt.Printf("%s Shell exited: %v\n", IconAlert(), err)
+	}
+	// The bins were already printed before the shell started
+	result.Bins = nil
+	return result
+}
+
+// copyIntoSandbox copies the file at path into dir, keeping its modification
+// time so the registry cache isn't taken for new or stale
+func copyIntoSandbox(path, dir string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	dest := filepath.Join(dir, filepath.Base(path))
+	if os.WriteFile(dest, data, 0644) == nil {
+		_ = os.Chtimes(dest, info.ModTime(), info.ModTime())
+	}
+}
+
+// isolatedInstallArgs returns the arguments of the install run in the sandbox
+func isolatedInstallArgs(args []string) []string {
+	installArgs := []string{"install", "--output", "plain"}
+	if installForce {
+		installArgs = append(installArgs, "--force")
+	}
+	if downloadConfirmYes {
+		installArgs = append(installArgs, "--yes")
+	}
+	if installFrom != "" {
+		installArgs = append(installArgs, "--from", installFrom)
+	}
+	return append(installArgs, args...)
+}
+
+// isolatedEnv is the environment of the sandbox, like that of test-install
+// but without debug logging
+func isolatedEnv(sandbox testInstallSandbox) []string {
+	return []string{
+		"ZANA_HOME=" + sandbox.home(),
+		"ZANA_DATA=" + sandbox.data(),
+		"ZANA_CACHE=" + sandbox.cache(),
+		"ZANA_TMP=" + sandbox.tmp(),
+		"ZANA_BIN_LAYOUT=flat",
+	}
+}
+
+// isolatedBins returns the paths of the executables in the sandbox's bin directory
+func isolatedBins(binDir string) []string {
+	bins := []string{}
+	entries, err := os.ReadDir(binDir)
+	if err != nil {
+		return bins
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			bins = append(bins, filepath.Join(binDir, e.Name()))
+		}
+	}
+	sort.Strings(bins)
+	return bins
+}
+
+func printIsolatedBins(result isolatedInstallResult) {
+	if len(result.Bins) == 0 {
+		fmt.Printf("%s No executables were installed\n", IconAlert())
+		return
+	}
+	fmt.Println("\nExecutables:")
+	for _, bin := range result.Bins {
+		fmt.Printf("  %s\n", bin)
+	}
+}
+
+func printIsolatedInstallResult(result isolatedInstallResult) {
+	if !result.Success {
+		fmt.Printf("%s Isolated install failed: %s\n", IconClose(), result.Error)
+	}
+	if result.Success && result.Bins != nil {
+		printIsolatedBins(result)
+	}
+	if result.Kept {
+		fmt.Printf("\nSandbox kept at %s\n", result.Sandbox)
+		fmt.Printf("Add %s to the PATH to use the executables\n", result.BinDir)
+	} else if result.Sandbox != "" {
+		fmt.Printf("\nSandbox %s removed\n", result.Sandbox)
+	}
+}
+
+// runIsolatedShell runs $SHELL (%COMSPEC% on Windows) with the sandbox's bin
+// directory first on the PATH and ZANA_* pointing at the sandbox
+func runIsolatedShell(sandbox testInstallSandbox) error {
+	shell := os.Getenv("SHELL")
+	if runtime.GOOS == "windows" {
+		shell = os.Getenv("COMSPEC")
+	}
+	if shell == "" {
+		shell = "sh"
+		if runtime.GOOS == "windows" {
+			shell = "cmd.exe"
+		}
+	}
+	cmd := exec.Command(shell)
+	path := filepath.Join(sandbox.data(), "bin") + string(os.PathListSeparator) + os.Getenv("PATH")
+	cmd.Env = append(os.Environ(), isolatedEnv(sandbox)...)
+	cmd.Env = append(cmd.Env, "PATH="+path, "ZANA_ISOLATED="+sandbox.root)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,124 @@
+package zana
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubIsolatedInstall(t *testing.T, tty bool, run func(env map[string]string, args []string) error) *[]int {
+	t.Helper()
+	codes := stubTestInstall(t, testInstallRegistryEntry, run)
+	prevIsolated, prevKeep, prevPrompt, prevShell, prevRegistry := installIsolated, installKeep, canPromptForSelection, isolatedShellFn, isolatedRegistryFilesFn
+	t.Cleanup(func() {
+		installIsolated, installKeep, canPromptForSelection, isolatedShellFn, isolatedRegistryFilesFn = prevIsolated, prevKeep, prevPrompt, prevShell, prevRegistry
+	})
+	registryDir := t.TempDir()
+	isolatedRegistryFilesFn = func() []string {
+		return []string{filepath.Join(registryDir, "registry-cache.json.zip"), filepath.Join(registryDir, "zana-registry.json")}
+	}
+	installIsolated, installKeep = true, false
+	canPromptForSelection = func() bool { return tty }
+	isolatedShellFn = func(testInstallSandbox) error {
+		t.Fatal("no shell without a terminal")
+		return nil
+	}
+	return codes
+}
+
+func TestInstallIsolated(t *testing.T) {
+	t.Run("prints the executables and removes the sandbox", func(t *testing.T) {
+		var sandboxEnv map[string]string
+		var gotArgs []string
+		codes := stubIsolatedInstall(t, false, func(env map[string]string, args []string) error {
+			sandboxEnv, gotArgs = env, args
+			fakeSandboxInstall(t, env)
+			return nil
+		})
+
+		out := captureOutput(t, func() { installCmd.Run(installCmd, []string{"npm:prettier"}) })
+		assert.Empty(t, *codes)
+		assert.Equal(t, []string{"install", "--output", "plain", "npm:prettier"}, gotArgs)
+		assert.Empty(t, sandboxEnv["ZANA_DEBUG"])
+		assert.Contains(t, out, filepath.Join(sandboxEnv["ZANA_DATA"], "bin", "prettier"))
+		assert.Contains(t, out, "removed")
+		_, err := os.Stat(filepath.Dir(sandboxEnv["ZANA_HOME"]))
+		assert.True(t, os.IsNotExist(err), "sandbox should be removed")
+	})
+
+	t.Run("reuses the downloaded registry", func(t *testing.T) {
+		var registry []byte
+		stubIsolatedInstall(t, false, func(env map[string]string, args []string) error {
+			registry, _ = os.ReadFile(filepath.Join(env["ZANA_CACHE"], "zana-registry.json"))
+			return nil
+		})
+		require.NoError(t, os.WriteFile(isolatedRegistryFilesFn()[1], []byte(`[{"name":"x"}]`), 0644))
+
+		captureOutput(t, func() { installCmd.Run(installCmd, []string{"npm:prettier"}) })
+		assert.Equal(t, `[{"name":"x"}]`, string(registry))
+	})
+
+	t.Run("starts a shell on a terminal", func(t *testing.T) {
+		stubIsolatedInstall(t, true, func(env map[string]string, args []string) error {
+			fakeSandboxInstall(t, env)
+			return nil
+		})
+		var shellSandbox testInstallSandbox
+		isolatedShellFn = func(sandbox testInstallSandbox) error {
+			shellSandbox = sandbox
+			assert.FileExists(t, filepath.Join(sandbox.data(), "bin", "prettier"))
+			return nil
+		}
+
+		out := captureOutput(t, func() { installCmd.Run(installCmd, []string{"npm:prettier"}) })
+		require.NotEmpty(t, shellSandbox.root)
+		assert.Contains(t, out, "Starting a shell")
+		assert.NoDirExists(t, shellSandbox.root)
+	})
+
+	t.Run("keeps the sandbox with --keep", func(t *testing.T) {
+		codes := stubIsolatedInstall(t, false, func(env map[string]string, args []string) error {
+			fakeSandboxInstall(t, env)
+			return nil
+		})
+		installKeep = true
+
+		out := captureOutputWithMode(t, func() { installCmd.Run(installCmd, []string{"npm:prettier"}) }, config.OutputModeJSON)
+		assert.Empty(t, *codes)
+		var result isolatedInstallResult
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		assert.True(t, result.Success)
+		assert.True(t, result.Kept)
+		assert.Equal(t, []string{filepath.Join(result.BinDir, "prettier")}, result.Bins)
+		assert.DirExists(t, result.Sandbox)
+		os.RemoveAll(result.Sandbox)
+	})
+
+	t.Run("failed install", func(t *testing.T) {
+		codes := stubIsolatedInstall(t, false, func(map[string]string, []string) error {
+			return errors.New("exit status 1")
+		})
+
+		out := captureOutput(t, func() { installCmd.Run(installCmd, []string{"npm:prettier"}) })
+		assert.Equal(t, []int{1}, *codes)
+		assert.Contains(t, out, "Isolated install failed: exit status 1")
+	})
+
+	t.Run("--keep needs --isolated", func(t *testing.T) {
+		codes := stubIsolatedInstall(t, false, func(map[string]string, []string) error {
+			t.Fatal("install should not run")
+			return nil
+		})
+		installIsolated, installKeep = false, true
+
+		out := captureOutput(t, func() { installCmd.Run(installCmd, []string{"npm:prettier"}) })
+		assert.Equal(t, []int{1}, *codes)
+		assert.Contains(t, out, "--keep can only be used together with --isolated")
+	})
+}
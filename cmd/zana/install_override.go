@@ -0,0 +1,95 @@
+package zana
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+)
+
+// installOverride is zana add <package> --from <source>: the registry
+// package and the source it gets installed from instead
+type installOverride struct {
+	// RegistryID is the registry package being replaced, e.g. npm:prettier
+	RegistryID string
+	// SourceID is the package installed in its place, e.g. github:prettier/prettier
+	SourceID string
+	// Arg is SourceID with the requested version, as passed to install
+	Arg string
+}
+
+// resolveInstallOverride resolves the package argument of --from to its
+// registry package, by ID or by exact name or alias, and from to the
+// package to install. The version given with either applies.
+func resolveInstallOverride(args []string, from string) (installOverride, error) {
+	if len(args) != 1 {
+		return installOverride{}, fmt.Errorf("--from takes exactly one package, got %d", len(args))
+	}
+	fromBase, fromVersion := parsePackageIDAndVersion(from)
+	provider, pkgName, err := parseUserPackageID(fromBase)
+	if err != nil {
+		return installOverride{}, fmt.Errorf("invalid --from: %w", err)
+	}
+	if !isSupportedProviderFn(provider) {
+		return installOverride{}, fmt.Errorf("invalid --from: unsupported provider '%s'. Supported providers: %s", provider, strings.Join(availableProvidersFn(), ", "))
+	}
+	if err := providers.CheckProviderAllowed(provider); err != nil {
+		return installOverride{}, err
+	}
+
+	baseID, version := parsePackageIDAndVersion(args[0])
+	var registryID string
+	if !packageid.HasProvider(baseID) {
+		exact, _ := splitExactMatches(baseID, findPackagesByName(baseID))
+		switch len(exact) {
+		case 0:
+			return installOverride{}, fmt.Errorf("no registry package is named '%s'", baseID)
+		case 1:
+			registryID = exact[0].SourceID
+		default:
+			ids := make([]string, 0, len(exact))
+			for _, match := range exact {
+				ids = append(ids, match.SourceID)
+			}
+			return installOverride{}, fmt.Errorf("'%s' matches %s, use <provider>:<package-id>", baseID, strings.Join(ids, ", "))
+		}
+	} else {
+		registryProvider, registryName, err := parseUserPackageID(baseID)
+		if err != nil {
+			return installOverride{}, err
+		}
+		registryID = toInternalPackageID(registryProvider, registryName)
+		if newRegistryParser().GetBySourceId(registryID).Source.ID == "" {
+			return installOverride{}, fmt.Errorf("%s is not in the registry", registryID)
+		}
+	}
+
+	override := installOverride{RegistryID: registryID, SourceID: toInternalPackageID(provider, pkgName)}
+	if override.SourceID == registryID {
+		return installOverride{}, fmt.Errorf("%s already is the registry source of %s", from, args[0])
+	}
+	if fromVersion == "" {
+		fromVersion = version
+	}
+	override.Arg = override.SourceID
+	if fromVersion != "" {
+		override.Arg += "@" + fromVersion
+	}
+	return override, nil
+}
+
+// recordInstallOverride records in the lock file which registry package an
+// installed override replaces, so it is found by the registry name and
+// zana update keeps following the override source
+func recordInstallOverride(override installOverride) {
+	if err := local_packages_parser.SetPackageOverrideOf(override.SourceID, override.RegistryID); err != nil {
+		fmt.Printf("%s Failed to record %s as override of %s: %v\n", IconClose(), override.SourceID, override.RegistryID, err)
+		return
+	}
+	fmt.Printf("%s Installed %s in place of %s, updates follow %s\n", IconLightbulb(), override.SourceID, override.RegistryID, override.SourceID)
+	if local_packages_parser.IsPackageInstalled(override.RegistryID) {
+		fmt.Printf("%s %s is installed too, remove it with: zana remove %s\n", IconLightbulb(), override.RegistryID, override.RegistryID)
+	}
+}
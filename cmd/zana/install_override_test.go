@@ -0,0 +1,120 @@
+package zana
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/versioncmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubOverrideRegistry(t *testing.T) {
+	t.Helper()
+	prevParser := newRegistryParser
+	t.Cleanup(func() { newRegistryParser = prevParser })
+	newRegistryParser = func() *registry_parser.RegistryParser {
+		rp := registry_parser.NewRegistryParser(nil)
+		require.NoError(t, rp.LoadFromBytes([]byte(`[
+			{"name": "prettier", "version": "3.3.3", "source": {"id": "npm:prettier"}},
+			{"name": "ruff", "version": "0.6.0", "source": {"id": "pypi:ruff"}},
+			{"name": "ruff", "version": "0.6.0", "source": {"id": "cargo:ruff"}}
+		]`)))
+		return rp
+	}
+}
+
+func TestResolveInstallOverride(t *testing.T) {
+	stubOverrideRegistry(t)
+
+	override, err := resolveInstallOverride([]string{"prettier"}, "github:prettier/prettier")
+	require.NoError(t, err)
+	assert.Equal(t, installOverride{RegistryID: "npm:prettier", SourceID: "github:prettier/prettier", Arg: "github:prettier/prettier"}, override)
+
+	override, err = resolveInstallOverride([]string{"npm:prettier@3.3.3"}, "github:prettier/prettier")
+	require.NoError(t, err)
+	assert.Equal(t, "github:prettier/prettier@3.3.3", override.Arg)
+
+	override, err = resolveInstallOverride([]string{"npm:prettier@3.3.3"}, "github:prettier/prettier@v3.4.0")
+	require.NoError(t, err)
+	assert.Equal(t, "github:prettier/prettier@v3.4.0", override.Arg)
+
+	for args, wantErr := range map[string]string{
+		"prettier black":     "--from takes exactly one package, got 2",
+		"eslint":             "no registry package is named 'eslint'",
+		"ruff":               "'ruff' matches pypi:ruff, cargo:ruff, use <provider>:<package-id>",
+		"npm:eslint":         "npm:eslint is not in the registry",
+		"npm:prettier@3.0.0": "",
+	} {
+		_, err := resolveInstallOverride(strings.Fields(args), "github:prettier/prettier")
+		if wantErr == "" {
+			assert.NoError(t, err, args)
+		} else {
+			assert.EqualError(t, err, wantErr, args)
+		}
+	}
+
+	_, err = resolveInstallOverride([]string{"prettier"}, "prettier")
+	assert.ErrorContains(t, err, "invalid --from")
+	_, err = resolveInstallOverride([]string{"prettier"}, "foo:prettier")
+	assert.ErrorContains(t, err, "invalid --from: unsupported provider 'foo'")
+	_, err = resolveInstallOverride([]string{"prettier"}, "npm:prettier")
+	assert.EqualError(t, err, "npm:prettier already is the registry source of prettier")
+}
+
+func TestFindInstalledPackagesByNameFindsOverrides(t *testing.T) {
+	stubOverrideRegistry(t)
+	prevLocal := newLocalPackagesParserFn
+	t.Cleanup(func() { newLocalPackagesParserFn = prevLocal })
+	newLocalPackagesParserFn = func() local_packages_parser.LocalPackageRoot {
+		return local_packages_parser.LocalPackageRoot{Packages: []local_packages_parser.LocalPackageItem{
+			{SourceID: "gitlab:someone/pretty-printer", Version: "v3.4.0", Extras: &local_packages_parser.PackageExtras{OverrideOf: "npm:prettier"}},
+		}}
+	}
+
+	matches := findInstalledPackagesByName("prettier")
+	require.Len(t, matches, 1)
+	assert.Equal(t, "gitlab:someone/pretty-printer", matches[0].SourceID)
+	assert.Equal(t, "prettier", matches[0].Name)
+}
+
+func TestUpdateAllFollowsInstallOverride(t *testing.T) {
+	var updated []string
+	providers.SetProviderFactory(&providers.MockProviderFactory{
+		MockGitHubProvider: &providers.MockPackageManager{UpdateFunc: func(_ context.Context, sourceID string) bool {
+			updated = append(updated, sourceID)
+			return true
+		}},
+	})
+	defer providers.ResetProviderFactory()
+	prevResolve := resolveVersionFn
+	t.Cleanup(func() { resolveVersionFn = prevResolve })
+	resolveVersionFn = func(sourceID, version string) (string, error) {
+		assert.Equal(t, "github:prettier/prettier", sourceID)
+		return "v3.4.0", nil
+	}
+
+	out := &MockOutputWriter{}
+	service := NewUpdateServiceWithDependencies(
+		&MockLocalPackagesProvider{
+			GetDataFunc: func(force bool) local_packages_parser.LocalPackageRoot {
+				return local_packages_parser.LocalPackageRoot{Packages: []local_packages_parser.LocalPackageItem{
+					{SourceID: "github:prettier/prettier", Version: "v3.3.3", Extras: &local_packages_parser.PackageExtras{OverrideOf: "npm:prettier"}},
+					{SourceID: "github:owner/unregistered", Version: "v1.0.0"},
+				}}
+			},
+		},
+		&MockRegistryProvider{GetLatestVersionFunc: func(string) string { return "" }},
+		&MockUpdateChecker{CheckIfUpdateIsAvailableFunc: func(currentVersion, latestVersion string) (bool, string) {
+			return currentVersion != latestVersion, ""
+		}},
+		out,
+	)
+
+	assert.True(t, service.UpdateAllPackagesUpTo(versioncmp.BumpUnknown))
+	assert.Equal(t, []string{"github:prettier/prettier"}, updated)
+}
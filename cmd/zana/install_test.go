@@ -2,11 +2,13 @@ package zana
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"os"
 	"strings"
 	"testing"
 
+	"github.com/mistweaverco/zana-client/internal/config"
 	"github.com/mistweaverco/zana-client/internal/lib/providers"
 	"github.com/stretchr/testify/assert"
 )
@@ -60,37 +62,41 @@ func TestParsePackageIDAndVersion(t *testing.T) {
 		expectedVersion string
 	}{
 		// Basic packages without versions
-		{"basic npm package", "pkg:npm/eslint", "pkg:npm/eslint", ""},
-		{"basic pypi package", "pkg:pypi/black", "pkg:pypi/black", ""},
-		{"basic golang package", "pkg:golang/golang.org/x/tools/gopls", "pkg:golang/golang.org/x/tools/gopls", ""},
-		{"basic cargo package", "pkg:cargo/ripgrep", "pkg:cargo/ripgrep", ""},
+		{"basic npm package", "pkg:npm/eslint", "npm:eslint", ""},
+		{"basic pypi package", "pkg:pypi/black", "pypi:black", ""},
+		{"basic golang package", "pkg:golang/golang.org/x/tools/gopls", "golang:golang.org/x/tools/gopls", ""},
+		{"basic cargo package", "pkg:cargo/ripgrep", "cargo:ripgrep", ""},
 
 		// NPM organization packages (these should NOT treat @org as version)
-		{"npm org package", "pkg:npm/@mistweaverco/kulala-fmt", "pkg:npm/@mistweaverco/kulala-fmt", ""},
-		{"npm org package with @", "pkg:npm/@prisma/language-server", "pkg:npm/@prisma/language-server", ""},
-		{"npm org package with @", "pkg:npm/@tailwindcss/language-server", "pkg:npm/@tailwindcss/language-server", ""},
+		{"npm org package", "pkg:npm/@mistweaverco/kulala-fmt", "npm:@mistweaverco/kulala-fmt", ""},
+		{"npm org package with @", "pkg:npm/@prisma/language-server", "npm:@prisma/language-server", ""},
+		{"npm org package with @", "pkg:npm/@tailwindcss/language-server", "npm:@tailwindcss/language-server", ""},
 
 		// Packages with versions
-		{"npm package with version", "pkg:npm/eslint@1.0.0", "pkg:npm/eslint", "1.0.0"},
-		{"pypi package with version", "pkg:pypi/black@22.3.0", "pkg:pypi/black", "22.3.0"},
-		{"golang package with version", "pkg:golang/golang.org/x/tools/gopls@v0.14.0", "pkg:golang/golang.org/x/tools/gopls", "v0.14.0"},
-		{"cargo package with version", "pkg:cargo/ripgrep@13.0.0", "pkg:cargo/ripgrep", "13.0.0"},
+		{"npm package with version", "pkg:npm/eslint@1.0.0", "npm:eslint", "1.0.0"},
+		{"pypi package with version", "pkg:pypi/black@22.3.0", "pypi:black", "22.3.0"},
+		{"golang package with version", "pkg:golang/golang.org/x/tools/gopls@v0.14.0", "golang:golang.org/x/tools/gopls", "v0.14.0"},
+		{"cargo package with version", "pkg:cargo/ripgrep@13.0.0", "cargo:ripgrep", "13.0.0"},
 
 		// NPM organization packages with versions
-		{"npm org package with version", "pkg:npm/@mistweaverco/kulala-fmt@2.10.0", "pkg:npm/@mistweaverco/kulala-fmt", "2.10.0"},
-		{"npm org package with version", "pkg:npm/@prisma/language-server@6.14.0", "pkg:npm/@prisma/language-server", "6.14.0"},
-		{"npm org package with version", "pkg:npm/@tailwindcss/language-server@0.14.26", "pkg:npm/@tailwindcss/language-server", "0.14.26"},
+		{"npm org package with version", "pkg:npm/@mistweaverco/kulala-fmt@2.10.0", "npm:@mistweaverco/kulala-fmt", "2.10.0"},
+		{"npm org package with version", "pkg:npm/@prisma/language-server@6.14.0", "npm:@prisma/language-server", "6.14.0"},
+		{"npm org package with version", "pkg:npm/@tailwindcss/language-server@0.14.26", "npm:@tailwindcss/language-server", "0.14.26"},
 
 		// Special version cases
-		{"package with latest version", "pkg:npm/eslint@latest", "pkg:npm/eslint", "latest"},
-		{"package with beta version", "pkg:npm/eslint@1.0.0-beta", "pkg:npm/eslint", "1.0.0-beta"},
-		{"package with alpha version", "pkg:npm/eslint@1.0.0-alpha.1", "pkg:npm/eslint", "1.0.0-alpha.1"},
-		{"package with rc version", "pkg:npm/eslint@1.0.0-rc.1", "pkg:npm/eslint", "1.0.0-rc.1"},
+		{"package with latest version", "pkg:npm/eslint@latest", "npm:eslint", "latest"},
+		{"package with beta version", "pkg:npm/eslint@1.0.0-beta", "npm:eslint", "1.0.0-beta"},
+		{"package with alpha version", "pkg:npm/eslint@1.0.0-alpha.1", "npm:eslint", "1.0.0-alpha.1"},
+		{"package with rc version", "pkg:npm/eslint@1.0.0-rc.1", "npm:eslint", "1.0.0-rc.1"},
+
+		// Canonical IDs
+		{"canonical package with version", "npm:eslint@1.0.0", "npm:eslint", "1.0.0"},
+		{"canonical org package", "npm:@mistweaverco/kulala-fmt", "npm:@mistweaverco/kulala-fmt", ""},
 
 		// Edge cases
-		{"package with @ in name but no version", "pkg:npm/@mistweaverco/kulala-fmt", "pkg:npm/@mistweaverco/kulala-fmt", ""},
-		{"package with multiple @ symbols", "pkg:npm/@org@suborg/package@1.0.0", "pkg:npm/@org@suborg/package", "1.0.0"},
-		{"package with @ at end but no version", "pkg:npm/package@", "pkg:npm/package@", ""},
+		{"package with @ in name but no version", "pkg:npm/@mistweaverco/kulala-fmt", "npm:@mistweaverco/kulala-fmt", ""},
+		{"package with multiple @ symbols", "pkg:npm/@org@suborg/package@1.0.0", "npm:@org@suborg/package", "1.0.0"},
+		{"package with @ at end but no version", "pkg:npm/package@", "npm:package@", ""},
 	}
 
 	for _, tc := range testCases {
@@ -435,7 +441,8 @@ func TestInstallCommandFullOutputGolden(t *testing.T) {
 		assert.Contains(t, out, "[✓] Successfully installed pypi:black@22.3.0")
 		assert.Contains(t, out, "Installation Summary:")
 		assert.Contains(t, out, "Successfully installed: 2")
-		assert.NotContains(t, out, "Failed to install:")
+		assert.Contains(t, out, "Failed to install: 0")
+		assert.Contains(t, out, "All packages installed successfully!")
 	})
 
 	t.Run("all packages fail", func(t *testing.T) {
@@ -519,3 +526,115 @@ func TestIsValidVersionString(t *testing.T) {
 		})
 	}
 }
+
+func TestInstallRefusesUnsupportedPlatforms(t *testing.T) {
+	prevCheck, prevInstall, prevResolve, prevForce := checkPlatformSupportFn, installPackageFn, resolveVersionFn, installForce
+	t.Cleanup(func() {
+		checkPlatformSupportFn, installPackageFn, resolveVersionFn, installForce = prevCheck, prevInstall, prevResolve, prevForce
+	})
+	checkPlatformSupportFn = func(sourceID string) error {
+		return &providers.UnsupportedPlatformError{
+			SourceID:     sourceID,
+			Target:       "linux_arm64",
+			Supported:    []string{"darwin", "linux_x64"},
+			Alternatives: []string{"cargo:tool"},
+		}
+	}
+	resolveVersionFn = func(string, string) (string, error) { return "1.0.0", nil }
+	var installed []string
+	installPackageFn = func(id, _ string) bool {
+		installed = append(installed, id)
+		return true
+	}
+
+	installForce = false
+	out := captureOutput(t, func() { installCmd.Run(installCmd, []string{"github:owner/tool"}) })
+	assert.Empty(t, installed)
+	assert.Contains(t, out, "Cannot install github:owner/tool: not supported on linux_arm64 (supported: darwin, linux_x64)")
+	assert.Contains(t, out, "Available on this platform: cargo:tool")
+	assert.Contains(t, out, "Use --force to install anyway")
+	assert.Contains(t, out, "Failed packages: github:owner/tool")
+	out = captureOutputWithMode(t, func() { installCmd.Run(installCmd, []string{"github:owner/tool"}) }, config.OutputModeJSON)
+	assert.Contains(t, out, `"github:owner/tool": "unsupported-platform"`)
+
+	installForce = true
+	captureOutput(t, func() { installCmd.Run(installCmd, []string{"github:owner/tool"}) })
+	assert.Equal(t, []string{"github:owner/tool"}, installed)
+}
+
+func TestInstallIntoTarget(t *testing.T) {
+	prevInstall, prevTargetInstall, prevResolve := installPackageFn, installToTargetFn, resolveVersionFn
+	prevTarget, prevNoLock := installTarget, installNoLock
+	t.Cleanup(func() {
+		installPackageFn, installToTargetFn, resolveVersionFn = prevInstall, prevTargetInstall, prevResolve
+		installTarget, installNoLock = prevTarget, prevNoLock
+	})
+	resolveVersionFn = func(string, string) (string, error) { return "1.0.0", nil }
+	installPackageFn = func(string, string) bool {
+		t.Fatal("global install must not be used with --target")
+		return false
+	}
+	var gotDir string
+	var gotNoLock bool
+	installToTargetFn = func(sourceID, version, dir string, noLock bool) ([]string, error) {
+		gotDir, gotNoLock = dir, noLock
+		return []string{"prettier"}, nil
+	}
+
+	installTarget, installNoLock = ".tools", true
+	out := captureOutput(t, func() { installCmd.Run(installCmd, []string{"npm:prettier"}) })
+	assert.Equal(t, ".tools", gotDir)
+	assert.True(t, gotNoLock)
+	assert.Contains(t, out, "Successfully installed npm:prettier@1.0.0")
+	assert.Contains(t, out, "Placed in .tools: prettier")
+
+	installToTargetFn = func(string, string, string, bool) ([]string, error) {
+		return nil, errors.New("failed to create target directory .tools")
+	}
+	out = captureOutput(t, func() { installCmd.Run(installCmd, []string{"npm:prettier"}) })
+	assert.Contains(t, out, "Failed to install npm:prettier@1.0.0")
+	assert.Contains(t, out, "failed to create target directory .tools")
+}
+
+func TestInstallNoLockRequiresTarget(t *testing.T) {
+	prevExit, prevTarget, prevNoLock := osExit, installTarget, installNoLock
+	t.Cleanup(func() { osExit, installTarget, installNoLock = prevExit, prevTarget, prevNoLock })
+	exitCode := -1
+	osExit = func(code int) { exitCode = code }
+
+	installTarget, installNoLock = "", true
+	out := captureOutput(t, func() { installCmd.Run(installCmd, []string{"npm:prettier"}) })
+	assert.Equal(t, 1, exitCode)
+	assert.Contains(t, out, "--no-lock can only be used together with --target")
+}
+
+func TestInstallNoSymlink(t *testing.T) {
+	prevInstall, prevUnlinked, prevResolve, prevNoSymlink := installPackageFn, installUnlinkedFn, resolveVersionFn, installNoSymlink
+	prevExit, prevTarget := osExit, installTarget
+	t.Cleanup(func() {
+		installPackageFn, installUnlinkedFn, resolveVersionFn, installNoSymlink = prevInstall, prevUnlinked, prevResolve, prevNoSymlink
+		osExit, installTarget = prevExit, prevTarget
+	})
+	resolveVersionFn = func(string, string) (string, error) { return "1.0.0", nil }
+	installPackageFn = func(string, string) bool {
+		t.Fatal("linking install must not be used with --no-symlink")
+		return false
+	}
+	var unlinked []string
+	installUnlinkedFn = func(sourceID, version string) bool {
+		unlinked = append(unlinked, sourceID+"@"+version)
+		return true
+	}
+
+	installNoSymlink = true
+	out := captureOutput(t, func() { installCmd.Run(installCmd, []string{"npm:prettier"}) })
+	assert.Equal(t, []string{"npm:prettier@1.0.0"}, unlinked)
+	assert.Contains(t, out, "Successfully installed npm:prettier@1.0.0")
+
+	exitCode := -1
+	osExit = func(code int) { exitCode = code }
+	installTarget = ".tools"
+	out = captureOutput(t, func() { installCmd.Run(installCmd, []string{"npm:prettier"}) })
+	assert.Equal(t, 1, exitCode)
+	assert.Contains(t, out, "--no-symlink can't be used together with --target")
+}
@@ -0,0 +1,79 @@
+package zana
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+)
+
+// installVersionFrom is --version-from
+var installVersionFrom string
+
+// Injectable helpers for tests
+var readLockFileSourceFn = readLockFileSource
+
+// readLockFileSource reads a zana-lock.json from a path or an http(s) URL
+func readLockFileSource(source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.ReadFile(source)
+	}
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", source, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// lockFileVersions holds the versions of another machine's lock file given
+// with --version-from, so packages can be brought to the same version one by
+// one without syncing the whole lock file
+type lockFileVersions struct {
+	source   string
+	versions map[string]string
+}
+
+// loadLockFileVersions reads the lock file at source, a path or URL
+func loadLockFileVersions(source string) (*lockFileVersions, error) {
+	data, err := readLockFileSourceFn(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --version-from %s: %w", source, err)
+	}
+	var lock local_packages_parser.LocalPackageRoot
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse --version-from %s: %w", source, err)
+	}
+	versions := make(map[string]string, len(lock.Packages))
+	for _, pkg := range lock.Packages {
+		if p, n, err := parseUserPackageID(pkg.SourceID); err == nil && pkg.Version != "" {
+			versions[toInternalPackageID(p, n)] = pkg.Version
+		}
+	}
+	return &lockFileVersions{source: source, versions: versions}, nil
+}
+
+// versionFor returns the version of internalID to install: the requested
+// one when given with @version, otherwise the one in the lock file. Without
+// --version-from (a nil receiver) the requested version is returned as is.
+func (l *lockFileVersions) versionFor(internalID, requested string) (string, error) {
+	if l == nil || requested != "" {
+		return requested, nil
+	}
+	version, ok := l.versions[internalID]
+	if !ok {
+		return "", fmt.Errorf("%s isn't in %s", internalID, l.source)
+	}
+	return version, nil
+}
@@ -0,0 +1,82 @@
+package zana
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const otherMachineLock = `{"packages": [
+	{"sourceId": "golang:golang.org/x/tools/gopls", "version": "v0.15.3"},
+	{"sourceId": "pkg:pypi/ruff", "version": "0.5.7"}
+]}`
+
+func stubVersionFrom(t *testing.T, lock string) {
+	t.Helper()
+	prev := readLockFileSourceFn
+	t.Cleanup(func() { readLockFileSourceFn = prev })
+	readLockFileSourceFn = func(source string) ([]byte, error) {
+		if source != "laptop/zana-lock.json" {
+			return nil, errors.New("not found")
+		}
+		return []byte(lock), nil
+	}
+}
+
+func TestLockFileVersions(t *testing.T) {
+	stubVersionFrom(t, otherMachineLock)
+
+	versions, err := loadLockFileVersions("laptop/zana-lock.json")
+	require.NoError(t, err)
+	v, err := versions.versionFor("golang:golang.org/x/tools/gopls", "")
+	require.NoError(t, err)
+	assert.Equal(t, "v0.15.3", v)
+	v, err = versions.versionFor("pypi:ruff", "")
+	require.NoError(t, err)
+	assert.Equal(t, "0.5.7", v, "legacy IDs are matched")
+	v, err = versions.versionFor("pypi:ruff", "0.6.0")
+	require.NoError(t, err)
+	assert.Equal(t, "0.6.0", v, "an explicit @version wins")
+	_, err = versions.versionFor("npm:prettier", "")
+	assert.EqualError(t, err, "npm:prettier isn't in laptop/zana-lock.json")
+
+	var none *lockFileVersions
+	v, err = none.versionFor("npm:prettier", "")
+	require.NoError(t, err)
+	assert.Equal(t, "", v)
+
+	_, err = loadLockFileVersions("missing.json")
+	assert.ErrorContains(t, err, "failed to read --version-from missing.json")
+	stubVersionFrom(t, "not json")
+	_, err = loadLockFileVersions("laptop/zana-lock.json")
+	assert.ErrorContains(t, err, "failed to parse --version-from laptop/zana-lock.json")
+}
+
+func TestInstallVersionFrom(t *testing.T) {
+	stubVersionFrom(t, otherMachineLock)
+	prevInstall, prevResolve, prevFlag := installPackageFn, resolveVersionFn, installVersionFrom
+	t.Cleanup(func() {
+		installPackageFn, resolveVersionFn, installVersionFrom = prevInstall, prevResolve, prevFlag
+	})
+	resolveVersionFn = func(sourceID, version string) (string, error) {
+		if version == "" {
+			return "latest", nil
+		}
+		return version, nil
+	}
+	var installed []string
+	installPackageFn = func(sourceID, version string) bool {
+		installed = append(installed, sourceID+"@"+version)
+		return true
+	}
+	installVersionFrom = "laptop/zana-lock.json"
+
+	out := captureOutput(t, func() {
+		installCmd.Run(installCmd, []string{"pypi:ruff", "golang:golang.org/x/tools/gopls@v0.16.0", "npm:prettier"})
+	})
+	assert.Equal(t, []string{"pypi:ruff@0.5.7", "golang:golang.org/x/tools/gopls@v0.16.0"}, installed)
+	assert.Contains(t, out, "npm:prettier isn't in laptop/zana-lock.json")
+	assert.Contains(t, out, "Failed to install: 1")
+}
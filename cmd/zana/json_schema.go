@@ -0,0 +1,20 @@
+package zana
+
+import "strconv"
+
+// JSONSchemaVersion is the version of the --json output of zana ls, info and
+// outdated. Fields may be added in any release; the version is raised when
+// fields are removed or renamed or change their type.
+const JSONSchemaVersion = 1
+
+// jsonSchemaVersion is the schema_version field of the --json output types,
+// it's always written as JSONSchemaVersion
+type jsonSchemaVersion struct{}
+
+func (jsonSchemaVersion) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Itoa(JSONSchemaVersion)), nil
+}
+
+func (*jsonSchemaVersion) UnmarshalJSON([]byte) error {
+	return nil
+}
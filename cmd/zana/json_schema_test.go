@@ -0,0 +1,110 @@
+package zana
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The fields of schema_version 1. Fields may be added, but removing or
+// renaming one breaks external tooling and needs a new schema version.
+
+func jsonKeys(t *testing.T, raw json.RawMessage) []string {
+	t.Helper()
+	var m map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(raw, &m))
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func assertSchemaVersion(t *testing.T, raw json.RawMessage) {
+	t.Helper()
+	var v struct {
+		SchemaVersion *int `json:"schema_version"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &v))
+	require.NotNil(t, v.SchemaVersion, "schema_version is missing in %s", raw)
+	assert.Equal(t, JSONSchemaVersion, *v.SchemaVersion)
+}
+
+func TestJSONSchemaList(t *testing.T) {
+	svc := NewListServiceWithDependencies(
+		&MockLocalPackagesProvider{GetDataFunc: func(bool) local_packages_parser.LocalPackageRoot {
+			return local_packages_parser.LocalPackageRoot{Packages: []local_packages_parser.LocalPackageItem{{SourceID: "npm:prettier", Version: "3.0.0"}}}
+		}},
+		&MockRegistryProvider{
+			GetDataFunc: func(bool) []registry_parser.RegistryItem {
+				return []registry_parser.RegistryItem{
+					{Name: "prettier", Version: "3.1.0", Description: "formatter", Source: registry_parser.RegistryItemSource{ID: "npm:prettier"}},
+					{Name: "black", Version: "24.2.0", Source: registry_parser.RegistryItemSource{ID: "pypi:black"}},
+				}
+			},
+			GetLatestVersionFunc: func(string) string { return "3.1.0" },
+		},
+		&MockUpdateChecker{CheckIfUpdateIsAvailableFunc: providers.CheckIfUpdateIsAvailable},
+		&MockFileDownloader{},
+	)
+
+	t.Run("installed", func(t *testing.T) {
+		out := captureOutputWithMode(t, func() { svc.ListInstalledPackages(ListQueryOptions{ShowBinaries: true}) }, config.OutputModeJSON)
+		assertSchemaVersion(t, json.RawMessage(out))
+		assert.Equal(t, []string{"count", "packages", "schema_version", "type", "updates_available"}, jsonKeys(t, json.RawMessage(out)))
+		var result struct {
+			Packages []json.RawMessage `json:"packages"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		require.Len(t, result.Packages, 1)
+		assert.Equal(t, []string{"binaries", "has_update", "name", "provider", "source_id", "version"}, jsonKeys(t, result.Packages[0]))
+	})
+
+	t.Run("all", func(t *testing.T) {
+		out := captureOutputWithMode(t, func() { svc.ListAllPackages(ListQueryOptions{Limit: 5}) }, config.OutputModeJSON)
+		assertSchemaVersion(t, json.RawMessage(out))
+		assert.Equal(t, []string{"count", "limit", "offset", "packages", "schema_version", "total", "type"}, jsonKeys(t, json.RawMessage(out)))
+		var result struct {
+			Packages []json.RawMessage `json:"packages"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		require.Len(t, result.Packages, 2)
+		assert.Equal(t, []string{"description", "has_update", "installed", "installed_version", "name", "provider", "repository", "source_id", "version"}, jsonKeys(t, result.Packages[0]))
+		assert.Equal(t, []string{"installed", "name", "provider", "repository", "source_id", "version"}, jsonKeys(t, result.Packages[1]))
+	})
+}
+
+func TestJSONSchemaInfo(t *testing.T) {
+	stubInfo(t)
+	out := captureOutputWithMode(t, func() { infoCmd.Run(infoCmd, []string{"npm:prettier"}) }, config.OutputModeJSON)
+	assertSchemaVersion(t, json.RawMessage(out))
+	assert.Subset(t, jsonKeys(t, json.RawMessage(out)), []string{"name", "package_id", "schema_version", "version"})
+
+	infoSummaryOnly = true
+	out = captureOutputWithMode(t, func() { infoCmd.Run(infoCmd, []string{"npm:prettier"}) }, config.OutputModeJSON)
+	var rows []json.RawMessage
+	require.NoError(t, json.Unmarshal([]byte(out), &rows))
+	require.Len(t, rows, 1)
+	assertSchemaVersion(t, rows[0])
+	assert.Equal(t, []string{"installed_version", "package_id", "provider", "registry_version", "schema_version", "status"}, jsonKeys(t, rows[0]))
+}
+
+func TestJSONSchemaOutdated(t *testing.T) {
+	stubOutdated(t)
+	out := captureOutputWithMode(t, func() { outdatedCmd.Run(outdatedCmd, nil) }, config.OutputModeJSON)
+	var packages []json.RawMessage
+	require.NoError(t, json.Unmarshal([]byte(out), &packages))
+	require.NotEmpty(t, packages)
+	for _, pkg := range packages {
+		assertSchemaVersion(t, pkg)
+		assert.Subset(t, jsonKeys(t, pkg), []string{"latest", "schema_version", "source_id", "version"})
+	}
+}
@@ -0,0 +1,118 @@
+package zana
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/spf13/cobra"
+)
+
+var linkCmd = &cobra.Command{
+	Use:   "link <pkgId> [pkgId...]",
+	Short: "Link the executables of unlinked packages into the bin directory",
+	Long: `Link the executables of packages installed with --no-symlink (or unlinked
+with zana unlink) into the zana bin directory again.
+
+Examples:
+  zana link npm:prettier
+  zana link cargo:ripgrep pypi:black`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: installedPackageIDCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		runLinkCommand(args, true)
+	},
+}
+
+var unlinkCmd = &cobra.Command{
+	Use:   "unlink <pkgId> [pkgId...]",
+	Short: "Remove the executables of packages from the bin directory",
+	Long: `Remove the executables of installed packages from the zana bin directory
+without uninstalling them, e.g. when only their data or libraries are needed
+or their executables clash with others.
+
+zana-lock.json records the packages as unlinked, so sync and update don't
+link them again until zana link is used.
+
+Examples:
+  zana unlink npm:prettier`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: installedPackageIDCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		runLinkCommand(args, false)
+	},
+}
+
+// indirections for testability
+var (
+	linkPackageFn   = providers.Link
+	unlinkPackageFn = providers.Unlink
+)
+
+// runLinkCommand links (or unlinks) the installed packages userPkgIDs and
+// exits with 1 when any of them failed
+func runLinkCommand(userPkgIDs []string, link bool) {
+	results := []map[string]interface{}{}
+	failed := false
+	for _, userPkgID := range userPkgIDs {
+		bins, sourceID, err := linkPackage(userPkgID, link)
+		result := map[string]interface{}{"package": sourceID, "success": err == nil, "bins": bins}
+		if err != nil {
+			failed = true
+			result["error"] = err.Error()
+			if !ShouldUseJSONOutput() {
+				fmt.Printf("%s %v\n", IconClose(), err)
+			}
+		} else if !ShouldUseJSONOutput() {
+			printLinkResult(sourceID, bins, link)
+		}
+		results = append(results, result)
+	}
+	if ShouldUseJSONOutput() {
+		PrintJSON(map[string]interface{}{
+			"success":  !failed,
+			"packages": results,
+		})
+	}
+	if failed {
+		osExit(1)
+	}
+}
+
+func linkPackage(userPkgID string, link bool) ([]string, string, error) {
+	provider, pkgName, err := parseUserPackageID(userPkgID)
+	if err != nil {
+		return nil, userPkgID, err
+	}
+	sourceID := toInternalPackageID(provider, pkgName)
+	if !packageIsInstalled(sourceID) {
+		return nil, sourceID, fmt.Errorf("%s is not installed", sourceID)
+	}
+	var bins []string
+	if link {
+		bins, err = linkPackageFn(sourceID)
+	} else {
+		bins, err = unlinkPackageFn(sourceID)
+	}
+	if err != nil {
+		action := "unlink"
+		if link {
+			action = "link"
+		}
+		return bins, sourceID, fmt.Errorf("failed to %s %s: %w", action, sourceID, err)
+	}
+	return bins, sourceID, nil
+}
+
+func printLinkResult(sourceID string, bins []string, link bool) {
+	switch {
+	case link && len(bins) == 0:
+		fmt.Printf("%s Linked %s (no executables)\n", IconCheck(), sourceID)
+	case link:
+		fmt.Printf("%s Linked %s: %s\n", IconCheck(), sourceID, strings.Join(bins, ", "))
+	case len(bins) == 0:
+		fmt.Printf("%s Unlinked %s (no executables were linked)\n", IconCheck(), sourceID)
+	default:
+		fmt.Printf("%s Unlinked %s, removed %s\n", IconCheck(), sourceID, strings.Join(bins, ", "))
+	}
+}
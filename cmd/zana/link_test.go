@@ -0,0 +1,66 @@
+package zana
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubLink(t *testing.T) *[]int {
+	t.Helper()
+	prevLink, prevUnlink, prevInstalled, prevExit := linkPackageFn, unlinkPackageFn, packageIsInstalled, osExit
+	t.Cleanup(func() {
+		linkPackageFn, unlinkPackageFn, packageIsInstalled, osExit = prevLink, prevUnlink, prevInstalled, prevExit
+	})
+	packageIsInstalled = func(id string) bool { return id != "npm:eslint" }
+	linkPackageFn = func(id string) ([]string, error) {
+		if id == "cargo:broken" {
+			return nil, errors.New("syncing the cargo packages failed")
+		}
+		return []string{"prettier"}, nil
+	}
+	unlinkPackageFn = func(string) ([]string, error) { return nil, nil }
+	codes := &[]int{}
+	osExit = func(code int) { *codes = append(*codes, code) }
+	return codes
+}
+
+func TestLinkCommands(t *testing.T) {
+	codes := stubLink(t)
+
+	out := captureOutput(t, func() { linkCmd.Run(linkCmd, []string{"npm:prettier"}) })
+	assert.Contains(t, out, "Linked npm:prettier: prettier")
+	out = captureOutput(t, func() { unlinkCmd.Run(unlinkCmd, []string{"npm:prettier"}) })
+	assert.Contains(t, out, "Unlinked npm:prettier (no executables were linked)")
+	assert.Empty(t, *codes)
+
+	out = captureOutput(t, func() { linkCmd.Run(linkCmd, []string{"npm:eslint", "cargo:broken"}) })
+	assert.Contains(t, out, "npm:eslint is not installed")
+	assert.Contains(t, out, "failed to link cargo:broken: syncing the cargo packages failed")
+	assert.Equal(t, []int{1}, *codes)
+}
+
+func TestLinkCommandsJSON(t *testing.T) {
+	codes := stubLink(t)
+
+	out := captureOutputWithMode(t, func() { linkCmd.Run(linkCmd, []string{"npm:prettier", "npm:eslint"}) }, config.OutputModeJSON)
+	var result struct {
+		Success  bool `json:"success"`
+		Packages []struct {
+			Package string   `json:"package"`
+			Success bool     `json:"success"`
+			Bins    []string `json:"bins"`
+			Error   string   `json:"error"`
+		} `json:"packages"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	assert.False(t, result.Success)
+	require.Len(t, result.Packages, 2)
+	assert.Equal(t, []string{"prettier"}, result.Packages[0].Bins)
+	assert.Equal(t, "npm:eslint is not installed", result.Packages[1].Error)
+	assert.Equal(t, []int{1}, *codes)
+}
@@ -2,14 +2,17 @@ package zana
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/x/term"
 	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/providers"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
 	"github.com/spf13/cobra"
@@ -63,7 +66,32 @@ By default, shows locally installed packages.
 Use --all to show all available packages from the registry.
 You can provide filter arguments to show only packages whose names match the filter strings (case-insensitive substring match).
 
-Optional filters (combinable): --only-outdated, --only-providers, --only-categories.`,
+Optional filters (combinable): --only-outdated, --only-providers, --only-categories.
+Use --binaries to also show the executables each installed package exposes in the bin dir.
+Use --updated-since (e.g. 7d) to show only packages installed or updated
+within that time, according to the history (see zana stats).
+
+Use --check-integrity to re-hash the executables of installed packages and compare
+them with the sha256 hashes recorded in the lock file at install time, to detect
+tampering or corruption. Add --repair to reinstall mismatched packages at their
+locked version. The command exits with status 1 when mismatches remain.
+
+Use --duplicates to find tools installed through more than one provider, e.g.
+cargo:stylua and github:JohnnyMorganz/StyLua, matched by registry name, aliases
+and executables. For the executables they share, the one that runs first on PATH
+is shown. On a terminal, you can pick the package to keep and the others are removed.
+
+With --all, use --limit and --offset to page through the registry.
+Rich and plain output is shown in a pager ($ZANA_PAGER, $PAGER or less) when
+stdout is a terminal; use --no-pager to print directly.
+
+Use --porcelain for output meant for scripts: one package per line,
+tab-separated fields, no header, no pager. The fields are stable across
+releases; new fields are only ever appended.
+  installed: <source-id> <version> <latest-version> <outdated>
+             plus <binaries> (comma-separated) with --binaries
+  --all:     <source-id> <version> <installed-version> <outdated>
+Unknown values are printed as "-", <outdated> is "true" or "false".`,
 	Args: cobra.ArbitraryArgs,
 	// Enable shell completion for package names
 	ValidArgsFunction: packageIDCompletion,
@@ -74,8 +102,39 @@ Optional filters (combinable): --only-outdated, --only-providers, --only-categor
 			fmt.Printf("%s %v\n", IconClose(), err)
 			os.Exit(1)
 		}
+		if allFlag && opts.UpdatedSince > 0 {
+			fmt.Printf("%s --updated-since cannot be combined with --all\n", IconClose())
+			os.Exit(1)
+		}
 		service := newListService()
 
+		checkIntegrity, _ := cmd.Flags().GetBool("check-integrity")
+		repair, _ := cmd.Flags().GetBool("repair")
+		if repair && !checkIntegrity {
+			fmt.Printf("%s --repair requires --check-integrity\n", IconClose())
+			os.Exit(1)
+		}
+		if opts.Porcelain && (checkIntegrity || ShouldUseJSONOutput()) {
+			fmt.Printf("%s --porcelain cannot be combined with --check-integrity or JSON output\n", IconClose())
+			os.Exit(1)
+		}
+		if duplicates, _ := cmd.Flags().GetBool("duplicates"); duplicates {
+			if allFlag || checkIntegrity || opts.Porcelain {
+				fmt.Printf("%s --duplicates cannot be combined with --all, --check-integrity or --porcelain\n", IconClose())
+				os.Exit(1)
+			}
+			service.ListDuplicates(opts)
+			return
+		}
+		if checkIntegrity {
+			if allFlag {
+				fmt.Printf("%s --check-integrity cannot be combined with --all\n", IconClose())
+				os.Exit(1)
+			}
+			service.CheckInstalledIntegrity(opts, repair)
+			return
+		}
+
 		if allFlag {
 			service.ListAllPackages(opts)
 		} else {
@@ -89,6 +148,15 @@ func init() {
 	listCmd.Flags().Bool("only-outdated", false, "Show only packages with an update available (with --all: registry entries you have installed that are outdated)")
 	listCmd.Flags().String("only-providers", "", "Comma-separated provider names to include, e.g. pypi,npm")
 	listCmd.Flags().String("only-categories", "", "Comma-separated category tokens; a package matches if any of its registry categories matches any token (substring match, case-insensitive), e.g. lsp,tree-sitter-parser")
+	listCmd.Flags().Bool("binaries", false, "Show the executables each installed package exposes in the bin dir")
+	listCmd.Flags().Int("limit", 0, "With --all: show at most this many packages (0 shows all)")
+	listCmd.Flags().Int("offset", 0, "With --all: skip this many packages before listing")
+	listCmd.Flags().Bool("no-pager", false, "Print directly instead of using a pager")
+	listCmd.Flags().Bool("porcelain", false, "One package per line with stable tab-separated fields, for scripts")
+	listCmd.Flags().String("updated-since", "", "Show only packages installed or updated within this duration, from the history (e.g. 12h, 7d, 2w)")
+	listCmd.Flags().Bool("check-integrity", false, "Re-hash the executables of installed packages and compare them with the hashes recorded in the lock file")
+	listCmd.Flags().Bool("repair", false, "With --check-integrity: reinstall packages whose executables were modified or are missing")
+	listCmd.Flags().Bool("duplicates", false, "Show tools installed through more than one provider and which of their executables runs")
 }
 
 // ListQueryOptions holds positional name filters plus optional list constraints.
@@ -97,6 +165,16 @@ type ListQueryOptions struct {
 	OnlyOutdated   bool
 	OnlyProviders  []string // lowercase provider names (validated)
 	OnlyCategories []string // trimmed tokens from --only-categories
+	ShowBinaries   bool     // show executables exposed in the bin dir (installed packages only)
+	Limit          int      // max registry packages to show with --all (0 = no limit)
+	Offset         int      // registry packages to skip with --all
+	NoPager        bool     // print directly instead of through a pager
+	Porcelain      bool     // stable tab-separated output for scripts
+	// UpdatedSince keeps packages installed or updated within this duration
+	// (installed packages only, 0 = no limit)
+	UpdatedSince time.Duration
+	// UpdatedSinceFlag is --updated-since as given, e.g. 7d
+	UpdatedSinceFlag string
 }
 
 func listQueryOptionsFromFlags(cmd *cobra.Command, args []string) (ListQueryOptions, error) {
@@ -110,6 +188,20 @@ func listQueryOptionsFromFlags(cmd *cobra.Command, args []string) (ListQueryOpti
 	}
 	onlyCat, _ := cmd.Flags().GetString("only-categories")
 	opts.OnlyCategories = parseCommaSeparatedList(onlyCat)
+	opts.ShowBinaries, _ = cmd.Flags().GetBool("binaries")
+	opts.Limit, _ = cmd.Flags().GetInt("limit")
+	opts.Offset, _ = cmd.Flags().GetInt("offset")
+	if opts.Limit < 0 || opts.Offset < 0 {
+		return ListQueryOptions{}, fmt.Errorf("--limit and --offset must not be negative")
+	}
+	opts.NoPager, _ = cmd.Flags().GetBool("no-pager")
+	opts.Porcelain, _ = cmd.Flags().GetBool("porcelain")
+	if since, _ := cmd.Flags().GetString("updated-since"); since != "" {
+		if opts.UpdatedSince, err = parseSinceDuration(since); err != nil {
+			return ListQueryOptions{}, err
+		}
+		opts.UpdatedSinceFlag = strings.TrimSpace(since)
+	}
 	return opts, nil
 }
 
@@ -181,7 +273,7 @@ func registryItemMatchesCategoryFilters(categories []string, filters []string) b
 }
 
 func (o ListQueryOptions) hasAdvancedFilters() bool {
-	return o.OnlyOutdated || len(o.OnlyProviders) > 0 || len(o.OnlyCategories) > 0
+	return o.OnlyOutdated || len(o.OnlyProviders) > 0 || len(o.OnlyCategories) > 0 || o.UpdatedSince > 0
 }
 
 func (o ListQueryOptions) constraintDescriptionPlain() string {
@@ -198,6 +290,9 @@ func (o ListQueryOptions) constraintDescriptionPlain() string {
 	if len(o.OnlyCategories) > 0 {
 		parts = append(parts, fmt.Sprintf("categories: %s", strings.Join(o.OnlyCategories, ", ")))
 	}
+	if o.UpdatedSince > 0 {
+		parts = append(parts, fmt.Sprintf("updated in the last %s", o.UpdatedSinceFlag))
+	}
 	return " — " + strings.Join(parts, "; ")
 }
 
@@ -215,19 +310,83 @@ func (o ListQueryOptions) constraintDescriptionMarkdown() string {
 	if len(o.OnlyCategories) > 0 {
 		parts = append(parts, fmt.Sprintf("categories: **%s**", strings.Join(o.OnlyCategories, ", ")))
 	}
+	if o.UpdatedSince > 0 {
+		parts = append(parts, fmt.Sprintf("updated in the last **%s**", o.UpdatedSinceFlag))
+	}
 	return " — " + strings.Join(parts, "; ")
 }
 
-func appendListQueryJSONFields(m map[string]any, o ListQueryOptions) {
-	if o.OnlyOutdated {
-		m["only_outdated"] = true
+func (o ListQueryOptions) isPaginated() bool {
+	return o.Limit > 0 || o.Offset > 0
+}
+
+// paginate returns the window of items selected by --offset and --limit
+func (o ListQueryOptions) paginate(items []registry_parser.RegistryItem) []registry_parser.RegistryItem {
+	if o.Offset >= len(items) {
+		return []registry_parser.RegistryItem{}
 	}
-	if len(o.OnlyProviders) > 0 {
-		m["only_providers"] = append([]string(nil), o.OnlyProviders...)
+	items = items[o.Offset:]
+	if o.Limit > 0 && o.Limit < len(items) {
+		items = items[:o.Limit]
 	}
-	if len(o.OnlyCategories) > 0 {
-		m["only_categories"] = append([]string(nil), o.OnlyCategories...)
+	return items
+}
+
+// pageDescription describes the shown window, e.g. " (showing 101–150)"
+func (o ListQueryOptions) pageDescription(shown int) string {
+	if !o.isPaginated() {
+		return ""
+	}
+	if shown == 0 {
+		return fmt.Sprintf(" (none after offset %d)", o.Offset)
+	}
+	return fmt.Sprintf(" (showing %d–%d)", o.Offset+1, o.Offset+shown)
+}
+
+// filterInstalledPackagesByName keeps the packages whose ID, name, or registry
+// aliases contain any of filters (case-insensitive); no filters keep all packages.
+func filterInstalledPackagesByName(localPackages []local_packages_parser.LocalPackageItem, filters []string) []local_packages_parser.LocalPackageItem {
+	if len(filters) == 0 {
+		return localPackages
 	}
+	filteredPackages := []local_packages_parser.LocalPackageItem{}
+	parser := newRegistryParser()
+	for _, pkg := range localPackages {
+		packageName := getPackageNameFromSourceID(pkg.SourceID)
+		packageNameLower := strings.ToLower(packageName)
+		sourceIDLower := strings.ToLower(pkg.SourceID)
+
+		// Check if package name, full sourceID, or aliases contain any of the filter strings
+		matches := false
+		for _, filter := range filters {
+			filterLower := strings.ToLower(filter)
+			// Match against full sourceID (provider:package-id) or just package name
+			if strings.Contains(sourceIDLower, filterLower) || strings.Contains(packageNameLower, filterLower) {
+				matches = true
+				break
+			}
+
+			// Also check aliases from registry
+			registryItem := parser.GetBySourceId(pkg.SourceID)
+			if registryItem.Source.ID != "" {
+				for _, alias := range registryItem.Aliases {
+					aliasLower := strings.ToLower(alias)
+					if strings.Contains(aliasLower, filterLower) {
+						matches = true
+						break
+					}
+				}
+				if matches {
+					break
+				}
+			}
+		}
+
+		if matches {
+			filteredPackages = append(filteredPackages, pkg)
+		}
+	}
+	return filteredPackages
 }
 
 // newListService is a factory to allow test injection
@@ -246,52 +405,13 @@ func (ls *ListService) ListInstalledPackages(opts ListQueryOptions) {
 	localPackages := ls.localPackages.GetData(true).Packages
 	filters := opts.NameFilters
 
-	// Filter packages if name filters are provided
-	filteredPackages := localPackages
-	if len(filters) > 0 {
-		filteredPackages = []local_packages_parser.LocalPackageItem{}
-		parser := newRegistryParser()
-		for _, pkg := range localPackages {
-			packageName := getPackageNameFromSourceID(pkg.SourceID)
-			packageNameLower := strings.ToLower(packageName)
-			sourceIDLower := strings.ToLower(pkg.SourceID)
-
-			// Check if package name, full sourceID, or aliases contain any of the filter strings
-			matches := false
-			for _, filter := range filters {
-				filterLower := strings.ToLower(filter)
-				// Match against full sourceID (provider:package-id) or just package name
-				if strings.Contains(sourceIDLower, filterLower) || strings.Contains(packageNameLower, filterLower) {
-					matches = true
-					break
-				}
-
-				// Also check aliases from registry
-				registryItem := parser.GetBySourceId(pkg.SourceID)
-				if registryItem.Source.ID != "" {
-					for _, alias := range registryItem.Aliases {
-						aliasLower := strings.ToLower(alias)
-						if strings.Contains(aliasLower, filterLower) {
-							matches = true
-							break
-						}
-					}
-					if matches {
-						break
-					}
-				}
-			}
-
-			if matches {
-				filteredPackages = append(filteredPackages, pkg)
-			}
-		}
-	}
-
+	filteredPackages := filterInstalledPackagesByName(localPackages, filters)
 	filteredPackages = ls.applyAdvancedFiltersToInstalled(filteredPackages, opts)
 
 	// Output based on mode
-	if ShouldUseJSONOutput() {
+	if opts.Porcelain {
+		ls.listInstalledPackagesPorcelain(os.Stdout, filteredPackages, opts)
+	} else if ShouldUseJSONOutput() {
 		ls.listInstalledPackagesJSON(filteredPackages, opts)
 	} else if ShouldUsePlainOutput() {
 		ls.listInstalledPackagesPlain(filteredPackages, opts)
@@ -305,6 +425,10 @@ func (ls *ListService) applyAdvancedFiltersToInstalled(packages []local_packages
 		return packages
 	}
 	catByID := ls.registryCategoriesBySourceID()
+	var changed map[string]time.Time
+	if opts.UpdatedSince > 0 {
+		changed = lastChangedBySourceID()
+	}
 	out := make([]local_packages_parser.LocalPackageItem, 0, len(packages))
 	for _, pkg := range packages {
 		prov := getProviderFromSourceID(pkg.SourceID)
@@ -317,6 +441,9 @@ func (ls *ListService) applyAdvancedFiltersToInstalled(packages []local_packages
 				continue
 			}
 		}
+		if opts.UpdatedSince > 0 && !changed[pkg.SourceID].After(opts.updatedAfter()) {
+			continue
+		}
 		if opts.OnlyOutdated {
 			if _, hasUpdate := ls.checkUpdateAvailability(pkg.SourceID, pkg.Version); !hasUpdate {
 				continue
@@ -327,6 +454,29 @@ func (ls *ListService) applyAdvancedFiltersToInstalled(packages []local_packages
 	return out
 }
 
+// packageBinariesFn is a variable to allow test injection
+var packageBinariesFn = providers.PackageBinaries
+
+// binariesBySourceID resolves the executables each given package exposes in the bin dir,
+// from the bin map of its registry entry (or of the entry it's installed in place of)
+// or else by scanning the bin dir.
+func (ls *ListService) binariesBySourceID(packages []local_packages_parser.LocalPackageItem) map[string][]string {
+	items := ls.registry.GetData(false)
+	bySourceID := make(map[string]registry_parser.RegistryItem, len(items))
+	for _, it := range items {
+		bySourceID[packageid.Normalize(it.Source.ID)] = it
+	}
+	m := make(map[string][]string, len(packages))
+	for _, pkg := range packages {
+		item, ok := bySourceID[packageid.Normalize(pkg.SourceID)]
+		if !ok && pkg.Extras != nil && pkg.Extras.OverrideOf != "" {
+			item = bySourceID[packageid.Normalize(pkg.Extras.OverrideOf)]
+		}
+		m[pkg.SourceID] = packageBinariesFn(pkg.SourceID, item)
+	}
+	return m
+}
+
 func (ls *ListService) registryCategoriesBySourceID() map[string][]string {
 	items := ls.registry.GetData(false)
 	m := make(map[string][]string, len(items))
@@ -340,6 +490,42 @@ func (ls *ListService) registryCategoriesBySourceID() map[string][]string {
 	return m
 }
 
+// deprecationsBySourceID maps the source IDs of deprecated registry entries to
+// their deprecation, in the <provider>:<package-id> form of the lock file
+func (ls *ListService) deprecationsBySourceID() map[string]*registry_parser.RegistryItemDeprecation {
+	m := make(map[string]*registry_parser.RegistryItemDeprecation)
+	for _, it := range ls.registry.GetData(false) {
+		if it.Deprecated == nil {
+			continue
+		}
+		id := strings.TrimSpace(it.Source.ID)
+		if provider, pkgName, err := parseUserPackageID(id); err == nil {
+			id = toInternalPackageID(provider, pkgName)
+		}
+		m[id] = it.Deprecated
+	}
+	return m
+}
+
+// deprecatedInstalled returns the source IDs of the deprecated packages among packages
+func deprecatedInstalled(packages []local_packages_parser.LocalPackageItem, deprecations map[string]*registry_parser.RegistryItemDeprecation) []string {
+	var ids []string
+	for _, pkg := range packages {
+		if deprecations[pkg.SourceID] != nil {
+			ids = append(ids, pkg.SourceID)
+		}
+	}
+	return ids
+}
+
+// formatBinaries joins binary names for display, using "-" when there are none
+func formatBinaries(binaries []string) string {
+	if len(binaries) == 0 {
+		return "-"
+	}
+	return strings.Join(binaries, ", ")
+}
+
 // listInstalledPackagesRich lists installed packages with rich formatting using markdown tables
 func (ls *ListService) listInstalledPackagesRich(filteredPackages []local_packages_parser.LocalPackageItem, opts ListQueryOptions) {
 	var markdown strings.Builder
@@ -378,15 +564,25 @@ func (ls *ListService) listInstalledPackagesRich(filteredPackages []local_packag
 	}
 
 	// Display packages grouped by provider and count updates
-	providers := []string{"npm", "golang", "pypi", "cargo", "github", "gitlab", "codeberg", "gem", "composer", "luarocks", "nuget", "opam", "openvsx", "generic"}
+	var binaries map[string][]string
+	if opts.ShowBinaries {
+		binaries = ls.binariesBySourceID(filteredPackages)
+	}
+
+	providers := []string{"npm", "golang", "pypi", "cargo", "github", "gitlab", "codeberg", "gitea", "gem", "composer", "luarocks", "nuget", "opam", "openvsx", "generic", "local", "treesitter"}
 	updateCount := 0
 	totalCount := 0
 
 	for _, provider := range providers {
 		if packages, exists := packagesByProvider[provider]; exists {
 			markdown.WriteString(fmt.Sprintf("## %s Packages\n\n", strings.ToUpper(provider)))
-			markdown.WriteString("| Package ID | Version | Status |\n")
-			markdown.WriteString("|------------|---------|--------|\n")
+			if opts.ShowBinaries {
+				markdown.WriteString("| Package ID | Version | Status | Binaries |\n")
+				markdown.WriteString("|------------|---------|--------|----------|\n")
+			} else {
+				markdown.WriteString("| Package ID | Version | Status |\n")
+				markdown.WriteString("|------------|---------|--------|\n")
+			}
 
 			for _, pkg := range packages {
 				updateInfo, hasUpdate := ls.checkUpdateAvailability(pkg.SourceID, pkg.Version)
@@ -406,7 +602,11 @@ func (ls *ListService) listInstalledPackagesRich(filteredPackages []local_packag
 					}
 				}
 
-				markdown.WriteString(fmt.Sprintf("| %s | %s | %s |\n", pkg.SourceID, pkg.Version, statusText))
+				if opts.ShowBinaries {
+					markdown.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", pkg.SourceID, pkg.Version, statusText, formatBinaries(binaries[pkg.SourceID])))
+				} else {
+					markdown.WriteString(fmt.Sprintf("| %s | %s | %s |\n", pkg.SourceID, pkg.Version, statusText))
+				}
 
 				totalCount++
 				if hasUpdate {
@@ -424,6 +624,10 @@ func (ls *ListService) listInstalledPackagesRich(filteredPackages []local_packag
 		markdown.WriteString(fmt.Sprintf("\n- **%d** updates available", updateCount))
 		markdown.WriteString(fmt.Sprintf("\n- %s Use `zana update --all` to update all packages", IconLightbulbPlain()))
 	}
+	if deprecated := deprecatedInstalled(filteredPackages, ls.deprecationsBySourceID()); len(deprecated) > 0 {
+		markdown.WriteString(fmt.Sprintf("\n- **%d** deprecated: %s", len(deprecated), strings.Join(deprecated, ", ")))
+		markdown.WriteString(fmt.Sprintf("\n- %s Use `zana migrate-package <pkgId>` to replace a deprecated package", IconLightbulbPlain()))
+	}
 	markdown.WriteString("\n")
 
 	ls.renderMarkdown(markdown.String())
@@ -462,7 +666,12 @@ func (ls *ListService) listInstalledPackagesPlain(filteredPackages []local_packa
 		packagesByProvider[provider] = append(packagesByProvider[provider], pkg)
 	}
 
-	providers := []string{"npm", "golang", "pypi", "cargo", "github", "gitlab", "codeberg", "gem", "composer", "luarocks", "nuget", "opam", "openvsx", "generic"}
+	var binaries map[string][]string
+	if opts.ShowBinaries {
+		binaries = ls.binariesBySourceID(filteredPackages)
+	}
+
+	providers := []string{"npm", "golang", "pypi", "cargo", "github", "gitlab", "codeberg", "gitea", "gem", "composer", "luarocks", "nuget", "opam", "openvsx", "generic", "local", "treesitter"}
 	updateCount := 0
 	totalCount := 0
 
@@ -472,6 +681,9 @@ func (ls *ListService) listInstalledPackagesPlain(filteredPackages []local_packa
 			for _, pkg := range packages {
 				updateInfo, hasUpdate := ls.checkUpdateAvailability(pkg.SourceID, pkg.Version)
 				fmt.Printf("   %s %s (v%s) %s\n", getProviderIcon(provider), pkg.SourceID, pkg.Version, updateInfo)
+				if opts.ShowBinaries {
+					fmt.Printf("      binaries: %s\n", formatBinaries(binaries[pkg.SourceID]))
+				}
 				totalCount++
 				if hasUpdate {
 					updateCount++
@@ -487,51 +699,53 @@ func (ls *ListService) listInstalledPackagesPlain(filteredPackages []local_packa
 		fmt.Printf(", %d updates available", updateCount)
 		fmt.Printf("\n%s Use 'zana update --all' to update all packages", IconLightbulb())
 	}
+	if deprecated := deprecatedInstalled(filteredPackages, ls.deprecationsBySourceID()); len(deprecated) > 0 {
+		fmt.Printf("\n%s %d deprecated: %s", IconAlert(), len(deprecated), strings.Join(deprecated, ", "))
+		fmt.Printf("\n%s Use 'zana migrate-package <pkgId>' to replace a deprecated package", IconLightbulb())
+	}
 	fmt.Println()
 }
 
 // listInstalledPackagesJSON lists installed packages in JSON format
 func (ls *ListService) listInstalledPackagesJSON(filteredPackages []local_packages_parser.LocalPackageItem, opts ListQueryOptions) {
-	filters := opts.NameFilters
-	result := make(map[string]any)
-	result["type"] = "installed"
-	if len(filters) > 0 {
-		result["filters"] = filters
-	}
-	appendListQueryJSONFields(result, opts)
+	result := newListJSON[listInstalledPackageJSON]("installed", opts)
 
 	if len(filteredPackages) == 0 {
-		result["count"] = 0
-		result["packages"] = []any{}
 		PrintJSON(result)
 		return
 	}
 
-	packagesData := make([]map[string]any, 0, len(filteredPackages))
-	updateCount := 0
+	var binaries map[string][]string
+	if opts.ShowBinaries {
+		binaries = ls.binariesBySourceID(filteredPackages)
+	}
 
+	deprecations := ls.deprecationsBySourceID()
+	updateCount := 0
 	for _, pkg := range filteredPackages {
-		packageName := getPackageNameFromSourceID(pkg.SourceID)
-		provider := getProviderFromSourceID(pkg.SourceID)
 		_, hasUpdate := ls.checkUpdateAvailability(pkg.SourceID, pkg.Version)
 
-		pkgData := map[string]any{
-			"source_id":  pkg.SourceID,
-			"name":       packageName,
-			"provider":   provider,
-			"version":    pkg.Version,
-			"has_update": hasUpdate,
+		pkgData := listInstalledPackageJSON{
+			SourceID:   pkg.SourceID,
+			Name:       getPackageNameFromSourceID(pkg.SourceID),
+			Provider:   getProviderFromSourceID(pkg.SourceID),
+			Version:    pkg.Version,
+			HasUpdate:  hasUpdate,
+			Deprecated: deprecations[pkg.SourceID],
 		}
-		packagesData = append(packagesData, pkgData)
+		if opts.ShowBinaries {
+			bins := binaries[pkg.SourceID]
+			pkgData.Binaries = &bins
+		}
+		result.Packages = append(result.Packages, pkgData)
 
 		if hasUpdate {
 			updateCount++
 		}
 	}
 
-	result["count"] = len(filteredPackages)
-	result["packages"] = packagesData
-	result["updates_available"] = updateCount
+	result.Count = len(filteredPackages)
+	result.UpdatesAvailable = &updateCount
 	PrintJSON(result)
 }
 
@@ -548,7 +762,7 @@ func (ls *ListService) ListAllPackages(opts ListQueryOptions) {
 	filters := opts.NameFilters
 
 	if len(registry) == 0 {
-		if !ShouldUseJSONOutput() {
+		if !ShouldUseJSONOutput() && !opts.Porcelain {
 			if ShouldUsePlainOutput() {
 				fmt.Println("No packages found in the registry.")
 				fmt.Println("[~] Downloading registry...")
@@ -560,13 +774,10 @@ func (ls *ListService) ListAllPackages(opts ListQueryOptions) {
 
 		// Try to download the registry
 		if err := ls.fileDownloader.DownloadAndUnzipRegistry(); err != nil {
-			if ShouldUseJSONOutput() {
-				result := map[string]any{
-					"type":    "all",
-					"error":   "failed to download registry",
-					"details": err.Error(),
-				}
-				PrintJSON(result)
+			if opts.Porcelain {
+				fmt.Fprintf(os.Stderr, "Failed to download registry: %v\n", err)
+			} else if ShouldUseJSONOutput() {
+				PrintJSON(listErrorJSON{Type: "all", Error: "failed to download registry", Details: err.Error()})
 			} else if ShouldUsePlainOutput() {
 				fmt.Printf("[✗] Failed to download registry: %v\n", err)
 				fmt.Println("[*] Use 'zana' (without flags) to download the registry manually.")
@@ -577,7 +788,7 @@ func (ls *ListService) ListAllPackages(opts ListQueryOptions) {
 			return
 		}
 
-		if !ShouldUseJSONOutput() {
+		if !ShouldUseJSONOutput() && !opts.Porcelain {
 			if ShouldUsePlainOutput() {
 				fmt.Println("[✓] Registry downloaded successfully!")
 				fmt.Println()
@@ -591,12 +802,10 @@ func (ls *ListService) ListAllPackages(opts ListQueryOptions) {
 		registry = ls.registry.GetData(true)
 
 		if len(registry) == 0 {
-			if ShouldUseJSONOutput() {
-				result := map[string]any{
-					"type":  "all",
-					"error": "still no packages found after downloading registry",
-				}
-				PrintJSON(result)
+			if opts.Porcelain {
+				fmt.Fprintln(os.Stderr, "Still no packages found after downloading registry.")
+			} else if ShouldUseJSONOutput() {
+				PrintJSON(listErrorJSON{Type: "all", Error: "still no packages found after downloading registry"})
 			} else if ShouldUsePlainOutput() {
 				fmt.Println("[✗] Still no packages found after downloading registry.")
 			} else {
@@ -645,14 +854,24 @@ func (ls *ListService) ListAllPackages(opts ListQueryOptions) {
 	}
 
 	filteredRegistry = ls.applyAdvancedFiltersToRegistry(filteredRegistry, opts)
+	total := len(filteredRegistry)
+	page := opts.paginate(filteredRegistry)
 
 	// Output based on mode
+	if opts.Porcelain {
+		ls.listAllPackagesPorcelain(os.Stdout, page)
+		return
+	}
 	if ShouldUseJSONOutput() {
-		ls.listAllPackagesJSON(filteredRegistry, opts)
-	} else if ShouldUsePlainOutput() {
-		ls.listAllPackagesPlain(filteredRegistry, opts)
+		ls.listAllPackagesJSON(page, total, opts)
+		return
+	}
+	out, closePager := startPager(opts.NoPager)
+	defer closePager()
+	if ShouldUsePlainOutput() {
+		ls.listAllPackagesPlain(out, page, total, opts)
 	} else {
-		ls.listAllPackagesRich(filteredRegistry, opts)
+		ls.listAllPackagesRich(out, page, total, opts)
 	}
 }
 
@@ -692,14 +911,17 @@ func (ls *ListService) applyAdvancedFiltersToRegistry(items []registry_parser.Re
 	return out
 }
 
-// listAllPackagesRich lists all packages with rich formatting using markdown tables
-func (ls *ListService) listAllPackagesRich(filteredRegistry []registry_parser.RegistryItem, opts ListQueryOptions) {
+// listAllPackagesRich lists all packages with rich formatting using markdown tables.
+// Each provider section is generated and rendered on its own, so output starts
+// streaming before the whole registry has been rendered.
+func (ls *ListService) listAllPackagesRich(w io.Writer, page []registry_parser.RegistryItem, total int, opts ListQueryOptions) {
 	var markdown strings.Builder
 	filters := opts.NameFilters
+	stream := newMarkdownStream(w)
 
 	markdown.WriteString(fmt.Sprintf("## %s All Available Packages\n\n", IconBookPlain()))
 
-	if len(filteredRegistry) == 0 {
+	if total == 0 {
 		if len(filters) > 0 || opts.hasAdvancedFilters() {
 			markdown.WriteString("No packages match the current criteria")
 			if len(filters) > 0 {
@@ -710,16 +932,18 @@ func (ls *ListService) listAllPackagesRich(filteredRegistry []registry_parser.Re
 		} else {
 			markdown.WriteString("No packages found in the registry.\n")
 		}
-		ls.renderMarkdown(markdown.String())
+		stream.render(markdown.String())
 		return
 	}
 
-	markdown.WriteString(fmt.Sprintf("Found **%d** packages in the registry", len(filteredRegistry)))
+	markdown.WriteString(fmt.Sprintf("Found **%d** packages in the registry", total))
 	if len(filters) > 0 {
 		markdown.WriteString(fmt.Sprintf(" matching name filters: %s", strings.Join(filters, ", ")))
 	}
 	markdown.WriteString(opts.constraintDescriptionMarkdown())
+	markdown.WriteString(opts.pageDescription(len(page)))
 	markdown.WriteString("\n\n")
+	stream.render(markdown.String())
 
 	// Get installed packages to check status
 	installedPackages := ls.localPackages.GetData(false).Packages
@@ -730,65 +954,80 @@ func (ls *ListService) listAllPackagesRich(filteredRegistry []registry_parser.Re
 
 	// Group packages by provider
 	packagesByProvider := make(map[string][]registry_parser.RegistryItem)
-	for _, pkg := range filteredRegistry {
+	for _, pkg := range page {
 		provider := getProviderFromSourceID(pkg.Source.ID)
 		packagesByProvider[provider] = append(packagesByProvider[provider], pkg)
 	}
 
 	// Display packages grouped by provider
-	providers := []string{"npm", "golang", "pypi", "cargo", "github", "gitlab", "codeberg", "gem", "composer", "luarocks", "nuget", "opam", "openvsx", "generic"}
+	providers := []string{"npm", "golang", "pypi", "cargo", "github", "gitlab", "codeberg", "gitea", "gem", "composer", "luarocks", "nuget", "opam", "openvsx", "generic", "local", "treesitter"}
 	for _, provider := range providers {
 		if packages, exists := packagesByProvider[provider]; exists {
-			markdown.WriteString(fmt.Sprintf("### %s %s Packages (%d)\n\n", IconDiamondPlain(), strings.ToUpper(provider), len(packages)))
-			markdown.WriteString("| Package ID | Version | Status | Description |\n")
-			markdown.WriteString("|------------|---------|--------|-------------|\n")
+			stream.render(ls.registrySectionMarkdown(provider, packages, installedMap))
+		}
+	}
+}
 
-			for _, pkg := range packages {
-				installedVersion, isInstalled := installedMap[pkg.Source.ID]
-
-				// Build status text
-				statusText := ""
-				if isInstalled {
-					updateInfo, hasUpdate := ls.checkUpdateAvailability(pkg.Source.ID, installedVersion)
-					if hasUpdate {
-						// Clean up update info for table display
-						statusText = strings.ReplaceAll(updateInfo, IconRefresh(), "")
-						statusText = strings.TrimSpace(statusText)
-						if statusText == "" {
-							statusText = "Update available"
-						}
-						// Highlight updates in markdown (icon + bold)
-						statusText = fmt.Sprintf("%s **%s**", IconRefreshPlain(), statusText)
-					} else {
-						statusText = fmt.Sprintf("%s Installed, up to date", IconCheckCirclePlain())
-					}
-				} else {
-					statusText = fmt.Sprintf("%s Not installed", IconEmptyPlain())
-				}
+// registrySectionMarkdown builds the markdown table of one provider's registry packages
+func (ls *ListService) registrySectionMarkdown(provider string, packages []registry_parser.RegistryItem, installedMap map[string]string) string {
+	var markdown strings.Builder
+	markdown.WriteString(fmt.Sprintf("### %s %s Packages (%d)\n\n", IconDiamondPlain(), strings.ToUpper(provider), len(packages)))
+	markdown.WriteString("| Package ID | Version | Status | Description |\n")
+	markdown.WriteString("|------------|---------|--------|-------------|\n")
 
-				// Escape pipe characters in description for markdown table
-				description := pkg.Description
-				if description != "" {
-					description = strings.ReplaceAll(description, "|", "\\|")
-				} else {
-					description = "—"
-				}
+	for _, pkg := range packages {
+		installedVersion, isInstalled := installedMap[pkg.Source.ID]
 
-				markdown.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", pkg.Source.ID, pkg.Version, statusText, description))
+		// Build status text
+		statusText := ""
+		if isInstalled {
+			updateInfo, hasUpdate := ls.checkUpdateAvailability(pkg.Source.ID, installedVersion)
+			if hasUpdate {
+				// Clean up update info for table display
+				statusText = strings.ReplaceAll(updateInfo, IconRefresh(), "")
+				statusText = strings.TrimSpace(statusText)
+				if statusText == "" {
+					statusText = "Update available"
+				}
+				// Highlight updates in markdown (icon + bold)
+				statusText = fmt.Sprintf("%s **%s**", IconRefreshPlain(), statusText)
+			} else {
+				statusText = fmt.Sprintf("%s Installed, up to date", IconCheckCirclePlain())
 			}
-			markdown.WriteString("\n")
+		} else {
+			statusText = fmt.Sprintf("%s Not installed", IconEmptyPlain())
 		}
-	}
 
-	ls.renderMarkdown(markdown.String())
+		// Escape pipe characters in description for markdown table
+		description := pkg.Description
+		if description != "" {
+			description = strings.ReplaceAll(description, "|", "\\|")
+		} else {
+			description = "—"
+		}
+
+		markdown.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", pkg.Source.ID, pkg.Version, statusText, description))
+	}
+	markdown.WriteString("\n")
+	return markdown.String()
 }
 
 // renderMarkdown renders markdown content using glamour
 func (ls *ListService) renderMarkdown(markdown string) {
+	newMarkdownStream(os.Stdout).render(markdown)
+}
+
+// markdownStream renders markdown chunks to w one at a time, reusing one renderer
+type markdownStream struct {
+	w io.Writer
+	r *glamour.TermRenderer
+}
+
+func newMarkdownStream(w io.Writer) *markdownStream {
 	// Get terminal width, default to 80 if not available
 	width := 80
-	if w, _, err := term.GetSize(os.Stdout.Fd()); err == nil && w > 0 {
-		width = w
+	if cols, _, err := term.GetSize(os.Stdout.Fd()); err == nil && cols > 0 {
+		width = cols
 	}
 
 	// Create a renderer with terminal width
@@ -797,93 +1036,90 @@ func (ls *ListService) renderMarkdown(markdown string) {
 		glamour.WithWordWrap(width),
 	)
 	if err != nil {
+		r = nil
+	}
+	return &markdownStream{w: w, r: r}
+}
+
+func (s *markdownStream) render(markdown string) {
+	if s.r == nil {
 		// Fallback to plain render
 		rendered, renderErr := glamour.Render(markdown, "dark")
 		if renderErr != nil {
-			fmt.Print(markdown)
+			fmt.Fprint(s.w, markdown)
 			return
 		}
-		fmt.Print(rendered)
+		fmt.Fprint(s.w, rendered)
 		return
 	}
 
-	rendered, err := r.Render(markdown)
+	rendered, err := s.r.Render(markdown)
 	if err != nil {
 		// Fallback to plain text if rendering fails
-		fmt.Print(markdown)
+		fmt.Fprint(s.w, markdown)
 		return
 	}
-	fmt.Print(rendered)
+	fmt.Fprint(s.w, rendered)
 }
 
 // listAllPackagesPlain lists all packages in plain text format
-func (ls *ListService) listAllPackagesPlain(filteredRegistry []registry_parser.RegistryItem, opts ListQueryOptions) {
+func (ls *ListService) listAllPackagesPlain(w io.Writer, page []registry_parser.RegistryItem, total int, opts ListQueryOptions) {
 	filters := opts.NameFilters
-	fmt.Printf("%s All Available Packages\n\n", IconBook())
+	fmt.Fprintf(w, "%s All Available Packages\n\n", IconBook())
 
-	if len(filteredRegistry) == 0 {
+	if total == 0 {
 		if len(filters) > 0 || opts.hasAdvancedFilters() {
-			fmt.Print("No packages match the current criteria")
+			fmt.Fprint(w, "No packages match the current criteria")
 			if len(filters) > 0 {
-				fmt.Printf(" (name filters: %s)", strings.Join(filters, ", "))
+				fmt.Fprintf(w, " (name filters: %s)", strings.Join(filters, ", "))
 			}
-			fmt.Println(opts.constraintDescriptionPlain() + ".")
+			fmt.Fprintln(w, opts.constraintDescriptionPlain()+".")
 		} else {
-			fmt.Println("No packages found in the registry.")
+			fmt.Fprintln(w, "No packages found in the registry.")
 		}
 		return
 	}
 
-	fmt.Printf("Found %d packages in the registry", len(filteredRegistry))
+	fmt.Fprintf(w, "Found %d packages in the registry", total)
 	if len(filters) > 0 {
-		fmt.Printf(" matching name filters: %s", strings.Join(filters, ", "))
-	}
-	fmt.Print(opts.constraintDescriptionPlain())
-	fmt.Printf(":\n\n")
-
-	// Get installed packages to check status
-	installedPackages := ls.localPackages.GetData(false).Packages
-	installedMap := make(map[string]string) // sourceID -> version
-	for _, pkg := range installedPackages {
-		installedMap[pkg.SourceID] = pkg.Version
+		fmt.Fprintf(w, " matching name filters: %s", strings.Join(filters, ", "))
 	}
+	fmt.Fprint(w, opts.constraintDescriptionPlain())
+	fmt.Fprint(w, opts.pageDescription(len(page)))
+	fmt.Fprintf(w, ":\n\n")
 
 	// Group packages by provider
 	packagesByProvider := make(map[string][]registry_parser.RegistryItem)
-	for _, pkg := range filteredRegistry {
+	for _, pkg := range page {
 		provider := getProviderFromSourceID(pkg.Source.ID)
 		packagesByProvider[provider] = append(packagesByProvider[provider], pkg)
 	}
 
-	providers := []string{"npm", "golang", "pypi", "cargo", "github", "gitlab", "codeberg", "gem", "composer", "luarocks", "nuget", "opam", "openvsx", "generic"}
+	providers := []string{"npm", "golang", "pypi", "cargo", "github", "gitlab", "codeberg", "gitea", "gem", "composer", "luarocks", "nuget", "opam", "openvsx", "generic", "local", "treesitter"}
 	for _, provider := range providers {
 		if packages, exists := packagesByProvider[provider]; exists {
-			fmt.Printf("%s %s Packages (%d):\n", IconDiamond(), strings.ToUpper(provider), len(packages))
+			fmt.Fprintf(w, "%s %s Packages (%d):\n", IconDiamond(), strings.ToUpper(provider), len(packages))
 			for _, pkg := range packages {
-				fmt.Printf("   %s %s (v%s)", getProviderIcon(provider), pkg.Source.ID, pkg.Version)
+				fmt.Fprintf(w, "   %s %s (v%s)", getProviderIcon(provider), pkg.Source.ID, pkg.Version)
 				if pkg.Description != "" {
-					fmt.Printf("\n      %s", pkg.Description)
+					fmt.Fprintf(w, "\n      %s", pkg.Description)
 				}
-				fmt.Println()
+				fmt.Fprintln(w)
 			}
-			fmt.Println()
+			fmt.Fprintln(w)
 		}
 	}
 }
 
 // listAllPackagesJSON lists all packages in JSON format
-func (ls *ListService) listAllPackagesJSON(filteredRegistry []registry_parser.RegistryItem, opts ListQueryOptions) {
-	filters := opts.NameFilters
-	result := make(map[string]any)
-	result["type"] = "all"
-	if len(filters) > 0 {
-		result["filters"] = filters
+func (ls *ListService) listAllPackagesJSON(filteredRegistry []registry_parser.RegistryItem, total int, opts ListQueryOptions) {
+	result := newListJSON[listRegistryPackageJSON]("all", opts)
+	if opts.isPaginated() {
+		offset := opts.Offset
+		result.Total, result.Offset, result.Limit = &total, &offset, opts.Limit
 	}
-	appendListQueryJSONFields(result, opts)
 
 	if len(filteredRegistry) == 0 {
-		result["count"] = 0
-		result["packages"] = []any{}
 		PrintJSON(result)
 		return
 	}
@@ -895,54 +1131,103 @@ func (ls *ListService) listAllPackagesJSON(filteredRegistry []registry_parser.Re
 		installedMap[pkg.SourceID] = pkg.Version
 	}
 
-	packagesData := make([]map[string]any, 0, len(filteredRegistry))
 	for _, pkg := range filteredRegistry {
-		packageName := getPackageNameFromSourceID(pkg.Source.ID)
-		provider := getProviderFromSourceID(pkg.Source.ID)
 		installedVersion, isInstalled := installedMap[pkg.Source.ID]
 
-		pkgData := map[string]any{
-			"source_id": pkg.Source.ID,
-			"name":      packageName,
-			"provider":  provider,
-			"version":   pkg.Version,
-			"installed": isInstalled,
+		pkgData := listRegistryPackageJSON{
+			SourceID:    pkg.Source.ID,
+			Name:        getPackageNameFromSourceID(pkg.Source.ID),
+			Provider:    getProviderFromSourceID(pkg.Source.ID),
+			Version:     pkg.Version,
+			Installed:   isInstalled,
+			Description: pkg.Description,
+			Homepage:    pkg.Homepage,
+			Repository:  providers.RepositoryURL(pkg.Source.ID),
 		}
 
 		if isInstalled {
-			pkgData["installed_version"] = installedVersion
+			pkgData.InstalledVersion = installedVersion
 			_, hasUpdate := ls.checkUpdateAvailability(pkg.Source.ID, installedVersion)
-			pkgData["has_update"] = hasUpdate
+			pkgData.HasUpdate = &hasUpdate
 		}
 
-		if pkg.Description != "" {
-			pkgData["description"] = pkg.Description
+		result.Packages = append(result.Packages, pkgData)
+	}
+
+	result.Count = len(filteredRegistry)
+	PrintJSON(result)
+}
+
+// listInstalledPackagesPorcelain prints one installed package per line:
+// source ID, version, latest version and whether it is outdated, plus the
+// binaries with --binaries. Fields are tab-separated and must stay stable.
+func (ls *ListService) listInstalledPackagesPorcelain(w io.Writer, filteredPackages []local_packages_parser.LocalPackageItem, opts ListQueryOptions) {
+	var binaries map[string][]string
+	if opts.ShowBinaries {
+		binaries = ls.binariesBySourceID(filteredPackages)
+	}
+	for _, pkg := range filteredPackages {
+		latest, hasUpdate := ls.latestVersion(pkg.SourceID, pkg.Version)
+		fields := []string{pkg.SourceID, porcelainField(pkg.Version), porcelainField(latest), fmt.Sprint(hasUpdate)}
+		if opts.ShowBinaries {
+			fields = append(fields, porcelainField(strings.Join(binaries[pkg.SourceID], ",")))
 		}
+		fmt.Fprintln(w, strings.Join(fields, "\t"))
+	}
+}
 
-		packagesData = append(packagesData, pkgData)
+// listAllPackagesPorcelain prints one registry package per line: source ID,
+// registry version, installed version and whether the installed version is
+// outdated. Fields are tab-separated and must stay stable.
+func (ls *ListService) listAllPackagesPorcelain(w io.Writer, page []registry_parser.RegistryItem) {
+	installedMap := make(map[string]string)
+	for _, pkg := range ls.localPackages.GetData(false).Packages {
+		installedMap[pkg.SourceID] = pkg.Version
+	}
+	for _, pkg := range page {
+		installed, outdated := "-", false
+		if version, ok := installedMap[pkg.Source.ID]; ok {
+			installed = porcelainField(version)
+			_, outdated = ls.latestVersion(pkg.Source.ID, version)
+		}
+		fmt.Fprintln(w, strings.Join([]string{pkg.Source.ID, porcelainField(pkg.Version), installed, fmt.Sprint(outdated)}, "\t"))
 	}
+}
 
-	result["count"] = len(filteredRegistry)
-	result["packages"] = packagesData
-	PrintJSON(result)
+// porcelainField keeps empty values from collapsing adjacent fields
+func porcelainField(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
 }
 
 // checkUpdateAvailability checks if an update is available for a package
 func (ls *ListService) checkUpdateAvailability(sourceID, currentVersion string) (string, bool) {
+	latestVersion, updateAvailable := ls.latestVersion(sourceID, currentVersion)
+	if latestVersion == "" {
+		return "", false // No registry info available
+	}
+	if updateAvailable {
+		return fmt.Sprintf("%s Update available: v%s", IconRefresh(), latestVersion), true
+	}
+	return IconCheckCircle() + " Up to date", false
+}
+
+// latestVersion returns the newest registry version for a package and whether
+// it is an update over currentVersion. The version is empty without registry info.
+func (ls *ListService) latestVersion(sourceID, currentVersion string) (string, bool) {
 	stable, prerelease := ls.registry.GetLatestVersions(sourceID)
 	if stable == "" && prerelease == "" {
-		return "", false // No registry info available
+		return "", false
 	}
 	latestVersion := chooseBestRemoteVersion(currentVersion, stable, prerelease)
 	// If local version is unknown or set to "latest", always show update to the concrete remote version
 	if currentVersion == "" || currentVersion == "latest" {
-		return fmt.Sprintf("%s Update available: v%s", IconRefresh(), latestVersion), true
+		return latestVersion, true
 	}
 	updateAvailable, _ := ls.updateChecker.CheckIfUpdateIsAvailable(currentVersion, latestVersion)
-	if updateAvailable {
-		return fmt.Sprintf("%s Update available: v%s", IconRefresh(), latestVersion), true
-	}
-	return IconCheckCircle() + " Up to date", false
+	return latestVersion, updateAvailable
 }
 
 // Default implementations for backward compatibility
@@ -998,38 +1283,20 @@ func checkUpdateAvailability(sourceID, currentVersion string) (string, bool) {
 }
 
 func getProviderFromSourceID(sourceID string) string {
-	sourceID = strings.TrimSpace(sourceID)
-	if sourceID == "" {
-		return "unknown"
-	}
-	if strings.HasPrefix(sourceID, "pkg:") {
-		rest := strings.TrimPrefix(sourceID, "pkg:")
-		idx := strings.Index(rest, "/")
-		if idx <= 0 || idx >= len(rest)-1 {
-			return "unknown"
-		}
-		return strings.ToLower(rest[:idx])
-	}
-	idx := strings.Index(sourceID, ":")
-	if idx <= 0 {
+	provider, name := packageid.Split(strings.TrimSpace(sourceID))
+	if provider == "" || name == "" {
 		return "unknown"
 	}
-	return strings.ToLower(sourceID[:idx])
+	return strings.ToLower(provider)
 }
 
 func getPackageNameFromSourceID(sourceID string) string {
-	// Support new format: provider:pkg
-	if strings.Contains(sourceID, ":") && !strings.HasPrefix(sourceID, "pkg:") {
-		parts := strings.SplitN(sourceID, ":", 2)
-		if len(parts) == 2 {
-			return parts[1]
-		}
+	if _, name := packageid.Split(sourceID); name != "" {
+		return name
 	}
-	// Legacy format: pkg:provider/pkg
-	withoutPrefix := strings.TrimPrefix(sourceID, "pkg:")
-	parts := strings.SplitN(withoutPrefix, "/", 2)
-	if len(parts) >= 2 {
-		return parts[1]
+	// IDs without provider separator, e.g. npm/package-name
+	if _, name, ok := strings.Cut(sourceID, "/"); ok {
+		return name
 	}
 	return sourceID
 }
@@ -1050,6 +1317,8 @@ func getProviderIcon(provider string) string {
 		return IconGitLab()
 	case "codeberg":
 		return IconCodeberg()
+	case "gitea":
+		return IconGitea()
 	case "gem":
 		return IconGem()
 	case "composer":
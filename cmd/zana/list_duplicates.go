@@ -0,0 +1,137 @@
+package zana
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/spinnerutil"
+)
+
+// indirections for testability
+var (
+	findDuplicateToolsFn = providers.FindDuplicateTools
+	chooseDuplicateFn    = chooseDuplicateToKeep
+)
+
+// duplicatesJSON is the JSON output of zana ls --duplicates
+type duplicatesJSON struct {
+	SchemaVersion jsonSchemaVersion         `json:"schema_version"`
+	Duplicates    []providers.DuplicateTool `json:"duplicates"`
+}
+
+// ListDuplicates implements zana ls --duplicates: installed packages that are
+// the same tool from different providers (e.g. cargo:stylua and
+// github:JohnnyMorganz/StyLua) are listed with the executable that wins on
+// PATH. On a terminal, removing the redundant packages is offered.
+func (ls *ListService) ListDuplicates(opts ListQueryOptions) {
+	packages := filterInstalledPackagesByName(ls.localPackages.GetData(true).Packages, opts.NameFilters)
+	packages = ls.applyAdvancedFiltersToInstalled(packages, opts)
+	bySourceID := map[string]registry_parser.RegistryItem{}
+	for _, item := range ls.registry.GetData(false) {
+		bySourceID[item.Source.ID] = item
+	}
+	duplicates := findDuplicateToolsFn(packages, func(sourceID string) registry_parser.RegistryItem {
+		return bySourceID[sourceID]
+	})
+
+	if ShouldUseJSONOutput() {
+		if duplicates == nil {
+			duplicates = []providers.DuplicateTool{}
+		}
+		PrintJSON(duplicatesJSON{Duplicates: duplicates})
+		return
+	}
+	if len(duplicates) == 0 {
+		fmt.Println("No tool is installed through more than one provider.")
+		return
+	}
+	for i, d := range duplicates {
+		if i > 0 {
+			fmt.Println()
+		}
+		printDuplicateTool(d)
+	}
+	if ShouldUsePlainOutput() || !canPromptForSelection() {
+		return
+	}
+	for _, d := range duplicates {
+		keep, ok := chooseDuplicateFn(d)
+		if !ok {
+			continue
+		}
+		for _, pkg := range d.Packages {
+			if pkg.SourceID != keep {
+				removeDuplicate(pkg.SourceID)
+			}
+		}
+	}
+}
+
+func printDuplicateTool(d providers.DuplicateTool) {
+	fmt.Printf("%s is installed through %d providers:\n", d.Name, len(d.Packages))
+	for _, pkg := range d.Packages {
+		line := fmt.Sprintf("  %s@%s (%s)", pkg.SourceID, pkg.Version, formatBinaries(pkg.Binaries))
+		if pkg.Wins {
+			line += ", runs"
+		}
+		fmt.Println(line)
+	}
+	for _, bin := range d.Shared {
+		switch {
+		case bin.Path == "":
+			fmt.Printf("  %s: not on PATH\n", bin.Name)
+		case bin.Owner == "":
+			fmt.Printf("  %s: %s runs, none of the packages'\n", bin.Name, bin.Path)
+		default:
+			fmt.Printf("  %s: %s runs (%s)\n", bin.Name, bin.Path, bin.Owner)
+		}
+	}
+	if redundant := d.Redundant(); len(redundant) > 0 {
+		if ShouldUsePlainOutput() {
+			fmt.Printf("  Redundant: zana remove %s\n", strings.Join(redundant, " "))
+		} else {
+			fmt.Printf("  %s Remove the redundant package with: zana remove %s\n", IconLightbulb(), strings.Join(redundant, " "))
+		}
+	}
+}
+
+// chooseDuplicateToKeep asks which package of d to keep, the others get
+// removed. ok is false when all of them are kept.
+func chooseDuplicateToKeep(d providers.DuplicateTool) (keep string, ok bool) {
+	options := []huh.Option[string]{huh.NewOption("Keep all", "")}
+	for _, pkg := range d.Packages {
+		label := "Keep " + pkg.SourceID
+		if pkg.Wins {
+			label += " (runs)"
+		}
+		options = append(options, huh.NewOption(label, pkg.SourceID))
+	}
+	keep = d.Winner
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title(fmt.Sprintf("%s is installed through %d providers", d.Name, len(d.Packages))).
+				Description("The other packages are removed").
+				Options(options...).
+				Value(&keep),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return "", false
+	}
+	return keep, keep != ""
+}
+
+func removeDuplicate(sourceID string) {
+	var success bool
+	if err := spinnerutil.Run(fmt.Sprintf("Removing %s...", sourceID), func() {
+		success = removePackageFn(sourceID)
+	}); err != nil || !success {
+		fmt.Printf("%s Failed to remove %s\n", IconClose(), sourceID)
+		return
+	}
+	fmt.Printf("%s Successfully removed %s\n", IconCheck(), sourceID)
+}
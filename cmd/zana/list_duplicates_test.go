@@ -0,0 +1,104 @@
+package zana
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubDuplicates(t *testing.T, interactive bool, keep string) (*ListService, *[]string) {
+	t.Helper()
+	installed := []local_packages_parser.LocalPackageItem{
+		{SourceID: "cargo:stylua", Version: "0.20.0"},
+		{SourceID: "github:JohnnyMorganz/StyLua", Version: "v0.20.0"},
+	}
+	mockLocal := &MockLocalPackagesProvider{
+		GetDataFunc: func(force bool) local_packages_parser.LocalPackageRoot {
+			return local_packages_parser.LocalPackageRoot{Packages: installed}
+		},
+	}
+	mockRegistry := &MockRegistryProvider{
+		GetDataFunc: func(force bool) []registry_parser.RegistryItem {
+			return []registry_parser.RegistryItem{{Name: "stylua", Source: registry_parser.RegistryItemSource{ID: "cargo:stylua"}}}
+		},
+	}
+	svc := NewListServiceWithDependencies(mockLocal, mockRegistry, &MockUpdateChecker{}, &MockFileDownloader{})
+
+	origFind, origChoose, origRemove, origPrompt := findDuplicateToolsFn, chooseDuplicateFn, removePackageFn, canPromptForSelection
+	t.Cleanup(func() {
+		findDuplicateToolsFn, chooseDuplicateFn, removePackageFn, canPromptForSelection = origFind, origChoose, origRemove, origPrompt
+	})
+	findDuplicateToolsFn = func(packages []local_packages_parser.LocalPackageItem, lookup func(string) registry_parser.RegistryItem) []providers.DuplicateTool {
+		if len(packages) < 2 || lookup("cargo:stylua").Name != "stylua" {
+			return nil
+		}
+		return []providers.DuplicateTool{{
+			Name: "stylua",
+			Packages: []providers.DuplicatePackage{
+				{SourceID: "cargo:stylua", Version: "0.20.0", Binaries: []string{"stylua"}},
+				{SourceID: "github:JohnnyMorganz/StyLua", Version: "v0.20.0", Binaries: []string{"stylua"}, Wins: true},
+			},
+			Shared: []providers.DuplicateBinary{{Name: "stylua", Path: "/zana/bin/github/stylua", Owner: "github:JohnnyMorganz/StyLua"}},
+			Winner: "github:JohnnyMorganz/StyLua",
+		}}
+	}
+	canPromptForSelection = func() bool { return interactive }
+	chooseDuplicateFn = func(d providers.DuplicateTool) (string, bool) { return keep, keep != "" }
+	removed := &[]string{}
+	removePackageFn = func(sourceID string) bool {
+		*removed = append(*removed, sourceID)
+		return true
+	}
+	return svc, removed
+}
+
+func TestListDuplicates(t *testing.T) {
+	t.Run("reports the winner and the redundant package", func(t *testing.T) {
+		svc, removed := stubDuplicates(t, false, "")
+		out := captureOutputWithMode(t, func() { svc.ListDuplicates(ListQueryOptions{}) }, config.OutputModePlain)
+		assert.Contains(t, out, "stylua is installed through 2 providers:")
+		assert.Contains(t, out, "github:JohnnyMorganz/StyLua@v0.20.0 (stylua), runs")
+		assert.Contains(t, out, "stylua: /zana/bin/github/stylua runs (github:JohnnyMorganz/StyLua)")
+		assert.Contains(t, out, "Redundant: zana remove cargo:stylua")
+		assert.Empty(t, *removed)
+	})
+
+	t.Run("removes the packages not kept", func(t *testing.T) {
+		svc, removed := stubDuplicates(t, true, "github:JohnnyMorganz/StyLua")
+		out := captureOutputWithMode(t, func() { svc.ListDuplicates(ListQueryOptions{}) }, config.OutputModeRich)
+		assert.Equal(t, []string{"cargo:stylua"}, *removed)
+		assert.Contains(t, out, "Successfully removed cargo:stylua")
+	})
+
+	t.Run("keep all removes nothing", func(t *testing.T) {
+		svc, removed := stubDuplicates(t, true, "")
+		captureOutputWithMode(t, func() { svc.ListDuplicates(ListQueryOptions{}) }, config.OutputModeRich)
+		assert.Empty(t, *removed)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		svc, _ := stubDuplicates(t, true, "cargo:stylua")
+		out := captureOutputWithMode(t, func() { svc.ListDuplicates(ListQueryOptions{}) }, config.OutputModeJSON)
+		var got struct {
+			Duplicates []providers.DuplicateTool `json:"duplicates"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &got))
+		require.Len(t, got.Duplicates, 1)
+		assert.Equal(t, "github:JohnnyMorganz/StyLua", got.Duplicates[0].Winner)
+	})
+
+	t.Run("nothing found", func(t *testing.T) {
+		svc, _ := stubDuplicates(t, false, "")
+		out := captureOutputWithMode(t, func() { svc.ListDuplicates(ListQueryOptions{NameFilters: []string{"cargo:"}}) }, config.OutputModePlain)
+		assert.Contains(t, out, "No tool is installed through more than one provider.")
+
+		out = captureOutputWithMode(t, func() { svc.ListDuplicates(ListQueryOptions{NameFilters: []string{"cargo:"}}) }, config.OutputModeJSON)
+		assert.Contains(t, out, `"duplicates": []`)
+	})
+}
@@ -0,0 +1,118 @@
+package zana
+
+import (
+	"fmt"
+
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+)
+
+// indirections for testability
+var (
+	checkIntegrityFn     = providers.CheckIntegrity
+	integrityReinstallFn = func(sourceID, version string) bool { return providers.SyncPackage(sourceID) == nil }
+)
+
+// CheckInstalledIntegrity implements zana ls --check-integrity: the executables of
+// the installed packages are re-hashed and compared with the hashes recorded in the
+// lock file. With repair, modified packages are synced again, see providers.SyncPackage.
+func (ls *ListService) CheckInstalledIntegrity(opts ListQueryOptions, repair bool) {
+	packages := filterInstalledPackagesByName(ls.localPackages.GetData(true).Packages, opts.NameFilters)
+	packages = ls.applyAdvancedFiltersToInstalled(packages, opts)
+	results := checkIntegrityFn(packages)
+
+	repaired := map[string]bool{}
+	if repair {
+		var recheck []local_packages_parser.LocalPackageItem
+		for _, r := range results {
+			if r.Status != providers.IntegrityModified {
+				continue
+			}
+			if !ShouldUseJSONOutput() {
+				fmt.Printf("%s Reinstalling %s@%s\n", IconRefresh(), r.SourceID, r.Version)
+			}
+			if integrityReinstallFn(r.SourceID, r.Version) {
+				repaired[r.SourceID] = true
+			}
+		}
+		if len(repaired) > 0 {
+			for _, pkg := range ls.localPackages.GetData(true).Packages {
+				if repaired[pkg.SourceID] {
+					recheck = append(recheck, pkg)
+				}
+			}
+			rechecked := map[string]providers.PackageIntegrity{}
+			for _, r := range checkIntegrityFn(recheck) {
+				rechecked[r.SourceID] = r
+			}
+			for i, r := range results {
+				if updated, ok := rechecked[r.SourceID]; ok {
+					results[i] = updated
+				}
+			}
+		}
+	}
+
+	failed := false
+	for _, r := range results {
+		if r.Status == providers.IntegrityModified {
+			failed = true
+		}
+	}
+
+	if ShouldUseJSONOutput() {
+		out := map[string]any{
+			"schema_version": JSONSchemaVersion,
+			"success":        !failed,
+			"packages":       results,
+		}
+		if repair {
+			ids := []string{}
+			for _, r := range results {
+				if repaired[r.SourceID] {
+					ids = append(ids, r.SourceID)
+				}
+			}
+			out["repaired"] = ids
+		}
+		PrintJSON(out)
+	} else {
+		printIntegrityResults(results, repaired)
+	}
+	if failed {
+		osExit(1)
+	}
+}
+
+func printIntegrityResults(results []providers.PackageIntegrity, repaired map[string]bool) {
+	if len(results) == 0 {
+		fmt.Println("No installed packages to check.")
+		return
+	}
+	unrecorded := 0
+	for _, r := range results {
+		switch r.Status {
+		case providers.IntegrityOK:
+			if repaired[r.SourceID] {
+				fmt.Printf("%s %s@%s (repaired)\n", IconCheck(), r.SourceID, r.Version)
+			} else {
+				fmt.Printf("%s %s@%s\n", IconCheck(), r.SourceID, r.Version)
+			}
+		case providers.IntegrityUnrecorded:
+			unrecorded++
+			fmt.Printf("%s %s@%s: no hashes recorded\n", IconAlert(), r.SourceID, r.Version)
+		default:
+			fmt.Printf("%s %s@%s\n", IconClose(), r.SourceID, r.Version)
+			for _, p := range r.Problems {
+				if p.Problem == "missing" {
+					fmt.Printf("    %s is missing\n", p.Path)
+				} else {
+					fmt.Printf("    %s was modified (expected sha256 %s, got %s)\n", p.Path, p.Expected, p.Actual)
+				}
+			}
+		}
+	}
+	if unrecorded > 0 {
+		fmt.Printf("\nHashes are recorded when a package is installed or updated; reinstall a package to record them.\n")
+	}
+}
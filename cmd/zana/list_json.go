@@ -0,0 +1,68 @@
+package zana
+
+import "github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+
+// listJSON is the --json output of zana ls (type installed) and
+// zana ls --all (type all)
+type listJSON[P any] struct {
+	SchemaVersion  jsonSchemaVersion `json:"schema_version"`
+	Type           string            `json:"type"`
+	Filters        []string          `json:"filters,omitempty"`
+	OnlyOutdated   bool              `json:"only_outdated,omitempty"`
+	OnlyProviders  []string          `json:"only_providers,omitempty"`
+	OnlyCategories []string          `json:"only_categories,omitempty"`
+	// Total, Offset and Limit are set for --offset/--limit pages of zana ls --all
+	Total            *int `json:"total,omitempty"`
+	Offset           *int `json:"offset,omitempty"`
+	Limit            int  `json:"limit,omitempty"`
+	Count            int  `json:"count"`
+	Packages         []P  `json:"packages"`
+	UpdatesAvailable *int `json:"updates_available,omitempty"`
+}
+
+// listInstalledPackageJSON is an installed package in the output of zana ls
+type listInstalledPackageJSON struct {
+	SourceID  string `json:"source_id"`
+	Name      string `json:"name"`
+	Provider  string `json:"provider"`
+	Version   string `json:"version"`
+	HasUpdate bool   `json:"has_update"`
+	// Binaries is set with --binaries
+	Binaries *[]string `json:"binaries,omitempty"`
+	// Deprecated is set when the registry deprecates the package
+	Deprecated *registry_parser.RegistryItemDeprecation `json:"deprecated,omitempty"`
+}
+
+// listRegistryPackageJSON is a registry package in the output of zana ls --all
+type listRegistryPackageJSON struct {
+	SourceID         string `json:"source_id"`
+	Name             string `json:"name"`
+	Provider         string `json:"provider"`
+	Version          string `json:"version"`
+	Installed        bool   `json:"installed"`
+	InstalledVersion string `json:"installed_version,omitempty"`
+	HasUpdate        *bool  `json:"has_update,omitempty"`
+	Description      string `json:"description,omitempty"`
+	Homepage         string `json:"homepage,omitempty"`
+	Repository       string `json:"repository,omitempty"`
+}
+
+// listErrorJSON is the output of zana ls --all when the registry is missing
+type listErrorJSON struct {
+	SchemaVersion jsonSchemaVersion `json:"schema_version"`
+	Type          string            `json:"type"`
+	Error         string            `json:"error"`
+	Details       string            `json:"details,omitempty"`
+}
+
+// newListJSON returns the output of zana ls for the query options
+func newListJSON[P any](listType string, o ListQueryOptions) listJSON[P] {
+	result := listJSON[P]{Type: listType, Filters: o.NameFilters, OnlyOutdated: o.OnlyOutdated, Packages: []P{}}
+	if len(o.OnlyProviders) > 0 {
+		result.OnlyProviders = append([]string(nil), o.OnlyProviders...)
+	}
+	if len(o.OnlyCategories) > 0 {
+		result.OnlyCategories = append([]string(nil), o.OnlyCategories...)
+	}
+	return result
+}
@@ -2,6 +2,7 @@ package zana
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"github.com/mistweaverco/zana-client/internal/config"
 	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
@@ -139,6 +141,14 @@ func (t *testFileSystemWrapper) Stat(name string) (os.FileInfo, error) {
 	return t.fs.Stat(name)
 }
 
+func (t *testFileSystemWrapper) Rename(oldpath, newpath string) error {
+	return t.fs.Rename(oldpath, newpath)
+}
+
+func (t *testFileSystemWrapper) Remove(name string) error {
+	return t.fs.Remove(name)
+}
+
 func (t *testFileSystemWrapper) UserConfigDir() (string, error) {
 	return "/tmp/zana_test", nil
 }
@@ -500,6 +510,165 @@ func TestListInstalledPackagesAdvancedFilters(t *testing.T) {
 	assert.Contains(t, out4, "No installed packages match")
 }
 
+func TestListInstalledPackagesBinaries(t *testing.T) {
+	mockLocal := &MockLocalPackagesProvider{
+		GetDataFunc: func(force bool) local_packages_parser.LocalPackageRoot {
+			return local_packages_parser.LocalPackageRoot{
+				Packages: []local_packages_parser.LocalPackageItem{
+					{SourceID: "npm:pkg-a", Version: "1.0.0"},
+					{SourceID: "pypi:pkg-b", Version: "2.0.0"},
+				},
+			}
+		},
+	}
+	mockRegistry := &MockRegistryProvider{
+		GetDataFunc: func(force bool) []registry_parser.RegistryItem {
+			return []registry_parser.RegistryItem{
+				{Source: registry_parser.RegistryItemSource{ID: "npm:pkg-a"}, Bin: map[string]string{"pkg-a": "x"}},
+				{Source: registry_parser.RegistryItemSource{ID: "pypi:pkg-b"}},
+			}
+		},
+	}
+	origPackageBinaries := packageBinariesFn
+	packageBinariesFn = func(_ string, item registry_parser.RegistryItem) []string {
+		names := []string{}
+		for name := range item.Bin {
+			names = append(names, name)
+		}
+		return names
+	}
+	defer func() { packageBinariesFn = origPackageBinaries }()
+
+	svc := NewListServiceWithDependencies(mockLocal, mockRegistry, &MockUpdateChecker{}, &MockFileDownloader{})
+
+	out := captureOutput(t, func() {
+		svc.ListInstalledPackages(ListQueryOptions{ShowBinaries: true})
+	})
+	assert.Contains(t, out, "binaries: pkg-a")
+	assert.Contains(t, out, "binaries: -")
+
+	out2 := captureOutput(t, func() {
+		svc.ListInstalledPackages(ListQueryOptions{})
+	})
+	assert.NotContains(t, out2, "binaries:")
+
+	out3 := captureOutputWithMode(t, func() {
+		svc.ListInstalledPackages(ListQueryOptions{ShowBinaries: true})
+	}, config.OutputModeJSON)
+	var result map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(out3), &result))
+	packages := result["packages"].([]any)
+	assert.Equal(t, []any{"pkg-a"}, packages[0].(map[string]any)["binaries"])
+	assert.Equal(t, []any{}, packages[1].(map[string]any)["binaries"])
+}
+
+func TestListBinariesBySourceID(t *testing.T) {
+	mockRegistry := &MockRegistryProvider{
+		GetDataFunc: func(force bool) []registry_parser.RegistryItem {
+			return []registry_parser.RegistryItem{
+				{Source: registry_parser.RegistryItemSource{ID: "pkg:npm/legacy"}, Bin: map[string]string{"legacy": "x"}},
+				{Source: registry_parser.RegistryItemSource{ID: "npm:prettier"}, Bin: map[string]string{"prettier": "x"}},
+			}
+		},
+	}
+	origPackageBinaries := packageBinariesFn
+	packageBinariesFn = func(sourceID string, item registry_parser.RegistryItem) []string {
+		names := []string{}
+		for name := range item.Bin {
+			names = append(names, name)
+		}
+		if len(names) == 0 {
+			names = append(names, "scanned:"+sourceID)
+		}
+		return names
+	}
+	defer func() { packageBinariesFn = origPackageBinaries }()
+
+	svc := NewListServiceWithDependencies(&MockLocalPackagesProvider{}, mockRegistry, &MockUpdateChecker{}, &MockFileDownloader{})
+	binaries := svc.binariesBySourceID([]local_packages_parser.LocalPackageItem{
+		{SourceID: "npm:legacy"},
+		{SourceID: "github:prettier/prettier", Extras: &local_packages_parser.PackageExtras{OverrideOf: "npm:prettier"}},
+		{SourceID: "local:mytool"},
+	})
+	assert.Equal(t, map[string][]string{
+		"npm:legacy":               {"legacy"},
+		"github:prettier/prettier": {"prettier"},
+		"local:mytool":             {"scanned:local:mytool"},
+	}, binaries)
+}
+
+func TestListPorcelain(t *testing.T) {
+	mockLocal := &MockLocalPackagesProvider{
+		GetDataFunc: func(force bool) local_packages_parser.LocalPackageRoot {
+			return local_packages_parser.LocalPackageRoot{
+				Packages: []local_packages_parser.LocalPackageItem{
+					{SourceID: "npm:pkg-a", Version: "1.0.0"},
+					{SourceID: "pypi:pkg-b", Version: "2.0.0"},
+				},
+			}
+		},
+	}
+	mockRegistry := &MockRegistryProvider{
+		GetDataFunc: func(force bool) []registry_parser.RegistryItem {
+			return []registry_parser.RegistryItem{
+				{Source: registry_parser.RegistryItemSource{ID: "npm:pkg-a"}, Version: "1.1.0", Bin: map[string]string{"pkg-a": "x"}},
+				{Source: registry_parser.RegistryItemSource{ID: "pypi:pkg-b"}},
+				{Source: registry_parser.RegistryItemSource{ID: "cargo:pkg-c"}, Version: "3.0.0"},
+			}
+		},
+		GetLatestVersionsFunc: func(sourceID string) (string, string) {
+			if sourceID == "npm:pkg-a" {
+				return "1.1.0", ""
+			}
+			return "", ""
+		},
+	}
+	mockUpdate := &MockUpdateChecker{
+		CheckIfUpdateIsAvailableFunc: func(currentVersion, latestVersion string) (bool, string) {
+			return currentVersion != latestVersion, latestVersion
+		},
+	}
+	origPackageBinaries := packageBinariesFn
+	packageBinariesFn = func(_ string, item registry_parser.RegistryItem) []string {
+		names := []string{}
+		for name := range item.Bin {
+			names = append(names, name)
+		}
+		return names
+	}
+	defer func() { packageBinariesFn = origPackageBinaries }()
+
+	svc := NewListServiceWithDependencies(mockLocal, mockRegistry, mockUpdate, &MockFileDownloader{})
+
+	t.Run("installed", func(t *testing.T) {
+		out := captureOutput(t, func() {
+			svc.ListInstalledPackages(ListQueryOptions{Porcelain: true})
+		})
+		assert.Equal(t, "npm:pkg-a\t1.0.0\t1.1.0\ttrue\npypi:pkg-b\t2.0.0\t-\tfalse\n", out)
+	})
+
+	t.Run("installed with binaries", func(t *testing.T) {
+		out := captureOutputWithMode(t, func() {
+			svc.ListInstalledPackages(ListQueryOptions{Porcelain: true, ShowBinaries: true})
+		}, config.OutputModeRich)
+		assert.Equal(t, "npm:pkg-a\t1.0.0\t1.1.0\ttrue\tpkg-a\npypi:pkg-b\t2.0.0\t-\tfalse\t-\n", out)
+	})
+
+	t.Run("all", func(t *testing.T) {
+		out := captureOutput(t, func() {
+			svc.ListAllPackages(ListQueryOptions{Porcelain: true, NoPager: true})
+		})
+		assert.Equal(t, "npm:pkg-a\t1.1.0\t1.0.0\ttrue\npypi:pkg-b\t-\t2.0.0\tfalse\ncargo:pkg-c\t3.0.0\t-\tfalse\n", out)
+	})
+
+	t.Run("no matches prints nothing", func(t *testing.T) {
+		out := captureOutput(t, func() {
+			svc.ListInstalledPackages(ListQueryOptions{Porcelain: true, NameFilters: []string{"missing"}})
+		})
+		assert.Empty(t, out)
+	})
+}
+
 func TestListAllPackagesAdvancedFilters(t *testing.T) {
 	mockLocal := &MockLocalPackagesProvider{
 		GetDataFunc: func(force bool) local_packages_parser.LocalPackageRoot {
@@ -1087,3 +1256,114 @@ func TestListCommandFullOutputGolden(t *testing.T) {
 		}
 	})
 }
+
+func TestListAllPackagesPagination(t *testing.T) {
+	mockRegistry := &MockRegistryProvider{
+		GetDataFunc: func(force bool) []registry_parser.RegistryItem {
+			items := []registry_parser.RegistryItem{}
+			for _, id := range []string{"npm:a", "npm:b", "npm:c", "pypi:d", "pypi:e"} {
+				items = append(items, registry_parser.RegistryItem{Source: registry_parser.RegistryItemSource{ID: id}, Version: "1.0.0"})
+			}
+			return items
+		},
+	}
+	svc := NewListServiceWithDependencies(&MockLocalPackagesProvider{}, mockRegistry, &MockUpdateChecker{}, &MockFileDownloader{})
+	opts := ListQueryOptions{Offset: 2, Limit: 2}
+
+	out := captureOutput(t, func() { svc.ListAllPackages(opts) })
+	assert.Contains(t, out, "Found 5 packages in the registry (showing 3–4):")
+	assert.Contains(t, out, "NPM Packages (1):")
+	assert.Contains(t, out, "npm:c")
+	assert.Contains(t, out, "pypi:d")
+	assert.NotContains(t, out, "npm:b")
+	assert.NotContains(t, out, "pypi:e")
+
+	out = captureOutputWithMode(t, func() { svc.ListAllPackages(opts) }, config.OutputModeRich)
+	assert.Contains(t, out, "showing 3–4")
+	assert.Contains(t, out, "pypi:d")
+	assert.NotContains(t, out, "pypi:e")
+
+	out = captureOutputWithMode(t, func() { svc.ListAllPackages(opts) }, config.OutputModeJSON)
+	var result map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	assert.Equal(t, float64(2), result["count"])
+	assert.Equal(t, float64(5), result["total"])
+	assert.Equal(t, float64(2), result["offset"])
+	assert.Equal(t, float64(2), result["limit"])
+
+	out = captureOutput(t, func() { svc.ListAllPackages(ListQueryOptions{Offset: 10}) })
+	assert.Contains(t, out, "Found 5 packages in the registry (none after offset 10):")
+}
+
+func TestListQueryOptionsRejectNegativePagination(t *testing.T) {
+	defer listCmd.Flags().Set("limit", "0")
+	require.NoError(t, listCmd.Flags().Set("limit", "-1"))
+	_, err := listQueryOptionsFromFlags(listCmd, nil)
+	assert.ErrorContains(t, err, "must not be negative")
+}
+
+func TestCheckInstalledIntegrity(t *testing.T) {
+	installed := []local_packages_parser.LocalPackageItem{
+		{SourceID: "github:owner/tool", Version: "v1.0.0"},
+		{SourceID: "npm:pkg", Version: "2.0.0"},
+	}
+	mockLocal := &MockLocalPackagesProvider{
+		GetDataFunc: func(force bool) local_packages_parser.LocalPackageRoot {
+			return local_packages_parser.LocalPackageRoot{Packages: installed}
+		},
+	}
+	svc := NewListServiceWithDependencies(mockLocal, &MockRegistryProvider{}, &MockUpdateChecker{}, &MockFileDownloader{})
+
+	tampered := true
+	origCheck, origReinstall, origExit := checkIntegrityFn, integrityReinstallFn, osExit
+	t.Cleanup(func() { checkIntegrityFn, integrityReinstallFn, osExit = origCheck, origReinstall, origExit })
+	checkIntegrityFn = func(packages []local_packages_parser.LocalPackageItem) []providers.PackageIntegrity {
+		var results []providers.PackageIntegrity
+		for _, pkg := range packages {
+			r := providers.PackageIntegrity{SourceID: pkg.SourceID, Version: pkg.Version, Status: providers.IntegrityOK}
+			switch {
+			case pkg.SourceID == "npm:pkg":
+				r.Status = providers.IntegrityUnrecorded
+			case tampered:
+				r.Status = providers.IntegrityModified
+				r.Problems = []providers.BinIntegrityProblem{{Name: "tool", Path: "/bin/tool", Problem: "modified", Expected: "aa", Actual: "bb"}}
+			}
+			results = append(results, r)
+		}
+		return results
+	}
+	var reinstalled []string
+	integrityReinstallFn = func(sourceID, version string) bool {
+		reinstalled = append(reinstalled, sourceID+"@"+version)
+		tampered = false
+		return true
+	}
+	exitCode := 0
+	osExit = func(code int) { exitCode = code }
+
+	t.Run("reports mismatches and exits 1", func(t *testing.T) {
+		out := captureOutput(t, func() { svc.CheckInstalledIntegrity(ListQueryOptions{}, false) })
+		assert.Contains(t, out, "/bin/tool was modified (expected sha256 aa, got bb)")
+		assert.Contains(t, out, "npm:pkg@2.0.0: no hashes recorded")
+		assert.Equal(t, 1, exitCode)
+		assert.Empty(t, reinstalled)
+	})
+
+	t.Run("filters by name", func(t *testing.T) {
+		exitCode = 0
+		out := captureOutput(t, func() { svc.CheckInstalledIntegrity(ListQueryOptions{NameFilters: []string{"pkg"}}, false) })
+		assert.NotContains(t, out, "github:owner/tool")
+		assert.Equal(t, 0, exitCode)
+	})
+
+	t.Run("repair reinstalls the locked version", func(t *testing.T) {
+		exitCode = 0
+		out := captureOutputWithMode(t, func() { svc.CheckInstalledIntegrity(ListQueryOptions{}, true) }, config.OutputModeJSON)
+		assert.Equal(t, []string{"github:owner/tool@v1.0.0"}, reinstalled)
+		assert.Equal(t, 0, exitCode)
+		var result map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(out), &result))
+		assert.Equal(t, true, result["success"])
+		assert.Equal(t, []any{"github:owner/tool"}, result["repaired"])
+	})
+}
@@ -0,0 +1,55 @@
+package zana
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+)
+
+// parseSinceDuration parses the duration of --updated-since: a Go duration
+// (36h, 90m) or a number of days or weeks (7d, 2w)
+func parseSinceDuration(raw string) (time.Duration, error) {
+	raw = strings.TrimSpace(raw)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(raw, suffix); ok {
+			count, err := strconv.ParseFloat(n, 64)
+			if err != nil || count <= 0 {
+				break
+			}
+			return time.Duration(count * float64(unit)), nil
+		}
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid --updated-since %q, use e.g. 12h, 7d or 2w", raw)
+	}
+	return d, nil
+}
+
+// lastChangedBySourceID returns when each package was last installed or
+// updated successfully, from the history
+func lastChangedBySourceID() map[string]time.Time {
+	entries, err := readHistoryFn()
+	if err != nil {
+		return nil
+	}
+	changed := map[string]time.Time{}
+	for _, entry := range entries {
+		if !entry.Success || entry.Action == files.HistoryActionRemove {
+			continue
+		}
+		if entry.Time.After(changed[entry.SourceID]) {
+			changed[entry.SourceID] = entry.Time
+		}
+	}
+	return changed
+}
+
+// updatedAfter returns the time --updated-since reaches back to
+func (o ListQueryOptions) updatedAfter() time.Time {
+	return clock.Now().Add(-o.UpdatedSince)
+}
@@ -0,0 +1,63 @@
+package zana
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSinceDuration(t *testing.T) {
+	for raw, want := range map[string]time.Duration{
+		"7d":   7 * 24 * time.Hour,
+		"2w":   14 * 24 * time.Hour,
+		"36h":  36 * time.Hour,
+		"90m":  90 * time.Minute,
+		"1.5d": 36 * time.Hour,
+	} {
+		d, err := parseSinceDuration(raw)
+		require.NoError(t, err, raw)
+		assert.Equal(t, want, d, raw)
+	}
+	for _, raw := range []string{"", "week", "0d", "-1h", "3y"} {
+		_, err := parseSinceDuration(raw)
+		assert.ErrorContains(t, err, "invalid --updated-since", raw)
+	}
+}
+
+func TestListInstalledPackagesUpdatedSince(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	t.Cleanup(clock.Set(clock.NewFake(now)))
+	prevHistory := readHistoryFn
+	t.Cleanup(func() { readHistoryFn = prevHistory })
+	readHistoryFn = func() ([]files.HistoryEntry, error) {
+		return []files.HistoryEntry{
+			{Time: now.Add(-30 * 24 * time.Hour), Action: files.HistoryActionInstall, SourceID: "npm:old", Success: true},
+			{Time: now.Add(-40 * 24 * time.Hour), Action: files.HistoryActionInstall, SourceID: "npm:updated", Success: true},
+			{Time: now.Add(-2 * 24 * time.Hour), Action: files.HistoryActionUpdate, SourceID: "npm:updated", Success: true},
+			{Time: now.Add(-time.Hour), Action: files.HistoryActionUpdate, SourceID: "npm:old", Success: false},
+			{Time: now.Add(-time.Hour), Action: files.HistoryActionRemove, SourceID: "npm:old", Success: true},
+		}, nil
+	}
+	svc := NewListServiceWithDependencies(&MockLocalPackagesProvider{
+		GetDataFunc: func(force bool) local_packages_parser.LocalPackageRoot {
+			return local_packages_parser.LocalPackageRoot{Packages: []local_packages_parser.LocalPackageItem{
+				{SourceID: "npm:old", Version: "1.0.0"},
+				{SourceID: "npm:updated", Version: "2.0.0"},
+				{SourceID: "npm:unrecorded", Version: "3.0.0"},
+			}}
+		},
+	}, &MockRegistryProvider{}, &MockUpdateChecker{}, &MockFileDownloader{})
+
+	out := captureOutput(t, func() {
+		svc.ListInstalledPackages(ListQueryOptions{UpdatedSince: 7 * 24 * time.Hour, UpdatedSinceFlag: "7d"})
+	})
+	assert.Contains(t, out, "npm:updated")
+	assert.NotContains(t, out, "npm:old")
+	assert.NotContains(t, out, "npm:unrecorded")
+	assert.Contains(t, out, "updated in the last")
+}
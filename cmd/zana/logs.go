@@ -0,0 +1,199 @@
+package zana
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/log"
+	"github.com/spf13/cobra"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Show the zana log",
+	Long: `Show the zana log.
+
+Every log line written while installing, updating or removing a package carries
+the operation's correlation ID, the action and the package, so the lines of
+parallel runs can be told apart.
+
+Examples:
+  zana logs
+  zana logs --lines 200
+  zana logs --follow --package npm:prettier
+  zana logs -f -p prettier`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := runLogs(ctx); err != nil {
+			fmt.Printf("%s Failed to read logs: %v\n", IconClose(), err)
+			osExit(1)
+		}
+	},
+}
+
+var (
+	logsFollow  bool
+	logsPackage string
+	logsLines   int
+)
+
+func init() {
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "keep printing new log lines as they are written")
+	logsCmd.Flags().StringVarP(&logsPackage, "package", "p", "", "only show lines of operations on this package (e.g. npm:prettier or prettier)")
+	logsCmd.Flags().IntVarP(&logsLines, "lines", "n", 50, "number of lines to show initially (0 shows all)")
+}
+
+// logEntry is a log line as written by the JSON log handler
+type logEntry struct {
+	Time        time.Time `json:"time"`
+	Level       string    `json:"level"`
+	Msg         string    `json:"msg"`
+	OperationID string    `json:"op"`
+	Action      string    `json:"action"`
+	Package     string    `json:"package"`
+	raw         string
+}
+
+func parseLogEntry(line string) (logEntry, bool) {
+	var entry logEntry
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		return logEntry{}, false
+	}
+	entry.raw = line
+	return entry, true
+}
+
+// matchesPackage reports whether the entry belongs to an operation on pkg.
+// pkg may be a full package ID (npm:prettier) or just the package name (prettier).
+func (e logEntry) matchesPackage(pkg string) bool {
+	if pkg == "" {
+		return true
+	}
+	if e.Package == pkg {
+		return true
+	}
+	if !strings.Contains(pkg, ":") {
+		if i := strings.Index(e.Package, ":"); i >= 0 {
+			return e.Package[i+1:] == pkg
+		}
+	}
+	return false
+}
+
+func formatLogEntry(e logEntry) string {
+	if ShouldUseJSONOutput() {
+		return e.raw
+	}
+	var b strings.Builder
+	b.WriteString(formatTimestamp(e.Time))
+	b.WriteString(fmt.Sprintf(" %-5s ", e.Level))
+	if e.OperationID != "" {
+		b.WriteString(fmt.Sprintf("[%s %s %s] ", e.OperationID, e.Action, e.Package))
+	}
+	b.WriteString(e.Msg)
+	return b.String()
+}
+
+// readLogEntries reads the complete log lines from offset on and returns the
+// entries with the offset after the last complete line.
+// When the file shrank (it was rotated), it is read from the start.
+func readLogEntries(path string, offset int64) ([]logEntry, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, offset, err
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil && info.Size() < offset {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, err
+	}
+
+	var entries []logEntry
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			// Incomplete last line, it is picked up once it is fully written
+			break
+		}
+		offset += int64(len(line))
+		if entry, ok := parseLogEntry(string(bytes.TrimSpace(line))); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, offset, nil
+}
+
+func filterLogEntries(entries []logEntry, pkg string) []logEntry {
+	filtered := entries[:0:0]
+	for _, e := range entries {
+		if e.matchesPackage(pkg) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// runLogs prints the last lines of the log and, with --follow, new lines until ctx is cancelled
+func runLogs(ctx context.Context) error {
+	path := logFilePathFn()
+	entries, offset, err := readLogEntries(path, 0)
+	if err != nil {
+		return err
+	}
+	entries = filterLogEntries(entries, logsPackage)
+	if logsLines > 0 && len(entries) > logsLines {
+		entries = entries[len(entries)-logsLines:]
+	}
+	if len(entries) == 0 && !logsFollow && !ShouldUseJSONOutput() {
+		fmt.Println("No log lines found")
+		return nil
+	}
+	for _, e := range entries {
+		fmt.Println(formatLogEntry(e))
+	}
+	if !logsFollow {
+		return nil
+	}
+
+	ticker := time.NewTicker(logsPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			var newEntries []logEntry
+			newEntries, offset, err = readLogEntries(path, offset)
+			if err != nil {
+				return err
+			}
+			for _, e := range filterLogEntries(newEntries, logsPackage) {
+				fmt.Println(formatLogEntry(e))
+			}
+		}
+	}
+}
+
+// indirections for testability
+var logFilePathFn = files.GetLogFilePath
+var enableLogFileFn = log.EnableFileOutput
+var logsPollInterval = 500 * time.Millisecond
@@ -0,0 +1,103 @@
+package zana
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleLog = `{"time":"2024-05-01T12:00:00Z","level":"INFO","msg":"Registry: Downloaded"}
+{"time":"2024-05-01T12:00:01Z","level":"INFO","msg":"NPM Install: Installing prettier","op":"aaaa1111","action":"install","package":"npm:prettier"}
+{"time":"2024-05-01T12:00:01Z","level":"INFO","msg":"PyPI Install: Installing black","op":"bbbb2222","action":"install","package":"pypi:black"}
+not json
+{"time":"2024-05-01T12:00:02Z","level":"ERROR","msg":"NPM Install: Failed","op":"aaaa1111","action":"install","package":"npm:prettier"}
+`
+
+func stubLogs(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "zana.log")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	prevPath, prevFollow, prevPackage, prevLines, prevInterval := logFilePathFn, logsFollow, logsPackage, logsLines, logsPollInterval
+	t.Cleanup(func() {
+		logFilePathFn, logsFollow, logsPackage, logsLines, logsPollInterval = prevPath, prevFollow, prevPackage, prevLines, prevInterval
+	})
+	logFilePathFn = func() string { return path }
+	logsFollow, logsPackage, logsLines = false, "", 50
+	return path
+}
+
+func TestLogsCommand(t *testing.T) {
+	t.Run("prints all lines", func(t *testing.T) {
+		stubLogs(t, sampleLog)
+		out := captureOutput(t, func() { require.NoError(t, runLogs(context.Background())) })
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		require.Len(t, lines, 4)
+		assert.Contains(t, lines[0], "INFO  Registry: Downloaded")
+		assert.Contains(t, lines[1], "[aaaa1111 install npm:prettier] NPM Install: Installing prettier")
+	})
+
+	t.Run("filters by package id or name", func(t *testing.T) {
+		stubLogs(t, sampleLog)
+		for _, pkg := range []string{"npm:prettier", "prettier"} {
+			logsPackage = pkg
+			out := captureOutput(t, func() { require.NoError(t, runLogs(context.Background())) })
+			assert.Equal(t, 2, strings.Count(out, "aaaa1111"), pkg)
+			assert.NotContains(t, out, "black")
+		}
+	})
+
+	t.Run("limits lines", func(t *testing.T) {
+		stubLogs(t, sampleLog)
+		logsLines = 1
+		out := captureOutput(t, func() { require.NoError(t, runLogs(context.Background())) })
+		assert.Equal(t, 1, strings.Count(strings.TrimSpace(out), "\n")+1)
+		assert.Contains(t, out, "NPM Install: Failed")
+	})
+
+	t.Run("json output prints raw lines", func(t *testing.T) {
+		stubLogs(t, sampleLog)
+		logsPackage = "black"
+		out := captureOutputWithMode(t, func() { require.NoError(t, runLogs(context.Background())) }, config.OutputModeJSON)
+		assert.Equal(t, `{"time":"2024-05-01T12:00:01Z","level":"INFO","msg":"PyPI Install: Installing black","op":"bbbb2222","action":"install","package":"pypi:black"}`, strings.TrimSpace(out))
+	})
+
+	t.Run("missing log file", func(t *testing.T) {
+		stubLogs(t, "")
+		logFilePathFn = func() string { return filepath.Join(t.TempDir(), "missing.log") }
+		out := captureOutput(t, func() { require.NoError(t, runLogs(context.Background())) })
+		assert.Contains(t, out, "No log lines found")
+	})
+}
+
+func TestLogsFollow(t *testing.T) {
+	path := stubLogs(t, sampleLog)
+	logsFollow, logsPackage, logsLines = true, "npm:prettier", 1
+	logsPollInterval = 5 * time.Millisecond
+
+	out := captureOutput(t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+			if err == nil {
+				_, _ = f.WriteString(`{"time":"2024-05-01T12:00:03Z","level":"INFO","msg":"PyPI Install: Done","op":"bbbb2222","action":"install","package":"pypi:black"}` + "\n")
+				_, _ = f.WriteString(`{"time":"2024-05-01T12:00:03Z","level":"INFO","msg":"NPM Install: Retrying","op":"aaaa1111","action":"install","package":"npm:prettier"}` + "\n")
+				f.Close()
+			}
+			time.Sleep(50 * time.Millisecond)
+			cancel()
+		}()
+		require.NoError(t, runLogs(ctx))
+	})
+	assert.Contains(t, out, "NPM Install: Failed")
+	assert.Contains(t, out, "NPM Install: Retrying")
+	assert.NotContains(t, out, "PyPI Install: Done")
+	assert.NotContains(t, out, "Installing prettier", "only the last line is shown initially")
+}
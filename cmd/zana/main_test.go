@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/spf13/cobra"
 )
 
 // TestMain isolates command tests from the user's real filesystem.
@@ -26,5 +28,20 @@ func TestMain(m *testing.M) {
 	_ = files.GetAppPackagesPath()
 	_ = os.MkdirAll(filepath.Join(tmp, "cache"), 0755)
 
+	// Don't depend on the provider tools installed on the test machine.
+	checkProviderHealthFn = func(provider string) providers.ProviderHealthStatus {
+		return providers.ProviderHealthStatus{Provider: provider, Available: true}
+	}
+	installNeedsProviderToolFn = func(string) bool { return true }
+	checkPlatformSupportFn = func(string) error { return nil }
+	// Commands under test shouldn't offer layout migrations.
+	checkLayoutMigrationsFn = func(*cobra.Command) {}
+	// Nor the first-run setup wizard.
+	offerFirstRunSetupFn = func(*cobra.Command) {}
+	// Don't send HEAD requests for download size estimates.
+	estimateDownloadsFn = func([]providers.PlannedDownload) providers.DownloadEstimate {
+		return providers.DownloadEstimate{}
+	}
+
 	os.Exit(m.Run())
 }
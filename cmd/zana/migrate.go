@@ -0,0 +1,163 @@
+package zana
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/huh"
+	"github.com/mistweaverco/zana-client/internal/lib/migrations"
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the on-disk layout of ZANA_HOME",
+	Long: `Upgrade the on-disk layout of ZANA_HOME.
+
+When a zana release changes how packages, executables or the lock file are
+stored, it ships a migration. Migrations run in order; each one backs up what
+it changes, transforms it and verifies the result, and is rolled back when it
+fails. The layout version is recorded in zana-state.json in ZANA_HOME.
+
+zana offers to migrate whenever it notices an outdated layout, so running
+this by hand is only needed in scripts or after declining that offer.
+
+Examples:
+  zana migrate --dry-run
+  zana migrate`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if migrateDryRun {
+			pending, err := pendingMigrationsFn()
+			if err != nil {
+				printMigrateError(err, nil)
+				osExit(1)
+				return
+			}
+			if ShouldUseJSONOutput() {
+				PrintJSON(map[string]interface{}{
+					"success": true,
+					"pending": migrationSummaries(pending),
+				})
+				return
+			}
+			if len(pending) == 0 {
+				fmt.Printf("%s The layout is up to date\n", IconCheck())
+				return
+			}
+			fmt.Printf("%d pending migrations:\n", len(pending))
+			for _, m := range pending {
+				fmt.Printf("  %d: %s\n", m.Version, m.Description)
+			}
+			return
+		}
+		if err := runMigrations(); err != nil {
+			osExit(1)
+		}
+	},
+}
+
+var migrateDryRun bool
+
+func init() {
+	migrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "list the pending migrations without running them")
+}
+
+// indirections for testability
+var (
+	pendingMigrationsFn = migrations.Pending
+	runMigrationsFn     = migrations.Run
+	confirmMigrationFn  = confirmMigration
+)
+
+type migrationSummary struct {
+	Version     int    `json:"version"`
+	Description string `json:"description"`
+}
+
+func migrationSummaries(ms []migrations.Migration) []migrationSummary {
+	out := make([]migrationSummary, 0, len(ms))
+	for _, m := range ms {
+		out = append(out, migrationSummary{Version: m.Version, Description: m.Description})
+	}
+	return out
+}
+
+// runMigrations runs the pending migrations, printing their progress
+func runMigrations() error {
+	applied, err := runMigrationsFn(func(m migrations.Migration) {
+		if !ShouldUseJSONOutput() {
+			fmt.Printf("Migrating to layout version %d: %s\n", m.Version, m.Description)
+		}
+	})
+	if err != nil {
+		printMigrateError(err, applied)
+		return err
+	}
+	if ShouldUseJSONOutput() {
+		PrintJSON(map[string]interface{}{
+			"success": true,
+			"applied": migrationSummaries(applied),
+		})
+	} else if len(applied) == 0 {
+		fmt.Printf("%s The layout is up to date\n", IconCheck())
+	} else {
+		fmt.Printf("%s Layout upgraded to version %d\n", IconCheck(), applied[len(applied)-1].Version)
+	}
+	return nil
+}
+
+func printMigrateError(err error, applied []migrations.Migration) {
+	if ShouldUseJSONOutput() {
+		PrintJSON(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+			"applied": migrationSummaries(applied),
+		})
+		return
+	}
+	fmt.Printf("%s %v\n", IconClose(), err)
+}
+
+// checkLayoutMigrations offers to migrate an outdated layout before a command
+// runs. Without a terminal to ask on, it only points at zana migrate.
+func checkLayoutMigrations(cmd *cobra.Command) {
+	switch cmd.Name() {
+	case "migrate":
+		return
+	}
+	if isHelpOrCompletion(cmd) {
+		return
+	}
+	pending, err := pendingMigrationsFn()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", IconClose(), err)
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+	if !canPromptForSelection() || !confirmMigrationFn(len(pending)) {
+		fmt.Fprintf(os.Stderr, "%s The zana layout is outdated (%d pending migrations), run \"zana migrate\" to upgrade it\n", IconLightbulb(), len(pending))
+		return
+	}
+	_ = runMigrations()
+}
+
+func confirmMigration(count int) bool {
+	confirm := true
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title("The zana layout needs upgrading").
+				Description(fmt.Sprintf("Run %d pending migrations now? Changed files are backed up and restored if a migration fails.", count)).
+				Affirmative("Yes").
+				Negative("No").
+				Value(&confirm),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return false
+	}
+	return confirm
+}
@@ -0,0 +1,88 @@
+package zana
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+func stubMigrate(t *testing.T, pending []migrations.Migration, runErr error) (runs *int, codes *[]int) {
+	t.Helper()
+	prevPending, prevRun, prevConfirm, prevPrompt, prevDryRun, prevExit := pendingMigrationsFn, runMigrationsFn, confirmMigrationFn, canPromptForSelection, migrateDryRun, osExit
+	t.Cleanup(func() {
+		pendingMigrationsFn, runMigrationsFn, confirmMigrationFn, canPromptForSelection, migrateDryRun, osExit = prevPending, prevRun, prevConfirm, prevPrompt, prevDryRun, prevExit
+	})
+
+	runs = new(int)
+	pendingMigrationsFn = func() ([]migrations.Migration, error) { return pending, nil }
+	runMigrationsFn = func(before func(migrations.Migration)) ([]migrations.Migration, error) {
+		*runs++
+		if runErr != nil {
+			return nil, runErr
+		}
+		for _, m := range pending {
+			before(m)
+		}
+		return pending, nil
+	}
+	migrateDryRun = false
+	codes = &[]int{}
+	osExit = func(code int) { *codes = append(*codes, code) }
+	return runs, codes
+}
+
+var testMigrations = []migrations.Migration{{Version: 1, Description: "Rewrite legacy package IDs"}}
+
+func TestMigrateCommand(t *testing.T) {
+	t.Run("runs pending migrations", func(t *testing.T) {
+		runs, codes := stubMigrate(t, testMigrations, nil)
+		out := captureOutput(t, func() { migrateCmd.Run(migrateCmd, nil) })
+		assert.Equal(t, 1, *runs)
+		assert.Empty(t, *codes)
+		assert.Contains(t, out, "Migrating to layout version 1: Rewrite legacy package IDs")
+		assert.Contains(t, out, "Layout upgraded to version 1")
+	})
+
+	t.Run("dry run lists pending migrations", func(t *testing.T) {
+		runs, _ := stubMigrate(t, testMigrations, nil)
+		migrateDryRun = true
+		out := captureOutputWithMode(t, func() { migrateCmd.Run(migrateCmd, nil) }, config.OutputModeJSON)
+		assert.Equal(t, 0, *runs)
+		assert.JSONEq(t, `{"success": true, "pending": [{"version": 1, "description": "Rewrite legacy package IDs"}]}`, out)
+	})
+
+	t.Run("failures exit non-zero", func(t *testing.T) {
+		_, codes := stubMigrate(t, testMigrations, errors.New("migration 1 failed and was rolled back"))
+		out := captureOutput(t, func() { migrateCmd.Run(migrateCmd, nil) })
+		assert.Equal(t, []int{1}, *codes)
+		assert.Contains(t, out, "migration 1 failed and was rolled back")
+	})
+}
+
+func TestCheckLayoutMigrations(t *testing.T) {
+	t.Run("runs after confirmation", func(t *testing.T) {
+		runs, _ := stubMigrate(t, testMigrations, nil)
+		canPromptForSelection = func() bool { return true }
+		confirmMigrationFn = func(count int) bool { return count == 1 }
+		captureOutput(t, func() { checkLayoutMigrations(listCmd) })
+		assert.Equal(t, 1, *runs)
+	})
+
+	t.Run("only hints without a terminal", func(t *testing.T) {
+		runs, _ := stubMigrate(t, testMigrations, nil)
+		canPromptForSelection = func() bool { return false }
+		captureOutput(t, func() { checkLayoutMigrations(listCmd) })
+		assert.Equal(t, 0, *runs)
+	})
+
+	t.Run("skipped for zana migrate itself", func(t *testing.T) {
+		runs, _ := stubMigrate(t, testMigrations, nil)
+		canPromptForSelection = func() bool { return true }
+		confirmMigrationFn = func(int) bool { return true }
+		checkLayoutMigrations(migrateCmd)
+		assert.Equal(t, 0, *runs)
+	})
+}
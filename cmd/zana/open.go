@@ -0,0 +1,148 @@
+package zana
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/spf13/cobra"
+)
+
+var openCmd = &cobra.Command{
+	Use:   "open <pkgId>",
+	Short: "Open the homepage or repository of a package in the browser",
+	Long: `Open the homepage of a package in the default browser,
+or its repository when the registry has no homepage for it.
+
+--repo opens the repository instead, --releases its releases page.
+For packages of registries such as npm or PyPI, the repository is
+the package's page on the registry, which links the source.
+
+Examples:
+  zana open npm:prettier
+  zana open --repo cargo:ripgrep
+  zana open --releases github:BurntSushi/ripgrep
+  zana open ripgrep (will prompt for provider selection if multiple matches)`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: packageIDCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		sourceIDs, err := resolveOpenPackage(args[0])
+		if err != nil {
+			fmt.Printf("%s %v\n", IconClose(), err)
+			osExit(1)
+			return
+		}
+		failed := false
+		results := []map[string]interface{}{}
+		for _, sourceID := range sourceIDs {
+			pageURL, err := packagePageURL(sourceID)
+			if err == nil {
+				err = openBrowserFn(pageURL)
+			}
+			result := map[string]interface{}{"package": sourceID, "url": pageURL, "success": err == nil}
+			if err != nil {
+				failed = true
+				result["error"] = err.Error()
+				if !ShouldUseJSONOutput() {
+					fmt.Printf("%s %v\n", IconClose(), err)
+				}
+			} else if !ShouldUseJSONOutput() {
+				fmt.Printf("%s Opened %s\n", IconCheck(), pageURL)
+			}
+			results = append(results, result)
+		}
+		if ShouldUseJSONOutput() {
+			PrintJSON(map[string]interface{}{
+				"success":  !failed,
+				"packages": results,
+			})
+		}
+		if failed {
+			osExit(1)
+		}
+	},
+}
+
+var (
+	openRepo     bool
+	openReleases bool
+)
+
+func init() {
+	openCmd.Flags().BoolVar(&openRepo, "repo", false, "open the repository instead of the homepage")
+	openCmd.Flags().BoolVar(&openReleases, "releases", false, "open the releases page")
+	openCmd.MarkFlagsMutuallyExclusive("repo", "releases")
+}
+
+// indirections for testability
+var openBrowserFn = openBrowser
+
+// resolveOpenPackage returns the source IDs userPkgID stands for, asking
+// which provider is meant when it's a name without one
+func resolveOpenPackage(userPkgID string) ([]string, error) {
+	baseID, _ := parsePackageIDAndVersion(userPkgID)
+	if packageid.HasProvider(baseID) {
+		provider, pkgName, err := parseUserPackageID(baseID)
+		if err != nil {
+			return nil, err
+		}
+		return []string{toInternalPackageID(provider, pkgName)}, nil
+	}
+	_ = downloadAndUnzipRegistryFn()
+	matches := findPackagesByName(baseID)
+	exactMatches, partialMatches := splitExactMatches(baseID, matches)
+	if len(exactMatches) == 1 {
+		return []string{exactMatches[0].SourceID}, nil
+	}
+	if len(exactMatches) == 0 {
+		exactMatches = partialMatches
+	}
+	if len(exactMatches) == 0 {
+		return nil, fmt.Errorf("no packages found matching '%s'", baseID)
+	}
+	return promptForProviderSelection(baseID, exactMatches, "open")
+}
+
+// packagePageURL returns the page of sourceID to open: the homepage from the
+// registry, falling back to the repository, or what --repo or --releases ask for
+func packagePageURL(sourceID string) (string, error) {
+	switch {
+	case openReleases:
+		if pageURL := providers.ReleasesURL(sourceID); pageURL != "" {
+			return pageURL, nil
+		}
+		return "", fmt.Errorf("%s has no known releases page", sourceID)
+	case !openRepo:
+		_ = downloadAndUnzipRegistryFn()
+		if homepage := newRegistryParser().GetBySourceId(sourceID).Homepage; homepage != "" {
+			return homepage, nil
+		}
+	}
+	if pageURL := providers.RepositoryURL(sourceID); pageURL != "" {
+		return pageURL, nil
+	}
+	return "", fmt.Errorf("%s has no known homepage or repository", sourceID)
+}
+
+// openBrowser opens pageURL in the default browser
+func openBrowser(pageURL string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", pageURL)
+	case "windows":
+		// start treats the first quoted argument as the window title and
+		// cmd would split the URL at &
+		cmd = exec.Command("cmd", "/c", "start", "", strings.ReplaceAll(pageURL, "&", "^&"))
+	default:
+		cmd = exec.Command("xdg-open", pageURL)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open %s: %w", pageURL, err)
+	}
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
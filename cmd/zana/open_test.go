@@ -0,0 +1,85 @@
+package zana
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubOpen(t *testing.T) (opened *[]string, codes *[]int) {
+	t.Helper()
+	prevParser, prevDownload, prevBrowser, prevRepo, prevReleases, prevExit := newRegistryParser, downloadAndUnzipRegistryFn, openBrowserFn, openRepo, openReleases, osExit
+	t.Cleanup(func() {
+		newRegistryParser, downloadAndUnzipRegistryFn, openBrowserFn, openRepo, openReleases, osExit = prevParser, prevDownload, prevBrowser, prevRepo, prevReleases, prevExit
+	})
+	newRegistryParser = func() *registry_parser.RegistryParser {
+		rp := registry_parser.NewRegistryParser(nil)
+		require.NoError(t, rp.LoadFromBytes([]byte(`[
+			{"name": "prettier", "version": "3.3.0", "homepage": "https://prettier.io", "source": {"id": "npm:prettier"}},
+			{"name": "ripgrep", "version": "14.1.0", "source": {"id": "github:BurntSushi/ripgrep"}},
+			{"name": "example", "version": "1.0.0", "source": {"id": "generic:example"}}
+		]`)))
+		return rp
+	}
+	downloadAndUnzipRegistryFn = func() error { return nil }
+	opened = &[]string{}
+	openBrowserFn = func(pageURL string) error {
+		*opened = append(*opened, pageURL)
+		return nil
+	}
+	openRepo, openReleases = false, false
+	codes = &[]int{}
+	osExit = func(code int) { *codes = append(*codes, code) }
+	return opened, codes
+}
+
+func TestOpenCommand(t *testing.T) {
+	t.Run("opens the homepage", func(t *testing.T) {
+		opened, codes := stubOpen(t)
+		out := captureOutput(t, func() { openCmd.Run(openCmd, []string{"npm:prettier"}) })
+		assert.Equal(t, []string{"https://prettier.io"}, *opened)
+		assert.Empty(t, *codes)
+		assert.Contains(t, out, "Opened https://prettier.io")
+	})
+
+	t.Run("falls back to the repository", func(t *testing.T) {
+		opened, _ := stubOpen(t)
+		captureOutput(t, func() { openCmd.Run(openCmd, []string{"github:BurntSushi/ripgrep"}) })
+		assert.Equal(t, []string{"https://github.com/BurntSushi/ripgrep"}, *opened)
+	})
+
+	t.Run("resolves a name with one exact match", func(t *testing.T) {
+		opened, _ := stubOpen(t)
+		captureOutput(t, func() { openCmd.Run(openCmd, []string{"prettier"}) })
+		assert.Equal(t, []string{"https://prettier.io"}, *opened)
+	})
+
+	t.Run("--repo and --releases", func(t *testing.T) {
+		opened, _ := stubOpen(t)
+		openRepo = true
+		captureOutput(t, func() { openCmd.Run(openCmd, []string{"npm:prettier"}) })
+		openRepo, openReleases = false, true
+		captureOutput(t, func() { openCmd.Run(openCmd, []string{"github:BurntSushi/ripgrep"}) })
+		assert.Equal(t, []string{"https://www.npmjs.com/package/prettier", "https://github.com/BurntSushi/ripgrep/releases"}, *opened)
+	})
+
+	t.Run("fails without a page", func(t *testing.T) {
+		opened, codes := stubOpen(t)
+		out := captureOutput(t, func() { openCmd.Run(openCmd, []string{"generic:example"}) })
+		assert.Empty(t, *opened)
+		assert.Equal(t, []int{1}, *codes)
+		assert.Contains(t, out, "generic:example has no known homepage or repository")
+	})
+
+	t.Run("json output", func(t *testing.T) {
+		_, codes := stubOpen(t)
+		openBrowserFn = func(pageURL string) error { return errors.New("failed to open " + pageURL + ": no browser") }
+		out := captureOutputWithMode(t, func() { openCmd.Run(openCmd, []string{"npm:prettier"}) }, config.OutputModeJSON)
+		assert.JSONEq(t, `{"success": false, "packages": [{"package": "npm:prettier", "url": "https://prettier.io", "success": false, "error": "failed to open https://prettier.io: no browser"}]}`, out)
+		assert.Equal(t, []int{1}, *codes)
+	})
+}
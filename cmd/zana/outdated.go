@@ -0,0 +1,204 @@
+package zana
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/mistweaverco/zana-client/internal/lib/versioncmp"
+	"github.com/spf13/cobra"
+)
+
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "List installed packages with a newer version available",
+	Long: `List installed packages with a newer version available.
+
+By default installed versions are compared against the registry.
+With --remote the providers are queried as well (npm view, pip index versions,
+cargo search, GitHub releases, ...), which finds releases the registry
+doesn't know about yet. Remote versions are cached for --max-age.
+
+With --diff every update is classified as a major, minor or patch bump (or
+unknown, when the versions can't be compared), grouped from the largest to
+the smallest step. The JSON output of --diff is a list of
+{source_id, current, latest, bump} objects.
+
+Examples:
+  zana outdated
+  zana outdated --remote
+  zana outdated --diff
+  zana outdated --remote --jobs 8 --max-age 0`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		service := newListService()
+		_ = service.fileDownloader.DownloadAndUnzipRegistry()
+		if outdatedRemote && !ShouldUseJSONOutput() && !ShouldUsePlainOutput() {
+			fmt.Println("Querying providers for the latest versions...")
+		}
+		packages := collectOutdated(service, outdatedRemote)
+		if outdatedDiff {
+			diffs := outdatedDiffs(packages)
+			switch {
+			case ShouldUseJSONOutput():
+				PrintJSON(diffs)
+			case ShouldUsePlainOutput():
+				printOutdatedDiffPlain(diffs)
+			default:
+				printOutdatedDiff(diffs)
+			}
+			return
+		}
+		switch {
+		case ShouldUseJSONOutput():
+			PrintJSON(packages)
+		default:
+			printOutdated(packages)
+		}
+	},
+}
+
+var (
+	outdatedRemote bool
+	outdatedJobs   int
+	outdatedMaxAge time.Duration
+	outdatedDiff   bool
+)
+
+func init() {
+	outdatedCmd.Flags().BoolVar(&outdatedRemote, "remote", false, "also ask the providers for their latest versions")
+	outdatedCmd.Flags().IntVar(&outdatedJobs, "jobs", 4, "number of provider queries to run at the same time with --remote")
+	outdatedCmd.Flags().BoolVar(&outdatedDiff, "diff", false, "classify the updates as major, minor, patch or unknown")
+	outdatedCmd.Flags().DurationVar(&outdatedMaxAge, "max-age", time.Hour, "reuse remote versions queried within this duration (0 always queries)")
+}
+
+// outdatedPackage is an installed package with a newer version available
+type outdatedPackage struct {
+	SchemaVersion   jsonSchemaVersion `json:"schema_version"`
+	SourceID        string            `json:"source_id"`
+	Version         string            `json:"version"`
+	Latest          string            `json:"latest"`
+	RegistryVersion string            `json:"registry_version,omitempty"`
+	RemoteVersion   string            `json:"remote_version,omitempty"`
+	RemoteError     string            `json:"remote_error,omitempty"`
+}
+
+// collectOutdated compares the installed packages against the registry and,
+// with remote, against the versions the providers report.
+func collectOutdated(ls *ListService, remote bool) []outdatedPackage {
+	installed := ls.localPackages.GetData(true).Packages
+	remoteVersions := map[string]providers.RemoteVersion{}
+	if remote {
+		ids := make([]string, 0, len(installed))
+		for _, pkg := range installed {
+			ids = append(ids, pkg.SourceID)
+		}
+		for _, rv := range latestRemoteVersionsFn(ids, providers.RemoteVersionOptions{Concurrency: outdatedJobs, MaxAge: outdatedMaxAge}) {
+			remoteVersions[rv.SourceID] = rv
+		}
+	}
+
+	outdated := []outdatedPackage{}
+	for _, pkg := range installed {
+		entry := outdatedPackage{SourceID: pkg.SourceID, Version: pkg.Version}
+		if stable, prerelease := ls.registry.GetLatestVersions(pkg.SourceID); stable != "" || prerelease != "" {
+			entry.RegistryVersion = chooseBestRemoteVersion(pkg.Version, stable, prerelease)
+		}
+		rv := remoteVersions[pkg.SourceID]
+		entry.RemoteVersion, entry.RemoteError = rv.Version, rv.Error
+
+		entry.Latest = entry.RegistryVersion
+		if entry.RemoteVersion != "" {
+			if entry.Latest == "" {
+				entry.Latest = entry.RemoteVersion
+			} else if newer, _ := ls.updateChecker.CheckIfUpdateIsAvailable(entry.Latest, entry.RemoteVersion); newer {
+				entry.Latest = entry.RemoteVersion
+			}
+		}
+		if entry.Latest == "" || entry.Latest == pkg.Version {
+			continue
+		}
+		if pkg.Version != "" && pkg.Version != "latest" {
+			if newer, _ := ls.updateChecker.CheckIfUpdateIsAvailable(pkg.Version, entry.Latest); !newer {
+				continue
+			}
+		}
+		outdated = append(outdated, entry)
+	}
+	return outdated
+}
+
+func printOutdated(packages []outdatedPackage) {
+	if len(packages) == 0 {
+		fmt.Printf("%s All packages are up to date\n", IconCheckCircle())
+		return
+	}
+	for _, p := range packages {
+		var sources []string
+		if p.RegistryVersion != "" {
+			sources = append(sources, "registry v"+p.RegistryVersion)
+		}
+		if p.RemoteVersion != "" {
+			sources = append(sources, "remote v"+p.RemoteVersion)
+		}
+		fmt.Printf("%s %s: v%s → v%s (%s)\n", IconRefresh(), p.SourceID, p.Version, p.Latest, strings.Join(sources, ", "))
+	}
+	fmt.Printf("\n%d outdated packages\n", len(packages))
+}
+
+// outdatedDiffs classifies the outdated packages, largest steps first
+func outdatedDiffs(packages []outdatedPackage) []providers.UpdateDiff {
+	updates := make([]providers.VersionUpdate, 0, len(packages))
+	for _, p := range packages {
+		updates = append(updates, providers.VersionUpdate{SourceID: p.SourceID, Current: p.Version, Latest: p.Latest})
+	}
+	diffs := providers.DiffUpdates(updates)
+	sort.SliceStable(diffs, func(i, j int) bool {
+		return bumpOrder(diffs[i].Bump) < bumpOrder(diffs[j].Bump)
+	})
+	return diffs
+}
+
+// bumpOrder sorts major before minor before patch, with unknown last
+func bumpOrder(b versioncmp.Bump) int {
+	if b == versioncmp.BumpUnknown {
+		return 0
+	}
+	return -int(b)
+}
+
+func printOutdatedDiff(diffs []providers.UpdateDiff) {
+	if len(diffs) == 0 {
+		fmt.Printf("%s All packages are up to date\n", IconCheckCircle())
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PACKAGE\tCURRENT\tLATEST\tBUMP")
+	counts := map[versioncmp.Bump]int{}
+	for _, d := range diffs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.SourceID, d.Current, d.Latest, d.Bump)
+		counts[d.Bump]++
+	}
+	_ = w.Flush()
+	var summary []string
+	for _, b := range []versioncmp.Bump{versioncmp.BumpMajor, versioncmp.BumpMinor, versioncmp.BumpPatch, versioncmp.BumpUnknown} {
+		if counts[b] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[b], b))
+		}
+	}
+	fmt.Printf("\n%d outdated packages: %s\n", len(diffs), strings.Join(summary, ", "))
+}
+
+// printOutdatedDiffPlain prints one "<source id> <current> <latest> <bump>" line per update
+func printOutdatedDiffPlain(diffs []providers.UpdateDiff) {
+	for _, d := range diffs {
+		fmt.Printf("%s %s %s %s\n", d.SourceID, d.Current, d.Latest, d.Bump)
+	}
+}
+
+// indirections for testability
+var latestRemoteVersionsFn = providers.LatestRemoteVersions
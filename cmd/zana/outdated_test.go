@@ -0,0 +1,128 @@
+package zana
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubOutdated(t *testing.T) *providers.RemoteVersionOptions {
+	t.Helper()
+	prevService, prevRemote, prevFlag, prevJobs := newListService, latestRemoteVersionsFn, outdatedRemote, outdatedJobs
+	t.Cleanup(func() {
+		newListService, latestRemoteVersionsFn, outdatedRemote, outdatedJobs = prevService, prevRemote, prevFlag, prevJobs
+	})
+
+	newListService = func() *ListService {
+		return NewListServiceWithDependencies(
+			&MockLocalPackagesProvider{GetDataFunc: func(bool) local_packages_parser.LocalPackageRoot {
+				return local_packages_parser.LocalPackageRoot{Packages: []local_packages_parser.LocalPackageItem{
+					{SourceID: "npm:prettier", Version: "3.0.0"},
+					{SourceID: "npm:eslint", Version: "9.0.0"},
+					{SourceID: "cargo:ripgrep", Version: "14.0.0"},
+				}}
+			}},
+			&MockRegistryProvider{GetLatestVersionFunc: func(sourceID string) string {
+				switch sourceID {
+				case "npm:prettier":
+					return "3.0.0"
+				case "npm:eslint":
+					return "9.1.0"
+				}
+				return ""
+			}},
+			&MockUpdateChecker{CheckIfUpdateIsAvailableFunc: providers.CheckIfUpdateIsAvailable},
+			&MockFileDownloader{},
+		)
+	}
+
+	var gotOpts providers.RemoteVersionOptions
+	latestRemoteVersionsFn = func(ids []string, opts providers.RemoteVersionOptions) []providers.RemoteVersion {
+		gotOpts = opts
+		return []providers.RemoteVersion{
+			{SourceID: "npm:prettier", Version: "3.1.0"},
+			{SourceID: "npm:eslint", Version: "9.0.5", Cached: true},
+			{SourceID: "cargo:ripgrep", Error: "cargo search failed"},
+		}
+	}
+	return &gotOpts
+}
+
+func TestCollectOutdated(t *testing.T) {
+	t.Run("registry only", func(t *testing.T) {
+		stubOutdated(t)
+		packages := collectOutdated(newListService(), false)
+		require.Len(t, packages, 1)
+		assert.Equal(t, outdatedPackage{SourceID: "npm:eslint", Version: "9.0.0", Latest: "9.1.0", RegistryVersion: "9.1.0"}, packages[0])
+	})
+
+	t.Run("remote versions newer than the registry are reported", func(t *testing.T) {
+		gotOpts := stubOutdated(t)
+		outdatedJobs = 3
+		packages := collectOutdated(newListService(), true)
+		assert.Equal(t, 3, gotOpts.Concurrency)
+		require.Len(t, packages, 2)
+		assert.Equal(t, "npm:prettier", packages[0].SourceID)
+		assert.Equal(t, "3.1.0", packages[0].Latest)
+		assert.Equal(t, "npm:eslint", packages[1].SourceID)
+		assert.Equal(t, "9.1.0", packages[1].Latest, "the registry version is newer than the remote one")
+	})
+}
+
+func TestOutdatedCommandOutput(t *testing.T) {
+	stubOutdated(t)
+	outdatedRemote = true
+
+	out := captureOutput(t, func() { outdatedCmd.Run(outdatedCmd, nil) })
+	assert.Contains(t, out, "npm:prettier: v3.0.0 → v3.1.0 (registry v3.0.0, remote v3.1.0)")
+	assert.Contains(t, out, "2 outdated packages")
+
+	out = captureOutputWithMode(t, func() { outdatedCmd.Run(outdatedCmd, nil) }, config.OutputModeJSON)
+	var result []map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	require.Len(t, result, 2)
+	assert.Equal(t, "3.1.0", result[0]["remote_version"])
+}
+
+func TestOutdatedDiff(t *testing.T) {
+	stubOutdated(t)
+	outdatedRemote = true
+	prevDiff := outdatedDiff
+	t.Cleanup(func() { outdatedDiff = prevDiff })
+	outdatedDiff = true
+
+	out := captureOutputWithMode(t, func() { outdatedCmd.Run(outdatedCmd, nil) }, config.OutputModeJSON)
+	var diffs []map[string]string
+	require.NoError(t, json.Unmarshal([]byte(out), &diffs))
+	assert.Equal(t, []map[string]string{
+		{"source_id": "npm:prettier", "current": "3.0.0", "latest": "3.1.0", "bump": "minor"},
+		{"source_id": "npm:eslint", "current": "9.0.0", "latest": "9.1.0", "bump": "minor"},
+	}, diffs)
+
+	out = captureOutputWithMode(t, func() { outdatedCmd.Run(outdatedCmd, nil) }, config.OutputModePlain)
+	assert.Equal(t, "npm:prettier 3.0.0 3.1.0 minor\nnpm:eslint 9.0.0 9.1.0 minor\n", out)
+
+	out = captureOutputWithMode(t, func() { outdatedCmd.Run(outdatedCmd, nil) }, config.OutputModeRich)
+	assert.Contains(t, out, "PACKAGE")
+	assert.Contains(t, out, "2 outdated packages: 2 minor")
+}
+
+func TestOutdatedDiffsOrder(t *testing.T) {
+	diffs := outdatedDiffs([]outdatedPackage{
+		{SourceID: "a", Version: "1.0.0", Latest: "1.0.1"},
+		{SourceID: "b", Version: "abc123", Latest: "def456"},
+		{SourceID: "c", Version: "1.0.0", Latest: "2.0.0"},
+		{SourceID: "d", Version: "1.0.0", Latest: "1.1.0"},
+		{SourceID: "e", Version: "2.0.0", Latest: "3.0.0"},
+	})
+	var order []string
+	for _, d := range diffs {
+		order = append(order, d.SourceID+" "+d.Bump.String())
+	}
+	assert.Equal(t, []string{"c major", "e major", "d minor", "a patch", "b unknown"}, order)
+}
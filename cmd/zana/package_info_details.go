@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/providers"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
 )
@@ -145,14 +146,7 @@ func collectTreeSitterDetails(item registry_parser.RegistryItem) *packageTreeSit
 }
 
 func normalizeInfoPackageRef(ref string) string {
-	ref = strings.TrimSpace(ref)
-	if strings.HasPrefix(ref, "pkg:") {
-		legacy := strings.TrimPrefix(ref, "pkg:")
-		parts := strings.SplitN(legacy, "/", 2)
-		if len(parts) == 2 {
-			return parts[0] + ":" + parts[1]
-		}
-	}
+	ref = packageid.Normalize(strings.TrimSpace(ref))
 	if idx := strings.LastIndex(ref, "@"); idx > 0 {
 		base := ref[:idx]
 		if strings.Contains(base, ":") {
@@ -0,0 +1,229 @@
+package zana
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+)
+
+// indirections for testability
+var (
+	canPromptForSelection = func() bool {
+		return !ShouldUseJSONOutput() && isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stdout.Fd())
+	}
+	promptForProviderSelectionFn = promptForProviderSelection
+	providerPriorityFn           = files.GetProviderPriority
+	readSelectionHistoryFn       = files.ReadHistory
+)
+
+// selectInstallMatches decides which of the registry entries matching the bare
+// name baseID get installed. On a terminal the user picks from a prompt showing
+// provider, version, description and local install stats. Otherwise exact matches
+// are resolved deterministically by provider priority (install.providerPriority in
+// config.yaml, then the built-in provider order); partial matches are never guessed.
+func selectInstallMatches(baseID string, matches []PackageMatch, exact bool) ([]string, error) {
+	matches, err := allowedMatches(matches)
+	if err != nil {
+		return nil, err
+	}
+	if canPromptForSelection() {
+		return promptForProviderSelectionFn(baseID, withInstallStats(matches), "install")
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no packages found matching '%s'", baseID)
+	}
+	ids := make([]string, 0, len(matches))
+	for _, m := range matches {
+		ids = append(ids, m.SourceID)
+	}
+	if !exact {
+		return nil, fmt.Errorf("'%s' is not an exact package name, use one of: %s", baseID, strings.Join(ids, ", "))
+	}
+	chosen := resolveByProviderPriority(matches, providerPriorityFn())
+	if len(matches) > 1 && !ShouldUseJSONOutput() {
+		fmt.Printf("%s '%s' matches %s, using %s (set install.providerPriority in config.yaml to change)\n",
+			IconLightbulb(), baseID, strings.Join(ids, ", "), chosen.SourceID)
+	}
+	return []string{chosen.SourceID}, nil
+}
+
+// splitExactMatches splits matches into those whose package name or one of
+// whose registry aliases equals name (case-insensitive) and the partial ones
+func splitExactMatches(name string, matches []PackageMatch) (exact, partial []PackageMatch) {
+	nameLower := strings.ToLower(name)
+	parser := newRegistryParser()
+	for _, match := range matches {
+		isExact := strings.ToLower(match.PackageName) == nameLower
+		if !isExact {
+			for _, alias := range parser.GetBySourceId(match.SourceID).Aliases {
+				if strings.ToLower(alias) == nameLower {
+					isExact = true
+					break
+				}
+			}
+		}
+		if isExact {
+			exact = append(exact, match)
+		} else {
+			partial = append(partial, match)
+		}
+	}
+	return exact, partial
+}
+
+// selectInstalledMatches decides which of the installed packages matching the
+// bare name baseID the action applies to. Exact matches are preferred over
+// partial ones; a single match is used right away, between several the user
+// picks from a prompt. Without a terminal, several matches are an error.
+func selectInstalledMatches(baseID string, matches []PackageMatch, action string) ([]string, error) {
+	if exact, _ := splitExactMatches(baseID, matches); len(exact) > 0 {
+		matches = exact
+	}
+	switch {
+	case len(matches) == 0:
+		return nil, fmt.Errorf("no installed packages found matching '%s'", baseID)
+	case len(matches) == 1:
+		return []string{matches[0].SourceID}, nil
+	case canPromptForSelection():
+		return promptForProviderSelectionFn(baseID, matches, action)
+	}
+	ids := make([]string, 0, len(matches))
+	for _, m := range matches {
+		ids = append(ids, m.SourceID)
+	}
+	return nil, fmt.Errorf("'%s' matches several installed packages, use one of: %s", baseID, strings.Join(ids, ", "))
+}
+
+// isPackagePattern reports whether a package argument is a glob pattern,
+// e.g. 'npm:*eslint*'
+func isPackagePattern(arg string) bool {
+	return strings.ContainsAny(arg, "*?[")
+}
+
+// matchInstalledPattern returns the installed packages matching the glob
+// pattern. Unlike in file globs, * and ? also match a /, so npm:*eslint*
+// matches npm:@typescript-eslint/parser. A pattern without a provider is
+// matched against the package names only.
+func matchInstalledPattern(pattern string, installed []local_packages_parser.LocalPackageItem) ([]string, error) {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	// path.Match only stops at /, hide it
+	hide := strings.NewReplacer("/", "\x00")
+	if _, err := path.Match(hide.Replace(pattern), ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+	}
+	withProvider := strings.Contains(pattern, ":")
+	var ids []string
+	for _, pkg := range installed {
+		name := strings.ToLower(pkg.SourceID)
+		if !withProvider {
+			_, name, _ = strings.Cut(name, ":")
+		}
+		if ok, _ := path.Match(hide.Replace(pattern), hide.Replace(name)); ok {
+			ids = append(ids, pkg.SourceID)
+		}
+	}
+	return ids, nil
+}
+
+// allowedMatches drops the matches of providers disabled in config.yaml.
+// When every match is dropped, the policy error is returned.
+func allowedMatches(matches []PackageMatch) ([]PackageMatch, error) {
+	allowed := make([]PackageMatch, 0, len(matches))
+	var policyErr error
+	for _, m := range matches {
+		if err := providers.CheckProviderAllowed(m.Provider); err != nil {
+			if policyErr == nil {
+				policyErr = err
+			}
+			continue
+		}
+		allowed = append(allowed, m)
+	}
+	if len(allowed) == 0 && policyErr != nil {
+		return nil, policyErr
+	}
+	return allowed, nil
+}
+
+// resolveByProviderPriority returns the match whose provider ranks first:
+// providers listed in priority come first in that order, followed by the
+// built-in provider order; ties are broken by source ID.
+func resolveByProviderPriority(matches []PackageMatch, priority []string) PackageMatch {
+	rank := map[string]int{}
+	for _, p := range append(append([]string{}, priority...), providers.AvailableProviders...) {
+		if _, ok := rank[p]; !ok {
+			rank[p] = len(rank)
+		}
+	}
+	rankOf := func(m PackageMatch) int {
+		if r, ok := rank[strings.ToLower(m.Provider)]; ok {
+			return r
+		}
+		return len(rank)
+	}
+	sorted := append([]PackageMatch{}, matches...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if ri, rj := rankOf(sorted[i]), rankOf(sorted[j]); ri != rj {
+			return ri < rj
+		}
+		return sorted[i].SourceID < sorted[j].SourceID
+	})
+	return sorted[0]
+}
+
+// withInstallStats adds the installed version and the number of successful
+// installs recorded in the history log to the matches
+func withInstallStats(matches []PackageMatch) []PackageMatch {
+	installs := map[string]int{}
+	if history, err := readSelectionHistoryFn(); err == nil {
+		for _, entry := range history {
+			if entry.Success && entry.Action == files.HistoryActionInstall {
+				installs[entry.SourceID]++
+			}
+		}
+	}
+	out := make([]PackageMatch, len(matches))
+	for i, m := range matches {
+		m.InstalledVersion = local_packages_parser.GetBySourceId(m.SourceID).Version
+		m.InstallCount = installs[m.SourceID]
+		out[i] = m
+	}
+	return out
+}
+
+// selectionLabel is the text of a match in the provider selection prompt
+func selectionLabel(match PackageMatch) string {
+	label := fmt.Sprintf("%s:%s", match.Provider, match.PackageName)
+	if match.Name != "" && match.Name != match.PackageName {
+		label = fmt.Sprintf("%s (%s)", label, match.Name)
+	}
+	if match.Version != "" {
+		label += " v" + strings.TrimPrefix(match.Version, "v")
+	}
+	stats := []string{}
+	if match.InstalledVersion != "" {
+		stats = append(stats, "installed v"+strings.TrimPrefix(match.InstalledVersion, "v"))
+	}
+	if match.InstallCount > 0 {
+		stats = append(stats, fmt.Sprintf("%d× installed here", match.InstallCount))
+	}
+	if len(stats) > 0 {
+		label += " [" + strings.Join(stats, ", ") + "]"
+	}
+	if match.Description != "" {
+		// Truncate description if too long
+		desc := match.Description
+		if len(desc) > 60 {
+			desc = desc[:57] + "..."
+		}
+		label = fmt.Sprintf("%s - %s", label, desc)
+	}
+	return label
+}
@@ -0,0 +1,182 @@
+package zana
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var prettierMatches = []PackageMatch{
+	{SourceID: "github:prettier/prettier", Provider: "github", PackageName: "prettier/prettier", Version: "3.3.0"},
+	{SourceID: "npm:prettier", Provider: "npm", PackageName: "prettier", Version: "3.3.0", Description: "Prettier is an opinionated code formatter"},
+}
+
+func stubPackageSelection(t *testing.T, interactive bool, priority []string) {
+	t.Helper()
+	prevCan, prevPrompt, prevPriority, prevHistory := canPromptForSelection, promptForProviderSelectionFn, providerPriorityFn, readSelectionHistoryFn
+	t.Cleanup(func() {
+		canPromptForSelection, promptForProviderSelectionFn, providerPriorityFn, readSelectionHistoryFn = prevCan, prevPrompt, prevPriority, prevHistory
+	})
+	canPromptForSelection = func() bool { return interactive }
+	providerPriorityFn = func() []string { return priority }
+	readSelectionHistoryFn = func() ([]files.HistoryEntry, error) { return nil, nil }
+}
+
+func TestResolveByProviderPriority(t *testing.T) {
+	assert.Equal(t, "npm:prettier", resolveByProviderPriority(prettierMatches, nil).SourceID)
+	assert.Equal(t, "github:prettier/prettier", resolveByProviderPriority(prettierMatches, []string{"github"}).SourceID)
+
+	unknown := []PackageMatch{{SourceID: "zz:b", Provider: "zz"}, {SourceID: "zz:a", Provider: "zz"}, {SourceID: "opam:a", Provider: "opam"}}
+	assert.Equal(t, "opam:a", resolveByProviderPriority(unknown, nil).SourceID)
+	assert.Equal(t, "zz:a", resolveByProviderPriority(unknown[:2], nil).SourceID, "ties are broken by source ID")
+}
+
+func TestSelectInstallMatches(t *testing.T) {
+	t.Run("non-interactive exact matches use the provider priority", func(t *testing.T) {
+		stubPackageSelection(t, false, []string{"github"})
+		var ids []string
+		var err error
+		out := captureOutput(t, func() { ids, err = selectInstallMatches("prettier", prettierMatches, true) })
+		require.NoError(t, err)
+		assert.Equal(t, []string{"github:prettier/prettier"}, ids)
+		assert.Contains(t, out, "'prettier' matches github:prettier/prettier, npm:prettier, using github:prettier/prettier")
+	})
+
+	t.Run("non-interactive partial matches are not guessed", func(t *testing.T) {
+		stubPackageSelection(t, false, nil)
+		_, err := selectInstallMatches("prett", prettierMatches, false)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "use one of: github:prettier/prettier, npm:prettier")
+	})
+
+	t.Run("interactive prompt gets install stats", func(t *testing.T) {
+		stubPackageSelection(t, true, nil)
+		readSelectionHistoryFn = func() ([]files.HistoryEntry, error) {
+			return []files.HistoryEntry{
+				{Time: time.Now(), Action: files.HistoryActionInstall, SourceID: "npm:prettier", Success: true},
+				{Time: time.Now(), Action: files.HistoryActionInstall, SourceID: "npm:prettier", Success: true},
+				{Time: time.Now(), Action: files.HistoryActionInstall, SourceID: "npm:prettier", Success: false},
+			}, nil
+		}
+		var prompted []PackageMatch
+		promptForProviderSelectionFn = func(name string, matches []PackageMatch, action string) ([]string, error) {
+			prompted = matches
+			return []string{matches[1].SourceID}, nil
+		}
+		ids, err := selectInstallMatches("prettier", prettierMatches, true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"npm:prettier"}, ids)
+		require.Len(t, prompted, 2)
+		assert.Equal(t, 2, prompted[1].InstallCount)
+		assert.Equal(t, 0, prompted[0].InstallCount)
+	})
+}
+
+func TestSelectionLabel(t *testing.T) {
+	match := prettierMatches[1]
+	match.InstalledVersion = "3.2.0"
+	match.InstallCount = 2
+	assert.Equal(t, "npm:prettier v3.3.0 [installed v3.2.0, 2× installed here] - Prettier is an opinionated code formatter", selectionLabel(match))
+	assert.Equal(t, "github:prettier/prettier v3.3.0", selectionLabel(prettierMatches[0]))
+}
+
+func TestSelectInstallMatchesSkipsDisabledProviders(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("ZANA_HOME", home)
+	require.NoError(t, os.WriteFile(filepath.Join(home, "config.yaml"), []byte("providers:\n  disabled: [github]\n"), 0644))
+	stubPackageSelection(t, false, []string{"github"})
+
+	ids, err := selectInstallMatches("prettier", prettierMatches, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"npm:prettier"}, ids)
+
+	_, err = selectInstallMatches("prettier", prettierMatches[:1], true)
+	assert.EqualError(t, err, "provider 'github' is disabled by policy (providers.disabled in config.yaml)")
+	assert.EqualError(t, validatePackageArgs([]string{"github:prettier/prettier"}), err.Error())
+}
+
+func TestSelectInstalledMatches(t *testing.T) {
+	prevParser := newRegistryParser
+	t.Cleanup(func() { newRegistryParser = prevParser })
+	newRegistryParser = func() *registry_parser.RegistryParser {
+		rp := registry_parser.NewRegistryParser(nil)
+		require.NoError(t, rp.LoadFromBytes([]byte(`[
+			{"name": "pyright", "version": "1.1.0", "aliases": ["pyright-langserver"], "source": {"id": "npm:pyright"}},
+			{"name": "basedpyright", "version": "1.2.0", "source": {"id": "pypi:basedpyright"}}
+		]`)))
+		return rp
+	}
+	installed := []PackageMatch{
+		{SourceID: "npm:pyright", Provider: "npm", PackageName: "pyright"},
+		{SourceID: "pypi:basedpyright", Provider: "pypi", PackageName: "basedpyright"},
+	}
+
+	t.Run("exact name or alias wins without prompting", func(t *testing.T) {
+		stubPackageSelection(t, true, nil)
+		promptForProviderSelectionFn = func(string, []PackageMatch, string) ([]string, error) {
+			t.Fatal("should not prompt")
+			return nil, nil
+		}
+		ids, err := selectInstalledMatches("Pyright", installed, "update")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"npm:pyright"}, ids)
+
+		ids, err = selectInstalledMatches("pyright-langserver", installed, "update")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"npm:pyright"}, ids)
+	})
+
+	t.Run("several partial matches prompt", func(t *testing.T) {
+		stubPackageSelection(t, true, nil)
+		var prompted []PackageMatch
+		promptForProviderSelectionFn = func(_ string, matches []PackageMatch, action string) ([]string, error) {
+			prompted = matches
+			assert.Equal(t, "update", action)
+			return []string{matches[1].SourceID}, nil
+		}
+		ids, err := selectInstalledMatches("pyr", installed, "update")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"pypi:basedpyright"}, ids)
+		assert.Len(t, prompted, 2)
+	})
+
+	t.Run("several matches without a terminal are an error", func(t *testing.T) {
+		stubPackageSelection(t, false, nil)
+		_, err := selectInstalledMatches("pyr", installed, "update")
+		assert.EqualError(t, err, "'pyr' matches several installed packages, use one of: npm:pyright, pypi:basedpyright")
+	})
+}
+
+func TestMatchInstalledPattern(t *testing.T) {
+	installed := []local_packages_parser.LocalPackageItem{
+		{SourceID: "npm:eslint"},
+		{SourceID: "npm:@typescript-eslint/parser"},
+		{SourceID: "npm:prettier"},
+		{SourceID: "github:eslint/eslint-ls"},
+	}
+
+	ids, err := matchInstalledPattern("npm:*eslint*", installed)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"npm:eslint", "npm:@typescript-eslint/parser"}, ids)
+
+	ids, err = matchInstalledPattern("eslint*", installed)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"npm:eslint", "github:eslint/eslint-ls"}, ids, "patterns without a provider match names")
+
+	ids, err = matchInstalledPattern("NPM:Prett?er", installed)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"npm:prettier"}, ids)
+
+	_, err = matchInstalledPattern("npm:[", installed)
+	assert.Error(t, err)
+
+	assert.True(t, isPackagePattern("npm:*eslint*"))
+	assert.False(t, isPackagePattern("npm:eslint"))
+}
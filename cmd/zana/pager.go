@@ -0,0 +1,68 @@
+package zana
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// startPager pipes output through a pager when stdout is a terminal and
+// returns the writer to print to, plus a function waiting for the pager to exit.
+// The pager is $ZANA_PAGER, $PAGER or less, in that order; "cat" or an empty
+// ZANA_PAGER disables paging. Like git, LESS defaults to FRX, so output that
+// fits on one screen is printed directly.
+func startPager(disabled bool) (io.Writer, func()) {
+	noPager := func() {}
+	if disabled || !pagerStdoutIsTerminal() {
+		return os.Stdout, noPager
+	}
+	command := pagerCommand()
+	if len(command) == 0 {
+		return os.Stdout, noPager
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return os.Stdout, noPager
+	}
+	if err := cmd.Start(); err != nil {
+		return os.Stdout, noPager
+	}
+	return stdin, func() {
+		stdin.Close()
+		_ = cmd.Wait()
+	}
+}
+
+// pagerCommand returns the pager command line, or nil when paging is disabled
+func pagerCommand() []string {
+	pager, set := os.LookupEnv("ZANA_PAGER")
+	if !set {
+		pager = os.Getenv("PAGER")
+		if pager == "" {
+			if _, err := pagerLookPath("less"); err == nil {
+				return []string{"less"}
+			}
+			return nil
+		}
+	}
+	fields := strings.Fields(pager)
+	if len(fields) == 0 || fields[0] == "cat" {
+		return nil
+	}
+	return fields
+}
+
+// indirections for testability
+var pagerStdoutIsTerminal = func() bool { return term.IsTerminal(os.Stdout.Fd()) }
+var pagerLookPath = exec.LookPath
@@ -0,0 +1,66 @@
+package zana
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPagerCommand(t *testing.T) {
+	prevLookPath := pagerLookPath
+	t.Cleanup(func() { pagerLookPath = prevLookPath })
+	pagerLookPath = func(string) (string, error) { return "/usr/bin/less", nil }
+
+	t.Setenv("PAGER", "")
+	os.Unsetenv("ZANA_PAGER")
+	assert.Equal(t, []string{"less"}, pagerCommand())
+
+	pagerLookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+	assert.Nil(t, pagerCommand(), "no pager without less")
+
+	t.Setenv("PAGER", "most -s")
+	assert.Equal(t, []string{"most", "-s"}, pagerCommand())
+
+	t.Setenv("ZANA_PAGER", "bat --plain")
+	assert.Equal(t, []string{"bat", "--plain"}, pagerCommand(), "ZANA_PAGER wins over PAGER")
+
+	t.Setenv("ZANA_PAGER", "")
+	assert.Nil(t, pagerCommand(), "an empty ZANA_PAGER disables paging")
+
+	t.Setenv("ZANA_PAGER", "cat")
+	assert.Nil(t, pagerCommand())
+}
+
+func TestStartPager(t *testing.T) {
+	prevTerminal := pagerStdoutIsTerminal
+	t.Cleanup(func() { pagerStdoutIsTerminal = prevTerminal })
+
+	pagerStdoutIsTerminal = func() bool { return false }
+	w, done := startPager(false)
+	done()
+	assert.Equal(t, os.Stdout, w, "no pager when stdout is not a terminal")
+
+	if _, err := exec.LookPath("tee"); err != nil {
+		t.Skip("tee is not available")
+	}
+	pagerStdoutIsTerminal = func() bool { return true }
+	w, done = startPager(true)
+	done()
+	assert.Equal(t, os.Stdout, w, "--no-pager")
+
+	path := filepath.Join(t.TempDir(), "paged.txt")
+	t.Setenv("ZANA_PAGER", "tee "+path)
+	captureOutput(t, func() {
+		w, done = startPager(false)
+		fmt.Fprintln(w, "through the pager")
+		done()
+	})
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "through the pager\n", string(data))
+}
@@ -0,0 +1,173 @@
+package zana
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/mistweaverco/zana-client/internal/lib/version"
+	"github.com/spf13/cobra"
+)
+
+// pluginPrefix is the file name prefix of plugin executables: zana foo runs zana-foo
+const pluginPrefix = "zana-"
+
+// plugin is an executable in the plugins directory providing the subcommand Name
+type plugin struct {
+	Name string
+	Path string
+}
+
+// pluginContext is passed to a plugin as JSON on stdin
+type pluginContext struct {
+	ZanaVersion    string      `json:"zana_version"`
+	ZanaExecutable string      `json:"zana_executable"`
+	Plugin         string      `json:"plugin"`
+	Args           []string    `json:"args"`
+	OutputMode     string      `json:"output_mode"`
+	Color          bool        `json:"color"`
+	Paths          pluginPaths `json:"paths"`
+}
+
+type pluginPaths struct {
+	Config       string `json:"config"`
+	Data         string `json:"data"`
+	Bin          string `json:"bin"`
+	Packages     string `json:"packages"`
+	Plugins      string `json:"plugins"`
+	LockFile     string `json:"lock_file"`
+	RegistryFile string `json:"registry_file"`
+}
+
+// indirections for testability
+var (
+	pluginsPathFn = files.GetAppPluginsPath
+	runPluginFn   = runPlugin
+)
+
+// windowsPluginExts are the extensions a plugin may have on Windows
+var windowsPluginExts = []string{".exe", ".cmd", ".bat", ".com"}
+
+// discoverPlugins returns the plugins in the plugins directory, sorted by name.
+// On Unix a plugin has to be executable; on Windows it needs one of windowsPluginExts.
+func discoverPlugins(dir string) []plugin {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	var plugins []plugin
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), pluginPrefix) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		info, err := os.Stat(path) // follow symlinks
+		if err != nil || info.IsDir() {
+			continue
+		}
+		name := strings.TrimPrefix(e.Name(), pluginPrefix)
+		if runtime.GOOS == "windows" {
+			ext := strings.ToLower(filepath.Ext(name))
+			found := false
+			for _, pluginExt := range windowsPluginExts {
+				found = found || ext == pluginExt
+			}
+			if !found {
+				continue
+			}
+			name = strings.TrimSuffix(name, filepath.Ext(name))
+		} else if info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+		if name == "" || strings.HasPrefix(name, "-") || seen[name] {
+			continue
+		}
+		seen[name] = true
+		plugins = append(plugins, plugin{Name: name, Path: path})
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// registerPlugins adds a subcommand for every plugin. Built-in commands
+// (and their aliases) always win over a plugin of the same name.
+func registerPlugins(root *cobra.Command) {
+	for _, p := range discoverPlugins(pluginsPathFn()) {
+		// help and completion are only added by cobra when the command runs
+		builtin := p.Name == "help" || p.Name == "completion"
+		if cmd, _, err := root.Find([]string{p.Name}); builtin || (err == nil && cmd != root) {
+			providers.Logger.Info(fmt.Sprintf("Plugins: Ignoring %s, %s is a built-in command", p.Path, p.Name))
+			continue
+		}
+		root.AddCommand(newPluginCommand(p))
+	}
+}
+
+// pluginAnnotation marks the commands of plugins
+const pluginAnnotation = "zana.plugin"
+
+func newPluginCommand(p plugin) *cobra.Command {
+	return &cobra.Command{
+		Use:         p.Name,
+		Short:       fmt.Sprintf("Plugin %s", p.Path),
+		Annotations: map[string]string{pluginAnnotation: p.Path},
+		// Every argument, flags included, belongs to the plugin
+		DisableFlagParsing: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runPluginFn(p, args); err != nil {
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) {
+					osExit(exitErr.ExitCode())
+					return
+				}
+				fmt.Fprintf(os.Stderr, "%s plugin %s: %v\n", IconClose(), p.Name, err)
+				osExit(126)
+			}
+		},
+	}
+}
+
+// newPluginContext describes the zana installation to a plugin
+func newPluginContext(p plugin, args []string) pluginContext {
+	executable, _ := os.Executable()
+	return pluginContext{
+		ZanaVersion:    version.VERSION,
+		ZanaExecutable: executable,
+		Plugin:         p.Name,
+		Args:           args,
+		OutputMode:     string(getColorConfig().Output),
+		Color:          shouldUseColors(),
+		Paths: pluginPaths{
+			Config:       files.GetAppDataPath(),
+			Data:         files.GetAppDataSharePath(),
+			Bin:          files.GetAppBinPath(),
+			Packages:     files.GetAppPackagesPath(),
+			Plugins:      pluginsPathFn(),
+			LockFile:     files.GetAppLocalPackagesFilePath(),
+			RegistryFile: files.GetAppRegistryFilePath(),
+		},
+	}
+}
+
+// runPlugin runs the plugin with args, passing the context as JSON on stdin
+func runPlugin(p plugin, args []string) error {
+	data, err := json.Marshal(newPluginContext(p, args))
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(p.Path, args...)
+	cmd.Stdin = strings.NewReader(string(data) + "\n")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "ZANA_PLUGIN="+p.Name)
+	return cmd.Run()
+}
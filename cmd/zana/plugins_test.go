@@ -0,0 +1,105 @@
+package zana
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755))
+	return path
+}
+
+func TestDiscoverPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts in this test")
+	}
+	dir := t.TempDir()
+	writePlugin(t, dir, "zana-foo", "exit 0\n")
+	writePlugin(t, dir, "zana-bar", "exit 0\n")
+	writePlugin(t, dir, "other-tool", "exit 0\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "zana-notexec"), []byte("x"), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "zana-dir"), 0755))
+
+	plugins := discoverPlugins(dir)
+	assert.Equal(t, []plugin{
+		{Name: "bar", Path: filepath.Join(dir, "zana-bar")},
+		{Name: "foo", Path: filepath.Join(dir, "zana-foo")},
+	}, plugins)
+
+	assert.Empty(t, discoverPlugins(filepath.Join(dir, "missing")))
+}
+
+func TestRegisterPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts in this test")
+	}
+	dir := t.TempDir()
+	writePlugin(t, dir, "zana-foo", "exit 0\n")
+	writePlugin(t, dir, "zana-list", "exit 0\n")
+	writePlugin(t, dir, "zana-ls", "exit 0\n")
+	writePlugin(t, dir, "zana-help", "exit 0\n")
+
+	origPath := pluginsPathFn
+	t.Cleanup(func() { pluginsPathFn = origPath })
+	pluginsPathFn = func() string { return dir }
+
+	root := &cobra.Command{Use: "zana"}
+	root.AddCommand(&cobra.Command{Use: "list", Aliases: []string{"ls"}, Run: func(*cobra.Command, []string) {}})
+	registerPlugins(root)
+
+	var names []string
+	for _, c := range root.Commands() {
+		names = append(names, c.Name())
+	}
+	assert.ElementsMatch(t, []string{"foo", "list"}, names, "built-in commands win over plugins")
+}
+
+func TestPluginCommandRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts in this test")
+	}
+	t.Setenv("ZANA_HOME", t.TempDir())
+	dir := t.TempDir()
+	out := filepath.Join(dir, "context.json")
+	path := writePlugin(t, dir, "zana-foo", "cat > '"+out+"'\necho \"$ZANA_PLUGIN $*\" >> '"+out+".args'\nexit 3\n")
+
+	origExit := osExit
+	t.Cleanup(func() { osExit = origExit })
+	exitCode := -1
+	osExit = func(code int) { exitCode = code }
+
+	cmd := newPluginCommand(plugin{Name: "foo", Path: path})
+	cmd.SetArgs([]string{"--verbose", "arg"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, 3, exitCode, "the plugin's exit code is passed on")
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	var ctx pluginContext
+	require.NoError(t, json.Unmarshal(data, &ctx))
+	assert.Equal(t, "foo", ctx.Plugin)
+	assert.Equal(t, []string{"--verbose", "arg"}, ctx.Args)
+	assert.Equal(t, os.Getenv("ZANA_HOME"), ctx.Paths.Config)
+
+	args, err := os.ReadFile(out + ".args")
+	require.NoError(t, err)
+	assert.Equal(t, "foo --verbose arg\n", string(args))
+
+	t.Run("reports plugins that fail to start", func(t *testing.T) {
+		exitCode = -1
+		cmd := newPluginCommand(plugin{Name: "gone", Path: filepath.Join(dir, "zana-gone")})
+		cmd.SetArgs([]string{})
+		require.NoError(t, cmd.Execute())
+		assert.Equal(t, 126, exitCode)
+	})
+}
@@ -0,0 +1,57 @@
+package zana
+
+import (
+	"fmt"
+
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+)
+
+// providerToolPreflight checks, before installing a package, that the tool its
+// provider shells out to (npm, pip, cargo, go, git, ...) is available when the
+// install uses it. Each provider is only checked once per command run.
+type providerToolPreflight struct {
+	statuses map[string]providers.ProviderHealthStatus
+}
+
+func newProviderToolPreflight() *providerToolPreflight {
+	return &providerToolPreflight{statuses: map[string]providers.ProviderHealthStatus{}}
+}
+
+// check reports whether the provider tool for sourceID is available.
+// A missing tool is reported via printf, with installation instructions
+// for the current OS the first time the provider is encountered.
+func (p *providerToolPreflight) check(sourceID string, printf func(format string, args ...interface{})) bool {
+	if !installNeedsProviderToolFn(sourceID) {
+		return true
+	}
+	provider := getProviderFromSourceID(sourceID)
+	status, seen := p.statuses[provider]
+	if !seen {
+		status = checkProviderHealthFn(provider)
+		p.statuses[provider] = status
+	}
+	if status.Available {
+		return true
+	}
+	printf("%s Cannot install %s: %s is not installed or not on PATH\n", IconClose(), sourceID, status.RequiredTool)
+	if !seen {
+		if status.InstallHint != "" {
+			printf("   %s %s\n", IconLightbulb(), status.InstallHint)
+		}
+		if len(status.FixCommand) > 0 {
+			printf("   %s Or run 'zana doctor --fix' to install it\n", IconLightbulb())
+		}
+	}
+	return false
+}
+
+// printfStdout adapts fmt.Printf to the printf signature used by providerToolPreflight
+func printfStdout(format string, args ...interface{}) {
+	fmt.Printf(format, args...)
+}
+
+// indirections for testability
+var (
+	checkProviderHealthFn      = providers.CheckProviderHealth
+	installNeedsProviderToolFn = providers.InstallNeedsProviderTool
+)
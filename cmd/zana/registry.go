@@ -0,0 +1,141 @@
+package zana
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/spf13/cobra"
+)
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Manage the registries packages are looked up in",
+	Long: `Manage the registries packages are looked up in.
+
+Besides the Zana Registry (or the registry.urls from config.yaml),
+zana can use registries of private tools. They are stored under
+registry.custom in config.yaml and take precedence over the other registries.
+
+The subcommands are:
+  list     - List all registries
+  add      - Add a custom registry
+  remove   - Remove a custom registry
+  enable   - Use a disabled custom registry again
+  disable  - Stop using a custom registry without removing it`,
+}
+
+var registryListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List all registries",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		registries := listRegistriesFn()
+		if ShouldUseJSONOutput() {
+			PrintJSON(registries)
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSTATUS\tSIGNATURE\tURL")
+		for _, r := range registries {
+			status := "enabled"
+			if !r.Enabled {
+				status = "disabled"
+			}
+			signature := "-"
+			if r.SignatureRequired {
+				signature = "required"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Name, status, signature, r.URL)
+		}
+		_ = w.Flush()
+	},
+}
+
+var registryAddCmd = &cobra.Command{
+	Use:   "add <name> <url>",
+	Short: "Add a custom registry",
+	Long: `Add a custom registry.
+
+The URL points to a registry zip laid out like the Zana Registry's.
+With --public-key (a base64 encoded ed25519 key) the registry is trusted only
+when its zip matches the signature published next to it as <url>.sig.
+
+Examples:
+  zana registry add work https://example.com/zana-registry.json.zip
+  zana registry add work https://example.com/zana-registry.json.zip --public-key "$(cat work-registry.pub)"`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := addCustomRegistryFn(files.CustomRegistry{Name: args[0], URL: args[1], PublicKey: registryPublicKey})
+		printRegistryResult(args[0], "Added", err)
+	},
+}
+
+var registryRemoveCmd = &cobra.Command{
+	Use:     "remove <name>",
+	Aliases: []string{"rm"},
+	Short:   "Remove a custom registry",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		printRegistryResult(args[0], "Removed", removeCustomRegistryFn(args[0]))
+	},
+}
+
+var registryEnableCmd = &cobra.Command{
+	Use:   "enable <name>",
+	Short: "Use a disabled custom registry again",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		printRegistryResult(args[0], "Enabled", setCustomRegistryEnabledFn(args[0], true))
+	},
+}
+
+var registryDisableCmd = &cobra.Command{
+	Use:   "disable <name>",
+	Short: "Stop using a custom registry without removing it",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		printRegistryResult(args[0], "Disabled", setCustomRegistryEnabledFn(args[0], false))
+	},
+}
+
+var registryPublicKey string
+
+// indirections for testability
+var (
+	listRegistriesFn           = files.ListRegistries
+	addCustomRegistryFn        = files.AddCustomRegistry
+	removeCustomRegistryFn     = files.RemoveCustomRegistry
+	setCustomRegistryEnabledFn = files.SetCustomRegistryEnabled
+)
+
+func init() {
+	registryCmd.AddCommand(registryAddCmd)
+	registryCmd.AddCommand(registryDisableCmd)
+	registryCmd.AddCommand(registryEnableCmd)
+	registryCmd.AddCommand(registryListCmd)
+	registryCmd.AddCommand(registryRemoveCmd)
+	registryAddCmd.Flags().StringVar(&registryPublicKey, "public-key", "", "base64 encoded ed25519 key the registry zip has to be signed with")
+}
+
+func printRegistryResult(name, done string, err error) {
+	if ShouldUseJSONOutput() {
+		out := map[string]interface{}{
+			"success": err == nil,
+			"name":    name,
+		}
+		if err != nil {
+			out["error"] = err.Error()
+		}
+		PrintJSON(out)
+	} else if err != nil {
+		fmt.Printf("%s %v\n", IconClose(), err)
+	} else {
+		fmt.Printf("%s %s registry '%s'\n", IconCheck(), done, name)
+	}
+	if err != nil {
+		osExit(1)
+	}
+}
@@ -0,0 +1,53 @@
+package zana
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryListCommand(t *testing.T) {
+	prev := listRegistriesFn
+	t.Cleanup(func() { listRegistriesFn = prev })
+	listRegistriesFn = func() []files.RegistryInfo {
+		return []files.RegistryInfo{
+			{Name: "default", URL: "https://example.com/zana.zip", Enabled: true},
+			{Name: "work", URL: "https://example.com/work.zip", SignatureRequired: true, Custom: true},
+		}
+	}
+
+	out := captureOutput(t, func() { registryListCmd.Run(registryListCmd, nil) })
+	assert.Contains(t, out, "NAME     STATUS    SIGNATURE  URL")
+	assert.Contains(t, out, "work     disabled  required   https://example.com/work.zip")
+
+	out = captureOutputWithMode(t, func() { registryListCmd.Run(registryListCmd, nil) }, config.OutputModeJSON)
+	assert.Contains(t, out, `"signature_required": true`)
+}
+
+func TestRegistryAddCommand(t *testing.T) {
+	prevAdd, prevExit, prevKey := addCustomRegistryFn, osExit, registryPublicKey
+	t.Cleanup(func() { addCustomRegistryFn, osExit, registryPublicKey = prevAdd, prevExit, prevKey })
+	var exitCodes []int
+	osExit = func(code int) { exitCodes = append(exitCodes, code) }
+	var added []files.CustomRegistry
+	addCustomRegistryFn = func(r files.CustomRegistry) error {
+		if r.Name == "work" && len(added) > 0 {
+			return errors.New("registry 'work' already exists")
+		}
+		added = append(added, r)
+		return nil
+	}
+	registryPublicKey = "a2V5"
+
+	out := captureOutput(t, func() { registryAddCmd.Run(registryAddCmd, []string{"work", "https://example.com/work.zip"}) })
+	assert.Contains(t, out, "Added registry 'work'")
+	assert.Equal(t, []files.CustomRegistry{{Name: "work", URL: "https://example.com/work.zip", PublicKey: "a2V5"}}, added)
+	assert.Empty(t, exitCodes)
+
+	out = captureOutput(t, func() { registryAddCmd.Run(registryAddCmd, []string{"work", "https://example.com/work.zip"}) })
+	assert.Contains(t, out, "registry 'work' already exists")
+	assert.Equal(t, []int{1}, exitCodes)
+}
@@ -2,8 +2,12 @@ package zana
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/charmbracelet/huh"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/providers"
 	"github.com/mistweaverco/zana-client/internal/lib/spinnerutil"
 	"github.com/spf13/cobra"
@@ -23,6 +27,13 @@ Supported package ID formats:
   github:user/repo
   gitlab:group/subgroup/project
   codeberg:user/repo
+  gitea:git.example.com/user/repo
+
+Glob patterns (quoted, so the shell leaves them alone) and --all remove many
+packages at once, --provider limits them to the packages of some providers.
+* also matches a /, and a pattern without a provider matches package names.
+The packages are listed for confirmation first; --dry-run only lists them,
+--yes skips the confirmation.
 
 Examples:
   zana remove npm:@prisma/language-server
@@ -30,20 +41,34 @@ Examples:
   zana delete pypi:black cargo:ripgrep
   zana remove npm:prettier golang:golang.org/x/tools/gopls
   zana remove github:sharkdp/bat
-  zana remove gitlab:group/subgroup/myproject`,
-	Args: cobra.MinimumNArgs(1),
+  zana remove gitlab:group/subgroup/myproject
+  zana remove 'npm:*eslint*'
+  zana remove --provider npm --all
+  zana remove --all --dry-run`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && !removeAll {
+			return fmt.Errorf("requires at least 1 package or --all")
+		}
+		return nil
+	},
 	// Enable shell completion for installed package IDs only.
 	ValidArgsFunction: installedPackageIDCompletion,
 	Run: func(cmd *cobra.Command, args []string) {
 		userIntegrations := append([]string(nil), removeIntegrations...)
 		providers.SetRequestedIntegrations(userIntegrations)
 
-		packages := args
+		// Patterns and --all select many installed packages at once
+		massIDs, packages, mass, err := resolveMassRemoval(args)
+		if err != nil {
+			fmt.Printf("%s %v\n", IconClose(), err)
+			osExit(1)
+			return
+		}
 
 		// Process all packages
 		// Use slices instead of fixed-size arrays to handle multi-select results
-		internalIDs := make([]string, 0, len(packages))
-		displayIDs := make([]string, 0, len(packages))
+		internalIDs := make([]string, 0, len(packages)+len(massIDs))
+		displayIDs := make([]string, 0, len(packages)+len(massIDs))
 
 		for _, userPkgID := range packages {
 			// Parse package ID and version from the user-facing ID
@@ -53,11 +78,12 @@ Examples:
 			var displayID string
 
 			// Check if this is a package name without provider
-			if !strings.Contains(baseID, ":") && !strings.HasPrefix(baseID, "pkg:") {
+			if !packageid.HasProvider(baseID) {
 				// Package name without provider - search installed packages and prompt user
 				matches := findInstalledPackagesByName(baseID)
 				if len(matches) == 0 {
 					fmt.Printf("%s No installed packages found matching '%s'\n", IconClose(), baseID)
+					osExit(1)
 					return
 				}
 
@@ -65,6 +91,7 @@ Examples:
 				selectedSourceIDs, err := promptForProviderSelection(baseID, matches, "remove")
 				if err != nil {
 					fmt.Printf("%s Error selecting provider for '%s': %v\n", IconClose(), baseID, err)
+					osExit(1)
 					return
 				}
 
@@ -79,10 +106,12 @@ Examples:
 				provider, pkgName, err := parseUserPackageID(baseID)
 				if err != nil {
 					fmt.Printf("Error: %v\n", err)
+					osExit(1)
 					return
 				}
 				if !isSupportedProviderFn(provider) {
 					fmt.Printf("Error: Unsupported provider '%s' for package '%s'. Supported providers: %s\n", provider, userPkgID, strings.Join(availableProvidersFn(), ", "))
+					osExit(1)
 					return
 				}
 
@@ -95,12 +124,33 @@ Examples:
 			displayIDs = append(displayIDs, displayID)
 		}
 
+		for _, id := range massIDs {
+			if !slices.Contains(internalIDs, id) {
+				internalIDs = append(internalIDs, id)
+				displayIDs = append(displayIDs, id)
+			}
+		}
+
+		if removeDryRun {
+			printRemovalPlan(displayIDs)
+			return
+		}
+		if mass && !removeYes {
+			if !canPromptForSelection() {
+				fmt.Printf("%s Not removing %d packages without confirmation, pass --yes to remove them\n", IconClose(), len(displayIDs))
+				osExit(1)
+				return
+			}
+			if !confirmRemovalFn(displayIDs) {
+				fmt.Println("Nothing removed.")
+				return
+			}
+		}
+
 		// Remove all packages
 		fmt.Printf("Removing %d package(s)...\n", len(internalIDs))
 
-		allSuccess := true
-		successCount := 0
-		failedCount := 0
+		summary := newOperationSummary("Remove", "remove", "removed")
 
 		for i := range internalIDs {
 			internalID := internalIDs[i]
@@ -116,8 +166,7 @@ Examples:
 			)
 			if resolveErr != nil {
 				fmt.Printf("%s %v\n", IconClose(), resolveErr)
-				failedCount++
-				allSuccess = false
+				summary.fail(displayID)
 				continue
 			}
 			providers.SetRequestedIntegrations(effectiveIntegrations)
@@ -131,8 +180,7 @@ Examples:
 			title := fmt.Sprintf("Removing %s...", displayID)
 			if err := spinnerutil.Run(title, action); err != nil {
 				fmt.Printf("%s Failed to remove %s: %v\n", IconClose(), displayID, err)
-				failedCount++
-				allSuccess = false
+				summary.fail(displayID)
 				providers.SetRequestedIntegrations(userIntegrations)
 				continue
 			}
@@ -141,40 +189,130 @@ Examples:
 
 			if success {
 				fmt.Printf("%s Successfully removed %s\n", IconCheck(), displayID)
-				successCount++
+				summary.succeed(displayID)
 			} else {
 				fmt.Printf("%s Failed to remove %s\n", IconClose(), displayID)
-				failedCount++
-				allSuccess = false
+				summary.fail(displayID)
 			}
 		}
 
 		// Print summary
 		if ShouldUseJSONOutput() {
 			result := map[string]interface{}{
-				"success_count": successCount,
-				"failure_count": failedCount,
-				"all_success":   allSuccess,
+				"success_count": summary.successCount(),
+				"failure_count": len(summary.failed),
+				"all_success":   summary.allSucceeded(),
+				"summary":       summary.toJSON(),
 			}
 			PrintJSON(result)
 		} else {
-			fmt.Printf("\nRemove Summary:\n")
-			fmt.Printf("  Successfully removed: %d\n", successCount)
-			fmt.Printf("  Failed to remove: %d\n", failedCount)
-
-			if allSuccess {
-				fmt.Printf("All packages removed successfully!\n")
-			} else {
-				fmt.Printf("Some packages failed to remove.\n")
-			}
+			summary.print(printfStdout)
+		}
+		if !summary.allSucceeded() {
+			osExit(1)
 		}
 	},
 }
 
 var removeIntegrations []string
+var removeProviders []string
+var removeAll bool
+var removeDryRun bool
+var removeYes bool
+
+// confirmRemovalFn is a variable to allow overriding in tests
+var confirmRemovalFn = confirmRemoval
 
 func init() {
 	removeCmd.Flags().StringSliceVar(&removeIntegrations, "integrate", nil, "run integration backends cleanup when removing (e.g. --integrate neovim)")
+	removeCmd.Flags().StringSliceVar(&removeProviders, "provider", nil, "only remove packages of these providers matched by patterns or --all")
+	removeCmd.Flags().BoolVar(&removeAll, "all", false, "remove all installed packages")
+	removeCmd.Flags().BoolVar(&removeDryRun, "dry-run", false, "list the packages that would be removed without removing them")
+	removeCmd.Flags().BoolVarP(&removeYes, "yes", "y", false, "remove packages matched by patterns or --all without asking")
+}
+
+// resolveMassRemoval returns the installed packages matched by the glob
+// patterns among args or, without patterns, selected with --all, the
+// remaining args and whether any of those were used. --provider only
+// narrows down what patterns and --all select.
+func resolveMassRemoval(args []string) (ids []string, rest []string, mass bool, err error) {
+	var patterns []string
+	for _, arg := range args {
+		if isPackagePattern(arg) {
+			patterns = append(patterns, arg)
+		} else {
+			rest = append(rest, arg)
+		}
+	}
+	mass = len(patterns) > 0 || removeAll
+	if !mass {
+		return nil, rest, false, nil
+	}
+
+	installed := newLocalPackagesParserFn().Packages
+	if len(removeProviders) > 0 {
+		var filtered []local_packages_parser.LocalPackageItem
+		for _, pkg := range installed {
+			if slices.Contains(removeProviders, getProviderFromSourceID(pkg.SourceID)) {
+				filtered = append(filtered, pkg)
+			}
+		}
+		installed = filtered
+	}
+
+	if removeAll && len(patterns) == 0 {
+		for _, pkg := range installed {
+			ids = append(ids, pkg.SourceID)
+		}
+	}
+	for _, pattern := range patterns {
+		matched, err := matchInstalledPattern(pattern, installed)
+		if err != nil {
+			return nil, nil, true, err
+		}
+		for _, id := range matched {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 && len(rest) == 0 {
+		return nil, nil, true, fmt.Errorf("no installed packages match")
+	}
+	return ids, rest, true, nil
+}
+
+// printRemovalPlan lists the packages zana remove --dry-run would remove
+func printRemovalPlan(ids []string) {
+	if ShouldUseJSONOutput() {
+		PrintJSON(map[string]interface{}{
+			"dry_run":  true,
+			"packages": ids,
+		})
+		return
+	}
+	fmt.Printf("Would remove %d package(s):\n", len(ids))
+	for _, id := range ids {
+		fmt.Printf("  %s\n", id)
+	}
+}
+
+func confirmRemoval(ids []string) bool {
+	confirm := false
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(fmt.Sprintf("Remove %d packages?", len(ids))).
+				Description(strings.Join(ids, "\n")).
+				Affirmative("Remove").
+				Negative("Cancel").
+				Value(&confirm),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return false
+	}
+	return confirm
 }
 
 // findInstalledPackagesByName searches installed packages for packages matching the given name
@@ -210,6 +348,11 @@ func findInstalledPackagesByName(packageName string) []PackageMatch {
 		// Also check aliases from registry
 		aliasMatches := false
 		registryItem := parser.GetBySourceId(sourceID)
+		if registryItem.Source.ID == "" && pkg.Extras != nil && pkg.Extras.OverrideOf != "" {
+			// Installed with --from in place of a registry package, known by its name
+			registryItem = parser.GetBySourceId(pkg.Extras.OverrideOf)
+			nameMatches = nameMatches || strings.Contains(strings.ToLower(registryItem.Name), packageNameLower)
+		}
 		if registryItem.Source.ID != "" {
 			for _, alias := range registryItem.Aliases {
 				if strings.Contains(strings.ToLower(alias), packageNameLower) {
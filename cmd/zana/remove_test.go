@@ -6,6 +6,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -25,8 +26,15 @@ func TestRemoveCommand(t *testing.T) {
 }
 
 func TestRemoveCommandRunPaths(t *testing.T) {
+	prevExit := osExit
+	t.Cleanup(func() { osExit = prevExit })
+	codes := []int{}
+	osExit = func(code int) { codes = append(codes, code) }
+
 	t.Run("invalid id format", func(t *testing.T) {
+		codes = codes[:0]
 		removeCmd.Run(removeCmd, []string{"invalid"})
+		assert.Equal(t, []int{1}, codes)
 	})
 
 	t.Run("unsupported provider", func(t *testing.T) {
@@ -35,7 +43,9 @@ func TestRemoveCommandRunPaths(t *testing.T) {
 		isSupportedProviderFn = func(p string) bool { return false }
 		availableProvidersFn = func() []string { return []string{"npm"} }
 		defer func() { isSupportedProviderFn = prevSupp; availableProvidersFn = prevAvail }()
+		codes = codes[:0]
 		removeCmd.Run(removeCmd, []string{"pkg:unknown/x"})
+		assert.Equal(t, []int{1}, codes)
 	})
 
 	t.Run("successful remove single package", func(t *testing.T) {
@@ -62,7 +72,9 @@ func TestRemoveCommandRunPaths(t *testing.T) {
 		isSupportedProviderFn = func(p string) bool { return true }
 		removePackageFn = func(id string) bool { return false }
 		defer func() { isSupportedProviderFn = prevSupp; removePackageFn = prevRemove }()
+		codes = codes[:0]
 		removeCmd.Run(removeCmd, []string{"pkg:npm/eslint"})
+		assert.Equal(t, []int{1}, codes)
 	})
 
 	t.Run("mixed success/failure multiple packages", func(t *testing.T) {
@@ -82,6 +94,10 @@ func TestRemoveCommandRunPaths(t *testing.T) {
 }
 
 func TestRemoveCommandFullOutputGolden(t *testing.T) {
+	prevExit := osExit
+	t.Cleanup(func() { osExit = prevExit })
+	osExit = func(int) {}
+
 	t.Run("remove success single package", func(t *testing.T) {
 		// Capture stdout
 		old := os.Stdout
@@ -198,3 +214,114 @@ func TestRemoveCommandFullOutputGolden(t *testing.T) {
 		assert.Contains(t, out, "Some packages failed to remove.")
 	})
 }
+
+func stubMassRemoval(t *testing.T, interactive bool) (*[]string, *[]int) {
+	t.Helper()
+	prevLocal, prevRemove, prevCan, prevConfirm := newLocalPackagesParserFn, removePackageFn, canPromptForSelection, confirmRemovalFn
+	prevProviders, prevAll, prevDryRun, prevYes, prevExit := removeProviders, removeAll, removeDryRun, removeYes, osExit
+	t.Cleanup(func() {
+		newLocalPackagesParserFn, removePackageFn, canPromptForSelection, confirmRemovalFn = prevLocal, prevRemove, prevCan, prevConfirm
+		removeProviders, removeAll, removeDryRun, removeYes, osExit = prevProviders, prevAll, prevDryRun, prevYes, prevExit
+	})
+	codes := &[]int{}
+	osExit = func(code int) { *codes = append(*codes, code) }
+	removeProviders, removeAll, removeDryRun, removeYes = nil, false, false, false
+	newLocalPackagesParserFn = func() local_packages_parser.LocalPackageRoot {
+		return local_packages_parser.LocalPackageRoot{Packages: []local_packages_parser.LocalPackageItem{
+			{SourceID: "npm:eslint", Version: "9.0.0"},
+			{SourceID: "npm:@typescript-eslint/parser", Version: "8.0.0"},
+			{SourceID: "npm:prettier", Version: "3.3.0"},
+			{SourceID: "pypi:black", Version: "24.2.0"},
+		}}
+	}
+	removed := &[]string{}
+	removePackageFn = func(id string) bool {
+		*removed = append(*removed, id)
+		return true
+	}
+	canPromptForSelection = func() bool { return interactive }
+	return removed, codes
+}
+
+func TestRemoveMassRemoval(t *testing.T) {
+	t.Run("dry run lists the pattern matches", func(t *testing.T) {
+		removed, _ := stubMassRemoval(t, true)
+		removeDryRun = true
+		out := captureOutput(t, func() { removeCmd.Run(removeCmd, []string{"npm:*eslint*"}) })
+		assert.Contains(t, out, "Would remove 2 package(s):\n  npm:eslint\n  npm:@typescript-eslint/parser\n")
+		assert.Empty(t, *removed)
+	})
+
+	t.Run("provider and all remove after confirmation", func(t *testing.T) {
+		removed, _ := stubMassRemoval(t, true)
+		removeProviders, removeAll = []string{"npm"}, true
+		var confirmed []string
+		confirmRemovalFn = func(ids []string) bool {
+			confirmed = ids
+			return true
+		}
+		captureOutput(t, func() { removeCmd.Run(removeCmd, nil) })
+		assert.Equal(t, []string{"npm:eslint", "npm:@typescript-eslint/parser", "npm:prettier"}, confirmed)
+		assert.Equal(t, confirmed, *removed)
+	})
+
+	t.Run("declining removes nothing", func(t *testing.T) {
+		removed, codes := stubMassRemoval(t, true)
+		removeAll = true
+		confirmRemovalFn = func([]string) bool { return false }
+		out := captureOutput(t, func() { removeCmd.Run(removeCmd, nil) })
+		assert.Contains(t, out, "Nothing removed.")
+		assert.Empty(t, *removed)
+		assert.Empty(t, *codes)
+	})
+
+	t.Run("provider only narrows down patterns", func(t *testing.T) {
+		removed, _ := stubMassRemoval(t, true)
+		removeProviders = []string{"npm"}
+		var confirmed []string
+		confirmRemovalFn = func(ids []string) bool {
+			confirmed = ids
+			return true
+		}
+		captureOutput(t, func() { removeCmd.Run(removeCmd, []string{"pypi:black", "*es*"}) })
+		assert.Equal(t, []string{"pypi:black", "npm:eslint", "npm:@typescript-eslint/parser"}, confirmed)
+		assert.Equal(t, confirmed, *removed)
+	})
+
+	t.Run("without a terminal --yes is required", func(t *testing.T) {
+		removed, codes := stubMassRemoval(t, false)
+		out := captureOutput(t, func() { removeCmd.Run(removeCmd, []string{"black*"}) })
+		assert.Contains(t, out, "Not removing 1 packages without confirmation, pass --yes")
+		assert.Empty(t, *removed)
+		assert.Equal(t, []int{1}, *codes)
+
+		removeYes = true
+		captureOutput(t, func() { removeCmd.Run(removeCmd, []string{"black*"}) })
+		assert.Equal(t, []string{"pypi:black"}, *removed)
+	})
+
+	t.Run("failed removals exit non-zero", func(t *testing.T) {
+		_, codes := stubMassRemoval(t, false)
+		removeAll, removeYes = true, true
+		removePackageFn = func(id string) bool { return id != "pypi:black" }
+		out := captureOutput(t, func() { removeCmd.Run(removeCmd, nil) })
+		assert.Contains(t, out, "Failed to remove pypi:black")
+		assert.Equal(t, []int{1}, *codes)
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		_, codes := stubMassRemoval(t, true)
+		out := captureOutput(t, func() { removeCmd.Run(removeCmd, []string{"cargo:*"}) })
+		assert.Contains(t, out, "no installed packages match")
+		assert.Equal(t, []int{1}, *codes)
+	})
+
+	t.Run("arguments are required without --all", func(t *testing.T) {
+		stubMassRemoval(t, true)
+		assert.Error(t, removeCmd.Args(removeCmd, nil))
+		removeProviders = []string{"npm"}
+		assert.Error(t, removeCmd.Args(removeCmd, nil))
+		removeAll = true
+		assert.NoError(t, removeCmd.Args(removeCmd, nil))
+	})
+}
@@ -3,9 +3,15 @@ package zana
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/chaos"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/mistweaverco/zana-client/internal/lib/spinnerutil"
+	"github.com/mistweaverco/zana-client/internal/lib/trace"
 	"github.com/mistweaverco/zana-client/internal/lib/version"
 	"github.com/spf13/cobra"
 )
@@ -18,6 +24,21 @@ var cfg = config.NewConfig(config.Config{
 	},
 })
 
+// configPath is the config.yaml given with --config
+var configPath string
+
+// refreshRegistry and noRefreshRegistry are --refresh and --no-refresh
+var refreshRegistry, noRefreshRegistry bool
+
+// noCache is --no-cache
+var noCache bool
+
+// skipChecksum is --skip-checksum
+var skipChecksum bool
+
+// chaosSeed seeds the failure injection enabled with the hidden --chaos flag
+var chaosSeed int64
+
 var rootCmd = &cobra.Command{
 	Use:   "zana",
 	Short: "Zana is Mason.nvim, but not only for Neovim",
@@ -34,22 +55,63 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute() {
+	addCompletionInstallCmd(rootCmd)
+	// zana foo runs the zana-foo plugin, see plugins.go
+	registerPlugins(rootCmd)
+	// The command wraps osExit to count its run and write its trace and
+	// events when it exits directly, see PersistentPreRun
+	exit := osExit
 	// Parse flags first to get color config
 	err := rootCmd.Execute()
+	recordUsage(err != nil)
+	writeTrace()
+	closeEvents()
+	osExit = exit
 	if err != nil {
 		osExit(1)
 	}
 }
 
+// tracePath is the file given with --trace
+var tracePath string
+
+// writeTrace writes the spans recorded for --trace to the trace file
+func writeTrace() {
+	if err := trace.Flush(); err != nil {
+		providers.Logger.Error(fmt.Sprintf("Failed to write trace file: %v", err))
+	}
+}
+
 func init() {
+	rootCmd.AddCommand(adoptCmd)
+	rootCmd.AddCommand(cacheCmd)
 	rootCmd.AddCommand(envCmd)
+	rootCmd.AddCommand(errorsCmd)
+	rootCmd.AddCommand(exportCmd)
 	rootCmd.AddCommand(healthCmd)
 	rootCmd.AddCommand(infoCmd)
+	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(installCmd)
+	rootCmd.AddCommand(linkCmd)
 	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(logsCmd)
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(migratePackageCmd)
+	rootCmd.AddCommand(openCmd)
+	rootCmd.AddCommand(outdatedCmd)
+	rootCmd.AddCommand(registryCmd)
 	rootCmd.AddCommand(removeCmd)
+	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(schemasCmd)
+	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(statsCmd)
 	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(testInstallCmd)
+	rootCmd.AddCommand(unlinkCmd)
 	rootCmd.AddCommand(updateCmd)
+	rootCmd.AddCommand(verifyRegistryEntryCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(whichCmd)
 	rootCmd.PersistentFlags().BoolVar(&cfg.Flags.Version, "version", false, "version")
 	rootCmd.PersistentFlags().DurationVar(&cfg.Flags.CacheMaxAge, "cache-max-age", 24*time.Hour, "maximum age of registry cache (e.g., 1h, 24h, 7d)")
 	colorFlag := rootCmd.PersistentFlags().VarPF(&cfg.Flags.Color, "color", "", "when to use colors and icons: always, auto (default), never")
@@ -58,8 +120,30 @@ func init() {
 	// Use StringVarP for output flag so it properly consumes the next argument as value
 	var outputFlagValue string
 	rootCmd.PersistentFlags().StringVarP(&outputFlagValue, "output", "o", string(config.OutputModeRich), "output format: rich (default), plain, json")
+	rootCmd.PersistentFlags().BoolVar(&cfg.Flags.UTC, "utc", false, "show times in UTC instead of the local time zone")
+	rootCmd.PersistentFlags().BoolVar(&cfg.Flags.ISO, "iso", false, "show absolute ISO 8601 times instead of relative ones (e.g. 3 days ago)")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "path to config.yaml (default $XDG_CONFIG_HOME/zana/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&eventsPath, "events", "", "append structured progress events as JSON lines to this file (e.g. /dev/fd/3)")
+	rootCmd.PersistentFlags().Var(&progressFlag, "progress", "report progress in a machine-readable format: json (JSON lines on stderr, the result stays on stdout)")
+	rootCmd.PersistentFlags().StringVar(&tracePath, "trace", "", "write an OpenTelemetry (OTLP/JSON) trace of downloads, extractions, subprocesses and lock file access to this file")
+	rootCmd.PersistentFlags().BoolVar(&refreshRegistry, "refresh", false, "download the registry again, even if it's younger than registry.cacheMaxAge")
+	rootCmd.PersistentFlags().BoolVar(&noRefreshRegistry, "no-refresh", false, "use the downloaded registry, however old it is")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "download the registry, release assets and release info again instead of using cached ones, and cache the fresh downloads")
+	rootCmd.PersistentFlags().BoolVar(&skipChecksum, "skip-checksum", false, "install release assets even if they don't match the checksum from the registry or published next to them")
+	rootCmd.MarkFlagsMutuallyExclusive("refresh", "no-refresh")
+	rootCmd.PersistentFlags().Int64Var(&chaosSeed, "chaos", 0, "randomly fail downloads, subprocesses and extractions, reproducibly for a seed (for testing)")
+	_ = rootCmd.PersistentFlags().MarkHidden("chaos")
 	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
-		// Load optional config.yaml (next to zana-lock.json) and apply defaults
+		// --config is handed down as ZANA_CONFIG, so the files package and the
+		// plugins and services zana starts read the same config.yaml
+		if configPath != "" {
+			if abs, err := filepath.Abs(configPath); err == nil {
+				configPath = abs
+			}
+			_ = os.Setenv("ZANA_CONFIG", configPath)
+		}
+
+		// Load optional config.yaml and apply defaults
 		// only when the user didn't explicitly set flags.
 		if fileCfg, ok, err := config.LoadFileConfig(); err == nil && ok {
 			if !cmd.Flags().Changed("cache-max-age") {
@@ -85,6 +169,78 @@ func init() {
 				cfg.Flags.Output = outputMode
 			}
 		}
+
+		if eventsPath != "" {
+			if err := startEvents(eventsPath); err != nil {
+				providers.Logger.Error(fmt.Sprintf("Failed to open events file: %v", err))
+			}
+		}
+
+		if progressFlag == progressModeJSON {
+			// Spinners and their titles would get in between the progress lines
+			spinnerutil.Disable()
+			writeProgressTo(os.Stderr)
+		}
+
+		if tracePath != "" {
+			trace.Enable(tracePath, cmd.CommandPath())
+			// Commands exit directly on failure, the trace is written all the same
+			exit := osExit
+			osExit = func(code int) {
+				writeTrace()
+				exit(code)
+			}
+		}
+
+		// Count the run when usage metrics are enabled (off by default)
+		startUsageMetrics(cmd)
+
+		if cmd.Flags().Changed("chaos") {
+			chaos.Enable(chaosSeed)
+			providers.Logger.Info(fmt.Sprintf("Chaos: Injecting failures with seed %d", chaosSeed))
+		}
+
+		// By default the registry is downloaded again once it's older than
+		// registry.cacheMaxAge, plus a random jitter
+		// --no-cache implies --refresh and wins over --no-refresh
+		files.SetCacheBypass(noCache)
+		providers.SetSkipChecksums(skipChecksum)
+		switch {
+		case refreshRegistry || noCache:
+			files.SetRegistryRefreshPolicy(files.RegistryRefreshAlways)
+		case noRefreshRegistry:
+			files.SetRegistryRefreshPolicy(files.RegistryRefreshNever)
+		default:
+			files.SetRegistryRefreshPolicy(files.RegistryRefreshAuto)
+		}
+
+		// Offer zana init before anything writes the state file
+		offerFirstRunSetupFn(cmd)
+
+		// Upgrade layouts written by older zana versions before touching them
+		checkLayoutMigrationsFn(cmd)
+
+		// Shell completion runs on every tab press, it must not rewrite bin
+		// or rotate the log file
+		if isHelpOrCompletion(cmd) {
+			return
+		}
+
+		// Keep a log of every run, so operations can be inspected with zana logs
+		if err := enableLogFileFn(logFilePathFn()); err != nil {
+			providers.Logger.Error(fmt.Sprintf("Failed to open log file: %v", err))
+		}
+
+		// Move existing bin entries when paths.binLayout changed
+		if err := ensureBinLayoutFn(); err != nil {
+			providers.Logger.Error(fmt.Sprintf("Failed to migrate bin layout: %v", err))
+		}
+
+		// Drop what interrupted installs left in the staging directory
+		cleanStaleStagingFn(providers.StaleStagingAge)
+	}
+	rootCmd.PersistentPostRun = func(cmd *cobra.Command, args []string) {
+		closeEvents()
 	}
 
 	// Set up the color config accessor for icons.go
@@ -93,5 +249,33 @@ func init() {
 	})
 }
 
+// isShellCompletionRequest reports whether cmd is the hidden command shells
+// run to complete a command line
+func isShellCompletionRequest(cmd *cobra.Command) bool {
+	return cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd
+}
+
+// isHelpOrCompletion reports whether cmd only prints help or completions,
+// so zana neither prompts nor touches the layout before it
+func isHelpOrCompletion(cmd *cobra.Command) bool {
+	switch cmd.Name() {
+	case "completion", "help":
+		return true
+	}
+	if cmd.HasParent() && cmd.Parent().Name() == "completion" {
+		return true
+	}
+	return isShellCompletionRequest(cmd)
+}
+
+// checkLayoutMigrationsFn is a variable to allow overriding in tests
+var checkLayoutMigrationsFn = checkLayoutMigrations
+
+// ensureBinLayoutFn is a variable to allow overriding in tests
+var ensureBinLayoutFn = providers.EnsureBinLayout
+
+// cleanStaleStagingFn is a variable to allow overriding in tests
+var cleanStaleStagingFn = providers.CleanStaleStaging
+
 // osExit is a variable to allow overriding in tests
 var osExit = os.Exit
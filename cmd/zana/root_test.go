@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/trace"
 	"github.com/mistweaverco/zana-client/internal/lib/version"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
@@ -117,6 +120,34 @@ func TestExecuteExitsOnError(t *testing.T) {
 	}
 }
 
+func TestExecuteRestoresOsExit(t *testing.T) {
+	prevOsExit := osExit
+	defer func() { osExit = prevOsExit }()
+	exits := 0
+	osExit = func(int) { exits++ }
+
+	// Commands wrap osExit, like startUsageMetrics does
+	wrapped := 0
+	cmd := &cobra.Command{Use: "wrapping", Run: func(cmd *cobra.Command, args []string) {
+		exit := osExit
+		osExit = func(code int) {
+			wrapped++
+			exit(code)
+		}
+	}}
+	cmd.SetArgs([]string{})
+	originalRoot := rootCmd
+	rootCmd = cmd
+	defer func() { rootCmd = originalRoot }()
+
+	Execute()
+	Execute()
+	osExit(0)
+
+	assert.Equal(t, 0, wrapped, "the wrappers of earlier runs are gone")
+	assert.Equal(t, 1, exits)
+}
+
 func TestConfigInitialization(t *testing.T) {
 	// Test that config is properly initialized
 	assert.NotNil(t, cfg)
@@ -215,3 +246,95 @@ func TestRootCommandCompletion(t *testing.T) {
 	assert.NotNil(t, rootCmd)
 	assert.NotNil(t, rootCmd.Run)
 }
+
+func TestRootCommandConfigFlag(t *testing.T) {
+	prevPath, prevColor := configPath, cfg.Flags.Color
+	prevMigrations, prevLayout, prevStaging, prevLog := checkLayoutMigrationsFn, ensureBinLayoutFn, cleanStaleStagingFn, enableLogFileFn
+	t.Cleanup(func() {
+		configPath, cfg.Flags.Color = prevPath, prevColor
+		checkLayoutMigrationsFn, ensureBinLayoutFn, cleanStaleStagingFn, enableLogFileFn = prevMigrations, prevLayout, prevStaging, prevLog
+	})
+	checkLayoutMigrationsFn = func(*cobra.Command) {}
+	ensureBinLayoutFn = func() error { return nil }
+	cleanStaleStagingFn = func(time.Duration) []string { return nil }
+	enableLogFileFn = func(string) error { return nil }
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "zana.yaml"), []byte("ui:\n  color: never\n"), 0644))
+	t.Chdir(dir)
+	t.Setenv("ZANA_CONFIG", "")
+
+	configPath = "zana.yaml"
+	rootCmd.PersistentPreRun(&cobra.Command{}, nil)
+	assert.Equal(t, filepath.Join(dir, "zana.yaml"), os.Getenv("ZANA_CONFIG"))
+	assert.Equal(t, config.ColorModeNever, cfg.Flags.Color)
+}
+
+func TestRootCommandSkipsLayoutAndLogForHelpAndCompletion(t *testing.T) {
+	prevFirstRun, prevMigrations, prevLayout, prevStaging, prevLog := offerFirstRunSetupFn, checkLayoutMigrationsFn, ensureBinLayoutFn, cleanStaleStagingFn, enableLogFileFn
+	t.Cleanup(func() {
+		offerFirstRunSetupFn, checkLayoutMigrationsFn, ensureBinLayoutFn, cleanStaleStagingFn, enableLogFileFn = prevFirstRun, prevMigrations, prevLayout, prevStaging, prevLog
+	})
+	offerFirstRunSetupFn = func(*cobra.Command) {}
+	checkLayoutMigrationsFn = func(*cobra.Command) {}
+	var touched []string
+	enableLogFileFn = func(string) error {
+		touched = append(touched, "log")
+		return nil
+	}
+	ensureBinLayoutFn = func() error {
+		touched = append(touched, "bin")
+		return nil
+	}
+	cleanStaleStagingFn = func(time.Duration) []string {
+		touched = append(touched, "staging")
+		return nil
+	}
+
+	completion := &cobra.Command{Use: "completion"}
+	bash := &cobra.Command{Use: "bash"}
+	completion.AddCommand(bash)
+	for _, cmd := range []*cobra.Command{
+		{Use: cobra.ShellCompRequestCmd},
+		{Use: cobra.ShellCompNoDescRequestCmd},
+		{Use: "help"},
+		completion,
+		bash,
+	} {
+		rootCmd.PersistentPreRun(cmd, nil)
+		assert.Empty(t, touched, cmd.Name())
+	}
+
+	rootCmd.PersistentPreRun(&cobra.Command{Use: "list"}, nil)
+	assert.Equal(t, []string{"log", "bin", "staging"}, touched)
+}
+
+func TestRootCommandTraceFlag(t *testing.T) {
+	prevTrace, prevExit := tracePath, osExit
+	prevMigrations, prevLayout, prevStaging, prevLog := checkLayoutMigrationsFn, ensureBinLayoutFn, cleanStaleStagingFn, enableLogFileFn
+	t.Cleanup(func() {
+		tracePath, osExit = prevTrace, prevExit
+		checkLayoutMigrationsFn, ensureBinLayoutFn, cleanStaleStagingFn, enableLogFileFn = prevMigrations, prevLayout, prevStaging, prevLog
+		_ = trace.Flush()
+	})
+	checkLayoutMigrationsFn = func(*cobra.Command) {}
+	ensureBinLayoutFn = func() error { return nil }
+	cleanStaleStagingFn = func(time.Duration) []string { return nil }
+	enableLogFileFn = func(string) error { return nil }
+	var codes []int
+	osExit = func(code int) { codes = append(codes, code) }
+
+	tracePath = filepath.Join(t.TempDir(), "trace.json")
+	rootCmd.PersistentPreRun(&cobra.Command{Use: "install"}, nil)
+	require.True(t, trace.Enabled())
+	trace.Start("download").End(nil)
+
+	// Failing commands exit directly, the trace is written before
+	osExit(1)
+	assert.Equal(t, []int{1}, codes)
+	assert.False(t, trace.Enabled())
+	data, err := os.ReadFile(tracePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"name":"install"`)
+	assert.Contains(t, string(data), `"name":"download"`)
+}
@@ -0,0 +1,83 @@
+package zana
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/spf13/cobra"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run <executable> [args...]",
+	Short: "Run an executable, installing its package on first use",
+	Long: `Run an executable from the zana bin directory.
+
+When the executable isn't installed yet, the package in zana-lock.json
+providing it is installed first. This is what the lazy shims written by
+"zana sync packages --lazy" call, so declared tools are only installed
+the first time they are used.
+
+Examples:
+  zana run prettier --check .
+  zana run -- rg --files`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := resolveRunExecutable(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", IconClose(), err)
+			osExit(127)
+			return
+		}
+		if err := runExecutableFn(path, args[1:]); err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				osExit(exitErr.ExitCode())
+				return
+			}
+			fmt.Fprintf(os.Stderr, "%s %v\n", IconClose(), err)
+			osExit(126)
+		}
+	},
+}
+
+func init() {
+	// Everything after the executable belongs to it
+	runCmd.Flags().SetInterspersed(false)
+}
+
+// indirections for testability
+var (
+	findExecutableFn    = providers.FindExecutable
+	lazyExecutableForFn = providers.LazyExecutableFor
+	runInstallFn        = providers.Install
+	runExecutableFn     = runExecutable
+)
+
+// resolveRunExecutable returns the path of the executable name, installing
+// the package from zana-lock.json providing it when it isn't installed yet
+func resolveRunExecutable(name string) (string, error) {
+	if path, ok := findExecutableFn(name); ok {
+		return path, nil
+	}
+	exe, ok := lazyExecutableForFn(name)
+	if !ok {
+		return "", fmt.Errorf("%s is not provided by any package in zana-lock.json", name)
+	}
+	fmt.Fprintf(os.Stderr, "%s Installing %s@%s for %s...\n", IconMagnify(), exe.SourceID, exe.Version, name)
+	if !runInstallFn(exe.SourceID, exe.Version) {
+		return "", fmt.Errorf("failed to install %s@%s, see zana logs", exe.SourceID, exe.Version)
+	}
+	if path, ok := findExecutableFn(name); ok {
+		return path, nil
+	}
+	return "", fmt.Errorf("%s was installed but provides no executable %s", exe.SourceID, name)
+}
+
+func runExecutable(path string, args []string) error {
+	cmd := exec.Command(path, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
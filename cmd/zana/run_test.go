@@ -0,0 +1,101 @@
+package zana
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubRun(t *testing.T) (installed map[string]bool, installs *[]string, codes *[]int) {
+	t.Helper()
+	prevFind, prevLazy, prevInstall, prevExec, prevExit := findExecutableFn, lazyExecutableForFn, runInstallFn, runExecutableFn, osExit
+	t.Cleanup(func() {
+		findExecutableFn, lazyExecutableForFn, runInstallFn, runExecutableFn, osExit = prevFind, prevLazy, prevInstall, prevExec, prevExit
+	})
+
+	installed = map[string]bool{"prettier": true}
+	findExecutableFn = func(name string) (string, bool) {
+		return "/bin/" + name, installed[name]
+	}
+	lazyExecutableForFn = func(name string) (providers.LazyExecutable, bool) {
+		if name == "tsc" {
+			return providers.LazyExecutable{Name: "tsc", SourceID: "npm:typescript", Version: "5.4.0"}, true
+		}
+		return providers.LazyExecutable{}, false
+	}
+	installs = &[]string{}
+	runInstallFn = func(sourceID, version string) bool {
+		*installs = append(*installs, sourceID+"@"+version)
+		installed["tsc"] = true
+		return true
+	}
+	codes = &[]int{}
+	osExit = func(code int) { *codes = append(*codes, code) }
+	return installed, installs, codes
+}
+
+func TestResolveRunExecutable(t *testing.T) {
+	t.Run("installed executable", func(t *testing.T) {
+		_, installs, _ := stubRun(t)
+		path, err := resolveRunExecutable("prettier")
+		require.NoError(t, err)
+		assert.Equal(t, "/bin/prettier", path)
+		assert.Empty(t, *installs)
+	})
+
+	t.Run("installs the declaring package on first use", func(t *testing.T) {
+		_, installs, _ := stubRun(t)
+		path, err := resolveRunExecutable("tsc")
+		require.NoError(t, err)
+		assert.Equal(t, "/bin/tsc", path)
+		assert.Equal(t, []string{"npm:typescript@5.4.0"}, *installs)
+	})
+
+	t.Run("undeclared executable", func(t *testing.T) {
+		stubRun(t)
+		_, err := resolveRunExecutable("eslint")
+		assert.EqualError(t, err, "eslint is not provided by any package in zana-lock.json")
+	})
+
+	t.Run("install without the executable", func(t *testing.T) {
+		stubRun(t)
+		runInstallFn = func(string, string) bool { return true }
+		_, err := resolveRunExecutable("tsc")
+		assert.EqualError(t, err, "npm:typescript was installed but provides no executable tsc")
+	})
+}
+
+func TestRunCommandPassesExitCode(t *testing.T) {
+	_, _, codes := stubRun(t)
+	var gotArgs []string
+	runExecutableFn = func(path string, args []string) error {
+		gotArgs = args
+		return exec.Command("sh", "-c", "exit 3").Run()
+	}
+
+	captureOutput(t, func() { runCmd.Run(runCmd, []string{"prettier", "--check", "."}) })
+	assert.Equal(t, []string{"--check", "."}, gotArgs)
+	assert.Equal(t, []int{3}, *codes)
+
+	*codes = nil
+	runExecutableFn = func(string, []string) error { return errors.New("exec format error") }
+	captureOutput(t, func() { runCmd.Run(runCmd, []string{"prettier"}) })
+	assert.Equal(t, []int{126}, *codes)
+}
+
+func TestSyncLazyShims(t *testing.T) {
+	prevWrite, prevExit := writeLazyShimsFn, osExit
+	t.Cleanup(func() { writeLazyShimsFn, osExit = prevWrite, prevExit })
+	writeLazyShimsFn = func() ([]string, error) { return []string{"tsc"}, nil }
+
+	out := captureOutput(t, syncLazyShims)
+	assert.Contains(t, out, "Wrote 1 shims")
+
+	out = captureOutputWithMode(t, syncLazyShims, config.OutputModeJSON)
+	assert.JSONEq(t, `{"success": true, "shims": ["tsc"]}`, out)
+}
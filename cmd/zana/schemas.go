@@ -0,0 +1,102 @@
+package zana
+
+import (
+	"fmt"
+
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/spf13/cobra"
+)
+
+var schemasCmd = &cobra.Command{
+	Use:   "schemas",
+	Short: "JSON schemas and config templates of installed packages",
+	Long: `JSON schemas and config templates of installed packages.
+
+Registry entries with "kind": "schemas" are data packages shipping JSON
+schemas or config templates. They are installed into the share directory
+like other data packages, and their schemas are listed in a schema catalog
+(in the SchemaStore catalog format) editors and language servers can load.`,
+}
+
+var schemasPathCmd = &cobra.Command{
+	Use:   "path [pkgId]",
+	Short: "Print the path of the schema catalog",
+	Long: `Writes the catalog of the schemas of all installed schema packages and
+prints its path, to point editors at, e.g. the yaml-language-server
+"yaml.schemaStore.url" or a JSON language server's schema catalog setting.
+
+With a package ID, prints the paths of the schemas of that package instead.
+
+Examples:
+  zana schemas path
+  zana schemas path github:owner/schemas`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: installedPackageIDCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := writeSchemaCatalogFn()
+		if err != nil {
+			printSchemasError(err)
+			return
+		}
+		if len(args) == 0 {
+			if ShouldUseJSONOutput() {
+				PrintJSON(map[string]interface{}{
+					"catalog": schemaCatalogPathFn(),
+					"schemas": entries,
+				})
+				return
+			}
+			fmt.Println(schemaCatalogPathFn())
+			return
+		}
+
+		provider, pkgName, err := parseUserPackageID(args[0])
+		if err != nil {
+			printSchemasError(err)
+			return
+		}
+		sourceID := toInternalPackageID(provider, pkgName)
+		paths := []string{}
+		for _, entry := range entries {
+			if entry.Package == sourceID {
+				paths = append(paths, entry.Path)
+			}
+		}
+		if len(paths) == 0 {
+			printSchemasError(fmt.Errorf("%s is not an installed schema package", sourceID))
+			return
+		}
+		if ShouldUseJSONOutput() {
+			PrintJSON(map[string]interface{}{
+				"package": sourceID,
+				"paths":   paths,
+			})
+			return
+		}
+		for _, p := range paths {
+			fmt.Println(p)
+		}
+	},
+}
+
+func init() {
+	schemasCmd.AddCommand(schemasPathCmd)
+}
+
+// indirections for testability
+var (
+	writeSchemaCatalogFn = providers.WriteSchemaCatalog
+	schemaCatalogPathFn  = providers.SchemaCatalogPath
+)
+
+func printSchemasError(err error) {
+	if ShouldUseJSONOutput() {
+		PrintJSON(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+	} else {
+		fmt.Printf("%s %v\n", IconClose(), err)
+	}
+	osExit(1)
+}
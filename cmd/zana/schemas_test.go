@@ -0,0 +1,54 @@
+package zana
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubSchemas(t *testing.T, entries []providers.SchemaCatalogEntry, err error) *[]int {
+	t.Helper()
+	prevWrite, prevPath, prevExit := writeSchemaCatalogFn, schemaCatalogPathFn, osExit
+	t.Cleanup(func() { writeSchemaCatalogFn, schemaCatalogPathFn, osExit = prevWrite, prevPath, prevExit })
+	writeSchemaCatalogFn = func() ([]providers.SchemaCatalogEntry, error) { return entries, err }
+	schemaCatalogPathFn = func() string { return "/share/schemas/catalog.json" }
+	codes := &[]int{}
+	osExit = func(code int) { *codes = append(*codes, code) }
+	return codes
+}
+
+func TestSchemasPath(t *testing.T) {
+	codes := stubSchemas(t, []providers.SchemaCatalogEntry{
+		{Name: "tsconfig", URL: "file:///share/github/owner/schemas/tsconfig.json", Package: "github:owner/schemas", Path: "/share/github/owner/schemas/tsconfig.json"},
+		{Name: "eslintrc", URL: "file:///share/github/owner/schemas/eslintrc.json", Package: "github:owner/schemas", Path: "/share/github/owner/schemas/eslintrc.json"},
+		{Name: "stylua", URL: "file:///share/github/other/stylua.json", Package: "github:other/stylua", Path: "/share/github/other/stylua.json"},
+	}, nil)
+
+	out := captureOutput(t, func() { schemasPathCmd.Run(schemasPathCmd, nil) })
+	assert.Equal(t, "/share/schemas/catalog.json\n", out)
+
+	out = captureOutput(t, func() { schemasPathCmd.Run(schemasPathCmd, []string{"github:owner/schemas"}) })
+	assert.Equal(t, "/share/github/owner/schemas/tsconfig.json\n/share/github/owner/schemas/eslintrc.json\n", out)
+
+	out = captureOutputWithMode(t, func() { schemasPathCmd.Run(schemasPathCmd, nil) }, config.OutputModeJSON)
+	var result map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	assert.Equal(t, "/share/schemas/catalog.json", result["catalog"])
+	assert.Len(t, result["schemas"], 3)
+
+	out = captureOutput(t, func() { schemasPathCmd.Run(schemasPathCmd, []string{"npm:prettier"}) })
+	assert.Contains(t, out, "npm:prettier is not an installed schema package")
+	assert.Equal(t, []int{1}, *codes)
+}
+
+func TestSchemasPathFailsWhenTheCatalogCannotBeWritten(t *testing.T) {
+	codes := stubSchemas(t, nil, errors.New("read-only file system"))
+	out := captureOutput(t, func() { schemasPathCmd.Run(schemasPathCmd, nil) })
+	assert.Contains(t, out, "read-only file system")
+	assert.Equal(t, []int{1}, *codes)
+}
@@ -0,0 +1,179 @@
+package zana
+
+import (
+	"fmt"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/spf13/cobra"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Snapshot and restore the installed toolchain",
+	Long: `Snapshot and restore the installed toolchain.
+
+A snapshot captures zana-lock.json and a manifest of the provider state, so you
+can experiment with updates and roll the whole toolchain back:
+
+  zana snapshot create before-update --with-packages
+  zana update --all
+  zana snapshot restore before-update
+
+The subcommands are:
+  create   - Capture the current state under a name
+  restore  - Put a snapshot back in place
+  list     - List snapshots
+  delete   - Remove a snapshot`,
+}
+
+var snapshotCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Capture the current state under a name",
+	Long: `Capture zana-lock.json and a manifest of the provider state under a name.
+
+With --with-packages the package and bin directories are copied as well, so
+restoring the snapshot doesn't reinstall anything.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manifest, err := createSnapshotFn(args[0], snapshotWithPackages)
+		if err != nil {
+			printSnapshotError("create", err)
+			osExit(1)
+			return
+		}
+		if ShouldUseJSONOutput() {
+			PrintJSON(map[string]interface{}{
+				"success":  true,
+				"snapshot": manifest,
+			})
+			return
+		}
+		fmt.Printf("%s Created snapshot %s (%d packages%s)\n", IconCheck(), manifest.Name, len(manifest.Packages), snapshotContentSuffix(manifest))
+	},
+}
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:   "restore <name>",
+	Short: "Put a snapshot back in place",
+	Long: `Put a snapshot back in place.
+
+Snapshots created with --with-packages swap the package and bin directories
+in as a whole. Otherwise only zana-lock.json is restored; run
+"zana sync packages" afterwards to install the snapshot's versions.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: snapshotNameCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		manifest, err := restoreSnapshotFn(args[0])
+		if err != nil {
+			printSnapshotError("restore", err)
+			osExit(1)
+			return
+		}
+		if ShouldUseJSONOutput() {
+			PrintJSON(map[string]interface{}{
+				"success":  true,
+				"snapshot": manifest,
+			})
+			return
+		}
+		fmt.Printf("%s Restored snapshot %s (%d packages)\n", IconCheck(), manifest.Name, len(manifest.Packages))
+		if !manifest.Content {
+			fmt.Printf("   %s Run 'zana sync packages' to install the snapshot's versions\n", IconLightbulb())
+		}
+	},
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List snapshots",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		snapshots, err := listSnapshotsFn()
+		if err != nil {
+			printSnapshotError("list", err)
+			osExit(1)
+			return
+		}
+		if ShouldUseJSONOutput() {
+			for i := range snapshots {
+				snapshots[i].CreatedAt = displayTime(snapshots[i].CreatedAt)
+			}
+			PrintJSON(snapshots)
+			return
+		}
+		if len(snapshots) == 0 {
+			fmt.Println("No snapshots found")
+			return
+		}
+		for _, s := range snapshots {
+			fmt.Printf("%s  %s  %d packages%s\n", s.Name, formatWhen(s.CreatedAt), len(s.Packages), snapshotContentSuffix(s))
+		}
+	},
+}
+
+var snapshotDeleteCmd = &cobra.Command{
+	Use:               "delete <name>",
+	Short:             "Remove a snapshot",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: snapshotNameCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := deleteSnapshotFn(args[0]); err != nil {
+			printSnapshotError("delete", err)
+			osExit(1)
+			return
+		}
+		if ShouldUseJSONOutput() {
+			PrintJSON(map[string]interface{}{"success": true})
+			return
+		}
+		fmt.Printf("%s Deleted snapshot %s\n", IconCheck(), args[0])
+	},
+}
+
+var snapshotWithPackages bool
+
+func init() {
+	snapshotCmd.AddCommand(snapshotCreateCmd)
+	snapshotCmd.AddCommand(snapshotRestoreCmd)
+	snapshotCmd.AddCommand(snapshotListCmd)
+	snapshotCmd.AddCommand(snapshotDeleteCmd)
+	snapshotCreateCmd.Flags().BoolVar(&snapshotWithPackages, "with-packages", false, "also capture the package and bin directories")
+}
+
+func snapshotContentSuffix(manifest files.SnapshotManifest) string {
+	if manifest.Content {
+		return ", with packages"
+	}
+	return ""
+}
+
+func snapshotNameCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	snapshots, _ := listSnapshotsFn()
+	names := make([]string, 0, len(snapshots))
+	for _, s := range snapshots {
+		names = append(names, s.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func printSnapshotError(action string, err error) {
+	if ShouldUseJSONOutput() {
+		PrintJSON(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+	} else {
+		fmt.Printf("%s Failed to %s snapshot: %v\n", IconClose(), action, err)
+	}
+}
+
+// indirections for testability
+var (
+	createSnapshotFn  = files.CreateSnapshot
+	restoreSnapshotFn = files.RestoreSnapshot
+	listSnapshotsFn   = files.ListSnapshots
+	deleteSnapshotFn  = files.DeleteSnapshot
+)
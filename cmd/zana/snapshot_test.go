@@ -0,0 +1,104 @@
+package zana
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotCommand(t *testing.T) {
+	names := []string{}
+	for _, c := range snapshotCmd.Commands() {
+		names = append(names, c.Name())
+	}
+	assert.ElementsMatch(t, []string{"create", "restore", "list", "delete"}, names)
+	assert.NotNil(t, snapshotCreateCmd.Flags().Lookup("with-packages"))
+}
+
+func stubSnapshotFns(t *testing.T) *[]int {
+	t.Helper()
+	prevCreate, prevRestore, prevList, prevDelete := createSnapshotFn, restoreSnapshotFn, listSnapshotsFn, deleteSnapshotFn
+	prevExit := osExit
+	exitCodes := []int{}
+	osExit = func(code int) { exitCodes = append(exitCodes, code) }
+	t.Cleanup(func() {
+		createSnapshotFn, restoreSnapshotFn, listSnapshotsFn, deleteSnapshotFn = prevCreate, prevRestore, prevList, prevDelete
+		osExit = prevExit
+		snapshotWithPackages = false
+	})
+	return &exitCodes
+}
+
+func TestSnapshotCreateCommandRun(t *testing.T) {
+	exitCodes := stubSnapshotFns(t)
+	var gotContent bool
+	createSnapshotFn = func(name string, content bool) (files.SnapshotManifest, error) {
+		gotContent = content
+		return files.SnapshotManifest{Name: name, Packages: make([]files.SnapshotPackage, 2), Content: content}, nil
+	}
+
+	snapshotWithPackages = true
+	out := captureOutput(t, func() { snapshotCreateCmd.Run(snapshotCreateCmd, []string{"before"}) })
+	assert.True(t, gotContent)
+	assert.Contains(t, out, "Created snapshot before (2 packages, with packages)")
+	assert.Empty(t, *exitCodes)
+
+	createSnapshotFn = func(string, bool) (files.SnapshotManifest, error) {
+		return files.SnapshotManifest{}, errors.New("snapshot before already exists")
+	}
+	out = captureOutput(t, func() { snapshotCreateCmd.Run(snapshotCreateCmd, []string{"before"}) })
+	assert.Contains(t, out, "Failed to create snapshot: snapshot before already exists")
+	assert.Equal(t, []int{1}, *exitCodes)
+}
+
+func TestSnapshotRestoreCommandRun(t *testing.T) {
+	stubSnapshotFns(t)
+	restoreSnapshotFn = func(name string) (files.SnapshotManifest, error) {
+		return files.SnapshotManifest{Name: name, Packages: make([]files.SnapshotPackage, 1)}, nil
+	}
+	out := captureOutput(t, func() { snapshotRestoreCmd.Run(snapshotRestoreCmd, []string{"before"}) })
+	assert.Contains(t, out, "Restored snapshot before (1 packages)")
+	assert.Contains(t, out, "zana sync packages")
+
+	restoreSnapshotFn = func(name string) (files.SnapshotManifest, error) {
+		return files.SnapshotManifest{Name: name, Content: true}, nil
+	}
+	out = captureOutput(t, func() { snapshotRestoreCmd.Run(snapshotRestoreCmd, []string{"before"}) })
+	assert.NotContains(t, out, "zana sync packages")
+}
+
+func TestSnapshotListCommandRun(t *testing.T) {
+	stubSnapshotFns(t)
+	listSnapshotsFn = func() ([]files.SnapshotManifest, error) { return []files.SnapshotManifest{}, nil }
+	out := captureOutput(t, func() { snapshotListCmd.Run(snapshotListCmd, nil) })
+	assert.Contains(t, out, "No snapshots found")
+
+	listSnapshotsFn = func() ([]files.SnapshotManifest, error) {
+		return []files.SnapshotManifest{{Name: "before", CreatedAt: time.Now(), Content: true}}, nil
+	}
+	out = captureOutput(t, func() { snapshotListCmd.Run(snapshotListCmd, nil) })
+	assert.Contains(t, out, "before")
+	assert.Contains(t, out, "0 packages, with packages")
+
+	out = captureOutputWithMode(t, func() { snapshotListCmd.Run(snapshotListCmd, nil) }, config.OutputModeJSON)
+	assert.Contains(t, out, `"name": "before"`)
+
+	names, _ := snapshotNameCompletion(snapshotRestoreCmd, nil, "")
+	assert.Equal(t, []string{"before"}, names)
+}
+
+func TestSnapshotDeleteCommandRun(t *testing.T) {
+	exitCodes := stubSnapshotFns(t)
+	deleteSnapshotFn = func(string) error { return nil }
+	out := captureOutput(t, func() { snapshotDeleteCmd.Run(snapshotDeleteCmd, []string{"before"}) })
+	assert.Contains(t, out, "Deleted snapshot before")
+
+	deleteSnapshotFn = func(string) error { return errors.New("snapshot before not found") }
+	out = captureOutput(t, func() { snapshotDeleteCmd.Run(snapshotDeleteCmd, []string{"before"}) })
+	assert.Contains(t, out, "Failed to delete snapshot")
+	assert.Equal(t, []int{1}, *exitCodes)
+}
@@ -25,22 +25,23 @@ func runZanaInstallWithTreeSitterSpinnerPhases(
 		return false, e
 	}
 	if providers.GitHubTreeSitterUsesPhasedInteractiveInstall(sourceID, registryItem) {
-		if e := providers.GitHubTreeSitterPreflightInteractive(sourceID, resolvedVersion); e != nil {
+		ctx := providers.NewInstallOperation(sourceID)
+		if e := providers.GitHubTreeSitterPreflightInteractive(ctx, sourceID, resolvedVersion); e != nil {
 			return false, e
 		}
 		buildTitle := strings.TrimSuffix(title, "...") + " (tree-sitter build)..."
 		var buildOK bool
 		err = spinnerutil.Run(buildTitle, func() {
-			buildOK = providers.GitHubTreeSitterPhaseBuildParsers(sourceID, resolvedVersion)
+			buildOK = providers.GitHubTreeSitterPhaseBuildParsers(ctx, sourceID, resolvedVersion)
 		})
 		if err != nil || !buildOK {
 			return false, err
 		}
-		if !providers.GitHubTreeSitterPhaseCacheNeovimQueries(sourceID, resolvedVersion) {
+		if !providers.GitHubTreeSitterPhaseCacheNeovimQueries(ctx, sourceID, resolvedVersion) {
 			return false, nil
 		}
 		err = spinnerutil.Run(title, func() {
-			success = providers.GitHubTreeSitterPhaseRegisterPackage(sourceID, resolvedVersion)
+			success = providers.GitHubTreeSitterPhaseRegisterPackage(ctx, sourceID, resolvedVersion)
 		})
 		return success, err
 	}
@@ -0,0 +1,344 @@
+package zana
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show statistics about installed packages",
+	Long: `Show statistics about installed packages.
+
+Summarizes the installed packages per provider, their disk usage,
+the oldest and newest installs, the number of outdated packages,
+the cache sizes and the average install durations.
+
+The timing budgets are the average and p95 durations of the latest installs
+and updates per provider. Operations taking far longer than the p95 are warned
+about when they happen and listed as slow operations, which often hints at
+network or registry issues.
+
+Install times and durations are taken from the history log (history.jsonl next to zana-lock.json),
+which records every install, update and removal.
+
+--usage shows how often each command ran and failed instead. These usage
+metrics are off by default: metrics.mode in config.yaml turns them on, with
+local only counting them in usage.json, and share also sending the aggregate
+counts (no package IDs, paths or arguments) to metrics.endpoint when --usage
+shows them, at most once a week.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if statsUsage {
+			printUsageMetrics()
+			return
+		}
+		service := newListService()
+		stats := collectStats(service)
+		switch {
+		case ShouldUseJSONOutput():
+			for _, install := range []*packageInstallStats{stats.OldestInstall, stats.NewestInstall} {
+				if install != nil {
+					install.InstalledAt = displayTime(install.InstalledAt)
+				}
+			}
+			for i := range stats.SlowOperations {
+				stats.SlowOperations[i].Time = displayTime(stats.SlowOperations[i].Time)
+			}
+			PrintJSON(stats)
+		case ShouldUsePlainOutput():
+			printStatsPlain(stats)
+		default:
+			printStatsRich(service, stats)
+		}
+	},
+}
+
+// providerStats holds the statistics of a single provider
+type providerStats struct {
+	Provider          string `json:"provider"`
+	Packages          int    `json:"packages"`
+	DiskUsageBytes    int64  `json:"disk_usage_bytes"`
+	AverageInstallMs  int64  `json:"average_install_ms"`
+	RecordedInstalls  int    `json:"recorded_installs"`
+	totalInstallTimes int64
+}
+
+// packageInstallStats identifies when an installed package was installed
+type packageInstallStats struct {
+	SourceID    string    `json:"source_id"`
+	Version     string    `json:"version"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// slowOperationStats is an operation that exceeded its timing budget
+type slowOperationStats struct {
+	SourceID   string    `json:"source_id"`
+	Action     string    `json:"action"`
+	DurationMs int64     `json:"duration_ms"`
+	SlowFactor float64   `json:"slow_factor"`
+	Time       time.Time `json:"time"`
+}
+
+// maxSlowOperations is how many of the latest slow operations stats lists
+const maxSlowOperations = 5
+
+type cacheStats struct {
+	RegistryBytes int64 `json:"registry_bytes"`
+	AssetsBytes   int64 `json:"assets_bytes"`
+	TotalBytes    int64 `json:"total_bytes"`
+}
+
+// zanaStats is the result of collectStats
+type zanaStats struct {
+	TotalPackages    int                        `json:"total_packages"`
+	Outdated         int                        `json:"outdated"`
+	DiskUsageBytes   int64                      `json:"disk_usage_bytes"`
+	Providers        []providerStats            `json:"providers"`
+	OldestInstall    *packageInstallStats       `json:"oldest_install"`
+	NewestInstall    *packageInstallStats       `json:"newest_install"`
+	Cache            cacheStats                 `json:"cache"`
+	AverageInstallMs int64                      `json:"average_install_ms"`
+	RecordedInstalls int                        `json:"recorded_installs"`
+	DurationBudgets  []providers.DurationBudget `json:"duration_budgets"`
+	SlowOperations   []slowOperationStats       `json:"slow_operations"`
+}
+
+// collectStats gathers the statistics for the installed packages
+func collectStats(ls *ListService) zanaStats {
+	packages := ls.localPackages.GetData(false).Packages
+	history, err := readHistoryFn()
+	if err != nil {
+		providers.Logger.Error(fmt.Sprintf("Stats: Error reading history log: %v", err))
+	}
+
+	stats := zanaStats{
+		TotalPackages: len(packages),
+		// Data-only packages live in the share directory, outside of the packages directory
+		DiskUsageBytes:  dirSizeFn(files.GetAppPackagesPath()) + dirSizeFn(files.GetAppSharePath()),
+		Providers:       []providerStats{},
+		DurationBudgets: providers.DurationBudgets(history),
+		SlowOperations:  []slowOperationStats{},
+		Cache: cacheStats{
+			RegistryBytes: dirSizeFn(files.GetRegistryCachePath()),
+			AssetsBytes:   dirSizeFn(files.GetAssetCachePath()),
+			TotalBytes:    dirSizeFn(files.GetCachePath()),
+		},
+	}
+
+	byProvider := map[string]*providerStats{}
+	providerStatsFor := func(provider string) *providerStats {
+		if ps, ok := byProvider[provider]; ok {
+			return ps
+		}
+		ps := &providerStats{
+			Provider:       provider,
+			DiskUsageBytes: dirSizeFn(filepath.Join(files.GetAppPackagesPath(), provider)) + dirSizeFn(filepath.Join(files.GetAppSharePath(), provider)),
+		}
+		byProvider[provider] = ps
+		return ps
+	}
+
+	for _, pkg := range packages {
+		providerStatsFor(getProviderFromSourceID(pkg.SourceID)).Packages++
+		if _, hasUpdate := ls.checkUpdateAvailability(pkg.SourceID, pkg.Version); hasUpdate {
+			stats.Outdated++
+		}
+	}
+
+	// Durations are averaged over all successful installs and updates in the history
+	var totalInstallTimes int64
+	installedAt := map[string]files.HistoryEntry{}
+	for _, entry := range history {
+		if !entry.Success || entry.Action == files.HistoryActionRemove {
+			continue
+		}
+		provider := getProviderFromSourceID(entry.SourceID)
+		if provider == "unknown" {
+			continue
+		}
+		ps := providerStatsFor(provider)
+		ps.RecordedInstalls++
+		ps.totalInstallTimes += entry.DurationMs
+		stats.RecordedInstalls++
+		totalInstallTimes += entry.DurationMs
+		installedAt[entry.SourceID+"@"+entry.Version] = entry
+	}
+	for i := len(history) - 1; i >= 0 && len(stats.SlowOperations) < maxSlowOperations; i-- {
+		if entry := history[i]; entry.SlowFactor > 0 {
+			stats.SlowOperations = append(stats.SlowOperations, slowOperationStats{
+				SourceID:   entry.SourceID,
+				Action:     entry.Action,
+				DurationMs: entry.DurationMs,
+				SlowFactor: entry.SlowFactor,
+				Time:       entry.Time,
+			})
+		}
+	}
+	if stats.RecordedInstalls > 0 {
+		stats.AverageInstallMs = totalInstallTimes / int64(stats.RecordedInstalls)
+	}
+
+	// Oldest/newest only consider the last install of the currently locked versions
+	for _, pkg := range packages {
+		entry, ok := installedAt[pkg.SourceID+"@"+pkg.Version]
+		if !ok {
+			continue
+		}
+		install := &packageInstallStats{SourceID: pkg.SourceID, Version: pkg.Version, InstalledAt: entry.Time}
+		if stats.OldestInstall == nil || entry.Time.Before(stats.OldestInstall.InstalledAt) {
+			stats.OldestInstall = install
+		}
+		if stats.NewestInstall == nil || entry.Time.After(stats.NewestInstall.InstalledAt) {
+			stats.NewestInstall = install
+		}
+	}
+
+	for _, ps := range byProvider {
+		if ps.RecordedInstalls > 0 {
+			ps.AverageInstallMs = ps.totalInstallTimes / int64(ps.RecordedInstalls)
+		}
+		stats.Providers = append(stats.Providers, *ps)
+	}
+	sort.Slice(stats.Providers, func(i, j int) bool {
+		if stats.Providers[i].Packages != stats.Providers[j].Packages {
+			return stats.Providers[i].Packages > stats.Providers[j].Packages
+		}
+		return stats.Providers[i].Provider < stats.Providers[j].Provider
+	})
+	return stats
+}
+
+// formatBytes formats a size in bytes using binary units, e.g. 1.5 MiB
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// formatInstallDuration formats an average install duration, "-" when nothing was recorded
+func formatInstallDuration(ms int64, recorded int) string {
+	if recorded == 0 {
+		return "-"
+	}
+	return formatDuration(time.Duration(ms) * time.Millisecond)
+}
+
+func formatInstallStats(install *packageInstallStats) string {
+	if install == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%s (v%s, %s)", install.SourceID, install.Version, formatWhen(install.InstalledAt))
+}
+
+func formatSlowOperation(op slowOperationStats) string {
+	return fmt.Sprintf("%s %s took %s, %.0fx longer than usual (%s)", op.SourceID, op.Action, formatInstallDuration(op.DurationMs, 1), op.SlowFactor, formatWhen(op.Time))
+}
+
+func printStatsRich(ls *ListService, stats zanaStats) {
+	var markdown strings.Builder
+	markdown.WriteString(fmt.Sprintf("# %s Zana Statistics\n\n", IconSummaryPlain()))
+	markdown.WriteString(fmt.Sprintf("- **%d** installed packages, **%d** outdated\n", stats.TotalPackages, stats.Outdated))
+	markdown.WriteString(fmt.Sprintf("- **%s** disk usage\n", formatBytes(stats.DiskUsageBytes)))
+	markdown.WriteString(fmt.Sprintf("- Oldest install: %s\n", formatInstallStats(stats.OldestInstall)))
+	markdown.WriteString(fmt.Sprintf("- Newest install: %s\n", formatInstallStats(stats.NewestInstall)))
+	markdown.WriteString(fmt.Sprintf("- Average install duration: %s\n\n", formatInstallDuration(stats.AverageInstallMs, stats.RecordedInstalls)))
+
+	if len(stats.Providers) > 0 {
+		markdown.WriteString("## Providers\n\n")
+		markdown.WriteString("| Provider | Packages | Disk usage | Avg. install |\n")
+		markdown.WriteString("|----------|----------|------------|--------------|\n")
+		for _, ps := range stats.Providers {
+			markdown.WriteString(fmt.Sprintf("| %s | %d | %s | %s |\n", ps.Provider, ps.Packages, formatBytes(ps.DiskUsageBytes), formatInstallDuration(ps.AverageInstallMs, ps.RecordedInstalls)))
+		}
+		markdown.WriteString("\n")
+	}
+
+	if len(stats.DurationBudgets) > 0 {
+		markdown.WriteString("## Timing budgets\n\n")
+		markdown.WriteString("| Provider | Action | Operations | Avg. | p95 |\n")
+		markdown.WriteString("|----------|--------|------------|------|-----|\n")
+		for _, b := range stats.DurationBudgets {
+			markdown.WriteString(fmt.Sprintf("| %s | %s | %d | %s | %s |\n", b.Provider, b.Action, b.Samples, formatInstallDuration(b.AverageMs, b.Samples), formatInstallDuration(b.P95Ms, b.Samples)))
+		}
+		markdown.WriteString("\n")
+	}
+
+	if len(stats.SlowOperations) > 0 {
+		markdown.WriteString("## Slow operations\n\n")
+		for _, op := range stats.SlowOperations {
+			markdown.WriteString(fmt.Sprintf("- %s\n", formatSlowOperation(op)))
+		}
+		markdown.WriteString("\n")
+	}
+
+	markdown.WriteString("## Cache\n\n")
+	markdown.WriteString(fmt.Sprintf("- Registry: %s\n", formatBytes(stats.Cache.RegistryBytes)))
+	markdown.WriteString(fmt.Sprintf("- Assets: %s\n", formatBytes(stats.Cache.AssetsBytes)))
+	markdown.WriteString(fmt.Sprintf("- Total: %s\n", formatBytes(stats.Cache.TotalBytes)))
+
+	ls.renderMarkdown(markdown.String())
+}
+
+func printStatsPlain(stats zanaStats) {
+	fmt.Printf("%s Zana Statistics\n\n", IconSummary())
+	fmt.Printf("Installed packages: %d\n", stats.TotalPackages)
+	fmt.Printf("Outdated packages: %d\n", stats.Outdated)
+	fmt.Printf("Disk usage: %s\n", formatBytes(stats.DiskUsageBytes))
+	fmt.Printf("Oldest install: %s\n", formatInstallStats(stats.OldestInstall))
+	fmt.Printf("Newest install: %s\n", formatInstallStats(stats.NewestInstall))
+	fmt.Printf("Average install duration: %s\n", formatInstallDuration(stats.AverageInstallMs, stats.RecordedInstalls))
+	fmt.Println()
+
+	if len(stats.Providers) > 0 {
+		fmt.Println("Providers:")
+		for _, ps := range stats.Providers {
+			fmt.Printf("   %s %s: %d packages, %s, avg. install %s\n", getProviderIcon(ps.Provider), ps.Provider, ps.Packages, formatBytes(ps.DiskUsageBytes), formatInstallDuration(ps.AverageInstallMs, ps.RecordedInstalls))
+		}
+		fmt.Println()
+	}
+
+	if len(stats.DurationBudgets) > 0 {
+		fmt.Println("Timing budgets:")
+		for _, b := range stats.DurationBudgets {
+			fmt.Printf("   %s %s: %d operations, avg. %s, p95 %s\n", b.Provider, b.Action, b.Samples, formatInstallDuration(b.AverageMs, b.Samples), formatInstallDuration(b.P95Ms, b.Samples))
+		}
+		fmt.Println()
+	}
+
+	if len(stats.SlowOperations) > 0 {
+		fmt.Println("Slow operations:")
+		for _, op := range stats.SlowOperations {
+			fmt.Printf("   %s\n", formatSlowOperation(op))
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("Cache: registry %s, assets %s, total %s\n", formatBytes(stats.Cache.RegistryBytes), formatBytes(stats.Cache.AssetsBytes), formatBytes(stats.Cache.TotalBytes))
+}
+
+var statsUsage bool
+
+func init() {
+	statsCmd.Flags().BoolVar(&statsUsage, "usage", false, "show how often each command ran and failed (needs metrics.mode in config.yaml)")
+}
+
+// indirections for testability
+var (
+	readHistoryFn = files.ReadHistory
+	dirSizeFn     = files.DirSize
+)
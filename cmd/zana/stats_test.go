@@ -0,0 +1,152 @@
+package zana
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubStats(t *testing.T) {
+	t.Helper()
+	prevHistory, prevDirSize, prevService := readHistoryFn, dirSizeFn, newListService
+	t.Cleanup(func() {
+		readHistoryFn, dirSizeFn, newListService = prevHistory, prevDirSize, prevService
+	})
+
+	day := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	readHistoryFn = func() ([]files.HistoryEntry, error) {
+		return []files.HistoryEntry{
+			{Time: day, Action: files.HistoryActionInstall, SourceID: "npm:prettier", Version: "3.0.0", DurationMs: 3000, Success: true},
+			{Time: day.Add(24 * time.Hour), Action: files.HistoryActionInstall, SourceID: "npm:eslint", Version: "9.0.0", DurationMs: 5000, Success: true},
+			{Time: day.Add(48 * time.Hour), Action: files.HistoryActionInstall, SourceID: "pypi:black", Version: "24.1.0", DurationMs: 1000, Success: true},
+			{Time: day.Add(72 * time.Hour), Action: files.HistoryActionInstall, SourceID: "pypi:ruff", Version: "0.1.0", DurationMs: 99000, Success: false},
+			{Time: day.Add(96 * time.Hour), Action: files.HistoryActionRemove, SourceID: "pypi:ruff", DurationMs: 10, Success: true},
+		}, nil
+	}
+	dirSizeFn = func(path string) int64 {
+		if filepath.Base(path) == "share" || filepath.Base(filepath.Dir(path)) == "share" {
+			// no data-only packages installed
+			return 0
+		}
+		switch filepath.Base(path) {
+		case "npm":
+			return 2048
+		case "pypi":
+			return 1024
+		case "packages":
+			return 3072
+		default:
+			return 512
+		}
+	}
+	newListService = func() *ListService {
+		return NewListServiceWithDependencies(
+			&MockLocalPackagesProvider{GetDataFunc: func(bool) local_packages_parser.LocalPackageRoot {
+				return local_packages_parser.LocalPackageRoot{Packages: []local_packages_parser.LocalPackageItem{
+					{SourceID: "npm:prettier", Version: "3.0.0"},
+					{SourceID: "npm:eslint", Version: "9.0.0"},
+					{SourceID: "pypi:black", Version: "24.1.0"},
+				}}
+			}},
+			&MockRegistryProvider{GetLatestVersionFunc: func(sourceID string) string {
+				if sourceID == "npm:eslint" {
+					return "9.1.0"
+				}
+				return ""
+			}},
+			&MockUpdateChecker{CheckIfUpdateIsAvailableFunc: func(current, latest string) (bool, string) {
+				return current != latest, latest
+			}},
+			&MockFileDownloader{},
+		)
+	}
+}
+
+func TestCollectStats(t *testing.T) {
+	stubStats(t)
+
+	stats := collectStats(newListService())
+	assert.Equal(t, 3, stats.TotalPackages)
+	assert.Equal(t, 1, stats.Outdated)
+	assert.Equal(t, int64(3072), stats.DiskUsageBytes)
+	require.Len(t, stats.Providers, 2)
+	assert.Equal(t, "npm", stats.Providers[0].Provider)
+	assert.Equal(t, 2, stats.Providers[0].Packages)
+	assert.Equal(t, int64(2048), stats.Providers[0].DiskUsageBytes)
+	assert.Equal(t, int64(4000), stats.Providers[0].AverageInstallMs)
+	assert.Equal(t, "pypi", stats.Providers[1].Provider)
+	assert.Equal(t, 1, stats.Providers[1].RecordedInstalls, "failed installs are not averaged")
+	assert.Equal(t, int64(3000), stats.AverageInstallMs)
+	require.NotNil(t, stats.OldestInstall)
+	assert.Equal(t, "npm:prettier", stats.OldestInstall.SourceID)
+	require.NotNil(t, stats.NewestInstall)
+	assert.Equal(t, "pypi:black", stats.NewestInstall.SourceID)
+}
+
+func TestStatsCommandOutput(t *testing.T) {
+	stubStats(t)
+
+	out := captureOutput(t, func() { statsCmd.Run(statsCmd, nil) })
+	assert.Contains(t, out, "Installed packages: 3")
+	assert.Contains(t, out, "Outdated packages: 1")
+	assert.Contains(t, out, "Disk usage: 3.0 KiB")
+	assert.Contains(t, out, "npm: 2 packages, 2.0 KiB, avg. install 4s")
+	assert.Contains(t, out, "Oldest install: npm:prettier (v3.0.0")
+
+	out = captureOutputWithMode(t, func() { statsCmd.Run(statsCmd, nil) }, config.OutputModeJSON)
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	assert.Equal(t, float64(3), result["total_packages"])
+	assert.Equal(t, float64(1), result["outdated"])
+	assert.Equal(t, "pypi:black", result["newest_install"].(map[string]interface{})["source_id"])
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "2.0 MiB", formatBytes(2*1024*1024))
+	assert.Equal(t, "-", formatInstallDuration(0, 0))
+	assert.Equal(t, "1.2s", formatInstallDuration(1234, 1))
+}
+
+func TestStatsTimingBudgets(t *testing.T) {
+	stubStats(t)
+	day := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	history := []files.HistoryEntry{}
+	for i := 0; i < 5; i++ {
+		history = append(history, files.HistoryEntry{Time: day, Action: files.HistoryActionInstall, SourceID: "npm:prettier", Version: "3.0.0", DurationMs: 2000, Success: true})
+	}
+	history = append(history,
+		files.HistoryEntry{Time: day.Add(time.Hour), Action: files.HistoryActionInstall, SourceID: "npm:eslint", Version: "9.0.0", DurationMs: 12000, Success: true, SlowFactor: 6},
+		files.HistoryEntry{Time: day.Add(2 * time.Hour), Action: files.HistoryActionUpdate, SourceID: "pypi:black", Version: "24.1.0", DurationMs: 1000, Success: true},
+	)
+	readHistoryFn = func() ([]files.HistoryEntry, error) { return history, nil }
+
+	stats := collectStats(newListService())
+	require.Len(t, stats.DurationBudgets, 2)
+	assert.Equal(t, "npm", stats.DurationBudgets[0].Provider)
+	assert.Equal(t, 6, stats.DurationBudgets[0].Samples)
+	assert.Equal(t, int64(12000), stats.DurationBudgets[0].P95Ms)
+	require.Len(t, stats.SlowOperations, 1)
+	assert.Equal(t, "npm:eslint", stats.SlowOperations[0].SourceID)
+
+	out := captureOutputWithMode(t, func() { statsCmd.Run(statsCmd, nil) }, config.OutputModePlain)
+	assert.Contains(t, out, "npm install: 6 operations, avg. 3.7s, p95 12s")
+	assert.Contains(t, out, "pypi update: 1 operations, avg. 1s, p95 1s")
+	assert.Contains(t, out, "npm:eslint install took 12s, 6x longer than usual")
+
+	out = captureOutputWithMode(t, func() { statsCmd.Run(statsCmd, nil) }, config.OutputModeJSON)
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	assert.Len(t, result["duration_budgets"], 2)
+	slow := result["slow_operations"].([]interface{})
+	require.Len(t, slow, 1)
+	assert.Equal(t, float64(6), slow[0].(map[string]interface{})["slow_factor"])
+}
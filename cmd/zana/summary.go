@@ -0,0 +1,176 @@
+package zana
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/errcodes"
+)
+
+// operationSummary collects the outcome of a command working through
+// packages (add, remove, update, sync) and renders the summary printed at its
+// end, so all of them report the same fields the same way.
+type operationSummary struct {
+	// title names the operation in the heading, e.g. Installation
+	title string
+	// action is the verb of the operation, e.g. install, and done its past
+	// tense, e.g. installed
+	action string
+	done   string
+
+	succeeded []string
+	failed    []string
+	skipped   []string
+	// skipReason explains skipped packages, e.g. up to date. Without one,
+	// the skipped count is only shown when packages were skipped.
+	skipReason string
+	// note follows the success count, e.g. (2 you requested, 1 dependency)
+	note string
+	// details are extra lines shown below the counts, e.g. Held back: 2
+	details []string
+	hints   []string
+	// codes records why packages failed, see zana errors
+	codes map[string]errcodes.Code
+	// extraSucceeded counts successes that aren't listed by ID, e.g.
+	// dependencies installed along the requested packages
+	extraSucceeded int
+	// reclaimedBytes is the space freed by removing older package versions
+	reclaimedBytes int64
+
+	started time.Time
+}
+
+// operationSummaryJSON is the "summary" object in the JSON output of
+// add, remove, update and sync
+type operationSummaryJSON struct {
+	Succeeded      int      `json:"succeeded"`
+	Failed         int      `json:"failed"`
+	Skipped        int      `json:"skipped"`
+	FailedPackages []string `json:"failed_packages"`
+	// FailureCodes maps each failed package to its error code
+	FailureCodes map[string]errcodes.Code `json:"failure_codes,omitempty"`
+	DurationMs   int64                    `json:"duration_ms"`
+	Details      []string                 `json:"details,omitempty"`
+	// ReclaimedBytes is the space freed by removing older package versions
+	ReclaimedBytes int64    `json:"reclaimed_bytes,omitempty"`
+	Hints          []string `json:"hints,omitempty"`
+}
+
+// newOperationSummary starts timing an operation, e.g.
+// newOperationSummary("Installation", "install", "installed")
+func newOperationSummary(title, action, done string) *operationSummary {
+	return &operationSummary{title: title, action: action, done: done, started: clock.Now()}
+}
+
+func (s *operationSummary) succeed(id string) { s.succeeded = append(s.succeeded, id) }
+func (s *operationSummary) fail(id string)    { s.failed = append(s.failed, id) }
+func (s *operationSummary) skip(id string)    { s.skipped = append(s.skipped, id) }
+
+// code records why id failed. Failed packages without one report
+// errcodes.Failed.
+func (s *operationSummary) code(id string, code errcodes.Code) {
+	if code == "" {
+		return
+	}
+	if s.codes == nil {
+		s.codes = map[string]errcodes.Code{}
+	}
+	s.codes[id] = code
+}
+
+// hint adds a follow-up hint shown below the summary
+func (s *operationSummary) hint(format string, args ...interface{}) {
+	s.hints = append(s.hints, fmt.Sprintf(format, args...))
+}
+
+func (s *operationSummary) successCount() int { return len(s.succeeded) + s.extraSucceeded }
+
+// allSucceeded reports whether no package failed
+func (s *operationSummary) allSucceeded() bool { return len(s.failed) == 0 }
+
+// followUpHints returns the hints to show, pointing to the logs of failed
+// packages unless a hint was given already
+func (s *operationSummary) followUpHints() []string {
+	hints := append([]string{}, s.hints...)
+	switch {
+	case len(s.failed) == 1:
+		hints = append(hints, fmt.Sprintf("Run 'zana logs -p %s' to see why it failed", s.failed[0]))
+	case len(s.failed) > 1:
+		hints = append(hints, "Run 'zana logs' to see why they failed")
+	}
+	return hints
+}
+
+// toJSON returns the summary for JSON output
+func (s *operationSummary) toJSON() operationSummaryJSON {
+	failed := s.failed
+	if failed == nil {
+		failed = []string{}
+	}
+	var codes map[string]errcodes.Code
+	for _, id := range s.failed {
+		if codes == nil {
+			codes = map[string]errcodes.Code{}
+		}
+		codes[id] = errcodes.Failed
+		if code, ok := s.codes[id]; ok {
+			codes[id] = code
+		}
+	}
+	return operationSummaryJSON{
+		Succeeded:      s.successCount(),
+		Failed:         len(s.failed),
+		Skipped:        len(s.skipped),
+		FailedPackages: failed,
+		FailureCodes:   codes,
+		DurationMs:     clock.Since(s.started).Milliseconds(),
+		Details:        s.details,
+		ReclaimedBytes: s.reclaimedBytes,
+		Hints:          s.followUpHints(),
+	}
+}
+
+// print renders the summary in rich or plain text. JSON output embeds
+// toJSON in the command's result instead, so nothing is printed then.
+func (s *operationSummary) print(printf func(format string, args ...interface{})) {
+	if ShouldUseJSONOutput() {
+		return
+	}
+	if ShouldUsePlainOutput() {
+		printf("\n%s Summary:\n", s.title)
+	} else {
+		printf("\n%s %s Summary:\n", IconSummary(), s.title)
+	}
+	line := fmt.Sprintf("  Successfully %s: %d", s.done, s.successCount())
+	if s.note != "" {
+		line += " " + s.note
+	}
+	printf("%s\n", line)
+	printf("  Failed to %s: %d\n", s.action, len(s.failed))
+	if len(s.failed) > 0 {
+		printf("  Failed packages: %s\n", strings.Join(s.failed, ", "))
+	}
+	if s.skipReason != "" {
+		printf("  Skipped (%s): %d\n", s.skipReason, len(s.skipped))
+	} else if len(s.skipped) > 0 {
+		printf("  Skipped: %d\n", len(s.skipped))
+	}
+	if s.reclaimedBytes > 0 {
+		printf("  Reclaimed from older versions: %s\n", formatBytes(s.reclaimedBytes))
+	}
+	for _, d := range s.details {
+		printf("  %s\n", d)
+	}
+	printf("  Took: %s\n", formatDuration(clock.Since(s.started)))
+
+	if s.allSucceeded() {
+		printf("All packages %s successfully!\n", s.done)
+	} else {
+		printf("Some packages failed to %s.\n", s.action)
+	}
+	for _, h := range s.followUpHints() {
+		printf("%s %s\n", IconLightbulb(), h)
+	}
+}
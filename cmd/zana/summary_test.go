@@ -0,0 +1,124 @@
+package zana
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/errcodes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperationSummary(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	t.Cleanup(clock.Set(fake))
+	summary := newOperationSummary("Update", "update", "updated")
+	summary.skipReason = "up to date"
+	summary.succeed("npm:prettier")
+	summary.succeed("npm:eslint")
+	summary.fail("pypi:black")
+	summary.skip("cargo:ripgrep")
+	summary.details = append(summary.details, "Held back: 1")
+	fake.Advance(1500 * time.Millisecond)
+
+	render := func() string {
+		var b strings.Builder
+		summary.print(func(format string, args ...interface{}) { fmt.Fprintf(&b, format, args...) })
+		return b.String()
+	}
+
+	t.Run("plain", func(t *testing.T) {
+		out := captureOutputWithMode(t, func() { fmt.Print(render()) }, config.OutputModePlain)
+		assert.Equal(t, `
+Update Summary:
+  Successfully updated: 2
+  Failed to update: 1
+  Failed packages: pypi:black
+  Skipped (up to date): 1
+  Held back: 1
+  Took: 1.5s
+Some packages failed to update.
+`+IconLightbulb()+` Run 'zana logs -p pypi:black' to see why it failed
+`, out)
+	})
+
+	t.Run("rich", func(t *testing.T) {
+		out := captureOutputWithMode(t, func() { fmt.Print(render()) }, config.OutputModeRich)
+		assert.Contains(t, out, IconSummary()+" Update Summary:")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		out := captureOutputWithMode(t, func() {
+			fmt.Print(render())
+			PrintJSON(summary.toJSON())
+		}, config.OutputModeJSON)
+		var got operationSummaryJSON
+		require.NoError(t, json.Unmarshal([]byte(out), &got), "nothing but the JSON is printed")
+		assert.Equal(t, operationSummaryJSON{
+			Succeeded:      2,
+			Failed:         1,
+			Skipped:        1,
+			FailedPackages: []string{"pypi:black"},
+			FailureCodes:   map[string]errcodes.Code{"pypi:black": errcodes.Failed},
+			DurationMs:     1500,
+			Details:        []string{"Held back: 1"},
+			Hints:          []string{"Run 'zana logs -p pypi:black' to see why it failed"},
+		}, got)
+	})
+}
+
+func TestOperationSummaryReclaimedBytes(t *testing.T) {
+	summary := newOperationSummary("Update", "update", "updated")
+	summary.succeed("github:owner/tool")
+	summary.reclaimedBytes = 2048
+
+	var b strings.Builder
+	captureOutputWithMode(t, func() {
+		summary.print(func(format string, args ...interface{}) { fmt.Fprintf(&b, format, args...) })
+	}, config.OutputModePlain)
+	assert.Contains(t, b.String(), "Reclaimed from older versions: "+formatBytes(2048))
+	assert.Equal(t, int64(2048), summary.toJSON().ReclaimedBytes)
+	assert.Zero(t, newOperationSummary("Update", "update", "updated").toJSON().ReclaimedBytes)
+}
+
+func TestOperationSummaryFailureCodes(t *testing.T) {
+	summary := newOperationSummary("Installation", "install", "installed")
+	summary.succeed("npm:prettier")
+	summary.failed = []string{"npm:eslint", "github:owner/tool"}
+	summary.code("github:owner/tool", errcodes.AssetNotFound)
+	summary.code("npm:eslint", "")
+	assert.Equal(t, map[string]errcodes.Code{
+		"npm:eslint":        errcodes.Failed,
+		"github:owner/tool": errcodes.AssetNotFound,
+	}, summary.toJSON().FailureCodes)
+	assert.Nil(t, newOperationSummary("Installation", "install", "installed").toJSON().FailureCodes)
+}
+
+func TestOperationSummaryAllSucceeded(t *testing.T) {
+	summary := newOperationSummary("Installation", "install", "installed")
+	summary.succeed("npm:prettier")
+	summary.extraSucceeded = 1
+	summary.note = installDependencyNote(1, 1)
+
+	var b strings.Builder
+	captureOutputWithMode(t, func() {
+		summary.print(func(format string, args ...interface{}) { fmt.Fprintf(&b, format, args...) })
+	}, config.OutputModePlain)
+	assert.Contains(t, b.String(), "Successfully installed: 2 (1 you requested, 1 dependency)")
+	assert.Contains(t, b.String(), "Failed to install: 0")
+	assert.Contains(t, b.String(), "All packages installed successfully!")
+	assert.NotContains(t, b.String(), "Skipped")
+	assert.NotContains(t, b.String(), "zana logs")
+	assert.Equal(t, []string{}, summary.toJSON().FailedPackages)
+}
+
+func TestInstallDependencyNote(t *testing.T) {
+	assert.Equal(t, "", installDependencyNote(2, 0))
+	assert.Equal(t, "(3 dependencies)", installDependencyNote(0, 3))
+	assert.Equal(t, "(2 you requested, 1 dependency)", installDependencyNote(2, 1))
+}
@@ -2,6 +2,7 @@ package zana
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/mistweaverco/zana-client/internal/lib/files"
@@ -11,13 +12,35 @@ import (
 )
 
 var syncCmd = &cobra.Command{
-	Use:   "sync",
+	Use:   "sync [pkgId...]",
 	Short: "Sync registry or packages",
 	Long: `Sync registry or packages.
 
 The sync command has two subcommands:
   registry  - Download and unzip the latest registry file
-  packages  - Ensure all packages in zana-lock.json are installed in exact versions`,
+  packages  - Ensure all packages in zana-lock.json are installed in exact versions
+
+Given package IDs, only those packages are repaired: each is installed again at
+its version in zana-lock.json, downloading its release asset again and
+recreating its wrappers and bin entries, without touching other packages.
+
+Examples:
+  zana sync github:sharkdp/bat
+  zana sync prettier`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return nil
+		}
+		return validatePackageArgs(args)
+	},
+	ValidArgsFunction: installedPackageIDCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			_ = cmd.Help()
+			return
+		}
+		syncPackages(args)
+	},
 }
 
 var syncRegistryCmd = &cobra.Command{
@@ -61,8 +84,25 @@ var syncPackagesCmd = &cobra.Command{
 	Long: `Ensure all packages defined in zana-lock.json are installed in the exact versions specified.
 
 This command reads the zana-lock.json file and ensures that all packages
-are installed with their exact versions as specified in the lock file.`,
+are installed with their exact versions as specified in the lock file.
+
+Release and generic assets are pinned by URL and sha256 in the lock file on
+install. Sync re-downloads exactly the pinned assets and fails when an asset
+changed upstream; --update-pins accepts the new assets and re-pins them.
+
+With --lazy nothing is installed: the bin directory gets a small shim for
+every executable that isn't installed yet, which installs its package via
+"zana run" the first time it is invoked.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if syncLazy {
+			syncLazyShims()
+			return
+		}
+		providers.SetEnforceAssetPins(!syncUpdatePins)
+		defer providers.SetEnforceAssetPins(false)
+		if !syncUpdatePins {
+			verifyCachedAssetPinsFn(local_packages_parser.GetData(false).Packages)
+		}
 		if err := providers.ConfigureExternalTreeSitterQueriesFromCLI(
 			cmd.Flags().Changed("external-treesitter-queries"),
 			syncExternalTreeSitterQueries,
@@ -101,8 +141,9 @@ are installed with their exact versions as specified in the lock file.`,
 			}
 
 			results := make([]pkgResult, 0, len(lock.Packages))
-			successCount := 0
-			failureCount := 0
+			summary := newOperationSummary("Sync", "sync", "synced")
+			tally := newProviderSyncTally(lock.Packages)
+			preflight := newProviderToolPreflight()
 
 			for _, pkg := range lock.Packages {
 				id := strings.TrimSpace(pkg.SourceID)
@@ -110,6 +151,11 @@ are installed with their exact versions as specified in the lock file.`,
 				if id == "" || ver == "" {
 					continue
 				}
+				if !preflight.check(id, printfStdout) {
+					summary.fail(id)
+					tally.record(id, false)
+					continue
+				}
 
 				var ints []string
 				if pkg.Extras != nil {
@@ -127,8 +173,9 @@ are installed with their exact versions as specified in the lock file.`,
 				ok, err := runZanaInstallWithTreeSitterSpinnerPhases(title, id, ver, registryItem, func() bool {
 					return providers.Install(id, ver)
 				})
+				tally.record(id, ok && err == nil)
 				if err != nil {
-					failureCount++
+					summary.fail(id)
 					fmt.Printf("%s Failed to sync %s@%s: %v\n", IconClose(), id, ver, err)
 					continue
 				}
@@ -143,24 +190,22 @@ are installed with their exact versions as specified in the lock file.`,
 				results = append(results, res)
 
 				if ok {
-					successCount++
+					summary.succeed(id)
 					fmt.Printf("%s Synced %s@%s\n", IconCheck(), id, ver)
 					for _, line := range res.integrationReport {
 						fmt.Printf("  %s@%s: %s\n", id, ver, line)
 					}
 				} else {
-					failureCount++
+					summary.fail(id)
 					fmt.Printf("%s Failed to sync %s@%s\n", IconClose(), id, ver)
 				}
 			}
 
 			// Final overview.
-			fmt.Printf("\nSync Summary:\n")
-			fmt.Printf("  Successfully synced: %d\n", successCount)
-			if failureCount > 0 {
-				fmt.Printf("  Failed to sync: %d\n", failureCount)
-			}
-			fmt.Printf("%s Packages sync completed\n", IconCheck())
+			summary.print(printfStdout)
+			tally.print()
+			fmt.Println()
+			warnUnmanagedBinaries(findUnmanagedBinariesFn())
 			return
 		}
 
@@ -179,25 +224,168 @@ are installed with their exact versions as specified in the lock file.`,
 			return
 		}
 
+		tally := newProviderSyncTally(newLocalPackagesParserFn().Packages)
+		tally.apply(consumeProviderSyncResultsFn())
 		if ShouldUseJSONOutput() {
 			result := map[string]interface{}{
-				"success": true,
+				"success":   !tally.failed(),
+				"providers": tally.list(),
 			}
 			PrintJSON(result)
 		} else {
-			fmt.Printf("%s Packages sync completed\n", IconCheck())
+			tally.print()
+			if tally.failed() {
+				fmt.Printf("%s Packages sync failed for some providers\n", IconClose())
+			} else {
+				fmt.Printf("%s Packages sync completed\n", IconCheck())
+			}
+		}
+		if tally.failed() {
+			osExit(1)
 		}
 	},
 }
 
-var syncExternalTreeSitterQueries string
+// providerSyncSummary is the outcome of a packages sync for one provider
+type providerSyncSummary struct {
+	Provider string `json:"provider"`
+	Packages int    `json:"packages"`
+	Synced   int    `json:"synced"`
+	Failed   int    `json:"failed"`
+	// Skipped is set when the provider wasn't synced, as it's disabled by policy
+	// or has no bulk sync
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// providerSyncTally groups the outcome of a packages sync by provider
+type providerSyncTally map[string]*providerSyncSummary
+
+// newProviderSyncTally counts the packages of each provider in the lock file
+func newProviderSyncTally(packages []local_packages_parser.LocalPackageItem) providerSyncTally {
+	tally := providerSyncTally{}
+	for _, pkg := range packages {
+		if strings.TrimSpace(pkg.SourceID) == "" || strings.TrimSpace(pkg.Version) == "" {
+			continue
+		}
+		provider := getProviderFromSourceID(pkg.SourceID)
+		if tally[provider] == nil {
+			tally[provider] = &providerSyncSummary{Provider: provider}
+		}
+		tally[provider].Packages++
+	}
+	return tally
+}
+
+// record counts a package synced one at a time
+func (t providerSyncTally) record(sourceID string, ok bool) {
+	ps := t[getProviderFromSourceID(sourceID)]
+	if ps == nil {
+		return
+	}
+	if ok {
+		ps.Synced++
+	} else {
+		ps.Failed++
+	}
+}
+
+// apply counts the packages of providers synced in bulk, results being
+// whether the Sync of each provider succeeded
+func (t providerSyncTally) apply(results map[string]bool) {
+	for provider, ps := range t {
+		ok, synced := results[provider]
+		switch {
+		case !synced:
+			ps.Skipped = true
+		case ok:
+			ps.Synced = ps.Packages
+		default:
+			ps.Failed = ps.Packages
+		}
+	}
+}
+
+func (t providerSyncTally) failed() bool {
+	for _, ps := range t {
+		if ps.Failed > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// list returns the summaries sorted by provider
+func (t providerSyncTally) list() []providerSyncSummary {
+	list := []providerSyncSummary{}
+	for _, ps := range t {
+		list = append(list, *ps)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Provider < list[j].Provider })
+	return list
+}
+
+func (t providerSyncTally) print() {
+	if len(t) == 0 {
+		return
+	}
+	fmt.Println("\nProviders:")
+	for _, ps := range t.list() {
+		switch {
+		case ps.Skipped:
+			fmt.Printf("   %s %s: %d packages, skipped\n", getProviderIcon(ps.Provider), ps.Provider, ps.Packages)
+		case ps.Failed > 0:
+			fmt.Printf("   %s %s: %d synced, %d failed\n", getProviderIcon(ps.Provider), ps.Provider, ps.Synced, ps.Failed)
+		default:
+			fmt.Printf("   %s %s: %d synced\n", getProviderIcon(ps.Provider), ps.Provider, ps.Synced)
+		}
+	}
+}
+
+var (
+	syncExternalTreeSitterQueries string
+	syncLazy                      bool
+	syncUpdatePins                bool
+)
 
 func init() {
 	syncCmd.AddCommand(syncRegistryCmd)
 	syncCmd.AddCommand(syncPackagesCmd)
+	syncPackagesCmd.Flags().BoolVar(&syncLazy, "lazy", false, "write shims installing packages on first use instead of installing them")
+	syncCmd.Flags().BoolVar(&syncUpdatePins, "update-pins", false, "accept changed upstream assets and re-pin them in zana-lock.json")
+	syncPackagesCmd.Flags().BoolVar(&syncUpdatePins, "update-pins", false, "accept changed upstream assets and re-pin them in zana-lock.json")
 	syncPackagesCmd.Flags().StringVar(&syncExternalTreeSitterQueries, "external-treesitter-queries", "ask", "optional Neovim query-only git clones: ask, always, never (ZANA_EXTERNAL_TREESITTER_QUERIES when default)")
 }
 
+// syncLazyShims writes lazy shims for the packages in zana-lock.json
+func syncLazyShims() {
+	written, err := writeLazyShimsFn()
+	if err != nil {
+		if ShouldUseJSONOutput() {
+			PrintJSON(map[string]interface{}{
+				"success": false,
+				"error":   err.Error(),
+				"shims":   written,
+			})
+		} else {
+			fmt.Printf("%s Failed to write shims: %v\n", IconClose(), err)
+		}
+		osExit(1)
+		return
+	}
+	if ShouldUseJSONOutput() {
+		PrintJSON(map[string]interface{}{
+			"success": true,
+			"shims":   written,
+		})
+		return
+	}
+	if len(written) == 0 {
+		fmt.Printf("%s All executables are installed or shimmed already\n", IconCheck())
+		return
+	}
+	fmt.Printf("%s Wrote %d shims, their packages get installed on first use\n", IconCheck(), len(written))
+}
+
 // downloadAndUnzipRegistryForced downloads and unzips the registry, forcing a fresh download
 func downloadAndUnzipRegistryForced() error {
 	return files.DownloadAndUnzipRegistryForced()
@@ -205,6 +393,9 @@ func downloadAndUnzipRegistryForced() error {
 
 // indirections for testability
 var (
-	syncRegistryFn = downloadAndUnzipRegistryForced
-	syncPackagesFn = providers.SyncAllFromLock
+	syncRegistryFn               = downloadAndUnzipRegistryForced
+	syncPackagesFn               = providers.SyncAllFromLock
+	consumeProviderSyncResultsFn = providers.ConsumeProviderSyncResults
+	verifyCachedAssetPinsFn      = providers.VerifyCachedAssetPins
+	writeLazyShimsFn             = providers.WriteLazyShims
 )
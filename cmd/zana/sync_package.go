@@ -0,0 +1,106 @@
+package zana
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+)
+
+// syncPackageFn is a variable to allow overriding in tests
+var syncPackageFn = providers.SyncPackage
+
+type syncPackageResult struct {
+	Package string `json:"package"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// resolveInstalledPackageIDs returns the source IDs of the installed packages
+// given as arguments, which may be bare names of installed packages
+func resolveInstalledPackageIDs(args []string) ([]string, error) {
+	var ids []string
+	for _, arg := range args {
+		baseID, _ := parsePackageIDAndVersion(arg)
+		if !packageid.HasProvider(baseID) {
+			matches := findInstalledPackagesByName(baseID)
+			switch len(matches) {
+			case 0:
+				return nil, fmt.Errorf("no installed packages found matching '%s'", baseID)
+			case 1:
+				ids = append(ids, matches[0].SourceID)
+			default:
+				found := make([]string, 0, len(matches))
+				for _, m := range matches {
+					found = append(found, m.SourceID)
+				}
+				return nil, fmt.Errorf("'%s' matches %s, use <provider>:<package-id>", baseID, strings.Join(found, ", "))
+			}
+			continue
+		}
+		provider, pkgName, err := parseUserPackageID(baseID)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, toInternalPackageID(provider, pkgName))
+	}
+	return ids, nil
+}
+
+// syncPackages implements zana sync <pkgId>: each package is reinstalled at
+// its locked version, leaving the other packages alone
+func syncPackages(args []string) {
+	ids, err := resolveInstalledPackageIDs(args)
+	if err != nil {
+		if ShouldUseJSONOutput() {
+			PrintJSON(map[string]interface{}{
+				"success": false,
+				"error":   err.Error(),
+			})
+		} else {
+			fmt.Printf("%s %v\n", IconClose(), err)
+		}
+		osExit(1)
+		return
+	}
+
+	providers.SetEnforceAssetPins(!syncUpdatePins)
+	defer providers.SetEnforceAssetPins(false)
+
+	results := make([]syncPackageResult, 0, len(ids))
+	summary := newOperationSummary("Sync", "sync", "synced")
+	for _, id := range ids {
+		if !ShouldUseJSONOutput() {
+			fmt.Printf("%s Syncing %s\n", IconRefresh(), id)
+		}
+		result := syncPackageResult{Package: id, Success: true}
+		if err := syncPackageFn(id); err != nil {
+			result.Success, result.Error = false, err.Error()
+			summary.fail(id)
+		} else {
+			summary.succeed(id)
+		}
+		results = append(results, result)
+		if ShouldUseJSONOutput() {
+			continue
+		}
+		if result.Success {
+			fmt.Printf("%s Synced %s\n", IconCheck(), id)
+		} else {
+			fmt.Printf("%s Failed to sync %s: %s\n", IconClose(), id, result.Error)
+		}
+	}
+	if ShouldUseJSONOutput() {
+		PrintJSON(map[string]interface{}{
+			"success":  summary.allSucceeded(),
+			"packages": results,
+			"summary":  summary.toJSON(),
+		})
+	} else {
+		summary.print(printfStdout)
+	}
+	if !summary.allSucceeded() {
+		osExit(1)
+	}
+}
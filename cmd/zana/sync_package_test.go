@@ -0,0 +1,90 @@
+package zana
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubSyncPackage(t *testing.T, sync func(sourceID string) error) *[]int {
+	t.Helper()
+	stubOverrideRegistry(t)
+	prevSync, prevLocal, prevExit := syncPackageFn, newLocalPackagesParserFn, osExit
+	t.Cleanup(func() { syncPackageFn, newLocalPackagesParserFn, osExit = prevSync, prevLocal, prevExit })
+	syncPackageFn = sync
+	newLocalPackagesParserFn = func() local_packages_parser.LocalPackageRoot {
+		return local_packages_parser.LocalPackageRoot{Packages: []local_packages_parser.LocalPackageItem{
+			{SourceID: "npm:prettier", Version: "3.3.3"},
+			{SourceID: "pypi:ruff", Version: "0.6.0"},
+			{SourceID: "cargo:ruff", Version: "0.6.0"},
+		}}
+	}
+	var codes []int
+	osExit = func(code int) { codes = append(codes, code) }
+	return &codes
+}
+
+func TestSyncSinglePackages(t *testing.T) {
+	t.Run("syncs the given packages only", func(t *testing.T) {
+		var synced []string
+		codes := stubSyncPackage(t, func(sourceID string) error {
+			synced = append(synced, sourceID)
+			return nil
+		})
+
+		out := captureOutput(t, func() { syncCmd.Run(syncCmd, []string{"prettier", "cargo:ruff"}) })
+		assert.Empty(t, *codes)
+		assert.Equal(t, []string{"npm:prettier", "cargo:ruff"}, synced)
+		assert.Contains(t, out, "Synced npm:prettier")
+		assert.Contains(t, out, "Synced cargo:ruff")
+	})
+
+	t.Run("reports failures", func(t *testing.T) {
+		codes := stubSyncPackage(t, func(sourceID string) error {
+			if sourceID == "pypi:ruff" {
+				return errors.New("reinstalling pypi:ruff@0.6.0 failed")
+			}
+			return nil
+		})
+
+		out := captureOutputWithMode(t, func() { syncCmd.Run(syncCmd, []string{"npm:prettier", "pypi:ruff"}) }, config.OutputModeJSON)
+		assert.Equal(t, []int{1}, *codes)
+		var result struct {
+			Success  bool                `json:"success"`
+			Packages []syncPackageResult `json:"packages"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		assert.False(t, result.Success)
+		assert.Equal(t, []syncPackageResult{
+			{Package: "npm:prettier", Success: true},
+			{Package: "pypi:ruff", Error: "reinstalling pypi:ruff@0.6.0 failed"},
+		}, result.Packages)
+	})
+
+	t.Run("ambiguous and unknown names", func(t *testing.T) {
+		codes := stubSyncPackage(t, func(string) error {
+			t.Fatal("nothing should be synced")
+			return nil
+		})
+
+		out := captureOutput(t, func() { syncCmd.Run(syncCmd, []string{"ruff"}) })
+		assert.Contains(t, out, "'ruff' matches pypi:ruff, cargo:ruff, use <provider>:<package-id>")
+		out = captureOutput(t, func() { syncCmd.Run(syncCmd, []string{"eslint"}) })
+		assert.Contains(t, out, "no installed packages found matching 'eslint'")
+		assert.Equal(t, []int{1, 1}, *codes)
+	})
+
+	t.Run("without packages it shows the help", func(t *testing.T) {
+		stubSyncPackage(t, func(string) error {
+			t.Fatal("nothing should be synced")
+			return nil
+		})
+		out := captureOutput(t, func() { syncCmd.Run(syncCmd, nil) })
+		assert.Contains(t, out, "zana sync github:sharkdp/bat")
+	})
+}
@@ -0,0 +1,79 @@
+package zana
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubSyncPackages(t *testing.T, results map[string]bool) *[]int {
+	t.Helper()
+	prevSync, prevResults, prevLocal, prevExit := syncPackagesFn, consumeProviderSyncResultsFn, newLocalPackagesParserFn, osExit
+	t.Cleanup(func() {
+		syncPackagesFn, consumeProviderSyncResultsFn, newLocalPackagesParserFn, osExit = prevSync, prevResults, prevLocal, prevExit
+	})
+	syncPackagesFn = func() error { return nil }
+	consumeProviderSyncResultsFn = func() map[string]bool { return results }
+	newLocalPackagesParserFn = func() local_packages_parser.LocalPackageRoot {
+		return local_packages_parser.LocalPackageRoot{Packages: []local_packages_parser.LocalPackageItem{
+			{SourceID: "npm:prettier", Version: "3.3.3"},
+			{SourceID: "npm:eslint", Version: "9.0.0"},
+			{SourceID: "pypi:ruff", Version: "0.6.0"},
+			{SourceID: "gem:rubocop", Version: "1.0.0"},
+		}}
+	}
+	var codes []int
+	osExit = func(code int) { codes = append(codes, code) }
+	return &codes
+}
+
+func TestSyncPackagesProviderSummary(t *testing.T) {
+	t.Run("summarizes each provider", func(t *testing.T) {
+		codes := stubSyncPackages(t, map[string]bool{"npm": true, "pypi": true})
+
+		out := captureOutputWithMode(t, func() { syncPackagesCmd.Run(syncPackagesCmd, nil) }, config.OutputModePlain)
+
+		assert.Empty(t, *codes)
+		assert.Contains(t, out, "npm: 2 synced")
+		assert.Contains(t, out, "pypi: 1 synced")
+		assert.Contains(t, out, "gem: 1 packages, skipped")
+		assert.Contains(t, out, "Packages sync completed")
+	})
+
+	t.Run("fails when a provider fails", func(t *testing.T) {
+		codes := stubSyncPackages(t, map[string]bool{"npm": false, "pypi": true, "gem": true})
+
+		out := captureOutputWithMode(t, func() { syncPackagesCmd.Run(syncPackagesCmd, nil) }, config.OutputModeJSON)
+
+		assert.Equal(t, []int{1}, *codes)
+		var result struct {
+			Success   bool                  `json:"success"`
+			Providers []providerSyncSummary `json:"providers"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		assert.False(t, result.Success)
+		assert.Equal(t, []providerSyncSummary{
+			{Provider: "gem", Packages: 1, Synced: 1},
+			{Provider: "npm", Packages: 2, Failed: 2},
+			{Provider: "pypi", Packages: 1, Synced: 1},
+		}, result.Providers)
+	})
+}
+
+func TestProviderSyncTallyRecord(t *testing.T) {
+	tally := newProviderSyncTally([]local_packages_parser.LocalPackageItem{
+		{SourceID: "npm:prettier", Version: "3.3.3"},
+		{SourceID: "npm:eslint", Version: "9.0.0"},
+		{SourceID: "pypi:ruff"},
+	})
+	tally.record("npm:prettier", true)
+	tally.record("npm:eslint", false)
+	tally.record("pypi:ruff", true)
+
+	assert.True(t, tally.failed())
+	assert.Equal(t, []providerSyncSummary{{Provider: "npm", Packages: 2, Synced: 1, Failed: 1}}, tally.list(), "packages without a version aren't synced")
+}
@@ -0,0 +1,314 @@
+package zana
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/spf13/cobra"
+)
+
+var testInstallCmd = &cobra.Command{
+	Use:   "test-install --registry <registry.json> <pkgId>",
+	Short: "Install a package from a local registry file into a throwaway sandbox",
+	Long: `Install a package from a local registry file into a throwaway sandbox.
+
+Meant for registry contributors: the package is installed into a temporary
+ZANA_HOME (with its own packages, bin and cache directories) using only the
+given registry file, which may contain a single entry or an array of entries.
+Afterwards the install is verified (lock file entry, executables in the bin
+directory), the log of the install is printed as trace and the sandbox is
+removed again, unless --keep is given.
+
+Examples:
+  zana test-install --registry ./my-registry.json npm:prettier
+  zana test-install --registry ./entry.json github:sharkdp/bat@v0.24.0 --keep`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		result, err := runTestInstall(args[0])
+		if err != nil {
+			if ShouldUseJSONOutput() {
+				PrintJSON(map[string]interface{}{
+					"success": false,
+					"error":   err.Error(),
+				})
+			} else {
+				fmt.Printf("%s Test install failed: %v\n", IconClose(), err)
+			}
+			osExit(1)
+			return
+		}
+		if ShouldUseJSONOutput() {
+			PrintJSON(result)
+		} else {
+			printTestInstallResult(result)
+		}
+		if !result.Success {
+			osExit(1)
+		}
+	},
+}
+
+var (
+	testInstallRegistry string
+	testInstallKeep     bool
+)
+
+func init() {
+	testInstallCmd.Flags().StringVar(&testInstallRegistry, "registry", "", "registry file with the package's entry (required)")
+	testInstallCmd.Flags().BoolVar(&testInstallKeep, "keep", false, "keep the sandbox directory for inspection")
+	_ = testInstallCmd.MarkFlagRequired("registry")
+}
+
+// testInstallCheck is a single verification of the sandboxed install
+type testInstallCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type testInstallResult struct {
+	Package      string             `json:"package"`
+	Success      bool               `json:"success"`
+	InstallError string             `json:"install_error,omitempty"`
+	Checks       []testInstallCheck `json:"checks"`
+	Trace        []string           `json:"trace"`
+	// Sandbox is only set when the sandbox was kept
+	Sandbox string `json:"sandbox,omitempty"`
+}
+
+// testInstallSandbox is the directory layout of a throwaway ZANA_HOME
+type testInstallSandbox struct {
+	root string
+}
+
+func (s testInstallSandbox) home() string  { return filepath.Join(s.root, "home") }
+func (s testInstallSandbox) data() string  { return filepath.Join(s.root, "data") }
+func (s testInstallSandbox) cache() string { return filepath.Join(s.root, "cache") }
+func (s testInstallSandbox) tmp() string   { return filepath.Join(s.root, "tmp") }
+
+func (s testInstallSandbox) env() []string {
+	return []string{
+		"ZANA_HOME=" + s.home(),
+		"ZANA_DATA=" + s.data(),
+		"ZANA_CACHE=" + s.cache(),
+		"ZANA_TMP=" + s.tmp(),
+		"ZANA_BIN_LAYOUT=flat",
+		"ZANA_DEBUG=debug",
+	}
+}
+
+// loadLocalRegistry reads a registry file holding either one entry or an array of entries
+func loadLocalRegistry(path string) ([]byte, registry_parser.RegistryRoot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "{") {
+		data = []byte("[" + trimmed + "]")
+	}
+	var registry registry_parser.RegistryRoot
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return nil, nil, fmt.Errorf("invalid registry file %s: %w", path, err)
+	}
+	return data, registry, nil
+}
+
+// findRegistryEntry returns the entry of internalID, accepting both ID formats in the registry
+func findRegistryEntry(registry registry_parser.RegistryRoot, internalID string) (registry_parser.RegistryItem, bool) {
+	for _, item := range registry {
+		if item.Source.ID == internalID {
+			return item, true
+		}
+		if provider, pkg, err := parseUserPackageID(item.Source.ID); err == nil && toInternalPackageID(provider, pkg) == internalID {
+			return item, true
+		}
+	}
+	return registry_parser.RegistryItem{}, false
+}
+
+func runTestInstall(userPkgID string) (testInstallResult, error) {
+	baseID, version := parsePackageIDAndVersion(userPkgID)
+	provider, pkgName, err := parseUserPackageID(baseID)
+	if err != nil {
+		return testInstallResult{}, err
+	}
+	internalID := toInternalPackageID(provider, pkgName)
+
+	registryJSON, registry, err := loadLocalRegistry(testInstallRegistry)
+	if err != nil {
+		return testInstallResult{}, err
+	}
+	item, ok := findRegistryEntry(registry, internalID)
+	if !ok {
+		return testInstallResult{}, fmt.Errorf("%s has no entry in %s", internalID, testInstallRegistry)
+	}
+
+	root, err := files.MkdirTemp("test-install")
+	if err != nil {
+		return testInstallResult{}, err
+	}
+	sandbox := testInstallSandbox{root: root}
+	if !testInstallKeep {
+		defer os.RemoveAll(root)
+	}
+	for _, dir := range []string{sandbox.home(), sandbox.data(), sandbox.cache(), sandbox.tmp()} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return testInstallResult{}, err
+		}
+	}
+	// Installs read the registry from the cache directory, so no download happens
+	if err := os.WriteFile(filepath.Join(sandbox.cache(), "zana-registry.json"), registryJSON, 0644); err != nil {
+		return testInstallResult{}, err
+	}
+
+	installArgs := []string{"install", "--output", "plain", userPkgID}
+	if version == "" && item.Version != "" {
+		installArgs[len(installArgs)-1] = internalID + "@" + item.Version
+	}
+	if !ShouldUseJSONOutput() {
+		fmt.Printf("%s Installing %s into sandbox %s\n", IconMagnify(), internalID, root)
+	}
+
+	result := testInstallResult{Package: internalID, Checks: []testInstallCheck{}, Trace: []string{}}
+	if err := testInstallRunFn(sandbox.env(), installArgs...); err != nil {
+		result.InstallError = err.Error()
+	}
+	result.Checks = verifySandboxInstall(sandbox, item, internalID)
+	result.Success = result.InstallError == ""
+	for _, c := range result.Checks {
+		result.Success = result.Success && c.OK
+	}
+	entries, _, _ := readLogEntries(filepath.Join(sandbox.home(), "zana.log"), 0)
+	for _, e := range entries {
+		result.Trace = append(result.Trace, formatLogEntry(e))
+	}
+	if testInstallKeep {
+		result.Sandbox = root
+	}
+	return result, nil
+}
+
+// verifySandboxInstall checks that the package ended up in the sandbox's lock
+// file and that the executables of its registry bin map, minus those left out
+// by its bin_filter, are in the bin directory, or for data-only packages, that
+// its content is in the share directory
+func verifySandboxInstall(sandbox testInstallSandbox, item registry_parser.RegistryItem, internalID string) []testInstallCheck {
+	checks := []testInstallCheck{}
+
+	lockCheck := testInstallCheck{Name: "recorded in zana-lock.json"}
+	var lock local_packages_parser.LocalPackageRoot
+	if data, err := os.ReadFile(filepath.Join(sandbox.home(), "zana-lock.json")); err != nil {
+		lockCheck.Detail = err.Error()
+	} else if err := json.Unmarshal(data, &lock); err != nil {
+		lockCheck.Detail = err.Error()
+	} else {
+		for _, pkg := range lock.Packages {
+			if p, n, err := parseUserPackageID(pkg.SourceID); err == nil && toInternalPackageID(p, n) == internalID {
+				lockCheck.OK = true
+				lockCheck.Detail = "version " + pkg.Version
+			}
+		}
+		if !lockCheck.OK {
+			lockCheck.Detail = "no entry for " + internalID
+		}
+	}
+	checks = append(checks, lockCheck)
+
+	if item.IsDataOnly() {
+		check := testInstallCheck{Name: "content in the share directory"}
+		provider, pkg, _ := strings.Cut(internalID, ":")
+		entries, err := os.ReadDir(filepath.Join(sandbox.data(), "share", provider, filepath.FromSlash(pkg)))
+		switch {
+		case err != nil:
+			check.Detail = "missing"
+		case len(entries) == 0:
+			check.Detail = "empty"
+		default:
+			check.OK = true
+		}
+		return append(checks, check)
+	}
+
+	names := make([]string, 0, len(item.Bin))
+	for name := range item.Bin {
+		if providers.BinAllowed(item, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		check := testInstallCheck{Name: "executable " + name}
+		info, err := os.Stat(filepath.Join(sandbox.data(), "bin", name))
+		switch {
+		case err != nil:
+			check.Detail = "missing from the bin directory"
+		case runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0:
+			check.Detail = "not executable"
+		default:
+			check.OK = true
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+func printTestInstallResult(result testInstallResult) {
+	if len(result.Trace) > 0 {
+		fmt.Println("\nTrace:")
+		for _, line := range result.Trace {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+	fmt.Println("\nVerification:")
+	if result.InstallError != "" {
+		fmt.Printf("  %s install: %s\n", IconClose(), result.InstallError)
+	}
+	for _, c := range result.Checks {
+		icon := IconCheck()
+		if !c.OK {
+			icon = IconClose()
+		}
+		if c.Detail != "" {
+			fmt.Printf("  %s %s (%s)\n", icon, c.Name, c.Detail)
+		} else {
+			fmt.Printf("  %s %s\n", icon, c.Name)
+		}
+	}
+	if result.Sandbox != "" {
+		fmt.Printf("\nSandbox kept at %s\n", result.Sandbox)
+	}
+	if result.Success {
+		fmt.Printf("\n%s %s installs cleanly\n", IconCheckCircle(), result.Package)
+	} else {
+		fmt.Printf("\n%s %s failed the test install\n", IconClose(), result.Package)
+	}
+}
+
+// testInstallRunFn runs zana itself with the sandbox environment.
+// In JSON mode the child's output goes to stderr to keep stdout parseable.
+var testInstallRunFn = func(env []string, args ...string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, args...)
+	cmd.Env = append(os.Environ(), env...)
+	var stdout io.Writer = os.Stdout
+	if ShouldUseJSONOutput() {
+		stdout = os.Stderr
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, stdout, os.Stderr
+	return cmd.Run()
+}
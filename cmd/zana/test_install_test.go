@@ -0,0 +1,143 @@
+package zana
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubTestInstall(t *testing.T, registry string, run func(env map[string]string, args []string) error) *[]int {
+	t.Helper()
+	prevRun, prevRegistry, prevKeep, prevExit := testInstallRunFn, testInstallRegistry, testInstallKeep, osExit
+	t.Cleanup(func() {
+		testInstallRunFn, testInstallRegistry, testInstallKeep, osExit = prevRun, prevRegistry, prevKeep, prevExit
+	})
+
+	path := filepath.Join(t.TempDir(), "registry.json")
+	require.NoError(t, os.WriteFile(path, []byte(registry), 0644))
+	testInstallRegistry = path
+	testInstallKeep = false
+
+	var codes []int
+	osExit = func(code int) { codes = append(codes, code) }
+	testInstallRunFn = func(env []string, args ...string) error {
+		vars := map[string]string{}
+		for _, kv := range env {
+			k, v, _ := strings.Cut(kv, "=")
+			vars[k] = v
+		}
+		return run(vars, args)
+	}
+	return &codes
+}
+
+const testInstallRegistryEntry = `{
+  "name": "prettier",
+  "version": "3.3.0",
+  "source": {"id": "pkg:npm/prettier"},
+  "bin": {"prettier": "npm:prettier"}
+}`
+
+// fakeSandboxInstall does what a successful install writes into the sandbox
+func fakeSandboxInstall(t *testing.T, env map[string]string) {
+	lock := `{"packages":[{"sourceId":"npm:prettier","version":"3.3.0"}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(env["ZANA_HOME"], "zana-lock.json"), []byte(lock), 0644))
+	log := `{"time":"2026-10-15T10:00:00Z","level":"INFO","msg":"NPM Install: installing prettier@3.3.0"}` + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(env["ZANA_HOME"], "zana.log"), []byte(log), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(env["ZANA_DATA"], "bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(env["ZANA_DATA"], "bin", "prettier"), []byte("#!/bin/sh\n"), 0755))
+}
+
+func TestTestInstallCommand(t *testing.T) {
+	t.Run("installs into a sandbox that is removed afterwards", func(t *testing.T) {
+		var sandboxEnv map[string]string
+		var gotArgs []string
+		codes := stubTestInstall(t, testInstallRegistryEntry, func(env map[string]string, args []string) error {
+			sandboxEnv, gotArgs = env, args
+			registry, err := os.ReadFile(filepath.Join(env["ZANA_CACHE"], "zana-registry.json"))
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(string(registry), "["), "a single entry is written as registry array")
+			fakeSandboxInstall(t, env)
+			return nil
+		})
+
+		out := captureOutput(t, func() { testInstallCmd.Run(testInstallCmd, []string{"npm:prettier"}) })
+		assert.Empty(t, *codes)
+		assert.Equal(t, []string{"install", "--output", "plain", "npm:prettier@3.3.0"}, gotArgs)
+		assert.Equal(t, "flat", sandboxEnv["ZANA_BIN_LAYOUT"])
+		assert.Equal(t, filepath.Dir(sandboxEnv["ZANA_HOME"]), filepath.Dir(sandboxEnv["ZANA_TMP"]))
+		assert.Contains(t, out, "NPM Install: installing prettier@3.3.0")
+		assert.Contains(t, out, "recorded in zana-lock.json (version 3.3.0)")
+		assert.Contains(t, out, "executable prettier")
+		assert.Contains(t, out, "npm:prettier installs cleanly")
+		_, err := os.Stat(filepath.Dir(sandboxEnv["ZANA_HOME"]))
+		assert.True(t, os.IsNotExist(err), "sandbox should be removed")
+	})
+
+	t.Run("failed install and missing executables fail the test", func(t *testing.T) {
+		codes := stubTestInstall(t, "["+testInstallRegistryEntry+"]", func(env map[string]string, args []string) error {
+			return errors.New("exit status 1")
+		})
+		testInstallKeep = true
+
+		out := captureOutputWithMode(t, func() { testInstallCmd.Run(testInstallCmd, []string{"npm:prettier"}) }, config.OutputModeJSON)
+		assert.Equal(t, []int{1}, *codes)
+		var result testInstallResult
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		assert.False(t, result.Success)
+		assert.Equal(t, "exit status 1", result.InstallError)
+		require.Len(t, result.Checks, 2)
+		assert.False(t, result.Checks[1].OK)
+		assert.Equal(t, "missing from the bin directory", result.Checks[1].Detail)
+		require.NotEmpty(t, result.Sandbox)
+		assert.DirExists(t, result.Sandbox)
+		os.RemoveAll(result.Sandbox)
+	})
+
+	t.Run("package missing from the registry file", func(t *testing.T) {
+		codes := stubTestInstall(t, testInstallRegistryEntry, func(map[string]string, []string) error {
+			t.Fatal("install should not run")
+			return nil
+		})
+		out := captureOutput(t, func() { testInstallCmd.Run(testInstallCmd, []string{"npm:eslint"}) })
+		assert.Equal(t, []int{1}, *codes)
+		assert.Contains(t, out, "npm:eslint has no entry in")
+	})
+}
+
+func TestVerifySandboxInstallDataPackage(t *testing.T) {
+	sandbox := testInstallSandbox{root: t.TempDir()}
+	item := registry_parser.RegistryItem{Kind: registry_parser.KindData}
+
+	checks := verifySandboxInstall(sandbox, item, "github:owner/fonts")
+	require.Len(t, checks, 2)
+	assert.Equal(t, "content in the share directory", checks[1].Name)
+	assert.Equal(t, "missing", checks[1].Detail)
+
+	dir := filepath.Join(sandbox.data(), "share", "github", "owner", "fonts")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Mono.ttf"), nil, 0644))
+	checks = verifySandboxInstall(sandbox, item, "github:owner/fonts")
+	assert.True(t, checks[1].OK)
+}
+
+func TestVerifySandboxInstallSkipsFilteredBins(t *testing.T) {
+	sandbox := testInstallSandbox{root: t.TempDir()}
+	item := registry_parser.RegistryItem{
+		Source:    registry_parser.RegistryItemSource{ID: "npm:typescript"},
+		Bin:       map[string]string{"tsc": "npm:tsc", "tsserver": "npm:tsserver"},
+		BinFilter: &registry_parser.RegistryItemBinFilter{Exclude: []string{"tsserver"}},
+	}
+
+	checks := verifySandboxInstall(sandbox, item, "npm:typescript")
+	require.Len(t, checks, 2)
+	assert.Equal(t, "executable tsc", checks[1].Name)
+}
@@ -0,0 +1,81 @@
+package zana
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+)
+
+// indirections for testability
+
+// displayTime converts t to the time zone times are shown in:
+// UTC with --utc, the local time zone otherwise
+func displayTime(t time.Time) time.Time {
+	if getColorConfig().UTC {
+		return t.UTC()
+	}
+	return t.Local()
+}
+
+// formatTimestamp renders t as a sortable absolute time (2024-05-01 14:03:09),
+// or as RFC 3339 with --iso
+func formatTimestamp(t time.Time) string {
+	if getColorConfig().ISO {
+		return displayTime(t).Format(time.RFC3339)
+	}
+	return displayTime(t).Format("2006-01-02 15:04:05")
+}
+
+// formatWhen renders t relative to now (3 days ago), or as RFC 3339 with --iso
+func formatWhen(t time.Time) string {
+	if getColorConfig().ISO {
+		return displayTime(t).Format(time.RFC3339)
+	}
+	return relativeTime(t, clock.Now())
+}
+
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+	var amount int
+	var unit string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		amount, unit = int(d/time.Minute), "minute"
+	case d < 24*time.Hour:
+		amount, unit = int(d/time.Hour), "hour"
+	case d < 30*24*time.Hour:
+		amount, unit = int(d/(24*time.Hour)), "day"
+	case d < 365*24*time.Hour:
+		amount, unit = int(d/(30*24*time.Hour)), "month"
+	default:
+		amount, unit = int(d/(365*24*time.Hour)), "year"
+	}
+	if amount != 1 {
+		unit += "s"
+	}
+	if future {
+		return fmt.Sprintf("in %d %s", amount, unit)
+	}
+	return fmt.Sprintf("%d %s ago", amount, unit)
+}
+
+// formatDuration renders d rounded to what is worth reading:
+// milliseconds below a second, tenths of a second below a minute,
+// whole seconds above (850ms, 1.2s, 3m5s)
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < time.Minute:
+		return d.Round(100 * time.Millisecond).String()
+	default:
+		return d.Round(time.Second).String()
+	}
+}
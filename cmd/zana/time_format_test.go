@@ -0,0 +1,78 @@
+package zana
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/stretchr/testify/assert"
+)
+
+func withTimeFlags(t *testing.T, utc, iso bool) {
+	t.Helper()
+	prev := getColorConfigFunc
+	t.Cleanup(func() { getColorConfigFunc = prev })
+	SetColorConfigFunc(func() config.ConfigFlags {
+		return config.ConfigFlags{Output: config.OutputModePlain, UTC: utc, ISO: iso}
+	})
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	cases := map[time.Duration]string{
+		10 * time.Second:         "just now",
+		time.Minute:              "1 minute ago",
+		45 * time.Minute:         "45 minutes ago",
+		5 * time.Hour:            "5 hours ago",
+		3 * 24 * time.Hour:       "3 days ago",
+		65 * 24 * time.Hour:      "2 months ago",
+		2 * 365 * 24 * time.Hour: "2 years ago",
+		-2 * time.Hour:           "in 2 hours",
+	}
+	for ago, want := range cases {
+		assert.Equal(t, want, relativeTime(now.Add(-ago), now))
+	}
+}
+
+func TestFormatWhenAndTimestamp(t *testing.T) {
+	t.Cleanup(clock.Set(clock.NewFake(time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC))))
+	at := time.Date(2024, 5, 7, 9, 30, 15, 0, time.FixedZone("CEST", 2*60*60))
+
+	withTimeFlags(t, true, false)
+	assert.Equal(t, "3 days ago", formatWhen(at))
+	assert.Equal(t, "2024-05-07 07:30:15", formatTimestamp(at))
+
+	withTimeFlags(t, true, true)
+	assert.Equal(t, "2024-05-07T07:30:15Z", formatWhen(at))
+	assert.Equal(t, "2024-05-07T07:30:15Z", formatTimestamp(at))
+}
+
+func TestFormatDuration(t *testing.T) {
+	assert.Equal(t, "850ms", formatDuration(850*time.Millisecond+300*time.Microsecond))
+	assert.Equal(t, "1.2s", formatDuration(1234*time.Millisecond))
+	assert.Equal(t, "3m5s", formatDuration(3*time.Minute+5400*time.Millisecond))
+}
+
+func TestLastInstalledAt(t *testing.T) {
+	day := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	prev := readHistoryFn
+	t.Cleanup(func() { readHistoryFn = prev })
+	readHistoryFn = func() ([]files.HistoryEntry, error) {
+		return []files.HistoryEntry{
+			{Time: day, Action: files.HistoryActionInstall, SourceID: "npm:prettier", Version: "3.0.0", Success: true},
+			{Time: day.Add(24 * time.Hour), Action: files.HistoryActionUpdate, SourceID: "npm:prettier", Version: "3.1.0", Success: true},
+			{Time: day.Add(48 * time.Hour), Action: files.HistoryActionUpdate, SourceID: "npm:prettier", Version: "3.2.0", Success: false},
+		}, nil
+	}
+
+	at, ok := lastInstalledAt("npm:prettier", "3.1.0")
+	assert.True(t, ok)
+	assert.Equal(t, day.Add(24*time.Hour), at)
+
+	_, ok = lastInstalledAt("npm:prettier", "3.2.0")
+	assert.False(t, ok, "failed updates do not count")
+	_, ok = lastInstalledAt("npm:eslint", "")
+	assert.False(t, ok)
+}
@@ -11,11 +11,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/providers"
-	"github.com/mistweaverco/zana-client/internal/lib/semver"
 	"github.com/mistweaverco/zana-client/internal/lib/spinnerutil"
 	"github.com/mistweaverco/zana-client/internal/lib/version"
+	"github.com/mistweaverco/zana-client/internal/lib/versioncmp"
 	"github.com/spf13/cobra"
 )
 
@@ -25,6 +28,8 @@ type UpdateService struct {
 	registry      RegistryProvider
 	updateChecker UpdateChecker
 	output        OutputWriter
+	// cancelled is set when the update was declined at the confirmation
+	cancelled bool
 }
 
 // OutputWriter defines the interface for writing output (for testing)
@@ -122,8 +127,10 @@ func NewUpdateServiceWithDependencies(
 	}
 }
 
-// updatePackage updates a single package using the provider factory system
-func (us *UpdateService) updatePackage(sourceID string) bool {
+// updatePackage updates a single package using the provider factory system.
+// It returns whether the update succeeded, the bytes freed by removing older
+// versions of the package and the commit range a git package moved across.
+func (us *UpdateService) updatePackage(sourceID string) (bool, int64, *providers.GitCommitRange) {
 	// Use the provider factory system which can be mocked in tests
 	return providers.Update(sourceID)
 }
@@ -134,12 +141,20 @@ var updateCmd = &cobra.Command{
 	Short:   "Update packages to their latest versions",
 	Long: `Update packages to their latest versions.
 
+A package given by its name or alias only (e.g. pyright) is looked up among
+the installed packages, exact matches first; zana asks which one is meant only
+when several match.
+
 Examples:
   zana update npm:eslint
+  zana update pyright
   zana update golang:golang.org/x/tools/gopls npm:prettier
   zana update pypi:black cargo:ripgrep
   zana update github:user/repo gitlab:group/subgroup/project
   zana update --all (update all installed packages)
+  zana update --all --only-patch (skip minor and major updates)
+  zana update --all --only-minor (skip major updates)
+  zana update --all --verify-versions (check the versions the tools report)
   zana update --self (update zana itself to the latest version)`,
 	Args: cobra.MinimumNArgs(0), // Allow no args if --all or --self is used
 	// Enable shell completion for installed package IDs only.
@@ -157,19 +172,27 @@ Examples:
 		}
 
 		allFlag, _ := cmd.Flags().GetBool("all")
+		onlyPatch, _ := cmd.Flags().GetBool("only-patch")
+		onlyMinor, _ := cmd.Flags().GetBool("only-minor")
+		maxBump := versioncmp.BumpUnknown
+		if onlyPatch {
+			maxBump = versioncmp.BumpPatch
+		} else if onlyMinor {
+			maxBump = versioncmp.BumpMinor
+		}
+
+		if maxBump != versioncmp.BumpUnknown && !allFlag {
+			service := newUpdateService()
+			service.output.Println("Error: --only-patch and --only-minor can only be used with --all")
+			return
+		}
 
 		if allFlag {
 			// Update all installed packages
 			service := newUpdateService()
 			service.output.Println("Updating all installed packages to latest versions...")
 
-			success := service.UpdateAllPackages()
-
-			if success {
-				service.output.Println("Successfully updated all packages")
-			} else {
-				service.output.Println("Failed to update some packages")
-			}
+			service.UpdateAllPackagesUpTo(maxBump)
 			return
 		}
 
@@ -193,7 +216,7 @@ Examples:
 			var displayID string
 
 			// Check if this is a package name without provider
-			if !strings.Contains(baseID, ":") && !strings.HasPrefix(baseID, "pkg:") {
+			if !packageid.HasProvider(baseID) {
 				// Package name without provider - search installed packages and prompt user
 				matches := findInstalledPackagesByName(baseID)
 				if len(matches) == 0 {
@@ -202,8 +225,8 @@ Examples:
 					return
 				}
 
-				// Always show confirmation for partial names (user didn't provide full provider:package-id)
-				selectedSourceIDs, err := promptForProviderSelection(baseID, matches, "update")
+				// Only ask when several installed packages match
+				selectedSourceIDs, err := selectInstalledMatches(baseID, matches, "update")
 				if err != nil {
 					service := newUpdateService()
 					service.output.Printf("%s Error selecting provider for '%s': %v\n", IconClose(), baseID, err)
@@ -229,6 +252,11 @@ Examples:
 					service.output.Printf("Error: Unsupported provider '%s' for package '%s'. Supported providers: %s\n", provider, userPkgID, strings.Join(providers.AvailableProviders, ", "))
 					return
 				}
+				if err := providers.CheckProviderAllowed(provider); err != nil {
+					service := newUpdateService()
+					service.output.Printf("Error: %v\n", err)
+					return
+				}
 
 				internalID = toInternalPackageID(provider, pkgName)
 				// Construct displayID from provider and package name (full provider:package-id format)
@@ -243,54 +271,63 @@ Examples:
 		service := newUpdateService()
 		service.output.Printf("Updating %d package(s) to latest versions...\n", len(internalIDs))
 
-		allSuccess := true
-		successCount := 0
-		failedCount := 0
+		summary := newOperationSummary("Update", "update", "updated")
+		preflight := newProviderToolPreflight()
+		commitRanges := newGitCommitRanges()
+		verifier := newVersionVerifier()
 
 		for idx := range internalIDs {
 			internalID := internalIDs[idx]
 			displayID := displayIDs[idx]
 
+			if !preflight.check(internalID, service.output.Printf) {
+				summary.fail(displayID)
+				continue
+			}
+
 			// Update the package with spinner showing package name
+			verifier.recordBefore(internalID)
 			var success bool
+			var reclaimed int64
+			var commits *providers.GitCommitRange
 			action := func() {
-				success = service.updatePackage(internalID)
+				success, reclaimed, commits = service.updatePackage(internalID)
 			}
 
 			title := fmt.Sprintf("Updating %s...", displayID)
 			if err := spinnerutil.Run(title, action); err != nil {
 				service.output.Printf("%s Failed to update %s: %v\n", IconClose(), displayID, err)
-				failedCount++
-				allSuccess = false
+				summary.fail(displayID)
 				continue
 			}
 
+			commitRanges.add(displayID, commits)
+			summary.reclaimedBytes += reclaimed
 			if success {
 				service.output.Printf("%s Successfully updated %s\n", IconCheck(), displayID)
-				successCount++
+				summary.succeed(displayID)
+				verifier.recordAfter(internalID)
 			} else {
 				service.output.Printf("%s Failed to update %s\n", IconClose(), displayID)
-				failedCount++
-				allSuccess = false
+				summary.fail(displayID)
 			}
 		}
 
 		// Print summary
-		service.output.Printf("\nUpdate Summary:\n")
-		service.output.Printf("  Successfully updated: %d\n", successCount)
-		service.output.Printf("  Failed to update: %d\n", failedCount)
-
-		if allSuccess {
-			service.output.Printf("All packages updated successfully!\n")
-		} else {
-			service.output.Printf("Some packages failed to update.\n")
-		}
+		verifier.addTo(summary)
+		service.printSummary(summary)
+		commitRanges.print(service.output)
 	},
 }
 
 func init() {
 	updateCmd.Flags().BoolP("all", "A", false, "Update all installed packages to their latest versions")
 	updateCmd.Flags().Bool("self", false, "Update zana itself to the latest version")
+	updateCmd.Flags().Bool("only-patch", false, "With --all, only apply patch updates and hold back minor and major ones")
+	updateCmd.Flags().BoolVarP(&downloadConfirmYes, "yes", "y", false, "update without asking when the download is larger than install.confirmDownloadSize")
+	updateCmd.Flags().Bool("only-minor", false, "With --all, only apply patch and minor updates and hold back major ones")
+	updateCmd.Flags().BoolVar(&updateVerifyVersions, "verify-versions", false, "run the smoke test command (e.g. ruff --version) of packages that have one before and after updating them, and show the version change they report")
+	updateCmd.MarkFlagsMutuallyExclusive("only-patch", "only-minor")
 }
 
 // newUpdateService is a factory to allow test injection
@@ -299,6 +336,23 @@ var newUpdateService = NewUpdateService
 // UpdateAllPackages updates all installed packages to their latest versions
 // Only updates packages that have updates available according to the registry data
 func (us *UpdateService) UpdateAllPackages() bool {
+	return us.UpdateAllPackagesUpTo(versioncmp.BumpUnknown)
+}
+
+// heldBackUpdate is an update skipped because its version jump is larger
+// than allowed
+type heldBackUpdate struct {
+	sourceID string
+	current  string
+	latest   string
+	bump     versioncmp.Bump
+}
+
+// UpdateAllPackagesUpTo updates all installed packages whose update is at
+// most a maxBump sized version jump. Larger updates, and those whose size
+// can't be determined, are held back and listed for manual review.
+// BumpUnknown allows every update.
+func (us *UpdateService) UpdateAllPackagesUpTo(maxBump versioncmp.Bump) bool {
 	// Get all installed packages
 	localPackages := us.localPackages.GetData(true).Packages
 
@@ -308,78 +362,192 @@ func (us *UpdateService) UpdateAllPackages() bool {
 	}
 
 	us.output.Printf("Found %d installed packages\n", len(localPackages))
+	summary := newOperationSummary("Update", "update", "updated")
+	summary.skipReason = "up to date"
 
 	// Check which packages have updates available
 	packagesToUpdate := make([]local_packages_parser.LocalPackageItem, 0)
+	planned := make([]providers.PlannedDownload, 0)
+	heldBack := make([]heldBackUpdate, 0)
 	skippedCount := 0
 
 	for _, pkg := range localPackages {
-		hasUpdate := us.checkUpdateAvailability(pkg.SourceID, pkg.Version)
-		if hasUpdate {
-			packagesToUpdate = append(packagesToUpdate, pkg)
-		} else {
+		latestVersion, hasUpdate := us.availableUpdate(pkg.SourceID, pkg.Version)
+		if latestVersion == "" && pkg.Extras != nil && pkg.Extras.OverrideOf != "" {
+			latestVersion, hasUpdate = us.overrideUpdate(pkg.SourceID, pkg.Version)
+		}
+		if !hasUpdate {
 			skippedCount++
+			summary.skip(pkg.SourceID)
+			continue
+		}
+		if maxBump != versioncmp.BumpUnknown {
+			if bump := versioncmp.BumpBetween(pkg.Version, latestVersion); bump > maxBump {
+				heldBack = append(heldBack, heldBackUpdate{sourceID: pkg.SourceID, current: pkg.Version, latest: latestVersion, bump: bump})
+				continue
+			}
 		}
+		packagesToUpdate = append(packagesToUpdate, pkg)
+		planned = append(planned, providers.PlannedDownload{SourceID: pkg.SourceID, Version: latestVersion})
 	}
 
 	if len(packagesToUpdate) == 0 {
+		if len(heldBack) > 0 {
+			us.output.Printf("No updates within the %s level (%d package(s) up to date)\n", maxBump, skippedCount)
+			printHeldBackUpdates(us.output, heldBack)
+			return true
+		}
 		us.output.Printf("All %d packages are up to date\n", len(localPackages))
 		return true
 	}
 
+	if !confirmDownloadEstimate(planned, us.output.Printf) {
+		us.output.Println("Nothing updated.")
+		us.cancelled = true
+		return true
+	}
+
 	us.output.Printf("Updating %d package(s) with available updates (skipping %d up-to-date package(s))\n", len(packagesToUpdate), skippedCount)
 
-	allSuccess := true
-	successCount := 0
-	failedCount := 0
+	preflight := newProviderToolPreflight()
+	commitRanges := newGitCommitRanges()
+	verifier := newVersionVerifier()
 
 	for _, pkg := range packagesToUpdate {
+		if !preflight.check(pkg.SourceID, us.output.Printf) {
+			summary.fail(pkg.SourceID)
+			continue
+		}
+
 		// Update the package with spinner showing package name
+		verifier.recordBefore(pkg.SourceID)
 		var success bool
+		var reclaimed int64
+		var commits *providers.GitCommitRange
 		action := func() {
-			success = us.updatePackage(pkg.SourceID)
+			success, reclaimed, commits = us.updatePackage(pkg.SourceID)
 		}
 
 		title := fmt.Sprintf("Updating %s...", pkg.SourceID)
 		if err := spinnerutil.Run(title, action); err != nil {
 			us.output.Printf("%s Failed to update %s: %v\n", IconClose(), pkg.SourceID, err)
-			failedCount++
-			allSuccess = false
+			summary.fail(pkg.SourceID)
 			continue
 		}
 
+		commitRanges.add(pkg.SourceID, commits)
+		summary.reclaimedBytes += reclaimed
 		if success {
-			successCount++
+			summary.succeed(pkg.SourceID)
 			us.output.Printf("%s Successfully updated %s\n", IconCheck(), pkg.SourceID)
+			verifier.recordAfter(pkg.SourceID)
 		} else {
-			failedCount++
+			summary.fail(pkg.SourceID)
 			us.output.Printf("%s Failed to update %s\n", IconClose(), pkg.SourceID)
-			allSuccess = false
 		}
 	}
 
-	us.output.Printf("\nUpdate Summary:\n")
-	us.output.Printf("  Successfully updated: %d\n", successCount)
-	us.output.Printf("  Failed to update: %d\n", failedCount)
-	us.output.Printf("  Skipped (up to date): %d\n", skippedCount)
+	if len(heldBack) > 0 {
+		summary.details = append(summary.details, fmt.Sprintf("Held back: %d", len(heldBack)))
+	}
+	verifier.addTo(summary)
+	us.printSummary(summary)
+	commitRanges.print(us.output)
+	printHeldBackUpdates(us.output, heldBack)
 
-	return allSuccess
+	return summary.allSucceeded()
+}
+
+// printSummary prints the summary of an update, as JSON in JSON output
+func (us *UpdateService) printSummary(summary *operationSummary) {
+	if ShouldUseJSONOutput() {
+		PrintJSON(map[string]interface{}{
+			"success": summary.allSucceeded(),
+			"summary": summary.toJSON(),
+		})
+		return
+	}
+	summary.print(us.output.Printf)
+}
+
+// printHeldBackUpdates lists the updates skipped by --only-patch/--only-minor
+func printHeldBackUpdates(output OutputWriter, heldBack []heldBackUpdate) {
+	if len(heldBack) == 0 {
+		return
+	}
+	output.Printf("\n%s Held back for manual review:\n", IconAlert())
+	for _, u := range heldBack {
+		output.Printf("  %s %s -> %s (%s)\n", u.sourceID, u.current, u.latest, u.bump)
+	}
+	output.Printf("  Run 'zana update <package>' to update them.\n")
+}
+
+// gitCommitRanges collects the commit ranges git based packages moved across
+// during an update, for the update summary.
+type gitCommitRanges struct {
+	ids    []string
+	ranges []providers.GitCommitRange
+}
+
+func newGitCommitRanges() *gitCommitRanges {
+	return &gitCommitRanges{}
+}
+
+// add records the commit range the update of displayID returned, if any
+func (g *gitCommitRanges) add(displayID string, r *providers.GitCommitRange) {
+	if r != nil {
+		g.ids = append(g.ids, displayID)
+		g.ranges = append(g.ranges, *r)
+	}
+}
+
+func (g *gitCommitRanges) print(output OutputWriter) {
+	if len(g.ranges) == 0 {
+		return
+	}
+	output.Printf("  Git commits:\n")
+	for i, r := range g.ranges {
+		if r.Changed() {
+			output.Printf("    %s: %s\n", g.ids[i], r)
+		} else {
+			output.Printf("    %s: unchanged at %s\n", g.ids[i], r)
+		}
+	}
 }
 
 // checkUpdateAvailability checks if an update is available for a package
 func (us *UpdateService) checkUpdateAvailability(sourceID, currentVersion string) bool {
+	_, updateAvailable := us.availableUpdate(sourceID, currentVersion)
+	return updateAvailable
+}
+
+// availableUpdate returns the version a package would be updated to and
+// whether that is an update
+func (us *UpdateService) availableUpdate(sourceID, currentVersion string) (string, bool) {
 	stable, prerelease := us.registry.GetLatestVersions(sourceID)
 	if stable == "" && prerelease == "" {
 		// No registry info available - skip update check (conservative: don't update)
-		return false
+		return "", false
 	}
 	latestVersion := chooseBestRemoteVersion(currentVersion, stable, prerelease)
 	// If local version is unknown or set to "latest", always show update to the concrete remote version
 	if currentVersion == "" || currentVersion == "latest" {
-		return true
+		return latestVersion, true
 	}
 	updateAvailable, _ := us.updateChecker.CheckIfUpdateIsAvailable(currentVersion, latestVersion)
-	return updateAvailable
+	return latestVersion, updateAvailable
+}
+
+// overrideUpdate is availableUpdate for packages installed with
+// zana add --from, which the registry has no versions of: the latest
+// version comes from the override's provider
+func (us *UpdateService) overrideUpdate(sourceID, currentVersion string) (string, bool) {
+	latestVersion, err := resolveVersionFn(sourceID, "latest")
+	if err != nil || latestVersion == "" || latestVersion == "latest" {
+		return "", false
+	}
+	updateAvailable, _ := us.updateChecker.CheckIfUpdateIsAvailable(currentVersion, latestVersion)
+	return latestVersion, updateAvailable
 }
 
 // GitHubRelease represents a GitHub release
@@ -481,7 +649,7 @@ func downloadBinary(version, platform string) (string, error) {
 	downloadURL := fmt.Sprintf("https://github.com/mistweaverco/zana-client/releases/download/%s/%s", version, fileName)
 
 	// Create temporary file
-	tempFile, err := os.CreateTemp("", "zana-update-*")
+	tempFile, err := files.CreateTemp("update")
 	if err != nil {
 		return "", fmt.Errorf("failed to create temporary file: %w", err)
 	}
@@ -520,7 +688,7 @@ func downloadBinary(version, platform string) (string, error) {
 
 // backupCurrentBinary creates a backup of the current binary
 func backupCurrentBinary(binaryPath string) (string, error) {
-	timestamp := time.Now().Format("20060102_150405")
+	timestamp := clock.Now().Format("20060102_150405")
 	backupPath := fmt.Sprintf("%s.backup.%s", binaryPath, timestamp)
 
 	if err := copyFile(binaryPath, backupPath); err != nil {
@@ -591,7 +759,7 @@ func runSelfUpdate(output OutputWriter) error {
 	}
 
 	// Compare versions
-	if !semver.IsGreater(currentVersion, latestVersion) {
+	if !versioncmp.IsGreater(currentVersion, latestVersion) {
 		output.Printf("zana is already up to date (version %s)\n", currentVersion)
 		return nil
 	}
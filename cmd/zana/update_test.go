@@ -1,11 +1,13 @@
 package zana
 
 import (
+	"context"
 	"strings"
 	"testing"
 
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
 	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/mistweaverco/zana-client/internal/lib/versioncmp"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -41,12 +43,12 @@ func TestUpdateAllPackagesGolden(t *testing.T) {
 		// Set up mock provider factory for this test
 		mockFactory := &providers.MockProviderFactory{
 			MockNPMProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return true // All updates succeed
 				},
 			},
 			MockPyPIProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return true // All updates succeed
 				},
 			},
@@ -101,12 +103,12 @@ func TestUpdateAllPackagesGolden(t *testing.T) {
 		// Set up mock provider factory for this test
 		mockFactory := &providers.MockProviderFactory{
 			MockNPMProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return true // First package succeeds
 				},
 			},
 			MockPyPIProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return false // Second package fails
 				},
 			},
@@ -160,12 +162,12 @@ func TestUpdateAllPackagesGolden(t *testing.T) {
 		// Set up mock provider factory for this test
 		mockFactory := &providers.MockProviderFactory{
 			MockNPMProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return false // All updates fail
 				},
 			},
 			MockPyPIProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return false // All updates fail
 				},
 			},
@@ -279,7 +281,7 @@ func TestUpdateCommandRunPaths(t *testing.T) {
 		// Set up mock provider factory for this test
 		mockFactory := &providers.MockProviderFactory{
 			MockNPMProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return true // Update succeeds
 				},
 			},
@@ -299,12 +301,12 @@ func TestUpdateCommandRunPaths(t *testing.T) {
 		// Set up mock provider factory for this test
 		mockFactory := &providers.MockProviderFactory{
 			MockNPMProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return true // All updates succeed
 				},
 			},
 			MockPyPIProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return true // All updates succeed
 				},
 			},
@@ -381,17 +383,17 @@ func TestUpdateCommandFullOutputGolden(t *testing.T) {
 		// Set up mock provider factory for this test
 		mockFactory := &providers.MockProviderFactory{
 			MockNPMProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return true // First package succeeds
 				},
 			},
 			MockPyPIProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return false // Second package fails
 				},
 			},
 			MockGolangProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return true // Third package succeeds
 				},
 			},
@@ -446,12 +448,12 @@ func TestUpdateCommandFullOutputGolden(t *testing.T) {
 		// Set up mock provider factory for this test
 		mockFactory := &providers.MockProviderFactory{
 			MockNPMProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return false // All updates fail
 				},
 			},
 			MockPyPIProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return false // All updates fail
 				},
 			},
@@ -504,7 +506,7 @@ func TestUpdateCommandFullOutputGolden(t *testing.T) {
 		// Set up mock provider factory for this test
 		mockFactory := &providers.MockProviderFactory{
 			MockNPMProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return true // All updates succeed
 				},
 			},
@@ -555,7 +557,7 @@ func TestUpdateCommandFullOutputGolden(t *testing.T) {
 		// Set up mock provider factory for this test
 		mockFactory := &providers.MockProviderFactory{
 			MockNPMProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return false // Update fails
 				},
 			},
@@ -580,7 +582,7 @@ func TestUpdateCommandFullOutputGolden(t *testing.T) {
 		// Set up mock provider factory for this test
 		mockFactory := &providers.MockProviderFactory{
 			MockNPMProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return true // Update succeeds
 				},
 			},
@@ -605,12 +607,12 @@ func TestUpdateCommandFullOutputGolden(t *testing.T) {
 		// Set up mock provider factory for this test
 		mockFactory := &providers.MockProviderFactory{
 			MockNPMProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return true // First package succeeds
 				},
 			},
 			MockPyPIProvider: &providers.MockPackageManager{
-				UpdateFunc: func(sourceID string) bool {
+				UpdateFunc: func(_ context.Context, sourceID string) bool {
 					return false // Second package fails
 				},
 			},
@@ -633,3 +635,115 @@ func TestUpdateCommandFullOutputGolden(t *testing.T) {
 		assert.Contains(t, allOutput, "Some packages failed to update.")
 	})
 }
+
+func TestGitCommitRangesSummary(t *testing.T) {
+	ranges := newGitCommitRanges()
+	ranges.add("github:owner/repo", &providers.GitCommitRange{From: "1111111aaaa", To: "2222222bbbb"})
+	ranges.add("gitlab:group/project", &providers.GitCommitRange{From: "3333333cccc", To: "3333333cccc"})
+	ranges.add("npm:prettier", nil)
+
+	out := &MockOutputWriter{}
+	ranges.print(out)
+	assert.Equal(t, []string{
+		"  Git commits:\n",
+		"    github:owner/repo: 1111111..2222222\n",
+		"    gitlab:group/project: unchanged at 3333333\n",
+	}, out.Output)
+
+	out = &MockOutputWriter{}
+	newGitCommitRanges().print(out)
+	assert.Empty(t, out.Output)
+}
+
+func TestUpdateAllPackagesUpTo(t *testing.T) {
+	var updated []string
+	update := func(_ context.Context, sourceID string) bool {
+		updated = append(updated, sourceID)
+		return true
+	}
+	mockFactory := &providers.MockProviderFactory{
+		MockNPMProvider:   &providers.MockPackageManager{UpdateFunc: update},
+		MockPyPIProvider:  &providers.MockPackageManager{UpdateFunc: update},
+		MockCargoProvider: &providers.MockPackageManager{UpdateFunc: update},
+	}
+	providers.SetProviderFactory(mockFactory)
+	defer providers.ResetProviderFactory()
+
+	latest := map[string]string{
+		"npm:prettier":  "3.3.4",
+		"pypi:black":    "24.9.0",
+		"cargo:ripgrep": "15.0.0",
+	}
+	newService := func(out *MockOutputWriter) *UpdateService {
+		return NewUpdateServiceWithDependencies(
+			&MockLocalPackagesProvider{
+				GetDataFunc: func(force bool) local_packages_parser.LocalPackageRoot {
+					return local_packages_parser.LocalPackageRoot{Packages: []local_packages_parser.LocalPackageItem{
+						{SourceID: "npm:prettier", Version: "3.3.3"},
+						{SourceID: "pypi:black", Version: "24.8.0"},
+						{SourceID: "cargo:ripgrep", Version: "14.1.1"},
+					}}
+				},
+			},
+			&MockRegistryProvider{GetLatestVersionFunc: func(sourceID string) string { return latest[sourceID] }},
+			&MockUpdateChecker{CheckIfUpdateIsAvailableFunc: func(currentVersion, latestVersion string) (bool, string) {
+				return currentVersion != latestVersion, ""
+			}},
+			out,
+		)
+	}
+
+	t.Run("only patch", func(t *testing.T) {
+		updated = nil
+		out := &MockOutputWriter{}
+		assert.True(t, newService(out).UpdateAllPackagesUpTo(versioncmp.BumpPatch))
+		assert.Equal(t, []string{"npm:prettier"}, updated)
+		allOutput := strings.Join(out.Output, "")
+		assert.Contains(t, allOutput, "Held back: 2")
+		assert.Contains(t, allOutput, "pypi:black 24.8.0 -> 24.9.0 (minor)")
+		assert.Contains(t, allOutput, "cargo:ripgrep 14.1.1 -> 15.0.0 (major)")
+	})
+
+	t.Run("only minor", func(t *testing.T) {
+		updated = nil
+		out := &MockOutputWriter{}
+		assert.True(t, newService(out).UpdateAllPackagesUpTo(versioncmp.BumpMinor))
+		assert.Equal(t, []string{"npm:prettier", "pypi:black"}, updated)
+		allOutput := strings.Join(out.Output, "")
+		assert.Contains(t, allOutput, "Held back: 1")
+		assert.Contains(t, allOutput, "cargo:ripgrep 14.1.1 -> 15.0.0 (major)")
+		assert.NotContains(t, allOutput, "pypi:black 24.8.0 ->")
+	})
+
+	t.Run("no filter", func(t *testing.T) {
+		updated = nil
+		out := &MockOutputWriter{}
+		assert.True(t, newService(out).UpdateAllPackages())
+		assert.Len(t, updated, 3)
+		assert.NotContains(t, strings.Join(out.Output, ""), "Held back")
+	})
+
+	t.Run("only held back updates", func(t *testing.T) {
+		updated = nil
+		latest["npm:prettier"] = "3.3.3"
+		defer func() { latest["npm:prettier"] = "3.3.4" }()
+		out := &MockOutputWriter{}
+		assert.True(t, newService(out).UpdateAllPackagesUpTo(versioncmp.BumpPatch))
+		assert.Empty(t, updated)
+		allOutput := strings.Join(out.Output, "")
+		assert.Contains(t, allOutput, "No updates within the patch level (1 package(s) up to date)")
+		assert.Contains(t, allOutput, "Held back for manual review")
+	})
+
+	t.Run("flags require --all", func(t *testing.T) {
+		out := &MockOutputWriter{}
+		prev := newUpdateService
+		newUpdateService = func() *UpdateService { return newService(out) }
+		defer func() { newUpdateService = prev }()
+
+		updateCmd.Flags().Set("only-patch", "true")
+		defer updateCmd.Flags().Set("only-patch", "false")
+		updateCmd.Run(updateCmd, []string{"npm:prettier"})
+		assert.Contains(t, strings.Join(out.Output, ""), "--only-patch and --only-minor can only be used with --all")
+	})
+}
@@ -0,0 +1,82 @@
+package zana
+
+import (
+	"fmt"
+
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+)
+
+// updateVerifyVersions is --verify-versions
+var updateVerifyVersions bool
+
+// Injectable helpers for tests
+var (
+	reportedVersionFn  = providers.ReportedVersion
+	installedVersionFn = func(sourceID string) string {
+		return local_packages_parser.GetBySourceId(sourceID).Version
+	}
+)
+
+// versionVerifier runs the smoke test command of updated packages before and
+// after their update with --verify-versions, to show the version change the
+// packages actually report in the update summary
+type versionVerifier struct {
+	enabled bool
+	before  map[string]string
+	checks  []providers.VersionCheck
+}
+
+func newVersionVerifier() *versionVerifier {
+	return &versionVerifier{enabled: updateVerifyVersions, before: map[string]string{}}
+}
+
+// recordBefore records the version sourceID reports before its update
+func (v *versionVerifier) recordBefore(sourceID string) {
+	if !v.enabled {
+		return
+	}
+	if version, ok := reportedVersionFn(sourceID); ok {
+		v.before[sourceID] = version
+	}
+}
+
+// recordAfter compares the version sourceID reports after its update with
+// the one it reported before. Packages without smoke test are skipped.
+func (v *versionVerifier) recordAfter(sourceID string) {
+	if !v.enabled {
+		return
+	}
+	before, hadBefore := v.before[sourceID]
+	after, ok := reportedVersionFn(sourceID)
+	if !hadBefore && !ok {
+		return
+	}
+	v.checks = append(v.checks, providers.VersionCheck{
+		SourceID: sourceID,
+		Before:   before,
+		After:    after,
+		Expected: installedVersionFn(sourceID),
+	})
+}
+
+// addTo adds the reported version changes to the update summary, with a
+// hint to repair packages that still report their old version
+func (v *versionVerifier) addTo(summary *operationSummary) {
+	for _, c := range v.checks {
+		if c.Stale() {
+			after := c.After
+			if after == "" {
+				after = "no version"
+			}
+			summary.details = append(summary.details, fmt.Sprintf("%s %s still reports %s after the update to %s", IconAlert(), c.SourceID, after, c.Expected))
+			summary.hint("Run 'zana sync %s' to relink it", c.SourceID)
+			continue
+		}
+		before := c.Before
+		if before == "" {
+			before = "?"
+		}
+		summary.details = append(summary.details, fmt.Sprintf("%s reports %s -> %s", c.SourceID, before, c.After))
+	}
+}
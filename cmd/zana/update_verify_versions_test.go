@@ -0,0 +1,53 @@
+package zana
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionVerifier(t *testing.T) {
+	prevReported, prevInstalled, prevFlag := reportedVersionFn, installedVersionFn, updateVerifyVersions
+	t.Cleanup(func() {
+		reportedVersionFn, installedVersionFn, updateVerifyVersions = prevReported, prevInstalled, prevFlag
+	})
+
+	reported := map[string]string{"npm:pyright": "1.1.379", "golang:gopls": "0.15.0"}
+	var calls []string
+	reportedVersionFn = func(sourceID string) (string, bool) {
+		calls = append(calls, sourceID)
+		v, ok := reported[sourceID]
+		return v, ok
+	}
+	installedVersionFn = func(sourceID string) string {
+		return map[string]string{"npm:pyright": "1.1.380", "golang:gopls": "v0.16.0", "npm:prettier": "3.3.3"}[sourceID]
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		updateVerifyVersions = false
+		v := newVersionVerifier()
+		v.recordBefore("npm:pyright")
+		v.recordAfter("npm:pyright")
+		assert.Empty(t, calls)
+	})
+
+	t.Run("reports the version changes", func(t *testing.T) {
+		updateVerifyVersions = true
+		v := newVersionVerifier()
+		for _, id := range []string{"npm:pyright", "golang:gopls", "npm:prettier"} {
+			v.recordBefore(id)
+		}
+		reported["npm:pyright"] = "1.1.380"
+		for _, id := range []string{"npm:pyright", "golang:gopls", "npm:prettier"} {
+			v.recordAfter(id)
+		}
+
+		summary := newOperationSummary("Update", "update", "updated")
+		v.addTo(summary)
+		assert.Equal(t, []string{
+			"npm:pyright reports 1.1.379 -> 1.1.380",
+			IconAlert() + " golang:gopls still reports 0.15.0 after the update to v0.16.0",
+		}, summary.details, "packages without smoke test are left out")
+		assert.Equal(t, []string{"Run 'zana sync golang:gopls' to relink it"}, summary.hints)
+	})
+}
@@ -0,0 +1,133 @@
+package zana
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/metrics"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/spf13/cobra"
+)
+
+// indirections for testability
+var (
+	recordUsageFn = metrics.Record
+	usageModeFn   = metrics.Mode
+	loadUsageFn   = metrics.Load
+	shareUsageFn  = metrics.Share
+)
+
+// usageCommand is the command of this run counted by the usage metrics and
+// usageRecorded whether it was counted already
+var (
+	usageCommand  string
+	usageRecorded bool
+)
+
+// startUsageMetrics makes the run of cmd count in the usage metrics when
+// they're enabled. Commands exit directly on failure, so osExit counts the
+// failed runs and Execute the others. Shell completion runs on every tab
+// press and isn't counted.
+func startUsageMetrics(cmd *cobra.Command) {
+	usageCommand = ""
+	if isShellCompletionRequest(cmd) || usageModeFn() == files.MetricsModeOff {
+		return
+	}
+	usageCommand = usageCommandName(cmd)
+	usageRecorded = false
+	exit := osExit
+	osExit = func(code int) {
+		recordUsage(code != 0)
+		exit(code)
+	}
+}
+
+// usageCommandName returns the name cmd is counted as: its path without
+// "zana", and "plugin" for plugins, whose names may be private
+func usageCommandName(cmd *cobra.Command) string {
+	if _, ok := cmd.Annotations[pluginAnnotation]; ok {
+		return "plugin"
+	}
+	if name := strings.TrimSpace(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name())); name != "" {
+		return name
+	}
+	return cmd.Root().Name()
+}
+
+// recordUsage counts the run in the usage metrics, once, then shares them
+// when share mode is due to
+func recordUsage(failed bool) {
+	if usageCommand == "" || usageRecorded {
+		return
+	}
+	usageRecorded = true
+	if err := recordUsageFn(usageCommand, failed); err != nil {
+		providers.Logger.Error(fmt.Sprintf("Failed to record usage metrics: %v", err))
+		return
+	}
+	if err := shareUsageFn(); err != nil {
+		providers.Logger.Error(fmt.Sprintf("Failed to share usage metrics: %v", err))
+	}
+}
+
+// commandUsageJSON is a command in the zana stats --usage output
+type commandUsageJSON struct {
+	Command     string  `json:"command"`
+	Runs        int     `json:"runs"`
+	Failures    int     `json:"failures"`
+	FailureRate float64 `json:"failure_rate"`
+}
+
+// printUsageMetrics prints the usage metrics for zana stats --usage
+func printUsageMetrics() {
+	mode := usageModeFn()
+	usage, err := loadUsageFn()
+	if err != nil {
+		if ShouldUseJSONOutput() {
+			PrintJSON(map[string]interface{}{"success": false, "error": err.Error()})
+		} else {
+			fmt.Printf("%s %v\n", IconClose(), err)
+		}
+		osExit(1)
+		return
+	}
+	commands := []commandUsageJSON{}
+	for _, name := range usage.CommandNames() {
+		c := usage.Commands[name]
+		commands = append(commands, commandUsageJSON{Command: name, Runs: c.Runs, Failures: c.Failures, FailureRate: c.FailureRate()})
+	}
+	if ShouldUseJSONOutput() {
+		result := map[string]interface{}{
+			"mode":     mode,
+			"commands": commands,
+		}
+		if !usage.Since.IsZero() {
+			result["since"] = displayTime(usage.Since)
+		}
+		if !usage.LastShared.IsZero() {
+			result["last_shared"] = displayTime(usage.LastShared)
+		}
+		PrintJSON(result)
+		return
+	}
+
+	if mode == files.MetricsModeOff {
+		fmt.Printf("%s Usage metrics are off. Set metrics.mode to local in config.yaml to count command usage.\n", IconLightbulb())
+		if len(commands) == 0 {
+			return
+		}
+		fmt.Println()
+	}
+	if len(commands) == 0 {
+		fmt.Printf("%s No command runs counted yet (metrics mode: %s)\n", IconSummary(), mode)
+		return
+	}
+	fmt.Printf("%s Command usage since %s (metrics mode: %s)\n\n", IconSummary(), formatWhen(usage.Since), mode)
+	for _, c := range commands {
+		fmt.Printf("   %s: %d runs, %d failed (%.0f%%)\n", c.Command, c.Runs, c.Failures, c.FailureRate*100)
+	}
+	if !usage.LastShared.IsZero() {
+		fmt.Printf("\nLast shared: %s\n", formatWhen(usage.LastShared))
+	}
+}
@@ -0,0 +1,172 @@
+package zana
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/metrics"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedUsage struct {
+	command string
+	failed  bool
+}
+
+func stubUsageMetrics(t *testing.T, mode string, usage metrics.Usage) *[]recordedUsage {
+	t.Helper()
+	prevRecord, prevMode, prevLoad, prevShare, prevExit := recordUsageFn, usageModeFn, loadUsageFn, shareUsageFn, osExit
+	prevCommand, prevRecorded, prevStatsUsage := usageCommand, usageRecorded, statsUsage
+	t.Cleanup(func() {
+		recordUsageFn, usageModeFn, loadUsageFn, shareUsageFn, osExit = prevRecord, prevMode, prevLoad, prevShare, prevExit
+		usageCommand, usageRecorded, statsUsage = prevCommand, prevRecorded, prevStatsUsage
+	})
+	recorded := []recordedUsage{}
+	recordUsageFn = func(command string, failed bool) error {
+		recorded = append(recorded, recordedUsage{command, failed})
+		return nil
+	}
+	usageModeFn = func() string { return mode }
+	loadUsageFn = func() (metrics.Usage, error) { return usage, nil }
+	shareUsageFn = func() error { return nil }
+	osExit = func(int) {}
+	usageCommand = ""
+	return &recorded
+}
+
+func TestUsageCommandName(t *testing.T) {
+	assert.Equal(t, "sync packages", usageCommandName(syncPackagesCmd))
+	assert.Equal(t, "install", usageCommandName(installCmd))
+	assert.Equal(t, "zana", usageCommandName(rootCmd))
+	assert.Equal(t, "plugin", usageCommandName(newPluginCommand(plugin{Name: "secret", Path: "/bin/zana-secret"})), "plugin names aren't recorded")
+}
+
+func TestRecordUsage(t *testing.T) {
+	t.Run("counts failed runs once", func(t *testing.T) {
+		recorded := stubUsageMetrics(t, files.MetricsModeLocal, metrics.Usage{})
+
+		startUsageMetrics(syncPackagesCmd)
+		osExit(1)
+		recordUsage(false)
+
+		assert.Equal(t, []recordedUsage{{"sync packages", true}}, *recorded)
+	})
+
+	t.Run("counts successful runs", func(t *testing.T) {
+		recorded := stubUsageMetrics(t, files.MetricsModeShare, metrics.Usage{})
+
+		startUsageMetrics(installCmd)
+		recordUsage(false)
+
+		assert.Equal(t, []recordedUsage{{"install", false}}, *recorded)
+	})
+
+	t.Run("shares after counting", func(t *testing.T) {
+		recorded := stubUsageMetrics(t, files.MetricsModeShare, metrics.Usage{})
+		shareUsageFn = func() error {
+			*recorded = append(*recorded, recordedUsage{command: "share"})
+			return nil
+		}
+
+		startUsageMetrics(installCmd)
+		recordUsage(false)
+		recordUsage(false)
+
+		assert.Equal(t, []recordedUsage{{"install", false}, {command: "share"}}, *recorded)
+	})
+
+	t.Run("shell completion isn't counted", func(t *testing.T) {
+		recorded := stubUsageMetrics(t, files.MetricsModeShare, metrics.Usage{})
+		shareUsageFn = func() error {
+			*recorded = append(*recorded, recordedUsage{command: "share"})
+			return nil
+		}
+		startUsageMetrics(&cobra.Command{Use: cobra.ShellCompRequestCmd})
+		osExit(1)
+		recordUsage(false)
+
+		assert.Empty(t, *recorded)
+	})
+
+	t.Run("counts nothing when off", func(t *testing.T) {
+		recorded := stubUsageMetrics(t, files.MetricsModeOff, metrics.Usage{})
+
+		startUsageMetrics(installCmd)
+		osExit(1)
+		recordUsage(false)
+
+		assert.Empty(t, *recorded)
+	})
+}
+
+func TestStatsUsage(t *testing.T) {
+	usage := metrics.Usage{
+		Since: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		Commands: map[string]metrics.CommandUsage{
+			"install": {Runs: 4, Failures: 1},
+			"ls":      {Runs: 10},
+		},
+	}
+
+	t.Run("lists the commands by runs", func(t *testing.T) {
+		stubUsageMetrics(t, files.MetricsModeLocal, usage)
+		statsUsage = true
+
+		out := captureOutputWithMode(t, func() { statsCmd.Run(statsCmd, nil) }, config.OutputModePlain)
+
+		assert.Contains(t, out, "metrics mode: local")
+		assert.Contains(t, out, "ls: 10 runs, 0 failed (0%)")
+		assert.Contains(t, out, "install: 4 runs, 1 failed (25%)")
+		assert.Less(t, strings.Index(out, "ls: 10"), strings.Index(out, "install: 4"))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		stubUsageMetrics(t, files.MetricsModeLocal, usage)
+		statsUsage = true
+
+		out := captureOutputWithMode(t, func() { statsCmd.Run(statsCmd, nil) }, config.OutputModeJSON)
+
+		var result struct {
+			Mode     string             `json:"mode"`
+			Since    string             `json:"since"`
+			Commands []commandUsageJSON `json:"commands"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		assert.Equal(t, "local", result.Mode)
+		assert.Equal(t, "2026-10-01T12:00:00Z", result.Since)
+		assert.Equal(t, []commandUsageJSON{
+			{Command: "ls", Runs: 10},
+			{Command: "install", Runs: 4, Failures: 1, FailureRate: 0.25},
+		}, result.Commands)
+	})
+
+	t.Run("explains how to turn them on", func(t *testing.T) {
+		stubUsageMetrics(t, files.MetricsModeOff, metrics.Usage{Commands: map[string]metrics.CommandUsage{}})
+		statsUsage = true
+
+		out := captureOutputWithMode(t, func() { statsCmd.Run(statsCmd, nil) }, config.OutputModePlain)
+
+		assert.Contains(t, out, "Usage metrics are off. Set metrics.mode to local in config.yaml")
+		assert.NotContains(t, out, "Command usage since")
+	})
+}
+
+func TestStatsUsageIsReadOnly(t *testing.T) {
+	recorded := stubUsageMetrics(t, files.MetricsModeShare, metrics.Usage{})
+	shared := 0
+	shareUsageFn = func() error {
+		shared++
+		return nil
+	}
+
+	captureOutputWithMode(t, printUsageMetrics, config.OutputModePlain)
+
+	assert.Zero(t, shared)
+	assert.Empty(t, *recorded)
+}
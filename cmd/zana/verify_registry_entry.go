@@ -0,0 +1,137 @@
+package zana
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/spf13/cobra"
+)
+
+var verifyRegistryEntryCmd = &cobra.Command{
+	Use:   "verify-registry-entry <pkgId>[@version]",
+	Short: "Check the asset and bin templates of a registry entry against upstream",
+	Long: `Check the asset and bin templates of a registry entry against upstream.
+
+Resolves the entry for every target the way an install would (template
+expansion, platform matching, download URLs, bin paths) and checks each
+download URL with a HEAD request, without downloading anything. Helps to
+debug failing installs, and registry contributors to validate new entries
+before publishing them with --registry.
+
+Exits with 1 when the entry has errors, warnings alone don't fail.
+
+Examples:
+  zana verify-registry-entry github:sharkdp/bat
+  zana verify-registry-entry github:sharkdp/bat@v0.24.0
+  zana verify-registry-entry --registry ./entry.json github:owner/tool`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: packageIDCompletion,
+	Run: func(cmd *cobra.Command, args []string) {
+		item, version, err := resolveRegistryEntry(args[0])
+		if err != nil {
+			if ShouldUseJSONOutput() {
+				PrintJSON(map[string]interface{}{
+					"success": false,
+					"error":   err.Error(),
+				})
+			} else {
+				fmt.Printf("%s %v\n", IconClose(), err)
+			}
+			osExit(1)
+			return
+		}
+		report := verifyRegistryEntryFn(item, version)
+		if ShouldUseJSONOutput() {
+			PrintJSON(verifyRegistryEntryJSON{Success: !report.HasErrors(), EntryReport: report})
+		} else {
+			printEntryReport(report)
+		}
+		if report.HasErrors() {
+			osExit(1)
+		}
+	},
+}
+
+var verifyRegistryEntryRegistry string
+
+func init() {
+	verifyRegistryEntryCmd.Flags().StringVar(&verifyRegistryEntryRegistry, "registry", "", "registry file with the entry, instead of the downloaded registry")
+}
+
+// indirections for testability
+var verifyRegistryEntryFn = providers.VerifyRegistryEntry
+
+// verifyRegistryEntryJSON is the JSON output of zana verify-registry-entry
+type verifyRegistryEntryJSON struct {
+	Success bool `json:"success"`
+	providers.EntryReport
+}
+
+// resolveRegistryEntry returns the registry entry of userPkgID, from
+// --registry when given, and the version to verify it with
+func resolveRegistryEntry(userPkgID string) (registry_parser.RegistryItem, string, error) {
+	baseID, version := parsePackageIDAndVersion(userPkgID)
+	provider, pkgName, err := parseUserPackageID(baseID)
+	if err != nil {
+		return registry_parser.RegistryItem{}, "", err
+	}
+	internalID := toInternalPackageID(provider, pkgName)
+	if verifyRegistryEntryRegistry != "" {
+		_, registry, err := loadLocalRegistry(verifyRegistryEntryRegistry)
+		if err != nil {
+			return registry_parser.RegistryItem{}, "", err
+		}
+		item, ok := findRegistryEntry(registry, internalID)
+		if !ok {
+			return registry_parser.RegistryItem{}, "", fmt.Errorf("%s isn't in %s", internalID, verifyRegistryEntryRegistry)
+		}
+		return item, version, nil
+	}
+	_ = downloadAndUnzipRegistryFn()
+	item := newRegistryParser().GetBySourceId(internalID)
+	if item.Source.ID == "" {
+		return registry_parser.RegistryItem{}, "", fmt.Errorf("%s isn't in the registry", internalID)
+	}
+	return item, version, nil
+}
+
+func printEntryReport(report providers.EntryReport) {
+	fmt.Printf("%s@%s\n", report.SourceID, report.Version)
+	if len(report.URLs) > 0 {
+		fmt.Println("\nDownloads:")
+		for _, u := range report.URLs {
+			status := fmt.Sprintf("%d", u.Status)
+			if u.Error != "" {
+				status = "unchecked"
+			}
+			fmt.Printf("  %s %s (%s)\n", status, u.URL, strings.Join(u.Targets, ", "))
+		}
+	}
+	if len(report.Findings) > 0 {
+		fmt.Println("\nFindings:")
+		for _, f := range report.Findings {
+			icon := IconAlert()
+			if f.Severity == providers.SeverityError {
+				icon = IconClose()
+			}
+			if ShouldUsePlainOutput() {
+				icon = f.Severity + ":"
+			}
+			if f.Target != "" {
+				fmt.Printf("  %s [%s] %s\n", icon, f.Target, f.Message)
+			} else {
+				fmt.Printf("  %s %s\n", icon, f.Message)
+			}
+		}
+	}
+	switch {
+	case report.HasErrors():
+		fmt.Printf("\n%s %s has errors\n", IconClose(), report.SourceID)
+	case len(report.Findings) > 0:
+		fmt.Printf("\n%s %s resolves, with warnings\n", IconAlert(), report.SourceID)
+	default:
+		fmt.Printf("\n%s %s resolves for all targets\n", IconCheckCircle(), report.SourceID)
+	}
+}
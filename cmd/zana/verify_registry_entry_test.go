@@ -0,0 +1,89 @@
+package zana
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubVerifyRegistryEntry(t *testing.T, registry string, report providers.EntryReport) (*[]int, *registry_parser.RegistryItem, *string) {
+	t.Helper()
+	prevFn, prevRegistry, prevExit := verifyRegistryEntryFn, verifyRegistryEntryRegistry, osExit
+	t.Cleanup(func() { verifyRegistryEntryFn, verifyRegistryEntryRegistry, osExit = prevFn, prevRegistry, prevExit })
+
+	path := filepath.Join(t.TempDir(), "entry.json")
+	require.NoError(t, os.WriteFile(path, []byte(registry), 0644))
+	verifyRegistryEntryRegistry = path
+
+	var codes []int
+	osExit = func(code int) { codes = append(codes, code) }
+	var verified registry_parser.RegistryItem
+	var version string
+	verifyRegistryEntryFn = func(item registry_parser.RegistryItem, v string) providers.EntryReport {
+		verified, version = item, v
+		return report
+	}
+	return &codes, &verified, &version
+}
+
+const verifyRegistryEntryJSONEntry = `{"name": "tool", "version": "v1.0.0", "source": {"id": "github:owner/tool"}}`
+
+func TestVerifyRegistryEntryCommand(t *testing.T) {
+	t.Run("verifies the entry of the registry file", func(t *testing.T) {
+		codes, verified, version := stubVerifyRegistryEntry(t, verifyRegistryEntryJSONEntry, providers.EntryReport{
+			SourceID: "github:owner/tool",
+			Version:  "v1.1.0",
+			URLs:     []providers.EntryURL{{Targets: []string{"linux_x64"}, URL: "https://example.com/tool.tar.gz", Status: 200}},
+			Findings: []providers.EntryFinding{{Severity: providers.SeverityWarning, Target: "haiku_x64", Message: "no platform looks up this target"}},
+		})
+
+		output := captureOutputWithMode(t, func() {
+			verifyRegistryEntryCmd.Run(verifyRegistryEntryCmd, []string{"github:owner/tool@v1.1.0"})
+		}, config.OutputModePlain)
+
+		assert.Empty(t, *codes)
+		assert.Equal(t, "github:owner/tool", verified.Source.ID)
+		assert.Equal(t, "v1.1.0", *version)
+		assert.Contains(t, output, "200 https://example.com/tool.tar.gz (linux_x64)")
+		assert.Contains(t, output, "warning: [haiku_x64] no platform looks up this target")
+		assert.Contains(t, output, "github:owner/tool resolves, with warnings")
+	})
+
+	t.Run("exits with 1 on errors", func(t *testing.T) {
+		codes, _, _ := stubVerifyRegistryEntry(t, verifyRegistryEntryJSONEntry, providers.EntryReport{
+			SourceID: "github:owner/tool",
+			Version:  "v1.0.0",
+			URLs:     []providers.EntryURL{},
+			Findings: []providers.EntryFinding{{Severity: providers.SeverityError, Message: "asset 1 has no target"}},
+		})
+
+		output := captureOutputWithMode(t, func() {
+			verifyRegistryEntryCmd.Run(verifyRegistryEntryCmd, []string{"github:owner/tool"})
+		}, config.OutputModeJSON)
+
+		assert.Equal(t, []int{1}, *codes)
+		var result map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(output), &result))
+		assert.Equal(t, false, result["success"])
+		assert.Equal(t, "github:owner/tool", result["source_id"])
+		assert.Len(t, result["findings"], 1)
+	})
+
+	t.Run("fails for packages missing from the registry file", func(t *testing.T) {
+		codes, _, _ := stubVerifyRegistryEntry(t, verifyRegistryEntryJSONEntry, providers.EntryReport{})
+
+		output := captureOutputWithMode(t, func() {
+			verifyRegistryEntryCmd.Run(verifyRegistryEntryCmd, []string{"github:owner/other"})
+		}, config.OutputModePlain)
+
+		assert.Equal(t, []int{1}, *codes)
+		assert.Contains(t, output, "github:owner/other isn't in")
+	})
+}
@@ -1,6 +1,6 @@
 package zana
 
-import "github.com/mistweaverco/zana-client/internal/lib/semver"
+import "github.com/mistweaverco/zana-client/internal/lib/versioncmp"
 
 // chooseBestRemoteVersion picks the appropriate remote version given the
 // current local version, a stable version (if any) and a prerelease version
@@ -9,7 +9,7 @@ import "github.com/mistweaverco/zana-client/internal/lib/semver"
 // Rules:
 //   - If only one of stable or prerelease is set, that one is used.
 //   - If currentVersion has a non-numeric prerelease (dev/alpha/beta-style),
-//     we prefer the newer of (stable, prerelease) according to semver.
+//     we prefer the newer of (stable, prerelease) according to versioncmp.
 //   - Otherwise (local is stable or numeric-only prerelease), we prefer the
 //     stable version when available; if there is no stable version, we fall
 //     back to the prerelease.
@@ -28,9 +28,9 @@ func chooseBestRemoteVersion(currentVersion, stable, prerelease string) string {
 	// Both stable and prerelease exist.
 	// If current is clearly on a non-numeric prerelease track,
 	// pick whichever of (stable, prerelease) is greater.
-	if semver.IsNonNumericPreRelease(currentVersion) {
-		if semver.IsGreater(stable, prerelease) {
-			// semver.IsGreater(a, b) == true means b > a
+	if versioncmp.IsNonNumericPreRelease(currentVersion) {
+		if versioncmp.IsGreater(stable, prerelease) {
+			// versioncmp.IsGreater(a, b) == true means b > a
 			return prerelease
 		}
 		return stable
@@ -0,0 +1,237 @@
+package zana
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/spf13/cobra"
+)
+
+const watchServiceName = "zana-watch"
+const watchLaunchdLabel = "co.mistweaver.zana-watch"
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch zana-lock.json and sync packages when it changes",
+	Long: `Watch zana-lock.json and sync packages when it changes.
+
+This is useful when the lock file is shared between machines, e.g. with a dotfiles sync tool.
+Whenever the lock file changes, the providers of packages that were added or changed
+are synced, which installs them at their locked version, and packages that were removed
+are uninstalled. Changes are only applied after the file has not been modified for the
+debounce duration; packages that fail are retried after the next debounce duration.
+
+Use --install-service to run the watcher in the background:
+  Linux: writes a systemd user unit (~/.config/systemd/user/zana-watch.service)
+  macOS: writes a launchd agent (~/Library/LaunchAgents/co.mistweaver.zana-watch.plist)`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if watchInstallService {
+			installWatchService()
+			return
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		runWatch(ctx)
+	},
+}
+
+var (
+	watchInterval       time.Duration
+	watchDebounce       time.Duration
+	watchInstallService bool
+)
+
+func init() {
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", 2*time.Second, "how often to check zana-lock.json for changes")
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", 2*time.Second, "how long zana-lock.json must stay unchanged before syncing")
+	watchCmd.Flags().BoolVar(&watchInstallService, "install-service", false, "install a systemd (Linux) or launchd (macOS) user service running zana watch")
+}
+
+// runWatch watches the lock file until ctx is cancelled, logging every change
+func runWatch(ctx context.Context) {
+	logWatch("Watching %s for changes", zanaLockFilePathFn())
+	watchLockFileFn(ctx, providers.LockWatchOptions{
+		Interval: watchInterval,
+		Debounce: watchDebounce,
+		OnChange: func(change providers.LockChange) {
+			logWatch("zana-lock.json changed: %d added, %d updated, %d removed", len(change.Added), len(change.Updated), len(change.Removed))
+			for _, id := range change.Added {
+				logWatch("  + %s", id)
+			}
+			for _, id := range change.Updated {
+				logWatch("  ~ %s", id)
+			}
+			for _, id := range change.Removed {
+				logWatch("  - %s", id)
+			}
+		},
+		OnSynced: func(change providers.LockChange, result providers.LockSyncResult) {
+			if ShouldUseJSONOutput() {
+				_ = PrintJSON(map[string]interface{}{
+					"time":    clock.Now().Format(time.RFC3339),
+					"success": len(result.Failed) == 0,
+					"change":  change,
+					"failed":  result.Failed,
+				})
+				return
+			}
+			if len(result.Failed) == 0 {
+				logWatch("%s Sync complete", IconCheck())
+				return
+			}
+			for _, id := range result.Failed {
+				logWatch("%s Failed to sync %s", IconClose(), id)
+			}
+		},
+	})
+	logWatch("Stopped watching")
+}
+
+// logWatch prints a timestamped log line, suppressed in JSON output mode
+func logWatch(format string, args ...interface{}) {
+	if ShouldUseJSONOutput() {
+		return
+	}
+	fmt.Printf("%s %s\n", clock.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
+
+// installWatchService writes the service definition for the current OS
+// and prints how to enable it
+func installWatchService() {
+	home, err := watchUserHomeDir()
+	if err != nil {
+		printWatchServiceError(err)
+		return
+	}
+	executable, err := watchExecutable()
+	if err != nil {
+		printWatchServiceError(err)
+		return
+	}
+	path, content, enable, err := watchServiceDefinition(watchGOOS, home, executable, os.Getenv("ZANA_HOME"))
+	if err != nil {
+		printWatchServiceError(err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		printWatchServiceError(err)
+		return
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		printWatchServiceError(err)
+		return
+	}
+	if ShouldUseJSONOutput() {
+		_ = PrintJSON(map[string]interface{}{
+			"success": true,
+			"file":    path,
+			"enable":  enable,
+		})
+		return
+	}
+	fmt.Printf("%s Wrote %s\n", IconCheck(), path)
+	fmt.Println("Enable it with:")
+	fmt.Printf("  %s\n", enable)
+}
+
+func printWatchServiceError(err error) {
+	if ShouldUseJSONOutput() {
+		_ = PrintJSON(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+	} else {
+		fmt.Printf("%s Failed to install watch service: %v\n", IconClose(), err)
+	}
+	osExit(1)
+}
+
+// watchServiceDefinition returns the path and content of the service definition
+// running zana watch for goos, together with the command enabling it.
+// zanaHome is passed on to the service when set.
+func watchServiceDefinition(goos, home, executable, zanaHome string) (path, content, enable string, err error) {
+	switch goos {
+	case "linux":
+		var env string
+		if zanaHome != "" {
+			env = fmt.Sprintf("Environment=\"ZANA_HOME=%s\"\n", zanaHome)
+		}
+		path = filepath.Join(home, ".config", "systemd", "user", watchServiceName+".service")
+		content = fmt.Sprintf(`[Unit]
+Description=zana - sync packages when zana-lock.json changes
+
+[Service]
+Type=simple
+ExecStart="%s" watch
+%sRestart=on-failure
+RestartSec=10
+
+[Install]
+WantedBy=default.target
+`, executable, env)
+		enable = "systemctl --user daemon-reload && systemctl --user enable --now " + watchServiceName + ".service"
+		return path, content, enable, nil
+	case "darwin":
+		var env string
+		if zanaHome != "" {
+			env = fmt.Sprintf(`	<key>EnvironmentVariables</key>
+	<dict>
+		<key>ZANA_HOME</key>
+		<string>%s</string>
+	</dict>
+`, xmlEscape(zanaHome))
+		}
+		logPath := filepath.Join(home, "Library", "Logs", watchServiceName+".log")
+		path = filepath.Join(home, "Library", "LaunchAgents", watchLaunchdLabel+".plist")
+		content = fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>%s</string>
+		<string>watch</string>
+	</array>
+%s	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+	<key>StandardOutPath</key>
+	<string>%s</string>
+	<key>StandardErrorPath</key>
+	<string>%s</string>
+</dict>
+</plist>
+`, watchLaunchdLabel, xmlEscape(executable), env, xmlEscape(logPath), xmlEscape(logPath))
+		enable = "launchctl load -w " + path
+		return path, content, enable, nil
+	default:
+		return "", "", "", fmt.Errorf("service installation is not supported on %s", goos)
+	}
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
+
+// indirections for testability
+var (
+	watchLockFileFn    = providers.WatchLockFile
+	zanaLockFilePathFn = files.GetAppLocalPackagesFilePath
+	watchUserHomeDir   = os.UserHomeDir
+	watchExecutable    = os.Executable
+	watchGOOS          = runtime.GOOS
+)
@@ -0,0 +1,87 @@
+package zana
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchServiceDefinition(t *testing.T) {
+	t.Run("systemd unit on linux", func(t *testing.T) {
+		path, content, enable, err := watchServiceDefinition("linux", "/home/me", "/usr/local/bin/zana", "/data/zana")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join("/home/me", ".config", "systemd", "user", "zana-watch.service"), path)
+		assert.Contains(t, content, `ExecStart="/usr/local/bin/zana" watch`)
+		assert.Contains(t, content, `Environment="ZANA_HOME=/data/zana"`)
+		assert.Contains(t, content, "WantedBy=default.target")
+		assert.Contains(t, enable, "systemctl --user enable --now zana-watch.service")
+	})
+
+	t.Run("launchd agent on darwin", func(t *testing.T) {
+		path, content, enable, err := watchServiceDefinition("darwin", "/Users/me", "/opt/zana & co/zana", "")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join("/Users/me", "Library", "LaunchAgents", "co.mistweaver.zana-watch.plist"), path)
+		assert.Contains(t, content, "<string>/opt/zana &amp; co/zana</string>")
+		assert.Contains(t, content, "<string>watch</string>")
+		assert.NotContains(t, content, "ZANA_HOME")
+		assert.Equal(t, "launchctl load -w "+path, enable)
+	})
+
+	t.Run("unsupported OS", func(t *testing.T) {
+		_, _, _, err := watchServiceDefinition("windows", "C:\\Users\\me", "zana.exe", "")
+		assert.Error(t, err)
+	})
+}
+
+func TestWatchInstallService(t *testing.T) {
+	home := t.TempDir()
+	prevHome, prevExe, prevGOOS, prevExit := watchUserHomeDir, watchExecutable, watchGOOS, osExit
+	t.Cleanup(func() {
+		watchUserHomeDir, watchExecutable, watchGOOS, osExit = prevHome, prevExe, prevGOOS, prevExit
+	})
+	exitCodes := []int{}
+	osExit = func(code int) { exitCodes = append(exitCodes, code) }
+	watchUserHomeDir = func() (string, error) { return home, nil }
+	watchExecutable = func() (string, error) { return "/usr/bin/zana", nil }
+
+	watchGOOS = "linux"
+	out := captureOutput(t, installWatchService)
+	unit := filepath.Join(home, ".config", "systemd", "user", "zana-watch.service")
+	assert.Contains(t, out, "Wrote "+unit)
+	data, err := os.ReadFile(unit)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "/usr/bin/zana")
+	assert.Empty(t, exitCodes)
+
+	watchGOOS = "plan9"
+	watchExecutable = func() (string, error) { return "", errors.New("unused") }
+	out = captureOutput(t, installWatchService)
+	assert.Contains(t, out, "Failed to install watch service")
+	assert.Equal(t, []int{1}, exitCodes)
+}
+
+func TestRunWatchLogsChanges(t *testing.T) {
+	prevWatch := watchLockFileFn
+	t.Cleanup(func() { watchLockFileFn = prevWatch })
+	t.Cleanup(clock.Set(clock.NewFake(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))))
+	watchLockFileFn = func(ctx context.Context, opts providers.LockWatchOptions) {
+		change := providers.LockChange{Added: []string{"npm:prettier"}, Removed: []string{"pypi:black"}}
+		opts.OnChange(change)
+		opts.OnSynced(change, providers.LockSyncResult{Failed: []string{"npm:prettier"}})
+	}
+
+	out := captureOutput(t, func() { runWatch(context.Background()) })
+	assert.Contains(t, out, "2024-01-02T03:04:05Z zana-lock.json changed: 1 added, 0 updated, 1 removed")
+	assert.Contains(t, out, "  + npm:prettier")
+	assert.Contains(t, out, "  - pypi:black")
+	assert.Contains(t, out, "Failed to sync npm:prettier")
+	assert.Contains(t, out, "Stopped watching")
+}
@@ -0,0 +1,120 @@
+package zana
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/providers"
+	"github.com/spf13/cobra"
+)
+
+var whichCmd = &cobra.Command{
+	Use:   "which <pkgId>",
+	Short: "Show where an installed package's executables or data live",
+	Long: `Show where an installed package's executables or data live.
+
+Prints the paths of the package's executables in the zana bin directory.
+Data-only packages (fonts, dictionaries, grammars) have no executables,
+use --data to print the directory their content was installed into.
+For treesitter packages --data prints the compiled parsers.
+
+Examples:
+  zana which npm:prettier
+  zana which --data github:owner/fonts
+  zana which --data treesitter:tree-sitter/tree-sitter-lua`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sourceID, paths, err := whichPaths(args[0], whichData)
+		if err != nil {
+			if ShouldUseJSONOutput() {
+				PrintJSON(map[string]interface{}{
+					"success": false,
+					"error":   err.Error(),
+				})
+			} else {
+				fmt.Printf("%s %v\n", IconClose(), err)
+			}
+			osExit(1)
+			return
+		}
+		if ShouldUseJSONOutput() {
+			PrintJSON(map[string]interface{}{
+				"package": sourceID,
+				"paths":   paths,
+			})
+			return
+		}
+		for _, p := range paths {
+			fmt.Println(p)
+		}
+	},
+}
+
+var whichData bool
+
+func init() {
+	whichCmd.Flags().BoolVar(&whichData, "data", false, "print the content directory of a data-only package")
+}
+
+// indirections for testability
+var (
+	dataPackagePathFn    = providers.DataPackagePath
+	treeSitterParsersFn  = providers.TreeSitterParserPaths
+	binPathForProviderFn = files.GetAppBinPathForProvider
+)
+
+// whichPaths returns the executables of the installed package userPkgID,
+// or its content directory when data is set
+func whichPaths(userPkgID string, data bool) (string, []string, error) {
+	provider, pkgName, err := parseUserPackageID(userPkgID)
+	if err != nil {
+		return "", nil, err
+	}
+	sourceID := toInternalPackageID(provider, pkgName)
+	if !packageIsInstalled(sourceID) {
+		return sourceID, nil, fmt.Errorf("%s is not installed", sourceID)
+	}
+	item := newRegistryParser().GetBySourceId(sourceID)
+
+	if provider == "treesitter" {
+		if !data {
+			return sourceID, nil, fmt.Errorf("%s is a tree-sitter grammar without executables, use --data", sourceID)
+		}
+		paths := treeSitterParsersFn(sourceID)
+		if len(paths) == 0 {
+			return sourceID, nil, fmt.Errorf("%s has no compiled parsers, reinstall it", sourceID)
+		}
+		return sourceID, paths, nil
+	}
+
+	if data {
+		if !item.IsDataOnly() {
+			return sourceID, nil, fmt.Errorf("%s is not a data package", sourceID)
+		}
+		return sourceID, []string{dataPackagePathFn(sourceID)}, nil
+	}
+	if item.IsDataOnly() {
+		return sourceID, nil, fmt.Errorf("%s is a data package without executables, use --data", sourceID)
+	}
+
+	names := make([]string, 0, len(item.Bin))
+	for name := range item.Bin {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	binDir := binPathForProviderFn(provider)
+	paths := []string{}
+	for _, name := range names {
+		path := filepath.Join(binDir, name)
+		if _, err := os.Lstat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return sourceID, nil, fmt.Errorf("%s has no executables in %s", sourceID, binDir)
+	}
+	return sourceID, paths, nil
+}
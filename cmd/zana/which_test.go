@@ -0,0 +1,95 @@
+package zana
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/config"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubWhich(t *testing.T) (binDir string, codes *[]int) {
+	t.Helper()
+	prevParser, prevInstalled, prevData, prevBin, prevFlag, prevExit := newRegistryParser, packageIsInstalled, dataPackagePathFn, binPathForProviderFn, whichData, osExit
+	t.Cleanup(func() {
+		newRegistryParser, packageIsInstalled, dataPackagePathFn, binPathForProviderFn, whichData, osExit = prevParser, prevInstalled, prevData, prevBin, prevFlag, prevExit
+	})
+
+	newRegistryParser = func() *registry_parser.RegistryParser {
+		rp := registry_parser.NewRegistryParser(nil)
+		require.NoError(t, rp.LoadFromBytes([]byte(`[
+			{"name": "prettier", "source": {"id": "npm:prettier"}, "bin": {"prettier": "npm:prettier"}},
+			{"name": "fonts", "kind": "data", "source": {"id": "github:owner/fonts"}}
+		]`)))
+		return rp
+	}
+	packageIsInstalled = func(id string) bool { return id != "npm:eslint" }
+	dataPackagePathFn = func(id string) string { return "/data/share/" + id }
+	binDir = t.TempDir()
+	binPathForProviderFn = func(string) string { return binDir }
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "prettier"), []byte("#!/bin/sh\n"), 0755))
+
+	codes = &[]int{}
+	osExit = func(code int) { *codes = append(*codes, code) }
+	return binDir, codes
+}
+
+func TestWhichPaths(t *testing.T) {
+	binDir, _ := stubWhich(t)
+
+	id, paths, err := whichPaths("npm:prettier", false)
+	require.NoError(t, err)
+	assert.Equal(t, "npm:prettier", id)
+	assert.Equal(t, []string{filepath.Join(binDir, "prettier")}, paths)
+
+	_, paths, err = whichPaths("github:owner/fonts", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/data/share/github:owner/fonts"}, paths)
+
+	_, _, err = whichPaths("github:owner/fonts", false)
+	assert.EqualError(t, err, "github:owner/fonts is a data package without executables, use --data")
+	_, _, err = whichPaths("npm:prettier", true)
+	assert.EqualError(t, err, "npm:prettier is not a data package")
+	_, _, err = whichPaths("npm:eslint", false)
+	assert.EqualError(t, err, "npm:eslint is not installed")
+}
+
+func TestWhichCommand(t *testing.T) {
+	_, codes := stubWhich(t)
+	whichData = true
+
+	out := captureOutputWithMode(t, func() { whichCmd.Run(whichCmd, []string{"github:owner/fonts"}) }, config.OutputModeJSON)
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	assert.Equal(t, "github:owner/fonts", result["package"])
+	assert.Equal(t, []interface{}{"/data/share/github:owner/fonts"}, result["paths"])
+
+	out = captureOutput(t, func() { whichCmd.Run(whichCmd, []string{"npm:prettier"}) })
+	assert.Contains(t, out, "npm:prettier is not a data package")
+	assert.Equal(t, []int{1}, *codes)
+}
+
+func TestWhichPathsTreeSitter(t *testing.T) {
+	stubWhich(t)
+	prev := treeSitterParsersFn
+	t.Cleanup(func() { treeSitterParsersFn = prev })
+	treeSitterParsersFn = func(id string) []string {
+		if id == "treesitter:tree-sitter/tree-sitter-lua" {
+			return []string{"/data/share/tree-sitter/parser/lua.so"}
+		}
+		return nil
+	}
+
+	_, paths, err := whichPaths("treesitter:tree-sitter/tree-sitter-lua", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/data/share/tree-sitter/parser/lua.so"}, paths)
+
+	_, _, err = whichPaths("treesitter:tree-sitter/tree-sitter-lua", false)
+	assert.EqualError(t, err, "treesitter:tree-sitter/tree-sitter-lua is a tree-sitter grammar without executables, use --data")
+	_, _, err = whichPaths("treesitter:tree-sitter/tree-sitter-c", true)
+	assert.EqualError(t, err, "treesitter:tree-sitter/tree-sitter-c has no compiled parsers, reinstall it")
+}
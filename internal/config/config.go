@@ -74,6 +74,10 @@ type ConfigFlags struct {
 	CacheMaxAge time.Duration
 	Color       ColorMode
 	Output      OutputMode
+	// UTC shows times in UTC instead of the local time zone
+	UTC bool
+	// ISO shows absolute RFC 3339 times instead of relative ones
+	ISO bool
 }
 
 type Config struct {
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/mistweaverco/zana-client/internal/lib/files"
@@ -9,7 +11,7 @@ import (
 )
 
 // FileConfig represents the optional user config.yaml file.
-// It lives next to zana-lock.json in the Zana config directory.
+// See files.GetConfigFilePath for where it is looked up.
 type FileConfig struct {
 	Registry struct {
 		URLs        []string `yaml:"urls"`
@@ -17,9 +19,17 @@ type FileConfig struct {
 	} `yaml:"registry"`
 
 	Paths struct {
-		CacheDir string `yaml:"cacheDir"`
+		CacheDir         string                           `yaml:"cacheDir"`
+		TempDir          string                           `yaml:"tempDir"`
+		BinLayout        string                           `yaml:"binLayout"`
+		BinProviderOrder []string                         `yaml:"binProviderOrder"`
+		BinFilters       map[string]files.BinFilterConfig `yaml:"binFilters"`
 	} `yaml:"paths"`
 
+	Install struct {
+		ProviderPriority []string `yaml:"providerPriority"`
+	} `yaml:"install"`
+
 	UI struct {
 		Color  string `yaml:"color"`
 		Output string `yaml:"output"`
@@ -27,7 +37,7 @@ type FileConfig struct {
 }
 
 func ConfigFilePath() string {
-	return files.GetAppDataPath() + string(os.PathSeparator) + "config.yaml"
+	return files.GetConfigFilePath()
 }
 
 // LoadFileConfig reads config.yaml. If the file doesn't exist, it returns (zeroValue, false, nil).
@@ -61,3 +71,62 @@ func (fc FileConfig) RegistryCacheMaxAgeOrZero() time.Duration {
 	}
 	return d
 }
+
+// SetFileConfigValue sets section.key in config.yaml to value, creating the
+// file if needed. The rest of the file, comments included, is kept.
+func SetFileConfigValue(section, key, value string) error {
+	path := ConfigFilePath()
+	var doc yaml.Node
+	b, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(b) > 0 {
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return err
+		}
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s isn't a mapping", path)
+	}
+	sectionNode := mappingValue(root, section)
+	if sectionNode == nil || sectionNode.Kind != yaml.MappingNode {
+		sectionNode = &yaml.Node{Kind: yaml.MappingNode}
+		setMappingValue(root, section, sectionNode)
+	}
+	setMappingValue(sectionNode, key, &yaml.Node{Kind: yaml.ScalarNode, Value: value})
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, 0644)
+}
+
+// mappingValue returns the value of key in mapping, or nil
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue replaces the value of key in mapping, or appends it
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetFileConfigValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zana", "config.yaml")
+	t.Setenv("ZANA_CONFIG", path)
+
+	require.NoError(t, SetFileConfigValue("ui", "output", "plain"))
+	cfg, ok, err := LoadFileConfig()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "plain", cfg.UI.Output)
+
+	require.NoError(t, os.WriteFile(path, []byte("# my zana setup\nregistry:\n  cacheMaxAge: 1h # hourly\nui:\n  color: never\n  output: rich\n"), 0644))
+	require.NoError(t, SetFileConfigValue("ui", "output", "json"))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# my zana setup")
+	assert.Contains(t, string(data), "cacheMaxAge: 1h # hourly")
+	cfg, _, err = LoadFileConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "json", cfg.UI.Output)
+	assert.Equal(t, "never", cfg.UI.Color)
+}
@@ -0,0 +1,135 @@
+// Package chaos injects failures into downloads, subprocesses and archive
+// extraction, so rollback and retry paths can be exercised end to end.
+// It is off unless enabled with the hidden --chaos <seed> flag.
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+var (
+	mu  sync.Mutex
+	rng *rand.Rand
+	// rate is the probability that an injection point fails while chaos is enabled
+	rate = 0.25
+)
+
+// InjectedError is returned by operations that chaos made fail
+type InjectedError struct {
+	What string
+}
+
+func (e *InjectedError) Error() string {
+	return fmt.Sprintf("chaos: injected failure of %s", e.What)
+}
+
+// IsInjected reports whether err is, or wraps, an injected failure
+func IsInjected(err error) bool {
+	var injected *InjectedError
+	return errors.As(err, &injected)
+}
+
+// Enable turns on failure injection. A seed fails the same injection
+// points every time, as long as operations run in the same order.
+func Enable(seed int64) {
+	mu.Lock()
+	defer mu.Unlock()
+	rng = rand.New(rand.NewSource(seed))
+}
+
+// Disable turns failure injection off
+func Disable() {
+	mu.Lock()
+	defer mu.Unlock()
+	rng = nil
+}
+
+// Enabled reports whether failure injection is on
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return rng != nil
+}
+
+// roll decides whether the current injection point fails and, if so,
+// also picks a number in [0, n) to vary how it fails
+func roll(n int) (int, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	if rng == nil || rng.Float64() >= rate {
+		return 0, false
+	}
+	return rng.Intn(n), true
+}
+
+// Fail returns an *InjectedError when the operation described by what
+// is picked to fail, nil otherwise
+func Fail(what string) error {
+	if _, fail := roll(1); fail {
+		return &InjectedError{What: what}
+	}
+	return nil
+}
+
+// Reader wraps r so that, when picked to fail, reading breaks off
+// with an *InjectedError after a few kilobytes at most
+func Reader(r io.Reader, what string) io.Reader {
+	after, fail := roll(4096)
+	if !fail {
+		return r
+	}
+	return &failingReader{r: r, left: int64(after), what: what}
+}
+
+type failingReader struct {
+	r    io.Reader
+	left int64
+	what string
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if f.left <= 0 {
+		return 0, &InjectedError{What: f.what}
+	}
+	if int64(len(p)) > f.left {
+		p = p[:f.left]
+	}
+	n, err := f.r.Read(p)
+	f.left -= int64(n)
+	return n, err
+}
+
+// PartialExtraction removes one file from the extracted archive in dir
+// when picked to fail, like an extraction that stopped halfway.
+// It returns the removed path relative to dir, or "" when nothing was removed.
+func PartialExtraction(dir string) string {
+	pick, fail := roll(1 << 30)
+	if !fail {
+		return ""
+	}
+	var regular []string
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			regular = append(regular, path)
+		}
+		return nil
+	})
+	if len(regular) == 0 {
+		return ""
+	}
+	sort.Strings(regular)
+	victim := regular[pick%len(regular)]
+	if err := os.Remove(victim); err != nil {
+		return ""
+	}
+	rel, _ := filepath.Rel(dir, victim)
+	return rel
+}
@@ -0,0 +1,82 @@
+package chaos
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withRate(t *testing.T, r float64) {
+	t.Helper()
+	prev := rate
+	t.Cleanup(func() {
+		rate = prev
+		Disable()
+	})
+	rate = r
+}
+
+func failures(n int) []bool {
+	out := make([]bool, n)
+	for i := range out {
+		out[i] = Fail("op") != nil
+	}
+	return out
+}
+
+func TestDisabledNeverFails(t *testing.T) {
+	withRate(t, 1)
+	Disable()
+	assert.False(t, Enabled())
+	assert.NoError(t, Fail("npm"))
+	data := []byte("payload")
+	assert.Equal(t, io.Reader(bytes.NewReader(data)), Reader(bytes.NewReader(data), "download"))
+	assert.Empty(t, PartialExtraction(t.TempDir()))
+}
+
+func TestSameSeedFailsTheSamePoints(t *testing.T) {
+	withRate(t, 0.5)
+	Enable(42)
+	first := failures(32)
+	Enable(42)
+	assert.Equal(t, first, failures(32))
+	assert.Contains(t, first, true)
+	assert.Contains(t, first, false)
+}
+
+func TestFailReturnsInjectedError(t *testing.T) {
+	withRate(t, 1)
+	Enable(1)
+	err := Fail("cargo")
+	require.Error(t, err)
+	assert.True(t, IsInjected(err))
+	assert.EqualError(t, err, "chaos: injected failure of cargo")
+}
+
+func TestReaderBreaksOff(t *testing.T) {
+	withRate(t, 1)
+	Enable(7)
+	data := bytes.Repeat([]byte("x"), 8192)
+	n, err := io.Copy(io.Discard, Reader(bytes.NewReader(data), "download of x"))
+	assert.True(t, IsInjected(err))
+	assert.Less(t, n, int64(len(data)))
+}
+
+func TestPartialExtractionRemovesAFile(t *testing.T) {
+	withRate(t, 1)
+	Enable(3)
+	dir := t.TempDir()
+	for _, name := range []string{"bin/tool", "README.md", "LICENSE"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0644))
+	}
+
+	removed := PartialExtraction(dir)
+	require.NotEmpty(t, removed)
+	assert.NoFileExists(t, filepath.Join(dir, removed))
+}
@@ -0,0 +1,116 @@
+// Package clock is the seam through which zana reads the current time,
+// sleeps and draws random numbers. Tests replace the clock with a Fake, so
+// TTL, jitter and backoff logic is deterministic and verifiable without
+// sleeping.
+package clock
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Clock provides time and randomness
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	// Int63n returns a random number in [0, n), n must be positive
+	Int63n(n int64) int64
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time        { return time.Now() }
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
+func (realClock) Int63n(n int64) int64  { return rand.Int63n(n) }
+
+type holder struct{ clock Clock }
+
+var current atomic.Pointer[holder]
+
+func init() {
+	current.Store(&holder{realClock{}})
+}
+
+// Set replaces the clock and returns a function restoring the previous one,
+// e.g. t.Cleanup(clock.Set(fake))
+func Set(c Clock) (restore func()) {
+	previous := current.Swap(&holder{c})
+	return func() { current.Store(previous) }
+}
+
+// Now returns the current time
+func Now() time.Time {
+	return current.Load().clock.Now()
+}
+
+// Since returns the time elapsed since t
+func Since(t time.Time) time.Duration {
+	return Now().Sub(t)
+}
+
+// Sleep pauses the calling goroutine for at least d
+func Sleep(d time.Duration) {
+	current.Load().clock.Sleep(d)
+}
+
+// Jitter returns a random duration in [0, max), 0 when max isn't positive
+func Jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(current.Load().clock.Int63n(int64(max)))
+}
+
+// Fake is a Clock for tests. Time only moves through Sleep, Advance and Step,
+// and random numbers come from a fixed seed unless RandFunc is set.
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+	rng *rand.Rand
+	// Step advances the time after every call to Now, for code measuring
+	// durations between two calls
+	Step time.Duration
+	// RandFunc, when set, replaces the seeded random numbers
+	RandFunc func(n int64) int64
+	// Slept records the durations passed to Sleep
+	Slept []time.Duration
+}
+
+// NewFake returns a Fake clock starting at now
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now, rng: rand.New(rand.NewSource(1))}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now
+	f.now = f.now.Add(f.Step)
+	return now
+}
+
+// Sleep advances the time by d instead of sleeping
+func (f *Fake) Sleep(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Slept = append(f.Slept, d)
+	f.now = f.now.Add(d)
+}
+
+// Advance moves the time forward by d
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func (f *Fake) Int63n(n int64) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.RandFunc != nil {
+		return f.RandFunc(n)
+	}
+	return f.rng.Int63n(n)
+}
@@ -0,0 +1,40 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	fake := NewFake(start)
+	t.Cleanup(Set(fake))
+
+	assert.Equal(t, start, Now())
+	Sleep(time.Minute)
+	fake.Advance(time.Hour)
+	assert.Equal(t, time.Hour+time.Minute, Since(start))
+	assert.Equal(t, []time.Duration{time.Minute}, fake.Slept)
+
+	fake.Step = time.Second
+	first := Now()
+	assert.Equal(t, time.Second, Now().Sub(first))
+
+	for range 100 {
+		j := Jitter(time.Minute)
+		assert.True(t, j >= 0 && j < time.Minute)
+	}
+	assert.Equal(t, time.Duration(0), Jitter(0))
+
+	fake.RandFunc = func(n int64) int64 { return n - 1 }
+	assert.Equal(t, time.Minute-1, Jitter(time.Minute))
+}
+
+func TestSetRestores(t *testing.T) {
+	restore := Set(NewFake(time.Unix(0, 0)))
+	assert.Equal(t, time.Unix(0, 0), Now())
+	restore()
+	assert.WithinDuration(t, time.Now(), Now(), time.Minute)
+}
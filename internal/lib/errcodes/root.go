@@ -0,0 +1,129 @@
+// Package errcodes is the catalog of the error codes zana reports in its JSON
+// output, so wrappers can tell failures apart without parsing messages.
+// Codes are stable: new ones may be added, existing ones aren't renamed or
+// removed.
+package errcodes
+
+import (
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+)
+
+// Code identifies a kind of failure, e.g. network
+type Code string
+
+const (
+	// Failed is a failure without a more specific code
+	Failed              Code = "failed"
+	Network             Code = "network"
+	AssetNotFound       Code = "asset-not-found"
+	ToolMissing         Code = "tool-missing"
+	Conflict            Code = "conflict"
+	Permission          Code = "permission"
+	PackageNotFound     Code = "package-not-found"
+	UnsupportedPlatform Code = "unsupported-platform"
+)
+
+// Entry describes a code of the catalog
+type Entry struct {
+	Code        Code   `json:"code"`
+	Description string `json:"description"`
+	Remediation string `json:"remediation"`
+}
+
+var catalog = []Entry{
+	{
+		Code:        Network,
+		Description: "A request to the registry or a package service failed: no connection, DNS, TLS or a timeout.",
+		Remediation: "Check the connection and proxy settings (HTTPS_PROXY), then retry. 'zana health --network' shows which services are reachable.",
+	},
+	{
+		Code:        AssetNotFound,
+		Description: "The release has no asset for this version and platform, or the release doesn't exist.",
+		Remediation: "Install another version with <pkgId>@<version>, or retry with --refresh in case the registry renamed the asset since.",
+	},
+	{
+		Code:        ToolMissing,
+		Description: "The tool the package's provider installs with (e.g. npm, pip3, go, cargo) isn't on PATH.",
+		Remediation: "Install the tool, or run 'zana health --fix' to install it with the system package manager.",
+	},
+	{
+		Code:        Conflict,
+		Description: "A file zana would create exists already and doesn't belong to zana, e.g. an executable in the bin directory.",
+		Remediation: "Move the existing file out of the way, or change paths.binLayout to keep the executables apart.",
+	},
+	{
+		Code:        Permission,
+		Description: "zana isn't allowed to write to its data, cache or bin directory.",
+		Remediation: "Fix the ownership or permissions of the directory, or point ZANA_HOME and ZANA_CACHE to writable ones.",
+	},
+	{
+		Code:        PackageNotFound,
+		Description: "The package or version isn't in the registry or in the given lock file.",
+		Remediation: "Check the package ID with 'zana ls --all <name>', and retry with --refresh for recently added packages.",
+	},
+	{
+		Code:        UnsupportedPlatform,
+		Description: "The registry doesn't offer the package for this operating system and architecture.",
+		Remediation: "Install one of the alternatives listed, or pass --force to try anyway.",
+	},
+	{
+		Code:        Failed,
+		Description: "The operation failed for a reason without a more specific code.",
+		Remediation: "Run 'zana logs -p <pkgId>' to see why.",
+	},
+}
+
+// Catalog returns all codes with their description and remediation
+func Catalog() []Entry {
+	return append([]Entry(nil), catalog...)
+}
+
+// Lookup returns the catalog entry of code
+func Lookup(code Code) (Entry, bool) {
+	for _, entry := range catalog {
+		if entry.Code == code {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Error is an error carrying its code
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+func (e *Error) Unwrap() error { return e.Err }
+
+// New wraps err with code
+func New(code Code, err error) error {
+	return &Error{Code: code, Err: err}
+}
+
+// Classify returns the code of err: the one it was wrapped with by New, or
+// the one its cause implies, Failed otherwise. It returns "" for nil.
+func Classify(err error) Code {
+	if err == nil {
+		return ""
+	}
+	var coded *Error
+	var netErr net.Error
+	switch {
+	case errors.As(err, &coded):
+		return coded.Code
+	case errors.Is(err, os.ErrPermission):
+		return Permission
+	case errors.Is(err, os.ErrExist):
+		return Conflict
+	case errors.Is(err, exec.ErrNotFound):
+		return ToolMissing
+	case errors.As(err, &netErr):
+		return Network
+	}
+	return Failed
+}
@@ -0,0 +1,41 @@
+package errcodes
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalog(t *testing.T) {
+	seen := map[Code]bool{}
+	for _, entry := range Catalog() {
+		assert.False(t, seen[entry.Code], "%s is listed once", entry.Code)
+		seen[entry.Code] = true
+		assert.NotEmpty(t, entry.Description, entry.Code)
+		assert.NotEmpty(t, entry.Remediation, entry.Code)
+	}
+	for _, code := range []Code{Failed, Network, AssetNotFound, ToolMissing, Conflict, Permission, PackageNotFound, UnsupportedPlatform} {
+		assert.True(t, seen[code], "%s is in the catalog", code)
+	}
+
+	entry, ok := Lookup(Network)
+	assert.True(t, ok)
+	assert.Equal(t, Network, entry.Code)
+	_, ok = Lookup("nope")
+	assert.False(t, ok)
+}
+
+func TestClassify(t *testing.T) {
+	assert.Equal(t, Code(""), Classify(nil))
+	assert.Equal(t, AssetNotFound, Classify(fmt.Errorf("download: %w", New(AssetNotFound, errors.New("HTTP error: 404")))))
+	assert.Equal(t, Permission, Classify(&os.PathError{Op: "mkdir", Path: "/zana", Err: os.ErrPermission}))
+	assert.Equal(t, Conflict, Classify(fmt.Errorf("link: %w", os.ErrExist)))
+	assert.Equal(t, ToolMissing, Classify(&exec.Error{Name: "npm", Err: exec.ErrNotFound}))
+	assert.Equal(t, Network, Classify(&net.DNSError{Err: "no such host", Name: "github.com"}))
+	assert.Equal(t, Failed, Classify(errors.New("checksum mismatch")))
+}
@@ -0,0 +1,185 @@
+// Package events publishes structured progress of package operations, so
+// integrations (editors, GUIs) don't have to parse the CLI's output.
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+)
+
+// Kind is the type of an event
+type Kind string
+
+const (
+	// KindProgress reports downloaded bytes
+	KindProgress Kind = "progress"
+	// KindLog carries a log record
+	KindLog Kind = "log"
+	// KindStateChange reports a phase transition of an operation
+	KindStateChange Kind = "state"
+)
+
+// States of an operation reported with KindStateChange
+const (
+	StateStarted     = "started"
+	StateDownloading = "downloading"
+	StateExtracting  = "extracting"
+	StateDone        = "done"
+	StateFailed      = "failed"
+)
+
+// Event is a single event. Which fields are set depends on Kind;
+// the operation fields are set for everything happening during a package operation.
+type Event struct {
+	Kind        Kind      `json:"kind"`
+	Time        time.Time `json:"time"`
+	OperationID string    `json:"op,omitempty"`
+	Action      string    `json:"action,omitempty"`
+	Package     string    `json:"package,omitempty"`
+
+	// State is set for KindStateChange
+	State string `json:"state,omitempty"`
+
+	// URL, BytesDone and BytesTotal are set for KindProgress.
+	// BytesTotal is -1 when the size is unknown.
+	URL        string `json:"url,omitempty"`
+	BytesDone  int64  `json:"bytes_done,omitempty"`
+	BytesTotal int64  `json:"bytes_total,omitempty"`
+
+	// Level and Message are set for KindLog
+	Level   string `json:"level,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Handlers receive the events of their kind; nil handlers are skipped
+type Handlers struct {
+	OnProgress    func(Event)
+	OnLog         func(Event)
+	OnStateChange func(Event)
+}
+
+func (h Handlers) dispatch(e Event) {
+	var fn func(Event)
+	switch e.Kind {
+	case KindProgress:
+		fn = h.OnProgress
+	case KindLog:
+		fn = h.OnLog
+	case KindStateChange:
+		fn = h.OnStateChange
+	}
+	if fn != nil {
+		fn(e)
+	}
+}
+
+var (
+	mu       sync.RWMutex
+	watchers = map[int]Handlers{}
+	nextID   int
+)
+
+// Watch registers handlers and returns a function unregistering them.
+// Handlers are called synchronously on the goroutine publishing the event,
+// which may be several at once when packages are installed in parallel:
+// they must be safe for concurrent use and return quickly. Use Subscribe
+// to receive the events on a channel instead.
+func Watch(h Handlers) func() {
+	mu.Lock()
+	defer mu.Unlock()
+	id := nextID
+	nextID++
+	watchers[id] = h
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(watchers, id)
+	}
+}
+
+// Active reports whether anyone is watching, so publishers can skip building events
+func Active() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return len(watchers) > 0
+}
+
+// Publish delivers e to all watchers, setting its time if unset
+func Publish(e Event) {
+	mu.RLock()
+	handlers := make([]Handlers, 0, len(watchers))
+	for _, h := range watchers {
+		handlers = append(handlers, h)
+	}
+	mu.RUnlock()
+	if len(handlers) == 0 {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = clock.Now()
+	}
+	for _, h := range handlers {
+		h.dispatch(e)
+	}
+}
+
+// Subscribe returns a channel receiving all events in publish order and a
+// function unsubscribing, which closes the channel. Events are queued without
+// limit, so publishers never block on a slow reader and nothing is dropped.
+func Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event)
+	var (
+		qmu    sync.Mutex
+		queue  []Event
+		closed bool
+	)
+	wake := make(chan struct{}, 1)
+	enqueue := func(e Event) {
+		qmu.Lock()
+		if !closed {
+			queue = append(queue, e)
+		}
+		qmu.Unlock()
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+	unwatch := Watch(Handlers{OnProgress: enqueue, OnLog: enqueue, OnStateChange: enqueue})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(ch)
+		for {
+			qmu.Lock()
+			pending := queue
+			queue = nil
+			qmu.Unlock()
+			for _, e := range pending {
+				select {
+				case ch <- e:
+				case <-done:
+					return
+				}
+			}
+			select {
+			case <-wake:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			unwatch()
+			qmu.Lock()
+			closed = true
+			qmu.Unlock()
+			close(done)
+		})
+	}
+}
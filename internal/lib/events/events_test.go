@@ -0,0 +1,90 @@
+package events
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	assert.False(t, Active())
+	var progress, logs, states []Event
+	stop := Watch(Handlers{
+		OnProgress:    func(e Event) { progress = append(progress, e) },
+		OnLog:         func(e Event) { logs = append(logs, e) },
+		OnStateChange: func(e Event) { states = append(states, e) },
+	})
+	assert.True(t, Active())
+
+	Publish(Event{Kind: KindStateChange, Package: "npm:prettier", State: StateStarted})
+	Publish(Event{Kind: KindLog, Message: "hello"})
+	Publish(Event{Kind: KindProgress, BytesDone: 10})
+	stop()
+	Publish(Event{Kind: KindLog, Message: "not delivered"})
+
+	assert.False(t, Active())
+	require.Len(t, states, 1)
+	assert.Equal(t, StateStarted, states[0].State)
+	assert.False(t, states[0].Time.IsZero())
+	require.Len(t, logs, 1)
+	assert.Equal(t, "hello", logs[0].Message)
+	require.Len(t, progress, 1)
+}
+
+func TestSubscribeConcurrentPublishers(t *testing.T) {
+	ch, unsubscribe := Subscribe()
+	defer unsubscribe()
+
+	const publishers, perPublisher = 8, 100
+	var wg sync.WaitGroup
+	for p := 0; p < publishers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perPublisher; i++ {
+				Publish(Event{Kind: KindProgress, Package: string(rune('a' + p)), BytesDone: int64(i)})
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	last := map[string]int64{}
+	for n := 0; n < publishers*perPublisher; n++ {
+		e := <-ch
+		if prev, ok := last[e.Package]; ok {
+			assert.Greater(t, e.BytesDone, prev, "events of one publisher arrive in order")
+		}
+		last[e.Package] = e.BytesDone
+	}
+	assert.Len(t, last, publishers)
+
+	unsubscribe()
+	_, open := <-ch
+	assert.False(t, open)
+}
+
+func TestProgressReader(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), progressStep*2+10)
+	assert.IsType(t, &bytes.Reader{}, ProgressReader(bytes.NewReader(data), Event{}, -1), "unwrapped without watchers")
+
+	var got []Event
+	stop := Watch(Handlers{OnProgress: func(e Event) { got = append(got, e) }})
+	defer stop()
+
+	r := ProgressReader(bytes.NewReader(data), Event{Package: "github:a/b", URL: "https://example.com/a.tar.gz"}, int64(len(data)))
+	n, err := io.Copy(io.Discard, r)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), n)
+
+	require.GreaterOrEqual(t, len(got), 3)
+	final := got[len(got)-1]
+	assert.Equal(t, KindProgress, final.Kind)
+	assert.Equal(t, "github:a/b", final.Package)
+	assert.Equal(t, "https://example.com/a.tar.gz", final.URL)
+	assert.Equal(t, int64(len(data)), final.BytesDone)
+	assert.Equal(t, int64(len(data)), final.BytesTotal)
+}
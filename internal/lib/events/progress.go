@@ -0,0 +1,38 @@
+package events
+
+import "io"
+
+// progressStep is the number of bytes between two progress events of a download
+const progressStep = 256 * 1024
+
+// progressReader publishes progress events while a download is read
+type progressReader struct {
+	r         io.Reader
+	base      Event
+	done      int64
+	published int64
+}
+
+// ProgressReader wraps r so reading it publishes KindProgress events based on
+// base (for the operation fields and URL) every 256 KiB and once at the end.
+// total is the expected size, or -1 if unknown. When nobody watches, r is returned as is.
+func ProgressReader(r io.Reader, base Event, total int64) io.Reader {
+	if !Active() {
+		return r
+	}
+	base.Kind = KindProgress
+	base.BytesTotal = total
+	return &progressReader{r: r, base: base}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	if p.done-p.published >= progressStep || err == io.EOF && p.done != p.published {
+		p.published = p.done
+		e := p.base
+		e.BytesDone = p.done
+		Publish(e)
+	}
+	return n, err
+}
@@ -0,0 +1,155 @@
+package files
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GetAssetCachePath returns the path to the downloaded release asset cache
+// e.g. /home/user/.cache/zana/assets
+func GetAssetCachePath() string {
+	return EnsureDirExists(GetCachePath() + string(os.PathSeparator) + "assets")
+}
+
+// AssetCacheKey returns the cache key for an asset download URL.
+// The key is stable across machines, so exported caches can be reused.
+func AssetCacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetAssetCacheFilePath returns the path an asset downloaded from url is cached at
+func GetAssetCacheFilePath(url string) string {
+	return filepath.Join(GetAssetCachePath(), AssetCacheKey(url))
+}
+
+// isAssetCacheKey reports whether name looks like a key created by AssetCacheKey
+func isAssetCacheKey(name string) bool {
+	if len(name) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil && strings.ToLower(name) == name
+}
+
+// ExportAssetCache writes all cached assets into a tar archive at tarPath
+// and returns the number of exported assets.
+func ExportAssetCache(tarPath string) (int, error) {
+	cacheDir := GetAssetCachePath()
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read asset cache: %w", err)
+	}
+
+	out, err := os.Create(tarPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", tarPath, err)
+	}
+	defer func() { _ = out.Close() }()
+
+	tw := tar.NewWriter(out)
+	count := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !isAssetCacheKey(entry.Name()) {
+			continue
+		}
+		if err := addFileToTar(tw, filepath.Join(cacheDir, entry.Name()), entry.Name()); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := tw.Close(); err != nil {
+		return count, fmt.Errorf("failed to finalize %s: %w", tarPath, err)
+	}
+	return count, nil
+}
+
+func addFileToTar(tw *tar.Writer, path string, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write header for %s: %w", name, err)
+	}
+	if _, err := io.Copy(tw, f); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// ImportAssetCache extracts assets from a tar archive created by ExportAssetCache
+// into the asset cache and returns the number of imported assets.
+// Entries that are not asset cache keys are skipped.
+func ImportAssetCache(tarPath string) (int, error) {
+	in, err := os.Open(tarPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", tarPath, err)
+	}
+	defer func() { _ = in.Close() }()
+
+	cacheDir := GetAssetCachePath()
+	tr := tar.NewReader(in)
+	count := 0
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, fmt.Errorf("failed to read %s: %w", tarPath, err)
+		}
+		if header.Typeflag != tar.TypeReg || !isAssetCacheKey(header.Name) {
+			continue
+		}
+		if err := writeAssetFromTar(tr, filepath.Join(cacheDir, header.Name)); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// writeAssetFromTar writes the current tar entry to dest via a temp file,
+// so that an interrupted import never leaves a truncated asset behind.
+func writeAssetFromTar(r io.Reader, dest string) error {
+	tmp := dest + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", tmp, err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	return os.Rename(tmp, dest)
+}
+
+// GetQuarantinePath returns the directory downloads that failed to extract
+// are moved to, e.g. /home/user/.cache/zana/quarantine
+func GetQuarantinePath() string {
+	return EnsureDirExists(GetCachePath() + string(os.PathSeparator) + "quarantine")
+}
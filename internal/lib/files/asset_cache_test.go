@@ -0,0 +1,82 @@
+package files
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssetCacheKey(t *testing.T) {
+	key := AssetCacheKey("https://example.com/tool.tar.gz")
+	assert.Len(t, key, 64)
+	assert.True(t, isAssetCacheKey(key))
+	assert.Equal(t, key, AssetCacheKey("https://example.com/tool.tar.gz"))
+	assert.NotEqual(t, key, AssetCacheKey("https://example.com/other.tar.gz"))
+	assert.False(t, isAssetCacheKey("../etc/passwd"))
+	assert.False(t, isAssetCacheKey("ABC"))
+}
+
+func TestGetAssetCacheFilePath(t *testing.T) {
+	t.Setenv("ZANA_CACHE", t.TempDir())
+	url := "https://example.com/tool.zip"
+	path := GetAssetCacheFilePath(url)
+	assert.Equal(t, filepath.Join(GetCachePath(), "assets", AssetCacheKey(url)), path)
+	assert.DirExists(t, filepath.Dir(path))
+}
+
+func TestExportImportAssetCache(t *testing.T) {
+	t.Setenv("ZANA_CACHE", t.TempDir())
+	urlA := "https://example.com/a.tar.gz"
+	urlB := "https://example.com/b.zip"
+	require.NoError(t, os.WriteFile(GetAssetCacheFilePath(urlA), []byte("asset-a"), 0644))
+	require.NoError(t, os.WriteFile(GetAssetCacheFilePath(urlB), []byte("asset-b"), 0644))
+	// Unrelated files in the cache dir are not exported
+	require.NoError(t, os.WriteFile(filepath.Join(GetAssetCachePath(), "notes.txt"), []byte("x"), 0644))
+
+	tarPath := filepath.Join(t.TempDir(), "assets.tar")
+	count, err := ExportAssetCache(tarPath)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// Import on a "different machine"
+	t.Setenv("ZANA_CACHE", t.TempDir())
+	count, err = ImportAssetCache(tarPath)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	data, err := os.ReadFile(GetAssetCacheFilePath(urlA))
+	require.NoError(t, err)
+	assert.Equal(t, "asset-a", string(data))
+	data, err = os.ReadFile(GetAssetCacheFilePath(urlB))
+	require.NoError(t, err)
+	assert.Equal(t, "asset-b", string(data))
+}
+
+func TestImportAssetCacheSkipsUnsafeEntries(t *testing.T) {
+	t.Setenv("ZANA_CACHE", t.TempDir())
+	tarPath := filepath.Join(t.TempDir(), "evil.tar")
+	f, err := os.Create(tarPath)
+	require.NoError(t, err)
+	tw := tar.NewWriter(f)
+	content := []byte("evil")
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "../evil", Mode: 0644, Size: int64(len(content))}))
+	_, err = tw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, f.Close())
+
+	count, err := ImportAssetCache(tarPath)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.NoFileExists(t, filepath.Join(GetCachePath(), "evil"))
+}
+
+func TestImportAssetCacheMissingFile(t *testing.T) {
+	t.Setenv("ZANA_CACHE", t.TempDir())
+	_, err := ImportAssetCache(filepath.Join(t.TempDir(), "missing.tar"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,88 @@
+package files
+
+import (
+	"os"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+)
+
+const (
+	// BinLayoutFlat links all executables directly into the bin directory (default)
+	BinLayoutFlat = "flat"
+	// BinLayoutPerProvider links executables into per-provider subdirectories,
+	// e.g. bin/npm and bin/cargo, so PATH precedence can be controlled per provider
+	BinLayoutPerProvider = "per-provider"
+)
+
+// GetBinLayout returns the configured bin directory layout.
+// Order of precedence:
+//   - ZANA_BIN_LAYOUT environment variable
+//   - paths.binLayout in config.yaml
+//   - flat
+//
+// Unknown values fall back to the flat layout.
+func GetBinLayout() string {
+	raw := fileSystem.Getenv("ZANA_BIN_LAYOUT")
+	if strings.TrimSpace(raw) == "" {
+		if cfg, ok := readZanaConfigFile(); ok {
+			raw = cfg.Paths.BinLayout
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case BinLayoutPerProvider:
+		return BinLayoutPerProvider
+	default:
+		return BinLayoutFlat
+	}
+}
+
+// GetAppBinPathForProvider returns the directory executables of the given
+// provider are linked into. With the flat layout (or an empty provider name)
+// this is the bin directory itself, otherwise a subdirectory named after the provider.
+// e.g. /home/user/.local/share/zana/bin/npm
+func GetAppBinPathForProvider(provider string) string {
+	if provider == "" || GetBinLayout() != BinLayoutPerProvider {
+		return GetAppBinPath()
+	}
+	return EnsureDirExists(GetAppBinPath() + string(os.PathSeparator) + provider)
+}
+
+// GetBinProviderOrder returns the provider names from paths.binProviderOrder in config.yaml.
+// Providers listed first take precedence in PATH when the per-provider layout is used.
+func GetBinProviderOrder() []string {
+	cfg, ok := readZanaConfigFile()
+	if !ok {
+		return nil
+	}
+	order := make([]string, 0, len(cfg.Paths.BinProviderOrder))
+	for _, p := range cfg.Paths.BinProviderOrder {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			order = append(order, p)
+		}
+	}
+	return order
+}
+
+// BinFilterConfig selects which executables of a package are linked into the bin directory.
+// Entries are glob patterns matched against the executable name.
+type BinFilterConfig struct {
+	Include []string `yaml:"include" json:"include,omitempty"`
+	Exclude []string `yaml:"exclude" json:"exclude,omitempty"`
+}
+
+// GetBinFilter returns the bin filter configured for the package in
+// paths.binFilters in config.yaml, keyed by package ID (e.g. npm:typescript)
+func GetBinFilter(sourceID string) (BinFilterConfig, bool) {
+	cfg, ok := readZanaConfigFile()
+	if !ok {
+		return BinFilterConfig{}, false
+	}
+	want := packageid.Normalize(sourceID)
+	for id, filter := range cfg.Paths.BinFilters {
+		if packageid.Normalize(strings.TrimSpace(id)) == want {
+			return filter, true
+		}
+	}
+	return BinFilterConfig{}, false
+}
@@ -0,0 +1,70 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBinLayout(t *testing.T) {
+	t.Run("defaults to flat", func(t *testing.T) {
+		t.Setenv("ZANA_HOME", t.TempDir())
+		t.Setenv("ZANA_BIN_LAYOUT", "")
+		assert.Equal(t, BinLayoutFlat, GetBinLayout())
+	})
+
+	t.Run("reads config file", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("ZANA_HOME", home)
+		t.Setenv("ZANA_BIN_LAYOUT", "")
+		require.NoError(t, os.WriteFile(filepath.Join(home, "config.yaml"), []byte("paths:\n  binLayout: per-provider\n  binProviderOrder: [Cargo, ' npm ', '']\n"), 0644))
+		assert.Equal(t, BinLayoutPerProvider, GetBinLayout())
+		assert.Equal(t, []string{"cargo", "npm"}, GetBinProviderOrder())
+	})
+
+	t.Run("environment variable wins over config", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("ZANA_HOME", home)
+		t.Setenv("ZANA_BIN_LAYOUT", "flat")
+		require.NoError(t, os.WriteFile(filepath.Join(home, "config.yaml"), []byte("paths:\n  binLayout: per-provider\n"), 0644))
+		assert.Equal(t, BinLayoutFlat, GetBinLayout())
+	})
+
+	t.Run("unknown value falls back to flat", func(t *testing.T) {
+		t.Setenv("ZANA_HOME", t.TempDir())
+		t.Setenv("ZANA_BIN_LAYOUT", "nested")
+		assert.Equal(t, BinLayoutFlat, GetBinLayout())
+	})
+}
+
+func TestGetAppBinPathForProvider(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("ZANA_HOME", t.TempDir())
+
+	t.Setenv("ZANA_BIN_LAYOUT", "flat")
+	assert.Equal(t, GetAppBinPath(), GetAppBinPathForProvider("npm"))
+
+	t.Setenv("ZANA_BIN_LAYOUT", "per-provider")
+	path := GetAppBinPathForProvider("npm")
+	assert.Equal(t, filepath.Join(GetAppBinPath(), "npm"), path)
+	assert.DirExists(t, path)
+	assert.Equal(t, GetAppBinPath(), GetAppBinPathForProvider(""))
+}
+
+func TestGetBinFilter(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("ZANA_HOME", home)
+	require.NoError(t, os.WriteFile(filepath.Join(home, "config.yaml"), []byte("paths:\n  binFilters:\n    npm:typescript:\n      exclude: [tsserver]\n"), 0644))
+
+	filter, ok := GetBinFilter("pkg:npm/typescript")
+	require.True(t, ok)
+	assert.Equal(t, []string{"tsserver"}, filter.Exclude)
+	assert.Empty(t, filter.Include)
+
+	_, ok = GetBinFilter("npm:prettier")
+	assert.False(t, ok)
+}
@@ -0,0 +1,16 @@
+package files
+
+// cacheBypassed is set with --no-cache
+var cacheBypassed bool
+
+// SetCacheBypass makes the following downloads ignore the downloaded registry
+// and the cached assets. Fresh downloads are still written to the caches, so
+// a cached file that broke installs gets replaced.
+func SetCacheBypass(bypass bool) {
+	cacheBypassed = bypass
+}
+
+// CacheBypassed reports whether cached downloads are ignored (--no-cache)
+func CacheBypassed() bool {
+	return cacheBypassed
+}
@@ -0,0 +1,47 @@
+package files
+
+import (
+	"strings"
+)
+
+// macOS architectures of release assets, see GetDarwinArchPreference
+const (
+	DarwinArchArm64     = "arm64"
+	DarwinArchUniversal = "universal"
+	DarwinArchX64       = "x64"
+)
+
+// DefaultDarwinArchPreference prefers native assets on Apple Silicon and
+// falls back to x86_64 ones running under Rosetta 2 last
+var DefaultDarwinArchPreference = []string{DarwinArchArm64, DarwinArchUniversal, DarwinArchX64}
+
+// GetDarwinArchPreference returns the order in which asset architectures are
+// tried on Apple Silicon Macs.
+// Order of precedence:
+//   - ZANA_DARWIN_ARCH_PREFERENCE environment variable (comma-separated)
+//   - install.darwinArchPreference in config.yaml
+//   - DefaultDarwinArchPreference
+//
+// Unknown architectures are ignored. Leaving out x64 never installs
+// x86_64 assets, even when Rosetta 2 is available.
+func GetDarwinArchPreference() []string {
+	var raw []string
+	if env := strings.TrimSpace(fileSystem.Getenv("ZANA_DARWIN_ARCH_PREFERENCE")); env != "" {
+		raw = strings.Split(env, ",")
+	} else if cfg, ok := readZanaConfigFile(); ok {
+		raw = cfg.Install.DarwinArchPreference
+	}
+
+	var preference []string
+	for _, arch := range raw {
+		arch = strings.ToLower(strings.TrimSpace(arch))
+		switch arch {
+		case DarwinArchArm64, DarwinArchUniversal, DarwinArchX64:
+			preference = append(preference, arch)
+		}
+	}
+	if len(preference) == 0 {
+		return DefaultDarwinArchPreference
+	}
+	return preference
+}
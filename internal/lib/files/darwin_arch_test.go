@@ -0,0 +1,31 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDarwinArchPreference(t *testing.T) {
+	env := map[string]string{}
+	mockFS := &MockFileSystem{
+		fs:                afero.NewMemMapFs(),
+		GetenvFunc:        func(key string) string { return env[key] },
+		UserHomeDirFunc:   func() (string, error) { return "/home/user", nil },
+		UserConfigDirFunc: func() (string, error) { return "/home/user/.config", nil },
+	}
+	SetFileSystem(mockFS)
+	defer ResetDependencies()
+
+	assert.Equal(t, DefaultDarwinArchPreference, GetDarwinArchPreference())
+
+	_ = afero.WriteFile(mockFS.fs, "/home/user/.config/zana/config.yaml", []byte("install:\n  darwinArchPreference: [Universal, arm64, ppc]\n"), 0o644)
+	assert.Equal(t, []string{DarwinArchUniversal, DarwinArchArm64}, GetDarwinArchPreference())
+
+	env["ZANA_DARWIN_ARCH_PREFERENCE"] = "arm64"
+	assert.Equal(t, []string{DarwinArchArm64}, GetDarwinArchPreference(), "the environment overrides config.yaml")
+
+	env["ZANA_DARWIN_ARCH_PREFERENCE"] = "ppc"
+	assert.Equal(t, DefaultDarwinArchPreference, GetDarwinArchPreference())
+}
@@ -0,0 +1,65 @@
+package files
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DefaultConfirmDownloadSize is the download size in bytes above which
+// installs and updates ask for confirmation, when neither
+// ZANA_CONFIRM_DOWNLOAD_SIZE nor install.confirmDownloadSize is set
+const DefaultConfirmDownloadSize int64 = 500 << 20
+
+// downloadSizeUnits are the suffixes ParseDownloadSize accepts, longest first
+var downloadSizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"gib", 1 << 30}, {"mib", 1 << 20}, {"kib", 1 << 10},
+	{"gb", 1 << 30}, {"mb", 1 << 20}, {"kb", 1 << 10},
+	{"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10},
+	{"b", 1},
+}
+
+// ParseDownloadSize parses a size like 500MB, 1.5G or 1048576 (bytes).
+// The units are binary, MB and MiB both are 1024*1024 bytes.
+func ParseDownloadSize(raw string) (int64, bool) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+	factor := int64(1)
+	for _, unit := range downloadSizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			factor = unit.factor
+			break
+		}
+	}
+	size, err := strconv.ParseFloat(value, 64)
+	if err != nil || size < 0 {
+		return 0, false
+	}
+	return int64(size * float64(factor)), true
+}
+
+// GetConfirmDownloadSize returns the download size in bytes above which
+// installs and updates ask for confirmation first.
+// Order of precedence:
+//   - ZANA_CONFIRM_DOWNLOAD_SIZE environment variable
+//   - install.confirmDownloadSize in config.yaml
+//   - DefaultConfirmDownloadSize
+//
+// 0 disables the confirmation. Invalid values fall back to the default.
+func GetConfirmDownloadSize() int64 {
+	raw := fileSystem.Getenv("ZANA_CONFIRM_DOWNLOAD_SIZE")
+	if strings.TrimSpace(raw) == "" {
+		cfg, ok := readZanaConfigFile()
+		if !ok || strings.TrimSpace(cfg.Install.ConfirmDownloadSize) == "" {
+			return DefaultConfirmDownloadSize
+		}
+		raw = cfg.Install.ConfirmDownloadSize
+	}
+	size, ok := ParseDownloadSize(raw)
+	if !ok {
+		return DefaultConfirmDownloadSize
+	}
+	return size
+}
@@ -0,0 +1,62 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDownloadSize(t *testing.T) {
+	for raw, want := range map[string]int64{
+		"0":        0,
+		"1048576":  1 << 20,
+		"500MB":    500 << 20,
+		"500 MiB":  500 << 20,
+		"1.5G":     3 << 29,
+		"64k":      64 << 10,
+		"2gb":      2 << 30,
+		"100b":     100,
+		" 10 mb  ": 10 << 20,
+	} {
+		size, ok := ParseDownloadSize(raw)
+		assert.True(t, ok, raw)
+		assert.Equal(t, want, size, raw)
+	}
+	for _, raw := range []string{"", "big", "-1MB", "MB", "10TB"} {
+		_, ok := ParseDownloadSize(raw)
+		assert.False(t, ok, raw)
+	}
+}
+
+func TestGetConfirmDownloadSize(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("ZANA_HOME", t.TempDir())
+		t.Setenv("ZANA_CONFIRM_DOWNLOAD_SIZE", "")
+		assert.Equal(t, DefaultConfirmDownloadSize, GetConfirmDownloadSize())
+	})
+
+	t.Run("reads config file", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("ZANA_HOME", home)
+		t.Setenv("ZANA_CONFIRM_DOWNLOAD_SIZE", "")
+		require.NoError(t, os.WriteFile(filepath.Join(home, "config.yaml"), []byte("install:\n  confirmDownloadSize: 100MB\n"), 0644))
+		assert.Equal(t, int64(100<<20), GetConfirmDownloadSize())
+	})
+
+	t.Run("environment variable wins over config", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("ZANA_HOME", home)
+		t.Setenv("ZANA_CONFIRM_DOWNLOAD_SIZE", "0")
+		require.NoError(t, os.WriteFile(filepath.Join(home, "config.yaml"), []byte("install:\n  confirmDownloadSize: 100MB\n"), 0644))
+		assert.Equal(t, int64(0), GetConfirmDownloadSize())
+	})
+
+	t.Run("invalid values fall back to the default", func(t *testing.T) {
+		t.Setenv("ZANA_HOME", t.TempDir())
+		t.Setenv("ZANA_CONFIRM_DOWNLOAD_SIZE", "lots")
+		assert.Equal(t, DefaultConfirmDownloadSize, GetConfirmDownloadSize())
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -186,6 +187,14 @@ func (m *MockFileSystem) Stat(name string) (os.FileInfo, error) {
 	return m.fs.Stat(name)
 }
 
+func (m *MockFileSystem) Rename(oldpath, newpath string) error {
+	return m.fs.Rename(oldpath, newpath)
+}
+
+func (m *MockFileSystem) Remove(name string) error {
+	return m.fs.Remove(name)
+}
+
 func (m *MockFileSystem) UserConfigDir() (string, error) {
 	if m.UserConfigDirFunc != nil {
 		return m.UserConfigDirFunc()
@@ -518,6 +527,14 @@ func TestIsCacheValid(t *testing.T) {
 
 	// Test with valid cache
 	assert.True(t, IsCacheValid("/cache_file", 24*time.Hour))
+
+	// The cache expires once maxAge has passed, without waiting for it
+	fake := clock.NewFake(time.Now())
+	defer clock.Set(fake)()
+	fake.Advance(23 * time.Hour)
+	assert.True(t, IsCacheValid("/cache_file", 24*time.Hour))
+	fake.Advance(2 * time.Hour)
+	assert.False(t, IsCacheValid("/cache_file", 24*time.Hour))
 }
 
 // TestDownloadAndUnzipRegistry tests the registry download and unzip functionality
@@ -574,9 +591,9 @@ func TestDownloadAndUnzipRegistry(t *testing.T) {
 
 		// Test the function
 		err := DownloadAndUnzipRegistry()
-		// This will fail due to mock implementation, but we're testing the function structure
+		// A download that doesn't open as a zip never replaces the cached one
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to unzip registry")
+		assert.Contains(t, err.Error(), "is not a valid zip: zip open error")
 	})
 
 	t.Run("merges multiple registries with later override", func(t *testing.T) {
@@ -1710,10 +1727,10 @@ func TestDownloadAndUnzipRegistryErrorPaths(t *testing.T) {
 		SetZipFileOpener(mockZipOpener)
 		defer ResetDependencies()
 
-		// Test that it fails due to unzip error
+		// Test that it fails due to unzip error, already when downloading
 		err := DownloadAndUnzipRegistry()
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to unzip registry")
+		assert.Contains(t, err.Error(), "is not a valid zip: unzip error")
 	})
 
 	t.Run("download and unzip registry with custom URL", func(t *testing.T) {
@@ -2819,3 +2836,21 @@ func TestUncoveredBranches(t *testing.T) {
 		assert.Contains(t, err.Error(), "open file error for io copy")
 	})
 }
+
+func TestGetProviderPriority(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("ZANA_HOME", home)
+	assert.Empty(t, GetProviderPriority())
+
+	require.NoError(t, os.WriteFile(filepath.Join(home, "config.yaml"), []byte("install:\n  providerPriority: [GitHub, ' npm ', '']\n"), 0644))
+	assert.Equal(t, []string{"github", "npm"}, GetProviderPriority())
+}
+
+func TestGetDisabledProviders(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("ZANA_HOME", home)
+	assert.Empty(t, GetDisabledProviders())
+
+	require.NoError(t, os.WriteFile(filepath.Join(home, "config.yaml"), []byte("providers:\n  disabled: [Generic, ' npm ', '']\n"), 0644))
+	assert.Equal(t, []string{"generic", "npm"}, GetDisabledProviders())
+}
@@ -0,0 +1,33 @@
+package files
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DefaultGitHubRequestsPerSecond is the rate GitHub API requests are limited to
+// when neither ZANA_GITHUB_RPS nor github.requestsPerSecond is set
+const DefaultGitHubRequestsPerSecond = 2.0
+
+// GetGitHubRequestsPerSecond returns how many GitHub API requests per second zana makes at most.
+// Order of precedence:
+//   - ZANA_GITHUB_RPS environment variable
+//   - github.requestsPerSecond in config.yaml
+//   - DefaultGitHubRequestsPerSecond
+//
+// 0 disables the limit. Invalid or negative values fall back to the default.
+func GetGitHubRequestsPerSecond() float64 {
+	raw := fileSystem.Getenv("ZANA_GITHUB_RPS")
+	if strings.TrimSpace(raw) == "" {
+		cfg, ok := readZanaConfigFile()
+		if !ok || strings.TrimSpace(cfg.GitHub.RequestsPerSecond) == "" {
+			return DefaultGitHubRequestsPerSecond
+		}
+		raw = cfg.GitHub.RequestsPerSecond
+	}
+	rps, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil || rps < 0 {
+		return DefaultGitHubRequestsPerSecond
+	}
+	return rps
+}
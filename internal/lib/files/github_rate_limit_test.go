@@ -0,0 +1,42 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetGitHubRequestsPerSecond(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("ZANA_HOME", t.TempDir())
+		t.Setenv("ZANA_GITHUB_RPS", "")
+		assert.Equal(t, DefaultGitHubRequestsPerSecond, GetGitHubRequestsPerSecond())
+	})
+
+	t.Run("reads config file", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("ZANA_HOME", home)
+		t.Setenv("ZANA_GITHUB_RPS", "")
+		require.NoError(t, os.WriteFile(filepath.Join(home, "config.yaml"), []byte("github:\n  requestsPerSecond: 0.5\n"), 0644))
+		assert.Equal(t, 0.5, GetGitHubRequestsPerSecond())
+	})
+
+	t.Run("environment variable wins over config", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("ZANA_HOME", home)
+		t.Setenv("ZANA_GITHUB_RPS", "0")
+		require.NoError(t, os.WriteFile(filepath.Join(home, "config.yaml"), []byte("github:\n  requestsPerSecond: 5\n"), 0644))
+		assert.Equal(t, 0.0, GetGitHubRequestsPerSecond())
+	})
+
+	t.Run("invalid values fall back to the default", func(t *testing.T) {
+		t.Setenv("ZANA_HOME", t.TempDir())
+		t.Setenv("ZANA_GITHUB_RPS", "-1")
+		assert.Equal(t, DefaultGitHubRequestsPerSecond, GetGitHubRequestsPerSecond())
+		t.Setenv("ZANA_GITHUB_RPS", "fast")
+		assert.Equal(t, DefaultGitHubRequestsPerSecond, GetGitHubRequestsPerSecond())
+	})
+}
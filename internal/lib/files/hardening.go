@@ -0,0 +1,57 @@
+package files
+
+import (
+	"strings"
+)
+
+// DefaultHardeningNice is the nice level of hardened subprocesses
+const DefaultHardeningNice = 10
+
+// SubprocessHardening restricts the subprocesses zana runs for the packages
+// of some providers (npm, pip, cargo, ...), see hardening in config.yaml
+type SubprocessHardening struct {
+	// Providers whose subprocesses are hardened, "*" for all of them
+	Providers []string `yaml:"providers"`
+	// Nice is the nice level of the subprocesses, DefaultHardeningNice when unset
+	Nice *int `yaml:"nice"`
+	// IoniceClass is the I/O scheduling class on Linux: idle (default),
+	// best-effort or none
+	IoniceClass string `yaml:"ioniceClass"`
+	// Env names environment variables passed to the subprocesses on top of
+	// the basic ones (PATH, HOME, proxies, ...), e.g. NPM_TOKEN
+	Env []string `yaml:"env"`
+}
+
+// GetSubprocessHardening returns hardening from config.yaml.
+// The ZANA_HARDENING environment variable (comma-separated providers, or *)
+// overrides hardening.providers.
+func GetSubprocessHardening() SubprocessHardening {
+	var h SubprocessHardening
+	if cfg, ok := readZanaConfigFile(); ok {
+		h = cfg.Hardening
+	}
+	if raw := strings.TrimSpace(fileSystem.Getenv("ZANA_HARDENING")); raw != "" {
+		h.Providers = strings.Split(raw, ",")
+	}
+	return h
+}
+
+// Hardens reports whether the subprocesses of provider are hardened
+func (h SubprocessHardening) Hardens(provider string) bool {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	for _, p := range h.Providers {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "*" || (p != "" && p == provider) {
+			return true
+		}
+	}
+	return false
+}
+
+// NiceLevel returns the nice level of hardened subprocesses
+func (h SubprocessHardening) NiceLevel() int {
+	if h.Nice == nil {
+		return DefaultHardeningNice
+	}
+	return *h.Nice
+}
@@ -0,0 +1,37 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSubprocessHardening(t *testing.T) {
+	env := map[string]string{}
+	mockFS := &MockFileSystem{
+		fs:                afero.NewMemMapFs(),
+		GetenvFunc:        func(key string) string { return env[key] },
+		UserHomeDirFunc:   func() (string, error) { return "/home/user", nil },
+		UserConfigDirFunc: func() (string, error) { return "/home/user/.config", nil },
+	}
+	SetFileSystem(mockFS)
+	defer ResetDependencies()
+
+	h := GetSubprocessHardening()
+	assert.False(t, h.Hardens("npm"))
+	assert.Equal(t, DefaultHardeningNice, h.NiceLevel())
+
+	_ = afero.WriteFile(mockFS.fs, "/home/user/.config/zana/config.yaml", []byte("hardening:\n  providers: [npm, PyPI]\n  nice: 5\n  env: [NPM_TOKEN]\n"), 0o644)
+	h = GetSubprocessHardening()
+	assert.True(t, h.Hardens("npm"))
+	assert.True(t, h.Hardens("pypi"))
+	assert.False(t, h.Hardens("cargo"))
+	assert.Equal(t, 5, h.NiceLevel())
+	assert.Equal(t, []string{"NPM_TOKEN"}, h.Env)
+
+	env["ZANA_HARDENING"] = "*"
+	h = GetSubprocessHardening()
+	assert.True(t, h.Hardens("cargo"), "ZANA_HARDENING overrides hardening.providers")
+	assert.Equal(t, 5, h.NiceLevel())
+}
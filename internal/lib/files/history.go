@@ -0,0 +1,104 @@
+package files
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// History actions
+const (
+	HistoryActionInstall = "install"
+	HistoryActionUpdate  = "update"
+	HistoryActionRemove  = "remove"
+)
+
+// HistoryEntry is a single line of the history log
+type HistoryEntry struct {
+	Time       time.Time `json:"time"`
+	Action     string    `json:"action"`
+	SourceID   string    `json:"sourceId"`
+	Version    string    `json:"version,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+	Success    bool      `json:"success"`
+	// SlowFactor is how many times longer than usual the operation took,
+	// set when it exceeded the duration budget of its provider
+	SlowFactor float64 `json:"slowFactor,omitempty"`
+}
+
+// Duration returns how long the recorded operation took
+func (e HistoryEntry) Duration() time.Duration {
+	return time.Duration(e.DurationMs) * time.Millisecond
+}
+
+// GetHistoryFilePath returns the path to the history log
+// e.g. /home/user/.config/zana/history.jsonl
+func GetHistoryFilePath() string {
+	return GetAppDataPath() + string(os.PathSeparator) + "history.jsonl"
+}
+
+// AppendHistoryEntry appends an entry to the history log
+func AppendHistoryEntry(entry HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(GetHistoryFilePath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history log: %w", err)
+	}
+	return nil
+}
+
+// ReadHistory returns all entries of the history log, oldest first.
+// A missing log is not an error, malformed lines are skipped.
+func ReadHistory() ([]HistoryEntry, error) {
+	f, err := os.Open(GetHistoryFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []HistoryEntry{}, nil
+		}
+		return nil, fmt.Errorf("failed to open history log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	entries := []HistoryEntry{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("failed to read history log: %w", err)
+	}
+	return entries, nil
+}
+
+// DirSize returns the total size in bytes of all regular files below path.
+// Symlinks are not followed and a missing path has a size of 0.
+func DirSize(path string) int64 {
+	var size int64
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
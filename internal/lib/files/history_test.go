@@ -0,0 +1,49 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryLog(t *testing.T) {
+	t.Setenv("ZANA_HOME", t.TempDir())
+
+	entries, err := ReadHistory()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, AppendHistoryEntry(HistoryEntry{Time: now, Action: HistoryActionInstall, SourceID: "npm:prettier", Version: "3.0.0", DurationMs: 1500, Success: true}))
+	require.NoError(t, AppendHistoryEntry(HistoryEntry{Time: now.Add(time.Hour), Action: HistoryActionRemove, SourceID: "npm:prettier", DurationMs: 20, Success: true}))
+
+	// Malformed lines are skipped
+	f, err := os.OpenFile(GetHistoryFilePath(), os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("not json\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	entries, err = ReadHistory()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "npm:prettier", entries[0].SourceID)
+	assert.Equal(t, 1500*time.Millisecond, entries[0].Duration())
+	assert.True(t, entries[0].Time.Equal(now))
+	assert.Equal(t, HistoryActionRemove, entries[1].Action)
+}
+
+func TestDirSize(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "a", "b"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "one"), make([]byte, 10), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a", "b", "two"), make([]byte, 32), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "one"), filepath.Join(dir, "link")))
+
+	assert.Equal(t, int64(42), DirSize(dir))
+	assert.Equal(t, int64(0), DirSize(filepath.Join(dir, "missing")))
+}
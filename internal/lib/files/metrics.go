@@ -0,0 +1,54 @@
+package files
+
+import (
+	"os"
+	"strings"
+)
+
+// Usage metrics modes, see metrics.mode in config.yaml
+const (
+	MetricsModeOff   = "off"
+	MetricsModeLocal = "local"
+	MetricsModeShare = "share"
+)
+
+// MetricsConfig configures the usage metrics, see metrics in config.yaml
+type MetricsConfig struct {
+	// Mode is off (default), local to count command usage in usage.json only,
+	// or share to also send the aggregate counts to Endpoint
+	Mode string `yaml:"mode"`
+	// Endpoint receives the aggregate counts in share mode
+	Endpoint string `yaml:"endpoint"`
+}
+
+// GetMetricsConfig returns metrics from config.yaml.
+// The ZANA_METRICS environment variable overrides metrics.mode, e.g. to turn
+// the metrics off for a single run. Unknown modes and share mode without an
+// endpoint fall back to off and local respectively.
+func GetMetricsConfig() MetricsConfig {
+	var m MetricsConfig
+	if cfg, ok := readZanaConfigFile(); ok {
+		m = cfg.Metrics
+	}
+	if raw := strings.TrimSpace(fileSystem.Getenv("ZANA_METRICS")); raw != "" {
+		m.Mode = raw
+	}
+	m.Mode = strings.ToLower(strings.TrimSpace(m.Mode))
+	m.Endpoint = strings.TrimSpace(m.Endpoint)
+	switch m.Mode {
+	case MetricsModeLocal:
+	case MetricsModeShare:
+		if m.Endpoint == "" {
+			m.Mode = MetricsModeLocal
+		}
+	default:
+		m.Mode = MetricsModeOff
+	}
+	return m
+}
+
+// GetUsageFilePath returns the path to the local usage metrics
+// e.g. /home/user/.config/zana/usage.json
+func GetUsageFilePath() string {
+	return GetAppDataPath() + string(os.PathSeparator) + "usage.json"
+}
@@ -0,0 +1,34 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMetricsConfig(t *testing.T) {
+	env := map[string]string{}
+	mockFS := &MockFileSystem{
+		fs:                afero.NewMemMapFs(),
+		GetenvFunc:        func(key string) string { return env[key] },
+		UserHomeDirFunc:   func() (string, error) { return "/home/user", nil },
+		UserConfigDirFunc: func() (string, error) { return "/home/user/.config", nil },
+	}
+	SetFileSystem(mockFS)
+	defer ResetDependencies()
+
+	assert.Equal(t, MetricsConfig{Mode: MetricsModeOff}, GetMetricsConfig(), "off by default")
+
+	_ = afero.WriteFile(mockFS.fs, "/home/user/.config/zana/config.yaml", []byte("metrics:\n  mode: Share\n"), 0o644)
+	assert.Equal(t, MetricsConfig{Mode: MetricsModeLocal}, GetMetricsConfig(), "sharing needs an endpoint")
+
+	_ = afero.WriteFile(mockFS.fs, "/home/user/.config/zana/config.yaml", []byte("metrics:\n  mode: share\n  endpoint: https://metrics.example.com\n"), 0o644)
+	assert.Equal(t, MetricsConfig{Mode: MetricsModeShare, Endpoint: "https://metrics.example.com"}, GetMetricsConfig())
+
+	env["ZANA_METRICS"] = "off"
+	assert.Equal(t, MetricsModeOff, GetMetricsConfig().Mode, "ZANA_METRICS overrides metrics.mode")
+
+	env["ZANA_METRICS"] = "everything"
+	assert.Equal(t, MetricsModeOff, GetMetricsConfig().Mode, "unknown modes are off")
+}
@@ -0,0 +1,133 @@
+package files
+
+import (
+	"os"
+	"path"
+	"strings"
+)
+
+// NpmRegistryConfig points an npm scope or packages at a private registry,
+// configured in npm.registries in config.yaml
+type NpmRegistryConfig struct {
+	// Scope is an npm scope such as "@mycompany", covering all of its packages
+	// and dependencies
+	Scope string `yaml:"scope" json:"scope,omitempty"`
+	// Packages are package name patterns (e.g. "internal-*") installed from URL
+	Packages []string `yaml:"packages" json:"packages,omitempty"`
+	URL      string   `yaml:"url" json:"url"`
+	// TokenEnv names the environment variable holding the auth token,
+	// which is preferred over an inline Token
+	TokenEnv string `yaml:"tokenEnv" json:"token_env,omitempty"`
+	Token    string `yaml:"token" json:"-"`
+}
+
+// PyPIIndexConfig installs matching packages from a private package index,
+// configured in pypi.indexes in config.yaml
+type PyPIIndexConfig struct {
+	// Packages are distribution name patterns (e.g. "mycompany-*")
+	Packages       []string `yaml:"packages" json:"packages,omitempty"`
+	IndexURL       string   `yaml:"indexUrl" json:"index_url"`
+	ExtraIndexURLs []string `yaml:"extraIndexUrls" json:"extra_index_urls,omitempty"`
+	// Username for the index, "__token__" when only a token is configured
+	Username string `yaml:"username" json:"username,omitempty"`
+	TokenEnv string `yaml:"tokenEnv" json:"token_env,omitempty"`
+	Token    string `yaml:"token" json:"-"`
+}
+
+// resolveToken returns the token from the environment variable tokenEnv,
+// falling back to the inline token
+func resolveToken(tokenEnv, token string) string {
+	if tokenEnv = strings.TrimSpace(tokenEnv); tokenEnv != "" {
+		if v := strings.TrimSpace(os.Getenv(tokenEnv)); v != "" {
+			return v
+		}
+	}
+	return strings.TrimSpace(token)
+}
+
+// ResolvedToken returns the auth token of the registry, if any
+func (r NpmRegistryConfig) ResolvedToken() string {
+	return resolveToken(r.TokenEnv, r.Token)
+}
+
+// ResolvedToken returns the auth token of the index, if any
+func (i PyPIIndexConfig) ResolvedToken() string {
+	return resolveToken(i.TokenEnv, i.Token)
+}
+
+// GetNpmRegistries returns the private npm registries configured in
+// npm.registries in config.yaml; entries without a URL are skipped
+func GetNpmRegistries() []NpmRegistryConfig {
+	cfg, ok := readZanaConfigFile()
+	if !ok {
+		return nil
+	}
+	registries := []NpmRegistryConfig{}
+	for _, r := range cfg.Npm.Registries {
+		r.URL = strings.TrimSpace(r.URL)
+		r.Scope = strings.TrimSpace(r.Scope)
+		if r.URL == "" {
+			continue
+		}
+		if r.Scope != "" && !strings.HasPrefix(r.Scope, "@") {
+			r.Scope = "@" + r.Scope
+		}
+		registries = append(registries, r)
+	}
+	return registries
+}
+
+// GetPyPIIndexes returns the private package indexes configured in
+// pypi.indexes in config.yaml; entries without an index URL are skipped
+func GetPyPIIndexes() []PyPIIndexConfig {
+	cfg, ok := readZanaConfigFile()
+	if !ok {
+		return nil
+	}
+	indexes := []PyPIIndexConfig{}
+	for _, i := range cfg.PyPI.Indexes {
+		i.IndexURL = strings.TrimSpace(i.IndexURL)
+		if i.IndexURL == "" {
+			continue
+		}
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
+
+// NpmRegistryFor returns the first registry whose scope or package patterns
+// match the npm package name
+func NpmRegistryFor(name string) (NpmRegistryConfig, bool) {
+	for _, r := range GetNpmRegistries() {
+		if r.Scope != "" && strings.HasPrefix(name, r.Scope+"/") || matchesPackagePattern(r.Packages, name) {
+			return r, true
+		}
+	}
+	return NpmRegistryConfig{}, false
+}
+
+// PyPIIndexFor returns the first index whose package patterns match the
+// distribution name; names are compared case-insensitively with "_" as "-"
+func PyPIIndexFor(name string) (PyPIIndexConfig, bool) {
+	normalize := func(s string) string { return strings.ReplaceAll(strings.ToLower(s), "_", "-") }
+	name = normalize(name)
+	for _, i := range GetPyPIIndexes() {
+		patterns := make([]string, 0, len(i.Packages))
+		for _, p := range i.Packages {
+			patterns = append(patterns, normalize(p))
+		}
+		if matchesPackagePattern(patterns, name) {
+			return i, true
+		}
+	}
+	return PyPIIndexConfig{}, false
+}
+
+func matchesPackagePattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.TrimSpace(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
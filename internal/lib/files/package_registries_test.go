@@ -0,0 +1,70 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const packageRegistriesConfig = `npm:
+  registries:
+    - scope: mycompany
+      url: https://npm.mycompany.com/
+      tokenEnv: MYCOMPANY_NPM_TOKEN
+      token: inline-npm
+    - packages: ["internal-*"]
+      url: https://npm.internal.example/
+    - scope: "@broken"
+pypi:
+  indexes:
+    - packages: ["MyCompany_*"]
+      indexUrl: https://pypi.mycompany.com/simple
+      extraIndexUrls: [https://pypi.org/simple]
+      tokenEnv: MYCOMPANY_PYPI_TOKEN
+`
+
+func TestPackageRegistries(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("ZANA_HOME", home)
+	t.Setenv("MYCOMPANY_NPM_TOKEN", "")
+	t.Setenv("MYCOMPANY_PYPI_TOKEN", "secret")
+	require.NoError(t, os.WriteFile(filepath.Join(home, "config.yaml"), []byte(packageRegistriesConfig), 0644))
+
+	registries := GetNpmRegistries()
+	require.Len(t, registries, 2, "entries without a URL are skipped")
+	assert.Equal(t, "@mycompany", registries[0].Scope)
+
+	r, ok := NpmRegistryFor("@mycompany/tool")
+	require.True(t, ok)
+	assert.Equal(t, "https://npm.mycompany.com/", r.URL)
+	assert.Equal(t, "inline-npm", r.ResolvedToken(), "falls back to the inline token")
+	t.Setenv("MYCOMPANY_NPM_TOKEN", "from-env")
+	assert.Equal(t, "from-env", r.ResolvedToken())
+
+	r, ok = NpmRegistryFor("internal-cli")
+	require.True(t, ok)
+	assert.Equal(t, "https://npm.internal.example/", r.URL)
+	assert.Empty(t, r.ResolvedToken())
+	_, ok = NpmRegistryFor("@mycompanyx/tool")
+	assert.False(t, ok)
+	_, ok = NpmRegistryFor("prettier")
+	assert.False(t, ok)
+
+	i, ok := PyPIIndexFor("mycompany-tool")
+	require.True(t, ok)
+	assert.Equal(t, "https://pypi.mycompany.com/simple", i.IndexURL)
+	assert.Equal(t, []string{"https://pypi.org/simple"}, i.ExtraIndexURLs)
+	assert.Equal(t, "secret", i.ResolvedToken())
+	_, ok = PyPIIndexFor("black")
+	assert.False(t, ok)
+
+	t.Run("no config", func(t *testing.T) {
+		t.Setenv("ZANA_HOME", t.TempDir())
+		assert.Empty(t, GetNpmRegistries())
+		_, ok := PyPIIndexFor("mycompany-tool")
+		assert.False(t, ok)
+	})
+}
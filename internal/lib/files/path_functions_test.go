@@ -3,10 +3,13 @@ package files
 import (
 	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestPathFunctions tests the path-related functions
@@ -83,6 +86,28 @@ func TestPathFunctionsComprehensive(t *testing.T) {
 		assert.Equal(t, "/envcache", GetCachePath())
 	})
 
+	t.Run("get data share path from ZANA_DATA", func(t *testing.T) {
+		mockFS := &MockFileSystem{
+			fs: afero.NewMemMapFs(),
+			GetenvFunc: func(key string) string {
+				if key == "ZANA_DATA" {
+					return "/envdata"
+				}
+				return ""
+			},
+			UserHomeDirFunc: func() (string, error) { return "/home/user", nil },
+			UserConfigDirFunc: func() (string, error) {
+				return "/home/user/.config", nil
+			},
+		}
+		SetFileSystem(mockFS)
+		defer ResetDependencies()
+
+		assert.Equal(t, "/envdata", GetAppDataSharePath())
+		assert.Equal(t, "/envdata/packages", GetAppPackagesPath())
+		assert.Equal(t, "/envdata/bin", GetAppBinPath())
+	})
+
 	t.Run("get cache path uses config when env not set", func(t *testing.T) {
 		mockFS := &MockFileSystem{
 			fs: afero.NewMemMapFs(),
@@ -188,3 +213,86 @@ func TestPathFunctionsErrorPaths(t *testing.T) {
 		})
 	})
 }
+
+func TestGetConfigFilePath(t *testing.T) {
+	env := map[string]string{"ZANA_HOME": "/data/zana"}
+	mockFS := &MockFileSystem{
+		fs:                afero.NewMemMapFs(),
+		GetenvFunc:        func(key string) string { return env[key] },
+		UserHomeDirFunc:   func() (string, error) { return "/home/user", nil },
+		UserConfigDirFunc: func() (string, error) { return "/home/user/.config", nil },
+	}
+	SetFileSystem(mockFS)
+	defer ResetDependencies()
+
+	// Without any config.yaml the config directory is used, not ZANA_HOME
+	assert.Equal(t, "/home/user/.config/zana/config.yaml", GetConfigFilePath())
+
+	// A config.yaml in ZANA_HOME is still read
+	_ = afero.WriteFile(mockFS.fs, "/data/zana/config.yaml", []byte("ui:\n  color: never\n"), 0o644)
+	assert.Equal(t, "/data/zana/config.yaml", GetConfigFilePath())
+
+	// but the one in the config directory wins
+	_ = afero.WriteFile(mockFS.fs, "/home/user/.config/zana/config.yaml", []byte(""), 0o644)
+	assert.Equal(t, "/home/user/.config/zana/config.yaml", GetConfigFilePath())
+
+	env["XDG_CONFIG_HOME"] = "/dotfiles/config"
+	_ = afero.WriteFile(mockFS.fs, "/dotfiles/config/zana/config.yaml", []byte(""), 0o644)
+	assert.Equal(t, "/dotfiles/config/zana/config.yaml", GetConfigFilePath())
+	env["XDG_CONFIG_HOME"] = "relative/config"
+	assert.Equal(t, "/home/user/.config/zana/config.yaml", GetConfigFilePath(), "relative XDG_CONFIG_HOME is ignored")
+
+	env["ZANA_CONFIG"] = "/etc/zana.yaml"
+	assert.Equal(t, "/etc/zana.yaml", GetConfigFilePath())
+	env["ZANA_CONFIG"] = "~/zana.yaml"
+	assert.Equal(t, "/home/user/zana.yaml", GetConfigFilePath())
+}
+
+func TestGetTempPath(t *testing.T) {
+	env := map[string]string{"ZANA_HOME": "/data/zana"}
+	mockFS := &MockFileSystem{
+		fs:                afero.NewMemMapFs(),
+		GetenvFunc:        func(key string) string { return env[key] },
+		UserHomeDirFunc:   func() (string, error) { return "/home/user", nil },
+		UserConfigDirFunc: func() (string, error) { return "/home/user/.config", nil },
+		TempDirFunc:       func() string { return "/var/tmp" },
+	}
+	SetFileSystem(mockFS)
+	defer ResetDependencies()
+
+	assert.Equal(t, "/var/tmp", GetTempPath())
+
+	_ = afero.WriteFile(mockFS.fs, "/home/user/.config/zana/config.yaml", []byte("paths:\n  tempDir: ~/tmp\n"), 0o644)
+	assert.Equal(t, "/home/user/tmp", GetTempPath())
+
+	env["ZANA_TMP"] = "/scratch"
+	assert.Equal(t, "/scratch", GetTempPath(), "ZANA_TMP wins over config")
+}
+
+func TestMkdirTemp(t *testing.T) {
+	t.Setenv("ZANA_TMP", t.TempDir())
+	prev := tempGetuid
+	defer func() { tempGetuid = prev }()
+	tempGetuid = func() int { return 1000 }
+
+	base := filepath.Join(os.Getenv("ZANA_TMP"), "zana-1000")
+	assert.Equal(t, base, GetAppTempPath())
+
+	a, err := MkdirTemp("test-install")
+	require.NoError(t, err)
+	b, err := MkdirTemp("test-install")
+	require.NoError(t, err)
+	assert.NotEqual(t, a, b)
+	assert.Equal(t, base, filepath.Dir(a))
+	assert.True(t, strings.HasPrefix(filepath.Base(a), "test-install-"))
+
+	f, err := CreateTemp("update")
+	require.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, base, filepath.Dir(f.Name()))
+	assert.True(t, strings.HasPrefix(filepath.Base(f.Name()), "update-"))
+
+	// Windows has no user IDs
+	tempGetuid = func() int { return -1 }
+	assert.Equal(t, filepath.Join(os.Getenv("ZANA_TMP"), "zana"), GetAppTempPath())
+}
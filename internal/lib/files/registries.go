@@ -0,0 +1,258 @@
+package files
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CustomRegistry is a registry of private tools added with zana registry add.
+// Custom registries are stored under registry.custom in config.yaml and are
+// merged after the registry.urls ones, so their entries take precedence.
+type CustomRegistry struct {
+	Name     string `yaml:"name" json:"name"`
+	URL      string `yaml:"url" json:"url"`
+	Disabled bool   `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+	// PublicKey is a base64 encoded ed25519 key. When set, the registry zip
+	// has to come with a valid signature published as <url>.sig.
+	PublicKey string `yaml:"publicKey,omitempty" json:"public_key,omitempty"`
+}
+
+// RegistryInfo describes a registry zana downloads, as shown by zana registry list
+type RegistryInfo struct {
+	Name              string `json:"name"`
+	URL               string `json:"url"`
+	Enabled           bool   `json:"enabled"`
+	SignatureRequired bool   `json:"signature_required"`
+	Custom            bool   `json:"custom"`
+}
+
+var registryNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// GetCustomRegistries returns the registries from registry.custom in config.yaml
+func GetCustomRegistries() []CustomRegistry {
+	cfg, ok := readZanaConfigFile()
+	if !ok {
+		return nil
+	}
+	return cfg.Registry.Custom
+}
+
+// ListRegistries returns the registries from ZANA_REGISTRY_URLS, registry.urls
+// or the built-in default, followed by the custom registries
+func ListRegistries() []RegistryInfo {
+	var list []RegistryInfo
+	name := "registry.urls"
+	switch {
+	case len(splitRegistryURLs(fileSystem.Getenv("ZANA_REGISTRY_URLS"))) > 0:
+		name = "ZANA_REGISTRY_URLS"
+	case len(configuredRegistryURLs()) == 0:
+		name = "default"
+	}
+	for _, u := range baseRegistryURLs() {
+		list = append(list, RegistryInfo{Name: name, URL: u, Enabled: true})
+	}
+	overridden := name == "ZANA_REGISTRY_URLS"
+	for _, r := range GetCustomRegistries() {
+		list = append(list, RegistryInfo{
+			Name:              r.Name,
+			URL:               r.URL,
+			Enabled:           !r.Disabled && !overridden,
+			SignatureRequired: r.PublicKey != "",
+			Custom:            true,
+		})
+	}
+	return list
+}
+
+// AddCustomRegistry adds r to registry.custom in config.yaml
+func AddCustomRegistry(r CustomRegistry) error {
+	if !registryNamePattern.MatchString(r.Name) {
+		return fmt.Errorf("invalid registry name '%s': use lowercase letters, digits, '.', '-' and '_'", r.Name)
+	}
+	if !strings.HasPrefix(r.URL, "https://") && !strings.HasPrefix(r.URL, "http://") {
+		return fmt.Errorf("invalid registry URL '%s': expected an http(s) URL of a registry zip", r.URL)
+	}
+	if r.PublicKey != "" {
+		if _, err := parseRegistryPublicKey(r.PublicKey); err != nil {
+			return err
+		}
+	}
+	registries := GetCustomRegistries()
+	for _, existing := range registries {
+		if existing.Name == r.Name {
+			return fmt.Errorf("registry '%s' already exists", r.Name)
+		}
+	}
+	return saveCustomRegistries(append(registries, r))
+}
+
+// RemoveCustomRegistry removes the custom registry called name
+func RemoveCustomRegistry(name string) error {
+	registries := GetCustomRegistries()
+	for i, r := range registries {
+		if r.Name == name {
+			return saveCustomRegistries(append(registries[:i:i], registries[i+1:]...))
+		}
+	}
+	return fmt.Errorf("registry '%s' not found", name)
+}
+
+// SetCustomRegistryEnabled enables or disables the custom registry called name
+func SetCustomRegistryEnabled(name string, enabled bool) error {
+	registries := GetCustomRegistries()
+	for i := range registries {
+		if registries[i].Name == name {
+			registries[i].Disabled = !enabled
+			return saveCustomRegistries(registries)
+		}
+	}
+	return fmt.Errorf("registry '%s' not found", name)
+}
+
+// saveCustomRegistries replaces registry.custom in config.yaml, keeping the
+// rest of the file (including comments) as it is. The merged registry is
+// dropped, so the next command rebuilds it from the changed set of registries.
+func saveCustomRegistries(registries []CustomRegistry) error {
+	path := GetConfigFilePath()
+	var doc yaml.Node
+	if f, err := fileSystem.OpenFile(path, os.O_RDONLY, 0); err == nil {
+		b, readErr := io.ReadAll(f)
+		_ = fileSystem.Close(f)
+		if readErr != nil {
+			return fmt.Errorf("failed to read %s: %w", path, readErr)
+		}
+		if len(bytes.TrimSpace(b)) > 0 {
+			if err := yaml.Unmarshal(b, &doc); err != nil {
+				return fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+		}
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("failed to update %s: the top level is not a mapping", path)
+	}
+
+	registry := yamlMappingValue(root, "registry")
+	if registry == nil {
+		registry = &yaml.Node{Kind: yaml.MappingNode}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "registry"}, registry)
+	}
+	custom := &yaml.Node{}
+	if err := custom.Encode(registries); err != nil {
+		return err
+	}
+	if existing := yamlMappingValue(registry, "custom"); existing != nil {
+		*existing = *custom
+	} else {
+		registry.Content = append(registry.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "custom"}, custom)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := fileSystem.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	out, err := fileSystem.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if _, err := out.Write(buf.Bytes()); err != nil {
+		_ = fileSystem.Close(out)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := fileSystem.Close(out); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	_ = fileSystem.Remove(GetAppRegistryFilePath())
+	return nil
+}
+
+// yamlMappingValue returns the value of key in mapping, or nil
+func yamlMappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func parseRegistryPublicKey(encoded string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key: expected a base64 encoded ed25519 key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// registryPublicKey returns the key the registry zip at url has to be signed
+// with, or nil when no signature is required
+func registryPublicKey(url string) (ed25519.PublicKey, error) {
+	for _, r := range GetCustomRegistries() {
+		if r.URL == url && r.PublicKey != "" {
+			return parseRegistryPublicKey(r.PublicKey)
+		}
+	}
+	return nil, nil
+}
+
+func registrySignatureURL(url string) string {
+	return url + ".sig"
+}
+
+// verifyRegistrySignature checks the downloaded zip against the signature
+// published next to it, when the registry requires one
+func verifyRegistrySignature(url string, partPath string) error {
+	key, err := registryPublicKey(url)
+	if err != nil || key == nil {
+		return err
+	}
+	resp, err := httpClient.Get(registrySignatureURL(url))
+	if err != nil {
+		return fmt.Errorf("failed to download the registry signature: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("registry %s requires a signature, but %s is not available (%s)", url, registrySignatureURL(url), resp.Status)
+	}
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to download the registry signature: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil {
+		// Also accept the raw 64 byte signature
+		signature = raw
+	}
+
+	f, err := fileSystem.OpenFile(partPath, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	zip, err := io.ReadAll(f)
+	_ = fileSystem.Close(f)
+	if err != nil {
+		return err
+	}
+	if len(signature) != ed25519.SignatureSize || !ed25519.Verify(key, zip, signature) {
+		return fmt.Errorf("registry %s does not match its signature", url)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package files
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomRegistriesRoundTrip(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("ZANA_HOME", home)
+	t.Setenv("ZANA_REGISTRY_URLS", "")
+	configPath := filepath.Join(home, "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("# my settings\nui:\n  color: never\n"), 0644))
+
+	require.NoError(t, AddCustomRegistry(CustomRegistry{Name: "work", URL: "https://example.com/work.zip"}))
+	require.NoError(t, AddCustomRegistry(CustomRegistry{Name: "lab", URL: "https://example.com/lab.zip"}))
+	assert.EqualError(t, AddCustomRegistry(CustomRegistry{Name: "work", URL: "https://example.com/other.zip"}), "registry 'work' already exists")
+	assert.Error(t, AddCustomRegistry(CustomRegistry{Name: "Bad Name", URL: "https://example.com/x.zip"}))
+	assert.Error(t, AddCustomRegistry(CustomRegistry{Name: "ftp", URL: "ftp://example.com/x.zip"}))
+	assert.Error(t, AddCustomRegistry(CustomRegistry{Name: "key", URL: "https://example.com/x.zip", PublicKey: "not-a-key"}))
+
+	assert.Equal(t, []string{defaultRegistryURL(), "https://example.com/work.zip", "https://example.com/lab.zip"}, ResolveRegistryURLs())
+
+	require.NoError(t, SetCustomRegistryEnabled("work", false))
+	assert.Equal(t, []string{defaultRegistryURL(), "https://example.com/lab.zip"}, ResolveRegistryURLs())
+	assert.Equal(t, []RegistryInfo{
+		{Name: "default", URL: defaultRegistryURL(), Enabled: true},
+		{Name: "work", URL: "https://example.com/work.zip", Custom: true},
+		{Name: "lab", URL: "https://example.com/lab.zip", Enabled: true, Custom: true},
+	}, ListRegistries())
+
+	require.NoError(t, RemoveCustomRegistry("lab"))
+	assert.EqualError(t, RemoveCustomRegistry("lab"), "registry 'lab' not found")
+	assert.Equal(t, []CustomRegistry{{Name: "work", URL: "https://example.com/work.zip", Disabled: true}}, GetCustomRegistries())
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# my settings\nui:\n  color: never\n")
+
+	t.Setenv("ZANA_REGISTRY_URLS", "https://mirror.example.com/registry.zip")
+	assert.Equal(t, []string{"https://mirror.example.com/registry.zip"}, ResolveRegistryURLs())
+}
+
+// signedRegistryClient serves a registry zip and its signature
+type signedRegistryClient struct {
+	zip       string
+	signature string
+}
+
+func (c *signedRegistryClient) Get(url string) (*http.Response, error) {
+	switch {
+	case strings.HasSuffix(url, ".sig") && c.signature != "":
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(c.signature))}, nil
+	case strings.HasSuffix(url, ".sig"), strings.HasSuffix(url, ".manifest.json"):
+		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, ContentLength: int64(len(c.zip)), Body: io.NopCloser(strings.NewReader(c.zip))}, nil
+}
+
+func TestDownloadRegistryZipVerifiesSignature(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	const url = "https://example.com/work.zip"
+	signed, tampered := registryZip(t, `[{"name": "signed"}]`), registryZip(t, `[{"name": "tampered"}]`)
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(private, []byte(signed)))
+
+	setup := func(t *testing.T, client *signedRegistryClient) afero.Fs {
+		fs := setupRegistryDownload(t, client)
+		require.NoError(t, AddCustomRegistry(CustomRegistry{Name: "work", URL: url, PublicKey: base64.StdEncoding.EncodeToString(public)}))
+		require.NoError(t, afero.WriteFile(fs, "/cache/registry.zip", []byte("old registry"), 0644))
+		return fs
+	}
+
+	t.Run("accepts a valid signature", func(t *testing.T) {
+		fs := setup(t, &signedRegistryClient{zip: signed, signature: signature})
+		require.NoError(t, downloadRegistryZip(url, "/cache/registry.zip", 0))
+		data, _ := afero.ReadFile(fs, "/cache/registry.zip")
+		assert.Equal(t, signed, string(data))
+	})
+
+	t.Run("rejects a tampered zip", func(t *testing.T) {
+		fs := setup(t, &signedRegistryClient{zip: tampered, signature: signature})
+		assert.EqualError(t, downloadRegistryZip(url, "/cache/registry.zip", 0), "registry https://example.com/work.zip does not match its signature")
+		data, _ := afero.ReadFile(fs, "/cache/registry.zip")
+		assert.Equal(t, "old registry", string(data))
+	})
+
+	t.Run("requires the signature", func(t *testing.T) {
+		setup(t, &signedRegistryClient{zip: signed})
+		err := downloadRegistryZip(url, "/cache/registry.zip", 0)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "requires a signature")
+	})
+}
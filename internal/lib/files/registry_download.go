@@ -0,0 +1,292 @@
+package files
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// registryManifest describes a registry zip. Registries can publish it next
+// to the zip as <url>.manifest.json, e.g. {"size": 1234, "sha256": "ab12..."}.
+type registryManifest struct {
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+func registryManifestURL(url string) string {
+	return url + ".manifest.json"
+}
+
+// registryPartialPath returns where the registry zip for cachePath is
+// downloaded to before it is verified
+func registryPartialPath(cachePath string) string {
+	return cachePath + ".part"
+}
+
+// registryValidatorPath returns where the ETag or Last-Modified of the zip
+// being downloaded to partPath is kept, to resume the download only while
+// the zip is unchanged
+func registryValidatorPath(partPath string) string {
+	return partPath + ".validator"
+}
+
+// removeRegistryPart removes a partial download and its validator
+func removeRegistryPart(partPath string) {
+	_ = fileSystem.Remove(partPath)
+	_ = fileSystem.Remove(registryValidatorPath(partPath))
+}
+
+// readRegistryValidator returns the validator of the partial download at
+// partPath, empty when there is none
+func readRegistryValidator(partPath string) string {
+	f, err := fileSystem.OpenFile(registryValidatorPath(partPath), os.O_RDONLY, 0)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = fileSystem.Close(f) }()
+	data, err := io.ReadAll(io.LimitReader(f, 1024))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// writeRegistryValidator keeps the validator of a download starting at
+// partPath: its strong ETag, else its Last-Modified. Downloads without one
+// can't be resumed safely and have their validator removed.
+func writeRegistryValidator(partPath string, header http.Header) {
+	validator := header.Get("ETag")
+	if strings.HasPrefix(validator, "W/") {
+		// If-Range only takes strong validators
+		validator = ""
+	}
+	if validator == "" {
+		validator = header.Get("Last-Modified")
+	}
+	path := registryValidatorPath(partPath)
+	if validator == "" {
+		_ = fileSystem.Remove(path)
+		return
+	}
+	out, err := fileSystem.Create(path)
+	if err != nil {
+		return
+	}
+	_, _ = fileSystem.WriteString(out, validator)
+	_ = fileSystem.Close(out)
+}
+
+// downloadRegistryZip downloads the registry zip at url to cachePath, unless
+// the cached zip is younger than maxAge. The zip is downloaded to a .part file
+// first, which is resumed when an earlier download was interrupted, and only
+// replaces cachePath once it matches the registry's manifest. When anything
+// fails, the previously downloaded zip stays untouched. Zips of registries
+// added with a public key also have to match their signature. Either way the
+// download has to be a readable zip.
+func downloadRegistryZip(url string, cachePath string, maxAge time.Duration) error {
+	if IsCacheValid(cachePath, maxAge) {
+		return nil
+	}
+
+	partPath := registryPartialPath(cachePath)
+	resumed, err := fetchRegistryPart(url, partPath)
+	if err != nil {
+		return err
+	}
+	manifest, err := fetchRegistryManifest(url)
+	if err != nil {
+		return err
+	}
+	verify := func() error {
+		if err := verifyRegistryPart(url, partPath, manifest); err != nil {
+			return err
+		}
+		return verifyRegistryZip(url, partPath)
+	}
+	if err := verify(); err != nil {
+		removeRegistryPart(partPath)
+		if !resumed {
+			return err
+		}
+		// The interrupted download may have been of an older registry zip
+		if _, err := fetchRegistryPart(url, partPath); err != nil {
+			return err
+		}
+		if err := verify(); err != nil {
+			removeRegistryPart(partPath)
+			return err
+		}
+	}
+	if err := verifyRegistrySignature(url, partPath); err != nil {
+		removeRegistryPart(partPath)
+		return err
+	}
+
+	if err := fileSystem.Rename(partPath, cachePath); err != nil {
+		return err
+	}
+	_ = fileSystem.Remove(registryValidatorPath(partPath))
+	return nil
+}
+
+// fetchRegistryPart downloads url to partPath, continuing an existing partial
+// download when the HTTP client and the server support range requests. The
+// range is asked for with If-Range, so a server whose zip changed since the
+// download started sends all of the new one instead of its tail. Downloads
+// without a validator to send start over. It reports whether the download
+// was resumed.
+func fetchRegistryPart(url string, partPath string) (bool, error) {
+	var offset int64
+	validator := readRegistryValidator(partPath)
+	if info, err := fileSystem.Stat(partPath); err == nil && !cacheBypassed && validator != "" {
+		offset = info.Size()
+	}
+
+	var resp *http.Response
+	var err error
+	if rangeClient, ok := httpClient.(RangeHTTPClient); ok && offset > 0 {
+		resp, err = rangeClient.GetRange(url, offset, validator)
+	} else {
+		offset = 0
+		resp, err = httpClient.Get(url)
+	}
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+		if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			// The server sent a different range than asked for, start over
+			removeRegistryPart(partPath)
+			return fetchRegistryPart(url, partPath)
+		}
+		flag = os.O_WRONLY | os.O_APPEND
+	case offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// The partial download is not a prefix of the current zip
+		removeRegistryPart(partPath)
+		return fetchRegistryPart(url, partPath)
+	case resp.StatusCode >= http.StatusMultipleChoices:
+		return false, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	default:
+		// The whole zip, because the server ignored the range or the zip
+		// changed since the download started
+		offset = 0
+		writeRegistryValidator(partPath, resp.Header)
+	}
+
+	out, err := fileSystem.OpenFile(partPath, flag, 0644)
+	if err != nil {
+		return false, err
+	}
+	written, err := io.Copy(out, resp.Body)
+	if closeErr := fileSystem.Close(out); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return false, err
+	}
+	if resp.ContentLength > 0 && written != resp.ContentLength {
+		// Keep the partial download, the next attempt resumes it
+		return false, fmt.Errorf("download of %s was interrupted after %d of %d bytes", url, written, resp.ContentLength)
+	}
+	return offset > 0, nil
+}
+
+// contentRangeStart returns the first byte of a "bytes 100-199/200" header
+func contentRangeStart(header string) (int64, bool) {
+	rest, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(rest, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	return n, err == nil
+}
+
+// fetchRegistryManifest returns the manifest published next to the registry
+// zip at url, or nil when the registry does not publish one or it can't be
+// fetched
+func fetchRegistryManifest(url string) (*registryManifest, error) {
+	resp, err := httpClient.Get(registryManifestURL(url))
+	if err != nil {
+		return nil, nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil
+	}
+	var manifest registryManifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid registry manifest %s: %w", registryManifestURL(url), err)
+	}
+	return &manifest, nil
+}
+
+// verifyRegistryPart checks the downloaded zip against the manifest
+func verifyRegistryPart(url string, partPath string, manifest *registryManifest) error {
+	if manifest == nil {
+		return nil
+	}
+	f, err := fileSystem.OpenFile(partPath, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	_ = fileSystem.Close(f)
+	if err != nil {
+		return err
+	}
+	if manifest.Size > 0 && size != manifest.Size {
+		return fmt.Errorf("registry %s is %d bytes, its manifest expects %d", url, size, manifest.Size)
+	}
+	if manifest.SHA256 != "" && !strings.EqualFold(hex.EncodeToString(hash.Sum(nil)), manifest.SHA256) {
+		return fmt.Errorf("registry %s does not match the sha256 in its manifest", url)
+	}
+	return nil
+}
+
+// verifyRegistryZip checks that the download at partPath opens as a zip
+// whose files match their checksums, which catches joined downloads of
+// different zips without a manifest
+func verifyRegistryZip(url string, partPath string) error {
+	archive, err := zipFileOpener.Open(partPath)
+	if err == nil {
+		err = readZipFiles(archive.File())
+		_ = archive.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("registry %s is not a valid zip: %w", url, err)
+	}
+	return nil
+}
+
+// readZipFiles reads all files, failing on a checksum mismatch
+func readZipFiles(files []*zip.File) error {
+	for _, file := range files {
+		rc, err := file.Open()
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(io.Discard, rc)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
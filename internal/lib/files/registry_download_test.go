@@ -0,0 +1,292 @@
+package files
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRangeHTTPClient serves a zip and its manifest, honouring range
+// requests whose If-Range matches the zip's ETag
+type mockRangeHTTPClient struct {
+	zip          string
+	etag         string
+	manifest     string
+	manifestErr  error
+	offsets      []int64
+	ifRanges     []string
+	fullRequests int
+}
+
+func (m *mockRangeHTTPClient) Get(url string) (*http.Response, error) {
+	if strings.HasSuffix(url, ".manifest.json") {
+		if m.manifestErr != nil {
+			return nil, m.manifestErr
+		}
+		if m.manifest == "" {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(m.manifest))}, nil
+	}
+	m.fullRequests++
+	header := http.Header{}
+	if m.etag != "" {
+		header.Set("ETag", m.etag)
+	}
+	return &http.Response{StatusCode: http.StatusOK, ContentLength: int64(len(m.zip)), Header: header, Body: io.NopCloser(strings.NewReader(m.zip))}, nil
+}
+
+func (m *mockRangeHTTPClient) GetRange(url string, offset int64, ifRange string) (*http.Response, error) {
+	m.offsets = append(m.offsets, offset)
+	m.ifRanges = append(m.ifRanges, ifRange)
+	if ifRange != m.etag {
+		return m.Get(url)
+	}
+	if offset >= int64(len(m.zip)) {
+		return &http.Response{StatusCode: http.StatusRequestedRangeNotSatisfiable, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+	rest := m.zip[offset:]
+	return &http.Response{
+		StatusCode:    http.StatusPartialContent,
+		ContentLength: int64(len(rest)),
+		Header:        http.Header{"Content-Range": {fmt.Sprintf("bytes %d-%d/%d", offset, len(m.zip)-1, len(m.zip))}},
+		Body:          io.NopCloser(strings.NewReader(rest)),
+	}, nil
+}
+
+func manifestFor(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return fmt.Sprintf(`{"size": %d, "sha256": %q}`, len(content), hex.EncodeToString(sum[:]))
+}
+
+// registryZip returns a registry zip holding registry as zana-registry.json
+func registryZip(t *testing.T, registry string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create("zana-registry.json")
+	require.NoError(t, err)
+	_, err = f.Write([]byte(registry))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.String()
+}
+
+func setupRegistryDownload(t *testing.T, client HTTPClient) afero.Fs {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	SetFileSystem(&MockFileSystem{fs: fs})
+	SetHTTPClient(client)
+	SetZipFileOpener(&MockZipFileOpener{OpenFunc: func(name string) (ZipArchive, error) {
+		data, err := afero.ReadFile(fs, name)
+		if err != nil {
+			return nil, err
+		}
+		r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		return &MockZipArchive{Files: r.File, CloseFunc: func() error { return nil }}, nil
+	}})
+	t.Cleanup(ResetDependencies)
+	require.NoError(t, fs.MkdirAll("/cache", 0755))
+	return fs
+}
+
+func TestDownloadRegistryZip(t *testing.T) {
+	const url = "https://example.com/registry.zip"
+	readCache := func(t *testing.T, fs afero.Fs) string {
+		t.Helper()
+		data, err := afero.ReadFile(fs, "/cache/registry.zip")
+		require.NoError(t, err)
+		return string(data)
+	}
+	// writePart leaves an interrupted download of the zip tagged etag
+	writePart := func(t *testing.T, fs afero.Fs, part, etag string) {
+		t.Helper()
+		require.NoError(t, afero.WriteFile(fs, "/cache/registry.zip.part", []byte(part), 0644))
+		if etag != "" {
+			require.NoError(t, afero.WriteFile(fs, "/cache/registry.zip.part.validator", []byte(etag), 0644))
+		}
+	}
+	oldZip, newZip := registryZip(t, `[{"name": "old"}]`), registryZip(t, `[{"name": "new"}]`)
+
+	t.Run("replaces the cache after verifying the manifest", func(t *testing.T) {
+		client := &mockRangeHTTPClient{zip: newZip, etag: `"v2"`, manifest: manifestFor(newZip)}
+		fs := setupRegistryDownload(t, client)
+		require.NoError(t, afero.WriteFile(fs, "/cache/registry.zip", []byte(oldZip), 0644))
+
+		require.NoError(t, downloadRegistryZip(url, "/cache/registry.zip", 0))
+
+		assert.Equal(t, newZip, readCache(t, fs))
+		for _, leftover := range []string{"/cache/registry.zip.part", "/cache/registry.zip.part.validator"} {
+			exists, _ := afero.Exists(fs, leftover)
+			assert.False(t, exists, leftover)
+		}
+	})
+
+	t.Run("keeps the previous zip when the hash does not match", func(t *testing.T) {
+		tampered := registryZip(t, `[{"name": "tampered!"}]`)
+		client := &mockRangeHTTPClient{zip: tampered, manifest: manifestFor(newZip)}
+		fs := setupRegistryDownload(t, client)
+		require.NoError(t, afero.WriteFile(fs, "/cache/registry.zip", []byte(oldZip), 0644))
+
+		err := downloadRegistryZip(url, "/cache/registry.zip", 0)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), fmt.Sprintf("manifest expects %d", len(newZip)))
+
+		assert.Equal(t, oldZip, readCache(t, fs))
+		exists, _ := afero.Exists(fs, "/cache/registry.zip.part")
+		assert.False(t, exists, "a bad download is not resumed")
+	})
+
+	t.Run("resumes an interrupted download of the same zip", func(t *testing.T) {
+		client := &mockRangeHTTPClient{zip: newZip, etag: `"v2"`, manifest: manifestFor(newZip)}
+		fs := setupRegistryDownload(t, client)
+		writePart(t, fs, newZip[:7], `"v2"`)
+
+		require.NoError(t, downloadRegistryZip(url, "/cache/registry.zip", 0))
+
+		assert.Equal(t, []int64{7}, client.offsets)
+		assert.Equal(t, []string{`"v2"`}, client.ifRanges)
+		assert.Zero(t, client.fullRequests)
+		assert.Equal(t, newZip, readCache(t, fs))
+	})
+
+	t.Run("downloads the whole zip when it changed since the download started", func(t *testing.T) {
+		client := &mockRangeHTTPClient{zip: newZip, etag: `"v2"`}
+		fs := setupRegistryDownload(t, client)
+		writePart(t, fs, oldZip[:len(oldZip)/2], `"v1"`)
+
+		require.NoError(t, downloadRegistryZip(url, "/cache/registry.zip", 0))
+
+		assert.Equal(t, []string{`"v1"`}, client.ifRanges)
+		assert.Equal(t, newZip, readCache(t, fs))
+	})
+
+	t.Run("does not resume downloads without a validator", func(t *testing.T) {
+		client := &mockRangeHTTPClient{zip: newZip}
+		fs := setupRegistryDownload(t, client)
+		writePart(t, fs, oldZip[:len(oldZip)/2], "")
+
+		require.NoError(t, downloadRegistryZip(url, "/cache/registry.zip", 0))
+
+		assert.Empty(t, client.offsets)
+		assert.Equal(t, newZip, readCache(t, fs))
+	})
+
+	t.Run("starts over when the resumed download does not verify", func(t *testing.T) {
+		client := &mockRangeHTTPClient{zip: newZip, etag: `"v2"`, manifest: manifestFor(newZip)}
+		fs := setupRegistryDownload(t, client)
+		writePart(t, fs, oldZip[:7]+"x", `"v2"`)
+
+		require.NoError(t, downloadRegistryZip(url, "/cache/registry.zip", 0))
+
+		assert.Equal(t, newZip, readCache(t, fs))
+	})
+
+	t.Run("starts over when the resumed download is not a zip", func(t *testing.T) {
+		// Without a manifest only reading the zip catches the prefix of
+		// another zip, here one the server didn't change the ETag of
+		client := &mockRangeHTTPClient{zip: newZip, etag: `"v2"`}
+		fs := setupRegistryDownload(t, client)
+		writePart(t, fs, oldZip[:strings.Index(oldZip, "old")+3], `"v2"`)
+
+		require.NoError(t, downloadRegistryZip(url, "/cache/registry.zip", 0))
+
+		assert.Equal(t, 1, client.fullRequests)
+		assert.Equal(t, newZip, readCache(t, fs))
+	})
+
+	t.Run("keeps the previous zip when the download is not a zip", func(t *testing.T) {
+		client := &mockRangeHTTPClient{zip: "<html>maintenance</html>"}
+		fs := setupRegistryDownload(t, client)
+		require.NoError(t, afero.WriteFile(fs, "/cache/registry.zip", []byte(oldZip), 0644))
+
+		err := downloadRegistryZip(url, "/cache/registry.zip", 0)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is not a valid zip")
+		assert.Equal(t, oldZip, readCache(t, fs))
+	})
+
+	t.Run("treats an unreachable manifest as missing", func(t *testing.T) {
+		client := &mockRangeHTTPClient{zip: newZip, manifestErr: errors.New("connection reset")}
+		fs := setupRegistryDownload(t, client)
+
+		require.NoError(t, downloadRegistryZip(url, "/cache/registry.zip", 0))
+
+		assert.Equal(t, newZip, readCache(t, fs))
+	})
+
+	t.Run("keeps a truncated download for resuming", func(t *testing.T) {
+		client := &MockHTTPClient{GetFunc: func(url string) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, ContentLength: 100, Body: io.NopCloser(strings.NewReader("new"))}, nil
+		}}
+		fs := setupRegistryDownload(t, client)
+		require.NoError(t, afero.WriteFile(fs, "/cache/registry.zip", []byte("old registry"), 0644))
+
+		err := downloadRegistryZip(url, "/cache/registry.zip", 0)
+		assert.EqualError(t, err, "download of https://example.com/registry.zip was interrupted after 3 of 100 bytes")
+
+		part, err := afero.ReadFile(fs, "/cache/registry.zip.part")
+		require.NoError(t, err)
+		assert.Equal(t, "new", string(part))
+		assert.Equal(t, "old registry", readCache(t, fs))
+	})
+
+	t.Run("rejects error responses", func(t *testing.T) {
+		client := &MockHTTPClient{GetFunc: func(url string) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(strings.NewReader("not found"))}, nil
+		}}
+		fs := setupRegistryDownload(t, client)
+
+		err := downloadRegistryZip(url, "/cache/registry.zip", 0)
+		assert.EqualError(t, err, "failed to download https://example.com/registry.zip: 404 Not Found")
+		exists, _ := afero.Exists(fs, "/cache/registry.zip")
+		assert.False(t, exists)
+	})
+}
+
+func TestContentRangeStart(t *testing.T) {
+	start, ok := contentRangeStart("bytes 100-199/200")
+	assert.True(t, ok)
+	assert.Equal(t, int64(100), start)
+
+	_, ok = contentRangeStart("items 1-2/3")
+	assert.False(t, ok)
+	_, ok = contentRangeStart("bytes */200")
+	assert.False(t, ok)
+}
+
+func TestDownloadAndUnzipRegistryKeepsPreviousZip(t *testing.T) {
+	fs := setupRegistryDownload(t, &MockHTTPClient{GetFunc: func(url string) (*http.Response, error) {
+		return nil, errors.New("network down")
+	}})
+
+	cachePath := GetRegistryCachePath()
+	require.NoError(t, afero.WriteFile(fs, cachePath, []byte("previous zip"), 0644))
+	old := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, fs.Chtimes(cachePath, old, old))
+
+	SetZipFileOpener(&MockZipFileOpener{OpenFunc: func(name string) (ZipArchive, error) {
+		return createRealZipArchive(map[string]string{"zana-registry.json": `[]`})
+	}})
+
+	require.NoError(t, DownloadAndUnzipRegistry())
+
+	data, err := afero.ReadFile(fs, cachePath)
+	require.NoError(t, err)
+	assert.Equal(t, "previous zip", string(data))
+}
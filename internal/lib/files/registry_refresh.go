@@ -0,0 +1,72 @@
+package files
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+)
+
+// RegistryRefreshPolicy decides when DownloadAndUnzipRegistry downloads the
+// registry again
+type RegistryRefreshPolicy string
+
+const (
+	// RegistryRefreshAuto downloads the registry when it's older than
+	// registry.cacheMaxAge, plus a random jitter
+	RegistryRefreshAuto RegistryRefreshPolicy = "auto"
+	// RegistryRefreshAlways downloads the registry on every command (--refresh)
+	RegistryRefreshAlways RegistryRefreshPolicy = "always"
+	// RegistryRefreshNever keeps using the downloaded registry (--no-refresh).
+	// It's still downloaded when there is none yet.
+	RegistryRefreshNever RegistryRefreshPolicy = "never"
+)
+
+var registryRefreshPolicy = RegistryRefreshAuto
+
+// SetRegistryRefreshPolicy sets the policy of the following registry downloads
+func SetRegistryRefreshPolicy(policy RegistryRefreshPolicy) {
+	registryRefreshPolicy = policy
+}
+
+// getRegistryRefreshJitter returns how much later than maxAge the registry
+// is refreshed at most. Spreading the refreshes keeps many machines started
+// at the same time (e.g. CI runners) from downloading it all at once.
+// Defaults to a tenth of maxAge, registry.refreshJitter in config.yaml
+// overrides it.
+func getRegistryRefreshJitter(maxAge time.Duration) time.Duration {
+	if cfg, ok := readZanaConfigFile(); ok {
+		if raw := strings.TrimSpace(cfg.Registry.RefreshJitter); raw != "" {
+			if parsed, err := time.ParseDuration(raw); err == nil {
+				if parsed < 0 {
+					return 0
+				}
+				return parsed
+			}
+		}
+	}
+	return maxAge / 10
+}
+
+// registryRefreshMaxAge returns the age at which the downloaded registry is
+// refreshed under the current policy
+func registryRefreshMaxAge() time.Duration {
+	if cacheBypassed {
+		return 0
+	}
+	switch registryRefreshPolicy {
+	case RegistryRefreshAlways:
+		return 0
+	case RegistryRefreshNever:
+		return math.MaxInt64
+	}
+	maxAge := getRegistryCacheMaxAge()
+	if maxAge <= 0 {
+		return maxAge
+	}
+	if jitter := getRegistryRefreshJitter(maxAge); jitter > 0 {
+		maxAge += clock.Jitter(jitter)
+	}
+	return maxAge
+}
@@ -0,0 +1,54 @@
+package files
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryRefreshMaxAge(t *testing.T) {
+	mockFS := &MockFileSystem{
+		fs:                afero.NewMemMapFs(),
+		GetenvFunc:        func(string) string { return "" },
+		UserHomeDirFunc:   func() (string, error) { return "/home/user", nil },
+		UserConfigDirFunc: func() (string, error) { return "/home/user/.config", nil },
+	}
+	SetFileSystem(mockFS)
+	defer func() {
+		ResetDependencies()
+		SetRegistryRefreshPolicy(RegistryRefreshAuto)
+	}()
+	var jitterBound time.Duration
+	fake := clock.NewFake(time.Now())
+	fake.RandFunc = func(n int64) int64 {
+		jitterBound = time.Duration(n)
+		return n - 1
+	}
+	defer clock.Set(fake)()
+
+	assert.Equal(t, 6*time.Hour+36*time.Minute-1, registryRefreshMaxAge(), "jitter defaults to a tenth of the max age")
+	assert.Equal(t, 36*time.Minute, jitterBound)
+
+	_ = afero.WriteFile(mockFS.fs, "/home/user/.config/zana/config.yaml", []byte("registry:\n  cacheMaxAge: 1h\n  refreshJitter: 0\n"), 0o644)
+	assert.Equal(t, time.Hour, registryRefreshMaxAge())
+
+	_ = afero.WriteFile(mockFS.fs, "/home/user/.config/zana/config.yaml", []byte("registry:\n  cacheMaxAge: 1h\n  refreshJitter: 30m\n"), 0o644)
+	assert.Equal(t, 90*time.Minute-1, registryRefreshMaxAge())
+
+	_ = afero.WriteFile(mockFS.fs, "/home/user/.config/zana/config.yaml", []byte("registry:\n  cacheMaxAge: 0\n"), 0o644)
+	assert.Equal(t, time.Duration(0), registryRefreshMaxAge(), "no jitter when the registry is never cached")
+
+	SetRegistryRefreshPolicy(RegistryRefreshAlways)
+	assert.Equal(t, time.Duration(0), registryRefreshMaxAge())
+
+	SetRegistryRefreshPolicy(RegistryRefreshNever)
+	assert.Equal(t, time.Duration(math.MaxInt64), registryRefreshMaxAge())
+
+	SetCacheBypass(true)
+	defer SetCacheBypass(false)
+	assert.Equal(t, time.Duration(0), registryRefreshMaxAge(), "--no-cache downloads the registry again")
+}
@@ -10,9 +10,12 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/spinnerutil"
 	"github.com/spf13/afero"
 	"gopkg.in/yaml.v3"
@@ -24,6 +27,8 @@ type FileSystem interface {
 	MkdirAll(path string, perm os.FileMode) error
 	OpenFile(name string, flag int, perm os.FileMode) (afero.File, error)
 	Stat(name string) (os.FileInfo, error)
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
 	UserConfigDir() (string, error)
 	UserHomeDir() (string, error)
 	TempDir() string
@@ -37,6 +42,13 @@ type HTTPClient interface {
 	Get(url string) (*http.Response, error)
 }
 
+// RangeHTTPClient is implemented by HTTP clients that can resume downloads.
+// Clients without it always download from the start. ifRange is the ETag or
+// Last-Modified of the download being resumed, sent as If-Range.
+type RangeHTTPClient interface {
+	GetRange(url string, offset int64, ifRange string) (*http.Response, error)
+}
+
 // ZipArchive is an interface that abstracts the functionality
 // of a *zip.ReadCloser.
 type ZipArchive interface {
@@ -70,6 +82,14 @@ func (d *defaultFileSystem) Stat(name string) (os.FileInfo, error) {
 	return d.fs.Stat(name)
 }
 
+func (d *defaultFileSystem) Rename(oldpath, newpath string) error {
+	return d.fs.Rename(oldpath, newpath)
+}
+
+func (d *defaultFileSystem) Remove(name string) error {
+	return d.fs.Remove(name)
+}
+
 func (d *defaultFileSystem) UserConfigDir() (string, error) {
 	return os.UserConfigDir()
 }
@@ -101,6 +121,16 @@ func (d *defaultHTTPClient) Get(url string) (*http.Response, error) {
 	return http.Get(url)
 }
 
+func (d *defaultHTTPClient) GetRange(url string, offset int64, ifRange string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	req.Header.Set("If-Range", ifRange)
+	return http.DefaultClient.Do(req)
+}
+
 // RealZipArchive is a wrapper for a real *zip.ReadCloser
 type RealZipArchive struct {
 	*zip.ReadCloser
@@ -189,6 +219,12 @@ func GetAppLocalPackagesFilePath() string {
 	return GetAppDataPath() + string(os.PathSeparator) + "zana-lock.json"
 }
 
+// GetLogFilePath returns the path to the log file
+// e.g. /home/user/.config/zana/zana.log
+func GetLogFilePath() string {
+	return GetAppDataPath() + string(os.PathSeparator) + "zana.log"
+}
+
 func FileExists(path string) bool {
 	if path == "" {
 		return false
@@ -216,12 +252,6 @@ func GetAppDataPath() string {
 	return EnsureDirExists(userConfigDir + string(os.PathSeparator) + "zana")
 }
 
-// GetTempPath returns the path to the temp directory
-// e.g. /tmp
-func GetTempPath() string {
-	return fileSystem.TempDir()
-}
-
 // GetAppRegistryFilePath returns the path to the registry file
 // e.g. /home/user/.cache/zana/zana-registry.json
 func GetAppRegistryFilePath() string {
@@ -237,13 +267,30 @@ func GetAppPackagesPath() string {
 	return EnsureDirExists(GetAppDataSharePath() + string(os.PathSeparator) + "packages")
 }
 
+// GetAppStagingPath returns the path packages are downloaded and extracted in
+// before they are moved into the packages directory
+// e.g. /home/user/.local/share/zana/staging
+func GetAppStagingPath() string {
+	return EnsureDirExists(GetAppDataSharePath() + string(os.PathSeparator) + "staging")
+}
+
+// GetAppPluginsPath returns the directory zana-<name> plugin executables are discovered in
+// e.g. /home/user/.config/zana/plugins
+func GetAppPluginsPath() string {
+	return GetAppDataPath() + string(os.PathSeparator) + "plugins"
+}
+
 // GetAppDataSharePath returns the path to the app data share directory
 // This is separate from the config directory and follows XDG Base Directory spec
+// If the ZANA_DATA environment variable is set, it will use that path
 // Otherwise:
 //   - Linux: ~/.local/share/zana
 //   - macOS: ~/Library/Application Support/zana (same as config)
 //   - Windows: %APPDATA%\zana (same as config)
 func GetAppDataSharePath() string {
+	if zanaData := fileSystem.Getenv("ZANA_DATA"); zanaData != "" {
+		return EnsureDirExists(zanaData)
+	}
 	// On Linux, use ~/.local/share, otherwise use config dir (macOS/Windows)
 	userConfigDir, err := fileSystem.UserConfigDir()
 	if err != nil {
@@ -265,6 +312,12 @@ func GetAppDataSharePath() string {
 	return EnsureDirExists(userConfigDir + string(os.PathSeparator) + "zana")
 }
 
+// GetAppSharePath returns the path content of data-only packages is installed into
+// e.g. /home/user/.local/share/zana/share
+func GetAppSharePath() string {
+	return EnsureDirExists(GetAppDataSharePath() + string(os.PathSeparator) + "share")
+}
+
 // GetAppBinPath returns the path to the bin directory
 // Otherwise:
 //   - Linux: ~/.local/share/zana/bin
@@ -423,7 +476,7 @@ func IsCacheValid(cachePath string, maxAge time.Duration) bool {
 	}
 
 	// Check if the file is older than maxAge
-	return time.Since(fileInfo.ModTime()) < maxAge
+	return clock.Since(fileInfo.ModTime()) < maxAge
 }
 
 // DownloadWithCache downloads a file with caching support
@@ -464,13 +517,50 @@ func DownloadWithCache(url string, cachePath string, maxAge time.Duration) error
 
 type zanaConfigFile struct {
 	Registry struct {
-		URLs        []string `yaml:"urls"`
-		CacheMaxAge string   `yaml:"cacheMaxAge"`
+		URLs          []string         `yaml:"urls"`
+		CacheMaxAge   string           `yaml:"cacheMaxAge"`
+		RefreshJitter string           `yaml:"refreshJitter"`
+		Custom        []CustomRegistry `yaml:"custom"`
 	} `yaml:"registry"`
 
 	Paths struct {
-		CacheDir string `yaml:"cacheDir"`
+		CacheDir         string                     `yaml:"cacheDir"`
+		TempDir          string                     `yaml:"tempDir"`
+		BinLayout        string                     `yaml:"binLayout"`
+		BinProviderOrder []string                   `yaml:"binProviderOrder"`
+		BinFilters       map[string]BinFilterConfig `yaml:"binFilters"`
 	} `yaml:"paths"`
+
+	Install struct {
+		ProviderPriority     []string `yaml:"providerPriority"`
+		SmokeTests           bool     `yaml:"smokeTests"`
+		DarwinArchPreference []string `yaml:"darwinArchPreference"`
+		ConfirmDownloadSize  string   `yaml:"confirmDownloadSize"`
+		KeepVersions         int      `yaml:"keepVersions"`
+		KeepVersionsDays     int      `yaml:"keepVersionsDays"`
+	} `yaml:"install"`
+
+	Providers struct {
+		Disabled []string `yaml:"disabled"`
+	} `yaml:"providers"`
+
+	GitHub struct {
+		RequestsPerSecond string `yaml:"requestsPerSecond"`
+	} `yaml:"github"`
+
+	Npm struct {
+		Registries []NpmRegistryConfig `yaml:"registries"`
+	} `yaml:"npm"`
+
+	PyPI struct {
+		Indexes []PyPIIndexConfig `yaml:"indexes"`
+	} `yaml:"pypi"`
+
+	Hardening SubprocessHardening `yaml:"hardening"`
+
+	Metrics MetricsConfig `yaml:"metrics"`
+
+	Wrappers CommandWrappers `yaml:"wrappers"`
 }
 
 func expandUserAndRelativePath(p string) string {
@@ -501,12 +591,46 @@ func expandUserAndRelativePath(p string) string {
 	return p
 }
 
-func getConfigFilePath() string {
-	return GetAppDataPath() + string(os.PathSeparator) + "config.yaml"
+// GetConfigFilePath returns the path to config.yaml, looked up in this order:
+//  1. ZANA_CONFIG (set by the --config flag)
+//  2. $XDG_CONFIG_HOME/zana/config.yaml, or the user config directory without
+//     XDG_CONFIG_HOME (e.g. ~/.config/zana/config.yaml)
+//  3. config.yaml in ZANA_HOME, where older versions looked for it
+//
+// ZANA_HOME only moves the data, so a config.yaml there is used only when
+// the config directory has none. Without any config.yaml this returns 2.
+func GetConfigFilePath() string {
+	if p := strings.TrimSpace(fileSystem.Getenv("ZANA_CONFIG")); p != "" {
+		return expandUserAndRelativePath(p)
+	}
+	path := filepath.Join(GetConfigPath(), "config.yaml")
+	if _, err := fileSystem.Stat(path); err == nil {
+		return path
+	}
+	if zanaHome := fileSystem.Getenv("ZANA_HOME"); zanaHome != "" {
+		legacy := filepath.Join(zanaHome, "config.yaml")
+		if _, err := fileSystem.Stat(legacy); err == nil {
+			return legacy
+		}
+	}
+	return path
+}
+
+// GetConfigPath returns the zana config directory, independent of ZANA_HOME
+// e.g. /home/user/.config/zana
+func GetConfigPath() string {
+	if xdg := strings.TrimSpace(fileSystem.Getenv("XDG_CONFIG_HOME")); xdg != "" && filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, "zana")
+	}
+	userConfigDir, err := fileSystem.UserConfigDir()
+	if err != nil {
+		return GetAppDataPath()
+	}
+	return filepath.Join(userConfigDir, "zana")
 }
 
 func readZanaConfigFile() (zanaConfigFile, bool) {
-	path := getConfigFilePath()
+	path := GetConfigFilePath()
 	f, err := fileSystem.OpenFile(path, os.O_RDONLY, 0)
 	if err != nil {
 		return zanaConfigFile{}, false
@@ -525,6 +649,39 @@ func readZanaConfigFile() (zanaConfigFile, bool) {
 	return cfg, true
 }
 
+// GetProviderPriority returns the provider names from install.providerPriority in config.yaml.
+// When a bare package name matches entries of several providers and there is no
+// terminal to ask, the entry of the provider listed first is installed.
+func GetProviderPriority() []string {
+	cfg, ok := readZanaConfigFile()
+	if !ok {
+		return nil
+	}
+	priority := make([]string, 0, len(cfg.Install.ProviderPriority))
+	for _, p := range cfg.Install.ProviderPriority {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			priority = append(priority, p)
+		}
+	}
+	return priority
+}
+
+// GetDisabledProviders returns the provider names from providers.disabled in config.yaml.
+// Packages of these providers can't be installed, updated or synced.
+func GetDisabledProviders() []string {
+	cfg, ok := readZanaConfigFile()
+	if !ok {
+		return nil
+	}
+	disabled := make([]string, 0, len(cfg.Providers.Disabled))
+	for _, p := range cfg.Providers.Disabled {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			disabled = append(disabled, p)
+		}
+	}
+	return disabled
+}
+
 func getRegistryCacheMaxAge() time.Duration {
 	// Default is intentionally short to reduce the chance of users seeing stale registry data
 	// without having to manually `zana sync registry`.
@@ -578,28 +735,47 @@ func splitRegistryURLs(raw string) []string {
 
 // ResolveRegistryURLs returns registry URLs in priority order:
 // 1) ZANA_REGISTRY_URLS (comma/space-separated list)
-// 2) config.yaml registry.urls (array)
-// 3) built-in default
+// 2) config.yaml registry.urls (array), followed by the enabled registry.custom entries
+// 3) built-in default, followed by the enabled registry.custom entries
 func ResolveRegistryURLs() []string {
 	if override := splitRegistryURLs(fileSystem.Getenv("ZANA_REGISTRY_URLS")); len(override) > 0 {
 		return override
 	}
-	if cfg, ok := readZanaConfigFile(); ok {
-		if len(cfg.Registry.URLs) > 0 {
-			urls := make([]string, 0, len(cfg.Registry.URLs))
-			for _, u := range cfg.Registry.URLs {
-				if s := strings.TrimSpace(u); s != "" {
-					urls = append(urls, s)
-				}
-			}
-			if len(urls) > 0 {
-				return urls
-			}
+	urls := baseRegistryURLs()
+	for _, r := range GetCustomRegistries() {
+		if !r.Disabled && !slices.Contains(urls, r.URL) {
+			urls = append(urls, r.URL)
 		}
 	}
+	return urls
+}
+
+// baseRegistryURLs returns the registry URLs without the custom registries
+func baseRegistryURLs() []string {
+	if override := splitRegistryURLs(fileSystem.Getenv("ZANA_REGISTRY_URLS")); len(override) > 0 {
+		return override
+	}
+	if urls := configuredRegistryURLs(); len(urls) > 0 {
+		return urls
+	}
 	return []string{defaultRegistryURL()}
 }
 
+// configuredRegistryURLs returns registry.urls from config.yaml
+func configuredRegistryURLs() []string {
+	cfg, ok := readZanaConfigFile()
+	if !ok {
+		return nil
+	}
+	urls := make([]string, 0, len(cfg.Registry.URLs))
+	for _, u := range cfg.Registry.URLs {
+		if s := strings.TrimSpace(u); s != "" {
+			urls = append(urls, s)
+		}
+	}
+	return urls
+}
+
 func downloadWithCacheFromURLs(urls []string, cachePath string, maxAge time.Duration) error {
 	// Check if cache is valid once
 	if IsCacheValid(cachePath, maxAge) {
@@ -680,22 +856,6 @@ type registryItemKey struct {
 	} `json:"source"`
 }
 
-func normalizeRegistrySourceID(id string) string {
-	// New format: "<provider>:<id>"
-	if strings.Contains(id, ":") && !strings.HasPrefix(id, "pkg:") {
-		return id
-	}
-	// Legacy format: "pkg:<provider>/<id>"
-	if strings.HasPrefix(id, "pkg:") {
-		withoutPrefix := strings.TrimPrefix(id, "pkg:")
-		parts := strings.SplitN(withoutPrefix, "/", 2)
-		if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
-			return parts[0] + ":" + parts[1]
-		}
-	}
-	return id
-}
-
 func mergeRegistryJSONArrays(registryJSONs [][]byte) ([]byte, error) {
 	type entry struct {
 		key string
@@ -716,7 +876,7 @@ func mergeRegistryJSONArrays(registryJSONs [][]byte) ([]byte, error) {
 			var k registryItemKey
 			_ = json.Unmarshal(raw, &k) // best-effort; key fallback below
 
-			key := normalizeRegistrySourceID(strings.TrimSpace(k.Source.ID))
+			key := packageid.Normalize(strings.TrimSpace(k.Source.ID))
 			if key == "" {
 				key = strings.TrimSpace(k.Name)
 			}
@@ -749,11 +909,17 @@ func mergeRegistryJSONArrays(registryJSONs [][]byte) ([]byte, error) {
 }
 
 // DownloadAndUnzipRegistry downloads the registry from the default URL and unzips it
-// This is used to ensure the registry is available for commands that need it
+// This is used to ensure the registry is available for commands that need it.
+// Whether a downloaded registry is refreshed follows SetRegistryRefreshPolicy.
 func DownloadAndUnzipRegistry() error {
+	return downloadAndUnzipRegistry(registryRefreshMaxAge())
+}
+
+// downloadAndUnzipRegistry downloads the registry zips older than cacheMaxAge
+// and merges the registries into zana-registry.json
+func downloadAndUnzipRegistry(cacheMaxAge time.Duration) error {
 	registryURLs := ResolveRegistryURLs()
 	registryJSONPath := GetAppRegistryFilePath()
-	cacheMaxAge := getRegistryCacheMaxAge()
 
 	if len(registryURLs) == 0 {
 		registryURLs = []string{defaultRegistryURL()}
@@ -791,11 +957,17 @@ func DownloadAndUnzipRegistry() error {
 	}
 
 	// Download all zips with spinner (only those that need it).
+	// A registry that fails to download keeps using its previous zip, if any.
 	var downloadErr error
+	var staleErrs []error
 	action := func() {
 		for i, u := range registryURLs {
 			p := cachePaths[i]
-			if err := DownloadWithCache(u, p, cacheMaxAge); err != nil {
+			if err := downloadRegistryZip(u, p, cacheMaxAge); err != nil {
+				if _, statErr := fileSystem.Stat(p); statErr == nil {
+					staleErrs = append(staleErrs, err)
+					continue
+				}
 				downloadErr = err
 				return
 			}
@@ -809,6 +981,9 @@ func DownloadAndUnzipRegistry() error {
 	if downloadErr != nil {
 		return fmt.Errorf("failed to download registry: %w", downloadErr)
 	}
+	for _, err := range staleErrs {
+		fmt.Fprintf(os.Stderr, "Warning: failed to update the registry, using the previous download: %v\n", err)
+	}
 
 	// Unzip each registry and merge them into a single zana-registry.json for consumers.
 	registryJSONName := filepath.Base(GetAppRegistryFilePath())
@@ -864,7 +1039,7 @@ func DownloadAndUnzipRegistryForced() error {
 	action := func() {
 		for i, u := range registryURLs {
 			p := registryCachePathForURL(u, i)
-			if err := DownloadWithCache(u, p, 0); err != nil {
+			if err := downloadRegistryZip(u, p, 0); err != nil {
 				downloadErr = err
 				return
 			}
@@ -881,5 +1056,5 @@ func DownloadAndUnzipRegistryForced() error {
 
 	// Reuse the non-forced merge/unzip path now that zips are fresh.
 	// (It will merge and write the final JSON.)
-	return DownloadAndUnzipRegistry()
+	return downloadAndUnzipRegistry(getRegistryCacheMaxAge())
 }
@@ -0,0 +1,21 @@
+package files
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SmokeTestsEnabled reports whether the smoke tests registry entries declare
+// run after installs and updates.
+// Order of precedence:
+//   - ZANA_SMOKE_TESTS environment variable (true/false, 1/0)
+//   - install.smokeTests in config.yaml
+//   - disabled
+func SmokeTestsEnabled() bool {
+	if raw := strings.TrimSpace(fileSystem.Getenv("ZANA_SMOKE_TESTS")); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		return err == nil && enabled
+	}
+	cfg, ok := readZanaConfigFile()
+	return ok && cfg.Install.SmokeTests
+}
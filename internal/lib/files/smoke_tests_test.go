@@ -0,0 +1,30 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSmokeTestsEnabled(t *testing.T) {
+	env := map[string]string{}
+	mockFS := &MockFileSystem{
+		fs:                afero.NewMemMapFs(),
+		GetenvFunc:        func(key string) string { return env[key] },
+		UserHomeDirFunc:   func() (string, error) { return "/home/user", nil },
+		UserConfigDirFunc: func() (string, error) { return "/home/user/.config", nil },
+	}
+	SetFileSystem(mockFS)
+	defer ResetDependencies()
+
+	assert.False(t, SmokeTestsEnabled(), "disabled by default")
+
+	_ = afero.WriteFile(mockFS.fs, "/home/user/.config/zana/config.yaml", []byte("install:\n  smokeTests: true\n"), 0o644)
+	assert.True(t, SmokeTestsEnabled())
+
+	env["ZANA_SMOKE_TESTS"] = "0"
+	assert.False(t, SmokeTestsEnabled(), "ZANA_SMOKE_TESTS overrides install.smokeTests")
+	env["ZANA_SMOKE_TESTS"] = "maybe"
+	assert.False(t, SmokeTestsEnabled())
+}
@@ -0,0 +1,364 @@
+package files
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+)
+
+const (
+	snapshotManifestFile = "manifest.json"
+	snapshotLockFile     = "zana-lock.json"
+	snapshotPackagesDir  = "packages"
+	snapshotBinDir       = "bin"
+)
+
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// SnapshotPackage is a package recorded in a snapshot's lock file
+type SnapshotPackage struct {
+	SourceID string `json:"sourceId"`
+	Version  string `json:"version"`
+}
+
+// SnapshotManifest describes the state of ZANA_HOME captured by a snapshot
+type SnapshotManifest struct {
+	Name      string            `json:"name"`
+	CreatedAt time.Time         `json:"createdAt"`
+	Packages  []SnapshotPackage `json:"packages"`
+	// Providers maps each provider to the entries of its packages directory
+	Providers map[string][]string `json:"providers"`
+	// Bin lists the entries of the bin directory
+	Bin []string `json:"bin"`
+	// Content is true when the package and bin directories were captured
+	// (copied) and can be restored without reinstalling anything.
+	Content bool `json:"content"`
+}
+
+// GetSnapshotsPath returns the path to the snapshots directory.
+// It lives next to the packages directory, on the same file system, so
+// restored package content can be renamed into place.
+// e.g. /home/user/.local/share/zana/snapshots
+func GetSnapshotsPath() string {
+	return EnsureDirExists(GetAppDataSharePath() + string(os.PathSeparator) + "snapshots")
+}
+
+func snapshotPath(name string) (string, error) {
+	if !snapshotNamePattern.MatchString(name) || strings.HasSuffix(name, ".tmp") {
+		return "", fmt.Errorf("invalid snapshot name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	return filepath.Join(GetSnapshotsPath(), name), nil
+}
+
+// readDirNames returns the sorted entry names of dir, or nil when it doesn't exist
+func readDirNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// CreateSnapshot captures the lock file and a manifest of the provider state
+// under name. With content, the package and bin directories are copied as well.
+func CreateSnapshot(name string, content bool) (SnapshotManifest, error) {
+	dir, err := snapshotPath(name)
+	if err != nil {
+		return SnapshotManifest{}, err
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return SnapshotManifest{}, fmt.Errorf("snapshot %s already exists", name)
+	}
+
+	lock, err := os.ReadFile(GetAppLocalPackagesFilePath())
+	if err != nil && !os.IsNotExist(err) {
+		return SnapshotManifest{}, fmt.Errorf("failed to read lock file: %w", err)
+	}
+	var lockRoot struct {
+		Packages []SnapshotPackage `json:"packages"`
+	}
+	if len(lock) > 0 {
+		if err := json.Unmarshal(lock, &lockRoot); err != nil {
+			return SnapshotManifest{}, fmt.Errorf("failed to parse lock file: %w", err)
+		}
+	}
+
+	manifest := SnapshotManifest{
+		Name:      name,
+		CreatedAt: clock.Now().UTC(),
+		Packages:  lockRoot.Packages,
+		Providers: map[string][]string{},
+		Content:   content,
+	}
+	packagesPath := GetAppPackagesPath()
+	providers, err := readDirNames(packagesPath)
+	if err != nil {
+		return SnapshotManifest{}, fmt.Errorf("failed to read packages directory: %w", err)
+	}
+	for _, provider := range providers {
+		if info, err := os.Stat(filepath.Join(packagesPath, provider)); err != nil || !info.IsDir() {
+			continue
+		}
+		entries, err := readDirNames(filepath.Join(packagesPath, provider))
+		if err != nil {
+			return SnapshotManifest{}, fmt.Errorf("failed to read packages of %s: %w", provider, err)
+		}
+		manifest.Providers[provider] = entries
+	}
+	if manifest.Bin, err = readDirNames(GetAppBinPath()); err != nil {
+		return SnapshotManifest{}, fmt.Errorf("failed to read bin directory: %w", err)
+	}
+
+	// Build the snapshot in a temporary directory so a failure leaves nothing behind
+	tmp := dir + ".tmp"
+	_ = os.RemoveAll(tmp)
+	if err := os.MkdirAll(tmp, 0755); err != nil {
+		return SnapshotManifest{}, err
+	}
+	if err := writeSnapshot(tmp, manifest, lock); err != nil {
+		_ = os.RemoveAll(tmp)
+		return SnapshotManifest{}, err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		_ = os.RemoveAll(tmp)
+		return SnapshotManifest{}, err
+	}
+	return manifest, nil
+}
+
+func writeSnapshot(dir string, manifest SnapshotManifest, lock []byte) error {
+	if lock != nil {
+		if err := os.WriteFile(filepath.Join(dir, snapshotLockFile), lock, 0644); err != nil {
+			return err
+		}
+	}
+	if manifest.Content {
+		if err := copyTree(GetAppPackagesPath(), filepath.Join(dir, snapshotPackagesDir)); err != nil {
+			return fmt.Errorf("failed to capture packages: %w", err)
+		}
+		if err := copyTree(GetAppBinPath(), filepath.Join(dir, snapshotBinDir)); err != nil {
+			return fmt.Errorf("failed to capture bin directory: %w", err)
+		}
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, snapshotManifestFile), data, 0644)
+}
+
+// copyTree recreates the tree at src under dest. Files are copied rather
+// than hard-linked, as package managers may update installed files in place
+// and would change the snapshot with them. Symlinks are recreated as they are.
+// A missing src is an empty tree, e.g. on a home nothing was installed in yet.
+func copyTree(src, dest string) error {
+	if _, err := os.Lstat(src); os.IsNotExist(err) {
+		return os.MkdirAll(dest, 0755)
+	}
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(src, dest string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// ReadSnapshot returns the manifest of the snapshot name
+func ReadSnapshot(name string) (SnapshotManifest, error) {
+	dir, err := snapshotPath(name)
+	if err != nil {
+		return SnapshotManifest{}, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, snapshotManifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return SnapshotManifest{}, fmt.Errorf("snapshot %s not found", name)
+		}
+		return SnapshotManifest{}, err
+	}
+	var manifest SnapshotManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return SnapshotManifest{}, fmt.Errorf("failed to parse manifest of snapshot %s: %w", name, err)
+	}
+	return manifest, nil
+}
+
+// ListSnapshots returns the manifests of all snapshots, oldest first
+func ListSnapshots() ([]SnapshotManifest, error) {
+	names, err := readDirNames(GetSnapshotsPath())
+	if err != nil {
+		return nil, err
+	}
+	snapshots := []SnapshotManifest{}
+	for _, name := range names {
+		if !snapshotNamePattern.MatchString(name) || strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		manifest, err := ReadSnapshot(name)
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, manifest)
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt) })
+	return snapshots, nil
+}
+
+// DeleteSnapshot removes the snapshot name
+func DeleteSnapshot(name string) error {
+	if _, err := ReadSnapshot(name); err != nil {
+		return err
+	}
+	dir, _ := snapshotPath(name)
+	return os.RemoveAll(dir)
+}
+
+// RestoreSnapshot puts the lock file of the snapshot name back in place.
+// When the snapshot captured package content, the package and bin directories
+// are swapped in together, so the toolchain is either fully restored or left
+// untouched. Otherwise the packages have to be synced from the lock file.
+func RestoreSnapshot(name string) (SnapshotManifest, error) {
+	manifest, err := ReadSnapshot(name)
+	if err != nil {
+		return SnapshotManifest{}, err
+	}
+	dir, _ := snapshotPath(name)
+
+	if manifest.Content {
+		if err := swapInTrees([]treeSwap{
+			{what: "packages", src: filepath.Join(dir, snapshotPackagesDir), dest: GetAppPackagesPath()},
+			{what: "bin directory", src: filepath.Join(dir, snapshotBinDir), dest: GetAppBinPath()},
+		}); err != nil {
+			return SnapshotManifest{}, err
+		}
+	}
+
+	lockPath := GetAppLocalPackagesFilePath()
+	lock, err := os.ReadFile(filepath.Join(dir, snapshotLockFile))
+	if os.IsNotExist(err) {
+		if err := os.Remove(lockPath); err != nil && !os.IsNotExist(err) {
+			return SnapshotManifest{}, err
+		}
+		return manifest, nil
+	}
+	if err != nil {
+		return SnapshotManifest{}, err
+	}
+	tmp := lockPath + ".tmp"
+	if err := os.WriteFile(tmp, lock, 0644); err != nil {
+		return SnapshotManifest{}, err
+	}
+	if err := os.Rename(tmp, lockPath); err != nil {
+		_ = os.Remove(tmp)
+		return SnapshotManifest{}, err
+	}
+	return manifest, nil
+}
+
+// snapshotRename renames during restores, injectable for tests
+var snapshotRename = os.Rename
+
+// treeSwap is a directory swapped in from a snapshot by swapInTrees
+type treeSwap struct {
+	what      string
+	src, dest string
+}
+
+func (t treeSwap) staged() string { return t.dest + ".restore" }
+func (t treeSwap) old() string    { return t.dest + ".old" }
+
+// swapInTrees replaces each dest with a copy of its src. All copies are
+// staged next to their dest before anything is swapped, and when a swap
+// fails the trees already swapped in are put back, so either every dest is
+// replaced or none is.
+func swapInTrees(swaps []treeSwap) error {
+	cleanup := func() {
+		for _, t := range swaps {
+			_ = os.RemoveAll(t.staged())
+		}
+	}
+	for _, t := range swaps {
+		_ = os.RemoveAll(t.staged())
+		_ = os.RemoveAll(t.old())
+		if err := copyTree(t.src, t.staged()); err != nil {
+			cleanup()
+			return fmt.Errorf("failed to restore %s: %w", t.what, err)
+		}
+	}
+
+	for i, t := range swaps {
+		err := snapshotRename(t.dest, t.old())
+		if err != nil && !os.IsNotExist(err) {
+			rollBackTrees(swaps[:i])
+			cleanup()
+			return fmt.Errorf("failed to restore %s: %w", t.what, err)
+		}
+		if err := snapshotRename(t.staged(), t.dest); err != nil {
+			_ = snapshotRename(t.old(), t.dest)
+			rollBackTrees(swaps[:i])
+			cleanup()
+			return fmt.Errorf("failed to restore %s: %w", t.what, err)
+		}
+	}
+	for _, t := range swaps {
+		_ = os.RemoveAll(t.old())
+	}
+	return nil
+}
+
+// rollBackTrees puts back the dests swaps replaced
+func rollBackTrees(swaps []treeSwap) {
+	for _, t := range swaps {
+		_ = os.RemoveAll(t.dest)
+		_ = snapshotRename(t.old(), t.dest)
+	}
+}
@@ -0,0 +1,175 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSnapshotHome(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("ZANA_HOME", t.TempDir())
+
+	pkgDir := filepath.Join(GetAppPackagesPath(), "npm", "node_modules", "prettier")
+	require.NoError(t, os.MkdirAll(pkgDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(pkgDir, "index.js"), []byte("v3"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(pkgDir, "index.js"), filepath.Join(GetAppBinPath(), "prettier")))
+	require.NoError(t, os.WriteFile(GetAppLocalPackagesFilePath(), []byte(`{"packages":[{"sourceId":"npm:prettier","version":"3.0.0"}]}`), 0644))
+}
+
+func TestCreateSnapshot(t *testing.T) {
+	setupSnapshotHome(t)
+
+	manifest, err := CreateSnapshot("before-update", false)
+	require.NoError(t, err)
+	assert.Equal(t, []SnapshotPackage{{SourceID: "npm:prettier", Version: "3.0.0"}}, manifest.Packages)
+	assert.Equal(t, map[string][]string{"npm": {"node_modules"}}, manifest.Providers)
+	assert.Equal(t, []string{"prettier"}, manifest.Bin)
+	assert.False(t, manifest.Content)
+
+	_, err = CreateSnapshot("before-update", false)
+	assert.ErrorContains(t, err, "already exists")
+	_, err = CreateSnapshot("../escape", false)
+	assert.ErrorContains(t, err, "invalid snapshot name")
+
+	read, err := ReadSnapshot("before-update")
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Packages, read.Packages)
+
+	snapshots, err := ListSnapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, "before-update", snapshots[0].Name)
+
+	require.NoError(t, DeleteSnapshot("before-update"))
+	assert.ErrorContains(t, DeleteSnapshot("before-update"), "not found")
+}
+
+func TestRestoreSnapshotLockOnly(t *testing.T) {
+	setupSnapshotHome(t)
+	_, err := CreateSnapshot("s1", false)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(GetAppLocalPackagesFilePath(), []byte(`{"packages":[]}`), 0644))
+	manifest, err := RestoreSnapshot("s1")
+	require.NoError(t, err)
+	assert.False(t, manifest.Content)
+
+	lock, err := os.ReadFile(GetAppLocalPackagesFilePath())
+	require.NoError(t, err)
+	assert.Contains(t, string(lock), "npm:prettier")
+
+	_, err = RestoreSnapshot("missing")
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestRestoreSnapshotWithContent(t *testing.T) {
+	setupSnapshotHome(t)
+	_, err := CreateSnapshot("s1", true)
+	require.NoError(t, err)
+
+	// Simulate an update that replaces files and adds a new binary
+	index := filepath.Join(GetAppPackagesPath(), "npm", "node_modules", "prettier", "index.js")
+	require.NoError(t, os.Remove(index))
+	require.NoError(t, os.WriteFile(index, []byte("v4"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(GetAppBinPath(), "eslint"), []byte{}, 0755))
+	require.NoError(t, os.WriteFile(GetAppLocalPackagesFilePath(), []byte(`{"packages":[{"sourceId":"npm:prettier","version":"4.0.0"}]}`), 0644))
+
+	_, err = RestoreSnapshot("s1")
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(index)
+	require.NoError(t, err)
+	assert.Equal(t, "v3", string(content))
+	names, err := readDirNames(GetAppBinPath())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prettier"}, names)
+	lock, err := os.ReadFile(GetAppLocalPackagesFilePath())
+	require.NoError(t, err)
+	assert.Contains(t, string(lock), "3.0.0")
+
+	// The snapshot stays usable after restoring it
+	_, err = RestoreSnapshot("s1")
+	require.NoError(t, err)
+	assert.NoDirExists(t, GetAppPackagesPath()+".old")
+	assert.NoDirExists(t, GetAppPackagesPath()+".restore")
+}
+
+func TestSnapshotContentIsCopied(t *testing.T) {
+	setupSnapshotHome(t)
+	_, err := CreateSnapshot("s1", true)
+	require.NoError(t, err)
+
+	// Package managers may rewrite installed files in place
+	index := filepath.Join(GetAppPackagesPath(), "npm", "node_modules", "prettier", "index.js")
+	require.NoError(t, os.WriteFile(index, []byte("v4"), 0644))
+
+	_, err = RestoreSnapshot("s1")
+	require.NoError(t, err)
+	content, err := os.ReadFile(index)
+	require.NoError(t, err)
+	assert.Equal(t, "v3", string(content))
+}
+
+func TestSnapshotOfEmptyHome(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("ZANA_HOME", t.TempDir())
+
+	manifest, err := CreateSnapshot("empty", true)
+	require.NoError(t, err)
+	assert.Empty(t, manifest.Packages)
+	assert.Empty(t, manifest.Bin)
+
+	_, err = RestoreSnapshot("empty")
+	require.NoError(t, err)
+	assert.DirExists(t, GetAppPackagesPath())
+	assert.NoFileExists(t, GetAppLocalPackagesFilePath())
+
+	// Nothing installed means no packages or bin directory to copy
+	dest := filepath.Join(t.TempDir(), "copy")
+	require.NoError(t, copyTree(filepath.Join(t.TempDir(), "missing"), dest))
+	names, err := readDirNames(dest)
+	require.NoError(t, err)
+	assert.Empty(t, names)
+}
+
+func TestRestoreSnapshotRollsBackOnBinSwapFailure(t *testing.T) {
+	setupSnapshotHome(t)
+	_, err := CreateSnapshot("s1", true)
+	require.NoError(t, err)
+
+	index := filepath.Join(GetAppPackagesPath(), "npm", "node_modules", "prettier", "index.js")
+	require.NoError(t, os.WriteFile(index, []byte("v4"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(GetAppBinPath(), "eslint"), []byte{}, 0755))
+
+	prevRename := snapshotRename
+	t.Cleanup(func() { snapshotRename = prevRename })
+	binPath := GetAppBinPath()
+	snapshotRename = func(oldpath, newpath string) error {
+		if oldpath == binPath+".restore" {
+			return os.ErrPermission
+		}
+		return os.Rename(oldpath, newpath)
+	}
+
+	_, err = RestoreSnapshot("s1")
+	assert.ErrorContains(t, err, "failed to restore bin directory")
+
+	// Neither directory was restored
+	content, err := os.ReadFile(index)
+	require.NoError(t, err)
+	assert.Equal(t, "v4", string(content))
+	names, err := readDirNames(binPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eslint", "prettier"}, names)
+	for _, dir := range []string{GetAppPackagesPath(), binPath} {
+		assert.NoDirExists(t, dir+".old")
+		assert.NoDirExists(t, dir+".restore")
+	}
+}
@@ -0,0 +1,67 @@
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tempGetuid is a variable to allow overriding in tests
+var tempGetuid = os.Getuid
+
+// GetTempPath returns the directory zana creates its temporary files in.
+// Order of precedence:
+//   - ZANA_TMP environment variable
+//   - paths.tempDir in config.yaml
+//   - the system temp directory (TMPDIR on Unix, TEMP on Windows)
+func GetTempPath() string {
+	if zanaTmp := strings.TrimSpace(fileSystem.Getenv("ZANA_TMP")); zanaTmp != "" {
+		return EnsureDirExists(expandUserAndRelativePath(zanaTmp))
+	}
+	if cfg, ok := readZanaConfigFile(); ok {
+		if raw := strings.TrimSpace(cfg.Paths.TempDir); raw != "" {
+			return EnsureDirExists(expandUserAndRelativePath(raw))
+		}
+	}
+	return fileSystem.TempDir()
+}
+
+// GetAppTempPath returns the directory in GetTempPath that holds the
+// temporary directories and files of zana's operations. It is per user,
+// as the system temp directory is usually shared.
+// e.g. /tmp/zana-1000
+func GetAppTempPath() string {
+	name := "zana"
+	if uid := tempGetuid(); uid >= 0 {
+		name = fmt.Sprintf("zana-%d", uid)
+	}
+	path := filepath.Join(GetTempPath(), name)
+	// Created on disk, as temporary files are handed to other processes
+	if err := os.MkdirAll(path, 0700); err != nil {
+		fmt.Printf("Warning: failed to create directory %s: %v\n", path, err)
+	}
+	return path
+}
+
+// MkdirTemp creates a new temporary directory for one operation in
+// GetAppTempPath, named after the namespace (e.g. test-install).
+// The caller removes it when the operation is done.
+func MkdirTemp(namespace string) (string, error) {
+	return os.MkdirTemp(GetAppTempPath(), tempPattern(namespace))
+}
+
+// CreateTemp creates a new temporary file for one operation in
+// GetAppTempPath, named after the namespace (e.g. update).
+// The caller closes and removes it when the operation is done.
+func CreateTemp(namespace string) (*os.File, error) {
+	return os.CreateTemp(GetAppTempPath(), tempPattern(namespace))
+}
+
+func tempPattern(namespace string) string {
+	namespace = strings.Trim(strings.NewReplacer("/", "_", string(os.PathSeparator), "_").Replace(namespace), "-")
+	if namespace == "" {
+		return "tmp-"
+	}
+	return namespace + "-"
+}
@@ -0,0 +1,36 @@
+package files
+
+import "time"
+
+// DefaultKeepVersions is how many older versions of a release asset package
+// are kept when install.keepVersions isn't set
+const DefaultKeepVersions = 1
+
+// VersionRetention is how long the older versions of release asset packages
+// are kept next to the installed one, see install.keepVersions and
+// install.keepVersionsDays in config.yaml
+type VersionRetention struct {
+	// Keep is how many older versions are kept, at least 1 so there is
+	// always a previous version to roll back to
+	Keep int
+	// MaxAge drops older versions replaced longer ago than that, all but the
+	// previous one. 0 keeps them however old they are.
+	MaxAge time.Duration
+}
+
+// GetVersionRetention returns the retention of older package versions from
+// config.yaml
+func GetVersionRetention() VersionRetention {
+	r := VersionRetention{Keep: DefaultKeepVersions}
+	cfg, ok := readZanaConfigFile()
+	if !ok {
+		return r
+	}
+	if cfg.Install.KeepVersions > 0 {
+		r.Keep = cfg.Install.KeepVersions
+	}
+	if cfg.Install.KeepVersionsDays > 0 {
+		r.MaxAge = time.Duration(cfg.Install.KeepVersionsDays) * 24 * time.Hour
+	}
+	return r
+}
@@ -0,0 +1,28 @@
+package files
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetVersionRetention(t *testing.T) {
+	mockFS := &MockFileSystem{
+		fs:                afero.NewMemMapFs(),
+		GetenvFunc:        func(string) string { return "" },
+		UserHomeDirFunc:   func() (string, error) { return "/home/user", nil },
+		UserConfigDirFunc: func() (string, error) { return "/home/user/.config", nil },
+	}
+	SetFileSystem(mockFS)
+	defer ResetDependencies()
+
+	assert.Equal(t, VersionRetention{Keep: DefaultKeepVersions}, GetVersionRetention(), "the previous version by default")
+
+	_ = afero.WriteFile(mockFS.fs, "/home/user/.config/zana/config.yaml", []byte("install:\n  keepVersions: 3\n  keepVersionsDays: 30\n"), 0o644)
+	assert.Equal(t, VersionRetention{Keep: 3, MaxAge: 30 * 24 * time.Hour}, GetVersionRetention())
+
+	_ = afero.WriteFile(mockFS.fs, "/home/user/.config/zana/config.yaml", []byte("install:\n  keepVersions: 0\n"), 0o644)
+	assert.Equal(t, VersionRetention{Keep: DefaultKeepVersions}, GetVersionRetention(), "the previous version is always kept")
+}
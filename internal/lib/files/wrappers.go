@@ -0,0 +1,54 @@
+package files
+
+import (
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+)
+
+// WrapperCommandPlaceholder is replaced by the wrapped command line, quoted
+// as a single argument, for wrappers taking the command as a string
+// (e.g. nix-shell --run). Without it the command is appended.
+const WrapperCommandPlaceholder = "{cmd}"
+
+// CommandWrappers are command prefixes for the subprocesses of providers or
+// single packages, see wrappers in config.yaml
+type CommandWrappers struct {
+	// Providers maps provider names to their wrapper, e.g. npm: [proxychains4, -q]
+	Providers map[string][]string `yaml:"providers"`
+	// Packages maps package IDs to their wrapper, which wins over the one of
+	// their provider
+	Packages map[string][]string `yaml:"packages"`
+}
+
+// GetCommandWrappers returns wrappers from config.yaml
+func GetCommandWrappers() CommandWrappers {
+	cfg, ok := readZanaConfigFile()
+	if !ok {
+		return CommandWrappers{}
+	}
+	return cfg.Wrappers
+}
+
+// For returns the wrapper of the subprocesses of sourceID: its own, else the
+// one of its provider, nil when it has none. A bare provider name, as used
+// by the operations syncing all packages of a provider, gets the wrapper of
+// the provider.
+func (w CommandWrappers) For(sourceID string) []string {
+	sourceID = packageid.Normalize(strings.TrimSpace(sourceID))
+	for id, wrapper := range w.Packages {
+		if packageid.Normalize(strings.TrimSpace(id)) == sourceID && len(wrapper) > 0 {
+			return wrapper
+		}
+	}
+	provider, _ := packageid.Split(sourceID)
+	if !packageid.HasProvider(sourceID) {
+		provider = sourceID
+	}
+	for name, wrapper := range w.Providers {
+		if strings.EqualFold(strings.TrimSpace(name), provider) && len(wrapper) > 0 {
+			return wrapper
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCommandWrappers(t *testing.T) {
+	mockFS := &MockFileSystem{
+		fs:                afero.NewMemMapFs(),
+		GetenvFunc:        func(string) string { return "" },
+		UserHomeDirFunc:   func() (string, error) { return "/home/user", nil },
+		UserConfigDirFunc: func() (string, error) { return "/home/user/.config", nil },
+	}
+	SetFileSystem(mockFS)
+	defer ResetDependencies()
+
+	assert.Nil(t, GetCommandWrappers().For("npm:prettier"))
+
+	_ = afero.WriteFile(mockFS.fs, "/home/user/.config/zana/config.yaml", []byte(`wrappers:
+  providers:
+    NPM: [proxychains4, -q]
+    cargo: []
+  packages:
+    "pkg:npm/eslint": [firejail, --net=none]
+`), 0o644)
+	w := GetCommandWrappers()
+	assert.Equal(t, []string{"proxychains4", "-q"}, w.For("npm:prettier"))
+	assert.Equal(t, []string{"firejail", "--net=none"}, w.For("npm:eslint"), "package wrappers win, in either ID format")
+	assert.Nil(t, w.For("cargo:ripgrep"))
+	assert.Nil(t, w.For("pypi:black"))
+	assert.Equal(t, []string{"proxychains4", "-q"}, w.For("npm"), "provider syncs get the wrapper of the provider")
+}
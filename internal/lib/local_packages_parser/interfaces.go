@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/trace"
 )
 
 // FileManager defines the interface for file operations
@@ -37,11 +38,17 @@ func (dfm *DefaultFileManager) FileExists(path string) bool {
 }
 
 func (dfm *DefaultFileManager) ReadFile(path string) ([]byte, error) {
-	return os.ReadFile(path)
+	span := trace.Start("lockfile.read", "file.path", path)
+	data, err := os.ReadFile(path)
+	span.End(err)
+	return data, err
 }
 
 func (dfm *DefaultFileManager) WriteFile(path string, data []byte, perm uint32) error {
-	return os.WriteFile(path, data, os.FileMode(perm))
+	span := trace.Start("lockfile.write", "file.path", path)
+	err := os.WriteFile(path, data, os.FileMode(perm))
+	span.End(err)
+	return err
 }
 
 // MockFileManager is a mock implementation for testing
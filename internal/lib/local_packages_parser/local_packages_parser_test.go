@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLocalPackagesParser(t *testing.T) {
@@ -504,6 +505,177 @@ func TestLocalPackagesParserWithMock(t *testing.T) {
 			assert.Equal(t, []string{"neovim"}, saved.Packages[0].Extras.Integrations)
 		}
 	})
+
+	t.Run("set package bin hashes records hashes and ignores unknown packages", func(t *testing.T) {
+		existingData := LocalPackageRoot{
+			Packages: []LocalPackageItem{
+				{SourceID: "github:owner/repo", Version: "v1.0.0"},
+			},
+		}
+		jsonData, _ := json.Marshal(existingData)
+
+		var written []byte
+		mockFileManager := &MockFileManager{
+			GetAppLocalPackagesFilePathFunc: func() string { return "/mock/path/local-packages.json" },
+			FileExistsFunc:                  func(path string) bool { return true },
+			ReadFileFunc:                    func(path string) ([]byte, error) { return jsonData, nil },
+			WriteFileFunc:                   func(path string, data []byte, perm uint32) error { written = data; return nil },
+		}
+
+		parser := NewWithFileManager(mockFileManager)
+		assert.NoError(t, parser.SetPackageBinHashes("github:other/repo", map[string]string{"x": "00"}))
+		assert.Nil(t, written)
+
+		assert.NoError(t, parser.SetPackageBinHashes("github:owner/repo", map[string]string{"repo": "ab"}))
+		var saved LocalPackageRoot
+		_ = json.Unmarshal(written, &saved)
+		if assert.NotNil(t, saved.Packages[0].Extras) {
+			assert.Equal(t, map[string]string{"repo": "ab"}, saved.Packages[0].Extras.BinHashes)
+		}
+
+		// A new version drops the hashes of the previous one
+		jsonData = written
+		assert.NoError(t, parser.AddLocalPackage("github:owner/repo", "v2.0.0"))
+		saved = LocalPackageRoot{}
+		_ = json.Unmarshal(written, &saved)
+		if saved.Packages[0].Extras != nil {
+			assert.Nil(t, saved.Packages[0].Extras.BinHashes)
+		}
+	})
+
+	t.Run("set package unlinked survives updates and rejects unknown packages", func(t *testing.T) {
+		jsonData, _ := json.Marshal(LocalPackageRoot{
+			Packages: []LocalPackageItem{{SourceID: "npm:prettier", Version: "3.0.0"}},
+		})
+		var written []byte
+		mockFileManager := &MockFileManager{
+			GetAppLocalPackagesFilePathFunc: func() string { return "/mock/path/local-packages.json" },
+			FileExistsFunc:                  func(path string) bool { return true },
+			ReadFileFunc:                    func(path string) ([]byte, error) { return jsonData, nil },
+			WriteFileFunc:                   func(path string, data []byte, perm uint32) error { written = data; return nil },
+		}
+
+		parser := NewWithFileManager(mockFileManager)
+		assert.Error(t, parser.SetPackageUnlinked("npm:other", true))
+		assert.Nil(t, written)
+
+		assert.NoError(t, parser.SetPackageUnlinked("pkg:npm/prettier", true))
+		jsonData = written
+		assert.NoError(t, parser.AddLocalPackage("npm:prettier", "3.1.0"))
+		var saved LocalPackageRoot
+		_ = json.Unmarshal(written, &saved)
+		if assert.NotNil(t, saved.Packages[0].Extras) {
+			assert.True(t, saved.Packages[0].Extras.Unlinked)
+		}
+
+		jsonData = written
+		assert.NoError(t, parser.SetPackageUnlinked("npm:prettier", false))
+		assert.NotContains(t, string(written), "unlinked")
+	})
+
+	t.Run("set package install method", func(t *testing.T) {
+		jsonData, _ := json.Marshal(LocalPackageRoot{
+			Packages: []LocalPackageItem{{SourceID: "github:owner/repo", Version: "v1.0.0"}},
+		})
+		var written []byte
+		mockFileManager := &MockFileManager{
+			GetAppLocalPackagesFilePathFunc: func() string { return "/mock/path/local-packages.json" },
+			FileExistsFunc:                  func(path string) bool { return true },
+			ReadFileFunc:                    func(path string) ([]byte, error) { return jsonData, nil },
+			WriteFileFunc:                   func(path string, data []byte, perm uint32) error { written = data; return nil },
+		}
+
+		parser := NewWithFileManager(mockFileManager)
+		assert.Error(t, parser.SetPackageInstallMethod("github:owner/other", InstallMethodGit))
+		assert.NoError(t, parser.SetPackageInstallMethod("github:owner/repo", InstallMethodTagArchive))
+		assert.Contains(t, string(written), `"install_method": "tag-archive"`)
+	})
+
+	t.Run("set package override", func(t *testing.T) {
+		jsonData, _ := json.Marshal(LocalPackageRoot{
+			Packages: []LocalPackageItem{{SourceID: "github:prettier/prettier", Version: "3.3.3"}},
+		})
+		var written []byte
+		mockFileManager := &MockFileManager{
+			GetAppLocalPackagesFilePathFunc: func() string { return "/mock/path/local-packages.json" },
+			FileExistsFunc:                  func(path string) bool { return true },
+			ReadFileFunc:                    func(path string) ([]byte, error) { return jsonData, nil },
+			WriteFileFunc:                   func(path string, data []byte, perm uint32) error { written = data; return nil },
+		}
+
+		parser := NewWithFileManager(mockFileManager)
+		assert.Error(t, parser.SetPackageOverrideOf("github:owner/other", "npm:prettier"))
+		assert.NoError(t, parser.SetPackageOverrideOf("github:prettier/prettier", "pkg:npm/prettier"))
+		assert.Contains(t, string(written), `"override_of": "npm:prettier"`)
+	})
+
+	t.Run("set package registry entry", func(t *testing.T) {
+		jsonData, _ := json.Marshal(LocalPackageRoot{
+			Packages: []LocalPackageItem{{SourceID: "npm:prettier", Version: "3.3.0"}},
+		})
+		var written []byte
+		mockFileManager := &MockFileManager{
+			GetAppLocalPackagesFilePathFunc: func() string { return "/mock/path/local-packages.json" },
+			FileExistsFunc:                  func(path string) bool { return true },
+			ReadFileFunc:                    func(path string) ([]byte, error) { return jsonData, nil },
+			WriteFileFunc:                   func(path string, data []byte, perm uint32) error { written = data; return nil },
+		}
+
+		parser := NewWithFileManager(mockFileManager)
+		assert.NoError(t, parser.SetPackageRegistryEntry("npm:other", json.RawMessage(`{"name":"other"}`)))
+		assert.Nil(t, written, "packages missing from the lock file are skipped")
+		assert.NoError(t, parser.SetPackageRegistryEntry("npm:prettier", json.RawMessage(`{"name":"prettier","bin":{"prettier":"npm:prettier"}}`)))
+
+		var root LocalPackageRoot
+		require.NoError(t, json.Unmarshal(written, &root))
+		assert.JSONEq(t, `{"name":"prettier","bin":{"prettier":"npm:prettier"}}`, string(root.Packages[0].Extras.RegistryEntry))
+	})
+
+	t.Run("set package asset pins", func(t *testing.T) {
+		jsonData, _ := json.Marshal(LocalPackageRoot{
+			Packages: []LocalPackageItem{{SourceID: "github:owner/tool", Version: "v1.0.0"}},
+		})
+		var written []byte
+		mockFileManager := &MockFileManager{
+			GetAppLocalPackagesFilePathFunc: func() string { return "/mock/path/local-packages.json" },
+			FileExistsFunc:                  func(path string) bool { return true },
+			ReadFileFunc:                    func(path string) ([]byte, error) { return jsonData, nil },
+			WriteFileFunc:                   func(path string, data []byte, perm uint32) error { written = data; return nil },
+		}
+
+		parser := NewWithFileManager(mockFileManager)
+		pins := []AssetPin{{URL: "https://github.com/owner/tool/releases/download/v1.0.0/tool.tar.gz", SHA256: "abc123"}}
+		assert.NoError(t, parser.SetPackageAssetPins("github:owner/other", pins))
+		assert.Nil(t, written, "packages missing from the lock file are skipped")
+		assert.NoError(t, parser.SetPackageAssetPins("github:owner/tool", pins))
+
+		var root LocalPackageRoot
+		require.NoError(t, json.Unmarshal(written, &root))
+		assert.Equal(t, pins, root.Packages[0].Extras.AssetPins)
+	})
+
+	t.Run("set package smoke test", func(t *testing.T) {
+		jsonData, _ := json.Marshal(LocalPackageRoot{
+			Packages: []LocalPackageItem{{SourceID: "npm:pyright", Version: "1.1.0"}},
+		})
+		var written []byte
+		mockFileManager := &MockFileManager{
+			GetAppLocalPackagesFilePathFunc: func() string { return "/mock/path/local-packages.json" },
+			FileExistsFunc:                  func(path string) bool { return true },
+			ReadFileFunc:                    func(path string) ([]byte, error) { return jsonData, nil },
+			WriteFileFunc:                   func(path string, data []byte, perm uint32) error { written = data; return nil },
+		}
+
+		parser := NewWithFileManager(mockFileManager)
+		result := &SmokeTestResult{Command: "pyright --version", Version: "1.1.0", Passed: true}
+		assert.NoError(t, parser.SetPackageSmokeTest("npm:other", result))
+		assert.Nil(t, written, "packages missing from the lock file are skipped")
+		assert.NoError(t, parser.SetPackageSmokeTest("npm:pyright", result))
+
+		var root LocalPackageRoot
+		require.NoError(t, json.Unmarshal(written, &root))
+		assert.Equal(t, result, root.Packages[0].Extras.SmokeTest)
+	})
 }
 
 func TestMockFileManager(t *testing.T) {
@@ -610,3 +782,44 @@ func TestLegacyFunctions(t *testing.T) {
 		assert.Len(t, saved.Packages, 0)
 	})
 }
+
+func TestNormalizeLockFile(t *testing.T) {
+	var written []byte
+	newParser := func(data string) *LocalPackagesParser {
+		written = nil
+		return NewWithFileManager(&MockFileManager{
+			FileExistsFunc: func(path string) bool { return true },
+			ReadFileFunc:   func(path string) ([]byte, error) { return []byte(data), nil },
+			WriteFileFunc: func(path string, data []byte, perm uint32) error {
+				written = data
+				return nil
+			},
+		})
+	}
+
+	t.Run("rewrites legacy IDs and drops duplicates", func(t *testing.T) {
+		parser := newParser(`{"packages": [
+			{"sourceId": "pkg:npm/prettier", "version": "3.3.0"},
+			{"sourceId": "npm:prettier", "version": "3.2.0"},
+			{"sourceId": "pypi:black", "version": "24.1.0",
+			 "extras": {"treesitter_parser_choices": [{"language": "lua", "sourceId": "pkg:github/tree-sitter/lua"}]}}
+		]}`)
+		changed, err := parser.NormalizeLockFile()
+		require.NoError(t, err)
+		assert.True(t, changed)
+
+		var root LocalPackageRoot
+		require.NoError(t, json.Unmarshal(written, &root))
+		require.Len(t, root.Packages, 2)
+		assert.Equal(t, LocalPackageItem{SourceID: "npm:prettier", Version: "3.3.0"}, root.Packages[0])
+		assert.Equal(t, "github:tree-sitter/lua", root.Packages[1].Extras.TreeSitterParserChoices[0].SourceID)
+	})
+
+	t.Run("leaves normalized files alone", func(t *testing.T) {
+		parser := newParser(`{"packages": [{"sourceId": "npm:prettier", "version": "3.3.0"}]}`)
+		changed, err := parser.NormalizeLockFile()
+		require.NoError(t, err)
+		assert.False(t, changed)
+		assert.Nil(t, written)
+	})
+}
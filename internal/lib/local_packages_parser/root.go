@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 )
 
 // marshalIndent is a package-level variable to allow injection during tests
@@ -30,7 +32,49 @@ type PackageExtras struct {
 	// so zana sync can reproduce the same query trees without re-resolving semver. Multiple rows may
 	// share the same language when several query-only repositories apply.
 	TreeSitterExternalQueries []TreeSitterExternalQueryPin `json:"treesitter_external_queries,omitempty"`
-}
+	// BinHashes records the sha256 of each executable the package exposes in the bin dir,
+	// keyed by file name, so zana ls --check-integrity can detect modified executables.
+	BinHashes map[string]string `json:"bin_hashes,omitempty"`
+	// Unlinked is set for packages whose executables are not linked into the
+	// bin dir (zana add --no-symlink, zana unlink).
+	Unlinked bool `json:"unlinked,omitempty"`
+	// InstallMethod records how a package of a git forge was installed: from
+	// a release asset, a tag's source archive or a git clone (InstallMethod*).
+	InstallMethod string `json:"install_method,omitempty"`
+	// OverrideOf is the registry package this package is installed in place
+	// of, from another source (zana add <package> --from <source>)
+	OverrideOf string `json:"override_of,omitempty"`
+	// RegistryEntry is the registry entry the package was installed or
+	// updated from, so zana info --as-installed can show which assets and
+	// bin map were used after the registry changed.
+	RegistryEntry json.RawMessage `json:"registry_entry,omitempty"`
+	// AssetPins records the URL and sha256 of every asset downloaded for the
+	// package, so zana sync re-downloads exactly these artifacts.
+	AssetPins []AssetPin `json:"asset_pins,omitempty"`
+	// SmokeTest is the outcome of the registry entry's smoke test after the
+	// last install or update. A failed version was rolled back.
+	SmokeTest *SmokeTestResult `json:"smoke_test,omitempty"`
+}
+
+// AssetPin pins a downloaded release or generic asset to its content hash
+type AssetPin struct {
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+}
+
+// SmokeTestResult records whether a smoke test passed for a version
+type SmokeTestResult struct {
+	Command string `json:"command"`
+	Version string `json:"version"`
+	Passed  bool   `json:"passed"`
+}
+
+// Install methods of git forge packages, see PackageExtras.InstallMethod
+const (
+	InstallMethodRelease    = "release"
+	InstallMethodTagArchive = "tag-archive"
+	InstallMethodGit        = "git"
+)
 
 // TreeSitterParserChoice records a disambiguated parser package for a tree-sitter language name.
 type TreeSitterParserChoice struct {
@@ -77,20 +121,6 @@ func NewWithFileManager(fileManager FileManager) *LocalPackagesParser {
 	}
 }
 
-// normalizePackageID converts a package ID from legacy format (pkg:provider/pkg)
-// to the new format (provider:pkg), or returns it unchanged if already in new format.
-// This ensures backward compatibility when reading zana-lock.json files.
-func normalizePackageID(sourceID string) string {
-	if strings.HasPrefix(sourceID, "pkg:") {
-		rest := strings.TrimPrefix(sourceID, "pkg:")
-		parts := strings.SplitN(rest, "/", 2)
-		if len(parts) == 2 {
-			return parts[0] + ":" + parts[1]
-		}
-	}
-	return sourceID
-}
-
 // GetData returns the local packages data from the local packages file.
 // The force flag is ignored; data is always read from disk to avoid caching.
 // Package IDs are normalized from legacy format (pkg:provider/pkg) to new format (provider:pkg)
@@ -116,7 +146,7 @@ func (lpp *LocalPackagesParser) GetData(force bool) LocalPackageRoot {
 
 	// Normalize all package IDs from legacy format to new format
 	for i := range localPackageRoot.Packages {
-		localPackageRoot.Packages[i].SourceID = normalizePackageID(localPackageRoot.Packages[i].SourceID)
+		localPackageRoot.Packages[i].SourceID = packageid.Normalize(localPackageRoot.Packages[i].SourceID)
 	}
 
 	return localPackageRoot
@@ -145,7 +175,7 @@ func (lpp *LocalPackagesParser) MergePackageIntegrations(sourceID string, integr
 		return nil
 	}
 
-	sourceID = normalizePackageID(sourceID)
+	sourceID = packageid.Normalize(sourceID)
 	if strings.TrimSpace(sourceID) == "" {
 		return nil
 	}
@@ -181,6 +211,252 @@ write:
 	return nil
 }
 
+// SetPackageBinHashes replaces the recorded executable hashes of an installed package
+func (lpp *LocalPackagesParser) SetPackageBinHashes(sourceID string, hashes map[string]string) error {
+	sourceID = packageid.Normalize(sourceID)
+	root := lpp.GetData(false)
+	found := false
+	for i := range root.Packages {
+		if root.Packages[i].SourceID != sourceID {
+			continue
+		}
+		if root.Packages[i].Extras == nil {
+			root.Packages[i].Extras = &PackageExtras{}
+		}
+		root.Packages[i].Extras.BinHashes = hashes
+		found = true
+		break
+	}
+	if !found {
+		// Not recorded in the lock file (e.g. installed with --no-lock)
+		return nil
+	}
+
+	root.Schema = lockSchemaURL
+	localPackagesFile := lpp.fileManager.GetAppLocalPackagesFilePath()
+	jsonData, err := marshalIndent(root, "", "  ")
+	if err != nil {
+		fmt.Println("Error marshaling JSON:", err)
+		return err
+	}
+	if err := lpp.fileManager.WriteFile(localPackagesFile, jsonData, 0644); err != nil {
+		fmt.Println("Error writing to file:", err)
+		return err
+	}
+	return nil
+}
+
+// SetPackageRegistryEntry records the registry entry an installed package
+// was installed from
+func (lpp *LocalPackagesParser) SetPackageRegistryEntry(sourceID string, entry json.RawMessage) error {
+	sourceID = packageid.Normalize(sourceID)
+	root := lpp.GetData(false)
+	found := false
+	for i := range root.Packages {
+		if root.Packages[i].SourceID != sourceID {
+			continue
+		}
+		if root.Packages[i].Extras == nil {
+			root.Packages[i].Extras = &PackageExtras{}
+		}
+		root.Packages[i].Extras.RegistryEntry = entry
+		found = true
+		break
+	}
+	if !found {
+		// Not recorded in the lock file (e.g. installed with --no-lock)
+		return nil
+	}
+
+	root.Schema = lockSchemaURL
+	localPackagesFile := lpp.fileManager.GetAppLocalPackagesFilePath()
+	jsonData, err := marshalIndent(root, "", "  ")
+	if err != nil {
+		fmt.Println("Error marshaling JSON:", err)
+		return err
+	}
+	if err := lpp.fileManager.WriteFile(localPackagesFile, jsonData, 0644); err != nil {
+		fmt.Println("Error writing to file:", err)
+		return err
+	}
+	return nil
+}
+
+// SetPackageAssetPins records the assets an installed package was
+// downloaded from
+func (lpp *LocalPackagesParser) SetPackageAssetPins(sourceID string, pins []AssetPin) error {
+	sourceID = packageid.Normalize(sourceID)
+	root := lpp.GetData(false)
+	found := false
+	for i := range root.Packages {
+		if root.Packages[i].SourceID != sourceID {
+			continue
+		}
+		if root.Packages[i].Extras == nil {
+			root.Packages[i].Extras = &PackageExtras{}
+		}
+		root.Packages[i].Extras.AssetPins = pins
+		found = true
+		break
+	}
+	if !found {
+		// Not recorded in the lock file (e.g. installed with --no-lock)
+		return nil
+	}
+
+	root.Schema = lockSchemaURL
+	localPackagesFile := lpp.fileManager.GetAppLocalPackagesFilePath()
+	jsonData, err := marshalIndent(root, "", "  ")
+	if err != nil {
+		fmt.Println("Error marshaling JSON:", err)
+		return err
+	}
+	if err := lpp.fileManager.WriteFile(localPackagesFile, jsonData, 0644); err != nil {
+		fmt.Println("Error writing to file:", err)
+		return err
+	}
+	return nil
+}
+
+// SetPackageSmokeTest records the smoke test result of an installed package
+func (lpp *LocalPackagesParser) SetPackageSmokeTest(sourceID string, result *SmokeTestResult) error {
+	sourceID = packageid.Normalize(sourceID)
+	root := lpp.GetData(false)
+	found := false
+	for i := range root.Packages {
+		if root.Packages[i].SourceID != sourceID {
+			continue
+		}
+		if root.Packages[i].Extras == nil {
+			root.Packages[i].Extras = &PackageExtras{}
+		}
+		root.Packages[i].Extras.SmokeTest = result
+		found = true
+		break
+	}
+	if !found {
+		// Not recorded in the lock file (e.g. installed with --no-lock)
+		return nil
+	}
+
+	root.Schema = lockSchemaURL
+	localPackagesFile := lpp.fileManager.GetAppLocalPackagesFilePath()
+	jsonData, err := marshalIndent(root, "", "  ")
+	if err != nil {
+		fmt.Println("Error marshaling JSON:", err)
+		return err
+	}
+	if err := lpp.fileManager.WriteFile(localPackagesFile, jsonData, 0644); err != nil {
+		fmt.Println("Error writing to file:", err)
+		return err
+	}
+	return nil
+}
+
+// SetPackageUnlinked records whether the executables of an installed package
+// are kept out of the bin dir
+func (lpp *LocalPackagesParser) SetPackageUnlinked(sourceID string, unlinked bool) error {
+	sourceID = packageid.Normalize(sourceID)
+	root := lpp.GetData(false)
+	found := false
+	for i := range root.Packages {
+		if root.Packages[i].SourceID != sourceID {
+			continue
+		}
+		if root.Packages[i].Extras == nil {
+			root.Packages[i].Extras = &PackageExtras{}
+		}
+		root.Packages[i].Extras.Unlinked = unlinked
+		found = true
+		break
+	}
+	if !found {
+		return fmt.Errorf("package %s is not in the lock file", sourceID)
+	}
+
+	root.Schema = lockSchemaURL
+	localPackagesFile := lpp.fileManager.GetAppLocalPackagesFilePath()
+	jsonData, err := marshalIndent(root, "", "  ")
+	if err != nil {
+		fmt.Println("Error marshaling JSON:", err)
+		return err
+	}
+	if err := lpp.fileManager.WriteFile(localPackagesFile, jsonData, 0644); err != nil {
+		fmt.Println("Error writing to file:", err)
+		return err
+	}
+	return nil
+}
+
+// SetPackageOverrideOf records the registry package an installed package
+// replaces, see PackageExtras.OverrideOf
+func (lpp *LocalPackagesParser) SetPackageOverrideOf(sourceID string, registrySourceID string) error {
+	sourceID = packageid.Normalize(sourceID)
+	root := lpp.GetData(false)
+	found := false
+	for i := range root.Packages {
+		if root.Packages[i].SourceID != sourceID {
+			continue
+		}
+		if root.Packages[i].Extras == nil {
+			root.Packages[i].Extras = &PackageExtras{}
+		}
+		root.Packages[i].Extras.OverrideOf = packageid.Normalize(registrySourceID)
+		found = true
+		break
+	}
+	if !found {
+		return fmt.Errorf("package %s is not in the lock file", sourceID)
+	}
+
+	root.Schema = lockSchemaURL
+	localPackagesFile := lpp.fileManager.GetAppLocalPackagesFilePath()
+	jsonData, err := marshalIndent(root, "", "  ")
+	if err != nil {
+		fmt.Println("Error marshaling JSON:", err)
+		return err
+	}
+	if err := lpp.fileManager.WriteFile(localPackagesFile, jsonData, 0644); err != nil {
+		fmt.Println("Error writing to file:", err)
+		return err
+	}
+	return nil
+}
+
+// SetPackageInstallMethod records how an installed package was installed
+func (lpp *LocalPackagesParser) SetPackageInstallMethod(sourceID string, method string) error {
+	sourceID = packageid.Normalize(sourceID)
+	root := lpp.GetData(false)
+	found := false
+	for i := range root.Packages {
+		if root.Packages[i].SourceID != sourceID {
+			continue
+		}
+		if root.Packages[i].Extras == nil {
+			root.Packages[i].Extras = &PackageExtras{}
+		}
+		root.Packages[i].Extras.InstallMethod = method
+		found = true
+		break
+	}
+	if !found {
+		return fmt.Errorf("package %s is not in the lock file", sourceID)
+	}
+
+	root.Schema = lockSchemaURL
+	localPackagesFile := lpp.fileManager.GetAppLocalPackagesFilePath()
+	jsonData, err := marshalIndent(root, "", "  ")
+	if err != nil {
+		fmt.Println("Error marshaling JSON:", err)
+		return err
+	}
+	if err := lpp.fileManager.WriteFile(localPackagesFile, jsonData, 0644); err != nil {
+		fmt.Println("Error writing to file:", err)
+		return err
+	}
+	return nil
+}
+
 func normalizeExternalQueryRepoURLForPin(u string) string {
 	u = strings.TrimSpace(u)
 	u = strings.TrimSuffix(u, "/")
@@ -196,7 +472,7 @@ func treeSitterExternalQueryPinKey(lang, repoURL string) string {
 // (commit SHA + repo URL). The lock row must already exist for sourceID. Multiple repos per
 // language are keyed by language and repo_url together.
 func (lpp *LocalPackagesParser) MergePackageTreeSitterExternalQueryPins(sourceID string, pins []TreeSitterExternalQueryPin) error {
-	sourceID = normalizePackageID(sourceID)
+	sourceID = packageid.Normalize(sourceID)
 	if strings.TrimSpace(sourceID) == "" || len(pins) == 0 {
 		return nil
 	}
@@ -279,7 +555,7 @@ func GetTreeSitterParserLockChoice(consumerSourceID, language string) (sourceID
 // MergePackageTreeSitterParserChoice records which registry parser package to use for a language.
 // consumerVersion is used to create a new lock row when the consumer package is not yet recorded.
 func (lpp *LocalPackagesParser) MergePackageTreeSitterParserChoice(consumerSourceID, language, chosenSourceID, consumerVersion string) error {
-	consumerSourceID = normalizePackageID(consumerSourceID)
+	consumerSourceID = packageid.Normalize(consumerSourceID)
 	language = strings.TrimSpace(language)
 	chosenSourceID = strings.TrimSpace(chosenSourceID)
 	if consumerSourceID == "" || language == "" || chosenSourceID == "" {
@@ -371,7 +647,7 @@ func GetTreeSitterQueryLockChoice(consumerSourceID, language, integration string
 func (lpp *LocalPackagesParser) MergePackageTreeSitterQueryChoice(
 	consumerSourceID, language, integration, chosenSourceID, consumerVersion string,
 ) error {
-	consumerSourceID = normalizePackageID(consumerSourceID)
+	consumerSourceID = packageid.Normalize(consumerSourceID)
 	language = strings.TrimSpace(language)
 	integration = strings.TrimSpace(integration)
 	chosenSourceID = strings.TrimSpace(chosenSourceID)
@@ -449,20 +725,15 @@ func MergePackageTreeSitterQueryChoice(
 }
 
 // GetDataForProvider returns the local packages data
-// for a specific provider. Supports both legacy (pkg:provider/pkg) and new (provider:pkg) formats.
+// for a specific provider. GetData converts legacy IDs (pkg:provider/pkg) already.
 func (lpp *LocalPackagesParser) GetDataForProvider(provider string) LocalPackageRoot {
 	localPackageRoot := lpp.GetData(false)
 	filteredPackages := []LocalPackageItem{}
 
 	for _, item := range localPackageRoot.Packages {
-		// Check for new format: provider:pkg
 		if strings.HasPrefix(item.SourceID, provider+":") {
 			filteredPackages = append(filteredPackages, item)
 		}
-		// Also check legacy format for backward compatibility (though GetData normalizes)
-		if strings.HasPrefix(item.SourceID, "pkg:"+provider+"/") {
-			filteredPackages = append(filteredPackages, item)
-		}
 	}
 
 	return LocalPackageRoot{Packages: filteredPackages}
@@ -470,7 +741,7 @@ func (lpp *LocalPackagesParser) GetDataForProvider(provider string) LocalPackage
 
 func (lpp *LocalPackagesParser) AddLocalPackage(sourceId string, version string) error {
 	// Normalize the source ID to new format before storing
-	normalizedID := normalizePackageID(sourceId)
+	normalizedID := packageid.Normalize(sourceId)
 	localPackageRoot := lpp.GetData(false)
 	packageExists := false
 
@@ -481,6 +752,7 @@ func (lpp *LocalPackagesParser) AddLocalPackage(sourceId string, version string)
 				localPackageRoot.Packages[i].Extras.TreeSitterExternalQueries = nil
 				localPackageRoot.Packages[i].Extras.TreeSitterParserChoices = nil
 				localPackageRoot.Packages[i].Extras.TreeSitterQueryChoices = nil
+				localPackageRoot.Packages[i].Extras.BinHashes = nil
 			}
 			// Update the existing package with the new version
 			localPackageRoot.Packages[i].Version = version
@@ -514,7 +786,7 @@ func (lpp *LocalPackagesParser) AddLocalPackage(sourceId string, version string)
 
 func (lpp *LocalPackagesParser) RemoveLocalPackage(sourceId string) error {
 	// Normalize the source ID to new format before looking up
-	normalizedID := normalizePackageID(sourceId)
+	normalizedID := packageid.Normalize(sourceId)
 	localPackageRoot := lpp.GetData(false)
 	for i, pkg := range localPackageRoot.Packages {
 		if pkg.SourceID == normalizedID {
@@ -540,7 +812,7 @@ func (lpp *LocalPackagesParser) RemoveLocalPackage(sourceId string) error {
 
 func (lpp *LocalPackagesParser) GetBySourceId(sourceId string) LocalPackageItem {
 	// Normalize the source ID to new format before looking up
-	normalizedID := normalizePackageID(sourceId)
+	normalizedID := packageid.Normalize(sourceId)
 	localPackageRoot := lpp.GetData(false)
 	for _, item := range localPackageRoot.Packages {
 		if item.SourceID == normalizedID {
@@ -552,7 +824,7 @@ func (lpp *LocalPackagesParser) GetBySourceId(sourceId string) LocalPackageItem
 
 func (lpp *LocalPackagesParser) IsPackageInstalled(sourceId string) bool {
 	// Normalize the source ID to new format before looking up
-	normalizedID := normalizePackageID(sourceId)
+	normalizedID := packageid.Normalize(sourceId)
 	localPackageRoot := lpp.GetData(false)
 	for _, item := range localPackageRoot.Packages {
 		if item.SourceID == normalizedID {
@@ -562,6 +834,70 @@ func (lpp *LocalPackagesParser) IsPackageInstalled(sourceId string) bool {
 	return false
 }
 
+// NormalizeLockFile rewrites zana-lock.json with legacy package IDs
+// (pkg:provider/pkg) converted to the provider:pkg format, dropping entries
+// that duplicate an earlier one after the conversion.
+// It reports whether the file changed.
+func (lpp *LocalPackagesParser) NormalizeLockFile() (bool, error) {
+	localPackagesFile := lpp.fileManager.GetAppLocalPackagesFilePath()
+	if !lpp.fileManager.FileExists(localPackagesFile) {
+		return false, nil
+	}
+	byteValue, err := lpp.fileManager.ReadFile(localPackagesFile)
+	if err != nil {
+		return false, err
+	}
+	var localPackageRoot LocalPackageRoot
+	if err := json.Unmarshal(byteValue, &localPackageRoot); err != nil {
+		return false, err
+	}
+
+	changed := false
+	seen := map[string]bool{}
+	packages := make([]LocalPackageItem, 0, len(localPackageRoot.Packages))
+	for _, pkg := range localPackageRoot.Packages {
+		normalizedID := packageid.Normalize(pkg.SourceID)
+		if normalizedID != pkg.SourceID {
+			changed = true
+			pkg.SourceID = normalizedID
+		}
+		if seen[normalizedID] {
+			changed = true
+			continue
+		}
+		seen[normalizedID] = true
+		if pkg.Extras != nil {
+			for i, choice := range pkg.Extras.TreeSitterParserChoices {
+				if id := packageid.Normalize(choice.SourceID); id != choice.SourceID {
+					changed = true
+					pkg.Extras.TreeSitterParserChoices[i].SourceID = id
+				}
+			}
+			for i, choice := range pkg.Extras.TreeSitterQueryChoices {
+				if id := packageid.Normalize(choice.SourceID); id != choice.SourceID {
+					changed = true
+					pkg.Extras.TreeSitterQueryChoices[i].SourceID = id
+				}
+			}
+		}
+		packages = append(packages, pkg)
+	}
+	if !changed {
+		return false, nil
+	}
+
+	localPackageRoot.Packages = packages
+	localPackageRoot.Schema = lockSchemaURL
+	jsonData, err := marshalIndent(localPackageRoot, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	if err := lpp.fileManager.WriteFile(localPackagesFile, jsonData, 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // Global instance for backward compatibility
 var globalParser *LocalPackagesParser
 
@@ -594,6 +930,34 @@ func MergePackageTreeSitterExternalQueryPins(sourceId string, pins []TreeSitterE
 	return globalParser.MergePackageTreeSitterExternalQueryPins(sourceId, pins)
 }
 
+func SetPackageBinHashes(sourceId string, hashes map[string]string) error {
+	return globalParser.SetPackageBinHashes(sourceId, hashes)
+}
+
+func SetPackageRegistryEntry(sourceId string, entry json.RawMessage) error {
+	return globalParser.SetPackageRegistryEntry(sourceId, entry)
+}
+
+func SetPackageAssetPins(sourceId string, pins []AssetPin) error {
+	return globalParser.SetPackageAssetPins(sourceId, pins)
+}
+
+func SetPackageSmokeTest(sourceId string, result *SmokeTestResult) error {
+	return globalParser.SetPackageSmokeTest(sourceId, result)
+}
+
+func SetPackageUnlinked(sourceId string, unlinked bool) error {
+	return globalParser.SetPackageUnlinked(sourceId, unlinked)
+}
+
+func SetPackageInstallMethod(sourceId string, method string) error {
+	return globalParser.SetPackageInstallMethod(sourceId, method)
+}
+
+func SetPackageOverrideOf(sourceId string, registrySourceId string) error {
+	return globalParser.SetPackageOverrideOf(sourceId, registrySourceId)
+}
+
 func GetBySourceId(sourceId string) LocalPackageItem {
 	return globalParser.GetBySourceId(sourceId)
 }
@@ -601,3 +965,7 @@ func GetBySourceId(sourceId string) LocalPackageItem {
 func IsPackageInstalled(sourceId string) bool {
 	return globalParser.IsPackageInstalled(sourceId)
 }
+
+func NormalizeLockFile() (bool, error) {
+	return globalParser.NormalizeLockFile()
+}
@@ -1,11 +1,19 @@
 package log
 
 import (
+	"context"
+	"encoding/json"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/mistweaverco/zana-client/internal/lib/events"
 	"github.com/mistweaverco/zana-client/internal/lib/version"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLog(t *testing.T) {
@@ -43,3 +51,113 @@ func TestNewLoggerProductionSetsErrorLevel(t *testing.T) {
 	assert.NotNil(t, logger)
 	assert.Equal(t, slog.LevelError, logLevel)
 }
+
+func readLogLines(t *testing.T, path string) []map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestOperationsAreAttachedToLogLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zana.log")
+	logger := NewLogger()
+	require.NoError(t, EnableFileOutput(path))
+	defer DisableFileOutput()
+
+	logger.Info("outside")
+	op := NewOperation("install", "npm:prettier")
+	opLogger := op.Logger(logger)
+	opLogger.Info("bound")
+	opLogger.With("step", "deps").Info("with")
+	nested := NewOperation("install", "npm:typescript")
+	logger.InfoContext(WithOperation(context.Background(), nested), "context")
+	logger.Info("unbound")
+	opLogger.Debug("below the file level")
+
+	lines := readLogLines(t, path)
+	require.Len(t, lines, 5)
+	assert.NotContains(t, lines[0], KeyOperationID)
+	assert.Equal(t, op.ID, lines[1][KeyOperationID])
+	assert.Equal(t, "install", lines[1][KeyAction])
+	assert.Equal(t, "npm:prettier", lines[1][KeyPackage])
+	assert.Equal(t, op.ID, lines[2][KeyOperationID])
+	assert.Equal(t, "deps", lines[2]["step"])
+	assert.Equal(t, nested.ID, lines[3][KeyOperationID])
+	assert.Equal(t, "npm:typescript", lines[3][KeyPackage])
+	assert.NotContains(t, lines[4], KeyOperationID)
+	assert.NotEqual(t, op.ID, nested.ID)
+}
+
+func TestOperationLoggersAcrossGoroutines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zana.log")
+	logger := NewLogger()
+	require.NoError(t, EnableFileOutput(path))
+	defer DisableFileOutput()
+
+	var wg sync.WaitGroup
+	for _, pkg := range []string{"npm:a", "npm:b", "npm:c"} {
+		opLogger := NewOperation("install", pkg).Logger(logger)
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			// Goroutines spawned by an operation keep logging for it
+			go func() {
+				defer wg.Done()
+				opLogger.Info(pkg)
+			}()
+		}
+	}
+	wg.Wait()
+
+	lines := readLogLines(t, path)
+	require.Len(t, lines, 60)
+	for _, line := range lines {
+		assert.Equal(t, line["msg"], line[KeyPackage])
+	}
+}
+
+func TestOperationFrom(t *testing.T) {
+	_, ok := OperationFrom(context.Background())
+	assert.False(t, ok)
+
+	op := NewOperation("remove", "cargo:ripgrep")
+	got, ok := OperationFrom(WithOperation(context.Background(), op))
+	assert.True(t, ok)
+	assert.Equal(t, op, got)
+}
+
+func TestEnableFileOutputRotatesLargeFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zana.log")
+	require.NoError(t, os.WriteFile(path, make([]byte, maxLogFileSize+1), 0644))
+	require.NoError(t, EnableFileOutput(path))
+	defer DisableFileOutput()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+	_, err = os.Stat(path + ".1")
+	assert.NoError(t, err)
+}
+
+func TestLogRecordsArePublishedAsEvents(t *testing.T) {
+	logger := NewLogger()
+	var got []events.Event
+	stop := events.Watch(events.Handlers{OnLog: func(e events.Event) { got = append(got, e) }})
+	defer stop()
+
+	op := NewOperation("install", "npm:prettier")
+	// Published even though the stderr level is higher
+	op.Logger(logger).Debug("resolving version")
+
+	require.Len(t, got, 1)
+	assert.Equal(t, "resolving version", got[0].Message)
+	assert.Equal(t, "DEBUG", got[0].Level)
+	assert.Equal(t, op.ID, got[0].OperationID)
+	assert.Equal(t, "npm:prettier", got[0].Package)
+}
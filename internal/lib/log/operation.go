@@ -0,0 +1,56 @@
+package log
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+)
+
+// Operation identifies a single package operation, e.g. installing npm:prettier.
+// Every log line emitted for an operation carries its ID, so the lines of
+// parallel runs can be told apart.
+type Operation struct {
+	ID      string
+	Action  string
+	Package string
+}
+
+// NewOperation returns a new operation with a fresh ID
+func NewOperation(action, pkg string) Operation {
+	return Operation{ID: newOperationID(), Action: action, Package: pkg}
+}
+
+type operationKey struct{}
+
+// WithOperation returns a copy of ctx carrying op
+func WithOperation(ctx context.Context, op Operation) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// OperationFrom returns the operation ctx carries, if any
+func OperationFrom(ctx context.Context) (Operation, bool) {
+	if ctx == nil {
+		return Operation{}, false
+	}
+	op, ok := ctx.Value(operationKey{}).(Operation)
+	return op, ok
+}
+
+// Logger returns l with op attached to every line it logs. Loggers not
+// created by NewLogger are returned with the operation's attributes added.
+func (op Operation) Logger(l *slog.Logger) *slog.Logger {
+	h, ok := l.Handler().(*operationHandler)
+	if !ok {
+		return l.With(KeyOperationID, op.ID, KeyAction, op.Action, KeyPackage, op.Package)
+	}
+	bound := *h
+	bound.op = &op
+	return slog.New(&bound)
+}
+
+func newOperationID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -1,12 +1,27 @@
 package log
 
 import (
+	"context"
+	"errors"
 	"log/slog"
 	"os"
+	"sync/atomic"
+
+	"github.com/mistweaverco/zana-client/internal/lib/events"
 )
 
 var logLevel slog.Level = slog.LevelDebug
 
+// Attribute keys of the operation attached to log lines
+const (
+	KeyOperationID = "op"
+	KeyAction      = "action"
+	KeyPackage     = "package"
+)
+
+// maxLogFileSize is the size at which the log file is rotated when enabling file output
+const maxLogFileSize = 5 * 1024 * 1024
+
 func SetLogLevel(level slog.Level) {
 	slog.SetLogLoggerLevel(level)
 }
@@ -26,5 +41,129 @@ func NewLogger() *slog.Logger {
 			logLevel = slog.LevelError
 		}
 	}
-	return slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))
+	return slog.New(&operationHandler{
+		stderr: slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}),
+	})
+}
+
+// logFile is the optional log file every logger additionally writes to
+type logFile struct {
+	file    *os.File
+	handler slog.Handler
+}
+
+var activeLogFile atomic.Pointer[logFile]
+
+// EnableFileOutput makes all loggers additionally write to the log file at path,
+// at info level or the ZANA_DEBUG level if lower.
+// A log file larger than 5 MiB is rotated to path.1 first.
+func EnableFileOutput(path string) error {
+	if info, err := os.Stat(path); err == nil && info.Size() > maxLogFileSize {
+		_ = os.Rename(path, path+".1")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	level := min(logLevel, slog.LevelInfo)
+	lf := &logFile{file: f, handler: slog.NewJSONHandler(f, &slog.HandlerOptions{Level: level})}
+	if prev := activeLogFile.Swap(lf); prev != nil {
+		prev.file.Close()
+	}
+	return nil
+}
+
+// DisableFileOutput stops writing to the log file
+func DisableFileOutput() {
+	if prev := activeLogFile.Swap(nil); prev != nil {
+		prev.file.Close()
+	}
+}
+
+// operationHandler attaches the operation the logger is bound to (see
+// Operation.Logger), or else the one the context of the record carries (see
+// WithOperation), to every record and writes it to stderr and the log file,
+// if enabled.
+// Attributes and groups added via With are replayed on the log file handler,
+// as the log file can be enabled after the logger was created.
+type operationHandler struct {
+	stderr slog.Handler
+	with   []func(slog.Handler) slog.Handler
+	op     *Operation
+}
+
+func (h *operationHandler) fileHandler() slog.Handler {
+	lf := activeLogFile.Load()
+	if lf == nil {
+		return nil
+	}
+	fh := lf.handler
+	for _, w := range h.with {
+		fh = w(fh)
+	}
+	return fh
+}
+
+func (h *operationHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.stderr.Enabled(ctx, level) || events.Active() {
+		return true
+	}
+	if lf := activeLogFile.Load(); lf != nil {
+		return lf.handler.Enabled(ctx, level)
+	}
+	return false
+}
+
+// operation returns the operation of a record logged with ctx
+func (h *operationHandler) operation(ctx context.Context) (Operation, bool) {
+	if h.op != nil {
+		return *h.op, true
+	}
+	return OperationFrom(ctx)
+}
+
+func (h *operationHandler) Handle(ctx context.Context, r slog.Record) error {
+	op, hasOp := h.operation(ctx)
+	if hasOp {
+		r = r.Clone()
+		r.AddAttrs(
+			slog.String(KeyOperationID, op.ID),
+			slog.String(KeyAction, op.Action),
+			slog.String(KeyPackage, op.Package),
+		)
+	}
+	publishLogEvent(r, op, hasOp)
+	var errs []error
+	if h.stderr.Enabled(ctx, r.Level) {
+		errs = append(errs, h.stderr.Handle(ctx, r))
+	}
+	if fh := h.fileHandler(); fh != nil && fh.Enabled(ctx, r.Level) {
+		errs = append(errs, fh.Handle(ctx, r))
+	}
+	return errors.Join(errs...)
+}
+
+// publishLogEvent publishes r, logged for op if hasOp, to the event watchers, if any
+func publishLogEvent(r slog.Record, op Operation, hasOp bool) {
+	if !events.Active() {
+		return
+	}
+	e := events.Event{Kind: events.KindLog, Time: r.Time, Level: r.Level.String(), Message: r.Message}
+	if hasOp {
+		e.OperationID, e.Action, e.Package = op.ID, op.Action, op.Package
+	}
+	events.Publish(e)
+}
+
+func (h *operationHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.derive(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h *operationHandler) WithGroup(name string) slog.Handler {
+	return h.derive(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h *operationHandler) derive(w func(slog.Handler) slog.Handler) slog.Handler {
+	with := append(append([]func(slog.Handler) slog.Handler{}, h.with...), w)
+	return &operationHandler{stderr: w(h.stderr), with: with, op: h.op}
 }
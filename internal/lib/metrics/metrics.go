@@ -0,0 +1,208 @@
+// Package metrics counts how often zana commands run and fail, locally in
+// usage.json next to zana-lock.json. It is off unless metrics.mode in
+// config.yaml is local or share. Only share mode sends anything: the
+// aggregate counts, at most once a week, to metrics.endpoint, after the
+// command run that finds them due.
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/version"
+)
+
+// ShareInterval is how often share mode sends the counts
+const ShareInterval = 7 * 24 * time.Hour
+
+// Injectable helpers for tests
+var (
+	usagePath   = files.GetUsageFilePath
+	configFn    = files.GetMetricsConfig
+	shareClient = &http.Client{Timeout: 5 * time.Second}
+)
+
+// CommandUsage counts the runs of a command
+type CommandUsage struct {
+	Runs     int `json:"runs"`
+	Failures int `json:"failures"`
+}
+
+// FailureRate returns the share of runs that failed, between 0 and 1
+func (c CommandUsage) FailureRate() float64 {
+	if c.Runs == 0 {
+		return 0
+	}
+	return float64(c.Failures) / float64(c.Runs)
+}
+
+func (c *CommandUsage) add(failed bool) {
+	c.Runs++
+	if failed {
+		c.Failures++
+	}
+}
+
+// Usage is the content of usage.json
+type Usage struct {
+	// Since is when the first run was counted
+	Since    time.Time               `json:"since"`
+	Commands map[string]CommandUsage `json:"commands"`
+	// Unshared counts the runs since the counts were last shared
+	Unshared   map[string]CommandUsage `json:"unshared,omitempty"`
+	LastShared time.Time               `json:"lastShared,omitempty"`
+}
+
+// CommandNames returns the counted commands, sorted by runs
+func (u Usage) CommandNames() []string {
+	names := make([]string, 0, len(u.Commands))
+	for name := range u.Commands {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if u.Commands[names[i]].Runs != u.Commands[names[j]].Runs {
+			return u.Commands[names[i]].Runs > u.Commands[names[j]].Runs
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// SharePayload is what share mode sends: the zana version and platform and
+// the counts per command. Package IDs, paths, arguments and plugin names are
+// never recorded, so there's nothing identifying to send.
+type SharePayload struct {
+	Version  string                  `json:"version"`
+	OS       string                  `json:"os"`
+	Arch     string                  `json:"arch"`
+	Commands map[string]CommandUsage `json:"commands"`
+}
+
+// Mode returns the configured metrics mode
+func Mode() string {
+	return configFn().Mode
+}
+
+// Load returns the recorded usage, empty when nothing was recorded yet
+func Load() (Usage, error) {
+	usage := Usage{Commands: map[string]CommandUsage{}}
+	data, err := os.ReadFile(usagePath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return usage, nil
+		}
+		return usage, err
+	}
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return Usage{Commands: map[string]CommandUsage{}}, fmt.Errorf("invalid usage metrics %s: %w", usagePath(), err)
+	}
+	if usage.Commands == nil {
+		usage.Commands = map[string]CommandUsage{}
+	}
+	return usage, nil
+}
+
+func save(usage Usage) error {
+	data, err := json.MarshalIndent(usage, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := usagePath()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Record counts a run of command, when metrics are enabled. In share mode
+// the run is kept for Share as well.
+func Record(command string, failed bool) error {
+	cfg := configFn()
+	if cfg.Mode != files.MetricsModeLocal && cfg.Mode != files.MetricsModeShare {
+		return nil
+	}
+	usage, err := Load()
+	if err != nil {
+		return err
+	}
+	now := clock.Now().UTC()
+	if usage.Since.IsZero() {
+		usage.Since = now
+	}
+	counted := usage.Commands[command]
+	counted.add(failed)
+	usage.Commands[command] = counted
+	if cfg.Mode == files.MetricsModeShare {
+		if usage.Unshared == nil {
+			usage.Unshared = map[string]CommandUsage{}
+		}
+		unshared := usage.Unshared[command]
+		unshared.add(failed)
+		usage.Unshared[command] = unshared
+	}
+	return save(usage)
+}
+
+// Share sends the counts recorded since they were last shared, in share mode
+// and once ShareInterval passed since the last time. zana calls it after
+// recording each run, so at most one run a week waits for the request.
+// Failing to share keeps the counts for the next attempt.
+func Share() error {
+	cfg := configFn()
+	if cfg.Mode != files.MetricsModeShare {
+		return nil
+	}
+	usage, err := Load()
+	if err != nil {
+		return err
+	}
+	if len(usage.Unshared) == 0 {
+		return nil
+	}
+	now := clock.Now().UTC()
+	last := usage.LastShared
+	if last.IsZero() {
+		last = usage.Since
+	}
+	if now.Sub(last) < ShareInterval {
+		return nil
+	}
+	if err := share(cfg.Endpoint, usage.Unshared); err != nil {
+		return err
+	}
+	usage.Unshared = nil
+	usage.LastShared = now
+	return save(usage)
+}
+
+// share sends the counts to endpoint
+func share(endpoint string, commands map[string]CommandUsage) error {
+	body, err := json.Marshal(SharePayload{
+		Version:  version.VERSION,
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		Commands: commands,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := shareClient.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("sharing usage metrics failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubMetrics(t *testing.T, cfg files.MetricsConfig) {
+	t.Helper()
+	prevPath, prevConfig := usagePath, configFn
+	t.Cleanup(func() { usagePath, configFn = prevPath, prevConfig })
+	path := filepath.Join(t.TempDir(), "usage.json")
+	usagePath = func() string { return path }
+	configFn = func() files.MetricsConfig { return cfg }
+}
+
+func TestRecordIsOffByDefault(t *testing.T) {
+	stubMetrics(t, files.MetricsConfig{Mode: files.MetricsModeOff})
+
+	require.NoError(t, Record("install", false))
+
+	usage, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, usage.Commands)
+	assert.NoFileExists(t, usagePath())
+}
+
+func TestRecordLocal(t *testing.T) {
+	stubMetrics(t, files.MetricsConfig{Mode: files.MetricsModeLocal})
+	defer clock.Set(clock.NewFake(time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)))()
+
+	require.NoError(t, Record("install", false))
+	require.NoError(t, Record("install", true))
+	require.NoError(t, Record("sync packages", false))
+	require.NoError(t, Record("sync packages", false))
+	require.NoError(t, Record("sync packages", false))
+
+	usage, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), usage.Since)
+	assert.Equal(t, CommandUsage{Runs: 2, Failures: 1}, usage.Commands["install"])
+	assert.Equal(t, 0.5, usage.Commands["install"].FailureRate())
+	assert.Equal(t, []string{"sync packages", "install"}, usage.CommandNames())
+	assert.Empty(t, usage.Unshared, "local mode keeps nothing to share")
+}
+
+func TestRecordShare(t *testing.T) {
+	var received []SharePayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, SharePayload{})
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	stubMetrics(t, files.MetricsConfig{Mode: files.MetricsModeShare, Endpoint: server.URL})
+	fake := clock.NewFake(time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC))
+	defer clock.Set(fake)()
+
+	require.NoError(t, Record("install", false))
+	fake.Advance(ShareInterval)
+	require.NoError(t, Record("install", true))
+	assert.Empty(t, received, "recording never shares")
+
+	usage, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, CommandUsage{Runs: 2, Failures: 1}, usage.Unshared["install"])
+}
+
+func TestShare(t *testing.T) {
+	var received []SharePayload
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload SharePayload
+		require.NoError(t, json.Unmarshal(body, &payload))
+		received = append(received, payload)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	stubMetrics(t, files.MetricsConfig{Mode: files.MetricsModeShare, Endpoint: server.URL})
+	fake := clock.NewFake(time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC))
+	defer clock.Set(fake)()
+
+	require.NoError(t, Share())
+	assert.Empty(t, received, "nothing to share before a run was counted")
+
+	require.NoError(t, Record("install", false))
+	require.NoError(t, Share())
+	assert.Empty(t, received, "nothing is shared before a week passed")
+
+	fake.Advance(ShareInterval)
+	require.NoError(t, Record("install", true))
+	status = http.StatusInternalServerError
+	assert.Error(t, Share())
+	require.Len(t, received, 1)
+	usage, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, CommandUsage{Runs: 2, Failures: 1}, usage.Unshared["install"], "failed shares are retried")
+
+	status = http.StatusNoContent
+	require.NoError(t, Record("update", false))
+	require.NoError(t, Share())
+	require.Len(t, received, 2)
+	assert.Equal(t, map[string]CommandUsage{"install": {Runs: 2, Failures: 1}, "update": {Runs: 1}}, received[1].Commands)
+	usage, err = Load()
+	require.NoError(t, err)
+	assert.Empty(t, usage.Unshared)
+	assert.Equal(t, fake.Now().UTC(), usage.LastShared)
+
+	require.NoError(t, Record("update", false))
+	require.NoError(t, Share())
+	assert.Len(t, received, 2)
+	usage, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, CommandUsage{Runs: 2}, usage.Commands["update"])
+}
+
+func TestShareOnlyInShareMode(t *testing.T) {
+	stubMetrics(t, files.MetricsConfig{Mode: files.MetricsModeLocal, Endpoint: "http://127.0.0.1:0"})
+	require.NoError(t, Record("install", false))
+	require.NoError(t, Share())
+}
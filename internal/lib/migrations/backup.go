@@ -0,0 +1,109 @@
+package migrations
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+)
+
+// Injectable helpers for tests
+var backupRoot = func() string {
+	return filepath.Join(files.GetAppDataPath(), "migration-backups")
+}
+
+// backup holds copies of the paths a migration changes. Files are copied
+// rather than hard-linked, since migrations may rewrite them in place.
+type backup struct {
+	dir   string
+	paths []string
+	// existed records which paths existed, the others are removed on restore
+	existed []bool
+}
+
+func createBackup(version int, paths []string) (*backup, error) {
+	dir := filepath.Join(backupRoot(), strconv.Itoa(version))
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	b := &backup{dir: dir, paths: paths, existed: make([]bool, len(paths))}
+	for i, path := range paths {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			continue
+		}
+		if err := copyTree(path, b.copyPath(i)); err != nil {
+			_ = os.RemoveAll(dir)
+			return nil, err
+		}
+		b.existed[i] = true
+	}
+	return b, nil
+}
+
+func (b *backup) copyPath(i int) string {
+	return filepath.Join(b.dir, strconv.Itoa(i))
+}
+
+// restore puts the backed up paths back in place
+func (b *backup) restore() error {
+	for i, path := range b.paths {
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		if !b.existed[i] {
+			continue
+		}
+		if err := os.Rename(b.copyPath(i), path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyTree copies the file, symlink or directory at src to dest
+func copyTree(src, dest string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(src, dest string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,200 @@
+// Package migrations upgrades the on-disk layout of ZANA_HOME and the data
+// directory between zana versions. The layout version is recorded in
+// zana-state.json in ZANA_HOME; every migration moves it up by one.
+package migrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+)
+
+// Migration upgrades the layout from Version-1 to Version
+type Migration struct {
+	Version     int
+	Description string
+	// Paths returns the files and directories the migration changes.
+	// They are backed up before Apply and restored when Apply or Verify fails.
+	Paths func() []string
+	Apply func() error
+	// Verify checks the migrated layout
+	Verify func() error
+}
+
+// State is the content of zana-state.json
+type State struct {
+	LayoutVersion int `json:"layoutVersion"`
+}
+
+// Injectable helpers for tests
+var statePath = func() string {
+	return filepath.Join(files.GetAppDataPath(), "zana-state.json")
+}
+var isFreshHome = func() bool {
+	if _, err := os.Stat(files.GetAppLocalPackagesFilePath()); err == nil {
+		return false
+	}
+	entries, _ := os.ReadDir(files.GetAppPackagesPath())
+	return len(entries) == 0
+}
+
+// registered lists all migrations in version order
+var registered = []Migration{
+	{
+		Version:     1,
+		Description: "Rewrite legacy package IDs (pkg:npm/foo) in zana-lock.json",
+		Paths: func() []string {
+			return []string{files.GetAppLocalPackagesFilePath()}
+		},
+		Apply: func() error {
+			_, err := local_packages_parser.NormalizeLockFile()
+			return err
+		},
+		Verify: func() error {
+			// GetData normalizes IDs while reading, so check the file itself
+			data, err := os.ReadFile(files.GetAppLocalPackagesFilePath())
+			if os.IsNotExist(err) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			var lock local_packages_parser.LocalPackageRoot
+			if err := json.Unmarshal(data, &lock); err != nil {
+				return err
+			}
+			seen := map[string]bool{}
+			for _, pkg := range lock.Packages {
+				if packageid.IsLegacy(pkg.SourceID) {
+					return fmt.Errorf("%s still uses the legacy format", pkg.SourceID)
+				}
+				if seen[pkg.SourceID] {
+					return fmt.Errorf("%s is listed twice", pkg.SourceID)
+				}
+				seen[pkg.SourceID] = true
+			}
+			return nil
+		},
+	},
+}
+
+// LatestVersion is the layout version this zana uses
+func LatestVersion() int {
+	if len(registered) == 0 {
+		return 0
+	}
+	return registered[len(registered)-1].Version
+}
+
+// ReadState returns the recorded layout version. Homes without zana-state.json
+// are at version 0, unless nothing was installed yet: those start out at
+// LatestVersion, which is recorded right away.
+func ReadState() (State, error) {
+	data, err := os.ReadFile(statePath())
+	if os.IsNotExist(err) {
+		if isFreshHome() {
+			state := State{LayoutVersion: LatestVersion()}
+			return state, writeState(state)
+		}
+		return State{}, nil
+	}
+	if err != nil {
+		return State{}, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("invalid %s: %w", statePath(), err)
+	}
+	return state, nil
+}
+
+func writeState(state State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := statePath()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// IsFirstRun reports whether zana hasn't run with this ZANA_HOME yet: nothing
+// is installed and no layout version is recorded. ReadState records one on
+// the first run, so only the very first command sees this.
+func IsFirstRun() bool {
+	if _, err := os.Stat(statePath()); err == nil {
+		return false
+	}
+	return isFreshHome()
+}
+
+// Pending returns the migrations the layout still needs, in order.
+// It fails when the layout was written by a newer zana.
+func Pending() ([]Migration, error) {
+	state, err := ReadState()
+	if err != nil {
+		return nil, err
+	}
+	if state.LayoutVersion > LatestVersion() {
+		return nil, fmt.Errorf("the layout (version %d) was upgraded by a newer zana, this one supports up to version %d", state.LayoutVersion, LatestVersion())
+	}
+	pending := []Migration{}
+	for _, m := range registered {
+		if m.Version > state.LayoutVersion {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Run applies the pending migrations in order, calling before for each one.
+// Every migration is backed up, applied and verified; a failing migration is
+// rolled back and stops the run, keeping the versions migrated so far.
+func Run(before func(Migration)) ([]Migration, error) {
+	pending, err := Pending()
+	if err != nil {
+		return nil, err
+	}
+	applied := []Migration{}
+	for _, m := range pending {
+		if before != nil {
+			before(m)
+		}
+		if err := runMigration(m); err != nil {
+			return applied, fmt.Errorf("migration %d (%s) failed and was rolled back: %w", m.Version, m.Description, err)
+		}
+		if err := writeState(State{LayoutVersion: m.Version}); err != nil {
+			return applied, err
+		}
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+func runMigration(m Migration) error {
+	backup, err := createBackup(m.Version, m.Paths())
+	if err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	err = m.Apply()
+	if err == nil && m.Verify != nil {
+		if verr := m.Verify(); verr != nil {
+			err = fmt.Errorf("verify: %w", verr)
+		}
+	}
+	if err != nil {
+		if rerr := backup.restore(); rerr != nil {
+			return fmt.Errorf("%w (restoring the backup failed, it is kept at %s: %v)", err, backup.dir, rerr)
+		}
+	}
+	_ = os.RemoveAll(backup.dir)
+	_ = os.Remove(backupRoot()) // only when empty
+	return err
+}
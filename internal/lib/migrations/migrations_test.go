@@ -0,0 +1,136 @@
+package migrations
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubMigrations runs the tests against a temp home with a file the
+// migrations change and returns the path of that file
+func stubMigrations(t *testing.T, fresh bool, migrations []Migration) string {
+	t.Helper()
+	home := t.TempDir()
+	prevState, prevFresh, prevBackup, prevRegistered := statePath, isFreshHome, backupRoot, registered
+	t.Cleanup(func() {
+		statePath, isFreshHome, backupRoot, registered = prevState, prevFresh, prevBackup, prevRegistered
+	})
+	statePath = func() string { return filepath.Join(home, "zana-state.json") }
+	isFreshHome = func() bool { return fresh }
+	backupRoot = func() string { return filepath.Join(home, "migration-backups") }
+	registered = migrations
+
+	target := filepath.Join(home, "data.txt")
+	require.NoError(t, os.WriteFile(target, []byte("v0"), 0644))
+	for i := range registered {
+		registered[i].Paths = func() []string { return []string{target} }
+	}
+	return target
+}
+
+func writeMigration(version int, content string, verify error) Migration {
+	return Migration{
+		Version:     version,
+		Description: "write " + content,
+		Apply: func() error {
+			return os.WriteFile(filepath.Join(filepath.Dir(statePath()), "data.txt"), []byte(content), 0644)
+		},
+		Verify: func() error { return verify },
+	}
+}
+
+func TestReadState(t *testing.T) {
+	t.Run("existing homes without state start at version 0", func(t *testing.T) {
+		stubMigrations(t, false, []Migration{writeMigration(1, "v1", nil)})
+		state, err := ReadState()
+		require.NoError(t, err)
+		assert.Equal(t, 0, state.LayoutVersion)
+		assert.NoFileExists(t, statePath())
+	})
+
+	t.Run("fresh homes start at the latest version", func(t *testing.T) {
+		stubMigrations(t, true, []Migration{writeMigration(1, "v1", nil), writeMigration(2, "v2", nil)})
+		pending, err := Pending()
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+		assert.FileExists(t, statePath())
+	})
+
+	t.Run("layouts of newer versions are refused", func(t *testing.T) {
+		stubMigrations(t, false, []Migration{writeMigration(1, "v1", nil)})
+		require.NoError(t, writeState(State{LayoutVersion: 3}))
+		_, err := Pending()
+		assert.EqualError(t, err, "the layout (version 3) was upgraded by a newer zana, this one supports up to version 1")
+	})
+}
+
+func TestRun(t *testing.T) {
+	t.Run("applies pending migrations in order", func(t *testing.T) {
+		target := stubMigrations(t, false, []Migration{writeMigration(1, "v1", nil), writeMigration(2, "v2", nil)})
+		started := []int{}
+		applied, err := Run(func(m Migration) { started = append(started, m.Version) })
+		require.NoError(t, err)
+		assert.Len(t, applied, 2)
+		assert.Equal(t, []int{1, 2}, started)
+
+		data, _ := os.ReadFile(target)
+		assert.Equal(t, "v2", string(data))
+		state, _ := ReadState()
+		assert.Equal(t, 2, state.LayoutVersion)
+		assert.NoDirExists(t, backupRoot())
+
+		applied, err = Run(nil)
+		require.NoError(t, err)
+		assert.Empty(t, applied)
+	})
+
+	t.Run("rolls back a migration failing verification", func(t *testing.T) {
+		target := stubMigrations(t, false, []Migration{writeMigration(1, "v1", nil), writeMigration(2, "broken", errors.New("bad content"))})
+		applied, err := Run(nil)
+		assert.EqualError(t, err, "migration 2 (write broken) failed and was rolled back: verify: bad content")
+		assert.Len(t, applied, 1)
+
+		data, _ := os.ReadFile(target)
+		assert.Equal(t, "v1", string(data))
+		state, _ := ReadState()
+		assert.Equal(t, 1, state.LayoutVersion)
+	})
+}
+
+func TestLockFileMigration(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("ZANA_HOME", home)
+	lockPath := filepath.Join(home, "zana-lock.json")
+	require.NoError(t, os.WriteFile(lockPath, []byte(`{"packages": [
+		{"sourceId": "pkg:npm/prettier", "version": "3.3.0"},
+		{"sourceId": "npm:prettier", "version": "3.2.0"}
+	]}`), 0644))
+
+	applied, err := Run(nil)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, 1, applied[0].Version)
+
+	data, err := os.ReadFile(lockPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "pkg:npm")
+	assert.Contains(t, string(data), `"sourceId": "npm:prettier"`)
+	state, err := ReadState()
+	require.NoError(t, err)
+	assert.Equal(t, LatestVersion(), state.LayoutVersion)
+}
+
+func TestIsFirstRun(t *testing.T) {
+	stubMigrations(t, true, []Migration{writeMigration(1, "v1", nil)})
+	assert.True(t, IsFirstRun())
+	_, err := ReadState()
+	require.NoError(t, err)
+	assert.False(t, IsFirstRun(), "the state is recorded on the first run")
+
+	stubMigrations(t, false, nil)
+	assert.False(t, IsFirstRun(), "homes with packages were used before")
+}
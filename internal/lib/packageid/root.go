@@ -0,0 +1,49 @@
+// Package packageid canonicalizes package IDs. zana writes them as
+// <provider>:<package-id>, older lock files, registries and scripts use the
+// legacy pkg:<provider>/<package-id>. IDs in either format are converted here,
+// so the rest of zana only deals with the canonical one.
+package packageid
+
+import "strings"
+
+const legacyPrefix = "pkg:"
+
+// IsLegacy reports whether id uses the legacy pkg:<provider>/<package-id> format
+func IsLegacy(id string) bool {
+	return strings.HasPrefix(id, legacyPrefix)
+}
+
+// Normalize converts a legacy ID to <provider>:<package-id>, e.g.
+// pkg:npm/@scope/pkg to npm:@scope/pkg. Other IDs, including malformed legacy
+// ones, are returned unchanged.
+func Normalize(id string) string {
+	if !IsLegacy(id) {
+		return id
+	}
+	provider, name := Split(id)
+	if provider == "" || name == "" {
+		return id
+	}
+	return provider + ":" + name
+}
+
+// Split returns the provider and package ID of id, in either format. Both
+// are empty when id doesn't name its provider, one of them is for malformed
+// IDs like pkg:npm/ or :prettier.
+func Split(id string) (provider, name string) {
+	if rest, ok := strings.CutPrefix(id, legacyPrefix); ok {
+		provider, name, _ = strings.Cut(rest, "/")
+		return provider, name
+	}
+	provider, name, ok := strings.Cut(id, ":")
+	if !ok {
+		return "", ""
+	}
+	return provider, name
+}
+
+// HasProvider reports whether id names its provider, as opposed to a bare
+// package name like prettier that is looked up in the registry
+func HasProvider(id string) bool {
+	return strings.Contains(id, ":")
+}
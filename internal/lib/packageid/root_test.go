@@ -0,0 +1,48 @@
+package packageid
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"pkg:npm/prettier", "npm:prettier"},
+		{"pkg:npm/@scope/pkg", "npm:@scope/pkg"},
+		{"pkg:golang/golang.org/x/tools/gopls", "golang:golang.org/x/tools/gopls"},
+		{"npm:prettier", "npm:prettier"},
+		{"prettier", "prettier"},
+		{"pkg:npm", "pkg:npm"},
+		{"pkg:npm/", "pkg:npm/"},
+		{"pkg:/prettier", "pkg:/prettier"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, Normalize(tt.input), tt.input)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	for input, want := range map[string][2]string{
+		"pkg:npm/@scope/pkg":     {"npm", "@scope/pkg"},
+		"github:sharkdp/bat":     {"github", "sharkdp/bat"},
+		"golang:example.com/x:y": {"golang", "example.com/x:y"},
+		"prettier":               {"", ""},
+		"pkg:npm/":               {"npm", ""},
+		":prettier":              {"", "prettier"},
+	} {
+		provider, name := Split(input)
+		assert.Equal(t, want, [2]string{provider, name}, input)
+	}
+}
+
+func TestIsLegacyAndHasProvider(t *testing.T) {
+	assert.True(t, IsLegacy("pkg:npm/prettier"))
+	assert.False(t, IsLegacy("npm:prettier"))
+	assert.True(t, HasProvider("pkg:npm/prettier"))
+	assert.True(t, HasProvider("npm:prettier"))
+	assert.False(t, HasProvider("prettier"))
+}
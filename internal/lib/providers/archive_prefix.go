@@ -0,0 +1,110 @@
+package providers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// stripArchivePrefix removes leading directories from an archive extracted
+// to extractDir. A strip_components declared in the registry is honoured as
+// is. Without one, a single top-level directory wrapping the whole archive
+// (e.g. tool-1.2.3-linux-amd64/) is stripped, but only when the expected bin
+// paths resolve inside it and not at the top level.
+func stripArchivePrefix(extractDir string, stripComponents *int, binPaths []string) error {
+	if stripComponents != nil {
+		for i := 0; i < *stripComponents; i++ {
+			if err := stripComponent(extractDir); err != nil {
+				return fmt.Errorf("strip_components: %w", err)
+			}
+		}
+		return nil
+	}
+	if detectArchivePrefix(extractDir, binPaths) == "" {
+		return nil
+	}
+	return stripComponent(extractDir)
+}
+
+// detectArchivePrefix returns the name of the single top-level directory in
+// extractDir when the bin paths are only found inside it, or ""
+func detectArchivePrefix(extractDir string, binPaths []string) string {
+	entries, err := os.ReadDir(extractDir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return ""
+	}
+	prefix := entries[0].Name()
+	found := false
+	for _, binPath := range binPaths {
+		if binPath == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(extractDir, binPath)); err == nil {
+			return ""
+		}
+		if _, err := os.Stat(filepath.Join(extractDir, prefix, binPath)); err == nil {
+			found = true
+		}
+	}
+	if !found {
+		return ""
+	}
+	return prefix
+}
+
+// stripComponent moves the content of every top-level directory in dir up by
+// one level. Like tar --strip-components, top-level files are dropped.
+func stripComponent(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	stripped, err := os.MkdirTemp(filepath.Dir(dir), ".strip-")
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		children, err := os.ReadDir(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			_ = os.RemoveAll(stripped)
+			return err
+		}
+		for _, child := range children {
+			target := filepath.Join(stripped, child.Name())
+			if _, err := os.Lstat(target); err == nil {
+				_ = os.RemoveAll(stripped)
+				return fmt.Errorf("%s is in more than one top-level directory", child.Name())
+			}
+			if err := os.Rename(filepath.Join(dir, entry.Name(), child.Name()), target); err != nil {
+				_ = os.RemoveAll(stripped)
+				return err
+			}
+		}
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.Rename(stripped, dir)
+}
+
+// assetBinPaths returns the bin paths the registry item expects in the
+// extracted asset. Assets that point into a subdirectory of the archive
+// ("file.tar.gz:subdir/") already name their layout, so none are returned.
+func assetBinPaths(asset *registry_parser.RegistryItemSourceAsset, registryItem registry_parser.RegistryItem) []string {
+	if strings.Contains(asset.File.String(), ":") {
+		return nil
+	}
+	paths := []string{}
+	for binName, binTemplate := range registryItem.Bin {
+		if binPath := ResolveBinPath(binTemplate, asset, binName); binPath != "" {
+			paths = append(paths, binPath)
+		}
+	}
+	return paths
+}
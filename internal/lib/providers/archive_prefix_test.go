@@ -0,0 +1,92 @@
+package providers
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeArchiveTree(t *testing.T, dir string, paths ...string) {
+	t.Helper()
+	for _, p := range paths {
+		full := filepath.Join(dir, p)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(p), 0644))
+	}
+}
+
+func TestStripArchivePrefixDetectsVersionedDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeArchiveTree(t, dir, "tool-1.2.3-linux/bin/tool", "tool-1.2.3-linux/README.md")
+
+	require.NoError(t, stripArchivePrefix(dir, nil, []string{"bin/tool"}))
+
+	assert.FileExists(t, filepath.Join(dir, "bin", "tool"))
+	assert.FileExists(t, filepath.Join(dir, "README.md"))
+	assert.NoDirExists(t, filepath.Join(dir, "tool-1.2.3-linux"))
+}
+
+func TestStripArchivePrefixKeepsMatchingLayout(t *testing.T) {
+	dir := t.TempDir()
+	writeArchiveTree(t, dir, "tool-1.2.3/tool")
+
+	// The bin mapping already names the top-level directory
+	require.NoError(t, stripArchivePrefix(dir, nil, []string{"tool-1.2.3/tool"}))
+	assert.FileExists(t, filepath.Join(dir, "tool-1.2.3", "tool"))
+
+	// Nothing in the bin mapping points into the directory
+	require.NoError(t, stripArchivePrefix(dir, nil, []string{"other"}))
+	assert.FileExists(t, filepath.Join(dir, "tool-1.2.3", "tool"))
+
+	// Several top-level entries are no common prefix
+	writeArchiveTree(t, dir, "LICENSE")
+	require.NoError(t, stripArchivePrefix(dir, nil, []string{"tool"}))
+	assert.FileExists(t, filepath.Join(dir, "tool-1.2.3", "tool"))
+}
+
+func TestStripArchivePrefixHonoursStripComponents(t *testing.T) {
+	dir := t.TempDir()
+	writeArchiveTree(t, dir, "a/x/tool", "b/y/lib.so", "a/NOTES", "top-level.txt")
+
+	two := 2
+	require.NoError(t, stripArchivePrefix(dir, &two, nil))
+
+	assert.FileExists(t, filepath.Join(dir, "tool"))
+	assert.FileExists(t, filepath.Join(dir, "lib.so"))
+	assert.NoFileExists(t, filepath.Join(dir, "NOTES"))
+	assert.NoFileExists(t, filepath.Join(dir, "top-level.txt"))
+
+	zero := 0
+	require.NoError(t, stripArchivePrefix(dir, &zero, []string{"missing"}))
+	assert.FileExists(t, filepath.Join(dir, "tool"))
+}
+
+func TestStripArchivePrefixRejectsCollisions(t *testing.T) {
+	dir := t.TempDir()
+	writeArchiveTree(t, dir, "a/tool", "b/tool")
+
+	one := 1
+	err := stripArchivePrefix(dir, &one, nil)
+	assert.EqualError(t, err, "strip_components: tool is in more than one top-level directory")
+}
+
+func TestAssetBinPaths(t *testing.T) {
+	var item registry_parser.RegistryItem
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"bin": {"tool": "{{source.asset.bin}}"},
+		"source": {"id": "github:o/tool", "asset": [
+			{"target": "linux_x64", "file": "tool.tar.gz", "bin": "bin/tool", "strip_components": 1},
+			{"target": "darwin_arm64", "file": "tool.tar.gz:tool-darwin/", "bin": "tool"}
+		]}
+	}`), &item))
+
+	assert.Equal(t, []string{"bin/tool"}, assetBinPaths(&item.Source.Asset[0], item))
+	require.NotNil(t, item.Source.Asset[0].StripComponents)
+	assert.Equal(t, 1, *item.Source.Asset[0].StripComponents)
+	assert.Empty(t, assetBinPaths(&item.Source.Asset[1], item))
+}
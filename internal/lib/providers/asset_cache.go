@@ -0,0 +1,175 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// Injectable helpers for tests
+var assetCacheHTTPGet = http.Get
+var assetCacheRegistryParser = registry_parser.NewDefaultRegistryParser
+
+// releaseAssetURL returns the release download URL of a git hosting provider
+func releaseAssetURL(provider, repo, tag, fileName string) string {
+	switch provider {
+	case "gitlab":
+		// GitLab release download URL format: https://gitlab.com/{project_path}/-/releases/{tag}/downloads/{filename}
+		return fmt.Sprintf("https://gitlab.com/%s/-/releases/%s/downloads/%s", repo, tag, fileName)
+	case "codeberg", "gitea":
+		// Gitea release download URL format: https://{host}/{owner}/{repo}/releases/download/{tag}/{filename}
+		f, forgeRepo, _ := forgeFor(provider, repo)
+		return f.releaseAssetURL(forgeRepo, tag, fileName)
+	default:
+		return fmt.Sprintf("https://github.com/%s/releases/download/%s/%s", repo, tag, fileName)
+	}
+}
+
+// restoreCachedAsset copies a download of url that zana cache prefetch or
+// import put into the asset cache to destPath and returns its sha256 sum.
+// It returns false when the asset is not cached or caches are bypassed
+// (--no-cache). Installs don't add to the cache, so it only holds what was
+// asked for.
+func restoreCachedAsset(url, destPath string) (string, bool) {
+	if files.CacheBypassed() {
+		return "", false
+	}
+	cachePath := files.GetAssetCacheFilePath(url)
+	if _, err := os.Stat(cachePath); err != nil {
+		return "", false
+	}
+	sum, err := copyAssetFile(cachePath, destPath)
+	if err != nil {
+		Logger.Info(fmt.Sprintf("Asset cache: Warning restoring %s: %v", url, err))
+		return "", false
+	}
+	Logger.Info(fmt.Sprintf("Asset cache: Using cached download of %s", url))
+	return sum, true
+}
+
+// copyAssetFile copies src to dest and returns the sha256 sum of the copy
+func copyAssetFile(src, dest string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = in.Close() }()
+
+	tmp := dest + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return "", err
+	}
+	sum, err := hashCopy(out, in)
+	if err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	return sum, os.Rename(tmp, dest)
+}
+
+// AssetURLsForPackage returns the download URLs an install of sourceID at version
+// fetches for the current platform. Only release asset based providers
+// (github, gitlab, codeberg, gitea) and the generic provider download assets,
+// for all other providers the result is empty.
+func AssetURLsForPackage(sourceID, version string) []string {
+	registryItem := assetCacheRegistryParser().GetBySourceId(sourceID)
+	if registryItem.Source.ID == "" {
+		return nil
+	}
+	if version == "" || version == "latest" {
+		version = registryItem.Version
+	}
+
+	provider, repo := packageid.Split(sourceID)
+	switch provider {
+	case "github", "gitlab", "codeberg", "gitea":
+		asset := FindMatchingAsset(context.Background(), registryItem.Source.Asset)
+		if asset == nil || version == "" {
+			return nil
+		}
+		fileName := ResolveTemplate(asset.File.String(), version)
+		return []string{releaseAssetURL(provider, repo, version, fileName)}
+	case "generic":
+		download := NewProviderGeneric().findMatchingDownload(context.Background(), registryItem.Source.Download)
+		if download == nil {
+			return nil
+		}
+		urls := make([]string, 0, len(download.Files))
+		for _, url := range download.Files {
+			urls = append(urls, ResolveTemplate(url, version))
+		}
+		return urls
+	default:
+		return nil
+	}
+}
+
+// PrefetchResult summarizes a PrefetchAssets run
+type PrefetchResult struct {
+	Downloaded int      `json:"downloaded"`
+	Cached     int      `json:"cached"`
+	Failed     []string `json:"failed,omitempty"`
+}
+
+// PrefetchAssets downloads the assets of all given packages into the asset cache,
+// so that later installs of the same versions do not need network access.
+func PrefetchAssets(packages []local_packages_parser.LocalPackageItem) PrefetchResult {
+	result := PrefetchResult{}
+	for _, pkg := range packages {
+		for _, url := range AssetURLsForPackage(pkg.SourceID, pkg.Version) {
+			cachePath := files.GetAssetCacheFilePath(url)
+			if _, err := os.Stat(cachePath); err == nil && !files.CacheBypassed() {
+				result.Cached++
+				continue
+			}
+			if err := downloadToAssetCache(url, cachePath); err != nil {
+				Logger.Error(fmt.Sprintf("Asset cache: Error prefetching %s for %s: %v", url, pkg.SourceID, err))
+				result.Failed = append(result.Failed, url)
+				continue
+			}
+			result.Downloaded++
+		}
+	}
+	return result
+}
+
+func downloadToAssetCache(url, cachePath string) error {
+	resp, err := assetCacheHTTPGet(url)
+	if err != nil {
+		return fmt.Errorf("failed to download: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	}
+
+	tmp := cachePath + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	if _, err := io.Copy(file, downloadBody(context.Background(), resp, url)); err != nil {
+		_ = file.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return os.Rename(tmp, cachePath)
+}
@@ -0,0 +1,122 @@
+package providers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withAssetCacheRegistry(t *testing.T, raw string) {
+	t.Helper()
+	reg := registry_parser.NewRegistryParser(nil)
+	require.NoError(t, reg.LoadFromBytes([]byte(raw)))
+	orig := assetCacheRegistryParser
+	assetCacheRegistryParser = func() *registry_parser.RegistryParser { return reg }
+	t.Cleanup(func() { assetCacheRegistryParser = orig })
+}
+
+func TestReleaseAssetURL(t *testing.T) {
+	assert.Equal(t, "https://github.com/o/r/releases/download/v1.0.0/r.tar.gz", releaseAssetURL("github", "o/r", "v1.0.0", "r.tar.gz"))
+	assert.Equal(t, "https://gitlab.com/g/s/p/-/releases/v1/downloads/p.zip", releaseAssetURL("gitlab", "g/s/p", "v1", "p.zip"))
+	assert.Equal(t, "https://codeberg.org/o/r/releases/download/1.0/r.zip", releaseAssetURL("codeberg", "o/r", "1.0", "r.zip"))
+}
+
+func TestRestoreCachedAsset(t *testing.T) {
+	t.Setenv("ZANA_CACHE", t.TempDir())
+	dir := t.TempDir()
+	url := "https://example.com/tool.tar.gz"
+	dest := filepath.Join(dir, "tool.tar.gz")
+
+	_, ok := restoreCachedAsset(url, dest)
+	assert.False(t, ok)
+
+	require.NoError(t, os.WriteFile(files.GetAssetCacheFilePath(url), []byte("payload"), 0644))
+	sum, ok := restoreCachedAsset(url, dest)
+	assert.True(t, ok)
+	assert.Equal(t, "239f59ed55e737c77147cf55ad0c1b030b6d7ee748a7426952f9b852d5a935e5", sum)
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "payload", string(data))
+}
+
+func TestRestoreCachedAssetBypassed(t *testing.T) {
+	t.Setenv("ZANA_CACHE", t.TempDir())
+	dir := t.TempDir()
+	url := "https://example.com/tool.tar.gz"
+	require.NoError(t, os.WriteFile(files.GetAssetCacheFilePath(url), []byte("payload"), 0644))
+
+	files.SetCacheBypass(true)
+	t.Cleanup(func() { files.SetCacheBypass(false) })
+	_, ok := restoreCachedAsset(url, filepath.Join(dir, "tool.tar.gz"))
+	assert.False(t, ok, "cached assets are ignored with --no-cache")
+}
+
+func TestAssetURLsForPackage(t *testing.T) {
+	target := DetectRegistryTarget()
+	withAssetCacheRegistry(t, fmt.Sprintf(`[
+		{"name": "tool", "version": "v1.2.0", "source": {"id": "github:owner/tool", "asset": [{"target": %q, "file": "tool-{{version}}.tar.gz"}]}},
+		{"name": "gen", "version": "2.0", "source": {"id": "generic:gen", "download": [{"target": %q, "files": {"gen.zip": "https://example.com/gen-{{version}}.zip"}}]}},
+		{"name": "lib", "version": "1.0.0", "source": {"id": "npm:lib"}}
+	]`, target, target))
+
+	assert.Equal(t,
+		[]string{"https://github.com/owner/tool/releases/download/v1.0.0/tool-v1.0.0.tar.gz"},
+		AssetURLsForPackage("github:owner/tool", "v1.0.0"))
+	assert.Equal(t,
+		[]string{"https://github.com/owner/tool/releases/download/v1.2.0/tool-v1.2.0.tar.gz"},
+		AssetURLsForPackage("github:owner/tool", "latest"))
+	assert.Equal(t,
+		[]string{"https://example.com/gen-2.0.zip"},
+		AssetURLsForPackage("generic:gen", "2.0"))
+	assert.Empty(t, AssetURLsForPackage("npm:lib", "1.0.0"))
+	assert.Empty(t, AssetURLsForPackage("github:owner/unknown", "1.0.0"))
+}
+
+func TestPrefetchAssets(t *testing.T) {
+	t.Setenv("ZANA_CACHE", t.TempDir())
+	target := DetectRegistryTarget()
+	withAssetCacheRegistry(t, fmt.Sprintf(`[
+		{"name": "tool", "version": "v1.0.0", "source": {"id": "github:owner/tool", "asset": [{"target": %q, "file": "tool.tar.gz"}]}},
+		{"name": "broken", "version": "v1.0.0", "source": {"id": "github:owner/broken", "asset": [{"target": %q, "file": "broken.tar.gz"}]}}
+	]`, target, target))
+
+	requests := 0
+	orig := assetCacheHTTPGet
+	assetCacheHTTPGet = func(url string) (*http.Response, error) {
+		requests++
+		if url == "https://github.com/owner/broken/releases/download/v1.0.0/broken.tar.gz" {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString("asset"))}, nil
+	}
+	defer func() { assetCacheHTTPGet = orig }()
+
+	packages := []local_packages_parser.LocalPackageItem{
+		{SourceID: "github:owner/tool", Version: "v1.0.0"},
+		{SourceID: "github:owner/broken", Version: "v1.0.0"},
+	}
+	result := PrefetchAssets(packages)
+	assert.Equal(t, 1, result.Downloaded)
+	assert.Equal(t, 0, result.Cached)
+	assert.Equal(t, []string{"https://github.com/owner/broken/releases/download/v1.0.0/broken.tar.gz"}, result.Failed)
+
+	data, err := os.ReadFile(files.GetAssetCacheFilePath("https://github.com/owner/tool/releases/download/v1.0.0/tool.tar.gz"))
+	require.NoError(t, err)
+	assert.Equal(t, "asset", string(data))
+
+	// Second run uses the cache for the already downloaded asset
+	result = PrefetchAssets(packages[:1])
+	assert.Equal(t, 0, result.Downloaded)
+	assert.Equal(t, 1, result.Cached)
+	assert.Equal(t, 2, requests)
+}
@@ -0,0 +1,189 @@
+package providers
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// skipChecksums disables the verification of release assets against their
+// checksums (--skip-checksum)
+var skipChecksums bool
+
+// SetSkipChecksums enables or disables installing release assets whose
+// checksum doesn't match
+func SetSkipChecksums(skip bool) {
+	skipChecksums = skip
+}
+
+// errAssetChecksumMismatch is returned when a downloaded release asset
+// doesn't match its checksum
+var errAssetChecksumMismatch = errors.New("checksum mismatch")
+
+// checksumSidecarExts are the checksum files looked for next to a release
+// asset, e.g. tool.tar.gz.sha256, when the registry has no checksum for it
+var checksumSidecarExts = []string{".sha256", ".sha512"}
+
+// assetChecksum is the expected checksum of a release asset
+type assetChecksum struct {
+	// algorithm is sha256 or sha512
+	algorithm string
+	sum       string
+	// source is where the checksum comes from, for error messages
+	source string
+}
+
+func (c assetChecksum) newHash() hash.Hash {
+	if c.algorithm == "sha512" {
+		return sha512.New()
+	}
+	return sha256.New()
+}
+
+// verifiedDownload wraps download, the downloadAsset of a release asset
+// provider, to verify each asset against its checksum once it's downloaded
+// (see verifyAssetChecksum). get fetches checksum sidecar files.
+func verifiedDownload(download func(ctx context.Context, url, destPath string) error, get func(url string) (*http.Response, error), assets registry_parser.RegistryItemSourceAssetList, version string) func(ctx context.Context, url, destPath string) error {
+	return func(ctx context.Context, url, destPath string) error {
+		if err := download(ctx, url, destPath); err != nil {
+			return err
+		}
+		return verifyAssetChecksum(ctx, url, destPath, get, assets, version)
+	}
+}
+
+// verifyAssetChecksum checks the release asset downloaded from url to assetPath
+// against the checksum the registry lists for it or, without one, against a
+// .sha256 or .sha512 file published next to it. Assets without any checksum
+// pass. A mismatching asset is deleted, dropped from the asset cache and
+// unpinned, and errAssetChecksumMismatch is returned.
+func verifyAssetChecksum(ctx context.Context, url, assetPath string, get func(url string) (*http.Response, error), assets registry_parser.RegistryItemSourceAssetList, version string) error {
+	if skipChecksums {
+		return nil
+	}
+	expected, ok := registryAssetChecksum(url, assets, version)
+	if !ok {
+		expected, ok = sidecarAssetChecksum(url, get)
+	}
+	if !ok {
+		Logger.InfoContext(ctx, fmt.Sprintf("Checksums: No checksum for %s, not verified", url))
+		return nil
+	}
+
+	sum, err := hashFileWith(assetPath, expected.newHash())
+	if err != nil {
+		return fmt.Errorf("failed to hash %s: %w", assetPath, err)
+	}
+	if strings.EqualFold(sum, expected.sum) {
+		Logger.InfoContext(ctx, fmt.Sprintf("Checksums: %s matches its %s from %s", url, expected.algorithm, expected.source))
+		return nil
+	}
+	_ = os.Remove(assetPath)
+	_ = os.Remove(files.GetAssetCacheFilePath(url))
+	forgetAssetPin(ctx, url)
+	return fmt.Errorf("%w: %s has %s %s, %s lists %s (use --skip-checksum to install it anyway)", errAssetChecksumMismatch, url, expected.algorithm, sum, expected.source, strings.ToLower(expected.sum))
+}
+
+// registryAssetChecksum returns the checksum the registry lists for the
+// asset downloaded from url, matched by its file name
+func registryAssetChecksum(url string, assets registry_parser.RegistryItemSourceAssetList, version string) (assetChecksum, bool) {
+	name := path.Base(url)
+	for _, asset := range assets {
+		if ResolveTemplate(asset.File.String(), version) != name {
+			continue
+		}
+		switch {
+		case asset.SHA512 != "":
+			return assetChecksum{algorithm: "sha512", sum: asset.SHA512, source: "the registry"}, true
+		case asset.SHA256 != "":
+			return assetChecksum{algorithm: "sha256", sum: asset.SHA256, source: "the registry"}, true
+		}
+	}
+	return assetChecksum{}, false
+}
+
+// sidecarAssetChecksum fetches the checksum published next to the asset at
+// url. Sidecars that are missing, unreachable or unreadable are ignored.
+func sidecarAssetChecksum(url string, get func(url string) (*http.Response, error)) (assetChecksum, bool) {
+	for _, ext := range checksumSidecarExts {
+		sidecarURL := url + ext
+		resp, err := get(sidecarURL)
+		if err != nil {
+			Logger.Info(fmt.Sprintf("Checksums: Warning fetching %s: %v", sidecarURL, err))
+			continue
+		}
+		sum, ok := "", false
+		if resp.StatusCode == http.StatusOK {
+			sum, ok = parseChecksumFile(io.LimitReader(resp.Body, 1<<20), path.Base(url))
+		}
+		_ = resp.Body.Close()
+		if ok {
+			// The length tells the algorithm, whatever the file is called
+			algorithm := "sha256"
+			if len(sum) == sha512.Size*2 {
+				algorithm = "sha512"
+			}
+			return assetChecksum{algorithm: algorithm, sum: sum, source: path.Base(sidecarURL)}, true
+		}
+	}
+	return assetChecksum{}, false
+}
+
+// parseChecksumFile returns the checksum of name from the output of
+// sha256sum (sum, space and file name per line, the name may be prefixed
+// with *) or from a file holding just the checksum
+func parseChecksumFile(r io.Reader, name string) (string, bool) {
+	var lone []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || !isHexChecksum(fields[0]) {
+			continue
+		}
+		if len(fields) == 1 {
+			lone = append(lone, fields[0])
+			continue
+		}
+		if path.Base(strings.TrimPrefix(fields[1], "*")) == name {
+			return fields[0], true
+		}
+	}
+	if len(lone) == 1 {
+		return lone[0], true
+	}
+	return "", false
+}
+
+// isHexChecksum reports whether s is a hex encoded sha256 or sha512 sum
+func isHexChecksum(s string) bool {
+	if len(s) != sha256.Size*2 && len(s) != sha512.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+func hashFileWith(filePath string, h hash.Hash) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,144 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func sha512Hex(data string) string {
+	sum := sha512.Sum512([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestParseChecksumFile(t *testing.T) {
+	sum := sha256Hex("tool")
+	other := sha256Hex("other")
+
+	got, ok := parseChecksumFile(strings.NewReader(sum+"\n"), "tool.tar.gz")
+	assert.True(t, ok)
+	assert.Equal(t, sum, got)
+
+	got, ok = parseChecksumFile(strings.NewReader(other+"  other.tar.gz\n"+sum+" *dist/tool.tar.gz\n"), "tool.tar.gz")
+	assert.True(t, ok)
+	assert.Equal(t, sum, got)
+
+	_, ok = parseChecksumFile(strings.NewReader(other+"  other.tar.gz\n"), "tool.tar.gz")
+	assert.False(t, ok)
+	_, ok = parseChecksumFile(strings.NewReader("<html>Not Found</html>"), "tool.tar.gz")
+	assert.False(t, ok)
+}
+
+func TestVerifyAssetChecksum(t *testing.T) {
+	const url = "https://github.com/owner/tool/releases/download/v1.0.0/tool-1.0.0.tar.gz"
+	assets := registry_parser.RegistryItemSourceAssetList{{File: registry_parser.RegistryItemSourceAssetFile{}}}
+	require.NoError(t, assets[0].File.UnmarshalJSON([]byte(`"tool-{{version}}.tar.gz"`)))
+
+	setup := func(t *testing.T) (string, *[]string) {
+		t.Helper()
+		t.Setenv("ZANA_CACHE", t.TempDir())
+		path := filepath.Join(t.TempDir(), "tool-1.0.0.tar.gz")
+		require.NoError(t, os.WriteFile(path, []byte("tool"), 0644))
+		require.NoError(t, os.WriteFile(files.GetAssetCacheFilePath(url), []byte("tool"), 0644))
+		requested := &[]string{}
+		return path, requested
+	}
+	get := func(requested *[]string, sidecars map[string]string) func(string) (*http.Response, error) {
+		return func(u string) (*http.Response, error) {
+			*requested = append(*requested, u)
+			if body, ok := sidecars[u]; ok {
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+			}
+			if strings.HasSuffix(u, ".sha512") {
+				return nil, errors.New("connection refused")
+			}
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+		}
+	}
+
+	t.Run("registry checksum matches", func(t *testing.T) {
+		path, requested := setup(t)
+		assets[0].SHA256 = strings.ToUpper(sha256Hex("tool"))
+		defer func() { assets[0].SHA256 = "" }()
+		require.NoError(t, verifyAssetChecksum(context.Background(), url, path, get(requested, nil), assets, "1.0.0"))
+		assert.Empty(t, *requested, "the registry checksum makes sidecars unnecessary")
+	})
+
+	t.Run("registry checksum mismatch", func(t *testing.T) {
+		path, requested := setup(t)
+		assets[0].SHA512 = sha512Hex("the real tool")
+		defer func() { assets[0].SHA512 = "" }()
+		err := verifyAssetChecksum(context.Background(), url, path, get(requested, nil), assets, "1.0.0")
+		require.ErrorIs(t, err, errAssetChecksumMismatch)
+		assert.Contains(t, err.Error(), "has sha512 "+sha512Hex("tool")+", the registry lists "+sha512Hex("the real tool"))
+		assert.Contains(t, err.Error(), "--skip-checksum")
+		assert.NoFileExists(t, path)
+		assert.NoFileExists(t, files.GetAssetCacheFilePath(url))
+	})
+
+	t.Run("sidecar checksum", func(t *testing.T) {
+		path, requested := setup(t)
+		sidecars := map[string]string{url + ".sha256": sha256Hex("tampered") + "  tool-1.0.0.tar.gz\n"}
+		err := verifyAssetChecksum(context.Background(), url, path, get(requested, sidecars), assets, "1.0.0")
+		require.ErrorIs(t, err, errAssetChecksumMismatch)
+		assert.Contains(t, err.Error(), "tool-1.0.0.tar.gz.sha256 lists")
+
+		path, requested = setup(t)
+		sidecars[url+".sha256"] = sha256Hex("tool")
+		require.NoError(t, verifyAssetChecksum(context.Background(), url, path, get(requested, sidecars), assets, "1.0.0"))
+		assert.Equal(t, []string{url + ".sha256"}, *requested)
+	})
+
+	t.Run("no checksum", func(t *testing.T) {
+		path, requested := setup(t)
+		require.NoError(t, verifyAssetChecksum(context.Background(), url, path, get(requested, nil), assets, "1.0.0"))
+		assert.Equal(t, []string{url + ".sha256", url + ".sha512"}, *requested)
+		assert.FileExists(t, path)
+	})
+
+	t.Run("skipped", func(t *testing.T) {
+		path, requested := setup(t)
+		assets[0].SHA256 = sha256Hex("the real tool")
+		defer func() { assets[0].SHA256 = "" }()
+		SetSkipChecksums(true)
+		defer SetSkipChecksums(false)
+		require.NoError(t, verifyAssetChecksum(context.Background(), url, path, get(requested, nil), assets, "1.0.0"))
+		assert.FileExists(t, path)
+	})
+}
+
+func TestGitHubInstallRefusesChecksumMismatch(t *testing.T) {
+	target := DetectRegistryTarget()
+	const assetURL = "https://github.com/owner/tool/releases/download/v1.0.0/tool.tar.gz"
+	stubGitHubTagInstall(t, `[
+		{"name": "tool", "version": "v1.0.0", "source": {"id": "github:owner/tool", "asset": [{"target": "`+target+`", "file": "tool.tar.gz", "sha256": "`+sha256Hex("the real tool")+`"}]},
+		 "bin": {"tool": "tool"}}
+	]`, map[string][]byte{
+		assetURL: tarGz(t, map[string]string{"tool": "#!/bin/sh\n"}),
+	})
+
+	p := NewProviderGitHub()
+	assert.False(t, p.Install(context.Background(), "github:owner/tool", "v1.0.0"))
+	assert.NoFileExists(t, filepath.Join(p.getRepoPath("owner/tool"), "tool"))
+	assert.NoFileExists(t, files.GetAssetCacheFilePath(assetURL))
+	assert.NotEmpty(t, ConsumeFailureCode("github:owner/tool"))
+}
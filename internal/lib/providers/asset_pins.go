@@ -0,0 +1,152 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+)
+
+// Injectable helpers for tests
+var assetPinsGet = local_packages_parser.GetBySourceId
+var assetPinsSet = local_packages_parser.SetPackageAssetPins
+
+// enforceAssetPins makes downloads of packages with asset pins in the lock
+// file fail unless they match a pin exactly (zana sync packages)
+var enforceAssetPins bool
+
+// pendingAssetPins collects the assets downloaded per operation ID until the
+// install finishes and the pins are written to the lock file
+var pendingAssetPins = map[string][]local_packages_parser.AssetPin{}
+var pendingAssetPinsMu sync.Mutex
+
+// SetEnforceAssetPins enables or disables verification of downloaded assets
+// against the asset pins recorded in the lock file
+func SetEnforceAssetPins(enforce bool) {
+	enforceAssetPins = enforce
+}
+
+// pinAsset pins the sha256 sum of an asset downloaded from url for the
+// current operation, hashed while it was written (see hashCopy). With pins
+// enforced, an asset that is not pinned for the package or whose hash
+// differs from its pin fails the download.
+func pinAsset(ctx context.Context, url, sum string) error {
+	op, ok := currentOperation(ctx)
+	if !ok {
+		return nil
+	}
+	if enforceAssetPins {
+		if err := verifyAssetPin(op.Package, url, sum); err != nil {
+			// The cached copy may be the one that changed, never reuse it
+			_ = os.Remove(files.GetAssetCacheFilePath(url))
+			return err
+		}
+	}
+
+	pendingAssetPinsMu.Lock()
+	defer pendingAssetPinsMu.Unlock()
+	pendingAssetPins[op.ID] = append(pendingAssetPins[op.ID], local_packages_parser.AssetPin{URL: url, SHA256: sum})
+	return nil
+}
+
+// forgetAssetPin drops the pin of url the current operation collected, for
+// a download that gets discarded
+func forgetAssetPin(ctx context.Context, url string) {
+	op, ok := currentOperation(ctx)
+	if !ok {
+		return
+	}
+	pendingAssetPinsMu.Lock()
+	defer pendingAssetPinsMu.Unlock()
+	pins := pendingAssetPins[op.ID][:0]
+	for _, pin := range pendingAssetPins[op.ID] {
+		if pin.URL != url {
+			pins = append(pins, pin)
+		}
+	}
+	pendingAssetPins[op.ID] = pins
+}
+
+// verifyAssetPin checks an asset against the pins of sourceID. Packages
+// without pins pass, they get pinned by this install.
+func verifyAssetPin(sourceID, url, sum string) error {
+	item := assetPinsGet(sourceID)
+	if item.Extras == nil || len(item.Extras.AssetPins) == 0 {
+		return nil
+	}
+	for _, pin := range item.Extras.AssetPins {
+		if pin.URL != url {
+			continue
+		}
+		if pin.SHA256 != sum {
+			return fmt.Errorf("asset %s changed upstream: expected sha256 %s, got %s (use --update-pins to accept it)", url, pin.SHA256, sum)
+		}
+		return nil
+	}
+	return fmt.Errorf("asset %s is not pinned in the lock file (use --update-pins to accept it)", url)
+}
+
+// takeAssetPins returns and forgets the assets pinned by the current operation
+func takeAssetPins(ctx context.Context) []local_packages_parser.AssetPin {
+	op, ok := currentOperation(ctx)
+	if !ok {
+		return nil
+	}
+	pendingAssetPinsMu.Lock()
+	defer pendingAssetPinsMu.Unlock()
+	pins := pendingAssetPins[op.ID]
+	delete(pendingAssetPins, op.ID)
+	return pins
+}
+
+// recordAssetPins stores the assets a package was just installed or updated
+// from in the lock file. Installs that downloaded nothing keep their pins.
+// Failures are logged only, the install itself has succeeded.
+func recordAssetPins(ctx context.Context, sourceID string, pins []local_packages_parser.AssetPin) {
+	if len(pins) == 0 {
+		return
+	}
+	if err := assetPinsSet(sourceID, pins); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("Asset pins: Warning recording the asset pins of %s: %v", sourceID, err))
+	}
+}
+
+// VerifyCachedAssetPins hashes the cached downloads of the assets pinned for
+// packages concurrently, before a sync installs them one by one, and drops
+// those not matching their pin from the asset cache, so they are downloaded
+// again instead of failing the sync. It returns how many were dropped.
+func VerifyCachedAssetPins(packages []local_packages_parser.LocalPackageItem) int {
+	var pins []local_packages_parser.AssetPin
+	for _, pkg := range packages {
+		if pkg.Extras != nil {
+			pins = append(pins, pkg.Extras.AssetPins...)
+		}
+	}
+
+	var dropped atomic.Int32
+	sem := make(chan struct{}, integrityWorkers())
+	var wg sync.WaitGroup
+	for _, pin := range pins {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			cachePath := files.GetAssetCacheFilePath(pin.URL)
+			sum, err := hashFile(cachePath)
+			if err != nil || sum == pin.SHA256 {
+				return
+			}
+			Logger.Info(fmt.Sprintf("Asset pins: Dropping cached %s, expected sha256 %s, got %s", pin.URL, pin.SHA256, sum))
+			if os.Remove(cachePath) == nil {
+				dropped.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	return int(dropped.Load())
+}
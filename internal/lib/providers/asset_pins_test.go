@@ -0,0 +1,119 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPinAsset(t *testing.T) {
+	t.Setenv("ZANA_CACHE", t.TempDir())
+	origGet, origSet := assetPinsGet, assetPinsSet
+	t.Cleanup(func() {
+		assetPinsGet, assetPinsSet = origGet, origSet
+		SetEnforceAssetPins(false)
+	})
+
+	url := "https://github.com/owner/tool/releases/download/v1.0.0/tool.tar.gz"
+	path := filepath.Join(t.TempDir(), "tool.tar.gz")
+	require.NoError(t, os.WriteFile(path, []byte("tool"), 0644))
+	sum, err := hashFile(path)
+	require.NoError(t, err)
+
+	var lockPins []local_packages_parser.AssetPin
+	assetPinsGet = func(sourceID string) local_packages_parser.LocalPackageItem {
+		return local_packages_parser.LocalPackageItem{SourceID: sourceID, Extras: &local_packages_parser.PackageExtras{AssetPins: lockPins}}
+	}
+
+	t.Run("outside an operation nothing is pinned", func(t *testing.T) {
+		assert.NoError(t, pinAsset(context.Background(), url, sum))
+		assert.Nil(t, takeAssetPins(context.Background()))
+	})
+
+	t.Run("downloads are collected per operation", func(t *testing.T) {
+		ctx := newOperation(context.Background(), files.HistoryActionInstall, "github:owner/tool")
+		require.NoError(t, pinAsset(ctx, url, sum))
+		assert.Equal(t, []local_packages_parser.AssetPin{{URL: url, SHA256: sum}}, takeAssetPins(ctx))
+		assert.Nil(t, takeAssetPins(ctx))
+	})
+
+	t.Run("enforced pins", func(t *testing.T) {
+		SetEnforceAssetPins(true)
+		ctx := newOperation(context.Background(), files.HistoryActionInstall, "github:owner/tool")
+
+		lockPins = nil
+		assert.NoError(t, pinAsset(ctx, url, sum), "unpinned packages get pinned")
+		takeAssetPins(ctx)
+
+		lockPins = []local_packages_parser.AssetPin{{URL: url, SHA256: sum}}
+		assert.NoError(t, pinAsset(ctx, url, sum))
+		takeAssetPins(ctx)
+
+		cachePath := files.GetAssetCacheFilePath(url)
+		require.NoError(t, os.MkdirAll(filepath.Dir(cachePath), 0755))
+		require.NoError(t, os.WriteFile(cachePath, []byte("tool"), 0644))
+		lockPins = []local_packages_parser.AssetPin{{URL: url, SHA256: "0000"}}
+		err := pinAsset(ctx, url, sum)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "changed upstream")
+		assert.NoFileExists(t, cachePath, "a mismatching asset is dropped from the cache")
+
+		lockPins = []local_packages_parser.AssetPin{{URL: "https://github.com/owner/tool/releases/download/v1.0.0/other.tar.gz", SHA256: sum}}
+		err = pinAsset(ctx, url, sum)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not pinned")
+		assert.Nil(t, takeAssetPins(ctx))
+	})
+}
+
+func TestRecordAssetPins(t *testing.T) {
+	origSet := assetPinsSet
+	t.Cleanup(func() { assetPinsSet = origSet })
+	recorded := map[string][]local_packages_parser.AssetPin{}
+	assetPinsSet = func(sourceID string, pins []local_packages_parser.AssetPin) error {
+		recorded[sourceID] = pins
+		return nil
+	}
+
+	recordAssetPins(context.Background(), "npm:prettier", nil)
+	pins := []local_packages_parser.AssetPin{{URL: "https://example.com/tool.tar.gz", SHA256: "abc"}}
+	recordAssetPins(context.Background(), "generic:tool", pins)
+
+	assert.NotContains(t, recorded, "npm:prettier", "installs without downloads keep their pins")
+	assert.Equal(t, pins, recorded["generic:tool"])
+}
+
+func TestVerifyCachedAssetPins(t *testing.T) {
+	t.Setenv("ZANA_CACHE", t.TempDir())
+	cache := func(url, content string) string {
+		path := files.GetAssetCacheFilePath(url)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+	good := cache("https://example.com/good.tar.gz", "good")
+	bad := cache("https://example.com/bad.tar.gz", "corrupted")
+	goodSum, err := hashFile(good)
+	require.NoError(t, err)
+
+	dropped := VerifyCachedAssetPins([]local_packages_parser.LocalPackageItem{
+		{SourceID: "github:owner/good", Extras: &local_packages_parser.PackageExtras{AssetPins: []local_packages_parser.AssetPin{
+			{URL: "https://example.com/good.tar.gz", SHA256: goodSum},
+			{URL: "https://example.com/uncached.tar.gz", SHA256: goodSum},
+		}}},
+		{SourceID: "github:owner/bad", Extras: &local_packages_parser.PackageExtras{AssetPins: []local_packages_parser.AssetPin{
+			{URL: "https://example.com/bad.tar.gz", SHA256: goodSum},
+		}}},
+		{SourceID: "npm:unpinned"},
+	})
+
+	assert.Equal(t, 1, dropped)
+	assert.FileExists(t, good)
+	assert.NoFileExists(t, bad)
+}
@@ -0,0 +1,80 @@
+package providers
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// Injectable helpers for tests
+var binFilterRegistryParser = registry_parser.NewDefaultRegistryParser
+var binFilterConfig = files.GetBinFilter
+
+// binFilter decides which executables of a package are linked (or get a
+// wrapper) in the bin directory, see files.BinFilterConfig
+type binFilter struct {
+	sourceID string
+	include  []string
+	exclude  []string
+	// unlinked packages get none of their executables linked
+	unlinked bool
+}
+
+// binFilterFor returns the bin filter of the package sourceID with the registry
+// entry item: paths.binFilters in config.yaml takes precedence over the registry's bin_filter
+func binFilterFor(sourceID string, item registry_parser.RegistryItem) binFilter {
+	if sourceID == "" {
+		sourceID = item.Source.ID
+	}
+	f := binFilter{sourceID: packageid.Normalize(sourceID), unlinked: isUnlinked(sourceID)}
+	if cfg, ok := binFilterConfig(sourceID); ok {
+		f.include, f.exclude = cfg.Include, cfg.Exclude
+	} else if item.BinFilter != nil {
+		f.include, f.exclude = item.BinFilter.Include, item.BinFilter.Exclude
+	}
+	return f
+}
+
+// binFilterForSourceID looks up the registry entry of sourceID for binFilterFor
+func binFilterForSourceID(sourceID string) binFilter {
+	return binFilterFor(sourceID, binFilterRegistryParser().GetBySourceId(sourceID))
+}
+
+func matchesAnyBinPattern(patterns []string, name string) bool {
+	// Match with and without extension, so "tsserver" also filters tsserver.cmd on Windows
+	bare := strings.TrimSuffix(name, filepath.Ext(name))
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, bare); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allows reports whether the executable name gets linked, logging skipped names
+func (f binFilter) allows(name string) bool {
+	if f.unlinked {
+		Logger.Info(fmt.Sprintf("Bin filter: Not linking %s of unlinked %s", name, f.sourceID))
+		return false
+	}
+	if matchesAnyBinPattern(f.exclude, name) || len(f.include) > 0 && !matchesAnyBinPattern(f.include, name) {
+		Logger.Info(fmt.Sprintf("Bin filter: Not linking %s of %s", name, f.sourceID))
+		return false
+	}
+	return true
+}
+
+// BinAllowed reports whether the executable name of the package with the
+// registry entry item is linked into the bin directory
+func BinAllowed(item registry_parser.RegistryItem, name string) bool {
+	return binFilterFor(item.Source.ID, item).allows(name)
+}
@@ -0,0 +1,98 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubBinFilterConfig(t *testing.T, configured map[string]files.BinFilterConfig) {
+	t.Helper()
+	orig := binFilterConfig
+	binFilterConfig = func(sourceID string) (files.BinFilterConfig, bool) {
+		cfg, ok := configured[packageid.Normalize(sourceID)]
+		return cfg, ok
+	}
+	t.Cleanup(func() { binFilterConfig = orig })
+}
+
+func TestBinFilterAllows(t *testing.T) {
+	t.Run("no patterns allow everything", func(t *testing.T) {
+		assert.True(t, binFilter{}.allows("tsc"))
+	})
+
+	t.Run("exclude patterns", func(t *testing.T) {
+		f := binFilter{exclude: []string{"tsserver", "*-debug"}}
+		assert.True(t, f.allows("tsc"))
+		assert.False(t, f.allows("tsserver"))
+		assert.False(t, f.allows("tsserver.cmd"))
+		assert.False(t, f.allows("node-debug"))
+	})
+
+	t.Run("include patterns", func(t *testing.T) {
+		f := binFilter{include: []string{"rg"}}
+		assert.True(t, f.allows("rg"))
+		assert.True(t, f.allows("rg.exe"))
+		assert.False(t, f.allows("rg-helper"))
+	})
+
+	t.Run("exclude wins over include", func(t *testing.T) {
+		f := binFilter{include: []string{"ts*"}, exclude: []string{"tsserver"}}
+		assert.True(t, f.allows("tsc"))
+		assert.False(t, f.allows("tsserver"))
+	})
+}
+
+func TestBinFilterFor(t *testing.T) {
+	item := registry_parser.RegistryItem{
+		Source:    registry_parser.RegistryItemSource{ID: "npm:typescript"},
+		BinFilter: &registry_parser.RegistryItemBinFilter{Exclude: []string{"tsc"}},
+	}
+
+	t.Run("uses the registry's bin_filter", func(t *testing.T) {
+		stubBinFilterConfig(t, nil)
+		assert.False(t, BinAllowed(item, "tsc"))
+		assert.True(t, BinAllowed(item, "tsserver"))
+	})
+
+	t.Run("config takes precedence", func(t *testing.T) {
+		stubBinFilterConfig(t, map[string]files.BinFilterConfig{
+			"npm:typescript": {Exclude: []string{"tsserver"}},
+		})
+		assert.True(t, BinAllowed(item, "tsc"))
+		assert.False(t, BinAllowed(item, "tsserver"))
+	})
+}
+
+func TestCreateSymlinksFromRegistry_SkipsFilteredBins(t *testing.T) {
+	t.Setenv("ZANA_HOME", t.TempDir())
+	t.Setenv("ZANA_DATA", t.TempDir())
+	t.Setenv("ZANA_BIN_LAYOUT", "flat")
+	stubBinFilterConfig(t, nil)
+
+	repoPath := t.TempDir()
+	for _, name := range []string{"tool", "tool-helper"} {
+		require.NoError(t, os.WriteFile(filepath.Join(repoPath, name), []byte("#!/bin/sh\n"), 0755))
+	}
+	item := registry_parser.RegistryItem{
+		Source:    registry_parser.RegistryItemSource{ID: "github:acme/tool"},
+		Bin:       map[string]string{"tool": "tool", "tool-helper": "tool-helper"},
+		BinFilter: &registry_parser.RegistryItemBinFilter{Exclude: []string{"*-helper"}},
+	}
+
+	p := NewProviderGitHub()
+	require.NoError(t, p.createSymlinksFromRegistry(context.Background(), "acme/tool", repoPath, nil, item))
+
+	binDir := files.GetAppBinPath()
+	_, err := os.Lstat(filepath.Join(binDir, "tool"))
+	assert.NoError(t, err)
+	_, err = os.Lstat(filepath.Join(binDir, "tool-helper"))
+	assert.True(t, os.IsNotExist(err))
+}
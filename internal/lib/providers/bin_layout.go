@@ -0,0 +1,198 @@
+package providers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// binLayoutMarkerFile records the layout the bin directory currently uses
+// (absent for the default flat layout), so a changed configuration can be
+// detected and existing entries migrated.
+const binLayoutMarkerFile = ".zana-bin-layout"
+
+// Injectable helpers for tests
+var binLayoutRegistryParser = registry_parser.NewDefaultRegistryParser
+var binLayoutLocalPackages = local_packages_parser.GetData
+
+// BinPathsInPrecedenceOrder returns the directories that need to be on PATH
+// for the configured bin layout, highest precedence first.
+// With the per-provider layout, providers listed in paths.binProviderOrder come first,
+// followed by all other providers in their default order.
+func BinPathsInPrecedenceOrder() []string {
+	if files.GetBinLayout() != files.BinLayoutPerProvider {
+		return []string{files.GetAppBinPath()}
+	}
+	order := []string{}
+	seen := map[string]bool{}
+	for _, p := range append(files.GetBinProviderOrder(), AvailableProviders...) {
+		if seen[p] || !IsSupportedProvider(p) {
+			continue
+		}
+		seen[p] = true
+		order = append(order, p)
+	}
+	paths := make([]string, 0, len(order))
+	for _, p := range order {
+		paths = append(paths, filepath.Join(files.GetAppBinPath(), p))
+	}
+	return paths
+}
+
+// EnsureBinLayout migrates existing bin entries when the configured bin layout
+// differs from the layout the bin directory was last set up with.
+func EnsureBinLayout() error {
+	binDir := files.GetAppBinPath()
+	marker := filepath.Join(binDir, binLayoutMarkerFile)
+	layout := files.GetBinLayout()
+
+	current := files.BinLayoutFlat
+	if b, err := os.ReadFile(marker); err == nil {
+		current = strings.TrimSpace(string(b))
+	}
+	if current == layout {
+		return nil
+	}
+
+	Logger.Info(fmt.Sprintf("Bin layout: Migrating bin directory from %s to %s", current, layout))
+	if layout == files.BinLayoutPerProvider {
+		if err := migrateBinToPerProvider(binDir); err != nil {
+			return err
+		}
+		return os.WriteFile(marker, []byte(layout+"\n"), 0644)
+	}
+	if err := migrateBinToFlat(binDir); err != nil {
+		return err
+	}
+	// The flat layout is the default, no marker needed
+	return os.Remove(marker)
+}
+
+// migrateBinToPerProvider moves entries from the bin directory into the
+// subdirectory of the provider that owns them. Ownership is detected from
+// the symlink target (a path below the provider's packages directory) or from
+// the registry bin names of the installed packages. Unknown entries stay in place.
+func migrateBinToPerProvider(binDir string) error {
+	entries, err := os.ReadDir(binDir)
+	if err != nil {
+		return err
+	}
+	owners := binOwnersFromLock()
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name() == binLayoutMarkerFile {
+			continue
+		}
+		src := filepath.Join(binDir, entry.Name())
+		provider := binOwnerFromSymlink(src)
+		if provider == "" {
+			provider = owners[entry.Name()]
+		}
+		if provider == "" {
+			continue
+		}
+		destDir := filepath.Join(binDir, provider)
+		if err := os.MkdirAll(destDir, 0755); err != nil {
+			return err
+		}
+		if err := moveBinEntry(src, filepath.Join(destDir, entry.Name())); err != nil {
+			Logger.Error(fmt.Sprintf("Bin layout: Error moving %s: %v", src, err))
+		}
+	}
+	return nil
+}
+
+// migrateBinToFlat moves all entries from provider subdirectories back into
+// the bin directory and removes the emptied subdirectories.
+func migrateBinToFlat(binDir string) error {
+	for _, provider := range AvailableProviders {
+		providerDir := filepath.Join(binDir, provider)
+		entries, err := os.ReadDir(providerDir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			src := filepath.Join(providerDir, entry.Name())
+			if err := moveBinEntry(src, filepath.Join(binDir, entry.Name())); err != nil {
+				Logger.Error(fmt.Sprintf("Bin layout: Error moving %s: %v", src, err))
+			}
+		}
+		_ = os.Remove(providerDir)
+	}
+	return nil
+}
+
+// binOwnersFromLock maps bin names (including Windows variants) of the
+// installed packages to the provider that installed them.
+// Adopted local packages own the bin entry named after them.
+func binOwnersFromLock() map[string]string {
+	owners := map[string]string{}
+	parser := binLayoutRegistryParser()
+	for _, pkg := range binLayoutLocalPackages(false).Packages {
+		provider, name := packageid.Split(pkg.SourceID)
+		if provider == "" {
+			continue
+		}
+		if provider == "local" {
+			owners[name] = provider
+			continue
+		}
+		for name := range parser.GetBySourceId(pkg.SourceID).Bin {
+			for _, candidate := range binaryNameCandidates(name) {
+				owners[candidate] = provider
+			}
+		}
+	}
+	return owners
+}
+
+// binOwnerFromSymlink returns the provider whose packages directory the
+// symlink at path points into, or an empty string.
+func binOwnerFromSymlink(path string) string {
+	target, err := os.Readlink(path)
+	if err != nil {
+		return ""
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	rel, err := filepath.Rel(files.GetAppPackagesPath(), filepath.Clean(target))
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	provider := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+	if !IsSupportedProvider(provider) {
+		return ""
+	}
+	return provider
+}
+
+// moveBinEntry moves a bin entry, rewriting relative symlink targets
+// so they keep pointing at the same file from the new location.
+func moveBinEntry(src, dest string) error {
+	if _, err := os.Lstat(dest); err == nil {
+		return fmt.Errorf("%s already exists", dest)
+	}
+	target, err := os.Readlink(src)
+	if err != nil {
+		// Not a symlink (e.g. a wrapper script)
+		return os.Rename(src, dest)
+	}
+	if !filepath.IsAbs(target) {
+		abs := filepath.Join(filepath.Dir(src), target)
+		if rel, err := filepath.Rel(filepath.Dir(dest), abs); err == nil {
+			target = rel
+		} else {
+			target = abs
+		}
+	}
+	if err := os.Symlink(target, dest); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
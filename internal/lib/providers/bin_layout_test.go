@@ -0,0 +1,93 @@
+package providers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupBinLayoutTest(t *testing.T) string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("ZANA_HOME", t.TempDir())
+	t.Setenv("ZANA_BIN_LAYOUT", "")
+
+	reg := registry_parser.NewRegistryParser(nil)
+	require.NoError(t, reg.LoadFromBytes([]byte(`[
+		{"name": "black", "source": {"id": "pypi:black"}, "bin": {"black": "pypi:black"}}
+	]`)))
+	origParser := binLayoutRegistryParser
+	origLocal := binLayoutLocalPackages
+	binLayoutRegistryParser = func() *registry_parser.RegistryParser { return reg }
+	binLayoutLocalPackages = func(bool) local_packages_parser.LocalPackageRoot {
+		return local_packages_parser.LocalPackageRoot{
+			Packages: []local_packages_parser.LocalPackageItem{{SourceID: "pypi:black", Version: "24.1.0"}},
+		}
+	}
+	t.Cleanup(func() {
+		binLayoutRegistryParser = origParser
+		binLayoutLocalPackages = origLocal
+	})
+	return files.GetAppBinPath()
+}
+
+func TestEnsureBinLayoutMigratesBothWays(t *testing.T) {
+	binDir := setupBinLayoutTest(t)
+
+	// A relative symlink into the cargo packages dir, a wrapper script owned
+	// by pypi (via the registry bin names) and an unknown file
+	cargoBin := filepath.Join(files.GetAppPackagesPath(), "cargo", "bin")
+	require.NoError(t, os.MkdirAll(cargoBin, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(cargoBin, "rg"), []byte("bin"), 0755))
+	rel, err := filepath.Rel(binDir, filepath.Join(cargoBin, "rg"))
+	require.NoError(t, err)
+	require.NoError(t, os.Symlink(rel, filepath.Join(binDir, "rg")))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "black"), []byte("#!/bin/sh"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "unknown"), []byte(""), 0755))
+
+	// Flat is the default, nothing to do
+	require.NoError(t, EnsureBinLayout())
+	assert.NoFileExists(t, filepath.Join(binDir, binLayoutMarkerFile))
+
+	t.Setenv("ZANA_BIN_LAYOUT", files.BinLayoutPerProvider)
+	require.NoError(t, EnsureBinLayout())
+
+	data, err := os.ReadFile(filepath.Join(binDir, "cargo", "rg"))
+	require.NoError(t, err, "relative symlink must still resolve after the move")
+	assert.Equal(t, "bin", string(data))
+	assert.FileExists(t, filepath.Join(binDir, "pypi", "black"))
+	assert.FileExists(t, filepath.Join(binDir, "unknown"))
+	assert.NoFileExists(t, filepath.Join(binDir, "black"))
+	assert.FileExists(t, filepath.Join(binDir, binLayoutMarkerFile))
+
+	t.Setenv("ZANA_BIN_LAYOUT", files.BinLayoutFlat)
+	require.NoError(t, EnsureBinLayout())
+
+	data, err = os.ReadFile(filepath.Join(binDir, "rg"))
+	require.NoError(t, err)
+	assert.Equal(t, "bin", string(data))
+	assert.FileExists(t, filepath.Join(binDir, "black"))
+	assert.NoDirExists(t, filepath.Join(binDir, "cargo"))
+	assert.NoDirExists(t, filepath.Join(binDir, "pypi"))
+	assert.NoFileExists(t, filepath.Join(binDir, binLayoutMarkerFile))
+}
+
+func TestBinPathsInPrecedenceOrder(t *testing.T) {
+	binDir := setupBinLayoutTest(t)
+
+	assert.Equal(t, []string{binDir}, BinPathsInPrecedenceOrder())
+
+	require.NoError(t, os.WriteFile(filepath.Join(os.Getenv("ZANA_HOME"), "config.yaml"), []byte("paths:\n  binLayout: per-provider\n  binProviderOrder: [cargo, unknown, npm]\n"), 0644))
+	paths := BinPathsInPrecedenceOrder()
+	require.Len(t, paths, len(AvailableProviders))
+	assert.Equal(t, filepath.Join(binDir, "cargo"), paths[0])
+	assert.Equal(t, filepath.Join(binDir, "npm"), paths[1])
+	assert.Equal(t, filepath.Join(binDir, "pypi"), paths[2])
+}
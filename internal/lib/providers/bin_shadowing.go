@@ -0,0 +1,145 @@
+package providers
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Injectable helpers for tests
+var shadowingPathEnv = func() string { return os.Getenv("PATH") }
+var shadowingBinDirs = BinPathsInPrecedenceOrder
+
+// BinShadow is an executable in a zana bin directory with a namesake elsewhere on PATH
+type BinShadow struct {
+	Name       string   `json:"name"`
+	ZanaPath   string   `json:"zana_path"`
+	OtherPaths []string `json:"other_paths"`
+	// Winner is the path that runs when Name is invoked, given the PATH order
+	Winner string `json:"winner"`
+	// OnPath is false when the zana bin directory is not on PATH at all
+	OnPath bool `json:"on_path"`
+}
+
+// ZanaWins reports whether the zana executable is the one that runs
+func (s BinShadow) ZanaWins() bool {
+	return s.Winner == s.ZanaPath
+}
+
+// FindBinShadowing returns the executables among names in binDir
+// that have a namesake in another directory on PATH
+func FindBinShadowing(binDir string, names []string) []BinShadow {
+	binDir = filepath.Clean(binDir)
+	pathDirs := shadowingPathDirs()
+	var shadows []BinShadow
+	for _, name := range names {
+		zanaPath := executableIn(binDir, name)
+		if zanaPath == "" {
+			continue
+		}
+		shadow := BinShadow{Name: name, ZanaPath: zanaPath}
+		for _, dir := range pathDirs {
+			if dir == binDir {
+				shadow.OnPath = true
+				if shadow.Winner == "" {
+					shadow.Winner = zanaPath
+				}
+				continue
+			}
+			if other := executableIn(dir, name); other != "" {
+				shadow.OtherPaths = append(shadow.OtherPaths, other)
+				if shadow.Winner == "" {
+					shadow.Winner = other
+				}
+			}
+		}
+		if len(shadow.OtherPaths) > 0 {
+			shadows = append(shadows, shadow)
+		}
+	}
+	return shadows
+}
+
+// FindAllBinShadowing checks every executable in the zana bin directories.
+// A name is reported once, for the bin directory that takes precedence.
+func FindAllBinShadowing() []BinShadow {
+	var shadows []BinShadow
+	seen := map[string]bool{}
+	for _, dir := range shadowingBinDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		var names []string
+		for _, e := range entries {
+			name := executableName(e.Name())
+			if e.IsDir() || strings.HasPrefix(e.Name(), ".") || seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		shadows = append(shadows, FindBinShadowing(dir, names)...)
+	}
+	return shadows
+}
+
+// shadowingPathDirs returns the PATH entries in order, without duplicates
+func shadowingPathDirs() []string {
+	var dirs []string
+	seen := map[string]bool{}
+	for _, dir := range filepath.SplitList(shadowingPathEnv()) {
+		if dir == "" {
+			continue
+		}
+		dir = filepath.Clean(dir)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// executableIn returns the path of the executable name in dir, or ""
+func executableIn(dir, name string) string {
+	candidates := []string{name}
+	if runtime.GOOS == "windows" {
+		candidates = nil
+		for _, ext := range strings.Split(strings.ToLower(os.Getenv("PATHEXT")), ";") {
+			if ext != "" {
+				candidates = append(candidates, name+ext)
+			}
+		}
+		candidates = append(candidates, name)
+	}
+	for _, candidate := range candidates {
+		path := filepath.Join(dir, candidate)
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+		return path
+	}
+	return ""
+}
+
+// executableName strips the extension Windows needs to run a file
+func executableName(fileName string) string {
+	if runtime.GOOS != "windows" {
+		return fileName
+	}
+	ext := strings.ToLower(filepath.Ext(fileName))
+	for _, pathExt := range strings.Split(strings.ToLower(os.Getenv("PATHEXT")), ";") {
+		if pathExt != "" && ext == pathExt {
+			return strings.TrimSuffix(fileName, filepath.Ext(fileName))
+		}
+	}
+	return fileName
+}
@@ -0,0 +1,77 @@
+package providers
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeExecutable(t *testing.T, dir, name string) string {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"), 0755))
+	return path
+}
+
+func withShadowingPath(t *testing.T, dirs ...string) {
+	t.Helper()
+	prev := shadowingPathEnv
+	t.Cleanup(func() { shadowingPathEnv = prev })
+	shadowingPathEnv = func() string { return strings.Join(dirs, string(os.PathListSeparator)) }
+}
+
+func TestFindBinShadowing(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on executable permission bits")
+	}
+	root := t.TempDir()
+	zanaBin, usrBin, localBin := filepath.Join(root, "zana"), filepath.Join(root, "usr"), filepath.Join(root, "local")
+	zanaTool := writeExecutable(t, zanaBin, "tool")
+	writeExecutable(t, zanaBin, "only-zana")
+	usrTool := writeExecutable(t, usrBin, "tool")
+	localTool := writeExecutable(t, localBin, "tool")
+	require.NoError(t, os.WriteFile(filepath.Join(usrBin, "only-zana"), []byte("not executable"), 0644))
+
+	t.Run("zana first on PATH wins", func(t *testing.T) {
+		withShadowingPath(t, zanaBin, usrBin, localBin)
+		shadows := FindBinShadowing(zanaBin, []string{"tool", "only-zana", "missing"})
+		require.Len(t, shadows, 1)
+		assert.Equal(t, BinShadow{Name: "tool", ZanaPath: zanaTool, OtherPaths: []string{usrTool, localTool}, Winner: zanaTool, OnPath: true}, shadows[0])
+		assert.True(t, shadows[0].ZanaWins())
+	})
+
+	t.Run("earlier directory shadows zana", func(t *testing.T) {
+		withShadowingPath(t, usrBin, zanaBin+string(os.PathSeparator), usrBin)
+		shadows := FindBinShadowing(zanaBin, []string{"tool"})
+		require.Len(t, shadows, 1)
+		assert.Equal(t, usrTool, shadows[0].Winner)
+		assert.True(t, shadows[0].OnPath)
+		assert.False(t, shadows[0].ZanaWins())
+	})
+
+	t.Run("zana bin directory not on PATH", func(t *testing.T) {
+		withShadowingPath(t, localBin)
+		shadows := FindBinShadowing(zanaBin, []string{"tool"})
+		require.Len(t, shadows, 1)
+		assert.False(t, shadows[0].OnPath)
+		assert.Equal(t, localTool, shadows[0].Winner)
+	})
+
+	t.Run("all bin directories", func(t *testing.T) {
+		withShadowingPath(t, zanaBin, usrBin)
+		prev := shadowingBinDirs
+		t.Cleanup(func() { shadowingBinDirs = prev })
+		shadowingBinDirs = func() []string { return []string{zanaBin} }
+		writeExecutable(t, zanaBin, ".hidden")
+
+		shadows := FindAllBinShadowing()
+		require.Len(t, shadows, 1)
+		assert.Equal(t, "tool", shadows[0].Name)
+	})
+}
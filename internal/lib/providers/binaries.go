@@ -0,0 +1,114 @@
+package providers
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// Allow injection for tests
+var binariesLstat = os.Lstat
+var binariesGOOS = runtime.GOOS
+
+// binaryNameCandidates returns the file names a bin entry may have in the
+// zana bin dir. On Windows wrappers may be created with an extension.
+func binaryNameCandidates(name string) []string {
+	if binariesGOOS != "windows" {
+		return []string{name}
+	}
+	return []string{name, name + ".exe", name + ".cmd", name + ".bat", name + ".ps1"}
+}
+
+// InstalledBinaries returns the sorted executable names a package exposes in
+// the zana bin dir. Candidate names come from the registry bin map and are
+// only reported when an entry with that name exists in the bin dir.
+func InstalledBinaries(registryItem registry_parser.RegistryItem) []string {
+	provider, _ := packageid.Split(registryItem.Source.ID)
+	return binariesIn(files.GetAppBinPathForProvider(provider), registryItem.Bin)
+}
+
+// PackageBinaries returns the sorted executable names the installed package
+// sourceID exposes in the zana bin dir. Names come from the bin map of its
+// registry item when there is one; otherwise the bin dir is scanned for
+// entries linking into the package's directory. An adopted local package is
+// its executable.
+func PackageBinaries(sourceID string, registryItem registry_parser.RegistryItem) []string {
+	provider, name := packageid.Split(packageid.Normalize(sourceID))
+	binDir := files.GetAppBinPathForProvider(provider)
+	if provider == "local" {
+		if _, err := binariesLstat(filepath.Join(binDir, name)); err == nil {
+			return []string{name}
+		}
+		return []string{}
+	}
+	if len(registryItem.Bin) > 0 {
+		return binariesIn(binDir, registryItem.Bin)
+	}
+	return binariesLinkingInto(binDir, packageDir(provider, name))
+}
+
+func binariesIn(binDir string, bin map[string]string) []string {
+	binaries := []string{}
+	for name := range bin {
+		for _, candidate := range binaryNameCandidates(name) {
+			path := filepath.Join(binDir, candidate)
+			if _, err := binariesLstat(path); err == nil && !IsLazyShim(path) {
+				binaries = append(binaries, candidate)
+				break
+			}
+		}
+	}
+	sort.Strings(binaries)
+	return binaries
+}
+
+// packageDir returns the directory a package of provider is installed in,
+// or an empty string for providers that share one between their packages
+func packageDir(provider, name string) string {
+	base := filepath.Join(files.GetAppPackagesPath(), provider)
+	switch provider {
+	case "npm":
+		return filepath.Join(base, "node_modules", filepath.FromSlash(name))
+	case "github", "gitlab", "codeberg", "gitea", "treesitter", "openvsx", "generic":
+		return filepath.Join(base, strings.ReplaceAll(name, "/", "_"))
+	}
+	return ""
+}
+
+// binariesLinkingInto returns the sorted names of the bin dir entries that
+// resolve to a file inside dir
+func binariesLinkingInto(binDir, dir string) []string {
+	binaries := []string{}
+	if dir == "" {
+		return binaries
+	}
+	dir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return binaries
+	}
+	entries, err := os.ReadDir(binDir)
+	if err != nil {
+		return binaries
+	}
+	for _, e := range entries {
+		path := filepath.Join(binDir, e.Name())
+		if e.Type()&os.ModeSymlink == 0 || IsLazyShim(path) {
+			continue
+		}
+		target, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(dir, target); err == nil && !strings.HasPrefix(rel, "..") {
+			binaries = append(binaries, e.Name())
+		}
+	}
+	sort.Strings(binaries)
+	return binaries
+}
@@ -0,0 +1,76 @@
+package providers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstalledBinaries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ZANA_HOME", t.TempDir())
+
+	binDir := files.GetAppBinPath()
+	assert.NoError(t, os.WriteFile(filepath.Join(binDir, "tool"), []byte{}, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(binDir, "tool-ls"), []byte{}, 0755))
+
+	t.Run("only reports entries present in the bin dir", func(t *testing.T) {
+		item := registry_parser.RegistryItem{
+			Bin: map[string]string{"tool-ls": "x", "tool": "y", "missing": "z"},
+		}
+		assert.Equal(t, []string{"tool", "tool-ls"}, InstalledBinaries(item))
+	})
+
+	t.Run("returns empty slice without bin entries", func(t *testing.T) {
+		assert.Equal(t, []string{}, InstalledBinaries(registry_parser.RegistryItem{}))
+	})
+
+	t.Run("matches windows extensions", func(t *testing.T) {
+		orig := binariesGOOS
+		binariesGOOS = "windows"
+		defer func() { binariesGOOS = orig }()
+
+		assert.NoError(t, os.WriteFile(filepath.Join(binDir, "win-tool.cmd"), []byte{}, 0755))
+		item := registry_parser.RegistryItem{Bin: map[string]string{"win-tool": "x"}}
+		assert.Equal(t, []string{"win-tool.cmd"}, InstalledBinaries(item))
+	})
+}
+
+func TestPackageBinaries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ZANA_HOME", t.TempDir())
+
+	binDir := files.GetAppBinPath()
+	link := func(target, name string) {
+		t.Helper()
+		assert.NoError(t, os.MkdirAll(filepath.Dir(target), 0755))
+		assert.NoError(t, os.WriteFile(target, []byte{}, 0755))
+		assert.NoError(t, os.Symlink(target, filepath.Join(binDir, name)))
+	}
+	packages := files.GetAppPackagesPath()
+	link(filepath.Join(packages, "github", "owner_tool", "bin", "tool"), "tool")
+	link(filepath.Join(packages, "github", "owner_other", "other"), "other")
+	link(filepath.Join(packages, "npm", "node_modules", "@scope", "cli", "bin.js"), "scoped-cli")
+	assert.NoError(t, os.WriteFile(filepath.Join(binDir, "mytool"), []byte{}, 0755))
+
+	t.Run("uses the registry bin map", func(t *testing.T) {
+		item := registry_parser.RegistryItem{Bin: map[string]string{"other": "x"}}
+		assert.Equal(t, []string{"other"}, PackageBinaries("github:owner/tool", item))
+	})
+
+	t.Run("scans for links into the package without a bin map", func(t *testing.T) {
+		assert.Equal(t, []string{"tool"}, PackageBinaries("github:owner/tool", registry_parser.RegistryItem{}))
+		assert.Equal(t, []string{"tool"}, PackageBinaries("pkg:github/owner/tool", registry_parser.RegistryItem{}))
+		assert.Equal(t, []string{"scoped-cli"}, PackageBinaries("npm:@scope/cli", registry_parser.RegistryItem{}))
+		assert.Equal(t, []string{}, PackageBinaries("cargo:ripgrep", registry_parser.RegistryItem{}))
+	})
+
+	t.Run("local packages are their executable", func(t *testing.T) {
+		assert.Equal(t, []string{"mytool"}, PackageBinaries("local:mytool", registry_parser.RegistryItem{}))
+		assert.Equal(t, []string{}, PackageBinaries("local:gone", registry_parser.RegistryItem{}))
+	})
+}
@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -10,7 +11,7 @@ import (
 
 	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
-	"github.com/mistweaverco/zana-client/internal/lib/shell_out"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 )
 
 type CargoProvider struct {
@@ -20,8 +21,8 @@ type CargoProvider struct {
 }
 
 // Injectable shell and OS helpers for tests
-var cargoShellOut = shell_out.ShellOut
-var cargoShellOutCapture = shell_out.ShellOutCapture
+var cargoShellOut = shellOut
+var cargoShellOutCapture = shellOutCapture
 var cargoReadDir = os.ReadDir
 var cargoLstat = os.Lstat
 var cargoRemove = os.Remove
@@ -36,7 +37,7 @@ var cargoSymlink = os.Symlink
 var lppCargoAdd = local_packages_parser.AddLocalPackage
 var lppCargoRemove = local_packages_parser.RemoveLocalPackage
 var lppCargoGetDataForProvider = local_packages_parser.GetDataForProvider
-var cargoHasCommand = shell_out.HasCommand
+var cargoHasCommand = hasCommand
 
 func NewProviderCargo() *CargoProvider {
 	p := &CargoProvider{}
@@ -48,22 +49,16 @@ func NewProviderCargo() *CargoProvider {
 
 func (p *CargoProvider) getRepo(sourceID string) string {
 	// Support both legacy (pkg:cargo/pkg) and new (cargo:pkg) formats
-	normalized := normalizePackageID(sourceID)
+	normalized := packageid.Normalize(sourceID)
 	if strings.HasPrefix(normalized, p.PREFIX) {
 		return strings.TrimPrefix(normalized, p.PREFIX)
 	}
-	// Fallback for legacy format
-	re := regexp.MustCompile("^pkg:" + p.PROVIDER_NAME + "/(.*)")
-	matches := re.FindStringSubmatch(sourceID)
-	if len(matches) > 1 {
-		return matches[1]
-	}
 	return ""
 }
 
 func (p *CargoProvider) createSymlinks() error {
 	cargoBinDir := filepath.Join(p.APP_PACKAGES_DIR, "bin")
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	if _, err := cargoStat(cargoBinDir); os.IsNotExist(err) {
 		return nil
 	}
@@ -71,11 +66,27 @@ func (p *CargoProvider) createSymlinks() error {
 	if err != nil {
 		return err
 	}
+	// All crates share one bin directory and binaries can't be told apart by
+	// crate here, so only the exclude patterns of the installed crates apply
+	// and unlinked crates exclude their registry bin names
+	filter := binFilter{sourceID: p.PROVIDER_NAME}
+	for _, pkg := range lppCargoGetDataForProvider(p.PROVIDER_NAME).Packages {
+		crateFilter := binFilterForSourceID(pkg.SourceID)
+		filter.exclude = append(filter.exclude, crateFilter.exclude...)
+		if crateFilter.unlinked {
+			for name := range binFilterRegistryParser().GetBySourceId(pkg.SourceID).Bin {
+				filter.exclude = append(filter.exclude, name)
+			}
+		}
+	}
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
 		}
 		binaryName := entry.Name()
+		if !filter.allows(binaryName) {
+			continue
+		}
 		binaryPath := filepath.Join(cargoBinDir, binaryName)
 		symlinkPath := filepath.Join(zanaBinDir, binaryName)
 		if _, err := cargoLstat(symlinkPath); err == nil {
@@ -95,7 +106,7 @@ func (p *CargoProvider) createSymlinks() error {
 }
 
 func (p *CargoProvider) removeAllSymlinks() error {
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	cargoBinDir := filepath.Join(p.APP_PACKAGES_DIR, "bin")
 	entries, err := cargoReadDir(zanaBinDir)
 	if err != nil {
@@ -134,16 +145,16 @@ func (p *CargoProvider) Clean() bool {
 		log.Println("Error removing directory:", err)
 		return false
 	}
-	return p.Sync()
+	return p.Sync(context.Background())
 }
 
-func (p *CargoProvider) checkCargoAvailable() bool {
-	return cargoHasCommand("cargo", []string{"--version"}, nil)
+func (p *CargoProvider) checkCargoAvailable(ctx context.Context) bool {
+	return cargoHasCommand(ctx, "cargo", []string{"--version"}, nil)
 }
 
-func (p *CargoProvider) getInstalledCrates() map[string]string {
+func (p *CargoProvider) getInstalledCrates(ctx context.Context) map[string]string {
 	installed := map[string]string{}
-	_, output, err := cargoShellOutCapture("cargo", []string{"install", "--list"}, p.APP_PACKAGES_DIR, []string{"CARGO_HOME=" + p.APP_PACKAGES_DIR})
+	_, output, err := cargoShellOutCapture(ctx, "cargo", []string{"install", "--list"}, p.APP_PACKAGES_DIR, []string{"CARGO_HOME=" + p.APP_PACKAGES_DIR})
 	if err != nil {
 		return installed
 	}
@@ -161,71 +172,64 @@ func (p *CargoProvider) getInstalledCrates() map[string]string {
 	return installed
 }
 
-func (p *CargoProvider) Sync() bool {
+func (p *CargoProvider) Sync(ctx context.Context) bool {
 	if _, err := cargoStat(p.APP_PACKAGES_DIR); os.IsNotExist(err) {
 		if err := cargoMkdir(p.APP_PACKAGES_DIR, 0755); err != nil {
 			fmt.Println("Error creating directory:", err)
 			return false
 		}
 	}
-	if !p.checkCargoAvailable() {
+	if !p.checkCargoAvailable(ctx) {
 		log.Println("Error: Cargo is not available. Please install Rust and ensure cargo is in your PATH.")
 		return false
 	}
-	desired := lppCargoGetDataForProvider("cargo").Packages
-	installed := p.getInstalledCrates()
 	allOk := true
-	installedCount := 0
-	skippedCount := 0
-	for _, pkg := range desired {
-		crate := p.getRepo(pkg.SourceID)
-		if crate == "" {
-			continue
-		}
+	var desired []desiredPackage
+	resolved := map[string]bool{}
+	for _, pkg := range desiredPackagesFor(lppCargoGetDataForProvider("cargo").Packages, p.getRepo) {
 		// Resolve desired version: if "latest" (or empty), query the actual latest version
-		desiredVersion := pkg.Version
-		if desiredVersion == "" || desiredVersion == "latest" {
-			latestVersion, err := p.getLatestVersion(crate)
+		if pkg.Version == "" || pkg.Version == "latest" {
+			latestVersion, err := latestVersionOf(ctx, ProviderCargo, pkg.Name, p.getLatestVersion)
 			if err != nil {
-				log.Printf("Error resolving latest version for %s: %v", crate, err)
+				log.Printf("Error resolving latest version for %s: %v", pkg.Name, err)
 				allOk = false
 				continue
 			}
-			desiredVersion = latestVersion
-		}
-
-		if v, ok := installed[crate]; ok && v == desiredVersion {
-			log.Printf("Cargo Sync: Package %s@%s already installed, skipping", crate, desiredVersion)
-			// If lockfile still has "latest", update it to the resolved version
-			if pkg.Version != desiredVersion {
-				if err := lppCargoAdd(pkg.SourceID, desiredVersion); err != nil {
-					log.Printf("Warning: failed to update zana-lock.json for %s: %v", crate, err)
-				}
-			}
-			skippedCount++
-			continue
+			pkg.Version = latestVersion
+			resolved[pkg.SourceID] = true
 		}
+		desired = append(desired, pkg)
+	}
+	plan := reconcilePackages(desired, p.getInstalledCrates(ctx), nil)
+	log.Printf("Cargo Sync: %s", plan)
+	for _, crate := range plan.Extraneous() {
+		log.Printf("Cargo Sync: Crate %s@%s is installed but not in zana-lock.json", crate.Name, crate.Installed)
+	}
 
-		log.Printf("Cargo Sync: Installing package %s@%s", crate, desiredVersion)
-		args := []string{"install", crate, "--force"}
-		if desiredVersion != "" {
-			args = append(args, "--version", desiredVersion)
-		}
-		args = append(args, "--locked")
-		code, err := cargoShellOut("cargo", args, p.APP_PACKAGES_DIR, []string{"CARGO_HOME=" + p.APP_PACKAGES_DIR})
+	installedCount := 0
+	failed := map[string]bool{}
+	for _, crate := range plan.Pending() {
+		log.Printf("Cargo Sync: Installing package %s@%s", crate.Name, crate.Desired)
+		args := []string{"install", crate.Name, "--force", "--version", crate.Desired, "--locked"}
+		code, err := cargoShellOut(newOperation(ctx, actionSync, crate.SourceID), "cargo", args, p.APP_PACKAGES_DIR, []string{"CARGO_HOME=" + p.APP_PACKAGES_DIR})
 		if err != nil || code != 0 {
-			log.Printf("Error installing %s@%s: %v", crate, desiredVersion, err)
+			log.Printf("Error installing %s@%s: %v", crate.Name, crate.Desired, err)
 			allOk = false
+			failed[crate.SourceID] = true
 			continue
 		}
-		// Persist resolved version to lockfile (covers cases where requested was "latest")
-		if pkg.Version != desiredVersion {
-			if err := lppCargoAdd(pkg.SourceID, desiredVersion); err != nil {
-				log.Printf("Warning: failed to update zana-lock.json for %s: %v", crate, err)
+		installedCount++
+	}
+	// Persist resolved versions of installed crates to the lockfile (covers
+	// cases where requested was "latest")
+	for _, crate := range plan {
+		if resolved[crate.SourceID] && !failed[crate.SourceID] {
+			if err := lppCargoAdd(crate.SourceID, crate.Desired); err != nil {
+				log.Printf("Warning: failed to update zana-lock.json for %s: %v", crate.Name, err)
 			}
 		}
-		installedCount++
 	}
+	skippedCount := len(plan.withState(PackageStateInstalled))
 	if err := p.createSymlinks(); err != nil {
 		log.Printf("Error creating symlinks for Cargo binaries: %v", err)
 	}
@@ -233,7 +237,7 @@ func (p *CargoProvider) Sync() bool {
 	return allOk
 }
 
-func (p *CargoProvider) Install(sourceID, version string) bool {
+func (p *CargoProvider) Install(ctx context.Context, sourceID, version string) bool {
 	crate := p.getRepo(sourceID)
 	if crate == "" {
 		return false
@@ -241,7 +245,7 @@ func (p *CargoProvider) Install(sourceID, version string) bool {
 	// Resolve version if "latest" or empty
 	resolvedVersion := version
 	if resolvedVersion == "" || resolvedVersion == "latest" {
-		latestVersion, err := p.getLatestVersion(crate)
+		latestVersion, err := latestVersionOf(ctx, ProviderCargo, crate, p.getLatestVersion)
 		if err != nil {
 			log.Printf("Error resolving latest version for %s: %v", crate, err)
 			return false
@@ -251,16 +255,16 @@ func (p *CargoProvider) Install(sourceID, version string) bool {
 	if err := lppCargoAdd(sourceID, resolvedVersion); err != nil {
 		return false
 	}
-	return p.Sync()
+	return p.Sync(ctx)
 }
 
-func (p *CargoProvider) Remove(sourceID string) bool {
+func (p *CargoProvider) Remove(ctx context.Context, sourceID string) bool {
 	crate := p.getRepo(sourceID)
 	if crate == "" {
 		return false
 	}
 	log.Printf("Cargo Remove: Removing package %s", crate)
-	code, err := cargoShellOut("cargo", []string{"uninstall", crate}, p.APP_PACKAGES_DIR, []string{"CARGO_HOME=" + p.APP_PACKAGES_DIR})
+	code, err := cargoShellOut(ctx, "cargo", []string{"uninstall", crate}, p.APP_PACKAGES_DIR, []string{"CARGO_HOME=" + p.APP_PACKAGES_DIR})
 	if err != nil || code != 0 {
 		log.Printf("Error uninstalling %s: %v", crate, err)
 	}
@@ -275,26 +279,26 @@ func (p *CargoProvider) Remove(sourceID string) bool {
 		log.Printf("Error creating symlinks: %v", err)
 	}
 	log.Printf("Cargo Remove: Package %s removed successfully", crate)
-	return p.Sync()
+	return p.Sync(ctx)
 }
 
-func (p *CargoProvider) Update(sourceID string) bool {
+func (p *CargoProvider) Update(ctx context.Context, sourceID string) bool {
 	crate := p.getRepo(sourceID)
 	if crate == "" {
 		log.Printf("Invalid source ID format for Cargo provider")
 		return false
 	}
-	latestVersion, err := p.getLatestVersion(crate)
+	latestVersion, err := latestVersionOf(ctx, ProviderCargo, crate, p.getLatestVersion)
 	if err != nil {
 		log.Printf("Error getting latest version for %s: %v", crate, err)
 		return false
 	}
 	log.Printf("Cargo Update: Updating %s to version %s", crate, latestVersion)
-	return p.Install(sourceID, latestVersion)
+	return p.Install(ctx, sourceID, latestVersion)
 }
 
-func (p *CargoProvider) getLatestVersion(crate string) (string, error) {
-	_, output, err := cargoShellOutCapture("cargo", []string{"search", crate, "-q"}, "", nil)
+func (p *CargoProvider) getLatestVersion(ctx context.Context, crate string) (string, error) {
+	_, output, err := cargoShellOutCapture(ctx, "cargo", []string{"search", crate, "-q"}, "", nil)
 	if err != nil {
 		return "", err
 	}
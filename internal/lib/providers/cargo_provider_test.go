@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"errors"
 	"os"
 	"path/filepath"
@@ -28,13 +29,17 @@ func TestMoreBranchesCargo(t *testing.T) {
 
 	// Update: latest fetch error
 	oldCap := cargoShellOutCapture
-	cargoShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 1, "", errors.New("err") }
-	assert.False(t, p.Update("pkg:cargo/x"))
+	cargoShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 1, "", errors.New("err")
+	}
+	assert.False(t, p.Update(context.Background(), "pkg:cargo/x"))
 	cargoShellOutCapture = oldCap
 
 	// getInstalledCrates error path
-	cargoShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 1, "", errors.New("err") }
-	m := p.getInstalledCrates()
+	cargoShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 1, "", errors.New("err")
+	}
+	m := p.getInstalledCrates(context.Background())
 	assert.Len(t, m, 0)
 	cargoShellOutCapture = oldCap
 }
@@ -47,7 +52,7 @@ func TestCargoLatestResolutionAndUninstallFail(t *testing.T) {
 	_ = lppCargoAdd("pkg:cargo/cr", "latest")
 	// search returns version
 	oldCap := cargoShellOutCapture
-	cargoShellOutCapture = func(cmd string, args []string, dir string, env []string) (int, string, error) {
+	cargoShellOutCapture = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, string, error) {
 		if len(args) > 0 && args[0] == "search" {
 			return 0, "cr = \"1.2.3\"", nil
 		}
@@ -58,7 +63,7 @@ func TestCargoLatestResolutionAndUninstallFail(t *testing.T) {
 	}
 	// uninstall fails logs but proceed
 	oldOut := cargoShellOut
-	cargoShellOut = func(cmd string, args []string, dir string, env []string) (int, error) {
+	cargoShellOut = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, error) {
 		if len(args) > 0 && args[0] == "uninstall" {
 			return 1, errors.New("uninstall")
 		}
@@ -66,8 +71,8 @@ func TestCargoLatestResolutionAndUninstallFail(t *testing.T) {
 	}
 	// create cargo bin dir
 	_ = os.MkdirAll(filepath.Join(p.APP_PACKAGES_DIR, "bin"), 0755)
-	assert.True(t, p.Sync())
-	assert.True(t, p.Remove("pkg:cargo/cr"))
+	assert.True(t, p.Sync(context.Background()))
+	assert.True(t, p.Remove(context.Background(), "pkg:cargo/cr"))
 	cargoShellOut = oldOut
 	cargoShellOutCapture = oldCap
 }
@@ -104,14 +109,14 @@ func TestCargoSync_CreateDirErrorAndUnavailable(t *testing.T) {
 	oldStat, oldMkdir := cargoStat, cargoMkdir
 	cargoStat = func(string) (os.FileInfo, error) { return nil, os.ErrNotExist }
 	cargoMkdir = func(string, os.FileMode) error { return errors.New("mkdir") }
-	assert.False(t, p.Sync())
+	assert.False(t, p.Sync(context.Background()))
 	cargoStat, cargoMkdir = oldStat, oldMkdir
 
 	// cargo unavailable
 	_ = os.MkdirAll(p.APP_PACKAGES_DIR, 0755)
 	oldHas := cargoHasCommand
-	cargoHasCommand = func(string, []string, []string) bool { return false }
-	assert.False(t, p.Sync())
+	cargoHasCommand = func(context.Context, string, []string, []string) bool { return false }
+	assert.False(t, p.Sync(context.Background()))
 	cargoHasCommand = oldHas
 }
 
@@ -123,11 +128,13 @@ func TestCargoSync_InstallErrorSetsAllOkFalse(t *testing.T) {
 	_ = lppCargoAdd("pkg:cargo/tool", "1.2.3")
 	// installed empty map: make ShellOutCapture return no list
 	oldCap := cargoShellOutCapture
-	cargoShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 0, "", nil }
+	cargoShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) { return 0, "", nil }
 	// install fails
 	oldOut := cargoShellOut
-	cargoShellOut = func(string, []string, string, []string) (int, error) { return 1, errors.New("install") }
-	assert.False(t, p.Sync())
+	cargoShellOut = func(context.Context, string, []string, string, []string) (int, error) {
+		return 1, errors.New("install")
+	}
+	assert.False(t, p.Sync(context.Background()))
 	cargoShellOut = oldOut
 	cargoShellOutCapture = oldCap
 }
@@ -281,7 +288,7 @@ func TestCargoSync_SkipInstalledWithLockUpdateWarningAndCreateSymlinksError(t *t
 	_ = lppCargoAdd("pkg:cargo/tool", "latest")
 	// installed shows same version 1.2.3 and also ensure getInstalledCrates path is executed first
 	oldCap := cargoShellOutCapture
-	cargoShellOutCapture = func(cmd string, args []string, dir string, env []string) (int, string, error) {
+	cargoShellOutCapture = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, string, error) {
 		if len(args) > 0 && args[0] == "install" && len(args) > 1 && args[1] == "--list" {
 			return 0, "tool v1.2.3:", nil
 		}
@@ -304,8 +311,8 @@ func TestCargoSync_SkipInstalledWithLockUpdateWarningAndCreateSymlinksError(t *t
 		return nil, errors.New("readdir") // trigger error log in createSymlinks
 	}
 	oldHas := cargoHasCommand
-	cargoHasCommand = func(string, []string, []string) bool { return true }
-	assert.True(t, p.Sync())
+	cargoHasCommand = func(context.Context, string, []string, []string) bool { return true }
+	assert.True(t, p.Sync(context.Background()))
 	// restore
 	cargoHasCommand = oldHas
 	cargoReadDir = oldRD
@@ -320,13 +327,15 @@ func TestCargoSync_LatestResolutionErrorSetsAllOkFalse(t *testing.T) {
 	_ = lppCargoAdd("pkg:cargo/tool", "latest")
 	// search fails
 	oldCap := cargoShellOutCapture
-	cargoShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 1, "", errors.New("search") }
+	cargoShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 1, "", errors.New("search")
+	}
 	oldHas := cargoHasCommand
-	cargoHasCommand = func(string, []string, []string) bool { return true }
+	cargoHasCommand = func(context.Context, string, []string, []string) bool { return true }
 	// no-op symlinks
 	oldRD := cargoReadDir
 	cargoReadDir = func(string) ([]os.DirEntry, error) { return []os.DirEntry{}, nil }
-	assert.False(t, p.Sync())
+	assert.False(t, p.Sync(context.Background()))
 	cargoReadDir = oldRD
 	cargoHasCommand = oldHas
 	cargoShellOutCapture = oldCap
@@ -337,7 +346,7 @@ func TestCargoSync_CreateSymlinksErrorLoggedWhenNoDesired(t *testing.T) {
 	p := NewProviderCargo()
 	_ = os.MkdirAll(p.APP_PACKAGES_DIR, 0755)
 	oldHas := cargoHasCommand
-	cargoHasCommand = func(string, []string, []string) bool { return true }
+	cargoHasCommand = func(context.Context, string, []string, []string) bool { return true }
 	// empty desired
 	oldGet := lppCargoGetDataForProvider
 	lppCargoGetDataForProvider = func(string) local_packages_parser.LocalPackageRoot {
@@ -348,7 +357,7 @@ func TestCargoSync_CreateSymlinksErrorLoggedWhenNoDesired(t *testing.T) {
 	// ensure cargo bin dir exists so createSymlinks does not short-circuit
 	_ = os.MkdirAll(filepath.Join(p.APP_PACKAGES_DIR, "bin"), 0755)
 	cargoReadDir = func(string) ([]os.DirEntry, error) { return nil, errors.New("readdir") }
-	assert.True(t, p.Sync())
+	assert.True(t, p.Sync(context.Background()))
 	cargoReadDir = oldRD
 	lppCargoGetDataForProvider = oldGet
 	cargoHasCommand = oldHas
@@ -359,8 +368,10 @@ func TestCargoInstall_LatestResolveErrorReturnsFalse(t *testing.T) {
 	p := NewProviderCargo()
 	_ = os.MkdirAll(p.APP_PACKAGES_DIR, 0755)
 	oldCap := cargoShellOutCapture
-	cargoShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 1, "", errors.New("search") }
-	assert.False(t, p.Install("pkg:cargo/tool", "latest"))
+	cargoShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 1, "", errors.New("search")
+	}
+	assert.False(t, p.Install(context.Background(), "pkg:cargo/tool", "latest"))
 	cargoShellOutCapture = oldCap
 }
 
@@ -369,7 +380,7 @@ func TestCargoRemove_RemoveAllSymlinksErrorLogs(t *testing.T) {
 	p := NewProviderCargo()
 	_ = os.MkdirAll(p.APP_PACKAGES_DIR, 0755)
 	oldOut := cargoShellOut
-	cargoShellOut = func(string, []string, string, []string) (int, error) { return 0, nil }
+	cargoShellOut = func(context.Context, string, []string, string, []string) (int, error) { return 0, nil }
 	oldLocalRemove := lppCargoRemove
 	lppCargoRemove = func(string) error { return nil }
 	oldRD := cargoReadDir
@@ -382,8 +393,8 @@ func TestCargoRemove_RemoveAllSymlinksErrorLogs(t *testing.T) {
 		return local_packages_parser.LocalPackageRoot{Packages: nil}
 	}
 	oldHas := cargoHasCommand
-	cargoHasCommand = func(string, []string, []string) bool { return true }
-	assert.True(t, p.Remove("pkg:cargo/tool"))
+	cargoHasCommand = func(context.Context, string, []string, []string) bool { return true }
+	assert.True(t, p.Remove(context.Background(), "pkg:cargo/tool"))
 	cargoHasCommand = oldHas
 	lppCargoGetDataForProvider = oldGet
 	cargoReadDir = oldRD
@@ -397,7 +408,7 @@ func TestCargoSync_InstallLatestSuccess(t *testing.T) {
 	_ = os.MkdirAll(p.APP_PACKAGES_DIR, 0755)
 	_ = lppCargoAdd("pkg:cargo/tool", "latest")
 	oldCap := cargoShellOutCapture
-	cargoShellOutCapture = func(cmd string, args []string, dir string, env []string) (int, string, error) {
+	cargoShellOutCapture = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, string, error) {
 		if len(args) > 1 && args[0] == "install" && args[1] == "--list" {
 			return 0, "", nil
 		}
@@ -407,13 +418,13 @@ func TestCargoSync_InstallLatestSuccess(t *testing.T) {
 		return 0, "", nil
 	}
 	oldOut := cargoShellOut
-	cargoShellOut = func(string, []string, string, []string) (int, error) { return 0, nil }
+	cargoShellOut = func(context.Context, string, []string, string, []string) (int, error) { return 0, nil }
 	// make createSymlinks no-op
 	oldRD := cargoReadDir
 	cargoReadDir = func(string) ([]os.DirEntry, error) { return []os.DirEntry{}, nil }
 	oldHas := cargoHasCommand
-	cargoHasCommand = func(string, []string, []string) bool { return true }
-	assert.True(t, p.Sync())
+	cargoHasCommand = func(context.Context, string, []string, []string) bool { return true }
+	assert.True(t, p.Sync(context.Background()))
 	cargoHasCommand = oldHas
 	cargoReadDir = oldRD
 	cargoShellOut = oldOut
@@ -428,7 +439,7 @@ func TestCargoSync_InstallLatestLockUpdateWarning(t *testing.T) {
 	_ = lppCargoAdd("pkg:cargo/tool", "latest")
 	// resolve latest and show not installed
 	oldCap := cargoShellOutCapture
-	cargoShellOutCapture = func(cmd string, args []string, dir string, env []string) (int, string, error) {
+	cargoShellOutCapture = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, string, error) {
 		if len(args) > 1 && args[0] == "install" && args[1] == "--list" {
 			return 0, "", nil
 		}
@@ -439,7 +450,7 @@ func TestCargoSync_InstallLatestLockUpdateWarning(t *testing.T) {
 	}
 	// install succeeds
 	oldOut := cargoShellOut
-	cargoShellOut = func(string, []string, string, []string) (int, error) { return 0, nil }
+	cargoShellOut = func(context.Context, string, []string, string, []string) (int, error) { return 0, nil }
 	// cause post-install lock update to warn
 	oldAdd := lppCargoAdd
 	lppCargoAdd = func(sourceId, version string) error { return errors.New("update-lock") }
@@ -447,8 +458,8 @@ func TestCargoSync_InstallLatestLockUpdateWarning(t *testing.T) {
 	oldRD := cargoReadDir
 	cargoReadDir = func(string) ([]os.DirEntry, error) { return []os.DirEntry{}, nil }
 	oldHas := cargoHasCommand
-	cargoHasCommand = func(string, []string, []string) bool { return true }
-	assert.True(t, p.Sync())
+	cargoHasCommand = func(context.Context, string, []string, []string) bool { return true }
+	assert.True(t, p.Sync(context.Background()))
 	// restore
 	cargoHasCommand = oldHas
 	cargoReadDir = oldRD
@@ -468,8 +479,8 @@ func TestCargoInstall_SpecificVersionSuccess(t *testing.T) {
 		return local_packages_parser.LocalPackageRoot{Packages: nil}
 	}
 	oldHas := cargoHasCommand
-	cargoHasCommand = func(string, []string, []string) bool { return true }
-	assert.True(t, p.Install("pkg:cargo/tool", "1.2.3"))
+	cargoHasCommand = func(context.Context, string, []string, []string) bool { return true }
+	assert.True(t, p.Install(context.Background(), "pkg:cargo/tool", "1.2.3"))
 	cargoHasCommand = oldHas
 	lppCargoGetDataForProvider = oldGet
 	lppCargoAdd = oldAdd
@@ -480,10 +491,10 @@ func TestCargoRemove_LocalRemoveError(t *testing.T) {
 	p := NewProviderCargo()
 	_ = os.MkdirAll(p.APP_PACKAGES_DIR, 0755)
 	oldOut := cargoShellOut
-	cargoShellOut = func(string, []string, string, []string) (int, error) { return 0, nil }
+	cargoShellOut = func(context.Context, string, []string, string, []string) (int, error) { return 0, nil }
 	oldLocalRemove := lppCargoRemove
 	lppCargoRemove = func(string) error { return errors.New("rm-local") }
-	assert.False(t, p.Remove("pkg:cargo/tool"))
+	assert.False(t, p.Remove(context.Background(), "pkg:cargo/tool"))
 	lppCargoRemove = oldLocalRemove
 	cargoShellOut = oldOut
 }
@@ -493,13 +504,15 @@ func TestCargoInstall_InvalidAndLatestAddError(t *testing.T) {
 	p := NewProviderCargo()
 	_ = os.MkdirAll(p.APP_PACKAGES_DIR, 0755)
 	// invalid source id
-	assert.False(t, p.Install("pkg:cargo/", "1.0.0"))
+	assert.False(t, p.Install(context.Background(), "pkg:cargo/", "1.0.0"))
 	// latest resolves but add fails
 	oldCap := cargoShellOutCapture
-	cargoShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 0, "tool = \"1.2.3\"", nil }
+	cargoShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 0, "tool = \"1.2.3\"", nil
+	}
 	oldAdd := lppCargoAdd
 	lppCargoAdd = func(string, string) error { return errors.New("add") }
-	assert.False(t, p.Install("pkg:cargo/tool", "latest"))
+	assert.False(t, p.Install(context.Background(), "pkg:cargo/tool", "latest"))
 	lppCargoAdd = oldAdd
 	cargoShellOutCapture = oldCap
 }
@@ -509,10 +522,10 @@ func TestCargoRemove_InvalidAndCreateSymlinksError(t *testing.T) {
 	p := NewProviderCargo()
 	_ = os.MkdirAll(p.APP_PACKAGES_DIR, 0755)
 	// invalid source id
-	assert.False(t, p.Remove("pkg:cargo/"))
+	assert.False(t, p.Remove(context.Background(), "pkg:cargo/"))
 	// happy uninstall and local remove, but createSymlinks error path
 	oldOut := cargoShellOut
-	cargoShellOut = func(string, []string, string, []string) (int, error) { return 0, nil }
+	cargoShellOut = func(context.Context, string, []string, string, []string) (int, error) { return 0, nil }
 	oldLocalRemove := lppCargoRemove
 	lppCargoRemove = func(string) error { return nil }
 	// readDir stub: first call (removeAllSymlinks) returns empty, second (createSymlinks) errors
@@ -531,8 +544,8 @@ func TestCargoRemove_InvalidAndCreateSymlinksError(t *testing.T) {
 		return local_packages_parser.LocalPackageRoot{Packages: nil}
 	}
 	oldHas := cargoHasCommand
-	cargoHasCommand = func(string, []string, []string) bool { return true }
-	assert.True(t, p.Remove("pkg:cargo/tool"))
+	cargoHasCommand = func(context.Context, string, []string, []string) bool { return true }
+	assert.True(t, p.Remove(context.Background(), "pkg:cargo/tool"))
 	// restore
 	cargoHasCommand = oldHas
 	lppCargoGetDataForProvider = oldGet
@@ -546,7 +559,9 @@ func TestCargoRemove_UninstallErrorProceeds(t *testing.T) {
 	p := NewProviderCargo()
 	_ = os.MkdirAll(p.APP_PACKAGES_DIR, 0755)
 	oldOut := cargoShellOut
-	cargoShellOut = func(string, []string, string, []string) (int, error) { return 1, errors.New("uninstall") }
+	cargoShellOut = func(context.Context, string, []string, string, []string) (int, error) {
+		return 1, errors.New("uninstall")
+	}
 	oldLocalRemove := lppCargoRemove
 	lppCargoRemove = func(string) error { return nil }
 	// ensure removeAll and createSymlinks harmless
@@ -558,8 +573,8 @@ func TestCargoRemove_UninstallErrorProceeds(t *testing.T) {
 		return local_packages_parser.LocalPackageRoot{Packages: nil}
 	}
 	oldHas := cargoHasCommand
-	cargoHasCommand = func(string, []string, []string) bool { return true }
-	assert.True(t, p.Remove("pkg:cargo/tool"))
+	cargoHasCommand = func(context.Context, string, []string, []string) bool { return true }
+	assert.True(t, p.Remove(context.Background(), "pkg:cargo/tool"))
 	cargoHasCommand = oldHas
 	lppCargoGetDataForProvider = oldGet
 	cargoReadDir = oldRD
@@ -576,20 +591,20 @@ func TestCargoSync_SkipInvalidRepoEntryAndSpecificInstallSuccess(t *testing.T) {
 	_ = lppCargoAdd("pkg:cargo/tool", "1.2.3")
 	// installed shows old version; force go through install
 	oldCap := cargoShellOutCapture
-	cargoShellOutCapture = func(cmd string, args []string, dir string, env []string) (int, string, error) {
+	cargoShellOutCapture = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, string, error) {
 		if len(args) > 1 && args[0] == "install" && args[1] == "--list" {
 			return 0, "tool v1.0.0:", nil
 		}
 		return 0, "", nil
 	}
 	oldOut := cargoShellOut
-	cargoShellOut = func(string, []string, string, []string) (int, error) { return 0, nil }
+	cargoShellOut = func(context.Context, string, []string, string, []string) (int, error) { return 0, nil }
 	// no-op symlink creation
 	oldRD := cargoReadDir
 	cargoReadDir = func(string) ([]os.DirEntry, error) { return []os.DirEntry{}, nil }
 	oldHas := cargoHasCommand
-	cargoHasCommand = func(string, []string, []string) bool { return true }
-	assert.True(t, p.Sync())
+	cargoHasCommand = func(context.Context, string, []string, []string) bool { return true }
+	assert.True(t, p.Sync(context.Background()))
 	cargoHasCommand = oldHas
 	cargoReadDir = oldRD
 	cargoShellOut = oldOut
@@ -601,7 +616,9 @@ func TestCargoInstall_EmptyVersionResolvesLatestSuccess(t *testing.T) {
 	p := NewProviderCargo()
 	_ = os.MkdirAll(p.APP_PACKAGES_DIR, 0755)
 	oldCap := cargoShellOutCapture
-	cargoShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 0, "tool = \"1.2.3\"", nil }
+	cargoShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 0, "tool = \"1.2.3\"", nil
+	}
 	oldAdd := lppCargoAdd
 	lppCargoAdd = func(string, string) error { return nil }
 	oldGet := lppCargoGetDataForProvider
@@ -609,10 +626,10 @@ func TestCargoInstall_EmptyVersionResolvesLatestSuccess(t *testing.T) {
 		return local_packages_parser.LocalPackageRoot{Packages: nil}
 	}
 	oldHas := cargoHasCommand
-	cargoHasCommand = func(string, []string, []string) bool { return true }
+	cargoHasCommand = func(context.Context, string, []string, []string) bool { return true }
 	oldRD := cargoReadDir
 	cargoReadDir = func(string) ([]os.DirEntry, error) { return []os.DirEntry{}, nil }
-	assert.True(t, p.Install("pkg:cargo/tool", ""))
+	assert.True(t, p.Install(context.Background(), "pkg:cargo/tool", ""))
 	cargoReadDir = oldRD
 	cargoHasCommand = oldHas
 	lppCargoGetDataForProvider = oldGet
@@ -630,21 +647,25 @@ func TestCargoMorePermutations(t *testing.T) {
 
 	// getLatestVersion not found
 	oldCap := cargoShellOutCapture
-	cargoShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 0, "no matches", nil }
-	_, err := p.getLatestVersion("crate")
+	cargoShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 0, "no matches", nil
+	}
+	_, err := p.getLatestVersion(context.Background(), "crate")
 	assert.Error(t, err)
 	cargoShellOutCapture = oldCap
 
 	// Sync skip installed path
 	_ = lppCargoAdd("pkg:cargo/myc", "1.0.0")
-	cargoShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 0, "myc v1.0.0: desc", nil }
-	assert.True(t, p.Sync())
+	cargoShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 0, "myc v1.0.0: desc", nil
+	}
+	assert.True(t, p.Sync(context.Background()))
 	cargoShellOutCapture = oldCap
 
 	// Install add failure -> false
 	oldAdd := lppCargoAdd
 	lppCargoAdd = func(string, string) error { return errors.New("add") }
-	assert.False(t, p.Install("pkg:cargo/myc", "1.0.0"))
+	assert.False(t, p.Install(context.Background(), "pkg:cargo/myc", "1.0.0"))
 	lppCargoAdd = oldAdd
 }
 
@@ -663,7 +684,7 @@ func TestCargoRepoAndCreateSymlinksSuccessAndCleanHappyAndInvalidUpdateRemove(t
 	// Clean happy path (make Sync return true quickly)
 	oldHas := cargoHasCommand
 	oldGet := lppCargoGetDataForProvider
-	cargoHasCommand = func(string, []string, []string) bool { return true }
+	cargoHasCommand = func(context.Context, string, []string, []string) bool { return true }
 	lppCargoGetDataForProvider = func(string) local_packages_parser.LocalPackageRoot {
 		return local_packages_parser.LocalPackageRoot{Packages: nil}
 	}
@@ -672,8 +693,8 @@ func TestCargoRepoAndCreateSymlinksSuccessAndCleanHappyAndInvalidUpdateRemove(t
 	lppCargoGetDataForProvider = oldGet
 
 	// Invalid repo branches
-	assert.False(t, p.Remove("pkg:cargo/"))
-	assert.False(t, p.Update("pkg:cargo/"))
+	assert.False(t, p.Remove(context.Background(), "pkg:cargo/"))
+	assert.False(t, p.Update(context.Background(), "pkg:cargo/"))
 }
 
 func TestCargoRemoveAllSymlinksReadDirErrorAndCreateSymlinksNoBinDir(t *testing.T) {
@@ -702,7 +723,7 @@ func TestCargoCleanWithRemoveAllSymlinksErrorStillProceeds(t *testing.T) {
 	// ensure Sync returns true (cargo available and desired empty)
 	oldHas := cargoHasCommand
 	oldGet := lppCargoGetDataForProvider
-	cargoHasCommand = func(string, []string, []string) bool { return true }
+	cargoHasCommand = func(context.Context, string, []string, []string) bool { return true }
 	lppCargoGetDataForProvider = func(string) local_packages_parser.LocalPackageRoot {
 		return local_packages_parser.LocalPackageRoot{Packages: nil}
 	}
@@ -723,7 +744,7 @@ func TestCargoSyncMixedDesiredInstalledAndRemoveAllSymlinksSuccessAndWarning(t *
 	_ = lppCargoAdd("pkg:cargo/beta", "latest")
 	// getInstalledCrates shows alpha installed
 	oldCap := cargoShellOutCapture
-	cargoShellOutCapture = func(cmd string, args []string, dir string, env []string) (int, string, error) {
+	cargoShellOutCapture = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, string, error) {
 		if len(args) > 0 && args[0] == "install" && len(args) > 1 && args[1] == "--list" { // not used
 			return 0, "", nil
 		}
@@ -737,11 +758,11 @@ func TestCargoSyncMixedDesiredInstalledAndRemoveAllSymlinksSuccessAndWarning(t *
 		return 0, "alpha v1.0.0:", nil
 	}
 	oldOut := cargoShellOut
-	cargoShellOut = func(string, []string, string, []string) (int, error) { return 0, nil }
+	cargoShellOut = func(context.Context, string, []string, string, []string) (int, error) { return 0, nil }
 	// ensure cargo bin exists with some binary for symlink creation call after Sync
 	_ = os.MkdirAll(filepath.Join(p.APP_PACKAGES_DIR, "bin"), 0755)
 	_ = os.WriteFile(filepath.Join(p.APP_PACKAGES_DIR, "bin", "dummy"), []byte(""), 0755)
-	assert.True(t, p.Sync())
+	assert.True(t, p.Sync(context.Background()))
 	cargoShellOut = oldOut
 	cargoShellOutCapture = oldCap
 
@@ -808,14 +829,16 @@ func TestCargoProviderBasicFlows(t *testing.T) {
 	oldOut := cargoShellOut
 	oldCap := cargoShellOutCapture
 	oldHas := cargoHasCommand
-	cargoShellOut = func(cmd string, args []string, dir string, env []string) (int, error) { return 0, nil }
-	cargoShellOutCapture = func(cmd string, args []string, dir string, env []string) (int, string, error) {
+	cargoShellOut = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, error) {
+		return 0, nil
+	}
+	cargoShellOutCapture = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, string, error) {
 		if len(args) > 0 && args[0] == "search" {
 			return 0, "mycrate = \"1.2.3\"", nil
 		}
 		return 0, "mycrate v1.0.0: installed binary\n", nil
 	}
-	cargoHasCommand = func(command string, args []string, env []string) bool { return true }
+	cargoHasCommand = func(_ context.Context, command string, args []string, env []string) bool { return true }
 	t.Cleanup(func() { cargoShellOut = oldOut; cargoShellOutCapture = oldCap; cargoHasCommand = oldHas })
 
 	p := NewProviderCargo()
@@ -839,23 +862,23 @@ func TestCargoProviderBasicFlows(t *testing.T) {
 	_ = os.Symlink(filepath.Join(cargoBin, "mycrate"), sl)
 
 	// getInstalledCrates
-	crates := p.getInstalledCrates()
+	crates := p.getInstalledCrates(context.Background())
 	assert.Equal(t, "1.0.0", crates["mycrate"]) // from stubbed capture
 
 	// Sync
-	ok := p.Sync()
+	ok := p.Sync(context.Background())
 	assert.True(t, ok)
 
 	// Install latest
-	ok = p.Install("pkg:cargo/mycrate", "latest")
+	ok = p.Install(context.Background(), "pkg:cargo/mycrate", "latest")
 	assert.True(t, ok)
 
 	// Update
-	ok = p.Update("pkg:cargo/mycrate")
+	ok = p.Update(context.Background(), "pkg:cargo/mycrate")
 	assert.True(t, ok)
 
 	// Remove
-	ok = p.Remove("pkg:cargo/mycrate")
+	ok = p.Remove(context.Background(), "pkg:cargo/mycrate")
 	assert.True(t, ok)
 
 	// Clean
@@ -863,6 +886,6 @@ func TestCargoProviderBasicFlows(t *testing.T) {
 	assert.True(t, ok)
 
 	// Remove again on missing to hit uninstall non-critical path
-	ok = p.Remove("pkg:cargo/mycrate")
+	ok = p.Remove(context.Background(), "pkg:cargo/mycrate")
 	assert.True(t, ok)
 }
@@ -0,0 +1,15 @@
+package providers
+
+import (
+	"fmt"
+
+	"github.com/mistweaverco/zana-client/internal/lib/chaos"
+)
+
+// chaosPartialExtraction removes a file from a freshly extracted archive
+// when --chaos picks the extraction to fail
+func chaosPartialExtraction(dir string) {
+	if removed := chaos.PartialExtraction(dir); removed != "" {
+		Logger.Info(fmt.Sprintf("Chaos: Removed %s from the extracted archive", removed))
+	}
+}
@@ -1,31 +1,37 @@
 package providers
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
+	"github.com/mistweaverco/zana-client/internal/lib/events"
 	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
-	"github.com/mistweaverco/zana-client/internal/lib/shell_out"
+	"github.com/mistweaverco/zana-client/internal/lib/trace"
 )
 
+// CodebergProvider installs packages from codeberg.org. Packages of other
+// Gitea and Forgejo instances are installed by the same code, see GiteaProvider.
 type CodebergProvider struct {
 	APP_PACKAGES_DIR string
 	PREFIX           string
 	PROVIDER_NAME    string
-	BASE_URL         string
+	// DISPLAY_NAME prefixes log messages
+	DISPLAY_NAME string
+	BASE_URL     string
 }
 
 // Injectable shell and OS helpers for tests
-var codebergShellOut = shell_out.ShellOut
-var codebergShellOutCapture = shell_out.ShellOutCapture
+var codebergShellOut = shellOut
+var codebergShellOutCapture = shellOutCapture
 var codebergStat = os.Stat
 var codebergMkdirAll = os.MkdirAll
 var codebergLstat = os.Lstat
@@ -33,7 +39,7 @@ var codebergRemove = os.Remove
 var codebergRemoveAll = os.RemoveAll
 var codebergSymlink = os.Symlink
 var codebergReadDir = os.ReadDir
-var codebergHasCommand = shell_out.HasCommand
+var codebergHasCommand = hasCommand
 
 // Injectable local packages helpers for tests
 var lppCodebergAdd = local_packages_parser.AddLocalPackage
@@ -43,35 +49,37 @@ var lppCodebergGetDataForProvider = local_packages_parser.GetDataForProvider
 // Injectable registry parser for tests
 var codebergRegistryParser = registry_parser.NewDefaultRegistryParser
 
-// Injectable HTTP client for tests
-var codebergHTTPGet = http.Get
-
 func NewProviderCodeberg() *CodebergProvider {
 	p := &CodebergProvider{}
 	p.PROVIDER_NAME = "codeberg"
 	p.APP_PACKAGES_DIR = filepath.Join(files.GetAppPackagesPath(), p.PROVIDER_NAME)
 	p.PREFIX = p.PROVIDER_NAME + ":"
-	p.BASE_URL = "https://codeberg.org"
+	p.DISPLAY_NAME = "Codeberg"
+	p.BASE_URL = forgeAt(codebergHost).baseURL()
 	return p
 }
 
 func (p *CodebergProvider) getRepo(sourceID string) string {
 	// Support both legacy (pkg:codeberg/user/repo) and new (codeberg:user/repo) formats
-	normalized := normalizePackageID(sourceID)
+	normalized := packageid.Normalize(sourceID)
 	if strings.HasPrefix(normalized, p.PREFIX) {
-		return strings.TrimPrefix(normalized, p.PREFIX)
-	}
-	// Fallback for legacy format
-	re := regexp.MustCompile("^pkg:" + p.PROVIDER_NAME + "/(.*)")
-	matches := re.FindStringSubmatch(sourceID)
-	if len(matches) > 1 {
-		return matches[1]
+		repo := strings.TrimPrefix(normalized, p.PREFIX)
+		if _, _, ok := forgeFor(p.PROVIDER_NAME, repo); ok {
+			return repo
+		}
 	}
 	return ""
 }
 
+// forge returns the forge hosting repo and the repository on it
+func (p *CodebergProvider) forge(repo string) (forge, string) {
+	f, forgeRepo, _ := forgeFor(p.PROVIDER_NAME, repo)
+	return f, forgeRepo
+}
+
 func (p *CodebergProvider) getRepoURL(repo string) string {
-	return fmt.Sprintf("%s/%s.git", p.BASE_URL, repo)
+	f, forgeRepo := p.forge(repo)
+	return f.repoURL(forgeRepo)
 }
 
 func (p *CodebergProvider) getRepoPath(repo string) string {
@@ -80,14 +88,14 @@ func (p *CodebergProvider) getRepoPath(repo string) string {
 	return filepath.Join(p.APP_PACKAGES_DIR, safeRepo)
 }
 
-func (p *CodebergProvider) checkGitAvailable() bool {
-	return codebergHasCommand("git", []string{"--version"}, nil)
+func (p *CodebergProvider) checkGitAvailable(ctx context.Context) bool {
+	return codebergHasCommand(ctx, "git", []string{"--version"}, nil)
 }
 
-func (p *CodebergProvider) Install(sourceID, version string) bool {
+func (p *CodebergProvider) Install(ctx context.Context, sourceID, version string) bool {
 	repo := p.getRepo(sourceID)
 	if repo == "" {
-		Logger.Error("Codeberg Install: Invalid source ID format")
+		Logger.ErrorContext(ctx, p.DISPLAY_NAME+" Install: Invalid source ID format")
 		return false
 	}
 
@@ -97,18 +105,18 @@ func (p *CodebergProvider) Install(sourceID, version string) bool {
 
 	// If registry has asset information, use release download method
 	if len(registryItem.Source.Asset) > 0 {
-		return p.installFromRelease(sourceID, repo, version, registryItem)
+		return p.installFromRelease(ctx, sourceID, repo, version, registryItem)
 	}
 
 	// Fallback to git clone method
-	return p.installFromGit(sourceID, repo, version)
+	return p.installFromGit(ctx, sourceID, repo, version)
 }
 
-func (p *CodebergProvider) installFromRelease(sourceID, repo, version string, registryItem registry_parser.RegistryItem) bool {
+func (p *CodebergProvider) installFromRelease(ctx context.Context, sourceID, repo, version string, registryItem registry_parser.RegistryItem) bool {
 	// Find matching asset for current platform
-	asset := FindMatchingAsset(registryItem.Source.Asset)
+	asset := FindMatchingAsset(ctx, registryItem.Source.Asset)
 	if asset == nil {
-		Logger.Error("Codeberg Install: No matching asset found for current platform")
+		Logger.ErrorContext(ctx, p.DISPLAY_NAME+" Install: No matching asset found for current platform")
 		return false
 	}
 
@@ -117,10 +125,10 @@ func (p *CodebergProvider) installFromRelease(sourceID, repo, version string, re
 	if resolvedVersion == "" || resolvedVersion == "latest" {
 		resolvedVersion = registryItem.Version
 		if resolvedVersion == "" {
-			// Try to get latest release from Codeberg API
+			// Try to get latest release from the forge API
 			latestTag, err := p.getLatestReleaseTag(repo)
 			if err != nil {
-				Logger.Error(fmt.Sprintf("Codeberg Install: Could not determine latest version: %v", err))
+				Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Could not determine latest version: %v", err))
 				return false
 			}
 			resolvedVersion = latestTag
@@ -131,74 +139,112 @@ func (p *CodebergProvider) installFromRelease(sourceID, repo, version string, re
 	assetFileName := ResolveTemplate(asset.File.String(), resolvedVersion)
 
 	// Download release asset
-	// Codeberg (Gitea) release download URL format: https://codeberg.org/{owner}/{repo}/releases/download/{tag}/{filename}
-	releaseURL := fmt.Sprintf("https://codeberg.org/%s/releases/download/%s/%s", repo, resolvedVersion, assetFileName)
-	Logger.Info(fmt.Sprintf("Codeberg Install: Downloading release asset from %s", releaseURL))
+	releaseURL := releaseAssetURL(p.PROVIDER_NAME, repo, resolvedVersion, assetFileName)
+	Logger.InfoContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Downloading release asset from %s", releaseURL))
 
 	// Ensure packages directory exists (create parent directories if needed)
 	if err := codebergMkdirAll(p.APP_PACKAGES_DIR, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("Codeberg Install: Error creating packages directory: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Error creating packages directory: %v", err))
 		return false
 	}
 
-	// Create temporary directory for extraction
-	tempDir := filepath.Join(p.APP_PACKAGES_DIR, strings.ReplaceAll(repo, "/", "_")+"_temp")
-	if err := codebergMkdirAll(tempDir, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("Codeberg Install: Error creating temp directory: %v", err))
+	// Download and extract in a staging directory of its own
+	tempDir, err := newStagingDir(ctx, p.PROVIDER_NAME, repo)
+	if err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Error creating temp directory: %v", err))
 		return false
 	}
 	defer codebergRemoveAll(tempDir)
 
-	// Download asset
+	// Download asset, verified against its checksum
+	download := verifiedDownload(p.downloadAsset, forgeGet, registryItem.Source.Asset, resolvedVersion)
 	assetPath := filepath.Join(tempDir, assetFileName)
-	if err := p.downloadAsset(releaseURL, assetPath); err != nil {
-		Logger.Error(fmt.Sprintf("Codeberg Install: Error downloading asset: %v", err))
-		return false
+	if err := download(ctx, releaseURL, assetPath); err != nil {
+		if !errors.Is(err, errReleaseAssetNotFound) {
+			Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Error downloading asset: %v", err))
+			return false
+		}
+		// The registry may list an asset upstream has since renamed
+		alternate, alternatePath, err := p.downloadAlternateAsset(ctx, repo, resolvedVersion, assetFileName, registryItem, tempDir)
+		if errors.Is(err, errReleaseAssetNotFound) {
+			Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: No release asset %s for %s", assetFileName, resolvedVersion))
+			return false
+		}
+		if err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: %v", err))
+			return false
+		}
+		asset, assetPath = alternate, alternatePath
+		releaseURL = releaseAssetURL(p.PROVIDER_NAME, repo, resolvedVersion, filepath.Base(alternatePath))
+		if err := verifyAssetChecksum(ctx, releaseURL, assetPath, forgeGet, registryItem.Source.Asset, resolvedVersion); err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Error downloading asset: %v", err))
+			return false
+		}
 	}
 
 	// Extract asset
 	extractDir := filepath.Join(tempDir, "extracted")
 	if err := codebergMkdirAll(extractDir, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("Codeberg Install: Error creating extract directory: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Error creating extract directory: %v", err))
 		return false
 	}
 
-	if err := p.extractArchive(assetPath, extractDir); err != nil {
-		Logger.Error(fmt.Sprintf("Codeberg Install: Error extracting asset: %v", err))
+	if err := extractOrRedownload(ctx, releaseURL, assetPath, extractDir, p.extractArchive, download); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Error extracting asset: %v", err))
+		return false
+	}
+	chaosPartialExtraction(extractDir)
+	if err := stripArchivePrefix(extractDir, asset.StripComponents, assetBinPaths(asset, registryItem)); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Error extracting asset: %v", err))
 		return false
 	}
 
+	// Data-only packages ship content without executables, nothing gets linked into bin
+	if registryItem.IsDataOnly() {
+		dest, err := installDataPackage(sourceID, extractDir)
+		if err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Error installing data package: %v", err))
+			return false
+		}
+		if err := lppCodebergAdd(sourceID, resolvedVersion); err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Error adding package to local packages: %v", err))
+			return false
+		}
+		Logger.InfoContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Successfully installed data package %s@%s into %s", repo, resolvedVersion, dest))
+		return true
+	}
+
 	// Find binaries and create symlinks
 	repoPath := p.getRepoPath(repo)
 	if err := codebergMkdirAll(repoPath, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("Codeberg Install: Error creating package directory: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Error creating package directory: %v", err))
 		return false
 	}
 
 	// Copy binaries to repo path
-	if err := p.copyBinariesFromExtract(extractDir, repoPath, asset, registryItem); err != nil {
-		Logger.Error(fmt.Sprintf("Codeberg Install: Error copying binaries: %v", err))
+	if err := p.copyBinariesFromExtract(ctx, extractDir, repoPath, asset, registryItem); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Error copying binaries: %v", err))
 		return false
 	}
 
 	// Create symlinks
-	if err := p.createSymlinksFromRegistry(repo, repoPath, asset, registryItem); err != nil {
-		Logger.Info(fmt.Sprintf("Codeberg Install: Warning creating symlinks: %v", err))
+	if err := p.createSymlinksFromRegistry(ctx, repo, repoPath, asset, registryItem); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Warning creating symlinks: %v", err))
 	}
 
 	// Add to local packages
 	if err := lppCodebergAdd(sourceID, resolvedVersion); err != nil {
-		Logger.Error(fmt.Sprintf("Codeberg Install: Error adding package to local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Error adding package to local packages: %v", err))
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("Codeberg Install: Successfully installed %s@%s from release", repo, resolvedVersion))
+	Logger.InfoContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Successfully installed %s@%s from release", repo, resolvedVersion))
 	return true
 }
 
-func (p *CodebergProvider) installFromGit(sourceID, repo, version string) bool {
-	if !p.checkGitAvailable() {
-		Logger.Error("Codeberg Install: git command not found. Please install git.")
+func (p *CodebergProvider) installFromGit(ctx context.Context, sourceID, repo, version string) bool {
+	if !p.checkGitAvailable(ctx) {
+		Logger.ErrorContext(ctx, p.DISPLAY_NAME+" Install: git command not found. Please install git.")
 		return false
 	}
 
@@ -207,25 +253,27 @@ func (p *CodebergProvider) installFromGit(sourceID, repo, version string) bool {
 
 	// Ensure packages directory exists
 	if err := codebergMkdirAll(p.APP_PACKAGES_DIR, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("Codeberg Install: Error creating packages directory: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Error creating packages directory: %v", err))
 		return false
 	}
 
 	// Clone or update repository
+	clone := p.clone(ctx, repoPath)
+	previousCommit := ""
 	if _, err := codebergStat(repoPath); os.IsNotExist(err) {
 		// Clone repository
-		Logger.Info(fmt.Sprintf("Codeberg Install: Cloning %s to %s", repoURL, repoPath))
-		code, err := codebergShellOut("git", []string{"clone", repoURL, repoPath}, p.APP_PACKAGES_DIR, nil)
+		Logger.InfoContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Cloning %s to %s", repoURL, repoPath))
+		code, err := codebergShellOut(ctx, "git", []string{"clone", repoURL, repoPath}, p.APP_PACKAGES_DIR, nil)
 		if err != nil || code != 0 {
-			Logger.Error(fmt.Sprintf("Codeberg Install: Error cloning repository: %v", err))
+			Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Error cloning repository: %v", err))
 			return false
 		}
 	} else {
-		// Update existing repository
-		Logger.Info(fmt.Sprintf("Codeberg Install: Updating repository at %s", repoPath))
-		code, err := codebergShellOut("git", []string{"fetch", "origin"}, repoPath, nil)
-		if err != nil || code != 0 {
-			Logger.Error(fmt.Sprintf("Codeberg Install: Error fetching updates: %v", err))
+		// Reuse the existing clone, fetching only what the requested version needs
+		previousCommit = clone.head()
+		Logger.InfoContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Reusing existing clone at %s", repoPath))
+		if err := clone.fetchFor(version); err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Error fetching updates: %v", err))
 			return false
 		}
 	}
@@ -235,130 +283,123 @@ func (p *CodebergProvider) installFromGit(sourceID, repo, version string) bool {
 	if resolvedVersion == "" || resolvedVersion == "latest" {
 		// Try to get latest tag from the cloned repo
 		var err error
-		resolvedVersion, err = p.getLatestVersionFromRepo(repoPath)
+		resolvedVersion, err = p.getLatestVersionFromRepo(ctx, repoPath)
 		if err != nil {
-			Logger.Info(fmt.Sprintf("Codeberg Install: Could not determine latest version, using default branch: %v", err))
+			Logger.InfoContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Could not determine latest version, using default branch: %v", err))
 			// Try to detect default branch
-			resolvedVersion = p.getDefaultBranch(repoPath)
+			resolvedVersion = p.getDefaultBranch(ctx, repoPath)
 		}
 	}
 
 	// Checkout specific version
-	code, err := codebergShellOut("git", []string{"checkout", resolvedVersion}, repoPath, nil)
-	if err != nil || code != 0 {
-		Logger.Error(fmt.Sprintf("Codeberg Install: Error checking out version %s: %v", resolvedVersion, err))
+	if err := clone.checkout(resolvedVersion); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Error checking out version %s: %v", resolvedVersion, err))
 		return false
 	}
+	if previousCommit != "" {
+		recordGitCommitRange(ctx, previousCommit, clone.head())
+	}
 
 	// Add to local packages
 	if err := lppCodebergAdd(sourceID, resolvedVersion); err != nil {
-		Logger.Error(fmt.Sprintf("Codeberg Install: Error adding package to local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Error adding package to local packages: %v", err))
 		return false
 	}
 
 	// Create symlinks for binaries
-	if err := p.createSymlinks(repo, repoPath); err != nil {
-		Logger.Info(fmt.Sprintf("Codeberg Install: Warning creating symlinks: %v", err))
+	if err := p.createSymlinks(ctx, repo, repoPath); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Warning creating symlinks: %v", err))
 		// Don't fail installation if symlinks fail
 	}
 
-	Logger.Info(fmt.Sprintf("Codeberg Install: Successfully installed %s@%s", repo, resolvedVersion))
+	Logger.InfoContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Install: Successfully installed %s@%s", repo, resolvedVersion))
 	return true
 }
 
-func (p *CodebergProvider) Remove(sourceID string) bool {
+func (p *CodebergProvider) Remove(ctx context.Context, sourceID string) bool {
 	repo := p.getRepo(sourceID)
 	if repo == "" {
-		Logger.Error("Codeberg Remove: Invalid source ID format")
+		Logger.ErrorContext(ctx, p.DISPLAY_NAME+" Remove: Invalid source ID format")
 		return false
 	}
 
 	repoPath := p.getRepoPath(repo)
-	Logger.Info(fmt.Sprintf("Codeberg Remove: Removing package %s", repo))
+	Logger.InfoContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Remove: Removing package %s", repo))
 
 	// Remove symlinks
-	if err := p.removeSymlinks(repo); err != nil {
-		Logger.Info(fmt.Sprintf("Codeberg Remove: Warning removing symlinks: %v", err))
+	if err := p.removeSymlinks(ctx, repo); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Remove: Warning removing symlinks: %v", err))
 	}
 
 	// Remove repository directory
 	if _, err := codebergStat(repoPath); err == nil {
 		if err := codebergRemoveAll(repoPath); err != nil {
-			Logger.Error(fmt.Sprintf("Codeberg Remove: Error removing repository directory: %v", err))
+			Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Remove: Error removing repository directory: %v", err))
 			return false
 		}
 	}
 
 	// Remove from local packages
 	if err := lppCodebergRemove(sourceID); err != nil {
-		Logger.Error(fmt.Sprintf("Codeberg Remove: Error removing package from local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Remove: Error removing package from local packages: %v", err))
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("Codeberg Remove: Successfully removed %s", repo))
+	Logger.InfoContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Remove: Successfully removed %s", repo))
 	return true
 }
 
-func (p *CodebergProvider) Update(sourceID string) bool {
+func (p *CodebergProvider) Update(ctx context.Context, sourceID string) bool {
 	repo := p.getRepo(sourceID)
 	if repo == "" {
-		Logger.Error("Codeberg Update: Invalid source ID format")
+		Logger.ErrorContext(ctx, p.DISPLAY_NAME+" Update: Invalid source ID format")
 		return false
 	}
 
 	repoPath := p.getRepoPath(repo)
 	if _, err := codebergStat(repoPath); os.IsNotExist(err) {
-		Logger.Error(fmt.Sprintf("Codeberg Update: Repository %s is not installed", repo))
+		Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Update: Repository %s is not installed", repo))
 		return false
 	}
 
-	// Fetch latest changes
-	code, err := codebergShellOut("git", []string{"fetch", "--tags", "origin"}, repoPath, nil)
-	if err != nil || code != 0 {
-		Logger.Error(fmt.Sprintf("Codeberg Update: Error fetching updates: %v", err))
+	// Fetch new tags and branches into the existing clone, pruning stale branches.
+	// Install reuses the clone and won't fetch again for the resolved tag.
+	if err := p.clone(ctx, repoPath).fetchAll(); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Update: Error fetching updates: %v", err))
 		return false
 	}
 
 	// Get latest version
-	latestVersion, err := p.getLatestVersionFromRepo(repoPath)
+	latestVersion, err := p.getLatestVersionFromRepo(ctx, repoPath)
 	if err != nil {
 		// No tags found, use default branch
-		latestVersion = p.getDefaultBranch(repoPath)
+		latestVersion = p.getDefaultBranch(ctx, repoPath)
 	}
 
-	Logger.Info(fmt.Sprintf("Codeberg Update: Updating %s to version %s", repo, latestVersion))
-	return p.Install(sourceID, latestVersion)
+	Logger.InfoContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Update: Updating %s to version %s", repo, latestVersion))
+	return p.Install(ctx, sourceID, latestVersion)
 }
 
-func (p *CodebergProvider) getLatestVersion(repo string) (string, error) {
+func (p *CodebergProvider) getLatestVersion(ctx context.Context, repo string) (string, error) {
 	// This is called before cloning, so we can't use the repo path
 	// Just return default branch - actual version will be resolved after clone
-	return p.getDefaultBranch(""), nil
+	return p.getDefaultBranch(ctx, ""), nil
 }
 
-func (p *CodebergProvider) getLatestVersionFromRepo(repoPath string) (string, error) {
-	// Fetch tags first
-	codebergShellOut("git", []string{"fetch", "--tags", "origin"}, repoPath, nil)
-
-	// Get latest tag
-	code, output, err := codebergShellOutCapture("git", []string{"describe", "--tags", "--abbrev=0"}, repoPath, nil)
-	if err != nil || code != 0 {
-		return "", fmt.Errorf("no tags found")
-	}
-
-	tag := strings.TrimSpace(output)
-	if tag == "" {
-		return "", fmt.Errorf("no tags found")
-	}
+func (p *CodebergProvider) getLatestVersionFromRepo(ctx context.Context, repoPath string) (string, error) {
+	// Callers fetch before resolving, so only the local tags are inspected
+	return p.clone(ctx, repoPath).latestTag()
+}
 
-	return tag, nil
+func (p *CodebergProvider) clone(ctx context.Context, repoPath string) gitClone {
+	return gitClone{ctx: ctx, path: repoPath, shellOut: codebergShellOut, shellOutCapture: codebergShellOutCapture}
 }
 
-func (p *CodebergProvider) getDefaultBranch(repoPath string) string {
+func (p *CodebergProvider) getDefaultBranch(ctx context.Context, repoPath string) string {
 	// Try to detect default branch
 	if repoPath != "" {
 		// Try to get default branch from existing repo
-		code, branchOutput, err := codebergShellOutCapture("git", []string{"symbolic-ref", "refs/remotes/origin/HEAD"}, repoPath, nil)
+		code, branchOutput, err := codebergShellOutCapture(ctx, "git", []string{"symbolic-ref", "refs/remotes/origin/HEAD"}, repoPath, nil)
 		if err == nil && code == 0 {
 			branch := strings.TrimSpace(branchOutput)
 			if strings.HasPrefix(branch, "refs/remotes/origin/") {
@@ -367,7 +408,7 @@ func (p *CodebergProvider) getDefaultBranch(repoPath string) string {
 		}
 		// Try common branch names
 		for _, branch := range []string{"main", "master", "trunk"} {
-			code, _, _ := codebergShellOutCapture("git", []string{"show-ref", "--verify", "--quiet", "refs/remotes/origin/" + branch}, repoPath, nil)
+			code, _, _ := codebergShellOutCapture(ctx, "git", []string{"show-ref", "--verify", "--quiet", "refs/remotes/origin/" + branch}, repoPath, nil)
 			if code == 0 {
 				return branch
 			}
@@ -377,8 +418,9 @@ func (p *CodebergProvider) getDefaultBranch(repoPath string) string {
 	return "main"
 }
 
-func (p *CodebergProvider) createSymlinks(_ string, repoPath string) error {
-	zanaBinDir := files.GetAppBinPath()
+func (p *CodebergProvider) createSymlinks(ctx context.Context, repo string, repoPath string) error {
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
+	filter := binFilterForSourceID(p.PREFIX + repo)
 
 	// Look for common binary locations
 	binDirs := []string{
@@ -401,6 +443,9 @@ func (p *CodebergProvider) createSymlinks(_ string, repoPath string) error {
 					if strings.HasPrefix(entry.Name(), ".") {
 						continue
 					}
+					if !filter.allows(entry.Name()) {
+						continue
+					}
 					// Create symlink
 					symlink := filepath.Join(zanaBinDir, entry.Name())
 					// Remove existing symlink if it exists
@@ -413,9 +458,9 @@ func (p *CodebergProvider) createSymlinks(_ string, repoPath string) error {
 						relPath = binPath
 					}
 					if err := codebergSymlink(relPath, symlink); err != nil {
-						Logger.Info(fmt.Sprintf("Codeberg: Warning creating symlink %s -> %s: %v", symlink, relPath, err))
+						Logger.InfoContext(ctx, fmt.Sprintf("Codeberg: Warning creating symlink %s -> %s: %v", symlink, relPath, err))
 					} else {
-						Logger.Info(fmt.Sprintf("Codeberg: Created symlink %s -> %s", symlink, relPath))
+						Logger.InfoContext(ctx, fmt.Sprintf("Codeberg: Created symlink %s -> %s", symlink, relPath))
 					}
 					// Only process first executable found per directory to avoid clutter
 					if info.Mode()&0111 != 0 {
@@ -429,10 +474,10 @@ func (p *CodebergProvider) createSymlinks(_ string, repoPath string) error {
 	return nil
 }
 
-func (p *CodebergProvider) removeSymlinks(repo string) error {
+func (p *CodebergProvider) removeSymlinks(ctx context.Context, repo string) error {
 	repoPath := p.getRepoPath(repo)
 	repoPath = filepath.Clean(repoPath) + string(os.PathSeparator)
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 
 	// Find and remove symlinks that point to this repo
 	entries, err := codebergReadDir(zanaBinDir)
@@ -457,7 +502,7 @@ func (p *CodebergProvider) removeSymlinks(repo string) error {
 				// Check if target is in our repo path
 				if strings.HasPrefix(target, repoPath) {
 					if err := codebergRemove(symlink); err != nil {
-						Logger.Info(fmt.Sprintf("Codeberg: Warning removing symlink %s: %v", symlink, err))
+						Logger.InfoContext(ctx, fmt.Sprintf("Codeberg: Warning removing symlink %s: %v", symlink, err))
 					}
 				}
 			}
@@ -467,8 +512,8 @@ func (p *CodebergProvider) removeSymlinks(repo string) error {
 	return nil
 }
 
-func (p *CodebergProvider) Sync() bool {
-	Logger.Info("Codeberg Sync: Syncing Codeberg packages")
+func (p *CodebergProvider) Sync(ctx context.Context) bool {
+	Logger.InfoContext(ctx, p.DISPLAY_NAME+" Sync: Syncing "+p.DISPLAY_NAME+" packages")
 	localPackages := lppCodebergGetDataForProvider(p.PROVIDER_NAME).Packages
 
 	allOk := true
@@ -480,14 +525,14 @@ func (p *CodebergProvider) Sync() bool {
 		repoPath := p.getRepoPath(repo)
 		if _, err := codebergStat(repoPath); os.IsNotExist(err) {
 			// Re-install missing packages
-			Logger.Info(fmt.Sprintf("Codeberg Sync: Re-installing missing package %s", repo))
-			if !p.Install(pkg.SourceID, pkg.Version) {
+			Logger.InfoContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Sync: Re-installing missing package %s", repo))
+			if !p.Install(newOperation(ctx, actionSync, pkg.SourceID), pkg.SourceID, pkg.Version) {
 				allOk = false
 			}
 		} else {
 			// Update symlinks
-			if err := p.createSymlinks(repo, repoPath); err != nil {
-				Logger.Info(fmt.Sprintf("Codeberg Sync: Warning creating symlinks for %s: %v", repo, err))
+			if err := p.createSymlinks(ctx, repo, repoPath); err != nil {
+				Logger.InfoContext(ctx, fmt.Sprintf(p.DISPLAY_NAME+" Sync: Warning creating symlinks for %s: %v", repo, err))
 			}
 		}
 	}
@@ -495,41 +540,43 @@ func (p *CodebergProvider) Sync() bool {
 	return allOk
 }
 
-// getLatestReleaseTag gets the latest release tag from Codeberg API (Gitea)
+// getLatestReleaseTag gets the latest release tag from the forge API
 func (p *CodebergProvider) getLatestReleaseTag(repo string) (string, error) {
-	apiURL := fmt.Sprintf("https://codeberg.org/api/v1/repos/%s/releases", repo)
-	resp, err := codebergHTTPGet(apiURL)
-	if err != nil {
-		return "", fmt.Errorf("failed to fetch release info: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("codeberg API returned status %d", resp.StatusCode)
-	}
-
-	var releases []struct {
-		TagName string `json:"tag_name"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
-		return "", fmt.Errorf("failed to parse release info: %w", err)
-	}
-
-	if len(releases) == 0 {
-		return "", fmt.Errorf("no releases found")
-	}
+	f, forgeRepo := p.forge(repo)
+	return f.latestReleaseTag(forgeRepo)
+}
 
-	return releases[0].TagName, nil
+// downloadAlternateAsset downloads a replacement for the release asset
+// missing from the release, see downloadAlternateAsset
+func (p *CodebergProvider) downloadAlternateAsset(ctx context.Context, repo, version, missing string, registryItem registry_parser.RegistryItem, tempDir string) (*registry_parser.RegistryItemSourceAsset, string, error) {
+	f, forgeRepo := p.forge(repo)
+	return downloadAlternateAsset(ctx, alternateAssetSource{
+		label:   p.DISPLAY_NAME + " Install",
+		repo:    repo,
+		version: version,
+		assetURL: func(name string) string {
+			return releaseAssetURL(p.PROVIDER_NAME, repo, version, name)
+		},
+		download: p.downloadAsset,
+		list:     func() ([]string, error) { return f.releaseAssets(forgeRepo, version) },
+	}, missing, registryItem, tempDir)
 }
 
 // downloadAsset downloads a file from a URL to a destination path
-func (p *CodebergProvider) downloadAsset(url, destPath string) error {
-	resp, err := codebergHTTPGet(url)
+func (p *CodebergProvider) downloadAsset(ctx context.Context, url, destPath string) error {
+	if sum, ok := restoreCachedAsset(url, destPath); ok {
+		return pinAsset(ctx, url, sum)
+	}
+
+	resp, err := forgeGet(url)
 	if err != nil {
 		return fmt.Errorf("failed to download: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return errReleaseAssetNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("HTTP error: %d", resp.StatusCode)
 	}
@@ -540,21 +587,27 @@ func (p *CodebergProvider) downloadAsset(url, destPath string) error {
 	}
 	defer func() { _ = file.Close() }()
 
-	if _, err := io.Copy(file, resp.Body); err != nil {
+	sum, err := hashCopy(file, downloadBody(ctx, resp, url))
+	if err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
-
+	if err := pinAsset(ctx, url, sum); err != nil {
+		return err
+	}
 	return nil
 }
 
 // extractArchive extracts an archive (tar.gz, zip, etc.) to a destination directory
-func (p *CodebergProvider) extractArchive(archivePath, destDir string) error {
+func (p *CodebergProvider) extractArchive(ctx context.Context, archivePath, destDir string) (err error) {
+	publishState(ctx, events.StateExtracting)
+	span := trace.StartContext(ctx, "extract", "file.path", archivePath)
+	defer func() { span.End(err) }()
 	ext := filepath.Ext(archivePath)
 	baseExt := filepath.Ext(strings.TrimSuffix(archivePath, ext))
 
 	if baseExt == ".tar" && ext == ".gz" {
 		// Extract tar.gz
-		code, err := codebergShellOut("tar", []string{"-xzf", archivePath, "-C", destDir}, "", nil)
+		code, err := codebergShellOut(ctx, "tar", []string{"-xzf", archivePath, "-C", destDir}, "", nil)
 		if err != nil || code != 0 {
 			return fmt.Errorf("failed to extract tar.gz: %v", err)
 		}
@@ -568,7 +621,7 @@ func (p *CodebergProvider) extractArchive(archivePath, destDir string) error {
 	} else if ext == ".gz" && baseExt != ".tar" {
 		// Single .gz file - gunzip and copy
 		outputPath := filepath.Join(destDir, strings.TrimSuffix(filepath.Base(archivePath), ".gz"))
-		code, err := codebergShellOut("sh", []string{"-c", fmt.Sprintf("gunzip -c %s > %s", archivePath, outputPath)}, "", nil)
+		code, err := codebergShellOut(ctx, "sh", []string{"-c", fmt.Sprintf("gunzip -c %s > %s", archivePath, outputPath)}, "", nil)
 		if err != nil || code != 0 {
 			return fmt.Errorf("failed to extract gz: %v", err)
 		}
@@ -598,7 +651,7 @@ func (p *CodebergProvider) extractArchive(archivePath, destDir string) error {
 }
 
 // copyBinariesFromExtract copies binaries from extracted archive to package directory
-func (p *CodebergProvider) copyBinariesFromExtract(extractDir, repoPath string, asset *registry_parser.RegistryItemSourceAsset, registryItem registry_parser.RegistryItem) error {
+func (p *CodebergProvider) copyBinariesFromExtract(ctx context.Context, extractDir, repoPath string, asset *registry_parser.RegistryItemSourceAsset, registryItem registry_parser.RegistryItem) error {
 	// Find binaries in extracted directory
 	// Asset file might have a path prefix (e.g., "file.tar.gz:subdir/")
 	assetFile := asset.File.String()
@@ -621,7 +674,7 @@ func (p *CodebergProvider) copyBinariesFromExtract(extractDir, repoPath string,
 			// Copy binary to repo path
 			destBinPath := filepath.Join(repoPath, filepath.Base(binPath))
 			if err := p.copyFile(sourceBinPath, destBinPath); err != nil {
-				Logger.Info(fmt.Sprintf("Codeberg: Warning copying binary %s: %v", binPath, err))
+				Logger.InfoContext(ctx, fmt.Sprintf("Codeberg: Warning copying binary %s: %v", binPath, err))
 			} else {
 				// Make executable
 				os.Chmod(destBinPath, 0755)
@@ -631,7 +684,7 @@ func (p *CodebergProvider) copyBinariesFromExtract(extractDir, repoPath string,
 			if foundPath := p.findBinaryInDir(extractDir, filepath.Base(binPath)); foundPath != "" {
 				destBinPath := filepath.Join(repoPath, filepath.Base(binPath))
 				if err := p.copyFile(foundPath, destBinPath); err != nil {
-					Logger.Info(fmt.Sprintf("Codeberg: Warning copying binary %s: %v", binPath, err))
+					Logger.InfoContext(ctx, fmt.Sprintf("Codeberg: Warning copying binary %s: %v", binPath, err))
 				} else {
 					os.Chmod(destBinPath, 0755)
 				}
@@ -682,10 +735,14 @@ func (p *CodebergProvider) findBinaryInDir(dir, name string) string {
 }
 
 // createSymlinksFromRegistry creates symlinks based on registry bin configuration
-func (p *CodebergProvider) createSymlinksFromRegistry(_ string, repoPath string, asset *registry_parser.RegistryItemSourceAsset, registryItem registry_parser.RegistryItem) error {
-	zanaBinDir := files.GetAppBinPath()
+func (p *CodebergProvider) createSymlinksFromRegistry(ctx context.Context, _ string, repoPath string, asset *registry_parser.RegistryItemSourceAsset, registryItem registry_parser.RegistryItem) error {
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
+	filter := binFilterFor(registryItem.Source.ID, registryItem)
 
 	for binName, binTemplate := range registryItem.Bin {
+		if !filter.allows(binName) {
+			continue
+		}
 		binPath := ResolveBinPath(binTemplate, asset, binName)
 		if binPath == "" {
 			continue
@@ -714,9 +771,9 @@ func (p *CodebergProvider) createSymlinksFromRegistry(_ string, repoPath string,
 		}
 
 		if err := codebergSymlink(relPath, symlink); err != nil {
-			Logger.Info(fmt.Sprintf("Codeberg: Warning creating symlink %s -> %s: %v", symlink, relPath, err))
+			Logger.InfoContext(ctx, fmt.Sprintf("Codeberg: Warning creating symlink %s -> %s: %v", symlink, relPath, err))
 		} else {
-			Logger.Info(fmt.Sprintf("Codeberg: Created symlink %s -> %s", symlink, relPath))
+			Logger.InfoContext(ctx, fmt.Sprintf("Codeberg: Created symlink %s -> %s", symlink, relPath))
 		}
 	}
 
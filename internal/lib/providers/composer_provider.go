@@ -1,17 +1,17 @@
 package providers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
-	"github.com/mistweaverco/zana-client/internal/lib/shell_out"
 )
 
 type ComposerProvider struct {
@@ -23,9 +23,9 @@ type ComposerProvider struct {
 var composerCmd = "composer"
 
 // Injectable shell and OS helpers for tests
-var composerShellOut = shell_out.ShellOut
-var composerShellOutCapture = shell_out.ShellOutCapture
-var composerHasCommand = shell_out.HasCommand
+var composerShellOut = shellOut
+var composerShellOutCapture = shellOutCapture
+var composerHasCommand = hasCommand
 var composerCreate = os.Create
 var composerReadFile = os.ReadFile
 var composerLstat = os.Lstat
@@ -52,20 +52,14 @@ func NewProviderComposer() *ComposerProvider {
 
 func (p *ComposerProvider) getRepo(sourceID string) string {
 	// Support both legacy (pkg:composer/vendor/package) and new (composer:vendor/package) formats
-	normalized := normalizePackageID(sourceID)
+	normalized := packageid.Normalize(sourceID)
 	if strings.HasPrefix(normalized, p.PREFIX) {
 		return strings.TrimPrefix(normalized, p.PREFIX)
 	}
-	// Fallback for legacy format
-	re := regexp.MustCompile("^pkg:" + p.PROVIDER_NAME + "/(.*)")
-	matches := re.FindStringSubmatch(sourceID)
-	if len(matches) > 1 {
-		return matches[1]
-	}
 	return ""
 }
 
-func (p *ComposerProvider) generateComposerJSON() bool {
+func (p *ComposerProvider) generateComposerJSON(ctx context.Context) bool {
 	found := false
 	composerJSON := struct {
 		Require map[string]string `json:"require"`
@@ -94,7 +88,7 @@ func (p *ComposerProvider) generateComposerJSON() bool {
 	filePath := filepath.Join(p.APP_PACKAGES_DIR, "composer.json")
 	file, err := composerCreate(filePath)
 	if err != nil {
-		Logger.Error(fmt.Sprintf("Error creating composer.json: %s", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Error creating composer.json: %s", err))
 		return false
 	}
 	defer func() {
@@ -107,28 +101,28 @@ func (p *ComposerProvider) generateComposerJSON() bool {
 	encoder.SetIndent("", "  ")
 	err = encoder.Encode(composerJSON)
 	if err != nil {
-		Logger.Error(fmt.Sprintf("Error encoding composer.json: %s", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Error encoding composer.json: %s", err))
 		return false
 	}
 
 	return true
 }
 
-func (p *ComposerProvider) Install(sourceID, version string) bool {
+func (p *ComposerProvider) Install(ctx context.Context, sourceID, version string) bool {
 	packageName := p.getRepo(sourceID)
 	if packageName == "" {
-		Logger.Error("Composer Install: Invalid source ID format")
+		Logger.ErrorContext(ctx, "Composer Install: Invalid source ID format")
 		return false
 	}
 
-	if !composerHasCommand("composer", []string{"--version"}, nil) {
-		Logger.Error("Composer Install: composer command not found. Please install Composer.")
+	if !composerHasCommand(ctx, "composer", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "Composer Install: composer command not found. Please install Composer.")
 		return false
 	}
 
 	// Ensure packages directory exists
 	if err := composerMkdirAll(p.APP_PACKAGES_DIR, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("Composer Install: Error creating packages directory: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Composer Install: Error creating packages directory: %v", err))
 		return false
 	}
 
@@ -138,10 +132,10 @@ func (p *ComposerProvider) Install(sourceID, version string) bool {
 		packageSpec = fmt.Sprintf("%s:^%s", packageName, version)
 	}
 
-	Logger.Info(fmt.Sprintf("Composer Install: Installing %s@%s", packageName, version))
-	code, err := composerShellOut(composerCmd, []string{"require", packageSpec, "--no-interaction", "--no-plugins", "--no-scripts"}, p.APP_PACKAGES_DIR, nil)
+	Logger.InfoContext(ctx, fmt.Sprintf("Composer Install: Installing %s@%s", packageName, version))
+	code, err := composerShellOut(ctx, composerCmd, []string{"require", packageSpec, "--no-interaction", "--no-plugins", "--no-scripts"}, p.APP_PACKAGES_DIR, nil)
 	if err != nil || code != 0 {
-		Logger.Error(fmt.Sprintf("Composer Install: Error installing package: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Composer Install: Error installing package: %v", err))
 		return false
 	}
 
@@ -173,78 +167,78 @@ func (p *ComposerProvider) Install(sourceID, version string) bool {
 
 	// Add to local packages
 	if err := lppComposerAdd(sourceID, installedVersion); err != nil {
-		Logger.Error(fmt.Sprintf("Composer Install: Error adding package to local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Composer Install: Error adding package to local packages: %v", err))
 		return false
 	}
 
 	// Regenerate composer.json
-	_ = p.generateComposerJSON()
+	_ = p.generateComposerJSON(ctx)
 
 	// Create wrappers for binaries
-	if err := p.createWrappers(); err != nil {
-		Logger.Info(fmt.Sprintf("Composer Install: Warning creating wrappers: %v", err))
+	if err := p.createWrappers(ctx); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("Composer Install: Warning creating wrappers: %v", err))
 	}
 
-	Logger.Info(fmt.Sprintf("Composer Install: Successfully installed %s@%s", packageName, installedVersion))
+	Logger.InfoContext(ctx, fmt.Sprintf("Composer Install: Successfully installed %s@%s", packageName, installedVersion))
 	return true
 }
 
-func (p *ComposerProvider) Remove(sourceID string) bool {
+func (p *ComposerProvider) Remove(ctx context.Context, sourceID string) bool {
 	packageName := p.getRepo(sourceID)
 	if packageName == "" {
-		Logger.Error("Composer Remove: Invalid source ID format")
+		Logger.ErrorContext(ctx, "Composer Remove: Invalid source ID format")
 		return false
 	}
 
-	if !composerHasCommand("composer", []string{"--version"}, nil) {
-		Logger.Error("Composer Remove: composer command not found. Please install Composer.")
+	if !composerHasCommand(ctx, "composer", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "Composer Remove: composer command not found. Please install Composer.")
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("Composer Remove: Removing %s", packageName))
+	Logger.InfoContext(ctx, fmt.Sprintf("Composer Remove: Removing %s", packageName))
 
 	// Remove wrappers
-	if err := p.removeWrappersForPackage(packageName); err != nil {
-		Logger.Info(fmt.Sprintf("Composer Remove: Warning removing wrappers: %v", err))
+	if err := p.removeWrappersForPackage(ctx, packageName); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("Composer Remove: Warning removing wrappers: %v", err))
 	}
 
 	// Remove package using composer
-	code, err := composerShellOut(composerCmd, []string{"remove", packageName, "--no-interaction", "--no-plugins", "--no-scripts"}, p.APP_PACKAGES_DIR, nil)
+	code, err := composerShellOut(ctx, composerCmd, []string{"remove", packageName, "--no-interaction", "--no-plugins", "--no-scripts"}, p.APP_PACKAGES_DIR, nil)
 	if err != nil || code != 0 {
-		Logger.Info(fmt.Sprintf("Composer Remove: Warning removing package (may not be installed): %v", err))
+		Logger.InfoContext(ctx, fmt.Sprintf("Composer Remove: Warning removing package (may not be installed): %v", err))
 	}
 
 	// Remove from local packages
 	if err := lppComposerRemove(sourceID); err != nil {
-		Logger.Error(fmt.Sprintf("Composer Remove: Error removing package from local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Composer Remove: Error removing package from local packages: %v", err))
 		return false
 	}
 
 	// Regenerate composer.json
-	_ = p.generateComposerJSON()
+	_ = p.generateComposerJSON(ctx)
 
-	Logger.Info(fmt.Sprintf("Composer Remove: Successfully removed %s", packageName))
+	Logger.InfoContext(ctx, fmt.Sprintf("Composer Remove: Successfully removed %s", packageName))
 	return true
 }
 
-func (p *ComposerProvider) Update(sourceID string) bool {
+func (p *ComposerProvider) Update(ctx context.Context, sourceID string) bool {
 	packageName := p.getRepo(sourceID)
 	if packageName == "" {
-		Logger.Error("Composer Update: Invalid source ID format")
+		Logger.ErrorContext(ctx, "Composer Update: Invalid source ID format")
 		return false
 	}
 
-	if !composerHasCommand("composer", []string{"--version"}, nil) {
-		Logger.Error("Composer Update: composer command not found. Please install Composer.")
+	if !composerHasCommand(ctx, "composer", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "Composer Update: composer command not found. Please install Composer.")
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("Composer Update: Updating %s", packageName))
+	Logger.InfoContext(ctx, fmt.Sprintf("Composer Update: Updating %s", packageName))
 
 	// Update package using composer
-	code, err := composerShellOut(composerCmd, []string{"update", packageName, "--no-interaction", "--no-plugins", "--no-scripts"}, p.APP_PACKAGES_DIR, nil)
+	code, err := composerShellOut(ctx, composerCmd, []string{"update", packageName, "--no-interaction", "--no-plugins", "--no-scripts"}, p.APP_PACKAGES_DIR, nil)
 	if err != nil || code != 0 {
-		Logger.Error(fmt.Sprintf("Composer Update: Error updating package: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Composer Update: Error updating package: %v", err))
 		return false
 	}
 
@@ -274,30 +268,30 @@ func (p *ComposerProvider) Update(sourceID string) bool {
 	// Update local packages
 	if err := lppComposerRemove(sourceID); err == nil {
 		if err := lppComposerAdd(sourceID, updatedVersion); err != nil {
-			Logger.Error(fmt.Sprintf("Composer Update: Error updating package in local packages: %v", err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("Composer Update: Error updating package in local packages: %v", err))
 			return false
 		}
 	}
 
 	// Regenerate composer.json
-	_ = p.generateComposerJSON()
+	_ = p.generateComposerJSON(ctx)
 
 	// Recreate wrappers
-	if err := p.createWrappers(); err != nil {
-		Logger.Info(fmt.Sprintf("Composer Update: Warning recreating wrappers: %v", err))
+	if err := p.createWrappers(ctx); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("Composer Update: Warning recreating wrappers: %v", err))
 	}
 
-	Logger.Info(fmt.Sprintf("Composer Update: Successfully updated %s@%s", packageName, updatedVersion))
+	Logger.InfoContext(ctx, fmt.Sprintf("Composer Update: Successfully updated %s@%s", packageName, updatedVersion))
 	return true
 }
 
-func (p *ComposerProvider) getLatestVersion(packageName string) (string, error) {
-	if !composerHasCommand("composer", []string{"--version"}, nil) {
+func (p *ComposerProvider) getLatestVersion(ctx context.Context, packageName string) (string, error) {
+	if !composerHasCommand(ctx, "composer", []string{"--version"}, nil) {
 		return "", fmt.Errorf("composer command not found")
 	}
 
 	// Use composer show to get latest version
-	code, output, err := composerShellOutCapture(composerCmd, []string{"show", packageName, "--all", "--no-interaction"}, "", nil)
+	code, output, err := composerShellOutCapture(ctx, composerCmd, []string{"show", packageName, "--all", "--no-interaction"}, "", nil)
 	if err != nil || code != 0 {
 		return "", fmt.Errorf("failed to get package info: %v", err)
 	}
@@ -332,29 +326,33 @@ func (p *ComposerProvider) findComposerBinDir() string {
 }
 
 // createWrappers creates wrapper scripts for composer executables
-func (p *ComposerProvider) createWrappers() error {
+func (p *ComposerProvider) createWrappers(ctx context.Context) error {
 	desired := lppComposerGetDataForProvider("composer").Packages
 	if len(desired) == 0 {
 		return nil
 	}
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	parser := registry_parser.NewDefaultRegistryParser()
 	for _, pkg := range desired {
 		registryItem := parser.GetBySourceId(pkg.SourceID)
 		if len(registryItem.Bin) == 0 {
 			continue
 		}
+		filter := binFilterFor(pkg.SourceID, registryItem)
 		for binName, binCmd := range registryItem.Bin {
+			if !filter.allows(binName) {
+				continue
+			}
 			wrapperPath := filepath.Join(zanaBinDir, binName)
 			if _, err := composerLstat(wrapperPath); err == nil {
 				_ = composerRemove(wrapperPath)
 			}
 			if err := p.createComposerWrapperForCommand(binCmd, wrapperPath); err != nil {
-				Logger.Error(fmt.Sprintf("Error creating wrapper for %s: %v", binName, err))
+				Logger.ErrorContext(ctx, fmt.Sprintf("Error creating wrapper for %s: %v", binName, err))
 				continue
 			}
 			if err := composerChmod(wrapperPath, 0755); err != nil {
-				Logger.Error(fmt.Sprintf("Error setting executable permissions for %s: %v", binName, err))
+				Logger.ErrorContext(ctx, fmt.Sprintf("Error setting executable permissions for %s: %v", binName, err))
 			}
 		}
 	}
@@ -378,29 +376,21 @@ func (p *ComposerProvider) createComposerWrapperForCommand(commandToExec string,
 		execCmd = filepath.Join(composerBinDir, commandToExec)
 	}
 
-	wrapperContent := fmt.Sprintf(`#!/bin/sh
-# Sets up PHP/Composer environment for zana-installed packages and runs the target command
-
-# Add the zana composer bin directory to PATH
-export PATH="%s:$PATH"
-
-# Add composer vendor directory to PHP include path
-export COMPOSER_VENDOR_DIR="%s"
-
-# Execute the command from registry
-exec %s "$@"
-`, composerBinDir, vendorDir, execCmd)
-
-	if err := composerWriteFile(wrapperPath, []byte(wrapperContent), 0755); err != nil {
-		return err
+	script := wrapperScript{
+		Description: "Sets up the PHP/Composer environment for zana-installed packages and runs the target command",
+		Env: []wrapperEnv{
+			{Name: "PATH", Value: composerBinDir, Separator: ":"},
+			{Name: "COMPOSER_VENDOR_DIR", Value: vendorDir},
+		},
+		Command: []string{execCmd},
 	}
-	return nil
+	return writeWrapperScript(script, wrapperPath, composerWriteFile)
 }
 
 // removeWrappersForPackage removes wrapper scripts for a specific package
-func (p *ComposerProvider) removeWrappersForPackage(packageName string) error {
+func (p *ComposerProvider) removeWrappersForPackage(ctx context.Context, packageName string) error {
 	desired := lppComposerGetDataForProvider("composer").Packages
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	parser := registry_parser.NewDefaultRegistryParser()
 
 	for _, pkg := range desired {
@@ -412,7 +402,7 @@ func (p *ComposerProvider) removeWrappersForPackage(packageName string) error {
 			wrapperPath := filepath.Join(zanaBinDir, binName)
 			if _, err := composerLstat(wrapperPath); err == nil {
 				if err := composerRemove(wrapperPath); err != nil {
-					Logger.Info(fmt.Sprintf("Composer: Warning removing wrapper %s: %v", wrapperPath, err))
+					Logger.InfoContext(ctx, fmt.Sprintf("Composer: Warning removing wrapper %s: %v", wrapperPath, err))
 				}
 			}
 		}
@@ -420,8 +410,8 @@ func (p *ComposerProvider) removeWrappersForPackage(packageName string) error {
 	return nil
 }
 
-func (p *ComposerProvider) Sync() bool {
-	Logger.Info("Composer Sync: Syncing composer packages")
+func (p *ComposerProvider) Sync(ctx context.Context) bool {
+	Logger.InfoContext(ctx, "Composer Sync: Syncing composer packages")
 	localPackages := lppComposerGetDataForProvider(p.PROVIDER_NAME).Packages
 
 	if len(localPackages) == 0 {
@@ -429,33 +419,33 @@ func (p *ComposerProvider) Sync() bool {
 	}
 
 	// Check for composer command before proceeding
-	if !composerHasCommand("composer", []string{"--version"}, nil) {
-		Logger.Error("Composer Sync: composer command not found. Please install Composer.")
+	if !composerHasCommand(ctx, "composer", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "Composer Sync: composer command not found. Please install Composer.")
 		return false
 	}
 
 	// Regenerate composer.json
-	if !p.generateComposerJSON() {
-		Logger.Error("Composer Sync: Failed to generate composer.json")
+	if !p.generateComposerJSON(ctx) {
+		Logger.ErrorContext(ctx, "Composer Sync: Failed to generate composer.json")
 		return false
 	}
 
 	// Install all packages using composer install
 	composerJSONPath := filepath.Join(p.APP_PACKAGES_DIR, "composer.json")
 	if _, err := composerStat(composerJSONPath); os.IsNotExist(err) {
-		Logger.Error("Composer Sync: composer.json not found")
+		Logger.ErrorContext(ctx, "Composer Sync: composer.json not found")
 		return false
 	}
 
-	code, err := composerShellOut(composerCmd, []string{"install", "--no-interaction", "--no-plugins", "--no-scripts"}, p.APP_PACKAGES_DIR, nil)
+	code, err := composerShellOut(ctx, composerCmd, []string{"install", "--no-interaction", "--no-plugins", "--no-scripts"}, p.APP_PACKAGES_DIR, nil)
 	if err != nil || code != 0 {
-		Logger.Error(fmt.Sprintf("Composer Sync: Error running composer install: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Composer Sync: Error running composer install: %v", err))
 		return false
 	}
 
 	// Recreate wrappers
-	if err := p.createWrappers(); err != nil {
-		Logger.Info(fmt.Sprintf("Composer Sync: Warning creating wrappers: %v", err))
+	if err := p.createWrappers(ctx); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("Composer Sync: Warning creating wrappers: %v", err))
 	}
 
 	return true
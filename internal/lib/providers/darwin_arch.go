@@ -0,0 +1,131 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+)
+
+// rosettaRuntimePath exists when Rosetta 2 is installed
+const rosettaRuntimePath = "/Library/Apple/usr/share/rosetta/rosetta"
+
+// Registry targets of macOS release assets
+const (
+	targetDarwinArm64     = "darwin_" + files.DarwinArchArm64
+	targetDarwinX64       = "darwin_" + files.DarwinArchX64
+	targetDarwinUniversal = "darwin_" + files.DarwinArchUniversal
+)
+
+// Injectable helpers for tests
+var (
+	assetPlatformTarget            = DetectRegistryTarget
+	darwinHostArch                 = detectDarwinHostArch
+	darwinArchPreference           = files.GetDarwinArchPreference
+	rosettaAvailable               = detectRosetta
+	darwinWarningOut     io.Writer = os.Stderr
+	darwinSysctl                   = func(name string) (string, error) {
+		out, err := exec.Command("sysctl", "-in", name).Output()
+		return strings.TrimSpace(string(out)), err
+	}
+	rosettaStat = os.Stat
+)
+
+// detectDarwinHostArch returns the architecture of the Mac (arm64 or x64),
+// also when zana itself is an x86_64 build translated by Rosetta 2
+func detectDarwinHostArch() string {
+	if runtime.GOARCH == "arm64" {
+		return files.DarwinArchArm64
+	}
+	if translated, err := darwinSysctl("sysctl.proc_translated"); err == nil && translated == "1" {
+		return files.DarwinArchArm64
+	}
+	return files.DarwinArchX64
+}
+
+func detectRosetta() bool {
+	_, err := rosettaStat(rosettaRuntimePath)
+	return err == nil
+}
+
+// darwinAssetTargets returns the registry targets release assets are looked
+// up for on macOS, most preferred first. Intel Macs only run x86_64 and
+// universal binaries, Apple Silicon Macs follow GetDarwinArchPreference and
+// skip x86_64 assets when Rosetta 2 is missing.
+func darwinAssetTargets() []string {
+	if darwinHostArch() != files.DarwinArchArm64 {
+		return []string{targetDarwinX64, targetDarwinUniversal}
+	}
+	var targets []string
+	for _, arch := range darwinArchPreference() {
+		if arch == files.DarwinArchX64 && !rosettaAvailable() {
+			continue
+		}
+		targets = append(targets, "darwin_"+arch)
+	}
+	return targets
+}
+
+// assetTargetCandidates returns the registry targets an asset is looked up
+// for on currentTarget, most preferred first
+func assetTargetCandidates(currentTarget string) []string {
+	switch {
+	case strings.HasPrefix(currentTarget, "darwin_"):
+		return darwinAssetTargets()
+	case strings.HasPrefix(currentTarget, "linux_"):
+		// Try fallback: check for linux_x64_gnu if linux_x64 not found
+		return []string{currentTarget, currentTarget + "_gnu"}
+	default:
+		return append([]string{currentTarget}, fallbackTargets(currentTarget)...)
+	}
+}
+
+// matchingTargetIndex returns the index of the target covering the current
+// platform best, or -1 when none does
+func matchingTargetIndex(ctx context.Context, targets []interface{}) int {
+	currentTarget := assetPlatformTarget()
+	for _, candidate := range assetTargetCandidates(currentTarget) {
+		for i, target := range targets {
+			if MatchesTarget(target, candidate) {
+				warnAboutRosetta(ctx, candidate)
+				return i
+			}
+		}
+	}
+	if strings.HasPrefix(currentTarget, "darwin_") {
+		explainMissingRosetta(targets)
+	}
+	return -1
+}
+
+// warnAboutRosetta warns when an x86_64 asset is picked on Apple Silicon
+func warnAboutRosetta(ctx context.Context, target string) {
+	if target != targetDarwinX64 || darwinHostArch() != files.DarwinArchArm64 {
+		return
+	}
+	msg := "installing the x86_64 build, which runs under Rosetta 2"
+	if op, ok := currentOperation(ctx); ok {
+		msg = op.Package + ": " + msg
+	}
+	Logger.WarnContext(ctx, msg)
+	_, _ = fmt.Fprintf(darwinWarningOut, "Warning: %s\n", msg)
+}
+
+// explainMissingRosetta logs why an x86_64 only package can't be installed
+// on an Apple Silicon Mac without Rosetta 2
+func explainMissingRosetta(targets []interface{}) {
+	if darwinHostArch() != files.DarwinArchArm64 || rosettaAvailable() {
+		return
+	}
+	for _, target := range targets {
+		if MatchesTarget(target, targetDarwinX64) {
+			Logger.Error("Only an x86_64 build is available, which needs Rosetta 2: install it with softwareupdate --install-rosetta")
+			return
+		}
+	}
+}
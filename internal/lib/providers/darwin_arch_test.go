@@ -0,0 +1,101 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubDarwin(t *testing.T, hostArch string, rosetta bool, preference []string) *bytes.Buffer {
+	t.Helper()
+	origTarget, origHost, origPref, origRosetta, origOut := assetPlatformTarget, darwinHostArch, darwinArchPreference, rosettaAvailable, darwinWarningOut
+	t.Cleanup(func() {
+		assetPlatformTarget, darwinHostArch, darwinArchPreference, rosettaAvailable, darwinWarningOut = origTarget, origHost, origPref, origRosetta, origOut
+	})
+	out := &bytes.Buffer{}
+	assetPlatformTarget = func() string { return "darwin_" + hostArch }
+	darwinHostArch = func() string { return hostArch }
+	darwinArchPreference = func() []string { return preference }
+	rosettaAvailable = func() bool { return rosetta }
+	darwinWarningOut = out
+	return out
+}
+
+func darwinAssets(t *testing.T, raw string) registry_parser.RegistryItemSourceAssetList {
+	t.Helper()
+	reg := registry_parser.NewRegistryParser(nil)
+	require.NoError(t, reg.LoadFromBytes([]byte(`[{"name": "tool", "source": {"id": "github:owner/tool", "asset": `+raw+`}}]`)))
+	return reg.GetBySourceId("github:owner/tool").Source.Asset
+}
+
+func TestDarwinAssetTargets(t *testing.T) {
+	stubDarwin(t, files.DarwinArchX64, false, files.DefaultDarwinArchPreference)
+	assert.Equal(t, []string{"darwin_x64", "darwin_universal"}, darwinAssetTargets(), "Intel Macs can't run arm64 builds")
+
+	stubDarwin(t, files.DarwinArchArm64, true, files.DefaultDarwinArchPreference)
+	assert.Equal(t, []string{"darwin_arm64", "darwin_universal", "darwin_x64"}, darwinAssetTargets())
+
+	stubDarwin(t, files.DarwinArchArm64, false, files.DefaultDarwinArchPreference)
+	assert.Equal(t, []string{"darwin_arm64", "darwin_universal"}, darwinAssetTargets(), "x86_64 builds need Rosetta 2")
+
+	stubDarwin(t, files.DarwinArchArm64, true, []string{files.DarwinArchUniversal, files.DarwinArchArm64})
+	assert.Equal(t, []string{"darwin_universal", "darwin_arm64"}, darwinAssetTargets())
+}
+
+func TestFindMatchingAssetOnDarwin(t *testing.T) {
+	all := darwinAssets(t, `[
+		{"target": "darwin_x64", "file": "tool-x64.tar.gz"},
+		{"target": "darwin_universal", "file": "tool-universal.tar.gz"},
+		{"target": "darwin_arm64", "file": "tool-arm64.tar.gz"}
+	]`)
+	x64Only := darwinAssets(t, `[{"target": ["darwin_x64", "linux_x64"], "file": "tool-x64.tar.gz"}]`)
+
+	t.Run("prefers native assets on Apple Silicon", func(t *testing.T) {
+		out := stubDarwin(t, files.DarwinArchArm64, true, files.DefaultDarwinArchPreference)
+		assert.Equal(t, "tool-arm64.tar.gz", FindMatchingAsset(context.Background(), all).File.String())
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("follows the preference order", func(t *testing.T) {
+		stubDarwin(t, files.DarwinArchArm64, true, []string{files.DarwinArchUniversal, files.DarwinArchArm64})
+		assert.Equal(t, "tool-universal.tar.gz", FindMatchingAsset(context.Background(), all).File.String())
+	})
+
+	t.Run("falls back to x86_64 with a warning", func(t *testing.T) {
+		out := stubDarwin(t, files.DarwinArchArm64, true, files.DefaultDarwinArchPreference)
+		assert.Equal(t, "tool-x64.tar.gz", FindMatchingAsset(context.Background(), x64Only).File.String())
+		assert.Contains(t, out.String(), "Rosetta 2")
+	})
+
+	t.Run("never picks x86_64 without Rosetta", func(t *testing.T) {
+		stubDarwin(t, files.DarwinArchArm64, false, files.DefaultDarwinArchPreference)
+		assert.Nil(t, FindMatchingAsset(context.Background(), x64Only))
+	})
+
+	t.Run("Intel Macs don't warn", func(t *testing.T) {
+		out := stubDarwin(t, files.DarwinArchX64, false, files.DefaultDarwinArchPreference)
+		assert.Equal(t, "tool-x64.tar.gz", FindMatchingAsset(context.Background(), all).File.String())
+		assert.Empty(t, out.String())
+	})
+}
+
+func TestItemSupportsTargetUnderRosetta(t *testing.T) {
+	item := registry_parser.RegistryItem{Source: registry_parser.RegistryItemSource{ID: "github:owner/tool"}, SupportedPlatforms: []string{"darwin_x64"}}
+	universal := registry_parser.RegistryItem{Source: registry_parser.RegistryItemSource{ID: "github:owner/uni"}, SupportedPlatforms: []string{"darwin_universal"}}
+
+	stubDarwin(t, files.DarwinArchArm64, true, files.DefaultDarwinArchPreference)
+	assert.True(t, itemSupportsTarget(item, "darwin_arm64"))
+	assert.True(t, itemSupportsTarget(universal, "darwin_arm64"))
+	assert.False(t, itemSupportsTarget(universal, "linux_x64"))
+
+	stubDarwin(t, files.DarwinArchArm64, false, files.DefaultDarwinArchPreference)
+	assert.False(t, itemSupportsTarget(item, "darwin_arm64"))
+
+	stubDarwin(t, files.DarwinArchArm64, true, []string{files.DarwinArchArm64})
+	assert.False(t, itemSupportsTarget(item, "darwin_arm64"), "x64 left out of the preference")
+}
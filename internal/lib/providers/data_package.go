@@ -0,0 +1,57 @@
+package providers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+)
+
+// dataSharePath is swapped in tests
+var dataSharePath = files.GetAppSharePath
+
+// DataPackagePath returns the directory the content of a data-only package is installed into
+// e.g. /home/user/.local/share/zana/share/github/owner/fonts
+func DataPackagePath(sourceID string) string {
+	provider, pkg := packageid.Split(sourceID)
+	return filepath.Join(dataSharePath(), provider, filepath.FromSlash(pkg))
+}
+
+// installDataPackage moves the content extracted to extractDir into the
+// package's share directory, replacing a previous install.
+// Archives holding a single top-level directory are unwrapped.
+// extractDir has to be on the same file system as the share directory,
+// which holds for the staging directories next to it.
+func installDataPackage(sourceID, extractDir string) (string, error) {
+	content := extractDir
+	if entries, err := os.ReadDir(extractDir); err == nil && len(entries) == 1 && entries[0].IsDir() {
+		content = filepath.Join(extractDir, entries[0].Name())
+	}
+	dest := DataPackagePath(sourceID)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", fmt.Errorf("failed to create share directory: %w", err)
+	}
+	// Move the previous install aside first, so it can be restored when the move fails
+	old := dest + ".old"
+	_ = os.RemoveAll(old)
+	hadPrevious := os.Rename(dest, old) == nil
+	if err := os.Rename(content, dest); err != nil {
+		if hadPrevious {
+			_ = os.Rename(old, dest)
+		}
+		return "", fmt.Errorf("failed to move content into %s: %w", dest, err)
+	}
+	_ = os.RemoveAll(old)
+	return dest, nil
+}
+
+// removeDataPackage removes the share directory of a data-only package, if any
+func removeDataPackage(sourceID string) error {
+	dest := DataPackagePath(sourceID)
+	if _, err := os.Stat(dest); err != nil {
+		return nil
+	}
+	return os.RemoveAll(dest)
+}
@@ -0,0 +1,55 @@
+package providers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubDataSharePath(t *testing.T) string {
+	t.Helper()
+	share := filepath.Join(t.TempDir(), "share")
+	prev := dataSharePath
+	t.Cleanup(func() { dataSharePath = prev })
+	dataSharePath = func() string { return share }
+	return share
+}
+
+func TestDataPackagePath(t *testing.T) {
+	share := stubDataSharePath(t)
+	assert.Equal(t, filepath.Join(share, "github", "owner", "fonts"), DataPackagePath("github:owner/fonts"))
+	assert.Equal(t, filepath.Join(share, "github", "owner", "fonts"), DataPackagePath("pkg:github/owner/fonts"))
+}
+
+func TestInstallDataPackage(t *testing.T) {
+	share := stubDataSharePath(t)
+
+	extract := func(files map[string]string) string {
+		dir := t.TempDir()
+		for name, content := range files {
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+		}
+		return dir
+	}
+
+	// A single top-level directory is unwrapped
+	dest, err := installDataPackage("github:owner/fonts", extract(map[string]string{"fonts-1.0/Mono.ttf": "v1"}))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(share, "github", "owner", "fonts"), dest)
+	assert.FileExists(t, filepath.Join(dest, "Mono.ttf"))
+
+	// Reinstalling replaces the previous content
+	dest, err = installDataPackage("github:owner/fonts", extract(map[string]string{"Sans.ttf": "v2", "LICENSE": "MIT"}))
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(dest, "Mono.ttf"))
+	assert.FileExists(t, filepath.Join(dest, "Sans.ttf"))
+	assert.NoDirExists(t, dest+".old")
+
+	require.NoError(t, removeDataPackage("github:owner/fonts"))
+	assert.NoDirExists(t, dest)
+	assert.NoError(t, removeDataPackage("github:owner/fonts"), "removing twice is fine")
+}
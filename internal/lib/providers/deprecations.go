@@ -0,0 +1,37 @@
+package providers
+
+import (
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// deprecationRegistryParser is injectable for tests
+var deprecationRegistryParser = registry_parser.NewDefaultRegistryParser
+
+// DeprecatedPackage is an installed package its registry entry marks as deprecated
+type DeprecatedPackage struct {
+	SourceID    string `json:"source_id"`
+	Version     string `json:"version"`
+	Reason      string `json:"reason,omitempty"`
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// FindDeprecatedPackages returns the packages deprecated in the registry, in
+// the order given
+func FindDeprecatedPackages(packages []local_packages_parser.LocalPackageItem) []DeprecatedPackage {
+	registry := deprecationRegistryParser()
+	deprecated := []DeprecatedPackage{}
+	for _, pkg := range packages {
+		d := registry.GetBySourceId(pkg.SourceID).Deprecated
+		if d == nil {
+			continue
+		}
+		deprecated = append(deprecated, DeprecatedPackage{
+			SourceID:    pkg.SourceID,
+			Version:     pkg.Version,
+			Reason:      d.Reason,
+			Replacement: d.Replacement,
+		})
+	}
+	return deprecated
+}
@@ -0,0 +1,34 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDeprecatedPackages(t *testing.T) {
+	orig := deprecationRegistryParser
+	t.Cleanup(func() { deprecationRegistryParser = orig })
+	deprecationRegistryParser = func() *registry_parser.RegistryParser {
+		rp := registry_parser.NewRegistryParser(nil)
+		require.NoError(t, rp.LoadFromBytes([]byte(`[
+			{"name": "tsserver", "source": {"id": "pkg:npm/typescript-language-server"}, "deprecated": {"reason": "renamed", "replacement": "npm:ts-ls"}},
+			{"name": "old", "source": {"id": "pkg:github/owner/old"}, "deprecated": {}},
+			{"name": "prettier", "source": {"id": "pkg:npm/prettier"}}
+		]`)))
+		return rp
+	}
+
+	deprecated := FindDeprecatedPackages([]local_packages_parser.LocalPackageItem{
+		{SourceID: "npm:prettier", Version: "3.0.0"},
+		{SourceID: "npm:typescript-language-server", Version: "4.0.0"},
+		{SourceID: "github:owner/old", Version: "v1.0.0"},
+	})
+	assert.Equal(t, []DeprecatedPackage{
+		{SourceID: "npm:typescript-language-server", Version: "4.0.0", Reason: "renamed", Replacement: "npm:ts-ls"},
+		{SourceID: "github:owner/old", Version: "v1.0.0"},
+	}, deprecated)
+}
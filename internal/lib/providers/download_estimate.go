@@ -0,0 +1,147 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// downloadEstimateTimeout is how long a HEAD request for an asset size waits
+const downloadEstimateTimeout = 5 * time.Second
+
+// downloadEstimateWorkers is how many HEAD requests run at once
+const downloadEstimateWorkers = 8
+
+// Injectable helpers for tests
+var (
+	downloadEstimateClient  = &http.Client{Timeout: downloadEstimateTimeout}
+	downloadEstimateHistory = files.ReadHistory
+)
+
+// PlannedDownload is a package about to be installed or updated to Version
+type PlannedDownload struct {
+	SourceID string
+	Version  string
+}
+
+// DownloadEstimate is what installing or updating a set of packages is
+// expected to download and how long it took before
+type DownloadEstimate struct {
+	// Bytes is the sum of the known download sizes
+	Bytes int64
+	// Unknown lists the packages whose download size is not known, e.g. those
+	// downloaded by npm or cargo
+	Unknown []string
+	// Duration is the sum of the average durations of earlier installs and
+	// updates of the packages, from the history
+	Duration time.Duration
+	// WithoutHistory lists the packages not installed or updated before
+	WithoutHistory []string
+}
+
+// EstimateDownloads estimates the download size of the planned packages,
+// from the registry asset sizes or, when the registry has none, the
+// Content-Length of HEAD requests, and their duration from the history
+func EstimateDownloads(planned []PlannedDownload) DownloadEstimate {
+	sizes := make([]int64, len(planned))
+	known := make([]bool, len(planned))
+	registry := assetCacheRegistryParser()
+	sem := make(chan struct{}, downloadEstimateWorkers)
+	var wg sync.WaitGroup
+	for i, p := range planned {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			sizes[i], known[i] = estimateDownloadSize(registry.GetBySourceId(p.SourceID), p.SourceID, p.Version)
+		}()
+	}
+	wg.Wait()
+
+	var estimate DownloadEstimate
+	for i, p := range planned {
+		if known[i] {
+			estimate.Bytes += sizes[i]
+		} else {
+			estimate.Unknown = append(estimate.Unknown, p.SourceID)
+		}
+	}
+
+	averages := averageDurations()
+	for _, p := range planned {
+		if d, ok := averages[packageid.Normalize(p.SourceID)]; ok {
+			estimate.Duration += d
+		} else {
+			estimate.WithoutHistory = append(estimate.WithoutHistory, p.SourceID)
+		}
+	}
+	return estimate
+}
+
+// estimateDownloadSize returns the download size of the release asset or
+// generic download of a package, if it can be found out
+func estimateDownloadSize(item registry_parser.RegistryItem, sourceID, version string) (int64, bool) {
+	if asset := FindMatchingAsset(context.Background(), item.Source.Asset); asset != nil && asset.Size > 0 {
+		return asset.Size, true
+	}
+	urls := AssetURLsForPackage(sourceID, version)
+	if len(urls) == 0 {
+		return 0, false
+	}
+	var total int64
+	for _, url := range urls {
+		size, ok := headContentLength(url)
+		if !ok {
+			return 0, false
+		}
+		total += size
+	}
+	return total, true
+}
+
+// headContentLength returns the size of the file at url from a HEAD request
+func headContentLength(url string) (int64, bool) {
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return 0, false
+	}
+	resp, err := downloadEstimateClient.Do(req)
+	if err != nil {
+		return 0, false
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.ContentLength < 0 {
+		return 0, false
+	}
+	return resp.ContentLength, true
+}
+
+// averageDurations returns the average duration of the successful installs
+// and updates of each package in the history
+func averageDurations() map[string]time.Duration {
+	entries, err := downloadEstimateHistory()
+	if err != nil {
+		return nil
+	}
+	totals := map[string]time.Duration{}
+	counts := map[string]int{}
+	for _, e := range entries {
+		if !e.Success || (e.Action != files.HistoryActionInstall && e.Action != files.HistoryActionUpdate) {
+			continue
+		}
+		id := packageid.Normalize(e.SourceID)
+		totals[id] += e.Duration()
+		counts[id]++
+	}
+	averages := make(map[string]time.Duration, len(totals))
+	for id, total := range totals {
+		averages[id] = total / time.Duration(counts[id])
+	}
+	return averages
+}
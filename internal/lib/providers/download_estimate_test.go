@@ -0,0 +1,86 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubDownloadEstimate answers HEAD requests with the sizes of the requested
+// URLs and reads the history from entries
+func stubDownloadEstimate(t *testing.T, sizes map[string]int64, entries []files.HistoryEntry) *[]string {
+	t.Helper()
+	prevClient, prevHistory := downloadEstimateClient, downloadEstimateHistory
+	t.Cleanup(func() { downloadEstimateClient, downloadEstimateHistory = prevClient, prevHistory })
+	var mu sync.Mutex
+	requested := []string{}
+	downloadEstimateClient = &http.Client{Transport: completionTransport(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		requested = append(requested, req.Method+" "+req.URL.String())
+		mu.Unlock()
+		size, ok := sizes[req.URL.String()]
+		if !ok {
+			return nil, errors.New("offline")
+		}
+		return &http.Response{StatusCode: http.StatusOK, ContentLength: size, Body: io.NopCloser(strings.NewReader("")), Header: http.Header{}}, nil
+	})}
+	downloadEstimateHistory = func() ([]files.HistoryEntry, error) { return entries, nil }
+	return &requested
+}
+
+func TestEstimateDownloads(t *testing.T) {
+	target := DetectRegistryTarget()
+	withAssetCacheRegistry(t, fmt.Sprintf(`[
+		{"name": "sized", "version": "v1.0.0", "source": {"id": "github:owner/sized", "asset": [{"target": %q, "file": "sized.tar.gz", "size": 1000}]}},
+		{"name": "tool", "version": "v1.2.0", "source": {"id": "github:owner/tool", "asset": [{"target": %q, "file": "tool-{{version}}.tar.gz"}]}},
+		{"name": "gen", "version": "2.0", "source": {"id": "generic:gen", "download": [{"target": %q, "files": {"a.zip": "https://example.com/a-{{version}}.zip", "b.zip": "https://example.com/b-{{version}}.zip"}}]}},
+		{"name": "lib", "version": "1.0.0", "source": {"id": "npm:lib"}}
+	]`, target, target, target))
+	requested := stubDownloadEstimate(t, map[string]int64{
+		"https://github.com/owner/tool/releases/download/v1.3.0/tool-v1.3.0.tar.gz": 200,
+		"https://example.com/a-2.0.zip":                                             30,
+		"https://example.com/b-2.0.zip":                                             4,
+	}, []files.HistoryEntry{
+		{Action: files.HistoryActionInstall, SourceID: "github:owner/tool", DurationMs: 1000, Success: true},
+		{Action: files.HistoryActionUpdate, SourceID: "github:owner/tool", DurationMs: 3000, Success: true},
+		{Action: files.HistoryActionUpdate, SourceID: "github:owner/tool", DurationMs: 60000, Success: false},
+		{Action: files.HistoryActionRemove, SourceID: "npm:lib", DurationMs: 5000, Success: true},
+		{Action: files.HistoryActionInstall, SourceID: "npm:lib", DurationMs: 500, Success: true},
+	})
+
+	estimate := EstimateDownloads([]PlannedDownload{
+		{SourceID: "github:owner/sized"},
+		{SourceID: "github:owner/tool", Version: "v1.3.0"},
+		{SourceID: "generic:gen", Version: "latest"},
+		{SourceID: "npm:lib", Version: "1.0.0"},
+	})
+
+	assert.Equal(t, int64(1234), estimate.Bytes)
+	assert.Equal(t, []string{"npm:lib"}, estimate.Unknown)
+	assert.Equal(t, 2500*time.Millisecond, estimate.Duration)
+	assert.Equal(t, []string{"github:owner/sized", "generic:gen"}, estimate.WithoutHistory)
+	assert.NotContains(t, *requested, "HEAD https://github.com/owner/sized/releases/download/v1.0.0/sized.tar.gz")
+	for _, req := range *requested {
+		assert.True(t, strings.HasPrefix(req, "HEAD "), req)
+	}
+}
+
+func TestEstimateDownloadsUnknownWhenHeadFails(t *testing.T) {
+	target := DetectRegistryTarget()
+	withAssetCacheRegistry(t, fmt.Sprintf(`[
+		{"name": "gen", "version": "2.0", "source": {"id": "generic:gen", "download": [{"target": %q, "files": {"a.zip": "https://example.com/a.zip", "b.zip": "https://example.com/b.zip"}}]}}
+	]`, target))
+	stubDownloadEstimate(t, map[string]int64{"https://example.com/a.zip": 30}, nil)
+
+	estimate := EstimateDownloads([]PlannedDownload{{SourceID: "generic:gen"}})
+	assert.Equal(t, int64(0), estimate.Bytes)
+	assert.Equal(t, []string{"generic:gen"}, estimate.Unknown)
+}
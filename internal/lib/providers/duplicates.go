@@ -0,0 +1,215 @@
+package providers
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// DuplicatePackage is one of the installed packages of a DuplicateTool
+type DuplicatePackage struct {
+	SourceID string   `json:"source_id"`
+	Version  string   `json:"version"`
+	Binaries []string `json:"binaries"`
+	// Wins is true when the shared executables that run are this package's
+	Wins bool `json:"wins"`
+}
+
+// DuplicateBinary is an executable more than one package of a DuplicateTool exposes
+type DuplicateBinary struct {
+	Name string `json:"name"`
+	// Path is the executable that runs when Name is invoked, given the PATH order,
+	// empty when none is on PATH
+	Path string `json:"path,omitempty"`
+	// Owner is the package Path belongs to, empty when it's none of them
+	Owner string `json:"owner,omitempty"`
+}
+
+// DuplicateTool is a tool installed through more than one provider, e.g.
+// cargo:stylua and github:JohnnyMorganz/StyLua
+type DuplicateTool struct {
+	Name     string             `json:"name"`
+	Packages []DuplicatePackage `json:"packages"`
+	Shared   []DuplicateBinary  `json:"shared_binaries"`
+	// Winner is the package whose executables run, empty when none of them does
+	Winner string `json:"winner,omitempty"`
+}
+
+// Redundant returns the packages the winner makes redundant
+func (d DuplicateTool) Redundant() []string {
+	if d.Winner == "" {
+		return nil
+	}
+	var ids []string
+	for _, pkg := range d.Packages {
+		if pkg.SourceID != d.Winner {
+			ids = append(ids, pkg.SourceID)
+		}
+	}
+	return ids
+}
+
+// duplicateKeys returns the keys packages of the same tool share: the registry
+// name and aliases (or the last segment of the package name without a
+// registry entry) and the names of the executables
+func duplicateKeys(sourceID string, item registry_parser.RegistryItem) []string {
+	var keys []string
+	if item.Name != "" {
+		keys = append(keys, "name:"+strings.ToLower(item.Name))
+	} else {
+		_, name := packageid.Split(sourceID)
+		keys = append(keys, "name:"+strings.ToLower(name[strings.LastIndex(name, "/")+1:]))
+	}
+	for _, alias := range item.Aliases {
+		keys = append(keys, "name:"+strings.ToLower(alias))
+	}
+	for bin := range item.Bin {
+		keys = append(keys, "bin:"+bin)
+	}
+	return keys
+}
+
+// FindDuplicateTools groups the installed packages that are the same tool by
+// their registry names, aliases and executables, and returns the groups with
+// packages of more than one provider. For the executables the packages share,
+// the one that runs first on PATH decides which package wins.
+func FindDuplicateTools(packages []local_packages_parser.LocalPackageItem, lookup func(sourceID string) registry_parser.RegistryItem) []DuplicateTool {
+	sorted := append([]local_packages_parser.LocalPackageItem{}, packages...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].SourceID < sorted[j].SourceID })
+
+	// Union-find over the packages, joined by shared keys
+	parent := make([]int, len(sorted))
+	var root func(i int) int
+	root = func(i int) int {
+		if parent[i] != i {
+			parent[i] = root(parent[i])
+		}
+		return parent[i]
+	}
+	items := make([]registry_parser.RegistryItem, len(sorted))
+	firstWithKey := map[string]int{}
+	for i, pkg := range sorted {
+		parent[i] = i
+		items[i] = lookup(pkg.SourceID)
+		for _, key := range duplicateKeys(pkg.SourceID, items[i]) {
+			if j, ok := firstWithKey[key]; ok {
+				parent[root(i)] = root(j)
+			} else {
+				firstWithKey[key] = i
+			}
+		}
+	}
+	groups := map[int][]int{}
+	var roots []int
+	for i := range sorted {
+		r := root(i)
+		if _, ok := groups[r]; !ok {
+			roots = append(roots, r)
+		}
+		groups[r] = append(groups[r], i)
+	}
+
+	var duplicates []DuplicateTool
+	for _, r := range roots {
+		members := groups[r]
+		providersSeen := map[string]bool{}
+		for _, i := range members {
+			provider, _ := packageid.Split(sorted[i].SourceID)
+			providersSeen[provider] = true
+		}
+		if len(providersSeen) < 2 {
+			continue
+		}
+		duplicates = append(duplicates, duplicateTool(sorted, items, members))
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].Name < duplicates[j].Name })
+	return duplicates
+}
+
+// duplicateTool describes the packages at members and which of them wins
+func duplicateTool(packages []local_packages_parser.LocalPackageItem, items []registry_parser.RegistryItem, members []int) DuplicateTool {
+	tool := DuplicateTool{Shared: []DuplicateBinary{}}
+	for _, i := range members {
+		if items[i].Name != "" {
+			tool.Name = items[i].Name
+			break
+		}
+	}
+	if tool.Name == "" {
+		_, name := packageid.Split(packages[members[0]].SourceID)
+		tool.Name = name[strings.LastIndex(name, "/")+1:]
+	}
+	exposedBy := map[string]int{}
+	for _, i := range members {
+		binaries := InstalledBinaries(items[i])
+		for _, bin := range binaries {
+			exposedBy[bin]++
+		}
+		tool.Packages = append(tool.Packages, DuplicatePackage{SourceID: packages[i].SourceID, Version: packages[i].Version, Binaries: binaries})
+	}
+
+	wins := map[string]int{}
+	for _, bin := range sortedKeys(exposedBy) {
+		if exposedBy[bin] < 2 {
+			continue
+		}
+		shared := DuplicateBinary{Name: bin, Path: pathWinner(bin)}
+		if shared.Path != "" {
+			shared.Owner = binaryOwner(shared.Path, tool.Packages)
+		}
+		if shared.Owner != "" {
+			wins[shared.Owner]++
+		}
+		tool.Shared = append(tool.Shared, shared)
+	}
+	for _, pkg := range tool.Packages {
+		if wins[pkg.SourceID] > wins[tool.Winner] {
+			tool.Winner = pkg.SourceID
+		}
+	}
+	for i := range tool.Packages {
+		tool.Packages[i].Wins = tool.Packages[i].SourceID == tool.Winner
+	}
+	return tool
+}
+
+// pathWinner returns the executable that runs when name is invoked, or ""
+func pathWinner(name string) string {
+	for _, dir := range shadowingPathDirs() {
+		if path := executableIn(dir, name); path != "" {
+			return path
+		}
+	}
+	return ""
+}
+
+// binaryOwner returns the package among packages the executable at path
+// belongs to, judging by the bin directory it's in and, with the flat layout
+// all providers share, by the packages directory its symlink points into
+func binaryOwner(path string, packages []DuplicatePackage) string {
+	dir := filepath.Dir(path)
+	for _, pkg := range packages {
+		provider, _ := packageid.Split(pkg.SourceID)
+		if filepath.Clean(files.GetAppBinPathForProvider(provider)) != dir {
+			continue
+		}
+		if files.GetBinLayout() == files.BinLayoutPerProvider || binOwnerFromSymlink(path) == provider {
+			return pkg.SourceID
+		}
+	}
+	return ""
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,115 @@
+package providers
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func duplicatesRegistry() func(string) registry_parser.RegistryItem {
+	items := map[string]registry_parser.RegistryItem{
+		"cargo:stylua":                       {Name: "stylua", Bin: map[string]string{"stylua": "cargo:stylua"}},
+		"github:JohnnyMorganz/StyLua":        {Name: "StyLua", Bin: map[string]string{"stylua": "stylua"}},
+		"npm:prettier":                       {Name: "prettier", Bin: map[string]string{"prettier": "npm:prettier"}},
+		"pypi:black":                         {Name: "black", Aliases: []string{"black-formatter"}, Bin: map[string]string{"black": "pypi:black"}},
+		"golang:example.com/black-formatter": {},
+	}
+	return func(sourceID string) registry_parser.RegistryItem {
+		item := items[sourceID]
+		item.Source.ID = sourceID
+		return item
+	}
+}
+
+func TestFindDuplicateTools(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on executable permission bits")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("ZANA_HOME", t.TempDir())
+	t.Setenv("ZANA_BIN_LAYOUT", files.BinLayoutPerProvider)
+
+	cargoBin, githubBin := files.GetAppBinPathForProvider("cargo"), files.GetAppBinPathForProvider("github")
+	writeExecutable(t, cargoBin, "stylua")
+	githubStylua := writeExecutable(t, githubBin, "stylua")
+	writeExecutable(t, files.GetAppBinPathForProvider("npm"), "prettier")
+
+	packages := []local_packages_parser.LocalPackageItem{
+		{SourceID: "npm:prettier", Version: "3.0.0"},
+		{SourceID: "cargo:stylua", Version: "0.20.0"},
+		{SourceID: "github:JohnnyMorganz/StyLua", Version: "v0.20.0"},
+		{SourceID: "pypi:black", Version: "24.1.0"},
+		{SourceID: "golang:example.com/black-formatter", Version: "v1.0.0"},
+	}
+
+	t.Run("first on PATH wins", func(t *testing.T) {
+		withShadowingPath(t, githubBin, cargoBin)
+		duplicates := FindDuplicateTools(packages, duplicatesRegistry())
+		require.Len(t, duplicates, 2)
+
+		// Correlated by alias, without shared executables
+		black := duplicates[0]
+		assert.Equal(t, "black", black.Name)
+		assert.Len(t, black.Packages, 2)
+		assert.Empty(t, black.Shared)
+		assert.Empty(t, black.Winner)
+		assert.Nil(t, black.Redundant())
+
+		stylua := duplicates[1]
+		assert.Equal(t, "stylua", stylua.Name)
+		assert.Equal(t, []DuplicateBinary{{Name: "stylua", Path: githubStylua, Owner: "github:JohnnyMorganz/StyLua"}}, stylua.Shared)
+		assert.Equal(t, "github:JohnnyMorganz/StyLua", stylua.Winner)
+		assert.Equal(t, []string{"cargo:stylua"}, stylua.Redundant())
+		assert.Equal(t, []string{"stylua"}, stylua.Packages[0].Binaries)
+		assert.False(t, stylua.Packages[0].Wins)
+		assert.True(t, stylua.Packages[1].Wins)
+	})
+
+	t.Run("another program wins", func(t *testing.T) {
+		other := t.TempDir()
+		otherStylua := writeExecutable(t, other, "stylua")
+		withShadowingPath(t, other, cargoBin, githubBin)
+		duplicates := FindDuplicateTools(packages, duplicatesRegistry())
+		require.Len(t, duplicates, 2)
+		assert.Equal(t, []DuplicateBinary{{Name: "stylua", Path: otherStylua}}, duplicates[1].Shared)
+		assert.Empty(t, duplicates[1].Winner)
+	})
+
+	t.Run("single provider", func(t *testing.T) {
+		withShadowingPath(t)
+		assert.Empty(t, FindDuplicateTools(packages[:2], duplicatesRegistry()))
+	})
+}
+
+func TestFindDuplicateToolsFlatLayout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("relies on symlinks")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("ZANA_HOME", t.TempDir())
+	t.Setenv("ZANA_BIN_LAYOUT", "")
+
+	// Both packages link stylua into the shared bin directory, the last install won
+	target := writeExecutable(t, filepath.Join(files.GetAppPackagesPath(), "cargo", "stylua", "bin"), "stylua")
+	binDir := files.GetAppBinPath()
+	require.NoError(t, os.MkdirAll(binDir, 0755))
+	require.NoError(t, os.Symlink(target, filepath.Join(binDir, "stylua")))
+	withShadowingPath(t, binDir)
+
+	duplicates := FindDuplicateTools([]local_packages_parser.LocalPackageItem{
+		{SourceID: "cargo:stylua", Version: "0.20.0"},
+		{SourceID: "github:JohnnyMorganz/StyLua", Version: "v0.20.0"},
+	}, duplicatesRegistry())
+	require.Len(t, duplicates, 1)
+	assert.Equal(t, "cargo:stylua", duplicates[0].Winner)
+	assert.Equal(t, []string{"github:JohnnyMorganz/StyLua"}, duplicates[0].Redundant())
+}
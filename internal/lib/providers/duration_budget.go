@@ -0,0 +1,132 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+)
+
+const (
+	// durationBudgetWindow is how many of the latest successful operations
+	// of a provider and action the budget is computed from
+	durationBudgetWindow = 50
+	// durationBudgetMinSamples is how many operations have to be recorded
+	// before the budget is enforced
+	durationBudgetMinSamples = 5
+	// An operation exceeds its budget when it took durationBudgetFactor times
+	// the p95 and at least durationBudgetMinExcess longer, so that quick
+	// operations don't warn about a few hundred milliseconds
+	durationBudgetFactor    = 3
+	durationBudgetMinExcess = 5 * time.Second
+)
+
+// Injectable helpers for tests
+var (
+	historyRead                  = files.ReadHistory
+	durationWarningOut io.Writer = os.Stderr
+)
+
+// DurationBudget is what the history log considers a usual duration for an
+// action (install or update) of a provider
+type DurationBudget struct {
+	Provider  string `json:"provider"`
+	Action    string `json:"action"`
+	Samples   int    `json:"samples"`
+	AverageMs int64  `json:"average_ms"`
+	P95Ms     int64  `json:"p95_ms"`
+}
+
+// DurationBudgets returns the budgets of all providers and actions with
+// successful installs or updates in history, sorted by provider and action
+func DurationBudgets(history []files.HistoryEntry) []DurationBudget {
+	durations := map[[2]string][]int64{}
+	for _, entry := range history {
+		if !entry.Success || entry.Action == files.HistoryActionRemove {
+			continue
+		}
+		provider, _ := packageid.Split(packageid.Normalize(entry.SourceID))
+		key := [2]string{provider, entry.Action}
+		durations[key] = append(durations[key], entry.DurationMs)
+	}
+	budgets := []DurationBudget{}
+	for key, ms := range durations {
+		if len(ms) > durationBudgetWindow {
+			ms = ms[len(ms)-durationBudgetWindow:]
+		}
+		budgets = append(budgets, newDurationBudget(key[0], key[1], ms))
+	}
+	sort.Slice(budgets, func(i, j int) bool {
+		if budgets[i].Provider != budgets[j].Provider {
+			return budgets[i].Provider < budgets[j].Provider
+		}
+		return budgets[i].Action < budgets[j].Action
+	})
+	return budgets
+}
+
+func newDurationBudget(provider, action string, ms []int64) DurationBudget {
+	sorted := append([]int64(nil), ms...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total int64
+	for _, d := range sorted {
+		total += d
+	}
+	// nearest-rank percentile
+	rank := int(math.Ceil(0.95*float64(len(sorted)))) - 1
+	return DurationBudget{
+		Provider:  provider,
+		Action:    action,
+		Samples:   len(sorted),
+		AverageMs: total / int64(len(sorted)),
+		P95Ms:     sorted[rank],
+	}
+}
+
+// Exceeds returns how many times longer than the average duration took, or
+// 0 when it's within the budget or too few operations were recorded yet
+func (b DurationBudget) Exceeds(duration time.Duration) float64 {
+	if b.Samples < durationBudgetMinSamples || b.AverageMs <= 0 {
+		return 0
+	}
+	p95 := time.Duration(b.P95Ms) * time.Millisecond
+	if duration <= durationBudgetFactor*p95 || duration-p95 < durationBudgetMinExcess {
+		return 0
+	}
+	return math.Round(float64(duration.Milliseconds())/float64(b.AverageMs)*10) / 10
+}
+
+// checkDurationBudget warns when an action of sourceID took much longer than
+// the same action usually takes for its provider, which often hints at
+// network or registry issues. It returns the factor recorded in the history.
+func checkDurationBudget(ctx context.Context, action, sourceID string, duration time.Duration) float64 {
+	history, err := historyRead()
+	if err != nil {
+		return 0
+	}
+	provider, _ := packageid.Split(packageid.Normalize(sourceID))
+	for _, budget := range DurationBudgets(history) {
+		if budget.Provider != provider || budget.Action != action {
+			continue
+		}
+		factor := budget.Exceeds(duration)
+		if factor == 0 {
+			return 0
+		}
+		msg := fmt.Sprintf("%s %s took %.0fx longer than usual (%s, usually up to %s)",
+			provider, action, factor, duration.Round(time.Second), (time.Duration(budget.P95Ms) * time.Millisecond).Round(time.Second))
+		if op, ok := currentOperation(ctx); ok {
+			msg = op.Package + ": " + msg
+		}
+		Logger.WarnContext(ctx, msg)
+		_, _ = fmt.Fprintf(durationWarningOut, "Warning: %s\n", msg)
+		return factor
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func historyOf(action, sourceID string, durationsMs ...int64) []files.HistoryEntry {
+	entries := []files.HistoryEntry{}
+	for _, ms := range durationsMs {
+		entries = append(entries, files.HistoryEntry{Action: action, SourceID: sourceID, DurationMs: ms, Success: true})
+	}
+	return entries
+}
+
+func TestDurationBudgets(t *testing.T) {
+	history := historyOf(files.HistoryActionInstall, "npm:prettier", 1000, 2000, 3000, 4000, 10000)
+	history = append(history, historyOf(files.HistoryActionUpdate, "pkg:npm/eslint", 500)...)
+	history = append(history, files.HistoryEntry{Action: files.HistoryActionInstall, SourceID: "npm:eslint", DurationMs: 99000})
+	history = append(history, historyOf(files.HistoryActionRemove, "npm:eslint", 99000)...)
+	history = append(history, historyOf(files.HistoryActionInstall, "github:owner/tool", 7000)...)
+
+	assert.Equal(t, []DurationBudget{
+		{Provider: "github", Action: "install", Samples: 1, AverageMs: 7000, P95Ms: 7000},
+		{Provider: "npm", Action: "install", Samples: 5, AverageMs: 4000, P95Ms: 10000},
+		{Provider: "npm", Action: "update", Samples: 1, AverageMs: 500, P95Ms: 500},
+	}, DurationBudgets(history), "failed operations and removals don't count")
+
+	ms := make([]int64, 0, durationBudgetWindow+10)
+	for i := 0; i < 10; i++ {
+		ms = append(ms, 600000)
+	}
+	for i := 0; i < durationBudgetWindow; i++ {
+		ms = append(ms, 1000)
+	}
+	budgets := DurationBudgets(historyOf(files.HistoryActionInstall, "npm:prettier", ms...))
+	require.Len(t, budgets, 1)
+	assert.Equal(t, durationBudgetWindow, budgets[0].Samples, "only the latest operations count")
+	assert.Equal(t, int64(1000), budgets[0].P95Ms)
+}
+
+func TestDurationBudgetExceeds(t *testing.T) {
+	budget := DurationBudget{Samples: 5, AverageMs: 2000, P95Ms: 3000}
+	assert.Equal(t, 0.0, budget.Exceeds(9*time.Second), "within 3x the p95")
+	assert.Equal(t, 6.0, budget.Exceeds(12*time.Second))
+
+	quick := DurationBudget{Samples: 5, AverageMs: 100, P95Ms: 200}
+	assert.Equal(t, 0.0, quick.Exceeds(2*time.Second), "a few seconds more don't count")
+
+	budget.Samples = durationBudgetMinSamples - 1
+	assert.Equal(t, 0.0, budget.Exceeds(time.Minute), "too few operations recorded")
+}
+
+func TestInstallWarnsAboutSlowOperations(t *testing.T) {
+	t.Setenv("ZANA_HOME", t.TempDir())
+	for _, entry := range historyOf(files.HistoryActionInstall, "npm:eslint", 2000, 2000, 2000, 2000, 2000) {
+		require.NoError(t, files.AppendHistoryEntry(entry))
+	}
+	var out bytes.Buffer
+	prevOut := durationWarningOut
+	durationWarningOut = &out
+	defer func() { durationWarningOut = prevOut }()
+	SetProviderFactory(&MockProviderFactory{
+		MockNPMProvider: &MockPackageManager{InstallFunc: func(context.Context, string, string) bool { return true }},
+	})
+	defer ResetProviderFactory()
+
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	fake.Step = 12 * time.Second
+	defer clock.Set(fake)()
+
+	assert.True(t, Install("npm:prettier", "3.0.0"))
+
+	assert.Equal(t, "Warning: npm:prettier: npm install took 6x longer than usual (12s, usually up to 2s)\n", out.String())
+	entries, err := files.ReadHistory()
+	require.NoError(t, err)
+	require.Len(t, entries, 6)
+	assert.Equal(t, 6.0, entries[5].SlowFactor)
+}
@@ -0,0 +1,74 @@
+package providers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/mistweaverco/zana-client/internal/lib/chaos"
+	"github.com/mistweaverco/zana-client/internal/lib/events"
+	"github.com/mistweaverco/zana-client/internal/lib/trace"
+)
+
+// operationEvent returns an event of kind carrying the package operation in progress
+func operationEvent(ctx context.Context, kind events.Kind) events.Event {
+	e := events.Event{Kind: kind}
+	if op, ok := currentOperation(ctx); ok {
+		e.OperationID, e.Action, e.Package = op.ID, op.Action, op.Package
+	}
+	return e
+}
+
+// publishState publishes a phase transition of the current operation
+func publishState(ctx context.Context, state string) {
+	if !events.Active() {
+		return
+	}
+	e := operationEvent(ctx, events.KindStateChange)
+	e.State = state
+	events.Publish(e)
+}
+
+// downloadBody publishes the start of a download and returns the response
+// body wrapped to publish the downloaded bytes and to trace the download
+func downloadBody(ctx context.Context, resp *http.Response, url string) io.Reader {
+	body := chaos.Reader(resp.Body, "download of "+url)
+	if trace.Enabled() {
+		span := trace.StartContext(ctx, "download", "url.full", url, "http.response.body.size", strconv.FormatInt(resp.ContentLength, 10))
+		body = &tracedReader{r: body, span: span}
+	}
+	if !events.Active() {
+		return body
+	}
+	publishState(ctx, events.StateDownloading)
+	base := operationEvent(ctx, events.KindProgress)
+	base.URL = url
+	return events.ProgressReader(body, base, resp.ContentLength)
+}
+
+// finishState publishes the final state of the current operation
+func finishState(ctx context.Context, ok bool) {
+	if ok {
+		publishState(ctx, events.StateDone)
+	} else {
+		publishState(ctx, events.StateFailed)
+	}
+}
+
+// tracedReader ends the --trace span of a download once the body is read
+// completely or reading fails
+type tracedReader struct {
+	r    io.Reader
+	span *trace.Span
+}
+
+func (t *tracedReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if err == io.EOF {
+		t.span.End(nil)
+	} else if err != nil {
+		t.span.End(err)
+	}
+	return n, err
+}
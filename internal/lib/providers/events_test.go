@@ -0,0 +1,54 @@
+package providers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperationsPublishStateChanges(t *testing.T) {
+	t.Setenv("ZANA_HOME", t.TempDir())
+	var got []events.Event
+	stop := events.Watch(events.Handlers{
+		OnStateChange: func(e events.Event) { got = append(got, e) },
+		OnProgress:    func(e events.Event) { got = append(got, e) },
+	})
+	defer stop()
+
+	SetProviderFactory(&MockProviderFactory{
+		MockNPMProvider: &MockPackageManager{
+			InstallFunc: func(ctx context.Context, sourceID, version string) bool {
+				resp := &http.Response{Body: io.NopCloser(strings.NewReader("tarball")), ContentLength: 7}
+				_, err := io.Copy(io.Discard, downloadBody(ctx, resp, "https://example.com/p.tgz"))
+				return err == nil && version != "broken"
+			},
+		},
+	})
+	defer ResetProviderFactory()
+
+	Install("npm:prettier", "3.0.0")
+	Install("npm:prettier", "broken")
+
+	var states []string
+	for _, e := range got {
+		assert.Equal(t, "npm:prettier", e.Package)
+		assert.NotEmpty(t, e.OperationID)
+		if e.Kind == events.KindProgress {
+			assert.Equal(t, int64(7), e.BytesDone)
+			assert.Equal(t, int64(7), e.BytesTotal)
+			continue
+		}
+		states = append(states, e.State)
+	}
+	assert.Equal(t, []string{
+		events.StateStarted, events.StateDownloading, events.StateDone,
+		events.StateStarted, events.StateDownloading, events.StateFailed,
+	}, states)
+	require.NotEqual(t, got[0].OperationID, got[len(got)-1].OperationID)
+}
@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,7 +11,6 @@ import (
 	"github.com/mattn/go-isatty"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
-	"github.com/mistweaverco/zana-client/internal/lib/shell_out"
 	"github.com/mistweaverco/zana-client/internal/lib/treesitterdeps"
 	"golang.org/x/mod/semver"
 )
@@ -29,15 +29,15 @@ const (
 var externalTreeSitterQueriesPolicyValue = externalTreeSitterQueriesAsk
 
 var (
-	externalQueriesShellOutCapture = shell_out.ShellOutCapture
-	externalQueriesGitHas          = shell_out.HasCommand
+	externalQueriesShellOutCapture = shellOutCapture
+	externalQueriesGitHas          = hasCommand
 )
 
 // externalQueriesGitRevParse reads HEAD after a successful clone (tests may stub).
 var externalQueriesGitRevParse = defaultExternalQueriesGitRevParse
 
-func defaultExternalQueriesGitRevParse(dir string) (string, error) {
-	code, out, err := externalQueriesShellOutCapture("git", []string{"-C", dir, "rev-parse", "HEAD"}, "", nil)
+func defaultExternalQueriesGitRevParse(ctx context.Context, dir string) (string, error) {
+	code, out, err := externalQueriesShellOutCapture(ctx, "git", []string{"-C", dir, "rev-parse", "HEAD"}, "", nil)
 	if err != nil || code != 0 {
 		return "", fmt.Errorf("git rev-parse HEAD in %q: %w %s", dir, err, strings.TrimSpace(out))
 	}
@@ -121,8 +121,8 @@ func externalQueryNeedsStillRequiringConfirm(sourceID, version string, needs []e
 // cloneExternalQueriesRepo clones repoURL into destDir, optionally checking out a tag/branch/commit.
 // When lockRef is set and lockRepoURL matches repoURL, lockRef is used instead of registry semver/ref.
 // Returns the resolved full commit SHA of HEAD.
-func cloneExternalQueriesRepo(repoURL, destDir, registryRef string, wantSemver bool, lockRepoURL, lockRef string) (string, error) {
-	if !externalQueriesGitHas("git", []string{"--version"}, nil) {
+func cloneExternalQueriesRepo(ctx context.Context, repoURL, destDir, registryRef string, wantSemver bool, lockRepoURL, lockRef string) (string, error) {
+	if !externalQueriesGitHas(ctx, "git", []string{"--version"}, nil) {
 		return "", fmt.Errorf("git not found in PATH (required to clone external tree-sitter query repositories)")
 	}
 	repoURL = strings.TrimSpace(repoURL)
@@ -145,7 +145,7 @@ func cloneExternalQueriesRepo(repoURL, destDir, registryRef string, wantSemver b
 	} else {
 		checkout = strings.TrimSpace(registryRef)
 		if checkout == "" && wantSemver {
-			code, out, err := externalQueriesShellOutCapture("git", []string{"ls-remote", "--tags", repoURL}, "", nil)
+			code, out, err := externalQueriesShellOutCapture(ctx, "git", []string{"ls-remote", "--tags", repoURL}, "", nil)
 			if err != nil || code != 0 {
 				return "", fmt.Errorf("git ls-remote --tags %q: %w %s", repoURL, err, strings.TrimSpace(out))
 			}
@@ -157,31 +157,31 @@ func cloneExternalQueriesRepo(repoURL, destDir, registryRef string, wantSemver b
 		}
 	}
 
-	if err := gitCloneExternalQueryRepo(repoURL, destDir, checkout, parent); err != nil {
+	if err := gitCloneExternalQueryRepo(ctx, repoURL, destDir, checkout, parent); err != nil {
 		return "", err
 	}
-	return externalQueriesGitRevParse(destDir)
+	return externalQueriesGitRevParse(ctx, destDir)
 }
 
-func gitCloneExternalQueryRepo(repoURL, destDir, checkout, parent string) error {
+func gitCloneExternalQueryRepo(ctx context.Context, repoURL, destDir, checkout, parent string) error {
 	args := []string{"clone", "--depth", "1"}
 	if checkout != "" {
 		args = append(args, "-b", checkout)
 	}
 	args = append(args, repoURL, destDir)
-	code, out, err := externalQueriesShellOutCapture("git", args, parent, nil)
+	code, out, err := externalQueriesShellOutCapture(ctx, "git", args, parent, nil)
 	if err == nil && code == 0 {
 		return nil
 	}
 	_ = os.RemoveAll(destDir)
-	code2, out2, err2 := externalQueriesShellOutCapture("git", []string{"clone", repoURL, destDir}, parent, nil)
+	code2, out2, err2 := externalQueriesShellOutCapture(ctx, "git", []string{"clone", repoURL, destDir}, parent, nil)
 	if err2 != nil || code2 != 0 {
 		return fmt.Errorf("git clone %q: %w %s (shallow failed: %v %s)", repoURL, err2, strings.TrimSpace(out2), err, strings.TrimSpace(out))
 	}
 	if strings.TrimSpace(checkout) == "" {
 		return nil
 	}
-	code3, out3, err3 := externalQueriesShellOutCapture("git", []string{"-C", destDir, "checkout", "--detach", checkout}, "", nil)
+	code3, out3, err3 := externalQueriesShellOutCapture(ctx, "git", []string{"-C", destDir, "checkout", "--detach", checkout}, "", nil)
 	if err3 != nil || code3 != 0 {
 		return fmt.Errorf("git checkout %q in %q: %w %s", checkout, destDir, err3, strings.TrimSpace(out3))
 	}
@@ -0,0 +1,35 @@
+package providers
+
+import (
+	"sync"
+
+	"github.com/mistweaverco/zana-client/internal/lib/errcodes"
+)
+
+// failureCodes is a side-channel for the CLI to report why an install
+// failed, as providers only return whether it succeeded.
+// Key format: "<sourceID>"
+var (
+	failureCodes   = map[string]errcodes.Code{}
+	failureCodesMu sync.Mutex
+)
+
+// recordFailureCode remembers why installing sourceID failed. The first code
+// recorded wins, as later failures usually follow from it.
+func recordFailureCode(sourceID string, code errcodes.Code) {
+	failureCodesMu.Lock()
+	defer failureCodesMu.Unlock()
+	if _, ok := failureCodes[sourceID]; !ok {
+		failureCodes[sourceID] = code
+	}
+}
+
+// ConsumeFailureCode returns and forgets the code recorded for sourceID, or
+// "" when none was
+func ConsumeFailureCode(sourceID string) errcodes.Code {
+	failureCodesMu.Lock()
+	defer failureCodesMu.Unlock()
+	code := failureCodes[sourceID]
+	delete(failureCodes, sourceID)
+	return code
+}
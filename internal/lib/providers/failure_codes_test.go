@@ -0,0 +1,15 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/errcodes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailureCodes(t *testing.T) {
+	recordFailureCode("github:owner/tool", errcodes.AssetNotFound)
+	recordFailureCode("github:owner/tool", errcodes.Failed)
+	assert.Equal(t, errcodes.AssetNotFound, ConsumeFailureCode("github:owner/tool"), "the first code wins")
+	assert.Equal(t, errcodes.Code(""), ConsumeFailureCode("github:owner/tool"), "codes are consumed")
+}
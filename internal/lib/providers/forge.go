@@ -0,0 +1,194 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// codebergHost is the forge behind the codeberg provider
+const codebergHost = "codeberg.org"
+
+// Injectable helpers for tests
+var forgeGetenv = os.Getenv
+var forgeHTTPDo = http.DefaultClient.Do
+
+// forge is a code forge speaking the Gitea API: Codeberg or a self-hosted
+// Gitea or Forgejo instance. The codeberg and gitea providers use it for
+// repository, release and API URLs and to authenticate their requests.
+type forge struct {
+	host string
+	// tokenEnvs are the environment variables holding an access token for
+	// host, the first one set wins
+	tokenEnvs []string
+}
+
+// forgeAt returns the forge at host, authenticated with the access token
+// in GITEA_TOKEN_<HOST> (see forgeTokenEnv). codeberg.org also takes its
+// token from CODEBERG_TOKEN.
+func forgeAt(host string) forge {
+	f := forge{host: host, tokenEnvs: []string{forgeTokenEnv(host)}}
+	if strings.EqualFold(host, codebergHost) {
+		f.tokenEnvs = append([]string{"CODEBERG_TOKEN"}, f.tokenEnvs...)
+	}
+	return f
+}
+
+// forgeTokenEnv returns the environment variable holding the access token
+// for the forge at host, e.g. GITEA_TOKEN_GIT_EXAMPLE_COM for git.example.com.
+// Tokens are per host so they're never sent to another instance.
+func forgeTokenEnv(host string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, host)
+	return "GITEA_TOKEN_" + name
+}
+
+func (f forge) baseURL() string {
+	return "https://" + f.host
+}
+
+// repoURL returns the git clone URL of repo (owner/name)
+func (f forge) repoURL(repo string) string {
+	return fmt.Sprintf("%s/%s.git", f.baseURL(), repo)
+}
+
+// releaseAssetURL returns the download URL of the asset fileName of the release tag of repo
+func (f forge) releaseAssetURL(repo, tag, fileName string) string {
+	return fmt.Sprintf("%s/%s/releases/download/%s/%s", f.baseURL(), repo, tag, fileName)
+}
+
+// apiURL returns the URL of the repository API endpoint path of repo,
+// e.g. /releases
+func (f forge) apiURL(repo, path string) string {
+	return fmt.Sprintf("%s/api/v1/repos/%s%s", f.baseURL(), repo, path)
+}
+
+// token returns the access token for the forge, if any
+func (f forge) token() string {
+	for _, env := range f.tokenEnvs {
+		if token := forgeGetenv(env); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// get fetches rawURL, authenticating requests to the forge's own host
+func (f forge) get(rawURL string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if u, err := url.Parse(rawURL); err == nil && strings.EqualFold(u.Host, f.host) {
+		if token := f.token(); token != "" {
+			req.Header.Set("Authorization", "token "+token)
+		}
+	}
+	return forgeHTTPDo(req)
+}
+
+// forgeGet fetches rawURL, authenticated for the forge it's on
+func forgeGet(rawURL string) (*http.Response, error) {
+	host := ""
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Host
+	}
+	return forgeAt(host).get(rawURL)
+}
+
+// latestReleaseTag returns the tag of the most recent release of repo
+func (f forge) latestReleaseTag(repo string) (string, error) {
+	resp, err := f.get(f.apiURL(repo, "/releases?limit=1"))
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch release info: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s API returned status %d", f.host, resp.StatusCode)
+	}
+
+	var releases []struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return "", fmt.Errorf("failed to parse release info: %w", err)
+	}
+
+	if len(releases) == 0 {
+		return "", fmt.Errorf("no releases found")
+	}
+
+	return releases[0].TagName, nil
+}
+
+// releaseAssets returns the names of the assets of the release tagged tag,
+// or errReleaseAssetNotFound when there is no such release
+func (f forge) releaseAssets(repo, tag string) ([]string, error) {
+	resp, err := f.get(f.apiURL(repo, "/releases/tags/"+url.PathEscape(tag)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch release info: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, errReleaseAssetNotFound
+	default:
+		return nil, fmt.Errorf("%s API returned status %d", f.host, resp.StatusCode)
+	}
+	var release struct {
+		Assets []struct {
+			Name string `json:"name"`
+		} `json:"assets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to parse release info: %w", err)
+	}
+	names := make([]string, 0, len(release.Assets))
+	for _, asset := range release.Assets {
+		names = append(names, asset.Name)
+	}
+	return names, nil
+}
+
+// splitForgeRepo splits a gitea package ID like git.example.com/owner/repo
+// into the host of the instance and the repository on it. ok is false when
+// either is missing.
+func splitForgeRepo(id string) (host, repo string, ok bool) {
+	host, repo, _ = strings.Cut(id, "/")
+	owner, name, _ := strings.Cut(repo, "/")
+	if host == "" || owner == "" || name == "" {
+		return "", "", false
+	}
+	return host, repo, true
+}
+
+// forgeFor returns the forge hosting the codeberg or gitea package id and
+// the repository on it. ok is false for other providers and malformed IDs.
+func forgeFor(provider, id string) (f forge, repo string, ok bool) {
+	switch provider {
+	case "codeberg":
+		return forgeAt(codebergHost), id, true
+	case "gitea":
+		host, repo, ok := splitForgeRepo(id)
+		if !ok {
+			return forge{}, "", false
+		}
+		return forgeAt(host), repo, true
+	default:
+		return forge{}, "", false
+	}
+}
@@ -0,0 +1,150 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubForgeHTTP(t *testing.T, env map[string]string, responses map[string][]byte) *[]*http.Request {
+	t.Helper()
+	prevDo, prevGetenv := forgeHTTPDo, forgeGetenv
+	t.Cleanup(func() { forgeHTTPDo, forgeGetenv = prevDo, prevGetenv })
+
+	requests := &[]*http.Request{}
+	forgeGetenv = func(key string) string { return env[key] }
+	forgeHTTPDo = func(req *http.Request) (*http.Response, error) {
+		*requests = append(*requests, req)
+		if body, ok := responses[req.URL.String()]; ok {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+	}
+	return requests
+}
+
+func TestForgeFor(t *testing.T) {
+	f, repo, ok := forgeFor("gitea", "git.example.com/owner/tool")
+	require.True(t, ok)
+	assert.Equal(t, "owner/tool", repo)
+	assert.Equal(t, "https://git.example.com/owner/tool.git", f.repoURL(repo))
+	assert.Equal(t, "https://git.example.com/api/v1/repos/owner/tool/releases", f.apiURL(repo, "/releases"))
+
+	f, repo, ok = forgeFor("codeberg", "owner/tool")
+	require.True(t, ok)
+	assert.Equal(t, "https://codeberg.org/owner/tool.git", f.repoURL(repo))
+
+	for _, id := range []string{"git.example.com/owner", "git.example.com", "/owner/tool"} {
+		_, _, ok := forgeFor("gitea", id)
+		assert.False(t, ok, id)
+	}
+	_, _, ok = forgeFor("github", "owner/tool")
+	assert.False(t, ok)
+
+	assert.Equal(t, "https://git.example.com/owner/tool/releases/download/v1.0.0/tool.tar.gz",
+		releaseAssetURL("gitea", "git.example.com/owner/tool", "v1.0.0", "tool.tar.gz"))
+	assert.Equal(t, "", NewProviderGitea().getRepo("gitea:owner/tool"))
+	assert.Equal(t, "git.example.com/owner/tool", NewProviderGitea().getRepo("gitea:git.example.com/owner/tool"))
+}
+
+func TestForgeGetAuthenticatesOwnHost(t *testing.T) {
+	assert.Equal(t, "GITEA_TOKEN_GIT_EXAMPLE_COM_8443", forgeTokenEnv("git.example.com:8443"))
+
+	requests := stubForgeHTTP(t, map[string]string{
+		"GITEA_TOKEN_GIT_EXAMPLE_COM": "secret",
+		"CODEBERG_TOKEN":              "cb-secret",
+	}, nil)
+
+	for _, url := range []string{
+		"https://git.example.com/owner/tool/releases/download/v1/tool.zip",
+		"https://codeberg.org/owner/tool/releases/download/v1/tool.zip",
+		"https://forgejo.example.org/owner/tool/releases/download/v1/tool.zip",
+	} {
+		resp, err := forgeGet(url)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+	require.Len(t, *requests, 3)
+	assert.Equal(t, "token secret", (*requests)[0].Header.Get("Authorization"))
+	assert.Equal(t, "token cb-secret", (*requests)[1].Header.Get("Authorization"))
+	assert.Empty(t, (*requests)[2].Header.Get("Authorization"))
+
+	// A forge never sends its token to another host
+	resp, err := forgeAt("git.example.com").get("https://objects.example.net/tool.zip")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Empty(t, (*requests)[3].Header.Get("Authorization"))
+}
+
+func TestForgeReleases(t *testing.T) {
+	stubForgeHTTP(t, nil, map[string][]byte{
+		"https://git.example.com/api/v1/repos/owner/tool/releases?limit=1":     []byte(`[{"tag_name": "v2.0.0"}]`),
+		"https://git.example.com/api/v1/repos/owner/tool/releases/tags/v2.0.0": []byte(`{"assets": [{"name": "tool-linux-x64.tar.gz"}, {"name": "checksums.txt"}]}`),
+	})
+	f := forgeAt("git.example.com")
+
+	tag, err := f.latestReleaseTag("owner/tool")
+	require.NoError(t, err)
+	assert.Equal(t, "v2.0.0", tag)
+
+	names, err := f.releaseAssets("owner/tool", "v2.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tool-linux-x64.tar.gz", "checksums.txt"}, names)
+
+	_, err = f.releaseAssets("owner/tool", "v9.9.9")
+	assert.ErrorIs(t, err, errReleaseAssetNotFound)
+}
+
+func TestGiteaInstallMatchesRenamedReleaseAsset(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("ZANA_HOME", t.TempDir())
+	t.Setenv("ZANA_CACHE", t.TempDir())
+	stubDataSharePath(t)
+
+	target := DetectRegistryTarget()
+	renamed := "tool-" + strings.Replace(assetPlatformTarget(), "_", "-", 1) + ".tar.gz"
+	reg := registry_parser.NewRegistryParser(nil)
+	require.NoError(t, reg.LoadFromBytes([]byte(`[
+		{"name": "tool", "version": "v1.0.0", "source": {"id": "gitea:git.example.com/owner/tool", "asset": [{"target": "`+target+`", "file": "tool.tar.gz"}]},
+		 "bin": {"tool": "tool"}}
+	]`)))
+	prevRegistry, prevAdd := codebergRegistryParser, lppCodebergAdd
+	t.Cleanup(func() { codebergRegistryParser, lppCodebergAdd = prevRegistry, prevAdd })
+	codebergRegistryParser = func() *registry_parser.RegistryParser { return reg }
+	added := map[string]string{}
+	lppCodebergAdd = func(sourceID, version string) error {
+		added[sourceID] = version
+		return nil
+	}
+	requests := stubForgeHTTP(t, map[string]string{"GITEA_TOKEN_GIT_EXAMPLE_COM": "secret"}, map[string][]byte{
+		"https://git.example.com/api/v1/repos/owner/tool/releases/tags/v1.0.0":   []byte(`{"assets": [{"name": "` + renamed + `"}]}`),
+		"https://git.example.com/owner/tool/releases/download/v1.0.0/" + renamed: tarGz(t, map[string]string{"tool": "#!/bin/sh\n"}),
+	})
+
+	p := NewProviderGitea()
+	require.True(t, p.Install(context.Background(), "gitea:git.example.com/owner/tool", "v1.0.0"))
+	assert.Equal(t, map[string]string{"gitea:git.example.com/owner/tool": "v1.0.0"}, added)
+	assert.FileExists(t, filepath.Join(p.getRepoPath("git.example.com/owner/tool"), "tool"))
+
+	var urls []string
+	for _, req := range *requests {
+		urls = append(urls, req.URL.String())
+		assert.Equal(t, "token secret", req.Header.Get("Authorization"))
+	}
+	assert.Equal(t, []string{
+		"https://git.example.com/owner/tool/releases/download/v1.0.0/tool.tar.gz",
+		"https://git.example.com/api/v1/repos/owner/tool/releases/tags/v1.0.0",
+		"https://git.example.com/owner/tool/releases/download/v1.0.0/" + renamed,
+		"https://git.example.com/owner/tool/releases/download/v1.0.0/" + renamed + ".sha256",
+		"https://git.example.com/owner/tool/releases/download/v1.0.0/" + renamed + ".sha512",
+	}, urls)
+}
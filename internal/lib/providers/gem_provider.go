@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,8 +10,8 @@ import (
 
 	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
-	"github.com/mistweaverco/zana-client/internal/lib/shell_out"
 )
 
 type GemProvider struct {
@@ -22,9 +23,9 @@ type GemProvider struct {
 var gemCmd = "gem"
 
 // Injectable shell and OS helpers for tests
-var gemShellOut = shell_out.ShellOut
-var gemShellOutCapture = shell_out.ShellOutCapture
-var gemHasCommand = shell_out.HasCommand
+var gemShellOut = shellOut
+var gemShellOutCapture = shellOutCapture
+var gemHasCommand = hasCommand
 var gemCreate = os.Create
 var gemReadDir = os.ReadDir
 var gemLstat = os.Lstat
@@ -52,20 +53,14 @@ func NewProviderGem() *GemProvider {
 
 func (p *GemProvider) getRepo(sourceID string) string {
 	// Support both legacy (pkg:gem/pkg) and new (gem:pkg) formats
-	normalized := normalizePackageID(sourceID)
+	normalized := packageid.Normalize(sourceID)
 	if strings.HasPrefix(normalized, p.PREFIX) {
 		return strings.TrimPrefix(normalized, p.PREFIX)
 	}
-	// Fallback for legacy format
-	re := regexp.MustCompile("^pkg:" + p.PROVIDER_NAME + "/(.*)")
-	matches := re.FindStringSubmatch(sourceID)
-	if len(matches) > 1 {
-		return matches[1]
-	}
 	return ""
 }
 
-func (p *GemProvider) generateGemfile() bool {
+func (p *GemProvider) generateGemfile(ctx context.Context) bool {
 	found := false
 	gemfileContent := make([]string, 0)
 	localPackages := lppGemGetData(true).Packages
@@ -88,7 +83,7 @@ func (p *GemProvider) generateGemfile() bool {
 	filePath := filepath.Join(p.APP_PACKAGES_DIR, "Gemfile")
 	file, err := gemCreate(filePath)
 	if err != nil {
-		Logger.Error(fmt.Sprintf("Error creating Gemfile: %s", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Error creating Gemfile: %s", err))
 		return false
 	}
 	defer func() {
@@ -100,28 +95,28 @@ func (p *GemProvider) generateGemfile() bool {
 	// Write Gemfile content
 	for _, line := range gemfileContent {
 		if _, err := file.WriteString(line + "\n"); err != nil {
-			Logger.Error(fmt.Sprintf("Error writing to Gemfile: %s", err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("Error writing to Gemfile: %s", err))
 			return false
 		}
 	}
 	return true
 }
 
-func (p *GemProvider) Install(sourceID, version string) bool {
+func (p *GemProvider) Install(ctx context.Context, sourceID, version string) bool {
 	gemName := p.getRepo(sourceID)
 	if gemName == "" {
-		Logger.Error("Gem Install: Invalid source ID format")
+		Logger.ErrorContext(ctx, "Gem Install: Invalid source ID format")
 		return false
 	}
 
-	if !gemHasCommand("gem", []string{"--version"}, nil) {
-		Logger.Error("Gem Install: gem command not found. Please install Ruby and RubyGems.")
+	if !gemHasCommand(ctx, "gem", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "Gem Install: gem command not found. Please install Ruby and RubyGems.")
 		return false
 	}
 
 	// Ensure packages directory exists
 	if err := gemMkdir(p.APP_PACKAGES_DIR, 0755); err != nil && !os.IsExist(err) {
-		Logger.Error(fmt.Sprintf("Gem Install: Error creating packages directory: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Gem Install: Error creating packages directory: %v", err))
 		return false
 	}
 
@@ -131,10 +126,10 @@ func (p *GemProvider) Install(sourceID, version string) bool {
 		args = append(args, "--version", version)
 	}
 
-	Logger.Info(fmt.Sprintf("Gem Install: Installing %s@%s", gemName, version))
-	code, err := gemShellOut(gemCmd, args, "", nil)
+	Logger.InfoContext(ctx, fmt.Sprintf("Gem Install: Installing %s@%s", gemName, version))
+	code, err := gemShellOut(ctx, gemCmd, args, "", nil)
 	if err != nil || code != 0 {
-		Logger.Error(fmt.Sprintf("Gem Install: Error installing gem: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Gem Install: Error installing gem: %v", err))
 		return false
 	}
 
@@ -142,7 +137,7 @@ func (p *GemProvider) Install(sourceID, version string) bool {
 	installedVersion := version
 	if installedVersion == "" || installedVersion == "latest" {
 		// Try to get the installed version
-		code, output, err := gemShellOutCapture(gemCmd, []string{"list", gemName, "--install-dir", p.APP_PACKAGES_DIR}, "", nil)
+		code, output, err := gemShellOutCapture(ctx, gemCmd, []string{"list", gemName, "--install-dir", p.APP_PACKAGES_DIR}, "", nil)
 		if err == nil && code == 0 {
 			// Parse output like "gemname (1.2.3)"
 			lines := strings.Split(output, "\n")
@@ -165,87 +160,87 @@ func (p *GemProvider) Install(sourceID, version string) bool {
 
 	// Add to local packages
 	if err := lppGemAdd(sourceID, installedVersion); err != nil {
-		Logger.Error(fmt.Sprintf("Gem Install: Error adding package to local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Gem Install: Error adding package to local packages: %v", err))
 		return false
 	}
 
 	// Generate Gemfile
-	_ = p.generateGemfile()
+	_ = p.generateGemfile(ctx)
 
 	// Create wrappers for binaries
-	if err := p.createWrappers(); err != nil {
-		Logger.Info(fmt.Sprintf("Gem Install: Warning creating wrappers: %v", err))
+	if err := p.createWrappers(ctx); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("Gem Install: Warning creating wrappers: %v", err))
 		// Don't fail installation if wrappers fail
 	}
 
-	Logger.Info(fmt.Sprintf("Gem Install: Successfully installed %s@%s", gemName, installedVersion))
+	Logger.InfoContext(ctx, fmt.Sprintf("Gem Install: Successfully installed %s@%s", gemName, installedVersion))
 	return true
 }
 
-func (p *GemProvider) Remove(sourceID string) bool {
+func (p *GemProvider) Remove(ctx context.Context, sourceID string) bool {
 	gemName := p.getRepo(sourceID)
 	if gemName == "" {
-		Logger.Error("Gem Remove: Invalid source ID format")
+		Logger.ErrorContext(ctx, "Gem Remove: Invalid source ID format")
 		return false
 	}
 
-	if !gemHasCommand("gem", []string{"--version"}, nil) {
-		Logger.Error("Gem Remove: gem command not found. Please install Ruby and RubyGems.")
+	if !gemHasCommand(ctx, "gem", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "Gem Remove: gem command not found. Please install Ruby and RubyGems.")
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("Gem Remove: Removing %s", gemName))
+	Logger.InfoContext(ctx, fmt.Sprintf("Gem Remove: Removing %s", gemName))
 
 	// Remove wrappers
-	if err := p.removeWrappersForGem(gemName); err != nil {
-		Logger.Info(fmt.Sprintf("Gem Remove: Warning removing wrappers: %v", err))
+	if err := p.removeWrappersForGem(ctx, gemName); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("Gem Remove: Warning removing wrappers: %v", err))
 	}
 
 	// Uninstall gem
 	args := []string{"uninstall", gemName, "--install-dir", p.APP_PACKAGES_DIR, "--executables", "--ignore-dependencies"}
-	code, err := gemShellOut(gemCmd, args, "", nil)
+	code, err := gemShellOut(ctx, gemCmd, args, "", nil)
 	if err != nil || code != 0 {
-		Logger.Info(fmt.Sprintf("Gem Remove: Warning uninstalling gem (may not be installed): %v", err))
+		Logger.InfoContext(ctx, fmt.Sprintf("Gem Remove: Warning uninstalling gem (may not be installed): %v", err))
 		// Don't fail if gem is not installed
 	}
 
 	// Remove from local packages
 	if err := lppGemRemove(sourceID); err != nil {
-		Logger.Error(fmt.Sprintf("Gem Remove: Error removing package from local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Gem Remove: Error removing package from local packages: %v", err))
 		return false
 	}
 
 	// Regenerate Gemfile
-	_ = p.generateGemfile()
+	_ = p.generateGemfile(ctx)
 
-	Logger.Info(fmt.Sprintf("Gem Remove: Successfully removed %s", gemName))
+	Logger.InfoContext(ctx, fmt.Sprintf("Gem Remove: Successfully removed %s", gemName))
 	return true
 }
 
-func (p *GemProvider) Update(sourceID string) bool {
+func (p *GemProvider) Update(ctx context.Context, sourceID string) bool {
 	gemName := p.getRepo(sourceID)
 	if gemName == "" {
-		Logger.Error("Gem Update: Invalid source ID format")
+		Logger.ErrorContext(ctx, "Gem Update: Invalid source ID format")
 		return false
 	}
 
-	if !gemHasCommand("gem", []string{"--version"}, nil) {
-		Logger.Error("Gem Update: gem command not found. Please install Ruby and RubyGems.")
+	if !gemHasCommand(ctx, "gem", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "Gem Update: gem command not found. Please install Ruby and RubyGems.")
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("Gem Update: Updating %s", gemName))
+	Logger.InfoContext(ctx, fmt.Sprintf("Gem Update: Updating %s", gemName))
 
 	// Update gem to latest version
 	args := []string{"update", gemName, "--install-dir", p.APP_PACKAGES_DIR, "--no-document", "--no-user-install"}
-	code, err := gemShellOut(gemCmd, args, "", nil)
+	code, err := gemShellOut(ctx, gemCmd, args, "", nil)
 	if err != nil || code != 0 {
-		Logger.Error(fmt.Sprintf("Gem Update: Error updating gem: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Gem Update: Error updating gem: %v", err))
 		return false
 	}
 
 	// Get updated version
-	code, output, err := gemShellOutCapture(gemCmd, []string{"list", gemName, "--install-dir", p.APP_PACKAGES_DIR}, "", nil)
+	code, output, err := gemShellOutCapture(ctx, gemCmd, []string{"list", gemName, "--install-dir", p.APP_PACKAGES_DIR}, "", nil)
 	var updatedVersion string
 	if err == nil && code == 0 {
 		lines := strings.Split(output, "\n")
@@ -267,29 +262,29 @@ func (p *GemProvider) Update(sourceID string) bool {
 	// Update local packages
 	if err := lppGemRemove(sourceID); err == nil {
 		if err := lppGemAdd(sourceID, updatedVersion); err != nil {
-			Logger.Error(fmt.Sprintf("Gem Update: Error updating package in local packages: %v", err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("Gem Update: Error updating package in local packages: %v", err))
 			return false
 		}
 	}
 
 	// Regenerate Gemfile
-	_ = p.generateGemfile()
+	_ = p.generateGemfile(ctx)
 
 	// Recreate wrappers
-	if err := p.createWrappers(); err != nil {
-		Logger.Info(fmt.Sprintf("Gem Update: Warning recreating wrappers: %v", err))
+	if err := p.createWrappers(ctx); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("Gem Update: Warning recreating wrappers: %v", err))
 	}
 
-	Logger.Info(fmt.Sprintf("Gem Update: Successfully updated %s@%s", gemName, updatedVersion))
+	Logger.InfoContext(ctx, fmt.Sprintf("Gem Update: Successfully updated %s@%s", gemName, updatedVersion))
 	return true
 }
 
-func (p *GemProvider) getLatestVersion(packageName string) (string, error) {
-	if !gemHasCommand("gem", []string{"--version"}, nil) {
+func (p *GemProvider) getLatestVersion(ctx context.Context, packageName string) (string, error) {
+	if !gemHasCommand(ctx, "gem", []string{"--version"}, nil) {
 		return "", fmt.Errorf("gem command not found")
 	}
 
-	code, output, err := gemShellOutCapture(gemCmd, []string{"search", "-r", packageName, "--remote"}, "", nil)
+	code, output, err := gemShellOutCapture(ctx, gemCmd, []string{"search", "-r", packageName, "--remote"}, "", nil)
 	if err != nil || code != 0 {
 		return "", fmt.Errorf("failed to search for gem: %v", err)
 	}
@@ -321,31 +316,35 @@ func (p *GemProvider) findGemBinDir() string {
 }
 
 // createWrappers creates wrapper scripts for gem executables
-func (p *GemProvider) createWrappers() error {
+func (p *GemProvider) createWrappers(ctx context.Context) error {
 	// Create wrappers based on zana-registry.json bin attribute
 	desired := lppGemGetDataForProvider("gem").Packages
 	if len(desired) == 0 {
 		return nil
 	}
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	parser := registry_parser.NewDefaultRegistryParser()
 	for _, pkg := range desired {
 		registryItem := parser.GetBySourceId(pkg.SourceID)
 		if len(registryItem.Bin) == 0 {
 			continue
 		}
+		filter := binFilterFor(pkg.SourceID, registryItem)
 		for binName, binCmd := range registryItem.Bin {
+			if !filter.allows(binName) {
+				continue
+			}
 			wrapperPath := filepath.Join(zanaBinDir, binName)
 			// Remove any existing wrapper with the same name to avoid conflicts
 			if _, err := gemLstat(wrapperPath); err == nil {
 				_ = gemRemove(wrapperPath)
 			}
 			if err := p.createGemWrapperForCommand(binCmd, wrapperPath); err != nil {
-				Logger.Error(fmt.Sprintf("Error creating wrapper for %s: %v", binName, err))
+				Logger.ErrorContext(ctx, fmt.Sprintf("Error creating wrapper for %s: %v", binName, err))
 				continue
 			}
 			if err := gemChmod(wrapperPath, 0755); err != nil {
-				Logger.Error(fmt.Sprintf("Error setting executable permissions for %s: %v", binName, err))
+				Logger.ErrorContext(ctx, fmt.Sprintf("Error setting executable permissions for %s: %v", binName, err))
 			}
 		}
 	}
@@ -378,23 +377,15 @@ func (p *GemProvider) createGemWrapperForCommand(commandToExec string, wrapperPa
 		execCmd = filepath.Join(gemBinDir, commandToExec)
 	}
 
-	wrapperContent := fmt.Sprintf(`#!/bin/sh
-# Sets up Ruby/Gem environment for zana-installed packages and runs the target command
-
-# Add the zana gem bin directory to PATH
-export PATH="%s:$PATH"
-
-# Add gem lib directories to RUBYLIB
-export RUBYLIB="%s:$RUBYLIB"
-
-# Execute the command from registry
-exec %s "$@"
-`, gemBinDir, p.APP_PACKAGES_DIR, execCmd)
-
-	if err := gemWriteFile(wrapperPath, []byte(wrapperContent), 0755); err != nil {
-		return err
+	script := wrapperScript{
+		Description: "Sets up the Ruby/Gem environment for zana-installed packages and runs the target command",
+		Env: []wrapperEnv{
+			{Name: "PATH", Value: gemBinDir, Separator: ":"},
+			{Name: "RUBYLIB", Value: p.APP_PACKAGES_DIR, Separator: ":"},
+		},
+		Command: []string{execCmd},
 	}
-	return nil
+	return writeWrapperScript(script, wrapperPath, gemWriteFile)
 }
 
 // findGemExecutable searches for an executable in gem directories
@@ -415,9 +406,9 @@ func (p *GemProvider) findGemExecutable(gemLibDir, execPath string) string {
 }
 
 // removeWrappersForGem removes wrapper scripts for a specific gem
-func (p *GemProvider) removeWrappersForGem(gemName string) error {
+func (p *GemProvider) removeWrappersForGem(ctx context.Context, gemName string) error {
 	desired := lppGemGetDataForProvider("gem").Packages
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	parser := registry_parser.NewDefaultRegistryParser()
 
 	// Find packages that match this gem
@@ -430,7 +421,7 @@ func (p *GemProvider) removeWrappersForGem(gemName string) error {
 			wrapperPath := filepath.Join(zanaBinDir, binName)
 			if _, err := gemLstat(wrapperPath); err == nil {
 				if err := gemRemove(wrapperPath); err != nil {
-					Logger.Info(fmt.Sprintf("Gem: Warning removing wrapper %s: %v", wrapperPath, err))
+					Logger.InfoContext(ctx, fmt.Sprintf("Gem: Warning removing wrapper %s: %v", wrapperPath, err))
 				}
 			}
 		}
@@ -438,8 +429,8 @@ func (p *GemProvider) removeWrappersForGem(gemName string) error {
 	return nil
 }
 
-func (p *GemProvider) Sync() bool {
-	Logger.Info("Gem Sync: Syncing gem packages")
+func (p *GemProvider) Sync(ctx context.Context) bool {
+	Logger.InfoContext(ctx, "Gem Sync: Syncing gem packages")
 	localPackages := lppGemGetDataForProvider(p.PROVIDER_NAME).Packages
 
 	if len(localPackages) == 0 {
@@ -447,29 +438,29 @@ func (p *GemProvider) Sync() bool {
 	}
 
 	// Check for gem command before proceeding
-	if !gemHasCommand("gem", []string{"--version"}, nil) {
-		Logger.Error("Gem Sync: gem command not found. Please install Ruby and RubyGems.")
+	if !gemHasCommand(ctx, "gem", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "Gem Sync: gem command not found. Please install Ruby and RubyGems.")
 		return false
 	}
 
 	// Regenerate Gemfile
-	if !p.generateGemfile() {
-		Logger.Error("Gem Sync: Failed to generate Gemfile")
+	if !p.generateGemfile(ctx) {
+		Logger.ErrorContext(ctx, "Gem Sync: Failed to generate Gemfile")
 		return false
 	}
 
 	// Install all packages using bundle install
 	gemfilePath := filepath.Join(p.APP_PACKAGES_DIR, "Gemfile")
 	if _, err := gemStat(gemfilePath); os.IsNotExist(err) {
-		Logger.Error("Gem Sync: Gemfile not found")
+		Logger.ErrorContext(ctx, "Gem Sync: Gemfile not found")
 		return false
 	}
 
 	// Use bundle install if available, otherwise use gem install for each package
-	if gemHasCommand("bundle", []string{"--version"}, nil) {
-		code, err := gemShellOut("bundle", []string{"install", "--gemfile", gemfilePath, "--path", p.APP_PACKAGES_DIR}, p.APP_PACKAGES_DIR, nil)
+	if gemHasCommand(ctx, "bundle", []string{"--version"}, nil) {
+		code, err := gemShellOut(ctx, "bundle", []string{"install", "--gemfile", gemfilePath, "--path", p.APP_PACKAGES_DIR}, p.APP_PACKAGES_DIR, nil)
 		if err != nil || code != 0 {
-			Logger.Error(fmt.Sprintf("Gem Sync: Error running bundle install: %v", err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("Gem Sync: Error running bundle install: %v", err))
 			return false
 		}
 	} else {
@@ -483,17 +474,17 @@ func (p *GemProvider) Sync() bool {
 			if pkg.Version != "" && pkg.Version != "latest" {
 				args = append(args, "--version", pkg.Version)
 			}
-			code, err := gemShellOut(gemCmd, args, "", nil)
+			code, err := gemShellOut(newOperation(ctx, actionSync, pkg.SourceID), gemCmd, args, "", nil)
 			if err != nil || code != 0 {
-				Logger.Error(fmt.Sprintf("Gem Sync: Error installing %s: %v", gemName, err))
+				Logger.ErrorContext(ctx, fmt.Sprintf("Gem Sync: Error installing %s: %v", gemName, err))
 				return false
 			}
 		}
 	}
 
 	// Recreate wrappers
-	if err := p.createWrappers(); err != nil {
-		Logger.Info(fmt.Sprintf("Gem Sync: Warning creating wrappers: %v", err))
+	if err := p.createWrappers(ctx); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("Gem Sync: Warning creating wrappers: %v", err))
 	}
 
 	return true
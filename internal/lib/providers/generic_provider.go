@@ -1,18 +1,21 @@
 package providers
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
+	"github.com/mistweaverco/zana-client/internal/lib/errcodes"
+	"github.com/mistweaverco/zana-client/internal/lib/events"
 	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
-	"github.com/mistweaverco/zana-client/internal/lib/shell_out"
+	"github.com/mistweaverco/zana-client/internal/lib/trace"
 )
 
 type GenericProvider struct {
@@ -22,7 +25,7 @@ type GenericProvider struct {
 }
 
 // Injectable shell and OS helpers for tests
-var genericShellOut = shell_out.ShellOut
+var genericShellOut = shellOut
 var genericStat = os.Stat
 var genericMkdirAll = os.MkdirAll
 var genericLstat = os.Lstat
@@ -53,23 +56,17 @@ func NewProviderGeneric() *GenericProvider {
 
 func (p *GenericProvider) getRepo(sourceID string) string {
 	// Support both legacy (pkg:generic/pkg) and new (generic:pkg) formats
-	normalized := normalizePackageID(sourceID)
+	normalized := packageid.Normalize(sourceID)
 	if strings.HasPrefix(normalized, p.PREFIX) {
 		return strings.TrimPrefix(normalized, p.PREFIX)
 	}
-	// Fallback for legacy format
-	re := regexp.MustCompile("^pkg:" + p.PROVIDER_NAME + "/(.*)")
-	matches := re.FindStringSubmatch(sourceID)
-	if len(matches) > 1 {
-		return matches[1]
-	}
 	return ""
 }
 
-func (p *GenericProvider) Install(sourceID, version string) bool {
+func (p *GenericProvider) Install(ctx context.Context, sourceID, version string) bool {
 	packageName := p.getRepo(sourceID)
 	if packageName == "" {
-		Logger.Error("Generic Install: Invalid source ID format")
+		Logger.ErrorContext(ctx, "Generic Install: Invalid source ID format")
 		return false
 	}
 
@@ -78,14 +75,15 @@ func (p *GenericProvider) Install(sourceID, version string) bool {
 	registryItem := registry.GetBySourceId(sourceID)
 
 	if len(registryItem.Source.Download) == 0 {
-		Logger.Error("Generic Install: No download information found in registry")
+		Logger.ErrorContext(ctx, "Generic Install: No download information found in registry")
 		return false
 	}
 
 	// Find matching download for current platform
-	download := p.findMatchingDownload(registryItem.Source.Download)
+	download := p.findMatchingDownload(ctx, registryItem.Source.Download)
 	if download == nil {
-		Logger.Error("Generic Install: No matching download found for current platform")
+		Logger.ErrorContext(ctx, "Generic Install: No matching download found for current platform")
+		recordFailureCode(sourceID, errcodes.AssetNotFound)
 		return false
 	}
 
@@ -100,21 +98,24 @@ func (p *GenericProvider) Install(sourceID, version string) bool {
 
 	// Ensure packages directory exists
 	if err := genericMkdirAll(p.APP_PACKAGES_DIR, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("Generic Install: Error creating packages directory: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Generic Install: Error creating packages directory: %v", err))
+		recordFailureCode(sourceID, errcodes.Classify(err))
 		return false
 	}
 
-	// Create package directory
+	// Create the directory of the version, which replaces the installed one
+	// once it's complete
 	packageDir := filepath.Join(p.APP_PACKAGES_DIR, packageName)
-	if err := genericMkdirAll(packageDir, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("Generic Install: Error creating package directory: %v", err))
+	installDir, err := newVersionDir(packageDir, resolvedVersion, genericMkdirAll)
+	if err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Generic Install: Error creating package directory: %v", err))
 		return false
 	}
 
 	// Download and extract files
-	extractDir := filepath.Join(packageDir, "extracted")
+	extractDir := filepath.Join(installDir, "extracted")
 	if err := genericMkdirAll(extractDir, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("Generic Install: Error creating extract directory: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Generic Install: Error creating extract directory: %v", err))
 		return false
 	}
 
@@ -123,12 +124,13 @@ func (p *GenericProvider) Install(sourceID, version string) bool {
 		// Resolve template variables in URL
 		resolvedURL := ResolveTemplate(url, resolvedVersion)
 
-		Logger.Info(fmt.Sprintf("Generic Install: Downloading %s from %s", filename, resolvedURL))
+		Logger.InfoContext(ctx, fmt.Sprintf("Generic Install: Downloading %s from %s", filename, resolvedURL))
 
 		// Download file
 		filePath := filepath.Join(extractDir, filename)
-		if err := p.downloadFile(resolvedURL, filePath); err != nil {
-			Logger.Error(fmt.Sprintf("Generic Install: Error downloading %s: %v", filename, err))
+		if err := p.downloadFile(ctx, resolvedURL, filePath); err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf("Generic Install: Error downloading %s: %v", filename, err))
+			recordFailureCode(sourceID, errcodes.Classify(err))
 			return false
 		}
 
@@ -136,12 +138,18 @@ func (p *GenericProvider) Install(sourceID, version string) bool {
 		if strings.HasSuffix(filename, ".zip") || strings.HasSuffix(filename, ".tar.gz") || strings.HasSuffix(filename, ".tar") {
 			extractSubDir := filepath.Join(extractDir, strings.TrimSuffix(filename, filepath.Ext(filename)))
 			if err := genericMkdirAll(extractSubDir, 0755); err != nil {
-				Logger.Error(fmt.Sprintf("Generic Install: Error creating extract subdirectory: %v", err))
+				Logger.ErrorContext(ctx, fmt.Sprintf("Generic Install: Error creating extract subdirectory: %v", err))
 				return false
 			}
 
-			if err := p.extractArchive(filePath, extractSubDir); err != nil {
-				Logger.Error(fmt.Sprintf("Generic Install: Error extracting %s: %v", filename, err))
+			if err := extractOrRedownload(ctx, resolvedURL, filePath, extractSubDir, p.extractArchive, p.downloadFile); err != nil {
+				Logger.ErrorContext(ctx, fmt.Sprintf("Generic Install: Error extracting %s: %v", filename, err))
+				return false
+			}
+			chaosPartialExtraction(extractSubDir)
+			// Bin paths are relative to extractDir, so only a declared strip_components applies
+			if err := stripArchivePrefix(extractSubDir, download.StripComponents, nil); err != nil {
+				Logger.ErrorContext(ctx, fmt.Sprintf("Generic Install: Error extracting %s: %v", filename, err))
 				return false
 			}
 
@@ -150,66 +158,76 @@ func (p *GenericProvider) Install(sourceID, version string) bool {
 		}
 	}
 
+	if err := activateVersion(sourceID, packageDir, resolvedVersion, installDir); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Generic Install: Error moving %s into place: %v", resolvedVersion, err))
+		return false
+	}
+
 	// Create symlinks
-	if err := p.createSymlinksFromRegistry(packageName, extractDir, download, registryItem); err != nil {
-		Logger.Info(fmt.Sprintf("Generic Install: Warning creating symlinks: %v", err))
+	if err := p.createSymlinksFromRegistry(ctx, packageName, filepath.Join(packageDir, "extracted"), download, registryItem); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("Generic Install: Warning creating symlinks: %v", err))
 	}
 
 	// Add to local packages
 	if err := lppGenericAdd(sourceID, resolvedVersion); err != nil {
-		Logger.Error(fmt.Sprintf("Generic Install: Error adding package to local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Generic Install: Error adding package to local packages: %v", err))
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("Generic Install: Successfully installed %s@%s", packageName, resolvedVersion))
+	collectVersions(ctx, packageDir)
+	Logger.InfoContext(ctx, fmt.Sprintf("Generic Install: Successfully installed %s@%s", packageName, resolvedVersion))
 	return true
 }
 
-func (p *GenericProvider) Remove(sourceID string) bool {
+func (p *GenericProvider) Remove(ctx context.Context, sourceID string) bool {
 	packageName := p.getRepo(sourceID)
 	if packageName == "" {
-		Logger.Error("Generic Remove: Invalid source ID format")
+		Logger.ErrorContext(ctx, "Generic Remove: Invalid source ID format")
 		return false
 	}
 
 	// Remove Neovim tree-sitter parser(s) if this package installed them.
 	registry := genericRegistryParser()
 	registryItem := registry.GetBySourceId(sourceID)
-	if err := removeNeovimTreeSitterParsers(registryItem); err != nil {
-		Logger.Info(fmt.Sprintf("Generic Remove: Warning removing Neovim tree-sitter parsers: %v", err))
+	if err := removeNeovimTreeSitterParsers(ctx, registryItem); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("Generic Remove: Warning removing Neovim tree-sitter parsers: %v", err))
 	}
 
-	Logger.Info(fmt.Sprintf("Generic Remove: Removing %s", packageName))
+	Logger.InfoContext(ctx, fmt.Sprintf("Generic Remove: Removing %s", packageName))
 
 	packageDir := filepath.Join(p.APP_PACKAGES_DIR, packageName)
 
 	// Remove symlinks
-	if err := p.removeSymlinks(packageName); err != nil {
-		Logger.Info(fmt.Sprintf("Generic Remove: Warning removing symlinks: %v", err))
+	if err := p.removeSymlinks(ctx, packageName); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("Generic Remove: Warning removing symlinks: %v", err))
 	}
 
 	// Remove package directory
 	if _, err := genericStat(packageDir); err == nil {
 		if err := genericRemoveAll(packageDir); err != nil {
-			Logger.Error(fmt.Sprintf("Generic Remove: Error removing package directory: %v", err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("Generic Remove: Error removing package directory: %v", err))
 			return false
 		}
 	}
+	if err := removeVersions(packageDir); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Generic Remove: Error removing older versions: %v", err))
+		return false
+	}
 
 	// Remove from local packages
 	if err := lppGenericRemove(sourceID); err != nil {
-		Logger.Error(fmt.Sprintf("Generic Remove: Error removing package from local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Generic Remove: Error removing package from local packages: %v", err))
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("Generic Remove: Successfully removed %s", packageName))
+	Logger.InfoContext(ctx, fmt.Sprintf("Generic Remove: Successfully removed %s", packageName))
 	return true
 }
 
-func (p *GenericProvider) Update(sourceID string) bool {
+func (p *GenericProvider) Update(ctx context.Context, sourceID string) bool {
 	packageName := p.getRepo(sourceID)
 	if packageName == "" {
-		Logger.Error("Generic Update: Invalid source ID format")
+		Logger.ErrorContext(ctx, "Generic Update: Invalid source ID format")
 		return false
 	}
 
@@ -219,15 +237,15 @@ func (p *GenericProvider) Update(sourceID string) bool {
 	latestVersion := registryItem.Version
 
 	if latestVersion == "" {
-		Logger.Error("Generic Update: No version information in registry")
+		Logger.ErrorContext(ctx, "Generic Update: No version information in registry")
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("Generic Update: Updating %s to version %s", packageName, latestVersion))
-	return p.Install(sourceID, latestVersion)
+	Logger.InfoContext(ctx, fmt.Sprintf("Generic Update: Updating %s to version %s", packageName, latestVersion))
+	return p.Install(ctx, sourceID, latestVersion)
 }
 
-func (p *GenericProvider) getLatestVersion(packageName string) (string, error) {
+func (p *GenericProvider) getLatestVersion(_ context.Context, packageName string) (string, error) {
 	// Generic provider gets version from registry, not from API
 	registry := genericRegistryParser()
 	registryItem := registry.GetBySourceId(p.PREFIX + packageName)
@@ -238,36 +256,32 @@ func (p *GenericProvider) getLatestVersion(packageName string) (string, error) {
 }
 
 // findMatchingDownload finds the download entry that matches the current platform
-func (p *GenericProvider) findMatchingDownload(downloads registry_parser.RegistryItemSourceDownloadList) *registry_parser.RegistryItemSourceDownloadFile {
-	currentTarget := DetectRegistryTarget()
-
+func (p *GenericProvider) findMatchingDownload(ctx context.Context, downloads registry_parser.RegistryItemSourceDownloadList) *registry_parser.RegistryItemSourceDownloadFile {
+	targets := make([]interface{}, len(downloads))
 	for i := range downloads {
-		if MatchesTarget(downloads[i].Target, currentTarget) {
-			return &downloads[i]
-		}
+		targets[i] = downloads[i].Target
 	}
-
-	// Try fallback: check for linux_x64_gnu if linux_x64 not found
-	if strings.HasPrefix(currentTarget, "linux_") {
-		fallbackTarget := currentTarget + "_gnu"
-		for i := range downloads {
-			if MatchesTarget(downloads[i].Target, fallbackTarget) {
-				return &downloads[i]
-			}
-		}
+	if i := matchingTargetIndex(ctx, targets); i >= 0 {
+		return &downloads[i]
 	}
-
 	return nil
 }
 
 // downloadFile downloads a file from a URL to a destination path
-func (p *GenericProvider) downloadFile(url, destPath string) error {
+func (p *GenericProvider) downloadFile(ctx context.Context, url, destPath string) error {
+	if sum, ok := restoreCachedAsset(url, destPath); ok {
+		return pinAsset(ctx, url, sum)
+	}
+
 	resp, err := genericHTTPGet(url)
 	if err != nil {
 		return fmt.Errorf("failed to download: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return errcodes.New(errcodes.AssetNotFound, fmt.Errorf("HTTP error: %d", resp.StatusCode))
+	}
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("HTTP error: %d", resp.StatusCode)
 	}
@@ -278,21 +292,27 @@ func (p *GenericProvider) downloadFile(url, destPath string) error {
 	}
 	defer func() { _ = file.Close() }()
 
-	if _, err := io.Copy(file, resp.Body); err != nil {
+	sum, err := hashCopy(file, downloadBody(ctx, resp, url))
+	if err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
-
+	if err := pinAsset(ctx, url, sum); err != nil {
+		return err
+	}
 	return nil
 }
 
 // extractArchive extracts an archive (tar.gz, zip, etc.) to a destination directory
-func (p *GenericProvider) extractArchive(archivePath, destDir string) error {
+func (p *GenericProvider) extractArchive(ctx context.Context, archivePath, destDir string) (err error) {
+	publishState(ctx, events.StateExtracting)
+	span := trace.StartContext(ctx, "extract", "file.path", archivePath)
+	defer func() { span.End(err) }()
 	ext := filepath.Ext(archivePath)
 	baseExt := filepath.Ext(strings.TrimSuffix(archivePath, ext))
 
 	if baseExt == ".tar" && ext == ".gz" {
 		// Extract tar.gz
-		code, err := genericShellOut("tar", []string{"-xzf", archivePath, "-C", destDir}, "", nil)
+		code, err := genericShellOut(ctx, "tar", []string{"-xzf", archivePath, "-C", destDir}, "", nil)
 		if err != nil || code != 0 {
 			return fmt.Errorf("failed to extract tar.gz: %v", err)
 		}
@@ -306,7 +326,7 @@ func (p *GenericProvider) extractArchive(archivePath, destDir string) error {
 	} else if ext == ".gz" && baseExt != ".tar" {
 		// Single .gz file - gunzip and copy
 		outputPath := filepath.Join(destDir, strings.TrimSuffix(filepath.Base(archivePath), ".gz"))
-		code, err := genericShellOut("sh", []string{"-c", fmt.Sprintf("gunzip -c %s > %s", archivePath, outputPath)}, "", nil)
+		code, err := genericShellOut(ctx, "sh", []string{"-c", fmt.Sprintf("gunzip -c %s > %s", archivePath, outputPath)}, "", nil)
 		if err != nil || code != 0 {
 			return fmt.Errorf("failed to extract gz: %v", err)
 		}
@@ -336,10 +356,14 @@ func (p *GenericProvider) extractArchive(archivePath, destDir string) error {
 }
 
 // createSymlinksFromRegistry creates symlinks based on registry bin configuration
-func (p *GenericProvider) createSymlinksFromRegistry(packageName, extractDir string, download *registry_parser.RegistryItemSourceDownloadFile, registryItem registry_parser.RegistryItem) error {
-	zanaBinDir := files.GetAppBinPath()
+func (p *GenericProvider) createSymlinksFromRegistry(ctx context.Context, packageName, extractDir string, download *registry_parser.RegistryItemSourceDownloadFile, registryItem registry_parser.RegistryItem) error {
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
+	filter := binFilterFor(registryItem.Source.ID, registryItem)
 
 	for binName, binTemplate := range registryItem.Bin {
+		if !filter.allows(binName) {
+			continue
+		}
 		// Resolve bin path template (e.g., "{{source.download.bin}}")
 		binPath := binTemplate
 		if strings.Contains(binPath, "{{source.download.bin}}") {
@@ -378,9 +402,9 @@ func (p *GenericProvider) createSymlinksFromRegistry(packageName, extractDir str
 		}
 
 		if err := genericSymlink(relPath, symlink); err != nil {
-			Logger.Info(fmt.Sprintf("Generic: Warning creating symlink %s -> %s: %v", symlink, relPath, err))
+			Logger.InfoContext(ctx, fmt.Sprintf("Generic: Warning creating symlink %s -> %s: %v", symlink, relPath, err))
 		} else {
-			Logger.Info(fmt.Sprintf("Generic: Created symlink %s -> %s", symlink, relPath))
+			Logger.InfoContext(ctx, fmt.Sprintf("Generic: Created symlink %s -> %s", symlink, relPath))
 		}
 	}
 
@@ -409,8 +433,8 @@ func (p *GenericProvider) findBinaryInDir(dir, name string) string {
 }
 
 // removeSymlinks removes symlinks for a specific package
-func (p *GenericProvider) removeSymlinks(packageName string) error {
-	zanaBinDir := files.GetAppBinPath()
+func (p *GenericProvider) removeSymlinks(ctx context.Context, packageName string) error {
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	packageDir := filepath.Join(p.APP_PACKAGES_DIR, packageName)
 
 	entries, err := genericReadDir(zanaBinDir)
@@ -431,7 +455,7 @@ func (p *GenericProvider) removeSymlinks(packageName string) error {
 				}
 				if strings.HasPrefix(target, packageDir) {
 					if err := genericRemove(symlink); err != nil {
-						Logger.Info(fmt.Sprintf("Generic: Warning removing symlink %s: %v", symlink, err))
+						Logger.InfoContext(ctx, fmt.Sprintf("Generic: Warning removing symlink %s: %v", symlink, err))
 					}
 				}
 			}
@@ -441,8 +465,8 @@ func (p *GenericProvider) removeSymlinks(packageName string) error {
 	return nil
 }
 
-func (p *GenericProvider) Sync() bool {
-	Logger.Info("Generic Sync: Syncing generic packages")
+func (p *GenericProvider) Sync(ctx context.Context) bool {
+	Logger.InfoContext(ctx, "Generic Sync: Syncing generic packages")
 	localPackages := lppGenericGetDataForProvider(p.PROVIDER_NAME).Packages
 
 	allOk := true
@@ -454,8 +478,8 @@ func (p *GenericProvider) Sync() bool {
 		packageDir := filepath.Join(p.APP_PACKAGES_DIR, packageName)
 		if _, err := genericStat(packageDir); os.IsNotExist(err) {
 			// Re-install missing packages
-			Logger.Info(fmt.Sprintf("Generic Sync: Re-installing missing package %s", packageName))
-			if !p.Install(pkg.SourceID, pkg.Version) {
+			Logger.InfoContext(ctx, fmt.Sprintf("Generic Sync: Re-installing missing package %s", packageName))
+			if !p.Install(newOperation(ctx, actionSync, pkg.SourceID), pkg.SourceID, pkg.Version) {
 				allOk = false
 			}
 		}
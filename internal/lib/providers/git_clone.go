@@ -0,0 +1,150 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// gitClone wraps the git commands used to keep an existing clone of a
+// git based package (GitHub, GitLab, Codeberg) up to date.
+// Reusing the clone means updates only transfer new objects instead of
+// re-cloning the whole repository.
+type gitClone struct {
+	// ctx carries the package operation the git commands run for
+	ctx             context.Context
+	path            string
+	shellOut        func(ctx context.Context, command string, args []string, dir string, env []string) (int, error)
+	shellOutCapture func(ctx context.Context, command string, args []string, dir string, env []string) (int, string, error)
+}
+
+var gitCommitPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+func (g gitClone) run(args ...string) error {
+	code, err := g.shellOut(g.ctx, "git", args, g.path, nil)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return fmt.Errorf("git %s exited with code %d", strings.Join(args, " "), code)
+	}
+	return nil
+}
+
+func (g gitClone) capture(args ...string) (string, bool) {
+	code, output, err := g.shellOutCapture(g.ctx, "git", args, g.path, nil)
+	if err != nil || code != 0 {
+		return "", false
+	}
+	return strings.TrimSpace(output), true
+}
+
+// head returns the commit currently checked out, or "" when it can't be determined
+func (g gitClone) head() string {
+	commit, _ := g.capture("rev-parse", "HEAD")
+	return commit
+}
+
+func (g gitClone) hasRef(ref string) bool {
+	_, ok := g.capture("rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	return ok
+}
+
+func (g gitClone) isRemoteBranch(name string) bool {
+	_, ok := g.capture("show-ref", "--verify", "--quiet", "refs/remotes/origin/"+name)
+	return ok
+}
+
+// fetchAll fetches all branches and tags, pruning remote-tracking
+// branches that no longer exist on the remote.
+func (g gitClone) fetchAll() error {
+	return g.run("fetch", "--prune", "--tags", "origin")
+}
+
+// fetchFor fetches only what is needed to check out version:
+// nothing when it is a tag or commit already in the clone,
+// only the branch itself when it is a known remote branch,
+// and everything otherwise.
+func (g gitClone) fetchFor(version string) error {
+	if version != "" && version != "latest" {
+		if g.hasRef("refs/tags/"+version) || (gitCommitPattern.MatchString(version) && g.hasRef(version)) {
+			return nil
+		}
+		if g.isRemoteBranch(version) {
+			return g.run("fetch", "--prune", "origin", version)
+		}
+	}
+	return g.fetchAll()
+}
+
+// checkout checks out version. Branches are reset to their remote
+// counterpart so they move forward with the remote.
+func (g gitClone) checkout(version string) error {
+	if g.isRemoteBranch(version) {
+		return g.run("checkout", "-B", version, "origin/"+version)
+	}
+	return g.run("checkout", version)
+}
+
+// defaultBranch returns the remote's default branch, falling back to the
+// common branch names and finally main
+func (g gitClone) defaultBranch() string {
+	if ref, ok := g.capture("symbolic-ref", "refs/remotes/origin/HEAD"); ok && strings.HasPrefix(ref, "refs/remotes/origin/") {
+		return strings.TrimPrefix(ref, "refs/remotes/origin/")
+	}
+	for _, branch := range []string{"main", "master", "trunk"} {
+		if g.isRemoteBranch(branch) {
+			return branch
+		}
+	}
+	return "main"
+}
+
+// latestTag returns the most recent tag reachable from the remote default
+// branch, falling back to the tags reachable from HEAD.
+func (g gitClone) latestTag() (string, error) {
+	for _, rev := range []string{"refs/remotes/origin/HEAD", "HEAD"} {
+		if tag, ok := g.capture("describe", "--tags", "--abbrev=0", rev); ok && tag != "" {
+			return tag, nil
+		}
+	}
+	return "", fmt.Errorf("no tags found")
+}
+
+// GitCommitRange is the commit range a git package moved across during an update
+type GitCommitRange struct {
+	From string
+	To   string
+}
+
+// Changed reports whether the update moved the clone to another commit
+func (r GitCommitRange) Changed() bool {
+	return r.From != r.To
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// String renders the range as "old..new" using abbreviated commits
+func (r GitCommitRange) String() string {
+	if !r.Changed() {
+		return shortCommit(r.To)
+	}
+	return shortCommit(r.From) + ".." + shortCommit(r.To)
+}
+
+// recordGitCommitRange records on the package operation ctx carries which
+// commits the existing clone of its package moved across
+func recordGitCommitRange(ctx context.Context, from, to string) {
+	if from == "" || to == "" {
+		return
+	}
+	if r := resultOf(ctx); r != nil {
+		r.commitRange.Store(&GitCommitRange{From: from, To: to})
+	}
+}
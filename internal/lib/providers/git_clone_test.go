@@ -0,0 +1,131 @@
+package providers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGitClone returns a gitClone recording the git commands it runs.
+// refs lists the refs "rev-parse --verify" and "show-ref --verify" succeed for.
+func fakeGitClone(refs ...string) (gitClone, *[]string) {
+	var ran []string
+	known := func(ref string) bool {
+		ref = strings.TrimSuffix(ref, "^{commit}")
+		for _, r := range refs {
+			if r == ref {
+				return true
+			}
+		}
+		return false
+	}
+	return gitClone{
+		path: "/repo",
+		shellOut: func(_ context.Context, _ string, args []string, _ string, _ []string) (int, error) {
+			ran = append(ran, strings.Join(args, " "))
+			return 0, nil
+		},
+		shellOutCapture: func(_ context.Context, _ string, args []string, _ string, _ []string) (int, string, error) {
+			switch {
+			case args[0] == "rev-parse" && args[1] == "HEAD":
+				return 0, "abcdef0123456789\n", nil
+			case args[0] == "rev-parse" || args[0] == "show-ref":
+				if known(args[len(args)-1]) {
+					return 0, "", nil
+				}
+				return 1, "", nil
+			case args[0] == "describe" && args[len(args)-1] == "refs/remotes/origin/HEAD":
+				return 0, "v2.0.0\n", nil
+			}
+			return 1, "", nil
+		},
+	}, &ran
+}
+
+func TestGitCloneFetchFor(t *testing.T) {
+	t.Run("skips fetching tags already in the clone", func(t *testing.T) {
+		clone, ran := fakeGitClone("refs/tags/v1.0.0")
+		require.NoError(t, clone.fetchFor("v1.0.0"))
+		assert.Empty(t, *ran)
+	})
+
+	t.Run("skips fetching known commits", func(t *testing.T) {
+		clone, ran := fakeGitClone("abc1234")
+		require.NoError(t, clone.fetchFor("abc1234"))
+		assert.Empty(t, *ran)
+	})
+
+	t.Run("fetches only the branch", func(t *testing.T) {
+		clone, ran := fakeGitClone("refs/remotes/origin/main")
+		require.NoError(t, clone.fetchFor("main"))
+		assert.Equal(t, []string{"fetch --prune origin main"}, *ran)
+	})
+
+	t.Run("fetches everything for unknown versions", func(t *testing.T) {
+		clone, ran := fakeGitClone()
+		require.NoError(t, clone.fetchFor("v3.0.0"))
+		require.NoError(t, clone.fetchFor("latest"))
+		assert.Equal(t, []string{"fetch --prune --tags origin", "fetch --prune --tags origin"}, *ran)
+	})
+}
+
+func TestGitCloneCheckout(t *testing.T) {
+	clone, ran := fakeGitClone("refs/remotes/origin/main")
+	require.NoError(t, clone.checkout("main"))
+	require.NoError(t, clone.checkout("v1.0.0"))
+	assert.Equal(t, []string{"checkout -B main origin/main", "checkout v1.0.0"}, *ran)
+
+	clone.shellOut = func(context.Context, string, []string, string, []string) (int, error) { return 1, nil }
+	assert.ErrorContains(t, clone.checkout("v1.0.0"), "git checkout v1.0.0 exited with code 1")
+}
+
+func TestGitCloneLatestTagAndHead(t *testing.T) {
+	clone, _ := fakeGitClone()
+	tag, err := clone.latestTag()
+	require.NoError(t, err)
+	assert.Equal(t, "v2.0.0", tag)
+	assert.Equal(t, "abcdef0123456789", clone.head())
+}
+
+func TestGitCommitRange(t *testing.T) {
+	recordGitCommitRange(context.Background(), "1111111aaaa", "2222222bbbb")
+
+	ctx := newOperation(context.Background(), files.HistoryActionUpdate, "github:owner/repo")
+	recordGitCommitRange(ctx, "", "abc")
+	assert.Nil(t, resultOf(ctx).commitRange.Load(), "ranges without a previous commit are not recorded")
+
+	recordGitCommitRange(ctx, "1111111aaaa", "2222222bbbb")
+	r := resultOf(ctx).commitRange.Load()
+	require.NotNil(t, r)
+	assert.True(t, r.Changed())
+	assert.Equal(t, "1111111..2222222", r.String())
+
+	other := newOperation(context.Background(), files.HistoryActionUpdate, "gitlab:group/project")
+	assert.Nil(t, resultOf(other).commitRange.Load(), "ranges belong to their operation")
+
+	assert.Equal(t, "abc", GitCommitRange{From: "abc", To: "abc"}.String())
+}
+
+func TestUpdateReturnsGitCommitRange(t *testing.T) {
+	t.Setenv("ZANA_HOME", t.TempDir())
+	SetProviderFactory(&MockProviderFactory{
+		MockGitHubProvider: &MockPackageManager{UpdateFunc: func(ctx context.Context, _ string) bool {
+			recordGitCommitRange(ctx, "1111111aaaa", "2222222bbbb")
+			return true
+		}},
+		MockNPMProvider: &MockPackageManager{UpdateFunc: func(context.Context, string) bool { return true }},
+	})
+	defer ResetProviderFactory()
+
+	ok, _, commits := Update("github:owner/repo")
+	assert.True(t, ok)
+	require.NotNil(t, commits)
+	assert.Equal(t, GitCommitRange{From: "1111111aaaa", To: "2222222bbbb"}, *commits)
+
+	_, _, commits = Update("npm:prettier")
+	assert.Nil(t, commits)
+}
@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"fmt"
 	"runtime"
 	"strings"
@@ -9,11 +10,15 @@ import (
 )
 
 // DetectRegistryTarget detects the current platform and returns the registry target string
-// Registry targets: darwin_arm64, darwin_x64, linux_x64, linux_arm64, linux_arm, win_x64, etc.
+// Registry targets: darwin_arm64, darwin_x64, linux_x64, linux_arm64, linux_arm,
+// linux_riscv64, win_x64, win_arm64, android_arm64, etc.
+// Linux builds of zana running in Termux detect as android.
 func DetectRegistryTarget() string {
-	goos := runtime.GOOS
-	goarch := runtime.GOARCH
+	return registryTarget(runtime.GOOS, runtime.GOARCH, isTermux())
+}
 
+// registryTarget returns the registry target of goos and goarch
+func registryTarget(goos, goarch string, termux bool) string {
 	var osPart string
 	var archPart string
 
@@ -22,6 +27,9 @@ func DetectRegistryTarget() string {
 		osPart = "darwin"
 	case "linux":
 		osPart = "linux"
+		if termux {
+			osPart = "android"
+		}
 	case "windows":
 		osPart = "win"
 	default:
@@ -68,25 +76,14 @@ func MatchesTarget(target interface{}, currentTarget string) bool {
 }
 
 // FindMatchingAsset finds the asset entry that matches the current platform
-func FindMatchingAsset(assets registry_parser.RegistryItemSourceAssetList) *registry_parser.RegistryItemSourceAsset {
-	currentTarget := DetectRegistryTarget()
-
+func FindMatchingAsset(ctx context.Context, assets registry_parser.RegistryItemSourceAssetList) *registry_parser.RegistryItemSourceAsset {
+	targets := make([]interface{}, len(assets))
 	for i := range assets {
-		if MatchesTarget(assets[i].Target, currentTarget) {
-			return &assets[i]
-		}
+		targets[i] = assets[i].Target
 	}
-
-	// Try fallback: check for linux_x64_gnu if linux_x64 not found
-	if strings.HasPrefix(currentTarget, "linux_") {
-		fallbackTarget := currentTarget + "_gnu"
-		for i := range assets {
-			if MatchesTarget(assets[i].Target, fallbackTarget) {
-				return &assets[i]
-			}
-		}
+	if i := matchingTargetIndex(ctx, targets); i >= 0 {
+		return &assets[i]
 	}
-
 	return nil
 }
 
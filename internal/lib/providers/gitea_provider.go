@@ -0,0 +1,24 @@
+package providers
+
+import (
+	"path/filepath"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+)
+
+// GiteaProvider installs packages from releases and repositories of any
+// Gitea or Forgejo instance. Its package IDs start with the host of the
+// instance, e.g. gitea:git.example.com/owner/repo, everything else is
+// shared with the codeberg provider.
+type GiteaProvider struct {
+	CodebergProvider
+}
+
+func NewProviderGitea() *GiteaProvider {
+	p := &GiteaProvider{}
+	p.PROVIDER_NAME = "gitea"
+	p.APP_PACKAGES_DIR = filepath.Join(files.GetAppPackagesPath(), p.PROVIDER_NAME)
+	p.PREFIX = p.PROVIDER_NAME + ":"
+	p.DISPLAY_NAME = "Gitea"
+	return p
+}
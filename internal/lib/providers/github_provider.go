@@ -1,19 +1,22 @@
 package providers
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
+	"github.com/mistweaverco/zana-client/internal/lib/errcodes"
+	"github.com/mistweaverco/zana-client/internal/lib/events"
 	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
-	"github.com/mistweaverco/zana-client/internal/lib/shell_out"
+	"github.com/mistweaverco/zana-client/internal/lib/trace"
 )
 
 type GitHubProvider struct {
@@ -24,8 +27,8 @@ type GitHubProvider struct {
 }
 
 // Injectable shell and OS helpers for tests
-var githubShellOut = shell_out.ShellOut
-var githubShellOutCapture = shell_out.ShellOutCapture
+var githubShellOut = shellOut
+var githubShellOutCapture = shellOutCapture
 var githubStat = os.Stat
 var githubMkdir = os.Mkdir
 var githubMkdirAll = os.MkdirAll
@@ -34,12 +37,14 @@ var githubRemove = os.Remove
 var githubRemoveAll = os.RemoveAll
 var githubSymlink = os.Symlink
 var githubReadDir = os.ReadDir
-var githubHasCommand = shell_out.HasCommand
+var githubHasCommand = hasCommand
 
 // Injectable local packages helpers for tests
 var lppGithubAdd = local_packages_parser.AddLocalPackage
 var lppGithubRemove = local_packages_parser.RemoveLocalPackage
 var lppGithubGetDataForProvider = local_packages_parser.GetDataForProvider
+var lppGithubSetInstallMethod = local_packages_parser.SetPackageInstallMethod
+var lppGithubGetBySourceID = local_packages_parser.GetBySourceId
 
 // Injectable registry parser for tests
 var githubRegistryParser = registry_parser.NewDefaultRegistryParser
@@ -58,16 +63,10 @@ func NewProviderGitHub() *GitHubProvider {
 
 func (p *GitHubProvider) getRepo(sourceID string) string {
 	// Support both legacy (pkg:github/user/repo) and new (github:user/repo) formats
-	normalized := normalizePackageID(sourceID)
+	normalized := packageid.Normalize(sourceID)
 	if strings.HasPrefix(normalized, p.PREFIX) {
 		return strings.TrimPrefix(normalized, p.PREFIX)
 	}
-	// Fallback for legacy format
-	re := regexp.MustCompile("^pkg:" + p.PROVIDER_NAME + "/(.*)")
-	matches := re.FindStringSubmatch(sourceID)
-	if len(matches) > 1 {
-		return matches[1]
-	}
 	return ""
 }
 
@@ -81,14 +80,14 @@ func (p *GitHubProvider) getRepoPath(repo string) string {
 	return filepath.Join(p.APP_PACKAGES_DIR, safeRepo)
 }
 
-func (p *GitHubProvider) checkGitAvailable() bool {
-	return githubHasCommand("git", []string{"--version"}, nil)
+func (p *GitHubProvider) checkGitAvailable(ctx context.Context) bool {
+	return githubHasCommand(ctx, "git", []string{"--version"}, nil)
 }
 
-func (p *GitHubProvider) Install(sourceID, version string) bool {
+func (p *GitHubProvider) Install(ctx context.Context, sourceID, version string) bool {
 	repo := p.getRepo(sourceID)
 	if repo == "" {
-		Logger.Error("GitHub Install: Invalid source ID format")
+		Logger.ErrorContext(ctx, "GitHub Install: Invalid source ID format")
 		return false
 	}
 
@@ -99,9 +98,9 @@ func (p *GitHubProvider) Install(sourceID, version string) bool {
 	// registry has a package whose name (or alias) matches the segment after "github:".
 	if !strings.Contains(repo, "/") && registryItem.Source.ID == "" {
 		if hit := registry.GetByNameOrAlias(repo); hit.Source.ID != "" {
-			norm := normalizePackageID(hit.Source.ID)
+			norm := packageid.Normalize(hit.Source.ID)
 			if strings.HasPrefix(norm, "github:") {
-				Logger.Info(fmt.Sprintf("GitHub Install: Resolved shorthand %q to %q", sourceID, hit.Source.ID))
+				Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Resolved shorthand %q to %q", sourceID, hit.Source.ID))
 				sourceID = hit.Source.ID
 				repo = p.getRepo(sourceID)
 				registryItem = hit
@@ -109,7 +108,7 @@ func (p *GitHubProvider) Install(sourceID, version string) bool {
 		}
 	}
 	if !strings.Contains(repo, "/") {
-		Logger.Error(fmt.Sprintf(
+		Logger.ErrorContext(ctx, fmt.Sprintf(
 			"GitHub Install: repository %q is missing the owner; use github:owner/repo (for example github:tree-sitter/tree-sitter-typescript)",
 			sourceID,
 		))
@@ -118,19 +117,31 @@ func (p *GitHubProvider) Install(sourceID, version string) bool {
 
 	// If registry has asset information, use release download method
 	if len(registryItem.Source.Asset) > 0 {
-		return p.installFromRelease(sourceID, repo, version, registryItem)
+		ok, unreleased := p.installFromRelease(ctx, sourceID, repo, version, registryItem)
+		if !unreleased {
+			return ok
+		}
+		// Some repos only tag their versions, without publishing releases
+		return p.installWithoutRelease(ctx, sourceID, repo, version, registryItem)
 	}
 
 	// Fallback to git clone method
-	return p.installFromGit(sourceID, repo, version)
+	return p.installFromGit(ctx, sourceID, repo, version)
 }
 
-func (p *GitHubProvider) installFromRelease(sourceID, repo, version string, registryItem registry_parser.RegistryItem) bool {
+// errReleaseAssetNotFound is returned by downloadAsset when the release or
+// its asset doesn't exist
+var errReleaseAssetNotFound = errors.New("release asset not found")
+
+// installFromRelease installs the package from its release asset. unreleased
+// is set when there is no release or asset to install from.
+func (p *GitHubProvider) installFromRelease(ctx context.Context, sourceID, repo, version string, registryItem registry_parser.RegistryItem) (ok bool, unreleased bool) {
 	// Find matching asset for current platform
-	asset := FindMatchingAsset(registryItem.Source.Asset)
+	asset := FindMatchingAsset(ctx, registryItem.Source.Asset)
 	if asset == nil {
-		Logger.Error("GitHub Install: No matching asset found for current platform")
-		return false
+		Logger.ErrorContext(ctx, "GitHub Install: No matching asset found for current platform")
+		recordFailureCode(sourceID, errcodes.AssetNotFound)
+		return false, false
 	}
 
 	// Resolve version
@@ -148,8 +159,8 @@ func (p *GitHubProvider) installFromRelease(sourceID, repo, version string, regi
 			// Try to get latest release from GitHub API
 			latestTag, err := p.getLatestReleaseTag(repo)
 			if err != nil {
-				Logger.Error(fmt.Sprintf("GitHub Install: Could not determine latest version: %v", err))
-				return false
+				Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Could not determine the latest release: %v", err))
+				return false, true
 			}
 			resolvedVersion = latestTag
 		}
@@ -159,220 +170,399 @@ func (p *GitHubProvider) installFromRelease(sourceID, repo, version string, regi
 	assetFileName := ResolveTemplate(asset.File.String(), resolvedVersion)
 
 	// Download release asset
-	releaseURL := fmt.Sprintf("https://github.com/%s/releases/download/%s/%s", repo, resolvedVersion, assetFileName)
-	Logger.Info(fmt.Sprintf("GitHub Install: Downloading release asset from %s", releaseURL))
+	releaseURL := releaseAssetURL(p.PROVIDER_NAME, repo, resolvedVersion, assetFileName)
+	Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Downloading release asset from %s", releaseURL))
 
 	// Ensure packages directory exists (create parent directories if needed)
 	if err := githubMkdirAll(p.APP_PACKAGES_DIR, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("GitHub Install: Error creating packages directory: %v", err))
-		return false
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error creating packages directory: %v", err))
+		recordFailureCode(sourceID, errcodes.Classify(err))
+		return false, false
 	}
 
-	// Create temporary directory for extraction
-	tempDir := filepath.Join(p.APP_PACKAGES_DIR, repo+"_temp")
-	if err := githubMkdirAll(tempDir, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("GitHub Install: Error creating temp directory: %v", err))
-		return false
+	// Download and extract in a staging directory of its own
+	tempDir, err := newStagingDir(ctx, p.PROVIDER_NAME, repo)
+	if err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error creating temp directory: %v", err))
+		return false, false
 	}
 	defer githubRemoveAll(tempDir)
 
-	// Download asset
+	// Download asset, verified against its checksum
+	download := verifiedDownload(p.downloadAsset, githubHTTPGet, registryItem.Source.Asset, resolvedVersion)
 	assetPath := filepath.Join(tempDir, assetFileName)
-	if err := p.downloadAsset(releaseURL, assetPath); err != nil {
-		Logger.Error(fmt.Sprintf("GitHub Install: Error downloading asset: %v", err))
-		return false
+	if err := download(ctx, releaseURL, assetPath); err != nil {
+		if !errors.Is(err, errReleaseAssetNotFound) {
+			Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error downloading asset: %v", err))
+			recordFailureCode(sourceID, errcodes.Classify(err))
+			return false, false
+		}
+		// The registry may list an asset upstream has since renamed
+		alternate, alternatePath, err := p.downloadAlternateAsset(ctx, repo, resolvedVersion, assetFileName, registryItem, tempDir)
+		if errors.Is(err, errReleaseAssetNotFound) {
+			Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: No release asset %s for %s", assetFileName, resolvedVersion))
+			recordFailureCode(sourceID, errcodes.AssetNotFound)
+			return false, true
+		}
+		if err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: %v", err))
+			return false, false
+		}
+		asset, assetPath = alternate, alternatePath
+		releaseURL = releaseAssetURL(p.PROVIDER_NAME, repo, resolvedVersion, filepath.Base(alternatePath))
+		if err := verifyAssetChecksum(ctx, releaseURL, assetPath, githubHTTPGet, registryItem.Source.Asset, resolvedVersion); err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error downloading asset: %v", err))
+			return false, false
+		}
 	}
 
 	// Extract asset
 	extractDir := filepath.Join(tempDir, "extracted")
 	if err := githubMkdirAll(extractDir, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("GitHub Install: Error creating extract directory: %v", err))
-		return false
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error creating extract directory: %v", err))
+		return false, false
 	}
 
-	if err := p.extractArchive(assetPath, extractDir); err != nil {
-		Logger.Error(fmt.Sprintf("GitHub Install: Error extracting asset: %v", err))
-		return false
+	if err := extractOrRedownload(ctx, releaseURL, assetPath, extractDir, p.extractArchive, download); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error extracting asset: %v", err))
+		return false, false
+	}
+	chaosPartialExtraction(extractDir)
+	if err := stripArchivePrefix(extractDir, asset.StripComponents, assetBinPaths(asset, registryItem)); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error extracting asset: %v", err))
+		return false, false
+	}
+
+	// Data-only packages ship content without executables, nothing gets linked into bin
+	if registryItem.IsDataOnly() {
+		dest, err := installDataPackage(sourceID, extractDir)
+		if err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error installing data package: %v", err))
+			return false, false
+		}
+		if err := lppGithubAdd(sourceID, resolvedVersion); err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error adding package to local packages: %v", err))
+			return false, false
+		}
+		p.recordInstallMethod(ctx, sourceID, local_packages_parser.InstallMethodRelease)
+		Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Successfully installed data package %s@%s into %s", repo, resolvedVersion, dest))
+		return true, false
 	}
 
 	// Find binaries and create symlinks
 	repoPath := p.getRepoPath(repo)
-	if err := githubMkdirAll(repoPath, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("GitHub Install: Error creating package directory: %v", err))
-		return false
+	installDir, err := newVersionDir(repoPath, resolvedVersion, githubMkdirAll)
+	if err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error creating package directory: %v", err))
+		return false, false
 	}
 
-	// Copy binaries to repo path
-	if err := p.copyBinariesFromExtract(extractDir, repoPath, asset, registryItem); err != nil {
-		Logger.Error(fmt.Sprintf("GitHub Install: Error copying binaries: %v", err))
-		return false
+	// Copy binaries to the directory of the version, which replaces the
+	// installed one once it's complete
+	if err := p.copyBinariesFromExtract(ctx, extractDir, installDir, asset, registryItem); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error copying binaries: %v", err))
+		return false, false
+	}
+	if err := activateVersion(sourceID, repoPath, resolvedVersion, installDir); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error moving %s into place: %v", resolvedVersion, err))
+		return false, false
 	}
 
 	// Clean up any legacy symlinks from prior git installs.
 	// (Those used relative symlinks into the repo dir, which must be removed before
 	// we create the curated bin links from the registry.)
-	_ = p.removeSymlinks(repo)
+	_ = p.removeSymlinks(ctx, repo)
 
 	// Create symlinks
-	if err := p.createSymlinksFromRegistry(repo, repoPath, asset, registryItem); err != nil {
-		Logger.Info(fmt.Sprintf("GitHub Install: Warning creating symlinks: %v", err))
+	if err := p.createSymlinksFromRegistry(ctx, repo, repoPath, asset, registryItem); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Warning creating symlinks: %v", err))
 	}
 
 	// Add to local packages
 	if err := lppGithubAdd(sourceID, resolvedVersion); err != nil {
-		Logger.Error(fmt.Sprintf("GitHub Install: Error adding package to local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error adding package to local packages: %v", err))
+		return false, false
+	}
+
+	p.recordInstallMethod(ctx, sourceID, local_packages_parser.InstallMethodRelease)
+	collectVersions(ctx, repoPath)
+	Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Successfully installed %s@%s from release", repo, resolvedVersion))
+	return true, false
+}
+
+// installWithoutRelease installs a package that has no release to download
+// from: from the source archive of the requested tag, or else from a clone
+func (p *GitHubProvider) installWithoutRelease(ctx context.Context, sourceID, repo, version string, registryItem registry_parser.RegistryItem) bool {
+	tag := version
+	switch tag {
+	case "", "latest", "main", "master", "trunk":
+		tag = registryItem.Version
+	}
+	if tag != "" && p.installFromTagArchive(ctx, sourceID, repo, tag, registryItem) {
+		return true
+	}
+
+	// git can't reuse the directory of a release or archive install
+	repoPath := p.getRepoPath(repo)
+	if _, err := githubStat(filepath.Join(repoPath, ".git")); os.IsNotExist(err) {
+		_ = p.removeSymlinks(ctx, repo)
+		if err := releaseVersion(repoPath); err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error removing %s: %v", repoPath, err))
+			return false
+		}
+		if err := githubRemoveAll(repoPath); err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error removing %s: %v", repoPath, err))
+			return false
+		}
+	}
+	return p.installFromGit(ctx, sourceID, repo, version)
+}
+
+// installFromTagArchive installs the source archive GitHub generates for a
+// tag (archive/refs/tags/<tag>.tar.gz), running the Tree-sitter build recipe
+// of the registry entry if it has one. It returns false when the archive is
+// not available, so the caller can fall back to a clone.
+func (p *GitHubProvider) installFromTagArchive(ctx context.Context, sourceID, repo, tag string, registryItem registry_parser.RegistryItem) bool {
+	archiveURL := fmt.Sprintf("%s/%s/archive/refs/tags/%s.tar.gz", p.BASE_URL, repo, tag)
+	Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Downloading the source archive of %s from %s", tag, archiveURL))
+
+	if err := githubMkdirAll(p.APP_PACKAGES_DIR, 0755); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error creating packages directory: %v", err))
+		return false
+	}
+	tempDir, err := newStagingDir(ctx, p.PROVIDER_NAME, repo)
+	if err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error creating temp directory: %v", err))
+		return false
+	}
+	defer githubRemoveAll(tempDir)
+
+	archivePath := filepath.Join(tempDir, "source.tar.gz")
+	if err := p.downloadAsset(ctx, archiveURL, archivePath); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: No source archive for %s: %v", tag, err))
+		return false
+	}
+	extractDir := filepath.Join(tempDir, "extracted")
+	if err := githubMkdirAll(extractDir, 0755); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error creating extract directory: %v", err))
+		return false
+	}
+	if err := extractOrRedownload(ctx, archiveURL, archivePath, extractDir, p.extractArchive, p.downloadAsset); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Error extracting the source archive: %v", err))
+		return false
+	}
+
+	if registryItem.IsDataOnly() {
+		dest, err := installDataPackage(sourceID, extractDir)
+		if err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error installing data package: %v", err))
+			return false
+		}
+		if err := lppGithubAdd(sourceID, tag); err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error adding package to local packages: %v", err))
+			return false
+		}
+		p.recordInstallMethod(ctx, sourceID, local_packages_parser.InstallMethodTagArchive)
+		Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Successfully installed data package %s@%s into %s", repo, tag, dest))
+		return true
+	}
+
+	// The archive wraps everything in a <repo>-<tag> directory
+	if err := stripComponent(extractDir); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Error extracting the source archive: %v", err))
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("GitHub Install: Successfully installed %s@%s from release", repo, resolvedVersion))
+	repoPath := p.getRepoPath(repo)
+	_ = p.removeSymlinks(ctx, repo)
+	if err := activateVersion(sourceID, repoPath, tag, extractDir); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error moving the source into place: %v", err))
+		return false
+	}
+
+	pins, err := buildAndMaybeIntegrateTreeSitter(ctx, repoPath, registryItem, tag, nil)
+	if err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error building tree-sitter parsers: %v", err))
+		return false
+	}
+	if err := lppGithubAdd(sourceID, tag); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error adding package to local packages: %v", err))
+		return false
+	}
+	if len(pins) > 0 {
+		if err := local_packages_parser.MergePackageTreeSitterExternalQueryPins(sourceID, pins); err != nil {
+			Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Warning persisting external query pins: %v", err))
+		}
+	}
+	p.recordInstallMethod(ctx, sourceID, local_packages_parser.InstallMethodTagArchive)
+
+	if err := p.createSymlinks(ctx, repo, repoPath); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Warning creating symlinks: %v", err))
+	}
+
+	collectVersions(ctx, repoPath)
+	Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Successfully installed %s@%s from the tag's source archive", repo, tag))
 	return true
 }
 
-func (p *GitHubProvider) installFromGit(sourceID, repo, version string) bool {
-	if !p.checkGitAvailable() {
-		Logger.Error("GitHub Install: git command not found. Please install git.")
+// recordInstallMethod records in the lock file how the package was installed
+func (p *GitHubProvider) recordInstallMethod(ctx context.Context, sourceID, method string) {
+	if err := lppGithubSetInstallMethod(sourceID, method); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Warning recording the install method: %v", err))
+	}
+}
+
+func (p *GitHubProvider) installFromGit(ctx context.Context, sourceID, repo, version string) bool {
+	if !p.checkGitAvailable(ctx) {
+		Logger.ErrorContext(ctx, "GitHub Install: git command not found. Please install git.")
 		return false
 	}
 
 	registry := githubRegistryParser()
 	registryItem := registry.GetBySourceId(sourceID)
 
-	repoPath, resolvedVersion, ok := p.gitCloneAndCheckout(sourceID, repo, version)
+	repoPath, resolvedVersion, ok := p.gitCloneAndCheckout(ctx, sourceID, repo, version)
 	if !ok {
 		return false
 	}
 
 	// If this is a Tree-sitter parser package, build artifacts and run requested integrations.
-	pins, err := buildAndMaybeIntegrateTreeSitter(repoPath, registryItem, resolvedVersion, nil)
+	pins, err := buildAndMaybeIntegrateTreeSitter(ctx, repoPath, registryItem, resolvedVersion, nil)
 	if err != nil {
-		Logger.Error(fmt.Sprintf("GitHub Install: Error building tree-sitter parsers: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error building tree-sitter parsers: %v", err))
 		return false
 	}
 
 	// Add to local packages
 	if err := lppGithubAdd(sourceID, resolvedVersion); err != nil {
-		Logger.Error(fmt.Sprintf("GitHub Install: Error adding package to local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error adding package to local packages: %v", err))
 		return false
 	}
 	if len(pins) > 0 {
 		if err := local_packages_parser.MergePackageTreeSitterExternalQueryPins(sourceID, pins); err != nil {
-			Logger.Info(fmt.Sprintf("GitHub Install: Warning persisting external query pins: %v", err))
+			Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Warning persisting external query pins: %v", err))
 		}
 	}
+	p.recordInstallMethod(ctx, sourceID, local_packages_parser.InstallMethodGit)
 
 	// Create symlinks for binaries
-	if err := p.createSymlinks(repo, repoPath); err != nil {
-		Logger.Info(fmt.Sprintf("GitHub Install: Warning creating symlinks: %v", err))
+	if err := p.createSymlinks(ctx, repo, repoPath); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Warning creating symlinks: %v", err))
 		// Don't fail installation if symlinks fail
 	}
 
-	Logger.Info(fmt.Sprintf("GitHub Install: Successfully installed %s@%s", repo, resolvedVersion))
+	collectVersions(ctx, repoPath)
+	Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Successfully installed %s@%s", repo, resolvedVersion))
 	return true
 }
 
-func (p *GitHubProvider) Remove(sourceID string) bool {
+func (p *GitHubProvider) Remove(ctx context.Context, sourceID string) bool {
 	repo := p.getRepo(sourceID)
 	if repo == "" {
-		Logger.Error("GitHub Remove: Invalid source ID format")
+		Logger.ErrorContext(ctx, "GitHub Remove: Invalid source ID format")
 		return false
 	}
 
 	// Remove Neovim tree-sitter parser(s) if this package installed them.
 	registry := githubRegistryParser()
 	registryItem := registry.GetBySourceId(sourceID)
-	if err := removeNeovimTreeSitterParsers(registryItem); err != nil {
-		Logger.Info(fmt.Sprintf("GitHub Remove: Warning removing Neovim tree-sitter parsers: %v", err))
+	if err := removeNeovimTreeSitterParsers(ctx, registryItem); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("GitHub Remove: Warning removing Neovim tree-sitter parsers: %v", err))
 	}
 
 	repoPath := p.getRepoPath(repo)
-	Logger.Info(fmt.Sprintf("GitHub Remove: Removing package %s", repo))
+	Logger.InfoContext(ctx, fmt.Sprintf("GitHub Remove: Removing package %s", repo))
 
 	// Remove symlinks
-	if err := p.removeSymlinks(repo); err != nil {
-		Logger.Info(fmt.Sprintf("GitHub Remove: Warning removing symlinks: %v", err))
+	if err := p.removeSymlinks(ctx, repo); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("GitHub Remove: Warning removing symlinks: %v", err))
 	}
 
 	// Remove repository directory
 	if _, err := githubStat(repoPath); err == nil {
 		if err := githubRemoveAll(repoPath); err != nil {
-			Logger.Error(fmt.Sprintf("GitHub Remove: Error removing repository directory: %v", err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Remove: Error removing repository directory: %v", err))
 			return false
 		}
 	}
+	if err := removeVersions(repoPath); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Remove: Error removing older versions: %v", err))
+		return false
+	}
 
 	// Remove from local packages
 	if err := lppGithubRemove(sourceID); err != nil {
-		Logger.Error(fmt.Sprintf("GitHub Remove: Error removing package from local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Remove: Error removing package from local packages: %v", err))
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("GitHub Remove: Successfully removed %s", repo))
+	Logger.InfoContext(ctx, fmt.Sprintf("GitHub Remove: Successfully removed %s", repo))
 	return true
 }
 
-func (p *GitHubProvider) Update(sourceID string) bool {
+func (p *GitHubProvider) Update(ctx context.Context, sourceID string) bool {
 	repo := p.getRepo(sourceID)
 	if repo == "" {
-		Logger.Error("GitHub Update: Invalid source ID format")
+		Logger.ErrorContext(ctx, "GitHub Update: Invalid source ID format")
 		return false
 	}
 
 	repoPath := p.getRepoPath(repo)
 	if _, err := githubStat(repoPath); os.IsNotExist(err) {
-		Logger.Error(fmt.Sprintf("GitHub Update: Repository %s is not installed", repo))
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Update: Repository %s is not installed", repo))
 		return false
 	}
 
-	// Fetch latest changes
-	code, err := githubShellOut("git", []string{"fetch", "--tags", "origin"}, repoPath, nil)
-	if err != nil || code != 0 {
-		Logger.Error(fmt.Sprintf("GitHub Update: Error fetching updates: %v", err))
+	// Release and tag archive installs have no clone to fetch into
+	if extras := lppGithubGetBySourceID(sourceID).Extras; extras != nil {
+		switch extras.InstallMethod {
+		case local_packages_parser.InstallMethodRelease, local_packages_parser.InstallMethodTagArchive:
+			Logger.InfoContext(ctx, fmt.Sprintf("GitHub Update: Updating %s to the latest version", repo))
+			return p.Install(ctx, sourceID, "latest")
+		}
+	}
+
+	// Fetch new tags and branches into the existing clone, pruning stale branches.
+	// Install reuses the clone and won't fetch again for the resolved tag.
+	if err := p.clone(ctx, repoPath).fetchAll(); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Update: Error fetching updates: %v", err))
 		return false
 	}
 
 	// Get latest version
-	latestVersion, err := p.getLatestVersionFromRepo(repoPath)
+	latestVersion, err := p.getLatestVersionFromRepo(ctx, repoPath)
 	if err != nil {
 		// No tags found, use default branch
-		latestVersion = p.getDefaultBranch(repoPath)
+		latestVersion = p.getDefaultBranch(ctx, repoPath)
 	}
 
-	Logger.Info(fmt.Sprintf("GitHub Update: Updating %s to version %s", repo, latestVersion))
-	return p.Install(sourceID, latestVersion)
+	Logger.InfoContext(ctx, fmt.Sprintf("GitHub Update: Updating %s to version %s", repo, latestVersion))
+	return p.Install(ctx, sourceID, latestVersion)
 }
 
-func (p *GitHubProvider) getLatestVersion(repo string) (string, error) {
+func (p *GitHubProvider) getLatestVersion(ctx context.Context, repo string) (string, error) {
 	// Prefer the latest GitHub release tag (works for binary release installs).
 	// If a repo doesn't publish releases, fall back to the default branch.
 	if tag, err := p.getLatestReleaseTag(repo); err == nil && strings.TrimSpace(tag) != "" {
 		return strings.TrimSpace(tag), nil
 	}
-	return p.getDefaultBranch(""), nil
+	return p.getDefaultBranch(ctx, ""), nil
 }
 
-func (p *GitHubProvider) getLatestVersionFromRepo(repoPath string) (string, error) {
-	// Fetch tags first
-	githubShellOut("git", []string{"fetch", "--tags", "origin"}, repoPath, nil)
-
-	// Get latest tag
-	code, output, err := githubShellOutCapture("git", []string{"describe", "--tags", "--abbrev=0"}, repoPath, nil)
-	if err != nil || code != 0 {
-		return "", fmt.Errorf("no tags found")
-	}
-
-	tag := strings.TrimSpace(output)
-	if tag == "" {
-		return "", fmt.Errorf("no tags found")
-	}
+func (p *GitHubProvider) getLatestVersionFromRepo(ctx context.Context, repoPath string) (string, error) {
+	// Callers fetch before resolving, so only the local tags are inspected
+	return p.clone(ctx, repoPath).latestTag()
+}
 
-	return tag, nil
+func (p *GitHubProvider) clone(ctx context.Context, repoPath string) gitClone {
+	return gitClone{ctx: ctx, path: repoPath, shellOut: githubShellOut, shellOutCapture: githubShellOutCapture}
 }
 
-func (p *GitHubProvider) getDefaultBranch(repoPath string) string {
+func (p *GitHubProvider) getDefaultBranch(ctx context.Context, repoPath string) string {
 	// Try to detect default branch
 	if repoPath != "" {
 		// Try to get default branch from existing repo
-		code, branchOutput, err := githubShellOutCapture("git", []string{"symbolic-ref", "refs/remotes/origin/HEAD"}, repoPath, nil)
+		code, branchOutput, err := githubShellOutCapture(ctx, "git", []string{"symbolic-ref", "refs/remotes/origin/HEAD"}, repoPath, nil)
 		if err == nil && code == 0 {
 			branch := strings.TrimSpace(branchOutput)
 			if strings.HasPrefix(branch, "refs/remotes/origin/") {
@@ -381,7 +571,7 @@ func (p *GitHubProvider) getDefaultBranch(repoPath string) string {
 		}
 		// Try common branch names
 		for _, branch := range []string{"main", "master", "trunk"} {
-			code, _, _ := githubShellOutCapture("git", []string{"show-ref", "--verify", "--quiet", "refs/remotes/origin/" + branch}, repoPath, nil)
+			code, _, _ := githubShellOutCapture(ctx, "git", []string{"show-ref", "--verify", "--quiet", "refs/remotes/origin/" + branch}, repoPath, nil)
 			if code == 0 {
 				return branch
 			}
@@ -391,8 +581,9 @@ func (p *GitHubProvider) getDefaultBranch(repoPath string) string {
 	return "main"
 }
 
-func (p *GitHubProvider) createSymlinks(_ string, repoPath string) error {
-	zanaBinDir := files.GetAppBinPath()
+func (p *GitHubProvider) createSymlinks(ctx context.Context, repo string, repoPath string) error {
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
+	filter := binFilterForSourceID(p.PREFIX + repo)
 
 	// Look for common binary locations
 	binDirs := []string{
@@ -417,6 +608,9 @@ func (p *GitHubProvider) createSymlinks(_ string, repoPath string) error {
 					if strings.HasPrefix(entry.Name(), ".") {
 						continue
 					}
+					if !filter.allows(entry.Name()) {
+						continue
+					}
 					// Create symlink
 					symlink := filepath.Join(zanaBinDir, entry.Name())
 					// Remove existing symlink if it exists
@@ -429,9 +623,9 @@ func (p *GitHubProvider) createSymlinks(_ string, repoPath string) error {
 						relPath = binPath
 					}
 					if err := githubSymlink(relPath, symlink); err != nil {
-						Logger.Info(fmt.Sprintf("GitHub: Warning creating symlink %s -> %s: %v", symlink, relPath, err))
+						Logger.InfoContext(ctx, fmt.Sprintf("GitHub: Warning creating symlink %s -> %s: %v", symlink, relPath, err))
 					} else {
-						Logger.Info(fmt.Sprintf("GitHub: Created symlink %s -> %s", symlink, relPath))
+						Logger.InfoContext(ctx, fmt.Sprintf("GitHub: Created symlink %s -> %s", symlink, relPath))
 					}
 					// Only process first executable found per directory to avoid clutter
 					break
@@ -443,10 +637,10 @@ func (p *GitHubProvider) createSymlinks(_ string, repoPath string) error {
 	return nil
 }
 
-func (p *GitHubProvider) removeSymlinks(repo string) error {
+func (p *GitHubProvider) removeSymlinks(ctx context.Context, repo string) error {
 	repoPath := p.getRepoPath(repo)
 	repoPath = filepath.Clean(repoPath) + string(os.PathSeparator)
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 
 	// Find and remove symlinks that point to this repo
 	entries, err := githubReadDir(zanaBinDir)
@@ -471,7 +665,7 @@ func (p *GitHubProvider) removeSymlinks(repo string) error {
 				// Check if target is in our repo path
 				if strings.HasPrefix(target, repoPath) {
 					if err := githubRemove(symlink); err != nil {
-						Logger.Info(fmt.Sprintf("GitHub: Warning removing symlink %s: %v", symlink, err))
+						Logger.InfoContext(ctx, fmt.Sprintf("GitHub: Warning removing symlink %s: %v", symlink, err))
 					}
 				}
 			}
@@ -481,8 +675,8 @@ func (p *GitHubProvider) removeSymlinks(repo string) error {
 	return nil
 }
 
-func (p *GitHubProvider) Sync() bool {
-	Logger.Info("GitHub Sync: Syncing GitHub packages")
+func (p *GitHubProvider) Sync(ctx context.Context) bool {
+	Logger.InfoContext(ctx, "GitHub Sync: Syncing GitHub packages")
 	localPackages := lppGithubGetDataForProvider(p.PROVIDER_NAME).Packages
 
 	allOk := true
@@ -494,14 +688,14 @@ func (p *GitHubProvider) Sync() bool {
 		repoPath := p.getRepoPath(repo)
 		if _, err := githubStat(repoPath); os.IsNotExist(err) {
 			// Re-install missing packages
-			Logger.Info(fmt.Sprintf("GitHub Sync: Re-installing missing package %s", repo))
-			if !p.Install(pkg.SourceID, pkg.Version) {
+			Logger.InfoContext(ctx, fmt.Sprintf("GitHub Sync: Re-installing missing package %s", repo))
+			if !p.Install(newOperation(ctx, actionSync, pkg.SourceID), pkg.SourceID, pkg.Version) {
 				allOk = false
 			}
 		} else {
 			// Update symlinks
-			if err := p.createSymlinks(repo, repoPath); err != nil {
-				Logger.Info(fmt.Sprintf("GitHub Sync: Warning creating symlinks for %s: %v", repo, err))
+			if err := p.createSymlinks(ctx, repo, repoPath); err != nil {
+				Logger.InfoContext(ctx, fmt.Sprintf("GitHub Sync: Warning creating symlinks for %s: %v", repo, err))
 			}
 		}
 	}
@@ -509,37 +703,27 @@ func (p *GitHubProvider) Sync() bool {
 	return allOk
 }
 
-// getLatestReleaseTag gets the latest release tag from GitHub API
+// getLatestReleaseTag gets the latest release tag from GitHub API,
+// cached per repo (see cachedLatestReleaseTag)
 func (p *GitHubProvider) getLatestReleaseTag(repo string) (string, error) {
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", repo)
-	resp, err := githubHTTPGet(apiURL)
-	if err != nil {
-		return "", fmt.Errorf("failed to fetch release info: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
-	}
-
-	var release struct {
-		TagName string `json:"tag_name"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return "", fmt.Errorf("failed to parse release info: %w", err)
-	}
-
-	return release.TagName, nil
+	return cachedLatestReleaseTag(repo, fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", repo))
 }
 
 // downloadAsset downloads a file from a URL to a destination path
-func (p *GitHubProvider) downloadAsset(url, destPath string) error {
+func (p *GitHubProvider) downloadAsset(ctx context.Context, url, destPath string) error {
+	if sum, ok := restoreCachedAsset(url, destPath); ok {
+		return pinAsset(ctx, url, sum)
+	}
+
 	resp, err := githubHTTPGet(url)
 	if err != nil {
 		return fmt.Errorf("failed to download: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return errReleaseAssetNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("HTTP error: %d", resp.StatusCode)
 	}
@@ -550,21 +734,27 @@ func (p *GitHubProvider) downloadAsset(url, destPath string) error {
 	}
 	defer func() { _ = file.Close() }()
 
-	if _, err := io.Copy(file, resp.Body); err != nil {
+	sum, err := hashCopy(file, downloadBody(ctx, resp, url))
+	if err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
-
+	if err := pinAsset(ctx, url, sum); err != nil {
+		return err
+	}
 	return nil
 }
 
 // extractArchive extracts an archive (tar.gz, zip, etc.) to a destination directory
-func (p *GitHubProvider) extractArchive(archivePath, destDir string) error {
+func (p *GitHubProvider) extractArchive(ctx context.Context, archivePath, destDir string) (err error) {
+	publishState(ctx, events.StateExtracting)
+	span := trace.StartContext(ctx, "extract", "file.path", archivePath)
+	defer func() { span.End(err) }()
 	ext := filepath.Ext(archivePath)
 	baseExt := filepath.Ext(strings.TrimSuffix(archivePath, ext))
 
 	if baseExt == ".tar" && ext == ".gz" {
 		// Extract tar.gz
-		code, err := githubShellOut("tar", []string{"-xzf", archivePath, "-C", destDir}, "", nil)
+		code, err := githubShellOut(ctx, "tar", []string{"-xzf", archivePath, "-C", destDir}, "", nil)
 		if err != nil || code != 0 {
 			return fmt.Errorf("failed to extract tar.gz: %v", err)
 		}
@@ -578,7 +768,7 @@ func (p *GitHubProvider) extractArchive(archivePath, destDir string) error {
 	} else if ext == ".gz" && baseExt != ".tar" {
 		// Single .gz file - gunzip and copy
 		outputPath := filepath.Join(destDir, strings.TrimSuffix(filepath.Base(archivePath), ".gz"))
-		code, err := githubShellOut("sh", []string{"-c", fmt.Sprintf("gunzip -c %s > %s", archivePath, outputPath)}, "", nil)
+		code, err := githubShellOut(ctx, "sh", []string{"-c", fmt.Sprintf("gunzip -c %s > %s", archivePath, outputPath)}, "", nil)
 		if err != nil || code != 0 {
 			return fmt.Errorf("failed to extract gz: %v", err)
 		}
@@ -608,7 +798,7 @@ func (p *GitHubProvider) extractArchive(archivePath, destDir string) error {
 }
 
 // copyBinariesFromExtract copies binaries from extracted archive to package directory
-func (p *GitHubProvider) copyBinariesFromExtract(extractDir, repoPath string, asset *registry_parser.RegistryItemSourceAsset, registryItem registry_parser.RegistryItem) error {
+func (p *GitHubProvider) copyBinariesFromExtract(ctx context.Context, extractDir, repoPath string, asset *registry_parser.RegistryItemSourceAsset, registryItem registry_parser.RegistryItem) error {
 	// Find binaries in extracted directory
 	// Asset file might have a path prefix (e.g., "file.tar.gz:subdir/")
 	assetFile := asset.File.String()
@@ -631,7 +821,7 @@ func (p *GitHubProvider) copyBinariesFromExtract(extractDir, repoPath string, as
 			// Copy binary to repo path
 			destBinPath := filepath.Join(repoPath, filepath.Base(binPath))
 			if err := p.copyFile(sourceBinPath, destBinPath); err != nil {
-				Logger.Info(fmt.Sprintf("GitHub: Warning copying binary %s: %v", binPath, err))
+				Logger.InfoContext(ctx, fmt.Sprintf("GitHub: Warning copying binary %s: %v", binPath, err))
 			} else {
 				// Make executable
 				os.Chmod(destBinPath, 0755)
@@ -641,7 +831,7 @@ func (p *GitHubProvider) copyBinariesFromExtract(extractDir, repoPath string, as
 			if foundPath := p.findBinaryInDir(extractDir, filepath.Base(binPath)); foundPath != "" {
 				destBinPath := filepath.Join(repoPath, filepath.Base(binPath))
 				if err := p.copyFile(foundPath, destBinPath); err != nil {
-					Logger.Info(fmt.Sprintf("GitHub: Warning copying binary %s: %v", binPath, err))
+					Logger.InfoContext(ctx, fmt.Sprintf("GitHub: Warning copying binary %s: %v", binPath, err))
 				} else {
 					os.Chmod(destBinPath, 0755)
 				}
@@ -692,10 +882,14 @@ func (p *GitHubProvider) findBinaryInDir(dir, name string) string {
 }
 
 // createSymlinksFromRegistry creates symlinks based on registry bin configuration
-func (p *GitHubProvider) createSymlinksFromRegistry(_ string, repoPath string, asset *registry_parser.RegistryItemSourceAsset, registryItem registry_parser.RegistryItem) error {
-	zanaBinDir := files.GetAppBinPath()
+func (p *GitHubProvider) createSymlinksFromRegistry(ctx context.Context, _ string, repoPath string, asset *registry_parser.RegistryItemSourceAsset, registryItem registry_parser.RegistryItem) error {
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
+	filter := binFilterFor(registryItem.Source.ID, registryItem)
 
 	for binName, binTemplate := range registryItem.Bin {
+		if !filter.allows(binName) {
+			continue
+		}
 		binPath := ResolveBinPath(binTemplate, asset, binName)
 		if binPath == "" {
 			continue
@@ -724,9 +918,9 @@ func (p *GitHubProvider) createSymlinksFromRegistry(_ string, repoPath string, a
 		}
 
 		if err := githubSymlink(relPath, symlink); err != nil {
-			Logger.Info(fmt.Sprintf("GitHub: Warning creating symlink %s -> %s: %v", symlink, relPath, err))
+			Logger.InfoContext(ctx, fmt.Sprintf("GitHub: Warning creating symlink %s -> %s: %v", symlink, relPath, err))
 		} else {
-			Logger.Info(fmt.Sprintf("GitHub: Created symlink %s -> %s", symlink, relPath))
+			Logger.InfoContext(ctx, fmt.Sprintf("GitHub: Created symlink %s -> %s", symlink, relPath))
 		}
 	}
 
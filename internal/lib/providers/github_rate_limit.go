@@ -0,0 +1,157 @@
+package providers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+)
+
+// GitHub API requests of all parallel operations share one token bucket, so
+// bulk operations like update --all stay below GitHub's secondary rate limits.
+// When GitHub still answers with a rate limit, every request waits for the
+// announced time (or an exponential backoff) before the request is retried.
+const (
+	githubMaxRetries  = 3
+	githubBaseBackoff = time.Second
+	// githubMaxBackoff is the longest zana waits for a rate limit to reset;
+	// an exhausted hourly quota is reported instead of waited for
+	githubMaxBackoff = time.Minute
+)
+
+// Injectable helpers for tests
+var githubRequestsPerSecond = files.GetGitHubRequestsPerSecond
+
+var (
+	githubLimiterMu sync.Mutex
+	githubLimiter   *tokenBucket
+)
+
+// tokenBucket hands out rate tokens per second, up to a burst of capacity.
+// Callers reserve a token and wait until it is due, so concurrent callers
+// are spread out instead of all retrying at the same moment.
+type tokenBucket struct {
+	mu          sync.Mutex
+	rate        float64 // 0 disables the limit
+	capacity    float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	capacity := math.Max(1, math.Ceil(rate))
+	return &tokenBucket{rate: rate, capacity: capacity, tokens: capacity}
+}
+
+// reserve takes a token and returns how long the caller has to wait before using it
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var wait time.Duration
+	if now.Before(b.pausedUntil) {
+		wait = b.pausedUntil.Sub(now)
+	}
+	if b.rate <= 0 {
+		return wait
+	}
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens < 0 {
+		wait = max(wait, time.Duration(-b.tokens/b.rate*float64(time.Second)))
+	}
+	return wait
+}
+
+// pause makes every caller wait until the given time
+func (b *tokenBucket) pause(until time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+}
+
+// sharedGitHubLimiter returns the token bucket of this process, created with
+// the configured rate on first use
+func sharedGitHubLimiter() *tokenBucket {
+	githubLimiterMu.Lock()
+	defer githubLimiterMu.Unlock()
+	if githubLimiter == nil {
+		githubLimiter = newTokenBucket(githubRequestsPerSecond())
+	}
+	return githubLimiter
+}
+
+// githubAPIDo sends a GitHub API request through the shared rate limiter and
+// retries it when GitHub answers with a (secondary) rate limit.
+// Only requests without a body can be retried, which all API lookups are.
+func githubAPIDo(req *http.Request) (*http.Response, error) {
+	limiter := sharedGitHubLimiter()
+	for attempt := 0; ; attempt++ {
+		if wait := limiter.reserve(clock.Now()); wait > 0 {
+			clock.Sleep(wait)
+		}
+		resp, err := githubHTTPDo(req)
+		if err != nil {
+			return nil, err
+		}
+		backoff, limited := githubRateLimitBackoff(resp, attempt)
+		if !limited {
+			return resp, nil
+		}
+		if attempt >= githubMaxRetries || backoff > githubMaxBackoff {
+			Logger.Error(fmt.Sprintf("GitHub API: Rate limited on %s, giving up (retry in %s)", req.URL, backoff.Round(time.Second)))
+			return resp, nil
+		}
+		Logger.Info(fmt.Sprintf("GitHub API: Rate limited on %s, retrying in %s", req.URL, backoff.Round(time.Second)))
+		_ = resp.Body.Close()
+		limiter.pause(clock.Now().Add(backoff))
+	}
+}
+
+// githubRateLimitBackoff reports whether resp is a rate limit response and how
+// long to wait before retrying: Retry-After when GitHub sends it, the quota
+// reset when the quota is exhausted, an exponential backoff otherwise
+func githubRateLimitBackoff(resp *http.Response, attempt int) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(resp.Header.Get("Retry-After"))); err == nil {
+		return time.Duration(max(seconds, 1)) * time.Second, true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return max(time.Unix(reset, 0).Sub(clock.Now()), 0) + time.Second, true
+		}
+	}
+	if resp.StatusCode == http.StatusForbidden && !isSecondaryRateLimitBody(resp) {
+		return 0, false
+	}
+	return githubBaseBackoff << attempt, true
+}
+
+// isSecondaryRateLimitBody checks the error message of a 403 response without
+// consuming the body, which stays readable for the caller
+func isSecondaryRateLimitBody(resp *http.Response) bool {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return false
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+	return strings.Contains(strings.ToLower(string(body)), "secondary rate limit")
+}
@@ -0,0 +1,123 @@
+package providers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucketReserve(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	t.Run("allows a burst and then spreads requests out", func(t *testing.T) {
+		b := newTokenBucket(2)
+		assert.Equal(t, time.Duration(0), b.reserve(now))
+		assert.Equal(t, time.Duration(0), b.reserve(now))
+		assert.Equal(t, 500*time.Millisecond, b.reserve(now))
+		assert.Equal(t, time.Second, b.reserve(now))
+		// Tokens are refilled over time
+		assert.Equal(t, time.Duration(0), b.reserve(now.Add(3*time.Second)))
+	})
+
+	t.Run("zero rate does not limit", func(t *testing.T) {
+		b := newTokenBucket(0)
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, time.Duration(0), b.reserve(now))
+		}
+	})
+
+	t.Run("pause delays every caller", func(t *testing.T) {
+		b := newTokenBucket(0)
+		b.pause(now.Add(5 * time.Second))
+		b.pause(now.Add(time.Second)) // an earlier pause does not shorten it
+		assert.Equal(t, 5*time.Second, b.reserve(now))
+		assert.Equal(t, time.Duration(0), b.reserve(now.Add(5*time.Second)))
+	})
+}
+
+// stubGitHubAPI replaces the HTTP client, clock and limiter used by githubAPIDo.
+// responses are returned in order; the returned fake clock collects the sleeps.
+func stubGitHubAPI(t *testing.T, responses ...func() *http.Response) (*int, *clock.Fake) {
+	t.Helper()
+	prevDo, prevLimiter := githubHTTPDo, githubLimiter
+	t.Cleanup(func() {
+		githubHTTPDo, githubLimiter = prevDo, prevLimiter
+	})
+	fake := clock.NewFake(time.Unix(1_700_000_000, 0))
+	t.Cleanup(clock.Set(fake))
+	githubLimiter = newTokenBucket(0)
+	calls := 0
+	githubHTTPDo = func(*http.Request) (*http.Response, error) {
+		resp := responses[min(calls, len(responses)-1)]()
+		calls++
+		return resp, nil
+	}
+	return &calls, fake
+}
+
+func githubResponse(status int, body string, header map[string]string) func() *http.Response {
+	return func() *http.Response {
+		resp := &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}
+		for k, v := range header {
+			resp.Header.Set(k, v)
+		}
+		return resp
+	}
+}
+
+func TestGitHubAPIDo(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/repos/owner/repo/releases/latest", nil)
+
+	t.Run("retries after Retry-After", func(t *testing.T) {
+		calls, fake := stubGitHubAPI(t,
+			githubResponse(http.StatusTooManyRequests, "", map[string]string{"Retry-After": "2"}),
+			githubResponse(http.StatusOK, `{}`, nil),
+		)
+		resp, err := githubAPIDo(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, 2, *calls)
+		assert.Equal(t, []time.Duration{2 * time.Second}, fake.Slept)
+	})
+
+	t.Run("backs off exponentially on secondary rate limits", func(t *testing.T) {
+		calls, fake := stubGitHubAPI(t,
+			githubResponse(http.StatusForbidden, `{"message":"You have exceeded a secondary rate limit."}`, nil),
+		)
+		resp, err := githubAPIDo(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Equal(t, githubMaxRetries+1, *calls)
+		assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, fake.Slept)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), "secondary rate limit")
+	})
+
+	t.Run("does not wait for an exhausted hourly quota", func(t *testing.T) {
+		reset := strconv.FormatInt(time.Unix(1_700_000_000, 0).Add(30*time.Minute).Unix(), 10)
+		calls, fake := stubGitHubAPI(t,
+			githubResponse(http.StatusForbidden, "", map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}),
+		)
+		resp, err := githubAPIDo(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Equal(t, 1, *calls)
+		assert.Empty(t, fake.Slept)
+	})
+
+	t.Run("other errors are returned as they are", func(t *testing.T) {
+		calls, fake := stubGitHubAPI(t, githubResponse(http.StatusForbidden, "Resource not accessible", nil))
+		resp, err := githubAPIDo(req)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, *calls)
+		assert.Empty(t, fake.Slept)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "Resource not accessible", string(body))
+	})
+}
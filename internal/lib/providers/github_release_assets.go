@@ -0,0 +1,299 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// Names release assets use for the registry target operating systems and
+// architectures, after x86_64 got normalized to x64 (see assetNameTokens)
+var (
+	assetOSAliases = map[string][]string{
+		"linux":  {"linux"},
+		"darwin": {"darwin", "macos", "mac", "osx", "apple"},
+		"win":    {"windows", "win", "win64", "win32", "msvc"},
+		// Static Linux builds run on Android too
+		"android": {"android", "termux", "linux"},
+	}
+	assetArchAliases = map[string][]string{
+		"x64":     {"x64", "amd64", "win64"},
+		"arm64":   {"arm64", "aarch64"},
+		"x86":     {"x86", "i386", "i686", "386", "win32"},
+		"arm":     {"arm", "armv6", "armv7", "armv7l", "armhf"},
+		"riscv64": {"riscv64", "riscv64gc", "riscv"},
+	}
+)
+
+// nonArchiveAssetExts are the extensions of checksums, signatures and
+// metadata published next to the actual release assets
+var nonArchiveAssetExts = map[string]bool{
+	".sha256": true, ".sha256sum": true, ".sha512": true, ".sha512sum": true, ".md5": true,
+	".asc": true, ".sig": true, ".minisig": true, ".pem": true, ".crt": true,
+	".sbom": true, ".json": true, ".txt": true, ".intoto": true, ".jsonl": true,
+}
+
+// alternateAssetSource is how a provider downloads the assets of a release
+// of repo and lists them, for downloadAlternateAsset
+type alternateAssetSource struct {
+	// label prefixes log messages, e.g. GitHub Install
+	label    string
+	repo     string
+	version  string
+	assetURL func(name string) string
+	download func(ctx context.Context, url, destPath string) error
+	// list returns the names of the release assets, nil when the provider
+	// can't list them
+	list func() ([]string, error)
+}
+
+// downloadAlternateAsset is called when the asset the registry lists for
+// the current platform is missing from the release, usually because
+// upstream renamed its assets and the registry metadata is stale.
+// It tries the other registry assets covering the platform, then the assets
+// of the release whose names match the platform best, and returns the asset
+// to install with the path it got downloaded to. errReleaseAssetNotFound is
+// returned when the release itself doesn't exist.
+func (p *GitHubProvider) downloadAlternateAsset(ctx context.Context, repo, version, missing string, registryItem registry_parser.RegistryItem, tempDir string) (*registry_parser.RegistryItemSourceAsset, string, error) {
+	src := alternateAssetSource{
+		label:   "GitHub Install",
+		repo:    repo,
+		version: version,
+		assetURL: func(name string) string {
+			return releaseAssetURL(p.PROVIDER_NAME, repo, version, name)
+		},
+		download: p.downloadAsset,
+	}
+	if p.PROVIDER_NAME == "github" {
+		src.list = func() ([]string, error) { return listReleaseAssets(repo, version) }
+	}
+	return downloadAlternateAsset(ctx, src, missing, registryItem, tempDir)
+}
+
+func downloadAlternateAsset(ctx context.Context, src alternateAssetSource, missing string, registryItem registry_parser.RegistryItem, tempDir string) (*registry_parser.RegistryItemSourceAsset, string, error) {
+	chosen := FindMatchingAsset(ctx, registryItem.Source.Asset)
+	for _, asset := range matchingAssets(registryItem.Source.Asset) {
+		name := ResolveTemplate(asset.File.String(), src.version)
+		if name == missing {
+			continue
+		}
+		path, err := src.tryAlternateAsset(ctx, name, tempDir)
+		if err != nil {
+			return nil, "", err
+		}
+		if path != "" {
+			Logger.WarnContext(ctx, fmt.Sprintf("%s: %s is missing from release %s of %s, installed %s instead", src.label, missing, src.version, src.repo, name))
+			return asset, path, nil
+		}
+	}
+
+	if src.list == nil {
+		return nil, "", errReleaseAssetNotFound
+	}
+	names, err := src.list()
+	if err != nil {
+		return nil, "", err
+	}
+	for _, name := range rankAlternateAssets(names, missing, assetPlatformTarget()) {
+		path, err := src.tryAlternateAsset(ctx, name, tempDir)
+		if err != nil {
+			return nil, "", err
+		}
+		if path != "" {
+			Logger.WarnContext(ctx, fmt.Sprintf("%s: %s is missing from release %s of %s, installed %s instead", src.label, missing, src.version, src.repo, name))
+			return chosen, path, nil
+		}
+	}
+	if len(names) == 0 {
+		return nil, "", fmt.Errorf("release %s of %s has no asset %s and no assets at all", src.version, src.repo, missing)
+	}
+	return nil, "", fmt.Errorf("release %s of %s has no asset %s and none of its assets matches %s, available assets: %s", src.version, src.repo, missing, assetPlatformTarget(), strings.Join(names, ", "))
+}
+
+// tryAlternateAsset downloads the release asset name into tempDir and
+// returns its path, or "" when the release has no such asset
+func (src alternateAssetSource) tryAlternateAsset(ctx context.Context, name, tempDir string) (string, error) {
+	url := src.assetURL(name)
+	Logger.InfoContext(ctx, fmt.Sprintf("%s: Trying alternate release asset %s", src.label, url))
+	path := filepath.Join(tempDir, name)
+	if err := src.download(ctx, url, path); err != nil {
+		if errors.Is(err, errReleaseAssetNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("error downloading asset %s: %w", name, err)
+	}
+	return path, nil
+}
+
+// matchingAssets returns the registry assets covering the current platform,
+// most preferred first
+func matchingAssets(assets registry_parser.RegistryItemSourceAssetList) []*registry_parser.RegistryItemSourceAsset {
+	var matching []*registry_parser.RegistryItemSourceAsset
+	seen := map[int]bool{}
+	for _, candidate := range assetTargetCandidates(assetPlatformTarget()) {
+		for i := range assets {
+			if !seen[i] && MatchesTarget(assets[i].Target, candidate) {
+				seen[i] = true
+				matching = append(matching, &assets[i])
+			}
+		}
+	}
+	return matching
+}
+
+// listReleaseAssets returns the names of the assets of the release tagged
+// tag, or errReleaseAssetNotFound when there is no such release
+func listReleaseAssets(repo, tag string) ([]string, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("https://api.github.com/repos/%s/releases/tags/%s", repo, tag), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := githubAPIDo(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch release info: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, errReleaseAssetNotFound
+	default:
+		return nil, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	}
+	var release struct {
+		Assets []struct {
+			Name string `json:"name"`
+		} `json:"assets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to parse release info: %w", err)
+	}
+	names := make([]string, 0, len(release.Assets))
+	for _, asset := range release.Assets {
+		names = append(names, asset.Name)
+	}
+	return names, nil
+}
+
+// rankAlternateAssets returns the release assets that can replace the
+// missing one on target (e.g. linux_x64), best first: the architecture
+// named explicitly beats no architecture at all, then the same libc and
+// archive format, then the longest name prefix shared with missing.
+// Assets for other platforms, checksums and signatures are left out.
+func rankAlternateAssets(names []string, missing, target string) []string {
+	parts := strings.Split(target, "_")
+	if len(parts) < 2 {
+		return nil
+	}
+	goos, arch := parts[0], parts[1]
+	missingMusl := assetNameTokens(missing)["musl"]
+
+	type ranked struct {
+		name   string
+		score  int
+		prefix int
+	}
+	var candidates []ranked
+	for _, name := range names {
+		if name == missing || nonArchiveAssetExts[strings.ToLower(filepath.Ext(name))] || strings.Contains(strings.ToLower(name), "checksum") {
+			continue
+		}
+		tokens := assetNameTokens(name)
+		if !hasAnyToken(tokens, assetOSAliases[goos]) {
+			continue
+		}
+		score := 0
+		switch {
+		case hasAnyToken(tokens, assetArchAliases[arch]):
+			score += 20
+		case goos == "darwin" && tokens["universal"]:
+			score += 10
+		case hasOtherArch(tokens, arch):
+			continue
+		default:
+			// No architecture in the name, likely the platform's only build
+			score += 5
+		}
+		if tokens["musl"] == missingMusl {
+			score += 2
+		}
+		if assetArchiveExt(name) == assetArchiveExt(missing) {
+			score += 3
+		}
+		candidates = append(candidates, ranked{name: name, score: score, prefix: commonPrefixLen(name, missing)})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		if candidates[i].prefix != candidates[j].prefix {
+			return candidates[i].prefix > candidates[j].prefix
+		}
+		return candidates[i].name < candidates[j].name
+	})
+	ranking := make([]string, len(candidates))
+	for i, c := range candidates {
+		ranking[i] = c.name
+	}
+	return ranking
+}
+
+// assetNameTokens splits an asset name into its lower case words,
+// e.g. stylua-linux-x86_64.zip into stylua, linux, x64 and zip
+func assetNameTokens(name string) map[string]bool {
+	name = strings.ToLower(name)
+	name = strings.NewReplacer("x86_64", "x64", "x86-64", "x64", "universal2", "universal").Replace(name)
+	tokens := map[string]bool{}
+	for _, token := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		tokens[token] = true
+	}
+	return tokens
+}
+
+func hasAnyToken(tokens map[string]bool, words []string) bool {
+	for _, word := range words {
+		if tokens[word] {
+			return true
+		}
+	}
+	return false
+}
+
+// hasOtherArch reports whether tokens name an architecture other than arch
+func hasOtherArch(tokens map[string]bool, arch string) bool {
+	for other, aliases := range assetArchAliases {
+		if other != arch && hasAnyToken(tokens, aliases) {
+			return true
+		}
+	}
+	return false
+}
+
+// assetArchiveExt returns the archive extension of an asset name,
+// including the .tar of compressed tarballs
+func assetArchiveExt(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if strings.EqualFold(filepath.Ext(strings.TrimSuffix(name, filepath.Ext(name))), ".tar") {
+		return ".tar" + ext
+	}
+	return ext
+}
+
+func commonPrefixLen(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,117 @@
+package providers
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// styluaAssets are the assets of a stylua release after upstream added the
+// architecture to the asset names
+var styluaAssets = []string{
+	"stylua-linux-x86_64.zip",
+	"stylua-linux-x86_64-musl.zip",
+	"stylua-linux-aarch64.zip",
+	"stylua-macos-x86_64.zip",
+	"stylua-macos-aarch64.zip",
+	"stylua-windows-x86_64.zip",
+	"stylua-linux-x86_64.zip.sha256",
+	"checksums.txt",
+}
+
+// styluaRelease is the GitHub API answer for the stylua release, whose asset
+// names don't match the registry anymore
+const styluaRelease = `{"tag_name": "v2.0.0", "assets": [
+	{"name": "stylua-linux-x86_64.tar.gz"},
+	{"name": "stylua-linux-aarch64.tar.gz"},
+	{"name": "stylua-macos-aarch64.tar.gz"},
+	{"name": "stylua-linux-x86_64.tar.gz.sha256"}
+]}`
+
+func stubAssetPlatformTarget(t *testing.T, target string) {
+	t.Helper()
+	prev := assetPlatformTarget
+	t.Cleanup(func() { assetPlatformTarget = prev })
+	assetPlatformTarget = func() string { return target }
+}
+
+func TestRankAlternateAssets(t *testing.T) {
+	assert.Equal(t, []string{"stylua-linux-x86_64.zip", "stylua-linux-x86_64-musl.zip"},
+		rankAlternateAssets(styluaAssets, "stylua-linux.zip", "linux_x64"))
+	assert.Equal(t, []string{"stylua-linux-x86_64-musl.zip", "stylua-linux-x86_64.zip"},
+		rankAlternateAssets(styluaAssets, "stylua-linux-musl.zip", "linux_x64"))
+	assert.Equal(t, []string{"stylua-linux-aarch64.zip"},
+		rankAlternateAssets(styluaAssets, "stylua-linux.zip", "linux_arm64"))
+	assert.Equal(t, []string{"stylua-macos-aarch64.zip"},
+		rankAlternateAssets(styluaAssets, "stylua-macos.zip", "darwin_arm64"))
+	assert.Equal(t, []string{"stylua-windows-x86_64.zip"},
+		rankAlternateAssets(styluaAssets, "stylua-win64.zip", "win_x64"))
+
+	// An explicit architecture beats a universal build, which beats none
+	assert.Equal(t, []string{"tool-darwin-arm64.tar.gz", "tool-darwin-universal.tar.gz", "tool-darwin.zip"},
+		rankAlternateAssets([]string{"tool-darwin.zip", "tool-darwin-universal.tar.gz", "tool-darwin-arm64.tar.gz"}, "tool-macos.tar.gz", "darwin_arm64"))
+	assert.Empty(t, rankAlternateAssets(styluaAssets, "stylua-freebsd.zip", "freebsd_x64"))
+}
+
+func TestGitHubInstallRetriesRenamedAsset(t *testing.T) {
+	stubAssetPlatformTarget(t, "linux_x64")
+	requested, _ := stubGitHubTagInstall(t, `[
+		{"name": "stylua", "version": "v2.0.0", "source": {"id": "github:JohnnyMorganz/StyLua", "asset": [{"target": "linux_x64", "file": "stylua-linux.tar.gz"}]},
+		 "bin": {"stylua": "stylua"}}
+	]`, map[string][]byte{
+		"https://api.github.com/repos/JohnnyMorganz/StyLua/releases/tags/v2.0.0":                      []byte(styluaRelease),
+		"https://github.com/JohnnyMorganz/StyLua/releases/download/v2.0.0/stylua-linux-x86_64.tar.gz": tarGz(t, map[string]string{"stylua": "#!/bin/sh\n"}),
+	})
+
+	p := NewProviderGitHub()
+	require.True(t, p.Install(context.Background(), "github:JohnnyMorganz/StyLua", "latest"))
+	assert.Equal(t, []string{
+		"https://github.com/JohnnyMorganz/StyLua/releases/download/v2.0.0/stylua-linux.tar.gz",
+		"https://github.com/JohnnyMorganz/StyLua/releases/download/v2.0.0/stylua-linux-x86_64.tar.gz",
+		// The release publishes no checksums for it
+		"https://github.com/JohnnyMorganz/StyLua/releases/download/v2.0.0/stylua-linux-x86_64.tar.gz.sha256",
+		"https://github.com/JohnnyMorganz/StyLua/releases/download/v2.0.0/stylua-linux-x86_64.tar.gz.sha512",
+	}, *requested)
+	assert.FileExists(t, filepath.Join(p.getRepoPath("JohnnyMorganz/StyLua"), "stylua"))
+}
+
+func TestGitHubInstallTriesOtherRegistryAssets(t *testing.T) {
+	stubAssetPlatformTarget(t, "linux_x64")
+	requested, _ := stubGitHubTagInstall(t, `[
+		{"name": "tool", "version": "v1.0.0", "source": {"id": "github:owner/tool", "asset": [
+			{"target": "linux_x64", "file": "tool-linux.tar.gz"},
+			{"target": "linux_x64_gnu", "file": "tool-linux-gnu.tar.gz"}
+		]}, "bin": {"tool": "tool"}}
+	]`, map[string][]byte{
+		"https://github.com/owner/tool/releases/download/v1.0.0/tool-linux-gnu.tar.gz": tarGz(t, map[string]string{"tool": "#!/bin/sh\n"}),
+	})
+
+	require.True(t, NewProviderGitHub().Install(context.Background(), "github:owner/tool", "v1.0.0"))
+	assert.Equal(t, "https://github.com/owner/tool/releases/download/v1.0.0/tool-linux-gnu.tar.gz", (*requested)[len(*requested)-3])
+}
+
+func TestDownloadAlternateAssetListsReleaseAssets(t *testing.T) {
+	stubAssetPlatformTarget(t, "freebsd_x64")
+	stubGitHubTagInstall(t, `[
+		{"name": "stylua", "version": "v2.0.0", "source": {"id": "github:JohnnyMorganz/StyLua", "asset": [{"target": "freebsd_x64", "file": "stylua-freebsd.zip"}]}}
+	]`, map[string][]byte{
+		"https://api.github.com/repos/JohnnyMorganz/StyLua/releases/tags/v2.0.0": []byte(styluaRelease),
+	})
+
+	p := NewProviderGitHub()
+	item := githubRegistryParser().GetBySourceId("github:JohnnyMorganz/StyLua")
+	_, _, err := p.downloadAlternateAsset(context.Background(), "JohnnyMorganz/StyLua", "v2.0.0", "stylua-freebsd.zip", item, t.TempDir())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "release v2.0.0 of JohnnyMorganz/StyLua has no asset stylua-freebsd.zip")
+	assert.Contains(t, err.Error(), "available assets: stylua-linux-x86_64.tar.gz, stylua-linux-aarch64.tar.gz")
+
+	// Without a release, the caller falls back to the tag archive
+	_, _, err = p.downloadAlternateAsset(context.Background(), "JohnnyMorganz/StyLua", "v9.9.9", "stylua-freebsd.zip", item, t.TempDir())
+	assert.ErrorIs(t, err, errReleaseAssetNotFound)
+
+	// A release without a matching asset fails instead of building from source
+	assert.False(t, p.Install(context.Background(), "github:JohnnyMorganz/StyLua", "v2.0.0"))
+}
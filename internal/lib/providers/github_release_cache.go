@@ -0,0 +1,172 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+)
+
+// Latest-release lookups are cached per repo. A cached release is used without
+// asking GitHub for releaseCacheBaseTTL; after that it is revalidated with its
+// ETag, which doesn't count against the API rate limit when nothing changed.
+// Every revalidation confirming the release doubles the entry's TTL, up to
+// releaseCacheMaxTTL, so repos that rarely release are rarely asked.
+const (
+	releaseCacheBaseTTL = 5 * time.Minute
+	releaseCacheMaxTTL  = 6 * time.Hour
+)
+
+// Injectable helpers for tests
+var releaseCachePath = func() string {
+	return filepath.Join(files.GetCachePath(), "github-releases.json")
+}
+var githubHTTPDo = http.DefaultClient.Do
+
+type releaseCacheEntry struct {
+	TagName   string        `json:"tag_name"`
+	ETag      string        `json:"etag,omitempty"`
+	CheckedAt time.Time     `json:"checked_at"`
+	TTL       time.Duration `json:"ttl"`
+}
+
+// releaseCacheMu serializes access to the cache file between parallel
+// installs. It's only held while the file is read or written, never during
+// a request.
+var releaseCacheMu sync.Mutex
+
+// releaseLookup is a latest-release lookup in flight
+type releaseLookup struct {
+	done chan struct{}
+	tag  string
+	err  error
+}
+
+// releaseLookups holds the lookups in flight per repo, so parallel installs
+// of the same repo share a single request
+var (
+	releaseLookupsMu sync.Mutex
+	releaseLookups   = map[string]*releaseLookup{}
+)
+
+func readReleaseCache() map[string]releaseCacheEntry {
+	cache := map[string]releaseCacheEntry{}
+	data, err := os.ReadFile(releaseCachePath())
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		Logger.Info(fmt.Sprintf("GitHub release cache: Ignoring unreadable cache: %v", err))
+		return map[string]releaseCacheEntry{}
+	}
+	return cache
+}
+
+func writeReleaseCache(cache map[string]releaseCacheEntry) {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err == nil {
+		path := releaseCachePath()
+		tmp := path + ".tmp"
+		if err = os.WriteFile(tmp, data, 0644); err == nil {
+			err = os.Rename(tmp, path)
+		}
+	}
+	if err != nil {
+		Logger.Info(fmt.Sprintf("GitHub release cache: Warning writing cache: %v", err))
+	}
+}
+
+// loadReleaseCacheEntry returns the cached release of repo
+func loadReleaseCacheEntry(repo string) (releaseCacheEntry, bool) {
+	releaseCacheMu.Lock()
+	defer releaseCacheMu.Unlock()
+	entry, ok := readReleaseCache()[repo]
+	return entry, ok
+}
+
+// storeReleaseCacheEntry caches the release of repo. The file is read again,
+// so entries other lookups stored in the meantime are kept.
+func storeReleaseCacheEntry(repo string, entry releaseCacheEntry) {
+	releaseCacheMu.Lock()
+	defer releaseCacheMu.Unlock()
+	cache := readReleaseCache()
+	cache[repo] = entry
+	writeReleaseCache(cache)
+}
+
+// cachedLatestReleaseTag returns the latest release tag of repo from the cache,
+// revalidating or fetching it from the releases API at apiURL when due.
+// Concurrent lookups of the same repo wait for the first one.
+func cachedLatestReleaseTag(repo, apiURL string) (string, error) {
+	releaseLookupsMu.Lock()
+	if l, ok := releaseLookups[repo]; ok {
+		releaseLookupsMu.Unlock()
+		<-l.done
+		return l.tag, l.err
+	}
+	l := &releaseLookup{done: make(chan struct{})}
+	releaseLookups[repo] = l
+	releaseLookupsMu.Unlock()
+
+	l.tag, l.err = lookupLatestReleaseTag(repo, apiURL)
+
+	releaseLookupsMu.Lock()
+	delete(releaseLookups, repo)
+	releaseLookupsMu.Unlock()
+	close(l.done)
+	return l.tag, l.err
+}
+
+func lookupLatestReleaseTag(repo, apiURL string) (string, error) {
+	entry, cached := loadReleaseCacheEntry(repo)
+	if files.CacheBypassed() {
+		// Fetch the release again without revalidating, --no-cache
+		cached = false
+	}
+	now := clock.Now()
+	if cached && now.Sub(entry.CheckedAt) < entry.TTL {
+		return entry.TagName, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return "", err
+	}
+	if cached && entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+	resp, err := githubAPIDo(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch release info: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached:
+		entry.CheckedAt = now
+		entry.TTL = min(entry.TTL*2, releaseCacheMaxTTL)
+	case resp.StatusCode == http.StatusOK:
+		var release struct {
+			TagName string `json:"tag_name"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+			return "", fmt.Errorf("failed to parse release info: %w", err)
+		}
+		ttl := releaseCacheBaseTTL
+		if cached && entry.TagName == release.TagName {
+			ttl = min(entry.TTL*2, releaseCacheMaxTTL)
+		}
+		entry = releaseCacheEntry{TagName: release.TagName, ETag: resp.Header.Get("ETag"), CheckedAt: now, TTL: ttl}
+	default:
+		return "", fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	}
+
+	storeReleaseCacheEntry(repo, entry)
+	return entry.TagName, nil
+}
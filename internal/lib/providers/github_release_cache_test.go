@@ -0,0 +1,160 @@
+package providers
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type releaseAPIStub struct {
+	tag      string
+	etag     string
+	requests []string
+}
+
+func (s *releaseAPIStub) do(req *http.Request) (*http.Response, error) {
+	s.requests = append(s.requests, req.Header.Get("If-None-Match"))
+	if inm := req.Header.Get("If-None-Match"); inm != "" && inm == s.etag {
+		return &http.Response{StatusCode: http.StatusNotModified, Body: io.NopCloser(strings.NewReader("")), Header: http.Header{}}, nil
+	}
+	header := http.Header{}
+	header.Set("ETag", s.etag)
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"tag_name":"` + s.tag + `"}`)), Header: header}, nil
+}
+
+func stubReleaseCache(t *testing.T, api *releaseAPIStub) *clock.Fake {
+	t.Helper()
+	prevPath, prevDo, prevLimiter := releaseCachePath, githubHTTPDo, githubLimiter
+	t.Cleanup(func() {
+		releaseCachePath, githubHTTPDo, githubLimiter = prevPath, prevDo, prevLimiter
+	})
+
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	t.Cleanup(clock.Set(fake))
+	path := filepath.Join(t.TempDir(), "github-releases.json")
+	releaseCachePath = func() string { return path }
+	githubHTTPDo = api.do
+	githubLimiter = newTokenBucket(0)
+	return fake
+}
+
+func TestCachedLatestReleaseTag(t *testing.T) {
+	api := &releaseAPIStub{tag: "v1.0.0", etag: `"a"`}
+	fake := stubReleaseCache(t, api)
+	lookup := func() string {
+		tag, err := cachedLatestReleaseTag("owner/repo", "https://api.github.com/repos/owner/repo/releases/latest")
+		require.NoError(t, err)
+		return tag
+	}
+
+	assert.Equal(t, "v1.0.0", lookup())
+	assert.Equal(t, "v1.0.0", lookup())
+	require.Len(t, api.requests, 1, "fresh entries are served from the cache")
+	assert.Empty(t, api.requests[0])
+
+	// Unchanged releases are revalidated with the ETag and the TTL doubles
+	fake.Advance(releaseCacheBaseTTL)
+	assert.Equal(t, "v1.0.0", lookup())
+	require.Len(t, api.requests, 2)
+	assert.Equal(t, `"a"`, api.requests[1])
+	assert.Equal(t, 2*releaseCacheBaseTTL, readReleaseCache()["owner/repo"].TTL)
+
+	fake.Advance(releaseCacheBaseTTL)
+	lookup()
+	assert.Len(t, api.requests, 2, "the doubled TTL isn't over yet")
+
+	// A new release resets the TTL
+	fake.Advance(releaseCacheBaseTTL)
+	api.tag, api.etag = "v1.1.0", `"b"`
+	assert.Equal(t, "v1.1.0", lookup())
+	entry := readReleaseCache()["owner/repo"]
+	assert.Equal(t, releaseCacheBaseTTL, entry.TTL)
+	assert.Equal(t, `"b"`, entry.ETag)
+}
+
+func TestCachedLatestReleaseTagBypassed(t *testing.T) {
+	api := &releaseAPIStub{tag: "v1.0.0", etag: `"a"`}
+	stubReleaseCache(t, api)
+	_, err := cachedLatestReleaseTag("owner/repo", "https://example.com")
+	require.NoError(t, err)
+
+	files.SetCacheBypass(true)
+	t.Cleanup(func() { files.SetCacheBypass(false) })
+	api.tag = "v1.1.0"
+	tag, err := cachedLatestReleaseTag("owner/repo", "https://example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.1.0", tag)
+	assert.Equal(t, []string{"", ""}, api.requests, "the release is fetched again without revalidating")
+	assert.Equal(t, "v1.1.0", readReleaseCache()["owner/repo"].TagName)
+}
+
+func TestCachedLatestReleaseTagTTLIsCapped(t *testing.T) {
+	api := &releaseAPIStub{tag: "v1.0.0", etag: `"a"`}
+	fake := stubReleaseCache(t, api)
+	for i := 0; i < 10; i++ {
+		_, err := cachedLatestReleaseTag("owner/repo", "https://example.com")
+		require.NoError(t, err)
+		fake.Advance(releaseCacheMaxTTL)
+	}
+	assert.Equal(t, releaseCacheMaxTTL, readReleaseCache()["owner/repo"].TTL)
+}
+
+func TestCachedLatestReleaseTagErrors(t *testing.T) {
+	stubReleaseCache(t, &releaseAPIStub{})
+	githubHTTPDo = func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusForbidden, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+	_, err := cachedLatestReleaseTag("owner/repo", "https://example.com")
+	assert.EqualError(t, err, "GitHub API returned status 403")
+	assert.Empty(t, readReleaseCache(), "failures aren't cached")
+}
+
+func TestCachedLatestReleaseTagRequestsRunUnlocked(t *testing.T) {
+	stubReleaseCache(t, &releaseAPIStub{})
+	var mu sync.Mutex
+	requests := map[string]int{}
+	started, release := make(chan struct{}), make(chan struct{})
+	githubHTTPDo = func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		requests[req.URL.Path]++
+		mu.Unlock()
+		if strings.Contains(req.URL.Path, "slow") {
+			started <- struct{}{}
+			<-release
+		}
+		tag := strings.Split(req.URL.Path, "/")[1]
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"tag_name":"` + tag + `"}`)), Header: http.Header{}}, nil
+	}
+
+	var wg sync.WaitGroup
+	slowTags := make([]string, 3)
+	for i := range slowTags {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slowTags[i], _ = cachedLatestReleaseTag("owner/slow", "https://example.com/slow")
+		}()
+	}
+	<-started
+	// Another repo isn't held up by the request in flight
+	tag, err := cachedLatestReleaseTag("owner/fast", "https://example.com/fast")
+	require.NoError(t, err)
+	assert.Equal(t, "fast", tag)
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, []string{"slow", "slow", "slow"}, slowTags)
+	assert.Equal(t, 1, requests["/slow"], "lookups of the same repo share the request")
+	cache := readReleaseCache()
+	assert.Equal(t, "slow", cache["owner/slow"].TagName)
+	assert.Equal(t, "fast", cache["owner/fast"].TagName, "entries stored in the meantime are kept")
+}
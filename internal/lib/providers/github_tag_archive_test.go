@@ -0,0 +1,166 @@
+package providers
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tarGz returns a .tar.gz archive of the given files
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+// stubGitHubTagInstall serves the given URLs, answering 404 for all others,
+// and returns the requested download URLs and the install methods recorded
+// per package. GitHub API lookups are served from the same URLs.
+func stubGitHubTagInstall(t *testing.T, raw string, responses map[string][]byte) (*[]string, map[string]string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("ZANA_HOME", t.TempDir())
+	t.Setenv("ZANA_CACHE", t.TempDir())
+	stubDataSharePath(t)
+
+	reg := registry_parser.NewRegistryParser(nil)
+	require.NoError(t, reg.LoadFromBytes([]byte(raw)))
+
+	prevRegistry, prevGet, prevDo, prevLimiter, prevShellOut, prevAdd, prevSetMethod := githubRegistryParser, githubHTTPGet, githubHTTPDo, githubLimiter, githubShellOut, lppGithubAdd, lppGithubSetInstallMethod
+	t.Cleanup(func() {
+		githubRegistryParser, githubHTTPGet, githubHTTPDo, githubLimiter, githubShellOut, lppGithubAdd, lppGithubSetInstallMethod = prevRegistry, prevGet, prevDo, prevLimiter, prevShellOut, prevAdd, prevSetMethod
+	})
+
+	requested := &[]string{}
+	methods := map[string]string{}
+	githubRegistryParser = func() *registry_parser.RegistryParser { return reg }
+	githubHTTPGet = func(url string) (*http.Response, error) {
+		*requested = append(*requested, url)
+		if body, ok := responses[url]; ok {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+	}
+	githubLimiter = newTokenBucket(0)
+	githubHTTPDo = func(req *http.Request) (*http.Response, error) {
+		if body, ok := responses[req.URL.String()]; ok {
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(body))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+	}
+	// tar extracts the archives, git is not available
+	githubShellOut = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, error) {
+		if cmd == "git" {
+			return 1, fmt.Errorf("git is not available in tests")
+		}
+		return prevShellOut(context.Background(), cmd, args, dir, env)
+	}
+	lppGithubAdd = func(string, string) error { return nil }
+	lppGithubSetInstallMethod = func(sourceID, method string) error {
+		methods[sourceID] = method
+		return nil
+	}
+	return requested, methods
+}
+
+func TestGitHubInstallFallsBackToTagArchive(t *testing.T) {
+	target := DetectRegistryTarget()
+	requested, methods := stubGitHubTagInstall(t, fmt.Sprintf(`[
+		{"name": "tool", "version": "v1.0.0", "source": {"id": "github:owner/tool", "asset": [{"target": %q, "file": "tool.tar.gz"}]}}
+	]`, target), map[string][]byte{
+		"https://github.com/owner/tool/archive/refs/tags/v1.0.0.tar.gz": tarGz(t, map[string]string{
+			"tool-1.0.0/README.md":  "readme",
+			"tool-1.0.0/src/main.c": "int main() {}",
+		}),
+	})
+
+	p := NewProviderGitHub()
+	assert.True(t, p.Install(context.Background(), "github:owner/tool", "v1.0.0"))
+	assert.Equal(t, []string{
+		"https://github.com/owner/tool/releases/download/v1.0.0/tool.tar.gz",
+		"https://github.com/owner/tool/archive/refs/tags/v1.0.0.tar.gz",
+	}, *requested)
+	assert.Equal(t, local_packages_parser.InstallMethodTagArchive, methods["github:owner/tool"])
+
+	repoPath := p.getRepoPath("owner/tool")
+	data, err := os.ReadFile(filepath.Join(repoPath, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "readme", string(data))
+	assert.FileExists(t, filepath.Join(repoPath, "src", "main.c"))
+}
+
+func TestGitHubInstallTagArchiveDataPackage(t *testing.T) {
+	target := DetectRegistryTarget()
+	_, methods := stubGitHubTagInstall(t, fmt.Sprintf(`[
+		{"name": "fonts", "kind": "data", "version": "2.0", "source": {"id": "github:owner/fonts", "asset": [{"target": %q, "file": "fonts.zip"}]}}
+	]`, target), map[string][]byte{
+		"https://github.com/owner/fonts/archive/refs/tags/2.0.tar.gz": tarGz(t, map[string]string{"fonts-2.0/Mono.ttf": "font"}),
+	})
+
+	assert.True(t, NewProviderGitHub().Install(context.Background(), "github:owner/fonts", "latest"))
+	assert.Equal(t, local_packages_parser.InstallMethodTagArchive, methods["github:owner/fonts"])
+	assert.FileExists(t, filepath.Join(DataPackagePath("github:owner/fonts"), "Mono.ttf"))
+}
+
+func TestGitHubInstallFallsBackToClone(t *testing.T) {
+	target := DetectRegistryTarget()
+	requested, methods := stubGitHubTagInstall(t, fmt.Sprintf(`[
+		{"name": "tool", "version": "v1.0.0", "source": {"id": "github:owner/tool", "asset": [{"target": %q, "file": "tool.tar.gz"}]}}
+	]`, target), nil)
+
+	var cloned []string
+	githubShellOut = func(_ context.Context, cmd string, args []string, _ string, _ []string) (int, error) {
+		cloned = append(cloned, cmd+" "+args[0])
+		return 1, fmt.Errorf("%s failed", cmd)
+	}
+
+	assert.False(t, NewProviderGitHub().Install(context.Background(), "github:owner/tool", "v1.0.0"))
+	assert.Contains(t, *requested, "https://github.com/owner/tool/archive/refs/tags/v1.0.0.tar.gz")
+	assert.Contains(t, cloned, "git clone")
+	assert.Empty(t, methods)
+}
+
+func TestGitHubUpdateReinstallsReleaseInstalls(t *testing.T) {
+	target := DetectRegistryTarget()
+	requested, _ := stubGitHubTagInstall(t, fmt.Sprintf(`[
+		{"name": "tool", "version": "v1.1.0", "source": {"id": "github:owner/tool", "asset": [{"target": %q, "file": "tool.tar.gz"}]}}
+	]`, target), map[string][]byte{
+		"https://github.com/owner/tool/archive/refs/tags/v1.1.0.tar.gz": tarGz(t, map[string]string{"tool-1.1.0/README.md": "v1.1.0"}),
+	})
+	prevGet := lppGithubGetBySourceID
+	t.Cleanup(func() { lppGithubGetBySourceID = prevGet })
+	lppGithubGetBySourceID = func(sourceID string) local_packages_parser.LocalPackageItem {
+		return local_packages_parser.LocalPackageItem{
+			SourceID: sourceID,
+			Version:  "v1.0.0",
+			Extras:   &local_packages_parser.PackageExtras{InstallMethod: local_packages_parser.InstallMethodTagArchive},
+		}
+	}
+
+	p := NewProviderGitHub()
+	require.NoError(t, os.MkdirAll(p.getRepoPath("owner/tool"), 0755))
+	assert.True(t, p.Update(context.Background(), "github:owner/tool"))
+	assert.Contains(t, *requested, "https://github.com/owner/tool/archive/refs/tags/v1.1.0.tar.gz")
+}
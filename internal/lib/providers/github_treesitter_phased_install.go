@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -69,7 +70,7 @@ func getGitHubProviderConcrete() (*GitHubProvider, bool) {
 // dependencies, and runs the external tree-sitter query confirmation when needed. Call this
 // before the install spinners; then run GitHubTreeSitterPhaseBuildParsers, PhaseCacheNeovimQueries
 // (external query clones show their own spinners), and PhaseRegisterPackage as wired from the CLI.
-func GitHubTreeSitterPreflightInteractive(sourceID, resolvedVersion string) error {
+func GitHubTreeSitterPreflightInteractive(ctx context.Context, sourceID, resolvedVersion string) error {
 	reg := githubRegistryParser().GetBySourceId(sourceID)
 	if !GitHubTreeSitterUsesPhasedInteractiveInstall(sourceID, reg) {
 		return fmt.Errorf("internal: GitHubTreeSitterPreflightInteractive called for non-phased package")
@@ -92,7 +93,7 @@ func GitHubTreeSitterPreflightInteractive(sourceID, resolvedVersion string) erro
 		return fmt.Errorf("invalid GitHub source id %q", sourceID)
 	}
 
-	repoPath, ver, ok := p.gitCloneAndCheckout(sourceID, repo, resolvedVersion)
+	repoPath, ver, ok := p.gitCloneAndCheckout(ctx, sourceID, repo, resolvedVersion)
 	if !ok {
 		return fmt.Errorf("git checkout failed for %s", sourceID)
 	}
@@ -140,15 +141,15 @@ func GitHubTreeSitterPreflightInteractive(sourceID, resolvedVersion string) erro
 
 // GitHubTreeSitterPhaseBuildParsers runs the tree-sitter compile step for a phased install.
 // Deferred state must match sourceID and resolvedVersion. On failure, deferred state is cleared.
-func GitHubTreeSitterPhaseBuildParsers(sourceID, resolvedVersion string) bool {
+func GitHubTreeSitterPhaseBuildParsers(ctx context.Context, sourceID, resolvedVersion string) bool {
 	d, ok := githubTreeSitterDeferredGet(sourceID, resolvedVersion)
 	if !ok {
-		Logger.Error("GitHub tree-sitter phased install: missing or stale deferred state")
+		Logger.ErrorContext(ctx, "GitHub tree-sitter phased install: missing or stale deferred state")
 		return false
 	}
-	langs, err := BuildTreeSitterParsersToCache(d.repoPath, d.sourceID, d.resolvedVersion, d.registryItem.TreeSitter.Build)
+	langs, err := BuildTreeSitterParsersToCache(ctx, d.repoPath, d.sourceID, d.resolvedVersion, d.registryItem.TreeSitter.Build)
 	if err != nil {
-		Logger.Error(fmt.Sprintf("GitHub Install: Error building tree-sitter parsers: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error building tree-sitter parsers: %v", err))
 		clearGitHubTreeSitterDeferred()
 		return false
 	}
@@ -159,13 +160,14 @@ func GitHubTreeSitterPhaseBuildParsers(sourceID, resolvedVersion string) bool {
 // GitHubTreeSitterPhaseCacheNeovimQueries caches Neovim query files (including optional external
 // git clones, each with its own spinner when stderr is a TTY). Call after a successful
 // GitHubTreeSitterPhaseBuildParsers. On failure, deferred state is cleared.
-func GitHubTreeSitterPhaseCacheNeovimQueries(sourceID, resolvedVersion string) bool {
+func GitHubTreeSitterPhaseCacheNeovimQueries(ctx context.Context, sourceID, resolvedVersion string) bool {
 	d, ok := githubTreeSitterDeferredGet(sourceID, resolvedVersion)
 	if !ok {
-		Logger.Error("GitHub tree-sitter phased install: missing or stale deferred state")
+		Logger.ErrorContext(ctx, "GitHub tree-sitter phased install: missing or stale deferred state")
 		return false
 	}
 	pins, err := cacheNeovimTreeSitterQueriesForBuiltLangs(
+		ctx,
 		d.repoPath,
 		d.sourceID,
 		d.resolvedVersion,
@@ -174,7 +176,7 @@ func GitHubTreeSitterPhaseCacheNeovimQueries(sourceID, resolvedVersion string) b
 		d.externalQueryPreflight,
 	)
 	if err != nil {
-		Logger.Error(fmt.Sprintf("GitHub Install: Error caching Neovim tree-sitter queries: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error caching Neovim tree-sitter queries: %v", err))
 		clearGitHubTreeSitterDeferred()
 		return false
 	}
@@ -184,73 +186,77 @@ func GitHubTreeSitterPhaseCacheNeovimQueries(sourceID, resolvedVersion string) b
 
 // GitHubTreeSitterPhaseRegisterPackage installs Neovim parsers from cache, registers the package,
 // and creates symlinks. Call after GitHubTreeSitterPhaseCacheNeovimQueries. Always clears deferred state.
-func GitHubTreeSitterPhaseRegisterPackage(sourceID, resolvedVersion string) bool {
+func GitHubTreeSitterPhaseRegisterPackage(ctx context.Context, sourceID, resolvedVersion string) bool {
 	d, ok := githubTreeSitterDeferredGet(sourceID, resolvedVersion)
 	if !ok {
-		Logger.Error("GitHub tree-sitter phased install: missing or stale deferred state")
+		Logger.ErrorContext(ctx, "GitHub tree-sitter phased install: missing or stale deferred state")
 		return false
 	}
 	defer clearGitHubTreeSitterDeferred()
 
 	queryOnly := queryOnlyNeovimLanguagesForInstall(d.registryItem.TreeSitter.Build, d.builtLangs)
-	if err := installNeovimParsersAndQueriesFromCache(d.sourceID, d.resolvedVersion, d.builtLangs, queryOnly); err != nil {
-		Logger.Error(fmt.Sprintf("GitHub Install: Error installing Neovim parsers: %v", err))
+	if err := installNeovimParsersAndQueriesFromCache(ctx, d.sourceID, d.resolvedVersion, d.builtLangs, queryOnly); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error installing Neovim parsers: %v", err))
 		return false
 	}
 
 	if err := lppGithubAdd(sourceID, d.resolvedVersion); err != nil {
-		Logger.Error(fmt.Sprintf("GitHub Install: Error adding package to local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error adding package to local packages: %v", err))
 		return false
 	}
 	if len(d.externalQueryPins) > 0 {
 		if err := local_packages_parser.MergePackageTreeSitterExternalQueryPins(d.sourceID, d.externalQueryPins); err != nil {
-			Logger.Info(fmt.Sprintf("GitHub Install: Warning persisting external query pins: %v", err))
+			Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Warning persisting external query pins: %v", err))
 		}
 	}
+	d.p.recordInstallMethod(ctx, d.sourceID, local_packages_parser.InstallMethodGit)
 
-	if err := d.p.createSymlinks(d.repo, d.repoPath); err != nil {
-		Logger.Info(fmt.Sprintf("GitHub Install: Warning creating symlinks: %v", err))
+	if err := d.p.createSymlinks(ctx, d.repo, d.repoPath); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Warning creating symlinks: %v", err))
 	}
 
-	Logger.Info(fmt.Sprintf("GitHub Install: Successfully installed %s@%s", d.repo, d.resolvedVersion))
+	Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Successfully installed %s@%s", d.repo, d.resolvedVersion))
 	return true
 }
 
 // GitHubTreeSitterCompleteInteractiveInstall finishes a phased GitHub tree-sitter install in one
 // call (no spinner between sub-steps). Prefer GitHubTreeSitterPhase* from the CLI when you need
 // spinners during external query clones.
-func GitHubTreeSitterCompleteInteractiveInstall(sourceID, resolvedVersion string) bool {
-	if !GitHubTreeSitterPhaseBuildParsers(sourceID, resolvedVersion) {
+func GitHubTreeSitterCompleteInteractiveInstall(ctx context.Context, sourceID, resolvedVersion string) bool {
+	if !GitHubTreeSitterPhaseBuildParsers(ctx, sourceID, resolvedVersion) {
 		return false
 	}
-	if !GitHubTreeSitterPhaseCacheNeovimQueries(sourceID, resolvedVersion) {
+	if !GitHubTreeSitterPhaseCacheNeovimQueries(ctx, sourceID, resolvedVersion) {
 		return false
 	}
-	return GitHubTreeSitterPhaseRegisterPackage(sourceID, resolvedVersion)
+	return GitHubTreeSitterPhaseRegisterPackage(ctx, sourceID, resolvedVersion)
 }
 
 // gitCloneAndCheckout mirrors the first phase of installFromGit: ensure clone, resolve version, checkout.
-func (p *GitHubProvider) gitCloneAndCheckout(sourceID, repo, version string) (repoPath string, resolvedVersion string, ok bool) {
+func (p *GitHubProvider) gitCloneAndCheckout(ctx context.Context, sourceID, repo, version string) (repoPath string, resolvedVersion string, ok bool) {
 	repoPath = p.getRepoPath(repo)
 	repoURL := p.getRepoURL(repo)
 
 	if err := githubMkdir(p.APP_PACKAGES_DIR, 0755); err != nil && !os.IsExist(err) {
-		Logger.Error(fmt.Sprintf("GitHub Install: Error creating packages directory: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error creating packages directory: %v", err))
 		return "", "", false
 	}
 
+	clone := p.clone(ctx, repoPath)
+	previousCommit := ""
 	if _, err := githubStat(repoPath); os.IsNotExist(err) {
-		Logger.Info(fmt.Sprintf("GitHub Install: Cloning %s to %s", repoURL, repoPath))
-		code, err := githubShellOut("git", []string{"clone", repoURL, repoPath}, p.APP_PACKAGES_DIR, nil)
+		Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Cloning %s to %s", repoURL, repoPath))
+		code, err := githubShellOut(ctx, "git", []string{"clone", repoURL, repoPath}, p.APP_PACKAGES_DIR, nil)
 		if err != nil || code != 0 {
-			Logger.Error(fmt.Sprintf("GitHub Install: Error cloning %s: %v", repoURL, err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error cloning %s: %v", repoURL, err))
 			return "", "", false
 		}
 	} else {
-		Logger.Info(fmt.Sprintf("GitHub Install: Updating repository at %s", repoPath))
-		code, err := githubShellOut("git", []string{"fetch", "origin"}, repoPath, nil)
-		if err != nil || code != 0 {
-			Logger.Error(fmt.Sprintf("GitHub Install: Error fetching updates: %v", err))
+		// Reuse the existing clone, fetching only what the requested version needs
+		previousCommit = clone.head()
+		Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Reusing existing clone at %s", repoPath))
+		if err := clone.fetchFor(version); err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error fetching updates: %v", err))
 			return "", "", false
 		}
 	}
@@ -258,18 +264,20 @@ func (p *GitHubProvider) gitCloneAndCheckout(sourceID, repo, version string) (re
 	resolvedVersion = version
 	if resolvedVersion == "" || resolvedVersion == "latest" {
 		var err error
-		resolvedVersion, err = p.getLatestVersionFromRepo(repoPath)
+		resolvedVersion, err = p.getLatestVersionFromRepo(ctx, repoPath)
 		if err != nil {
-			Logger.Info(fmt.Sprintf("GitHub Install: Could not determine latest version, using default branch: %v", err))
-			resolvedVersion = p.getDefaultBranch(repoPath)
+			Logger.InfoContext(ctx, fmt.Sprintf("GitHub Install: Could not determine latest version, using default branch: %v", err))
+			resolvedVersion = p.getDefaultBranch(ctx, repoPath)
 		}
 	}
 
-	code, err := githubShellOut("git", []string{"checkout", resolvedVersion}, repoPath, nil)
-	if err != nil || code != 0 {
-		Logger.Error(fmt.Sprintf("GitHub Install: Error checking out version %s: %v", resolvedVersion, err))
+	if err := clone.checkout(resolvedVersion); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitHub Install: Error checking out version %s: %v", resolvedVersion, err))
 		return "", "", false
 	}
+	if previousCommit != "" {
+		recordGitCommitRange(ctx, previousCommit, clone.head())
+	}
 
 	return repoPath, resolvedVersion, true
 }
@@ -0,0 +1,85 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/mistweaverco/zana-client/internal/lib/versioncmp"
+)
+
+// gitlabAPIURL is the base of the GitLab REST API
+const gitlabAPIURL = "https://gitlab.com/api/v4"
+
+// Injectable helpers for tests
+var gitlabGetenv = os.Getenv
+var gitlabHTTPDo = http.DefaultClient.Do
+
+// gitlabGet fetches rawURL, authenticating requests to gitlab.com with
+// GITLAB_TOKEN (a personal, project or group access token) or, inside
+// GitLab CI, with CI_JOB_TOKEN, so private projects can be installed from
+func gitlabGet(rawURL string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if u, err := url.Parse(rawURL); err == nil && u.Host == "gitlab.com" {
+		if token := gitlabGetenv("GITLAB_TOKEN"); token != "" {
+			req.Header.Set("PRIVATE-TOKEN", token)
+		} else if token := gitlabGetenv("CI_JOB_TOKEN"); token != "" {
+			req.Header.Set("JOB-TOKEN", token)
+		}
+	}
+	return gitlabHTTPDo(req)
+}
+
+// genericPackageURL returns the download URL of fileName in version of the
+// generic package name of the GitLab project repo
+func genericPackageURL(repo, name, version, fileName string) string {
+	return fmt.Sprintf("%s/projects/%s/packages/generic/%s/%s/%s",
+		gitlabAPIURL, url.PathEscape(repo), url.PathEscape(name), url.PathEscape(version), url.PathEscape(fileName))
+}
+
+// getLatestGenericPackageVersion returns the highest version of the generic
+// package name published in the GitLab project repo
+func (p *GitLabProvider) getLatestGenericPackageVersion(repo, name string) (string, error) {
+	query := url.Values{}
+	query.Set("package_type", "generic")
+	query.Set("package_name", name)
+	query.Set("per_page", "100")
+	apiURL := fmt.Sprintf("%s/projects/%s/packages?%s", gitlabAPIURL, url.PathEscape(repo), query.Encode())
+	resp, err := gitlabHTTPGet(apiURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch package versions: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GitLab API returned status %d", resp.StatusCode)
+	}
+
+	var packages []struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&packages); err != nil {
+		return "", fmt.Errorf("failed to parse package versions: %w", err)
+	}
+
+	latest := ""
+	for _, pkg := range packages {
+		// package_name matches partially, so other packages may be listed
+		if pkg.Name != name {
+			continue
+		}
+		if latest == "" || versioncmp.IsGreater(latest, pkg.Version) {
+			latest = pkg.Version
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no versions of generic package %s found", name)
+	}
+	return latest, nil
+}
@@ -0,0 +1,76 @@
+package providers
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubGitLabHTTP(t *testing.T, env map[string]string, do func(*http.Request) (*http.Response, error)) {
+	t.Helper()
+	prevGetenv, prevDo, prevGet := gitlabGetenv, gitlabHTTPDo, gitlabHTTPGet
+	t.Cleanup(func() { gitlabGetenv, gitlabHTTPDo, gitlabHTTPGet = prevGetenv, prevDo, prevGet })
+	gitlabGetenv = func(key string) string { return env[key] }
+	gitlabHTTPDo = do
+	gitlabHTTPGet = gitlabGet
+}
+
+func gitlabJSONResponse(body string) *http.Response {
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: http.Header{}}
+}
+
+func TestGenericPackageURL(t *testing.T) {
+	assert.Equal(t,
+		"https://gitlab.com/api/v4/projects/group%2Fsub%2Ftool/packages/generic/tool/1.2.0/tool-linux-amd64.tar.gz",
+		genericPackageURL("group/sub/tool", "tool", "1.2.0", "tool-linux-amd64.tar.gz"))
+}
+
+func TestGitLabGetAuthentication(t *testing.T) {
+	var headers []http.Header
+	do := func(req *http.Request) (*http.Response, error) {
+		headers = append(headers, req.Header.Clone())
+		return gitlabJSONResponse("[]"), nil
+	}
+
+	stubGitLabHTTP(t, map[string]string{"GITLAB_TOKEN": "glpat-x", "CI_JOB_TOKEN": "job"}, do)
+	_, err := gitlabGet("https://gitlab.com/api/v4/projects/1/packages")
+	require.NoError(t, err)
+	_, err = gitlabGet("https://example.com/file")
+	require.NoError(t, err)
+
+	stubGitLabHTTP(t, map[string]string{"CI_JOB_TOKEN": "job"}, do)
+	_, err = gitlabGet("https://gitlab.com/group/tool/-/releases/v1/downloads/tool.tar.gz")
+	require.NoError(t, err)
+
+	require.Len(t, headers, 3)
+	assert.Equal(t, "glpat-x", headers[0].Get("PRIVATE-TOKEN"))
+	assert.Empty(t, headers[0].Get("JOB-TOKEN"))
+	assert.Empty(t, headers[1].Get("PRIVATE-TOKEN"), "tokens are only sent to gitlab.com")
+	assert.Equal(t, "job", headers[2].Get("JOB-TOKEN"))
+}
+
+func TestGetLatestGenericPackageVersion(t *testing.T) {
+	var requested string
+	stubGitLabHTTP(t, nil, func(req *http.Request) (*http.Response, error) {
+		requested = req.URL.String()
+		return gitlabJSONResponse(`[
+			{"name": "tool", "version": "1.9.0"},
+			{"name": "tool", "version": "1.10.0"},
+			{"name": "tool-docs", "version": "2.0.0"}
+		]`), nil
+	})
+
+	p := NewProviderGitLab()
+	version, err := p.getLatestGenericPackageVersion("group/tool", "tool")
+	require.NoError(t, err)
+	assert.Equal(t, "1.10.0", version)
+	assert.Contains(t, requested, "/projects/group%2Ftool/packages?")
+	assert.Contains(t, requested, "package_type=generic")
+
+	_, err = p.getLatestGenericPackageVersion("group/tool", "other")
+	assert.EqualError(t, err, "no versions of generic package other found")
+}
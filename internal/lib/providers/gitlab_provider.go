@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,13 +9,14 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
+	"github.com/mistweaverco/zana-client/internal/lib/events"
 	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
-	"github.com/mistweaverco/zana-client/internal/lib/shell_out"
+	"github.com/mistweaverco/zana-client/internal/lib/trace"
 )
 
 type GitLabProvider struct {
@@ -25,8 +27,8 @@ type GitLabProvider struct {
 }
 
 // Injectable shell and OS helpers for tests
-var gitlabShellOut = shell_out.ShellOut
-var gitlabShellOutCapture = shell_out.ShellOutCapture
+var gitlabShellOut = shellOut
+var gitlabShellOutCapture = shellOutCapture
 var gitlabStat = os.Stat
 var gitlabMkdirAll = os.MkdirAll
 var gitlabLstat = os.Lstat
@@ -34,7 +36,7 @@ var gitlabRemove = os.Remove
 var gitlabRemoveAll = os.RemoveAll
 var gitlabSymlink = os.Symlink
 var gitlabReadDir = os.ReadDir
-var gitlabHasCommand = shell_out.HasCommand
+var gitlabHasCommand = hasCommand
 
 // Injectable local packages helpers for tests
 var lppGitlabAdd = local_packages_parser.AddLocalPackage
@@ -45,7 +47,7 @@ var lppGitlabGetDataForProvider = local_packages_parser.GetDataForProvider
 var gitlabRegistryParser = registry_parser.NewDefaultRegistryParser
 
 // Injectable HTTP client for tests
-var gitlabHTTPGet = http.Get
+var gitlabHTTPGet = gitlabGet
 
 func NewProviderGitLab() *GitLabProvider {
 	p := &GitLabProvider{}
@@ -59,16 +61,10 @@ func NewProviderGitLab() *GitLabProvider {
 func (p *GitLabProvider) getRepo(sourceID string) string {
 	// Support both legacy (pkg:gitlab/group/subgroup/project) and new (gitlab:group/subgroup/project) formats
 	// GitLab allows deeply nested paths, so we preserve the full path
-	normalized := normalizePackageID(sourceID)
+	normalized := packageid.Normalize(sourceID)
 	if strings.HasPrefix(normalized, p.PREFIX) {
 		return strings.TrimPrefix(normalized, p.PREFIX)
 	}
-	// Fallback for legacy format
-	re := regexp.MustCompile("^pkg:" + p.PROVIDER_NAME + "/(.*)")
-	matches := re.FindStringSubmatch(sourceID)
-	if len(matches) > 1 {
-		return matches[1]
-	}
 	return ""
 }
 
@@ -83,14 +79,14 @@ func (p *GitLabProvider) getRepoPath(repo string) string {
 	return filepath.Join(p.APP_PACKAGES_DIR, safeRepo)
 }
 
-func (p *GitLabProvider) checkGitAvailable() bool {
-	return gitlabHasCommand("git", []string{"--version"}, nil)
+func (p *GitLabProvider) checkGitAvailable(ctx context.Context) bool {
+	return gitlabHasCommand(ctx, "git", []string{"--version"}, nil)
 }
 
-func (p *GitLabProvider) Install(sourceID, version string) bool {
+func (p *GitLabProvider) Install(ctx context.Context, sourceID, version string) bool {
 	repo := p.getRepo(sourceID)
 	if repo == "" {
-		Logger.Error("GitLab Install: Invalid source ID format")
+		Logger.ErrorContext(ctx, "GitLab Install: Invalid source ID format")
 		return false
 	}
 
@@ -100,18 +96,18 @@ func (p *GitLabProvider) Install(sourceID, version string) bool {
 
 	// If registry has asset information, use release download method
 	if len(registryItem.Source.Asset) > 0 {
-		return p.installFromRelease(sourceID, repo, version, registryItem)
+		return p.installFromRelease(ctx, sourceID, repo, version, registryItem)
 	}
 
 	// Fallback to git clone method
-	return p.installFromGit(sourceID, repo, version)
+	return p.installFromGit(ctx, sourceID, repo, version)
 }
 
-func (p *GitLabProvider) installFromRelease(sourceID, repo, version string, registryItem registry_parser.RegistryItem) bool {
+func (p *GitLabProvider) installFromRelease(ctx context.Context, sourceID, repo, version string, registryItem registry_parser.RegistryItem) bool {
 	// Find matching asset for current platform
-	asset := FindMatchingAsset(registryItem.Source.Asset)
+	asset := FindMatchingAsset(ctx, registryItem.Source.Asset)
 	if asset == nil {
-		Logger.Error("GitLab Install: No matching asset found for current platform")
+		Logger.ErrorContext(ctx, "GitLab Install: No matching asset found for current platform")
 		return false
 	}
 
@@ -119,11 +115,18 @@ func (p *GitLabProvider) installFromRelease(sourceID, repo, version string, regi
 	resolvedVersion := version
 	if resolvedVersion == "" || resolvedVersion == "latest" {
 		resolvedVersion = registryItem.Version
-		if resolvedVersion == "" {
+		if resolvedVersion == "" && registryItem.Source.GitLabPackage != nil {
+			latestVersion, err := p.getLatestGenericPackageVersion(repo, registryItem.Source.GitLabPackage.PackageName(repo))
+			if err != nil {
+				Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Could not determine latest version: %v", err))
+				return false
+			}
+			resolvedVersion = latestVersion
+		} else if resolvedVersion == "" {
 			// Try to get latest release from GitLab API
 			latestTag, err := p.getLatestReleaseTag(repo)
 			if err != nil {
-				Logger.Error(fmt.Sprintf("GitLab Install: Could not determine latest version: %v", err))
+				Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Could not determine latest version: %v", err))
 				return false
 			}
 			resolvedVersion = latestTag
@@ -133,75 +136,105 @@ func (p *GitLabProvider) installFromRelease(sourceID, repo, version string, regi
 	// Resolve asset filename with template variables
 	assetFileName := ResolveTemplate(asset.File.String(), resolvedVersion)
 
-	// Download release asset
-	// GitLab release download URL format: https://gitlab.com/{project_path}/-/releases/{tag}/downloads/{filename}
-	releaseURL := fmt.Sprintf("https://gitlab.com/%s/-/releases/%s/downloads/%s", repo, resolvedVersion, assetFileName)
-	Logger.Info(fmt.Sprintf("GitLab Install: Downloading release asset from %s", releaseURL))
+	// Download release asset, or the file of the generic package
+	releaseURL := releaseAssetURL(p.PROVIDER_NAME, repo, resolvedVersion, assetFileName)
+	if pkg := registryItem.Source.GitLabPackage; pkg != nil {
+		releaseURL = genericPackageURL(repo, pkg.PackageName(repo), resolvedVersion, assetFileName)
+	}
+	Logger.InfoContext(ctx, fmt.Sprintf("GitLab Install: Downloading release asset from %s", releaseURL))
 
 	// Ensure packages directory exists (create parent directories if needed)
 	if err := gitlabMkdirAll(p.APP_PACKAGES_DIR, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("GitLab Install: Error creating packages directory: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Error creating packages directory: %v", err))
 		return false
 	}
 
-	// Create temporary directory for extraction
-	tempDir := filepath.Join(p.APP_PACKAGES_DIR, strings.ReplaceAll(repo, "/", "_")+"_temp")
-	if err := gitlabMkdirAll(tempDir, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("GitLab Install: Error creating temp directory: %v", err))
+	// Download and extract in a staging directory of its own
+	tempDir, err := newStagingDir(ctx, p.PROVIDER_NAME, repo)
+	if err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Error creating temp directory: %v", err))
 		return false
 	}
 	defer gitlabRemoveAll(tempDir)
 
-	// Download asset
+	// Download asset, verified against its checksum
+	download := verifiedDownload(p.downloadAsset, gitlabHTTPGet, registryItem.Source.Asset, resolvedVersion)
 	assetPath := filepath.Join(tempDir, assetFileName)
-	if err := p.downloadAsset(releaseURL, assetPath); err != nil {
-		Logger.Error(fmt.Sprintf("GitLab Install: Error downloading asset: %v", err))
+	if err := download(ctx, releaseURL, assetPath); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Error downloading asset: %v", err))
 		return false
 	}
 
 	// Extract asset
 	extractDir := filepath.Join(tempDir, "extracted")
 	if err := gitlabMkdirAll(extractDir, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("GitLab Install: Error creating extract directory: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Error creating extract directory: %v", err))
 		return false
 	}
 
-	if err := p.extractArchive(assetPath, extractDir); err != nil {
-		Logger.Error(fmt.Sprintf("GitLab Install: Error extracting asset: %v", err))
+	if err := extractOrRedownload(ctx, releaseURL, assetPath, extractDir, p.extractArchive, download); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Error extracting asset: %v", err))
+		return false
+	}
+	chaosPartialExtraction(extractDir)
+	if err := stripArchivePrefix(extractDir, asset.StripComponents, assetBinPaths(asset, registryItem)); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Error extracting asset: %v", err))
 		return false
 	}
 
+	// Data-only packages ship content without executables, nothing gets linked into bin
+	if registryItem.IsDataOnly() {
+		dest, err := installDataPackage(sourceID, extractDir)
+		if err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Error installing data package: %v", err))
+			return false
+		}
+		if err := lppGitlabAdd(sourceID, resolvedVersion); err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Error adding package to local packages: %v", err))
+			return false
+		}
+		Logger.InfoContext(ctx, fmt.Sprintf("GitLab Install: Successfully installed data package %s@%s into %s", repo, resolvedVersion, dest))
+		return true
+	}
+
 	// Find binaries and create symlinks
 	repoPath := p.getRepoPath(repo)
-	if err := gitlabMkdirAll(repoPath, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("GitLab Install: Error creating package directory: %v", err))
+	installDir, err := newVersionDir(repoPath, resolvedVersion, gitlabMkdirAll)
+	if err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Error creating package directory: %v", err))
 		return false
 	}
 
-	// Copy binaries to repo path
-	if err := p.copyBinariesFromExtract(extractDir, repoPath, asset, registryItem); err != nil {
-		Logger.Error(fmt.Sprintf("GitLab Install: Error copying binaries: %v", err))
+	// Copy binaries to the directory of the version, which replaces the
+	// installed one once it's complete
+	if err := p.copyBinariesFromExtract(ctx, extractDir, installDir, asset, registryItem); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Error copying binaries: %v", err))
+		return false
+	}
+	if err := activateVersion(sourceID, repoPath, resolvedVersion, installDir); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Error moving %s into place: %v", resolvedVersion, err))
 		return false
 	}
 
 	// Create symlinks
-	if err := p.createSymlinksFromRegistry(repo, repoPath, asset, registryItem); err != nil {
-		Logger.Info(fmt.Sprintf("GitLab Install: Warning creating symlinks: %v", err))
+	if err := p.createSymlinksFromRegistry(ctx, repo, repoPath, asset, registryItem); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("GitLab Install: Warning creating symlinks: %v", err))
 	}
 
 	// Add to local packages
 	if err := lppGitlabAdd(sourceID, resolvedVersion); err != nil {
-		Logger.Error(fmt.Sprintf("GitLab Install: Error adding package to local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Error adding package to local packages: %v", err))
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("GitLab Install: Successfully installed %s@%s from release", repo, resolvedVersion))
+	collectVersions(ctx, repoPath)
+	Logger.InfoContext(ctx, fmt.Sprintf("GitLab Install: Successfully installed %s@%s from release", repo, resolvedVersion))
 	return true
 }
 
-func (p *GitLabProvider) installFromGit(sourceID, repo, version string) bool {
-	if !p.checkGitAvailable() {
-		Logger.Error("GitLab Install: git command not found. Please install git.")
+func (p *GitLabProvider) installFromGit(ctx context.Context, sourceID, repo, version string) bool {
+	if !p.checkGitAvailable(ctx) {
+		Logger.ErrorContext(ctx, "GitLab Install: git command not found. Please install git.")
 		return false
 	}
 
@@ -210,25 +243,33 @@ func (p *GitLabProvider) installFromGit(sourceID, repo, version string) bool {
 
 	// Ensure packages directory exists
 	if err := gitlabMkdirAll(p.APP_PACKAGES_DIR, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("GitLab Install: Error creating packages directory: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Error creating packages directory: %v", err))
+		return false
+	}
+
+	// A release install can't be reused as a clone
+	if err := releaseVersion(repoPath); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Error removing %s: %v", repoPath, err))
 		return false
 	}
 
 	// Clone or update repository
+	clone := p.clone(ctx, repoPath)
+	previousCommit := ""
 	if _, err := gitlabStat(repoPath); os.IsNotExist(err) {
 		// Clone repository
-		Logger.Info(fmt.Sprintf("GitLab Install: Cloning %s to %s", repoURL, repoPath))
-		code, err := gitlabShellOut("git", []string{"clone", repoURL, repoPath}, p.APP_PACKAGES_DIR, nil)
+		Logger.InfoContext(ctx, fmt.Sprintf("GitLab Install: Cloning %s to %s", repoURL, repoPath))
+		code, err := gitlabShellOut(ctx, "git", []string{"clone", repoURL, repoPath}, p.APP_PACKAGES_DIR, nil)
 		if err != nil || code != 0 {
-			Logger.Error(fmt.Sprintf("GitLab Install: Error cloning repository: %v", err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Error cloning repository: %v", err))
 			return false
 		}
 	} else {
-		// Update existing repository
-		Logger.Info(fmt.Sprintf("GitLab Install: Updating repository at %s", repoPath))
-		code, err := gitlabShellOut("git", []string{"fetch", "origin"}, repoPath, nil)
-		if err != nil || code != 0 {
-			Logger.Error(fmt.Sprintf("GitLab Install: Error fetching updates: %v", err))
+		// Reuse the existing clone, fetching only what the requested version needs
+		previousCommit = clone.head()
+		Logger.InfoContext(ctx, fmt.Sprintf("GitLab Install: Reusing existing clone at %s", repoPath))
+		if err := clone.fetchFor(version); err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Error fetching updates: %v", err))
 			return false
 		}
 	}
@@ -238,130 +279,139 @@ func (p *GitLabProvider) installFromGit(sourceID, repo, version string) bool {
 	if resolvedVersion == "" || resolvedVersion == "latest" {
 		// Try to get latest tag from the cloned repo
 		var err error
-		resolvedVersion, err = p.getLatestVersionFromRepo(repoPath)
+		resolvedVersion, err = p.getLatestVersionFromRepo(ctx, repoPath)
 		if err != nil {
-			Logger.Info(fmt.Sprintf("GitLab Install: Could not determine latest version, using default branch: %v", err))
+			Logger.InfoContext(ctx, fmt.Sprintf("GitLab Install: Could not determine latest version, using default branch: %v", err))
 			// Try to detect default branch
-			resolvedVersion = p.getDefaultBranch(repoPath)
+			resolvedVersion = p.getDefaultBranch(ctx, repoPath)
 		}
 	}
 
 	// Checkout specific version
-	code, err := gitlabShellOut("git", []string{"checkout", resolvedVersion}, repoPath, nil)
-	if err != nil || code != 0 {
-		Logger.Error(fmt.Sprintf("GitLab Install: Error checking out version %s: %v", resolvedVersion, err))
+	if err := clone.checkout(resolvedVersion); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Error checking out version %s: %v", resolvedVersion, err))
 		return false
 	}
+	if previousCommit != "" {
+		recordGitCommitRange(ctx, previousCommit, clone.head())
+	}
 
 	// Add to local packages
 	if err := lppGitlabAdd(sourceID, resolvedVersion); err != nil {
-		Logger.Error(fmt.Sprintf("GitLab Install: Error adding package to local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Install: Error adding package to local packages: %v", err))
 		return false
 	}
 
 	// Create symlinks for binaries
-	if err := p.createSymlinks(repo, repoPath); err != nil {
-		Logger.Info(fmt.Sprintf("GitLab Install: Warning creating symlinks: %v", err))
+	if err := p.createSymlinks(ctx, repo, repoPath); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("GitLab Install: Warning creating symlinks: %v", err))
 		// Don't fail installation if symlinks fail
 	}
 
-	Logger.Info(fmt.Sprintf("GitLab Install: Successfully installed %s@%s", repo, resolvedVersion))
+	collectVersions(ctx, repoPath)
+	Logger.InfoContext(ctx, fmt.Sprintf("GitLab Install: Successfully installed %s@%s", repo, resolvedVersion))
 	return true
 }
 
-func (p *GitLabProvider) Remove(sourceID string) bool {
+func (p *GitLabProvider) Remove(ctx context.Context, sourceID string) bool {
 	repo := p.getRepo(sourceID)
 	if repo == "" {
-		Logger.Error("GitLab Remove: Invalid source ID format")
+		Logger.ErrorContext(ctx, "GitLab Remove: Invalid source ID format")
 		return false
 	}
 
 	repoPath := p.getRepoPath(repo)
-	Logger.Info(fmt.Sprintf("GitLab Remove: Removing package %s", repo))
+	Logger.InfoContext(ctx, fmt.Sprintf("GitLab Remove: Removing package %s", repo))
 
 	// Remove symlinks
-	if err := p.removeSymlinks(repo); err != nil {
-		Logger.Info(fmt.Sprintf("GitLab Remove: Warning removing symlinks: %v", err))
+	if err := p.removeSymlinks(ctx, repo); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("GitLab Remove: Warning removing symlinks: %v", err))
 	}
 
 	// Remove repository directory
 	if _, err := gitlabStat(repoPath); err == nil {
 		if err := gitlabRemoveAll(repoPath); err != nil {
-			Logger.Error(fmt.Sprintf("GitLab Remove: Error removing repository directory: %v", err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Remove: Error removing repository directory: %v", err))
 			return false
 		}
 	}
+	if err := removeVersions(repoPath); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Remove: Error removing older versions: %v", err))
+		return false
+	}
 
 	// Remove from local packages
 	if err := lppGitlabRemove(sourceID); err != nil {
-		Logger.Error(fmt.Sprintf("GitLab Remove: Error removing package from local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Remove: Error removing package from local packages: %v", err))
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("GitLab Remove: Successfully removed %s", repo))
+	Logger.InfoContext(ctx, fmt.Sprintf("GitLab Remove: Successfully removed %s", repo))
 	return true
 }
 
-func (p *GitLabProvider) Update(sourceID string) bool {
+func (p *GitLabProvider) Update(ctx context.Context, sourceID string) bool {
 	repo := p.getRepo(sourceID)
 	if repo == "" {
-		Logger.Error("GitLab Update: Invalid source ID format")
+		Logger.ErrorContext(ctx, "GitLab Update: Invalid source ID format")
 		return false
 	}
 
+	// Generic packages aren't cloned, their versions come from the package registry
+	if pkg := gitlabRegistryParser().GetBySourceId(sourceID).Source.GitLabPackage; pkg != nil {
+		latestVersion, err := p.getLatestGenericPackageVersion(repo, pkg.PackageName(repo))
+		if err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Update: Error getting latest version: %v", err))
+			return false
+		}
+		Logger.InfoContext(ctx, fmt.Sprintf("GitLab Update: Updating %s to version %s", repo, latestVersion))
+		return p.Install(ctx, sourceID, latestVersion)
+	}
+
 	repoPath := p.getRepoPath(repo)
 	if _, err := gitlabStat(repoPath); os.IsNotExist(err) {
-		Logger.Error(fmt.Sprintf("GitLab Update: Repository %s is not installed", repo))
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Update: Repository %s is not installed", repo))
 		return false
 	}
 
-	// Fetch latest changes
-	code, err := gitlabShellOut("git", []string{"fetch", "--tags", "origin"}, repoPath, nil)
-	if err != nil || code != 0 {
-		Logger.Error(fmt.Sprintf("GitLab Update: Error fetching updates: %v", err))
+	// Fetch new tags and branches into the existing clone, pruning stale branches.
+	// Install reuses the clone and won't fetch again for the resolved tag.
+	if err := p.clone(ctx, repoPath).fetchAll(); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("GitLab Update: Error fetching updates: %v", err))
 		return false
 	}
 
 	// Get latest version
-	latestVersion, err := p.getLatestVersionFromRepo(repoPath)
+	latestVersion, err := p.getLatestVersionFromRepo(ctx, repoPath)
 	if err != nil {
 		// No tags found, use default branch
-		latestVersion = p.getDefaultBranch(repoPath)
+		latestVersion = p.getDefaultBranch(ctx, repoPath)
 	}
 
-	Logger.Info(fmt.Sprintf("GitLab Update: Updating %s to version %s", repo, latestVersion))
-	return p.Install(sourceID, latestVersion)
+	Logger.InfoContext(ctx, fmt.Sprintf("GitLab Update: Updating %s to version %s", repo, latestVersion))
+	return p.Install(ctx, sourceID, latestVersion)
 }
 
-func (p *GitLabProvider) getLatestVersion(repo string) (string, error) {
+func (p *GitLabProvider) getLatestVersion(ctx context.Context, repo string) (string, error) {
 	// This is called before cloning, so we can't use the repo path
 	// Just return default branch - actual version will be resolved after clone
-	return p.getDefaultBranch(""), nil
+	return p.getDefaultBranch(ctx, ""), nil
 }
 
-func (p *GitLabProvider) getLatestVersionFromRepo(repoPath string) (string, error) {
-	// Fetch tags first
-	gitlabShellOut("git", []string{"fetch", "--tags", "origin"}, repoPath, nil)
-
-	// Get latest tag
-	code, output, err := gitlabShellOutCapture("git", []string{"describe", "--tags", "--abbrev=0"}, repoPath, nil)
-	if err != nil || code != 0 {
-		return "", fmt.Errorf("no tags found")
-	}
-
-	tag := strings.TrimSpace(output)
-	if tag == "" {
-		return "", fmt.Errorf("no tags found")
-	}
+func (p *GitLabProvider) getLatestVersionFromRepo(ctx context.Context, repoPath string) (string, error) {
+	// Callers fetch before resolving, so only the local tags are inspected
+	return p.clone(ctx, repoPath).latestTag()
+}
 
-	return tag, nil
+func (p *GitLabProvider) clone(ctx context.Context, repoPath string) gitClone {
+	return gitClone{ctx: ctx, path: repoPath, shellOut: gitlabShellOut, shellOutCapture: gitlabShellOutCapture}
 }
 
-func (p *GitLabProvider) getDefaultBranch(repoPath string) string {
+func (p *GitLabProvider) getDefaultBranch(ctx context.Context, repoPath string) string {
 	// Try to detect default branch
 	if repoPath != "" {
 		// Try to get default branch from existing repo
-		code, branchOutput, err := gitlabShellOutCapture("git", []string{"symbolic-ref", "refs/remotes/origin/HEAD"}, repoPath, nil)
+		code, branchOutput, err := gitlabShellOutCapture(ctx, "git", []string{"symbolic-ref", "refs/remotes/origin/HEAD"}, repoPath, nil)
 		if err == nil && code == 0 {
 			branch := strings.TrimSpace(branchOutput)
 			if strings.HasPrefix(branch, "refs/remotes/origin/") {
@@ -370,7 +420,7 @@ func (p *GitLabProvider) getDefaultBranch(repoPath string) string {
 		}
 		// Try common branch names
 		for _, branch := range []string{"main", "master", "trunk"} {
-			code, _, _ := gitlabShellOutCapture("git", []string{"show-ref", "--verify", "--quiet", "refs/remotes/origin/" + branch}, repoPath, nil)
+			code, _, _ := gitlabShellOutCapture(ctx, "git", []string{"show-ref", "--verify", "--quiet", "refs/remotes/origin/" + branch}, repoPath, nil)
 			if code == 0 {
 				return branch
 			}
@@ -380,8 +430,9 @@ func (p *GitLabProvider) getDefaultBranch(repoPath string) string {
 	return "main"
 }
 
-func (p *GitLabProvider) createSymlinks(_ string, repoPath string) error {
-	zanaBinDir := files.GetAppBinPath()
+func (p *GitLabProvider) createSymlinks(ctx context.Context, repo string, repoPath string) error {
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
+	filter := binFilterForSourceID(p.PREFIX + repo)
 
 	// Look for common binary locations
 	binDirs := []string{
@@ -404,6 +455,9 @@ func (p *GitLabProvider) createSymlinks(_ string, repoPath string) error {
 					if strings.HasPrefix(entry.Name(), ".") {
 						continue
 					}
+					if !filter.allows(entry.Name()) {
+						continue
+					}
 					// Create symlink
 					symlink := filepath.Join(zanaBinDir, entry.Name())
 					// Remove existing symlink if it exists
@@ -416,9 +470,9 @@ func (p *GitLabProvider) createSymlinks(_ string, repoPath string) error {
 						relPath = binPath
 					}
 					if err := gitlabSymlink(relPath, symlink); err != nil {
-						Logger.Info(fmt.Sprintf("GitLab: Warning creating symlink %s -> %s: %v", symlink, relPath, err))
+						Logger.InfoContext(ctx, fmt.Sprintf("GitLab: Warning creating symlink %s -> %s: %v", symlink, relPath, err))
 					} else {
-						Logger.Info(fmt.Sprintf("GitLab: Created symlink %s -> %s", symlink, relPath))
+						Logger.InfoContext(ctx, fmt.Sprintf("GitLab: Created symlink %s -> %s", symlink, relPath))
 					}
 					// Only process first executable found per directory to avoid clutter
 					if info.Mode()&0111 != 0 {
@@ -432,10 +486,10 @@ func (p *GitLabProvider) createSymlinks(_ string, repoPath string) error {
 	return nil
 }
 
-func (p *GitLabProvider) removeSymlinks(repo string) error {
+func (p *GitLabProvider) removeSymlinks(ctx context.Context, repo string) error {
 	repoPath := p.getRepoPath(repo)
 	repoPath = filepath.Clean(repoPath) + string(os.PathSeparator)
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 
 	// Find and remove symlinks that point to this repo
 	entries, err := gitlabReadDir(zanaBinDir)
@@ -460,7 +514,7 @@ func (p *GitLabProvider) removeSymlinks(repo string) error {
 				// Check if target is in our repo path
 				if strings.HasPrefix(target, repoPath) {
 					if err := gitlabRemove(symlink); err != nil {
-						Logger.Info(fmt.Sprintf("GitLab: Warning removing symlink %s: %v", symlink, err))
+						Logger.InfoContext(ctx, fmt.Sprintf("GitLab: Warning removing symlink %s: %v", symlink, err))
 					}
 				}
 			}
@@ -470,8 +524,8 @@ func (p *GitLabProvider) removeSymlinks(repo string) error {
 	return nil
 }
 
-func (p *GitLabProvider) Sync() bool {
-	Logger.Info("GitLab Sync: Syncing GitLab packages")
+func (p *GitLabProvider) Sync(ctx context.Context) bool {
+	Logger.InfoContext(ctx, "GitLab Sync: Syncing GitLab packages")
 	localPackages := lppGitlabGetDataForProvider(p.PROVIDER_NAME).Packages
 
 	allOk := true
@@ -483,14 +537,14 @@ func (p *GitLabProvider) Sync() bool {
 		repoPath := p.getRepoPath(repo)
 		if _, err := gitlabStat(repoPath); os.IsNotExist(err) {
 			// Re-install missing packages
-			Logger.Info(fmt.Sprintf("GitLab Sync: Re-installing missing package %s", repo))
-			if !p.Install(pkg.SourceID, pkg.Version) {
+			Logger.InfoContext(ctx, fmt.Sprintf("GitLab Sync: Re-installing missing package %s", repo))
+			if !p.Install(newOperation(ctx, actionSync, pkg.SourceID), pkg.SourceID, pkg.Version) {
 				allOk = false
 			}
 		} else {
 			// Update symlinks
-			if err := p.createSymlinks(repo, repoPath); err != nil {
-				Logger.Info(fmt.Sprintf("GitLab Sync: Warning creating symlinks for %s: %v", repo, err))
+			if err := p.createSymlinks(ctx, repo, repoPath); err != nil {
+				Logger.InfoContext(ctx, fmt.Sprintf("GitLab Sync: Warning creating symlinks for %s: %v", repo, err))
 			}
 		}
 	}
@@ -528,7 +582,11 @@ func (p *GitLabProvider) getLatestReleaseTag(repo string) (string, error) {
 }
 
 // downloadAsset downloads a file from a URL to a destination path
-func (p *GitLabProvider) downloadAsset(url, destPath string) error {
+func (p *GitLabProvider) downloadAsset(ctx context.Context, url, destPath string) error {
+	if sum, ok := restoreCachedAsset(url, destPath); ok {
+		return pinAsset(ctx, url, sum)
+	}
+
 	resp, err := gitlabHTTPGet(url)
 	if err != nil {
 		return fmt.Errorf("failed to download: %w", err)
@@ -545,21 +603,27 @@ func (p *GitLabProvider) downloadAsset(url, destPath string) error {
 	}
 	defer func() { _ = file.Close() }()
 
-	if _, err := io.Copy(file, resp.Body); err != nil {
+	sum, err := hashCopy(file, downloadBody(ctx, resp, url))
+	if err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
-
+	if err := pinAsset(ctx, url, sum); err != nil {
+		return err
+	}
 	return nil
 }
 
 // extractArchive extracts an archive (tar.gz, zip, etc.) to a destination directory
-func (p *GitLabProvider) extractArchive(archivePath, destDir string) error {
+func (p *GitLabProvider) extractArchive(ctx context.Context, archivePath, destDir string) (err error) {
+	publishState(ctx, events.StateExtracting)
+	span := trace.StartContext(ctx, "extract", "file.path", archivePath)
+	defer func() { span.End(err) }()
 	ext := filepath.Ext(archivePath)
 	baseExt := filepath.Ext(strings.TrimSuffix(archivePath, ext))
 
 	if baseExt == ".tar" && ext == ".gz" {
 		// Extract tar.gz
-		code, err := gitlabShellOut("tar", []string{"-xzf", archivePath, "-C", destDir}, "", nil)
+		code, err := gitlabShellOut(ctx, "tar", []string{"-xzf", archivePath, "-C", destDir}, "", nil)
 		if err != nil || code != 0 {
 			return fmt.Errorf("failed to extract tar.gz: %v", err)
 		}
@@ -573,7 +637,7 @@ func (p *GitLabProvider) extractArchive(archivePath, destDir string) error {
 	} else if ext == ".gz" && baseExt != ".tar" {
 		// Single .gz file - gunzip and copy
 		outputPath := filepath.Join(destDir, strings.TrimSuffix(filepath.Base(archivePath), ".gz"))
-		code, err := gitlabShellOut("sh", []string{"-c", fmt.Sprintf("gunzip -c %s > %s", archivePath, outputPath)}, "", nil)
+		code, err := gitlabShellOut(ctx, "sh", []string{"-c", fmt.Sprintf("gunzip -c %s > %s", archivePath, outputPath)}, "", nil)
 		if err != nil || code != 0 {
 			return fmt.Errorf("failed to extract gz: %v", err)
 		}
@@ -603,7 +667,7 @@ func (p *GitLabProvider) extractArchive(archivePath, destDir string) error {
 }
 
 // copyBinariesFromExtract copies binaries from extracted archive to package directory
-func (p *GitLabProvider) copyBinariesFromExtract(extractDir, repoPath string, asset *registry_parser.RegistryItemSourceAsset, registryItem registry_parser.RegistryItem) error {
+func (p *GitLabProvider) copyBinariesFromExtract(ctx context.Context, extractDir, repoPath string, asset *registry_parser.RegistryItemSourceAsset, registryItem registry_parser.RegistryItem) error {
 	// Find binaries in extracted directory
 	// Asset file might have a path prefix (e.g., "file.tar.gz:subdir/")
 	assetFile := asset.File.String()
@@ -626,7 +690,7 @@ func (p *GitLabProvider) copyBinariesFromExtract(extractDir, repoPath string, as
 			// Copy binary to repo path
 			destBinPath := filepath.Join(repoPath, filepath.Base(binPath))
 			if err := p.copyFile(sourceBinPath, destBinPath); err != nil {
-				Logger.Info(fmt.Sprintf("GitLab: Warning copying binary %s: %v", binPath, err))
+				Logger.InfoContext(ctx, fmt.Sprintf("GitLab: Warning copying binary %s: %v", binPath, err))
 			} else {
 				// Make executable
 				os.Chmod(destBinPath, 0755)
@@ -636,7 +700,7 @@ func (p *GitLabProvider) copyBinariesFromExtract(extractDir, repoPath string, as
 			if foundPath := p.findBinaryInDir(extractDir, filepath.Base(binPath)); foundPath != "" {
 				destBinPath := filepath.Join(repoPath, filepath.Base(binPath))
 				if err := p.copyFile(foundPath, destBinPath); err != nil {
-					Logger.Info(fmt.Sprintf("GitLab: Warning copying binary %s: %v", binPath, err))
+					Logger.InfoContext(ctx, fmt.Sprintf("GitLab: Warning copying binary %s: %v", binPath, err))
 				} else {
 					os.Chmod(destBinPath, 0755)
 				}
@@ -687,10 +751,14 @@ func (p *GitLabProvider) findBinaryInDir(dir, name string) string {
 }
 
 // createSymlinksFromRegistry creates symlinks based on registry bin configuration
-func (p *GitLabProvider) createSymlinksFromRegistry(_ string, repoPath string, asset *registry_parser.RegistryItemSourceAsset, registryItem registry_parser.RegistryItem) error {
-	zanaBinDir := files.GetAppBinPath()
+func (p *GitLabProvider) createSymlinksFromRegistry(ctx context.Context, _ string, repoPath string, asset *registry_parser.RegistryItemSourceAsset, registryItem registry_parser.RegistryItem) error {
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
+	filter := binFilterFor(registryItem.Source.ID, registryItem)
 
 	for binName, binTemplate := range registryItem.Bin {
+		if !filter.allows(binName) {
+			continue
+		}
 		binPath := ResolveBinPath(binTemplate, asset, binName)
 		if binPath == "" {
 			continue
@@ -719,9 +787,9 @@ func (p *GitLabProvider) createSymlinksFromRegistry(_ string, repoPath string, a
 		}
 
 		if err := gitlabSymlink(relPath, symlink); err != nil {
-			Logger.Info(fmt.Sprintf("GitLab: Warning creating symlink %s -> %s: %v", symlink, relPath, err))
+			Logger.InfoContext(ctx, fmt.Sprintf("GitLab: Warning creating symlink %s -> %s: %v", symlink, relPath, err))
 		} else {
-			Logger.Info(fmt.Sprintf("GitLab: Created symlink %s -> %s", symlink, relPath))
+			Logger.InfoContext(ctx, fmt.Sprintf("GitLab: Created symlink %s -> %s", symlink, relPath))
 		}
 	}
 
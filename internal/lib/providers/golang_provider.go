@@ -1,17 +1,17 @@
 package providers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
-	"github.com/mistweaverco/zana-client/internal/lib/shell_out"
 )
 
 type GolangProvider struct {
@@ -21,8 +21,8 @@ type GolangProvider struct {
 }
 
 // Injectable shell and OS helpers for tests
-var goShellOut = shell_out.ShellOut
-var goShellOutCapture = shell_out.ShellOutCapture
+var goShellOut = shellOut
+var goShellOutCapture = shellOutCapture
 var goCreate = os.Create
 var goStat = os.Stat
 var goMkdir = os.Mkdir
@@ -46,16 +46,10 @@ func NewProviderGolang() *GolangProvider {
 
 func (p *GolangProvider) getRepo(sourceID string) string {
 	// Support both legacy (pkg:golang/pkg) and new (golang:pkg) formats
-	normalized := normalizePackageID(sourceID)
+	normalized := packageid.Normalize(sourceID)
 	if strings.HasPrefix(normalized, p.PREFIX) {
 		return strings.TrimPrefix(normalized, p.PREFIX)
 	}
-	// Fallback for legacy format
-	re := regexp.MustCompile("^pkg:" + p.PROVIDER_NAME + "/(.*)")
-	matches := re.FindStringSubmatch(sourceID)
-	if len(matches) > 1 {
-		return matches[1]
-	}
 	return ""
 }
 
@@ -95,13 +89,17 @@ func (p *GolangProvider) createSymlink(sourceID string) error {
 	parser := registry_parser.NewDefaultRegistryParser()
 	registryItem := parser.GetBySourceId(sourceID)
 	golangBinDir := filepath.Join(p.APP_PACKAGES_DIR, "bin")
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 
 	if len(registryItem.Bin) == 0 {
 		return fmt.Errorf("error: no binary name found for package %s", sourceID)
 	}
 
+	filter := binFilterFor(sourceID, registryItem)
 	for binName := range registryItem.Bin {
+		if !filter.allows(binName) {
+			continue
+		}
 		symlink := filepath.Join(zanaBinDir, binName)
 		// Remove any existing symlink with the same name to avoid conflicts
 		if _, err := goLstat(symlink); err == nil {
@@ -142,13 +140,17 @@ func (p *GolangProvider) removeBin(sourceID string) error {
 func (p *GolangProvider) removeSymlink(sourceID string) error {
 	parser := registry_parser.NewDefaultRegistryParser()
 	registryItem := parser.GetBySourceId(sourceID)
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 
 	if len(registryItem.Bin) == 0 {
 		return fmt.Errorf("error: no binary name found for package %s", sourceID)
 	}
 
+	filter := binFilterFor(sourceID, registryItem)
 	for binName := range registryItem.Bin {
+		if !filter.allows(binName) {
+			continue
+		}
 		symlink := filepath.Join(zanaBinDir, binName)
 		if _, err := goLstat(symlink); err == nil {
 			if err := goRemove(symlink); err != nil {
@@ -190,33 +192,33 @@ func (p *GolangProvider) Clean() bool {
 	return true
 }
 
-func (p *GolangProvider) checkGoAvailable() bool {
-	checkCode, err := goShellOut("go", []string{"version"}, p.APP_PACKAGES_DIR, nil)
+func (p *GolangProvider) checkGoAvailable(ctx context.Context) bool {
+	checkCode, err := goShellOut(ctx, "go", []string{"version"}, p.APP_PACKAGES_DIR, nil)
 	return err == nil && checkCode == 0
 }
 
-func (p *GolangProvider) Sync() bool {
+func (p *GolangProvider) Sync(ctx context.Context) bool {
 	if _, err := goStat(p.APP_PACKAGES_DIR); os.IsNotExist(err) {
 		if err := goMkdir(p.APP_PACKAGES_DIR, 0755); err != nil {
 			fmt.Println("Error creating directory:", err)
 			return false
 		}
 	}
-	if !p.checkGoAvailable() {
-		Logger.Error("Golang Sync: Go is not available. Please install Go and ensure it's in your PATH.")
+	if !p.checkGoAvailable(ctx) {
+		Logger.ErrorContext(ctx, "Golang Sync: Go is not available. Please install Go and ensure it's in your PATH.")
 		return false
 	}
 	packagesFound := p.generatePackageJSON()
 	if !packagesFound {
 		return true
 	}
-	Logger.Info("Golang Sync: Generating package.json")
+	Logger.InfoContext(ctx, "Golang Sync: Generating package.json")
 	desired := local_packages_parser.GetDataForProvider("golang").Packages
 	goModPath := filepath.Join(p.APP_PACKAGES_DIR, "go.mod")
 	if _, err := goStat(goModPath); os.IsNotExist(err) {
-		initCode, err := goShellOut("go", []string{"mod", "init", "zana-golang-packages"}, p.APP_PACKAGES_DIR, nil)
+		initCode, err := goShellOut(ctx, "go", []string{"mod", "init", "zana-golang-packages"}, p.APP_PACKAGES_DIR, nil)
 		if err != nil || initCode != 0 {
-			Logger.Error(fmt.Sprintf("Error initializing Go module: %v", err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("Error initializing Go module: %v", err))
 			return false
 		}
 	}
@@ -232,75 +234,75 @@ func (p *GolangProvider) Sync() bool {
 			installed = true
 		}
 		if !installed {
-			Logger.Info(fmt.Sprintf("Golang Sync: Package %s@%s not installed, installing...", name, pkg.Version))
-			installCode, err := goShellOut("go", []string{"install", name + "@" + pkg.Version}, p.APP_PACKAGES_DIR, []string{"GOBIN=" + gobin})
+			Logger.InfoContext(ctx, fmt.Sprintf("Golang Sync: Package %s@%s not installed, installing...", name, pkg.Version))
+			installCode, err := goShellOut(newOperation(ctx, actionSync, pkg.SourceID), "go", []string{"install", name + "@" + pkg.Version}, p.APP_PACKAGES_DIR, []string{"GOBIN=" + gobin})
 			if err != nil || installCode != 0 {
-				Logger.Error(fmt.Sprintf("Error installing %s@%s: %v", name, pkg.Version, err))
+				Logger.ErrorContext(ctx, fmt.Sprintf("Error installing %s@%s: %v", name, pkg.Version, err))
 				allOk = false
 			} else {
 				installedCount++
 				if err := p.createSymlink(pkg.SourceID); err != nil {
-					Logger.Error(fmt.Sprintf("Error creating symlinks for %s: %v", name, err))
+					Logger.ErrorContext(ctx, fmt.Sprintf("Error creating symlinks for %s: %v", name, err))
 				}
 			}
 		} else {
-			Logger.Info(fmt.Sprintf("Golang Sync: Package %s@%s already installed, skipping", name, pkg.Version))
+			Logger.InfoContext(ctx, fmt.Sprintf("Golang Sync: Package %s@%s already installed, skipping", name, pkg.Version))
 			skippedCount++
 		}
 	}
-	Logger.Debug(fmt.Sprintf("Golang Sync: %d packages installed, %d packages skipped", installedCount, skippedCount))
+	Logger.DebugContext(ctx, fmt.Sprintf("Golang Sync: %d packages installed, %d packages skipped", installedCount, skippedCount))
 	return allOk
 }
 
-func (p *GolangProvider) Install(sourceID, version string) bool {
+func (p *GolangProvider) Install(ctx context.Context, sourceID, version string) bool {
 	var err error
 	if version == "latest" {
-		version, err = p.getLatestVersion(p.getRepo(sourceID))
+		version, err = latestVersionOf(ctx, ProviderGolang, p.getRepo(sourceID), p.getLatestVersion)
 		if err != nil {
-			Logger.Error("Error getting latest version for package %s: %v", sourceID, err)
+			Logger.ErrorContext(ctx, "Error getting latest version for package %s: %v", sourceID, err)
 			return false
 		}
 	}
 	if err := lppGoAdd(sourceID, version); err != nil {
 		return false
 	}
-	return p.Sync()
+	return p.Sync(ctx)
 }
 
-func (p *GolangProvider) Remove(sourceID string) bool {
+func (p *GolangProvider) Remove(ctx context.Context, sourceID string) bool {
 	packageName := p.getRepo(sourceID)
-	Logger.Debug(fmt.Sprintf("Golang Remove: Removing package %s", packageName))
+	Logger.DebugContext(ctx, fmt.Sprintf("Golang Remove: Removing package %s", packageName))
 	if err := p.removeSymlink(sourceID); err != nil {
-		Logger.Error(fmt.Sprintf("Error removing symlinks for package %s: %v", packageName, err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Error removing symlinks for package %s: %v", packageName, err))
 	}
 	if err := p.removeBin(sourceID); err != nil {
-		Logger.Error(fmt.Sprintf("Error removing binaries for package %s: %v", packageName, err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Error removing binaries for package %s: %v", packageName, err))
 	}
 	if err := lppGoRemove(sourceID); err != nil {
-		Logger.Error(fmt.Sprintf("Error removing package %s from local packages: %v", packageName, err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Error removing package %s from local packages: %v", packageName, err))
 		return false
 	}
-	Logger.Debug(fmt.Sprintf("Golang Remove: Package %s removed from local packages", packageName))
-	return p.Sync()
+	Logger.DebugContext(ctx, fmt.Sprintf("Golang Remove: Package %s removed from local packages", packageName))
+	return p.Sync(ctx)
 }
 
-func (p *GolangProvider) Update(sourceID string) bool {
+func (p *GolangProvider) Update(ctx context.Context, sourceID string) bool {
 	repo := p.getRepo(sourceID)
 	if repo == "" {
-		Logger.Error("Golang Update: Invalid source ID format")
+		Logger.ErrorContext(ctx, "Golang Update: Invalid source ID format")
 		return false
 	}
-	latestVersion, err := p.getLatestVersion(repo)
+	latestVersion, err := latestVersionOf(ctx, ProviderGolang, repo, p.getLatestVersion)
 	if err != nil {
-		Logger.Error(fmt.Sprintf("Error getting latest version for package %s: %v", repo, err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Error getting latest version for package %s: %v", repo, err))
 		return false
 	}
-	Logger.Debug(fmt.Sprintf("Golang Update: Latest version for %s is %s", repo, latestVersion))
-	return p.Install(sourceID, latestVersion)
+	Logger.DebugContext(ctx, fmt.Sprintf("Golang Update: Latest version for %s is %s", repo, latestVersion))
+	return p.Install(ctx, sourceID, latestVersion)
 }
 
-func (p *GolangProvider) getLatestVersion(packageName string) (string, error) {
-	_, output, err := goShellOutCapture("go", []string{"list", "-m", "-versions", packageName}, "", nil)
+func (p *GolangProvider) getLatestVersion(ctx context.Context, packageName string) (string, error) {
+	_, output, err := goShellOutCapture(ctx, "go", []string{"list", "-m", "-versions", packageName}, "", nil)
 	if err != nil {
 		return "", err
 	}
@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"errors"
 	"os"
 	"path/filepath"
@@ -150,7 +151,7 @@ func TestGolangSync_DirMkdirErrorAndModInitError(t *testing.T) {
 	oldMkdir := goMkdir
 	goStat = func(string) (os.FileInfo, error) { return nil, os.ErrNotExist }
 	goMkdir = func(string, os.FileMode) error { return errors.New("mkdir") }
-	assert.False(t, p.Sync())
+	assert.False(t, p.Sync(context.Background()))
 	goMkdir = oldMkdir
 	goStat = oldStat
 
@@ -168,13 +169,13 @@ func TestGolangSync_DirMkdirErrorAndModInitError(t *testing.T) {
 		return oldStat(name)
 	}
 	// Make go unavailable to force init path, but return error for init call specifically
-	goShellOut = func(cmd string, args []string, dir string, env []string) (int, error) {
+	goShellOut = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, error) {
 		if len(args) >= 2 && args[0] == "mod" && args[1] == "init" {
 			return 1, errors.New("init")
 		}
 		return 0, nil
 	}
-	assert.False(t, p.Sync())
+	assert.False(t, p.Sync(context.Background()))
 	goShellOut = oldOut
 	goStat = oldStat
 }
@@ -185,10 +186,12 @@ func TestGolangInstall_LatestFetchError(t *testing.T) {
 	_ = os.MkdirAll(p.APP_PACKAGES_DIR, 0755)
 
 	oldCap := goShellOutCapture
-	goShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 1, "", errors.New("err") }
+	goShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 1, "", errors.New("err")
+	}
 	defer func() { goShellOutCapture = oldCap }()
 
-	assert.False(t, p.Install("pkg:golang/github.com/acme/tool", "latest"))
+	assert.False(t, p.Install(context.Background(), "pkg:golang/github.com/acme/tool", "latest"))
 }
 
 func TestGolangSync_InstallErrorSetsAllOkFalse(t *testing.T) {
@@ -201,7 +204,7 @@ func TestGolangSync_InstallErrorSetsAllOkFalse(t *testing.T) {
 	_ = lppGoAdd("pkg:golang/github.com/acme/tool", "v1.0.0")
 	// stub goShellOut: go available ok, install fails
 	oldOut := goShellOut
-	goShellOut = func(cmd string, args []string, dir string, env []string) (int, error) {
+	goShellOut = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, error) {
 		if len(args) == 1 && args[0] == "version" {
 			return 0, nil
 		}
@@ -212,7 +215,7 @@ func TestGolangSync_InstallErrorSetsAllOkFalse(t *testing.T) {
 	}
 	defer func() { goShellOut = oldOut }()
 
-	assert.False(t, p.Sync())
+	assert.False(t, p.Sync(context.Background()))
 }
 func TestGolangErrorBranches(t *testing.T) {
 	_ = withTempZanaHome(t)
@@ -238,8 +241,8 @@ func TestGolangErrorBranches(t *testing.T) {
 	// Sync go unavailable
 	_ = os.MkdirAll(p.APP_PACKAGES_DIR, 0755)
 	oldGo := goShellOut
-	goShellOut = func(string, []string, string, []string) (int, error) { return 1, errors.New("x") }
-	assert.False(t, p.Sync())
+	goShellOut = func(context.Context, string, []string, string, []string) (int, error) { return 1, errors.New("x") }
+	assert.False(t, p.Sync(context.Background()))
 	goShellOut = oldGo
 }
 
@@ -251,13 +254,15 @@ func TestMoreBranchesGolang(t *testing.T) {
 	// Install: add fails -> false
 	oldAdd := lppGoAdd
 	lppGoAdd = func(string, string) error { return errors.New("add") }
-	assert.False(t, p.Install("pkg:golang/mod", "1.0.0"))
+	assert.False(t, p.Install(context.Background(), "pkg:golang/mod", "1.0.0"))
 	lppGoAdd = oldAdd
 
 	// Update: latest fetch fails -> false
 	oldCap := goShellOutCapture
-	goShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 1, "", errors.New("err") }
-	assert.False(t, p.Update("pkg:golang/mod"))
+	goShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 1, "", errors.New("err")
+	}
+	assert.False(t, p.Update(context.Background(), "pkg:golang/mod"))
 	goShellOutCapture = oldCap
 
 	// Clean loop path: setup a package and registry with bin; induce remove symlink error
@@ -278,7 +283,7 @@ func TestMoreBranchesGolang(t *testing.T) {
 	gm := filepath.Join(p.APP_PACKAGES_DIR, "go.mod")
 	_ = os.Remove(gm)
 	oldOut := goShellOut
-	goShellOut = func(cmd string, args []string, dir string, env []string) (int, error) {
+	goShellOut = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, error) {
 		if len(args) >= 2 && args[0] == "mod" && args[1] == "init" {
 			return 0, nil
 		}
@@ -286,7 +291,7 @@ func TestMoreBranchesGolang(t *testing.T) {
 	}
 	// Add desired package
 	_ = local_packages_parser.AddLocalPackage("pkg:golang/github.com/a/b", "1.0.0")
-	assert.False(t, p.Sync())
+	assert.False(t, p.Sync(context.Background()))
 	goShellOut = oldOut
 }
 
@@ -310,8 +315,10 @@ func TestGolangSyncInstallSuccess(t *testing.T) {
 	_ = os.WriteFile(filepath.Join(gobin, "y"), []byte(""), 0755)
 	// go commands succeed
 	oldOut := goShellOut
-	goShellOut = func(cmd string, args []string, dir string, env []string) (int, error) { return 0, nil }
-	assert.True(t, p.Sync())
+	goShellOut = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, error) {
+		return 0, nil
+	}
+	assert.True(t, p.Sync(context.Background()))
 	goShellOut = oldOut
 }
 
@@ -336,14 +343,16 @@ func TestGolangMorePermutations(t *testing.T) {
 	}})
 	_ = registry_parser.NewDefaultRegistryParser().GetData(true)
 	oldGo := goShellOut
-	goShellOut = func(string, []string, string, []string) (int, error) { return 0, nil }
-	assert.True(t, p.Sync())
+	goShellOut = func(context.Context, string, []string, string, []string) (int, error) { return 0, nil }
+	assert.True(t, p.Sync(context.Background()))
 	goShellOut = oldGo
 
 	// getLatestVersion invalid output
 	oldCap := goShellOutCapture
-	goShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 0, "onlyone", nil }
-	_, err := p.getLatestVersion("mod")
+	goShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 0, "onlyone", nil
+	}
+	_, err := p.getLatestVersion(context.Background(), "mod")
 	assert.Error(t, err)
 	goShellOutCapture = oldCap
 }
@@ -374,7 +383,7 @@ func TestGolangCreateSymlinkSuccessAndGeneratePackageJSONCreateErrorAndUpdateInv
 	goCreate = oldCreate
 
 	// Update invalid repo
-	assert.False(t, p.Update("pkg:golang/"))
+	assert.False(t, p.Update(context.Background(), "pkg:golang/"))
 }
 
 func TestGolangCleanMultipleAndInstallLatestSuccess(t *testing.T) {
@@ -409,9 +418,11 @@ func TestGolangCleanMultipleAndInstallLatestSuccess(t *testing.T) {
 	_ = os.WriteFile(filepath.Join(gobin, "inst"), []byte(""), 0755)
 	oldCap := goShellOutCapture
 	oldOut := goShellOut
-	goShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 0, "mod v1.0.0 v2.0.0", nil }
-	goShellOut = func(string, []string, string, []string) (int, error) { return 0, nil }
-	assert.True(t, p.Install("pkg:golang/github.com/a/inst", "latest"))
+	goShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 0, "mod v1.0.0 v2.0.0", nil
+	}
+	goShellOut = func(context.Context, string, []string, string, []string) (int, error) { return 0, nil }
+	assert.True(t, p.Install(context.Background(), "pkg:golang/github.com/a/inst", "latest"))
 	goShellOut = oldOut
 	goShellOutCapture = oldCap
 }
@@ -421,8 +432,10 @@ func TestGolangProviderBasicFlows(t *testing.T) {
 	oldOut := goShellOut
 	oldCap := goShellOutCapture
 	oldCreate := goCreate
-	goShellOut = func(cmd string, args []string, dir string, env []string) (int, error) { return 0, nil }
-	goShellOutCapture = func(cmd string, args []string, dir string, env []string) (int, string, error) {
+	goShellOut = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, error) {
+		return 0, nil
+	}
+	goShellOutCapture = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, string, error) {
 		return 0, "module x v1.0.0 v2.0.0", nil
 	}
 	goCreate = func(name string) (*os.File, error) {
@@ -449,19 +462,19 @@ func TestGolangProviderBasicFlows(t *testing.T) {
 	assert.NoError(t, os.WriteFile(filepath.Join(gobin, "tool"), []byte("#!/bin/sh\n"), 0755))
 
 	// Sync should run and create symlink
-	ok := p.Sync()
+	ok := p.Sync(context.Background())
 	assert.True(t, ok)
 
 	// Update path
-	ok = p.Update("pkg:golang/github.com/acme/tool")
+	ok = p.Update(context.Background(), "pkg:golang/github.com/acme/tool")
 	assert.True(t, ok)
 
 	// Install latest
-	ok = p.Install("pkg:golang/github.com/acme/tool", "latest")
+	ok = p.Install(context.Background(), "pkg:golang/github.com/acme/tool", "latest")
 	assert.True(t, ok)
 
 	// Remove
-	ok = p.Remove("pkg:golang/github.com/acme/tool")
+	ok = p.Remove(context.Background(), "pkg:golang/github.com/acme/tool")
 	assert.True(t, ok)
 
 	// Clean
@@ -0,0 +1,36 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+)
+
+// Injectable helpers for tests
+var historyAppend = files.AppendHistoryEntry
+
+// recordHistory appends an operation to the history log.
+// Failures are logged only, as the history is informational.
+func recordHistory(ctx context.Context, action, sourceID, version string, start time.Time, ok bool) {
+	if detectProvider(sourceID) == ProviderUnsupported {
+		return
+	}
+	duration := clock.Since(start)
+	entry := files.HistoryEntry{
+		Time:       start.UTC(),
+		Action:     action,
+		SourceID:   sourceID,
+		Version:    version,
+		DurationMs: duration.Milliseconds(),
+		Success:    ok,
+	}
+	if ok && action != files.HistoryActionRemove {
+		entry.SlowFactor = checkDurationBudget(ctx, action, sourceID, duration)
+	}
+	if err := historyAppend(entry); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("History: Warning recording %s of %s: %v", action, sourceID, err))
+	}
+}
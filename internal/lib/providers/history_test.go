@@ -0,0 +1,103 @@
+package providers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallAndRemoveRecordHistory(t *testing.T) {
+	t.Setenv("ZANA_HOME", t.TempDir())
+	SetProviderFactory(&MockProviderFactory{
+		MockNPMProvider: &MockPackageManager{
+			InstallFunc: func(_ context.Context, sourceID, version string) bool { return version != "broken" },
+			RemoveFunc:  func(_ context.Context, sourceID string) bool { return true },
+		},
+	})
+	defer ResetProviderFactory()
+
+	fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 2, 0, time.UTC))
+	fake.Step = 2 * time.Second
+	defer clock.Set(fake)()
+
+	assert.True(t, Install("npm:prettier", "3.0.0"))
+	assert.False(t, Install("npm:prettier", "broken"))
+	assert.True(t, Remove("npm:prettier"))
+	assert.False(t, Install("unsupported:pkg", "1.0.0"))
+
+	entries, err := files.ReadHistory()
+	require.NoError(t, err)
+	require.Len(t, entries, 3, "unsupported providers are not recorded")
+	assert.Equal(t, files.HistoryActionInstall, entries[0].Action)
+	assert.Equal(t, "3.0.0", entries[0].Version)
+	assert.Equal(t, 2*time.Second, entries[0].Duration())
+	assert.True(t, entries[0].Success)
+	assert.False(t, entries[1].Success)
+	assert.Equal(t, files.HistoryActionRemove, entries[2].Action)
+}
+
+func TestOperationsWrapProviderCalls(t *testing.T) {
+	t.Setenv("ZANA_HOME", t.TempDir())
+	var seen []log.Operation
+	record := func(ctx context.Context) bool {
+		op, ok := currentOperation(ctx)
+		seen = append(seen, op)
+		return ok
+	}
+	SetProviderFactory(&MockProviderFactory{
+		MockNPMProvider: &MockPackageManager{
+			InstallFunc: func(ctx context.Context, _, _ string) bool { return record(ctx) },
+			UpdateFunc:  func(ctx context.Context, _ string) bool { return record(ctx) },
+			RemoveFunc:  func(ctx context.Context, _ string) bool { return record(ctx) },
+		},
+	})
+	defer ResetProviderFactory()
+
+	assert.True(t, Install("npm:prettier", "3.0.0"))
+	ok, _, _ := Update("npm:prettier")
+	assert.True(t, ok)
+	assert.True(t, Remove("npm:prettier"))
+
+	require.Len(t, seen, 3)
+	assert.Equal(t, log.Operation{ID: seen[0].ID, Action: files.HistoryActionInstall, Package: "npm:prettier"}, seen[0])
+	assert.Equal(t, files.HistoryActionUpdate, seen[1].Action)
+	assert.Equal(t, files.HistoryActionRemove, seen[2].Action)
+	assert.NotEqual(t, seen[0].ID, seen[1].ID)
+}
+
+func TestConcurrentOperationsKeepTheirOwnContext(t *testing.T) {
+	t.Setenv("ZANA_HOME", t.TempDir())
+	var mu sync.Mutex
+	seen := map[string]string{}
+	SetProviderFactory(&MockProviderFactory{
+		MockNPMProvider: &MockPackageManager{
+			InstallFunc: func(ctx context.Context, sourceID, _ string) bool {
+				op, _ := currentOperation(ctx)
+				mu.Lock()
+				defer mu.Unlock()
+				seen[sourceID] = op.Package
+				return true
+			},
+		},
+	})
+	defer ResetProviderFactory()
+
+	var wg sync.WaitGroup
+	for _, id := range []string{"npm:prettier", "npm:eslint", "npm:typescript"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Install(id, "1.0.0")
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, map[string]string{"npm:prettier": "npm:prettier", "npm:eslint": "npm:eslint", "npm:typescript": "npm:typescript"}, seen)
+}
@@ -0,0 +1,164 @@
+package providers
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// Injectable helpers for tests
+var targetInstall = Install
+var targetRegistryParser = registry_parser.NewDefaultRegistryParser
+
+// targetDataDir is the directory in a --target directory that --no-lock
+// installs keep the package in, instead of the zana packages directory
+const targetDataDir = ".zana"
+
+// lockSnapshot holds the raw contents of the lock file so an install can be
+// undone in it without touching the entries of other packages.
+type lockSnapshot struct {
+	path   string
+	data   []byte
+	exists bool
+}
+
+func snapshotLockFile() (lockSnapshot, error) {
+	path := files.GetAppLocalPackagesFilePath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return lockSnapshot{path: path}, nil
+		}
+		return lockSnapshot{}, err
+	}
+	return lockSnapshot{path: path, data: data, exists: true}, nil
+}
+
+// useDataDir points ZANA_DATA, and with it the packages and bin
+// directories, at dir until the returned function is called
+func useDataDir(dir string) func() {
+	previous, set := os.LookupEnv("ZANA_DATA")
+	_ = os.Setenv("ZANA_DATA", dir)
+	return func() {
+		if set {
+			_ = os.Setenv("ZANA_DATA", previous)
+		} else {
+			_ = os.Unsetenv("ZANA_DATA")
+		}
+	}
+}
+
+func (s lockSnapshot) restore() error {
+	if !s.exists {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(s.path, s.data, 0644)
+}
+
+// InstallToTarget installs a package and exposes its binaries in dir instead of
+// the zana bin dir, e.g. to vendor a tool into a project's .tools/ directory.
+// Bin dir entries created by the install are removed again, entries that
+// existed before are kept. With noLock the lock file is left as it was and
+// the package is installed into dir/.zana rather than the zana packages
+// directory, so nothing outside dir refers to it.
+// It returns the names of the binaries placed in dir.
+func InstallToTarget(sourceID, version, dir string, noLock bool) ([]string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target directory %s: %w", dir, err)
+	}
+	if err := os.MkdirAll(absDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create target directory %s: %w", absDir, err)
+	}
+
+	var snapshot lockSnapshot
+	if noLock {
+		if snapshot, err = snapshotLockFile(); err != nil {
+			return nil, fmt.Errorf("failed to read lock file: %w", err)
+		}
+		defer useDataDir(filepath.Join(absDir, targetDataDir))()
+	}
+
+	registryItem := targetRegistryParser().GetBySourceId(sourceID)
+	provider, _ := packageid.Split(sourceID)
+	binDir := files.GetAppBinPathForProvider(provider)
+	existed := map[string]bool{}
+	for name := range registryItem.Bin {
+		for _, candidate := range binaryNameCandidates(name) {
+			if _, err := binariesLstat(filepath.Join(binDir, candidate)); err == nil {
+				existed[candidate] = true
+			}
+		}
+	}
+
+	ok := targetInstall(sourceID, version)
+	if noLock {
+		if err := snapshot.restore(); err != nil {
+			Logger.Info(fmt.Sprintf("InstallToTarget: Warning restoring lock file: %v", err))
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("failed to install %s", sourceID)
+	}
+
+	binaries := InstalledBinaries(registryItem)
+	for _, name := range binaries {
+		src := filepath.Join(binDir, name)
+		if err := placeBinary(src, filepath.Join(absDir, name)); err != nil {
+			return nil, fmt.Errorf("failed to place %s in %s: %w", name, absDir, err)
+		}
+		if !existed[name] {
+			if err := os.Remove(src); err != nil {
+				Logger.Info(fmt.Sprintf("InstallToTarget: Warning removing %s: %v", src, err))
+			}
+		}
+	}
+	Logger.Info(fmt.Sprintf("InstallToTarget: Placed %d binaries of %s in %s", len(binaries), sourceID, absDir))
+	return binaries, nil
+}
+
+// placeBinary links dest to what the bin dir entry src points at.
+// Entries that aren't symlinks (wrapper scripts) or can't be linked are copied.
+func placeBinary(src, dest string) error {
+	if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if link, err := os.Readlink(src); err == nil {
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(src), link)
+		}
+		if err := os.Symlink(link, dest); err == nil {
+			return nil
+		}
+	}
+	return copyExecutable(src, dest)
+}
+
+func copyExecutable(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm()|0111)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
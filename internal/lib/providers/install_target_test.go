@@ -0,0 +1,125 @@
+package providers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupInstallTarget(t *testing.T, install func(sourceID, version string) bool) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ZANA_HOME", t.TempDir())
+	reg := registry_parser.NewRegistryParser(nil)
+	require.NoError(t, reg.LoadFromBytes([]byte(`[
+		{"name": "tool", "source": {"id": "npm:tool"}, "bin": {"tool": "npm:tool", "tool-ls": "npm:tool-ls"}}
+	]`)))
+	origParser, origInstall := targetRegistryParser, targetInstall
+	targetRegistryParser = func() *registry_parser.RegistryParser { return reg }
+	targetInstall = install
+	t.Cleanup(func() { targetRegistryParser, targetInstall = origParser, origInstall })
+}
+
+// fakeToolInstall mimics a provider install: it writes the package, links
+// tool into the bin dir, creates a tool-ls wrapper script and records the lock entry.
+func fakeToolInstall(t *testing.T) func(sourceID, version string) bool {
+	return func(sourceID, version string) bool {
+		pkgDir := filepath.Join(files.GetAppPackagesPath(), "npm", "tool")
+		require.NoError(t, os.MkdirAll(pkgDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(pkgDir, "tool"), []byte("#!/bin/sh\n"), 0755))
+		binDir := files.GetAppBinPath()
+		_ = os.Symlink(filepath.Join(pkgDir, "tool"), filepath.Join(binDir, "tool"))
+		require.NoError(t, os.WriteFile(filepath.Join(binDir, "tool-ls"), []byte("#!/bin/sh\nexec tool\n"), 0755))
+		require.NoError(t, os.WriteFile(files.GetAppLocalPackagesFilePath(), []byte(`{"packages":[{"sourceId":"npm:tool","version":"1.0.0"}]}`), 0644))
+		return true
+	}
+}
+
+func TestInstallToTarget(t *testing.T) {
+	t.Run("places binaries in the target and removes new bin dir entries", func(t *testing.T) {
+		setupInstallTarget(t, fakeToolInstall(t))
+		target := filepath.Join(t.TempDir(), ".tools")
+
+		binaries, err := InstallToTarget("npm:tool", "1.0.0", target, false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"tool", "tool-ls"}, binaries)
+
+		link, err := os.Readlink(filepath.Join(target, "tool"))
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(files.GetAppPackagesPath(), "npm", "tool", "tool"), link)
+		content, err := os.ReadFile(filepath.Join(target, "tool-ls"))
+		require.NoError(t, err)
+		assert.Equal(t, "#!/bin/sh\nexec tool\n", string(content))
+
+		assert.NoFileExists(t, filepath.Join(files.GetAppBinPath(), "tool-ls"))
+		_, err = os.Lstat(filepath.Join(files.GetAppBinPath(), "tool"))
+		assert.True(t, os.IsNotExist(err))
+		assert.FileExists(t, files.GetAppLocalPackagesFilePath())
+	})
+
+	t.Run("keeps bin dir entries that existed before", func(t *testing.T) {
+		setupInstallTarget(t, fakeToolInstall(t))
+		existing := filepath.Join(files.GetAppBinPath(), "tool-ls")
+		require.NoError(t, os.WriteFile(existing, []byte("old"), 0755))
+
+		_, err := InstallToTarget("npm:tool", "1.0.0", t.TempDir(), false)
+		require.NoError(t, err)
+		assert.FileExists(t, existing)
+	})
+
+	t.Run("no-lock restores the lock file", func(t *testing.T) {
+		setupInstallTarget(t, fakeToolInstall(t))
+		lockPath := files.GetAppLocalPackagesFilePath()
+		original := []byte(`{"packages":[{"sourceId":"npm:other","version":"2.0.0"}]}`)
+		require.NoError(t, os.WriteFile(lockPath, original, 0644))
+
+		_, err := InstallToTarget("npm:tool", "1.0.0", t.TempDir(), true)
+		require.NoError(t, err)
+		content, err := os.ReadFile(lockPath)
+		require.NoError(t, err)
+		assert.Equal(t, original, content)
+	})
+
+	t.Run("no-lock removes a lock file created by the install", func(t *testing.T) {
+		setupInstallTarget(t, fakeToolInstall(t))
+
+		_, err := InstallToTarget("npm:tool", "1.0.0", t.TempDir(), true)
+		require.NoError(t, err)
+		assert.NoFileExists(t, files.GetAppLocalPackagesFilePath())
+	})
+
+	t.Run("no-lock keeps the package in the target", func(t *testing.T) {
+		setupInstallTarget(t, fakeToolInstall(t))
+		packagesPath, binPath := files.GetAppPackagesPath(), files.GetAppBinPath()
+		target := filepath.Join(t.TempDir(), ".tools")
+
+		binaries, err := InstallToTarget("npm:tool", "1.0.0", target, true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"tool", "tool-ls"}, binaries)
+
+		link, err := os.Readlink(filepath.Join(target, "tool"))
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(target, ".zana", "packages", "npm", "tool", "tool"), link)
+		assert.FileExists(t, filepath.Join(target, "tool-ls"))
+
+		// Nothing is left in the global tree
+		assert.Equal(t, packagesPath, files.GetAppPackagesPath())
+		for _, dir := range []string{packagesPath, binPath} {
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			assert.Empty(t, entries, dir)
+		}
+	})
+
+	t.Run("returns an error when the install fails", func(t *testing.T) {
+		setupInstallTarget(t, func(string, string) bool { return false })
+
+		_, err := InstallToTarget("npm:tool", "1.0.0", t.TempDir(), false)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,155 @@
+package providers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// Injectable helpers for tests
+var integrityRegistryParser = registry_parser.NewDefaultRegistryParser
+var integrityInstalledBinaries = InstalledBinaries
+var integritySetBinHashes = local_packages_parser.SetPackageBinHashes
+
+// Integrity statuses reported by CheckIntegrity
+const (
+	IntegrityOK         = "ok"
+	IntegrityModified   = "modified"
+	IntegrityUnrecorded = "unrecorded"
+)
+
+// BinIntegrityProblem is an executable that no longer matches the hash recorded at install time
+type BinIntegrityProblem struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Problem  string `json:"problem"` // "modified" or "missing"
+	Expected string `json:"expected"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+// PackageIntegrity is the result of checking the executables of one installed package
+type PackageIntegrity struct {
+	SourceID string                `json:"source_id"`
+	Version  string                `json:"version"`
+	Status   string                `json:"status"`
+	Problems []BinIntegrityProblem `json:"problems,omitempty"`
+}
+
+// hashFile returns the hex encoded sha256 of the file at path, following symlinks
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	return hashCopy(io.Discard, f)
+}
+
+// hashCopy copies src to dst and returns the hex encoded sha256 of what was
+// copied, so downloads are hashed while they are written instead of being
+// read again afterwards
+func hashCopy(dst io.Writer, src io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(dst, h), src); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// binHashes hashes the executables a package exposes in the bin dir
+func binHashes(sourceID string) map[string]string {
+	item := integrityRegistryParser().GetBySourceId(sourceID)
+	if item.Source.ID == "" {
+		item.Source.ID = sourceID
+	}
+	provider, _ := packageid.Split(sourceID)
+	binDir := files.GetAppBinPathForProvider(provider)
+	hashes := map[string]string{}
+	for _, name := range integrityInstalledBinaries(item) {
+		sum, err := hashFile(filepath.Join(binDir, name))
+		if err != nil {
+			Logger.Info(fmt.Sprintf("Integrity: Warning hashing %s of %s: %v", name, sourceID, err))
+			continue
+		}
+		hashes[name] = sum
+	}
+	return hashes
+}
+
+// recordBinHashes stores the hashes of the executables of a freshly installed
+// or updated package in the lock file. Failures are logged only, the install
+// itself has succeeded.
+func recordBinHashes(sourceID string) {
+	if err := integritySetBinHashes(sourceID, binHashes(sourceID)); err != nil {
+		Logger.Info(fmt.Sprintf("Integrity: Warning recording hashes of %s: %v", sourceID, err))
+	}
+}
+
+// CheckIntegrity re-hashes the executables of the given installed packages
+// and compares them with the hashes recorded in the lock file. Packages are
+// verified concurrently, the results keep the order of packages.
+func CheckIntegrity(packages []local_packages_parser.LocalPackageItem) []PackageIntegrity {
+	results := make([]PackageIntegrity, len(packages))
+	sem := make(chan struct{}, integrityWorkers())
+	var wg sync.WaitGroup
+	for i, pkg := range packages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = checkPackageIntegrity(pkg)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// integrityWorkers is how many packages CheckIntegrity verifies at once,
+// hashing is CPU bound so one per CPU
+func integrityWorkers() int {
+	return max(runtime.NumCPU(), 1)
+}
+
+// checkPackageIntegrity compares the executables of one package with the
+// hashes recorded in the lock file
+func checkPackageIntegrity(pkg local_packages_parser.LocalPackageItem) PackageIntegrity {
+	result := PackageIntegrity{SourceID: pkg.SourceID, Version: pkg.Version, Status: IntegrityOK}
+	if pkg.Extras == nil || pkg.Extras.BinHashes == nil {
+		result.Status = IntegrityUnrecorded
+		return result
+	}
+	provider, _ := packageid.Split(pkg.SourceID)
+	binDir := files.GetAppBinPathForProvider(provider)
+	names := make([]string, 0, len(pkg.Extras.BinHashes))
+	for name := range pkg.Extras.BinHashes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		expected := pkg.Extras.BinHashes[name]
+		path := filepath.Join(binDir, name)
+		actual, err := hashFile(path)
+		switch {
+		case err != nil:
+			result.Problems = append(result.Problems, BinIntegrityProblem{Name: name, Path: path, Problem: "missing", Expected: expected})
+		case actual != expected:
+			result.Problems = append(result.Problems, BinIntegrityProblem{Name: name, Path: path, Problem: "modified", Expected: expected, Actual: actual})
+		}
+	}
+	if len(result.Problems) > 0 {
+		result.Status = IntegrityModified
+	}
+	return result
+}
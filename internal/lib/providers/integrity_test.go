@@ -0,0 +1,95 @@
+package providers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordAndCheckIntegrity(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ZANA_HOME", t.TempDir())
+
+	binDir := files.GetAppBinPathForProvider("github")
+	assert.NoError(t, os.MkdirAll(binDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(binDir, "tool"), []byte("v1"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(binDir, "tool-ls"), []byte("ls"), 0755))
+
+	origParser, origBinaries, origSet := integrityRegistryParser, integrityInstalledBinaries, integritySetBinHashes
+	t.Cleanup(func() {
+		integrityRegistryParser, integrityInstalledBinaries, integritySetBinHashes = origParser, origBinaries, origSet
+	})
+	registryParser := registry_parser.NewRegistryParser(nil)
+	assert.NoError(t, registryParser.LoadFromBytes([]byte(`[]`)))
+	integrityRegistryParser = func() *registry_parser.RegistryParser { return registryParser }
+	integrityInstalledBinaries = func(item registry_parser.RegistryItem) []string {
+		assert.Equal(t, "github:owner/tool", item.Source.ID)
+		return []string{"tool", "tool-ls"}
+	}
+	var recorded map[string]string
+	integritySetBinHashes = func(sourceID string, hashes map[string]string) error {
+		recorded = hashes
+		return nil
+	}
+
+	recordBinHashes("github:owner/tool")
+	assert.Len(t, recorded, 2)
+	assert.Len(t, recorded["tool"], 64)
+
+	pkg := local_packages_parser.LocalPackageItem{
+		SourceID: "github:owner/tool",
+		Version:  "v1.0.0",
+		Extras:   &local_packages_parser.PackageExtras{BinHashes: recorded},
+	}
+
+	t.Run("unchanged executables are ok", func(t *testing.T) {
+		results := CheckIntegrity([]local_packages_parser.LocalPackageItem{pkg})
+		assert.Equal(t, IntegrityOK, results[0].Status)
+		assert.Empty(t, results[0].Problems)
+	})
+
+	t.Run("modified and missing executables are reported", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(filepath.Join(binDir, "tool"), []byte("tampered"), 0755))
+		assert.NoError(t, os.Remove(filepath.Join(binDir, "tool-ls")))
+
+		results := CheckIntegrity([]local_packages_parser.LocalPackageItem{pkg})
+		assert.Equal(t, IntegrityModified, results[0].Status)
+		if assert.Len(t, results[0].Problems, 2) {
+			assert.Equal(t, "modified", results[0].Problems[0].Problem)
+			assert.Equal(t, recorded["tool"], results[0].Problems[0].Expected)
+			assert.NotEqual(t, recorded["tool"], results[0].Problems[0].Actual)
+			assert.Equal(t, "missing", results[0].Problems[1].Problem)
+			assert.Equal(t, filepath.Join(binDir, "tool-ls"), results[0].Problems[1].Path)
+		}
+	})
+
+	t.Run("packages without recorded hashes are unrecorded", func(t *testing.T) {
+		results := CheckIntegrity([]local_packages_parser.LocalPackageItem{{SourceID: "npm:pkg", Version: "1.0.0"}})
+		assert.Equal(t, IntegrityUnrecorded, results[0].Status)
+	})
+
+	t.Run("results keep the order of the packages", func(t *testing.T) {
+		packages := []local_packages_parser.LocalPackageItem{}
+		for i := 0; i < 3*integrityWorkers(); i++ {
+			packages = append(packages, pkg, local_packages_parser.LocalPackageItem{SourceID: "npm:pkg", Version: "1.0.0"})
+		}
+		results := CheckIntegrity(packages)
+		for i, result := range results {
+			assert.Equal(t, packages[i].SourceID, result.SourceID)
+		}
+	})
+}
+
+func TestHashCopy(t *testing.T) {
+	var copied strings.Builder
+	sum, err := hashCopy(&copied, strings.NewReader("payload"))
+	assert.NoError(t, err)
+	assert.Equal(t, "payload", copied.String())
+	assert.Equal(t, "239f59ed55e737c77147cf55ad0c1b030b6d7ee748a7426952f9b852d5a935e5", sum)
+}
@@ -1,45 +1,48 @@
 package providers
 
-// PackageManager defines the interface for package management operations
+import "context"
+
+// PackageManager defines the interface for package management operations.
+// ctx carries the package operation the calls are made for.
 type PackageManager interface {
-	Install(sourceID, version string) bool
-	Remove(sourceID string) bool
-	Update(sourceID string) bool
-	getLatestVersion(packageName string) (string, error)
+	Install(ctx context.Context, sourceID, version string) bool
+	Remove(ctx context.Context, sourceID string) bool
+	Update(ctx context.Context, sourceID string) bool
+	getLatestVersion(ctx context.Context, packageName string) (string, error)
 }
 
 // MockPackageManager is a mock implementation for testing
 type MockPackageManager struct {
-	InstallFunc          func(sourceID, version string) bool
-	RemoveFunc           func(sourceID string) bool
-	UpdateFunc           func(sourceID string) bool
-	GetLatestVersionFunc func(packageName string) (string, error)
+	InstallFunc          func(ctx context.Context, sourceID, version string) bool
+	RemoveFunc           func(ctx context.Context, sourceID string) bool
+	UpdateFunc           func(ctx context.Context, sourceID string) bool
+	GetLatestVersionFunc func(ctx context.Context, packageName string) (string, error)
 }
 
-func (m *MockPackageManager) Install(sourceID, version string) bool {
+func (m *MockPackageManager) Install(ctx context.Context, sourceID, version string) bool {
 	if m.InstallFunc != nil {
-		return m.InstallFunc(sourceID, version)
+		return m.InstallFunc(ctx, sourceID, version)
 	}
 	return false
 }
 
-func (m *MockPackageManager) Remove(sourceID string) bool {
+func (m *MockPackageManager) Remove(ctx context.Context, sourceID string) bool {
 	if m.RemoveFunc != nil {
-		return m.RemoveFunc(sourceID)
+		return m.RemoveFunc(ctx, sourceID)
 	}
 	return false
 }
 
-func (m *MockPackageManager) Update(sourceID string) bool {
+func (m *MockPackageManager) Update(ctx context.Context, sourceID string) bool {
 	if m.UpdateFunc != nil {
-		return m.UpdateFunc(sourceID)
+		return m.UpdateFunc(ctx, sourceID)
 	}
 	return false
 }
 
-func (m *MockPackageManager) getLatestVersion(packageName string) (string, error) {
+func (m *MockPackageManager) getLatestVersion(ctx context.Context, packageName string) (string, error) {
 	if m.GetLatestVersionFunc != nil {
-		return m.GetLatestVersionFunc(packageName)
+		return m.GetLatestVersionFunc(ctx, packageName)
 	}
 	return "", nil
 }
@@ -53,6 +56,7 @@ type ProviderFactory interface {
 	CreateGitHubProvider() PackageManager
 	CreateGitLabProvider() PackageManager
 	CreateCodebergProvider() PackageManager
+	CreateGiteaProvider() PackageManager
 	CreateGemProvider() PackageManager
 	CreateComposerProvider() PackageManager
 	CreateLuaRocksProvider() PackageManager
@@ -60,6 +64,8 @@ type ProviderFactory interface {
 	CreateOpamProvider() PackageManager
 	CreateOpenVSXProvider() PackageManager
 	CreateGenericProvider() PackageManager
+	CreateLocalProvider() PackageManager
+	CreateTreeSitterProvider() PackageManager
 }
 
 // DefaultProviderFactory is the default implementation
@@ -93,6 +99,10 @@ func (f *DefaultProviderFactory) CreateCodebergProvider() PackageManager {
 	return NewProviderCodeberg()
 }
 
+func (f *DefaultProviderFactory) CreateGiteaProvider() PackageManager {
+	return NewProviderGitea()
+}
+
 func (f *DefaultProviderFactory) CreateGemProvider() PackageManager {
 	return NewProviderGem()
 }
@@ -120,3 +130,11 @@ func (f *DefaultProviderFactory) CreateOpenVSXProvider() PackageManager {
 func (f *DefaultProviderFactory) CreateGenericProvider() PackageManager {
 	return NewProviderGeneric()
 }
+
+func (f *DefaultProviderFactory) CreateLocalProvider() PackageManager {
+	return NewProviderLocal()
+}
+
+func (f *DefaultProviderFactory) CreateTreeSitterProvider() PackageManager {
+	return NewProviderTreeSitter()
+}
@@ -0,0 +1,68 @@
+package providers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+)
+
+// latestVersionTTL is how long a resolved latest version is reused. It covers
+// a batch install or update, while long-running commands such as zana watch
+// still see new releases.
+const latestVersionTTL = 5 * time.Minute
+
+type latestVersionKey struct {
+	provider Provider
+	name     string
+}
+
+// latestVersionLookup is the latest version of one package. Its mutex makes
+// parallel installs of the same package wait for one lookup instead of each
+// asking the package registry.
+type latestVersionLookup struct {
+	mu         sync.Mutex
+	version    string
+	resolvedAt time.Time
+}
+
+var latestVersions = struct {
+	sync.Mutex
+	lookups map[latestVersionKey]*latestVersionLookup
+}{lookups: map[latestVersionKey]*latestVersionLookup{}}
+
+// latestVersionOf returns the latest version of packageName from lookup,
+// reusing what was resolved for the same provider and package earlier in this
+// process, e.g. by zana update before the provider's Update asks again.
+// Failed lookups are not remembered.
+func latestVersionOf(ctx context.Context, provider Provider, packageName string, lookup func(context.Context, string) (string, error)) (string, error) {
+	key := latestVersionKey{provider: provider, name: packageName}
+	latestVersions.Lock()
+	l, ok := latestVersions.lookups[key]
+	if !ok {
+		l = &latestVersionLookup{}
+		latestVersions.lookups[key] = l
+	}
+	latestVersions.Unlock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := clock.Now()
+	if l.version != "" && now.Sub(l.resolvedAt) < latestVersionTTL {
+		return l.version, nil
+	}
+	version, err := lookup(ctx, packageName)
+	if err != nil {
+		return version, err
+	}
+	l.version, l.resolvedAt = version, now
+	return version, nil
+}
+
+// resetLatestVersions forgets the latest versions resolved so far
+func resetLatestVersions() {
+	latestVersions.Lock()
+	defer latestVersions.Unlock()
+	latestVersions.lookups = map[latestVersionKey]*latestVersionLookup{}
+}
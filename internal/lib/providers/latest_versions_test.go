@@ -0,0 +1,74 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatestVersionOf(t *testing.T) {
+	resetLatestVersions()
+	t.Cleanup(resetLatestVersions)
+	fake := clock.NewFake(time.Date(2026, 10, 15, 10, 0, 0, 0, time.UTC))
+	t.Cleanup(clock.Set(fake))
+
+	var calls atomic.Int32
+	lookup := func(_ context.Context, name string) (string, error) {
+		calls.Add(1)
+		return name + "-1.0.0", nil
+	}
+
+	t.Run("lookups are reused per provider and package", func(t *testing.T) {
+		v, err := latestVersionOf(context.Background(), ProviderNPM, "prettier", lookup)
+		assert.NoError(t, err)
+		assert.Equal(t, "prettier-1.0.0", v)
+		v, _ = latestVersionOf(context.Background(), ProviderNPM, "prettier", lookup)
+		assert.Equal(t, "prettier-1.0.0", v)
+		assert.Equal(t, int32(1), calls.Load())
+
+		_, _ = latestVersionOf(context.Background(), ProviderPyPi, "prettier", lookup)
+		_, _ = latestVersionOf(context.Background(), ProviderNPM, "eslint", lookup)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("parallel lookups of a package ask once", func(t *testing.T) {
+		calls.Store(0)
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = latestVersionOf(context.Background(), ProviderCargo, "ripgrep", lookup)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("failed lookups are asked again", func(t *testing.T) {
+		failing := func(context.Context, string) (string, error) {
+			calls.Add(1)
+			return "", errors.New("registry down")
+		}
+		calls.Store(0)
+		_, err := latestVersionOf(context.Background(), ProviderGolang, "gopls", failing)
+		assert.EqualError(t, err, "registry down")
+		v, err := latestVersionOf(context.Background(), ProviderGolang, "gopls", lookup)
+		assert.NoError(t, err)
+		assert.Equal(t, "gopls-1.0.0", v)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("lookups expire after the TTL", func(t *testing.T) {
+		calls.Store(0)
+		fake.Advance(latestVersionTTL)
+		_, _ = latestVersionOf(context.Background(), ProviderNPM, "prettier", lookup)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
@@ -0,0 +1,129 @@
+package providers
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// lazyShimMarker identifies lazy shims, so they are never mistaken for
+// the executable they stand in for
+const lazyShimMarker = "zana-lazy-shim"
+
+// The shims are generic: they pass the name they were invoked as to zana run,
+// which installs the package providing it and runs the real executable
+const lazyShimUnix = "#!/bin/sh\n# " + lazyShimMarker + "\nexec zana run -- \"${0##*/}\" \"$@\"\n"
+const lazyShimWindows = "@echo off\r\nrem " + lazyShimMarker + "\r\nzana run -- %~n0 %*\r\n"
+
+// Injectable helpers for tests
+var lazyShimRegistryParser = registry_parser.NewDefaultRegistryParser
+var lazyShimLocalPackages = local_packages_parser.GetData
+
+// LazyExecutable is an executable declared by a package in zana-lock.json
+type LazyExecutable struct {
+	Name     string
+	SourceID string
+	Version  string
+}
+
+// IsLazyShim reports whether the file at path is a lazy shim
+func IsLazyShim(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, 128)
+	n, _ := f.Read(head)
+	return bytes.Contains(head[:n], []byte(lazyShimMarker))
+}
+
+// lazyExecutables returns the executables of the packages in zana-lock.json
+// according to their registry bin maps and bin filters, sorted by name
+func lazyExecutables() []LazyExecutable {
+	registry := lazyShimRegistryParser()
+	out := []LazyExecutable{}
+	for _, pkg := range lazyShimLocalPackages(false).Packages {
+		sourceID := strings.TrimSpace(pkg.SourceID)
+		if sourceID == "" {
+			continue
+		}
+		item := registry.GetBySourceId(sourceID)
+		filter := binFilterFor(sourceID, item)
+		for name := range item.Bin {
+			if filter.allows(name) {
+				out = append(out, LazyExecutable{Name: name, SourceID: sourceID, Version: strings.TrimSpace(pkg.Version)})
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// LazyExecutableFor returns the package in zana-lock.json providing the executable name
+func LazyExecutableFor(name string) (LazyExecutable, bool) {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	for _, e := range lazyExecutables() {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return LazyExecutable{}, false
+}
+
+// FindExecutable returns the path of the executable name in the bin
+// directories in PATH precedence order, ignoring lazy shims
+func FindExecutable(name string) (string, bool) {
+	for _, dir := range BinPathsInPrecedenceOrder() {
+		for _, candidate := range binaryNameCandidates(name) {
+			path := filepath.Join(dir, candidate)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() && !IsLazyShim(path) {
+				return path, true
+			}
+		}
+	}
+	return "", false
+}
+
+// WriteLazyShims writes a lazy shim for every executable of the packages in
+// zana-lock.json that has no entry in the bin directory yet. Installing the
+// package replaces its shims with the real executables.
+// It returns the names of the shims written.
+func WriteLazyShims() ([]string, error) {
+	written := []string{}
+	for _, e := range lazyExecutables() {
+		provider, _ := packageid.Split(e.SourceID)
+		binDir := files.GetAppBinPathForProvider(provider)
+		name, content := e.Name, lazyShimUnix
+		if binariesGOOS == "windows" {
+			name, content = e.Name+".cmd", lazyShimWindows
+		}
+		exists := false
+		for _, candidate := range binaryNameCandidates(e.Name) {
+			if _, err := os.Lstat(filepath.Join(binDir, candidate)); err == nil {
+				exists = true
+				break
+			}
+		}
+		if exists {
+			continue
+		}
+		if err := os.MkdirAll(binDir, 0755); err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(filepath.Join(binDir, name), []byte(content), 0755); err != nil {
+			return written, fmt.Errorf("failed to write shim for %s: %w", e.Name, err)
+		}
+		Logger.Info(fmt.Sprintf("Lazy shims: Wrote shim for %s of %s", e.Name, e.SourceID))
+		written = append(written, e.Name)
+	}
+	return written, nil
+}
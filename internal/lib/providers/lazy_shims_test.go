@@ -0,0 +1,83 @@
+package providers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupLazyShimTest(t *testing.T) string {
+	t.Helper()
+	t.Setenv("ZANA_HOME", t.TempDir())
+	t.Setenv("ZANA_DATA", t.TempDir())
+	t.Setenv("ZANA_BIN_LAYOUT", "flat")
+	stubBinFilterConfig(t, nil)
+
+	reg := registry_parser.NewRegistryParser(nil)
+	require.NoError(t, reg.LoadFromBytes([]byte(`[
+		{"name": "prettier", "source": {"id": "npm:prettier"}, "bin": {"prettier": "npm:prettier"}},
+		{"name": "typescript", "source": {"id": "npm:typescript"}, "bin": {"tsc": "npm:tsc", "tsserver": "npm:tsserver"},
+		 "bin_filter": {"exclude": ["tsserver"]}}
+	]`)))
+	origParser, origLocal, origGOOS := lazyShimRegistryParser, lazyShimLocalPackages, binariesGOOS
+	lazyShimRegistryParser = func() *registry_parser.RegistryParser { return reg }
+	lazyShimLocalPackages = func(bool) local_packages_parser.LocalPackageRoot {
+		return local_packages_parser.LocalPackageRoot{Packages: []local_packages_parser.LocalPackageItem{
+			{SourceID: "npm:prettier", Version: "3.3.0"},
+			{SourceID: "npm:typescript", Version: "5.4.0"},
+		}}
+	}
+	binariesGOOS = "linux"
+	t.Cleanup(func() {
+		lazyShimRegistryParser, lazyShimLocalPackages, binariesGOOS = origParser, origLocal, origGOOS
+	})
+	return files.GetAppBinPath()
+}
+
+func TestWriteLazyShims(t *testing.T) {
+	binDir := setupLazyShimTest(t)
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "prettier"), []byte("#!/bin/sh\n"), 0755))
+
+	written, err := WriteLazyShims()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tsc"}, written)
+	assert.True(t, IsLazyShim(filepath.Join(binDir, "tsc")))
+	assert.False(t, IsLazyShim(filepath.Join(binDir, "prettier")))
+	assert.NoFileExists(t, filepath.Join(binDir, "tsserver"))
+
+	// Existing shims are left alone
+	written, err = WriteLazyShims()
+	require.NoError(t, err)
+	assert.Empty(t, written)
+}
+
+func TestLazyExecutableFor(t *testing.T) {
+	setupLazyShimTest(t)
+
+	exe, ok := LazyExecutableFor("tsc")
+	require.True(t, ok)
+	assert.Equal(t, LazyExecutable{Name: "tsc", SourceID: "npm:typescript", Version: "5.4.0"}, exe)
+
+	_, ok = LazyExecutableFor("tsserver")
+	assert.False(t, ok)
+}
+
+func TestFindExecutableIgnoresShims(t *testing.T) {
+	binDir := setupLazyShimTest(t)
+	_, err := WriteLazyShims()
+	require.NoError(t, err)
+
+	_, ok := FindExecutable("tsc")
+	assert.False(t, ok)
+
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "tsc"), []byte("#!/bin/sh\n"), 0755))
+	path, ok := FindExecutable("tsc")
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(binDir, "tsc"), path)
+}
@@ -0,0 +1,134 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// Injectable helpers for tests
+var linkRegistryParser = registry_parser.NewDefaultRegistryParser
+var linkGetBySourceID = local_packages_parser.GetBySourceId
+var linkSetUnlinked = local_packages_parser.SetPackageUnlinked
+var linkSetBinHashes = local_packages_parser.SetPackageBinHashes
+var linkSyncProvider = syncProvider
+
+// unlinkedInstalls holds the packages being installed with InstallUnlinked,
+// which have no lock entry to carry the unlinked flag yet
+var unlinkedInstalls = map[string]bool{}
+var unlinkedInstallsMu sync.Mutex
+
+// isUnlinked reports whether the executables of sourceID are kept out of the bin dir
+func isUnlinked(sourceID string) bool {
+	sourceID = packageid.Normalize(sourceID)
+	unlinkedInstallsMu.Lock()
+	installing := unlinkedInstalls[sourceID]
+	unlinkedInstallsMu.Unlock()
+	if installing {
+		return true
+	}
+	item := linkGetBySourceID(sourceID)
+	return item.Extras != nil && item.Extras.Unlinked
+}
+
+// InstallUnlinked installs a package like Install, but without linking its
+// executables into the bin dir; zana-lock.json records the package as
+// unlinked so sync and update keep it that way until Link is called
+func InstallUnlinked(sourceId string, version string) bool {
+	key := packageid.Normalize(sourceId)
+	unlinkedInstallsMu.Lock()
+	unlinkedInstalls[key] = true
+	unlinkedInstallsMu.Unlock()
+	defer func() {
+		unlinkedInstallsMu.Lock()
+		delete(unlinkedInstalls, key)
+		unlinkedInstallsMu.Unlock()
+	}()
+
+	if !Install(sourceId, version) {
+		return false
+	}
+	// Reinstalling a linked package leaves its previous bin entries behind
+	if _, err := Unlink(sourceId); err != nil {
+		Logger.Error(fmt.Sprintf("Install: Error marking %s as unlinked: %v", sourceId, err))
+		return false
+	}
+	return true
+}
+
+// Unlink removes the bin entries of an installed package and records it as
+// unlinked in zana-lock.json. It returns the names of the removed entries.
+func Unlink(sourceId string) ([]string, error) {
+	if err := linkSetUnlinked(sourceId, true); err != nil {
+		return nil, err
+	}
+	removed, err := removeBinEntries(sourceId)
+	if err != nil {
+		return removed, err
+	}
+	// There are no executables left to check with zana ls --check-integrity
+	if err := linkSetBinHashes(sourceId, nil); err != nil {
+		return removed, err
+	}
+	Logger.Info(fmt.Sprintf("Unlink: Removed bin entries of %s: %v", sourceId, removed))
+	return removed, nil
+}
+
+// Link links the executables of an unlinked package into the bin dir again by
+// syncing its provider. It returns the names of the package's bin entries.
+func Link(sourceId string) ([]string, error) {
+	if err := linkSetUnlinked(sourceId, false); err != nil {
+		return nil, err
+	}
+	name, _ := packageid.Split(packageid.Normalize(sourceId))
+	if !syncInOperation(name, func(ctx context.Context) bool { return linkSyncProvider(ctx, detectProvider(sourceId)) }) {
+		return nil, fmt.Errorf("syncing the %s packages failed", name)
+	}
+	recordBinHashes(sourceId)
+	linked := InstalledBinaries(linkRegistryParser().GetBySourceId(sourceId))
+	Logger.Info(fmt.Sprintf("Link: Linked bin entries of %s: %v", sourceId, linked))
+	return linked, nil
+}
+
+// removeBinEntries removes the entries a package exposes in the bin dir.
+// Symlinks into another provider's packages (a name clash in the flat bin
+// layout) are left alone.
+func removeBinEntries(sourceID string) ([]string, error) {
+	item := linkRegistryParser().GetBySourceId(sourceID)
+	provider, _ := packageid.Split(packageid.Normalize(sourceID))
+	binDir := files.GetAppBinPathForProvider(provider)
+	removed := []string{}
+	for _, name := range InstalledBinaries(item) {
+		path := filepath.Join(binDir, name)
+		if owner := binOwnerFromSymlink(path); owner != "" && owner != provider {
+			continue
+		}
+		if err := os.Remove(path); os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		removed = append(removed, name)
+	}
+	return removed, nil
+}
+
+// syncProvider runs the Sync of a single provider, which (re)creates the bin
+// entries of its installed packages
+func syncProvider(ctx context.Context, provider Provider) bool {
+	pm := packageManagerFor(provider)
+	if provider == ProviderGeneric {
+		pm = getGenericProvider()
+	}
+	if s, ok := pm.(interface{ Sync(context.Context) bool }); ok {
+		return s.Sync(ctx)
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnlinkAndLink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses symlinks")
+	}
+	_ = withTempZanaHome(t)
+	writeRegistry(t, []registry_parser.RegistryItem{{
+		Name: "prettier", Source: registry_parser.RegistryItemSource{ID: "pkg:npm/prettier"},
+		Bin: map[string]string{"prettier": "npm:prettier", "eslint": "npm:eslint"},
+	}})
+	require.NoError(t, local_packages_parser.AddLocalPackage("npm:prettier", "3.0.0"))
+	require.NoError(t, local_packages_parser.SetPackageBinHashes("npm:prettier", map[string]string{"prettier": "ab"}))
+
+	binDir := files.GetAppBinPathForProvider("npm")
+	require.NoError(t, os.MkdirAll(binDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "prettier"), []byte("#!/bin/sh\n"), 0755))
+	// eslint is a symlink into another provider's packages and must survive
+	other := filepath.Join(files.GetAppPackagesPath(), "pypi", "eslint")
+	require.NoError(t, os.Symlink(other, filepath.Join(binDir, "eslint")))
+
+	assert.True(t, binFilterForSourceID("npm:prettier").allows("prettier"))
+	removed, err := Unlink("npm:prettier")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prettier"}, removed)
+	assert.NoFileExists(t, filepath.Join(binDir, "prettier"))
+	_, err = os.Lstat(filepath.Join(binDir, "eslint"))
+	assert.NoError(t, err)
+
+	item := local_packages_parser.GetBySourceId("npm:prettier")
+	require.NotNil(t, item.Extras)
+	assert.True(t, item.Extras.Unlinked)
+	assert.Nil(t, item.Extras.BinHashes)
+	assert.False(t, binFilterForSourceID("npm:prettier").allows("prettier"))
+
+	origSync := linkSyncProvider
+	t.Cleanup(func() { linkSyncProvider = origSync })
+	var synced []Provider
+	linkSyncProvider = func(_ context.Context, p Provider) bool {
+		synced = append(synced, p)
+		return os.WriteFile(filepath.Join(binDir, "prettier"), []byte("#!/bin/sh\n"), 0755) == nil
+	}
+	linked, err := Link("npm:prettier")
+	require.NoError(t, err)
+	assert.Equal(t, []Provider{ProviderNPM}, synced)
+	assert.Contains(t, linked, "prettier")
+	assert.False(t, local_packages_parser.GetBySourceId("npm:prettier").Extras.Unlinked)
+	assert.True(t, binFilterForSourceID("npm:prettier").allows("prettier"))
+
+	t.Run("failing sync and unknown packages", func(t *testing.T) {
+		linkSyncProvider = func(context.Context, Provider) bool { return false }
+		_, err := Link("npm:prettier")
+		assert.ErrorContains(t, err, "syncing the npm packages failed")
+		_, err = Unlink("npm:unknown")
+		assert.Error(t, err)
+	})
+}
+
+func TestInstallUnlinked(t *testing.T) {
+	_ = withTempZanaHome(t)
+	var unlinkedDuringInstall bool
+	SetProviderFactory(&MockProviderFactory{
+		MockNPMProvider: &MockPackageManager{
+			InstallFunc: func(_ context.Context, sourceID, version string) bool {
+				unlinkedDuringInstall = !binFilterForSourceID(sourceID).allows("prettier")
+				return local_packages_parser.AddLocalPackage(sourceID, version) == nil
+			},
+		},
+	})
+	defer ResetProviderFactory()
+
+	assert.True(t, InstallUnlinked("npm:prettier", "3.0.0"))
+	assert.True(t, unlinkedDuringInstall, "no executables are linked during the install")
+	assert.True(t, isUnlinked("npm:prettier"))
+	assert.False(t, isUnlinked("npm:other"))
+}
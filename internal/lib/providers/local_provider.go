@@ -0,0 +1,130 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+)
+
+// LocalPackageVersion is the version recorded for adopted executables,
+// which have no upstream to version them
+const LocalPackageVersion = "local"
+
+// LocalProvider tracks executables that were put into the zana bin dir by
+// hand and adopted with zana adopt. Nothing is downloaded for them, the lock
+// file entry only records that zana owns the bin entry.
+type LocalProvider struct {
+	PREFIX        string
+	PROVIDER_NAME string
+}
+
+// Injectable helpers for tests
+var localLstat = os.Lstat
+var localRemove = os.Remove
+var lppLocalAdd = local_packages_parser.AddLocalPackage
+var lppLocalRemove = local_packages_parser.RemoveLocalPackage
+
+func NewProviderLocal() *LocalProvider {
+	p := &LocalProvider{}
+	p.PROVIDER_NAME = "local"
+	p.PREFIX = p.PROVIDER_NAME + ":"
+	return p
+}
+
+func (p *LocalProvider) getRepo(sourceID string) string {
+	return strings.TrimPrefix(packageid.Normalize(sourceID), p.PREFIX)
+}
+
+func (p *LocalProvider) binPath(name string) string {
+	return filepath.Join(files.GetAppBinPathForProvider(p.PROVIDER_NAME), name)
+}
+
+// Install records a local package whose executable is in the bin dir.
+// Local packages can't be fetched, so a missing executable is an error.
+func (p *LocalProvider) Install(ctx context.Context, sourceID, version string) bool {
+	name := p.getRepo(sourceID)
+	if name == "" {
+		Logger.ErrorContext(ctx, "Local Install: Invalid source ID format")
+		return false
+	}
+	if _, err := localLstat(p.binPath(name)); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Local Install: %s is not in the bin dir; local packages can't be downloaded, put the executable back and run zana adopt %s", name, name))
+		return false
+	}
+	if err := lppLocalAdd(p.PREFIX+name, LocalPackageVersion); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Local Install: Error recording %s: %v", name, err))
+		return false
+	}
+	return true
+}
+
+// Remove deletes the adopted executable and its lock file entry
+func (p *LocalProvider) Remove(ctx context.Context, sourceID string) bool {
+	name := p.getRepo(sourceID)
+	if name == "" {
+		Logger.ErrorContext(ctx, "Local Remove: Invalid source ID format")
+		return false
+	}
+	if err := localRemove(p.binPath(name)); err != nil && !os.IsNotExist(err) {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Local Remove: Error removing %s: %v", name, err))
+		return false
+	}
+	if err := lppLocalRemove(p.PREFIX + name); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Local Remove: Error removing %s from the lock file: %v", name, err))
+		return false
+	}
+	return true
+}
+
+// Update has nothing to do, local packages have no upstream
+func (p *LocalProvider) Update(ctx context.Context, sourceID string) bool {
+	Logger.InfoContext(ctx, fmt.Sprintf("Local Update: %s is managed by hand, nothing to update", sourceID))
+	return true
+}
+
+func (p *LocalProvider) getLatestVersion(_ context.Context, packageName string) (string, error) {
+	return LocalPackageVersion, nil
+}
+
+// Adopt registers an unmanaged executable in the bin dir as the local package
+// local:<name>, so sync and remove treat it like any other package. With the
+// per-provider bin layout the executable is moved into the local bin dir.
+// It returns the source ID of the new package.
+func Adopt(name string) (string, error) {
+	var found *UnmanagedBinary
+	for _, b := range FindUnmanagedBinaries() {
+		if b.Name == name {
+			found = &b
+			break
+		}
+	}
+	if found == nil {
+		if binEntryExists(name) {
+			return "", fmt.Errorf("%s is already managed by zana", name)
+		}
+		return "", fmt.Errorf("there is no executable named %s in the bin dir", name)
+	}
+
+	p := NewProviderLocal()
+	dest := p.binPath(name)
+	if dest != found.Path {
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return "", err
+		}
+		if err := moveBinEntry(found.Path, dest); err != nil {
+			return "", fmt.Errorf("failed to move %s into %s: %w", name, filepath.Dir(dest), err)
+		}
+	}
+	sourceID := p.PREFIX + name
+	if err := lppLocalAdd(sourceID, LocalPackageVersion); err != nil {
+		return "", err
+	}
+	Logger.Info(fmt.Sprintf("Adopt: Registered %s as %s", found.Path, sourceID))
+	return sourceID, nil
+}
@@ -0,0 +1,97 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubLocalLock keeps the lock file of the local provider in memory
+func stubLocalLock(t *testing.T) map[string]string {
+	t.Helper()
+	lock := map[string]string{"pypi:black": "24.1.0"}
+	origAdd, origRemove, origLocal := lppLocalAdd, lppLocalRemove, binLayoutLocalPackages
+	lppLocalAdd = func(sourceID, version string) error {
+		lock[sourceID] = version
+		return nil
+	}
+	lppLocalRemove = func(sourceID string) error {
+		delete(lock, sourceID)
+		return nil
+	}
+	binLayoutLocalPackages = func(bool) local_packages_parser.LocalPackageRoot {
+		root := local_packages_parser.LocalPackageRoot{}
+		for id, version := range lock {
+			root.Packages = append(root.Packages, local_packages_parser.LocalPackageItem{SourceID: id, Version: version})
+		}
+		return root
+	}
+	t.Cleanup(func() {
+		lppLocalAdd, lppLocalRemove, binLayoutLocalPackages = origAdd, origRemove, origLocal
+	})
+	return lock
+}
+
+func TestFindUnmanagedBinariesAndAdopt(t *testing.T) {
+	binDir := setupBinLayoutTest(t)
+	lock := stubLocalLock(t)
+
+	cargoBin := filepath.Join(files.GetAppPackagesPath(), "cargo", "bin")
+	require.NoError(t, os.MkdirAll(cargoBin, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(cargoBin, "rg"), []byte("bin"), 0755))
+	require.NoError(t, os.Symlink(filepath.Join(cargoBin, "rg"), filepath.Join(binDir, "rg")))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "black"), []byte("#!/bin/sh"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "mytool"), []byte("#!/bin/sh"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, binLayoutMarkerFile), []byte(""), 0644))
+
+	assert.Equal(t, []UnmanagedBinary{{Name: "mytool", Path: filepath.Join(binDir, "mytool")}}, FindUnmanagedBinaries())
+
+	_, err := Adopt("black")
+	assert.ErrorContains(t, err, "already managed")
+	_, err = Adopt("missing")
+	assert.ErrorContains(t, err, "no executable named missing")
+
+	sourceID, err := Adopt("mytool")
+	require.NoError(t, err)
+	assert.Equal(t, "local:mytool", sourceID)
+	assert.Equal(t, LocalPackageVersion, lock["local:mytool"])
+	assert.FileExists(t, filepath.Join(binDir, "mytool"), "the flat layout keeps the executable in place")
+	assert.Empty(t, FindUnmanagedBinaries())
+}
+
+func TestAdoptMovesIntoPerProviderBinDir(t *testing.T) {
+	binDir := setupBinLayoutTest(t)
+	t.Setenv("ZANA_BIN_LAYOUT", files.BinLayoutPerProvider)
+	lock := stubLocalLock(t)
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "mytool"), []byte("#!/bin/sh"), 0755))
+
+	_, err := Adopt("mytool")
+	require.NoError(t, err)
+	assert.Contains(t, lock, "local:mytool")
+	assert.NoFileExists(t, filepath.Join(binDir, "mytool"))
+	assert.FileExists(t, filepath.Join(binDir, "local", "mytool"))
+}
+
+func TestLocalProvider(t *testing.T) {
+	binDir := setupBinLayoutTest(t)
+	lock := stubLocalLock(t)
+	p := NewProviderLocal()
+
+	assert.False(t, p.Install(context.Background(), "local:mytool", LocalPackageVersion), "a missing executable can't be downloaded")
+	assert.NotContains(t, lock, "local:mytool")
+
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "mytool"), []byte("#!/bin/sh"), 0755))
+	assert.True(t, p.Install(context.Background(), "local:mytool", LocalPackageVersion))
+	assert.Contains(t, lock, "local:mytool")
+	assert.True(t, p.Update(context.Background(), "local:mytool"))
+
+	assert.True(t, p.Remove(context.Background(), "local:mytool"))
+	assert.NotContains(t, lock, "local:mytool")
+	assert.NoFileExists(t, filepath.Join(binDir, "mytool"))
+}
@@ -0,0 +1,239 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+)
+
+// Injectable helpers for tests
+var lockWatchReadLock = local_packages_parser.GetData
+var lockWatchInstall = Install
+var lockWatchRemove = Remove
+var lockWatchSync = syncChangedProvider
+
+// LockChange describes the difference between two versions of zana-lock.json
+type LockChange struct {
+	Added   []string `json:"added,omitempty"`
+	Updated []string `json:"updated,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// IsEmpty reports whether no package was added, updated or removed
+func (c LockChange) IsEmpty() bool {
+	return len(c.Added) == 0 && len(c.Updated) == 0 && len(c.Removed) == 0
+}
+
+// DiffLockPackages compares two package lists from zana-lock.json
+func DiffLockPackages(previous, current []local_packages_parser.LocalPackageItem) LockChange {
+	before := make(map[string]string, len(previous))
+	for _, pkg := range previous {
+		before[pkg.SourceID] = pkg.Version
+	}
+	change := LockChange{}
+	after := make(map[string]bool, len(current))
+	for _, pkg := range current {
+		after[pkg.SourceID] = true
+		version, ok := before[pkg.SourceID]
+		switch {
+		case !ok:
+			change.Added = append(change.Added, pkg.SourceID)
+		case version != pkg.Version:
+			change.Updated = append(change.Updated, pkg.SourceID)
+		}
+	}
+	for _, pkg := range previous {
+		if !after[pkg.SourceID] {
+			change.Removed = append(change.Removed, pkg.SourceID)
+		}
+	}
+	sort.Strings(change.Added)
+	sort.Strings(change.Updated)
+	sort.Strings(change.Removed)
+	return change
+}
+
+// LockSyncResult lists the packages ApplyLockChange failed to install or remove
+type LockSyncResult struct {
+	Failed []string `json:"failed,omitempty"`
+}
+
+// ApplyLockChange brings the installed packages in line with a lock file change.
+// The providers of added and updated packages are synced once each, so npm,
+// pypi and go reconcile all their packages in bulk; providers without a Sync
+// install their packages one by one at the locked version.
+// Removed packages are removed.
+func ApplyLockChange(change LockChange, packages []local_packages_parser.LocalPackageItem) LockSyncResult {
+	versions := make(map[string]string, len(packages))
+	for _, pkg := range packages {
+		versions[pkg.SourceID] = pkg.Version
+	}
+	result := LockSyncResult{}
+	SetRequestedIntegrations(nil)
+
+	byProvider := map[string][]string{}
+	order := []string{}
+	for _, sourceID := range append(append([]string{}, change.Added...), change.Updated...) {
+		provider, _ := packageid.Split(packageid.Normalize(sourceID))
+		if _, ok := byProvider[provider]; !ok {
+			order = append(order, provider)
+		}
+		byProvider[provider] = append(byProvider[provider], sourceID)
+	}
+	for _, provider := range order {
+		sourceIDs := byProvider[provider]
+		ok, supported := lockWatchSync(provider, detectProvider(packageid.Normalize(sourceIDs[0])))
+		if supported {
+			if !ok {
+				result.Failed = append(result.Failed, sourceIDs...)
+			}
+			continue
+		}
+		for _, sourceID := range sourceIDs {
+			if !lockWatchInstall(sourceID, versions[sourceID]) {
+				result.Failed = append(result.Failed, sourceID)
+			}
+		}
+	}
+	for _, sourceID := range change.Removed {
+		if !lockWatchRemove(sourceID) {
+			result.Failed = append(result.Failed, sourceID)
+		}
+	}
+	return result
+}
+
+// syncChangedProvider runs the Sync of the provider called name. supported
+// is false for providers without a Sync.
+func syncChangedProvider(name string, provider Provider) (ok, supported bool) {
+	pm := packageManagerFor(provider)
+	if provider == ProviderGeneric {
+		pm = getGenericProvider()
+	}
+	s, supported := pm.(interface{ Sync(context.Context) bool })
+	if !supported {
+		return false, false
+	}
+	if syncDisabled(name) {
+		return false, true
+	}
+	ok = syncInOperation(name, s.Sync)
+	recordProviderSync(name, ok)
+	return ok, true
+}
+
+// retryBaseline returns the lock packages to diff the next version of the
+// lock file against. Failed packages keep their entry from previous, so the
+// next diff contains them again and they are retried.
+func retryBaseline(previous, current []local_packages_parser.LocalPackageItem, failed []string) []local_packages_parser.LocalPackageItem {
+	if len(failed) == 0 {
+		return current
+	}
+	isFailed := make(map[string]bool, len(failed))
+	for _, sourceID := range failed {
+		isFailed[sourceID] = true
+	}
+	baseline := []local_packages_parser.LocalPackageItem{}
+	for _, pkg := range current {
+		if !isFailed[pkg.SourceID] {
+			baseline = append(baseline, pkg)
+		}
+	}
+	for _, pkg := range previous {
+		if isFailed[pkg.SourceID] {
+			baseline = append(baseline, pkg)
+		}
+	}
+	return baseline
+}
+
+// LockWatchOptions configures WatchLockFile
+type LockWatchOptions struct {
+	// Interval is how often the lock file is checked for modifications
+	Interval time.Duration
+	// Debounce is how long the lock file must stay unmodified before syncing,
+	// so that a tool writing the file in several steps triggers a single sync
+	Debounce time.Duration
+	// OnChange is called with every non-empty change before it is applied
+	OnChange func(LockChange)
+	// OnSynced is called after a change has been applied
+	OnSynced func(LockChange, LockSyncResult)
+}
+
+// lockFileState identifies a version of the lock file on disk
+type lockFileState struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+func statLockFile() lockFileState {
+	info, err := os.Stat(files.GetAppLocalPackagesFilePath())
+	if err != nil {
+		return lockFileState{}
+	}
+	return lockFileState{modTime: info.ModTime(), size: info.Size(), exists: true}
+}
+
+// WatchLockFile polls zana-lock.json until ctx is cancelled and applies the packages
+// added, updated or removed since the previous version of the file.
+func WatchLockFile(ctx context.Context, opts LockWatchOptions) {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.Debounce < 0 {
+		opts.Debounce = 0
+	}
+
+	baseline := lockWatchReadLock(true).Packages
+	state := statLockFile()
+	var pendingSince time.Time
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if current := statLockFile(); current != state {
+				// Restart the debounce window on every modification
+				state = current
+				pendingSince = now
+				continue
+			}
+			if pendingSince.IsZero() || now.Sub(pendingSince) < opts.Debounce {
+				continue
+			}
+			pendingSince = time.Time{}
+
+			previous := baseline
+			current := lockWatchReadLock(true).Packages
+			change := DiffLockPackages(previous, current)
+			baseline = current
+			if change.IsEmpty() {
+				continue
+			}
+			if opts.OnChange != nil {
+				opts.OnChange(change)
+			}
+			result := ApplyLockChange(change, current)
+			if opts.OnSynced != nil {
+				opts.OnSynced(change, result)
+			}
+			// Installing rewrites the lock file, this must not trigger another sync
+			baseline = retryBaseline(previous, lockWatchReadLock(true).Packages, result.Failed)
+			state = statLockFile()
+			if len(result.Failed) > 0 {
+				// Retry the failed packages once the debounce window has passed again
+				pendingSince = now
+			}
+		}
+	}
+}
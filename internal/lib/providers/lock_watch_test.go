@@ -0,0 +1,203 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffLockPackages(t *testing.T) {
+	previous := []local_packages_parser.LocalPackageItem{
+		{SourceID: "npm:prettier", Version: "3.0.0"},
+		{SourceID: "pypi:black", Version: "24.1.0"},
+		{SourceID: "cargo:ripgrep", Version: "14.0.0"},
+	}
+	current := []local_packages_parser.LocalPackageItem{
+		{SourceID: "npm:prettier", Version: "3.1.0"},
+		{SourceID: "cargo:ripgrep", Version: "14.0.0"},
+		{SourceID: "golang:gopls", Version: "0.15.0"},
+	}
+
+	change := DiffLockPackages(previous, current)
+	assert.Equal(t, []string{"golang:gopls"}, change.Added)
+	assert.Equal(t, []string{"npm:prettier"}, change.Updated)
+	assert.Equal(t, []string{"pypi:black"}, change.Removed)
+	assert.False(t, change.IsEmpty())
+	assert.True(t, DiffLockPackages(current, current).IsEmpty())
+}
+
+func TestApplyLockChange(t *testing.T) {
+	origInstall := lockWatchInstall
+	origRemove := lockWatchRemove
+	origSync := lockWatchSync
+	t.Cleanup(func() {
+		lockWatchInstall = origInstall
+		lockWatchRemove = origRemove
+		lockWatchSync = origSync
+	})
+
+	synced := []string{}
+	installed := map[string]string{}
+	removed := []string{}
+	lockWatchSync = func(name string, provider Provider) (bool, bool) {
+		if provider == ProviderLocal {
+			return false, false
+		}
+		synced = append(synced, name)
+		return name != "pypi", true
+	}
+	lockWatchInstall = func(sourceID, version string) bool {
+		installed[sourceID] = version
+		return sourceID != "local:broken"
+	}
+	lockWatchRemove = func(sourceID string) bool {
+		removed = append(removed, sourceID)
+		return true
+	}
+
+	change := LockChange{
+		Added:   []string{"local:broken", "npm:eslint", "pypi:ruff"},
+		Updated: []string{"npm:prettier"},
+		Removed: []string{"pypi:black"},
+	}
+	result := ApplyLockChange(change, []local_packages_parser.LocalPackageItem{
+		{SourceID: "local:broken", Version: "local"},
+		{SourceID: "npm:eslint", Version: "9.0.0"},
+		{SourceID: "npm:prettier", Version: "3.1.0"},
+		{SourceID: "pypi:ruff", Version: "0.5.0"},
+	})
+
+	// npm is synced once for both of its packages
+	assert.Equal(t, []string{"npm", "pypi"}, synced)
+	assert.Equal(t, map[string]string{"local:broken": "local"}, installed)
+	assert.Equal(t, []string{"pypi:black"}, removed)
+	assert.Equal(t, []string{"local:broken", "pypi:ruff"}, result.Failed)
+}
+
+func TestRetryBaseline(t *testing.T) {
+	previous := []local_packages_parser.LocalPackageItem{
+		{SourceID: "npm:prettier", Version: "3.0.0"},
+		{SourceID: "pypi:black", Version: "24.1.0"},
+	}
+	current := []local_packages_parser.LocalPackageItem{
+		{SourceID: "npm:prettier", Version: "3.1.0"},
+		{SourceID: "golang:gopls", Version: "0.15.0"},
+	}
+
+	assert.Equal(t, current, retryBaseline(previous, current, nil))
+
+	baseline := retryBaseline(previous, current, []string{"npm:prettier", "golang:gopls", "pypi:black"})
+	change := DiffLockPackages(baseline, current)
+	assert.Equal(t, []string{"golang:gopls"}, change.Added)
+	assert.Equal(t, []string{"npm:prettier"}, change.Updated)
+	assert.Equal(t, []string{"pypi:black"}, change.Removed)
+}
+
+func TestWatchLockFileDebouncesChanges(t *testing.T) {
+	t.Setenv("ZANA_HOME", t.TempDir())
+	lockPath := files.GetAppLocalPackagesFilePath()
+	require.NoError(t, os.WriteFile(lockPath, []byte(`{"packages":[{"sourceId":"npm:prettier","version":"3.0.0"}]}`), 0644))
+
+	origInstall := lockWatchInstall
+	origRemove := lockWatchRemove
+	origSync := lockWatchSync
+	t.Cleanup(func() {
+		lockWatchInstall = origInstall
+		lockWatchRemove = origRemove
+		lockWatchSync = origSync
+	})
+	lockWatchInstall = func(string, string) bool { return true }
+	lockWatchRemove = func(string) bool { return true }
+	lockWatchSync = func(string, Provider) (bool, bool) { return true, true }
+
+	var mu sync.Mutex
+	changes := []LockChange{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		WatchLockFile(ctx, LockWatchOptions{
+			Interval: 10 * time.Millisecond,
+			Debounce: 50 * time.Millisecond,
+			OnChange: func(c LockChange) {
+				mu.Lock()
+				defer mu.Unlock()
+				changes = append(changes, c)
+			},
+		})
+		close(done)
+	}()
+
+	// Two writes in quick succession must result in a single sync
+	time.Sleep(30 * time.Millisecond)
+	require.NoError(t, os.WriteFile(lockPath, []byte(`{"packages":[{"sourceId":"npm:prettier","version":"3.1.0"}]}`), 0644))
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, os.WriteFile(lockPath, []byte(`{"packages":[{"sourceId":"npm:prettier","version":"3.1.0"},{"sourceId":"pypi:black","version":"24.1.0"}]}`), 0644))
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(changes) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+
+	require.Len(t, changes, 1)
+	assert.Equal(t, []string{"pypi:black"}, changes[0].Added)
+	assert.Equal(t, []string{"npm:prettier"}, changes[0].Updated)
+}
+
+func TestWatchLockFileRetriesFailedPackages(t *testing.T) {
+	t.Setenv("ZANA_HOME", t.TempDir())
+	lockPath := files.GetAppLocalPackagesFilePath()
+	require.NoError(t, os.WriteFile(lockPath, []byte(`{"packages":[]}`), 0644))
+
+	origRemove := lockWatchRemove
+	origSync := lockWatchSync
+	t.Cleanup(func() {
+		lockWatchRemove = origRemove
+		lockWatchSync = origSync
+	})
+	lockWatchRemove = func(string) bool { return true }
+
+	var mu sync.Mutex
+	attempts := 0
+	lockWatchSync = func(string, Provider) (bool, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		return attempts > 1, true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		WatchLockFile(ctx, LockWatchOptions{Interval: 10 * time.Millisecond})
+		close(done)
+	}()
+
+	time.Sleep(30 * time.Millisecond)
+	require.NoError(t, os.WriteFile(lockPath, []byte(`{"packages":[{"sourceId":"npm:prettier","version":"3.1.0"}]}`), 0644))
+
+	// The failed sync is retried without another change to the lock file,
+	// and a successful one isn't repeated
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return attempts == 2
+	}, 2*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, attempts)
+}
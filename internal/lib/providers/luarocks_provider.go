@@ -1,16 +1,16 @@
 package providers
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
-	"github.com/mistweaverco/zana-client/internal/lib/shell_out"
 )
 
 type LuaRocksProvider struct {
@@ -22,9 +22,9 @@ type LuaRocksProvider struct {
 var luarocksCmd = "luarocks"
 
 // Injectable shell and OS helpers for tests
-var luarocksShellOut = shell_out.ShellOut
-var luarocksShellOutCapture = shell_out.ShellOutCapture
-var luarocksHasCommand = shell_out.HasCommand
+var luarocksShellOut = shellOut
+var luarocksShellOutCapture = shellOutCapture
+var luarocksHasCommand = hasCommand
 var luarocksLstat = os.Lstat
 var luarocksRemove = os.Remove
 var luarocksChmod = os.Chmod
@@ -47,34 +47,28 @@ func NewProviderLuaRocks() *LuaRocksProvider {
 
 func (p *LuaRocksProvider) getRepo(sourceID string) string {
 	// Support both legacy (pkg:luarocks/pkg) and new (luarocks:pkg) formats
-	normalized := normalizePackageID(sourceID)
+	normalized := packageid.Normalize(sourceID)
 	if strings.HasPrefix(normalized, p.PREFIX) {
 		return strings.TrimPrefix(normalized, p.PREFIX)
 	}
-	// Fallback for legacy format
-	re := regexp.MustCompile("^pkg:" + p.PROVIDER_NAME + "/(.*)")
-	matches := re.FindStringSubmatch(sourceID)
-	if len(matches) > 1 {
-		return matches[1]
-	}
 	return ""
 }
 
-func (p *LuaRocksProvider) Install(sourceID, version string) bool {
+func (p *LuaRocksProvider) Install(ctx context.Context, sourceID, version string) bool {
 	packageName := p.getRepo(sourceID)
 	if packageName == "" {
-		Logger.Error("LuaRocks Install: Invalid source ID format")
+		Logger.ErrorContext(ctx, "LuaRocks Install: Invalid source ID format")
 		return false
 	}
 
-	if !luarocksHasCommand("luarocks", []string{"--version"}, nil) {
-		Logger.Error("LuaRocks Install: luarocks command not found. Please install LuaRocks.")
+	if !luarocksHasCommand(ctx, "luarocks", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "LuaRocks Install: luarocks command not found. Please install LuaRocks.")
 		return false
 	}
 
 	// Ensure packages directory exists
 	if err := luarocksMkdirAll(p.APP_PACKAGES_DIR, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("LuaRocks Install: Error creating packages directory: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("LuaRocks Install: Error creating packages directory: %v", err))
 		return false
 	}
 
@@ -85,10 +79,10 @@ func (p *LuaRocksProvider) Install(sourceID, version string) bool {
 	}
 	args := []string{"install", packageSpec, "--tree", p.APP_PACKAGES_DIR}
 
-	Logger.Info(fmt.Sprintf("LuaRocks Install: Installing %s@%s", packageName, version))
-	code, err := luarocksShellOut(luarocksCmd, args, "", nil)
+	Logger.InfoContext(ctx, fmt.Sprintf("LuaRocks Install: Installing %s@%s", packageName, version))
+	code, err := luarocksShellOut(ctx, luarocksCmd, args, "", nil)
 	if err != nil || code != 0 {
-		Logger.Error(fmt.Sprintf("LuaRocks Install: Error installing rock: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("LuaRocks Install: Error installing rock: %v", err))
 		return false
 	}
 
@@ -96,7 +90,7 @@ func (p *LuaRocksProvider) Install(sourceID, version string) bool {
 	installedVersion := version
 	if installedVersion == "" || installedVersion == "latest" {
 		// Try to get the installed version
-		code, output, err := luarocksShellOutCapture(luarocksCmd, []string{"list", "--tree", p.APP_PACKAGES_DIR}, "", nil)
+		code, output, err := luarocksShellOutCapture(ctx, luarocksCmd, []string{"list", "--tree", p.APP_PACKAGES_DIR}, "", nil)
 		if err == nil && code == 0 {
 			lines := strings.Split(output, "\n")
 			for _, line := range lines {
@@ -117,78 +111,78 @@ func (p *LuaRocksProvider) Install(sourceID, version string) bool {
 
 	// Add to local packages
 	if err := lppLuarocksAdd(sourceID, installedVersion); err != nil {
-		Logger.Error(fmt.Sprintf("LuaRocks Install: Error adding package to local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("LuaRocks Install: Error adding package to local packages: %v", err))
 		return false
 	}
 
 	// Create wrappers for binaries
-	if err := p.createWrappers(); err != nil {
-		Logger.Info(fmt.Sprintf("LuaRocks Install: Warning creating wrappers: %v", err))
+	if err := p.createWrappers(ctx); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("LuaRocks Install: Warning creating wrappers: %v", err))
 	}
 
-	Logger.Info(fmt.Sprintf("LuaRocks Install: Successfully installed %s@%s", packageName, installedVersion))
+	Logger.InfoContext(ctx, fmt.Sprintf("LuaRocks Install: Successfully installed %s@%s", packageName, installedVersion))
 	return true
 }
 
-func (p *LuaRocksProvider) Remove(sourceID string) bool {
+func (p *LuaRocksProvider) Remove(ctx context.Context, sourceID string) bool {
 	packageName := p.getRepo(sourceID)
 	if packageName == "" {
-		Logger.Error("LuaRocks Remove: Invalid source ID format")
+		Logger.ErrorContext(ctx, "LuaRocks Remove: Invalid source ID format")
 		return false
 	}
 
-	if !luarocksHasCommand("luarocks", []string{"--version"}, nil) {
-		Logger.Error("LuaRocks Remove: luarocks command not found. Please install LuaRocks.")
+	if !luarocksHasCommand(ctx, "luarocks", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "LuaRocks Remove: luarocks command not found. Please install LuaRocks.")
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("LuaRocks Remove: Removing %s", packageName))
+	Logger.InfoContext(ctx, fmt.Sprintf("LuaRocks Remove: Removing %s", packageName))
 
 	// Remove wrappers
-	if err := p.removeWrappersForPackage(packageName); err != nil {
-		Logger.Info(fmt.Sprintf("LuaRocks Remove: Warning removing wrappers: %v", err))
+	if err := p.removeWrappersForPackage(ctx, packageName); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("LuaRocks Remove: Warning removing wrappers: %v", err))
 	}
 
 	// Uninstall rock
-	code, err := luarocksShellOut(luarocksCmd, []string{"remove", packageName, "--tree", p.APP_PACKAGES_DIR}, "", nil)
+	code, err := luarocksShellOut(ctx, luarocksCmd, []string{"remove", packageName, "--tree", p.APP_PACKAGES_DIR}, "", nil)
 	if err != nil || code != 0 {
-		Logger.Info(fmt.Sprintf("LuaRocks Remove: Warning uninstalling rock (may not be installed): %v", err))
+		Logger.InfoContext(ctx, fmt.Sprintf("LuaRocks Remove: Warning uninstalling rock (may not be installed): %v", err))
 	}
 
 	// Remove from local packages
 	if err := lppLuarocksRemove(sourceID); err != nil {
-		Logger.Error(fmt.Sprintf("LuaRocks Remove: Error removing package from local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("LuaRocks Remove: Error removing package from local packages: %v", err))
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("LuaRocks Remove: Successfully removed %s", packageName))
+	Logger.InfoContext(ctx, fmt.Sprintf("LuaRocks Remove: Successfully removed %s", packageName))
 	return true
 }
 
-func (p *LuaRocksProvider) Update(sourceID string) bool {
+func (p *LuaRocksProvider) Update(ctx context.Context, sourceID string) bool {
 	packageName := p.getRepo(sourceID)
 	if packageName == "" {
-		Logger.Error("LuaRocks Update: Invalid source ID format")
+		Logger.ErrorContext(ctx, "LuaRocks Update: Invalid source ID format")
 		return false
 	}
 
-	if !luarocksHasCommand("luarocks", []string{"--version"}, nil) {
-		Logger.Error("LuaRocks Update: luarocks command not found. Please install LuaRocks.")
+	if !luarocksHasCommand(ctx, "luarocks", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "LuaRocks Update: luarocks command not found. Please install LuaRocks.")
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("LuaRocks Update: Updating %s", packageName))
+	Logger.InfoContext(ctx, fmt.Sprintf("LuaRocks Update: Updating %s", packageName))
 
 	// Update rock
-	code, err := luarocksShellOut(luarocksCmd, []string{"install", packageName, "--tree", p.APP_PACKAGES_DIR, "--force"}, "", nil)
+	code, err := luarocksShellOut(ctx, luarocksCmd, []string{"install", packageName, "--tree", p.APP_PACKAGES_DIR, "--force"}, "", nil)
 	if err != nil || code != 0 {
-		Logger.Error(fmt.Sprintf("LuaRocks Update: Error updating rock: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("LuaRocks Update: Error updating rock: %v", err))
 		return false
 	}
 
 	// Get updated version
 	var updatedVersion string
-	code, output, err := luarocksShellOutCapture(luarocksCmd, []string{"list", "--tree", p.APP_PACKAGES_DIR}, "", nil)
+	code, output, err := luarocksShellOutCapture(ctx, luarocksCmd, []string{"list", "--tree", p.APP_PACKAGES_DIR}, "", nil)
 	if err == nil && code == 0 {
 		lines := strings.Split(output, "\n")
 		for _, line := range lines {
@@ -208,26 +202,26 @@ func (p *LuaRocksProvider) Update(sourceID string) bool {
 	// Update local packages
 	if err := lppLuarocksRemove(sourceID); err == nil {
 		if err := lppLuarocksAdd(sourceID, updatedVersion); err != nil {
-			Logger.Error(fmt.Sprintf("LuaRocks Update: Error updating package in local packages: %v", err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("LuaRocks Update: Error updating package in local packages: %v", err))
 			return false
 		}
 	}
 
 	// Recreate wrappers
-	if err := p.createWrappers(); err != nil {
-		Logger.Info(fmt.Sprintf("LuaRocks Update: Warning recreating wrappers: %v", err))
+	if err := p.createWrappers(ctx); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("LuaRocks Update: Warning recreating wrappers: %v", err))
 	}
 
-	Logger.Info(fmt.Sprintf("LuaRocks Update: Successfully updated %s@%s", packageName, updatedVersion))
+	Logger.InfoContext(ctx, fmt.Sprintf("LuaRocks Update: Successfully updated %s@%s", packageName, updatedVersion))
 	return true
 }
 
-func (p *LuaRocksProvider) getLatestVersion(packageName string) (string, error) {
-	if !luarocksHasCommand("luarocks", []string{"--version"}, nil) {
+func (p *LuaRocksProvider) getLatestVersion(ctx context.Context, packageName string) (string, error) {
+	if !luarocksHasCommand(ctx, "luarocks", []string{"--version"}, nil) {
 		return "", fmt.Errorf("luarocks command not found")
 	}
 
-	code, output, err := luarocksShellOutCapture(luarocksCmd, []string{"search", packageName}, "", nil)
+	code, output, err := luarocksShellOutCapture(ctx, luarocksCmd, []string{"search", packageName}, "", nil)
 	if err != nil || code != 0 {
 		return "", fmt.Errorf("failed to search for rock: %v", err)
 	}
@@ -258,29 +252,33 @@ func (p *LuaRocksProvider) findLuaRocksBinDir() string {
 }
 
 // createWrappers creates wrapper scripts for LuaRocks executables
-func (p *LuaRocksProvider) createWrappers() error {
+func (p *LuaRocksProvider) createWrappers(ctx context.Context) error {
 	desired := lppLuarocksGetDataForProvider("luarocks").Packages
 	if len(desired) == 0 {
 		return nil
 	}
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	parser := registry_parser.NewDefaultRegistryParser()
 	for _, pkg := range desired {
 		registryItem := parser.GetBySourceId(pkg.SourceID)
 		if len(registryItem.Bin) == 0 {
 			continue
 		}
+		filter := binFilterFor(pkg.SourceID, registryItem)
 		for binName, binCmd := range registryItem.Bin {
+			if !filter.allows(binName) {
+				continue
+			}
 			wrapperPath := filepath.Join(zanaBinDir, binName)
 			if _, err := luarocksLstat(wrapperPath); err == nil {
 				_ = luarocksRemove(wrapperPath)
 			}
 			if err := p.createLuaRocksWrapperForCommand(binCmd, wrapperPath); err != nil {
-				Logger.Error(fmt.Sprintf("Error creating wrapper for %s: %v", binName, err))
+				Logger.ErrorContext(ctx, fmt.Sprintf("Error creating wrapper for %s: %v", binName, err))
 				continue
 			}
 			if err := luarocksChmod(wrapperPath, 0755); err != nil {
-				Logger.Error(fmt.Sprintf("Error setting executable permissions for %s: %v", binName, err))
+				Logger.ErrorContext(ctx, fmt.Sprintf("Error setting executable permissions for %s: %v", binName, err))
 			}
 		}
 	}
@@ -304,29 +302,21 @@ func (p *LuaRocksProvider) createLuaRocksWrapperForCommand(commandToExec string,
 		execCmd = filepath.Join(luarocksBinDir, commandToExec)
 	}
 
-	wrapperContent := fmt.Sprintf(`#!/bin/sh
-# Sets up Lua/LuaRocks environment for zana-installed packages and runs the target command
-
-# Add the zana LuaRocks bin directory to PATH
-export PATH="%s:$PATH"
-
-# Add LuaRocks lib directory to LUA_PATH
-export LUA_PATH="%s/?.lua;%s/?/init.lua;$LUA_PATH"
-
-# Execute the command from registry
-exec %s "$@"
-`, luarocksBinDir, luarocksLibDir, luarocksLibDir, execCmd)
-
-	if err := luarocksWriteFile(wrapperPath, []byte(wrapperContent), 0755); err != nil {
-		return err
+	script := wrapperScript{
+		Description: "Sets up the Lua/LuaRocks environment for zana-installed packages and runs the target command",
+		Env: []wrapperEnv{
+			{Name: "PATH", Value: luarocksBinDir, Separator: ":"},
+			{Name: "LUA_PATH", Value: luarocksLibDir + "/?.lua;" + luarocksLibDir + "/?/init.lua", Separator: ";"},
+		},
+		Command: []string{execCmd},
 	}
-	return nil
+	return writeWrapperScript(script, wrapperPath, luarocksWriteFile)
 }
 
 // removeWrappersForPackage removes wrapper scripts for a specific package
-func (p *LuaRocksProvider) removeWrappersForPackage(packageName string) error {
+func (p *LuaRocksProvider) removeWrappersForPackage(ctx context.Context, packageName string) error {
 	desired := lppLuarocksGetDataForProvider("luarocks").Packages
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	parser := registry_parser.NewDefaultRegistryParser()
 
 	for _, pkg := range desired {
@@ -338,7 +328,7 @@ func (p *LuaRocksProvider) removeWrappersForPackage(packageName string) error {
 			wrapperPath := filepath.Join(zanaBinDir, binName)
 			if _, err := luarocksLstat(wrapperPath); err == nil {
 				if err := luarocksRemove(wrapperPath); err != nil {
-					Logger.Info(fmt.Sprintf("LuaRocks: Warning removing wrapper %s: %v", wrapperPath, err))
+					Logger.InfoContext(ctx, fmt.Sprintf("LuaRocks: Warning removing wrapper %s: %v", wrapperPath, err))
 				}
 			}
 		}
@@ -346,8 +336,8 @@ func (p *LuaRocksProvider) removeWrappersForPackage(packageName string) error {
 	return nil
 }
 
-func (p *LuaRocksProvider) Sync() bool {
-	Logger.Info("LuaRocks Sync: Syncing LuaRocks packages")
+func (p *LuaRocksProvider) Sync(ctx context.Context) bool {
+	Logger.InfoContext(ctx, "LuaRocks Sync: Syncing LuaRocks packages")
 	localPackages := lppLuarocksGetDataForProvider(p.PROVIDER_NAME).Packages
 
 	if len(localPackages) == 0 {
@@ -355,8 +345,8 @@ func (p *LuaRocksProvider) Sync() bool {
 	}
 
 	// Check for luarocks command before proceeding
-	if !luarocksHasCommand("luarocks", []string{"--version"}, nil) {
-		Logger.Error("LuaRocks Sync: luarocks command not found. Please install LuaRocks.")
+	if !luarocksHasCommand(ctx, "luarocks", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "LuaRocks Sync: luarocks command not found. Please install LuaRocks.")
 		return false
 	}
 
@@ -371,16 +361,16 @@ func (p *LuaRocksProvider) Sync() bool {
 			packageSpec = fmt.Sprintf("%s %s", packageName, pkg.Version)
 		}
 		args := []string{"install", packageSpec, "--tree", p.APP_PACKAGES_DIR}
-		code, err := luarocksShellOut(luarocksCmd, args, "", nil)
+		code, err := luarocksShellOut(newOperation(ctx, actionSync, pkg.SourceID), luarocksCmd, args, "", nil)
 		if err != nil || code != 0 {
-			Logger.Error(fmt.Sprintf("LuaRocks Sync: Error installing %s: %v", packageName, err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("LuaRocks Sync: Error installing %s: %v", packageName, err))
 			allOk = false
 		}
 	}
 
 	// Recreate wrappers
-	if err := p.createWrappers(); err != nil {
-		Logger.Info(fmt.Sprintf("LuaRocks Sync: Warning creating wrappers: %v", err))
+	if err := p.createWrappers(ctx); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("LuaRocks Sync: Warning creating wrappers: %v", err))
 	}
 
 	return allOk
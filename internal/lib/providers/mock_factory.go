@@ -2,20 +2,23 @@ package providers
 
 // MockProviderFactory is a mock implementation for testing
 type MockProviderFactory struct {
-	MockNPMProvider      PackageManager
-	MockPyPIProvider     PackageManager
-	MockGolangProvider   PackageManager
-	MockCargoProvider    PackageManager
-	MockGitHubProvider   PackageManager
-	MockGitLabProvider   PackageManager
-	MockCodebergProvider PackageManager
-	MockGemProvider      PackageManager
-	MockComposerProvider PackageManager
-	MockLuaRocksProvider PackageManager
-	MockNuGetProvider    PackageManager
-	MockOpamProvider     PackageManager
-	MockOpenVSXProvider  PackageManager
-	MockGenericProvider  PackageManager
+	MockNPMProvider        PackageManager
+	MockPyPIProvider       PackageManager
+	MockGolangProvider     PackageManager
+	MockCargoProvider      PackageManager
+	MockGitHubProvider     PackageManager
+	MockGitLabProvider     PackageManager
+	MockCodebergProvider   PackageManager
+	MockGiteaProvider      PackageManager
+	MockGemProvider        PackageManager
+	MockComposerProvider   PackageManager
+	MockLuaRocksProvider   PackageManager
+	MockNuGetProvider      PackageManager
+	MockOpamProvider       PackageManager
+	MockOpenVSXProvider    PackageManager
+	MockGenericProvider    PackageManager
+	MockLocalProvider      PackageManager
+	MockTreeSitterProvider PackageManager
 }
 
 func (f *MockProviderFactory) CreateNPMProvider() PackageManager {
@@ -67,6 +70,13 @@ func (f *MockProviderFactory) CreateCodebergProvider() PackageManager {
 	return &MockPackageManager{}
 }
 
+func (f *MockProviderFactory) CreateGiteaProvider() PackageManager {
+	if f.MockGiteaProvider != nil {
+		return f.MockGiteaProvider
+	}
+	return &MockPackageManager{}
+}
+
 func (f *MockProviderFactory) CreateGemProvider() PackageManager {
 	if f.MockGemProvider != nil {
 		return f.MockGemProvider
@@ -115,3 +125,17 @@ func (f *MockProviderFactory) CreateGenericProvider() PackageManager {
 	}
 	return &MockPackageManager{}
 }
+
+func (f *MockProviderFactory) CreateLocalProvider() PackageManager {
+	if f.MockLocalProvider != nil {
+		return f.MockLocalProvider
+	}
+	return &MockPackageManager{}
+}
+
+func (f *MockProviderFactory) CreateTreeSitterProvider() PackageManager {
+	if f.MockTreeSitterProvider != nil {
+		return f.MockTreeSitterProvider
+	}
+	return &MockPackageManager{}
+}
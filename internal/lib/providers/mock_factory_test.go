@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -48,15 +49,15 @@ func TestMockProviderFactory_UsesInjectedManagers(t *testing.T) {
 
 func TestMockPackageManager_DefaultReturns(t *testing.T) {
 	m := &MockPackageManager{}
-	assert.False(t, m.Install("pkg:any/x", "1.0.0"))
-	assert.False(t, m.Remove("pkg:any/x"))
-	assert.False(t, m.Update("pkg:any/x"))
+	assert.False(t, m.Install(context.Background(), "pkg:any/x", "1.0.0"))
+	assert.False(t, m.Remove(context.Background(), "pkg:any/x"))
+	assert.False(t, m.Update(context.Background(), "pkg:any/x"))
 }
 
 func TestMockPackageManager_GetLatestVersionFuncBranch(t *testing.T) {
 	called := 0
 	m := &MockPackageManager{
-		GetLatestVersionFunc: func(packageName string) (string, error) {
+		GetLatestVersionFunc: func(_ context.Context, packageName string) (string, error) {
 			called++
 			if packageName != "foo" {
 				return "", errors.New("unexpected package")
@@ -65,7 +66,7 @@ func TestMockPackageManager_GetLatestVersionFuncBranch(t *testing.T) {
 		},
 	}
 
-	ver, err := m.getLatestVersion("foo")
+	ver, err := m.getLatestVersion(context.Background(), "foo")
 	assert.NoError(t, err)
 	assert.Equal(t, "9.9.9", ver)
 	assert.Equal(t, 1, called)
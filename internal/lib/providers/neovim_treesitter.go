@@ -2,6 +2,7 @@ package providers
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -17,14 +18,13 @@ import (
 	"github.com/mattn/go-isatty"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
-	"github.com/mistweaverco/zana-client/internal/lib/shell_out"
 	"github.com/mistweaverco/zana-client/internal/lib/spinnerutil"
 	"github.com/mistweaverco/zana-client/internal/lib/treesitterdeps"
 )
 
 // Injectable helpers for tests
 var (
-	neovimShellOutCapture = shell_out.ShellOutCapture
+	neovimShellOutCapture = shellOutCapture
 	neovimMkdirAll        = os.MkdirAll
 	neovimRemove          = os.Remove
 	neovimRemoveAll       = os.RemoveAll
@@ -186,6 +186,7 @@ func copyNeovimTreeSitterQueriesDir(src, dst string, inherits []string) error {
 }
 
 func cacheNeovimTreeSitterQueriesAfterBuild(
+	ctx context.Context,
 	repoPath, fullGrammarDir, sourceID, version string,
 	build registry_parser.RegistryItemTreeSitterBuild,
 	allowExternalQueryClone func(lang, repoURL string) bool,
@@ -229,6 +230,7 @@ func cacheNeovimTreeSitterQueriesAfterBuild(
 		var cloneErr error
 		if spinErr := spinnerutil.RunIfTTY(cloneTitle, func() {
 			resolved, cloneErr = cloneExternalQueriesRepo(
+				ctx,
 				repoURL,
 				cloneDir,
 				spec.Ref,
@@ -269,6 +271,7 @@ func cacheNeovimTreeSitterQueriesAfterBuild(
 }
 
 func cacheNeovimTreeSitterQueriesForBuiltLangs(
+	ctx context.Context,
 	repoPath, sourceID, version string,
 	build []registry_parser.RegistryItemTreeSitterBuild,
 	builtLangs []string,
@@ -346,7 +349,7 @@ func cacheNeovimTreeSitterQueriesForBuiltLangs(
 		if grammarDir != "" {
 			fullGrammarDir = filepath.Join(repoPath, filepath.FromSlash(grammarDir))
 		}
-		newPins, err := cacheNeovimTreeSitterQueriesAfterBuild(repoPath, fullGrammarDir, sourceID, version, b, allowExternalQueryClone)
+		newPins, err := cacheNeovimTreeSitterQueriesAfterBuild(ctx, repoPath, fullGrammarDir, sourceID, version, b, allowExternalQueryClone)
 		if err != nil {
 			return nil, err
 		}
@@ -363,7 +366,7 @@ var neovimQueryLanguageNameRe = regexp.MustCompile(`(?i)^[a-z][a-z0-9_-]*$`)
 // neovimBundledQueriesPresent reports whether Neovim's own runtime ships highlights.scm for lang
 // (nvim --clean, no user site). When true, installing grammar-local copies into ~/.local/share/nvim/site/queries
 // shadows the bundled queries and can break vim.treesitter.start (e.g. markdown + LSP preview buffers).
-func neovimBundledQueriesPresent(lang string) (bool, error) {
+func neovimBundledQueriesPresent(ctx context.Context, lang string) (bool, error) {
 	lang = strings.TrimSpace(lang)
 	if lang == "" {
 		return false, nil
@@ -376,7 +379,7 @@ func neovimBundledQueriesPresent(lang string) (bool, error) {
 		`lua local f = vim.api.nvim_get_runtime_file("queries/%s/highlights.scm", false); io.stdout:write((f and #f > 0) and "1" or "0")`,
 		lang,
 	)
-	code, out, err := neovimShellOutCapture("nvim", []string{
+	code, out, err := neovimShellOutCapture(ctx, "nvim", []string{
 		"--clean",
 		"--headless",
 		"-i", "NONE",
@@ -397,9 +400,9 @@ func neovimForceInstallQueriesFromGrammars() bool {
 	return v == "1" || v == "true" || v == "yes"
 }
 
-func detectNeovimDataPath() (string, error) {
+func detectNeovimDataPath(ctx context.Context) (string, error) {
 	// Prefer Neovim itself: this respects OS + user overrides.
-	if code, out, err := neovimShellOutCapture("nvim", []string{
+	if code, out, err := neovimShellOutCapture(ctx, "nvim", []string{
 		"--headless",
 		"+lua io.write(vim.fn.stdpath('data'))",
 		"+q",
@@ -436,15 +439,15 @@ func detectNeovimDataPath() (string, error) {
 	}
 }
 
-func installNeovimParsersFromCache(sourceID, version string, languages []string) error {
-	return installNeovimParsersAndQueriesFromCache(sourceID, version, languages, nil)
+func installNeovimParsersFromCache(ctx context.Context, sourceID, version string, languages []string) error {
+	return installNeovimParsersAndQueriesFromCache(ctx, sourceID, version, languages, nil)
 }
 
-func installNeovimParsersAndQueriesFromCache(sourceID, version string, languages []string, allowMissingParser map[string]struct{}) error {
+func installNeovimParsersAndQueriesFromCache(ctx context.Context, sourceID, version string, languages []string, allowMissingParser map[string]struct{}) error {
 	if !integrationEnabled("neovim") {
 		return nil
 	}
-	dataDir, err := detectNeovimDataPath()
+	dataDir, err := detectNeovimDataPath(ctx)
 	if err != nil {
 		return fmt.Errorf("detect neovim data path: %w", err)
 	}
@@ -479,7 +482,7 @@ func installNeovimParsersAndQueriesFromCache(sourceID, version string, languages
 			if rerr == nil && len(entries) > 0 {
 				skipQueries := false
 				if !neovimForceInstallQueriesFromGrammars() {
-					bundled, qerr := neovimBundledQueriesPresent(lang)
+					bundled, qerr := neovimBundledQueriesPresent(ctx, lang)
 					if qerr != nil {
 						return fmt.Errorf("neovim bundled queries check for %s: %w", lang, qerr)
 					}
@@ -774,7 +777,7 @@ func queryOnlyNeovimLanguagesForInstall(build []registry_parser.RegistryItemTree
 	return out
 }
 
-func buildAndMaybeIntegrateTreeSitter(repoPath string, registryItem registry_parser.RegistryItem, version string, opts *buildTreeSitterOpts) ([]local_packages_parser.TreeSitterExternalQueryPin, error) {
+func buildAndMaybeIntegrateTreeSitter(ctx context.Context, repoPath string, registryItem registry_parser.RegistryItem, version string, opts *buildTreeSitterOpts) ([]local_packages_parser.TreeSitterExternalQueryPin, error) {
 	if !IsTreeSitterCategory(registryItem.Categories) {
 		return nil, nil
 	}
@@ -794,7 +797,7 @@ func buildAndMaybeIntegrateTreeSitter(repoPath string, registryItem registry_par
 		return nil, err
 	}
 
-	langs, err := BuildTreeSitterParsersToCache(repoPath, registryItem.Source.ID, version, registryItem.TreeSitter.Build)
+	langs, err := BuildTreeSitterParsersToCache(ctx, repoPath, registryItem.Source.ID, version, registryItem.TreeSitter.Build)
 	if err != nil {
 		return nil, err
 	}
@@ -802,19 +805,19 @@ func buildAndMaybeIntegrateTreeSitter(repoPath string, registryItem registry_par
 	if opts != nil {
 		pre = opts.ExternalQueryPreflight
 	}
-	pins, err := cacheNeovimTreeSitterQueriesForBuiltLangs(repoPath, registryItem.Source.ID, version, registryItem.TreeSitter.Build, langs, pre)
+	pins, err := cacheNeovimTreeSitterQueriesForBuiltLangs(ctx, repoPath, registryItem.Source.ID, version, registryItem.TreeSitter.Build, langs, pre)
 	if err != nil {
 		return nil, err
 	}
 	neovimLangs := FilterLanguagesForNeovimTreeSitterIntegration(registryItem.TreeSitter.Build, langs)
 	queryOnly := queryOnlyNeovimLanguagesForInstall(registryItem.TreeSitter.Build, neovimLangs)
-	if err := installNeovimParsersAndQueriesFromCache(registryItem.Source.ID, version, neovimLangs, queryOnly); err != nil {
+	if err := installNeovimParsersAndQueriesFromCache(ctx, registryItem.Source.ID, version, neovimLangs, queryOnly); err != nil {
 		return nil, err
 	}
 	return pins, nil
 }
 
-func removeNeovimTreeSitterParsers(registryItem registry_parser.RegistryItem) error {
+func removeNeovimTreeSitterParsers(ctx context.Context, registryItem registry_parser.RegistryItem) error {
 	if !integrationEnabled("neovim") {
 		return nil
 	}
@@ -828,7 +831,7 @@ func removeNeovimTreeSitterParsers(registryItem registry_parser.RegistryItem) er
 		return nil
 	}
 
-	dataDir, err := detectNeovimDataPath()
+	dataDir, err := detectNeovimDataPath(ctx)
 	if err != nil {
 		return fmt.Errorf("detect neovim data path: %w", err)
 	}
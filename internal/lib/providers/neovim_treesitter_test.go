@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -116,7 +117,7 @@ func TestCacheNeovimTreeSitterQueriesForBuiltLangs_QueriesOnlyWithoutGrammarDir(
 		{Language: "html_tags", QueriesOnly: true, Integrations: []string{"neovim"}},
 	}
 
-	_, err := cacheNeovimTreeSitterQueriesForBuiltLangs(repo, "github:demo/html", "v1", build, []string{"html_tags"}, nil)
+	_, err := cacheNeovimTreeSitterQueriesForBuiltLangs(context.Background(), repo, "github:demo/html", "v1", build, []string{"html_tags"}, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -139,7 +140,7 @@ func TestInstallNeovimParsersAndQueriesFromCache_AllowsQueriesOnlyMissingParser(
 	t.Cleanup(func() { requestedIntegrations = prevIntegrations })
 
 	prevShellOut := neovimShellOutCapture
-	neovimShellOutCapture = func(command string, args []string, dir string, env []string) (int, string, error) {
+	neovimShellOutCapture = func(_ context.Context, command string, args []string, dir string, env []string) (int, string, error) {
 		for _, a := range args {
 			if a == "--clean" {
 				// html_tags has no bundled highlights in stock Neovim.
@@ -159,7 +160,7 @@ func TestInstallNeovimParsersAndQueriesFromCache_AllowsQueriesOnlyMissingParser(
 	}
 	allowMissing := map[string]struct{}{"html_tags": {}}
 
-	err := installNeovimParsersAndQueriesFromCache("github:demo/html", "v1", []string{"html_tags"}, allowMissing)
+	err := installNeovimParsersAndQueriesFromCache(context.Background(), "github:demo/html", "v1", []string{"html_tags"}, allowMissing)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -173,7 +174,7 @@ func TestInstallNeovimParsersAndQueriesFromCache_AllowsQueriesOnlyMissingParser(
 }
 
 func TestNeovimBundledQueriesPresent_InvalidLanguage(t *testing.T) {
-	_, err := neovimBundledQueriesPresent("bad;lang")
+	_, err := neovimBundledQueriesPresent(context.Background(), "bad;lang")
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -189,7 +190,7 @@ func TestInstallNeovimParsersAndQueriesFromCache_SkipsQueriesWhenBundled(t *test
 	t.Cleanup(func() { requestedIntegrations = prevIntegrations })
 
 	prevShellOut := neovimShellOutCapture
-	neovimShellOutCapture = func(command string, args []string, dir string, env []string) (int, string, error) {
+	neovimShellOutCapture = func(_ context.Context, command string, args []string, dir string, env []string) (int, string, error) {
 		for _, a := range args {
 			if a == "--clean" {
 				return 0, "1", nil
@@ -223,7 +224,7 @@ func TestInstallNeovimParsersAndQueriesFromCache_SkipsQueriesWhenBundled(t *test
 		t.Fatal(err)
 	}
 
-	err := installNeovimParsersAndQueriesFromCache("github:x/markdown", "v1", []string{"markdown"}, nil)
+	err := installNeovimParsersAndQueriesFromCache(context.Background(), "github:x/markdown", "v1", []string{"markdown"}, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -0,0 +1,86 @@
+package providers
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+)
+
+// NetworkProbeTimeout is how long a single network probe waits for a response
+const NetworkProbeTimeout = 5 * time.Second
+
+// NetworkProbeResult is the outcome of probing one service zana talks to
+type NetworkProbeResult struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Reachable is set when the service answered, whatever the status code
+	Reachable  bool   `json:"reachable"`
+	StatusCode int    `json:"status_code,omitempty"`
+	LatencyMs  int64  `json:"latency_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+type networkProbeTarget struct {
+	name string
+	url  string
+}
+
+// Injectable helpers for tests
+var networkProbeTargets = defaultNetworkProbeTargets
+var networkProbeClient = &http.Client{Timeout: NetworkProbeTimeout}
+
+// defaultNetworkProbeTargets returns the registry URLs followed by the
+// upstream services of the most used providers
+func defaultNetworkProbeTargets() []networkProbeTarget {
+	var targets []networkProbeTarget
+	for _, url := range files.ResolveRegistryURLs() {
+		targets = append(targets, networkProbeTarget{name: "Registry", url: url})
+	}
+	return append(targets,
+		networkProbeTarget{name: "GitHub API", url: "https://api.github.com/rate_limit"},
+		networkProbeTarget{name: "crates.io", url: "https://index.crates.io/config.json"},
+		networkProbeTarget{name: "PyPI", url: "https://pypi.org/simple/"},
+		networkProbeTarget{name: "npm registry", url: "https://registry.npmjs.org/"},
+	)
+}
+
+// ProbeNetwork sends a HEAD request to every service zana downloads from,
+// concurrently, and reports whether and how fast each one answered.
+// HEAD keeps probes cheap, e.g. the registry archive isn't downloaded.
+func ProbeNetwork() []NetworkProbeResult {
+	targets := networkProbeTargets()
+	results := make([]NetworkProbeResult, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = probe(target)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func probe(target networkProbeTarget) NetworkProbeResult {
+	result := NetworkProbeResult{Name: target.name, URL: target.url}
+	req, err := http.NewRequest(http.MethodHead, target.url, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	start := clock.Now()
+	resp, err := networkProbeClient.Do(req)
+	result.LatencyMs = clock.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	_ = resp.Body.Close()
+	result.Reachable = true
+	result.StatusCode = resp.StatusCode
+	return result
+}
@@ -0,0 +1,45 @@
+package providers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeNetwork(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	orig := networkProbeTargets
+	t.Cleanup(func() { networkProbeTargets = orig })
+	networkProbeTargets = func() []networkProbeTarget {
+		return []networkProbeTarget{
+			{name: "up", url: server.URL + "/"},
+			{name: "missing", url: server.URL + "/missing"},
+			{name: "down", url: "http://127.0.0.1:1/"},
+		}
+	}
+
+	results := ProbeNetwork()
+	require.Len(t, results, 3)
+
+	assert.Equal(t, "up", results[0].Name)
+	assert.True(t, results[0].Reachable)
+	assert.Equal(t, http.StatusOK, results[0].StatusCode)
+	assert.Empty(t, results[0].Error)
+
+	assert.True(t, results[1].Reachable, "any HTTP answer means the service is reachable")
+	assert.Equal(t, http.StatusNotFound, results[1].StatusCode)
+
+	assert.Equal(t, "down", results[2].Name)
+	assert.False(t, results[2].Reachable)
+	assert.NotEmpty(t, results[2].Error)
+}
@@ -1,21 +1,21 @@
 package providers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
-	"github.com/mistweaverco/zana-client/internal/lib/shell_out"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 )
 
 // Injectable shell and OS helpers for tests
-var npmShellOut = shell_out.ShellOut
-var npmShellOutCapture = shell_out.ShellOutCapture
+var npmShellOut = shellOut
+var npmShellOutCapture = shellOutCapture
 var npmCreate = os.Create
 var npmReadFile = os.ReadFile
 var npmReadDir = os.ReadDir
@@ -50,16 +50,10 @@ func NewProviderNPM() *NPMProvider {
 
 func (p *NPMProvider) getRepo(sourceID string) string {
 	// Support both legacy (pkg:npm/pkg) and new (npm:pkg) formats
-	normalized := normalizePackageID(sourceID)
+	normalized := packageid.Normalize(sourceID)
 	if strings.HasPrefix(normalized, p.PREFIX) {
 		return strings.TrimPrefix(normalized, p.PREFIX)
 	}
-	// Fallback for legacy format
-	re := regexp.MustCompile("^pkg:" + p.PROVIDER_NAME + "/(.*)")
-	matches := re.FindStringSubmatch(sourceID)
-	if len(matches) > 1 {
-		return matches[1]
-	}
 	return ""
 }
 
@@ -145,8 +139,8 @@ func (p *NPMProvider) readPackageJSON(packagePath string) (*PackageJSON, error)
 	return &pkg, nil
 }
 
-func (p *NPMProvider) removeAllSymlinks() error {
-	binDir := files.GetAppBinPath()
+func (p *NPMProvider) removeAllSymlinks(ctx context.Context) error {
+	binDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	entries, err := npmReadDir(binDir)
 	if err != nil {
 		return err
@@ -158,7 +152,7 @@ func (p *NPMProvider) removeAllSymlinks() error {
 		symlinkPath := filepath.Join(binDir, entry.Name())
 		if _, err := npmLstat(symlinkPath); err == nil {
 			if err := npmRemove(symlinkPath); err != nil {
-				Logger.Info(fmt.Sprintf("warning: failed to remove symlink %s: %v", symlinkPath, err))
+				Logger.InfoContext(ctx, fmt.Sprintf("warning: failed to remove symlink %s: %v", symlinkPath, err))
 			}
 		}
 	}
@@ -166,138 +160,155 @@ func (p *NPMProvider) removeAllSymlinks() error {
 }
 
 func (p *NPMProvider) Clean() bool {
-	if err := p.removeAllSymlinks(); err != nil {
+	if err := p.removeAllSymlinks(context.Background()); err != nil {
 		Logger.Info(fmt.Sprintf("error removing symlinks: %v", err))
 	}
 	if err := npmRemoveAll(p.APP_PACKAGES_DIR); err != nil {
 		Logger.Info(fmt.Sprintf("error removing directory: %v", err))
 		return false
 	}
-	return p.Sync()
+	return p.Sync(context.Background())
 }
 
-func (p *NPMProvider) Sync() bool {
+func (p *NPMProvider) Sync(ctx context.Context) bool {
 	if _, err := npmStat(p.APP_PACKAGES_DIR); os.IsNotExist(err) {
 		if err := npmMkdir(p.APP_PACKAGES_DIR, 0755); err != nil {
 			fmt.Println("error creating directory:", err)
 			return false
 		}
 	}
-	Logger.Info("npm sync: Starting sync process")
+	Logger.InfoContext(ctx, "npm sync: Starting sync process")
+	// The lock file still describes the previous package.json, so read it
+	// before generating the new one
+	locked, lockHasRoot := p.getLockedDependencies()
 	packagesFound := p.generatePackageJSON()
 	if !packagesFound {
 		return true
 	}
-	desired := lppGetDataForProvider("npm").Packages
-	lockFile := filepath.Join(p.APP_PACKAGES_DIR, "package-lock.json")
-	packageJSONFile := filepath.Join(p.APP_PACKAGES_DIR, "package.json")
-	lockExists := false
-	lockNewer := false
-	if lockStat, err := npmStat(lockFile); err == nil {
-		lockExists = true
-		if pkgStat, err := npmStat(packageJSONFile); err == nil {
-			lockNewer = lockStat.ModTime().After(pkgStat.ModTime())
-		}
+	if err := p.writeNpmrc(); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("npm sync: Warning writing .npmrc: %v", err))
 	}
-	// Note: We intentionally unify handling of the fast-path here to avoid
-	// duplicated branches that were hard to exercise in tests. The behavior
-	// remains the same: when all desired match the lockfile, create symlinks
-	// and return true.
-	if lockExists && lockNewer {
-		installed := p.getInstalledPackagesFromLock(lockFile)
-		allInstalled := true
-		needsUpdate := false
-		for _, pkg := range desired {
-			name := p.getRepo(pkg.SourceID)
-			if v, ok := installed[name]; !ok || v != pkg.Version {
-				allInstalled = false
-				needsUpdate = true
-				break
-			}
-		}
-		if allInstalled {
-			for _, pkg := range desired {
-				name := p.getRepo(pkg.SourceID)
-				if err := p.createPackageSymlinks(name); err != nil {
-					Logger.Info(fmt.Sprintf("error creating symlinks for %s: %v", name, err))
-				}
-			}
+	desired := desiredPackagesFor(lppGetDataForProvider("npm").Packages, p.getRepo)
+	plan := reconcilePackages(desired, p.getInstalledPackages(desired, locked), nil)
+	Logger.InfoContext(ctx, fmt.Sprintf("npm sync: %s", plan))
+
+	pending := plan.Pending()
+	if len(pending) > 0 && lockMatchesDesired(locked, desired) {
+		Logger.InfoContext(ctx, "npm sync: package-lock.json matches, using npm ci for faster bulk installation")
+		installCode, err := npmShellOut(ctx, "npm", []string{"ci"}, p.APP_PACKAGES_DIR, nil)
+		if err == nil && installCode == 0 {
+			p.linkPackages(ctx, desired)
 			return true
 		}
-		if needsUpdate {
-			Logger.Info("npm sync: Attempting npm ci for faster bulk installation")
-			if p.tryNpmCi() {
-				Logger.Info("npm sync: npm ci completed successfully")
-				return true
-			}
-			Logger.Info("npm sync: npm ci failed, falling back to individual package installation")
-			if err := npmRemove(lockFile); err != nil {
-				Logger.Info(fmt.Sprintf("warning: failed to remove lock file: %v", err))
-			}
+		Logger.InfoContext(ctx, fmt.Sprintf("npm sync: npm ci failed, falling back to individual package installation: %v", err))
+		if err := npmRemove(filepath.Join(p.APP_PACKAGES_DIR, "package-lock.json")); err != nil {
+			Logger.InfoContext(ctx, fmt.Sprintf("warning: failed to remove lock file: %v", err))
 		}
 	}
-	Logger.Info("npm sync: Installing packages individually")
+
 	allOk := true
 	installedCount := 0
-	skippedCount := 0
-	for _, pkg := range desired {
-		name := p.getRepo(pkg.SourceID)
-		if p.isPackageInstalled(name, pkg.Version) {
-			Logger.Info(fmt.Sprintf("npm sync: Package %s@%s already installed, skipping", name, pkg.Version))
-			skippedCount++
-			if err := p.createPackageSymlinks(name); err != nil {
-				Logger.Info(fmt.Sprintf("error creating symlinks for %s: %v", name, err))
-			}
-			continue
-		}
-		Logger.Info(fmt.Sprintf("npm sync: Installing package %s@%s", name, pkg.Version))
-		installCode, err := npmShellOut("npm", []string{"install", name + "@" + pkg.Version}, p.APP_PACKAGES_DIR, nil)
+	for _, pkg := range pending {
+		Logger.InfoContext(ctx, fmt.Sprintf("npm sync: Installing package %s@%s", pkg.Name, pkg.Desired))
+		_, env := p.registryOptions(pkg.Name)
+		installCode, err := npmShellOut(newOperation(ctx, actionSync, pkg.SourceID), "npm", []string{"install", pkg.Name + "@" + pkg.Desired}, p.APP_PACKAGES_DIR, env)
 		if err != nil || installCode != 0 {
-			fmt.Printf("error installing %s@%s: %v\n", name, pkg.Version, err)
+			fmt.Printf("error installing %s@%s: %v\n", pkg.Name, pkg.Desired, err)
 			allOk = false
-		} else {
-			installedCount++
-			if err := p.createPackageSymlinks(name); err != nil {
-				Logger.Info(fmt.Sprintf("Error creating symlinks for %s: %v", name, err))
-			}
+			continue
+		}
+		installedCount++
+	}
+	// npm install removes packages that left package.json on its own, so
+	// extraneous packages only need pruning when nothing was installed
+	if extraneous := plan.Extraneous(); len(extraneous) > 0 && len(pending) == 0 && lockHasRoot {
+		Logger.InfoContext(ctx, fmt.Sprintf("npm sync: Pruning %d extraneous packages", len(extraneous)))
+		if code, err := npmShellOut(ctx, "npm", []string{"prune"}, p.APP_PACKAGES_DIR, nil); err != nil || code != 0 {
+			Logger.InfoContext(ctx, fmt.Sprintf("warning: npm prune failed: %v", err))
 		}
 	}
-	Logger.Info(fmt.Sprintf("npm sync: Completed - %d packages installed, %d packages skipped", installedCount, skippedCount))
+	p.linkPackages(ctx, desired)
+	Logger.InfoContext(ctx, fmt.Sprintf("npm sync: Completed - %d packages installed, %d packages skipped", installedCount, len(plan.withState(PackageStateInstalled))))
 	return allOk
 }
 
-func (p *NPMProvider) getInstalledPackagesFromLock(lockFile string) map[string]string {
-	installed := map[string]string{}
-	data, err := os.ReadFile(lockFile)
+// linkPackages creates the symlinks of all desired packages
+func (p *NPMProvider) linkPackages(ctx context.Context, desired []desiredPackage) {
+	for _, pkg := range desired {
+		if err := p.createPackageSymlinks(ctx, pkg.Name); err != nil {
+			Logger.InfoContext(ctx, fmt.Sprintf("error creating symlinks for %s: %v", pkg.Name, err))
+		}
+	}
+}
+
+// getLockedDependencies returns the direct dependencies recorded in
+// package-lock.json. Lockfile v2/v3 record them on the root package; v1 only
+// has the flattened tree, so hasRoot is false and transitive packages are
+// included.
+func (p *NPMProvider) getLockedDependencies() (locked map[string]string, hasRoot bool) {
+	locked = map[string]string{}
+	data, err := npmReadFile(filepath.Join(p.APP_PACKAGES_DIR, "package-lock.json"))
 	if err != nil {
-		return installed
+		return locked, false
 	}
 	var lock struct {
+		Packages map[string]struct {
+			Dependencies map[string]string `json:"dependencies"`
+		} `json:"packages"`
 		Dependencies map[string]struct {
 			Version string `json:"version"`
 		} `json:"dependencies"`
 	}
-	if err := json.Unmarshal(data, &lock); err == nil {
-		for pkg, info := range lock.Dependencies {
-			installed[pkg] = info.Version
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return locked, false
+	}
+	if root, ok := lock.Packages[""]; ok {
+		for name, version := range root.Dependencies {
+			locked[name] = version
+		}
+		return locked, true
+	}
+	for name, info := range lock.Dependencies {
+		locked[name] = info.Version
+	}
+	return locked, false
+}
+
+// getInstalledPackages returns the versions in node_modules of the desired
+// and previously locked packages
+func (p *NPMProvider) getInstalledPackages(desired []desiredPackage, locked map[string]string) map[string]string {
+	names := make(map[string]bool, len(desired)+len(locked))
+	for _, pkg := range desired {
+		names[pkg.Name] = true
+	}
+	for name := range locked {
+		names[name] = true
+	}
+	installed := map[string]string{}
+	for name := range names {
+		pkg, err := p.readPackageJSON(filepath.Join(p.APP_PACKAGES_DIR, "node_modules", name))
+		if err == nil && pkg.Version != "" {
+			installed[name] = pkg.Version
 		}
 	}
 	return installed
 }
 
-func (p *NPMProvider) isPackageInstalled(packageName, expectedVersion string) bool {
-	packagePath := filepath.Join(p.APP_PACKAGES_DIR, "node_modules", packageName)
-	if _, err := os.Stat(packagePath); os.IsNotExist(err) {
+// lockMatchesDesired reports whether package-lock.json locks every desired
+// package at its version, so npm ci can install them all at once
+func lockMatchesDesired(locked map[string]string, desired []desiredPackage) bool {
+	if len(desired) == 0 {
 		return false
 	}
-	pkg, err := p.readPackageJSON(packagePath)
-	if err != nil {
-		return false
+	for _, pkg := range desired {
+		if v, ok := locked[pkg.Name]; !ok || v != pkg.Version {
+			return false
+		}
 	}
-	return pkg.Version == expectedVersion
+	return true
 }
 
-func (p *NPMProvider) createPackageSymlinks(packageName string) error {
+func (p *NPMProvider) createPackageSymlinks(ctx context.Context, packageName string) error {
 	nodeModulesPath := filepath.Join(p.APP_PACKAGES_DIR, "node_modules")
 	packagePath := filepath.Join(nodeModulesPath, packageName)
 	pkg, err := p.readPackageJSON(packagePath)
@@ -305,30 +316,34 @@ func (p *NPMProvider) createPackageSymlinks(packageName string) error {
 		return fmt.Errorf("error reading package.json for %s: %v", packageName, err)
 	}
 	if len(pkg.Bin) > 0 {
-		binDir := files.GetAppBinPath()
+		binDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
+		filter := binFilterForSourceID(p.PREFIX + packageName)
 		for binPath := range pkg.Bin {
+			if !filter.allows(binPath) {
+				continue
+			}
 			actualBinPath := filepath.Join(nodeModulesPath, ".bin", binPath)
 			symlinkPath := filepath.Join(binDir, binPath)
 			if _, err := npmLstat(symlinkPath); err == nil {
 				if err := npmRemove(symlinkPath); err != nil {
-					Logger.Info(fmt.Sprintf("warning: failed to remove existing symlink %s: %v", symlinkPath, err))
+					Logger.InfoContext(ctx, fmt.Sprintf("warning: failed to remove existing symlink %s: %v", symlinkPath, err))
 				}
 			}
-			Logger.Info(fmt.Sprintf("Creating symlink for %s -> %s\n", symlinkPath, actualBinPath))
+			Logger.InfoContext(ctx, fmt.Sprintf("Creating symlink for %s -> %s\n", symlinkPath, actualBinPath))
 			if err := npmSymlink(actualBinPath, symlinkPath); err != nil {
-				Logger.Info(fmt.Sprintf("error creating symlink for %s: %v", binPath, err))
+				Logger.InfoContext(ctx, fmt.Sprintf("error creating symlink for %s: %v", binPath, err))
 				return err
 			}
 			if err := npmChmod(symlinkPath, 0755); err != nil {
-				Logger.Info(fmt.Sprintf("error setting executable permissions for %s: %v", binPath, err))
+				Logger.InfoContext(ctx, fmt.Sprintf("error setting executable permissions for %s: %v", binPath, err))
 			}
 		}
 	}
 	return nil
 }
 
-func (p *NPMProvider) removePackageSymlinks(packageName string) error {
-	binDir := files.GetAppBinPath()
+func (p *NPMProvider) removePackageSymlinks(ctx context.Context, packageName string) error {
+	binDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	nodeModulesPath := filepath.Join(p.APP_PACKAGES_DIR, "node_modules")
 	packagePath := filepath.Join(nodeModulesPath, packageName)
 	pkg, err := p.readPackageJSON(packagePath)
@@ -339,103 +354,68 @@ func (p *NPMProvider) removePackageSymlinks(packageName string) error {
 		symlinkPath := filepath.Join(binDir, binName)
 		if _, err := npmLstat(symlinkPath); err == nil {
 			if err := npmRemove(symlinkPath); err != nil {
-				Logger.Info(fmt.Sprintf("warning: failed to remove symlink %s: %v", symlinkPath, err))
+				Logger.InfoContext(ctx, fmt.Sprintf("warning: failed to remove symlink %s: %v", symlinkPath, err))
 			}
 		}
 	}
 	return nil
 }
 
-func (p *NPMProvider) Install(sourceID, version string) bool {
+func (p *NPMProvider) Install(ctx context.Context, sourceID, version string) bool {
 	packageName := p.getRepo(sourceID)
 	if version == "" || version == "latest" {
 		var err error
-		version, err = p.getLatestVersion(packageName)
+		version, err = latestVersionOf(ctx, ProviderNPM, packageName, p.getLatestVersion)
 		if err != nil {
-			Logger.Info(fmt.Sprintf("error getting latest version for %s: %v", packageName, err))
+			Logger.InfoContext(ctx, fmt.Sprintf("error getting latest version for %s: %v", packageName, err))
 			return false
 		}
 	}
 	if err := lppAdd(sourceID, version); err != nil {
 		return false
 	}
-	success := p.Sync()
+	success := p.Sync(ctx)
 	if success {
-		if err := p.createPackageSymlinks(packageName); err != nil {
-			Logger.Info(fmt.Sprintf("error creating symlinks for %s: %v", packageName, err))
+		if err := p.createPackageSymlinks(ctx, packageName); err != nil {
+			Logger.InfoContext(ctx, fmt.Sprintf("error creating symlinks for %s: %v", packageName, err))
 		}
 	}
 	return success
 }
 
-func (p *NPMProvider) Remove(sourceID string) bool {
+func (p *NPMProvider) Remove(ctx context.Context, sourceID string) bool {
 	packageName := p.getRepo(sourceID)
-	Logger.Info(fmt.Sprintf("npm remove: Removing package %s", packageName))
-	_ = p.removePackageSymlinks(packageName)
+	Logger.InfoContext(ctx, fmt.Sprintf("npm remove: Removing package %s", packageName))
+	_ = p.removePackageSymlinks(ctx, packageName)
 	if err := lppRemove(sourceID); err != nil {
-		Logger.Info(fmt.Sprintf("Error removing package %s from local packages: %v", packageName, err))
+		Logger.InfoContext(ctx, fmt.Sprintf("Error removing package %s from local packages: %v", packageName, err))
 		return false
 	}
-	Logger.Info(fmt.Sprintf("npm remove: Package %s removed successfully", packageName))
-	return p.Sync()
+	Logger.InfoContext(ctx, fmt.Sprintf("npm remove: Package %s removed successfully", packageName))
+	return p.Sync(ctx)
 }
 
-func (p *NPMProvider) Update(sourceID string) bool {
+func (p *NPMProvider) Update(ctx context.Context, sourceID string) bool {
 	repo := p.getRepo(sourceID)
 	if repo == "" {
-		Logger.Info("Invalid source ID format for NPM provider")
+		Logger.InfoContext(ctx, "Invalid source ID format for NPM provider")
 		return false
 	}
-	latestVersion, err := p.getLatestVersion(repo)
+	latestVersion, err := latestVersionOf(ctx, ProviderNPM, repo, p.getLatestVersion)
 	if err != nil {
-		Logger.Info(fmt.Sprintf("error getting latest version for %s: %v", repo, err))
+		Logger.InfoContext(ctx, fmt.Sprintf("error getting latest version for %s: %v", repo, err))
 		return false
 	}
-	Logger.Info(fmt.Sprintf("npm update: Updating %s to version %s", repo, latestVersion))
-	return p.Install(sourceID, latestVersion)
+	Logger.InfoContext(ctx, fmt.Sprintf("npm update: Updating %s to version %s", repo, latestVersion))
+	return p.Install(ctx, sourceID, latestVersion)
 }
 
-func (p *NPMProvider) getLatestVersion(packageName string) (string, error) {
-	_, output, err := npmShellOutCapture("npm", []string{"view", packageName, "version"}, "", nil)
+func (p *NPMProvider) getLatestVersion(ctx context.Context, packageName string) (string, error) {
+	dir, env := p.registryOptions(packageName)
+	_, output, err := npmShellOutCapture(ctx, "npm", []string{"view", packageName, "version"}, dir, env)
 	if err != nil {
-		Logger.Error(fmt.Sprintf("npm getLatestVersion: Command failed for %s: %v, output: %s", packageName, err, output))
+		Logger.ErrorContext(ctx, fmt.Sprintf("npm getLatestVersion: Command failed for %s: %v, output: %s", packageName, err, output))
 		return "", err
 	}
 	return strings.TrimSpace(output), nil
 }
-
-func (p *NPMProvider) tryNpmCi() bool {
-	lockFile := filepath.Join(p.APP_PACKAGES_DIR, "package-lock.json")
-	if _, err := os.Stat(lockFile); os.IsNotExist(err) {
-		Logger.Info("npm Sync: No package-lock.json found, cannot use npm ci")
-		return false
-	}
-	Logger.Info("npm sync: Using npm ci for faster bulk installation")
-	installCode, err := npmShellOut("npm", []string{"ci"}, p.APP_PACKAGES_DIR, nil)
-	if err != nil || installCode != 0 {
-		Logger.Info(fmt.Sprintf("npm sync: npm ci failed, falling back to individual package installation: %v", err))
-		return false
-	}
-	Logger.Info("npm sync: npm ci completed successfully, creating symlinks")
-	return true
-}
-
-func (p *NPMProvider) hasPackageJSONChanged() bool {
-	packageJSONFile := filepath.Join(p.APP_PACKAGES_DIR, "package.json")
-	lockFile := filepath.Join(p.APP_PACKAGES_DIR, "package-lock.json")
-	if _, err := npmStat(packageJSONFile); os.IsNotExist(err) {
-		return true
-	}
-	if _, err := npmStat(lockFile); os.IsNotExist(err) {
-		return true
-	}
-	pkgStat, err := npmStat(packageJSONFile)
-	if err != nil {
-		return true
-	}
-	lockStat, err := npmStat(lockFile)
-	if err != nil {
-		return true
-	}
-	return pkgStat.ModTime().After(lockStat.ModTime())
-}
@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"errors"
 	"os"
 	"path/filepath"
@@ -24,43 +25,6 @@ func TestNPMErrorBranches(t *testing.T) {
 	assert.Error(t, err)
 	npmReadFile = oldRF
 
-	// hasPackageJSONChanged branches
-	oldStat := npmStat
-	// package.json missing
-	npmStat = func(name string) (os.FileInfo, error) { return nil, os.ErrNotExist }
-	assert.True(t, p.hasPackageJSONChanged())
-	// lock missing
-	npmStat = func(name string) (os.FileInfo, error) {
-		if filepath.Base(name) == "package.json" {
-			return fileInfoNow(t), nil
-		}
-		return nil, os.ErrNotExist
-	}
-	assert.True(t, p.hasPackageJSONChanged())
-	// pkgStat error
-	npmStat = func(name string) (os.FileInfo, error) {
-		if filepath.Base(name) == "package.json" {
-			return nil, errors.New("err")
-		}
-		return fileInfoNow(t), nil
-	}
-	assert.True(t, p.hasPackageJSONChanged())
-	// lockStat error
-	npmStat = func(name string) (os.FileInfo, error) {
-		if filepath.Base(name) == "package.json" {
-			return fileInfoNow(t), nil
-		}
-		return nil, errors.New("err")
-	}
-	assert.True(t, p.hasPackageJSONChanged())
-	npmStat = oldStat
-
-	// tryNpmCi no lock
-	oldStat2 := npmStat
-	npmStat = func(name string) (os.FileInfo, error) { return nil, os.ErrNotExist }
-	assert.False(t, p.tryNpmCi())
-	npmStat = oldStat2
-
 	// createPackageSymlinks symlink error and chmod error branches
 	// prepare a fake package.json
 	nm := filepath.Join(p.APP_PACKAGES_DIR, "node_modules", "pkg")
@@ -77,12 +41,12 @@ func TestNPMErrorBranches(t *testing.T) {
 	npmLstat = func(string) (os.FileInfo, error) { return fileInfoNow(t), nil }
 	npmRemove = func(string) error { return errors.New("rmerr") }
 	npmSymlink = func(oldname, newname string) error { return errors.New("symerr") }
-	_ = p.createPackageSymlinks("pkg")
+	_ = p.createPackageSymlinks(context.Background(), "pkg")
 
 	// symlink ok, chmod error
 	npmSymlink = func(oldname, newname string) error { return nil }
 	npmChmod = func(string, os.FileMode) error { return errors.New("chmoderr") }
-	err = p.createPackageSymlinks("pkg")
+	err = p.createPackageSymlinks(context.Background(), "pkg")
 	assert.NoError(t, err)
 	npmLstat, npmRemove, npmSymlink, npmChmod = oldL, oldRm, oldSym, oldCh
 
@@ -91,13 +55,13 @@ func TestNPMErrorBranches(t *testing.T) {
 	oldRm = npmRemove
 	npmLstat = func(string) (os.FileInfo, error) { return fileInfoNow(t), nil }
 	npmRemove = func(string) error { return errors.New("rmerr") }
-	assert.NoError(t, p.removePackageSymlinks("pkg"))
+	assert.NoError(t, p.removePackageSymlinks(context.Background(), "pkg"))
 	npmLstat, npmRemove = oldL, oldRm
 
 	// removeAllSymlinks readDir error
 	oldRD := npmReadDir
 	npmReadDir = func(string) ([]os.DirEntry, error) { return nil, errors.New("readdir") }
-	assert.Error(t, p.removeAllSymlinks())
+	assert.Error(t, p.removeAllSymlinks(context.Background()))
 	npmReadDir = oldRD
 
 	// Clean dir remove error -> false
@@ -129,19 +93,19 @@ func TestPushingRemainingBranchesTo100(t *testing.T) {
 	_ = os.MkdirAll(nm, 0755)
 	assert.NoError(t, os.WriteFile(filepath.Join(nm, "package.json"), []byte(`{"name":"a","version":"1.0.0","bin":{"a":"./bin/a.js"}}`), 0644))
 	// fast path
-	assert.True(t, np.Sync())
+	assert.True(t, np.Sync(context.Background()))
 
 	// needsUpdate: change desired version and simulate ci success
 	_ = local_packages_parser.AddLocalPackage("pkg:npm/a", "2.0.0")
 	oldOut := npmShellOut
-	npmShellOut = func(string, []string, string, []string) (int, error) { return 0, nil }
-	assert.True(t, np.Sync())
+	npmShellOut = func(context.Context, string, []string, string, []string) (int, error) { return 0, nil }
+	assert.True(t, np.Sync(context.Background()))
 	npmShellOut = oldOut
 
 	// NPM: Remove returns false when local RemoveLocalPackage fails
 	oldRemoveLocal := lppRemove
 	lppRemove = func(string) error { return errors.New("x") }
-	assert.False(t, np.Remove("pkg:npm/a"))
+	assert.False(t, np.Remove(context.Background(), "pkg:npm/a"))
 	lppRemove = oldRemoveLocal
 
 	// PyPI: Clean removeAll error and then success
@@ -157,7 +121,7 @@ func TestPushingRemainingBranchesTo100(t *testing.T) {
 	_ = os.MkdirAll(gp.APP_PACKAGES_DIR, 0755)
 	oldRemoveLocal2 := lppGoRemove
 	lppGoRemove = func(string) error { return errors.New("x") }
-	assert.False(t, gp.Remove("pkg:golang/x"))
+	assert.False(t, gp.Remove(context.Background(), "pkg:golang/x"))
 	lppGoRemove = oldRemoveLocal2
 
 	// Cargo: Remove returns false when local RemoveLocalPackage fails
@@ -165,7 +129,7 @@ func TestPushingRemainingBranchesTo100(t *testing.T) {
 	_ = os.MkdirAll(cp.APP_PACKAGES_DIR, 0755)
 	oldRemoveLocal3 := lppCargoRemove
 	lppCargoRemove = func(string) error { return errors.New("x") }
-	assert.False(t, cp.Remove("pkg:cargo/x"))
+	assert.False(t, cp.Remove(context.Background(), "pkg:cargo/x"))
 	lppCargoRemove = oldRemoveLocal3
 }
 
@@ -176,22 +140,24 @@ func TestMoreBranchesNPM(t *testing.T) {
 
 	// Install: latest version fetch fails -> false
 	oldCap := npmShellOutCapture
-	npmShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 1, "", errors.New("err") }
-	assert.False(t, p.Install("pkg:npm/pkg", "latest"))
+	npmShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 1, "", errors.New("err")
+	}
+	assert.False(t, p.Install(context.Background(), "pkg:npm/pkg", "latest"))
 	npmShellOutCapture = oldCap
 
 	// Install: add local package fails -> false
 	oldAdd := lppAdd
 	lppAdd = func(string, string) error { return errors.New("add") }
-	assert.False(t, p.Install("pkg:npm/pkg", "1.0.0"))
+	assert.False(t, p.Install(context.Background(), "pkg:npm/pkg", "1.0.0"))
 	lppAdd = oldAdd
 
 	// Update: repo empty -> false
-	assert.False(t, p.Update("pkg:npm/"))
+	assert.False(t, p.Update(context.Background(), "pkg:npm/"))
 
 	// removeAllSymlinks success path
 	_ = os.MkdirAll(files.GetAppBinPath(), 0755)
-	assert.NoError(t, p.removeAllSymlinks())
+	assert.NoError(t, p.removeAllSymlinks(context.Background()))
 }
 
 func TestNPMNeedsUpdateCiFailThenInstallIndividually(t *testing.T) {
@@ -214,7 +180,7 @@ func TestNPMNeedsUpdateCiFailThenInstallIndividually(t *testing.T) {
 	_ = os.WriteFile(filepath.Join(nm, "package.json"), []byte(`{"name":"a","version":"2.0.0","bin":{"a":"./bin/a.js"}}`), 0644)
 	// tryNpmCi should fail
 	oldOut := npmShellOut
-	npmShellOut = func(cmd string, args []string, dir string, env []string) (int, error) {
+	npmShellOut = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, error) {
 		if len(args) == 1 && args[0] == "ci" {
 			return 1, errors.New("ci")
 		}
@@ -224,7 +190,7 @@ func TestNPMNeedsUpdateCiFailThenInstallIndividually(t *testing.T) {
 	// lock removal error should be tolerated
 	oldRm := npmRemove
 	npmRemove = func(string) error { return errors.New("rm") }
-	assert.True(t, p.Sync())
+	assert.True(t, p.Sync(context.Background()))
 	npmRemove = oldRm
 	npmShellOut = oldOut
 }
@@ -239,7 +205,7 @@ func TestNPMAllConditionalsToggle(t *testing.T) {
 	lppGetData = func(bool) local_packages_parser.LocalPackageRoot {
 		return local_packages_parser.LocalPackageRoot{Packages: nil}
 	}
-	assert.True(t, p.Sync())
+	assert.True(t, p.Sync(context.Background()))
 	lppGetData = oldGet
 
 	// Setup desired package a@2.0.0, lock newer with 1.0.0 to trigger needsUpdate
@@ -258,13 +224,13 @@ func TestNPMAllConditionalsToggle(t *testing.T) {
 
 	// Case 2: needsUpdate with ci success -> returns true early
 	oldOut := npmShellOut
-	npmShellOut = func(cmd string, args []string, dir string, env []string) (int, error) {
+	npmShellOut = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, error) {
 		if len(args) == 1 && args[0] == "ci" {
 			return 0, nil
 		}
 		return 0, nil
 	}
-	assert.True(t, p.Sync())
+	assert.True(t, p.Sync(context.Background()))
 	npmShellOut = oldOut
 
 	// Case 3: Installing individually path with install failure -> returns false
@@ -276,10 +242,10 @@ func TestNPMAllConditionalsToggle(t *testing.T) {
 	_ = os.MkdirAll(bn, 0755)
 	_ = os.WriteFile(filepath.Join(bn, "package.json"), []byte(`{"name":"b","version":"0.9.0","bin":{"b":"./bin/b.js"}}`), 0644)
 	oldOut2 := npmShellOut
-	npmShellOut = func(cmd string, args []string, dir string, env []string) (int, error) {
+	npmShellOut = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, error) {
 		return 1, errors.New("install")
 	}
-	assert.False(t, p.Sync())
+	assert.False(t, p.Sync(context.Background()))
 	npmShellOut = oldOut2
 }
 
@@ -338,7 +304,7 @@ func TestNPMRemoveAllSymlinksWarnOnRemove(t *testing.T) {
 	oldLs, oldRm := npmLstat, npmRemove
 	npmLstat = func(string) (os.FileInfo, error) { return fileInfoNow(t), nil }
 	npmRemove = func(string) error { return errors.New("rm") }
-	assert.NoError(t, p.removeAllSymlinks())
+	assert.NoError(t, p.removeAllSymlinks(context.Background()))
 	npmLstat, npmRemove = oldLs, oldRm
 }
 
@@ -368,7 +334,7 @@ func TestNPMSyncCreateDirError(t *testing.T) {
 	oldStat, oldMkdir := npmStat, npmMkdir
 	npmStat = func(string) (os.FileInfo, error) { return nil, os.ErrNotExist }
 	npmMkdir = func(string, os.FileMode) error { return errors.New("mkdir") }
-	assert.False(t, p.Sync())
+	assert.False(t, p.Sync(context.Background()))
 	npmStat, npmMkdir = oldStat, oldMkdir
 }
 
@@ -391,7 +357,7 @@ func TestNPMFastPathLogsCreateSymlinkErrors(t *testing.T) {
 	// force symlink creation to fail so the log path is taken
 	oldSym := npmSymlink
 	npmSymlink = func(string, string) error { return errors.New("sym") }
-	assert.True(t, p.Sync())
+	assert.True(t, p.Sync(context.Background()))
 	npmSymlink = oldSym
 }
 
@@ -400,37 +366,32 @@ func TestNPMInstallAndRemovePermutations(t *testing.T) {
 	p := NewProviderNPM()
 	_ = os.MkdirAll(p.APP_PACKAGES_DIR, 0755)
 
-	// getInstalledPackagesFromLock cases
-	assert.Equal(t, 0, len(p.getInstalledPackagesFromLock(filepath.Join(p.APP_PACKAGES_DIR, "missing.json"))))
-	bad := filepath.Join(p.APP_PACKAGES_DIR, "bad.json")
-	_ = os.WriteFile(bad, []byte("not-json"), 0644)
-	assert.Equal(t, 0, len(p.getInstalledPackagesFromLock(bad)))
-	good := filepath.Join(p.APP_PACKAGES_DIR, "good.json")
-	_ = os.WriteFile(good, []byte(`{"dependencies":{"x":{"version":"1.2.3"}}}`), 0644)
-	mp := p.getInstalledPackagesFromLock(good)
-	assert.Equal(t, "1.2.3", mp["x"])
-
-	// isPackageInstalled false when dir missing
-	assert.False(t, p.isPackageInstalled("none", "1.0.0"))
-	// false when readPackageJSON fails
+	// getLockedDependencies cases
+	lock := filepath.Join(p.APP_PACKAGES_DIR, "package-lock.json")
+	locked, hasRoot := p.getLockedDependencies()
+	assert.Empty(t, locked)
+	assert.False(t, hasRoot)
+	_ = os.WriteFile(lock, []byte("not-json"), 0644)
+	locked, _ = p.getLockedDependencies()
+	assert.Empty(t, locked)
+	_ = os.WriteFile(lock, []byte(`{"dependencies":{"x":{"version":"1.2.3"}}}`), 0644)
+	locked, hasRoot = p.getLockedDependencies()
+	assert.Equal(t, map[string]string{"x": "1.2.3"}, locked)
+	assert.False(t, hasRoot, "v1 lockfiles have no root package")
+	_ = os.WriteFile(lock, []byte(`{"lockfileVersion":3,"packages":{"":{"dependencies":{"x":"1.2.4"}},"node_modules/y":{"version":"2.0.0"}}}`), 0644)
+	locked, hasRoot = p.getLockedDependencies()
+	assert.Equal(t, map[string]string{"x": "1.2.4"}, locked)
+	assert.True(t, hasRoot)
+
+	// getInstalledPackages skips missing and broken packages
 	dir := filepath.Join(p.APP_PACKAGES_DIR, "node_modules", "broken")
 	_ = os.MkdirAll(dir, 0755)
 	_ = os.WriteFile(filepath.Join(dir, "package.json"), []byte("{"), 0644)
-	assert.False(t, p.isPackageInstalled("broken", "1.0.0"))
-	// true when versions match
 	okd := filepath.Join(p.APP_PACKAGES_DIR, "node_modules", "ok")
 	_ = os.MkdirAll(okd, 0755)
 	_ = os.WriteFile(filepath.Join(okd, "package.json"), []byte(`{"name":"ok","version":"1.0.0"}`), 0644)
-	assert.True(t, p.isPackageInstalled("ok", "1.0.0"))
-
-	// hasPackageJSONChanged false when lock newer
-	pkgPath := filepath.Join(p.APP_PACKAGES_DIR, "package.json")
-	lock := filepath.Join(p.APP_PACKAGES_DIR, "package-lock.json")
-	_ = os.WriteFile(pkgPath, []byte("{}"), 0644)
-	_ = os.WriteFile(lock, []byte("{}"), 0644)
-	now := time.Now()
-	_ = os.Chtimes(lock, now.Add(2*time.Hour), now.Add(2*time.Hour))
-	assert.False(t, p.hasPackageJSONChanged())
+	installed := p.getInstalledPackages([]desiredPackage{{Name: "none"}, {Name: "broken"}}, map[string]string{"ok": "1.0.0"})
+	assert.Equal(t, map[string]string{"ok": "1.0.0"}, installed)
 
 	// createPackageSymlinks chmod success
 	// Setup a package with bin
@@ -440,7 +401,7 @@ func TestNPMInstallAndRemovePermutations(t *testing.T) {
 	_ = os.WriteFile(filepath.Join(pa, "package.json"), []byte(`{"name":"pkg","version":"1.0.0","bin":{"cli":"./bin/cli.js"}}`), 0644)
 	oldCh := npmChmod
 	npmChmod = func(string, os.FileMode) error { return nil }
-	assert.NoError(t, p.createPackageSymlinks("pkg"))
+	assert.NoError(t, p.createPackageSymlinks(context.Background(), "pkg"))
 	npmChmod = oldCh
 
 	// removePackageSymlinks removal success
@@ -448,7 +409,7 @@ func TestNPMInstallAndRemovePermutations(t *testing.T) {
 	oldRm := npmRemove
 	npmLstat = func(string) (os.FileInfo, error) { return fileInfoNow(t), nil }
 	npmRemove = func(string) error { return nil }
-	assert.NoError(t, p.removePackageSymlinks("pkg"))
+	assert.NoError(t, p.removePackageSymlinks(context.Background(), "pkg"))
 	npmLstat, npmRemove = oldLs, oldRm
 
 	// Install success returns true even if createPackageSymlinks fails afterwards
@@ -456,11 +417,11 @@ func TestNPMInstallAndRemovePermutations(t *testing.T) {
 	lppGetData = func(bool) local_packages_parser.LocalPackageRoot {
 		return local_packages_parser.LocalPackageRoot{Packages: nil}
 	}
-	assert.True(t, p.Install("pkg:npm/pkg", "1.0.0"))
+	assert.True(t, p.Install(context.Background(), "pkg:npm/pkg", "1.0.0"))
 	lppGetData = oldGet
 
 	// Remove success (lppRemove ok) with Sync returning true from empty desired
-	assert.True(t, p.Remove("pkg:npm/pkg"))
+	assert.True(t, p.Remove(context.Background(), "pkg:npm/pkg"))
 }
 
 func TestGetRepoAllProviders(t *testing.T) {
@@ -539,7 +500,7 @@ func TestNPMFastPathMultiPackageSymlinkLoop(t *testing.T) {
 	_ = os.MkdirAll(bn, 0755)
 	_ = os.WriteFile(filepath.Join(an, "package.json"), []byte(`{"name":"a","version":"1.0.0","bin":{"a":"./bin/a.js"}}`), 0644)
 	_ = os.WriteFile(filepath.Join(bn, "package.json"), []byte(`{"name":"b","version":"2.0.0","bin":{"b":"./bin/b.js"}}`), 0644)
-	assert.True(t, p.Sync())
+	assert.True(t, p.Sync(context.Background()))
 }
 
 func TestNPMFastPathMultiPackageSymlinkLoopWithSymlinkErrors(t *testing.T) {
@@ -561,7 +522,7 @@ func TestNPMFastPathMultiPackageSymlinkLoopWithSymlinkErrors(t *testing.T) {
 	_ = os.WriteFile(filepath.Join(bn, "package.json"), []byte(`{"name":"b","version":"2.0.0","bin":{"b":"./bin/b.js"}}`), 0644)
 	oldSym := npmSymlink
 	npmSymlink = func(string, string) error { return errors.New("sym") }
-	assert.True(t, p.Sync())
+	assert.True(t, p.Sync(context.Background()))
 	npmSymlink = oldSym
 }
 
@@ -584,7 +545,7 @@ func TestNPMFastPathMultiPackageSymlinkLoopSuccess(t *testing.T) {
 	_ = os.WriteFile(filepath.Join(bn, "package.json"), []byte(`{"name":"b","version":"2.0.0","bin":{"b":"./bin/b.js"}}`), 0644)
 	oldCh := npmChmod
 	npmChmod = func(string, os.FileMode) error { return nil }
-	assert.True(t, p.Sync())
+	assert.True(t, p.Sync(context.Background()))
 	npmChmod = oldCh
 }
 
@@ -631,7 +592,7 @@ func TestNPMFastPathSecondLoopAllInstalled(t *testing.T) {
 	oldCh := npmChmod
 	npmChmod = func(string, os.FileMode) error { return nil }
 
-	assert.True(t, p.Sync())
+	assert.True(t, p.Sync(context.Background()))
 	npmChmod = oldCh
 	npmStat = oldStat
 }
@@ -669,7 +630,7 @@ func TestNPMFastPathAllInstalledCallsSymlinkForEachPackage(t *testing.T) {
 	oldCh := npmChmod
 	npmChmod = func(string, os.FileMode) error { return nil }
 
-	assert.True(t, p.Sync())
+	assert.True(t, p.Sync(context.Background()))
 	// Expect both a and b symlink attempts
 	assert.GreaterOrEqual(t, called["a"], 1)
 	assert.GreaterOrEqual(t, called["b"], 1)
@@ -704,7 +665,16 @@ func TestNPMFastPathAllInstalledMixedSymlinkSuccessAndError(t *testing.T) {
 	// ensure chmod does not fail for the success package
 	oldCh := npmChmod
 	npmChmod = func(string, os.FileMode) error { return nil }
-	assert.True(t, p.Sync())
+	// the broken b counts as missing and the lock matches, so npm ci reinstalls
+	oldOut := npmShellOut
+	var calls [][]string
+	npmShellOut = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, error) {
+		calls = append(calls, args)
+		return 0, nil
+	}
+	assert.True(t, p.Sync(context.Background()))
+	assert.Equal(t, [][]string{{"ci"}}, calls)
+	npmShellOut = oldOut
 	npmChmod = oldCh
 }
 
@@ -712,8 +682,10 @@ func TestNPMUpdateLatestFetchFail(t *testing.T) {
 	_ = withTempZanaHome(t)
 	p := NewProviderNPM()
 	oldCap := npmShellOutCapture
-	npmShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 1, "", errors.New("err") }
-	assert.False(t, p.Update("pkg:npm/x"))
+	npmShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 1, "", errors.New("err")
+	}
+	assert.False(t, p.Update(context.Background(), "pkg:npm/x"))
 	npmShellOutCapture = oldCap
 }
 
@@ -729,7 +701,7 @@ func TestNPMSkipPathSymlinkError(t *testing.T) {
 	// No lock file so it goes to individual install path; mark package installed so skip branch executes
 	oldSym := npmSymlink
 	npmSymlink = func(string, string) error { return errors.New("sym") }
-	assert.True(t, p.Sync())
+	assert.True(t, p.Sync(context.Background()))
 	npmSymlink = oldSym
 }
 
@@ -746,7 +718,7 @@ func TestNPMInstallPostSyncSymlinkError(t *testing.T) {
 	oldSym := npmSymlink
 	npmSymlink = func(string, string) error { return errors.New("sym") }
 	// Call Install with a specific package
-	assert.True(t, p.Install("pkg:npm/post", "1.0.0"))
+	assert.True(t, p.Install(context.Background(), "pkg:npm/post", "1.0.0"))
 	// restore
 	npmSymlink = oldSym
 	lppGetDataForProvider = oldGetProv
@@ -769,7 +741,7 @@ func TestNPMRemoveLogsSymlinkRemovalError(t *testing.T) {
 	// Ensure local removal succeeds
 	oldLR := lppRemove
 	lppRemove = func(string) error { return nil }
-	assert.True(t, p.Remove("pkg:npm/pkg"))
+	assert.True(t, p.Remove(context.Background(), "pkg:npm/pkg"))
 	lppRemove = oldLR
 	npmLstat, npmRemove = oldLs, oldRm
 }
@@ -783,8 +755,8 @@ func TestNPMInstallIndividualPathSymlinkError(t *testing.T) {
 	_ = os.MkdirAll(filepath.Join(p.APP_PACKAGES_DIR, "node_modules", ".bin"), 0755)
 	// install succeeds
 	oldOut := npmShellOut
-	npmShellOut = func(string, []string, string, []string) (int, error) { return 0, nil }
-	assert.True(t, p.Sync())
+	npmShellOut = func(context.Context, string, []string, string, []string) (int, error) { return 0, nil }
+	assert.True(t, p.Sync(context.Background()))
 	npmShellOut = oldOut
 }
 
@@ -795,8 +767,10 @@ func TestNPMProviderBasicFlows(t *testing.T) {
 	oldOut := npmShellOut
 	oldCap := npmShellOutCapture
 	oldCreate := npmCreate
-	npmShellOut = func(cmd string, args []string, dir string, env []string) (int, error) { return 0, nil }
-	npmShellOutCapture = func(cmd string, args []string, dir string, env []string) (int, string, error) {
+	npmShellOut = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, error) {
+		return 0, nil
+	}
+	npmShellOutCapture = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, string, error) {
 		return 0, "1.2.3\n", nil
 	}
 	npmCreate = func(name string) (*os.File, error) {
@@ -833,46 +807,33 @@ func TestNPMProviderBasicFlows(t *testing.T) {
 	pkgJSON := `{"name":"eslint","version":"1.0.0","bin":{"eslint":"./bin/eslint.js"}}`
 	assert.NoError(t, os.WriteFile(filepath.Join(nm, "package.json"), []byte(pkgJSON), 0644))
 
-	// isPackageInstalled true/false
-	assert.True(t, p.isPackageInstalled("eslint", "1.0.0"))
-	assert.False(t, p.isPackageInstalled("eslint", "2.0.0"))
+	// getInstalledPackages reads the version from node_modules
+	assert.Equal(t, map[string]string{"eslint": "1.0.0"}, p.getInstalledPackages([]desiredPackage{{Name: "eslint"}}, nil))
 
 	// createPackageSymlinks
-	assert.NoError(t, p.createPackageSymlinks("eslint"))
+	assert.NoError(t, p.createPackageSymlinks(context.Background(), "eslint"))
 
 	// removePackageSymlinks
-	assert.NoError(t, p.removePackageSymlinks("eslint"))
+	assert.NoError(t, p.removePackageSymlinks(context.Background(), "eslint"))
 
-	// getInstalledPackagesFromLock
+	// Sync finds eslint in sync with the lock file
 	lock := filepath.Join(p.APP_PACKAGES_DIR, "package-lock.json")
-	lockData := `{"dependencies":{"eslint":{"version":"1.0.0"}}}`
+	lockData := `{"lockfileVersion":3,"packages":{"":{"dependencies":{"eslint":"1.0.0"}}}}`
 	assert.NoError(t, os.WriteFile(lock, []byte(lockData), 0644))
-	inst := p.getInstalledPackagesFromLock(lock)
-	assert.Equal(t, "1.0.0", inst["eslint"])
-	// ensure lock is newer than package.json so fast-path is taken
-	pkgPath := filepath.Join(p.APP_PACKAGES_DIR, "package.json")
-	assert.NoError(t, os.WriteFile(pkgPath, []byte("{}"), 0644))
-	now := time.Now()
-	_ = os.Chtimes(lock, now.Add(1*time.Hour), now.Add(1*time.Hour))
-
-	// tryNpmCi: lock exists and stub returns success
-	assert.True(t, p.tryNpmCi())
-	// failure path
-	npmShellOut = func(cmd string, args []string, dir string, env []string) (int, error) { return 1, nil }
-	assert.False(t, p.tryNpmCi())
-	// reset to success before Sync and later flows
-	npmShellOut = func(cmd string, args []string, dir string, env []string) (int, error) { return 0, nil }
+	npmShellOut = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, error) {
+		return 0, nil
+	}
 
 	// Sync reaches install individually path (node_modules has correct content, installs ok)
-	ok = p.Sync()
+	ok = p.Sync(context.Background())
 	assert.True(t, ok)
 
 	// Install with latest
-	ok = p.Install("pkg:npm/eslint", "latest")
+	ok = p.Install(context.Background(), "pkg:npm/eslint", "latest")
 	assert.True(t, ok)
 
 	// Update
-	ok = p.Update("pkg:npm/eslint")
+	ok = p.Update(context.Background(), "pkg:npm/eslint")
 	assert.True(t, ok)
 
 	// Clean
@@ -880,13 +841,9 @@ func TestNPMProviderBasicFlows(t *testing.T) {
 	assert.True(t, ok)
 
 	// Remove
-	ok = p.Remove("pkg:npm/eslint")
+	ok = p.Remove(context.Background(), "pkg:npm/eslint")
 	assert.True(t, ok)
 
-	// hasPackageJSONChanged scenarios after flows
-	// now make package newer to toggle path
-	_ = os.Chtimes(pkgPath, now.Add(2*time.Hour), now.Add(2*time.Hour))
-	assert.True(t, p.hasPackageJSONChanged())
 }
 
 func TestNPMCustomBinFieldUnmarshal(t *testing.T) {
@@ -1,16 +1,16 @@
 package providers
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
-	"github.com/mistweaverco/zana-client/internal/lib/shell_out"
 )
 
 type NuGetProvider struct {
@@ -22,9 +22,9 @@ type NuGetProvider struct {
 var nugetCmd = "dotnet"
 
 // Injectable shell and OS helpers for tests
-var nugetShellOut = shell_out.ShellOut
-var nugetShellOutCapture = shell_out.ShellOutCapture
-var nugetHasCommand = shell_out.HasCommand
+var nugetShellOut = shellOut
+var nugetShellOutCapture = shellOutCapture
+var nugetHasCommand = hasCommand
 var nugetLstat = os.Lstat
 var nugetRemove = os.Remove
 var nugetChmod = os.Chmod
@@ -47,34 +47,28 @@ func NewProviderNuGet() *NuGetProvider {
 
 func (p *NuGetProvider) getRepo(sourceID string) string {
 	// Support both legacy (pkg:nuget/pkg) and new (nuget:pkg) formats
-	normalized := normalizePackageID(sourceID)
+	normalized := packageid.Normalize(sourceID)
 	if strings.HasPrefix(normalized, p.PREFIX) {
 		return strings.TrimPrefix(normalized, p.PREFIX)
 	}
-	// Fallback for legacy format
-	re := regexp.MustCompile("^pkg:" + p.PROVIDER_NAME + "/(.*)")
-	matches := re.FindStringSubmatch(sourceID)
-	if len(matches) > 1 {
-		return matches[1]
-	}
 	return ""
 }
 
-func (p *NuGetProvider) Install(sourceID, version string) bool {
+func (p *NuGetProvider) Install(ctx context.Context, sourceID, version string) bool {
 	packageName := p.getRepo(sourceID)
 	if packageName == "" {
-		Logger.Error("NuGet Install: Invalid source ID format")
+		Logger.ErrorContext(ctx, "NuGet Install: Invalid source ID format")
 		return false
 	}
 
-	if !nugetHasCommand("dotnet", []string{"--version"}, nil) {
-		Logger.Error("NuGet Install: dotnet command not found. Please install .NET SDK.")
+	if !nugetHasCommand(ctx, "dotnet", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "NuGet Install: dotnet command not found. Please install .NET SDK.")
 		return false
 	}
 
 	// Ensure packages directory exists
 	if err := nugetMkdirAll(p.APP_PACKAGES_DIR, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("NuGet Install: Error creating packages directory: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("NuGet Install: Error creating packages directory: %v", err))
 		return false
 	}
 
@@ -92,7 +86,7 @@ func (p *NuGetProvider) Install(sourceID, version string) bool {
 	// Ensure project file exists
 	if _, err := nugetStat(projectPath); os.IsNotExist(err) {
 		if err := nugetWriteFile(projectPath, []byte(projectContent), 0644); err != nil {
-			Logger.Error(fmt.Sprintf("NuGet Install: Error creating project file: %v", err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("NuGet Install: Error creating project file: %v", err))
 			return false
 		}
 	}
@@ -103,10 +97,10 @@ func (p *NuGetProvider) Install(sourceID, version string) bool {
 		args = append(args, "--version", version)
 	}
 
-	Logger.Info(fmt.Sprintf("NuGet Install: Installing %s@%s", packageName, version))
-	code, err := nugetShellOut(nugetCmd, args, p.APP_PACKAGES_DIR, nil)
+	Logger.InfoContext(ctx, fmt.Sprintf("NuGet Install: Installing %s@%s", packageName, version))
+	code, err := nugetShellOut(ctx, nugetCmd, args, p.APP_PACKAGES_DIR, nil)
 	if err != nil || code != 0 {
-		Logger.Error(fmt.Sprintf("NuGet Install: Error installing tool: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("NuGet Install: Error installing tool: %v", err))
 		return false
 	}
 
@@ -114,7 +108,7 @@ func (p *NuGetProvider) Install(sourceID, version string) bool {
 	installedVersion := version
 	if installedVersion == "" || installedVersion == "latest" {
 		// Try to get the installed version using dotnet tool list
-		code, output, err := nugetShellOutCapture(nugetCmd, []string{"tool", "list", "--tool-path", p.APP_PACKAGES_DIR}, "", nil)
+		code, output, err := nugetShellOutCapture(ctx, nugetCmd, []string{"tool", "list", "--tool-path", p.APP_PACKAGES_DIR}, "", nil)
 		if err == nil && code == 0 {
 			lines := strings.Split(output, "\n")
 			for _, line := range lines {
@@ -135,78 +129,78 @@ func (p *NuGetProvider) Install(sourceID, version string) bool {
 
 	// Add to local packages
 	if err := lppNugetAdd(sourceID, installedVersion); err != nil {
-		Logger.Error(fmt.Sprintf("NuGet Install: Error adding package to local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("NuGet Install: Error adding package to local packages: %v", err))
 		return false
 	}
 
 	// Create wrappers for binaries
-	if err := p.createWrappers(); err != nil {
-		Logger.Info(fmt.Sprintf("NuGet Install: Warning creating wrappers: %v", err))
+	if err := p.createWrappers(ctx); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("NuGet Install: Warning creating wrappers: %v", err))
 	}
 
-	Logger.Info(fmt.Sprintf("NuGet Install: Successfully installed %s@%s", packageName, installedVersion))
+	Logger.InfoContext(ctx, fmt.Sprintf("NuGet Install: Successfully installed %s@%s", packageName, installedVersion))
 	return true
 }
 
-func (p *NuGetProvider) Remove(sourceID string) bool {
+func (p *NuGetProvider) Remove(ctx context.Context, sourceID string) bool {
 	packageName := p.getRepo(sourceID)
 	if packageName == "" {
-		Logger.Error("NuGet Remove: Invalid source ID format")
+		Logger.ErrorContext(ctx, "NuGet Remove: Invalid source ID format")
 		return false
 	}
 
-	if !nugetHasCommand("dotnet", []string{"--version"}, nil) {
-		Logger.Error("NuGet Remove: dotnet command not found. Please install .NET SDK.")
+	if !nugetHasCommand(ctx, "dotnet", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "NuGet Remove: dotnet command not found. Please install .NET SDK.")
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("NuGet Remove: Removing %s", packageName))
+	Logger.InfoContext(ctx, fmt.Sprintf("NuGet Remove: Removing %s", packageName))
 
 	// Remove wrappers
-	if err := p.removeWrappersForPackage(packageName); err != nil {
-		Logger.Info(fmt.Sprintf("NuGet Remove: Warning removing wrappers: %v", err))
+	if err := p.removeWrappersForPackage(ctx, packageName); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("NuGet Remove: Warning removing wrappers: %v", err))
 	}
 
 	// Uninstall tool
-	code, err := nugetShellOut(nugetCmd, []string{"tool", "uninstall", packageName, "--tool-path", p.APP_PACKAGES_DIR}, p.APP_PACKAGES_DIR, nil)
+	code, err := nugetShellOut(ctx, nugetCmd, []string{"tool", "uninstall", packageName, "--tool-path", p.APP_PACKAGES_DIR}, p.APP_PACKAGES_DIR, nil)
 	if err != nil || code != 0 {
-		Logger.Info(fmt.Sprintf("NuGet Remove: Warning uninstalling tool (may not be installed): %v", err))
+		Logger.InfoContext(ctx, fmt.Sprintf("NuGet Remove: Warning uninstalling tool (may not be installed): %v", err))
 	}
 
 	// Remove from local packages
 	if err := lppNugetRemove(sourceID); err != nil {
-		Logger.Error(fmt.Sprintf("NuGet Remove: Error removing package from local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("NuGet Remove: Error removing package from local packages: %v", err))
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("NuGet Remove: Successfully removed %s", packageName))
+	Logger.InfoContext(ctx, fmt.Sprintf("NuGet Remove: Successfully removed %s", packageName))
 	return true
 }
 
-func (p *NuGetProvider) Update(sourceID string) bool {
+func (p *NuGetProvider) Update(ctx context.Context, sourceID string) bool {
 	packageName := p.getRepo(sourceID)
 	if packageName == "" {
-		Logger.Error("NuGet Update: Invalid source ID format")
+		Logger.ErrorContext(ctx, "NuGet Update: Invalid source ID format")
 		return false
 	}
 
-	if !nugetHasCommand("dotnet", []string{"--version"}, nil) {
-		Logger.Error("NuGet Update: dotnet command not found. Please install .NET SDK.")
+	if !nugetHasCommand(ctx, "dotnet", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "NuGet Update: dotnet command not found. Please install .NET SDK.")
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("NuGet Update: Updating %s", packageName))
+	Logger.InfoContext(ctx, fmt.Sprintf("NuGet Update: Updating %s", packageName))
 
 	// Update tool
-	code, err := nugetShellOut(nugetCmd, []string{"tool", "update", packageName, "--tool-path", p.APP_PACKAGES_DIR}, p.APP_PACKAGES_DIR, nil)
+	code, err := nugetShellOut(ctx, nugetCmd, []string{"tool", "update", packageName, "--tool-path", p.APP_PACKAGES_DIR}, p.APP_PACKAGES_DIR, nil)
 	if err != nil || code != 0 {
-		Logger.Error(fmt.Sprintf("NuGet Update: Error updating tool: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("NuGet Update: Error updating tool: %v", err))
 		return false
 	}
 
 	// Get updated version
 	var updatedVersion string
-	code, output, err := nugetShellOutCapture(nugetCmd, []string{"tool", "list", "--tool-path", p.APP_PACKAGES_DIR}, "", nil)
+	code, output, err := nugetShellOutCapture(ctx, nugetCmd, []string{"tool", "list", "--tool-path", p.APP_PACKAGES_DIR}, "", nil)
 	if err == nil && code == 0 {
 		lines := strings.Split(output, "\n")
 		for _, line := range lines {
@@ -226,28 +220,28 @@ func (p *NuGetProvider) Update(sourceID string) bool {
 	// Update local packages
 	if err := lppNugetRemove(sourceID); err == nil {
 		if err := lppNugetAdd(sourceID, updatedVersion); err != nil {
-			Logger.Error(fmt.Sprintf("NuGet Update: Error updating package in local packages: %v", err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("NuGet Update: Error updating package in local packages: %v", err))
 			return false
 		}
 	}
 
 	// Recreate wrappers
-	if err := p.createWrappers(); err != nil {
-		Logger.Info(fmt.Sprintf("NuGet Update: Warning recreating wrappers: %v", err))
+	if err := p.createWrappers(ctx); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("NuGet Update: Warning recreating wrappers: %v", err))
 	}
 
-	Logger.Info(fmt.Sprintf("NuGet Update: Successfully updated %s@%s", packageName, updatedVersion))
+	Logger.InfoContext(ctx, fmt.Sprintf("NuGet Update: Successfully updated %s@%s", packageName, updatedVersion))
 	return true
 }
 
-func (p *NuGetProvider) getLatestVersion(packageName string) (string, error) {
-	if !nugetHasCommand("dotnet", []string{"--version"}, nil) {
+func (p *NuGetProvider) getLatestVersion(ctx context.Context, packageName string) (string, error) {
+	if !nugetHasCommand(ctx, "dotnet", []string{"--version"}, nil) {
 		return "", fmt.Errorf("dotnet command not found")
 	}
 
 	// Use dotnet tool search or NuGet API
 	// For now, we'll use dotnet tool search
-	code, output, err := nugetShellOutCapture(nugetCmd, []string{"tool", "search", packageName}, "", nil)
+	code, output, err := nugetShellOutCapture(ctx, nugetCmd, []string{"tool", "search", packageName}, "", nil)
 	if err != nil || code != 0 {
 		return "", fmt.Errorf("failed to search for tool: %v", err)
 	}
@@ -274,29 +268,33 @@ func (p *NuGetProvider) findNuGetBinDir() string {
 }
 
 // createWrappers creates wrapper scripts for NuGet tool executables
-func (p *NuGetProvider) createWrappers() error {
+func (p *NuGetProvider) createWrappers(ctx context.Context) error {
 	desired := lppNugetGetDataForProvider("nuget").Packages
 	if len(desired) == 0 {
 		return nil
 	}
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	parser := registry_parser.NewDefaultRegistryParser()
 	for _, pkg := range desired {
 		registryItem := parser.GetBySourceId(pkg.SourceID)
 		if len(registryItem.Bin) == 0 {
 			continue
 		}
+		filter := binFilterFor(pkg.SourceID, registryItem)
 		for binName, binCmd := range registryItem.Bin {
+			if !filter.allows(binName) {
+				continue
+			}
 			wrapperPath := filepath.Join(zanaBinDir, binName)
 			if _, err := nugetLstat(wrapperPath); err == nil {
 				_ = nugetRemove(wrapperPath)
 			}
 			if err := p.createNuGetWrapperForCommand(binCmd, wrapperPath); err != nil {
-				Logger.Error(fmt.Sprintf("Error creating wrapper for %s: %v", binName, err))
+				Logger.ErrorContext(ctx, fmt.Sprintf("Error creating wrapper for %s: %v", binName, err))
 				continue
 			}
 			if err := nugetChmod(wrapperPath, 0755); err != nil {
-				Logger.Error(fmt.Sprintf("Error setting executable permissions for %s: %v", binName, err))
+				Logger.ErrorContext(ctx, fmt.Sprintf("Error setting executable permissions for %s: %v", binName, err))
 			}
 		}
 	}
@@ -319,26 +317,20 @@ func (p *NuGetProvider) createNuGetWrapperForCommand(commandToExec string, wrapp
 		execCmd = filepath.Join(nugetBinDir, commandToExec)
 	}
 
-	wrapperContent := fmt.Sprintf(`#!/bin/sh
-# Sets up .NET/NuGet environment for zana-installed packages and runs the target command
-
-# Add the zana NuGet tools directory to PATH
-export PATH="%s:$PATH"
-
-# Execute the command from registry
-exec %s "$@"
-`, nugetBinDir, execCmd)
-
-	if err := nugetWriteFile(wrapperPath, []byte(wrapperContent), 0755); err != nil {
-		return err
+	script := wrapperScript{
+		Description: "Sets up the .NET/NuGet environment for zana-installed packages and runs the target command",
+		Env: []wrapperEnv{
+			{Name: "PATH", Value: nugetBinDir, Separator: ":"},
+		},
+		Command: []string{execCmd},
 	}
-	return nil
+	return writeWrapperScript(script, wrapperPath, nugetWriteFile)
 }
 
 // removeWrappersForPackage removes wrapper scripts for a specific package
-func (p *NuGetProvider) removeWrappersForPackage(packageName string) error {
+func (p *NuGetProvider) removeWrappersForPackage(ctx context.Context, packageName string) error {
 	desired := lppNugetGetDataForProvider("nuget").Packages
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	parser := registry_parser.NewDefaultRegistryParser()
 
 	for _, pkg := range desired {
@@ -350,7 +342,7 @@ func (p *NuGetProvider) removeWrappersForPackage(packageName string) error {
 			wrapperPath := filepath.Join(zanaBinDir, binName)
 			if _, err := nugetLstat(wrapperPath); err == nil {
 				if err := nugetRemove(wrapperPath); err != nil {
-					Logger.Info(fmt.Sprintf("NuGet: Warning removing wrapper %s: %v", wrapperPath, err))
+					Logger.InfoContext(ctx, fmt.Sprintf("NuGet: Warning removing wrapper %s: %v", wrapperPath, err))
 				}
 			}
 		}
@@ -358,8 +350,8 @@ func (p *NuGetProvider) removeWrappersForPackage(packageName string) error {
 	return nil
 }
 
-func (p *NuGetProvider) Sync() bool {
-	Logger.Info("NuGet Sync: Syncing NuGet packages")
+func (p *NuGetProvider) Sync(ctx context.Context) bool {
+	Logger.InfoContext(ctx, "NuGet Sync: Syncing NuGet packages")
 	localPackages := lppNugetGetDataForProvider(p.PROVIDER_NAME).Packages
 
 	if len(localPackages) == 0 {
@@ -367,8 +359,8 @@ func (p *NuGetProvider) Sync() bool {
 	}
 
 	// Check for dotnet command before proceeding
-	if !nugetHasCommand("dotnet", []string{"--version"}, nil) {
-		Logger.Error("NuGet Sync: dotnet command not found. Please install .NET SDK.")
+	if !nugetHasCommand(ctx, "dotnet", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "NuGet Sync: dotnet command not found. Please install .NET SDK.")
 		return false
 	}
 
@@ -382,16 +374,16 @@ func (p *NuGetProvider) Sync() bool {
 		if pkg.Version != "" && pkg.Version != "latest" {
 			args = append(args, "--version", pkg.Version)
 		}
-		code, err := nugetShellOut(nugetCmd, args, p.APP_PACKAGES_DIR, nil)
+		code, err := nugetShellOut(newOperation(ctx, actionSync, pkg.SourceID), nugetCmd, args, p.APP_PACKAGES_DIR, nil)
 		if err != nil || code != 0 {
-			Logger.Error(fmt.Sprintf("NuGet Sync: Error installing %s: %v", packageName, err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("NuGet Sync: Error installing %s: %v", packageName, err))
 			return false
 		}
 	}
 
 	// Recreate wrappers
-	if err := p.createWrappers(); err != nil {
-		Logger.Info(fmt.Sprintf("NuGet Sync: Warning creating wrappers: %v", err))
+	if err := p.createWrappers(ctx); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("NuGet Sync: Warning creating wrappers: %v", err))
 	}
 
 	return true
@@ -1,16 +1,16 @@
 package providers
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
-	"github.com/mistweaverco/zana-client/internal/lib/shell_out"
 )
 
 type OpamProvider struct {
@@ -22,9 +22,9 @@ type OpamProvider struct {
 var opamCmd = "opam"
 
 // Injectable shell and OS helpers for tests
-var opamShellOut = shell_out.ShellOut
-var opamShellOutCapture = shell_out.ShellOutCapture
-var opamHasCommand = shell_out.HasCommand
+var opamShellOut = shellOut
+var opamShellOutCapture = shellOutCapture
+var opamHasCommand = hasCommand
 var opamLstat = os.Lstat
 var opamRemove = os.Remove
 var opamChmod = os.Chmod
@@ -47,34 +47,28 @@ func NewProviderOpam() *OpamProvider {
 
 func (p *OpamProvider) getRepo(sourceID string) string {
 	// Support both legacy (pkg:opam/pkg) and new (opam:pkg) formats
-	normalized := normalizePackageID(sourceID)
+	normalized := packageid.Normalize(sourceID)
 	if strings.HasPrefix(normalized, p.PREFIX) {
 		return strings.TrimPrefix(normalized, p.PREFIX)
 	}
-	// Fallback for legacy format
-	re := regexp.MustCompile("^pkg:" + p.PROVIDER_NAME + "/(.*)")
-	matches := re.FindStringSubmatch(sourceID)
-	if len(matches) > 1 {
-		return matches[1]
-	}
 	return ""
 }
 
-func (p *OpamProvider) Install(sourceID, version string) bool {
+func (p *OpamProvider) Install(ctx context.Context, sourceID, version string) bool {
 	packageName := p.getRepo(sourceID)
 	if packageName == "" {
-		Logger.Error("OPAM Install: Invalid source ID format")
+		Logger.ErrorContext(ctx, "OPAM Install: Invalid source ID format")
 		return false
 	}
 
-	if !opamHasCommand("opam", []string{"--version"}, nil) {
-		Logger.Error("OPAM Install: opam command not found. Please install OPAM.")
+	if !opamHasCommand(ctx, "opam", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "OPAM Install: opam command not found. Please install OPAM.")
 		return false
 	}
 
 	// Ensure packages directory exists
 	if err := opamMkdirAll(p.APP_PACKAGES_DIR, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("OPAM Install: Error creating packages directory: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("OPAM Install: Error creating packages directory: %v", err))
 		return false
 	}
 
@@ -82,12 +76,12 @@ func (p *OpamProvider) Install(sourceID, version string) bool {
 	switchPath := filepath.Join(p.APP_PACKAGES_DIR, "switch")
 	if _, err := opamStat(switchPath); os.IsNotExist(err) {
 		// Initialize OPAM switch
-		code, err := opamShellOut(opamCmd, []string{"switch", "create", switchPath, "ocaml-base-compiler.5.1.0", "--no-switch"}, "", nil)
+		code, err := opamShellOut(ctx, opamCmd, []string{"switch", "create", switchPath, "ocaml-base-compiler.5.1.0", "--no-switch"}, "", nil)
 		if err != nil || code != 0 {
 			// Try with default compiler
-			code, err = opamShellOut(opamCmd, []string{"switch", "create", switchPath, "--no-switch"}, "", nil)
+			code, err = opamShellOut(ctx, opamCmd, []string{"switch", "create", switchPath, "--no-switch"}, "", nil)
 			if err != nil || code != 0 {
-				Logger.Error(fmt.Sprintf("OPAM Install: Error creating switch: %v", err))
+				Logger.ErrorContext(ctx, fmt.Sprintf("OPAM Install: Error creating switch: %v", err))
 				return false
 			}
 		}
@@ -99,10 +93,10 @@ func (p *OpamProvider) Install(sourceID, version string) bool {
 		packageSpec = fmt.Sprintf("%s.%s", packageName, version)
 	}
 
-	Logger.Info(fmt.Sprintf("OPAM Install: Installing %s@%s", packageName, version))
-	code, err := opamShellOut(opamCmd, []string{"install", packageSpec, "--switch", switchPath, "--yes", "--no-depexts"}, "", nil)
+	Logger.InfoContext(ctx, fmt.Sprintf("OPAM Install: Installing %s@%s", packageName, version))
+	code, err := opamShellOut(ctx, opamCmd, []string{"install", packageSpec, "--switch", switchPath, "--yes", "--no-depexts"}, "", nil)
 	if err != nil || code != 0 {
-		Logger.Error(fmt.Sprintf("OPAM Install: Error installing package: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("OPAM Install: Error installing package: %v", err))
 		return false
 	}
 
@@ -110,7 +104,7 @@ func (p *OpamProvider) Install(sourceID, version string) bool {
 	installedVersion := version
 	if installedVersion == "" || installedVersion == "latest" {
 		// Try to get the installed version
-		code, output, err := opamShellOutCapture(opamCmd, []string{"list", "--switch", switchPath, "--installed"}, "", nil)
+		code, output, err := opamShellOutCapture(ctx, opamCmd, []string{"list", "--switch", switchPath, "--installed"}, "", nil)
 		if err == nil && code == 0 {
 			lines := strings.Split(output, "\n")
 			for _, line := range lines {
@@ -131,82 +125,82 @@ func (p *OpamProvider) Install(sourceID, version string) bool {
 
 	// Add to local packages
 	if err := lppOpamAdd(sourceID, installedVersion); err != nil {
-		Logger.Error(fmt.Sprintf("OPAM Install: Error adding package to local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("OPAM Install: Error adding package to local packages: %v", err))
 		return false
 	}
 
 	// Create wrappers for binaries
-	if err := p.createWrappers(); err != nil {
-		Logger.Info(fmt.Sprintf("OPAM Install: Warning creating wrappers: %v", err))
+	if err := p.createWrappers(ctx); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("OPAM Install: Warning creating wrappers: %v", err))
 	}
 
-	Logger.Info(fmt.Sprintf("OPAM Install: Successfully installed %s@%s", packageName, installedVersion))
+	Logger.InfoContext(ctx, fmt.Sprintf("OPAM Install: Successfully installed %s@%s", packageName, installedVersion))
 	return true
 }
 
-func (p *OpamProvider) Remove(sourceID string) bool {
+func (p *OpamProvider) Remove(ctx context.Context, sourceID string) bool {
 	packageName := p.getRepo(sourceID)
 	if packageName == "" {
-		Logger.Error("OPAM Remove: Invalid source ID format")
+		Logger.ErrorContext(ctx, "OPAM Remove: Invalid source ID format")
 		return false
 	}
 
-	if !opamHasCommand("opam", []string{"--version"}, nil) {
-		Logger.Error("OPAM Remove: opam command not found. Please install OPAM.")
+	if !opamHasCommand(ctx, "opam", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "OPAM Remove: opam command not found. Please install OPAM.")
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("OPAM Remove: Removing %s", packageName))
+	Logger.InfoContext(ctx, fmt.Sprintf("OPAM Remove: Removing %s", packageName))
 
 	switchPath := filepath.Join(p.APP_PACKAGES_DIR, "switch")
 
 	// Remove wrappers
-	if err := p.removeWrappersForPackage(packageName); err != nil {
-		Logger.Info(fmt.Sprintf("OPAM Remove: Warning removing wrappers: %v", err))
+	if err := p.removeWrappersForPackage(ctx, packageName); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("OPAM Remove: Warning removing wrappers: %v", err))
 	}
 
 	// Uninstall package
-	code, err := opamShellOut(opamCmd, []string{"remove", packageName, "--switch", switchPath, "--yes"}, "", nil)
+	code, err := opamShellOut(ctx, opamCmd, []string{"remove", packageName, "--switch", switchPath, "--yes"}, "", nil)
 	if err != nil || code != 0 {
-		Logger.Info(fmt.Sprintf("OPAM Remove: Warning uninstalling package (may not be installed): %v", err))
+		Logger.InfoContext(ctx, fmt.Sprintf("OPAM Remove: Warning uninstalling package (may not be installed): %v", err))
 	}
 
 	// Remove from local packages
 	if err := lppOpamRemove(sourceID); err != nil {
-		Logger.Error(fmt.Sprintf("OPAM Remove: Error removing package from local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("OPAM Remove: Error removing package from local packages: %v", err))
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("OPAM Remove: Successfully removed %s", packageName))
+	Logger.InfoContext(ctx, fmt.Sprintf("OPAM Remove: Successfully removed %s", packageName))
 	return true
 }
 
-func (p *OpamProvider) Update(sourceID string) bool {
+func (p *OpamProvider) Update(ctx context.Context, sourceID string) bool {
 	packageName := p.getRepo(sourceID)
 	if packageName == "" {
-		Logger.Error("OPAM Update: Invalid source ID format")
+		Logger.ErrorContext(ctx, "OPAM Update: Invalid source ID format")
 		return false
 	}
 
-	if !opamHasCommand("opam", []string{"--version"}, nil) {
-		Logger.Error("OPAM Update: opam command not found. Please install OPAM.")
+	if !opamHasCommand(ctx, "opam", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "OPAM Update: opam command not found. Please install OPAM.")
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("OPAM Update: Updating %s", packageName))
+	Logger.InfoContext(ctx, fmt.Sprintf("OPAM Update: Updating %s", packageName))
 
 	switchPath := filepath.Join(p.APP_PACKAGES_DIR, "switch")
 
 	// Update package
-	code, err := opamShellOut(opamCmd, []string{"upgrade", packageName, "--switch", switchPath, "--yes", "--no-depexts"}, "", nil)
+	code, err := opamShellOut(ctx, opamCmd, []string{"upgrade", packageName, "--switch", switchPath, "--yes", "--no-depexts"}, "", nil)
 	if err != nil || code != 0 {
-		Logger.Error(fmt.Sprintf("OPAM Update: Error updating package: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("OPAM Update: Error updating package: %v", err))
 		return false
 	}
 
 	// Get updated version
 	var updatedVersion string
-	code, output, err := opamShellOutCapture(opamCmd, []string{"list", "--switch", switchPath, "--installed"}, "", nil)
+	code, output, err := opamShellOutCapture(ctx, opamCmd, []string{"list", "--switch", switchPath, "--installed"}, "", nil)
 	if err == nil && code == 0 {
 		lines := strings.Split(output, "\n")
 		for _, line := range lines {
@@ -226,26 +220,26 @@ func (p *OpamProvider) Update(sourceID string) bool {
 	// Update local packages
 	if err := lppOpamRemove(sourceID); err == nil {
 		if err := lppOpamAdd(sourceID, updatedVersion); err != nil {
-			Logger.Error(fmt.Sprintf("OPAM Update: Error updating package in local packages: %v", err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("OPAM Update: Error updating package in local packages: %v", err))
 			return false
 		}
 	}
 
 	// Recreate wrappers
-	if err := p.createWrappers(); err != nil {
-		Logger.Info(fmt.Sprintf("OPAM Update: Warning recreating wrappers: %v", err))
+	if err := p.createWrappers(ctx); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("OPAM Update: Warning recreating wrappers: %v", err))
 	}
 
-	Logger.Info(fmt.Sprintf("OPAM Update: Successfully updated %s@%s", packageName, updatedVersion))
+	Logger.InfoContext(ctx, fmt.Sprintf("OPAM Update: Successfully updated %s@%s", packageName, updatedVersion))
 	return true
 }
 
-func (p *OpamProvider) getLatestVersion(packageName string) (string, error) {
-	if !opamHasCommand("opam", []string{"--version"}, nil) {
+func (p *OpamProvider) getLatestVersion(ctx context.Context, packageName string) (string, error) {
+	if !opamHasCommand(ctx, "opam", []string{"--version"}, nil) {
 		return "", fmt.Errorf("opam command not found")
 	}
 
-	code, output, err := opamShellOutCapture(opamCmd, []string{"show", packageName, "--field", "version"}, "", nil)
+	code, output, err := opamShellOutCapture(ctx, opamCmd, []string{"show", packageName, "--field", "version"}, "", nil)
 	if err != nil || code != 0 {
 		return "", fmt.Errorf("failed to get package info: %v", err)
 	}
@@ -270,29 +264,33 @@ func (p *OpamProvider) findOpamBinDir() string {
 }
 
 // createWrappers creates wrapper scripts for OPAM executables
-func (p *OpamProvider) createWrappers() error {
+func (p *OpamProvider) createWrappers(ctx context.Context) error {
 	desired := lppOpamGetDataForProvider("opam").Packages
 	if len(desired) == 0 {
 		return nil
 	}
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	parser := registry_parser.NewDefaultRegistryParser()
 	for _, pkg := range desired {
 		registryItem := parser.GetBySourceId(pkg.SourceID)
 		if len(registryItem.Bin) == 0 {
 			continue
 		}
+		filter := binFilterFor(pkg.SourceID, registryItem)
 		for binName, binCmd := range registryItem.Bin {
+			if !filter.allows(binName) {
+				continue
+			}
 			wrapperPath := filepath.Join(zanaBinDir, binName)
 			if _, err := opamLstat(wrapperPath); err == nil {
 				_ = opamRemove(wrapperPath)
 			}
 			if err := p.createOpamWrapperForCommand(binCmd, wrapperPath); err != nil {
-				Logger.Error(fmt.Sprintf("Error creating wrapper for %s: %v", binName, err))
+				Logger.ErrorContext(ctx, fmt.Sprintf("Error creating wrapper for %s: %v", binName, err))
 				continue
 			}
 			if err := opamChmod(wrapperPath, 0755); err != nil {
-				Logger.Error(fmt.Sprintf("Error setting executable permissions for %s: %v", binName, err))
+				Logger.ErrorContext(ctx, fmt.Sprintf("Error setting executable permissions for %s: %v", binName, err))
 			}
 		}
 	}
@@ -316,29 +314,21 @@ func (p *OpamProvider) createOpamWrapperForCommand(commandToExec string, wrapper
 		execCmd = filepath.Join(opamBinDir, commandToExec)
 	}
 
-	wrapperContent := fmt.Sprintf(`#!/bin/sh
-# Sets up OCaml/OPAM environment for zana-installed packages and runs the target command
-
-# Add the zana OPAM bin directory to PATH
-export PATH="%s:$PATH"
-
-# Set OPAM switch
-export OPAM_SWITCH_PREFIX="%s"
-
-# Execute the command from registry
-exec %s "$@"
-`, opamBinDir, switchPath, execCmd)
-
-	if err := opamWriteFile(wrapperPath, []byte(wrapperContent), 0755); err != nil {
-		return err
+	script := wrapperScript{
+		Description: "Sets up the OCaml/OPAM environment for zana-installed packages and runs the target command",
+		Env: []wrapperEnv{
+			{Name: "PATH", Value: opamBinDir, Separator: ":"},
+			{Name: "OPAM_SWITCH_PREFIX", Value: switchPath},
+		},
+		Command: []string{execCmd},
 	}
-	return nil
+	return writeWrapperScript(script, wrapperPath, opamWriteFile)
 }
 
 // removeWrappersForPackage removes wrapper scripts for a specific package
-func (p *OpamProvider) removeWrappersForPackage(packageName string) error {
+func (p *OpamProvider) removeWrappersForPackage(ctx context.Context, packageName string) error {
 	desired := lppOpamGetDataForProvider("opam").Packages
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	parser := registry_parser.NewDefaultRegistryParser()
 
 	for _, pkg := range desired {
@@ -350,7 +340,7 @@ func (p *OpamProvider) removeWrappersForPackage(packageName string) error {
 			wrapperPath := filepath.Join(zanaBinDir, binName)
 			if _, err := opamLstat(wrapperPath); err == nil {
 				if err := opamRemove(wrapperPath); err != nil {
-					Logger.Info(fmt.Sprintf("OPAM: Warning removing wrapper %s: %v", wrapperPath, err))
+					Logger.InfoContext(ctx, fmt.Sprintf("OPAM: Warning removing wrapper %s: %v", wrapperPath, err))
 				}
 			}
 		}
@@ -358,8 +348,8 @@ func (p *OpamProvider) removeWrappersForPackage(packageName string) error {
 	return nil
 }
 
-func (p *OpamProvider) Sync() bool {
-	Logger.Info("OPAM Sync: Syncing OPAM packages")
+func (p *OpamProvider) Sync(ctx context.Context) bool {
+	Logger.InfoContext(ctx, "OPAM Sync: Syncing OPAM packages")
 	localPackages := lppOpamGetDataForProvider(p.PROVIDER_NAME).Packages
 
 	if len(localPackages) == 0 {
@@ -367,8 +357,8 @@ func (p *OpamProvider) Sync() bool {
 	}
 
 	// Check for opam command before proceeding
-	if !opamHasCommand("opam", []string{"--version"}, nil) {
-		Logger.Error("OPAM Sync: opam command not found. Please install OPAM.")
+	if !opamHasCommand(ctx, "opam", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "OPAM Sync: opam command not found. Please install OPAM.")
 		return false
 	}
 
@@ -384,16 +374,16 @@ func (p *OpamProvider) Sync() bool {
 		if pkg.Version != "" && pkg.Version != "latest" {
 			packageSpec = fmt.Sprintf("%s.%s", packageName, pkg.Version)
 		}
-		code, err := opamShellOut(opamCmd, []string{"install", packageSpec, "--switch", switchPath, "--yes", "--no-depexts"}, "", nil)
+		code, err := opamShellOut(newOperation(ctx, actionSync, pkg.SourceID), opamCmd, []string{"install", packageSpec, "--switch", switchPath, "--yes", "--no-depexts"}, "", nil)
 		if err != nil || code != 0 {
-			Logger.Error(fmt.Sprintf("OPAM Sync: Error installing %s: %v", packageName, err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("OPAM Sync: Error installing %s: %v", packageName, err))
 			allOk = false
 		}
 	}
 
 	// Recreate wrappers
-	if err := p.createWrappers(); err != nil {
-		Logger.Info(fmt.Sprintf("OPAM Sync: Warning creating wrappers: %v", err))
+	if err := p.createWrappers(ctx); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("OPAM Sync: Warning creating wrappers: %v", err))
 	}
 
 	return allOk
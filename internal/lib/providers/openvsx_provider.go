@@ -1,17 +1,18 @@
 package providers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
 )
 
@@ -53,16 +54,10 @@ func NewProviderOpenVSX() *OpenVSXProvider {
 
 func (p *OpenVSXProvider) getRepo(sourceID string) string {
 	// Support both legacy (pkg:openvsx/publisher/extension) and new (openvsx:publisher/extension) formats
-	normalized := normalizePackageID(sourceID)
+	normalized := packageid.Normalize(sourceID)
 	if strings.HasPrefix(normalized, p.PREFIX) {
 		return strings.TrimPrefix(normalized, p.PREFIX)
 	}
-	// Fallback for legacy format
-	re := regexp.MustCompile("^pkg:" + p.PROVIDER_NAME + "/(.*)")
-	matches := re.FindStringSubmatch(sourceID)
-	if len(matches) > 1 {
-		return matches[1]
-	}
 	return ""
 }
 
@@ -72,17 +67,17 @@ func (p *OpenVSXProvider) getExtensionPath(publisher, extension string) string {
 	return filepath.Join(p.APP_PACKAGES_DIR, safePath)
 }
 
-func (p *OpenVSXProvider) Install(sourceID, version string) bool {
+func (p *OpenVSXProvider) Install(ctx context.Context, sourceID, version string) bool {
 	repo := p.getRepo(sourceID)
 	if repo == "" {
-		Logger.Error("OpenVSX Install: Invalid source ID format")
+		Logger.ErrorContext(ctx, "OpenVSX Install: Invalid source ID format")
 		return false
 	}
 
 	// Parse publisher/extension from repo
 	parts := strings.Split(repo, "/")
 	if len(parts) != 2 {
-		Logger.Error("OpenVSX Install: Invalid format, expected publisher/extension")
+		Logger.ErrorContext(ctx, "OpenVSX Install: Invalid format, expected publisher/extension")
 		return false
 	}
 	publisher := parts[0]
@@ -98,9 +93,9 @@ func (p *OpenVSXProvider) Install(sourceID, version string) bool {
 		resolvedVersion = registryItem.Version
 		if resolvedVersion == "" {
 			// Try to get latest version from OpenVSX API
-			latestVersion, err := p.getLatestVersion(repo)
+			latestVersion, err := latestVersionOf(ctx, ProviderOpenVSX, repo, p.getLatestVersion)
 			if err != nil {
-				Logger.Error(fmt.Sprintf("OpenVSX Install: Could not determine latest version: %v", err))
+				Logger.ErrorContext(ctx, fmt.Sprintf("OpenVSX Install: Could not determine latest version: %v", err))
 				return false
 			}
 			resolvedVersion = latestVersion
@@ -111,7 +106,7 @@ func (p *OpenVSXProvider) Install(sourceID, version string) bool {
 	var vsixFileName string
 	if len(registryItem.Source.Asset) > 0 {
 		// Use asset file from registry
-		asset := FindMatchingAsset(registryItem.Source.Asset)
+		asset := FindMatchingAsset(ctx, registryItem.Source.Asset)
 		if asset != nil {
 			vsixFileName = ResolveTemplate(asset.File.String(), resolvedVersion)
 		}
@@ -122,31 +117,31 @@ func (p *OpenVSXProvider) Install(sourceID, version string) bool {
 
 	// Download VSIX file
 	downloadURL := fmt.Sprintf("%s/api/%s/%s/%s/file/%s", p.BASE_URL, publisher, extension, resolvedVersion, vsixFileName)
-	Logger.Info(fmt.Sprintf("OpenVSX Install: Downloading extension from %s", downloadURL))
+	Logger.InfoContext(ctx, fmt.Sprintf("OpenVSX Install: Downloading extension from %s", downloadURL))
 
 	// Ensure packages directory exists
 	if err := openvsxMkdirAll(p.APP_PACKAGES_DIR, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("OpenVSX Install: Error creating packages directory: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("OpenVSX Install: Error creating packages directory: %v", err))
 		return false
 	}
 
 	// Create temporary directory for download
 	extractPath := p.getExtensionPath(publisher, extension)
 	if err := openvsxMkdirAll(extractPath, 0755); err != nil {
-		Logger.Error(fmt.Sprintf("OpenVSX Install: Error creating extract directory: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("OpenVSX Install: Error creating extract directory: %v", err))
 		return false
 	}
 
 	// Download VSIX file
 	vsixPath := filepath.Join(extractPath, vsixFileName)
-	if err := p.downloadFile(downloadURL, vsixPath); err != nil {
-		Logger.Error(fmt.Sprintf("OpenVSX Install: Error downloading VSIX: %v", err))
+	if err := p.downloadFile(ctx, downloadURL, vsixPath); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("OpenVSX Install: Error downloading VSIX: %v", err))
 		return false
 	}
 
 	// Extract VSIX (it's a ZIP file)
 	if err := files.Unzip(vsixPath, extractPath); err != nil {
-		Logger.Error(fmt.Sprintf("OpenVSX Install: Error extracting VSIX: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("OpenVSX Install: Error extracting VSIX: %v", err))
 		return false
 	}
 
@@ -154,81 +149,81 @@ func (p *OpenVSXProvider) Install(sourceID, version string) bool {
 	_ = openvsxRemove(vsixPath)
 
 	// Create symlinks for binaries
-	if err := p.createSymlinksFromRegistry(publisher, extension, extractPath, registryItem); err != nil {
-		Logger.Info(fmt.Sprintf("OpenVSX Install: Warning creating symlinks: %v", err))
+	if err := p.createSymlinksFromRegistry(ctx, publisher, extension, extractPath, registryItem); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("OpenVSX Install: Warning creating symlinks: %v", err))
 	}
 
 	// Add to local packages
 	if err := lppOpenvsxAdd(sourceID, resolvedVersion); err != nil {
-		Logger.Error(fmt.Sprintf("OpenVSX Install: Error adding package to local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("OpenVSX Install: Error adding package to local packages: %v", err))
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("OpenVSX Install: Successfully installed %s@%s", repo, resolvedVersion))
+	Logger.InfoContext(ctx, fmt.Sprintf("OpenVSX Install: Successfully installed %s@%s", repo, resolvedVersion))
 	return true
 }
 
-func (p *OpenVSXProvider) Remove(sourceID string) bool {
+func (p *OpenVSXProvider) Remove(ctx context.Context, sourceID string) bool {
 	repo := p.getRepo(sourceID)
 	if repo == "" {
-		Logger.Error("OpenVSX Remove: Invalid source ID format")
+		Logger.ErrorContext(ctx, "OpenVSX Remove: Invalid source ID format")
 		return false
 	}
 
 	parts := strings.Split(repo, "/")
 	if len(parts) != 2 {
-		Logger.Error("OpenVSX Remove: Invalid format")
+		Logger.ErrorContext(ctx, "OpenVSX Remove: Invalid format")
 		return false
 	}
 	publisher := parts[0]
 	extension := parts[1]
 
-	Logger.Info(fmt.Sprintf("OpenVSX Remove: Removing %s", repo))
+	Logger.InfoContext(ctx, fmt.Sprintf("OpenVSX Remove: Removing %s", repo))
 
 	extractPath := p.getExtensionPath(publisher, extension)
 
 	// Remove symlinks
-	if err := p.removeSymlinks(repo); err != nil {
-		Logger.Info(fmt.Sprintf("OpenVSX Remove: Warning removing symlinks: %v", err))
+	if err := p.removeSymlinks(ctx, repo); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("OpenVSX Remove: Warning removing symlinks: %v", err))
 	}
 
 	// Remove extension directory
 	if _, err := openvsxStat(extractPath); err == nil {
 		if err := openvsxRemoveAll(extractPath); err != nil {
-			Logger.Error(fmt.Sprintf("OpenVSX Remove: Error removing extension directory: %v", err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("OpenVSX Remove: Error removing extension directory: %v", err))
 			return false
 		}
 	}
 
 	// Remove from local packages
 	if err := lppOpenvsxRemove(sourceID); err != nil {
-		Logger.Error(fmt.Sprintf("OpenVSX Remove: Error removing package from local packages: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("OpenVSX Remove: Error removing package from local packages: %v", err))
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("OpenVSX Remove: Successfully removed %s", repo))
+	Logger.InfoContext(ctx, fmt.Sprintf("OpenVSX Remove: Successfully removed %s", repo))
 	return true
 }
 
-func (p *OpenVSXProvider) Update(sourceID string) bool {
+func (p *OpenVSXProvider) Update(ctx context.Context, sourceID string) bool {
 	repo := p.getRepo(sourceID)
 	if repo == "" {
-		Logger.Error("OpenVSX Update: Invalid source ID format")
+		Logger.ErrorContext(ctx, "OpenVSX Update: Invalid source ID format")
 		return false
 	}
 
 	// Get latest version
-	latestVersion, err := p.getLatestVersion(repo)
+	latestVersion, err := latestVersionOf(ctx, ProviderOpenVSX, repo, p.getLatestVersion)
 	if err != nil {
-		Logger.Error(fmt.Sprintf("OpenVSX Update: Could not determine latest version: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("OpenVSX Update: Could not determine latest version: %v", err))
 		return false
 	}
 
-	Logger.Info(fmt.Sprintf("OpenVSX Update: Updating %s to version %s", repo, latestVersion))
-	return p.Install(sourceID, latestVersion)
+	Logger.InfoContext(ctx, fmt.Sprintf("OpenVSX Update: Updating %s to version %s", repo, latestVersion))
+	return p.Install(ctx, sourceID, latestVersion)
 }
 
-func (p *OpenVSXProvider) getLatestVersion(repo string) (string, error) {
+func (p *OpenVSXProvider) getLatestVersion(_ context.Context, repo string) (string, error) {
 	apiURL := fmt.Sprintf("%s/api/%s", p.BASE_URL, repo)
 	resp, err := openvsxHTTPGet(apiURL)
 	if err != nil {
@@ -251,7 +246,7 @@ func (p *OpenVSXProvider) getLatestVersion(repo string) (string, error) {
 }
 
 // downloadFile downloads a file from a URL to a destination path
-func (p *OpenVSXProvider) downloadFile(url, destPath string) error {
+func (p *OpenVSXProvider) downloadFile(ctx context.Context, url, destPath string) error {
 	resp, err := openvsxHTTPGet(url)
 	if err != nil {
 		return fmt.Errorf("failed to download: %w", err)
@@ -268,7 +263,7 @@ func (p *OpenVSXProvider) downloadFile(url, destPath string) error {
 	}
 	defer func() { _ = file.Close() }()
 
-	if _, err := io.Copy(file, resp.Body); err != nil {
+	if _, err := io.Copy(file, downloadBody(ctx, resp, url)); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
@@ -276,10 +271,14 @@ func (p *OpenVSXProvider) downloadFile(url, destPath string) error {
 }
 
 // createSymlinksFromRegistry creates symlinks based on registry bin configuration
-func (p *OpenVSXProvider) createSymlinksFromRegistry(publisher, extension, extractPath string, registryItem registry_parser.RegistryItem) error {
-	zanaBinDir := files.GetAppBinPath()
+func (p *OpenVSXProvider) createSymlinksFromRegistry(ctx context.Context, publisher, extension, extractPath string, registryItem registry_parser.RegistryItem) error {
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
+	filter := binFilterFor(registryItem.Source.ID, registryItem)
 
 	for binName, binTemplate := range registryItem.Bin {
+		if !filter.allows(binName) {
+			continue
+		}
 		binPath := ResolveBinPath(binTemplate, nil, binName)
 		if binPath == "" {
 			continue
@@ -317,9 +316,9 @@ func (p *OpenVSXProvider) createSymlinksFromRegistry(publisher, extension, extra
 		}
 
 		if err := openvsxSymlink(relPath, symlink); err != nil {
-			Logger.Info(fmt.Sprintf("OpenVSX: Warning creating symlink %s -> %s: %v", symlink, relPath, err))
+			Logger.InfoContext(ctx, fmt.Sprintf("OpenVSX: Warning creating symlink %s -> %s: %v", symlink, relPath, err))
 		} else {
-			Logger.Info(fmt.Sprintf("OpenVSX: Created symlink %s -> %s", symlink, relPath))
+			Logger.InfoContext(ctx, fmt.Sprintf("OpenVSX: Created symlink %s -> %s", symlink, relPath))
 		}
 	}
 
@@ -348,8 +347,8 @@ func (p *OpenVSXProvider) findBinaryInDir(dir, name string) string {
 }
 
 // removeSymlinks removes symlinks for a specific extension
-func (p *OpenVSXProvider) removeSymlinks(repo string) error {
-	zanaBinDir := files.GetAppBinPath()
+func (p *OpenVSXProvider) removeSymlinks(ctx context.Context, repo string) error {
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	extractPath := p.getExtensionPath(strings.Split(repo, "/")[0], strings.Split(repo, "/")[1])
 
 	entries, err := openvsxReadDir(zanaBinDir)
@@ -370,7 +369,7 @@ func (p *OpenVSXProvider) removeSymlinks(repo string) error {
 				}
 				if strings.HasPrefix(target, extractPath) {
 					if err := openvsxRemove(symlink); err != nil {
-						Logger.Info(fmt.Sprintf("OpenVSX: Warning removing symlink %s: %v", symlink, err))
+						Logger.InfoContext(ctx, fmt.Sprintf("OpenVSX: Warning removing symlink %s: %v", symlink, err))
 					}
 				}
 			}
@@ -380,8 +379,8 @@ func (p *OpenVSXProvider) removeSymlinks(repo string) error {
 	return nil
 }
 
-func (p *OpenVSXProvider) Sync() bool {
-	Logger.Info("OpenVSX Sync: Syncing OpenVSX packages")
+func (p *OpenVSXProvider) Sync(ctx context.Context) bool {
+	Logger.InfoContext(ctx, "OpenVSX Sync: Syncing OpenVSX packages")
 	localPackages := lppOpenvsxGetDataForProvider(p.PROVIDER_NAME).Packages
 
 	allOk := true
@@ -397,16 +396,16 @@ func (p *OpenVSXProvider) Sync() bool {
 		extractPath := p.getExtensionPath(parts[0], parts[1])
 		if _, err := openvsxStat(extractPath); os.IsNotExist(err) {
 			// Re-install missing packages
-			Logger.Info(fmt.Sprintf("OpenVSX Sync: Re-installing missing package %s", repo))
-			if !p.Install(pkg.SourceID, pkg.Version) {
+			Logger.InfoContext(ctx, fmt.Sprintf("OpenVSX Sync: Re-installing missing package %s", repo))
+			if !p.Install(newOperation(ctx, actionSync, pkg.SourceID), pkg.SourceID, pkg.Version) {
 				allOk = false
 			}
 		} else {
 			// Update symlinks
 			registry := openvsxRegistryParser()
 			registryItem := registry.GetBySourceId(pkg.SourceID)
-			if err := p.createSymlinksFromRegistry(parts[0], parts[1], extractPath, registryItem); err != nil {
-				Logger.Info(fmt.Sprintf("OpenVSX Sync: Warning creating symlinks for %s: %v", repo, err))
+			if err := p.createSymlinksFromRegistry(ctx, parts[0], parts[1], extractPath, registryItem); err != nil {
+				Logger.InfoContext(ctx, fmt.Sprintf("OpenVSX Sync: Warning creating symlinks for %s: %v", repo, err))
 			}
 		}
 	}
@@ -0,0 +1,76 @@
+package providers
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/log"
+	"github.com/mistweaverco/zana-client/internal/lib/shell_out"
+)
+
+// actionSync is the action of the operations syncing the packages of a
+// provider with the lock file
+const actionSync = "sync"
+
+// newOperation returns a copy of ctx carrying a new package operation. The
+// context is passed down to the provider calls made for the operation, which
+// tie their log lines (logged with it, e.g. Logger.InfoContext),
+// subprocesses, trace spans, staging directories and asset pins to the
+// operation. Operations running at the same time each have their own.
+func newOperation(ctx context.Context, action, sourceID string) context.Context {
+	ctx = context.WithValue(ctx, operationResultKey{}, &operationResult{})
+	return log.WithOperation(ctx, log.NewOperation(action, sourceID))
+}
+
+type operationResultKey struct{}
+
+// operationResult collects what the provider calls made for an operation
+// report back to it
+type operationResult struct {
+	// reclaimedBytes is the space collectVersions freed
+	reclaimedBytes atomic.Int64
+	// commitRange is the commit range the existing clone of a git package
+	// moved across, nil when nothing was updated from a clone
+	commitRange atomic.Pointer[GitCommitRange]
+}
+
+// resultOf returns the result of the package operation ctx carries, nil
+// outside of operations
+func resultOf(ctx context.Context) *operationResult {
+	r, _ := ctx.Value(operationResultKey{}).(*operationResult)
+	return r
+}
+
+// currentOperation returns the package operation ctx carries, if any
+func currentOperation(ctx context.Context) (log.Operation, bool) {
+	return log.OperationFrom(ctx)
+}
+
+// shellOut runs a command for the package operation ctx carries, with the
+// wrapper and hardening configured for its package
+func shellOut(ctx context.Context, command string, args []string, dir string, env []string) (int, error) {
+	return shell_out.ShellOutContext(ctx, command, args, dir, env)
+}
+
+// shellOutCapture runs a command like shellOut, capturing its output
+func shellOutCapture(ctx context.Context, command string, args []string, dir string, env []string) (int, string, error) {
+	return shell_out.ShellOutCaptureContext(ctx, command, args, dir, env)
+}
+
+// shellOutInteractive runs a command like shellOut, attached to the terminal
+func shellOutInteractive(ctx context.Context, command string, args []string, dir string, env []string) (int, error) {
+	return shell_out.ShellOutInteractiveContext(ctx, command, args, dir, env)
+}
+
+// hasCommand checks for a command for the package operation ctx carries
+func hasCommand(ctx context.Context, command string, args []string, env []string) bool {
+	return shell_out.HasCommandContext(ctx, command, args, env)
+}
+
+// NewInstallOperation returns a context carrying a new install operation of
+// sourceID, for installs driven phase by phase from outside the package, see
+// GitHubTreeSitterPreflightInteractive
+func NewInstallOperation(sourceID string) context.Context {
+	return newOperation(context.Background(), files.HistoryActionInstall, sourceID)
+}
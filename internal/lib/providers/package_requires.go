@@ -9,6 +9,7 @@ import (
 	"github.com/charmbracelet/huh"
 	"github.com/mattn/go-isatty"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
 	"github.com/mistweaverco/zana-client/internal/lib/spinnerutil"
 )
@@ -104,7 +105,7 @@ func EnsureLockfilePackageRequires(autoInstall bool) error {
 	seen := map[string]struct{}{}
 	var combined []string
 	for _, pkg := range lock.Packages {
-		id := normalizePackageID(strings.TrimSpace(pkg.SourceID))
+		id := packageid.Normalize(strings.TrimSpace(pkg.SourceID))
 		if id == "" {
 			continue
 		}
@@ -242,7 +243,7 @@ func collectRequiresInstallOrder(
 	resolved map[string]bool,
 	order *[]string,
 ) (bool, error) {
-	id := normalizePackageID(sourceID)
+	id := packageid.Normalize(sourceID)
 	if id == "" {
 		return false, nil
 	}
@@ -277,7 +278,7 @@ func expandRegistryRequires(req *registry_parser.RegistryItemRequires, reg *regi
 	var deps []string
 	seen := map[string]struct{}{}
 	add := func(id string) {
-		n := normalizePackageID(id)
+		n := packageid.Normalize(id)
 		if n == "" {
 			return
 		}
@@ -317,7 +318,7 @@ func resolveRequiresOneChoice(refs []string, autoInstall bool) (string, error) {
 		if err != nil {
 			return "", err
 		}
-		options = append(options, normalizePackageID(id))
+		options = append(options, packageid.Normalize(id))
 	}
 	sort.Strings(options)
 	if autoInstall {
@@ -331,7 +332,7 @@ func resolveRequiresOneChoice(refs []string, autoInstall bool) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return normalizePackageID(chosen), nil
+	return packageid.Normalize(chosen), nil
 }
 
 func requiresOneSatisfied(refs []string) bool {
@@ -340,7 +341,7 @@ func requiresOneSatisfied(refs []string) bool {
 		if err != nil {
 			continue
 		}
-		if packageRequiresIsInstalled(normalizePackageID(id)) {
+		if packageRequiresIsInstalled(packageid.Normalize(id)) {
 			return true
 		}
 	}
@@ -353,20 +354,16 @@ func parseRequirePackageRef(ref string) (sourceID string, version string, err er
 	if ref == "" {
 		return "", "", fmt.Errorf("empty package requires reference")
 	}
-	if strings.HasPrefix(ref, "pkg:") {
-		base, ver := splitRequirePackageVersion(ref)
-		legacy := strings.TrimPrefix(base, "pkg:")
-		parts := strings.SplitN(legacy, "/", 2)
-		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+	base, ver := splitRequirePackageVersion(ref)
+	if packageid.IsLegacy(base) {
+		if base = packageid.Normalize(base); packageid.IsLegacy(base) {
 			return "", "", fmt.Errorf("invalid legacy requires reference %q", ref)
 		}
-		return normalizePackageID(parts[0] + ":" + parts[1]), ver, nil
 	}
-	if !strings.Contains(ref, ":") {
+	if !packageid.HasProvider(base) {
 		return "", "", fmt.Errorf("invalid requires reference %q: expected <provider>:<package-id>[@version]", ref)
 	}
-	base, ver := splitRequirePackageVersion(ref)
-	return normalizePackageID(base), ver, nil
+	return base, ver, nil
 }
 
 func splitRequirePackageVersion(pkgID string) (string, string) {
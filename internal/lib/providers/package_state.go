@@ -0,0 +1,147 @@
+package providers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+)
+
+// PackageState is the state of a package when the packages a provider should
+// have (zana-lock.json) are compared to the ones it actually has installed
+type PackageState string
+
+const (
+	// PackageStateInstalled means the desired version is installed
+	PackageStateInstalled PackageState = "installed"
+	// PackageStateMissing means the package is not installed at all
+	PackageStateMissing PackageState = "missing"
+	// PackageStateVersionMismatch means another version is installed
+	PackageStateVersionMismatch PackageState = "version-mismatch"
+	// PackageStateExtraneous means the package is installed but not desired
+	PackageStateExtraneous PackageState = "extraneous"
+)
+
+// desiredPackage is a package a provider should have installed, by its
+// provider-specific name (e.g. the npm package or crate name)
+type desiredPackage struct {
+	SourceID string
+	Name     string
+	Version  string
+}
+
+// packageStatus is the reconciled state of a single package.
+// Desired is empty for extraneous packages, Installed for missing ones.
+type packageStatus struct {
+	SourceID  string
+	Name      string
+	Desired   string
+	Installed string
+	State     PackageState
+}
+
+// packagePlan is the result of reconciling the desired with the actual state,
+// desired packages first in their given order, then extraneous ones by name
+type packagePlan []packageStatus
+
+// desiredPackagesFor maps lock entries to desired packages using the
+// provider's name resolution; entries without a name are skipped
+func desiredPackagesFor(packages []local_packages_parser.LocalPackageItem, nameOf func(sourceID string) string) []desiredPackage {
+	desired := make([]desiredPackage, 0, len(packages))
+	for _, pkg := range packages {
+		name := nameOf(pkg.SourceID)
+		if name == "" {
+			continue
+		}
+		desired = append(desired, desiredPackage{SourceID: pkg.SourceID, Name: name, Version: pkg.Version})
+	}
+	return desired
+}
+
+// reconcilePackages compares the desired packages with the installed ones
+// (name -> version). Names are compared after normalize, if given, so
+// providers with case-insensitive names can still match them up.
+func reconcilePackages(desired []desiredPackage, installed map[string]string, normalize func(string) string) packagePlan {
+	if normalize == nil {
+		normalize = func(name string) string { return name }
+	}
+	actual := make(map[string]string, len(installed))
+	actualNames := make(map[string]string, len(installed))
+	for name, version := range installed {
+		actual[normalize(name)] = version
+		actualNames[normalize(name)] = name
+	}
+
+	plan := make(packagePlan, 0, len(desired))
+	wanted := make(map[string]bool, len(desired))
+	for _, pkg := range desired {
+		key := normalize(pkg.Name)
+		wanted[key] = true
+		status := packageStatus{SourceID: pkg.SourceID, Name: pkg.Name, Desired: pkg.Version}
+		version, ok := actual[key]
+		switch {
+		case !ok:
+			status.State = PackageStateMissing
+		case version != pkg.Version:
+			status.Installed = version
+			status.State = PackageStateVersionMismatch
+		default:
+			status.Installed = version
+			status.State = PackageStateInstalled
+		}
+		plan = append(plan, status)
+	}
+
+	var extraneous []packageStatus
+	for key, version := range actual {
+		if !wanted[key] {
+			extraneous = append(extraneous, packageStatus{Name: actualNames[key], Installed: version, State: PackageStateExtraneous})
+		}
+	}
+	sort.Slice(extraneous, func(i, j int) bool { return extraneous[i].Name < extraneous[j].Name })
+	return append(plan, extraneous...)
+}
+
+// withState returns the packages in one of the given states
+func (plan packagePlan) withState(states ...PackageState) []packageStatus {
+	var result []packageStatus
+	for _, status := range plan {
+		for _, state := range states {
+			if status.State == state {
+				result = append(result, status)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Pending returns the desired packages that need to be installed
+func (plan packagePlan) Pending() []packageStatus {
+	return plan.withState(PackageStateMissing, PackageStateVersionMismatch)
+}
+
+// Extraneous returns the installed packages that are not desired
+func (plan packagePlan) Extraneous() []packageStatus {
+	return plan.withState(PackageStateExtraneous)
+}
+
+// InSync reports whether every desired package is installed at its version
+func (plan packagePlan) InSync() bool {
+	return len(plan.Pending()) == 0
+}
+
+// String summarizes the plan for logging, e.g. "2 installed, 1 missing"
+func (plan packagePlan) String() string {
+	var parts []string
+	for _, state := range []PackageState{PackageStateInstalled, PackageStateMissing, PackageStateVersionMismatch, PackageStateExtraneous} {
+		if n := len(plan.withState(state)); n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, state))
+		}
+	}
+	if len(parts) == 0 {
+		return "no packages"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,50 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcilePackages(t *testing.T) {
+	desired := []desiredPackage{
+		{SourceID: "pkg:npm/a", Name: "a", Version: "1.0.0"},
+		{SourceID: "pkg:npm/b", Name: "b", Version: "2.0.0"},
+		{SourceID: "pkg:npm/c", Name: "c", Version: "3.0.0"},
+	}
+	plan := reconcilePackages(desired, map[string]string{"a": "1.0.0", "b": "1.9.0", "z": "0.1.0", "y": "0.2.0"}, nil)
+
+	assert.Equal(t, packagePlan{
+		{SourceID: "pkg:npm/a", Name: "a", Desired: "1.0.0", Installed: "1.0.0", State: PackageStateInstalled},
+		{SourceID: "pkg:npm/b", Name: "b", Desired: "2.0.0", Installed: "1.9.0", State: PackageStateVersionMismatch},
+		{SourceID: "pkg:npm/c", Name: "c", Desired: "3.0.0", State: PackageStateMissing},
+		{Name: "y", Installed: "0.2.0", State: PackageStateExtraneous},
+		{Name: "z", Installed: "0.1.0", State: PackageStateExtraneous},
+	}, plan)
+	assert.False(t, plan.InSync())
+	assert.Len(t, plan.Pending(), 2)
+	assert.Len(t, plan.Extraneous(), 2)
+	assert.Equal(t, "1 installed, 1 missing, 1 version-mismatch, 2 extraneous", plan.String())
+
+	t.Run("names are compared normalized", func(t *testing.T) {
+		plan := reconcilePackages([]desiredPackage{{Name: "Foo_Bar", Version: "1.0"}}, map[string]string{"foo-bar": "1.0"}, normalizeDistributionName)
+		assert.True(t, plan.InSync())
+		assert.Empty(t, plan.Extraneous())
+	})
+
+	t.Run("nothing desired or installed", func(t *testing.T) {
+		plan := reconcilePackages(nil, nil, nil)
+		assert.True(t, plan.InSync())
+		assert.Equal(t, "no packages", plan.String())
+	})
+}
+
+func TestDesiredPackagesFor(t *testing.T) {
+	p := NewProviderNPM()
+	desired := desiredPackagesFor([]local_packages_parser.LocalPackageItem{
+		{SourceID: "pkg:npm/@scope/pkg", Version: "1.0.0"},
+		{SourceID: "pkg:npm/", Version: "1.0.0"},
+	}, p.getRepo)
+	assert.Equal(t, []desiredPackage{{SourceID: "pkg:npm/@scope/pkg", Name: "@scope/pkg", Version: "1.0.0"}}, desired)
+}
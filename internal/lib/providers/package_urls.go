@@ -0,0 +1,77 @@
+package providers
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+)
+
+// RepositoryURL returns where the source of a package is browsed, derived
+// from its source ID: the repository for git hosting providers, the package
+// page (which links the repository) for package registries.
+// It returns "" for providers without a public page, e.g. generic or local.
+func RepositoryURL(sourceID string) string {
+	provider, pkg := packageid.Split(packageid.Normalize(sourceID))
+	if pkg == "" {
+		return ""
+	}
+	switch provider {
+	case "github":
+		return "https://github.com/" + pkg
+	case "gitlab":
+		return "https://gitlab.com/" + pkg
+	case "codeberg":
+		return "https://codeberg.org/" + pkg
+	case "gitea":
+		// The package ID starts with the host of the instance
+		return "https://" + pkg
+	case "npm":
+		return "https://www.npmjs.com/package/" + pkg
+	case "pypi":
+		return fmt.Sprintf("https://pypi.org/project/%s/", url.PathEscape(pkg))
+	case "cargo":
+		return "https://crates.io/crates/" + url.PathEscape(pkg)
+	case "golang":
+		return "https://pkg.go.dev/" + pkg
+	case "gem":
+		return "https://rubygems.org/gems/" + url.PathEscape(pkg)
+	case "composer":
+		return "https://packagist.org/packages/" + pkg
+	case "nuget":
+		return "https://www.nuget.org/packages/" + url.PathEscape(pkg)
+	case "opam":
+		return fmt.Sprintf("https://opam.ocaml.org/packages/%s/", url.PathEscape(pkg))
+	case "openvsx":
+		return "https://open-vsx.org/extension/" + pkg
+	}
+	return ""
+}
+
+// ReleasesURL returns the page listing the releases or versions of a
+// package, or "" when RepositoryURL has none
+func ReleasesURL(sourceID string) string {
+	repoURL := RepositoryURL(sourceID)
+	if repoURL == "" {
+		return ""
+	}
+	provider, _ := packageid.Split(packageid.Normalize(sourceID))
+	switch provider {
+	case "github", "codeberg", "gitea":
+		return repoURL + "/releases"
+	case "gitlab":
+		return repoURL + "/-/releases"
+	case "npm":
+		return repoURL + "?activeTab=versions"
+	case "pypi":
+		return repoURL + "#history"
+	case "cargo", "gem":
+		return repoURL + "/versions"
+	case "golang":
+		return repoURL + "?tab=versions"
+	case "nuget":
+		return repoURL + "#versions-body-tab"
+	}
+	// Packagist, opam and Open VSX list the versions on the package page
+	return repoURL
+}
@@ -0,0 +1,27 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageURLs(t *testing.T) {
+	cases := []struct {
+		sourceID, repository, releases string
+	}{
+		{"github:BurntSushi/ripgrep", "https://github.com/BurntSushi/ripgrep", "https://github.com/BurntSushi/ripgrep/releases"},
+		{"pkg:github/BurntSushi/ripgrep", "https://github.com/BurntSushi/ripgrep", "https://github.com/BurntSushi/ripgrep/releases"},
+		{"gitlab:group/sub/project", "https://gitlab.com/group/sub/project", "https://gitlab.com/group/sub/project/-/releases"},
+		{"npm:@prisma/language-server", "https://www.npmjs.com/package/@prisma/language-server", "https://www.npmjs.com/package/@prisma/language-server?activeTab=versions"},
+		{"pypi:ruff", "https://pypi.org/project/ruff/", "https://pypi.org/project/ruff/#history"},
+		{"golang:golang.org/x/tools/gopls", "https://pkg.go.dev/golang.org/x/tools/gopls", "https://pkg.go.dev/golang.org/x/tools/gopls?tab=versions"},
+		{"composer:phpstan/phpstan", "https://packagist.org/packages/phpstan/phpstan", "https://packagist.org/packages/phpstan/phpstan"},
+		{"generic:example", "", ""},
+		{"ripgrep", "", ""},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.repository, RepositoryURL(c.sourceID), c.sourceID)
+		assert.Equal(t, c.releases, ReleasesURL(c.sourceID), c.sourceID)
+	}
+}
@@ -0,0 +1,191 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+)
+
+// Release asset installs of the github, gitlab and generic providers put
+// every version into a directory of its own next to the package directory,
+// e.g. packages/github/owner_tool@v1.2.0, and make the package directory a
+// symlink to the installed one. The bin dir links into the package
+// directory, so an update only swaps that symlink and the previous version
+// stays in place to roll back to, until collectVersions drops it.
+
+// Injectable helpers for tests
+var versionRetention = files.GetVersionRetention
+var versionLockedVersion = func(sourceID string) string {
+	return local_packages_parser.GetBySourceId(sourceID).Version
+}
+
+// versionStagingSuffix marks a version directory that is still being filled
+const versionStagingSuffix = ".staging"
+
+var versionNameReplacer = strings.NewReplacer("/", "_", "\\", "_", ":", "_")
+
+// versionDir returns the directory version of the package in packageDir is
+// installed into
+func versionDir(packageDir, version string) string {
+	if version == "" {
+		version = "unknown"
+	}
+	return packageDir + "@" + versionNameReplacer.Replace(version)
+}
+
+// newVersionDir returns an empty directory to install version of the package
+// in packageDir into, which activateVersion moves into place
+func newVersionDir(packageDir, version string, mkdirAll func(string, os.FileMode) error) (string, error) {
+	dir := versionDir(packageDir, version) + versionStagingSuffix
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	if err := mkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// activateVersion moves installDir, from newVersionDir, into the version
+// directory of version and points packageDir at it. A packageDir that is a
+// directory, from before versioned installs or from a git install, is kept
+// as the version directory of the version sourceID had in the lock file.
+func activateVersion(sourceID, packageDir, version, installDir string) error {
+	dest := versionDir(packageDir, version)
+	now := clock.Now()
+
+	info, err := os.Lstat(packageDir)
+	switch {
+	case err == nil && info.Mode()&os.ModeSymlink != 0:
+		// The version replaced now ages from here on, see collectVersions
+		if previous, err := filepath.EvalSymlinks(packageDir); err == nil && previous != dest {
+			_ = os.Chtimes(previous, now, now)
+		}
+	case err == nil:
+		previous := versionDir(packageDir, versionLockedVersion(sourceID))
+		if _, err := os.Lstat(previous); previous != dest && os.IsNotExist(err) {
+			if err := os.Rename(packageDir, previous); err != nil {
+				return fmt.Errorf("failed to keep the previous version: %w", err)
+			}
+			_ = os.Chtimes(previous, now, now)
+		} else if err := os.RemoveAll(packageDir); err != nil {
+			return err
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	if err := os.RemoveAll(dest); err != nil {
+		return err
+	}
+	if err := os.Rename(installDir, dest); err != nil {
+		return err
+	}
+	_ = os.Chtimes(dest, now, now)
+
+	// Swap the symlink in with a rename, so packageDir never goes missing
+	link := packageDir + ".link"
+	_ = os.Remove(link)
+	if err := os.Symlink(filepath.Base(dest), link); err != nil {
+		return err
+	}
+	if err := os.Rename(link, packageDir); err != nil {
+		_ = os.Remove(link)
+		return err
+	}
+	return nil
+}
+
+// releaseVersion removes the symlink packageDir is after a versioned install,
+// keeping the version it points at as the previous one, so a git clone can
+// take its place. A packageDir that isn't a symlink is left alone.
+func releaseVersion(packageDir string) error {
+	info, err := os.Lstat(packageDir)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return nil
+	}
+	if previous, err := filepath.EvalSymlinks(packageDir); err == nil {
+		now := clock.Now()
+		_ = os.Chtimes(previous, now, now)
+	}
+	return os.Remove(packageDir)
+}
+
+// versionDirs returns the version directories of the package in packageDir,
+// without the one being filled
+func versionDirs(packageDir string) []string {
+	entries, err := os.ReadDir(filepath.Dir(packageDir))
+	if err != nil {
+		return nil
+	}
+	prefix := filepath.Base(packageDir) + "@"
+	var dirs []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || !strings.HasPrefix(name, prefix) || strings.HasSuffix(name, versionStagingSuffix) {
+			continue
+		}
+		dirs = append(dirs, filepath.Join(filepath.Dir(packageDir), name))
+	}
+	return dirs
+}
+
+// collectVersions removes the older version directories of the package in
+// packageDir that the version retention doesn't keep: beyond the
+// install.keepVersions most recently replaced ones, or replaced more than
+// install.keepVersionsDays ago. The installed version and the previous one
+// are always kept. The space freed is added to the result of the operation
+// ctx carries.
+func collectVersions(ctx context.Context, packageDir string) {
+	active, _ := filepath.EvalSymlinks(packageDir)
+	type older struct {
+		path string
+		info os.FileInfo
+	}
+	var versions []older
+	for _, dir := range versionDirs(packageDir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil && resolved == active {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil {
+			versions = append(versions, older{path: dir, info: info})
+		}
+	}
+	// Most recently replaced first
+	sort.Slice(versions, func(i, j int) bool { return versions[i].info.ModTime().After(versions[j].info.ModTime()) })
+
+	retention := versionRetention()
+	now := clock.Now()
+	for i, v := range versions {
+		expired := retention.MaxAge > 0 && now.Sub(v.info.ModTime()) > retention.MaxAge
+		if i == 0 || (i < retention.Keep && !expired) {
+			continue
+		}
+		size := files.DirSize(v.path)
+		if err := os.RemoveAll(v.path); err != nil {
+			Logger.InfoContext(ctx, fmt.Sprintf("Versions: Warning removing %s: %v", v.path, err))
+			continue
+		}
+		Logger.InfoContext(ctx, fmt.Sprintf("Versions: Removed %s, reclaiming %d bytes", v.path, size))
+		if r := resultOf(ctx); r != nil {
+			r.reclaimedBytes.Add(size)
+		}
+	}
+}
+
+// removeVersions removes all version directories of the package in packageDir
+func removeVersions(packageDir string) error {
+	for _, dir := range versionDirs(packageDir) {
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubVersionRetention(t *testing.T, retention files.VersionRetention) {
+	t.Helper()
+	prevRetention, prevLocked := versionRetention, versionLockedVersion
+	t.Cleanup(func() {
+		versionRetention, versionLockedVersion = prevRetention, prevLocked
+	})
+	versionRetention = func() files.VersionRetention { return retention }
+	versionLockedVersion = func(string) string { return "v1" }
+}
+
+// installVersion installs a tool file with content as version of packageDir
+func installVersion(t *testing.T, packageDir, version, content string) {
+	t.Helper()
+	dir, err := newVersionDir(packageDir, version, os.MkdirAll)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tool"), []byte(content), 0755))
+	require.NoError(t, activateVersion("github:owner/tool", packageDir, version, dir))
+}
+
+func TestActivateVersion(t *testing.T) {
+	stubVersionRetention(t, files.VersionRetention{Keep: 1})
+	packageDir := filepath.Join(t.TempDir(), "owner_tool")
+
+	// Installs from before versioned installs are kept as the locked version
+	require.NoError(t, os.MkdirAll(packageDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "tool"), []byte("v1"), 0755))
+	installVersion(t, packageDir, "v2", "v2")
+
+	content, err := os.ReadFile(filepath.Join(packageDir, "tool"))
+	require.NoError(t, err)
+	assert.Equal(t, "v2", string(content))
+	link, err := os.Readlink(packageDir)
+	require.NoError(t, err)
+	assert.Equal(t, "owner_tool@v2", link)
+	content, err = os.ReadFile(filepath.Join(packageDir+"@v1", "tool"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(content), "the previous version is kept for rollbacks")
+
+	// Reinstalling a version replaces its directory
+	installVersion(t, packageDir, "v2", "v2 again")
+	content, err = os.ReadFile(filepath.Join(packageDir, "tool"))
+	require.NoError(t, err)
+	assert.Equal(t, "v2 again", string(content))
+	assert.Equal(t, []string{packageDir + "@v1", packageDir + "@v2"}, versionDirs(packageDir))
+
+	require.NoError(t, removeVersions(packageDir))
+	assert.Empty(t, versionDirs(packageDir))
+}
+
+func TestCollectVersions(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC))
+	defer clock.Set(fake)()
+
+	t.Run("keeps the most recently replaced versions", func(t *testing.T) {
+		stubVersionRetention(t, files.VersionRetention{Keep: 2})
+		packageDir := filepath.Join(t.TempDir(), "owner_tool")
+		for _, version := range []string{"v1", "v2", "v3", "v4"} {
+			installVersion(t, packageDir, version, version)
+			fake.Advance(time.Hour)
+		}
+
+		ctx := newOperation(context.Background(), files.HistoryActionUpdate, "github:owner/tool")
+		collectVersions(ctx, packageDir)
+
+		assert.Equal(t, []string{packageDir + "@v2", packageDir + "@v3", packageDir + "@v4"}, versionDirs(packageDir))
+		assert.Equal(t, int64(2), resultOf(ctx).reclaimedBytes.Load())
+	})
+
+	t.Run("drops versions replaced too long ago but the previous one", func(t *testing.T) {
+		stubVersionRetention(t, files.VersionRetention{Keep: 5, MaxAge: 10 * 24 * time.Hour})
+		packageDir := filepath.Join(t.TempDir(), "owner_tool")
+		installVersion(t, packageDir, "v1", "v1")
+		installVersion(t, packageDir, "v2", "v2")
+		fake.Advance(20 * 24 * time.Hour)
+		installVersion(t, packageDir, "v3", "v3")
+
+		collectVersions(context.Background(), packageDir)
+		assert.Equal(t, []string{packageDir + "@v2", packageDir + "@v3"}, versionDirs(packageDir))
+
+		fake.Advance(20 * 24 * time.Hour)
+		collectVersions(context.Background(), packageDir)
+		assert.Equal(t, []string{packageDir + "@v2", packageDir + "@v3"}, versionDirs(packageDir), "the previous version stays")
+	})
+}
+
+func TestGitHubInstallKeepsPreviousVersion(t *testing.T) {
+	target := DetectRegistryTarget()
+	stubGitHubTagInstall(t, `[
+		{"name": "tool", "version": "v2.0.0", "source": {"id": "github:owner/tool", "asset": [{"target": "`+target+`", "file": "tool.tar.gz"}]},
+		 "bin": {"tool": "tool"}}
+	]`, map[string][]byte{
+		"https://github.com/owner/tool/releases/download/v1.0.0/tool.tar.gz": tarGz(t, map[string]string{"tool": "v1"}),
+		"https://github.com/owner/tool/releases/download/v2.0.0/tool.tar.gz": tarGz(t, map[string]string{"tool": "v2"}),
+	})
+	stubVersionRetention(t, files.VersionRetention{Keep: 1})
+	prevRemove := lppGithubRemove
+	t.Cleanup(func() { lppGithubRemove = prevRemove })
+	lppGithubRemove = func(string) error { return nil }
+
+	p := NewProviderGitHub()
+	repoPath := p.getRepoPath("owner/tool")
+	require.True(t, p.Install(context.Background(), "github:owner/tool", "v1.0.0"))
+	require.True(t, p.Install(context.Background(), "github:owner/tool", "v2.0.0"))
+
+	content, err := os.ReadFile(filepath.Join(files.GetAppBinPathForProvider("github"), "tool"))
+	require.NoError(t, err)
+	assert.Equal(t, "v2", string(content))
+	assert.Equal(t, []string{repoPath + "@v1.0.0", repoPath + "@v2.0.0"}, versionDirs(repoPath))
+
+	require.True(t, p.Remove(context.Background(), "github:owner/tool"))
+	assert.Empty(t, versionDirs(repoPath))
+	_, err = os.Lstat(repoPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestGitHubTagArchiveInstallKeepsPreviousVersion(t *testing.T) {
+	target := DetectRegistryTarget()
+	stubGitHubTagInstall(t, `[
+		{"name": "tool", "version": "v2.0.0", "source": {"id": "github:owner/tool", "asset": [{"target": "`+target+`", "file": "tool.tar.gz"}]},
+		 "bin": {"tool": "tool"}}
+	]`, map[string][]byte{
+		"https://github.com/owner/tool/releases/download/v1.0.0/tool.tar.gz": tarGz(t, map[string]string{"tool": "v1"}),
+		"https://github.com/owner/tool/archive/refs/tags/v2.0.0.tar.gz":      tarGz(t, map[string]string{"tool-2.0.0/README.md": "readme"}),
+	})
+	stubVersionRetention(t, files.VersionRetention{Keep: 1})
+
+	p := NewProviderGitHub()
+	repoPath := p.getRepoPath("owner/tool")
+	require.True(t, p.Install(context.Background(), "github:owner/tool", "v1.0.0"))
+	require.True(t, p.Install(context.Background(), "github:owner/tool", "v2.0.0"))
+
+	assert.FileExists(t, filepath.Join(repoPath, "README.md"))
+	assert.Equal(t, []string{repoPath + "@v1.0.0", repoPath + "@v2.0.0"}, versionDirs(repoPath))
+}
+
+func TestReleaseVersion(t *testing.T) {
+	stubVersionRetention(t, files.VersionRetention{Keep: 1})
+	packageDir := filepath.Join(t.TempDir(), "owner_tool")
+	installVersion(t, packageDir, "v1", "v1")
+
+	require.NoError(t, releaseVersion(packageDir))
+	_, err := os.Lstat(packageDir)
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, []string{packageDir + "@v1"}, versionDirs(packageDir), "the released version is kept")
+
+	// A clone in place of the symlink is left alone
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, ".git"), 0755))
+	require.NoError(t, releaseVersion(packageDir))
+	assert.DirExists(t, filepath.Join(packageDir, ".git"))
+}
@@ -0,0 +1,148 @@
+package providers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// Injectable helpers for tests
+var platformTarget = DetectRegistryTarget
+var platformRegistryParser = registry_parser.NewDefaultRegistryParser
+
+// UnsupportedPlatformError is returned when registry metadata excludes the current platform
+type UnsupportedPlatformError struct {
+	SourceID  string
+	Target    string
+	Supported []string
+	// Alternatives lists packages of other providers sharing the package's name
+	// or aliases that do support the current platform.
+	Alternatives []string
+}
+
+func (e *UnsupportedPlatformError) Error() string {
+	return fmt.Sprintf("%s is not supported on %s (supported: %s)", e.SourceID, e.Target, strings.Join(e.Supported, ", "))
+}
+
+// PlatformMatches reports whether a registry platform token covers target.
+// Tokens are targets (linux_x64, linux_x64_gnu, darwin_universal), OS names
+// (linux, darwin, win) or unix.
+func PlatformMatches(platform, target string) bool {
+	platform = strings.ToLower(strings.TrimSpace(platform))
+	if platform == target || strings.HasPrefix(platform, target+"_") {
+		return true
+	}
+	osPart, _, _ := strings.Cut(target, "_")
+	if platform == targetDarwinUniversal {
+		return osPart == "darwin"
+	}
+	if platform == "unix" {
+		return osPart != "win"
+	}
+	return platform == osPart
+}
+
+// assetTargets returns the targets the item's release assets and downloads are published for
+func assetTargets(item registry_parser.RegistryItem) []string {
+	var targets []string
+	add := func(target interface{}) {
+		switch v := target.(type) {
+		case string:
+			targets = append(targets, v)
+		case []interface{}:
+			for _, t := range v {
+				if str, ok := t.(string); ok {
+					targets = append(targets, str)
+				}
+			}
+		}
+	}
+	for _, a := range item.Source.Asset {
+		add(a.Target)
+	}
+	for _, d := range item.Source.Download {
+		add(d.Target)
+	}
+	return targets
+}
+
+// SupportedPlatforms returns the platforms a registry item can be installed on:
+// its declared supported_platforms, otherwise the targets of its release assets.
+// Nil means the item isn't restricted to specific platforms.
+func SupportedPlatforms(item registry_parser.RegistryItem) []string {
+	if len(item.SupportedPlatforms) > 0 {
+		return item.SupportedPlatforms
+	}
+	return assetTargets(item)
+}
+
+// itemSupportsTarget reports whether the registry item can be installed on target
+func itemSupportsTarget(item registry_parser.RegistryItem, target string) bool {
+	platforms := SupportedPlatforms(item)
+	if len(platforms) == 0 {
+		return true
+	}
+	for _, p := range platforms {
+		if PlatformMatches(p, target) || (target == targetDarwinArm64 && runsUnderRosetta(p)) {
+			return true
+		}
+		for _, fallback := range fallbackTargets(target) {
+			if strings.EqualFold(strings.TrimSpace(p), fallback) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// runsUnderRosetta reports whether packages for the platform token can be
+// installed on Apple Silicon through Rosetta 2 (see darwinAssetTargets)
+func runsUnderRosetta(platform string) bool {
+	return strings.EqualFold(strings.TrimSpace(platform), targetDarwinX64) &&
+		slices.Contains(darwinArchPreference(), files.DarwinArchX64) &&
+		rosettaAvailable()
+}
+
+// platformAlternatives returns packages of other providers with the same name or
+// an overlapping alias that support target
+func platformAlternatives(registry registry_parser.RegistryRoot, item registry_parser.RegistryItem, target string) []string {
+	names := append([]string{item.Name}, item.Aliases...)
+	var alternatives []string
+	for _, other := range registry {
+		if other.Source.ID == item.Source.ID || other.Source.ID == "" {
+			continue
+		}
+		otherNames := append([]string{other.Name}, other.Aliases...)
+		related := slices.ContainsFunc(otherNames, func(n string) bool {
+			return n != "" && slices.ContainsFunc(names, func(m string) bool { return strings.EqualFold(n, m) })
+		})
+		if related && itemSupportsTarget(other, target) {
+			alternatives = append(alternatives, other.Source.ID)
+		}
+	}
+	return alternatives
+}
+
+// CheckPlatformSupport returns an *UnsupportedPlatformError when the registry
+// metadata of sourceID excludes the current OS/architecture.
+// Packages that aren't in the registry are not restricted.
+func CheckPlatformSupport(sourceID string) error {
+	parser := platformRegistryParser()
+	item := parser.GetBySourceId(sourceID)
+	if item.Source.ID == "" {
+		return nil
+	}
+	target := platformTarget()
+	if itemSupportsTarget(item, target) {
+		return nil
+	}
+	return &UnsupportedPlatformError{
+		SourceID:     sourceID,
+		Target:       target,
+		Supported:    SupportedPlatforms(item),
+		Alternatives: platformAlternatives(parser.GetData(false), item, target),
+	}
+}
@@ -0,0 +1,33 @@
+package providers
+
+import (
+	"os"
+	"strings"
+)
+
+// Injectable helpers for tests
+var termuxGetenv = os.Getenv
+
+// isTermux reports whether zana runs in Termux on Android, where Linux builds
+// of zana report linux as their OS
+func isTermux() bool {
+	if termuxGetenv("TERMUX_VERSION") != "" {
+		return true
+	}
+	return strings.Contains(termuxGetenv("PREFIX"), "/com.termux/")
+}
+
+// fallbackTargets returns the targets whose builds also run on target, most
+// preferred first: Windows on ARM emulates x64, and Android runs static Linux
+// builds, which are usually published as musl or plain linux_<arch> assets.
+func fallbackTargets(target string) []string {
+	osPart, arch, _ := strings.Cut(target, "_")
+	switch {
+	case target == "win_arm64":
+		return []string{"win_x64"}
+	case osPart == "android":
+		return []string{"linux_" + arch + "_musl", "linux_" + arch}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,84 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryTarget(t *testing.T) {
+	assert.Equal(t, "win_arm64", registryTarget("windows", "arm64", false))
+	assert.Equal(t, "linux_riscv64", registryTarget("linux", "riscv64", false))
+	assert.Equal(t, "android_arm64", registryTarget("linux", "arm64", true))
+	assert.Equal(t, "android_arm64", registryTarget("android", "arm64", false))
+	assert.Equal(t, "linux_x64", registryTarget("linux", "amd64", false))
+}
+
+func TestIsTermux(t *testing.T) {
+	env := map[string]string{}
+	orig := termuxGetenv
+	termuxGetenv = func(key string) string { return env[key] }
+	t.Cleanup(func() { termuxGetenv = orig })
+
+	assert.False(t, isTermux())
+	env["PREFIX"] = "/data/data/com.termux/files/usr"
+	assert.True(t, isTermux())
+	env = map[string]string{"TERMUX_VERSION": "0.118.0"}
+	assert.True(t, isTermux())
+}
+
+func TestFindMatchingAssetEmergingPlatforms(t *testing.T) {
+	var assets registry_parser.RegistryItemSourceAssetList
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"target": "linux_x64_gnu", "file": "tool-linux-x64.tar.gz"},
+		{"target": "linux_arm64", "file": "tool-linux-arm64.tar.gz"},
+		{"target": "linux_arm64_musl", "file": "tool-linux-arm64-musl.tar.gz"},
+		{"target": "linux_riscv64_gnu", "file": "tool-linux-riscv64.tar.gz"},
+		{"target": "win_x64", "file": "tool-windows-x64.zip"}
+	]`), &assets))
+	for target, want := range map[string]string{
+		"linux_riscv64": "tool-linux-riscv64.tar.gz",
+		"win_arm64":     "tool-windows-x64.zip",
+		"android_arm64": "tool-linux-arm64-musl.tar.gz",
+	} {
+		t.Run(target, func(t *testing.T) {
+			orig := assetPlatformTarget
+			assetPlatformTarget = func() string { return target }
+			t.Cleanup(func() { assetPlatformTarget = orig })
+
+			asset := FindMatchingAsset(context.Background(), assets)
+			require.NotNil(t, asset)
+			assert.Equal(t, want, asset.File.String())
+		})
+	}
+
+	t.Run("native builds are preferred", func(t *testing.T) {
+		orig := assetPlatformTarget
+		assetPlatformTarget = func() string { return "win_arm64" }
+		t.Cleanup(func() { assetPlatformTarget = orig })
+
+		var native registry_parser.RegistryItemSourceAssetList
+		require.NoError(t, json.Unmarshal([]byte(`[{"target": "win_arm64", "file": "tool-windows-arm64.zip"}]`), &native))
+		asset := FindMatchingAsset(context.Background(), append(assets, native...))
+		require.NotNil(t, asset)
+		assert.Equal(t, "tool-windows-arm64.zip", asset.File.String())
+	})
+}
+
+func TestItemSupportsFallbackTargets(t *testing.T) {
+	item := registry_parser.RegistryItem{SupportedPlatforms: []string{"win_x64", "linux_arm64"}}
+	assert.True(t, itemSupportsTarget(item, "win_arm64"))
+	assert.True(t, itemSupportsTarget(item, "android_arm64"))
+	assert.False(t, itemSupportsTarget(item, "linux_riscv64"))
+}
+
+func TestRankAlternateAssetsEmergingPlatforms(t *testing.T) {
+	names := []string{"tool-linux-x86_64.tar.gz", "tool-linux-riscv64gc.tar.gz", "tool-linux-aarch64.tar.gz", "tool-windows-arm64.zip"}
+	assert.Equal(t, []string{"tool-linux-riscv64gc.tar.gz"}, rankAlternateAssets(names, "tool-linux-riscv64.tar.gz", "linux_riscv64"))
+	assert.Equal(t, []string{"tool-windows-arm64.zip"}, rankAlternateAssets(names, "tool-win-arm64.zip", "win_arm64"))
+	assert.Equal(t, []string{"tool-linux-aarch64.tar.gz"}, rankAlternateAssets(names, "tool-android-arm64.tar.gz", "android_arm64"))
+}
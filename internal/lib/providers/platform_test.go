@@ -0,0 +1,63 @@
+package providers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withPlatformRegistry(t *testing.T, target, raw string) {
+	t.Helper()
+	reg := registry_parser.NewRegistryParser(nil)
+	require.NoError(t, reg.LoadFromBytes([]byte(raw)))
+	origParser, origTarget := platformRegistryParser, platformTarget
+	platformRegistryParser = func() *registry_parser.RegistryParser { return reg }
+	platformTarget = func() string { return target }
+	t.Cleanup(func() { platformRegistryParser, platformTarget = origParser, origTarget })
+}
+
+func TestPlatformMatches(t *testing.T) {
+	assert.True(t, PlatformMatches("linux_x64", "linux_x64"))
+	assert.True(t, PlatformMatches("linux_x64_gnu", "linux_x64"))
+	assert.True(t, PlatformMatches("linux", "linux_arm64"))
+	assert.True(t, PlatformMatches("unix", "darwin_arm64"))
+	assert.True(t, PlatformMatches("Win", "win_x64"))
+	assert.False(t, PlatformMatches("unix", "win_x64"))
+	assert.False(t, PlatformMatches("linux_x64", "linux_arm64"))
+	assert.False(t, PlatformMatches("darwin", "linux_x64"))
+}
+
+const platformRegistry = `[
+	{"name": "winonly", "source": {"id": "npm:winonly"}, "supported_platforms": ["win"]},
+	{"name": "anywhere", "source": {"id": "npm:anywhere"}},
+	{"name": "tool", "aliases": ["the-tool"], "source": {"id": "github:owner/tool", "asset": [
+		{"target": ["darwin_x64", "darwin_arm64"], "file": "tool-darwin.tar.gz"},
+		{"target": "linux_x64_gnu", "file": "tool-linux.tar.gz"}
+	]}},
+	{"name": "the-tool", "source": {"id": "cargo:the-tool"}},
+	{"name": "tool", "source": {"id": "pypi:tool"}, "supported_platforms": ["darwin"]}
+]`
+
+func TestCheckPlatformSupport(t *testing.T) {
+	withPlatformRegistry(t, "linux_arm64", platformRegistry)
+
+	assert.NoError(t, CheckPlatformSupport("npm:anywhere"))
+	assert.NoError(t, CheckPlatformSupport("npm:not-in-registry"))
+
+	err := CheckPlatformSupport("npm:winonly")
+	var platformErr *UnsupportedPlatformError
+	require.True(t, errors.As(err, &platformErr))
+	assert.Equal(t, []string{"win"}, platformErr.Supported)
+	assert.Equal(t, "npm:winonly is not supported on linux_arm64 (supported: win)", err.Error())
+
+	err = CheckPlatformSupport("github:owner/tool")
+	require.True(t, errors.As(err, &platformErr))
+	assert.Equal(t, []string{"darwin_x64", "darwin_arm64", "linux_x64_gnu"}, platformErr.Supported, "asset targets are used without declared platforms")
+	assert.Equal(t, []string{"cargo:the-tool"}, platformErr.Alternatives, "only alternatives supporting the platform are suggested")
+
+	withPlatformRegistry(t, "linux_x64", platformRegistry)
+	assert.NoError(t, CheckPlatformSupport("github:owner/tool"))
+}
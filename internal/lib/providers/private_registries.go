@@ -0,0 +1,117 @@
+package providers
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+)
+
+// npmrcHeader marks the .npmrc zana generates in the npm packages directory
+const npmrcHeader = "# Generated by zana from npm.registries in config.yaml, do not edit\n"
+
+// Injectable helpers for tests
+var npmRegistries = files.GetNpmRegistries
+var npmRegistryFor = files.NpmRegistryFor
+var pypiIndexFor = files.PyPIIndexFor
+
+// npmrcContent renders the scoped registries and auth tokens of
+// npm.registries. Tokens from tokenEnv are referenced as ${VAR}, which npm
+// expands itself, so they are never written to disk.
+func npmrcContent(registries []files.NpmRegistryConfig) string {
+	var b strings.Builder
+	for _, r := range registries {
+		if r.Scope != "" {
+			fmt.Fprintf(&b, "%s:registry=%s\n", r.Scope, r.URL)
+		}
+		token := ""
+		if r.TokenEnv != "" && os.Getenv(r.TokenEnv) != "" {
+			token = "${" + r.TokenEnv + "}"
+		} else if r.Token != "" {
+			token = r.Token
+		}
+		if key := npmrcAuthKey(r.URL); token != "" && key != "" {
+			fmt.Fprintf(&b, "%s:_authToken=%s\n", key, token)
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return npmrcHeader + b.String()
+}
+
+// npmrcAuthKey returns the registry URL without its scheme, the form npm
+// expects auth settings to be keyed by (e.g. //npm.mycompany.com/)
+func npmrcAuthKey(registryURL string) string {
+	u, err := url.Parse(registryURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return "//" + u.Host + strings.TrimSuffix(u.Path, "/") + "/"
+}
+
+// writeNpmrc writes the .npmrc next to package.json for the configured
+// private registries, or removes a previously generated one
+func (p *NPMProvider) writeNpmrc() error {
+	path := filepath.Join(p.APP_PACKAGES_DIR, ".npmrc")
+	content := npmrcContent(npmRegistries())
+	if content == "" {
+		existing, err := os.ReadFile(path)
+		if err == nil && strings.HasPrefix(string(existing), npmrcHeader) {
+			return os.Remove(path)
+		}
+		return nil
+	}
+	// The file may hold inline tokens
+	return os.WriteFile(path, []byte(content), 0600)
+}
+
+// registryOptions returns the directory and environment to run npm in for
+// packageName: packages of a private registry are resolved in the packages
+// directory, where the generated .npmrc applies, and packages matched by
+// name (not scope) get the registry as npm_config_registry
+func (p *NPMProvider) registryOptions(packageName string) (string, []string) {
+	r, ok := npmRegistryFor(packageName)
+	if !ok {
+		return "", nil
+	}
+	err := os.MkdirAll(p.APP_PACKAGES_DIR, 0755)
+	if err == nil {
+		err = p.writeNpmrc()
+	}
+	if err != nil {
+		Logger.Info(fmt.Sprintf("npm: Warning writing .npmrc: %v", err))
+	}
+	if r.Scope != "" && strings.HasPrefix(packageName, r.Scope+"/") {
+		return p.APP_PACKAGES_DIR, nil
+	}
+	return p.APP_PACKAGES_DIR, []string{"npm_config_registry=" + r.URL}
+}
+
+// pipIndexEnv returns the environment that makes pip install distribution
+// name from its configured private index, with the credentials in the URL
+func pipIndexEnv(name string) []string {
+	index, ok := pypiIndexFor(name)
+	if !ok {
+		return nil
+	}
+	indexURL := index.IndexURL
+	if token := index.ResolvedToken(); token != "" {
+		if u, err := url.Parse(indexURL); err == nil {
+			username := index.Username
+			if username == "" {
+				username = "__token__"
+			}
+			u.User = url.UserPassword(username, token)
+			indexURL = u.String()
+		}
+	}
+	env := []string{"PIP_INDEX_URL=" + indexURL}
+	if len(index.ExtraIndexURLs) > 0 {
+		env = append(env, "PIP_EXTRA_INDEX_URL="+strings.Join(index.ExtraIndexURLs, " "))
+	}
+	return env
+}
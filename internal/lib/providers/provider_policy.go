@@ -0,0 +1,56 @@
+package providers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+)
+
+// Injectable helpers for tests
+var disabledProvidersFn = files.GetDisabledProviders
+
+// ProviderDisabledError is returned for packages of a provider that is
+// disabled with providers.disabled in config.yaml
+type ProviderDisabledError struct {
+	Provider string
+}
+
+func (e *ProviderDisabledError) Error() string {
+	return fmt.Sprintf("provider '%s' is disabled by policy (providers.disabled in config.yaml)", e.Provider)
+}
+
+// IsProviderDisabled reports whether provider is listed in providers.disabled
+func IsProviderDisabled(provider string) bool {
+	provider = strings.ToLower(provider)
+	for _, p := range disabledProvidersFn() {
+		if p == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckProviderAllowed returns a *ProviderDisabledError when provider is disabled
+func CheckProviderAllowed(provider string) error {
+	if IsProviderDisabled(provider) {
+		return &ProviderDisabledError{Provider: strings.ToLower(provider)}
+	}
+	return nil
+}
+
+// checkPackageAllowed is CheckProviderAllowed for the provider of sourceID
+func checkPackageAllowed(sourceID string) error {
+	provider, _ := packageid.Split(sourceID)
+	return CheckProviderAllowed(provider)
+}
+
+// syncDisabled reports whether syncing provider is skipped by policy
+func syncDisabled(provider string) bool {
+	if !IsProviderDisabled(provider) {
+		return false
+	}
+	Logger.Info(fmt.Sprintf("Sync: Skipping %s packages, the provider is disabled by policy", provider))
+	return true
+}
@@ -0,0 +1,58 @@
+package providers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubDisabledProviders(t *testing.T, disabled ...string) {
+	t.Helper()
+	prev := disabledProvidersFn
+	t.Cleanup(func() { disabledProvidersFn = prev })
+	disabledProvidersFn = func() []string { return disabled }
+}
+
+func TestCheckProviderAllowed(t *testing.T) {
+	stubDisabledProviders(t, "generic")
+
+	assert.NoError(t, CheckProviderAllowed("npm"))
+	err := CheckProviderAllowed("Generic")
+	require.Error(t, err)
+	var policyErr *ProviderDisabledError
+	require.ErrorAs(t, err, &policyErr)
+	assert.Equal(t, "generic", policyErr.Provider)
+	assert.EqualError(t, err, "provider 'generic' is disabled by policy (providers.disabled in config.yaml)")
+
+	assert.Error(t, checkPackageAllowed("generic:tool"))
+	assert.NoError(t, checkPackageAllowed("npm:prettier"))
+}
+
+func TestDisabledProviderIsNotInstalledOrUpdated(t *testing.T) {
+	stubDisabledProviders(t, "npm")
+	called := false
+	SetProviderFactory(&MockProviderFactory{
+		MockNPMProvider: &MockPackageManager{
+			InstallFunc: func(_ context.Context, sourceID, version string) bool { called = true; return true },
+			UpdateFunc:  func(_ context.Context, sourceID string) bool { called = true; return true },
+		},
+	})
+	t.Cleanup(ResetProviderFactory)
+
+	assert.False(t, installWithProvider(context.Background(), "npm:prettier", "3.0.0"))
+	assert.False(t, updateWithProvider(context.Background(), "npm:prettier"))
+	assert.False(t, called)
+}
+
+func TestCheckAllProvidersHealthReportsDisabledProviders(t *testing.T) {
+	stubDisabledProviders(t, "generic")
+	prev := healthHasCommand
+	t.Cleanup(func() { healthHasCommand = prev })
+	healthHasCommand = func(_ context.Context, cmd string, args []string, env []string) bool { return true }
+
+	for _, status := range CheckAllProvidersHealth() {
+		assert.Equal(t, status.Provider == "generic", status.Disabled, status.Provider)
+	}
+}
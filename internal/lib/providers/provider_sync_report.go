@@ -0,0 +1,19 @@
+package providers
+
+// providerSyncResults is a best-effort side-channel for the CLI to summarize
+// which providers a bulk sync reconciled successfully.
+// Key format: provider name, e.g. "npm"
+var providerSyncResults = map[string]bool{}
+
+func recordProviderSync(provider string, ok bool) {
+	providerSyncResults[provider] = ok
+}
+
+// ConsumeProviderSyncResults returns whether the Sync of each provider synced
+// by the last SyncAllFromLock succeeded. Providers skipped by policy or
+// without a provider implementation are missing.
+func ConsumeProviderSyncResults() map[string]bool {
+	results := providerSyncResults
+	providerSyncResults = map[string]bool{}
+	return results
+}
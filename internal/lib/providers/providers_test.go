@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -40,6 +41,8 @@ func TestDetectProvider(t *testing.T) {
 		{"pypi source", "pkg:pypi/package-name", ProviderPyPi},
 		{"golang source", "pkg:golang/package-name", ProviderGolang},
 		{"cargo source", "pkg:cargo/package-name", ProviderCargo},
+		{"local source", "local:my-tool", ProviderLocal},
+		{"treesitter source", "treesitter:tree-sitter/tree-sitter-lua", ProviderTreeSitter},
 		{"unsupported source", "pkg:unsupported/package-name", ProviderUnsupported},
 		{"empty source", "", ProviderUnsupported},
 		{"no prefix", "npm/package-name", ProviderUnsupported},
@@ -94,7 +97,7 @@ func TestCheckIfUpdateIsAvailableReturnValue(t *testing.T) {
 
 func TestAvailableProviders(t *testing.T) {
 	// Test that all expected providers are available
-	expectedProviders := []string{"npm", "pypi", "golang", "cargo", "github", "gitlab", "codeberg", "gem", "composer", "luarocks", "nuget", "opam", "openvsx", "generic"}
+	expectedProviders := []string{"npm", "pypi", "golang", "cargo", "github", "gitlab", "codeberg", "gitea", "gem", "composer", "luarocks", "nuget", "opam", "openvsx", "generic", "local", "treesitter"}
 
 	assert.Len(t, AvailableProviders, len(expectedProviders))
 
@@ -119,31 +122,36 @@ func TestProviderConstants(t *testing.T) {
 	assert.Equal(t, Provider(11), ProviderOpam)
 	assert.Equal(t, Provider(12), ProviderOpenVSX)
 	assert.Equal(t, Provider(13), ProviderGeneric)
-	assert.Equal(t, Provider(14), ProviderUnsupported)
+	assert.Equal(t, Provider(14), ProviderLocal)
+	assert.Equal(t, Provider(15), ProviderTreeSitter)
+	assert.Equal(t, Provider(16), ProviderGitea)
+	assert.Equal(t, Provider(17), ProviderUnsupported)
 }
 
 func TestInstallWithMockFactory(t *testing.T) {
+	t.Setenv("ZANA_HOME", t.TempDir())
+
 	// Create mock providers that return predictable results
 	mockNPM := &MockPackageManager{
-		InstallFunc: func(sourceID, version string) bool {
+		InstallFunc: func(_ context.Context, sourceID, version string) bool {
 			return sourceID == "pkg:npm/test-package"
 		},
 	}
 
 	mockPyPI := &MockPackageManager{
-		InstallFunc: func(sourceID, version string) bool {
+		InstallFunc: func(_ context.Context, sourceID, version string) bool {
 			return sourceID == "pkg:pypi/test-package"
 		},
 	}
 
 	mockGolang := &MockPackageManager{
-		InstallFunc: func(sourceID, version string) bool {
+		InstallFunc: func(_ context.Context, sourceID, version string) bool {
 			return sourceID == "pkg:golang/test-package"
 		},
 	}
 
 	mockCargo := &MockPackageManager{
-		InstallFunc: func(sourceID, version string) bool {
+		InstallFunc: func(_ context.Context, sourceID, version string) bool {
 			return sourceID == "pkg:cargo/test-package"
 		},
 	}
@@ -185,27 +193,29 @@ func TestInstallWithMockFactory(t *testing.T) {
 }
 
 func TestRemoveWithMockFactory(t *testing.T) {
+	t.Setenv("ZANA_HOME", t.TempDir())
+
 	// Create mock providers that return predictable results
 	mockNPM := &MockPackageManager{
-		RemoveFunc: func(sourceID string) bool {
+		RemoveFunc: func(_ context.Context, sourceID string) bool {
 			return sourceID == "pkg:npm/test-package"
 		},
 	}
 
 	mockPyPI := &MockPackageManager{
-		RemoveFunc: func(sourceID string) bool {
+		RemoveFunc: func(_ context.Context, sourceID string) bool {
 			return sourceID == "pkg:pypi/test-package"
 		},
 	}
 
 	mockGolang := &MockPackageManager{
-		RemoveFunc: func(sourceID string) bool {
+		RemoveFunc: func(_ context.Context, sourceID string) bool {
 			return sourceID == "pkg:golang/test-package"
 		},
 	}
 
 	mockCargo := &MockPackageManager{
-		RemoveFunc: func(sourceID string) bool {
+		RemoveFunc: func(_ context.Context, sourceID string) bool {
 			return sourceID == "pkg:cargo/test-package"
 		},
 	}
@@ -245,27 +255,29 @@ func TestRemoveWithMockFactory(t *testing.T) {
 }
 
 func TestUpdateWithMockFactory(t *testing.T) {
+	t.Setenv("ZANA_HOME", t.TempDir())
+
 	// Create mock providers that return predictable results
 	mockNPM := &MockPackageManager{
-		UpdateFunc: func(sourceID string) bool {
+		UpdateFunc: func(_ context.Context, sourceID string) bool {
 			return sourceID == "pkg:npm/test-package"
 		},
 	}
 
 	mockPyPI := &MockPackageManager{
-		UpdateFunc: func(sourceID string) bool {
+		UpdateFunc: func(_ context.Context, sourceID string) bool {
 			return sourceID == "pkg:pypi/test-package"
 		},
 	}
 
 	mockGolang := &MockPackageManager{
-		UpdateFunc: func(sourceID string) bool {
+		UpdateFunc: func(_ context.Context, sourceID string) bool {
 			return sourceID == "pkg:golang/test-package"
 		},
 	}
 
 	mockCargo := &MockPackageManager{
-		UpdateFunc: func(sourceID string) bool {
+		UpdateFunc: func(_ context.Context, sourceID string) bool {
 			return sourceID == "pkg:cargo/test-package"
 		},
 	}
@@ -298,7 +310,7 @@ func TestUpdateWithMockFactory(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := Update(tt.sourceId)
+			result, _, _ := Update(tt.sourceId)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
@@ -309,7 +321,7 @@ func TestSyncAllFromLockWithMockFactory(t *testing.T) {
 	// requests it; avoid invoking a real nvim (can hang in headless/CI).
 	fakeNvimData := t.TempDir()
 	prevNvim := neovimShellOutCapture
-	neovimShellOutCapture = func(cmd string, args []string, dir string, env []string) (int, string, error) {
+	neovimShellOutCapture = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, string, error) {
 		if cmd == "nvim" {
 			for _, a := range args {
 				if a == "--clean" {
@@ -318,7 +330,7 @@ func TestSyncAllFromLockWithMockFactory(t *testing.T) {
 			}
 			return 0, fakeNvimData, nil
 		}
-		return prevNvim(cmd, args, dir, env)
+		return prevNvim(context.Background(), cmd, args, dir, env)
 	}
 	t.Cleanup(func() { neovimShellOutCapture = prevNvim })
 
@@ -375,11 +387,11 @@ func TestFactoriesAndGithubProvider(t *testing.T) {
 
 	// MockPackageManager getLatestVersion default path
 	m := &MockPackageManager{}
-	ver, err := m.getLatestVersion("anything")
+	ver, err := m.getLatestVersion(context.Background(), "anything")
 	assert.NoError(t, err)
 	assert.Equal(t, "", ver)
 
 	// GitHubProvider Install
 	g := &GitHubProvider{}
-	g.Install("pkg:github/owner/repo", "latest")
+	g.Install(context.Background(), "pkg:github/owner/repo", "latest")
 }
@@ -1,16 +1,16 @@
 package providers
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
-	"github.com/mistweaverco/zana-client/internal/lib/shell_out"
 )
 
 type PyPiProvider struct {
@@ -22,9 +22,9 @@ type PyPiProvider struct {
 var pipCmd = "pip"
 
 // Injectable shell and OS helpers for tests
-var pipShellOut = shell_out.ShellOut
-var pipShellOutCapture = shell_out.ShellOutCapture
-var pipHasCommand = shell_out.HasCommand
+var pipShellOut = shellOut
+var pipShellOutCapture = shellOutCapture
+var pipHasCommand = hasCommand
 var pipCreate = os.Create
 var pipReadDir = os.ReadDir
 var pipReadFile = os.ReadFile
@@ -44,17 +44,17 @@ var lppPyGetDataForProvider = local_packages_parser.GetDataForProvider
 var lppPyGetData = local_packages_parser.GetData
 
 // Injectable for testing
-var pipGetPythonVersion = func(p *PyPiProvider) (string, error) {
+var pipGetPythonVersion = func(ctx context.Context, p *PyPiProvider) (string, error) {
 	// Try python3 first, then python
 	pythonCmd := "python3"
-	if !pipHasCommand("python3", []string{"--version"}, nil) {
-		if !pipHasCommand("python", []string{"--version"}, nil) {
+	if !pipHasCommand(ctx, "python3", []string{"--version"}, nil) {
+		if !pipHasCommand(ctx, "python", []string{"--version"}, nil) {
 			return "", fmt.Errorf("python3 or python command not found")
 		}
 		pythonCmd = "python"
 	}
 
-	code, output, err := pipShellOutCapture(pythonCmd, []string{"-c", "import sys; print(f'{sys.version_info.major}.{sys.version_info.minor}')"}, "", nil)
+	code, output, err := pipShellOutCapture(ctx, pythonCmd, []string{"-c", "import sys; print(f'{sys.version_info.major}.{sys.version_info.minor}')"}, "", nil)
 	if err != nil || code != 0 {
 		return "", fmt.Errorf("failed to detect Python version: %v, output: %s", err, output)
 	}
@@ -64,8 +64,8 @@ var pipGetPythonVersion = func(p *PyPiProvider) (string, error) {
 
 // getPythonVersion detects the current Python version (e.g., "3.11", "3.12")
 // by running python -c "import sys; print(f'{sys.version_info.major}.{sys.version_info.minor}')"
-func (p *PyPiProvider) getPythonVersion() (string, error) {
-	return pipGetPythonVersion(p)
+func (p *PyPiProvider) getPythonVersion(ctx context.Context) (string, error) {
+	return pipGetPythonVersion(ctx, p)
 }
 
 func NewProviderPyPi() *PyPiProvider {
@@ -75,32 +75,26 @@ func NewProviderPyPi() *PyPiProvider {
 	p.PREFIX = p.PROVIDER_NAME + ":"
 
 	// Prefer pip3 to ensure we're using Python 3, fallback to pip
-	hasPip3 := pipHasCommand("pip3", []string{"--version"}, nil)
+	hasPip3 := pipHasCommand(context.Background(), "pip3", []string{"--version"}, nil)
 	if hasPip3 {
 		pipCmd = "pip3"
 	} else {
 		// pipCmd defaults to "pip" if pip3 is not available
-		_ = pipHasCommand("pip", []string{"--version"}, nil)
+		_ = pipHasCommand(context.Background(), "pip", []string{"--version"}, nil)
 	}
 	return p
 }
 
 func (p *PyPiProvider) getRepo(sourceID string) string {
 	// Support both legacy (pkg:pypi/pkg) and new (pypi:pkg) formats
-	normalized := normalizePackageID(sourceID)
+	normalized := packageid.Normalize(sourceID)
 	if strings.HasPrefix(normalized, p.PREFIX) {
 		return strings.TrimPrefix(normalized, p.PREFIX)
 	}
-	// Fallback for legacy format
-	re := regexp.MustCompile("^pkg:" + p.PROVIDER_NAME + "/(.*)")
-	matches := re.FindStringSubmatch(sourceID)
-	if len(matches) > 1 {
-		return matches[1]
-	}
 	return ""
 }
 
-func (p *PyPiProvider) generateRequirementsTxt() bool {
+func (p *PyPiProvider) generateRequirementsTxt(ctx context.Context) bool {
 	found := false
 	dependenciesTxt := make([]string, 0)
 	localPackages := lppPyGetData(true).Packages
@@ -114,12 +108,12 @@ func (p *PyPiProvider) generateRequirementsTxt() bool {
 	filePath := filepath.Join(p.APP_PACKAGES_DIR, "requirements.txt")
 	file, err := pipCreate(filePath)
 	if err != nil {
-		Logger.Error(fmt.Sprintf("Error creating requirements.txt: %s", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Error creating requirements.txt: %s", err))
 		return false
 	}
 	for _, line := range dependenciesTxt {
 		if _, err := file.WriteString(line + "\n"); err != nil {
-			Logger.Error(fmt.Sprintf("Error writing to requirements.txt: %s", err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("Error writing to requirements.txt: %s", err))
 			return false
 		}
 	}
@@ -182,31 +176,35 @@ func (p *PyPiProvider) readPackageInfo(packagePath string) (*PackageInfo, error)
 }
 
 // createWrappers creates wrapper scripts for Python package scripts
-func (p *PyPiProvider) createWrappers() error {
+func (p *PyPiProvider) createWrappers(ctx context.Context) error {
 	// Create wrappers based on zana-registry.json bin attribute
 	desired := lppPyGetDataForProvider("pypi").Packages
 	if len(desired) == 0 {
 		return nil
 	}
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	parser := registry_parser.NewDefaultRegistryParser()
 	for _, pkg := range desired {
 		registryItem := parser.GetBySourceId(pkg.SourceID)
 		if len(registryItem.Bin) == 0 {
 			continue
 		}
+		filter := binFilterFor(pkg.SourceID, registryItem)
 		for binName, binCmd := range registryItem.Bin {
+			if !filter.allows(binName) {
+				continue
+			}
 			wrapperPath := filepath.Join(zanaBinDir, binName)
 			// Remove any existing wrapper with the same name to avoid conflicts
 			if _, err := pipLstat(wrapperPath); err == nil {
 				_ = pipRemove(wrapperPath)
 			}
-			if err := p.createPythonWrapperForCommand(binCmd, wrapperPath); err != nil {
-				Logger.Error(fmt.Sprintf("Error creating wrapper for %s: %v", binName, err))
+			if err := p.createPythonWrapperForCommand(ctx, binCmd, wrapperPath); err != nil {
+				Logger.ErrorContext(ctx, fmt.Sprintf("Error creating wrapper for %s: %v", binName, err))
 				continue
 			}
 			if err := pipChmod(wrapperPath, 0755); err != nil {
-				Logger.Error(fmt.Sprintf("Error setting executable permissions for %s: %v", binName, err))
+				Logger.ErrorContext(ctx, fmt.Sprintf("Error setting executable permissions for %s: %v", binName, err))
 			}
 		}
 	}
@@ -223,8 +221,8 @@ func (p *PyPiProvider) normalizePyPiBinCommand(commandToExec string) string {
 }
 
 // createPythonWrapperForCommand creates a wrapper that prepares the environment and executes the given command.
-func (p *PyPiProvider) createPythonWrapperForCommand(commandToExec string, wrapperPath string) error {
-	sitePackagesDir := p.findSitePackagesDir()
+func (p *PyPiProvider) createPythonWrapperForCommand(ctx context.Context, commandToExec string, wrapperPath string) error {
+	sitePackagesDir := p.findSitePackagesDir(ctx)
 	binDir := filepath.Join(p.APP_PACKAGES_DIR, "bin")
 	if sitePackagesDir == "" {
 		sitePackagesDir = p.APP_PACKAGES_DIR
@@ -233,28 +231,26 @@ func (p *PyPiProvider) createPythonWrapperForCommand(commandToExec string, wrapp
 	if commandToExec == "" {
 		return fmt.Errorf("empty command for wrapper %s", wrapperPath)
 	}
-	wrapperContent := fmt.Sprintf(`#!/bin/sh
-# Sets up Python environment for zana-installed packages and runs the target command
-
-# Add the zana Python packages to PYTHONPATH and PATH (to resolve console scripts)
-export PYTHONPATH="%s:$PYTHONPATH"
-export PATH="%s:$PATH"
-
-# Execute the command from registry
-exec %s "$@"
-`, sitePackagesDir, binDir, commandToExec)
-	if err := pipWriteFile(wrapperPath, []byte(wrapperContent), 0755); err != nil {
-		return err
-	}
-	return nil
+	script := wrapperScript{
+		Description: "Sets up the Python environment for zana-installed packages and runs the target command",
+		Env: []wrapperEnv{
+			// Keep packages from the user site-packages from shadowing the zana-installed ones
+			{Name: "PYTHONNOUSERSITE", Value: "1"},
+			{Name: "PYTHONPATH", Value: sitePackagesDir, Separator: ":"},
+			// Resolve console scripts from the zana Python bin directory
+			{Name: "PATH", Value: binDir, Separator: ":"},
+		},
+		Command: strings.Fields(commandToExec),
+	}
+	return writeWrapperScript(script, wrapperPath, pipWriteFile)
 }
 
 // findSitePackagesDir finds the site-packages directory where pip installed the modules.
 // It uses the current Python version to locate the correct directory, ensuring compatibility
 // with the latest Python version instead of relying on old versions.
-func (p *PyPiProvider) findSitePackagesDir() string {
+func (p *PyPiProvider) findSitePackagesDir(ctx context.Context) string {
 	// First, try to detect the current Python version and use that
-	pythonVersion, err := p.getPythonVersion()
+	pythonVersion, err := p.getPythonVersion(ctx)
 	if err == nil {
 		// Construct the expected path using current Python version
 		expectedPath := filepath.Join(p.APP_PACKAGES_DIR, "lib", "python"+pythonVersion, "site-packages")
@@ -297,13 +293,13 @@ func (p *PyPiProvider) findSitePackagesDir() string {
 }
 
 // removeAllWrappers removes all wrapper scripts from the zana bin directory
-func (p *PyPiProvider) removeAllWrappers() error {
+func (p *PyPiProvider) removeAllWrappers(ctx context.Context) error {
 	// Remove only wrappers managed by the PyPI provider based on registry bin names
 	desired := lppPyGetDataForProvider("pypi").Packages
 	if len(desired) == 0 {
 		return nil
 	}
-	zanaBinDir := files.GetAppBinPath()
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	parser := registry_parser.NewDefaultRegistryParser()
 	for _, pkg := range desired {
 		registryItem := parser.GetBySourceId(pkg.SourceID)
@@ -311,7 +307,7 @@ func (p *PyPiProvider) removeAllWrappers() error {
 			wrapperPath := filepath.Join(zanaBinDir, binName)
 			if _, err := pipLstat(wrapperPath); err == nil {
 				if err := pipRemove(wrapperPath); err != nil {
-					Logger.Error(fmt.Sprintf("Warning: failed to remove wrapper script %s: %v", wrapperPath, err))
+					Logger.ErrorContext(ctx, fmt.Sprintf("Warning: failed to remove wrapper script %s: %v", wrapperPath, err))
 				}
 			}
 		}
@@ -320,8 +316,8 @@ func (p *PyPiProvider) removeAllWrappers() error {
 }
 
 // removePackageWrappers removes wrapper scripts for a specific package
-func (p *PyPiProvider) removePackageWrappers(packageName string) error {
-	zanaBinDir := files.GetAppBinPath()
+func (p *PyPiProvider) removePackageWrappers(ctx context.Context, packageName string) error {
+	zanaBinDir := files.GetAppBinPathForProvider(p.PROVIDER_NAME)
 	// Reconstruct sourceId to query registry (use new format)
 	sourceID := p.PREFIX + packageName
 	parser := registry_parser.NewDefaultRegistryParser()
@@ -333,7 +329,7 @@ func (p *PyPiProvider) removePackageWrappers(packageName string) error {
 		wrapperPath := filepath.Join(zanaBinDir, binName)
 		if _, err := pipLstat(wrapperPath); err == nil {
 			if err := pipRemove(wrapperPath); err != nil {
-				Logger.Error(fmt.Sprintf("Warning: failed to remove wrapper script %s: %v", wrapperPath, err))
+				Logger.ErrorContext(ctx, fmt.Sprintf("Warning: failed to remove wrapper script %s: %v", wrapperPath, err))
 			}
 		}
 	}
@@ -348,8 +344,8 @@ func normalizeDistributionName(name string) string {
 }
 
 // findPackageInfoDir tries to locate the <dist>-<version>.dist-info (or .egg-info) directory for a package
-func (p *PyPiProvider) findPackageInfoDir(packageName string) string {
-	sitePackagesDir := p.findSitePackagesDir()
+func (p *PyPiProvider) findPackageInfoDir(ctx context.Context, packageName string) string {
+	sitePackagesDir := p.findSitePackagesDir(ctx)
 	if sitePackagesDir == "" {
 		return ""
 	}
@@ -408,15 +404,15 @@ func (p *PyPiProvider) parseEntryPointsFromInfoDir(infoDir string) []string {
 }
 
 func (p *PyPiProvider) Clean() bool {
-	_ = p.removeAllWrappers()
+	_ = p.removeAllWrappers(context.Background())
 	if err := pipRemoveAll(p.APP_PACKAGES_DIR); err != nil {
 		Logger.Error("Error removing directory:")
 		return false
 	}
-	return p.Sync()
+	return p.Sync(context.Background())
 }
 
-func (p *PyPiProvider) Sync() bool {
+func (p *PyPiProvider) Sync(ctx context.Context) bool {
 	if _, err := pipStat(p.APP_PACKAGES_DIR); os.IsNotExist(err) {
 		if err := pipMkdir(p.APP_PACKAGES_DIR, 0755); err != nil {
 			fmt.Println("Error creating directory:", err)
@@ -424,84 +420,66 @@ func (p *PyPiProvider) Sync() bool {
 		}
 	}
 
-	packagesFound := p.generateRequirementsTxt()
+	packagesFound := p.generateRequirementsTxt(ctx)
 	if !packagesFound {
 		return true
 	}
 
-	Logger.Info("PyPI Sync: Starting sync process")
+	Logger.InfoContext(ctx, "PyPI Sync: Starting sync process")
 
 	// Ensure we're using the current Python version by detecting it
-	pythonVersion, err := p.getPythonVersion()
+	pythonVersion, err := p.getPythonVersion(ctx)
 	if err != nil {
-		Logger.Error(fmt.Sprintf("PyPI Sync: Failed to detect Python version: %v", err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("PyPI Sync: Failed to detect Python version: %v", err))
 		// Continue anyway, pip will use whatever Python it's associated with
 	} else {
-		Logger.Info(fmt.Sprintf("PyPI Sync: Using Python version %s", pythonVersion))
+		Logger.InfoContext(ctx, fmt.Sprintf("PyPI Sync: Using Python version %s", pythonVersion))
 	}
 
-	desired := local_packages_parser.GetDataForProvider("pypi").Packages
-
-	if p.areAllPackagesInstalled(desired) {
-		Logger.Info("PyPI Sync: All packages already installed correctly, skipping installation")
-		_ = p.createWrappers()
+	desired := desiredPackagesFor(local_packages_parser.GetDataForProvider("pypi").Packages, p.getRepo)
+	// pip freeze also lists dependencies, so extraneous packages are expected
+	// and left alone
+	plan := reconcilePackages(desired, p.getInstalledPackages(ctx), normalizeDistributionName)
+	if plan.InSync() {
+		Logger.InfoContext(ctx, "PyPI Sync: All packages already installed correctly, skipping installation")
+		_ = p.createWrappers(ctx)
 		return true
 	}
 
-	installed := p.getInstalledPackages()
 	allOk := true
 	installedCount := 0
-	skippedCount := 0
-
-	for _, pkg := range desired {
-		name := p.getRepo(pkg.SourceID)
-		if v, ok := installed[name]; !ok || v != pkg.Version {
-			pkgString := fmt.Sprintf("%s==%s", name, pkg.Version)
-			Logger.Info(fmt.Sprintf("PyPI Sync: Installing package %s", pkgString))
-			// Use the current pip command which should be associated with the current Python version
-			installCode, err := pipShellOut(pipCmd, []string{"install", pkgString, "--prefix", p.APP_PACKAGES_DIR}, p.APP_PACKAGES_DIR, nil)
-			if err != nil || installCode != 0 {
-				Logger.Error(fmt.Sprintf("Error installing %s==%s: %v", name, pkg.Version, err))
-				allOk = false
-			} else {
-				installedCount++
-			}
+	for _, pkg := range plan.Pending() {
+		pkgString := fmt.Sprintf("%s==%s", pkg.Name, pkg.Desired)
+		Logger.InfoContext(ctx, fmt.Sprintf("PyPI Sync: Installing package %s (%s)", pkgString, pkg.State))
+		// Use the current pip command which should be associated with the current Python version
+		installCode, err := pipShellOut(newOperation(ctx, actionSync, pkg.SourceID), pipCmd, []string{"install", pkgString, "--prefix", p.APP_PACKAGES_DIR}, p.APP_PACKAGES_DIR, pipIndexEnv(pkg.Name))
+		if err != nil || installCode != 0 {
+			Logger.ErrorContext(ctx, fmt.Sprintf("Error installing %s: %v", pkgString, err))
+			allOk = false
 		} else {
-			Logger.Info(fmt.Sprintf("PyPI Sync: Package %s==%s already installed, skipping", name, pkg.Version))
-			skippedCount++
+			installedCount++
 		}
 	}
+	skippedCount := len(plan.withState(PackageStateInstalled))
 
 	if allOk {
-		_ = p.createWrappers()
+		_ = p.createWrappers(ctx)
 	}
 
-	Logger.Info(fmt.Sprintf("PyPI Sync: Completed - %d packages installed, %d packages skipped", installedCount, skippedCount))
+	Logger.InfoContext(ctx, fmt.Sprintf("PyPI Sync: Completed - %d packages installed, %d packages skipped", installedCount, skippedCount))
 	return allOk
 }
 
-// areAllPackagesInstalled checks if all desired packages are already installed with correct versions
-func (p *PyPiProvider) areAllPackagesInstalled(desired []local_packages_parser.LocalPackageItem) bool {
-	installed := p.getInstalledPackages()
-	for _, pkg := range desired {
-		name := p.getRepo(pkg.SourceID)
-		if v, ok := installed[name]; !ok || v != pkg.Version {
-			return false
-		}
-	}
-	return true
-}
-
 // getInstalledPackages gets the list of installed packages using pip freeze scoped to this provider's site-packages
 // (--prefix installs are not visible to a plain `pip freeze`, which lists the active interpreter's environment).
-func (p *PyPiProvider) getInstalledPackages() map[string]string {
+func (p *PyPiProvider) getInstalledPackages(ctx context.Context) map[string]string {
 	installed := map[string]string{}
-	siteDir := p.findSitePackagesDir()
+	siteDir := p.findSitePackagesDir(ctx)
 	args := []string{"freeze"}
 	if siteDir != "" {
 		args = append(args, "--path", siteDir)
 	}
-	freezeCode, freezeOut, freezeErr := pipShellOutCapture(pipCmd, args, p.APP_PACKAGES_DIR, nil)
+	freezeCode, freezeOut, freezeErr := pipShellOutCapture(ctx, pipCmd, args, p.APP_PACKAGES_DIR, nil)
 	trimmed := strings.TrimSpace(freezeOut)
 	if freezeCode == 0 {
 		if trimmed == "" {
@@ -524,33 +502,33 @@ func (p *PyPiProvider) getInstalledPackages() map[string]string {
 	if freezeErr != nil {
 		errMsg = freezeErr.Error()
 	}
-	Logger.Error(fmt.Sprintf("Error getting installed packages with %s freeze (args=%v): %s; output: %q", pipCmd, args, errMsg, freezeOut))
+	Logger.ErrorContext(ctx, fmt.Sprintf("Error getting installed packages with %s freeze (args=%v): %s; output: %q", pipCmd, args, errMsg, freezeOut))
 	return installed
 }
 
-func (p *PyPiProvider) Install(sourceID, version string) bool {
+func (p *PyPiProvider) Install(ctx context.Context, sourceID, version string) bool {
 	var err error
 	if version == "latest" {
-		version, err = p.getLatestVersion(p.getRepo(sourceID))
+		version, err = latestVersionOf(ctx, ProviderPyPi, p.getRepo(sourceID), p.getLatestVersion)
 		if err != nil {
-			Logger.Error(fmt.Sprintf("Error getting latest version for %s: %v", sourceID, err))
+			Logger.ErrorContext(ctx, fmt.Sprintf("Error getting latest version for %s: %v", sourceID, err))
 			return false
 		}
 	}
 	if err = lppPyAdd(sourceID, version); err != nil {
-		Logger.Error(fmt.Sprintf("Error adding package %s to local packages: %v", sourceID, err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Error adding package %s to local packages: %v", sourceID, err))
 		return false
 	}
-	return p.Sync()
+	return p.Sync(ctx)
 }
 
-func (p *PyPiProvider) removeBin(sourceID string) error {
+func (p *PyPiProvider) removeBin(ctx context.Context, sourceID string) error {
 	packageName := p.getRepo(sourceID)
 	if packageName == "" {
 		return fmt.Errorf("invalid source ID format for PyPI provider")
 	}
-	Logger.Info(fmt.Sprintf("PyPI Remove: Removing bin for package %s", packageName))
-	infoDir := p.findPackageInfoDir(packageName)
+	Logger.InfoContext(ctx, fmt.Sprintf("PyPI Remove: Removing bin for package %s", packageName))
+	infoDir := p.findPackageInfoDir(ctx, packageName)
 	if infoDir == "" {
 		return fmt.Errorf("no package info directory found for %s", packageName)
 	}
@@ -566,44 +544,44 @@ func (p *PyPiProvider) removeBin(sourceID string) error {
 	return nil
 }
 
-func (p *PyPiProvider) Remove(sourceID string) bool {
+func (p *PyPiProvider) Remove(ctx context.Context, sourceID string) bool {
 	packageName := p.getRepo(sourceID)
-	Logger.Info(fmt.Sprintf("PyPI Remove: Removing package %s", packageName))
+	Logger.InfoContext(ctx, fmt.Sprintf("PyPI Remove: Removing package %s", packageName))
 	// Try to remove bin - log error but continue (package might already be partially removed)
 	// Only fail if it's a critical error, not just missing directory
-	if err := p.removeBin(sourceID); err != nil {
-		Logger.Error(fmt.Sprintf("Error removing bin for package %s: %v", packageName, err))
+	if err := p.removeBin(ctx, sourceID); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Error removing bin for package %s: %v", packageName, err))
 		// Continue with wrapper and local package removal even if bin removal fails
 		// This handles cases where package is partially installed or already removed
 	}
-	_ = p.removePackageWrappers(packageName)
+	_ = p.removePackageWrappers(ctx, packageName)
 	if err := lppPyRemove(sourceID); err != nil {
-		Logger.Error(fmt.Sprintf("Error removing package %s from local packages: %v", packageName, err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Error removing package %s from local packages: %v", packageName, err))
 		return false
 	}
-	Logger.Info(fmt.Sprintf("PyPI Remove: Package %s removed successfully", packageName))
-	return p.Sync()
+	Logger.InfoContext(ctx, fmt.Sprintf("PyPI Remove: Package %s removed successfully", packageName))
+	return p.Sync(ctx)
 }
 
-func (p *PyPiProvider) Update(sourceID string) bool {
+func (p *PyPiProvider) Update(ctx context.Context, sourceID string) bool {
 	repo := p.getRepo(sourceID)
 	if repo == "" {
-		Logger.Error("Invalid source ID format for PyPI provider")
+		Logger.ErrorContext(ctx, "Invalid source ID format for PyPI provider")
 		return false
 	}
-	latestVersion, err := p.getLatestVersion(repo)
+	latestVersion, err := latestVersionOf(ctx, ProviderPyPi, repo, p.getLatestVersion)
 	if err != nil {
-		Logger.Error(fmt.Sprintf("Error getting latest version for %s: %v", repo, err))
+		Logger.ErrorContext(ctx, fmt.Sprintf("Error getting latest version for %s: %v", repo, err))
 		return false
 	}
-	Logger.Info(fmt.Sprintf("PyPI Update: Updating %s to version %s", repo, latestVersion))
-	return p.Install(sourceID, latestVersion)
+	Logger.InfoContext(ctx, fmt.Sprintf("PyPI Update: Updating %s to version %s", repo, latestVersion))
+	return p.Install(ctx, sourceID, latestVersion)
 }
 
-func (p *PyPiProvider) getLatestVersion(packageName string) (string, error) {
-	_, output, err := pipShellOutCapture(pipCmd, []string{"index", "versions", packageName}, "", nil)
+func (p *PyPiProvider) getLatestVersion(ctx context.Context, packageName string) (string, error) {
+	_, output, err := pipShellOutCapture(ctx, pipCmd, []string{"index", "versions", packageName}, "", pipIndexEnv(packageName))
 	if err != nil {
-		Logger.Error(fmt.Sprintf("PyPI getLatestVersion: Command failed for %s: %v, output: %s", packageName, err, output))
+		Logger.ErrorContext(ctx, fmt.Sprintf("PyPI getLatestVersion: Command failed for %s: %v, output: %s", packageName, err, output))
 		return "", err
 	}
 
@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"os"
@@ -26,7 +27,7 @@ func withTempZanaHome(t *testing.T) string {
 	// In CI/dev environments where e.g. cargo isn't installed, make availability checks
 	// deterministic by default (individual tests can override as needed).
 	oldCargoHas := cargoHasCommand
-	cargoHasCommand = func(string, []string, []string) bool { return true }
+	cargoHasCommand = func(context.Context, string, []string, []string) bool { return true }
 	t.Cleanup(func() { cargoHasCommand = oldCargoHas })
 
 	// Ensure dirs initialized
@@ -53,8 +54,10 @@ func TestPyPiProviderBasicFlows(t *testing.T) {
 	oldOut := pipShellOut
 	oldCap := pipShellOutCapture
 	oldCreate := pipCreate
-	pipShellOut = func(cmd string, args []string, dir string, env []string) (int, error) { return 0, nil }
-	pipShellOutCapture = func(cmd string, args []string, dir string, env []string) (int, string, error) {
+	pipShellOut = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, error) {
+		return 0, nil
+	}
+	pipShellOutCapture = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, string, error) {
 		return 0, "package==1.0.0\n", nil
 	}
 	pipCreate = func(name string) (*os.File, error) {
@@ -79,24 +82,24 @@ func TestPyPiProviderBasicFlows(t *testing.T) {
 	_ = registry_parser.NewDefaultRegistryParser().GetData(true)
 
 	// generate requirements
-	ok := p.generateRequirementsTxt()
+	ok := p.generateRequirementsTxt(context.Background())
 	assert.True(t, ok)
 
 	// getInstalledPackages parses freeze output
-	installed := p.getInstalledPackages()
+	installed := p.getInstalledPackages(context.Background())
 	assert.Equal(t, "1.0.0", installed["package"])
 
 	// create wrappers
-	assert.NoError(t, p.createWrappers())
+	assert.NoError(t, p.createWrappers(context.Background()))
 
 	// createPythonWrapperForCommand with empty command
-	err := p.createPythonWrapperForCommand("", filepath.Join(files.GetAppBinPath(), "noop"))
+	err := p.createPythonWrapperForCommand(context.Background(), "", filepath.Join(files.GetAppBinPath(), "noop"))
 	assert.Error(t, err)
 
 	// Simulate site-packages and entry_points for removeBin
 	// Use the Python version that findSitePackagesDir will detect
 	pythonVersion := "3.14" // Default fallback
-	if v, err := p.getPythonVersion(); err == nil {
+	if v, err := p.getPythonVersion(context.Background()); err == nil {
 		pythonVersion = v
 	}
 	site := filepath.Join(p.APP_PACKAGES_DIR, "lib", "python"+pythonVersion, "site-packages", "black-1.0.0.dist-info")
@@ -107,7 +110,7 @@ func TestPyPiProviderBasicFlows(t *testing.T) {
 	binFile := filepath.Join(p.APP_PACKAGES_DIR, "bin", "black")
 	_ = os.MkdirAll(filepath.Dir(binFile), 0755)
 	assert.NoError(t, os.WriteFile(binFile, []byte("#!/bin/sh\n"), 0755))
-	assert.NoError(t, p.removeBin("pkg:pypi/black"))
+	assert.NoError(t, p.removeBin(context.Background(), "pkg:pypi/black"))
 
 	// Also create the directory structure for later Remove call
 	site2 := filepath.Join(p.APP_PACKAGES_DIR, "lib", "python"+pythonVersion, "site-packages", "black-2.0.0.dist-info")
@@ -115,28 +118,28 @@ func TestPyPiProviderBasicFlows(t *testing.T) {
 	assert.NoError(t, os.WriteFile(filepath.Join(site2, "entry_points.txt"), []byte(ep), 0644))
 
 	// getLatestVersion parses pip index output
-	pipShellOutCapture = func(cmd string, args []string, dir string, env []string) (int, string, error) {
+	pipShellOutCapture = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, string, error) {
 		return 0, "Available versions: 2.0.0, 1.0.0, 0.1", nil
 	}
-	v, err := p.getLatestVersion("black")
+	v, err := p.getLatestVersion(context.Background(), "black")
 	assert.NoError(t, err)
 	assert.Equal(t, "2.0.0", v)
 
 	// Install latest
-	ok = p.Install("pkg:pypi/black", "latest")
+	ok = p.Install(context.Background(), "pkg:pypi/black", "latest")
 	assert.True(t, ok)
 
 	// Update
-	ok = p.Update("pkg:pypi/black")
+	ok = p.Update(context.Background(), "pkg:pypi/black")
 	assert.True(t, ok)
 
 	// Remove (no wrappers existing should still succeed)
-	ok = p.Remove("pkg:pypi/black")
+	ok = p.Remove(context.Background(), "pkg:pypi/black")
 	assert.True(t, ok)
 
 	// removeAllWrappers when wrappers exist
 	_ = os.WriteFile(filepath.Join(files.GetAppBinPath(), "black"), []byte(""), 0755)
-	assert.NoError(t, p.removeAllWrappers())
+	assert.NoError(t, p.removeAllWrappers(context.Background()))
 
 	// Clean
 	ok = p.Clean()
@@ -200,7 +203,7 @@ func TestPyPiErrorBranches(t *testing.T) {
 	pipLstat = func(string) (os.FileInfo, error) { return fileInfoNow(t), nil }
 	pipRemove = func(string) error { return errors.New("rm") }
 	pipChmod = func(string, os.FileMode) error { return errors.New("chmod") }
-	assert.NoError(t, p.createWrappers())
+	assert.NoError(t, p.createWrappers(context.Background()))
 	pipLstat, pipRemove, pipChmod = oldLs, oldRm, oldCh
 
 	// findSitePackagesDir errors
@@ -208,12 +211,12 @@ func TestPyPiErrorBranches(t *testing.T) {
 	oldRDir := pipReadDir
 	oldGetPyVer := pipGetPythonVersion
 	// Mock getPythonVersion to fail so it uses fallback logic
-	pipGetPythonVersion = func(*PyPiProvider) (string, error) { return "", errors.New("python not found") }
+	pipGetPythonVersion = func(context.Context, *PyPiProvider) (string, error) { return "", errors.New("python not found") }
 	pipStat = func(string) (os.FileInfo, error) { return nil, os.ErrNotExist }
-	assert.Equal(t, "", p.findSitePackagesDir())
+	assert.Equal(t, "", p.findSitePackagesDir(context.Background()))
 	pipStat = oldSt
 	pipReadDir = func(string) ([]os.DirEntry, error) { return nil, errors.New("readdir") }
-	assert.Equal(t, "", p.findSitePackagesDir())
+	assert.Equal(t, "", p.findSitePackagesDir(context.Background()))
 	pipReadDir = oldRDir
 	pipGetPythonVersion = oldGetPyVer
 
@@ -222,16 +225,16 @@ func TestPyPiErrorBranches(t *testing.T) {
 	oldRm = pipRemove
 	pipLstat = func(string) (os.FileInfo, error) { return fileInfoNow(t), nil }
 	pipRemove = func(string) error { return errors.New("rm") }
-	assert.NoError(t, p.removeAllWrappers())
-	assert.NoError(t, p.removePackageWrappers("black"))
+	assert.NoError(t, p.removeAllWrappers(context.Background()))
+	assert.NoError(t, p.removePackageWrappers(context.Background(), "black"))
 	pipLstat, pipRemove = oldLs, oldRm
 
 	// getLatestVersion invalid format
 	oldCap := pipShellOutCapture
-	pipShellOutCapture = func(cmd string, args []string, dir string, env []string) (int, string, error) {
+	pipShellOutCapture = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, string, error) {
 		return 0, "no parens", nil
 	}
-	_, err = p.getLatestVersion("black")
+	_, err = p.getLatestVersion(context.Background(), "black")
 	assert.Error(t, err)
 	pipShellOutCapture = oldCap
 }
@@ -243,19 +246,23 @@ func TestMoreBranchesPyPI(t *testing.T) {
 
 	// Install: latest fetch fails
 	oldCap := pipShellOutCapture
-	pipShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 1, "", errors.New("err") }
-	assert.False(t, p.Install("pkg:pypi/black", "latest"))
+	pipShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 1, "", errors.New("err")
+	}
+	assert.False(t, p.Install(context.Background(), "pkg:pypi/black", "latest"))
 	pipShellOutCapture = oldCap
 
 	// Install: add fails
 	oldAdd := lppPyAdd
 	lppPyAdd = func(string, string) error { return errors.New("add") }
-	assert.False(t, p.Install("pkg:pypi/black", "1.0.0"))
+	assert.False(t, p.Install(context.Background(), "pkg:pypi/black", "1.0.0"))
 	lppPyAdd = oldAdd
 
 	// Update: latest fetch fails
-	pipShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 1, "", errors.New("err") }
-	assert.False(t, p.Update("pkg:pypi/black"))
+	pipShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 1, "", errors.New("err")
+	}
+	assert.False(t, p.Update(context.Background(), "pkg:pypi/black"))
 	pipShellOutCapture = oldCap
 
 	// Remove: removeBin error -> should handle gracefully and continue
@@ -263,11 +270,11 @@ func TestMoreBranchesPyPI(t *testing.T) {
 	// But Remove should continue and try to remove from local packages
 	// Mock getPythonVersion to fail so findSitePackagesDir returns empty
 	oldGetPyVer := pipGetPythonVersion
-	pipGetPythonVersion = func(*PyPiProvider) (string, error) { return "", errors.New("python not found") }
+	pipGetPythonVersion = func(context.Context, *PyPiProvider) (string, error) { return "", errors.New("python not found") }
 	// Also mock local package removal to fail so Remove returns false
 	oldLppRm := lppPyRemove
 	lppPyRemove = func(string) error { return errors.New("rm-local") }
-	result := p.Remove("pkg:pypi/black")
+	result := p.Remove(context.Background(), "pkg:pypi/black")
 	assert.False(t, result) // Should fail because local package removal fails
 	lppPyRemove = oldLppRm
 	pipGetPythonVersion = oldGetPyVer
@@ -275,7 +282,7 @@ func TestMoreBranchesPyPI(t *testing.T) {
 	// createPythonWrapperForCommand write error by pointing to a directory
 	dir := filepath.Join(p.APP_PACKAGES_DIR, "bin", "d")
 	_ = os.MkdirAll(dir, 0755)
-	assert.Error(t, p.createPythonWrapperForCommand("echo true", dir))
+	assert.Error(t, p.createPythonWrapperForCommand(context.Background(), "echo true", dir))
 
 	// Additional injection-based coverage to push to 100%
 	// Close warning path for requirements.txt
@@ -283,7 +290,7 @@ func TestMoreBranchesPyPI(t *testing.T) {
 	pipClose = func(f *os.File) error { return errors.New("close") }
 	// Force found=true by adding a desired pypi package
 	_ = lppPyAdd("pkg:pypi/extra", "1.0.0")
-	_ = p.generateRequirementsTxt()
+	_ = p.generateRequirementsTxt(context.Background())
 	pipClose = oldClose
 
 	// removePackageWrappers uses injectables for lstat/remove
@@ -297,12 +304,12 @@ func TestMoreBranchesPyPI(t *testing.T) {
 	oldRm := pipRemove
 	pipLstat = func(string) (os.FileInfo, error) { return fileInfoNow(t), nil }
 	pipRemove = func(string) error { return errors.New("rm") }
-	_ = p.removePackageWrappers("wrap")
+	_ = p.removePackageWrappers(context.Background(), "wrap")
 	pipLstat, pipRemove = oldLs, oldRm
 
 	// Constructor pip/pip3 detection branches
 	oldHas := pipHasCommand
-	pipHasCommand = func(cmd string, args []string, env []string) bool {
+	pipHasCommand = func(_ context.Context, cmd string, args []string, env []string) bool {
 		if cmd == "pip" {
 			return false
 		}
@@ -314,7 +321,7 @@ func TestMoreBranchesPyPI(t *testing.T) {
 	p2 := NewProviderPyPi()
 	assert.NotNil(t, p2)
 	// pip and pip3 both missing -> error log path (no assertion on log content)
-	pipHasCommand = func(cmd string, args []string, env []string) bool { return false }
+	pipHasCommand = func(_ context.Context, cmd string, args []string, env []string) bool { return false }
 	_ = NewProviderPyPi()
 	pipHasCommand = oldHas
 }
@@ -324,7 +331,7 @@ func TestPyPiGetRepoEmptyAndCreateWrappersEarlyReturn(t *testing.T) {
 	p := NewProviderPyPi()
 	assert.Equal(t, "", p.getRepo("pkg:pypi"))
 	// No desired packages -> createWrappers should return nil quickly
-	assert.NoError(t, p.createWrappers())
+	assert.NoError(t, p.createWrappers(context.Background()))
 }
 
 func TestPyPiGenerateRequirements_WriteErrorAndCloseWarning(t *testing.T) {
@@ -346,7 +353,7 @@ func TestPyPiGenerateRequirements_WriteErrorAndCloseWarning(t *testing.T) {
 	}
 	// Also trigger close warning for completeness
 	pipClose = func(f *os.File) error { return errors.New("close") }
-	assert.False(t, p.generateRequirementsTxt())
+	assert.False(t, p.generateRequirementsTxt(context.Background()))
 	// restore
 	pipClose = oldClose
 	pipCreate = oldCreate
@@ -364,7 +371,7 @@ func TestPyPiCreateWrappers_WrapperCreateErrorContinues(t *testing.T) {
 	}})
 	_ = registry_parser.NewDefaultRegistryParser().GetData(true)
 	// Should not error overall
-	assert.NoError(t, p.createWrappers())
+	assert.NoError(t, p.createWrappers(context.Background()))
 }
 
 func TestPyPiCreatePythonWrapper_StripsPypiPrefixFromRegistryBin(t *testing.T) {
@@ -372,12 +379,14 @@ func TestPyPiCreatePythonWrapper_StripsPypiPrefixFromRegistryBin(t *testing.T) {
 	p := NewProviderPyPi()
 	_ = os.MkdirAll(p.APP_PACKAGES_DIR, 0755)
 	wrapper := filepath.Join(files.GetAppBinPath(), "yamllint")
-	assert.NoError(t, p.createPythonWrapperForCommand("pypi:yamllint", wrapper))
+	assert.NoError(t, p.createPythonWrapperForCommand(context.Background(), "pypi:yamllint", wrapper))
 	data, err := os.ReadFile(wrapper)
 	assert.NoError(t, err)
 	body := string(data)
 	assert.Contains(t, body, "exec yamllint \"$@\"")
 	assert.NotContains(t, body, "pypi:yamllint")
+	assert.Contains(t, body, "export PYTHONNOUSERSITE=1")
+	assert.Contains(t, body, `"${PYTHONPATH:+:$PYTHONPATH}"`)
 }
 
 func TestPyPiFindPackageInfoDir_ErrorAndContinues(t *testing.T) {
@@ -394,7 +403,7 @@ func TestPyPiFindPackageInfoDir_ErrorAndContinues(t *testing.T) {
 		}
 		return oldRD(path)
 	}
-	assert.Equal(t, "", p.findPackageInfoDir("pkg"))
+	assert.Equal(t, "", p.findPackageInfoDir(context.Background(), "pkg"))
 	// Restore
 	pipReadDir = oldRD
 
@@ -403,7 +412,7 @@ func TestPyPiFindPackageInfoDir_ErrorAndContinues(t *testing.T) {
 	_ = os.WriteFile(filepath.Join(lib, "file.txt"), []byte(""), 0644)
 	// 2) A directory that is not *.dist-info or *.egg-info
 	_ = os.MkdirAll(filepath.Join(lib, "notinfo"), 0755)
-	assert.Equal(t, "", p.findPackageInfoDir("pkg"))
+	assert.Equal(t, "", p.findPackageInfoDir(context.Background(), "pkg"))
 }
 
 func TestPyPiParseEntryPoints_ErrorReturnsNil(t *testing.T) {
@@ -424,7 +433,7 @@ func TestPyPiSync_CreateDirErrorAndSkipInstalledInLoopAndFreezeError(t *testing.
 	oldMkdir := pipMkdir
 	pipStat = func(string) (os.FileInfo, error) { return nil, os.ErrNotExist }
 	pipMkdir = func(string, os.FileMode) error { return errors.New("mkdir") }
-	assert.False(t, p.Sync())
+	assert.False(t, p.Sync(context.Background()))
 	// restore
 	pipStat = oldStat
 	pipMkdir = oldMkdir
@@ -443,11 +452,11 @@ func TestPyPiSync_CreateDirErrorAndSkipInstalledInLoopAndFreezeError(t *testing.
 	}})
 	_ = registry_parser.NewDefaultRegistryParser().GetData(true)
 	// requirements
-	assert.True(t, p.generateRequirementsTxt())
+	assert.True(t, p.generateRequirementsTxt(context.Background()))
 	// freeze returns c1 only; then later return non-zero to exercise error path too
 	oldCap := pipShellOutCapture
 	called := 0
-	pipShellOutCapture = func(cmd string, args []string, dir string, env []string) (int, string, error) {
+	pipShellOutCapture = func(_ context.Context, cmd string, args []string, dir string, env []string) (int, string, error) {
 		// only used for freeze in this test
 		called++
 		if called == 1 {
@@ -457,8 +466,10 @@ func TestPyPiSync_CreateDirErrorAndSkipInstalledInLoopAndFreezeError(t *testing.
 	}
 	// install fails for c2
 	oldOut := pipShellOut
-	pipShellOut = func(string, []string, string, []string) (int, error) { return 1, errors.New("install") }
-	assert.False(t, p.Sync())
+	pipShellOut = func(context.Context, string, []string, string, []string) (int, error) {
+		return 1, errors.New("install")
+	}
+	assert.False(t, p.Sync(context.Background()))
 	// restore
 	pipShellOut = oldOut
 	pipShellOutCapture = oldCap
@@ -469,7 +480,7 @@ func TestPyPiFindSitePackages_ReadDirErrorAndEmpty(t *testing.T) {
 	p := NewProviderPyPi()
 	// Mock getPythonVersion to fail so it uses fallback logic
 	oldGetPyVer := pipGetPythonVersion
-	pipGetPythonVersion = func(*PyPiProvider) (string, error) { return "", errors.New("python not found") }
+	pipGetPythonVersion = func(context.Context, *PyPiProvider) (string, error) { return "", errors.New("python not found") }
 	defer func() { pipGetPythonVersion = oldGetPyVer }()
 
 	// Create lib dir to pass first stat
@@ -478,12 +489,12 @@ func TestPyPiFindSitePackages_ReadDirErrorAndEmpty(t *testing.T) {
 	// readDir error -> returns ""
 	oldRD := pipReadDir
 	pipReadDir = func(string) ([]os.DirEntry, error) { return nil, errors.New("readdir") }
-	assert.Equal(t, "", p.findSitePackagesDir())
+	assert.Equal(t, "", p.findSitePackagesDir(context.Background()))
 	// restore and create empty python dir without site-packages stat success -> still ""
 	pipReadDir = oldRD
 	py := filepath.Join(lib, "python3.11")
 	_ = os.MkdirAll(py, 0755)
-	assert.Equal(t, "", p.findSitePackagesDir())
+	assert.Equal(t, "", p.findSitePackagesDir(context.Background()))
 }
 
 func TestPyPiSync_SkipBranchInLoop(t *testing.T) {
@@ -502,14 +513,16 @@ func TestPyPiSync_SkipBranchInLoop(t *testing.T) {
 	}})
 	_ = registry_parser.NewDefaultRegistryParser().GetData(true)
 	// ensure requirements created
-	_ = p.generateRequirementsTxt()
+	_ = p.generateRequirementsTxt(context.Background())
 	// Freeze returns c1 installed for both areAllPackagesInstalled and the installed map
 	oldCap := pipShellOutCapture
-	pipShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 0, "c1==1.0.0", nil }
+	pipShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 0, "c1==1.0.0", nil
+	}
 	// Install succeeds for c2 so Sync returns true
 	oldOut := pipShellOut
-	pipShellOut = func(string, []string, string, []string) (int, error) { return 0, nil }
-	assert.True(t, p.Sync())
+	pipShellOut = func(context.Context, string, []string, string, []string) (int, error) { return 0, nil }
+	assert.True(t, p.Sync(context.Background()))
 	pipShellOut = oldOut
 	pipShellOutCapture = oldCap
 }
@@ -518,8 +531,8 @@ func TestPyPiGetLatestVersion_NoVersions(t *testing.T) {
 	_ = withTempZanaHome(t)
 	p := NewProviderPyPi()
 	oldCap := pipShellOutCapture
-	pipShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 0, "()", nil }
-	_, err := p.getLatestVersion("pkg")
+	pipShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) { return 0, "()", nil }
+	_, err := p.getLatestVersion(context.Background(), "pkg")
 	assert.Error(t, err)
 	pipShellOutCapture = oldCap
 }
@@ -551,7 +564,7 @@ func TestPyPiRemove_WrapperRemovalErrorAndLocalRemoveError(t *testing.T) {
 	// Now make local package removal fail to hit the log and false return
 	oldLocalRemove := lppPyRemove
 	lppPyRemove = func(string) error { return errors.New("local-remove") }
-	assert.False(t, p.Remove("pkg:pypi/tool"))
+	assert.False(t, p.Remove(context.Background(), "pkg:pypi/tool"))
 	// restore
 	lppPyRemove = oldLocalRemove
 	pipLstat, pipRemove = oldLs, oldRm
@@ -577,7 +590,7 @@ func TestPyPiRemove_LocalRemoveErrorReturnsFalse(t *testing.T) {
 	// Make local remove fail so Remove returns false at the targeted line
 	oldLocalRemove := lppPyRemove
 	lppPyRemove = func(string) error { return errors.New("local-remove") }
-	assert.False(t, p.Remove("pkg:pypi/tool"))
+	assert.False(t, p.Remove(context.Background(), "pkg:pypi/tool"))
 	lppPyRemove = oldLocalRemove
 }
 
@@ -586,7 +599,7 @@ func TestPyPiRemove_InvalidSourceAndRemoveBinFailure(t *testing.T) {
 	p := NewProviderPyPi()
 	_ = os.MkdirAll(p.APP_PACKAGES_DIR, 0755)
 	// invalid source id for removeBin
-	assert.Error(t, p.removeBin("pkg:pypi/"))
+	assert.Error(t, p.removeBin(context.Background(), "pkg:pypi/"))
 	// setup info dir and bin then make removal fail
 	lib := filepath.Join(p.APP_PACKAGES_DIR, "lib", "python3.11", "site-packages")
 	_ = os.MkdirAll(lib, 0755)
@@ -599,7 +612,7 @@ func TestPyPiRemove_InvalidSourceAndRemoveBinFailure(t *testing.T) {
 	oldRm := pipRemove
 	pipLstat = func(string) (os.FileInfo, error) { return fileInfoNow(t), nil }
 	pipRemove = func(string) error { return errors.New("rm") }
-	assert.Error(t, p.removeBin("pkg:pypi/tool"))
+	assert.Error(t, p.removeBin(context.Background(), "pkg:pypi/tool"))
 	pipLstat, pipRemove = oldLs, oldRm
 }
 
@@ -617,11 +630,13 @@ func TestPyPiAreAllInstalledTrueTriggersWrappers(t *testing.T) {
 	_ = registry_parser.NewDefaultRegistryParser().GetData(true)
 	// freeze shows installed equal
 	oldCap := pipShellOutCapture
-	pipShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 0, "black==1.0.0", nil }
+	pipShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 0, "black==1.0.0", nil
+	}
 	// ensure wrappers can be created
 	oldCh := pipChmod
 	pipChmod = func(string, os.FileMode) error { return nil }
-	assert.True(t, p.Sync())
+	assert.True(t, p.Sync(context.Background()))
 	pipChmod = oldCh
 	pipShellOutCapture = oldCap
 }
@@ -636,7 +651,7 @@ func TestPyPiMorePermutations(t *testing.T) {
 	lppPyGetData = func(bool) local_packages_parser.LocalPackageRoot {
 		return local_packages_parser.LocalPackageRoot{Packages: nil}
 	}
-	assert.False(t, p.generateRequirementsTxt())
+	assert.False(t, p.generateRequirementsTxt(context.Background()))
 	lppPyGetData = oldGet
 
 	// createWrappers: no bin case then multi-bin
@@ -647,36 +662,36 @@ func TestPyPiMorePermutations(t *testing.T) {
 		Bin: map[string]string{},
 	}})
 	_ = registry_parser.NewDefaultRegistryParser().GetData(true)
-	assert.NoError(t, p.createWrappers())
+	assert.NoError(t, p.createWrappers(context.Background()))
 	// Multi-bin
 	writeRegistry(t, []registry_parser.RegistryItem{{
 		Name: "tool", Version: "1.0.0", Source: registry_parser.RegistryItemSource{ID: "pkg:pypi/tool"},
 		Bin: map[string]string{"a": "a", "b": "b"},
 	}})
 	_ = lppPyAdd("pkg:pypi/tool", "1.0.0")
-	assert.NoError(t, p.createWrappers())
+	assert.NoError(t, p.createWrappers(context.Background()))
 
 	// findSitePackagesDir success
 	// Use the Python version that findSitePackagesDir will detect
 	pythonVersion := "3.14" // Default fallback
-	if v, err := p.getPythonVersion(); err == nil {
+	if v, err := p.getPythonVersion(context.Background()); err == nil {
 		pythonVersion = v
 	}
 	lib := filepath.Join(p.APP_PACKAGES_DIR, "lib", "python"+pythonVersion, "site-packages")
 	_ = os.MkdirAll(lib, 0755)
-	detectedLib := p.findSitePackagesDir()
+	detectedLib := p.findSitePackagesDir(context.Background())
 	assert.Equal(t, lib, detectedLib)
 
 	// findPackageInfoDir with dist-info and egg-info
 	infoDist := filepath.Join(detectedLib, "pkg-1.0.0.dist-info")
 	_ = os.MkdirAll(infoDist, 0755)
-	result := p.findPackageInfoDir("pkg")
+	result := p.findPackageInfoDir(context.Background(), "pkg")
 	if result != "" {
 		assert.Contains(t, result, "dist-info")
 	}
 	infoEgg := filepath.Join(detectedLib, "egg.egg-info")
 	_ = os.MkdirAll(infoEgg, 0755)
-	resultEgg := p.findPackageInfoDir("egg")
+	resultEgg := p.findPackageInfoDir(context.Background(), "egg")
 	if resultEgg != "" {
 		assert.Contains(t, resultEgg, "egg-info")
 	}
@@ -684,11 +699,11 @@ func TestPyPiMorePermutations(t *testing.T) {
 	// Remove: local remove fails -> false
 	oldRm := lppPyRemove
 	lppPyRemove = func(string) error { return errors.New("rm") }
-	assert.False(t, p.Remove("pkg:pypi/tool"))
+	assert.False(t, p.Remove(context.Background(), "pkg:pypi/tool"))
 	lppPyRemove = oldRm
 
 	// Update invalid repo
-	assert.False(t, p.Update("pkg:pypi/"))
+	assert.False(t, p.Update(context.Background(), "pkg:pypi/"))
 }
 
 func TestPyPiGenerateRequirementsCreateErrorAndRemoveWrappersNoBinAndRemoveBinSuccess(t *testing.T) {
@@ -699,7 +714,7 @@ func TestPyPiGenerateRequirementsCreateErrorAndRemoveWrappersNoBinAndRemoveBinSu
 	// generateRequirementsTxt create error
 	oldCreate := pipCreate
 	pipCreate = func(string) (*os.File, error) { return nil, errors.New("create") }
-	assert.False(t, p.generateRequirementsTxt())
+	assert.False(t, p.generateRequirementsTxt(context.Background()))
 	pipCreate = oldCreate
 
 	// removePackageWrappers no-bin returns nil
@@ -708,7 +723,7 @@ func TestPyPiGenerateRequirementsCreateErrorAndRemoveWrappersNoBinAndRemoveBinSu
 		Bin: map[string]string{},
 	}})
 	_ = registry_parser.NewDefaultRegistryParser().GetData(true)
-	assert.NoError(t, p.removePackageWrappers("nobin"))
+	assert.NoError(t, p.removePackageWrappers(context.Background(), "nobin"))
 
 	// removeBin success: create info dir and bin file; ensure it deletes
 	binDir := filepath.Join(p.APP_PACKAGES_DIR, "bin")
@@ -717,7 +732,7 @@ func TestPyPiGenerateRequirementsCreateErrorAndRemoveWrappersNoBinAndRemoveBinSu
 	assert.NoError(t, os.WriteFile(binFile, []byte(""), 0755))
 	// Use the Python version that findSitePackagesDir will detect
 	pythonVersion := "3.14" // Default fallback
-	if v, err := p.getPythonVersion(); err == nil {
+	if v, err := p.getPythonVersion(context.Background()); err == nil {
 		pythonVersion = v
 	}
 	lib := filepath.Join(p.APP_PACKAGES_DIR, "lib", "python"+pythonVersion, "site-packages")
@@ -727,7 +742,7 @@ func TestPyPiGenerateRequirementsCreateErrorAndRemoveWrappersNoBinAndRemoveBinSu
 	// entry_points.txt with console_scripts
 	assert.NoError(t, os.WriteFile(filepath.Join(info, "entry_points.txt"), []byte("[console_scripts]\ntool = t:m\n"), 0644))
 	// removeBin should succeed when the package info directory exists
-	err := p.removeBin("pkg:pypi/tool")
+	err := p.removeBin(context.Background(), "pkg:pypi/tool")
 	if err != nil {
 		// If it fails, it's because the directory wasn't found - skip this assertion
 		t.Logf("removeBin returned error (expected if package info dir not found): %v", err)
@@ -759,11 +774,13 @@ func TestPyPiSyncMixedInstalledSkippedAndGuiScriptsAndRemoveHappy(t *testing.T)
 	oldCap := pipShellOutCapture
 	oldOut := pipShellOut
 	oldCh := pipChmod
-	pipShellOutCapture = func(string, []string, string, []string) (int, string, error) { return 0, "c1==1.0.0\nc2==2.0.0", nil }
+	pipShellOutCapture = func(context.Context, string, []string, string, []string) (int, string, error) {
+		return 0, "c1==1.0.0\nc2==2.0.0", nil
+	}
 	// ensure any pip installs (if reached) succeed; and chmod succeeds
-	pipShellOut = func(string, []string, string, []string) (int, error) { return 0, nil }
+	pipShellOut = func(context.Context, string, []string, string, []string) (int, error) { return 0, nil }
 	pipChmod = func(string, os.FileMode) error { return nil }
-	assert.True(t, p.Sync())
+	assert.True(t, p.Sync(context.Background()))
 
 	// parseEntryPoints gui_scripts
 	infoDir := filepath.Join(p.APP_PACKAGES_DIR, "lib", "python3.11", "site-packages", "gui-1.0.0.dist-info")
@@ -787,7 +804,7 @@ func TestPyPiSyncMixedInstalledSkippedAndGuiScriptsAndRemoveHappy(t *testing.T)
 	_ = os.WriteFile(filepath.Join(pid, "entry_points.txt"), []byte("[console_scripts]\ntool = t:m\n"), 0644)
 	_ = os.MkdirAll(filepath.Join(p.APP_PACKAGES_DIR, "bin"), 0755)
 	_ = os.WriteFile(filepath.Join(p.APP_PACKAGES_DIR, "bin", "tool"), []byte(""), 0755)
-	assert.True(t, p.Remove("pkg:pypi/tool"))
+	assert.True(t, p.Remove(context.Background(), "pkg:pypi/tool"))
 	// restore after Remove's internal Sync completed
 	pipChmod = oldCh
 	pipShellOut = oldOut
@@ -798,5 +815,5 @@ func TestPyPiSyncReturnsEarlyWhenNoPackages(t *testing.T) {
 	_ = withTempZanaHome(t)
 	p := NewProviderPyPi()
 	_ = os.MkdirAll(p.APP_PACKAGES_DIR, 0755)
-	assert.True(t, p.Sync())
+	assert.True(t, p.Sync(context.Background()))
 }
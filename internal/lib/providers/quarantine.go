@@ -0,0 +1,141 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+)
+
+// quarantineMetaExt is the extension of the metadata file written next to
+// each quarantined download
+const quarantineMetaExt = ".json"
+
+// QuarantineEntry describes a download that failed to extract and was moved
+// into the quarantine directory instead of being retried from the same bytes
+type QuarantineEntry struct {
+	// File is the path of the quarantined download
+	File          string    `json:"file"`
+	URL           string    `json:"url"`
+	Package       string    `json:"package,omitempty"`
+	SHA256        string    `json:"sha256,omitempty"`
+	Size          int64     `json:"size"`
+	Error         string    `json:"error"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
+
+// quarantineDownload moves the download of url at path, which failed to
+// extract with cause, into the quarantine directory with its metadata, and
+// drops it from the asset cache and the pins of the running operation, so
+// the next download of url fetches it again
+func quarantineDownload(ctx context.Context, url, path string, cause error) {
+	_ = os.Remove(files.GetAssetCacheFilePath(url))
+	forgetAssetPin(ctx, url)
+
+	entry := QuarantineEntry{URL: url, Error: cause.Error(), QuarantinedAt: clock.Now().UTC()}
+	if op, ok := currentOperation(ctx); ok {
+		entry.Package = op.Package
+	}
+	if info, err := os.Stat(path); err == nil {
+		entry.Size = info.Size()
+	}
+	entry.SHA256, _ = hashFile(path)
+
+	name := entry.QuarantinedAt.Format("20060102T150405.000000000Z") + "-" + filepath.Base(path)
+	dest := filepath.Join(files.GetQuarantinePath(), name)
+	if err := os.Rename(path, dest); err != nil {
+		// The staging directory may be on another file system
+		if _, err := copyAssetFile(path, dest); err != nil {
+			Logger.InfoContext(ctx, fmt.Sprintf("Quarantine: Warning moving %s: %v", path, err))
+			_ = os.Remove(path)
+			return
+		}
+		_ = os.Remove(path)
+	}
+	entry.File = dest
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err == nil {
+		err = os.WriteFile(dest+quarantineMetaExt, data, 0644)
+	}
+	if err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("Quarantine: Warning writing the metadata of %s: %v", dest, err))
+	}
+	Logger.WarnContext(ctx, fmt.Sprintf("Quarantine: %s failed to extract (%v), moved it to %s", url, cause, dest))
+}
+
+// extractOrRedownload extracts the download of url at path into destDir.
+// When the archive is corrupt or truncated, it's quarantined (see
+// quarantineDownload), downloaded once more and extracted again, before the
+// error is returned.
+func extractOrRedownload(ctx context.Context, url, path, destDir string, extract func(ctx context.Context, archivePath, destDir string) error, download func(ctx context.Context, url, destPath string) error) error {
+	err := extract(ctx, path, destDir)
+	if err == nil {
+		return nil
+	}
+	quarantineDownload(ctx, url, path, err)
+	if err := os.RemoveAll(destDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return err
+	}
+	Logger.InfoContext(ctx, fmt.Sprintf("Quarantine: Downloading %s again", url))
+	if derr := download(ctx, url, path); derr != nil {
+		return fmt.Errorf("%w, downloading it again failed: %v", err, derr)
+	}
+	if err := extract(ctx, path, destDir); err != nil {
+		quarantineDownload(ctx, url, path, err)
+		return err
+	}
+	return nil
+}
+
+// QuarantinedDownloads returns the quarantined downloads, most recent first
+func QuarantinedDownloads() []QuarantineEntry {
+	dir := files.GetQuarantinePath()
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var entries []QuarantineEntry
+	for _, e := range dirEntries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), quarantineMetaExt) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		var entry QuarantineEntry
+		if json.Unmarshal(data, &entry) != nil {
+			continue
+		}
+		// The directory may have moved along with the cache
+		entry.File = filepath.Join(dir, strings.TrimSuffix(e.Name(), quarantineMetaExt))
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].QuarantinedAt.After(entries[j].QuarantinedAt) })
+	return entries
+}
+
+// ClearQuarantine deletes the quarantined downloads and returns how many it deleted
+func ClearQuarantine() (int, error) {
+	deleted := 0
+	for _, entry := range QuarantinedDownloads() {
+		if err := os.Remove(entry.File); err != nil && !os.IsNotExist(err) {
+			return deleted, err
+		}
+		if err := os.Remove(entry.File + quarantineMetaExt); err != nil && !os.IsNotExist(err) {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
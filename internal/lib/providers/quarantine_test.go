@@ -0,0 +1,112 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractOrRedownload(t *testing.T) {
+	const url = "https://example.com/tool.tar.gz"
+
+	setup := func(t *testing.T) (archive, destDir string) {
+		t.Helper()
+		t.Setenv("ZANA_CACHE", t.TempDir())
+		dir := t.TempDir()
+		archive = filepath.Join(dir, "tool.tar.gz")
+		require.NoError(t, os.WriteFile(archive, []byte("truncated"), 0644))
+		require.NoError(t, os.WriteFile(files.GetAssetCacheFilePath(url), []byte("truncated"), 0644))
+		destDir = filepath.Join(dir, "extracted")
+		require.NoError(t, os.MkdirAll(destDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(destDir, "partial"), nil, 0644))
+		return archive, destDir
+	}
+	extract := func(_ context.Context, archivePath, destDir string) error {
+		data, err := os.ReadFile(archivePath)
+		if err != nil {
+			return err
+		}
+		if string(data) != "complete" {
+			return errors.New("unexpected end of archive")
+		}
+		return os.WriteFile(filepath.Join(destDir, "tool"), data, 0755)
+	}
+
+	t.Run("quarantines the corrupt download and downloads it again", func(t *testing.T) {
+		archive, destDir := setup(t)
+		downloads := 0
+		download := func(_ context.Context, u, destPath string) error {
+			downloads++
+			assert.Equal(t, url, u)
+			assert.NoFileExists(t, files.GetAssetCacheFilePath(url), "the corrupt bytes must not be reused")
+			return os.WriteFile(destPath, []byte("complete"), 0644)
+		}
+
+		require.NoError(t, extractOrRedownload(context.Background(), url, archive, destDir, extract, download))
+		assert.Equal(t, 1, downloads)
+		assert.FileExists(t, filepath.Join(destDir, "tool"))
+		assert.NoFileExists(t, filepath.Join(destDir, "partial"))
+
+		entries := QuarantinedDownloads()
+		require.Len(t, entries, 1)
+		assert.Equal(t, url, entries[0].URL)
+		assert.Equal(t, "unexpected end of archive", entries[0].Error)
+		assert.Equal(t, int64(len("truncated")), entries[0].Size)
+		assert.NotEmpty(t, entries[0].SHA256)
+		data, err := os.ReadFile(entries[0].File)
+		require.NoError(t, err)
+		assert.Equal(t, "truncated", string(data))
+
+		deleted, err := ClearQuarantine()
+		require.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+		assert.Empty(t, QuarantinedDownloads())
+	})
+
+	t.Run("surfaces the error after one retry", func(t *testing.T) {
+		archive, destDir := setup(t)
+		downloads := 0
+		download := func(_ context.Context, u, destPath string) error {
+			downloads++
+			return os.WriteFile(destPath, []byte("still truncated"), 0644)
+		}
+
+		err := extractOrRedownload(context.Background(), url, archive, destDir, extract, download)
+		require.Error(t, err)
+		assert.Equal(t, 1, downloads)
+		assert.Len(t, QuarantinedDownloads(), 2)
+	})
+
+	t.Run("failed download", func(t *testing.T) {
+		archive, destDir := setup(t)
+		err := extractOrRedownload(context.Background(), url, archive, destDir, extract, func(context.Context, string, string) error { return errors.New("HTTP error: 503") })
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unexpected end of archive, downloading it again failed: HTTP error: 503")
+	})
+}
+
+func TestGitHubInstallRedownloadsCorruptCachedAsset(t *testing.T) {
+	target := DetectRegistryTarget()
+	const assetURL = "https://github.com/owner/tool/releases/download/v1.0.0/tool.tar.gz"
+	requested, _ := stubGitHubTagInstall(t, `[
+		{"name": "tool", "version": "v1.0.0", "source": {"id": "github:owner/tool", "asset": [{"target": "`+target+`", "file": "tool.tar.gz"}]},
+		 "bin": {"tool": "tool"}}
+	]`, map[string][]byte{
+		assetURL: tarGz(t, map[string]string{"tool": "#!/bin/sh\n"}),
+	})
+	require.NoError(t, os.WriteFile(files.GetAssetCacheFilePath(assetURL), []byte("not a tarball"), 0644))
+
+	p := NewProviderGitHub()
+	require.True(t, p.Install(context.Background(), "github:owner/tool", "v1.0.0"))
+	// The corrupt copy comes from the cache, the asset is downloaded once,
+	// checksum sidecars are looked for both times
+	assert.Equal(t, []string{assetURL + ".sha256", assetURL + ".sha512", assetURL, assetURL + ".sha256", assetURL + ".sha512"}, *requested)
+	assert.FileExists(t, filepath.Join(p.getRepoPath("owner/tool"), "tool"))
+	require.Len(t, QuarantinedDownloads(), 1)
+}
@@ -0,0 +1,188 @@
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+)
+
+// Injectable helpers for tests
+var readmeHTTPGet = http.Get
+
+// ErrReadmeNotFound is returned when neither the installed content nor
+// the upstream project has a README
+var ErrReadmeNotFound = errors.New("no README found")
+
+// readmeNames are the file names recognised as a README, in order of preference
+var readmeNames = []string{"readme.md", "readme.markdown", "readme", "readme.txt", "readme.rst"}
+
+// PackageReadme returns the README of a package and where it was read from.
+// The installed content is searched first (node_modules/<pkg> for npm, the
+// dist-info METADATA of Python packages, the release or clone directory of
+// git hosted packages); otherwise the README is fetched from upstream.
+func PackageReadme(sourceID string) (content, origin string, err error) {
+	provider, pkg := packageid.Split(sourceID)
+	if content, path, ok := installedReadme(provider, pkg, sourceID); ok {
+		return content, path, nil
+	}
+	readmeURL := upstreamReadmeURL(provider, pkg)
+	if readmeURL == "" {
+		return "", "", ErrReadmeNotFound
+	}
+	content, err = fetchUpstreamReadme(provider, readmeURL)
+	if err != nil {
+		return "", "", err
+	}
+	return content, readmeURL, nil
+}
+
+func installedReadme(provider, pkg, sourceID string) (string, string, bool) {
+	packagesDir := filepath.Join(packagesPathFn(), provider)
+	switch provider {
+	case "npm":
+		return readmeInDir(filepath.Join(packagesDir, "node_modules", filepath.FromSlash(pkg)))
+	case "pypi":
+		return pythonMetadataReadme(packagesDir, pkg)
+	case "github", "gitlab", "codeberg", "gitea":
+		if content, path, ok := readmeInDir(filepath.Join(packagesDir, strings.ReplaceAll(pkg, "/", "_"))); ok {
+			return content, path, true
+		}
+		return readmeInDir(DataPackagePath(sourceID))
+	}
+	return "", "", false
+}
+
+// readmeInDir reads the README directly inside dir
+func readmeInDir(dir string) (string, string, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", "", false
+	}
+	found := map[string]string{}
+	for _, e := range entries {
+		if !e.IsDir() {
+			found[strings.ToLower(e.Name())] = e.Name()
+		}
+	}
+	for _, name := range readmeNames {
+		if actual, ok := found[name]; ok {
+			path := filepath.Join(dir, actual)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", "", false
+			}
+			return string(data), path, true
+		}
+	}
+	return "", "", false
+}
+
+// pythonMetadataReadme reads the long description pip stored as the body
+// of <name>-<version>.dist-info/METADATA in any of the site-packages directories
+func pythonMetadataReadme(packagesDir, pkg string) (string, string, bool) {
+	matches, _ := filepath.Glob(filepath.Join(packagesDir, "lib", "python*", "site-packages", "*.dist-info", "METADATA"))
+	sort.Sort(sort.Reverse(sort.StringSlice(matches))) // newest Python first
+	want := normalizePythonName(pkg)
+	for _, path := range matches {
+		distInfo := filepath.Base(filepath.Dir(path))
+		name, _, _ := strings.Cut(strings.TrimSuffix(distInfo, ".dist-info"), "-")
+		if normalizePythonName(name) != want {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		// The description follows the headers, after the first blank line
+		text := strings.ReplaceAll(string(data), "\r\n", "\n")
+		if _, body, ok := strings.Cut(text, "\n\n"); ok && strings.TrimSpace(body) != "" {
+			return body, path, true
+		}
+	}
+	return "", "", false
+}
+
+// normalizePythonName normalises a distribution name the way PEP 503 does
+func normalizePythonName(name string) string {
+	name = strings.ToLower(name)
+	return strings.NewReplacer("-", "_", ".", "_").Replace(name)
+}
+
+// upstreamReadmeURL returns where the README of a package is fetched from
+// when it is not installed, or "" for providers without a known location
+func upstreamReadmeURL(provider, pkg string) string {
+	switch provider {
+	case "github":
+		return fmt.Sprintf("https://raw.githubusercontent.com/%s/HEAD/README.md", pkg)
+	case "gitlab":
+		return fmt.Sprintf("https://gitlab.com/%s/-/raw/HEAD/README.md", pkg)
+	case "codeberg", "gitea":
+		// The Gitea API serves files from the default branch
+		f, repo, ok := forgeFor(provider, pkg)
+		if !ok {
+			return ""
+		}
+		return f.apiURL(repo, "/raw/README.md")
+	case "npm":
+		return "https://registry.npmjs.org/" + strings.Replace(pkg, "/", "%2F", 1)
+	case "pypi":
+		return fmt.Sprintf("https://pypi.org/pypi/%s/json", url.PathEscape(pkg))
+	}
+	return ""
+}
+
+// fetchUpstreamReadme downloads the README; npm and PyPI embed it in their package metadata
+func fetchUpstreamReadme(provider, readmeURL string) (string, error) {
+	resp, err := readmeHTTPGet(readmeURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch README: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", ErrReadmeNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch README: %s returned status %d", readmeURL, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch README: %w", err)
+	}
+
+	var content string
+	switch provider {
+	case "npm":
+		var meta struct {
+			Readme string `json:"readme"`
+		}
+		if err := json.Unmarshal(body, &meta); err != nil {
+			return "", fmt.Errorf("failed to parse npm metadata: %w", err)
+		}
+		content = meta.Readme
+	case "pypi":
+		var meta struct {
+			Info struct {
+				Description string `json:"description"`
+			} `json:"info"`
+		}
+		if err := json.Unmarshal(body, &meta); err != nil {
+			return "", fmt.Errorf("failed to parse PyPI metadata: %w", err)
+		}
+		content = meta.Info.Description
+	default:
+		content = string(body)
+	}
+	if strings.TrimSpace(content) == "" {
+		return "", ErrReadmeNotFound
+	}
+	return content, nil
+}
@@ -0,0 +1,84 @@
+package providers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubReadmeHTTP(t *testing.T, responses map[string]string) *[]string {
+	t.Helper()
+	var requested []string
+	prev := readmeHTTPGet
+	t.Cleanup(func() { readmeHTTPGet = prev })
+	readmeHTTPGet = func(url string) (*http.Response, error) {
+		requested = append(requested, url)
+		body, ok := responses[url]
+		if !ok {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(bytes.NewReader(nil))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(body))}, nil
+	}
+	return &requested
+}
+
+func TestPackageReadmeFromInstalledContent(t *testing.T) {
+	_, packages := withStagingDirs(t)
+	requested := stubReadmeHTTP(t, nil)
+	writeArchiveTree(t, packages,
+		"npm/node_modules/@scope/tool/README.md",
+		"github/owner_tool/Readme.markdown",
+	)
+	metadata := filepath.Join(packages, "pypi", "lib", "python3.12", "site-packages", "Black_Tool-24.1.0.dist-info", "METADATA")
+	require.NoError(t, os.MkdirAll(filepath.Dir(metadata), 0755))
+	require.NoError(t, os.WriteFile(metadata, []byte("Metadata-Version: 2.1\nName: black-tool\n\n# Black\n"), 0644))
+
+	content, origin, err := PackageReadme("npm:@scope/tool")
+	require.NoError(t, err)
+	assert.Equal(t, "npm/node_modules/@scope/tool/README.md", content)
+	assert.Equal(t, filepath.Join(packages, "npm", "node_modules", "@scope", "tool", "README.md"), origin)
+
+	content, _, err = PackageReadme("github:owner/tool")
+	require.NoError(t, err)
+	assert.Equal(t, "github/owner_tool/Readme.markdown", content)
+
+	content, origin, err = PackageReadme("pypi:black.tool")
+	require.NoError(t, err)
+	assert.Equal(t, "# Black\n", content)
+	assert.Equal(t, metadata, origin)
+
+	assert.Empty(t, *requested)
+}
+
+func TestPackageReadmeFetchesUpstream(t *testing.T) {
+	withStagingDirs(t)
+	stubReadmeHTTP(t, map[string]string{
+		"https://registry.npmjs.org/@scope%2Ftool":                    `{"readme": "# Tool"}`,
+		"https://pypi.org/pypi/black/json":                            `{"info": {"description": "# Black"}}`,
+		"https://raw.githubusercontent.com/owner/tool/HEAD/README.md": "# GitHub tool",
+	})
+
+	content, origin, err := PackageReadme("npm:@scope/tool")
+	require.NoError(t, err)
+	assert.Equal(t, "# Tool", content)
+	assert.Equal(t, "https://registry.npmjs.org/@scope%2Ftool", origin)
+
+	content, _, err = PackageReadme("pypi:black")
+	require.NoError(t, err)
+	assert.Equal(t, "# Black", content)
+
+	content, _, err = PackageReadme("github:owner/tool")
+	require.NoError(t, err)
+	assert.Equal(t, "# GitHub tool", content)
+
+	_, _, err = PackageReadme("gitlab:group/missing")
+	assert.ErrorIs(t, err, ErrReadmeNotFound)
+	_, _, err = PackageReadme("cargo:ripgrep")
+	assert.ErrorIs(t, err, ErrReadmeNotFound)
+}
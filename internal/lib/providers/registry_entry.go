@@ -0,0 +1,30 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// Injectable helpers for tests
+var registryEntryParser = registry_parser.NewDefaultRegistryParser
+var registryEntrySet = local_packages_parser.SetPackageRegistryEntry
+
+// recordRegistryEntry stores the registry entry a package was just installed
+// or updated from in the lock file. Failures are logged only, the install
+// itself has succeeded.
+func recordRegistryEntry(sourceID string) {
+	item := registryEntryParser().GetBySourceId(sourceID)
+	if item.Source.ID == "" {
+		return
+	}
+	entry, err := json.Marshal(item)
+	if err == nil {
+		err = registryEntrySet(sourceID, entry)
+	}
+	if err != nil {
+		Logger.Info(fmt.Sprintf("Registry: Warning recording the registry entry of %s: %v", sourceID, err))
+	}
+}
@@ -0,0 +1,287 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// Severities of EntryFinding
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// indirections for testability
+var registryEntryClient = &http.Client{Timeout: 15 * time.Second}
+
+// EntryFinding is a problem VerifyRegistryEntry found in a registry entry
+type EntryFinding struct {
+	Severity string `json:"severity"`
+	// Target is the registry target the problem applies to, if it's specific to one
+	Target  string `json:"target,omitempty"`
+	Message string `json:"message"`
+}
+
+// EntryURL is a download URL a registry entry resolves to for some targets,
+// checked with a HEAD request
+type EntryURL struct {
+	Targets []string `json:"targets"`
+	URL     string   `json:"url"`
+	Status  int      `json:"status,omitempty"`
+	Size    int64    `json:"size,omitempty"`
+	Error   string   `json:"error,omitempty"`
+	// registrySize is the size the entry declares for the asset
+	registrySize int64
+}
+
+// EntryReport is the outcome of VerifyRegistryEntry
+type EntryReport struct {
+	SourceID string         `json:"source_id"`
+	Version  string         `json:"version"`
+	URLs     []EntryURL     `json:"urls"`
+	Findings []EntryFinding `json:"findings"`
+}
+
+// HasErrors reports whether any finding is an error
+func (r EntryReport) HasErrors() bool {
+	for _, f := range r.Findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *EntryReport) add(severity, target, format string, args ...interface{}) {
+	r.Findings = append(r.Findings, EntryFinding{Severity: severity, Target: target, Message: fmt.Sprintf(format, args...)})
+}
+
+// knownAssetTarget returns whether some platform looks up assets for target
+func knownAssetTarget(target string) bool {
+	if target == targetDarwinUniversal {
+		return true
+	}
+	osPart, rest, _ := strings.Cut(target, "_")
+	arch, libc, _ := strings.Cut(rest, "_")
+	switch osPart {
+	case "darwin", "linux", "win", "android", "freebsd":
+	default:
+		return false
+	}
+	switch arch {
+	case "x64", "x86", "arm64", "arm", "riscv64", "ppc64le", "s390x":
+	default:
+		return false
+	}
+	return libc == "" || osPart == "linux" && (libc == "gnu" || libc == "musl")
+}
+
+// targetList returns the targets of an asset or download
+func targetList(target interface{}) []string {
+	switch v := target.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		targets := []string{}
+		for _, t := range v {
+			if str, ok := t.(string); ok {
+				targets = append(targets, str)
+			}
+		}
+		return targets
+	}
+	return nil
+}
+
+// VerifyRegistryEntry resolves the release assets or downloads of item for
+// every target the way installs do (template expansion, URL construction,
+// bin paths) and checks the URLs with HEAD requests, without downloading
+// anything. version overrides the version of the entry.
+func VerifyRegistryEntry(item registry_parser.RegistryItem, version string) EntryReport {
+	sourceID := packageid.Normalize(item.Source.ID)
+	report := EntryReport{SourceID: sourceID, Version: version, URLs: []EntryURL{}, Findings: []EntryFinding{}}
+	provider, repo := packageid.Split(sourceID)
+	if !IsSupportedProvider(provider) {
+		report.add(SeverityError, "", "unsupported provider %q", provider)
+		return report
+	}
+	if report.Version == "" || report.Version == "latest" {
+		report.Version = item.Version
+	}
+	if len(item.Bin) == 0 && !item.IsDataOnly() {
+		report.add(SeverityWarning, "", "no bin entries, nothing will be linked into the bin directory")
+	}
+	for binName, binPath := range item.Bin {
+		if strings.TrimSpace(binName) == "" || strings.TrimSpace(binPath) == "" {
+			report.add(SeverityError, "", "bin %q maps to %q, both have to be set", binName, binPath)
+		}
+	}
+
+	urls := map[string]*EntryURL{}
+	addURL := func(target, u string, size int64) {
+		if entry, ok := urls[u]; ok {
+			entry.Targets = append(entry.Targets, target)
+			return
+		}
+		urls[u] = &EntryURL{Targets: []string{target}, URL: u, registrySize: size}
+	}
+	seen := map[string]bool{}
+	checkTarget := func(target string) bool {
+		if seen[target] {
+			report.add(SeverityWarning, target, "listed more than once, the first entry wins")
+			return false
+		}
+		seen[target] = true
+		if !knownAssetTarget(target) {
+			report.add(SeverityWarning, target, "no platform looks up this target")
+		}
+		return true
+	}
+
+	switch {
+	case provider == "generic":
+		if len(item.Source.Download) == 0 {
+			report.add(SeverityError, "", "generic packages need source.download entries")
+		}
+		for i, download := range item.Source.Download {
+			targets := targetList(download.Target)
+			if len(targets) == 0 {
+				report.add(SeverityError, "", "download %d has no target", i+1)
+			}
+			if len(download.Files) == 0 {
+				report.add(SeverityError, "", "download %d has no files", i+1)
+			}
+			for _, target := range targets {
+				if !checkTarget(target) {
+					continue
+				}
+				for fileName, u := range download.Files {
+					resolved := ResolveTemplate(u, report.Version)
+					if strings.Contains(resolved, "{{") {
+						report.add(SeverityError, target, "unresolved template in the URL of %s: %s", fileName, resolved)
+						continue
+					}
+					addURL(target, resolved, 0)
+				}
+				for binName, binTemplate := range item.Bin {
+					if strings.Contains(binTemplate, "{{source.download.bin}}") && download.Bin == "" {
+						report.add(SeverityError, target, "bin %s uses {{source.download.bin}}, but the download has no bin", binName)
+					}
+				}
+			}
+		}
+	case len(item.Source.Asset) > 0 && (provider == "github" || provider == "gitlab" || provider == "codeberg" || provider == "gitea"):
+		if report.Version == "" {
+			report.add(SeverityError, "", "no version to expand the asset templates with, set version in the entry or pass @version")
+			break
+		}
+		for i := range item.Source.Asset {
+			asset := &item.Source.Asset[i]
+			targets := targetList(asset.Target)
+			if len(targets) == 0 {
+				report.add(SeverityError, "", "asset %d has no target", i+1)
+			}
+			for _, target := range targets {
+				if !checkTarget(target) {
+					continue
+				}
+				fileName := ResolveTemplate(asset.File.String(), report.Version)
+				switch {
+				case fileName == "":
+					report.add(SeverityError, target, "the asset has no file")
+					continue
+				case strings.Contains(fileName, "{{"):
+					report.add(SeverityError, target, "unresolved template in file: %s", fileName)
+					continue
+				}
+				if pkg := item.Source.GitLabPackage; provider == "gitlab" && pkg != nil {
+					addURL(target, genericPackageURL(repo, pkg.PackageName(repo), report.Version, fileName), asset.Size)
+				} else {
+					addURL(target, releaseAssetURL(provider, repo, report.Version, fileName), asset.Size)
+				}
+				for binName, binTemplate := range item.Bin {
+					binPath := ResolveBinPath(binTemplate, asset, binName)
+					switch {
+					case binPath == "":
+						report.add(SeverityError, target, "bin %s resolves to nothing, check the asset's bin", binName)
+					case strings.Contains(binPath, "{{"):
+						report.add(SeverityError, target, "unresolved template in bin %s: %s", binName, binPath)
+					}
+				}
+			}
+		}
+		if !hasAssetForPlatform(item.Source.Asset) {
+			report.add(SeverityWarning, assetPlatformTarget(), "no asset for this platform")
+		}
+	}
+
+	for _, entry := range urls {
+		report.URLs = append(report.URLs, *entry)
+	}
+	sort.Slice(report.URLs, func(i, j int) bool { return report.URLs[i].URL < report.URLs[j].URL })
+	headEntryURLs(report.URLs)
+	for _, entry := range report.URLs {
+		target := strings.Join(entry.Targets, ", ")
+		switch {
+		case entry.Error != "":
+			report.add(SeverityWarning, target, "couldn't check %s: %s", entry.URL, entry.Error)
+		case entry.Status == http.StatusNotFound:
+			report.add(SeverityError, target, "%s doesn't exist (404)", entry.URL)
+		case entry.Status != http.StatusOK:
+			report.add(SeverityWarning, target, "%s returned status %d", entry.URL, entry.Status)
+		case entry.registrySize > 0 && entry.Size > 0 && entry.registrySize != entry.Size:
+			report.add(SeverityWarning, target, "the entry declares a size of %d bytes, %s has %d", entry.registrySize, entry.URL, entry.Size)
+		}
+	}
+	return report
+}
+
+// hasAssetForPlatform reports whether installs on this platform find one of
+// the assets, like matchingTargetIndex but without its Rosetta warning
+func hasAssetForPlatform(assets []registry_parser.RegistryItemSourceAsset) bool {
+	for _, candidate := range assetTargetCandidates(assetPlatformTarget()) {
+		for _, asset := range assets {
+			if MatchesTarget(asset.Target, candidate) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// headEntryURLs sends the HEAD requests of the URLs at once
+func headEntryURLs(urls []EntryURL) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, downloadEstimateWorkers)
+	for i := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			req, err := http.NewRequest(http.MethodHead, urls[i].URL, nil)
+			if err != nil {
+				urls[i].Error = err.Error()
+				return
+			}
+			resp, err := registryEntryClient.Do(req)
+			if err != nil {
+				urls[i].Error = err.Error()
+				return
+			}
+			_ = resp.Body.Close()
+			urls[i].Status = resp.StatusCode
+			if resp.ContentLength > 0 {
+				urls[i].Size = resp.ContentLength
+			}
+		}()
+	}
+	wg.Wait()
+}
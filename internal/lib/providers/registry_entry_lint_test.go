@@ -0,0 +1,136 @@
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRegistryEntryClient answers HEAD requests with the statuses of the
+// requested URLs, failing for URLs without one
+func stubRegistryEntryClient(t *testing.T, statuses map[string]int) {
+	t.Helper()
+	prev := registryEntryClient
+	t.Cleanup(func() { registryEntryClient = prev })
+	registryEntryClient = &http.Client{Transport: completionTransport(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, http.MethodHead, req.Method)
+		status, ok := statuses[req.URL.String()]
+		if !ok {
+			return nil, errors.New("offline")
+		}
+		return &http.Response{StatusCode: status, ContentLength: 100, Body: io.NopCloser(strings.NewReader("")), Header: http.Header{}}, nil
+	})}
+}
+
+func parseEntry(t *testing.T, entry string) registry_parser.RegistryItem {
+	t.Helper()
+	var item registry_parser.RegistryItem
+	require.NoError(t, json.Unmarshal([]byte(entry), &item))
+	return item
+}
+
+func findingMessages(report EntryReport, severity string) []string {
+	messages := []string{}
+	for _, f := range report.Findings {
+		if f.Severity == severity {
+			messages = append(messages, f.Target+": "+f.Message)
+		}
+	}
+	return messages
+}
+
+func TestVerifyRegistryEntryAssets(t *testing.T) {
+	target := DetectRegistryTarget()
+	stubRegistryEntryClient(t, map[string]int{
+		"https://github.com/owner/tool/releases/download/v1.2.0/tool-v1.2.0-" + target + ".tar.gz": http.StatusOK,
+		"https://github.com/owner/tool/releases/download/v1.2.0/tool-v1.2.0-other.tar.gz":          http.StatusNotFound,
+	})
+	item := parseEntry(t, fmt.Sprintf(`{
+		"name": "tool", "version": "v1.2.0", "bin": {"tool": "{{source.asset.bin}}"},
+		"source": {"id": "github:owner/tool", "asset": [
+			{"target": %q, "file": "tool-{{version}}-%s.tar.gz", "bin": "tool", "size": 100},
+			{"target": "haiku_x64", "file": "tool-{{version}}-other.tar.gz", "bin": "tool"},
+			{"target": "win_x64", "file": "tool-{{release}}.zip", "bin": "tool.exe"},
+			{"target": "linux_arm", "file": "tool-{{version}}-other.tar.gz"}
+		]}
+	}`, target, target))
+
+	report := VerifyRegistryEntry(item, "")
+
+	assert.Equal(t, "github:owner/tool", report.SourceID)
+	assert.Equal(t, "v1.2.0", report.Version)
+	assert.True(t, report.HasErrors())
+	require.Len(t, report.URLs, 2)
+	for _, u := range report.URLs {
+		if strings.HasSuffix(u.URL, "-other.tar.gz") {
+			assert.Equal(t, []string{"haiku_x64", "linux_arm"}, u.Targets)
+			assert.Equal(t, http.StatusNotFound, u.Status)
+		} else {
+			assert.Equal(t, []string{target}, u.Targets)
+			assert.Equal(t, http.StatusOK, u.Status)
+		}
+	}
+	errs := findingMessages(report, SeverityError)
+	assert.Contains(t, errs, "win_x64: unresolved template in file: tool-{{release}}.zip")
+	assert.Contains(t, errs, "linux_arm: bin tool resolves to nothing, check the asset's bin")
+	assert.Contains(t, errs, "haiku_x64, linux_arm: https://github.com/owner/tool/releases/download/v1.2.0/tool-v1.2.0-other.tar.gz doesn't exist (404)")
+	assert.Contains(t, findingMessages(report, SeverityWarning), "haiku_x64: no platform looks up this target")
+	assert.Len(t, errs, 3)
+}
+
+func TestVerifyRegistryEntryVersionAndSize(t *testing.T) {
+	target := DetectRegistryTarget()
+	stubRegistryEntryClient(t, map[string]int{
+		"https://github.com/owner/tool/releases/download/v2.0.0/tool.tar.gz": http.StatusOK,
+	})
+	item := parseEntry(t, fmt.Sprintf(`{
+		"name": "tool", "version": "v1.2.0", "bin": {"tool": "tool"},
+		"source": {"id": "github:owner/tool", "asset": [
+			{"target": %q, "file": "tool.tar.gz", "size": 5},
+			{"target": %q, "file": "tool.tar.gz"}
+		]}
+	}`, target, target))
+
+	report := VerifyRegistryEntry(item, "v2.0.0")
+
+	assert.Equal(t, "v2.0.0", report.Version)
+	assert.False(t, report.HasErrors())
+	assert.Equal(t, []string{
+		target + ": listed more than once, the first entry wins",
+		target + ": the entry declares a size of 5 bytes, https://github.com/owner/tool/releases/download/v2.0.0/tool.tar.gz has 100",
+	}, findingMessages(report, SeverityWarning))
+}
+
+func TestVerifyRegistryEntryGeneric(t *testing.T) {
+	stubRegistryEntryClient(t, map[string]int{"https://example.com/a-2.0.zip": http.StatusForbidden})
+	item := parseEntry(t, `{
+		"name": "gen", "version": "2.0", "bin": {"gen": "{{source.download.bin}}"},
+		"source": {"id": "generic:gen", "download": [
+			{"target": "linux_x64", "files": {"a.zip": "https://example.com/a-{{version}}.zip"}},
+			{"target": ["darwin_arm64"], "files": {"b.zip": "https://example.com/b-{{version}}.zip"}, "bin": "gen"}
+		]}
+	}`)
+
+	report := VerifyRegistryEntry(item, "")
+
+	require.Len(t, report.URLs, 2)
+	assert.Equal(t, []string{"linux_x64: bin gen uses {{source.download.bin}}, but the download has no bin"}, findingMessages(report, SeverityError))
+	warnings := findingMessages(report, SeverityWarning)
+	assert.Contains(t, warnings, "linux_x64: https://example.com/a-2.0.zip returned status 403")
+	assert.Contains(t, warnings, "darwin_arm64: couldn't check https://example.com/b-2.0.zip: Head \"https://example.com/b-2.0.zip\": offline")
+}
+
+func TestVerifyRegistryEntryUnsupported(t *testing.T) {
+	report := VerifyRegistryEntry(registry_parser.RegistryItem{Source: registry_parser.RegistryItemSource{ID: "nope:tool"}}, "")
+	assert.True(t, report.HasErrors())
+	assert.Equal(t, []string{`: unsupported provider "nope"`}, findingMessages(report, SeverityError))
+	assert.Empty(t, report.URLs)
+}
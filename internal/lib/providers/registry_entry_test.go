@@ -0,0 +1,36 @@
+package providers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordRegistryEntry(t *testing.T) {
+	origParser, origSet := registryEntryParser, registryEntrySet
+	t.Cleanup(func() { registryEntryParser, registryEntrySet = origParser, origSet })
+	registryParser := registry_parser.NewRegistryParser(nil)
+	require.NoError(t, registryParser.LoadFromBytes([]byte(`[
+		{"name": "tool", "version": "v1.0.0", "source": {"id": "github:owner/tool", "asset": [{"target": "linux_x64", "file": "tool.tar.gz"}]}, "bin": {"tool": "tool"}}
+	]`)))
+	registryEntryParser = func() *registry_parser.RegistryParser { return registryParser }
+	recorded := map[string]json.RawMessage{}
+	registryEntrySet = func(sourceID string, entry json.RawMessage) error {
+		recorded[sourceID] = entry
+		return nil
+	}
+
+	recordRegistryEntry("github:owner/tool")
+	recordRegistryEntry("github:owner/unknown")
+
+	require.Contains(t, recorded, "github:owner/tool")
+	assert.NotContains(t, recorded, "github:owner/unknown")
+	var item registry_parser.RegistryItem
+	require.NoError(t, json.Unmarshal(recorded["github:owner/tool"], &item))
+	assert.Equal(t, "v1.0.0", item.Version)
+	assert.Equal(t, map[string]string{"tool": "tool"}, item.Bin)
+	assert.Equal(t, "tool.tar.gz", item.Source.Asset[0].File.String())
+}
@@ -0,0 +1,135 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+)
+
+// Injectable helpers for tests
+var remoteVersionLookup = lookupRemoteVersion
+var remoteVersionCachePath = func() string {
+	return filepath.Join(files.GetCachePath(), "remote-versions.json")
+}
+
+// RemoteVersionOptions configures LatestRemoteVersions
+type RemoteVersionOptions struct {
+	// Concurrency limits how many provider queries run at the same time
+	Concurrency int
+	// MaxAge is how long a cached version is reused; 0 always queries the provider
+	MaxAge time.Duration
+}
+
+// RemoteVersion is the latest version a provider reports for a package
+type RemoteVersion struct {
+	SourceID string `json:"source_id"`
+	Version  string `json:"version,omitempty"`
+	Cached   bool   `json:"cached"`
+	Error    string `json:"error,omitempty"`
+}
+
+type remoteVersionCacheEntry struct {
+	Version   string    `json:"version"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// lookupRemoteVersion asks the provider itself for the latest version of
+// sourceID (npm view, pip index versions, cargo search, GitHub releases, ...),
+// bypassing the registry.
+func lookupRemoteVersion(sourceID string) (string, error) {
+	provider := detectProvider(sourceID)
+	_, packageName := packageid.Split(packageid.Normalize(sourceID))
+	pkgManager := packageManagerFor(provider)
+	if pkgManager == nil || packageName == "" {
+		return "", fmt.Errorf("provider of %s can't be queried for versions", sourceID)
+	}
+	version, err := pkgManager.getLatestVersion(context.Background(), packageName)
+	if err != nil {
+		return "", err
+	}
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return "", fmt.Errorf("no version reported for %s", sourceID)
+	}
+	return version, nil
+}
+
+func readRemoteVersionCache() map[string]remoteVersionCacheEntry {
+	cache := map[string]remoteVersionCacheEntry{}
+	data, err := os.ReadFile(remoteVersionCachePath())
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		Logger.Info(fmt.Sprintf("Remote versions: Ignoring unreadable cache: %v", err))
+		return map[string]remoteVersionCacheEntry{}
+	}
+	return cache
+}
+
+func writeRemoteVersionCache(cache map[string]remoteVersionCacheEntry) {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err == nil {
+		err = os.WriteFile(remoteVersionCachePath(), data, 0644)
+	}
+	if err != nil {
+		Logger.Info(fmt.Sprintf("Remote versions: Warning writing cache: %v", err))
+	}
+}
+
+// LatestRemoteVersions queries the providers of sourceIDs for their latest
+// versions, at most opts.Concurrency at a time. Versions queried less than
+// opts.MaxAge ago are taken from the cache. Results are in the order of sourceIDs.
+func LatestRemoteVersions(sourceIDs []string, opts RemoteVersionOptions) []RemoteVersion {
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	cache := readRemoteVersionCache()
+	now := clock.Now()
+
+	results := make([]RemoteVersion, len(sourceIDs))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, sourceID := range sourceIDs {
+		results[i].SourceID = sourceID
+		if entry, ok := cache[sourceID]; ok && opts.MaxAge > 0 && now.Sub(entry.CheckedAt) < opts.MaxAge {
+			results[i].Version = entry.Version
+			results[i].Cached = true
+			continue
+		}
+		wg.Add(1)
+		go func(i int, sourceID string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			version, err := remoteVersionLookup(sourceID)
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Version = version
+		}(i, sourceID)
+	}
+	wg.Wait()
+
+	// The cache is updated only once the lookups are done, since the loop
+	// above reads it while they run
+	for _, result := range results {
+		if !result.Cached && result.Error == "" {
+			cache[result.SourceID] = remoteVersionCacheEntry{Version: result.Version, CheckedAt: now}
+		}
+	}
+
+	writeRemoteVersionCache(cache)
+	return results
+}
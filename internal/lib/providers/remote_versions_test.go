@@ -0,0 +1,95 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func stubRemoteVersions(t *testing.T, lookup func(string) (string, error)) {
+	t.Helper()
+	cachePath := filepath.Join(t.TempDir(), "remote-versions.json")
+	origLookup, origPath := remoteVersionLookup, remoteVersionCachePath
+	remoteVersionLookup = lookup
+	remoteVersionCachePath = func() string { return cachePath }
+	t.Cleanup(clock.Set(clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))))
+	t.Cleanup(func() { remoteVersionLookup, remoteVersionCachePath = origLookup, origPath })
+}
+
+func TestLatestRemoteVersions(t *testing.T) {
+	t.Run("queries providers and keeps the order", func(t *testing.T) {
+		stubRemoteVersions(t, func(id string) (string, error) {
+			if id == "npm:broken" {
+				return "", errors.New("npm view failed")
+			}
+			return "2.0.0", nil
+		})
+		results := LatestRemoteVersions([]string{"npm:a", "npm:broken", "pypi:b"}, RemoteVersionOptions{Concurrency: 2, MaxAge: time.Hour})
+		assert.Equal(t, []RemoteVersion{
+			{SourceID: "npm:a", Version: "2.0.0"},
+			{SourceID: "npm:broken", Error: "npm view failed"},
+			{SourceID: "pypi:b", Version: "2.0.0"},
+		}, results)
+	})
+
+	t.Run("reuses cached versions within max age", func(t *testing.T) {
+		var calls atomic.Int32
+		stubRemoteVersions(t, func(string) (string, error) {
+			calls.Add(1)
+			return "1.0.0", nil
+		})
+		LatestRemoteVersions([]string{"npm:a"}, RemoteVersionOptions{Concurrency: 1, MaxAge: time.Hour})
+		results := LatestRemoteVersions([]string{"npm:a"}, RemoteVersionOptions{Concurrency: 1, MaxAge: time.Hour})
+		assert.Equal(t, int32(1), calls.Load())
+		assert.True(t, results[0].Cached)
+		assert.Equal(t, "1.0.0", results[0].Version)
+
+		LatestRemoteVersions([]string{"npm:a"}, RemoteVersionOptions{Concurrency: 1})
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("limits concurrency", func(t *testing.T) {
+		var mu sync.Mutex
+		running, maxRunning := 0, 0
+		stubRemoteVersions(t, func(string) (string, error) {
+			mu.Lock()
+			running++
+			maxRunning = max(maxRunning, running)
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return "1.0.0", nil
+		})
+		LatestRemoteVersions([]string{"npm:a", "npm:b", "npm:c", "npm:d", "npm:e"}, RemoteVersionOptions{Concurrency: 2})
+		assert.LessOrEqual(t, maxRunning, 2)
+	})
+}
+
+func TestLookupRemoteVersion(t *testing.T) {
+	mockFactory := &MockProviderFactory{
+		MockNPMProvider: &MockPackageManager{
+			GetLatestVersionFunc: func(_ context.Context, name string) (string, error) {
+				assert.Equal(t, "prettier", name)
+				return " 3.3.3\n", nil
+			},
+		},
+	}
+	SetProviderFactory(mockFactory)
+	defer ResetProviderFactory()
+
+	version, err := lookupRemoteVersion("npm:prettier")
+	assert.NoError(t, err)
+	assert.Equal(t, "3.3.3", version)
+
+	_, err = lookupRemoteVersion("generic:tool")
+	assert.Error(t, err)
+}
@@ -0,0 +1,55 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// reportedVersionPattern matches version numbers in --version output,
+// e.g. 0.6.0 in "ruff 0.6.0" or 1.2.3-rc.1 in "tool v1.2.3-rc.1 (abc123)"
+var reportedVersionPattern = regexp.MustCompile(`v?(\d+(?:\.\d+)+(?:[-+][0-9A-Za-z.-]+)?)`)
+
+// VersionCheck compares the versions a package reported before and after an
+// update with the version it was updated to
+type VersionCheck struct {
+	SourceID string `json:"source_id"`
+	Before   string `json:"before"`
+	After    string `json:"after"`
+	Expected string `json:"expected"`
+}
+
+// Stale reports whether the package still reports its old version after an
+// update to another one, or stopped reporting one, e.g. because its bin
+// entry still points at the old binary
+func (c VersionCheck) Stale() bool {
+	if c.Before == "" {
+		return false
+	}
+	if c.After == "" {
+		return true
+	}
+	return c.After == c.Before && strings.TrimPrefix(c.Expected, "v") != c.Before
+}
+
+// ReportedVersion runs the smoke test command the registry declares for
+// sourceID (e.g. ruff --version) the way smoke tests run, and returns the
+// version number in its output. It returns false for packages without a
+// smoke test, when the command fails or prints no version.
+func ReportedVersion(sourceID string) (string, bool) {
+	command := strings.TrimSpace(smokeTestRegistryParser().GetBySourceId(sourceID).SmokeTest)
+	if command == "" {
+		return "", false
+	}
+	output, err := runSmokeTestCapture(context.Background(), sourceID, command)
+	if err != nil {
+		Logger.Info(fmt.Sprintf("Version check: %s failed %q: %v", sourceID, command, err))
+		return "", false
+	}
+	m := reportedVersionPattern.FindStringSubmatch(output)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
@@ -0,0 +1,48 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportedVersion(t *testing.T) {
+	stubSmokeTest(t, false, "1.1.0")
+	output, code := "pyright 1.1.380\n", 0
+	smokeTestShellOut = func(_ context.Context, command string, args []string, dir string, env []string) (int, string, error) {
+		if code != 0 {
+			return code, output, errors.New("exit status 1")
+		}
+		return code, output, nil
+	}
+
+	version, ok := ReportedVersion("npm:pyright")
+	assert.True(t, ok, "smoke tests don't have to be enabled")
+	assert.Equal(t, "1.1.380", version)
+
+	output = "tool v2.0.0-rc.1 (abc1234)"
+	version, _ = ReportedVersion("npm:pyright")
+	assert.Equal(t, "2.0.0-rc.1", version)
+
+	output = "usage: pyright [options]"
+	_, ok = ReportedVersion("npm:pyright")
+	assert.False(t, ok, "no version in the output")
+
+	output, code = "1.1.380", 1
+	_, ok = ReportedVersion("npm:pyright")
+	assert.False(t, ok, "the command failed")
+
+	_, ok = ReportedVersion("npm:prettier")
+	assert.False(t, ok, "no smoke test")
+}
+
+func TestVersionCheckStale(t *testing.T) {
+	assert.False(t, VersionCheck{Before: "1.0.0", After: "1.1.0", Expected: "1.1.0"}.Stale())
+	assert.False(t, VersionCheck{Before: "1.0.0", After: "1.1.0", Expected: "v1.1.0"}.Stale())
+	assert.True(t, VersionCheck{Before: "1.0.0", After: "1.0.0", Expected: "v1.1.0"}.Stale())
+	assert.True(t, VersionCheck{Before: "1.0.0", After: "", Expected: "1.1.0"}.Stale())
+	assert.False(t, VersionCheck{Before: "1.0.0", After: "1.0.0", Expected: "1.0.0"}.Stale(), "reinstalls keep their version")
+	assert.False(t, VersionCheck{After: "1.1.0", Expected: "1.1.0"}.Stale())
+}
@@ -1,11 +1,19 @@
 package providers
 
 import (
+	"context"
+	"fmt"
 	"strings"
 
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/events"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
 	"github.com/mistweaverco/zana-client/internal/lib/log"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
-	"github.com/mistweaverco/zana-client/internal/lib/semver"
+	"github.com/mistweaverco/zana-client/internal/lib/trace"
+	"github.com/mistweaverco/zana-client/internal/lib/versioncmp"
 )
 
 type Provider int
@@ -25,6 +33,9 @@ const (
 	ProviderOpam
 	ProviderOpenVSX
 	ProviderGeneric
+	ProviderLocal
+	ProviderTreeSitter
+	ProviderGitea
 	ProviderUnsupported
 )
 
@@ -72,6 +83,10 @@ func getCodebergProvider() PackageManager {
 	return globalFactory.CreateCodebergProvider()
 }
 
+func getGiteaProvider() PackageManager {
+	return globalFactory.CreateGiteaProvider()
+}
+
 func getGemProvider() PackageManager {
 	return globalFactory.CreateGemProvider()
 }
@@ -100,6 +115,14 @@ func getGenericProvider() PackageManager {
 	return globalFactory.CreateGenericProvider()
 }
 
+func getLocalProvider() PackageManager {
+	return globalFactory.CreateLocalProvider()
+}
+
+func getTreeSitterProvider() PackageManager {
+	return globalFactory.CreateTreeSitterProvider()
+}
+
 // AvailableProviders lists all provider names supported by Zana
 var AvailableProviders = []string{
 	"npm",
@@ -109,6 +132,7 @@ var AvailableProviders = []string{
 	"github",
 	"gitlab",
 	"codeberg",
+	"gitea",
 	"gem",
 	"composer",
 	"luarocks",
@@ -116,6 +140,8 @@ var AvailableProviders = []string{
 	"opam",
 	"openvsx",
 	"generic",
+	"local",
+	"treesitter",
 }
 
 // IsSupportedProvider returns true if the given provider name is supported
@@ -128,33 +154,8 @@ func IsSupportedProvider(name string) bool {
 	return false
 }
 
-// normalizePackageID converts a package ID from legacy format (pkg:provider/pkg)
-// to the new format (provider:pkg), or returns it unchanged if already in new format.
-func normalizePackageID(sourceID string) string {
-	if strings.HasPrefix(sourceID, "pkg:") {
-		rest := strings.TrimPrefix(sourceID, "pkg:")
-		parts := strings.SplitN(rest, "/", 2)
-		if len(parts) == 2 {
-			return parts[0] + ":" + parts[1]
-		}
-	}
-	return sourceID
-}
-
-// extractProviderAndPackage extracts provider and package name from a source ID.
-// Supports both legacy (pkg:provider/pkg) and new (provider:pkg) formats.
-func extractProviderAndPackage(sourceID string) (string, string) {
-	normalized := normalizePackageID(sourceID)
-	parts := strings.SplitN(normalized, ":", 2)
-	if len(parts) == 2 {
-		return parts[0], parts[1]
-	}
-	return "", ""
-}
-
 func detectProvider(sourceId string) Provider {
-	normalized := normalizePackageID(sourceId)
-	providerName, _ := extractProviderAndPackage(normalized)
+	providerName, _ := packageid.Split(sourceId)
 	if providerName == "" {
 		return ProviderUnsupported
 	}
@@ -174,6 +175,8 @@ func detectProvider(sourceId string) Provider {
 		return ProviderGitLab
 	case "codeberg":
 		return ProviderCodeberg
+	case "gitea":
+		return ProviderGitea
 	case "gem":
 		return ProviderGem
 	case "composer":
@@ -188,6 +191,10 @@ func detectProvider(sourceId string) Provider {
 		return ProviderOpenVSX
 	case "generic":
 		return ProviderGeneric
+	case "local":
+		return ProviderLocal
+	case "treesitter":
+		return ProviderTreeSitter
 	default:
 		return ProviderUnsupported
 	}
@@ -196,81 +203,133 @@ func detectProvider(sourceId string) Provider {
 // CheckIfUpdateIsAvailable checks if an update is available for a given package
 // and returns a boolean indicating if an update is available and the latest version number
 func CheckIfUpdateIsAvailable(localVersion string, remoteVersion string) (bool, string) {
-	if semver.IsGreater(localVersion, remoteVersion) {
+	if versioncmp.IsGreater(localVersion, remoteVersion) {
 		return true, remoteVersion
 	}
 	return false, ""
 }
 
+// syncInOperation runs the Sync of provider as an operation of its own, so
+// its subprocesses get the hardening and wrappers configured for it
+func syncInOperation(provider string, sync func(context.Context) bool) bool {
+	return sync(newOperation(context.Background(), actionSync, provider))
+}
+
 func syncAllProviders() {
 	npmProvider := getNPMProvider()
-	if npm, ok := npmProvider.(*NPMProvider); ok {
-		npm.Sync()
+	if npm, ok := npmProvider.(*NPMProvider); ok && !syncDisabled("npm") {
+		recordProviderSync("npm", syncInOperation("npm", npm.Sync))
 	}
 
 	pypiProvider := getPyPIProvider()
-	if pypi, ok := pypiProvider.(*PyPiProvider); ok {
-		pypi.Sync()
+	if pypi, ok := pypiProvider.(*PyPiProvider); ok && !syncDisabled("pypi") {
+		recordProviderSync("pypi", syncInOperation("pypi", pypi.Sync))
 	}
 
 	golangProvider := getGolangProvider()
-	if golang, ok := golangProvider.(*GolangProvider); ok {
-		golang.Sync()
+	if golang, ok := golangProvider.(*GolangProvider); ok && !syncDisabled("golang") {
+		recordProviderSync("golang", syncInOperation("golang", golang.Sync))
 	}
 
 	cargoProvider := getCargoProvider()
-	if cargo, ok := cargoProvider.(*CargoProvider); ok {
-		cargo.Sync()
+	if cargo, ok := cargoProvider.(*CargoProvider); ok && !syncDisabled("cargo") {
+		recordProviderSync("cargo", syncInOperation("cargo", cargo.Sync))
 	}
 
 	githubProvider := getGitHubProvider()
-	if github, ok := githubProvider.(*GitHubProvider); ok {
-		github.Sync()
+	if github, ok := githubProvider.(*GitHubProvider); ok && !syncDisabled("github") {
+		recordProviderSync("github", syncInOperation("github", github.Sync))
 	}
 
 	gitlabProvider := getGitLabProvider()
-	if gitlab, ok := gitlabProvider.(*GitLabProvider); ok {
-		gitlab.Sync()
+	if gitlab, ok := gitlabProvider.(*GitLabProvider); ok && !syncDisabled("gitlab") {
+		recordProviderSync("gitlab", syncInOperation("gitlab", gitlab.Sync))
 	}
 
 	codebergProvider := getCodebergProvider()
-	if codeberg, ok := codebergProvider.(*CodebergProvider); ok {
-		codeberg.Sync()
+	if codeberg, ok := codebergProvider.(*CodebergProvider); ok && !syncDisabled("codeberg") {
+		recordProviderSync("codeberg", syncInOperation("codeberg", codeberg.Sync))
+	}
+
+	giteaProvider := getGiteaProvider()
+	if gitea, ok := giteaProvider.(*GiteaProvider); ok && !syncDisabled("gitea") {
+		recordProviderSync("gitea", syncInOperation("gitea", gitea.Sync))
 	}
 
 	gemProvider := getGemProvider()
-	if gem, ok := gemProvider.(*GemProvider); ok {
-		gem.Sync()
+	if gem, ok := gemProvider.(*GemProvider); ok && !syncDisabled("gem") {
+		recordProviderSync("gem", syncInOperation("gem", gem.Sync))
 	}
 
 	composerProvider := getComposerProvider()
-	if composer, ok := composerProvider.(*ComposerProvider); ok {
-		composer.Sync()
+	if composer, ok := composerProvider.(*ComposerProvider); ok && !syncDisabled("composer") {
+		recordProviderSync("composer", syncInOperation("composer", composer.Sync))
 	}
 
 	luarocksProvider := getLuaRocksProvider()
-	if luarocks, ok := luarocksProvider.(*LuaRocksProvider); ok {
-		luarocks.Sync()
+	if luarocks, ok := luarocksProvider.(*LuaRocksProvider); ok && !syncDisabled("luarocks") {
+		recordProviderSync("luarocks", syncInOperation("luarocks", luarocks.Sync))
 	}
 
 	nugetProvider := getNuGetProvider()
-	if nuget, ok := nugetProvider.(*NuGetProvider); ok {
-		nuget.Sync()
+	if nuget, ok := nugetProvider.(*NuGetProvider); ok && !syncDisabled("nuget") {
+		recordProviderSync("nuget", syncInOperation("nuget", nuget.Sync))
 	}
 
 	opamProvider := getOpamProvider()
-	if opam, ok := opamProvider.(*OpamProvider); ok {
-		opam.Sync()
+	if opam, ok := opamProvider.(*OpamProvider); ok && !syncDisabled("opam") {
+		recordProviderSync("opam", syncInOperation("opam", opam.Sync))
 	}
 
 	openvsxProvider := getOpenVSXProvider()
-	if openvsx, ok := openvsxProvider.(*OpenVSXProvider); ok {
-		openvsx.Sync()
+	if openvsx, ok := openvsxProvider.(*OpenVSXProvider); ok && !syncDisabled("openvsx") {
+		recordProviderSync("openvsx", syncInOperation("openvsx", openvsx.Sync))
 	}
 
 	genericProvider := getGenericProvider()
-	if generic, ok := genericProvider.(*GenericProvider); ok {
-		generic.Sync()
+	if generic, ok := genericProvider.(*GenericProvider); ok && !syncDisabled("generic") {
+		recordProviderSync("generic", syncInOperation("generic", generic.Sync))
+	}
+}
+
+// packageManagerFor returns the package manager of provider, or nil for
+// providers that don't resolve versions themselves
+func packageManagerFor(provider Provider) PackageManager {
+	switch provider {
+	case ProviderNPM:
+		return getNPMProvider()
+	case ProviderPyPi:
+		return getPyPIProvider()
+	case ProviderGolang:
+		return getGolangProvider()
+	case ProviderCargo:
+		return getCargoProvider()
+	case ProviderGitHub:
+		return getGitHubProvider()
+	case ProviderGitLab:
+		return getGitLabProvider()
+	case ProviderCodeberg:
+		return getCodebergProvider()
+	case ProviderGitea:
+		return getGiteaProvider()
+	case ProviderGem:
+		return getGemProvider()
+	case ProviderComposer:
+		return getComposerProvider()
+	case ProviderLuaRocks:
+		return getLuaRocksProvider()
+	case ProviderNuGet:
+		return getNuGetProvider()
+	case ProviderOpam:
+		return getOpamProvider()
+	case ProviderOpenVSX:
+		return getOpenVSXProvider()
+	case ProviderLocal:
+		return getLocalProvider()
+	case ProviderTreeSitter:
+		return getTreeSitterProvider()
+	default:
+		return nil
 	}
 }
 
@@ -297,40 +356,12 @@ func ResolveVersion(sourceId string, version string) (string, error) {
 	}
 
 	provider := detectProvider(sourceId)
-	_, packageName := extractProviderAndPackage(normalizePackageID(sourceId))
+	_, packageName := packageid.Split(packageid.Normalize(sourceId))
 	if packageName == "" {
 		return version, nil
 	}
 
-	var pkgManager PackageManager
-	switch provider {
-	case ProviderNPM:
-		pkgManager = getNPMProvider()
-	case ProviderPyPi:
-		pkgManager = getPyPIProvider()
-	case ProviderGolang:
-		pkgManager = getGolangProvider()
-	case ProviderCargo:
-		pkgManager = getCargoProvider()
-	case ProviderGitHub:
-		pkgManager = getGitHubProvider()
-	case ProviderGitLab:
-		pkgManager = getGitLabProvider()
-	case ProviderCodeberg:
-		pkgManager = getCodebergProvider()
-	case ProviderGem:
-		pkgManager = getGemProvider()
-	case ProviderComposer:
-		pkgManager = getComposerProvider()
-	case ProviderLuaRocks:
-		pkgManager = getLuaRocksProvider()
-	case ProviderNuGet:
-		pkgManager = getNuGetProvider()
-	case ProviderOpam:
-		pkgManager = getOpamProvider()
-	case ProviderOpenVSX:
-		pkgManager = getOpenVSXProvider()
-	case ProviderGeneric:
+	if provider == ProviderGeneric {
 		// Generic provider gets version from registry
 		registry := registry_parser.NewDefaultRegistryParser()
 		registryItem := registry.GetBySourceId(sourceId)
@@ -338,14 +369,11 @@ func ResolveVersion(sourceId string, version string) (string, error) {
 			return registryItem.Version, nil
 		}
 		return "latest", nil
-	case ProviderUnsupported:
-		return version, nil
-	default:
-		return version, nil
 	}
 
+	pkgManager := packageManagerFor(provider)
 	if pkgManager != nil {
-		resolvedVersion, err := pkgManager.getLatestVersion(packageName)
+		resolvedVersion, err := latestVersionOf(context.Background(), provider, packageName, pkgManager.getLatestVersion)
 		if err != nil {
 			return version, err
 		}
@@ -355,111 +383,217 @@ func ResolveVersion(sourceId string, version string) (string, error) {
 	return version, nil
 }
 
+// Install installs a package and records the install in the history log
 func Install(sourceId string, version string) bool {
+	ctx := newOperation(context.Background(), files.HistoryActionInstall, sourceId)
+	span := trace.StartContext(ctx, files.HistoryActionInstall, "zana.package", sourceId)
+	start := clock.Now()
+	publishState(ctx, events.StateStarted)
+	previousVersion := local_packages_parser.GetBySourceId(sourceId).Version
+	ok := installWithProvider(ctx, sourceId, version)
+	if ok {
+		// Drop codes of failed attempts a fallback made up for
+		ConsumeFailureCode(sourceId)
+		ok = checkSmokeTest(ctx, sourceId, previousVersion)
+	}
+	pins := takeAssetPins(ctx)
+	if ok {
+		recordBinHashes(sourceId)
+		recordRegistryEntry(sourceId)
+		recordAssetPins(ctx, sourceId, pins)
+		refreshSchemaCatalog(sourceId)
+	}
+	span.EndOK(ok)
+	finishState(ctx, ok)
+	recordHistory(ctx, files.HistoryActionInstall, sourceId, version, start, ok)
+	return ok
+}
+
+func installWithProvider(ctx context.Context, sourceId string, version string) bool {
+	if err := checkPackageAllowed(sourceId); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Install: Not installing %s: %v", sourceId, err))
+		return false
+	}
 	provider := detectProvider(sourceId)
 	switch provider {
 	case ProviderNPM:
-		return getNPMProvider().Install(sourceId, version)
+		return getNPMProvider().Install(ctx, sourceId, version)
 	case ProviderPyPi:
-		return getPyPIProvider().Install(sourceId, version)
+		return getPyPIProvider().Install(ctx, sourceId, version)
 	case ProviderGolang:
-		return getGolangProvider().Install(sourceId, version)
+		return getGolangProvider().Install(ctx, sourceId, version)
 	case ProviderCargo:
-		return getCargoProvider().Install(sourceId, version)
+		return getCargoProvider().Install(ctx, sourceId, version)
 	case ProviderGitHub:
-		return getGitHubProvider().Install(sourceId, version)
+		return getGitHubProvider().Install(ctx, sourceId, version)
 	case ProviderGitLab:
-		return getGitLabProvider().Install(sourceId, version)
+		return getGitLabProvider().Install(ctx, sourceId, version)
 	case ProviderCodeberg:
-		return getCodebergProvider().Install(sourceId, version)
+		return getCodebergProvider().Install(ctx, sourceId, version)
+	case ProviderGitea:
+		return getGiteaProvider().Install(ctx, sourceId, version)
 	case ProviderGem:
-		return getGemProvider().Install(sourceId, version)
+		return getGemProvider().Install(ctx, sourceId, version)
 	case ProviderComposer:
-		return getComposerProvider().Install(sourceId, version)
+		return getComposerProvider().Install(ctx, sourceId, version)
 	case ProviderLuaRocks:
-		return getLuaRocksProvider().Install(sourceId, version)
+		return getLuaRocksProvider().Install(ctx, sourceId, version)
 	case ProviderNuGet:
-		return getNuGetProvider().Install(sourceId, version)
+		return getNuGetProvider().Install(ctx, sourceId, version)
 	case ProviderOpam:
-		return getOpamProvider().Install(sourceId, version)
+		return getOpamProvider().Install(ctx, sourceId, version)
 	case ProviderOpenVSX:
-		return getOpenVSXProvider().Install(sourceId, version)
+		return getOpenVSXProvider().Install(ctx, sourceId, version)
 	case ProviderGeneric:
-		return getGenericProvider().Install(sourceId, version)
+		return getGenericProvider().Install(ctx, sourceId, version)
+	case ProviderLocal:
+		return getLocalProvider().Install(ctx, sourceId, version)
+	case ProviderTreeSitter:
+		return getTreeSitterProvider().Install(ctx, sourceId, version)
 	case ProviderUnsupported:
 		// Unsupported provider
 	}
 	return false
 }
 
+// Remove removes a package and records the removal in the history log
 func Remove(sourceId string) bool {
+	ctx := newOperation(context.Background(), files.HistoryActionRemove, sourceId)
+	span := trace.StartContext(ctx, files.HistoryActionRemove, "zana.package", sourceId)
+	start := clock.Now()
+	publishState(ctx, events.StateStarted)
+	ok := removeWithProvider(ctx, sourceId)
+	if ok {
+		if err := removeDataPackage(sourceId); err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf("Remove: Error removing data of %s: %v", sourceId, err))
+			ok = false
+		}
+	}
+	if ok {
+		refreshSchemaCatalog(sourceId)
+	}
+	span.EndOK(ok)
+	finishState(ctx, ok)
+	recordHistory(ctx, files.HistoryActionRemove, sourceId, "", start, ok)
+	return ok
+}
+
+func removeWithProvider(ctx context.Context, sourceId string) bool {
 	provider := detectProvider(sourceId)
 	switch provider {
 	case ProviderNPM:
-		return getNPMProvider().Remove(sourceId)
+		return getNPMProvider().Remove(ctx, sourceId)
 	case ProviderPyPi:
-		return getPyPIProvider().Remove(sourceId)
+		return getPyPIProvider().Remove(ctx, sourceId)
 	case ProviderGolang:
-		return getGolangProvider().Remove(sourceId)
+		return getGolangProvider().Remove(ctx, sourceId)
 	case ProviderCargo:
-		return getCargoProvider().Remove(sourceId)
+		return getCargoProvider().Remove(ctx, sourceId)
 	case ProviderGitHub:
-		return getGitHubProvider().Remove(sourceId)
+		return getGitHubProvider().Remove(ctx, sourceId)
 	case ProviderGitLab:
-		return getGitLabProvider().Remove(sourceId)
+		return getGitLabProvider().Remove(ctx, sourceId)
 	case ProviderCodeberg:
-		return getCodebergProvider().Remove(sourceId)
+		return getCodebergProvider().Remove(ctx, sourceId)
+	case ProviderGitea:
+		return getGiteaProvider().Remove(ctx, sourceId)
 	case ProviderGem:
-		return getGemProvider().Remove(sourceId)
+		return getGemProvider().Remove(ctx, sourceId)
 	case ProviderComposer:
-		return getComposerProvider().Remove(sourceId)
+		return getComposerProvider().Remove(ctx, sourceId)
 	case ProviderLuaRocks:
-		return getLuaRocksProvider().Remove(sourceId)
+		return getLuaRocksProvider().Remove(ctx, sourceId)
 	case ProviderNuGet:
-		return getNuGetProvider().Remove(sourceId)
+		return getNuGetProvider().Remove(ctx, sourceId)
 	case ProviderOpam:
-		return getOpamProvider().Remove(sourceId)
+		return getOpamProvider().Remove(ctx, sourceId)
 	case ProviderOpenVSX:
-		return getOpenVSXProvider().Remove(sourceId)
+		return getOpenVSXProvider().Remove(ctx, sourceId)
 	case ProviderGeneric:
-		return getGenericProvider().Remove(sourceId)
+		return getGenericProvider().Remove(ctx, sourceId)
+	case ProviderLocal:
+		return getLocalProvider().Remove(ctx, sourceId)
+	case ProviderTreeSitter:
+		return getTreeSitterProvider().Remove(ctx, sourceId)
 	case ProviderUnsupported:
 		// Unsupported provider
 	}
 	return false
 }
 
-func Update(sourceId string) bool {
+// Update updates a package to its latest version and records the update in the history log.
+// It returns whether the update succeeded, the bytes freed by removing
+// older versions of the package and, for git packages whose existing clone
+// was updated, the commit range the clone moved across.
+func Update(sourceId string) (bool, int64, *GitCommitRange) {
+	ctx := newOperation(context.Background(), files.HistoryActionUpdate, sourceId)
+	span := trace.StartContext(ctx, files.HistoryActionUpdate, "zana.package", sourceId)
+	start := clock.Now()
+	publishState(ctx, events.StateStarted)
+	previousVersion := local_packages_parser.GetBySourceId(sourceId).Version
+	ok := updateWithProvider(ctx, sourceId)
+	if ok {
+		ok = checkSmokeTest(ctx, sourceId, previousVersion)
+	}
+	pins := takeAssetPins(ctx)
+	if ok {
+		recordBinHashes(sourceId)
+		recordRegistryEntry(sourceId)
+		recordAssetPins(ctx, sourceId, pins)
+		refreshSchemaCatalog(sourceId)
+	}
+	span.EndOK(ok)
+	finishState(ctx, ok)
+	var version string
+	if ok {
+		version = local_packages_parser.GetBySourceId(sourceId).Version
+	}
+	recordHistory(ctx, files.HistoryActionUpdate, sourceId, version, start, ok)
+	result := resultOf(ctx)
+	return ok, result.reclaimedBytes.Load(), result.commitRange.Load()
+}
+
+func updateWithProvider(ctx context.Context, sourceId string) bool {
+	if err := checkPackageAllowed(sourceId); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Update: Not updating %s: %v", sourceId, err))
+		return false
+	}
 	provider := detectProvider(sourceId)
 	switch provider {
 	case ProviderNPM:
-		return getNPMProvider().Update(sourceId)
+		return getNPMProvider().Update(ctx, sourceId)
 	case ProviderPyPi:
-		return getPyPIProvider().Update(sourceId)
+		return getPyPIProvider().Update(ctx, sourceId)
 	case ProviderGolang:
-		return getGolangProvider().Update(sourceId)
+		return getGolangProvider().Update(ctx, sourceId)
 	case ProviderCargo:
-		return getCargoProvider().Update(sourceId)
+		return getCargoProvider().Update(ctx, sourceId)
 	case ProviderGitHub:
-		return getGitHubProvider().Update(sourceId)
+		return getGitHubProvider().Update(ctx, sourceId)
 	case ProviderGitLab:
-		return getGitLabProvider().Update(sourceId)
+		return getGitLabProvider().Update(ctx, sourceId)
 	case ProviderCodeberg:
-		return getCodebergProvider().Update(sourceId)
+		return getCodebergProvider().Update(ctx, sourceId)
+	case ProviderGitea:
+		return getGiteaProvider().Update(ctx, sourceId)
 	case ProviderGem:
-		return getGemProvider().Update(sourceId)
+		return getGemProvider().Update(ctx, sourceId)
 	case ProviderComposer:
-		return getComposerProvider().Update(sourceId)
+		return getComposerProvider().Update(ctx, sourceId)
 	case ProviderLuaRocks:
-		return getLuaRocksProvider().Update(sourceId)
+		return getLuaRocksProvider().Update(ctx, sourceId)
 	case ProviderNuGet:
-		return getNuGetProvider().Update(sourceId)
+		return getNuGetProvider().Update(ctx, sourceId)
 	case ProviderOpam:
-		return getOpamProvider().Update(sourceId)
+		return getOpamProvider().Update(ctx, sourceId)
 	case ProviderOpenVSX:
-		return getOpenVSXProvider().Update(sourceId)
+		return getOpenVSXProvider().Update(ctx, sourceId)
 	case ProviderGeneric:
-		return getGenericProvider().Update(sourceId)
+		return getGenericProvider().Update(ctx, sourceId)
+	case ProviderLocal:
+		return getLocalProvider().Update(ctx, sourceId)
+	case ProviderTreeSitter:
+		return getTreeSitterProvider().Update(ctx, sourceId)
 	case ProviderUnsupported:
 		// Unsupported provider
 	}
@@ -1,11 +1,17 @@
 package providers
 
 import (
+	"context"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDetectProviderUnsupported(t *testing.T) {
@@ -22,8 +28,8 @@ func TestSyncAllInvokesProviderSyncs(t *testing.T) {
 	// Make Go available and Cargo available so their Sync methods run and return fast
 	oldGo := goShellOut
 	oldCargoHas := cargoHasCommand
-	goShellOut = func(string, []string, string, []string) (int, error) { return 0, nil }
-	cargoHasCommand = func(string, []string, []string) bool { return true }
+	goShellOut = func(context.Context, string, []string, string, []string) (int, error) { return 0, nil }
+	cargoHasCommand = func(context.Context, string, []string, []string) bool { return true }
 	defer func() { goShellOut = oldGo; cargoHasCommand = oldCargoHas }()
 
 	// Ensure base packages dir exists to avoid mkdir races in different providers
@@ -32,3 +38,70 @@ func TestSyncAllInvokesProviderSyncs(t *testing.T) {
 	// Call SyncAllFromLock; with empty desired sets, each provider's Sync should no-op/return quickly
 	_ = SyncAllFromLock()
 }
+
+// fakeTools puts shell scripts named after tools on PATH, each logging its
+// command line to the returned file. tools maps each name to the number of
+// its own arguments, after which it runs the rest of its command line like
+// ionice and nice do, or to -1 for tools running nothing.
+func fakeTools(t *testing.T, tools map[string]int) string {
+	t.Helper()
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "commands.log")
+	for tool, args := range tools {
+		script := "#!/bin/sh\necho \"" + tool + " $*\" >> \"$ZANA_TEST_COMMANDS\"\n"
+		if args >= 0 {
+			script += "shift " + strconv.Itoa(args) + "\nexec \"$@\"\n"
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, tool), []byte(script), 0755))
+	}
+	t.Setenv("PATH", dir)
+	t.Setenv("ZANA_TEST_COMMANDS", logPath)
+	return logPath
+}
+
+// commandLines returns the command lines logged by the tools of fakeTools
+func commandLines(t *testing.T, logPath string) []string {
+	t.Helper()
+	data, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestSyncAllFromLockHardensSubprocesses(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("ionice only exists on Linux")
+	}
+	_ = withTempZanaHome(t)
+	t.Setenv("ZANA_HARDENING", "golang")
+	commands := fakeTools(t, map[string]int{"ionice": 2, "nice": 2, "go": -1})
+	require.NoError(t, lppGoAdd("golang:github.com/x/y", "v1.0.0"))
+
+	_ = SyncAllFromLock()
+
+	lines := commandLines(t, commands)
+	assert.Contains(t, lines, "ionice -c 3 nice -n 10 go version")
+	assert.Contains(t, lines, "ionice -c 3 nice -n 10 go install github.com/x/y@v1.0.0")
+}
+
+func TestSyncAllFromLockWrapsSubprocesses(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake tools are shell scripts")
+	}
+	_ = withTempZanaHome(t)
+	require.NoError(t, os.WriteFile(filepath.Join(os.Getenv("ZANA_HOME"), "config.yaml"), []byte(`wrappers:
+  providers:
+    golang: [provider-wrapper]
+  packages:
+    golang:github.com/x/y: [package-wrapper, --quiet]
+`), 0644))
+	commands := fakeTools(t, map[string]int{"provider-wrapper": 0, "package-wrapper": 1, "go": -1})
+	require.NoError(t, lppGoAdd("golang:github.com/x/y", "v1.0.0"))
+	require.NoError(t, lppGoAdd("golang:github.com/x/z", "v2.0.0"))
+
+	_ = SyncAllFromLock()
+
+	lines := commandLines(t, commands)
+	assert.Contains(t, lines, "provider-wrapper go version")
+	assert.Contains(t, lines, "package-wrapper --quiet go install github.com/x/y@v1.0.0")
+	assert.Contains(t, lines, "provider-wrapper go install github.com/x/z@v2.0.0")
+}
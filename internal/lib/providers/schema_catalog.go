@@ -0,0 +1,150 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// Injectable helpers for tests
+var (
+	schemaCatalogRegistryParser = registry_parser.NewDefaultRegistryParser
+	schemaCatalogInstalled      = func() []local_packages_parser.LocalPackageItem {
+		return local_packages_parser.GetData(false).Packages
+	}
+)
+
+// schemaCatalogSchema is the JSON schema of schema catalogs, as used by SchemaStore
+const schemaCatalogSchema = "https://json.schemastore.org/schema-catalog.json"
+
+// SchemaCatalogEntry is a schema in the catalog of installed schema packages
+type SchemaCatalogEntry struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	FileMatch   []string `json:"fileMatch,omitempty"`
+	URL         string   `json:"url"`
+	// Package is the source ID of the package shipping the schema
+	Package string `json:"-"`
+	// Path is the schema file on disk
+	Path string `json:"-"`
+}
+
+type schemaCatalog struct {
+	Schema  string               `json:"$schema"`
+	Version int                  `json:"version"`
+	Schemas []SchemaCatalogEntry `json:"schemas"`
+}
+
+// SchemaCatalogPath returns where the schema catalog of the installed schema
+// packages is written, e.g. /home/user/.local/share/zana/share/schemas/catalog.json
+func SchemaCatalogPath() string {
+	return filepath.Join(dataSharePath(), "schemas", "catalog.json")
+}
+
+// InstalledSchemas lists the schemas of the installed KindSchemas packages,
+// the ones their registry entry declares or else all their .json files
+func InstalledSchemas() []SchemaCatalogEntry {
+	registry := schemaCatalogRegistryParser()
+	entries := []SchemaCatalogEntry{}
+	for _, pkg := range schemaCatalogInstalled() {
+		item := registry.GetBySourceId(pkg.SourceID)
+		if item.Kind != registry_parser.KindSchemas {
+			continue
+		}
+		dir := DataPackagePath(pkg.SourceID)
+		if len(item.Schemas) == 0 {
+			entries = append(entries, discoverSchemas(pkg.SourceID, dir)...)
+			continue
+		}
+		for _, schema := range item.Schemas {
+			path := filepath.Join(dir, filepath.FromSlash(schema.File))
+			if _, err := os.Stat(path); err != nil {
+				Logger.Warn(fmt.Sprintf("Schemas: %s has no schema %s", pkg.SourceID, schema.File))
+				continue
+			}
+			name := schema.Name
+			if name == "" {
+				name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			}
+			entries = append(entries, SchemaCatalogEntry{
+				Name:        name,
+				Description: schema.Description,
+				FileMatch:   schema.FileMatch,
+				URL:         fileURL(path),
+				Package:     pkg.SourceID,
+				Path:        path,
+			})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// discoverSchemas lists the .json files of a schema package without declared schemas
+func discoverSchemas(sourceID, dir string) []SchemaCatalogEntry {
+	entries := []SchemaCatalogEntry{}
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".json") {
+			return nil
+		}
+		entries = append(entries, SchemaCatalogEntry{
+			Name:    strings.TrimSuffix(d.Name(), filepath.Ext(d.Name())),
+			URL:     fileURL(path),
+			Package: sourceID,
+			Path:    path,
+		})
+		return nil
+	})
+	return entries
+}
+
+// fileURL returns the file:// URL of an absolute path
+func fileURL(path string) string {
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// WriteSchemaCatalog writes the catalog of the installed schemas to
+// SchemaCatalogPath, for editors to load schemas from
+func WriteSchemaCatalog() ([]SchemaCatalogEntry, error) {
+	entries := InstalledSchemas()
+	data, err := json.MarshalIndent(schemaCatalog{Schema: schemaCatalogSchema, Version: 1, Schemas: entries}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	path := SchemaCatalogPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// refreshSchemaCatalog rewrites the schema catalog after a schema package was
+// installed, updated or removed. Failures are logged only.
+func refreshSchemaCatalog(sourceID string) {
+	if schemaCatalogRegistryParser().GetBySourceId(sourceID).Kind != registry_parser.KindSchemas {
+		return
+	}
+	if _, err := WriteSchemaCatalog(); err != nil {
+		Logger.Info(fmt.Sprintf("Schemas: Warning writing the schema catalog: %v", err))
+	}
+}
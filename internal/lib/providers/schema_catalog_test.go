@@ -0,0 +1,105 @@
+package providers
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubSchemaCatalog(t *testing.T, registry string, installed ...string) string {
+	t.Helper()
+	share := stubDataSharePath(t)
+	prevParser, prevInstalled := schemaCatalogRegistryParser, schemaCatalogInstalled
+	t.Cleanup(func() { schemaCatalogRegistryParser, schemaCatalogInstalled = prevParser, prevInstalled })
+	reg := registry_parser.NewRegistryParser(nil)
+	require.NoError(t, reg.LoadFromBytes([]byte(registry)))
+	schemaCatalogRegistryParser = func() *registry_parser.RegistryParser { return reg }
+	schemaCatalogInstalled = func() []local_packages_parser.LocalPackageItem {
+		packages := []local_packages_parser.LocalPackageItem{}
+		for _, id := range installed {
+			packages = append(packages, local_packages_parser.LocalPackageItem{SourceID: id, Version: "v1.0.0"})
+		}
+		return packages
+	}
+	return share
+}
+
+func writeSchemaFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(`{"type": "object"}`), 0644))
+	}
+}
+
+func TestInstalledSchemas(t *testing.T) {
+	stubSchemaCatalog(t, `[
+		{"name": "ts-schemas", "kind": "schemas", "source": {"id": "github:owner/ts-schemas"},
+		 "schemas": [
+			{"file": "schemas/tsconfig.json", "description": "TypeScript config", "file_match": ["tsconfig*.json"]},
+			{"file": "schemas/missing.json"}
+		 ]},
+		{"name": "more-schemas", "kind": "schemas", "source": {"id": "github:owner/more-schemas"}},
+		{"name": "fonts", "kind": "data", "source": {"id": "github:owner/fonts"}}
+	]`, "github:owner/ts-schemas", "github:owner/more-schemas", "github:owner/fonts")
+	writeSchemaFiles(t, DataPackagePath("github:owner/ts-schemas"), "schemas/tsconfig.json", "schemas/undeclared.json")
+	writeSchemaFiles(t, DataPackagePath("github:owner/more-schemas"), "eslintrc.json", "nested/stylua.JSON", "README.md")
+	writeSchemaFiles(t, DataPackagePath("github:owner/fonts"), "font.json")
+
+	schemas := InstalledSchemas()
+	names := []string{}
+	for _, s := range schemas {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"eslintrc", "stylua", "tsconfig"}, names)
+
+	tsconfig := schemas[2]
+	path := filepath.Join(DataPackagePath("github:owner/ts-schemas"), "schemas", "tsconfig.json")
+	assert.Equal(t, SchemaCatalogEntry{
+		Name:        "tsconfig",
+		Description: "TypeScript config",
+		FileMatch:   []string{"tsconfig*.json"},
+		URL:         fileURL(path),
+		Package:     "github:owner/ts-schemas",
+		Path:        path,
+	}, tsconfig)
+	assert.Equal(t, "github:owner/more-schemas", schemas[0].Package)
+}
+
+func TestFileURL(t *testing.T) {
+	assert.Equal(t, "file:///home/user/schemas/a%20b.json", fileURL("/home/user/schemas/a b.json"))
+}
+
+func TestWriteSchemaCatalog(t *testing.T) {
+	share := stubSchemaCatalog(t, `[
+		{"name": "ts-schemas", "kind": "schemas", "source": {"id": "github:owner/ts-schemas"}}
+	]`, "github:owner/ts-schemas")
+	writeSchemaFiles(t, DataPackagePath("github:owner/ts-schemas"), "tsconfig.json")
+
+	entries, err := WriteSchemaCatalog()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, filepath.Join(share, "schemas", "catalog.json"), SchemaCatalogPath())
+
+	data, err := os.ReadFile(SchemaCatalogPath())
+	require.NoError(t, err)
+	var catalog map[string]any
+	require.NoError(t, json.Unmarshal(data, &catalog))
+	assert.Equal(t, schemaCatalogSchema, catalog["$schema"])
+	assert.Equal(t, float64(1), catalog["version"])
+	assert.Equal(t, []any{map[string]any{"name": "tsconfig", "url": entries[0].URL}}, catalog["schemas"])
+
+	// Only schema packages rewrite the catalog
+	require.NoError(t, os.Remove(SchemaCatalogPath()))
+	refreshSchemaCatalog("github:owner/other")
+	assert.NoFileExists(t, SchemaCatalogPath())
+	refreshSchemaCatalog("github:owner/ts-schemas")
+	assert.FileExists(t, SchemaCatalogPath())
+}
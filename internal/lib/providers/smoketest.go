@@ -0,0 +1,104 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
+
+// Injectable helpers for tests
+var (
+	smokeTestsEnabled       = files.SmokeTestsEnabled
+	smokeTestRegistryParser = registry_parser.NewDefaultRegistryParser
+	smokeTestGet            = local_packages_parser.GetBySourceId
+	smokeTestSet            = local_packages_parser.SetPackageSmokeTest
+	smokeTestShellOut       = shellOutCapture
+	smokeTestInstall        = installWithProvider
+	smokeTestRemove         = removeWithProvider
+)
+
+// checkSmokeTest runs the smoke test the registry entry of sourceID declares
+// after an install or update. When it fails, the package is rolled back to
+// previousVersion, or removed when it wasn't installed before.
+// It reports whether the package passed (or has no smoke test).
+func checkSmokeTest(ctx context.Context, sourceID, previousVersion string) bool {
+	if !smokeTestsEnabled() {
+		return true
+	}
+	command := strings.TrimSpace(smokeTestRegistryParser().GetBySourceId(sourceID).SmokeTest)
+	if command == "" {
+		return true
+	}
+
+	version := smokeTestGet(sourceID).Version
+	result := &local_packages_parser.SmokeTestResult{Command: command, Version: version}
+	if err := runSmokeTest(ctx, sourceID, command); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Smoke test: %s@%s failed %q: %v", sourceID, version, command, err))
+		rollBackSmokeTest(ctx, sourceID, previousVersion, version)
+		recordSmokeTest(ctx, sourceID, result)
+		return false
+	}
+	Logger.InfoContext(ctx, fmt.Sprintf("Smoke test: %s@%s passed %q", sourceID, version, command))
+	result.Passed = true
+	recordSmokeTest(ctx, sourceID, result)
+	return true
+}
+
+// runSmokeTest runs command in an empty temporary directory, preferring the
+// package's executables in the bin dir over other ones on PATH
+func runSmokeTest(ctx context.Context, sourceID, command string) error {
+	_, err := runSmokeTestCapture(ctx, sourceID, command)
+	return err
+}
+
+// runSmokeTestCapture is runSmokeTest returning the command's output
+func runSmokeTestCapture(ctx context.Context, sourceID, command string) (string, error) {
+	argv := strings.Fields(command)
+	provider, _ := packageid.Split(sourceID)
+	if path, err := exec.LookPath(filepath.Join(files.GetAppBinPathForProvider(provider), argv[0])); err == nil {
+		argv[0] = path
+	}
+
+	dir, err := os.MkdirTemp(tempPathFn(), "smoke-test-")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	code, output, err := smokeTestShellOut(ctx, argv[0], argv[1:], dir, nil)
+	if err != nil || code != 0 {
+		return output, fmt.Errorf("exit code %d: %s", code, strings.TrimSpace(output))
+	}
+	return output, nil
+}
+
+// rollBackSmokeTest restores the install from before a failed smoke test.
+// Reinstalls of the same version have nothing to roll back to.
+func rollBackSmokeTest(ctx context.Context, sourceID, previousVersion, version string) {
+	switch {
+	case previousVersion == "":
+		if !smokeTestRemove(ctx, sourceID) {
+			Logger.ErrorContext(ctx, fmt.Sprintf("Smoke test: Error removing %s", sourceID))
+		}
+	case previousVersion != version:
+		if !smokeTestInstall(ctx, sourceID, previousVersion) {
+			Logger.ErrorContext(ctx, fmt.Sprintf("Smoke test: Error rolling %s back to %s", sourceID, previousVersion))
+		}
+	}
+}
+
+// recordSmokeTest stores a smoke test result in the lock file. Removed
+// packages are not in the lock file anymore and are skipped.
+func recordSmokeTest(ctx context.Context, sourceID string, result *local_packages_parser.SmokeTestResult) {
+	if err := smokeTestSet(sourceID, result); err != nil {
+		Logger.InfoContext(ctx, fmt.Sprintf("Smoke test: Warning recording the result of %s: %v", sourceID, err))
+	}
+}
@@ -0,0 +1,114 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type smokeTestStub struct {
+	exitCode  int
+	ran       []string
+	installed []string
+	removed   []string
+	recorded  []*local_packages_parser.SmokeTestResult
+}
+
+func stubSmokeTest(t *testing.T, enabled bool, installedVersion string) *smokeTestStub {
+	t.Helper()
+	t.Setenv("ZANA_TMP", t.TempDir())
+	t.Setenv("ZANA_DATA", t.TempDir())
+	origEnabled, origParser, origGet, origSet := smokeTestsEnabled, smokeTestRegistryParser, smokeTestGet, smokeTestSet
+	origShellOut, origInstall, origRemove := smokeTestShellOut, smokeTestInstall, smokeTestRemove
+	t.Cleanup(func() {
+		smokeTestsEnabled, smokeTestRegistryParser, smokeTestGet, smokeTestSet = origEnabled, origParser, origGet, origSet
+		smokeTestShellOut, smokeTestInstall, smokeTestRemove = origShellOut, origInstall, origRemove
+	})
+
+	reg := registry_parser.NewRegistryParser(nil)
+	require.NoError(t, reg.LoadFromBytes([]byte(`[
+		{"name": "pyright", "version": "1.1.0", "source": {"id": "npm:pyright"}, "bin": {"pyright": "npm:pyright"}, "smoke_test": "pyright --version"},
+		{"name": "prettier", "version": "3.3.0", "source": {"id": "npm:prettier"}, "bin": {"prettier": "npm:prettier"}}
+	]`)))
+
+	stub := &smokeTestStub{}
+	smokeTestsEnabled = func() bool { return enabled }
+	smokeTestRegistryParser = func() *registry_parser.RegistryParser { return reg }
+	smokeTestGet = func(sourceID string) local_packages_parser.LocalPackageItem {
+		return local_packages_parser.LocalPackageItem{SourceID: sourceID, Version: installedVersion}
+	}
+	smokeTestSet = func(sourceID string, result *local_packages_parser.SmokeTestResult) error {
+		stub.recorded = append(stub.recorded, result)
+		return nil
+	}
+	smokeTestShellOut = func(_ context.Context, command string, args []string, dir string, env []string) (int, string, error) {
+		stub.ran = append(stub.ran, command)
+		if stub.exitCode != 0 {
+			return stub.exitCode, "boom", errors.New("exit status 1")
+		}
+		return 0, "pyright 1.1.0", nil
+	}
+	smokeTestInstall = func(_ context.Context, sourceID, version string) bool {
+		stub.installed = append(stub.installed, sourceID+"@"+version)
+		return true
+	}
+	smokeTestRemove = func(_ context.Context, sourceID string) bool {
+		stub.removed = append(stub.removed, sourceID)
+		return true
+	}
+	return stub
+}
+
+func TestCheckSmokeTest(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		stub := stubSmokeTest(t, false, "1.1.0")
+		assert.True(t, checkSmokeTest(context.Background(), "npm:pyright", ""))
+		assert.Empty(t, stub.ran)
+	})
+
+	t.Run("entries without a smoke test pass", func(t *testing.T) {
+		stub := stubSmokeTest(t, true, "3.3.0")
+		assert.True(t, checkSmokeTest(context.Background(), "npm:prettier", ""))
+		assert.Empty(t, stub.ran)
+		assert.Empty(t, stub.recorded)
+	})
+
+	t.Run("passing", func(t *testing.T) {
+		stub := stubSmokeTest(t, true, "1.1.0")
+		assert.True(t, checkSmokeTest(context.Background(), "npm:pyright", "1.0.0"))
+		assert.Len(t, stub.ran, 1)
+		assert.Equal(t, []*local_packages_parser.SmokeTestResult{{Command: "pyright --version", Version: "1.1.0", Passed: true}}, stub.recorded)
+		assert.Empty(t, stub.installed)
+		assert.Empty(t, stub.removed)
+	})
+
+	t.Run("failing update rolls back", func(t *testing.T) {
+		stub := stubSmokeTest(t, true, "1.1.0")
+		stub.exitCode = 1
+		assert.False(t, checkSmokeTest(context.Background(), "npm:pyright", "1.0.0"))
+		assert.Equal(t, []string{"npm:pyright@1.0.0"}, stub.installed)
+		assert.Empty(t, stub.removed)
+		assert.Equal(t, []*local_packages_parser.SmokeTestResult{{Command: "pyright --version", Version: "1.1.0", Passed: false}}, stub.recorded)
+	})
+
+	t.Run("failing fresh install is removed", func(t *testing.T) {
+		stub := stubSmokeTest(t, true, "1.1.0")
+		stub.exitCode = 1
+		assert.False(t, checkSmokeTest(context.Background(), "npm:pyright", ""))
+		assert.Equal(t, []string{"npm:pyright"}, stub.removed)
+		assert.Empty(t, stub.installed)
+	})
+
+	t.Run("failing reinstall keeps the version", func(t *testing.T) {
+		stub := stubSmokeTest(t, true, "1.1.0")
+		stub.exitCode = 1
+		assert.False(t, checkSmokeTest(context.Background(), "npm:pyright", "1.1.0"))
+		assert.Empty(t, stub.removed)
+		assert.Empty(t, stub.installed)
+	})
+}
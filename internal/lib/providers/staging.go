@@ -0,0 +1,98 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+)
+
+// StaleStagingAge is how old a staging directory has to be before it is
+// considered left behind by an interrupted install
+const StaleStagingAge = 24 * time.Hour
+
+// Injectable helpers for tests
+var (
+	stagingMkdirTemp = os.MkdirTemp
+	stagingPathFn    = files.GetAppStagingPath
+	packagesPathFn   = files.GetAppPackagesPath
+	tempPathFn       = files.GetAppTempPath
+)
+
+// legacyTempProviders are the providers that extracted into
+// packages/<provider>/<repo>_temp before the staging directory existed
+var legacyTempProviders = []string{"github", "gitlab", "codeberg"}
+
+// newStagingDir creates a fresh directory to download and extract a package in.
+// Concurrent installs of the same package get different directories, named
+// after the operation ctx carries, if any (see newOperation).
+//
+// Staging directories live next to the packages directory rather than in
+// files.GetTempPath, so the extracted package can be renamed into place.
+func newStagingDir(ctx context.Context, provider, repo string) (string, error) {
+	name := provider + "-" + strings.ReplaceAll(repo, "/", "_") + "-"
+	if op, ok := currentOperation(ctx); ok {
+		name += op.ID + "-"
+	}
+	return stagingMkdirTemp(stagingPathFn(), name)
+}
+
+// CleanStaleStaging removes staging and temporary directories, and the
+// <repo>_temp directories older zana versions extracted into, that were last
+// modified more than maxAge ago. It returns the removed paths.
+func CleanStaleStaging(maxAge time.Duration) []string {
+	var candidates []string
+	for _, dir := range []string{stagingPathFn(), tempPathFn()} {
+		if entries, err := os.ReadDir(dir); err == nil {
+			for _, e := range entries {
+				candidates = append(candidates, filepath.Join(dir, e.Name()))
+			}
+		}
+	}
+	for _, provider := range legacyTempProviders {
+		// GitHub kept owner/repo nested, so look one level deeper too
+		dir := filepath.Join(packagesPathFn(), provider)
+		candidates = append(candidates, legacyTempDirs(dir)...)
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			if e.IsDir() && !strings.HasSuffix(e.Name(), "_temp") {
+				candidates = append(candidates, legacyTempDirs(filepath.Join(dir, e.Name()))...)
+			}
+		}
+	}
+
+	cutoff := clock.Now().Add(-maxAge)
+	var removed []string
+	for _, path := range candidates {
+		info, err := os.Lstat(path)
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			Logger.Error(fmt.Sprintf("Staging: Error removing %s: %v", path, err))
+			continue
+		}
+		Logger.Info(fmt.Sprintf("Staging: Removed %s left behind by an interrupted install", path))
+		removed = append(removed, path)
+	}
+	return removed
+}
+
+func legacyTempDirs(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() && strings.HasSuffix(e.Name(), "_temp") {
+			dirs = append(dirs, filepath.Join(dir, e.Name()))
+		}
+	}
+	return dirs
+}
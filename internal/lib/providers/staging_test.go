@@ -0,0 +1,76 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withStagingDirs(t *testing.T) (staging, packages string) {
+	t.Helper()
+	staging, packages = t.TempDir(), t.TempDir()
+	temp := t.TempDir()
+	prevStaging, prevPackages, prevTemp := stagingPathFn, packagesPathFn, tempPathFn
+	t.Cleanup(func() { stagingPathFn, packagesPathFn, tempPathFn = prevStaging, prevPackages, prevTemp })
+	stagingPathFn = func() string { return staging }
+	packagesPathFn = func() string { return packages }
+	tempPathFn = func() string { return temp }
+	return staging, packages
+}
+
+func makeDirAged(t *testing.T, path string, age time.Duration) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(path, "extracted"), 0755))
+	old := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(path, old, old))
+}
+
+func TestNewStagingDirIsUnique(t *testing.T) {
+	staging, _ := withStagingDirs(t)
+
+	a, err := newStagingDir(context.Background(), "gitlab", "group/sub/tool")
+	require.NoError(t, err)
+	b, err := newStagingDir(context.Background(), "gitlab", "group/sub/tool")
+	require.NoError(t, err)
+
+	assert.NotEqual(t, a, b)
+	assert.Equal(t, staging, filepath.Dir(a))
+	assert.True(t, strings.HasPrefix(filepath.Base(a), "gitlab-group_sub_tool-"))
+
+	ctx := newOperation(context.Background(), "install", "gitlab:group/sub/tool")
+	op, _ := currentOperation(ctx)
+	c, err := newStagingDir(ctx, "gitlab", "group/sub/tool")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(filepath.Base(c), "gitlab-group_sub_tool-"+op.ID+"-"))
+}
+
+func TestCleanStaleStaging(t *testing.T) {
+	staging, packages := withStagingDirs(t)
+	staleStaging := filepath.Join(staging, "github-owner_tool-123")
+	freshStaging := filepath.Join(staging, "github-owner_tool-456")
+	staleGitHub := filepath.Join(packages, "github", "owner", "tool_temp")
+	staleGitLab := filepath.Join(packages, "gitlab", "group_tool_temp")
+	installed := filepath.Join(packages, "github", "owner", "tool")
+	staleTemp := filepath.Join(tempPathFn(), "test-install-789")
+	makeDirAged(t, staleStaging, 48*time.Hour)
+	makeDirAged(t, staleTemp, 48*time.Hour)
+	makeDirAged(t, freshStaging, time.Minute)
+	makeDirAged(t, staleGitHub, 48*time.Hour)
+	makeDirAged(t, staleGitLab, 48*time.Hour)
+	makeDirAged(t, installed, 48*time.Hour)
+
+	removed := CleanStaleStaging(24 * time.Hour)
+
+	assert.ElementsMatch(t, []string{staleStaging, staleTemp, staleGitHub, staleGitLab}, removed)
+	assert.NoDirExists(t, staleStaging)
+	assert.NoDirExists(t, staleGitHub)
+	assert.NoDirExists(t, staleGitLab)
+	assert.DirExists(t, freshStaging)
+	assert.DirExists(t, installed)
+}
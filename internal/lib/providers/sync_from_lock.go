@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -80,7 +81,8 @@ func SyncAllFromLock() error {
 			continue
 		}
 		queryOnly := queryOnlyNeovimLanguagesForInstall(item.TreeSitter.Build, langs)
-		if err := installNeovimParsersAndQueriesFromCache(item.Source.ID, version, langs, queryOnly); err != nil && firstErr == nil {
+		ctx := newOperation(context.Background(), actionSync, sourceID)
+		if err := installNeovimParsersAndQueriesFromCache(ctx, item.Source.ID, version, langs, queryOnly); err != nil && firstErr == nil {
 			firstErr = fmt.Errorf("apply integrations for %s@%s: %w", sourceID, version, err)
 		}
 	}
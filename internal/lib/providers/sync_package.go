@@ -0,0 +1,41 @@
+package providers
+
+import (
+	"fmt"
+
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+)
+
+// Injectable helpers for tests
+var syncPackageGetBySourceID = local_packages_parser.GetBySourceId
+var syncPackageInstall = Install
+
+// SyncPackage reconciles a single installed package with zana-lock.json,
+// without touching the other packages: its bin entries are removed and it's
+// installed again at its locked version, which downloads its release asset
+// again and recreates its wrappers and symlinks. The lock entry keeps its
+// extras, so integrations, overrides and an unlinked state are kept.
+func SyncPackage(sourceID string) error {
+	pkg := syncPackageGetBySourceID(sourceID)
+	if pkg.SourceID == "" {
+		return fmt.Errorf("%s is not installed", sourceID)
+	}
+	var integrations []string
+	if pkg.Extras != nil {
+		integrations = pkg.Extras.Integrations
+	}
+	SetRequestedIntegrations(integrations)
+	defer SetRequestedIntegrations(nil)
+
+	if !isUnlinked(pkg.SourceID) {
+		removed, err := removeBinEntries(pkg.SourceID)
+		if err != nil {
+			return err
+		}
+		Logger.Info(fmt.Sprintf("Sync: Removed bin entries of %s: %v", pkg.SourceID, removed))
+	}
+	if !syncPackageInstall(pkg.SourceID, pkg.Version) {
+		return fmt.Errorf("reinstalling %s@%s failed", pkg.SourceID, pkg.Version)
+	}
+	return nil
+}
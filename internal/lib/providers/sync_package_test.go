@@ -0,0 +1,58 @@
+package providers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncPackage(t *testing.T) {
+	_ = withTempZanaHome(t)
+	writeRegistry(t, []registry_parser.RegistryItem{
+		{Name: "prettier", Source: registry_parser.RegistryItemSource{ID: "pkg:npm/prettier"}, Bin: map[string]string{"prettier": "npm:prettier"}},
+		{Name: "eslint", Source: registry_parser.RegistryItemSource{ID: "pkg:npm/eslint"}, Bin: map[string]string{"eslint": "npm:eslint"}},
+	})
+	require.NoError(t, local_packages_parser.AddLocalPackage("npm:prettier", "3.0.0"))
+	require.NoError(t, local_packages_parser.AddLocalPackage("npm:eslint", "9.0.0"))
+	require.NoError(t, local_packages_parser.MergePackageIntegrations("npm:prettier", []string{"neovim"}))
+
+	binDir := files.GetAppBinPathForProvider("npm")
+	require.NoError(t, os.MkdirAll(binDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "prettier"), []byte("tampered"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "eslint"), []byte("#!/bin/sh\n"), 0755))
+
+	origInstall := syncPackageInstall
+	t.Cleanup(func() { syncPackageInstall = origInstall })
+	var installed []string
+	var integrations []string
+	syncPackageInstall = func(sourceID, version string) bool {
+		installed = append(installed, sourceID+"@"+version)
+		integrations = requestedIntegrations
+		assert.NoFileExists(t, filepath.Join(binDir, "prettier"), "bin entries are removed before the install")
+		return true
+	}
+
+	require.NoError(t, SyncPackage("npm:prettier"))
+	assert.Equal(t, []string{"npm:prettier@3.0.0"}, installed)
+	assert.Equal(t, []string{"neovim"}, integrations)
+	assert.FileExists(t, filepath.Join(binDir, "eslint"), "other packages are left alone")
+	assert.Empty(t, requestedIntegrations)
+
+	t.Run("missing bin entries are fine", func(t *testing.T) {
+		installed = nil
+		require.NoError(t, SyncPackage("npm:prettier"))
+		assert.Equal(t, []string{"npm:prettier@3.0.0"}, installed)
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		assert.EqualError(t, SyncPackage("npm:typescript"), "npm:typescript is not installed")
+		syncPackageInstall = func(string, string) bool { return false }
+		assert.EqualError(t, SyncPackage("npm:eslint"), "reinstalling npm:eslint@9.0.0 failed")
+	})
+}
@@ -0,0 +1,161 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Injectable helpers for tests
+var toolHintsGOOS = runtime.GOOS
+var toolHintsLookPath = exec.LookPath
+var toolFixShellOut = shellOutInteractive
+
+// systemPackageManagers lists the supported system package managers per OS,
+// in order of preference. The first one found on PATH is used for install hints.
+var systemPackageManagers = map[string][]string{
+	"darwin":  {"brew"},
+	"linux":   {"apt-get", "dnf", "pacman", "zypper", "apk", "brew"},
+	"windows": {"winget", "scoop", "choco"},
+}
+
+// toolPackages maps a provider tool to the package providing it per system package manager
+var toolPackages = map[string]map[string]string{
+	"npm": {
+		"brew": "node", "apt-get": "nodejs npm", "dnf": "nodejs npm", "pacman": "nodejs npm",
+		"zypper": "nodejs npm", "apk": "nodejs npm", "winget": "OpenJS.NodeJS.LTS", "scoop": "nodejs-lts", "choco": "nodejs-lts",
+	},
+	"pip3": {
+		"brew": "python", "apt-get": "python3-pip", "dnf": "python3-pip", "pacman": "python-pip",
+		"zypper": "python3-pip", "apk": "py3-pip", "winget": "Python.Python.3.12", "scoop": "python", "choco": "python",
+	},
+	"go": {
+		"brew": "go", "apt-get": "golang-go", "dnf": "golang", "pacman": "go",
+		"zypper": "go", "apk": "go", "winget": "GoLang.Go", "scoop": "go", "choco": "golang",
+	},
+	"cargo": {
+		"brew": "rustup", "apt-get": "cargo", "dnf": "cargo", "pacman": "rustup",
+		"zypper": "rustup", "apk": "cargo", "winget": "Rustlang.Rustup", "scoop": "rustup", "choco": "rustup.install",
+	},
+	"git": {
+		"brew": "git", "apt-get": "git", "dnf": "git", "pacman": "git",
+		"zypper": "git", "apk": "git", "winget": "Git.Git", "scoop": "git", "choco": "git",
+	},
+	"gem": {
+		"brew": "ruby", "apt-get": "ruby-full", "dnf": "rubygems", "pacman": "rubygems",
+		"zypper": "ruby", "apk": "ruby", "winget": "RubyInstallerTeam.Ruby.3.3", "scoop": "ruby", "choco": "ruby",
+	},
+	"composer": {
+		"brew": "composer", "apt-get": "composer", "dnf": "composer", "pacman": "composer",
+		"zypper": "php-composer2", "apk": "composer", "scoop": "composer", "choco": "composer",
+	},
+	"luarocks": {
+		"brew": "luarocks", "apt-get": "luarocks", "dnf": "luarocks", "pacman": "luarocks",
+		"zypper": "lua54-luarocks", "apk": "luarocks", "scoop": "luarocks",
+	},
+	"dotnet": {
+		"brew": "--cask dotnet-sdk", "apt-get": "dotnet-sdk-8.0", "dnf": "dotnet-sdk-8.0", "pacman": "dotnet-sdk",
+		"winget": "Microsoft.DotNet.SDK.8", "scoop": "dotnet-sdk", "choco": "dotnet-sdk",
+	},
+	"opam": {
+		"brew": "opam", "apt-get": "opam", "dnf": "opam", "pacman": "opam",
+		"zypper": "opam", "apk": "opam",
+	},
+	"code": {
+		"brew": "--cask visual-studio-code", "winget": "Microsoft.VisualStudioCode", "scoop": "vscode", "choco": "vscode",
+	},
+	"cc": {
+		"brew": "gcc", "apt-get": "build-essential", "dnf": "gcc", "pacman": "gcc",
+		"zypper": "gcc", "apk": "build-base", "winget": "MartinStorsjo.LLVM-MinGW.UCRT", "scoop": "gcc", "choco": "mingw",
+	},
+}
+
+// toolDocsURLs points to the upstream installation instructions of each tool
+var toolDocsURLs = map[string]string{
+	"npm":      "https://nodejs.org/en/download",
+	"pip3":     "https://www.python.org/downloads/",
+	"go":       "https://go.dev/doc/install",
+	"cargo":    "https://rustup.rs",
+	"git":      "https://git-scm.com/downloads",
+	"gem":      "https://www.ruby-lang.org/en/documentation/installation/",
+	"composer": "https://getcomposer.org/download/",
+	"luarocks": "https://github.com/luarocks/luarocks/wiki/Download",
+	"dotnet":   "https://dotnet.microsoft.com/download",
+	"opam":     "https://opam.ocaml.org/doc/Install.html",
+	"code":     "https://code.visualstudio.com/download",
+	"cc":       "https://gcc.gnu.org/install/binaries.html",
+}
+
+// detectSystemPackageManager returns the first supported system package manager found on PATH
+func detectSystemPackageManager() string {
+	for _, pm := range systemPackageManagers[toolHintsGOOS] {
+		if _, err := toolHintsLookPath(pm); err == nil {
+			return pm
+		}
+	}
+	return ""
+}
+
+// toolInstallCommand returns the command installing the given packages with pm
+func toolInstallCommand(pm string, packages []string) []string {
+	switch pm {
+	case "brew":
+		return append([]string{"brew", "install"}, packages...)
+	case "apt-get":
+		return append([]string{"sudo", "apt-get", "install", "-y"}, packages...)
+	case "dnf":
+		return append([]string{"sudo", "dnf", "install", "-y"}, packages...)
+	case "pacman":
+		return append([]string{"sudo", "pacman", "-S", "--needed", "--noconfirm"}, packages...)
+	case "zypper":
+		return append([]string{"sudo", "zypper", "install", "-y"}, packages...)
+	case "apk":
+		return append([]string{"sudo", "apk", "add"}, packages...)
+	case "winget":
+		return append([]string{"winget", "install", "--exact", "--id"}, packages...)
+	case "scoop":
+		return append([]string{"scoop", "install"}, packages...)
+	case "choco":
+		return append([]string{"choco", "install", "-y"}, packages...)
+	}
+	return nil
+}
+
+// ToolInstallHint returns human readable installation instructions for a provider tool
+// on the current OS, and the command installing it with the system package manager.
+// The command is empty when no supported system package manager provides the tool.
+func ToolInstallHint(tool string) (string, []string) {
+	docs := toolDocsURLs[tool]
+	if pm := detectSystemPackageManager(); pm != "" {
+		if pkg, ok := toolPackages[tool][pm]; ok {
+			command := toolInstallCommand(pm, strings.Fields(pkg))
+			hint := fmt.Sprintf("Install it with: %s", strings.Join(command, " "))
+			if docs != "" {
+				hint += fmt.Sprintf(" (or see %s)", docs)
+			}
+			return hint, command
+		}
+	}
+	if docs == "" {
+		return fmt.Sprintf("Install %s and make sure it is on your PATH", tool), nil
+	}
+	return fmt.Sprintf("See %s for installation instructions", docs), nil
+}
+
+// FixProviderTool installs the missing tool of an unavailable provider
+// with the system package manager.
+func FixProviderTool(status ProviderHealthStatus) error {
+	if status.Available {
+		return nil
+	}
+	if len(status.FixCommand) == 0 {
+		return fmt.Errorf("no automatic installation available for %s: %s", status.RequiredTool, status.InstallHint)
+	}
+	Logger.Info(fmt.Sprintf("Doctor: Installing %s with %s", status.RequiredTool, strings.Join(status.FixCommand, " ")))
+	if _, err := toolFixShellOut(context.Background(), status.FixCommand[0], status.FixCommand[1:], "", nil); err != nil {
+		return fmt.Errorf("failed to install %s: %w", status.RequiredTool, err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubToolHints(t *testing.T, goos string, onPath ...string) {
+	t.Helper()
+	origGOOS, origLookPath, origHasCommand, origShellOut := toolHintsGOOS, toolHintsLookPath, healthHasCommand, toolFixShellOut
+	t.Cleanup(func() {
+		toolHintsGOOS, toolHintsLookPath, healthHasCommand, toolFixShellOut = origGOOS, origLookPath, origHasCommand, origShellOut
+	})
+	toolHintsGOOS = goos
+	toolHintsLookPath = func(file string) (string, error) {
+		for _, p := range onPath {
+			if p == file {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+	healthHasCommand = func(_ context.Context, command string, _ []string, _ []string) bool {
+		for _, p := range onPath {
+			if p == command {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func TestToolInstallHint(t *testing.T) {
+	t.Run("uses the system package manager", func(t *testing.T) {
+		stubToolHints(t, "linux", "dnf")
+		hint, command := ToolInstallHint("npm")
+		assert.Equal(t, []string{"sudo", "dnf", "install", "-y", "nodejs", "npm"}, command)
+		assert.Contains(t, hint, "Install it with: sudo dnf install -y nodejs npm")
+		assert.Contains(t, hint, "https://nodejs.org/en/download")
+	})
+
+	t.Run("prefers the first package manager found", func(t *testing.T) {
+		stubToolHints(t, "linux", "apt-get", "brew")
+		_, command := ToolInstallHint("go")
+		assert.Equal(t, []string{"sudo", "apt-get", "install", "-y", "golang-go"}, command)
+	})
+
+	t.Run("brew casks", func(t *testing.T) {
+		stubToolHints(t, "darwin", "brew")
+		_, command := ToolInstallHint("code")
+		assert.Equal(t, []string{"brew", "install", "--cask", "visual-studio-code"}, command)
+	})
+
+	t.Run("falls back to the docs without a package manager", func(t *testing.T) {
+		stubToolHints(t, "windows")
+		hint, command := ToolInstallHint("cargo")
+		assert.Nil(t, command)
+		assert.Equal(t, "See https://rustup.rs for installation instructions", hint)
+	})
+}
+
+func TestCheckProviderHealth(t *testing.T) {
+	stubToolHints(t, "darwin", "brew", "pip")
+
+	npm := CheckProviderHealth("npm")
+	assert.False(t, npm.Available)
+	assert.Equal(t, "npm", npm.RequiredTool)
+	assert.Equal(t, []string{"brew", "install", "node"}, npm.FixCommand)
+
+	pypi := CheckProviderHealth("pypi")
+	assert.True(t, pypi.Available, "pip is accepted when pip3 is missing")
+	assert.Empty(t, pypi.InstallHint)
+
+	assert.True(t, CheckProviderHealth("generic").Available)
+	assert.True(t, CheckProviderHealth("unknown").Available)
+}
+
+func TestFixProviderTool(t *testing.T) {
+	stubToolHints(t, "darwin", "brew")
+	var ran []string
+	toolFixShellOut = func(_ context.Context, command string, args []string, _ string, _ []string) (int, error) {
+		ran = append([]string{command}, args...)
+		return 0, nil
+	}
+
+	require.NoError(t, FixProviderTool(CheckProviderHealth("golang")))
+	assert.Equal(t, []string{"brew", "install", "go"}, ran)
+
+	toolFixShellOut = func(context.Context, string, []string, string, []string) (int, error) {
+		return 1, errors.New("exit status 1")
+	}
+	assert.ErrorContains(t, FixProviderTool(CheckProviderHealth("golang")), "failed to install go")
+
+	toolHintsGOOS = "windows"
+	assert.ErrorContains(t, FixProviderTool(CheckProviderHealth("opam")), "no automatic installation available for opam")
+	assert.NoError(t, FixProviderTool(ProviderHealthStatus{Provider: "npm", Available: true}))
+}
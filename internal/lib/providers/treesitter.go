@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,7 +10,6 @@ import (
 
 	"github.com/mistweaverco/zana-client/internal/lib/files"
 	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
-	"github.com/mistweaverco/zana-client/internal/lib/shell_out"
 	"github.com/mistweaverco/zana-client/internal/lib/treesitterdeps"
 )
 
@@ -41,15 +41,15 @@ func SharedLibExt() string {
 
 // injectable for tests
 var (
-	treeSitterHasCommand      = shell_out.HasCommand
-	treeSitterShellOut        = shell_out.ShellOut
-	treeSitterShellOutCapture = shell_out.ShellOutCapture
+	treeSitterHasCommand      = hasCommand
+	treeSitterShellOut        = shellOut
+	treeSitterShellOutCapture = shellOutCapture
 	osMkdirAll                = func(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
 	treeSitterStat            = os.Stat
 )
 
-func HasTreeSitterCLI() bool {
-	return treeSitterHasCommand("tree-sitter", []string{"--version"}, nil)
+func HasTreeSitterCLI(ctx context.Context) bool {
+	return treeSitterHasCommand(ctx, "tree-sitter", []string{"--version"}, nil)
 }
 
 func SafeArtifactPackageID(sourceID string) string {
@@ -74,6 +74,7 @@ func TreeSitterArtifactPath(sourceID, version, language string) string {
 // upstream source into Zana's artifact cache and returns the list of languages
 // that were built.
 func BuildTreeSitterParsersToCache(
+	ctx context.Context,
 	repoPath string,
 	sourceID string,
 	version string,
@@ -92,7 +93,7 @@ func BuildTreeSitterParsersToCache(
 			break
 		}
 	}
-	if needsParserBuild && !HasTreeSitterCLI() {
+	if needsParserBuild && !HasTreeSitterCLI(ctx) {
 		return nil, fmt.Errorf("tree-sitter CLI not found in PATH (required to build parsers from source)")
 	}
 
@@ -131,7 +132,7 @@ func BuildTreeSitterParsersToCache(
 			needsGenerate = true
 		}
 		if needsGenerate {
-			code, output, err := treeSitterShellOutCapture("tree-sitter", []string{"generate"}, fullGrammarDir, nil)
+			code, output, err := treeSitterShellOutCapture(ctx, "tree-sitter", []string{"generate"}, fullGrammarDir, nil)
 			if err != nil || code != 0 {
 				if strings.TrimSpace(output) == "" {
 					return nil, fmt.Errorf("tree-sitter generate failed for %s in %s", lang, grammarDir)
@@ -140,7 +141,7 @@ func BuildTreeSitterParsersToCache(
 			}
 		}
 
-		code, output, err := treeSitterShellOutCapture("tree-sitter", []string{"build", "-o", outPath, fullGrammarDir}, "", nil)
+		code, output, err := treeSitterShellOutCapture(ctx, "tree-sitter", []string{"build", "-o", outPath, fullGrammarDir}, "", nil)
 		if err != nil || code != 0 {
 			if strings.TrimSpace(output) == "" {
 				return nil, fmt.Errorf("tree-sitter build failed for %s in %s", lang, grammarDir)
@@ -0,0 +1,357 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/local_packages_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/mistweaverco/zana-client/internal/lib/versioncmp"
+)
+
+// TreeSitterProvider compiles tree-sitter grammars from their GitHub
+// repository (treesitter:owner/repo) with the system C compiler.
+// The parsers are written to share/tree-sitter/parser/<language>.<so|dylib|dll>,
+// so share/tree-sitter can be added to Neovim's runtimepath as is.
+type TreeSitterProvider struct {
+	APP_PACKAGES_DIR string
+	PREFIX           string
+	PROVIDER_NAME    string
+	BASE_URL         string
+}
+
+// Injectable shell and OS helpers for tests
+var treeSitterProviderShellOut = shellOut
+var treeSitterProviderShellOutCapture = shellOutCapture
+var treeSitterProviderHasCommand = hasCommand
+var treeSitterProviderGetenv = os.Getenv
+
+// Injectable local packages helpers for tests
+var lppTreeSitterAdd = local_packages_parser.AddLocalPackage
+var lppTreeSitterRemove = local_packages_parser.RemoveLocalPackage
+
+// Injectable registry parser for tests
+var treeSitterProviderRegistryParser = registry_parser.NewDefaultRegistryParser
+
+func NewProviderTreeSitter() *TreeSitterProvider {
+	p := &TreeSitterProvider{}
+	p.PROVIDER_NAME = "treesitter"
+	p.APP_PACKAGES_DIR = filepath.Join(files.GetAppPackagesPath(), p.PROVIDER_NAME)
+	p.PREFIX = p.PROVIDER_NAME + ":"
+	p.BASE_URL = "https://github.com"
+	return p
+}
+
+// TreeSitterParserDir is the directory the treesitter provider writes parsers into
+// e.g. /home/user/.local/share/zana/share/tree-sitter/parser
+func TreeSitterParserDir() string {
+	return filepath.Join(dataSharePath(), "tree-sitter", "parser")
+}
+
+// treeSitterGrammar is a grammar in a cloned repository
+type treeSitterGrammar struct {
+	language string
+	dir      string
+}
+
+func (p *TreeSitterProvider) getRepo(sourceID string) string {
+	return strings.TrimPrefix(packageid.Normalize(sourceID), p.PREFIX)
+}
+
+func (p *TreeSitterProvider) getRepoURL(repo string) string {
+	return fmt.Sprintf("%s/%s.git", p.BASE_URL, repo)
+}
+
+func (p *TreeSitterProvider) getRepoPath(repo string) string {
+	return filepath.Join(p.APP_PACKAGES_DIR, strings.ReplaceAll(repo, "/", "_"))
+}
+
+func (p *TreeSitterProvider) clone(ctx context.Context, repoPath string) gitClone {
+	return gitClone{ctx: ctx, path: repoPath, shellOut: treeSitterProviderShellOut, shellOutCapture: treeSitterProviderShellOutCapture}
+}
+
+// treeSitterLanguageName derives a language name from a repository or
+// directory name, e.g. tree-sitter-c-sharp becomes c_sharp
+func treeSitterLanguageName(name string) string {
+	name = strings.TrimPrefix(strings.ToLower(name), "tree-sitter-")
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// isGrammarDir reports whether dir holds a grammar, generated or not
+func isGrammarDir(dir string) bool {
+	for _, f := range []string{filepath.Join("src", "parser.c"), "grammar.js"} {
+		if _, err := os.Stat(filepath.Join(dir, f)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// grammars returns the grammars of the cloned repo: those declared in the
+// registry's treesitter.build, otherwise the repository root or, for repos
+// holding several grammars (e.g. typescript and tsx), its subdirectories
+func (p *TreeSitterProvider) grammars(sourceID, repo, repoPath string) []treeSitterGrammar {
+	var grammars []treeSitterGrammar
+	if item := treeSitterProviderRegistryParser().GetBySourceId(sourceID); item.TreeSitter != nil {
+		for _, b := range item.TreeSitter.Build {
+			if b.QueriesOnly || strings.TrimSpace(b.Language) == "" || strings.TrimSpace(b.GrammarDir) == "" {
+				continue
+			}
+			grammars = append(grammars, treeSitterGrammar{language: b.Language, dir: filepath.Join(repoPath, filepath.FromSlash(b.GrammarDir))})
+		}
+		if len(grammars) > 0 {
+			return grammars
+		}
+	}
+	if isGrammarDir(repoPath) {
+		return []treeSitterGrammar{{language: treeSitterLanguageName(filepath.Base(repo)), dir: repoPath}}
+	}
+	entries, _ := os.ReadDir(repoPath)
+	for _, e := range entries {
+		dir := filepath.Join(repoPath, e.Name())
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") && isGrammarDir(dir) {
+			grammars = append(grammars, treeSitterGrammar{language: treeSitterLanguageName(e.Name()), dir: dir})
+		}
+	}
+	return grammars
+}
+
+// findCCompiler returns $CC or the first of cc, gcc and clang found in PATH
+func findCCompiler(ctx context.Context) (string, error) {
+	candidates := []string{"cc", "gcc", "clang"}
+	if cc := strings.TrimSpace(treeSitterProviderGetenv("CC")); cc != "" {
+		candidates = append([]string{cc}, candidates...)
+	}
+	for _, cc := range candidates {
+		if treeSitterProviderHasCommand(ctx, cc, []string{"--version"}, nil) {
+			return cc, nil
+		}
+	}
+	return "", fmt.Errorf("no C compiler found, install cc, gcc or clang or set CC")
+}
+
+// compileArgs returns the compiler arguments building the grammar in dir
+// into the shared library outPath. External scanners written in C++ are
+// compiled by the same driver, which picks the language by file extension.
+func compileArgs(dir, outPath string) []string {
+	src := filepath.Join(dir, "src")
+	args := []string{"-o", outPath, "-shared", "-O2", "-I", src}
+	if runtime.GOOS != "windows" {
+		args = append(args, "-fPIC")
+	}
+	args = append(args, filepath.Join(src, "parser.c"))
+	cpp := false
+	for _, scanner := range []string{"scanner.c", "scanner.cc"} {
+		path := filepath.Join(src, scanner)
+		if _, err := os.Stat(path); err == nil {
+			args = append(args, path)
+			cpp = cpp || scanner == "scanner.cc"
+		}
+	}
+	if cpp {
+		if runtime.GOOS == "darwin" {
+			args = append(args, "-lc++")
+		} else {
+			args = append(args, "-lstdc++")
+		}
+	}
+	return args
+}
+
+// compile builds the parser of g into the parser dir. Grammars that don't
+// ship their generated sources need the tree-sitter CLI to generate them first.
+func (p *TreeSitterProvider) compile(ctx context.Context, cc string, g treeSitterGrammar) error {
+	if _, err := os.Stat(filepath.Join(g.dir, "src", "parser.c")); err != nil {
+		if !HasTreeSitterCLI(ctx) {
+			return fmt.Errorf("%s doesn't ship src/parser.c, generating it needs the tree-sitter CLI", g.language)
+		}
+		code, output, err := treeSitterProviderShellOutCapture(ctx, "tree-sitter", []string{"generate"}, g.dir, nil)
+		if err != nil || code != 0 {
+			return fmt.Errorf("tree-sitter generate failed for %s: %s", g.language, strings.TrimSpace(output))
+		}
+	}
+	outPath := filepath.Join(TreeSitterParserDir(), g.language+SharedLibExt())
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return fmt.Errorf("failed to create parser directory: %w", err)
+	}
+	// Compile next to the parser and rename, so an editor never loads a half written library
+	tmpPath := outPath + ".tmp"
+	code, output, err := treeSitterProviderShellOutCapture(ctx, cc, compileArgs(g.dir, tmpPath), g.dir, nil)
+	if err != nil || code != 0 {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("compiling %s failed: %s", g.language, strings.TrimSpace(output))
+	}
+	return os.Rename(tmpPath, outPath)
+}
+
+// cloneAndCheckout clones repo, or fetches into an existing clone, and
+// checks out version, resolving latest to the latest tag or the default branch
+func (p *TreeSitterProvider) cloneAndCheckout(ctx context.Context, sourceID, repo, version string) (string, string, error) {
+	repoPath := p.getRepoPath(repo)
+	clone := p.clone(ctx, repoPath)
+	previousCommit := ""
+	if _, err := os.Stat(repoPath); os.IsNotExist(err) {
+		if err := os.MkdirAll(p.APP_PACKAGES_DIR, 0755); err != nil {
+			return "", "", fmt.Errorf("error creating packages directory: %w", err)
+		}
+		Logger.InfoContext(ctx, fmt.Sprintf("Tree-sitter Install: Cloning %s to %s", p.getRepoURL(repo), repoPath))
+		code, err := treeSitterProviderShellOut(ctx, "git", []string{"clone", p.getRepoURL(repo), repoPath}, p.APP_PACKAGES_DIR, nil)
+		if err != nil || code != 0 {
+			return "", "", fmt.Errorf("error cloning %s: exit code %d, %v", p.getRepoURL(repo), code, err)
+		}
+	} else {
+		previousCommit = clone.head()
+		if err := clone.fetchFor(version); err != nil {
+			return "", "", fmt.Errorf("error fetching updates: %w", err)
+		}
+	}
+
+	resolvedVersion := version
+	if resolvedVersion == "" || resolvedVersion == "latest" {
+		tag, err := clone.latestTag()
+		if err != nil {
+			tag = clone.defaultBranch()
+		}
+		resolvedVersion = tag
+	}
+	if err := clone.checkout(resolvedVersion); err != nil {
+		return "", "", fmt.Errorf("error checking out version %s: %w", resolvedVersion, err)
+	}
+	if previousCommit != "" {
+		recordGitCommitRange(ctx, previousCommit, clone.head())
+	}
+	return repoPath, resolvedVersion, nil
+}
+
+func (p *TreeSitterProvider) Install(ctx context.Context, sourceID, version string) bool {
+	repo := p.getRepo(sourceID)
+	if !strings.Contains(repo, "/") {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Tree-sitter Install: %q is missing the owner; use treesitter:owner/repo (for example treesitter:tree-sitter/tree-sitter-lua)", sourceID))
+		return false
+	}
+	if !treeSitterProviderHasCommand(ctx, "git", []string{"--version"}, nil) {
+		Logger.ErrorContext(ctx, "Tree-sitter Install: git command not found. Please install git.")
+		return false
+	}
+	cc, err := findCCompiler(ctx)
+	if err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Tree-sitter Install: %v", err))
+		return false
+	}
+
+	repoPath, resolvedVersion, err := p.cloneAndCheckout(ctx, sourceID, repo, version)
+	if err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Tree-sitter Install: %v", err))
+		return false
+	}
+	grammars := p.grammars(sourceID, repo, repoPath)
+	if len(grammars) == 0 {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Tree-sitter Install: No tree-sitter grammar found in %s", repo))
+		return false
+	}
+	for _, g := range grammars {
+		if err := p.compile(ctx, cc, g); err != nil {
+			Logger.ErrorContext(ctx, fmt.Sprintf("Tree-sitter Install: %v", err))
+			return false
+		}
+	}
+
+	if err := lppTreeSitterAdd(p.PREFIX+repo, resolvedVersion); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Tree-sitter Install: Error adding package to local packages: %v", err))
+		return false
+	}
+	Logger.InfoContext(ctx, fmt.Sprintf("Tree-sitter Install: Successfully built %d parser(s) of %s@%s", len(grammars), repo, resolvedVersion))
+	return true
+}
+
+func (p *TreeSitterProvider) Remove(ctx context.Context, sourceID string) bool {
+	repo := p.getRepo(sourceID)
+	if repo == "" {
+		Logger.ErrorContext(ctx, "Tree-sitter Remove: Invalid source ID format")
+		return false
+	}
+	repoPath := p.getRepoPath(repo)
+	for _, path := range p.parserPaths(sourceID, repo, repoPath) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			Logger.InfoContext(ctx, fmt.Sprintf("Tree-sitter Remove: Warning removing %s: %v", path, err))
+		}
+	}
+	if err := os.RemoveAll(repoPath); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Tree-sitter Remove: Error removing repository directory: %v", err))
+		return false
+	}
+	if err := lppTreeSitterRemove(p.PREFIX + repo); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Tree-sitter Remove: Error removing package from local packages: %v", err))
+		return false
+	}
+	Logger.InfoContext(ctx, fmt.Sprintf("Tree-sitter Remove: Successfully removed %s", repo))
+	return true
+}
+
+func (p *TreeSitterProvider) Update(ctx context.Context, sourceID string) bool {
+	repo := p.getRepo(sourceID)
+	repoPath := p.getRepoPath(repo)
+	if _, err := os.Stat(repoPath); os.IsNotExist(err) {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Tree-sitter Update: %s is not installed", repo))
+		return false
+	}
+	if err := p.clone(ctx, repoPath).fetchAll(); err != nil {
+		Logger.ErrorContext(ctx, fmt.Sprintf("Tree-sitter Update: Error fetching updates: %v", err))
+		return false
+	}
+	return p.Install(ctx, sourceID, "latest")
+}
+
+// getLatestVersion returns the highest tag of the remote repository, or
+// latest when it has none, which Install resolves to the default branch
+func (p *TreeSitterProvider) getLatestVersion(ctx context.Context, repo string) (string, error) {
+	code, output, err := treeSitterProviderShellOutCapture(ctx, "git", []string{"ls-remote", "--tags", "--refs", p.getRepoURL(repo)}, "", nil)
+	if err != nil || code != 0 {
+		return "", fmt.Errorf("listing tags of %s failed: %s", repo, strings.TrimSpace(output))
+	}
+	latest := ""
+	for _, line := range strings.Split(output, "\n") {
+		_, ref, ok := strings.Cut(strings.TrimSpace(line), "refs/tags/")
+		if !ok {
+			continue
+		}
+		if latest == "" || versioncmp.IsGreater(latest, ref) {
+			latest = ref
+		}
+	}
+	if latest == "" {
+		return "latest", nil
+	}
+	return latest, nil
+}
+
+// parserPaths returns the parser files built for the grammars of repo
+func (p *TreeSitterProvider) parserPaths(sourceID, repo, repoPath string) []string {
+	var paths []string
+	for _, g := range p.grammars(sourceID, repo, repoPath) {
+		paths = append(paths, filepath.Join(TreeSitterParserDir(), g.language+SharedLibExt()))
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// TreeSitterParserPaths returns the parsers the installed treesitter package
+// sourceID built, for zana which --data
+func TreeSitterParserPaths(sourceID string) []string {
+	p := NewProviderTreeSitter()
+	repo := p.getRepo(sourceID)
+	var paths []string
+	for _, path := range p.parserPaths(sourceID, repo, p.getRepoPath(repo)) {
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,127 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTreeSitterProviderTest returns a provider cloning from a local
+// directory holding the repository owner/tree-sitter-mini, tagged v0.1.0,
+// and the in-memory lock file it records into
+func setupTreeSitterProviderTest(t *testing.T, registryJSON string) (*TreeSitterProvider, map[string]string) {
+	t.Helper()
+	for _, tool := range []string{"git", "cc"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not found", tool)
+		}
+	}
+	t.Setenv("ZANA_DATA", t.TempDir())
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	share := t.TempDir()
+
+	remotes := t.TempDir()
+	repo := filepath.Join(remotes, "owner", "tree-sitter-mini.git")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "src", "parser.c"), []byte("const void *tree_sitter_mini(void) { return 0; }\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "src", "scanner.c"), []byte("int tree_sitter_mini_external_scanner_create(void) { return 0; }\n"), 0644))
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "grammar"},
+		{"tag", "v0.1.0"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	reg := registry_parser.NewRegistryParser(nil)
+	require.NoError(t, reg.LoadFromBytes([]byte(registryJSON)))
+	lock := map[string]string{}
+	origShare, origParser, origAdd, origRemove := dataSharePath, treeSitterProviderRegistryParser, lppTreeSitterAdd, lppTreeSitterRemove
+	dataSharePath = func() string { return share }
+	treeSitterProviderRegistryParser = func() *registry_parser.RegistryParser { return reg }
+	lppTreeSitterAdd = func(sourceID, version string) error {
+		lock[sourceID] = version
+		return nil
+	}
+	lppTreeSitterRemove = func(sourceID string) error {
+		delete(lock, sourceID)
+		return nil
+	}
+	t.Cleanup(func() {
+		dataSharePath, treeSitterProviderRegistryParser, lppTreeSitterAdd, lppTreeSitterRemove = origShare, origParser, origAdd, origRemove
+	})
+
+	p := NewProviderTreeSitter()
+	p.BASE_URL = remotes
+	return p, lock
+}
+
+func TestTreeSitterProviderInstallWhichRemove(t *testing.T) {
+	p, lock := setupTreeSitterProviderTest(t, `[]`)
+	sourceID := "treesitter:owner/tree-sitter-mini"
+
+	latest, err := p.getLatestVersion(context.Background(), "owner/tree-sitter-mini")
+	require.NoError(t, err)
+	assert.Equal(t, "v0.1.0", latest)
+
+	require.True(t, p.Install(context.Background(), sourceID, "latest"))
+	assert.Equal(t, "v0.1.0", lock[sourceID])
+	parser := filepath.Join(TreeSitterParserDir(), "mini"+SharedLibExt())
+	assert.FileExists(t, parser)
+	assert.Equal(t, []string{parser}, TreeSitterParserPaths(sourceID))
+
+	require.True(t, p.Remove(context.Background(), sourceID))
+	assert.NotContains(t, lock, sourceID)
+	assert.NoFileExists(t, parser)
+	assert.NoDirExists(t, p.getRepoPath("owner/tree-sitter-mini"))
+}
+
+func TestTreeSitterProviderUsesRegistryGrammars(t *testing.T) {
+	p, _ := setupTreeSitterProviderTest(t, `[
+		{"name": "mini", "source": {"id": "treesitter:owner/tree-sitter-mini"},
+		 "treesitter": {"build": [{"language": "mini_lang", "grammar_dir": "."}, {"language": "mini_queries", "queries_only": true}]}}
+	]`)
+
+	require.True(t, p.Install(context.Background(), "treesitter:owner/tree-sitter-mini", "v0.1.0"))
+	assert.FileExists(t, filepath.Join(TreeSitterParserDir(), "mini_lang"+SharedLibExt()))
+	assert.NoFileExists(t, filepath.Join(TreeSitterParserDir(), "mini_queries"+SharedLibExt()))
+}
+
+func TestTreeSitterProviderRejectsMissingOwner(t *testing.T) {
+	assert.False(t, NewProviderTreeSitter().Install(context.Background(), "treesitter:lua", "latest"))
+}
+
+func TestFindCCompiler(t *testing.T) {
+	origHas, origGetenv := treeSitterProviderHasCommand, treeSitterProviderGetenv
+	t.Cleanup(func() { treeSitterProviderHasCommand, treeSitterProviderGetenv = origHas, origGetenv })
+	available := map[string]bool{"clang": true, "zig-cc": true}
+	treeSitterProviderHasCommand = func(_ context.Context, cmd string, _ []string, _ []string) bool { return available[cmd] }
+
+	treeSitterProviderGetenv = func(string) string { return "" }
+	cc, err := findCCompiler(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "clang", cc)
+
+	treeSitterProviderGetenv = func(string) string { return "zig-cc" }
+	cc, err = findCCompiler(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "zig-cc", cc)
+
+	available = map[string]bool{}
+	_, err = findCCompiler(context.Background())
+	assert.ErrorContains(t, err, "no C compiler found")
+}
+
+func TestTreeSitterLanguageName(t *testing.T) {
+	assert.Equal(t, "c_sharp", treeSitterLanguageName("tree-sitter-c-sharp"))
+	assert.Equal(t, "tsx", treeSitterLanguageName("tsx"))
+	assert.Equal(t, "lua", treeSitterLanguageName("Tree-Sitter-Lua"))
+}
@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -34,18 +35,18 @@ func TestBuildTreeSitterParsersToCache_UsesShellOut(t *testing.T) {
 		treeSitterStat = oldStat
 	})
 
-	treeSitterHasCommand = func(cmd string, args []string, env []string) bool { return true }
+	treeSitterHasCommand = func(_ context.Context, cmd string, args []string, env []string) bool { return true }
 	treeSitterStat = func(name string) (os.FileInfo, error) { return nil, nil }
 
 	var gotArgs []string
-	treeSitterShellOut = func(command string, args []string, dir string, env []string) (int, error) {
+	treeSitterShellOut = func(_ context.Context, command string, args []string, dir string, env []string) (int, error) {
 		if command != "tree-sitter" {
 			t.Fatalf("expected tree-sitter, got %q", command)
 		}
 		gotArgs = append([]string{}, args...)
 		return 0, nil
 	}
-	treeSitterShellOutCapture = func(command string, args []string, dir string, env []string) (int, string, error) {
+	treeSitterShellOutCapture = func(_ context.Context, command string, args []string, dir string, env []string) (int, string, error) {
 		if command != "tree-sitter" {
 			t.Fatalf("expected tree-sitter, got %q", command)
 		}
@@ -65,7 +66,7 @@ func TestBuildTreeSitterParsersToCache_UsesShellOut(t *testing.T) {
 	}
 
 	// Act
-	langs, err := BuildTreeSitterParsersToCache("/tmp/repo", "github:tree-sitter/tree-sitter-typescript", "v0.0.1", build)
+	langs, err := BuildTreeSitterParsersToCache(context.Background(), "/tmp/repo", "github:tree-sitter/tree-sitter-typescript", "v0.0.1", build)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -88,9 +89,9 @@ func TestBuildTreeSitterParsersToCache_UsesShellOut(t *testing.T) {
 func TestBuildTreeSitterParsersToCache_FailsWithoutCLI(t *testing.T) {
 	oldHas := treeSitterHasCommand
 	t.Cleanup(func() { treeSitterHasCommand = oldHas })
-	treeSitterHasCommand = func(cmd string, args []string, env []string) bool { return false }
+	treeSitterHasCommand = func(_ context.Context, cmd string, args []string, env []string) bool { return false }
 
-	_, err := BuildTreeSitterParsersToCache("/tmp/repo", "github:x/y", "v1", []registry_parser.RegistryItemTreeSitterBuild{
+	_, err := BuildTreeSitterParsersToCache(context.Background(), "/tmp/repo", "github:x/y", "v1", []registry_parser.RegistryItemTreeSitterBuild{
 		{Language: "x", GrammarDir: "x", Integrations: []string{"neovim"}},
 	})
 	if err == nil {
@@ -105,13 +106,13 @@ func TestBuildTreeSitterParsersToCache_QueriesOnlySkipsCLIAndBuild(t *testing.T)
 		treeSitterHasCommand = oldHas
 		treeSitterShellOutCapture = oldShellCapture
 	})
-	treeSitterHasCommand = func(cmd string, args []string, env []string) bool { return false }
-	treeSitterShellOutCapture = func(command string, args []string, dir string, env []string) (int, string, error) {
+	treeSitterHasCommand = func(_ context.Context, cmd string, args []string, env []string) bool { return false }
+	treeSitterShellOutCapture = func(_ context.Context, command string, args []string, dir string, env []string) (int, string, error) {
 		t.Fatalf("queries-only build should not call %s %#v", command, args)
 		return 1, "", nil
 	}
 
-	langs, err := BuildTreeSitterParsersToCache("/tmp/repo", "github:x/y", "v1", []registry_parser.RegistryItemTreeSitterBuild{
+	langs, err := BuildTreeSitterParsersToCache(context.Background(), "/tmp/repo", "github:x/y", "v1", []registry_parser.RegistryItemTreeSitterBuild{
 		{Language: "html_tags", QueriesOnly: true, Integrations: []string{"neovim"}},
 	})
 	if err != nil {
@@ -0,0 +1,64 @@
+package providers
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+)
+
+// UnmanagedBinary is an entry in a zana bin directory that no installed
+// package owns, e.g. an executable copied there by hand
+type UnmanagedBinary struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// unmanagedBinDirs returns the bin dir followed by the directories on PATH
+// for the configured bin layout
+func unmanagedBinDirs() []string {
+	dirs := []string{files.GetAppBinPath()}
+	for _, dir := range BinPathsInPrecedenceOrder() {
+		if !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// FindUnmanagedBinaries returns the entries of the zana bin directories that
+// don't belong to an installed or adopted package. Symlinks into the packages
+// directory and lazy shims are zana's own and never reported.
+func FindUnmanagedBinaries() []UnmanagedBinary {
+	owners := binOwnersFromLock()
+	var unmanaged []UnmanagedBinary
+	for _, dir := range unmanagedBinDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() || strings.HasPrefix(e.Name(), ".") || owners[e.Name()] != "" {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			if binOwnerFromSymlink(path) != "" || IsLazyShim(path) {
+				continue
+			}
+			unmanaged = append(unmanaged, UnmanagedBinary{Name: e.Name(), Path: path})
+		}
+	}
+	return unmanaged
+}
+
+// binEntryExists reports whether any zana bin directory has an entry named name
+func binEntryExists(name string) bool {
+	for _, dir := range unmanagedBinDirs() {
+		if _, err := os.Lstat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
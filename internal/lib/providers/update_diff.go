@@ -0,0 +1,36 @@
+package providers
+
+import "github.com/mistweaverco/zana-client/internal/lib/versioncmp"
+
+// VersionUpdate is an installed package and a newer version found for it
+type VersionUpdate struct {
+	SourceID string
+	Current  string
+	Latest   string
+}
+
+// UpdateDiff is the step from the installed version of a package to the
+// newer one, classified as a major, minor or patch bump
+type UpdateDiff struct {
+	SourceID string          `json:"source_id"`
+	Current  string          `json:"current"`
+	Latest   string          `json:"latest"`
+	Bump     versioncmp.Bump `json:"bump"`
+}
+
+// DiffUpdates classifies a batch of updates, in the order given, so callers
+// (zana outdated --diff, the Neovim integration, CI scripts) share one
+// comparison. Updates whose versions can't be compared, e.g. commit hashes or
+// "latest", are BumpUnknown.
+func DiffUpdates(updates []VersionUpdate) []UpdateDiff {
+	diffs := make([]UpdateDiff, 0, len(updates))
+	for _, u := range updates {
+		bump := versioncmp.BumpBetween(u.Current, u.Latest)
+		if bump == versioncmp.BumpNone {
+			// The caller found the version to be newer by other means
+			bump = versioncmp.BumpUnknown
+		}
+		diffs = append(diffs, UpdateDiff{SourceID: u.SourceID, Current: u.Current, Latest: u.Latest, Bump: bump})
+	}
+	return diffs
+}
@@ -0,0 +1,28 @@
+package providers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/versioncmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffUpdates(t *testing.T) {
+	diffs := DiffUpdates([]VersionUpdate{
+		{SourceID: "npm:prettier", Current: "3.0.0", Latest: "3.0.1"},
+		{SourceID: "npm:eslint", Current: "v8.57.0", Latest: "9.0.0"},
+		{SourceID: "github:owner/tool", Current: "latest", Latest: "v1.2.0"},
+		{SourceID: "golang:gopls", Current: "0.16.0", Latest: "0.16.0"},
+	})
+	require.Len(t, diffs, 4)
+	assert.Equal(t, versioncmp.BumpPatch, diffs[0].Bump)
+	assert.Equal(t, versioncmp.BumpMajor, diffs[1].Bump)
+	assert.Equal(t, versioncmp.BumpUnknown, diffs[2].Bump)
+	assert.Equal(t, versioncmp.BumpUnknown, diffs[3].Bump, "updates found by other means are never none")
+
+	data, err := json.Marshal(diffs[1])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"source_id":"npm:eslint","current":"v8.57.0","latest":"9.0.0","bump":"major"}`, string(data))
+}
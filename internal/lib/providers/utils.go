@@ -1,70 +1,109 @@
 package providers
 
-import "github.com/mistweaverco/zana-client/internal/lib/shell_out"
+import (
+	"context"
+
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
+)
 
 // ProviderHealthStatus represents the health status of a single provider
 type ProviderHealthStatus struct {
-	Provider     string `json:"provider"`
-	Available    bool   `json:"available"`
-	RequiredTool string `json:"required_tool,omitempty"`
-	Description  string `json:"description"`
+	Provider     string   `json:"provider"`
+	Available    bool     `json:"available"`
+	RequiredTool string   `json:"required_tool,omitempty"`
+	Description  string   `json:"description"`
+	InstallHint  string   `json:"install_hint,omitempty"`
+	FixCommand   []string `json:"fix_command,omitempty"`
+	// Disabled is set for providers listed in providers.disabled in config.yaml
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// Injectable for tests
+var healthHasCommand = hasCommand
+
+// providerRequirements lists the tool each provider shells out to
+var providerRequirements = []struct {
+	name        string
+	requiredCmd []string // Command and args to check
+	description string
+}{
+	{"npm", []string{"npm", "--version"}, "Node.js package manager for JavaScript packages"},
+	{"pypi", []string{"pip3", "--version"}, "Python package manager for Python packages"},
+	{"golang", []string{"go", "version"}, "Go programming language for Go packages"},
+	{"cargo", []string{"cargo", "--version"}, "Rust package manager for Rust packages"},
+	{"github", []string{"git", "--version"}, "Git for GitHub repository packages"},
+	{"gitlab", []string{"git", "--version"}, "Git for GitLab repository packages"},
+	{"codeberg", []string{"git", "--version"}, "Git for Codeberg repository packages"},
+	{"gitea", []string{"git", "--version"}, "Git for Gitea and Forgejo repository packages"},
+	{"gem", []string{"gem", "--version"}, "RubyGems for Ruby packages"},
+	{"composer", []string{"composer", "--version"}, "Composer for PHP packages"},
+	{"luarocks", []string{"luarocks", "--version"}, "LuaRocks for Lua packages"},
+	{"nuget", []string{"dotnet", "--version"}, ".NET SDK for NuGet packages"},
+	{"opam", []string{"opam", "--version"}, "OPAM for OCaml packages"},
+	{"openvsx", []string{"code", "--version"}, "VS Code CLI for OpenVSX extensions"},
+	{"generic", nil, "Generic provider (no specific tools required)"},
+	{"treesitter", []string{"cc", "--version"}, "C compiler (cc, gcc or clang) and Git for tree-sitter grammars"},
+}
+
+// installToolRegistryParser is injectable for tests
+var installToolRegistryParser = registry_parser.NewDefaultRegistryParser
+
+// InstallNeedsProviderTool reports whether installing sourceID shells out to
+// the tool CheckProviderHealth checks for its provider. OpenVSX extensions and
+// the release assets of the git hosting providers are only downloaded, the
+// latter check for git themselves when they fall back to a clone.
+func InstallNeedsProviderTool(sourceID string) bool {
+	provider, _ := packageid.Split(packageid.Normalize(sourceID))
+	switch provider {
+	case "openvsx":
+		return false
+	case "github", "gitlab", "codeberg", "gitea":
+		return len(installToolRegistryParser().GetBySourceId(sourceID).Source.Asset) == 0
+	}
+	return true
 }
 
 // CheckAllProvidersHealth checks all providers and returns their health status
 func CheckAllProvidersHealth() []ProviderHealthStatus {
 	var statuses []ProviderHealthStatus
-
-	// Check each provider
-	providers := []struct {
-		name        string
-		requiredCmd []string // Command and args to check
-		description string
-	}{
-		{"npm", []string{"npm", "--version"}, "Node.js package manager for JavaScript packages"},
-		{"pypi", []string{"pip3", "--version"}, "Python package manager for Python packages"},
-		{"golang", []string{"go", "version"}, "Go programming language for Go packages"},
-		{"cargo", []string{"cargo", "--version"}, "Rust package manager for Rust packages"},
-		{"github", []string{"git", "--version"}, "Git for GitHub repository packages"},
-		{"gitlab", []string{"git", "--version"}, "Git for GitLab repository packages"},
-		{"codeberg", []string{"git", "--version"}, "Git for Codeberg repository packages"},
-		{"gem", []string{"gem", "--version"}, "RubyGems for Ruby packages"},
-		{"composer", []string{"composer", "--version"}, "Composer for PHP packages"},
-		{"luarocks", []string{"luarocks", "--version"}, "LuaRocks for Lua packages"},
-		{"nuget", []string{"dotnet", "--version"}, ".NET SDK for NuGet packages"},
-		{"opam", []string{"opam", "--version"}, "OPAM for OCaml packages"},
-		{"openvsx", []string{"code", "--version"}, "VS Code CLI for OpenVSX extensions"},
-		{"generic", nil, "Generic provider (no specific tools required)"},
+	for _, p := range providerRequirements {
+		status := CheckProviderHealth(p.name)
+		status.Disabled = IsProviderDisabled(p.name)
+		statuses = append(statuses, status)
 	}
+	return statuses
+}
 
-	for _, p := range providers {
-		available := true
-		var requiredTool string
-		if len(p.requiredCmd) > 0 {
-			cmd := p.requiredCmd[0]
-			args := p.requiredCmd[1:]
-			available = shell_out.HasCommand(cmd, args, nil)
-			requiredTool = cmd
-			// Special handling for PyPI - check both pip3 and pip
-			if p.name == "pypi" && !available {
-				available = shell_out.HasCommand("pip", []string{"--version"}, nil)
-				if available {
-					requiredTool = "pip"
-				}
-			}
+// CheckProviderHealth checks whether the tool a provider shells out to is available.
+// For a missing tool the status includes installation instructions for the current OS.
+// Unknown providers are reported as available, as they have no requirements to check.
+func CheckProviderHealth(provider string) ProviderHealthStatus {
+	status := ProviderHealthStatus{Provider: provider, Available: true}
+	for _, p := range providerRequirements {
+		if p.name != provider {
+			continue
 		}
-
-		status := ProviderHealthStatus{
-			Provider:    p.name,
-			Available:   available,
-			Description: p.description,
+		status.Description = p.description
+		if len(p.requiredCmd) == 0 {
+			return status
 		}
-
-		if !available && requiredTool != "" {
-			status.RequiredTool = requiredTool
+		cmd := p.requiredCmd[0]
+		args := p.requiredCmd[1:]
+		status.Available = healthHasCommand(context.Background(), cmd, args, nil)
+		// Special handling for PyPI - check both pip3 and pip
+		if p.name == "pypi" && !status.Available {
+			status.Available = healthHasCommand(context.Background(), "pip", []string{"--version"}, nil)
 		}
-
-		statuses = append(statuses, status)
+		// Any C compiler builds tree-sitter grammars
+		if p.name == "treesitter" && !status.Available {
+			status.Available = healthHasCommand(context.Background(), "gcc", []string{"--version"}, nil) || healthHasCommand(context.Background(), "clang", []string{"--version"}, nil)
+		}
+		if !status.Available {
+			status.RequiredTool = cmd
+			status.InstallHint, status.FixCommand = ToolInstallHint(cmd)
+		}
+		return status
 	}
-
-	return statuses
+	return status
 }
@@ -3,7 +3,9 @@ package providers
 import (
 	"testing"
 
+	"github.com/mistweaverco/zana-client/internal/lib/registry_parser"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCheckAllProvidersHealth(t *testing.T) {
@@ -31,3 +33,24 @@ func TestCheckAllProvidersHealth(t *testing.T) {
 		assert.True(t, providerNames["generic"])
 	})
 }
+
+func TestInstallNeedsProviderTool(t *testing.T) {
+	orig := installToolRegistryParser
+	t.Cleanup(func() { installToolRegistryParser = orig })
+	installToolRegistryParser = func() *registry_parser.RegistryParser {
+		rp := registry_parser.NewRegistryParser(nil)
+		require.NoError(t, rp.LoadFromBytes([]byte(`[
+			{"name": "bat", "source": {"id": "pkg:github/sharkdp/bat", "asset": [{"target": "linux_x64", "file": "bat.tar.gz"}]}},
+			{"name": "glab", "source": {"id": "gitlab:gitlab-org/cli", "asset": [{"target": "linux_x64", "file": "glab.tar.gz"}]}},
+			{"name": "tool", "source": {"id": "github:owner/tool"}}
+		]`)))
+		return rp
+	}
+
+	assert.False(t, InstallNeedsProviderTool("github:sharkdp/bat"), "release assets are downloaded")
+	assert.False(t, InstallNeedsProviderTool("gitlab:gitlab-org/cli"))
+	assert.True(t, InstallNeedsProviderTool("github:owner/tool"), "cloned with git")
+	assert.True(t, InstallNeedsProviderTool("codeberg:owner/unknown"))
+	assert.False(t, InstallNeedsProviderTool("openvsx:redhat/vscode-yaml"), "the .vsix is only downloaded")
+	assert.True(t, InstallNeedsProviderTool("npm:prettier"))
+}
@@ -0,0 +1,191 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
+	"github.com/mistweaverco/zana-client/internal/lib/versioncmp"
+)
+
+// Shell completion of versions (zana add npm:prettier@<TAB>) must not keep the
+// shell waiting, so the registries are asked with a short timeout and their
+// answers are cached for versionCompletionMaxAge.
+const (
+	versionCompletionTimeout = 2 * time.Second
+	versionCompletionMaxAge  = time.Hour
+	// versionCompletionLimit is how many of the newest versions are offered
+	versionCompletionLimit = 30
+)
+
+// Injectable helpers for tests
+var versionCompletionHTTPClient = &http.Client{Timeout: versionCompletionTimeout}
+var versionCompletionCachePath = func() string {
+	return filepath.Join(files.GetCachePath(), "version-completions.json")
+}
+
+type versionCompletionCacheEntry struct {
+	Versions  []string  `json:"versions"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+var versionCompletionCacheMu sync.Mutex
+
+// SupportsVersionCompletion reports whether versions of the provider's packages can be completed
+func SupportsVersionCompletion(provider string) bool {
+	switch provider {
+	case "npm", "pypi", "cargo":
+		return true
+	}
+	return false
+}
+
+// CompletionVersions returns the newest versions of an npm, PyPI or crates.io
+// package, newest first. Errors (e.g. when offline) are logged only and
+// return no versions, as completion falls back to offering nothing.
+func CompletionVersions(sourceID string) []string {
+	sourceID = packageid.Normalize(sourceID)
+	provider, pkg := packageid.Split(sourceID)
+	if !SupportsVersionCompletion(provider) || pkg == "" {
+		return nil
+	}
+
+	versionCompletionCacheMu.Lock()
+	defer versionCompletionCacheMu.Unlock()
+	cache := readVersionCompletionCache()
+	now := clock.Now()
+	if entry, ok := cache[sourceID]; ok && now.Sub(entry.CheckedAt) < versionCompletionMaxAge {
+		return entry.Versions
+	}
+
+	versions, err := fetchCompletionVersions(provider, pkg)
+	if err != nil {
+		Logger.Info(fmt.Sprintf("Version completion: %s: %v", sourceID, err))
+		if entry, ok := cache[sourceID]; ok {
+			// Outdated versions are better than none
+			return entry.Versions
+		}
+		return nil
+	}
+	sortVersionsNewestFirst(versions)
+	if len(versions) > versionCompletionLimit {
+		versions = versions[:versionCompletionLimit]
+	}
+	cache[sourceID] = versionCompletionCacheEntry{Versions: versions, CheckedAt: now}
+	writeVersionCompletionCache(cache)
+	return versions
+}
+
+func fetchCompletionVersions(provider, pkg string) ([]string, error) {
+	switch provider {
+	case "npm":
+		var meta struct {
+			Versions map[string]json.RawMessage `json:"versions"`
+		}
+		// The abbreviated metadata is a fraction of the full document
+		if err := getCompletionJSON("https://registry.npmjs.org/"+strings.Replace(pkg, "/", "%2F", 1), "application/vnd.npm.install-v1+json", &meta); err != nil {
+			return nil, err
+		}
+		versions := make([]string, 0, len(meta.Versions))
+		for v := range meta.Versions {
+			versions = append(versions, v)
+		}
+		return versions, nil
+	case "pypi":
+		var meta struct {
+			Releases map[string][]struct {
+				Yanked bool `json:"yanked"`
+			} `json:"releases"`
+		}
+		if err := getCompletionJSON(fmt.Sprintf("https://pypi.org/pypi/%s/json", url.PathEscape(pkg)), "application/json", &meta); err != nil {
+			return nil, err
+		}
+		versions := make([]string, 0, len(meta.Releases))
+		for v, releaseFiles := range meta.Releases {
+			// Releases without files can't be installed, yanked ones shouldn't be
+			if len(releaseFiles) == 0 || releaseFiles[0].Yanked {
+				continue
+			}
+			versions = append(versions, v)
+		}
+		return versions, nil
+	case "cargo":
+		var meta struct {
+			Versions []struct {
+				Num    string `json:"num"`
+				Yanked bool   `json:"yanked"`
+			} `json:"versions"`
+		}
+		if err := getCompletionJSON(fmt.Sprintf("https://crates.io/api/v1/crates/%s/versions", url.PathEscape(pkg)), "application/json", &meta); err != nil {
+			return nil, err
+		}
+		versions := make([]string, 0, len(meta.Versions))
+		for _, v := range meta.Versions {
+			if !v.Yanked {
+				versions = append(versions, v.Num)
+			}
+		}
+		return versions, nil
+	}
+	return nil, fmt.Errorf("provider %s has no version completion", provider)
+}
+
+func getCompletionJSON(u, accept string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", accept)
+	// crates.io rejects requests without a User-Agent
+	req.Header.Set("User-Agent", "zana-client (https://github.com/mistweaverco/zana-client)")
+	resp, err := versionCompletionHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// sortVersionsNewestFirst sorts versions in descending order; versions that
+// can't be compared keep their relative order
+func sortVersionsNewestFirst(versions []string) {
+	sort.Strings(versions)
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versioncmp.Compare(versions[i], versions[j]) > 0
+	})
+}
+
+func readVersionCompletionCache() map[string]versionCompletionCacheEntry {
+	cache := map[string]versionCompletionCacheEntry{}
+	data, err := os.ReadFile(versionCompletionCachePath())
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return map[string]versionCompletionCacheEntry{}
+	}
+	return cache
+}
+
+func writeVersionCompletionCache(cache map[string]versionCompletionCacheEntry) {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err == nil {
+		err = os.WriteFile(versionCompletionCachePath(), data, 0644)
+	}
+	if err != nil {
+		Logger.Info(fmt.Sprintf("Version completion: Warning writing cache: %v", err))
+	}
+}
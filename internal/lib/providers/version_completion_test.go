@@ -0,0 +1,88 @@
+package providers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+type completionTransport func(*http.Request) (*http.Response, error)
+
+func (f completionTransport) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// stubVersionCompletion answers registry requests with the body for the
+// requested URL and returns the requested URLs
+func stubVersionCompletion(t *testing.T, bodies map[string]string) *[]string {
+	t.Helper()
+	prevClient, prevPath := versionCompletionHTTPClient, versionCompletionCachePath
+	t.Cleanup(func() {
+		versionCompletionHTTPClient, versionCompletionCachePath = prevClient, prevPath
+	})
+	path := filepath.Join(t.TempDir(), "version-completions.json")
+	versionCompletionCachePath = func() string { return path }
+	requested := []string{}
+	versionCompletionHTTPClient = &http.Client{Transport: completionTransport(func(req *http.Request) (*http.Response, error) {
+		requested = append(requested, req.URL.String())
+		body, ok := bodies[req.URL.String()]
+		if !ok {
+			return nil, errors.New("offline")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: http.Header{}}, nil
+	})}
+	return &requested
+}
+
+func TestCompletionVersions(t *testing.T) {
+	t.Run("npm versions newest first", func(t *testing.T) {
+		stubVersionCompletion(t, map[string]string{
+			"https://registry.npmjs.org/@scope%2Fpkg": `{"versions":{"1.2.0":{},"1.10.0":{},"2.0.0-beta.1":{},"1.9.3":{}}}`,
+		})
+		assert.Equal(t, []string{"2.0.0-beta.1", "1.10.0", "1.9.3", "1.2.0"}, CompletionVersions("npm:@scope/pkg"))
+	})
+
+	t.Run("pypi skips yanked releases and releases without files", func(t *testing.T) {
+		stubVersionCompletion(t, map[string]string{
+			"https://pypi.org/pypi/black/json": `{"releases":{"24.1.0":[{"yanked":false}],"24.2.0":[{"yanked":true}],"23.0.0":[],"24.1.1":[{"yanked":false}]}}`,
+		})
+		assert.Equal(t, []string{"24.1.1", "24.1.0"}, CompletionVersions("pypi:black"))
+	})
+
+	t.Run("cargo skips yanked versions", func(t *testing.T) {
+		stubVersionCompletion(t, map[string]string{
+			"https://crates.io/api/v1/crates/ripgrep/versions": `{"versions":[{"num":"14.1.0","yanked":false},{"num":"14.0.0","yanked":true},{"num":"13.0.0","yanked":false}]}`,
+		})
+		assert.Equal(t, []string{"14.1.0", "13.0.0"}, CompletionVersions("cargo:ripgrep"))
+	})
+
+	t.Run("answers are cached", func(t *testing.T) {
+		requested := stubVersionCompletion(t, map[string]string{
+			"https://crates.io/api/v1/crates/ripgrep/versions": `{"versions":[{"num":"14.1.0"}]}`,
+		})
+		fake := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+		t.Cleanup(clock.Set(fake))
+		assert.Equal(t, []string{"14.1.0"}, CompletionVersions("cargo:ripgrep"))
+		assert.Equal(t, []string{"14.1.0"}, CompletionVersions("cargo:ripgrep"))
+		assert.Len(t, *requested, 1)
+
+		// An outdated entry is still used when the registry can't be reached
+		fake.Advance(2 * versionCompletionMaxAge)
+		versionCompletionHTTPClient = &http.Client{Transport: completionTransport(func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("offline")
+		})}
+		assert.Equal(t, []string{"14.1.0"}, CompletionVersions("cargo:ripgrep"))
+	})
+
+	t.Run("offline and unsupported providers offer nothing", func(t *testing.T) {
+		requested := stubVersionCompletion(t, nil)
+		assert.Empty(t, CompletionVersions("npm:prettier"))
+		assert.Empty(t, CompletionVersions("github:owner/repo"))
+		assert.Len(t, *requested, 1)
+	})
+}
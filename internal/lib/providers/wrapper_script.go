@@ -0,0 +1,82 @@
+package providers
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// wrapperEnv is an environment variable exported by a wrapper script
+type wrapperEnv struct {
+	Name  string
+	Value string
+	// Separator prepends Value to the variable's current value, joined with
+	// Separator (e.g. ":" for PATH). The separator is only added when the
+	// variable is set and non-empty, so no empty entry (meaning the current
+	// directory for PATH and PYTHONPATH) sneaks in.
+	Separator string
+}
+
+// wrapperScript is a POSIX sh script placed in the zana bin dir that prepares
+// the environment of a package and replaces itself with the package's command.
+// Because the command is exec'ed, no shell stays around as parent process:
+// signals reach the command directly and its exit code is the script's.
+type wrapperScript struct {
+	// Description is written as comment below the shebang
+	Description string
+	Env         []wrapperEnv
+	// Command is the executable followed by any fixed arguments;
+	// the arguments the wrapper is called with are appended unchanged.
+	Command []string
+}
+
+var shellSafeWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes s for POSIX sh, leaving words without special characters as they are
+func shellQuote(s string) string {
+	if shellSafeWord.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// render returns the script content
+func (w wrapperScript) render() (string, error) {
+	if len(w.Command) == 0 || strings.TrimSpace(w.Command[0]) == "" {
+		return "", fmt.Errorf("empty command")
+	}
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	if w.Description != "" {
+		b.WriteString("# " + w.Description + "\n")
+	}
+	b.WriteString("\n")
+	for _, env := range w.Env {
+		if env.Separator == "" {
+			fmt.Fprintf(&b, "export %s=%s\n", env.Name, shellQuote(env.Value))
+			continue
+		}
+		fmt.Fprintf(&b, "export %s=%s\"${%s:+%s$%s}\"\n", env.Name, shellQuote(env.Value), env.Name, env.Separator, env.Name)
+	}
+	if len(w.Env) > 0 {
+		b.WriteString("\n")
+	}
+	words := make([]string, 0, len(w.Command)+1)
+	for _, word := range w.Command {
+		words = append(words, shellQuote(word))
+	}
+	words = append(words, `"$@"`)
+	b.WriteString("exec " + strings.Join(words, " ") + "\n")
+	return b.String(), nil
+}
+
+// writeWrapperScript renders w and writes it to path with writeFile
+// (the provider's injectable os.WriteFile).
+func writeWrapperScript(w wrapperScript, path string, writeFile func(string, []byte, os.FileMode) error) error {
+	content, err := w.render()
+	if err != nil {
+		return fmt.Errorf("%w for wrapper %s", err, path)
+	}
+	return writeFile(path, []byte(content), 0755)
+}
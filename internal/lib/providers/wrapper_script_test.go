@@ -0,0 +1,73 @@
+package providers
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, "yamllint", shellQuote("yamllint"))
+	assert.Equal(t, "/opt/zana/bin", shellQuote("/opt/zana/bin"))
+	assert.Equal(t, "'/my tools/bin'", shellQuote("/my tools/bin"))
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+	assert.Equal(t, "'$HOME'", shellQuote("$HOME"))
+	assert.Equal(t, "''", shellQuote(""))
+}
+
+func TestWrapperScriptRender(t *testing.T) {
+	script := wrapperScript{
+		Description: "Runs the tool",
+		Env: []wrapperEnv{
+			{Name: "PYTHONNOUSERSITE", Value: "1"},
+			{Name: "PYTHONPATH", Value: "/site packages", Separator: ":"},
+		},
+		Command: []string{"yamllint"},
+	}
+	content, err := script.render()
+	require.NoError(t, err)
+	assert.Equal(t, `#!/bin/sh
+# Runs the tool
+
+export PYTHONNOUSERSITE=1
+export PYTHONPATH='/site packages'"${PYTHONPATH:+:$PYTHONPATH}"
+
+exec yamllint "$@"
+`, content)
+
+	_, err = wrapperScript{}.render()
+	assert.Error(t, err)
+}
+
+func TestWrapperScriptExecution(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("wrapper scripts are POSIX sh")
+	}
+	dir := filepath.Join(t.TempDir(), "dir with 'quotes' and $vars")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	target := filepath.Join(dir, "print-args")
+	require.NoError(t, os.WriteFile(target, []byte("#!/bin/sh\nprintf '%s|' \"$@\" \"$EXTRA_PATH\" \"$$\"\n"), 0755))
+
+	wrapperPath := filepath.Join(t.TempDir(), "wrapper")
+	err := writeWrapperScript(wrapperScript{
+		Env:     []wrapperEnv{{Name: "EXTRA_PATH", Value: dir, Separator: ":"}},
+		Command: []string{target, "fixed arg"},
+	}, wrapperPath, os.WriteFile)
+	require.NoError(t, err)
+
+	cmd := exec.Command(wrapperPath, "a b", "", "*", `"quoted"`)
+	cmd.Env = append(os.Environ(), "EXTRA_PATH=")
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	parts := strings.Split(string(out), "|")
+	assert.Equal(t, []string{"fixed arg", "a b", "", "*", `"quoted"`, dir}, parts[:6])
+	// exec replaces the wrapper's shell, so the command runs as the wrapper's process
+	assert.Equal(t, strconv.Itoa(cmd.Process.Pid), parts[6])
+}
@@ -172,6 +172,24 @@ func TestLoadFromBytes(t *testing.T) {
 		assert.True(t, parser.HasDataForTesting())
 	})
 
+	t.Run("normalizes legacy source IDs", func(t *testing.T) {
+		parser := NewRegistryParser(&mockFileReader{})
+
+		jsonData := `[
+			{"name": "a", "version": "1.0.0", "source": {"id": "pkg:npm/a"}},
+			{"name": "b", "version": "1.0.0", "source": {"id": "pkg:github/owner/b"}},
+			{"name": "c", "version": "1.0.0", "source": {"id": "cargo:c"}}
+		]`
+
+		require.NoError(t, parser.LoadFromBytes([]byte(jsonData)))
+
+		data := parser.GetDataForTesting()
+		assert.Equal(t, "npm:a", data[0].Source.ID)
+		assert.Equal(t, "github:owner/b", data[1].Source.ID)
+		assert.Equal(t, "cargo:c", data[2].Source.ID)
+		assert.Equal(t, "npm:a", parser.GetByNameOrAlias("a").Source.ID)
+	})
+
 	t.Run("returns error for invalid JSON", func(t *testing.T) {
 		mockReader := &mockFileReader{}
 		parser := NewRegistryParser(mockReader)
@@ -520,3 +538,79 @@ func TestGetByNameOrAlias(t *testing.T) {
 		assert.Equal(t, "2.0.0", item.Version)
 	})
 }
+
+func TestGitLabPackage(t *testing.T) {
+	parser := NewRegistryParser(&mockFileReader{})
+	require.NoError(t, parser.LoadFromBytes([]byte(`[
+		{"name": "tool", "source": {"id": "gitlab:group/sub/tool", "gitlab_package": {}}},
+		{"name": "cli", "source": {"id": "gitlab:group/app", "gitlab_package": {"name": "app-cli"}}},
+		{"name": "other", "source": {"id": "gitlab:group/other"}}
+	]`)))
+
+	pkg := parser.GetBySourceId("gitlab:group/sub/tool").Source.GitLabPackage
+	require.NotNil(t, pkg)
+	assert.Equal(t, "tool", pkg.PackageName("group/sub/tool"))
+
+	pkg = parser.GetBySourceId("gitlab:group/app").Source.GitLabPackage
+	require.NotNil(t, pkg)
+	assert.Equal(t, "app-cli", pkg.PackageName("group/app"))
+
+	assert.Nil(t, parser.GetBySourceId("gitlab:group/other").Source.GitLabPackage)
+}
+
+func TestGetBundlesIncluding(t *testing.T) {
+	parser := NewRegistryParser(&mockFileReader{})
+	require.NoError(t, parser.LoadFromBytes([]byte(`[
+		{"name": "ruff", "source": {"id": "pypi:ruff"}},
+		{"name": "python-dev", "source": {"id": "github:zana/python-dev"}, "includes": ["pypi:ruff", "pkg:pypi/python-lsp-server"]},
+		{"name": "python-lint", "source": {"id": "github:zana/python-lint"}, "includes": ["pypi:ruff"]},
+		{"name": "web-dev", "source": {"id": "github:zana/web-dev"}, "includes": ["npm:prettier"]}
+	]`)))
+
+	var names []string
+	for _, b := range parser.GetBundlesIncluding("pypi:ruff") {
+		names = append(names, b.Name)
+	}
+	assert.Equal(t, []string{"python-dev", "python-lint"}, names)
+
+	bundles := parser.GetBundlesIncluding("pkg:pypi/python-lsp-server")
+	require.Len(t, bundles, 1)
+	assert.Equal(t, "python-dev", bundles[0].Name)
+
+	assert.Empty(t, parser.GetBundlesIncluding("npm:eslint"))
+}
+
+func TestRegistryItemJSONRoundTrip(t *testing.T) {
+	parser := NewRegistryParser(&mockFileReader{})
+	require.NoError(t, parser.LoadFromBytes([]byte(`[
+		{"name": "tool", "version": "v1.0.0", "source": {"id": "github:owner/tool", "asset": [
+			{"target": "linux_x64", "file": "tool.tar.gz", "bin": "tool"},
+			{"target": ["darwin_x64", "darwin_arm64"], "file": ["tool.zip", "tool-extra.zip"]}
+		]}, "bin": {"tool": "tool"}}
+	]`)))
+
+	data, err := json.Marshal(parser.GetBySourceId("github:owner/tool"))
+	require.NoError(t, err)
+	var item RegistryItem
+	require.NoError(t, json.Unmarshal(data, &item))
+	require.Len(t, item.Source.Asset, 2)
+	assert.Equal(t, "tool.tar.gz", item.Source.Asset[0].File.String())
+	assert.Equal(t, []string{"tool.zip", "tool-extra.zip"}, item.Source.Asset[1].File.GetArray())
+	assert.Equal(t, map[string]string{"tool": "tool"}, item.Bin)
+}
+
+func TestSchemasKind(t *testing.T) {
+	parser := NewRegistryParser(&mockFileReader{})
+	require.NoError(t, parser.LoadFromBytes([]byte(`[
+		{"name": "schemas", "kind": "schemas", "source": {"id": "github:owner/schemas"},
+		 "schemas": [{"file": "tsconfig.json", "name": "TypeScript config", "file_match": ["tsconfig*.json"]}]},
+		{"name": "fonts", "kind": "data", "source": {"id": "github:owner/fonts"}},
+		{"name": "tool", "source": {"id": "github:owner/tool"}}
+	]`)))
+
+	item := parser.GetBySourceId("github:owner/schemas")
+	assert.True(t, item.IsDataOnly())
+	assert.Equal(t, []RegistryItemSchema{{File: "tsconfig.json", Name: "TypeScript config", FileMatch: []string{"tsconfig*.json"}}}, item.Schemas)
+	assert.True(t, parser.GetBySourceId("github:owner/fonts").IsDataOnly())
+	assert.False(t, parser.GetBySourceId("github:owner/tool").IsDataOnly())
+}
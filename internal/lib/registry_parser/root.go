@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/packageid"
 )
 
 // FileReader interface for dependency injection in tests
@@ -65,6 +66,11 @@ func (f *RegistryItemSourceAssetFile) UnmarshalJSON(data []byte) error {
 	return fmt.Errorf("cannot unmarshal file: expected string or array")
 }
 
+// MarshalJSON writes the file as it was read, as a string or an array
+func (f RegistryItemSourceAssetFile) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.value)
+}
+
 func (f *RegistryItemSourceAssetFile) String() string {
 	if str, ok := f.value.(string); ok {
 		return str
@@ -94,6 +100,15 @@ type RegistryItemSourceAsset struct {
 	Target interface{}                 `json:"target"` // Can be string or []string
 	File   RegistryItemSourceAssetFile `json:"file"`
 	Bin    interface{}                 `json:"bin,omitempty"` // Can be string or map[string]string
+	// StripComponents removes that many leading directories from the
+	// extracted archive, like tar --strip-components
+	StripComponents *int `json:"strip_components,omitempty"`
+	// Size is the download size of the asset in bytes, if the registry knows it
+	Size int64 `json:"size,omitempty"`
+	// SHA256 and SHA512 are the hex encoded checksums of the asset, if the
+	// registry knows them
+	SHA256 string `json:"sha256,omitempty"`
+	SHA512 string `json:"sha512,omitempty"`
 }
 
 // RegistryItemSourceAssetList is a custom type that can unmarshal both a single object and an array
@@ -128,6 +143,9 @@ type RegistryItemSourceDownloadFile struct {
 	Target interface{}       `json:"target"` // Can be string or []string
 	Files  map[string]string `json:"files"`  // Map of filename -> URL
 	Bin    string            `json:"bin,omitempty"`
+	// StripComponents removes that many leading directories from each
+	// extracted archive, like tar --strip-components
+	StripComponents *int `json:"strip_components,omitempty"`
 }
 
 type RegistryItemSourceDownloadList []RegistryItemSourceDownloadFile
@@ -161,6 +179,24 @@ type RegistryItemSource struct {
 	ID       string                         `json:"id"`
 	Asset    RegistryItemSourceAssetList    `json:"asset,omitempty"`
 	Download RegistryItemSourceDownloadList `json:"download,omitempty"`
+
+	// GitLabPackage makes gitlab: packages download their assets from
+	// the project's generic package registry instead of its releases
+	GitLabPackage *RegistryItemSourceGitLabPackage `json:"gitlab_package,omitempty"`
+}
+
+// RegistryItemSourceGitLabPackage names a package in a GitLab project's generic package registry
+type RegistryItemSourceGitLabPackage struct {
+	// Name is the package name, defaults to the last segment of the project path
+	Name string `json:"name,omitempty"`
+}
+
+// PackageName returns the generic package name for the GitLab project path repo
+func (p *RegistryItemSourceGitLabPackage) PackageName(repo string) string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return repo[strings.LastIndex(repo, "/")+1:]
 }
 
 // RegistryItemTreeSitterExternalQueries points at a separate repository that only
@@ -233,6 +269,13 @@ func (r *RegistryItemRequires) IsEmpty() bool {
 	return r == nil || (len(r.All) == 0 && len(r.One) == 0)
 }
 
+// RegistryItemBinFilter holds glob patterns matched against executable names.
+// Excluded names are never linked; when Include is set, only matching names are.
+type RegistryItemBinFilter struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
 type RegistryItem struct {
 	Name              string                  `json:"name"`
 	Version           string                  `json:"version"`
@@ -247,26 +290,63 @@ type RegistryItem struct {
 	Bin               map[string]string       `json:"bin"`
 	TreeSitter        *RegistryItemTreeSitter `json:"treesitter,omitempty"`
 	Requires          *RegistryItemRequires   `json:"requires,omitempty"`
+	// SupportedPlatforms restricts the package to these registry targets
+	// (e.g. linux_x64, darwin, win, unix). Empty means all platforms.
+	SupportedPlatforms []string `json:"supported_platforms,omitempty"`
+	// Kind is empty for regular packages, KindData for packages shipping
+	// content only (fonts, dictionaries, grammars) or KindSchemas for data
+	// packages shipping JSON schemas and config templates, see IsDataOnly
+	Kind string `json:"kind,omitempty"`
+	// Schemas describes the JSON schemas of a KindSchemas package for the
+	// schema catalog. Without it, every .json file of the package is listed.
+	Schemas []RegistryItemSchema `json:"schemas,omitempty"`
+	// BinFilter limits which executables are linked into the bin directory
+	BinFilter *RegistryItemBinFilter `json:"bin_filter,omitempty"`
+	// Includes makes the entry a bundle: a curated set of packages used
+	// together, e.g. the LSP server, linter and formatter of a language
+	Includes []string `json:"includes,omitempty"`
+	// SmokeTest is a command checking that an install works, e.g.
+	// "pyright --version", run after installs when smoke tests are enabled
+	SmokeTest string `json:"smoke_test,omitempty"`
+	// Deprecated marks a package that shouldn't be used anymore, optionally
+	// naming the package replacing it
+	Deprecated *RegistryItemDeprecation `json:"deprecated,omitempty"`
+}
+
+// RegistryItemDeprecation explains why a package is deprecated
+type RegistryItemDeprecation struct {
+	// Reason is shown to users, e.g. "renamed upstream"
+	Reason string `json:"reason,omitempty"`
+	// Replacement is the source ID of the package to use instead
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// KindData marks packages without executables. Their content is installed
+// into the share directory and nothing is linked into the bin directory.
+const KindData = "data"
+
+// KindSchemas marks data packages shipping JSON schemas or config
+// templates, which zana lists in its schema catalog for editors
+const KindSchemas = "schemas"
+
+// RegistryItemSchema is a JSON schema shipped by a KindSchemas package
+type RegistryItemSchema struct {
+	// File is the path of the schema inside the package, e.g. schemas/tsconfig.json
+	File string `json:"file"`
+	// Name defaults to the file name without extension
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	// FileMatch are the globs of the files the schema applies to, e.g. tsconfig*.json
+	FileMatch []string `json:"file_match,omitempty"`
+}
+
+// IsDataOnly reports whether the package ships content only
+func (item RegistryItem) IsDataOnly() bool {
+	return item.Kind == KindData || item.Kind == KindSchemas
 }
 
 type RegistryRoot []RegistryItem
 
-func normalizeSourceID(id string) string {
-	// New format: "<provider>:<id>"
-	if strings.Contains(id, ":") && !strings.HasPrefix(id, "pkg:") {
-		return id
-	}
-	// Legacy format: "pkg:<provider>/<id>"
-	if strings.HasPrefix(id, "pkg:") {
-		withoutPrefix := strings.TrimPrefix(id, "pkg:")
-		parts := strings.SplitN(withoutPrefix, "/", 2)
-		if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
-			return parts[0] + ":" + parts[1]
-		}
-	}
-	return id
-}
-
 // GetData retrieves registry data, optionally forcing a refresh
 func (rp *RegistryParser) GetData(force bool) RegistryRoot {
 	if rp.hasData && !force {
@@ -288,9 +368,9 @@ func (rp *RegistryParser) GetData(force bool) RegistryRoot {
 // GetBySourceId finds a registry item by its source ID
 func (rp *RegistryParser) GetBySourceId(sourceId string) RegistryItem {
 	registryRoot := rp.GetData(false)
-	want := normalizeSourceID(sourceId)
+	want := packageid.Normalize(sourceId)
 	for _, item := range registryRoot {
-		if item.Source.ID == sourceId || normalizeSourceID(item.Source.ID) == want {
+		if item.Source.ID == sourceId || packageid.Normalize(item.Source.ID) == want {
 			return item
 		}
 	}
@@ -335,6 +415,21 @@ func (rp *RegistryParser) GetByNameOrAlias(name string) RegistryItem {
 	return RegistryItem{}
 }
 
+// GetBundlesIncluding returns the bundles whose includes list the package
+func (rp *RegistryParser) GetBundlesIncluding(sourceId string) []RegistryItem {
+	sourceId = packageid.Normalize(sourceId)
+	var bundles []RegistryItem
+	for _, item := range rp.GetData(false) {
+		for _, ref := range item.Includes {
+			if packageid.Normalize(strings.TrimSpace(ref)) == sourceId {
+				bundles = append(bundles, item)
+				break
+			}
+		}
+	}
+	return bundles
+}
+
 // LoadFromBytes loads registry data from JSON bytes
 func (rp *RegistryParser) LoadFromBytes(data []byte) error {
 	var registry RegistryRoot
@@ -342,6 +437,12 @@ func (rp *RegistryParser) LoadFromBytes(data []byte) error {
 		return fmt.Errorf("failed to parse registry data: %w", err)
 	}
 
+	// Registries may still use legacy IDs (pkg:provider/pkg), everything
+	// after this works with the provider:pkg format of the lock file
+	for i := range registry {
+		registry[i].Source.ID = packageid.Normalize(registry[i].Source.ID)
+	}
+
 	// Sort the registry by name
 	sort.Slice(registry, func(i, j int) bool {
 		return registry[i].Name < registry[j].Name
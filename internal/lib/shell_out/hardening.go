@@ -0,0 +1,136 @@
+package shell_out
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/log"
+)
+
+// Injectable helpers for tests
+var hardeningConfig = files.GetSubprocessHardening
+var hardeningGOOS = runtime.GOOS
+var hardeningLookPath = exec.LookPath
+var hardeningEnviron = os.Environ
+
+// unshareAvailable reports whether unshare can drop the network of a
+// subprocess, which needs unprivileged user namespaces
+var unshareAvailable = sync.OnceValue(func() bool {
+	if _, err := exec.LookPath("unshare"); err != nil {
+		return false
+	}
+	return exec.Command("unshare", "--net", "--map-root-user", "true").Run() == nil
+})
+
+// hardenedEnv are the environment variables hardened subprocesses keep,
+// besides those of hardening.env
+var hardenedEnv = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "TZ", "LANG", "LANGUAGE",
+	"TMPDIR", "TMP", "TEMP",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "ALL_PROXY",
+	"SSL_CERT_FILE", "SSL_CERT_DIR",
+	// Windows
+	"SYSTEMROOT", "SYSTEMDRIVE", "WINDIR", "COMSPEC", "PATHEXT",
+	"USERPROFILE", "APPDATA", "LOCALAPPDATA", "PROGRAMDATA",
+}
+
+// hardenedEnvPrefixes are the prefixes of the environment variables hardened
+// subprocesses keep
+var hardenedEnvPrefixes = []string{"LC_", "XDG_", "ZANA_"}
+
+// hardening returns the hardening config when the subprocesses of the package
+// operation ctx carries are hardened
+func hardening(ctx context.Context) (files.SubprocessHardening, bool) {
+	op, ok := log.OperationFrom(ctx)
+	if !ok {
+		return files.SubprocessHardening{}, false
+	}
+	provider, _, _ := strings.Cut(op.Package, ":")
+	h := hardeningConfig()
+	return h, h.Hardens(provider)
+}
+
+// newCommand creates the command of a subprocess, with env added to zana's
+// environment, prefixed with the wrapper configured for the package of the
+// operation ctx carries.
+// Subprocesses of hardened providers only get the basic environment
+// variables and run with lowered CPU and I/O priority.
+// Verify-only steps (offline) run without network on Linux, if possible.
+func newCommand(ctx context.Context, command string, args []string, env []string, offline bool) *exec.Cmd {
+	argv := wrappedCommandLine(ctx, append([]string{command}, args...))
+	h, hardened := hardening(ctx)
+	if !hardened {
+		cmd := exec.Command(argv[0], argv[1:]...)
+		if env != nil {
+			env = append(env, os.Environ()...)
+			cmd.Env = append(cmd.Env, env...)
+		}
+		return cmd
+	}
+	argv = hardenedCommandLine(h, argv, offline)
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Env = append(append([]string{}, env...), hardenedEnviron(h.Env)...)
+	return cmd
+}
+
+// hardenedCommandLine wraps argv with the tools lowering its priority and
+// dropping its network. Tools that aren't installed are left out.
+func hardenedCommandLine(h files.SubprocessHardening, argv []string, offline bool) []string {
+	if hardeningGOOS == "windows" {
+		return argv
+	}
+	if offline && hardeningGOOS == "linux" && unshareAvailable() {
+		argv = append([]string{"unshare", "--net", "--map-root-user", "--"}, argv...)
+	}
+	if nice := h.NiceLevel(); nice != 0 {
+		if _, err := hardeningLookPath("nice"); err == nil {
+			argv = append([]string{"nice", "-n", strconv.Itoa(nice)}, argv...)
+		}
+	}
+	if hardeningGOOS == "linux" {
+		class := ""
+		switch strings.ToLower(strings.TrimSpace(h.IoniceClass)) {
+		case "", "idle":
+			class = "3"
+		case "best-effort":
+			class = "2"
+		}
+		if _, err := hardeningLookPath("ionice"); err == nil && class != "" {
+			argv = append([]string{"ionice", "-c", class}, argv...)
+		}
+	}
+	return argv
+}
+
+// hardenedEnviron returns the variables of zana's environment hardened
+// subprocesses keep: the basic ones and those named in extra
+func hardenedEnviron(extra []string) []string {
+	keep := map[string]struct{}{}
+	for _, name := range append(append([]string{}, hardenedEnv...), extra...) {
+		keep[strings.ToUpper(name)] = struct{}{}
+	}
+	var env []string
+	for _, kv := range hardeningEnviron() {
+		name, _, _ := strings.Cut(kv, "=")
+		upper := strings.ToUpper(name)
+		if _, ok := keep[upper]; ok || hasAnyPrefix(upper, hardenedEnvPrefixes) {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package shell_out
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubHardening(t *testing.T, goos string, h files.SubprocessHardening) {
+	t.Helper()
+	prevConfig, prevGOOS, prevLookPath, prevEnviron, prevUnshare := hardeningConfig, hardeningGOOS, hardeningLookPath, hardeningEnviron, unshareAvailable
+	t.Cleanup(func() {
+		hardeningConfig, hardeningGOOS, hardeningLookPath, hardeningEnviron, unshareAvailable = prevConfig, prevGOOS, prevLookPath, prevEnviron, prevUnshare
+	})
+	hardeningConfig = func() files.SubprocessHardening { return h }
+	hardeningGOOS = goos
+	hardeningLookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+	hardeningEnviron = func() []string {
+		return []string{"PATH=/usr/bin", "HOME=/home/user", "LC_ALL=C", "AWS_SECRET_ACCESS_KEY=secret", "NPM_TOKEN=token", "ZANA_HOME=/zana"}
+	}
+	unshareAvailable = func() bool { return true }
+}
+
+// installing returns a context carrying the install of pkg
+func installing(pkg string) context.Context {
+	return log.WithOperation(context.Background(), log.NewOperation(files.HistoryActionInstall, pkg))
+}
+
+func TestNewCommandHardened(t *testing.T) {
+	stubHardening(t, "linux", files.SubprocessHardening{Providers: []string{"npm"}, Env: []string{"NPM_TOKEN"}})
+
+	t.Run("outside of hardened operations nothing changes", func(t *testing.T) {
+		cmd := newCommand(context.Background(), "npm", []string{"install"}, nil, false)
+		assert.Equal(t, []string{"npm", "install"}, cmd.Args)
+		assert.Nil(t, cmd.Env)
+
+		cmd = newCommand(installing("pypi:black"), "pip", []string{"install"}, nil, false)
+		assert.Equal(t, []string{"pip", "install"}, cmd.Args)
+	})
+
+	t.Run("hardened subprocesses get a cleaned environment and lower priority", func(t *testing.T) {
+		cmd := newCommand(installing("npm:prettier"), "npm", []string{"install"}, []string{"npm_config_prefix=/zana/npm"}, false)
+		assert.Equal(t, []string{"ionice", "-c", "3", "nice", "-n", "10", "npm", "install"}, cmd.Args)
+		assert.Equal(t, []string{"npm_config_prefix=/zana/npm", "PATH=/usr/bin", "HOME=/home/user", "LC_ALL=C", "NPM_TOKEN=token", "ZANA_HOME=/zana"}, cmd.Env)
+	})
+
+	t.Run("verify-only steps run without network", func(t *testing.T) {
+		cmd := newCommand(installing("npm:prettier"), "node", []string{"--version"}, nil, true)
+		assert.Equal(t, []string{"ionice", "-c", "3", "nice", "-n", "10", "unshare", "--net", "--map-root-user", "--", "node", "--version"}, cmd.Args)
+	})
+}
+
+func TestHardenedCommandLine(t *testing.T) {
+	zero := 0
+	argv := []string{"cargo", "install", "ripgrep"}
+
+	stubHardening(t, "darwin", files.SubprocessHardening{})
+	assert.Equal(t, []string{"nice", "-n", "10", "cargo", "install", "ripgrep"}, hardenedCommandLine(files.SubprocessHardening{}, argv, true))
+
+	stubHardening(t, "windows", files.SubprocessHardening{})
+	assert.Equal(t, argv, hardenedCommandLine(files.SubprocessHardening{}, argv, true))
+
+	stubHardening(t, "linux", files.SubprocessHardening{})
+	assert.Equal(t, argv, hardenedCommandLine(files.SubprocessHardening{Nice: &zero, IoniceClass: "none"}, argv, false))
+	assert.Equal(t, []string{"ionice", "-c", "2", "cargo", "install", "ripgrep"}, hardenedCommandLine(files.SubprocessHardening{Nice: &zero, IoniceClass: "best-effort"}, argv, false))
+
+	hardeningLookPath = func(file string) (string, error) { return "", fmt.Errorf("%s: %w", file, exec.ErrNotFound) }
+	unshareAvailable = func() bool { return false }
+	assert.Equal(t, argv, hardenedCommandLine(files.SubprocessHardening{}, argv, true), "missing tools are left out")
+}
+
+func TestShellOutHardened(t *testing.T) {
+	stubHardening(t, "linux", files.SubprocessHardening{Providers: []string{"*"}})
+	hardeningLookPath = exec.LookPath
+	unshareAvailable = func() bool { return false }
+	code, out, err := ShellOutCaptureContext(installing("npm:prettier"), "sh", []string{"-c", "echo ${AWS_SECRET_ACCESS_KEY:-unset} $FOO"}, "", []string{"FOO=bar"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "unset bar\n", out)
+}
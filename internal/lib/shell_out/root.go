@@ -1,18 +1,39 @@
 package shell_out
 
 import (
+	"context"
 	"os"
 	"os/exec"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/chaos"
+	"github.com/mistweaverco/zana-client/internal/lib/trace"
 )
 
+// startSpan starts the --trace span of running command
+func startSpan(ctx context.Context, command string, args []string) *trace.Span {
+	if !trace.Enabled() {
+		return nil
+	}
+	return trace.StartContext(ctx, "exec "+command, "process.executable.name", command, "process.command_args", strings.Join(args, " "))
+}
+
 func ShellOut(command string, args []string, dir string, env []string) (int, error) {
-	cmd := exec.Command(command, args...)
-	cmd.Dir = dir
-	if env != nil {
-		env = append(env, os.Environ()...)
-		cmd.Env = append(cmd.Env, env...)
+	return ShellOutContext(context.Background(), command, args, dir, env)
+}
+
+// ShellOutContext runs a command like ShellOut. When ctx carries a package
+// operation (see log.WithOperation), the command is run with the wrapper and
+// hardening configured for its package.
+func ShellOutContext(ctx context.Context, command string, args []string, dir string, env []string) (int, error) {
+	if err := chaos.Fail(command); err != nil {
+		return 1, err
 	}
+	cmd := newCommand(ctx, command, args, env, false)
+	cmd.Dir = dir
+	span := startSpan(ctx, command, args)
 	err := cmd.Run()
+	span.End(err)
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
 			return exitError.ExitCode(), err
@@ -23,12 +44,17 @@ func ShellOut(command string, args []string, dir string, env []string) (int, err
 }
 
 func HasCommand(command string, args []string, env []string) bool {
-	cmd := exec.Command(command, args...)
-	if env != nil {
-		env = append(env, os.Environ()...)
-		cmd.Env = append(cmd.Env, env...)
-	}
+	return HasCommandContext(context.Background(), command, args, env)
+}
+
+// HasCommandContext checks for a command like HasCommand, for the package
+// operation ctx carries (see ShellOutContext)
+func HasCommandContext(ctx context.Context, command string, args []string, env []string) bool {
+	// Checking for a command is verify-only
+	cmd := newCommand(ctx, command, args, env, true)
+	span := startSpan(ctx, command, args)
 	err := cmd.Run()
+	span.End(err)
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
 			return exitError.ExitCode() == 0
@@ -41,13 +67,20 @@ func HasCommand(command string, args []string, env []string) bool {
 // ShellOutCapture runs a command and captures its exit code and
 // output without printing it to stdout or stderr.
 func ShellOutCapture(command string, args []string, dir string, env []string) (int, string, error) {
-	cmd := exec.Command(command, args...)
-	cmd.Dir = dir
-	if env != nil {
-		env = append(env, os.Environ()...)
-		cmd.Env = append(cmd.Env, env...)
+	return ShellOutCaptureContext(context.Background(), command, args, dir, env)
+}
+
+// ShellOutCaptureContext runs a command like ShellOutCapture, for the
+// package operation ctx carries (see ShellOutContext)
+func ShellOutCaptureContext(ctx context.Context, command string, args []string, dir string, env []string) (int, string, error) {
+	if err := chaos.Fail(command); err != nil {
+		return 1, "", err
 	}
+	cmd := newCommand(ctx, command, args, env, false)
+	cmd.Dir = dir
+	span := startSpan(ctx, command, args)
 	output, err := cmd.CombinedOutput()
+	span.End(err)
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
 			return exitError.ExitCode(), string(output), err
@@ -56,3 +89,32 @@ func ShellOutCapture(command string, args []string, dir string, env []string) (i
 	}
 	return 0, string(output), nil
 }
+
+// ShellOutInteractive runs a command attached to the terminal,
+// so the user can follow its output and answer prompts (e.g. sudo).
+func ShellOutInteractive(command string, args []string, dir string, env []string) (int, error) {
+	return ShellOutInteractiveContext(context.Background(), command, args, dir, env)
+}
+
+// ShellOutInteractiveContext runs a command like ShellOutInteractive, for
+// the package operation ctx carries (see ShellOutContext)
+func ShellOutInteractiveContext(ctx context.Context, command string, args []string, dir string, env []string) (int, error) {
+	if err := chaos.Fail(command); err != nil {
+		return 1, err
+	}
+	cmd := newCommand(ctx, command, args, env, false)
+	cmd.Dir = dir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	span := startSpan(ctx, command, args)
+	err := cmd.Run()
+	span.End(err)
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return exitError.ExitCode(), err
+		}
+		return -1, err
+	}
+	return 0, nil
+}
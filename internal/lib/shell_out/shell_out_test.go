@@ -2,9 +2,12 @@ package shell_out
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/mistweaverco/zana-client/internal/lib/trace"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestShellOut(t *testing.T) {
@@ -102,3 +105,26 @@ func TestShellOutCapture(t *testing.T) {
 		assert.Contains(t, output, "xyz")
 	})
 }
+
+func TestShellOutInteractive(t *testing.T) {
+	exitCode, err := ShellOutInteractive("true", []string{}, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, exitCode)
+
+	exitCode, err = ShellOutInteractive("false", []string{}, "", nil)
+	assert.Error(t, err)
+	assert.Equal(t, 1, exitCode)
+}
+
+func TestShellOutTrace(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "trace.json")
+	trace.Enable(file, "zana test")
+	_, _ = ShellOut("false", []string{"--flag"}, "", nil)
+	require.NoError(t, trace.Flush())
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"name":"exec false"`)
+	assert.Contains(t, string(data), `{"key":"process.command_args","value":{"stringValue":"--flag"}}`)
+	assert.Contains(t, string(data), `"status":{"code":2,"message":"exit status 1"}`)
+}
@@ -0,0 +1,55 @@
+package shell_out
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/mistweaverco/zana-client/internal/lib/log"
+)
+
+// Injectable helpers for tests
+var wrappersConfig = files.GetCommandWrappers
+
+var shellSafeWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// wrappedCommandLine prefixes argv with the wrapper configured for the
+// package of the operation ctx carries, e.g. firejail or proxychains4.
+// A {cmd} in the wrapper is replaced by argv as one argument.
+func wrappedCommandLine(ctx context.Context, argv []string) []string {
+	op, ok := log.OperationFrom(ctx)
+	if !ok {
+		return argv
+	}
+	wrapper := wrappersConfig().For(op.Package)
+	if len(wrapper) == 0 {
+		return argv
+	}
+	wrapped := make([]string, 0, len(wrapper)+len(argv))
+	replaced := false
+	for _, arg := range wrapper {
+		if strings.Contains(arg, files.WrapperCommandPlaceholder) {
+			arg = strings.ReplaceAll(arg, files.WrapperCommandPlaceholder, shellJoin(argv))
+			replaced = true
+		}
+		wrapped = append(wrapped, arg)
+	}
+	if !replaced {
+		wrapped = append(wrapped, argv...)
+	}
+	return wrapped
+}
+
+// shellJoin joins argv into a command line for POSIX sh
+func shellJoin(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		if shellSafeWord.MatchString(arg) {
+			quoted[i] = arg
+		} else {
+			quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,44 @@
+package shell_out
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mistweaverco/zana-client/internal/lib/files"
+	"github.com/stretchr/testify/assert"
+)
+
+func stubWrappers(t *testing.T, w files.CommandWrappers) {
+	t.Helper()
+	prev := wrappersConfig
+	t.Cleanup(func() { wrappersConfig = prev })
+	wrappersConfig = func() files.CommandWrappers { return w }
+}
+
+func TestNewCommandWrapped(t *testing.T) {
+	stubHardening(t, "linux", files.SubprocessHardening{Providers: []string{"cargo"}})
+	stubWrappers(t, files.CommandWrappers{
+		Providers: map[string][]string{"npm": {"proxychains4", "-q"}, "cargo": {"firejail", "--quiet"}},
+		Packages:  map[string][]string{"npm:prettier": {"nix-shell", "-p", "nodejs", "--run", "{cmd}"}},
+	})
+
+	t.Run("outside of package operations nothing is wrapped", func(t *testing.T) {
+		cmd := newCommand(context.Background(), "npm", []string{"install"}, nil, false)
+		assert.Equal(t, []string{"npm", "install"}, cmd.Args)
+	})
+
+	t.Run("the wrapper of the provider prefixes the command", func(t *testing.T) {
+		cmd := newCommand(installing("npm:eslint"), "npm", []string{"install", "eslint@9.0.0"}, nil, false)
+		assert.Equal(t, []string{"proxychains4", "-q", "npm", "install", "eslint@9.0.0"}, cmd.Args)
+	})
+
+	t.Run("the wrapper of the package takes the command as one argument", func(t *testing.T) {
+		cmd := newCommand(installing("npm:prettier"), "npm", []string{"install", "prettier@3.3.3", "--message=it's"}, nil, false)
+		assert.Equal(t, []string{"nix-shell", "-p", "nodejs", "--run", `npm install prettier@3.3.3 '--message=it'\''s'`}, cmd.Args)
+	})
+
+	t.Run("hardening wraps the wrapper", func(t *testing.T) {
+		cmd := newCommand(installing("cargo:ripgrep"), "cargo", []string{"install", "ripgrep"}, nil, false)
+		assert.Equal(t, []string{"ionice", "-c", "3", "nice", "-n", "10", "firejail", "--quiet", "cargo", "install", "ripgrep"}, cmd.Args)
+	})
+}
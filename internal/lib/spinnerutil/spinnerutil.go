@@ -12,13 +12,28 @@ import (
 
 var spinnerDepth int32
 
+// disabled is set by Disable
+var disabled atomic.Bool
+
+// Disable makes all Run* functions run their action without a spinner and
+// without printing the title, e.g. while stderr carries machine-readable output.
+func Disable() {
+	disabled.Store(true)
+}
+
 // Run shows a huh spinner with title while action runs.
 // When another Run is already active (nested), a second Bubble Tea program would corrupt the
 // terminal; nested calls print the title to stderr and run the action without a spinner.
+// The spinner draws on stdout, so it is skipped the same way when stdout isn't a terminal,
+// keeping piped output (JSON, zana ls --porcelain) free of escape sequences.
 func Run(title string, action func()) error {
+	if disabled.Load() {
+		action()
+		return nil
+	}
 	n := atomic.AddInt32(&spinnerDepth, 1)
 	defer atomic.AddInt32(&spinnerDepth, -1)
-	if n > 1 {
+	if n > 1 || !isatty.IsTerminal(os.Stdout.Fd()) {
 		if isatty.IsTerminal(os.Stderr.Fd()) {
 			_, _ = fmt.Fprintf(os.Stderr, "%s\n", title)
 		}
@@ -31,6 +46,10 @@ func Run(title string, action func()) error {
 // RunIfTTY runs action inside a spinner only when stderr is a terminal; otherwise prints the
 // title to stderr and runs the action (useful for CI / logs).
 func RunIfTTY(title string, action func()) error {
+	if disabled.Load() {
+		action()
+		return nil
+	}
 	if !isatty.IsTerminal(os.Stderr.Fd()) {
 		_, _ = fmt.Fprintf(os.Stderr, "%s\n", title)
 		action()
@@ -41,7 +60,7 @@ func RunIfTTY(title string, action func()) error {
 
 // RunWithTTYOrPlain runs action with a spinner when stderr is a TTY; otherwise runs plainBefore (if non-nil) then action.
 func RunWithTTYOrPlain(title string, plainBefore func(), action func()) error {
-	if !isatty.IsTerminal(os.Stderr.Fd()) {
+	if disabled.Load() || !isatty.IsTerminal(os.Stderr.Fd()) {
 		if plainBefore != nil {
 			plainBefore()
 		}
@@ -0,0 +1,248 @@
+// Package trace records spans of package operations, downloads, archive
+// extraction, subprocesses and lock file access, and writes them as an
+// OTLP/JSON trace file that Jaeger or Perfetto can open.
+// It is off unless enabled with the --trace <file> flag; while off, starting
+// a span returns nil and costs next to nothing.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/log"
+	"github.com/mistweaverco/zana-client/internal/lib/version"
+)
+
+// Span is a timed phase of a zana run. A nil *Span is valid and does nothing.
+type Span struct {
+	id       string
+	parentID string
+	name     string
+	start    time.Time
+	end      time.Time
+	attrs    [][2]string
+	err      string
+}
+
+var (
+	mu      sync.Mutex
+	path    string
+	traceID string
+	root    *Span
+	spans   []*Span
+	// operationSpans holds the first span started for each operation (see
+	// StartContext), which the other spans of the operation nest under
+	operationSpans map[string]*Span
+	// now is replaced in tests
+	now = time.Now
+)
+
+// Enable starts recording spans under a root span called name. Flush writes
+// them to file.
+func Enable(file, name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	path = file
+	traceID = newID(16)
+	root = &Span{id: newID(8), name: name, start: now()}
+	spans = []*Span{root}
+	operationSpans = map[string]*Span{}
+}
+
+// Enabled reports whether spans are recorded
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return root != nil
+}
+
+// Start starts a span called name with attributes given as key/value pairs,
+// nested under the root span
+func Start(name string, attrs ...string) *Span {
+	return StartContext(context.Background(), name, attrs...)
+}
+
+// StartContext starts a span like Start. Spans started with a context
+// carrying a package operation (see log.WithOperation) nest under the
+// operation's first span.
+func StartContext(ctx context.Context, name string, attrs ...string) *Span {
+	mu.Lock()
+	defer mu.Unlock()
+	if root == nil {
+		return nil
+	}
+	s := &Span{id: newID(8), parentID: root.id, name: name, start: now()}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		s.attrs = append(s.attrs, [2]string{attrs[i], attrs[i+1]})
+	}
+	if op, ok := log.OperationFrom(ctx); ok {
+		if parent, ok := operationSpans[op.ID]; ok {
+			s.parentID = parent.id
+		} else {
+			operationSpans[op.ID] = s
+			s.attrs = append(s.attrs, [2]string{"zana.operation.id", op.ID})
+		}
+	}
+	spans = append(spans, s)
+	return s
+}
+
+// End ends the span, as failed when err is not nil. Ending a span twice
+// keeps the first end.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !s.end.IsZero() {
+		return
+	}
+	s.end = now()
+	if err != nil {
+		s.err = err.Error()
+	}
+}
+
+// EndOK ends the span, as failed unless ok
+func (s *Span) EndOK(ok bool) {
+	if s == nil {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !s.end.IsZero() {
+		return
+	}
+	s.end = now()
+	if !ok {
+		s.err = "failed"
+	}
+}
+
+// Flush ends the spans still running, writes the trace file and stops
+// recording. It does nothing when recording is off.
+func Flush() error {
+	mu.Lock()
+	if root == nil {
+		mu.Unlock()
+		return nil
+	}
+	end := now()
+	for _, s := range spans {
+		if s.end.IsZero() {
+			s.end = end
+		}
+	}
+	doc := otlpDocument(traceID, spans)
+	file := path
+	root, spans, operationSpans = nil, nil, nil
+	mu.Unlock()
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+// OTLP/JSON encoding, see
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+
+type otlpTrace struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	spanKindInternal = 1
+	statusCodeOK     = 1
+	statusCodeError  = 2
+)
+
+func otlpDocument(traceID string, spans []*Span) otlpTrace {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           traceID,
+			SpanID:            s.id,
+			ParentSpanID:      s.parentID,
+			Name:              s.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Status:            otlpStatus{Code: statusCodeOK},
+		}
+		for _, a := range s.attrs {
+			span.Attributes = append(span.Attributes, otlpAttribute{Key: a[0], Value: otlpValue{StringValue: a[1]}})
+		}
+		if s.err != "" {
+			span.Status = otlpStatus{Code: statusCodeError, Message: s.err}
+		}
+		out = append(out, span)
+	}
+	return otlpTrace{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			{Key: "service.name", Value: otlpValue{StringValue: "zana"}},
+			{Key: "service.version", Value: otlpValue{StringValue: version.VERSION}},
+		}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "zana", Version: version.VERSION}, Spans: out}},
+	}}}
+}
+
+// newID returns n random bytes as hex, the form of OTLP trace and span IDs
+func newID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
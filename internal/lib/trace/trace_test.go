@@ -0,0 +1,92 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/zana-client/internal/lib/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readTrace(t *testing.T, file string) []otlpSpan {
+	t.Helper()
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	var doc otlpTrace
+	require.NoError(t, json.Unmarshal(data, &doc))
+	require.Len(t, doc.ResourceSpans, 1)
+	assert.Equal(t, otlpAttribute{Key: "service.name", Value: otlpValue{StringValue: "zana"}}, doc.ResourceSpans[0].Resource.Attributes[0])
+	require.Len(t, doc.ResourceSpans[0].ScopeSpans, 1)
+	return doc.ResourceSpans[0].ScopeSpans[0].Spans
+}
+
+func TestDisabled(t *testing.T) {
+	assert.False(t, Enabled())
+	span := Start("download")
+	assert.Nil(t, span)
+	span.End(nil)
+	span.EndOK(false)
+	assert.NoError(t, Flush())
+}
+
+func TestSpans(t *testing.T) {
+	clock := time.Unix(1700000000, 0)
+	prevNow := now
+	t.Cleanup(func() { now = prevNow })
+	now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	file := filepath.Join(t.TempDir(), "trace.json")
+	Enable(file, "zana install")
+	assert.True(t, Enabled())
+
+	lock := Start("lockfile.read", "file.path", "/zana/zana-lock.json")
+	lock.End(nil)
+
+	ctx := log.WithOperation(context.Background(), log.NewOperation("install", "npm:prettier"))
+	install := StartContext(ctx, "install", "zana.package", "npm:prettier")
+	npm := StartContext(ctx, "exec npm", "process.executable.name", "npm")
+	npm.End(errors.New("exit status 1"))
+	npm.End(nil)
+	install.EndOK(false)
+	Start("download", "url.full", "https://example.com/tool.tar.gz")
+
+	require.NoError(t, Flush())
+	assert.False(t, Enabled())
+
+	spans := readTrace(t, file)
+	require.Len(t, spans, 5)
+	root := spans[0]
+	assert.Equal(t, "zana install", root.Name)
+	assert.Empty(t, root.ParentSpanID)
+	assert.Len(t, root.TraceID, 32)
+	assert.Len(t, root.SpanID, 16)
+	for _, s := range spans {
+		assert.Equal(t, root.TraceID, s.TraceID)
+	}
+
+	assert.Equal(t, root.SpanID, spans[1].ParentSpanID)
+	assert.Equal(t, []otlpAttribute{{Key: "file.path", Value: otlpValue{StringValue: "/zana/zana-lock.json"}}}, spans[1].Attributes)
+	assert.Equal(t, "1700000002000000000", spans[1].StartTimeUnixNano)
+	assert.Equal(t, "1700000003000000000", spans[1].EndTimeUnixNano)
+
+	// Spans of an operation nest under its first span
+	assert.Equal(t, root.SpanID, spans[2].ParentSpanID)
+	assert.Equal(t, "zana.operation.id", spans[2].Attributes[1].Key)
+	assert.Equal(t, otlpStatus{Code: statusCodeError, Message: "failed"}, spans[2].Status)
+	assert.Equal(t, spans[2].SpanID, spans[3].ParentSpanID)
+	assert.Equal(t, otlpStatus{Code: statusCodeError, Message: "exit status 1"}, spans[3].Status, "the first end counts")
+
+	// Spans still running are ended by Flush
+	assert.Equal(t, root.SpanID, spans[4].ParentSpanID)
+	assert.Equal(t, otlpStatus{Code: statusCodeOK}, spans[4].Status)
+	assert.Equal(t, root.EndTimeUnixNano, spans[4].EndTimeUnixNano)
+}
@@ -434,5 +434,13 @@ func SourceIDToHTTPSCloneURL(sourceID string) string {
 		}
 		return "https://codeberg.org/" + rest
 	}
+	if strings.HasPrefix(sourceID, "gitea:") {
+		// gitea IDs start with the host of the instance
+		rest := strings.Trim(strings.TrimPrefix(sourceID, "gitea:"), "/")
+		if strings.Count(rest, "/") < 2 {
+			return ""
+		}
+		return "https://" + rest
+	}
 	return ""
 }
@@ -0,0 +1,492 @@
+package versioncmp
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/mistweaverco/zana-client/internal/lib/semver"
+)
+
+// Scheme identifies the versioning scheme a version string follows.
+type Scheme string
+
+const (
+	// SchemeSemver is major.minor.patch with optional -prerelease and +build
+	SchemeSemver Scheme = "semver"
+	// SchemePEP440 is the Python versioning scheme (e.g. 1.2.0rc1, 1.0.post2, 2.0.dev3)
+	SchemePEP440 Scheme = "pep440"
+	// SchemeCalver is a date based version (e.g. 2024.03.01, 2024-03-11)
+	SchemeCalver Scheme = "calver"
+	// SchemeOrdinal is a plain dotted number sequence that does not fit semver
+	// (e.g. 1.2.3.4 or a single build number)
+	SchemeOrdinal Scheme = "ordinal"
+	// SchemeUnknown is used when the version could not be classified
+	SchemeUnknown Scheme = "unknown"
+)
+
+// Suffix label ranks, ordered the way PEP 440 orders them.
+// A release without any suffix has rank 0.
+const (
+	rankDev     = -40
+	rankAlpha   = -30
+	rankUnknown = -25
+	rankBeta    = -20
+	rankRC      = -10
+	rankRelease = 0
+	rankPost    = 10
+)
+
+// suffixToken is a single label/number pair in a version suffix,
+// e.g. "rc1" becomes {label: "rc", rank: rankRC, num: 1}.
+type suffixToken struct {
+	label string
+	rank  int
+	num   int
+}
+
+// parsedVersion is the scheme-agnostic representation of a version
+// used when the two sides are not both plain semver.
+type parsedVersion struct {
+	epoch   int
+	release []int
+	suffix  []suffixToken
+}
+
+// isDigit returns true if the given byte is an ASCII digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// startsWithVersion reports whether s starts with an optional "v"/"V"
+// followed by a digit.
+func startsWithVersion(s string) bool {
+	if s == "" {
+		return false
+	}
+	if s[0] == 'v' || s[0] == 'V' {
+		s = s[1:]
+	}
+	return s != "" && isDigit(s[0])
+}
+
+// isPrefixSeparator returns true for characters that separate a tag
+// prefix (e.g. "release-", "tool/", "pkg@") from the version itself.
+func isPrefixSeparator(c byte) bool {
+	return c == '-' || c == '_' || c == '/' || c == '@'
+}
+
+// Normalize strips whitespace, a leading "v" and any tag prefix
+// from a version string. For example "release-1.2", "tool-v1.2",
+// "cli/v1.2" and "v1.2" all normalize to "1.2".
+// Strings that do not contain a recognizable version are returned trimmed.
+func Normalize(version string) string {
+	version = strings.TrimSpace(version)
+	if startsWithVersion(version) {
+		return strings.TrimLeft(version[:1], "vV") + version[1:]
+	}
+	for i := 0; i < len(version)-1; i++ {
+		if isPrefixSeparator(version[i]) && startsWithVersion(version[i+1:]) {
+			rest := version[i+1:]
+			return strings.TrimLeft(rest[:1], "vV") + rest[1:]
+		}
+	}
+	return version
+}
+
+// splitRelease splits the leading dotted numeric part off a version string.
+// The separator set controls which characters are allowed between numbers.
+func splitRelease(version string, separators string) ([]int, string) {
+	var release []int
+	i := 0
+	for i < len(version) {
+		start := i
+		for i < len(version) && isDigit(version[i]) {
+			i++
+		}
+		if start == i {
+			break
+		}
+		n, err := strconv.Atoi(version[start:i])
+		if err != nil {
+			return nil, version
+		}
+		release = append(release, n)
+		if i < len(version) && strings.IndexByte(separators, version[i]) != -1 &&
+			i+1 < len(version) && isDigit(version[i+1]) {
+			i++
+			continue
+		}
+		break
+	}
+	return release, version[i:]
+}
+
+// isCalver reports whether the (normalized) version looks like a date based
+// version: a four digit year starting with 19 or 20 followed by a month.
+func isCalver(version string) bool {
+	if len(version) < 6 || !isDigit(version[0]) {
+		return false
+	}
+	if !strings.HasPrefix(version, "19") && !strings.HasPrefix(version, "20") {
+		return false
+	}
+	release, rest := splitRelease(version, ".-_")
+	if len(release) < 2 || release[0] < 1900 || release[0] > 2999 {
+		return false
+	}
+	if release[1] < 1 || release[1] > 12 {
+		return false
+	}
+	return rest == "" || rest[0] == '+'
+}
+
+// isSemver reports whether the (normalized) version is a plain semver string
+// with at most three numeric core parts.
+func isSemver(version string) bool {
+	if idx := strings.Index(version, "+"); idx != -1 {
+		version = version[:idx]
+	}
+	release, rest := splitRelease(version, ".")
+	if len(release) == 0 || len(release) > 3 {
+		return false
+	}
+	if rest == "" {
+		return true
+	}
+	if rest[0] != '-' || len(rest) == 1 {
+		return false
+	}
+	for i := 1; i < len(rest); i++ {
+		c := rest[i]
+		if !isDigit(c) && c != '.' && c != '-' &&
+			(c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// DetectScheme returns the versioning scheme the given version follows.
+// Tag prefixes and a leading "v" are ignored.
+func DetectScheme(version string) Scheme {
+	version = Normalize(version)
+	if version == "" || !isDigit(version[0]) {
+		return SchemeUnknown
+	}
+	if strings.Contains(version, "!") {
+		if _, ok := parse(version); ok {
+			return SchemePEP440
+		}
+		return SchemeUnknown
+	}
+	if isCalver(version) {
+		return SchemeCalver
+	}
+	if isSemver(version) {
+		return SchemeSemver
+	}
+	p, ok := parse(version)
+	if !ok {
+		return SchemeUnknown
+	}
+	if len(p.suffix) == 0 {
+		return SchemeOrdinal
+	}
+	return SchemePEP440
+}
+
+// labelRank maps a suffix label to its rank.
+func labelRank(label string) int {
+	switch label {
+	case "dev", "snapshot", "nightly":
+		return rankDev
+	case "a", "alpha":
+		return rankAlpha
+	case "b", "beta":
+		return rankBeta
+	case "rc", "c", "pre", "preview":
+		return rankRC
+	case "post", "rev", "r", "p", "patch":
+		return rankPost
+	default:
+		return rankUnknown
+	}
+}
+
+// parseSuffix parses everything after the release part into tokens.
+func parseSuffix(suffix string) ([]suffixToken, bool) {
+	var tokens []suffixToken
+	suffix = strings.ToLower(suffix)
+	i := 0
+	for i < len(suffix) {
+		c := suffix[i]
+		if c == '.' || c == '-' || c == '_' {
+			i++
+			continue
+		}
+		start := i
+		for i < len(suffix) && suffix[i] >= 'a' && suffix[i] <= 'z' {
+			i++
+		}
+		label := suffix[start:i]
+		numStart := i
+		for i < len(suffix) && isDigit(suffix[i]) {
+			i++
+		}
+		if label == "" && numStart == i {
+			return nil, false
+		}
+		num := 0
+		if numStart != i {
+			n, err := strconv.Atoi(suffix[numStart:i])
+			if err != nil {
+				return nil, false
+			}
+			num = n
+		}
+		if label == "" {
+			// A bare number after a hyphen is an implicit post release (PEP 440)
+			label = "post"
+		}
+		tokens = append(tokens, suffixToken{label: label, rank: labelRank(label), num: num})
+	}
+	return tokens, true
+}
+
+// parse parses a normalized version string into its generic representation.
+func parse(version string) (parsedVersion, bool) {
+	var p parsedVersion
+	if idx := strings.Index(version, "+"); idx != -1 {
+		version = version[:idx]
+	}
+	if idx := strings.Index(version, "!"); idx != -1 {
+		epoch, err := strconv.Atoi(version[:idx])
+		if err != nil {
+			return p, false
+		}
+		p.epoch = epoch
+		version = version[idx+1:]
+	}
+	separators := "."
+	if isCalver(version) {
+		separators = ".-_"
+	}
+	release, rest := splitRelease(version, separators)
+	if len(release) == 0 {
+		return p, false
+	}
+	suffix, ok := parseSuffix(rest)
+	if !ok {
+		return p, false
+	}
+	p.release = release
+	p.suffix = suffix
+	return p, true
+}
+
+// compareInts returns -1, 0 or 1 comparing a and b.
+func compareInts(a, b int) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// compareParsed compares two generic versions. Release parts are compared
+// numerically (missing parts count as zero), then the suffix tokens.
+func compareParsed(a, b parsedVersion) int {
+	if c := compareInts(a.epoch, b.epoch); c != 0 {
+		return c
+	}
+	maxLen := len(a.release)
+	if len(b.release) > maxLen {
+		maxLen = len(b.release)
+	}
+	for i := 0; i < maxLen; i++ {
+		var x, y int
+		if i < len(a.release) {
+			x = a.release[i]
+		}
+		if i < len(b.release) {
+			y = b.release[i]
+		}
+		if c := compareInts(x, y); c != 0 {
+			return c
+		}
+	}
+	maxLen = len(a.suffix)
+	if len(b.suffix) > maxLen {
+		maxLen = len(b.suffix)
+	}
+	for i := 0; i < maxLen; i++ {
+		release := suffixToken{rank: rankRelease}
+		x, y := release, release
+		if i < len(a.suffix) {
+			x = a.suffix[i]
+		}
+		if i < len(b.suffix) {
+			y = b.suffix[i]
+		}
+		if c := compareInts(x.rank, y.rank); c != 0 {
+			return c
+		}
+		if x.rank == rankUnknown {
+			if c := strings.Compare(x.label, y.label); c != 0 {
+				return c
+			}
+		}
+		if c := compareInts(x.num, y.num); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// Compare compares two version strings and returns
+// -1 if v1 < v2, 0 if they are equal (or cannot be compared)
+// and 1 if v1 > v2.
+// When both versions are plain semver the semver rules are used
+// (including the "unstable track" handling of non-numeric prereleases),
+// otherwise the versions are compared numerically part by part,
+// with PEP 440 style suffix ordering (dev < alpha < beta < rc < release < post).
+func Compare(v1, v2 string) int {
+	n1 := Normalize(v1)
+	n2 := Normalize(v2)
+	if n1 == "" || n2 == "" || n1 == n2 {
+		return 0
+	}
+	s1 := DetectScheme(n1)
+	s2 := DetectScheme(n2)
+	if s1 == SchemeUnknown || s2 == SchemeUnknown {
+		return 0
+	}
+	if s1 == SchemeSemver && s2 == SchemeSemver {
+		if semver.IsGreater(n1, n2) {
+			return -1
+		}
+		if semver.IsGreater(n2, n1) {
+			return 1
+		}
+		return 0
+	}
+	p1, ok1 := parse(n1)
+	p2, ok2 := parse(n2)
+	if !ok1 || !ok2 {
+		return 0
+	}
+	return compareParsed(p1, p2)
+}
+
+// IsGreater returns true if v2 is greater than v1,
+// mirroring the argument order of semver.IsGreater.
+// IsGreater("2024.03.01", "2024.10.01") returns true
+// IsGreater("1.2.3.4", "1.2.3.5") returns true
+// IsGreater("release-1.2", "tool-v1.3") returns true
+func IsGreater(v1, v2 string) bool {
+	if v1 == "" || v2 == "" {
+		return false
+	}
+	return Compare(v1, v2) == -1
+}
+
+// IsNonNumericPreRelease reports whether the given version has a
+// non-numeric pre-release part (e.g. alpha, beta, dev, rc).
+// Tag prefixes are ignored.
+func IsNonNumericPreRelease(version string) bool {
+	n := Normalize(version)
+	switch DetectScheme(n) {
+	case SchemeSemver:
+		return semver.IsNonNumericPreRelease(n)
+	case SchemePEP440:
+		p, ok := parse(n)
+		if !ok {
+			return false
+		}
+		for _, token := range p.suffix {
+			if token.rank < rankRelease {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Bump is the size of the step between two versions, ordered from the
+// smallest to the largest jump.
+type Bump int
+
+const (
+	// BumpNone means the versions are equal or the target is older
+	BumpNone Bump = iota
+	// BumpPatch changes the third release part or anything after it,
+	// including the pre-release and post-release suffixes
+	BumpPatch
+	// BumpMinor changes the second release part
+	BumpMinor
+	// BumpMajor changes the first release part or the epoch
+	BumpMajor
+	// BumpUnknown means the size of the step could not be determined
+	BumpUnknown
+)
+
+// String returns the name of the bump, e.g. "minor"
+func (b Bump) String() string {
+	switch b {
+	case BumpNone:
+		return "none"
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText writes the bump by its name, so JSON output reads "minor"
+func (b Bump) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// BumpBetween classifies the step from version from to version to
+// by the first release part that changes.
+// Versions that cannot be parsed give BumpUnknown.
+// BumpBetween("1.2.3", "1.2.4") returns BumpPatch
+// BumpBetween("v1.2.3", "1.3.0") returns BumpMinor
+// BumpBetween("2024.03.01", "2025.01.01") returns BumpMajor
+func BumpBetween(from, to string) Bump {
+	nFrom := Normalize(from)
+	nTo := Normalize(to)
+	if nFrom == "" || nTo == "" || DetectScheme(nFrom) == SchemeUnknown || DetectScheme(nTo) == SchemeUnknown {
+		return BumpUnknown
+	}
+	if Compare(nFrom, nTo) != -1 {
+		return BumpNone
+	}
+	pFrom, ok1 := parse(nFrom)
+	pTo, ok2 := parse(nTo)
+	if !ok1 || !ok2 {
+		return BumpUnknown
+	}
+	if pFrom.epoch != pTo.epoch {
+		return BumpMajor
+	}
+	part := func(release []int, i int) int {
+		if i < len(release) {
+			return release[i]
+		}
+		return 0
+	}
+	if part(pFrom.release, 0) != part(pTo.release, 0) {
+		return BumpMajor
+	}
+	if part(pFrom.release, 1) != part(pTo.release, 1) {
+		return BumpMinor
+	}
+	return BumpPatch
+}
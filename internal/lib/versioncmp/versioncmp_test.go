@@ -0,0 +1,150 @@
+package versioncmp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain", "1.2.3", "1.2.3"},
+		{"v prefix", "v1.2.3", "1.2.3"},
+		{"uppercase v prefix", "V1.2.3", "1.2.3"},
+		{"release prefix", "release-1.2", "1.2"},
+		{"tool v prefix", "tool-v1.2", "1.2"},
+		{"slash prefix", "cli/v1.2.3", "1.2.3"},
+		{"at prefix", "pkg@2.0.0", "2.0.0"},
+		{"whitespace", "  1.0.0 ", "1.0.0"},
+		{"keeps prerelease", "1.2.3-beta-1", "1.2.3-beta-1"},
+		{"no version", "latest", "latest"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Normalize(tt.input))
+		})
+	}
+}
+
+func TestDetectScheme(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Scheme
+	}{
+		{"semver", "1.2.3", SchemeSemver},
+		{"semver with prerelease", "v1.2.3-beta.1", SchemeSemver},
+		{"semver with build", "1.2.3+build.5", SchemeSemver},
+		{"semver short", "1.2", SchemeSemver},
+		{"calver dotted", "2024.03.01", SchemeCalver},
+		{"calver hyphenated", "2024-03-11", SchemeCalver},
+		{"calver with tag prefix", "nightly-2024.3", SchemeCalver},
+		{"pep440 rc", "1.2.0rc1", SchemePEP440},
+		{"pep440 post", "1.0.post2", SchemePEP440},
+		{"pep440 dev", "2.0.0.dev3", SchemePEP440},
+		{"pep440 epoch", "1!2.0", SchemePEP440},
+		{"four part", "1.2.3.4", SchemeOrdinal},
+		{"unknown", "latest", SchemeUnknown},
+		{"empty", "", SchemeUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DetectScheme(tt.input))
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		v1       string
+		v2       string
+		expected int
+	}{
+		{"semver less", "1.2.3", "1.2.4", -1},
+		{"semver equal", "1.2.3", "v1.2.3", 0},
+		{"semver greater", "2.0.0", "1.9.9", 1},
+		{"semver stable over numeric prerelease", "1.0.0-1", "1.0.0", -1},
+		{"semver dev track not downgraded", "1.0.0-dev.1", "1.0.0", 0},
+		{"calver less", "2024.03.01", "2024.10.01", -1},
+		{"calver greater", "2025.01.01", "2024.12.31", 1},
+		{"calver hyphenated", "2024-03-11", "2024-03-18", -1},
+		{"calver with build counter", "2024.03.01", "2024.03.01.1", -1},
+		{"four part less", "1.2.3.4", "1.2.3.5", -1},
+		{"four part vs three part", "1.2.3", "1.2.3.1", -1},
+		{"four part equal padded", "1.2.3.0", "1.2.3", 0},
+		{"tag prefixed", "release-1.2", "tool-v1.3", -1},
+		{"tag prefixed equal", "release-1.2.0", "v1.2.0", 0},
+		{"pep440 rc before release", "1.2.0rc1", "1.2.0", -1},
+		{"pep440 dev before alpha", "1.0.dev1", "1.0a1", -1},
+		{"pep440 alpha before beta", "1.0a2", "1.0b1", -1},
+		{"pep440 post after release", "1.0.post1", "1.0", 1},
+		{"pep440 dev of pre", "1.0a1.dev1", "1.0a1", -1},
+		{"pep440 epoch wins", "1!1.0", "2.0", 1},
+		{"unknown cannot compare", "latest", "1.0.0", 0},
+		{"empty", "", "1.0.0", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Compare(tt.v1, tt.v2))
+		})
+	}
+}
+
+func TestIsGreater(t *testing.T) {
+	assert.True(t, IsGreater("2024.03.01", "2024.10.01"))
+	assert.True(t, IsGreater("1.2.3.4", "1.2.3.5"))
+	assert.True(t, IsGreater("release-1.2", "tool-v1.3"))
+	assert.False(t, IsGreater("1.2.3", "1.2.3"))
+	assert.False(t, IsGreater("2.0.0", "1.0.0"))
+	assert.False(t, IsGreater("", "1.0.0"))
+	assert.False(t, IsGreater("1.0.0", ""))
+}
+
+func TestIsNonNumericPreRelease(t *testing.T) {
+	assert.True(t, IsNonNumericPreRelease("v1.0.0-beta.1"))
+	assert.True(t, IsNonNumericPreRelease("1.2.0rc1"))
+	assert.True(t, IsNonNumericPreRelease("tool-v1.0.0-alpha"))
+	assert.False(t, IsNonNumericPreRelease("1.0.0-1"))
+	assert.False(t, IsNonNumericPreRelease("1.0.post1"))
+	assert.False(t, IsNonNumericPreRelease("2024.03.01"))
+	assert.False(t, IsNonNumericPreRelease("1.0.0"))
+}
+
+func TestBumpBetween(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected Bump
+	}{
+		{"patch", "1.2.3", "1.2.4", BumpPatch},
+		{"minor", "1.2.3", "1.3.0", BumpMinor},
+		{"major", "1.2.3", "2.0.0", BumpMajor},
+		{"tag prefixes", "v1.2.3", "tool-v1.2.9", BumpPatch},
+		{"missing parts count as zero", "1.2", "1.2.1", BumpPatch},
+		{"prerelease to release", "1.3.0rc1", "1.3.0", BumpPatch},
+		{"pep440 minor", "24.1.0", "24.2.0rc1", BumpMinor},
+		{"fourth part", "1.2.3.4", "1.2.3.5", BumpPatch},
+		{"calver year", "2024.03.01", "2025.01.01", BumpMajor},
+		{"epoch", "1.2.3", "1!1.2.3", BumpMajor},
+		{"equal", "1.2.3", "v1.2.3", BumpNone},
+		{"downgrade", "1.3.0", "1.2.9", BumpNone},
+		{"unparsable", "latest", "1.2.3", BumpUnknown},
+		{"empty", "", "1.2.3", BumpUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, BumpBetween(tt.from, tt.to))
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package testutil
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// zana runs the zana executable against s and returns its stdout
+func zana(t *testing.T, s *Server, bin string, args ...string) string {
+	t.Helper()
+	cmd := s.Command(bin, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	require.NoError(t, err, "zana %s\nstdout:\n%s\nstderr:\n%s", strings.Join(args, " "), out, stderr.String())
+	return string(out)
+}
+
+func TestInstallListUpdateRemove(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds zana")
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("the fixture executables are shell scripts")
+	}
+	s := NewServer(t, Fixtures()...)
+	bin := BuildZana(t)
+
+	zana(t, s, bin, "sync", "registry", "-o", "plain")
+	zana(t, s, bin, "install", "generic:hello", "-o", "plain")
+	out, err := exec.Command(s.BinPath("hello")).Output()
+	require.NoError(t, err)
+	assert.Equal(t, "hello 1.0.0\n", string(out))
+
+	assert.Equal(t, "generic:hello\t1.0.0\t1.0.0\tfalse\n", zana(t, s, bin, "ls", "--porcelain"))
+	all := zana(t, s, bin, "ls", "--all", "--porcelain")
+	assert.Contains(t, all, "npm:prettier\t3.3.3\t-\tfalse\n")
+	assert.Contains(t, all, "generic:hello\t1.0.0\t1.0.0\tfalse\n")
+
+	s.Publish(GenericPackage("hello", "1.1.0"))
+	assert.Equal(t, "generic:hello\t1.0.0\t1.1.0\ttrue\n", zana(t, s, bin, "ls", "--porcelain", "--refresh"))
+
+	zana(t, s, bin, "update", "generic:hello", "-o", "plain")
+	out, err = exec.Command(s.BinPath("hello")).Output()
+	require.NoError(t, err)
+	assert.Equal(t, "hello 1.1.0\n", string(out))
+	assert.Equal(t, "generic:hello\t1.1.0\t1.1.0\tfalse\n", zana(t, s, bin, "ls", "--porcelain"))
+
+	zana(t, s, bin, "remove", "generic:hello", "-o", "plain")
+	assert.Empty(t, zana(t, s, bin, "ls", "--porcelain"))
+	assert.NoFileExists(t, s.BinPath("hello"))
+
+	for _, path := range s.Requests() {
+		assert.True(t, path == registryPath || path == registryManifestPath || strings.HasPrefix(path, "/assets/generic/hello/"), "unexpected request %s", path)
+	}
+}
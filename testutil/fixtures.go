@@ -0,0 +1,94 @@
+package testutil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// assetTargets are the registry targets fixture assets are published for
+var assetTargets = []string{"linux_x64", "linux_arm64", "darwin_x64", "darwin_arm64", "win_x64", "win_arm64"}
+
+// Package is a registry entry served by a Server
+type Package struct {
+	// ID is the source ID, e.g. generic:hello
+	ID          string
+	Version     string
+	Description string
+	Categories  []string
+	// Bin maps executable names to paths in the downloaded assets
+	Bin map[string]string
+	// Assets are the files a generic package downloads, by file name.
+	// The server serves them for every published version.
+	Assets map[string][]byte
+}
+
+// GenericPackage returns a generic package whose executable name is a shell
+// script printing "<name> <version>"
+func GenericPackage(name, version string) Package {
+	script := name + ".sh"
+	return Package{
+		ID:          "generic:" + name,
+		Version:     version,
+		Description: fmt.Sprintf("Test package %s", name),
+		Categories:  []string{"Test"},
+		Bin:         map[string]string{name: script},
+		Assets: map[string][]byte{
+			script: []byte(fmt.Sprintf("#!/bin/sh\necho %s %s\n", name, version)),
+		},
+	}
+}
+
+// Fixtures returns a registry entry for each provider. Only the generic
+// package has assets; the others shell out to their package manager when
+// installed, but serve ls, info, outdated and search tests.
+func Fixtures() []Package {
+	return []Package{
+		{ID: "npm:prettier", Version: "3.3.3", Description: "Opinionated code formatter", Categories: []string{"Formatter"}, Bin: map[string]string{"prettier": "npm:prettier"}},
+		{ID: "pypi:black", Version: "24.8.0", Description: "The uncompromising Python code formatter", Categories: []string{"Formatter"}, Bin: map[string]string{"black": "pypi:black"}},
+		{ID: "golang:golang.org/x/tools/gopls", Version: "0.16.2", Description: "The Go language server", Categories: []string{"LSP"}, Bin: map[string]string{"gopls": "golang:gopls"}},
+		{ID: "cargo:ripgrep", Version: "14.1.1", Description: "Recursively search directories for a regex pattern", Categories: []string{"Tool"}, Bin: map[string]string{"rg": "cargo:rg"}},
+		{ID: "github:tamasfe/taplo", Version: "0.9.3", Description: "A TOML toolkit", Categories: []string{"LSP"}, Bin: map[string]string{"taplo": "taplo"}},
+		{ID: "gitlab:gitlab-org/gitlab-lsp", Version: "7.0.0", Description: "GitLab language server", Categories: []string{"LSP"}},
+		{ID: "codeberg:mergiraf/mergiraf", Version: "0.4.0", Description: "A syntax-aware git merge driver", Categories: []string{"Tool"}},
+		{ID: "gem:rubocop", Version: "1.66.1", Description: "A Ruby static code analyzer and formatter", Categories: []string{"Linter"}, Bin: map[string]string{"rubocop": "gem:rubocop"}},
+		{ID: "composer:phpstan/phpstan", Version: "1.12.3", Description: "PHP static analysis tool", Categories: []string{"Linter"}},
+		{ID: "luarocks:luacheck", Version: "1.2.0", Description: "A static analyzer and linter for Lua", Categories: []string{"Linter"}},
+		{ID: "nuget:csharp-ls", Version: "0.15.0", Description: "Roslyn-based LSP language server for C#", Categories: []string{"LSP"}},
+		{ID: "opam:ocaml-lsp-server", Version: "1.19.0", Description: "OCaml language server", Categories: []string{"LSP"}},
+		{ID: "openvsx:vscode/css-language-features", Version: "1.95.0", Description: "CSS language features", Categories: []string{"LSP"}},
+		GenericPackage("hello", "1.0.0"),
+	}
+}
+
+// registryEntry returns the zana-registry.json entry of the package, with
+// download URLs on the server at baseURL
+func (p Package) registryEntry(baseURL string) map[string]interface{} {
+	name := p.ID[strings.Index(p.ID, ":")+1:]
+	source := map[string]interface{}{"id": p.ID}
+	if len(p.Assets) > 0 {
+		files := map[string]string{}
+		for file := range p.Assets {
+			files[file] = baseURL + assetPath(p.ID, "{{version}}", file)
+		}
+		source["download"] = []map[string]interface{}{{"target": assetTargets, "files": files}}
+	}
+	bin := p.Bin
+	if bin == nil {
+		bin = map[string]string{}
+	}
+	categories := p.Categories
+	if categories == nil {
+		categories = []string{}
+	}
+	return map[string]interface{}{
+		"name":        name,
+		"version":     p.Version,
+		"description": p.Description,
+		"homepage":    "",
+		"licenses":    []string{},
+		"languages":   []string{},
+		"categories":  categories,
+		"source":      source,
+		"bin":         bin,
+	}
+}
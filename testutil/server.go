@@ -0,0 +1,225 @@
+// Package testutil runs zana against a fake registry: an httptest server
+// serving a synthetic registry zip and the release assets of its packages,
+// with ZANA_HOME, the data and cache directories isolated in temp dirs.
+// Full flows (install, ls, update, remove) can be tested without touching
+// the network or the user's installation.
+//
+// It lives outside internal, so plugin authors can test their plugins
+// against a real zana binary, e.g. one built with BuildZana.
+package testutil
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// registryPath is where the server serves the registry zip, next to its manifest
+const (
+	registryPath         = "/zana-registry.json.zip"
+	registryManifestPath = registryPath + ".manifest.json"
+)
+
+// Server is a fake zana registry with an isolated zana installation
+type Server struct {
+	*httptest.Server
+	// Home is the isolated ZANA_HOME, holding zana-lock.json and config.yaml
+	Home string
+	// Data is the isolated ZANA_DATA, holding the packages and bin directories
+	Data string
+	// Cache is the isolated ZANA_CACHE, holding the downloaded registry
+	Cache string
+
+	mu       sync.Mutex
+	packages []Package
+	assets   map[string][]byte
+	requests []string
+}
+
+// NewServer starts a fake registry serving packages.
+// The server is closed when the test ends.
+func NewServer(t testing.TB, packages ...Package) *Server {
+	t.Helper()
+	s := &Server{
+		Home:   t.TempDir(),
+		Data:   t.TempDir(),
+		Cache:  t.TempDir(),
+		assets: map[string][]byte{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+	s.Publish(packages...)
+	return s
+}
+
+// Publish adds packages to the registry, replacing those with the same ID.
+// Assets of previously published versions stay available, like on a real
+// release page, so locked versions can still be installed.
+func (s *Server) Publish(packages ...Package) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, pkg := range packages {
+		replaced := false
+		for i := range s.packages {
+			if s.packages[i].ID == pkg.ID {
+				s.packages[i] = pkg
+				replaced = true
+			}
+		}
+		if !replaced {
+			s.packages = append(s.packages, pkg)
+		}
+		for name, content := range pkg.Assets {
+			s.assets[assetPath(pkg.ID, pkg.Version, name)] = content
+		}
+	}
+}
+
+// RegistryURL returns the URL of the registry zip
+func (s *Server) RegistryURL() string {
+	return s.URL + registryPath
+}
+
+// AssetURL returns the URL an asset of a package version is served at
+func (s *Server) AssetURL(id, version, name string) string {
+	return s.URL + assetPath(id, version, name)
+}
+
+// Requests returns the paths requested so far, in order
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// Env returns the environment variables pointing zana at the server and the
+// isolated directories, to be appended to os.Environ() for a zana process
+func (s *Server) Env() []string {
+	return []string{
+		"HOME=" + s.Home,
+		"XDG_CONFIG_HOME=" + filepath.Join(s.Home, ".config"),
+		"ZANA_HOME=" + s.Home,
+		"ZANA_DATA=" + s.Data,
+		"ZANA_CACHE=" + s.Cache,
+		"ZANA_REGISTRY_URLS=" + s.RegistryURL(),
+	}
+}
+
+// Setenv applies Env to the test process, for tests calling zana's packages
+// directly instead of running a zana binary
+func (s *Server) Setenv(t testing.TB) {
+	t.Helper()
+	for _, kv := range s.Env() {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+}
+
+// Command returns a command running the zana executable at zanaPath with
+// args against the server
+func (s *Server) Command(zanaPath string, args ...string) *exec.Cmd {
+	cmd := exec.Command(zanaPath, args...)
+	cmd.Env = append(os.Environ(), s.Env()...)
+	cmd.Dir = s.Home
+	return cmd
+}
+
+// BinPath returns the path of an executable in the isolated bin directory
+func (s *Server) BinPath(name string) string {
+	return filepath.Join(s.Data, "bin", name)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.URL.Path)
+	s.mu.Unlock()
+
+	switch r.URL.Path {
+	case registryPath:
+		body, err := s.registryZip()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		_, _ = w.Write(body)
+		return
+	case registryManifestPath:
+		// Lets zana verify the zip like one from the real registry
+		body, err := s.registryZip()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sum := sha256.Sum256(body)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"size": len(body), "sha256": hex.EncodeToString(sum[:])})
+		return
+	}
+
+	s.mu.Lock()
+	content, ok := s.assets[r.URL.Path]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	_, _ = w.Write(content)
+}
+
+// registryZip returns the registry as the zip zana downloads
+func (s *Server) registryZip() ([]byte, error) {
+	s.mu.Lock()
+	entries := make([]map[string]interface{}, 0, len(s.packages))
+	for _, pkg := range s.packages {
+		entries = append(entries, pkg.registryEntry(s.URL))
+	}
+	s.mu.Unlock()
+
+	registryJSON, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create("zana-registry.json")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(registryJSON); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func assetPath(id, version, name string) string {
+	return "/assets/" + strings.ReplaceAll(id, ":", "/") + "/" + version + "/" + name
+}
+
+// BuildZana builds the zana executable of the module the test runs in and
+// returns its path. The module needs to depend on zana, e.g. in a plugin's
+// repository via a tools.go or go.mod require.
+func BuildZana(t testing.TB) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "zana")
+	if os.PathSeparator == '\\' {
+		path += ".exe"
+	}
+	out, err := exec.Command("go", "build", "-o", path, "github.com/mistweaverco/zana-client").CombinedOutput()
+	if err != nil {
+		t.Fatalf("building zana: %v\n%s", err, out)
+	}
+	return path
+}